@@ -16,6 +16,7 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/freetsdb/freetsdb/models"
@@ -136,8 +137,15 @@ type Client struct {
 	httpClient *http.Client
 	userAgent  string
 	precision  string
+
+	mu             sync.Mutex
+	session        string
+	sessionExpires time.Time
 }
 
+// sessionRenewWindow is how long before it expires a session is renewed.
+const sessionRenewWindow = time.Minute
+
 const (
 	// ConsistencyOne requires at least one data node acknowledged a write.
 	ConsistencyOne = "one"
@@ -193,6 +201,93 @@ func NewClient(c Config) (*Client, error) {
 func (c *Client) SetAuth(u, p string) {
 	c.username = u
 	c.password = p
+
+	c.mu.Lock()
+	c.session = ""
+	c.mu.Unlock()
+}
+
+// Login starts a session with the username and password of the client. Later
+// requests are authenticated by the session token instead of the credentials,
+// and the session is renewed shortly before it expires. If Login fails the
+// credentials keep being sent with every request.
+func (c *Client) Login() error {
+	c.mu.Lock()
+	c.session = ""
+	c.mu.Unlock()
+
+	if c.username == "" {
+		return errors.New("username required to login")
+	}
+
+	u := c.url
+	u.Path = path.Join(u.Path, "login")
+
+	req, err := http.NewRequest("POST", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.SetBasicAuth(c.username, c.password)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("login failed: %s", bytes.TrimSpace(body))
+	}
+
+	var session struct {
+		Token   string    `json:"token"`
+		Expires time.Time `json:"expires"`
+	}
+	if err := json.Unmarshal(body, &session); err != nil {
+		return err
+	} else if session.Token == "" {
+		return errors.New("login failed: no session token returned")
+	}
+
+	c.mu.Lock()
+	c.session, c.sessionExpires = session.Token, session.Expires
+	c.mu.Unlock()
+	return nil
+}
+
+// setAuth sets the credentials of req, preferring the session token.
+func (c *Client) setAuth(req *http.Request) {
+	if c.username == "" {
+		return
+	}
+	if token := c.sessionToken(); token != "" {
+		req.Header.Set("Authorization", "Session "+token)
+		return
+	}
+	req.SetBasicAuth(c.username, c.password)
+}
+
+// sessionToken returns the session token, renewing the session if it is about
+// to expire, or an empty string if the client has no session.
+func (c *Client) sessionToken() string {
+	c.mu.Lock()
+	token, expires := c.session, c.sessionExpires
+	c.mu.Unlock()
+
+	if token == "" || time.Until(expires) > sessionRenewWindow {
+		return token
+	}
+	if err := c.Login(); err != nil {
+		return ""
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.session
 }
 
 // SetPrecision will update the precision
@@ -236,9 +331,7 @@ func (c *Client) QueryContext(ctx context.Context, q Query) (*Response, error) {
 		return nil, err
 	}
 	req.Header.Set("User-Agent", c.userAgent)
-	if c.username != "" {
-		req.SetBasicAuth(c.username, c.password)
-	}
+	c.setAuth(req)
 
 	req = req.WithContext(ctx)
 
@@ -328,9 +421,7 @@ func (c *Client) Write(bp BatchPoints) (*Response, error) {
 	}
 	req.Header.Set("Content-Type", "")
 	req.Header.Set("User-Agent", c.userAgent)
-	if c.username != "" {
-		req.SetBasicAuth(c.username, c.password)
-	}
+	c.setAuth(req)
 
 	precision := bp.Precision
 	if precision == "" {
@@ -380,9 +471,7 @@ func (c *Client) WriteLineProtocol(data, database, retentionPolicy, precision, w
 	}
 	req.Header.Set("Content-Type", "")
 	req.Header.Set("User-Agent", c.userAgent)
-	if c.username != "" {
-		req.SetBasicAuth(c.username, c.password)
-	}
+	c.setAuth(req)
 	params := req.URL.Query()
 	params.Set("db", database)
 	params.Set("rp", retentionPolicy)
@@ -424,9 +513,7 @@ func (c *Client) Ping() (time.Duration, string, error) {
 		return 0, "", err
 	}
 	req.Header.Set("User-Agent", c.userAgent)
-	if c.username != "" {
-		req.SetBasicAuth(c.username, c.password)
-	}
+	c.setAuth(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -337,6 +337,50 @@ func TestClient_BasicAuth(t *testing.T) {
 	}
 }
 
+func TestClient_Login(t *testing.T) {
+	var logins int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			if u, p, ok := r.BasicAuth(); !ok || u != "username" || p != "password" {
+				t.Errorf("unexpected login credentials: %q %q", u, p)
+			}
+			logins++
+
+			// The first session is about to expire so the client renews it.
+			expires := time.Now().Add(time.Hour)
+			if logins == 1 {
+				expires = time.Now().Add(time.Second)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"token":   fmt.Sprintf("token%d", logins),
+				"expires": expires,
+			})
+		case "/ping":
+			if have, want := r.Header.Get("Authorization"), "Session token2"; have != want {
+				t.Errorf("unexpected authorization: %q != %q", have, want)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	config := client.Config{URL: *u, Username: "username", Password: "password"}
+	c, err := client.NewClient(config)
+	if err != nil {
+		t.Fatalf("unexpected error.  expected %v, actual %v", nil, err)
+	}
+
+	if err := c.Login(); err != nil {
+		t.Fatalf("unexpected error.  expected %v, actual %v", nil, err)
+	} else if _, _, err := c.Ping(); err != nil {
+		t.Fatalf("unexpected error.  expected %v, actual %v", nil, err)
+	} else if logins != 2 {
+		t.Fatalf("unexpected logins: %d", logins)
+	}
+}
+
 func TestClient_Write(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		in, err := ioutil.ReadAll(r.Body)
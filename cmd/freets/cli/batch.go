@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"io/ioutil"
+	"strings"
+)
+
+// Exit codes returned by freets for each class of failure.
+const (
+	ExitCodeError      = 1 // unclassified failure
+	ExitCodeConnection = 2 // the server could not be reached or did not respond
+	ExitCodeQuery      = 3 // a query returned an error
+	ExitCodeWrite      = 4 // an INSERT was not written
+	ExitCodeFile       = 5 // the file to execute could not be read
+)
+
+// ExitError is an error along with the exit code freets exits with.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// Error returns the message of the underlying error.
+func (e *ExitError) Error() string { return e.Err.Error() }
+
+// ExitCode returns the exit code for err.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	} else if e, ok := err.(*ExitError); ok {
+		return e.Code
+	}
+	return ExitCodeError
+}
+
+// executeFile runs each statement in the file at path. Every statement is
+// run unless FailFast is set, and the error of the first statement that
+// failed is returned.
+func (c *CommandLine) executeFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return &ExitError{Code: ExitCodeFile, Err: err}
+	}
+
+	if c.Type == QueryLanguageFlux {
+		return c.ExecuteFluxQuery(string(data))
+	}
+
+	var first error
+	for _, stmt := range splitStatements(string(data)) {
+		if err := c.ParseCommand(stmt); err != nil && err != ErrBlankCommand {
+			if c.FailFast {
+				return err
+			} else if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// splitStatements splits s into statements. A statement continues on the
+// following lines the same way it does in the shell. Blank lines and lines
+// starting with -- are skipped.
+func splitStatements(s string) []string {
+	var stmts []string
+	var stmt string
+	for _, line := range strings.Split(s, "\n") {
+		if stmt == "" {
+			if l := strings.TrimSpace(line); l == "" || strings.HasPrefix(l, "--") {
+				continue
+			}
+			stmt = line
+		} else {
+			stmt = joinLines(stmt, line)
+		}
+
+		if statementComplete(stmt) {
+			stmts = append(stmts, stmt)
+			stmt = ""
+		}
+	}
+	if stmt != "" {
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	stmts := splitStatements(`-- create the database
+CREATE DATABASE db
+
+SELECT mean(value) FROM cpu \
+WHERE host = 'server
+01'
+INSERT cpu value=1
+SHOW DATABASES`)
+
+	exp := []string{
+		"CREATE DATABASE db",
+		"SELECT mean(value) FROM cpu WHERE host = 'server 01'",
+		"INSERT cpu value=1",
+		"SHOW DATABASES",
+	}
+	if !reflect.DeepEqual(stmts, exp) {
+		t.Fatalf("unexpected statements:\n%q\nexpected:\n%q", stmts, exp)
+	}
+}
@@ -49,7 +49,10 @@ type CommandLine struct {
 	ServerVersion   string
 	Pretty          bool   // controls pretty print for json
 	Format          string // controls the output format.  Valid values are json, csv, or column
+	ColumnWidth     int    // truncates values in the column format to this width, 0 does not truncate
 	Execute         string
+	ExecuteFile     string
+	FailFast        bool // stop executing ExecuteFile at the first statement that fails
 	ShowVersion     bool
 	Import          bool
 	Chunked         bool
@@ -60,6 +63,7 @@ type CommandLine struct {
 	ForceTTY        bool // Force the CLI to act as if it were connected to a TTY
 	osSignals       chan os.Signal
 	historyFilePath string
+	completer       *completer
 
 	Client         *client.Client
 	ClientConfig   client.Config // Client config options.
@@ -135,7 +139,10 @@ func (c *CommandLine) Run() error {
 			}
 			c.ClientConfig.UnsafeSsl = false
 		}
-		return fmt.Errorf("Failed to connect to %s: %s\n%s", c.Client.Addr(), err.Error(), msg)
+		return &ExitError{
+			Code: ExitCodeConnection,
+			Err:  fmt.Errorf("Failed to connect to %s: %s\n%s", c.Client.Addr(), err.Error(), msg),
+		}
 	}
 
 	// Modify precision.
@@ -158,6 +165,10 @@ func (c *CommandLine) Run() error {
 		return nil
 	}
 
+	if c.ExecuteFile != "" {
+		return c.executeFile(c.ExecuteFile)
+	}
+
 	if c.Import {
 		addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
 		u, e := client.ParseConnectionString(addr, c.Ssl)
@@ -242,6 +253,13 @@ func (c *CommandLine) Run() error {
 		}
 	}
 
+	// Complete keywords and the names returned by SHOW queries.
+	c.completer = newCompleter()
+	c.Line.SetWordCompleter(c.completer.complete)
+	if c.Database != "" {
+		c.loadCompletions()
+	}
+
 	// read from prompt until exit is run
 	return c.mainLoop()
 }
@@ -257,7 +275,7 @@ func (c *CommandLine) mainLoop() error {
 			c.exit()
 			return nil
 		default:
-			l, e := c.Line.Prompt("> ")
+			l, e := c.readStatement()
 			if e == io.EOF {
 				// Instead of die, register that someone exited the program gracefully
 				l = "exit"
@@ -311,6 +329,8 @@ func (c *CommandLine) ParseCommand(cmd string) error {
 			}
 		case "chunk":
 			c.SetChunkSize(cmd)
+		case "column":
+			c.SetColumnWidth(cmd)
 		case "pretty":
 			c.Pretty = !c.Pretty
 			if c.Pretty {
@@ -370,6 +390,12 @@ func (c *CommandLine) Connect(cmd string) error {
 	}
 	c.ServerVersion = v
 
+	// Start a session so the credentials aren't sent with every request.
+	// Servers without sessions keep receiving the credentials instead.
+	if ClientConfig.Username != "" {
+		c.Client.Login()
+	}
+
 	// Update the command with the current connection information
 	if host, port, err := net.SplitHostPort(ClientConfig.URL.Host); err == nil {
 		c.Host = host
@@ -412,6 +438,9 @@ func (c *CommandLine) SetAuth(cmd string) {
 
 	// Update the client as well
 	c.Client.SetAuth(c.ClientConfig.Username, c.ClientConfig.Password)
+	if c.ClientConfig.Username != "" {
+		c.Client.Login()
+	}
 }
 
 func (c *CommandLine) clear(cmd string) {
@@ -463,6 +492,9 @@ func (c *CommandLine) use(cmd string) {
 
 	c.Database = db
 	fmt.Printf("Using database %s\n", db)
+	if c.completer != nil {
+		c.loadCompletions()
+	}
 
 	if rp != "" {
 		if !c.retentionPolicyExists(db, rp) {
@@ -597,6 +629,30 @@ func (c *CommandLine) SetChunkSize(cmd string) {
 	}
 }
 
+// SetColumnWidth sets the width values are truncated to in the column format.
+// 0 does not truncate.
+func (c *CommandLine) SetColumnWidth(cmd string) {
+	// normalize cmd
+	cmd = strings.ToLower(cmd)
+	cmd = strings.Join(strings.Fields(cmd), " ")
+
+	// Remove the "column width" keyword if it exists
+	cmd = strings.TrimPrefix(cmd, "column width ")
+
+	// Remove the "column" keyword if it exists
+	cmd = strings.TrimPrefix(cmd, "column ")
+
+	if n, err := strconv.ParseInt(cmd, 10, 64); err == nil {
+		c.ColumnWidth = int(n)
+		if c.ColumnWidth <= 0 {
+			c.ColumnWidth = 0
+		}
+		fmt.Printf("column width set to %d\n", c.ColumnWidth)
+	} else {
+		fmt.Printf("unable to parse column width from %q\n", cmd)
+	}
+}
+
 // SetPrecision sets client precision.
 func (c *CommandLine) SetPrecision(cmd string) {
 	// normalize cmd
@@ -759,6 +815,7 @@ func (c *CommandLine) Insert(stmt string) error {
 			fmt.Println(`Please set a database with the command "use <database>" or`)
 			fmt.Println("INSERT INTO <database>.<retention-policy> <point>")
 		}
+		return &ExitError{Code: ExitCodeWrite, Err: err}
 	}
 	return nil
 }
@@ -782,7 +839,7 @@ func (c *CommandLine) ExecuteQuery(query string) error {
 		pq, err := influxql.NewParser(strings.NewReader(query)).ParseQuery()
 		if err != nil {
 			fmt.Printf("ERR: %s\n", err)
-			return err
+			return &ExitError{Code: ExitCodeQuery, Err: err}
 		}
 		for _, stmt := range pq.Statements {
 			if selectStatement, ok := stmt.(*influxql.SelectStatement); ok {
@@ -828,16 +885,19 @@ func (c *CommandLine) ExecuteQuery(query string) error {
 			}
 		}
 		fmt.Printf("ERR: %s\n", err)
-		return err
+		return &ExitError{Code: ExitCodeConnection, Err: err}
 	}
 	c.FormatResponse(response, os.Stdout)
+	if c.completer != nil {
+		c.completer.addResponse(response)
+	}
 	if err := response.Error(); err != nil {
 		fmt.Printf("ERR: %s\n", response.Error())
 		if c.Database == "" {
 			fmt.Println("Warning: It is possible this error is due to not setting a database.")
 			fmt.Println(`Please set a database with the command "use <database>".`)
 		}
-		return err
+		return &ExitError{Code: ExitCodeQuery, Err: err}
 	}
 	return nil
 }
@@ -971,6 +1031,11 @@ func (c *CommandLine) formatResults(result client.Result, separator string, supp
 		}
 
 		columnNames = append(columnNames, row.Columns...)
+		if c.Format == "column" {
+			for i := range columnNames {
+				columnNames[i] = truncate(columnNames[i], c.ColumnWidth)
+			}
+		}
 
 		// Output a line separator if we have more than one set or results and format is column
 		if i > 0 && c.Format == "column" && !suppressHeaders {
@@ -1014,7 +1079,11 @@ func (c *CommandLine) formatResults(result client.Result, separator string, supp
 			}
 
 			for _, vv := range v {
-				values = append(values, interfaceToString(vv))
+				s := interfaceToString(vv)
+				if c.Format == "column" {
+					s = truncate(s, c.ColumnWidth)
+				}
+				values = append(values, s)
 			}
 			rows = append(rows, strings.Join(values, separator))
 		}
@@ -1053,6 +1122,7 @@ func (c *CommandLine) Settings() {
 	fmt.Fprintf(w, "RetentionPolicy\t%s\n", c.RetentionPolicy)
 	fmt.Fprintf(w, "Pretty\t%v\n", c.Pretty)
 	fmt.Fprintf(w, "Format\t%s\n", c.Format)
+	fmt.Fprintf(w, "Column Width\t%d\n", c.ColumnWidth)
 	fmt.Fprintf(w, "Write Consistency\t%s\n", c.ClientConfig.WriteConsistency)
 	fmt.Fprintf(w, "Chunked\t%v\n", c.Chunked)
 	fmt.Fprintf(w, "Chunk Size\t%d\n", c.ChunkSize)
@@ -1069,13 +1139,17 @@ func (c *CommandLine) help() {
         chunk size <size>     sets the size of the chunked responses.  Set to 0 to reset to the default chunked size
         use <db_name>         sets current database
         format <format>       specifies the format of the server responses: json, csv, or column
+        column width <width>  truncates values in the column format to width characters.  Set to 0 to not truncate
         precision <format>    specifies the format of the timestamp: rfc3339, h, m, s, ms, u or ns
         consistency <level>   sets write consistency level: any, one, quorum, or all
-        history               displays command history
+        history               displays command history, ctrl+r searches it
         settings              outputs the current settings for the shell
         clear                 clears settings such as database or retention policy.  run 'clear' for help
         exit/quit/ctrl+d      quits the influx shell
 
+        A statement ending with \ or with an unterminated quote or parenthesis continues on the next line.
+        Tab completes keywords and the names returned by SHOW queries.
+
         show databases        show database names
         show series           show series information
         show measurements     show measurement information
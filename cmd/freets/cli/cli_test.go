@@ -5,10 +5,12 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"testing"
@@ -75,6 +77,39 @@ func TestRunCLI_ExecuteInsert_WithSignals(t *testing.T) {
 	}
 }
 
+func TestRunCLI_ExecuteFile(t *testing.T) {
+	t.Parallel()
+	ts := emptyTestServer()
+	defer ts.Close()
+
+	f, err := ioutil.TempFile("", "freets-execute-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	fmt.Fprintln(f, "-- write a point")
+	fmt.Fprintln(f, "INSERT sensor,floor=1 value=2")
+	fmt.Fprintln(f, "SHOW DIAGNOSTICS")
+	f.Close()
+
+	u, _ := url.Parse(ts.URL)
+	h, p, _ := net.SplitHostPort(u.Host)
+	c := cli.New(CLIENT_VERSION)
+	c.Host = h
+	c.Port, _ = strconv.Atoi(p)
+	c.ExecuteFile = f.Name()
+	c.IgnoreSignals = true
+	c.ForceTTY = true
+	if err := c.Run(); err != nil {
+		t.Fatalf("Run failed with error: %s", err)
+	}
+
+	c.ExecuteFile = f.Name() + ".missing"
+	if err := c.Run(); cli.ExitCode(err) != cli.ExitCodeFile {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestSetAuth(t *testing.T) {
 	t.Parallel()
 	c := cli.New(CLIENT_VERSION)
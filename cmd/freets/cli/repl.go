@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/freetsdb/freetsdb/client"
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+// statementComplete returns true if s is a complete statement. A statement
+// continues on the next line if it ends with a backslash, or if it has an
+// unterminated quote or an unbalanced parenthesis.
+func statementComplete(s string) bool {
+	s = strings.TrimRightFunc(s, unicode.IsSpace)
+	if strings.HasSuffix(s, `\`) {
+		return false
+	}
+
+	// Line protocol does not quote the way InfluxQL does, so only a trailing
+	// backslash continues an insert.
+	if fields := strings.Fields(s); len(fields) > 0 && strings.EqualFold(fields[0], "insert") {
+		return true
+	}
+
+	var quote rune
+	var depth int
+	var escaped bool
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		}
+	}
+	return quote == 0 && depth <= 0
+}
+
+// readStatement prompts for a statement, reading continuation lines until
+// the statement is complete. The lines are joined with a space.
+func (c *CommandLine) readStatement() (string, error) {
+	l, err := c.Line.Prompt("> ")
+	for err == nil && !statementComplete(l) {
+		var next string
+		if next, err = c.Line.Prompt("... "); err == nil {
+			l = joinLines(l, next)
+		}
+	}
+	return l, err
+}
+
+// joinLines joins the continuation line next to the statement s.
+func joinLines(s, next string) string {
+	s = strings.TrimSuffix(strings.TrimRightFunc(s, unicode.IsSpace), `\`)
+	return strings.TrimRightFunc(s, unicode.IsSpace) + " " + next
+}
+
+// completionKeywords are the InfluxQL keywords offered for completion.
+var completionKeywords = []string{
+	"ALL", "ALTER", "AND", "AS", "ASC", "BY", "CARDINALITY", "CASE", "CONTINUOUS",
+	"CREATE", "DATABASE", "DATABASES", "DEFAULT", "DELETE", "DESC", "DROP",
+	"DURATION", "ELSE", "END", "EXECUTE", "EXPLAIN", "FIELD", "FILL", "FROM", "GRANT", "GRANTS",
+	"GROUP", "INTO", "KEY", "KEYS", "LIMIT", "MATERIALIZED", "MEASUREMENT", "MEASUREMENTS", "OFFSET",
+	"ON", "OR", "ORDER", "POLICIES", "POLICY", "PRIVILEGES", "QUERIES", "QUERY",
+	"REPLICATION", "RETENTION", "REVOKE", "SELECT", "SERIES", "SHARD", "SHARDS",
+	"SHOW", "SLIMIT", "SOFFSET", "STATS", "SUBSCRIPTIONS", "TAG", "TEMPLATE", "TEMPLATES",
+	"THEN", "TO", "USER", "USERS", "VALUES", "VIEW", "VIEWS", "WHEN", "WHERE", "WITH", "WRITE",
+}
+
+// completionCommands are the shell commands offered for completion at the
+// start of a line.
+var completionCommands = []string{
+	"auth", "chunk", "chunked", "clear", "column", "connect", "consistency", "exit",
+	"format", "help", "history", "insert", "node", "precision", "pretty", "quit",
+	"settings", "use",
+}
+
+// completer completes keywords, shell commands and the database,
+// measurement, tag and field names returned by SHOW queries.
+type completer struct {
+	names map[string]struct{}
+}
+
+func newCompleter() *completer {
+	return &completer{names: make(map[string]struct{})}
+}
+
+// addResponse records the names returned by the SHOW queries in response.
+func (cp *completer) addResponse(response *client.Response) {
+	for _, result := range response.Results {
+		for _, row := range result.Series {
+			if len(row.Columns) == 0 {
+				continue
+			}
+			switch row.Columns[0] {
+			case "name", "tagKey", "fieldKey":
+			default:
+				continue
+			}
+			for _, v := range row.Values {
+				if len(v) == 0 {
+					continue
+				}
+				if name, ok := v[0].(string); ok && name != "" {
+					cp.names[name] = struct{}{}
+				}
+			}
+		}
+	}
+}
+
+// complete completes the word before pos in line. It implements
+// liner.WordCompleter.
+func (cp *completer) complete(line string, pos int) (head string, completions []string, tail string) {
+	head, tail = line[:pos], line[pos:]
+	i := strings.LastIndexAny(head, " \t,()=") + 1
+	head, word := head[:i], head[i:]
+	if word == "" {
+		return head, nil, tail
+	}
+
+	// Keywords are completed in the case the word was typed in.
+	lower := unicode.IsLower(firstRune(word))
+	for _, kw := range completionKeywords {
+		if strings.HasPrefix(kw, strings.ToUpper(word)) {
+			if lower {
+				kw = strings.ToLower(kw)
+			}
+			completions = append(completions, kw)
+		}
+	}
+	if strings.TrimSpace(head) == "" {
+		for _, cmd := range completionCommands {
+			if strings.HasPrefix(cmd, strings.ToLower(word)) {
+				completions = append(completions, cmd)
+			}
+		}
+	}
+
+	prefix := strings.TrimPrefix(word, `"`)
+	for name := range cp.names {
+		if strings.HasPrefix(name, prefix) {
+			completions = append(completions, influxql.QuoteIdent(name))
+		}
+	}
+	sort.Strings(completions)
+	return head, completions, tail
+}
+
+// loadCompletions records the measurement, tag and field names of the
+// current database for completion.
+func (c *CommandLine) loadCompletions() {
+	for _, q := range []string{
+		"SHOW MEASUREMENTS LIMIT 1000",
+		"SHOW TAG KEYS SLIMIT 1000",
+		"SHOW FIELD KEYS SLIMIT 1000",
+	} {
+		response, err := c.Client.Query(client.Query{Command: q, Database: c.Database})
+		if err != nil || response.Error() != nil {
+			continue
+		}
+		c.completer.addResponse(response)
+	}
+}
+
+// truncate shortens s to width characters, marking it with an ellipsis.
+// A width of 0 does not truncate.
+func truncate(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	if width <= 3 {
+		return string(r[:width])
+	}
+	return string(r[:width-3]) + "..."
+}
+
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/client"
+	"github.com/freetsdb/freetsdb/models"
+)
+
+func TestStatementComplete(t *testing.T) {
+	tests := []struct {
+		stmt     string
+		complete bool
+	}{
+		{stmt: `SELECT * FROM cpu`, complete: true},
+		{stmt: `SELECT * FROM cpu \`, complete: false},
+		{stmt: `SELECT * FROM cpu WHERE host = 'server`, complete: false},
+		{stmt: `SELECT * FROM "cpu`, complete: false},
+		{stmt: `SELECT * FROM cpu WHERE host = 'it\'s'`, complete: true},
+		{stmt: `SELECT mean(value) FROM (SELECT value FROM cpu`, complete: false},
+		{stmt: `SELECT mean(value) FROM (SELECT value FROM cpu)`, complete: true},
+		{stmt: `INSERT cpu,host=o'neil value=1`, complete: true},
+		{stmt: `INSERT cpu value=1 \`, complete: false},
+	}
+
+	for _, test := range tests {
+		if got := statementComplete(test.stmt); got != test.complete {
+			t.Errorf("statementComplete(%q) = %v, expected %v", test.stmt, got, test.complete)
+		}
+	}
+}
+
+func TestCompleter_Complete(t *testing.T) {
+	cp := newCompleter()
+	cp.addResponse(&client.Response{Results: []client.Result{{
+		Series: []models.Row{
+			{Name: "measurements", Columns: []string{"name"}, Values: [][]interface{}{{"cpu"}, {"cpu load"}}},
+			{Name: "cpu", Columns: []string{"tagKey"}, Values: [][]interface{}{{"host"}}},
+		},
+	}}})
+
+	tests := []struct {
+		line        string
+		head        string
+		completions []string
+	}{
+		{line: "sel", completions: []string{"select"}},
+		{line: "SHOW MEAS", head: "SHOW ", completions: []string{"MEASUREMENT", "MEASUREMENTS"}},
+		{line: "SELECT * FROM cp", head: "SELECT * FROM ", completions: []string{`"cpu load"`, "cpu"}},
+		{line: "SELECT * FROM cpu WHERE ho", head: "SELECT * FROM cpu WHERE ", completions: []string{"host"}},
+		{line: "us", completions: []string{"use", "user", "users"}},
+	}
+
+	for _, test := range tests {
+		head, completions, tail := cp.complete(test.line, len(test.line))
+		if head != test.head || tail != "" {
+			t.Errorf("%q: unexpected head and tail: %q %q", test.line, head, tail)
+		}
+		if !reflect.DeepEqual(completions, test.completions) {
+			t.Errorf("%q: unexpected completions: %q, expected %q", test.line, completions, test.completions)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		exp   string
+	}{
+		{s: "server01", width: 0, exp: "server01"},
+		{s: "server01", width: 8, exp: "server01"},
+		{s: "server01", width: 7, exp: "serv..."},
+		{s: "server01", width: 2, exp: "se"},
+	}
+
+	for _, test := range tests {
+		if got := truncate(test.s, test.width); got != test.exp {
+			t.Errorf("truncate(%q, %d) = %q, expected %q", test.s, test.width, got, test.exp)
+		}
+	}
+}
@@ -52,13 +52,18 @@ func main() {
 	fs.StringVar(&c.ClientConfig.Precision, "precision", defaultPrecision, "Precision specifies the format of the timestamp:  rfc3339,h,m,s,ms,u or ns.")
 	fs.StringVar(&c.ClientConfig.WriteConsistency, "consistency", "all", "Set write consistency level: any, one, quorum, or all.")
 	fs.BoolVar(&c.Pretty, "pretty", false, "Turns on pretty print for the json format.")
+	fs.IntVar(&c.ColumnWidth, "column-width", 0, "Truncates values in the column format to this width.  By default values are not truncated.")
 	fs.IntVar(&c.NodeID, "node", 0, "Specify the node that data should be retrieved from (enterprise only).")
 	fs.StringVar(&c.Execute, "execute", c.Execute, "Execute command and quit.")
+	fs.StringVar(&c.ExecuteFile, "execute-file", c.ExecuteFile, "Execute the statements in a file and quit.")
+	fs.BoolVar(&c.FailFast, "fail-fast", false, "Stop executing the file at the first statement that fails.")
 	fs.BoolVar(&c.ShowVersion, "version", false, "Displays the FreeTSDB version.")
 	fs.BoolVar(&c.Import, "import", false, "Import a previous database.")
 	fs.IntVar(&c.ImporterConfig.PPS, "pps", defaultPPS, "How many points per second the import will allow.  By default it is zero and will not throttle importing.")
 	fs.StringVar(&c.ImporterConfig.Path, "path", "", "path to the file to import")
 	fs.BoolVar(&c.ImporterConfig.Compressed, "compressed", false, "set to true if the import file is compressed")
+	fs.IntVar(&c.ImporterConfig.Workers, "workers", 1, "How many batches the import writes concurrently.")
+	fs.StringVar(&c.ImporterConfig.Checkpoint, "checkpoint", "", "File the import checkpoints its progress to, so an interrupted import resumes where it stopped.")
 
 	// Define our own custom usage to print
 	fs.Usage = func() {
@@ -83,6 +88,12 @@ func main() {
         Set this when connecting to the cluster using https and not use SSL verification.
   -execute 'command'
        Execute command and quit.
+  -execute-file 'path'
+       Execute the statements in a file and quit.  Statements continue on the
+       next line as they do in the shell, and lines starting with -- are skipped.
+  -fail-fast
+       Stop executing the file at the first statement that fails.  By default
+       every statement is executed.
   -type 'influxql|flux'
        Type specifies the query language for executing commands or when invoking the REPL.
   -format 'json|csv|column'
@@ -93,6 +104,8 @@ func main() {
        Set write consistency level: any, one, quorum, or all
   -pretty
        Turns on pretty print for the json format.
+  -column-width 'width'
+       Truncates values in the column format to this width.  By default values are not truncated.
   -import
        Import a previous database export from file
   -pps
@@ -101,6 +114,11 @@ func main() {
        Path to file to import
   -compressed
        Set to true if the import file is compressed
+  -workers
+       How many batches the import writes concurrently.  By default it is one.
+  -checkpoint 'path'
+       File the import checkpoints its progress to.  An import run again with the
+       same checkpoint file resumes where the previous one stopped.
 
 Examples:
 
@@ -108,7 +126,19 @@ Examples:
     $ freets -database 'metrics' -execute 'select * from cpu' -format 'json' -pretty
 
     # Connect to a specific database on startup and set database context:
-    $ freets -database 'metrics' -host 'localhost' -port '8086'`)
+    $ freets -database 'metrics' -host 'localhost' -port '8086'
+
+    # Run the queries in a file, stopping at the first failure:
+    $ freets -database 'metrics' -execute-file 'queries.iql' -format 'csv' -fail-fast
+
+Exit codes:
+
+    0  every statement succeeded
+    1  unclassified failure
+    2  the server could not be reached or did not respond
+    3  a query returned an error
+    4  an INSERT was not written
+    5  the file to execute could not be read`)
 	}
 	fs.Parse(os.Args[1:])
 
@@ -126,6 +156,6 @@ Examples:
 
 	if err := c.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err)
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 }
@@ -38,6 +38,7 @@ The commands are:
     buildtsi             generates tsi1 indexes from tsm1 data
     help                 display this help message
     report               displays a shard level report
+    reportkeys           reports series keys and tag values over the length limits
     verify               verifies integrity of TSM files
     verify-seriesfile    verifies integrity of the Series file
 
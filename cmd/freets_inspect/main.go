@@ -16,6 +16,7 @@ import (
 	"github.com/freetsdb/freetsdb/cmd/freets_inspect/export"
 	"github.com/freetsdb/freetsdb/cmd/freets_inspect/help"
 	"github.com/freetsdb/freetsdb/cmd/freets_inspect/report"
+	"github.com/freetsdb/freetsdb/cmd/freets_inspect/reportkeys"
 	"github.com/freetsdb/freetsdb/cmd/freets_inspect/reporttsi"
 	"github.com/freetsdb/freetsdb/cmd/freets_inspect/verify/seriesfile"
 	"github.com/freetsdb/freetsdb/cmd/freets_inspect/verify/tsm"
@@ -97,6 +98,11 @@ func (m *Main) Run(args ...string) error {
 		if err := name.Run(args...); err != nil {
 			return fmt.Errorf("report: %s", err)
 		}
+	case "reportkeys":
+		name := reportkeys.NewCommand()
+		if err := name.Run(args...); err != nil {
+			return fmt.Errorf("reportkeys: %s", err)
+		}
 	case "reporttsi":
 		name := reporttsi.NewCommand()
 		if err := name.Run(args...); err != nil {
@@ -0,0 +1,244 @@
+// Package reportkeys reports the series keys and tag values of the series files
+// which are over the length limits.
+package reportkeys
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/tsdb"
+)
+
+// Command represents the program execution for "freets_inspect reportkeys".
+type Command struct {
+	// Standard input/output, overridden for testing.
+	Stderr io.Writer
+	Stdout io.Writer
+
+	dir        string
+	db         string
+	seriesFile string
+
+	maxKeyLength      int
+	maxTagValueLength int
+}
+
+// NewCommand returns a new instance of Command.
+func NewCommand() *Command {
+	return &Command{
+		Stderr: os.Stderr,
+		Stdout: os.Stdout,
+	}
+}
+
+// Run executes the command.
+func (cmd *Command) Run(args ...string) error {
+	fs := flag.NewFlagSet("reportkeys", flag.ExitOnError)
+	fs.StringVar(&cmd.dir, "dir", filepath.Join(os.Getenv("HOME"), ".freetsdb", "data"),
+		"Data directory.")
+	fs.StringVar(&cmd.db, "db", "",
+		"Only use this database inside of the data directory.")
+	fs.StringVar(&cmd.seriesFile, "series-file", "",
+		"Path to a series file. This overrides -db and -dir.")
+	fs.IntVar(&cmd.maxKeyLength, "max-series-key-length", models.MaxKeyLength,
+		"Maximum length of a series key.")
+	fs.IntVar(&cmd.maxTagValueLength, "max-tag-value-length", 0,
+		"Maximum length of a tag value. 0 disables the limit.")
+
+	fs.SetOutput(cmd.Stdout)
+	fs.Usage = cmd.printUsage
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(cmd.Stdout, 8, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "Database\tSeries ID\tProblem")
+
+	var n int
+	report := func(db, path string) error {
+		m, err := cmd.reportSeriesFile(tw, db, path)
+		n += m
+		return err
+	}
+
+	if cmd.seriesFile != "" {
+		if err := report(filepath.Base(filepath.Dir(cmd.seriesFile)), cmd.seriesFile); err != nil {
+			return err
+		}
+	} else if cmd.db != "" {
+		if err := report(cmd.db, filepath.Join(cmd.dir, cmd.db, tsdb.SeriesFileDirectory)); err != nil {
+			return err
+		}
+	} else {
+		dbs, err := ioutil.ReadDir(cmd.dir)
+		if err != nil {
+			return err
+		}
+		for _, db := range dbs {
+			if !db.IsDir() {
+				continue
+			}
+			if err := report(db.Name(), filepath.Join(cmd.dir, db.Name(), tsdb.SeriesFileDirectory)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.Stdout, "\n%d series keys over the limits or corrupted.\n", n)
+	return nil
+}
+
+// reportSeriesFile reports the live series of a series file whose key is over
+// the limits or cannot be parsed, and returns their number.
+func (cmd *Command) reportSeriesFile(w io.Writer, db, path string) (int, error) {
+	partitions, err := ioutil.ReadDir(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, partition := range partitions {
+		if !partition.IsDir() {
+			continue
+		}
+		keys, err := readPartitionKeys(filepath.Join(path, partition.Name()))
+		if err != nil {
+			return n, err
+		}
+
+		ids := make([]uint64, 0, len(keys))
+		for id := range keys {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		for _, id := range ids {
+			if problem := cmd.checkSeriesKey(keys[id]); problem != "" {
+				fmt.Fprintf(w, "%s\t%d\t%s\n", db, id, problem)
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+// checkSeriesKey returns the problem of a series key from a series file, or
+// an empty string if it is within the limits.
+func (cmd *Command) checkSeriesKey(key []byte) string {
+	name, tags, ok := parseSeriesKey(key)
+	if !ok {
+		return fmt.Sprintf("corrupted series key: %x", truncate(key))
+	}
+	return errString(tsdb.ValidateSeriesKeyLength(models.MakeKey(name, tags), name, tags, cmd.maxKeyLength, cmd.maxTagValueLength))
+}
+
+// readPartitionKeys returns the keys of the live series of a partition of a
+// series file by id.
+func readPartitionKeys(path string) (map[uint64][]byte, error) {
+	segments, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[uint64][]byte)
+	for _, info := range segments {
+		id, err := tsdb.ParseSeriesSegmentFilename(info.Name())
+		if err != nil {
+			continue
+		}
+
+		segment := tsdb.NewSeriesSegment(id, filepath.Join(path, info.Name()))
+		if err := segment.Open(); err != nil {
+			return nil, err
+		}
+		err = segment.ForEachEntry(func(flag uint8, id uint64, _ int64, key []byte) error {
+			switch flag {
+			case tsdb.SeriesEntryInsertFlag:
+				keys[id] = append([]byte(nil), key...)
+			case tsdb.SeriesEntryTombstoneFlag:
+				delete(keys, id)
+			}
+			return nil
+		})
+		if err := segment.Close(); err != nil {
+			return nil, err
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// parseSeriesKey parses a series key from a series file, and returns false if
+// it cannot be parsed or does not encode its name and tags back, like the keys
+// whose lengths overflowed their encoding.
+func parseSeriesKey(key []byte) (name []byte, tags models.Tags, ok bool) {
+	defer func() {
+		if recover() != nil {
+			name, tags, ok = nil, nil, false
+		}
+	}()
+
+	name, tags = tsdb.ParseSeriesKey(key)
+	if !bytes.Equal(tsdb.AppendSeriesKey(nil, name, tags), key) {
+		return nil, nil, false
+	}
+	return name, tags, true
+}
+
+// truncate cuts a corrupted series key to a length fit for the report.
+func truncate(key []byte) []byte {
+	const maxLength = 64
+	if len(key) > maxLength {
+		return key[:maxLength]
+	}
+	return key
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func (cmd *Command) printUsage() {
+	usage := `Reports the series keys and tag values over the length limits.
+
+Series keys longer than the limits of the series file are reported as
+corrupted when their lengths do not encode back to the stored key.
+
+Usage: freets_inspect reportkeys [flags]
+
+    -dir <path>
+            Root data path.
+            Defaults to "%[1]s/.freetsdb/data".
+    -db <name>
+            Only report this database inside of the data directory.
+    -series-file <path>
+            Path to a series file. This overrides -db and -dir.
+    -max-series-key-length <n>
+            Maximum length of a series key.
+            Defaults to "%[2]d".
+    -max-tag-value-length <n>
+            Maximum length of a tag value.
+            Defaults to "0", which disables the limit.
+`
+
+	fmt.Fprintf(cmd.Stdout, usage, os.Getenv("HOME"), models.MaxKeyLength)
+}
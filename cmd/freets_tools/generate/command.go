@@ -0,0 +1,166 @@
+package generate
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/freetsdb/freetsdb/cmd/freets_tools/importer"
+	"github.com/freetsdb/freetsdb/cmd/freets_tools/internal/format/binary"
+	"github.com/freetsdb/freetsdb/cmd/freets_tools/server"
+	"go.uber.org/zap"
+)
+
+// Command represents the program execution for "freets_tools gen".
+type Command struct {
+	// Standard input/output, overridden for testing.
+	Stderr io.Writer
+	Stdout io.Writer
+	Logger *zap.Logger
+	server server.Interface
+
+	gen           generator
+	tags          string
+	fields        string
+	start         string
+	seed          int64
+	format        string
+	configPath    string
+	database      string
+	rp            string
+	shardDuration time.Duration
+	buildTSI      bool
+}
+
+// NewCommand returns a new instance of the gen Command.
+func NewCommand(server server.Interface) *Command {
+	return &Command{
+		Stderr: os.Stderr,
+		Stdout: os.Stdout,
+		server: server,
+	}
+}
+
+// Run executes the gen command using the specified args.
+func (cmd *Command) Run(args []string) error {
+	if err := cmd.parseFlags(args); err != nil {
+		return err
+	}
+
+	// Without a config the workload is written to stdout.
+	if cmd.configPath == "" {
+		if cmd.format == "binary" {
+			return cmd.gen.writeBuckets(binary.NewWriter(cmd.Stdout, cmd.database, cmd.rp, cmd.shardDuration), cmd.shardDuration)
+		}
+		return cmd.gen.writeLines(cmd.Stdout)
+	}
+
+	// Otherwise it is written into the data directory as TSM by streaming
+	// it through the importer.
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(cmd.gen.writeBuckets(binary.NewWriter(pw, cmd.database, cmd.rp, cmd.shardDuration), cmd.shardDuration))
+	}()
+
+	c := importer.NewCommand(cmd.server)
+	c.Stdin = pr
+	c.Stderr = cmd.Stderr
+	c.Logger = cmd.Logger
+	importArgs := []string{
+		"-config", cmd.configPath,
+		"-database", cmd.database,
+		"-rp", cmd.rp,
+		"-shard-duration", cmd.shardDuration.String(),
+	}
+	if cmd.buildTSI {
+		importArgs = append(importArgs, "-build-tsi")
+	}
+	err := c.Run(importArgs)
+	pr.CloseWithError(io.ErrClosedPipe)
+	return err
+}
+
+func (cmd *Command) parseFlags(args []string) error {
+	fs := flag.NewFlagSet("gen", flag.ContinueOnError)
+	fs.IntVar(&cmd.gen.measurements, "measurements", 1, "Number of measurements")
+	fs.IntVar(&cmd.gen.seriesN, "series", 1000, "Number of series per measurement")
+	fs.StringVar(&cmd.tags, "tags", "host=1000", "Tag keys and their cardinality, such as host=100,region=10")
+	fs.StringVar(&cmd.gen.distribution, "distribution", distributionUniform, "Distribution of tag values across series (uniform, zipf)")
+	fs.StringVar(&cmd.fields, "fields", "value=float", "Field keys and their type (float, integer, unsigned, boolean, string)")
+	fs.StringVar(&cmd.start, "start", "", "Time of the first point, as RFC3339 (default: points before now)")
+	fs.DurationVar(&cmd.gen.interval, "interval", 10*time.Second, "Interval between the points of a series")
+	fs.IntVar(&cmd.gen.points, "points", 100, "Number of points per series")
+	fs.IntVar(&cmd.gen.pps, "pps", 0, "Points written per second to stdout (default: unlimited)")
+	fs.Float64Var(&cmd.gen.outOfOrder, "out-of-order", 0, "Fraction of the points written out of order to stdout")
+	fs.Int64Var(&cmd.seed, "seed", 0, "Seed of the random values (default: the current time)")
+	fs.StringVar(&cmd.format, "format", "line", "Output format to stdout (line, binary)")
+	fs.StringVar(&cmd.configPath, "config", "", "Config file of the data directory to write TSM to, instead of stdout")
+	fs.StringVar(&cmd.database, "database", "", "Database name")
+	fs.StringVar(&cmd.rp, "rp", "", "Retention policy name")
+	fs.DurationVar(&cmd.shardDuration, "shard-duration", time.Hour*24*7, "Target shard duration")
+	fs.BoolVar(&cmd.buildTSI, "build-tsi", false, "Build the on disk TSI when writing to a data directory")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var err error
+	if cmd.gen.tags, err = parseTags(cmd.tags); err != nil {
+		return err
+	}
+	if cmd.gen.fields, err = parseFields(cmd.fields); err != nil {
+		return err
+	}
+
+	switch cmd.gen.distribution {
+	case distributionUniform, distributionZipf:
+	default:
+		return fmt.Errorf("invalid distribution '%s'", cmd.gen.distribution)
+	}
+
+	switch cmd.format {
+	case "line", "binary":
+	default:
+		return fmt.Errorf("invalid format '%s'", cmd.format)
+	}
+
+	switch {
+	case cmd.gen.measurements < 1:
+		return errors.New("measurements must be at least 1")
+	case cmd.gen.seriesN < 1:
+		return errors.New("series must be at least 1")
+	case cmd.gen.points < 1:
+		return errors.New("points must be at least 1")
+	case cmd.gen.interval <= 0:
+		return errors.New("interval must be positive")
+	case cmd.gen.outOfOrder < 0 || cmd.gen.outOfOrder > 1:
+		return errors.New("out-of-order must be between 0 and 1")
+	case cmd.shardDuration <= 0:
+		return errors.New("shard-duration must be positive")
+	}
+
+	if cmd.format == "binary" || cmd.configPath != "" {
+		if cmd.database == "" {
+			return errors.New("database is required")
+		} else if cmd.rp == "" {
+			return errors.New("retention policy is required")
+		}
+	}
+
+	if cmd.start == "" {
+		cmd.gen.start = time.Now().Add(-time.Duration(cmd.gen.points) * cmd.gen.interval).Truncate(cmd.gen.interval)
+	} else if cmd.gen.start, err = time.Parse(time.RFC3339, cmd.start); err != nil {
+		return fmt.Errorf("invalid start: %s", err)
+	}
+
+	if cmd.seed == 0 {
+		cmd.seed = time.Now().UnixNano()
+	}
+	cmd.gen.rand = rand.New(rand.NewSource(cmd.seed))
+
+	return nil
+}
@@ -0,0 +1,397 @@
+package generate
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/freetsdb/freetsdb/cmd/freets_tools/internal/format"
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/pkg/escape"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/tsdb"
+)
+
+// The distributions of the values of each tag across the series.
+const (
+	distributionUniform = "uniform"
+	distributionZipf    = "zipf"
+)
+
+// maxDelay is the most intervals an out-of-order point is written late by.
+const maxDelay = 10
+
+// tagSpec is a tag key and the number of values it has.
+type tagSpec struct {
+	key         string
+	cardinality int
+}
+
+// fieldSpec is a field key and its type.
+type fieldSpec struct {
+	key string
+	typ influxql.DataType
+}
+
+// parseTags parses a list of tag keys and their cardinality, such as
+// "host=100,region=5".
+func parseTags(s string) ([]tagSpec, error) {
+	var tags []tagSpec
+	for _, kv := range strings.Split(s, ",") {
+		if kv == "" {
+			continue
+		}
+		i := strings.IndexByte(kv, '=')
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid tag %q: expected key=cardinality", kv)
+		}
+		n, err := strconv.Atoi(kv[i+1:])
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid cardinality for tag %q", kv[:i])
+		}
+		tags = append(tags, tagSpec{key: kv[:i], cardinality: n})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].key < tags[j].key })
+	return tags, nil
+}
+
+// parseFields parses a list of field keys and their types, such as
+// "value=float,count=integer".
+func parseFields(s string) ([]fieldSpec, error) {
+	var fields []fieldSpec
+	for _, kv := range strings.Split(s, ",") {
+		if kv == "" {
+			continue
+		}
+		i := strings.IndexByte(kv, '=')
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid field %q: expected key=type", kv)
+		}
+		var typ influxql.DataType
+		switch kv[i+1:] {
+		case "float":
+			typ = influxql.Float
+		case "integer":
+			typ = influxql.Integer
+		case "unsigned":
+			typ = influxql.Unsigned
+		case "boolean":
+			typ = influxql.Boolean
+		case "string":
+			typ = influxql.String
+		default:
+			return nil, fmt.Errorf("invalid type for field %q: %q", kv[:i], kv[i+1:])
+		}
+		fields = append(fields, fieldSpec{key: kv[:i], typ: typ})
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("at least one field is required")
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].key < fields[j].key })
+	return fields, nil
+}
+
+// series is a generated series.
+type series struct {
+	name []byte
+	tags models.Tags
+	key  []byte
+}
+
+// generator generates the points of a synthetic workload.
+type generator struct {
+	measurements int
+	seriesN      int // series per measurement
+	tags         []tagSpec
+	distribution string
+	fields       []fieldSpec
+	start        time.Time
+	interval     time.Duration
+	points       int // points per series
+	pps          int
+	outOfOrder   float64
+	rand         *rand.Rand
+}
+
+// series returns the series of the workload, sorted by key. Tag values are
+// assigned to the series evenly, or following a Zipf distribution so a few
+// values are shared by most series.
+func (g *generator) series() ([]series, error) {
+	max := 1
+	for _, t := range g.tags {
+		if max *= t.cardinality; max >= g.seriesN {
+			max = g.seriesN
+			break
+		}
+	}
+	if max < g.seriesN {
+		return nil, fmt.Errorf("the tags have %d combinations, fewer than the %d series requested", max, g.seriesN)
+	}
+
+	var zipfs []*rand.Zipf
+	if g.distribution == distributionZipf {
+		for _, t := range g.tags {
+			zipfs = append(zipfs, rand.NewZipf(g.rand, 1.1, 1, uint64(t.cardinality-1)))
+		}
+	}
+
+	all := make([]series, 0, g.measurements*g.seriesN)
+	for m := 0; m < g.measurements; m++ {
+		name := []byte(fmt.Sprintf("m%d", m))
+		seen := make(map[string]struct{}, g.seriesN)
+		for i, attempts := 0, 0; len(seen) < g.seriesN; i++ {
+			if attempts++; attempts > 100*g.seriesN {
+				return nil, fmt.Errorf("unable to generate %d distinct series with a %s distribution; raise the tag cardinalities", g.seriesN, g.distribution)
+			}
+
+			tags := make(models.Tags, len(g.tags))
+			v := i
+			for j, t := range g.tags {
+				var n int
+				if zipfs != nil {
+					n = int(zipfs[j].Uint64())
+				} else {
+					n, v = v%t.cardinality, v/t.cardinality
+				}
+				tags[j] = models.NewTag([]byte(t.key), []byte(t.key+strconv.Itoa(n)))
+			}
+
+			key := models.MakeKey(name, tags)
+			if _, ok := seen[string(key)]; ok {
+				continue
+			}
+			seen[string(key)] = struct{}{}
+			all = append(all, series{name: name, tags: tags, key: key})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return bytes.Compare(all[i].key, all[j].key) < 0 })
+	return all, nil
+}
+
+// writeLines writes the workload to w as line protocol. Every series gets a
+// point at each interval, and the outOfOrder fraction of the points are
+// written up to maxDelay intervals late.
+func (g *generator) writeLines(w io.Writer) error {
+	all, err := g.series()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	begin := time.Now()
+	var written int
+	late := make(map[int][][]byte)
+	var line []byte
+	for k := 0; k < g.points+maxDelay; k++ {
+		for _, l := range late[k] {
+			bw.Write(l)
+		}
+		written += len(late[k])
+		delete(late, k)
+		if k >= g.points {
+			continue
+		}
+
+		t := g.start.Add(time.Duration(k) * g.interval).UnixNano()
+		for _, s := range all {
+			line = g.appendLine(line[:0], s, t)
+			if g.outOfOrder > 0 && g.rand.Float64() < g.outOfOrder {
+				d := k + 1 + g.rand.Intn(maxDelay)
+				late[d] = append(late[d], append([]byte(nil), line...))
+				continue
+			}
+			if _, err := bw.Write(line); err != nil {
+				return err
+			}
+			written++
+
+			// Throttle to the points per second.
+			if g.pps > 0 && written%100 == 0 {
+				if d := time.Duration(written)*time.Second/time.Duration(g.pps) - time.Since(begin); d > 0 {
+					bw.Flush()
+					time.Sleep(d)
+				}
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// appendLine appends the line protocol of the point of s at time t.
+func (g *generator) appendLine(b []byte, s series, t int64) []byte {
+	b = append(b, s.key...)
+	for i, f := range g.fields {
+		if i == 0 {
+			b = append(b, ' ')
+		} else {
+			b = append(b, ',')
+		}
+		b = append(b, escape.String(f.key)...)
+		b = append(b, '=')
+		switch f.typ {
+		case influxql.Float:
+			b = strconv.AppendFloat(b, g.rand.Float64()*100, 'f', -1, 64)
+		case influxql.Integer:
+			b = strconv.AppendInt(b, g.rand.Int63n(1000), 10)
+			b = append(b, 'i')
+		case influxql.Unsigned:
+			b = strconv.AppendUint(b, uint64(g.rand.Int63n(1000)), 10)
+			b = append(b, 'u')
+		case influxql.Boolean:
+			b = strconv.AppendBool(b, g.rand.Intn(2) == 1)
+		case influxql.String:
+			b = append(b, `"value`...)
+			b = strconv.AppendInt(b, int64(g.rand.Intn(100)), 10)
+			b = append(b, '"')
+		}
+	}
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, t, 10)
+	return append(b, '\n')
+}
+
+// writeBuckets writes the workload to w, split into buckets of the shard
+// duration. Points are written in time order, so outOfOrder does not apply.
+func (g *generator) writeBuckets(w format.Writer, shardDuration time.Duration) error {
+	all, err := g.series()
+	if err != nil {
+		return err
+	}
+
+	start := g.start.UnixNano()
+	end := start + int64(g.points-1)*int64(g.interval)
+	for bstart := g.start.Truncate(shardDuration).UnixNano(); bstart <= end; bstart += int64(shardDuration) {
+		bend := bstart + int64(shardDuration)
+		if err := g.writeBucket(w, all, bstart, bend); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// writeBucket writes the points of all series between start and end.
+func (g *generator) writeBucket(w format.Writer, all []series, start, end int64) error {
+	// The first point of the series in the bucket.
+	first := g.start.UnixNano()
+	if first < start {
+		first += (start - first + int64(g.interval) - 1) / int64(g.interval) * int64(g.interval)
+	}
+	last := g.start.UnixNano() + int64(g.points-1)*int64(g.interval)
+	if last >= end {
+		last = end - 1
+	}
+	if first > last {
+		return nil
+	}
+
+	bw, err := w.NewBucket(start, end)
+	if err != nil {
+		return err
+	}
+	defer bw.Close()
+
+	for _, s := range all {
+		for _, f := range g.fields {
+			bw.BeginSeries(s.name, []byte(f.key), f.typ, s.tags)
+			c := &pointCursor{next: first, last: last, interval: int64(g.interval), rand: g.rand}
+			switch f.typ {
+			case influxql.Float:
+				bw.WriteFloatCursor(&floatCursor{c})
+			case influxql.Integer:
+				bw.WriteIntegerCursor(&integerCursor{c})
+			case influxql.Unsigned:
+				bw.WriteUnsignedCursor(&unsignedCursor{c})
+			case influxql.Boolean:
+				bw.WriteBooleanCursor(&booleanCursor{c})
+			case influxql.String:
+				bw.WriteStringCursor(&stringCursor{c})
+			}
+			bw.EndSeries()
+			if err := bw.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pointCursor generates the timestamps of the points of a series, a block
+// at a time.
+type pointCursor struct {
+	next, last int64
+	interval   int64
+	rand       *rand.Rand
+}
+
+func (c *pointCursor) timestamps() []int64 {
+	var ts []int64
+	for ; c.next <= c.last && len(ts) < tsdb.DefaultMaxPointsPerBlock; c.next += c.interval {
+		ts = append(ts, c.next)
+	}
+	return ts
+}
+
+func (c *pointCursor) Close()                  {}
+func (c *pointCursor) Err() error              { return nil }
+func (c *pointCursor) Stats() tsdb.CursorStats { return tsdb.CursorStats{} }
+
+type floatCursor struct{ *pointCursor }
+
+func (c *floatCursor) Next() *tsdb.FloatArray {
+	a := &tsdb.FloatArray{Timestamps: c.timestamps()}
+	a.Values = make([]float64, len(a.Timestamps))
+	for i := range a.Values {
+		a.Values[i] = c.rand.Float64() * 100
+	}
+	return a
+}
+
+type integerCursor struct{ *pointCursor }
+
+func (c *integerCursor) Next() *tsdb.IntegerArray {
+	a := &tsdb.IntegerArray{Timestamps: c.timestamps()}
+	a.Values = make([]int64, len(a.Timestamps))
+	for i := range a.Values {
+		a.Values[i] = c.rand.Int63n(1000)
+	}
+	return a
+}
+
+type unsignedCursor struct{ *pointCursor }
+
+func (c *unsignedCursor) Next() *tsdb.UnsignedArray {
+	a := &tsdb.UnsignedArray{Timestamps: c.timestamps()}
+	a.Values = make([]uint64, len(a.Timestamps))
+	for i := range a.Values {
+		a.Values[i] = uint64(c.rand.Int63n(1000))
+	}
+	return a
+}
+
+type booleanCursor struct{ *pointCursor }
+
+func (c *booleanCursor) Next() *tsdb.BooleanArray {
+	a := &tsdb.BooleanArray{Timestamps: c.timestamps()}
+	a.Values = make([]bool, len(a.Timestamps))
+	for i := range a.Values {
+		a.Values[i] = c.rand.Intn(2) == 1
+	}
+	return a
+}
+
+type stringCursor struct{ *pointCursor }
+
+func (c *stringCursor) Next() *tsdb.StringArray {
+	a := &tsdb.StringArray{Timestamps: c.timestamps()}
+	a.Values = make([]string, len(a.Timestamps))
+	for i := range a.Values {
+		a.Values[i] = "value" + strconv.Itoa(c.rand.Intn(100))
+	}
+	return a
+}
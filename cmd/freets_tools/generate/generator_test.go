@@ -0,0 +1,89 @@
+package generate
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/freetsdb/freetsdb/models"
+)
+
+func newGenerator(t *testing.T, distribution string, outOfOrder float64) *generator {
+	tags, err := parseTags("region=4,host=50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := parseFields("value=float,count=integer,ok=boolean")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &generator{
+		measurements: 2,
+		seriesN:      20,
+		tags:         tags,
+		distribution: distribution,
+		fields:       fields,
+		start:        time.Unix(0, 0),
+		interval:     time.Second,
+		points:       50,
+		outOfOrder:   outOfOrder,
+		rand:         rand.New(rand.NewSource(1)),
+	}
+}
+
+func TestGenerator_Series(t *testing.T) {
+	for _, distribution := range []string{distributionUniform, distributionZipf} {
+		t.Run(distribution, func(t *testing.T) {
+			all, err := newGenerator(t, distribution, 0).series()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != 40 {
+				t.Fatalf("unexpected series count: got=%d exp=40", len(all))
+			}
+			for i := 1; i < len(all); i++ {
+				if bytes.Compare(all[i-1].key, all[i].key) >= 0 {
+					t.Fatalf("series not sorted or not distinct: %s, %s", all[i-1].key, all[i].key)
+				}
+			}
+		})
+	}
+
+	g := newGenerator(t, distributionUniform, 0)
+	g.seriesN = 500
+	if _, err := g.series(); err == nil {
+		t.Fatal("expected error for more series than tag combinations")
+	}
+}
+
+func TestGenerator_WriteLines(t *testing.T) {
+	g := newGenerator(t, distributionUniform, 0.2)
+	var buf bytes.Buffer
+	if err := g.writeLines(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	points, err := models.ParsePoints(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 2*20*50 {
+		t.Fatalf("unexpected point count: got=%d exp=%d", len(points), 2*20*50)
+	}
+
+	var outOfOrder int
+	var last int64
+	for _, p := range points {
+		if p.UnixNano() < last {
+			outOfOrder++
+		}
+		last = p.UnixNano()
+		if fields, err := p.Fields(); err != nil || len(fields) != 3 {
+			t.Fatalf("unexpected fields: %v %v", fields, err)
+		}
+	}
+	if outOfOrder == 0 {
+		t.Fatal("expected points out of order")
+	}
+}
@@ -33,8 +33,12 @@ Usage: freets_tools command [arguments]
 
 The commands are:
 
+    bulkload             writes historical line protocol directly to TSM files
     export               reshapes existing shards to a new shard duration
     compact-shard        fully compacts the specified shard
+    gen                  generates synthetic workloads for benchmarking
+    migrate-index        converts shards from the in-memory index to TSI
+    stress               benchmarks writes and queries against a running server
     help                 display this help message
 
 Use "freets_tools command -help" for more information about a command.
@@ -0,0 +1,707 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/freetsdb/freetsdb/cmd/freets_tools/server"
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/tsdb"
+	"github.com/freetsdb/freetsdb/tsdb/engine/tsm1"
+	"go.uber.org/zap"
+)
+
+// BulkLoadCommand represents the program execution for "bulkload".
+//
+// It loads historical line protocol directly into TSM files, bypassing the
+// WAL and cache of the write path. Points are first sorted into one file per
+// shard group, and each shard group is then written as fully compacted TSM
+// files. The values of a shard group are sorted in runs of a bounded number
+// of values, which are merged as the shard group is written.
+type BulkLoadCommand struct {
+	// Standard input/output, overridden for testing.
+	Stderr io.Writer
+	Stdin  io.Reader
+	Logger *zap.Logger
+	server server.Interface
+
+	configPath      string
+	database        string
+	retentionPolicy string
+	replication     int
+	duration        time.Duration
+	shardDuration   time.Duration
+	buildTSI        bool
+	replace         bool
+	path            string
+	precision       string
+	tmpDir          string
+	maxValues       int
+}
+
+// NewBulkLoadCommand returns a new instance of BulkLoadCommand.
+func NewBulkLoadCommand(server server.Interface) *BulkLoadCommand {
+	return &BulkLoadCommand{
+		Stderr: os.Stderr,
+		Stdin:  os.Stdin,
+		server: server,
+	}
+}
+
+// Run executes the bulkload command using the specified args.
+func (cmd *BulkLoadCommand) Run(args []string) error {
+	if err := cmd.parseFlags(args); err != nil {
+		return err
+	}
+
+	if err := cmd.server.Open(cmd.configPath); err != nil {
+		return err
+	}
+	defer cmd.server.Close()
+
+	i := newImporter(cmd.server, cmd.database, cmd.retentionPolicy, cmd.replace, cmd.buildTSI, cmd.Logger)
+	i.compacted = true
+
+	rp := &meta.RetentionPolicyInfo{Name: cmd.retentionPolicy, ShardGroupDuration: cmd.shardDuration}
+	if cmd.duration >= time.Hour {
+		rp.Duration = cmd.duration
+	}
+	if cmd.replication > 0 {
+		rp.ReplicaN = cmd.replication
+	}
+	if err := i.CreateDatabase(rp); err != nil {
+		return err
+	}
+
+	r, err := cmd.input()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	dir, err := ioutil.TempDir(cmd.tmpDir, "bulkload")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	groups, err := spillShardGroups(r, dir, i.rpi.ShardGroupDuration, cmd.precision)
+	if err != nil {
+		return err
+	}
+
+	schema, err := loadFieldSchema(filepath.Join(i.dataDir, i.db))
+	if err != nil {
+		return err
+	}
+
+	var n int
+	for _, g := range groups {
+		written, err := loadShardGroup(i, g, i.rpi.ShardGroupDuration, cmd.maxValues, schema)
+		if err != nil {
+			return fmt.Errorf("shard group %s: %s", time.Unix(0, g.start).UTC().Format(time.RFC3339), err)
+		}
+		n += written
+	}
+	fmt.Fprintf(cmd.Stderr, "Loaded %d values into %d shard groups\n", n, len(groups))
+	return nil
+}
+
+// input returns the line protocol to load, read from the file at path, or
+// from standard input if no path is given. Files ending in .gz are decompressed.
+func (cmd *BulkLoadCommand) input() (io.ReadCloser, error) {
+	if cmd.path == "" || cmd.path == "-" {
+		return ioutil.NopCloser(cmd.Stdin), nil
+	}
+
+	f, err := os.Open(cmd.path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(cmd.path, ".gz") {
+		return f, nil
+	}
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: gr, f: f}, nil
+}
+
+// gzipFile closes the file a gzip.Reader reads from with the reader.
+type gzipFile struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g *gzipFile) Close() error {
+	g.Reader.Close()
+	return g.f.Close()
+}
+
+// spilledShardGroup is the file holding the points of a shard group.
+type spilledShardGroup struct {
+	start int64
+	path  string
+	f     *os.File
+	w     *bufio.Writer
+}
+
+// spillShardGroups reads line protocol from r and writes each point to the
+// file of its shard group in dir, with a nanosecond timestamp. It returns the
+// files ordered by the start time of their shard group.
+func spillShardGroups(r io.Reader, dir string, shardGroupDuration time.Duration, precision string) (groups []*spilledShardGroup, err error) {
+	byStart := make(map[int64]*spilledShardGroup)
+	defer func() {
+		for _, g := range byStart {
+			if e := g.w.Flush(); e != nil && err == nil {
+				err = e
+			}
+			if e := g.f.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	}()
+
+	now := time.Now().UTC()
+	br := bufio.NewReaderSize(r, 1<<20)
+	for lineno := 1; ; lineno++ {
+		line, rerr := br.ReadBytes('\n')
+		if rerr != nil && rerr != io.EOF {
+			return nil, rerr
+		}
+
+		if l := bytes.TrimSpace(line); len(l) > 0 && l[0] != '#' {
+			points, err := models.ParsePointsWithPrecision(l, now, precision)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", lineno, err)
+			}
+
+			for _, p := range points {
+				start := p.Time().Truncate(shardGroupDuration).UnixNano()
+				g := byStart[start]
+				if g == nil {
+					path := filepath.Join(dir, strconv.FormatInt(start, 10)+".txt")
+					f, err := os.Create(path)
+					if err != nil {
+						return nil, err
+					}
+					g = &spilledShardGroup{start: start, path: path, f: f, w: bufio.NewWriter(f)}
+					byStart[start] = g
+					groups = append(groups, g)
+				}
+
+				if _, err := g.w.WriteString(p.String()); err != nil {
+					return nil, err
+				} else if err := g.w.WriteByte('\n'); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if rerr == io.EOF {
+			break
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].start < groups[j].start })
+	return groups, nil
+}
+
+// loadShardGroup sorts the points of shard group g by series, field and time
+// and writes them to the shard of the group with i. Values of a field written
+// more than once at the same time keep the last value. At most maxValues
+// values are sorted in memory at once. The types of the fields are checked
+// against schema. It returns the number of values written.
+func loadShardGroup(i *importer, g *spilledShardGroup, shardGroupDuration time.Duration, maxValues int, schema *fieldSchema) (n int, err error) {
+	end := g.start + int64(shardGroupDuration)
+
+	// The shards of the group already stored are replaced or skipped, so the
+	// types of their fields do not conflict.
+	existing, err := i.MetaClient.ShardGroupsByTimeRange(i.db, i.rpi.Name, time.Unix(0, g.start), time.Unix(0, end))
+	if err != nil {
+		return 0, err
+	}
+	skip := make(map[uint64]struct{})
+	for _, sgi := range existing {
+		for _, si := range sgi.Shards {
+			skip[si.ID] = struct{}{}
+		}
+	}
+
+	runs, err := sortShardGroup(g, maxValues, func(name []byte, field string, typ models.FieldType) error {
+		return schema.check(name, field, typ, skip)
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer closeRuns(runs)
+
+	if err := i.StartShardGroup(g.start, end); err != nil {
+		return 0, err
+	}
+	defer func() {
+		// A failed write closes the shard group itself.
+		if i.sh == nil && !i.skipShard {
+			return
+		}
+		if cerr := i.CloseShardGroup(); err == nil {
+			err = cerr
+		}
+	}()
+
+	var series []byte
+	err = mergeRuns(runs, func(key []byte, vals tsm1.Values) error {
+		// Keys are sorted by series first, so each series is added once.
+		if seriesKey, _ := tsm1.SeriesAndFieldFromCompositeKey(key); !bytes.Equal(seriesKey, series) {
+			if err := i.AddSeries(seriesKey); err != nil {
+				return err
+			}
+			series = append(series[:0], seriesKey...)
+		}
+
+		for len(vals) > 0 {
+			block := vals
+			if len(block) > tsdb.DefaultMaxPointsPerBlock {
+				block = block[:tsdb.DefaultMaxPointsPerBlock]
+			}
+			if err := i.Write(key, block); err != nil {
+				return err
+			}
+			vals = vals[len(block):]
+			n += len(block)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// sortShardGroup reads the points of shard group g and returns their values
+// sorted by key in runs. Every maxValues values, the values read are sorted
+// into a run written next to the file of the group, and the last values read
+// are kept in memory. check is called with the type of each field of each
+// measurement the first time it is seen in the group.
+func sortShardGroup(g *spilledShardGroup, maxValues int, check func(name []byte, field string, typ models.FieldType) error) (runs []valueRun, err error) {
+	f, err := os.Open(g.path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		f.Close()
+		os.Remove(g.path)
+		if err != nil {
+			closeRuns(runs)
+		}
+	}()
+
+	values := make(map[string]tsm1.Values)
+	fieldTypes := make(map[string]models.FieldType)
+	var buffered int
+
+	br := bufio.NewReaderSize(f, 1<<20)
+	for {
+		line, rerr := br.ReadBytes('\n')
+		if rerr != nil && rerr != io.EOF {
+			return nil, rerr
+		}
+
+		if l := bytes.TrimSpace(line); len(l) > 0 {
+			points, err := models.ParsePointsWithPrecision(l, time.Time{}, "n")
+			if err != nil {
+				return nil, err
+			}
+
+			for _, p := range points {
+				seriesKey := string(p.Key())
+				t := p.UnixNano()
+				iter := p.FieldIterator()
+				for iter.Next() {
+					field := string(iter.FieldKey())
+
+					// A field must have the same type in every series of a measurement.
+					typeKey := string(p.Name()) + "\x00" + field
+					if typ, ok := fieldTypes[typeKey]; !ok {
+						if err := check(p.Name(), field, iter.Type()); err != nil {
+							return nil, err
+						}
+						fieldTypes[typeKey] = iter.Type()
+					} else if typ != iter.Type() {
+						return nil, fmt.Errorf("field type conflict: field %q of measurement %q", field, p.Name())
+					}
+
+					v, err := fieldValue(iter, t)
+					if err != nil {
+						return nil, err
+					}
+					key := tsm1.SeriesFieldKey(seriesKey, field)
+					values[key] = append(values[key], v)
+					buffered++
+				}
+			}
+
+			if buffered >= maxValues {
+				run, err := writeRun(fmt.Sprintf("%s.%d", g.path, len(runs)), values)
+				if err != nil {
+					return nil, err
+				}
+				runs = append(runs, run)
+				values = make(map[string]tsm1.Values)
+				buffered = 0
+			}
+		}
+
+		if rerr == io.EOF {
+			break
+		}
+	}
+
+	if len(values) > 0 {
+		runs = append(runs, newMemRun(values))
+	}
+	return runs, nil
+}
+
+// valueRun returns the values of a part of a shard group by key, in the order
+// of the keys.
+type valueRun interface {
+	// next returns the next key and its values, or a nil key after the
+	// last one.
+	next() ([]byte, tsm1.Values, error)
+	close() error
+}
+
+// memRun is a run of values held in memory.
+type memRun struct {
+	keys   []string
+	values map[string]tsm1.Values
+}
+
+func newMemRun(values map[string]tsm1.Values) *memRun {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return &memRun{keys: keys, values: values}
+}
+
+func (r *memRun) next() ([]byte, tsm1.Values, error) {
+	if len(r.keys) == 0 {
+		return nil, nil, nil
+	}
+	key := r.keys[0]
+	r.keys = r.keys[1:]
+	vals := r.values[key]
+	delete(r.values, key)
+	return []byte(key), vals, nil
+}
+
+func (r *memRun) close() error { return nil }
+
+// fileRun is a run of values written to a file. Each key is written with its
+// length, followed by its values encoded as a single block and its length.
+type fileRun struct {
+	f *os.File
+	r *bufio.Reader
+}
+
+// writeRun writes values sorted by key to a new run file at path and returns
+// the run, read from the start of the file.
+func writeRun(path string, values map[string]tsm1.Values) (*fileRun, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	run := &fileRun{f: f}
+
+	mr := newMemRun(values)
+	w := bufio.NewWriterSize(f, 1<<20)
+	var buf []byte
+	for {
+		key, vals, _ := mr.next()
+		if key == nil {
+			break
+		}
+		if buf, err = vals.Deduplicate().Encode(buf[:0]); err != nil {
+			run.close()
+			return nil, err
+		}
+		if err := writeRunBytes(w, key); err != nil {
+			run.close()
+			return nil, err
+		} else if err := writeRunBytes(w, buf); err != nil {
+			run.close()
+			return nil, err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		run.close()
+		return nil, err
+	} else if _, err := f.Seek(0, io.SeekStart); err != nil {
+		run.close()
+		return nil, err
+	}
+	run.r = bufio.NewReaderSize(f, 1<<20)
+	return run, nil
+}
+
+// writeRunBytes writes b to w, preceded by its length.
+func writeRunBytes(w io.Writer, b []byte) error {
+	if err := binary.Write(w, binary.BigEndian, uint32(len(b))); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// readRunBytes reads bytes written by writeRunBytes from r. It returns
+// io.EOF at the end of r.
+func readRunBytes(r io.Reader) ([]byte, error) {
+	var n uint32
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (r *fileRun) next() ([]byte, tsm1.Values, error) {
+	key, err := readRunBytes(r.r)
+	if err == io.EOF {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	block, err := readRunBytes(r.r)
+	if err != nil {
+		return nil, nil, err
+	}
+	vals, err := tsm1.DecodeBlock(block, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, vals, nil
+}
+
+// close closes and removes the file of the run.
+func (r *fileRun) close() error {
+	r.f.Close()
+	return os.Remove(r.f.Name())
+}
+
+// closeRuns closes runs.
+func closeRuns(runs []valueRun) {
+	for _, r := range runs {
+		r.close()
+	}
+}
+
+// mergeRuns calls fn with the values of each key of runs, in the order of the
+// keys. The values of a key in several runs are merged, and values at the same
+// time keep the value of the last run.
+func mergeRuns(runs []valueRun, fn func(key []byte, vals tsm1.Values) error) error {
+	keys := make([][]byte, len(runs))
+	values := make([]tsm1.Values, len(runs))
+	for j, r := range runs {
+		k, v, err := r.next()
+		if err != nil {
+			return err
+		}
+		keys[j], values[j] = k, v
+	}
+
+	for {
+		var key []byte
+		for _, k := range keys {
+			if k != nil && (key == nil || bytes.Compare(k, key) < 0) {
+				key = k
+			}
+		}
+		if key == nil {
+			return nil
+		}
+
+		var merged tsm1.Values
+		for j, r := range runs {
+			if keys[j] == nil || !bytes.Equal(keys[j], key) {
+				continue
+			}
+			merged = append(merged, values[j]...)
+
+			k, v, err := r.next()
+			if err != nil {
+				return err
+			}
+			keys[j], values[j] = k, v
+		}
+
+		if err := fn(key, merged.Deduplicate()); err != nil {
+			return err
+		}
+	}
+}
+
+// fieldSchema holds the types of the fields of a database, to check that the
+// fields loaded keep them.
+type fieldSchema struct {
+	// shards are the fields of the shards stored before the load, by ID.
+	shards map[uint64]*tsdb.MeasurementFieldSet
+
+	// types are the types of the fields loaded, by measurement and field.
+	types map[string]models.FieldType
+}
+
+// loadFieldSchema reads the fields of the shards of the database stored in
+// dir.
+func loadFieldSchema(dir string) (*fieldSchema, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*", "fields.idx"))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &fieldSchema{
+		shards: make(map[uint64]*tsdb.MeasurementFieldSet),
+		types:  make(map[string]models.FieldType),
+	}
+	for _, path := range paths {
+		id, err := strconv.ParseUint(filepath.Base(filepath.Dir(path)), 10, 64)
+		if err != nil {
+			continue
+		}
+		fs, err := tsdb.NewMeasurementFieldSet(path)
+		if err != nil {
+			return nil, fmt.Errorf("read fields of shard %d: %s", id, err)
+		}
+		s.shards[id] = fs
+	}
+	return s, nil
+}
+
+// check returns an error if field of measurement name was loaded with another
+// type than typ, or has another type in a shard stored before the load that
+// is not in skip.
+func (s *fieldSchema) check(name []byte, field string, typ models.FieldType, skip map[uint64]struct{}) error {
+	key := string(name) + "\x00" + field
+	if t, ok := s.types[key]; ok {
+		if t != typ {
+			return fmt.Errorf("field type conflict: field %q of measurement %q", field, name)
+		}
+		return nil
+	}
+
+	dataType := fieldDataType(typ)
+	for id, fs := range s.shards {
+		if _, ok := skip[id]; ok {
+			continue
+		}
+		if mf := fs.Fields(name); mf != nil {
+			if f := mf.Field(field); f != nil && f.Type != dataType {
+				return fmt.Errorf("field type conflict: field %q of measurement %q is %s in shard %d", field, name, f.Type, id)
+			}
+		}
+	}
+	s.types[key] = typ
+	return nil
+}
+
+// fieldDataType returns the data type of fields of type typ.
+func fieldDataType(typ models.FieldType) influxql.DataType {
+	switch typ {
+	case models.Float:
+		return influxql.Float
+	case models.Integer:
+		return influxql.Integer
+	case models.Unsigned:
+		return influxql.Unsigned
+	case models.Boolean:
+		return influxql.Boolean
+	case models.String:
+		return influxql.String
+	default:
+		return influxql.Unknown
+	}
+}
+
+// fieldValue returns the value at time t of the current field of iter.
+func fieldValue(iter models.FieldIterator, t int64) (tsm1.Value, error) {
+	switch iter.Type() {
+	case models.Float:
+		v, err := iter.FloatValue()
+		return tsm1.NewFloatValue(t, v), err
+	case models.Integer:
+		v, err := iter.IntegerValue()
+		return tsm1.NewIntegerValue(t, v), err
+	case models.Unsigned:
+		v, err := iter.UnsignedValue()
+		return tsm1.NewUnsignedValue(t, v), err
+	case models.Boolean:
+		v, err := iter.BooleanValue()
+		return tsm1.NewBooleanValue(t, v), err
+	case models.String:
+		return tsm1.NewStringValue(t, iter.StringValue()), nil
+	default:
+		return nil, fmt.Errorf("unsupported type for field %q", iter.FieldKey())
+	}
+}
+
+func (cmd *BulkLoadCommand) parseFlags(args []string) error {
+	fs := flag.NewFlagSet("bulkload", flag.ContinueOnError)
+	fs.StringVar(&cmd.configPath, "config", "", "Config file")
+	fs.StringVar(&cmd.database, "database", "", "Database name")
+	fs.StringVar(&cmd.retentionPolicy, "rp", "", "Retention policy")
+	fs.IntVar(&cmd.replication, "replication", 0, "Retention policy replication")
+	fs.DurationVar(&cmd.duration, "duration", time.Hour*0, "Retention policy duration")
+	fs.DurationVar(&cmd.shardDuration, "shard-duration", time.Hour*24*7, "Retention policy shard duration")
+	fs.BoolVar(&cmd.buildTSI, "build-tsi", false, "Build the on disk TSI")
+	fs.BoolVar(&cmd.replace, "replace", false, "Enables replacing the data of existing shards")
+	fs.StringVar(&cmd.path, "path", "", "Line protocol file to load, standard input if not set")
+	fs.StringVar(&cmd.precision, "precision", "ns", "Precision of the timestamps of the line protocol")
+	fs.StringVar(&cmd.tmpDir, "tmpdir", "", "Directory for the points sorted by shard group")
+	fs.IntVar(&cmd.maxValues, "max-values", 10000000, "Values of a shard group sorted in memory at once")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if cmd.database == "" {
+		return errors.New("database is required")
+	}
+
+	if cmd.retentionPolicy == "" {
+		return errors.New("retention policy is required")
+	}
+
+	if cmd.shardDuration <= 0 {
+		return errors.New("shard duration must be positive")
+	}
+
+	if cmd.maxValues <= 0 {
+		return errors.New("max values must be positive")
+	}
+
+	return nil
+}
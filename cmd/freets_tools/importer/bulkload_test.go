@@ -0,0 +1,223 @@
+package importer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/tsdb"
+	"github.com/freetsdb/freetsdb/tsdb/engine/tsm1"
+)
+
+func TestSpillShardGroups(t *testing.T) {
+	dir := mustTempDir(t)
+	defer os.RemoveAll(dir)
+
+	lp := "cpu value=1 7200\n# comment\n\ncpu value=2 10\nmem value=3 3700\n"
+	groups, err := spillShardGroups(strings.NewReader(lp), dir, time.Hour, "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var starts []int64
+	for _, g := range groups {
+		starts = append(starts, g.start)
+	}
+	if exp := []int64{0, int64(time.Hour), int64(2 * time.Hour)}; !reflect.DeepEqual(starts, exp) {
+		t.Fatalf("unexpected shard groups: got=%v exp=%v", starts, exp)
+	}
+
+	buf, err := ioutil.ReadFile(groups[0].path)
+	if err != nil {
+		t.Fatal(err)
+	} else if got, exp := string(buf), "cpu value=2 10000000000\n"; got != exp {
+		t.Fatalf("unexpected points: got=%q exp=%q", got, exp)
+	}
+}
+
+// Ensure the values of a shard group sorted in several runs are merged in the
+// order of their keys, and values at the same time keep the last value.
+func TestSortShardGroup(t *testing.T) {
+	dir := mustTempDir(t)
+	defer os.RemoveAll(dir)
+
+	lp := "mem value=1 3\ncpu,host=a value=1 2\ncpu,host=a value=2 1\ncpu,host=a value=3 2\nmem value=4 1\n"
+	groups, err := spillShardGroups(strings.NewReader(lp), dir, time.Hour, "n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := sortShardGroup(groups[0], 2, func(name []byte, field string, typ models.FieldType) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeRuns(runs)
+	if len(runs) != 3 {
+		t.Fatalf("unexpected number of runs: %d", len(runs))
+	}
+
+	var keys []string
+	var values []tsm1.Values
+	if err := mergeRuns(runs, func(key []byte, vals tsm1.Values) error {
+		keys = append(keys, string(key))
+		values = append(values, vals)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := []string{"cpu,host=a#!~#value", "mem#!~#value"}; !reflect.DeepEqual(keys, exp) {
+		t.Fatalf("unexpected keys: got=%v exp=%v", keys, exp)
+	}
+	if exp := []tsm1.Values{
+		{tsm1.NewFloatValue(1, 2), tsm1.NewFloatValue(2, 3)},
+		{tsm1.NewFloatValue(1, 4), tsm1.NewFloatValue(3, 1)},
+	}; !reflect.DeepEqual(values, exp) {
+		t.Fatalf("unexpected values: got=%v exp=%v", values, exp)
+	}
+
+	// The runs written to disk are removed once closed.
+	closeRuns(runs)
+	if paths, _ := filepath.Glob(filepath.Join(dir, "*")); len(paths) != 0 {
+		t.Fatalf("unexpected files: %v", paths)
+	}
+}
+
+// Ensure the types of the fields loaded are checked against the fields loaded
+// before and the fields of the shards stored, except the shards skipped.
+func TestFieldSchema_Check(t *testing.T) {
+	dir := mustTempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "rp0", "2", "fields.idx")
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatal(err)
+	}
+	fs, err := tsdb.NewMeasurementFieldSet(path)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := fs.CreateFieldsIfNotExists([]byte("cpu")).CreateFieldIfNotExists([]byte("value"), influxql.Integer); err != nil {
+		t.Fatal(err)
+	} else if err := fs.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := loadFieldSchema(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := schema.check([]byte("cpu"), "value", models.Float, nil); err == nil || !strings.Contains(err.Error(), "shard 2") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := schema.check([]byte("cpu"), "value", models.Float, map[uint64]struct{}{2: {}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.check([]byte("cpu"), "value", models.Integer, nil); err == nil {
+		t.Fatal("expected a conflict with the field loaded")
+	}
+	if err := schema.check([]byte("mem"), "value", models.String, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure a shard group is loaded as TSM files holding its sorted values.
+func TestLoadShardGroup(t *testing.T) {
+	dir := mustTempDir(t)
+	defer os.RemoveAll(dir)
+
+	i := &importer{
+		MetaClient: &MetaClient{},
+		db:         "db0",
+		dataDir:    dir,
+		rpi:        &meta.RetentionPolicyInfo{Name: "rp0", ShardGroupDuration: time.Hour},
+		seriesBuf:  make([]byte, 0, 2048),
+		compacted:  true,
+	}
+	schema, err := loadFieldSchema(filepath.Join(dir, "db0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lp := "cpu,host=b value=1 2\ncpu,host=a value=2 1\ncpu,host=a value=3 1\n"
+	groups, err := spillShardGroups(strings.NewReader(lp), dir, time.Hour, "n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := loadShardGroup(i, groups[0], time.Hour, 1, schema); err != nil {
+		t.Fatal(err)
+	} else if n != 2 {
+		t.Fatalf("unexpected values written: %d", n)
+	} else if i.sh != nil {
+		t.Fatal("expected the shard group to be closed")
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "db0", "rp0", "1", "*."+tsm1.TSMFileExtension))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(files) != 1 {
+		t.Fatalf("unexpected TSM files: %v", files)
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := tsm1.NewTSMReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if vals, err := r.ReadAll([]byte("cpu,host=a#!~#value")); err != nil {
+		t.Fatal(err)
+	} else if exp := []tsm1.Value{tsm1.NewFloatValue(1, 3)}; !reflect.DeepEqual(vals, exp) {
+		t.Fatalf("unexpected values: got=%v exp=%v", vals, exp)
+	}
+	if vals, err := r.ReadAll([]byte("cpu,host=b#!~#value")); err != nil {
+		t.Fatal(err)
+	} else if exp := []tsm1.Value{tsm1.NewFloatValue(2, 1)}; !reflect.DeepEqual(vals, exp) {
+		t.Fatalf("unexpected values: got=%v exp=%v", vals, exp)
+	}
+}
+
+func mustTempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "bulkload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// MetaClient is a meta client without shard groups, which creates shard
+// group 1 with shard 1.
+type MetaClient struct{}
+
+func (*MetaClient) Database(name string) *meta.DatabaseInfo { return nil }
+func (*MetaClient) RetentionPolicy(database, name string) (*meta.RetentionPolicyInfo, error) {
+	return nil, nil
+}
+func (*MetaClient) ShardGroupsByTimeRange(database, policy string, min, max time.Time) ([]meta.ShardGroupInfo, error) {
+	return nil, nil
+}
+func (*MetaClient) CreateRetentionPolicy(database string, rpi *meta.RetentionPolicyInfo) (*meta.RetentionPolicyInfo, error) {
+	return rpi, nil
+}
+func (*MetaClient) UpdateRetentionPolicy(database, name string, rpu *meta.RetentionPolicyUpdate) error {
+	return nil
+}
+func (*MetaClient) CreateDatabase(name string) (*meta.DatabaseInfo, error) { return nil, nil }
+func (*MetaClient) CreateDatabaseWithRetentionPolicy(name string, rpi *meta.RetentionPolicyInfo) (*meta.DatabaseInfo, error) {
+	return nil, nil
+}
+func (*MetaClient) DeleteShardGroup(database, policy string, id uint64) error { return nil }
+func (*MetaClient) CreateShardGroup(database, policy string, timestamp time.Time) (*meta.ShardGroupInfo, error) {
+	return &meta.ShardGroupInfo{ID: 1, Shards: []meta.ShardInfo{{ID: 1}}}, nil
+}
@@ -32,10 +32,18 @@ type importer struct {
 	sw           *seriesWriter
 	buildTsi     bool
 	seriesBuf    []byte
+
+	// compacted marks the TSM files written as fully compacted, for data
+	// that is written sorted, in full blocks.
+	compacted bool
 }
 
 const seriesBatchSize = 1000
 
+// fullyCompactedSequence is the TSM file sequence number of the highest
+// compaction level.
+const fullyCompactedSequence = 4
+
 func newImporter(server server.Interface, db string, rp string, replace bool, buildTsi bool, log *zap.Logger) *importer {
 	i := &importer{MetaClient: server.MetaClient(), db: db, dataDir: server.TSDBConfig().Dir, replace: replace, buildTsi: buildTsi, log: log, skipShard: false}
 
@@ -147,10 +155,18 @@ func (i *importer) StartShardGroup(start int64, end int64) error {
 	}
 
 	i.skipShard = false
-	i.sh = shard.NewWriter(shardID, shardsPath)
+	if i.compacted {
+		i.sh = shard.NewWriter(shardID, shardsPath, shard.Sequence(fullyCompactedSequence))
+	} else {
+		i.sh = shard.NewWriter(shardID, shardsPath)
+	}
 	i.currentShard = shardID
 
-	i.startSeriesFile()
+	if err := i.startSeriesFile(); err != nil {
+		i.sh.Close()
+		i.sh = nil
+		return err
+	}
 	return nil
 }
 
@@ -216,6 +232,7 @@ func (i *importer) startSeriesFile() error {
 	}
 
 	if err != nil {
+		i.sfile.Close()
 		return err
 	}
 	return nil
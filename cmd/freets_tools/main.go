@@ -10,9 +10,12 @@ import (
 	"github.com/freetsdb/freetsdb/cmd"
 	"github.com/freetsdb/freetsdb/cmd/freets_tools/compact"
 	"github.com/freetsdb/freetsdb/cmd/freets_tools/export"
+	"github.com/freetsdb/freetsdb/cmd/freets_tools/generate"
 	"github.com/freetsdb/freetsdb/cmd/freets_tools/help"
 	"github.com/freetsdb/freetsdb/cmd/freets_tools/importer"
+	"github.com/freetsdb/freetsdb/cmd/freets_tools/migrateindex"
 	"github.com/freetsdb/freetsdb/cmd/freets_tools/server"
+	"github.com/freetsdb/freetsdb/cmd/freets_tools/stress"
 	metaRun "github.com/freetsdb/freetsdb/cmd/freetsd-meta/run"
 	dataRun "github.com/freetsdb/freetsdb/cmd/freetsd/run"
 	"github.com/freetsdb/freetsdb/services/meta"
@@ -55,6 +58,11 @@ func (m *Main) Run(args ...string) error {
 		if err := help.NewCommand().Run(args...); err != nil {
 			return fmt.Errorf("help failed: %s", err)
 		}
+	case "bulkload":
+		c := importer.NewBulkLoadCommand(&ossServer{logger: zap.NewNop()})
+		if err := c.Run(args); err != nil {
+			return fmt.Errorf("bulkload failed: %s", err)
+		}
 	case "compact-shard":
 		c := compact.NewCommand()
 		if err := c.Run(args); err != nil {
@@ -65,11 +73,26 @@ func (m *Main) Run(args ...string) error {
 		if err := c.Run(args); err != nil {
 			return fmt.Errorf("export failed: %s", err)
 		}
+	case "gen":
+		c := generate.NewCommand(&ossServer{logger: zap.NewNop()})
+		if err := c.Run(args); err != nil {
+			return fmt.Errorf("gen failed: %s", err)
+		}
 	case "import":
 		cmd := importer.NewCommand(&ossServer{logger: zap.NewNop()})
 		if err := cmd.Run(args); err != nil {
 			return fmt.Errorf("import failed: %s", err)
 		}
+	case "migrate-index":
+		c := migrateindex.NewCommand()
+		if err := c.Run(args); err != nil {
+			return fmt.Errorf("migrate-index failed: %s", err)
+		}
+	case "stress":
+		c := stress.NewCommand()
+		if err := c.Run(args); err != nil {
+			return fmt.Errorf("stress failed: %s", err)
+		}
 	default:
 		return fmt.Errorf(`unknown command "%s"`+"\n"+`Run 'freets-tools help' for usage`+"\n\n", name)
 	}
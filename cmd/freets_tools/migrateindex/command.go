@@ -0,0 +1,303 @@
+// Package migrateindex converts the shards of an in-memory index to TSI.
+package migrateindex
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/freetsdb/freetsdb/cmd/freets_inspect/buildtsi"
+	"github.com/freetsdb/freetsdb/logger"
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/tsdb"
+	"github.com/freetsdb/freetsdb/tsdb/engine/tsm1"
+	"github.com/freetsdb/freetsdb/tsdb/index/tsi1"
+	"go.uber.org/zap"
+)
+
+const defaultBatchSize = 10000
+
+// Command represents the program execution for "freets_tools migrate-index".
+type Command struct {
+	// Standard input/output, overridden for testing.
+	Stderr io.Writer
+	Stdout io.Writer
+	Logger *zap.Logger
+
+	dataDir         string
+	walDir          string
+	databaseFilter  string
+	retentionFilter string
+	shardFilter     string
+	maxLogFileSize  int64
+	maxCacheSize    uint64
+	batchSize       int
+	rollback        bool
+	verbose         bool
+}
+
+// NewCommand returns a new instance of Command.
+func NewCommand() *Command {
+	return &Command{
+		Stderr: os.Stderr,
+		Stdout: os.Stdout,
+		Logger: zap.NewNop(),
+	}
+}
+
+// shard is a shard found in the data directory.
+type shard struct {
+	id       uint64
+	database string
+	path     string
+	walPath  string
+}
+
+// Run executes the migrate-index command using the specified args.
+func (cmd *Command) Run(args []string) error {
+	if err := cmd.parseFlags(args); err != nil {
+		return err
+	}
+	if cmd.verbose {
+		cmd.Logger = logger.New(cmd.Stderr)
+	}
+
+	fmt.Fprintln(cmd.Stdout, "The server must be stopped while the index is migrated.")
+
+	databases, err := cmd.shards()
+	if err != nil {
+		return err
+	}
+
+	if cmd.rollback {
+		for _, shards := range databases {
+			for _, sh := range shards {
+				if err := cmd.rollbackShard(sh); err != nil {
+					return err
+				}
+			}
+		}
+		fmt.Fprintln(cmd.Stdout, `Set index-version = "inmem" before starting the server.`)
+		return nil
+	}
+
+	var migrated int
+	for db, shards := range databases {
+		n, err := cmd.migrateDatabase(db, shards)
+		migrated += n
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(cmd.Stdout, "Migrated %d shards.\n", migrated)
+	fmt.Fprintln(cmd.Stdout, `Set index-version = "tsi1" before starting the server.`)
+	return nil
+}
+
+// shards returns the shards that match the filters, grouped by database.
+func (cmd *Command) shards() (map[string][]shard, error) {
+	dbs, err := ioutil.ReadDir(cmd.dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	databases := make(map[string][]shard)
+	for _, db := range dbs {
+		if !db.IsDir() || (cmd.databaseFilter != "" && db.Name() != cmd.databaseFilter) {
+			continue
+		}
+
+		rps, err := ioutil.ReadDir(filepath.Join(cmd.dataDir, db.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, rp := range rps {
+			if !rp.IsDir() || rp.Name() == tsdb.SeriesFileDirectory ||
+				(cmd.retentionFilter != "" && rp.Name() != cmd.retentionFilter) {
+				continue
+			}
+
+			rpPath := filepath.Join(cmd.dataDir, db.Name(), rp.Name())
+			fis, err := ioutil.ReadDir(rpPath)
+			if err != nil {
+				return nil, err
+			}
+			for _, fi := range fis {
+				if !fi.IsDir() || (cmd.shardFilter != "" && fi.Name() != cmd.shardFilter) {
+					continue
+				}
+				id, err := strconv.ParseUint(fi.Name(), 10, 64)
+				if err != nil {
+					continue
+				}
+				databases[db.Name()] = append(databases[db.Name()], shard{
+					id:       id,
+					database: db.Name(),
+					path:     filepath.Join(rpPath, fi.Name()),
+					walPath:  filepath.Join(cmd.walDir, db.Name(), rp.Name(), fi.Name()),
+				})
+			}
+		}
+	}
+	return databases, nil
+}
+
+// migrateDatabase builds and verifies the TSI index of each shard of a
+// database. It returns the number of shards migrated.
+func (cmd *Command) migrateDatabase(db string, shards []shard) (int, error) {
+	sfile := tsdb.NewSeriesFile(filepath.Join(cmd.dataDir, db, tsdb.SeriesFileDirectory))
+	sfile.Logger = cmd.Logger
+	if err := sfile.Open(); err != nil {
+		return 0, err
+	}
+	defer sfile.Close()
+
+	var migrated int
+	for _, sh := range shards {
+		if _, err := os.Stat(filepath.Join(sh.path, "index")); err == nil {
+			fmt.Fprintf(cmd.Stdout, "shard %d already has a TSI index, skipping\n", sh.id)
+			continue
+		}
+
+		log := cmd.Logger.With(logger.Database(db), logger.Shard(sh.id))
+		if err := buildtsi.IndexShard(sfile, sh.path, sh.walPath, cmd.maxLogFileSize, cmd.maxCacheSize, cmd.batchSize, log, false); err != nil {
+			return migrated, fmt.Errorf("shard %d: %s", sh.id, err)
+		}
+
+		n, err := cmd.verifyShard(sfile, sh)
+		if err != nil {
+			// Roll the shard back so the server can still start with the
+			// in-memory index.
+			if rerr := os.RemoveAll(filepath.Join(sh.path, "index")); rerr != nil {
+				return migrated, fmt.Errorf("shard %d: %s; removing the index: %s", sh.id, err, rerr)
+			}
+			return migrated, fmt.Errorf("shard %d: %s; the index was removed", sh.id, err)
+		}
+		fmt.Fprintf(cmd.Stdout, "shard %d: migrated and verified %d series\n", sh.id, n)
+		migrated++
+	}
+	return migrated, nil
+}
+
+// verifyShard checks that the TSI index of sh has the series of its TSM and
+// WAL files. It returns the number of series.
+func (cmd *Command) verifyShard(sfile *tsdb.SeriesFile, sh shard) (uint64, error) {
+	expected, err := shardSeriesIDs(sfile, sh, cmd.maxCacheSize)
+	if err != nil {
+		return 0, err
+	}
+
+	idx := tsi1.NewIndex(sfile, sh.database, tsi1.WithPath(filepath.Join(sh.path, "index")))
+	if err := idx.Open(); err != nil {
+		return 0, err
+	}
+	defer idx.Close()
+
+	got := idx.SeriesIDSet()
+	if !got.Equals(expected) {
+		return 0, fmt.Errorf("index has %d series, expected %d", got.Cardinality(), expected.Cardinality())
+	}
+	return got.Cardinality(), nil
+}
+
+// shardSeriesIDs returns the IDs of the series in the TSM and WAL files of sh.
+// A series missing from the series file gets the ID 0, so it never matches
+// the index.
+func shardSeriesIDs(sfile *tsdb.SeriesFile, sh shard, maxCacheSize uint64) (*tsdb.SeriesIDSet, error) {
+	ids := tsdb.NewSeriesIDSet()
+	add := func(key []byte) {
+		seriesKey, _ := tsm1.SeriesAndFieldFromCompositeKey(key)
+		name, tags := models.ParseKeyBytes(seriesKey)
+		ids.Add(sfile.SeriesID(name, tags, nil))
+	}
+
+	tsmPaths, err := filepath.Glob(filepath.Join(sh.path, "*."+tsm1.TSMFileExtension))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range tsmPaths {
+		if err := func() error {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			// Files that can't be read were skipped when the index was built.
+			r, err := tsm1.NewTSMReader(f)
+			if err != nil {
+				return nil
+			}
+			defer r.Close()
+
+			for i := 0; i < r.KeyCount(); i++ {
+				key, _ := r.KeyAt(i)
+				add(key)
+			}
+			return nil
+		}(); err != nil {
+			return nil, err
+		}
+	}
+
+	walPaths, err := filepath.Glob(filepath.Join(sh.walPath, "*."+tsm1.WALFileExtension))
+	if err != nil {
+		return nil, err
+	} else if len(walPaths) > 0 {
+		cache := tsm1.NewCache(maxCacheSize)
+		if err := tsm1.NewCacheLoader(walPaths).Load(cache); err != nil {
+			return nil, err
+		}
+		for _, key := range cache.Keys() {
+			add(key)
+		}
+	}
+	return ids, nil
+}
+
+// rollbackShard removes the TSI index of sh, so the server rebuilds the
+// in-memory index from its TSM and WAL files.
+func (cmd *Command) rollbackShard(sh shard) error {
+	indexPath := filepath.Join(sh.path, "index")
+	if _, err := os.Stat(indexPath); os.IsNotExist(err) {
+		return nil
+	}
+	for _, path := range []string{indexPath, filepath.Join(sh.path, ".index")} {
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(cmd.Stdout, "shard %d: removed the TSI index\n", sh.id)
+	return nil
+}
+
+func (cmd *Command) parseFlags(args []string) error {
+	fs := flag.NewFlagSet("migrate-index", flag.ContinueOnError)
+	fs.StringVar(&cmd.dataDir, "datadir", "", "Data directory")
+	fs.StringVar(&cmd.walDir, "waldir", "", "WAL directory")
+	fs.StringVar(&cmd.databaseFilter, "database", "", "Optional: the database to migrate")
+	fs.StringVar(&cmd.retentionFilter, "retention", "", "Optional: the retention policy to migrate")
+	fs.StringVar(&cmd.shardFilter, "shard", "", "Optional: the shard ID to migrate")
+	fs.Int64Var(&cmd.maxLogFileSize, "max-log-file-size", tsdb.DefaultMaxIndexLogFileSize, "Optional: maximum log file size")
+	fs.Uint64Var(&cmd.maxCacheSize, "max-cache-size", tsdb.DefaultCacheMaxMemorySize, "Optional: maximum cache size")
+	fs.IntVar(&cmd.batchSize, "batch-size", defaultBatchSize, "Optional: the number of series written to the index at once")
+	fs.BoolVar(&cmd.rollback, "rollback", false, "Remove the TSI index of the shards instead, returning them to the in-memory index")
+	fs.BoolVar(&cmd.verbose, "v", false, "Verbose output")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if cmd.dataDir == "" {
+		return errors.New("datadir is required")
+	}
+	if cmd.walDir == "" {
+		return errors.New("waldir is required")
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package migrateindex
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/tsdb/engine/tsm1"
+)
+
+// Ensure the series of the TSM and WAL files of a shard are migrated to a TSI
+// index, and the index is removed on rollback.
+func TestCommand_Run(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dataDir, walDir := filepath.Join(dir, "data"), filepath.Join(dir, "wal")
+	shardPath := filepath.Join(dataDir, "db0", "rp0", "1")
+	shardWALPath := filepath.Join(walDir, "db0", "rp0", "1")
+	for _, path := range []string{shardPath, shardWALPath} {
+		if err := os.MkdirAll(path, 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Create(filepath.Join(shardPath, "000000001-000000001."+tsm1.TSMFileExtension))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := tsm1.NewTSMWriter(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"cpu,host=a#!~#value", "cpu,host=b#!~#value"} {
+		if err := w.Write([]byte(key), []tsm1.Value{tsm1.NewValue(1, 1.0)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.WriteIndex(); err != nil {
+		t.Fatal(err)
+	} else if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wal := tsm1.NewWAL(shardWALPath)
+	if err := wal.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wal.WriteMulti(map[string][]tsm1.Value{
+		"mem,host=a#!~#free": {tsm1.NewValue(1, int64(1))},
+	}); err != nil {
+		t.Fatal(err)
+	} else if err := wal.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	cmd := NewCommand()
+	cmd.Stdout = &stdout
+	if err := cmd.Run([]string{"-datadir", dataDir, "-waldir", walDir}); err != nil {
+		t.Fatal(err)
+	} else if out := stdout.String(); !strings.Contains(out, "shard 1: migrated and verified 3 series") || !strings.Contains(out, "Migrated 1 shards.") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if _, err := os.Stat(filepath.Join(shardPath, "index")); err != nil {
+		t.Fatalf("expected a TSI index: %v", err)
+	}
+
+	// Shards already migrated are skipped.
+	stdout.Reset()
+	cmd = NewCommand()
+	cmd.Stdout = &stdout
+	if err := cmd.Run([]string{"-datadir", dataDir, "-waldir", walDir}); err != nil {
+		t.Fatal(err)
+	} else if out := stdout.String(); !strings.Contains(out, "shard 1 already has a TSI index, skipping") || !strings.Contains(out, "Migrated 0 shards.") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+
+	stdout.Reset()
+	cmd = NewCommand()
+	cmd.Stdout = &stdout
+	if err := cmd.Run([]string{"-datadir", dataDir, "-waldir", walDir, "-rollback"}); err != nil {
+		t.Fatal(err)
+	} else if out := stdout.String(); !strings.Contains(out, "shard 1: removed the TSI index") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if _, err := os.Stat(filepath.Join(shardPath, "index")); !os.IsNotExist(err) {
+		t.Fatalf("expected the TSI index to be removed: %v", err)
+	}
+}
+
+// Ensure shards whose directory is not a shard ID, and the series file, are
+// not taken for shards.
+func TestCommand_Shards(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, path := range []string{
+		filepath.Join("db0", "rp0", "1"),
+		filepath.Join("db0", "rp0", "tmp"),
+		filepath.Join("db0", "rp1", "2"),
+		filepath.Join("db0", "_series", "00"),
+		filepath.Join("db1", "rp0", "3"),
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, path), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := NewCommand()
+	cmd.dataDir, cmd.walDir = dir, "wal"
+	cmd.databaseFilter = "db0"
+	databases, err := cmd.shards()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(databases) != 1 || len(databases["db0"]) != 2 {
+		t.Fatalf("unexpected shards: %+v", databases)
+	}
+	for _, sh := range databases["db0"] {
+		if exp := filepath.Join("wal", "db0", filepath.Base(filepath.Dir(sh.path)), filepath.Base(sh.path)); sh.walPath != exp {
+			t.Fatalf("unexpected WAL path: %s", sh.walPath)
+		}
+	}
+
+	cmd.retentionFilter = "rp1"
+	if databases, err := cmd.shards(); err != nil {
+		t.Fatal(err)
+	} else if len(databases["db0"]) != 1 || databases["db0"][0].id != 2 {
+		t.Fatalf("unexpected shards: %+v", databases)
+	}
+}
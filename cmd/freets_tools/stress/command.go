@@ -0,0 +1,247 @@
+package stress
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	client "github.com/freetsdb/freetsdb/client/v2"
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+// Command represents the program execution for "freets_tools stress".
+type Command struct {
+	// Standard input/output, overridden for testing.
+	Stderr io.Writer
+	Stdout io.Writer
+
+	host           string
+	username       string
+	password       string
+	database       string
+	measurement    string
+	series         int
+	batchSize      int
+	writers        int
+	queriers       int
+	query          string
+	duration       time.Duration
+	reportInterval time.Duration
+	noCreate       bool
+
+	client client.Client
+	writes *histogram
+	reads  *histogram
+}
+
+// NewCommand returns a new instance of the stress Command.
+func NewCommand() *Command {
+	return &Command{
+		Stderr: os.Stderr,
+		Stdout: os.Stdout,
+	}
+}
+
+// Run executes the stress command using the specified args.
+func (cmd *Command) Run(args []string) (err error) {
+	if err := cmd.parseFlags(args); err != nil {
+		return err
+	}
+
+	cmd.client, err = client.NewHTTPClient(client.HTTPConfig{
+		Addr:     cmd.host,
+		Username: cmd.username,
+		Password: cmd.password,
+	})
+	if err != nil {
+		return err
+	}
+	defer cmd.client.Close()
+
+	if _, _, err := cmd.client.Ping(0); err != nil {
+		return fmt.Errorf("ping %s: %s", cmd.host, err)
+	}
+
+	if !cmd.noCreate {
+		q := client.NewQuery("CREATE DATABASE "+influxql.QuoteIdent(cmd.database), "", "")
+		if resp, err := cmd.client.Query(q); err != nil {
+			return err
+		} else if err := resp.Error(); err != nil {
+			return err
+		}
+	}
+
+	cmd.writes, cmd.reads = newHistogram(), newHistogram()
+	fmt.Fprintf(cmd.Stderr, "stressing %s for %s with %d writers and %d queriers\n", cmd.host, cmd.duration, cmd.writers, cmd.queriers)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < cmd.writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cmd.writeLoop(i, done)
+		}(i)
+	}
+	for i := 0; i < cmd.queriers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cmd.queryLoop(done)
+		}()
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(cmd.reportInterval)
+	timer := time.NewTimer(cmd.duration)
+	for running := true; running; {
+		select {
+		case <-ticker.C:
+			cmd.printProgress(time.Since(start))
+		case <-timer.C:
+			running = false
+		}
+	}
+	ticker.Stop()
+	close(done)
+	wg.Wait()
+
+	cmd.printReport(time.Since(start))
+	return nil
+}
+
+// writeLoop writes batches until done is closed. Each writer starts at a
+// different series so that the writers cover all the series between them.
+func (cmd *Command) writeLoop(id int, done <-chan struct{}) {
+	next := id * cmd.series / cmd.writers
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		bp, _ := client.NewBatchPoints(client.BatchPointsConfig{Database: cmd.database})
+		now := time.Now()
+		for j := 0; j < cmd.batchSize; j++ {
+			// Points of the same series in a batch are a nanosecond apart so
+			// that they do not overwrite each other.
+			n := next + j
+			t := now.Add(time.Duration(n / cmd.series))
+			pt, err := client.NewPoint(cmd.measurement,
+				map[string]string{"series": "series" + strconv.Itoa(n%cmd.series)},
+				map[string]interface{}{"value": float64(n)},
+				t,
+			)
+			if err != nil {
+				cmd.writes.record(0, 0, err)
+				return
+			}
+			bp.AddPoint(pt)
+		}
+		next = (next + cmd.batchSize) % cmd.series
+
+		begin := time.Now()
+		err := cmd.client.Write(bp)
+		cmd.writes.record(time.Since(begin), cmd.batchSize, err)
+	}
+}
+
+// queryLoop runs the query until done is closed.
+func (cmd *Command) queryLoop(done <-chan struct{}) {
+	q := client.NewQuery(cmd.query, cmd.database, "")
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		begin := time.Now()
+		resp, err := cmd.client.Query(q)
+		if err == nil {
+			err = resp.Error()
+		}
+		cmd.reads.record(time.Since(begin), 0, err)
+	}
+}
+
+// printProgress prints a line of the results so far.
+func (cmd *Command) printProgress(elapsed time.Duration) {
+	w, r := cmd.writes.snapshot(), cmd.reads.snapshot()
+	fmt.Fprintf(cmd.Stderr, "%s: %.0f points/sec, write p99 %s (%.2f%% errors)",
+		elapsed.Truncate(time.Second), float64(w.points)/elapsed.Seconds(), w.p99, 100*w.errorRate())
+	if cmd.queriers > 0 {
+		fmt.Fprintf(cmd.Stderr, ", query p99 %s (%.2f%% errors)", r.p99, 100*r.errorRate())
+	}
+	fmt.Fprintln(cmd.Stderr)
+}
+
+// printReport prints the final results.
+func (cmd *Command) printReport(elapsed time.Duration) {
+	w := cmd.writes.snapshot()
+	fmt.Fprintf(cmd.Stdout, "Writes: %d requests, %d points in %s\n", w.count, w.points, elapsed.Truncate(time.Millisecond))
+	fmt.Fprintf(cmd.Stdout, "  points/sec: %.0f\n", float64(w.points)/elapsed.Seconds())
+	cmd.printSummary(w)
+
+	if cmd.queriers > 0 {
+		r := cmd.reads.snapshot()
+		fmt.Fprintf(cmd.Stdout, "\nQueries: %d requests in %s\n", r.count, elapsed.Truncate(time.Millisecond))
+		fmt.Fprintf(cmd.Stdout, "  queries/sec: %.1f\n", float64(r.count)/elapsed.Seconds())
+		cmd.printSummary(r)
+	}
+}
+
+func (cmd *Command) printSummary(s summary) {
+	fmt.Fprintf(cmd.Stdout, "  errors: %d (%.2f%%)\n", s.errors, 100*s.errorRate())
+	fmt.Fprintf(cmd.Stdout, "  latency: p50 %s, p95 %s, p99 %s\n", s.p50, s.p95, s.p99)
+	s.writeHistogram(cmd.Stdout)
+}
+
+func (cmd *Command) parseFlags(args []string) error {
+	fs := flag.NewFlagSet("stress", flag.ContinueOnError)
+	fs.StringVar(&cmd.host, "host", "http://localhost:8086", "URL of the server")
+	fs.StringVar(&cmd.username, "username", "", "Username to connect to the server")
+	fs.StringVar(&cmd.password, "password", "", "Password to connect to the server")
+	fs.StringVar(&cmd.database, "database", "stress", "Database to write to and query")
+	fs.StringVar(&cmd.measurement, "measurement", "stress", "Measurement to write to")
+	fs.BoolVar(&cmd.noCreate, "no-create", false, "Do not create the database")
+	fs.IntVar(&cmd.series, "series", 10000, "Number of series written")
+	fs.IntVar(&cmd.batchSize, "batch-size", 5000, "Number of points per write")
+	fs.IntVar(&cmd.writers, "writers", 4, "Number of concurrent writers")
+	fs.IntVar(&cmd.queriers, "queriers", 0, "Number of concurrent queriers")
+	fs.StringVar(&cmd.query, "query", "SELECT count(value) FROM stress WHERE time > now() - 1m", "Query run by the queriers")
+	fs.DurationVar(&cmd.duration, "duration", time.Minute, "How long to run for")
+	fs.DurationVar(&cmd.reportInterval, "report-interval", 10*time.Second, "Interval between progress reports")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch {
+	case cmd.database == "":
+		return errors.New("database is required")
+	case cmd.series < 1:
+		return errors.New("series must be at least 1")
+	case cmd.batchSize < 1:
+		return errors.New("batch-size must be at least 1")
+	case cmd.writers < 0 || cmd.queriers < 0:
+		return errors.New("writers and queriers must not be negative")
+	case cmd.writers == 0 && cmd.queriers == 0:
+		return errors.New("at least one writer or querier is required")
+	case cmd.duration <= 0 || cmd.reportInterval <= 0:
+		return errors.New("duration and report-interval must be positive")
+	}
+
+	if cmd.queriers > 0 {
+		if _, err := influxql.ParseQuery(cmd.query); err != nil {
+			return fmt.Errorf("invalid query: %s", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package stress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/freetsdb/freetsdb/pkg/tdigest"
+)
+
+// bucketBounds are the upper bounds of the buckets of a latency histogram.
+// Latencies above the last bound fall into an overflow bucket.
+var bucketBounds = []time.Duration{
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	20 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+}
+
+// histogram records the latencies and errors of requests. It is safe for
+// concurrent use.
+type histogram struct {
+	mu      sync.Mutex
+	digest  *tdigest.TDigest
+	buckets []int64
+	count   int64
+	errors  int64
+	points  int64
+}
+
+func newHistogram() *histogram {
+	return &histogram{
+		digest:  tdigest.New(),
+		buckets: make([]int64, len(bucketBounds)+1),
+	}
+}
+
+// record records a request that took d and carried n points. A failed
+// request counts as an error and its latency is not recorded.
+func (h *histogram) record(d time.Duration, n int, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.count++
+	if err != nil {
+		h.errors++
+		return
+	}
+	h.points += int64(n)
+	h.digest.Add(float64(d), 1)
+
+	i := 0
+	for i < len(bucketBounds) && d > bucketBounds[i] {
+		i++
+	}
+	h.buckets[i]++
+}
+
+// summary is a snapshot of a histogram.
+type summary struct {
+	count, errors, points int64
+	p50, p95, p99         time.Duration
+	buckets               []int64
+}
+
+// snapshot returns a summary of the requests recorded so far.
+func (h *histogram) snapshot() summary {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := summary{
+		count:   h.count,
+		errors:  h.errors,
+		points:  h.points,
+		buckets: append([]int64(nil), h.buckets...),
+	}
+	if h.count > h.errors {
+		s.p50 = time.Duration(h.digest.Quantile(0.50))
+		s.p95 = time.Duration(h.digest.Quantile(0.95))
+		s.p99 = time.Duration(h.digest.Quantile(0.99))
+	}
+	return s
+}
+
+// errorRate returns the fraction of requests that failed.
+func (s summary) errorRate() float64 {
+	if s.count == 0 {
+		return 0
+	}
+	return float64(s.errors) / float64(s.count)
+}
+
+// writeHistogram writes the buckets of s to w as a bar chart.
+func (s summary) writeHistogram(w io.Writer) {
+	const width = 40
+
+	var max int64
+	for _, n := range s.buckets {
+		if n > max {
+			max = n
+		}
+	}
+	if max == 0 {
+		return
+	}
+
+	for i, n := range s.buckets {
+		label := "> " + bucketBounds[len(bucketBounds)-1].String()
+		if i < len(bucketBounds) {
+			label = "<= " + bucketBounds[i].String()
+		}
+		fmt.Fprintf(w, "  %8s | %-*s %d\n", label, width, strings.Repeat("#", int(n*width/max)), n)
+	}
+}
@@ -0,0 +1,34 @@
+package stress
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	h := newHistogram()
+	for i := 1; i <= 100; i++ {
+		h.record(time.Duration(i)*time.Millisecond, 10, nil)
+	}
+	h.record(time.Second, 10, errors.New("timeout"))
+
+	s := h.snapshot()
+	if s.count != 101 || s.errors != 1 || s.points != 1000 {
+		t.Fatalf("unexpected counts: count=%d errors=%d points=%d", s.count, s.errors, s.points)
+	}
+	if s.p50 < 45*time.Millisecond || s.p50 > 55*time.Millisecond {
+		t.Errorf("unexpected p50: %s", s.p50)
+	}
+	if s.p99 < 95*time.Millisecond || s.p99 > 100*time.Millisecond {
+		t.Errorf("unexpected p99: %s", s.p99)
+	}
+
+	// 1ms, 2ms, 3-5ms, 6-10ms, 11-20ms, 21-50ms, 51-100ms
+	exp := []int64{1, 1, 3, 5, 10, 30, 50, 0, 0, 0, 0, 0, 0}
+	for i := range exp {
+		if s.buckets[i] != exp[i] {
+			t.Fatalf("unexpected buckets: got=%v exp=%v", s.buckets, exp)
+		}
+	}
+}
@@ -120,6 +120,10 @@ func (cmd *Command) Run(args ...string) error {
 
 	}
 
+	if cmd.portable && cmd.isBackup && err == nil {
+		cmd.backupSeriesFiles()
+	}
+
 	if cmd.portable {
 		filename := cmd.portableFileBase + ".manifest"
 		if err := cmd.manifest.Save(filepath.Join(cmd.path, filename)); err != nil {
@@ -327,6 +331,63 @@ func (cmd *Command) backupShard(db, rp, sid string) error {
 
 }
 
+// backupSeriesFiles backs up the series files of the databases of the shards
+// backed up, after the shards so that they have the series of their TSI
+// indexes. The indexes are rebuilt on restore without them, so the databases
+// whose series file could not be backed up are only reported.
+func (cmd *Command) backupSeriesFiles() {
+	seen := make(map[string]bool)
+	for _, e := range cmd.manifest.Files {
+		if seen[e.Database] {
+			continue
+		}
+		seen[e.Database] = true
+
+		if err := cmd.backupSeriesFile(e.Database); err != nil {
+			cmd.StderrLogger.Printf("error (%s) when backing up the series file of db: %s. its indexes will be rebuilt on restore", err, e.Database)
+		}
+	}
+}
+
+// backupSeriesFile backs up the series file of db to a compressed file.
+func (cmd *Command) backupSeriesFile(db string) error {
+	filePrefix := cmd.portableFileBase + "." + db + ".series"
+	filename := filePrefix + ".tar.gz"
+	path := filepath.Join(cmd.path, filename)
+	cmd.StdoutLogger.Printf("backing up series file of db=%v to %s", db, path)
+
+	out, err := os.OpenFile(path+backup_util.Suffix, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path + backup_util.Suffix)
+	defer out.Close()
+
+	zw := gzip.NewWriter(out)
+	zw.Name = filePrefix + ".tar"
+	cw := backup_util.CountingWriter{Writer: zw}
+	if err := snapshotter.NewClient(cmd.host).SeriesFileBackup(db, &cw); err != nil {
+		return err
+	} else if cw.Total == 0 {
+		return errors.New("no series file received")
+	} else if err := zw.Close(); err != nil {
+		return err
+	} else if err := out.Close(); err != nil {
+		return err
+	} else if err := os.Rename(path+backup_util.Suffix, path); err != nil {
+		return err
+	}
+
+	cmd.manifest.SeriesFiles = append(cmd.manifest.SeriesFiles, backup_util.Entry{
+		Database:     db,
+		FileName:     filename,
+		Size:         cw.Total,
+		LastModified: time.Now().UnixNano(),
+	})
+	cmd.BackupFiles = append(cmd.BackupFiles, filename)
+	return nil
+}
+
 // backupDatabase will request the database information from the server and then backup
 // every shard in every retention policy in the database. Each shard will be written to a separate file.
 func (cmd *Command) backupDatabase() error {
@@ -91,6 +91,10 @@ type Manifest struct {
 	Limited bool      `json:"limited"`
 	Files   []Entry   `json:"files"`
 
+	// SeriesFiles are the series files of the databases of the shards, which
+	// let the TSI indexes of the shards be restored without a rebuild.
+	SeriesFiles []Entry `json:"seriesFiles,omitempty"`
+
 	// If limited is true, then one (or all) of the following fields will be set
 
 	Database string `json:"database,omitempty"`
@@ -132,6 +136,9 @@ func (m *Manifest) Size() int64 {
 	for _, f := range m.Files {
 		size += f.Size
 	}
+	for _, f := range m.SeriesFiles {
+		size += f.Size
+	}
 	return size
 }
 
@@ -202,6 +209,41 @@ func LoadIncremental(dir string) (*MetaEntry, map[uint64]*Entry, error) {
 	return &metaEntry, shards, nil
 }
 
+// LoadSeriesFiles returns the series files of the most recent manifest files
+// of a given directory listing them, by database.
+func LoadSeriesFiles(dir string) (map[string]*Entry, error) {
+	manifests, err := filepath.Glob(filepath.Join(dir, "*.manifest"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(manifests)))
+
+	seriesFiles := make(map[string]*Entry)
+	for _, fileName := range manifests {
+		b, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		var manifest Manifest
+		if err := json.Unmarshal(b, &manifest); err != nil {
+			return nil, fmt.Errorf("read manifest: %v", err)
+		}
+
+		// Series ids are never reused, so the most recent series file of a
+		// database has the series of the shards of the earlier backups too.
+		for i := range manifest.SeriesFiles {
+			e := manifest.SeriesFiles[i]
+			if _, ok := seriesFiles[e.Database]; ok {
+				continue
+			} else if _, err := os.Stat(filepath.Join(dir, e.FileName)); err != nil {
+				continue
+			}
+			seriesFiles[e.Database] = &e
+		}
+	}
+	return seriesFiles, nil
+}
+
 type CountingWriter struct {
 	io.Writer
 	Total int64 // Total # of bytes transferred
@@ -36,6 +36,8 @@ The commands are:
     backup               downloads a snapshot of a data node and saves it to disk
     config               display the default configuration
     help                 display this help message
+    meta                 exports or applies a declarative document of the cluster metadata
+    reindex-shard        rebuilds the index of a shard while it stays online
     restore              uses a snapshot of a data node to rebuild a cluster
     run                  run node with existing configuration
     version              displays the FreeTSDB version
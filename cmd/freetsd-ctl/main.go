@@ -11,7 +11,9 @@ import (
 	"github.com/freetsdb/freetsdb/cmd"
 	"github.com/freetsdb/freetsdb/cmd/freetsd-ctl/backup"
 	"github.com/freetsdb/freetsdb/cmd/freetsd-ctl/help"
+	"github.com/freetsdb/freetsdb/cmd/freetsd-ctl/metadata"
 	"github.com/freetsdb/freetsdb/cmd/freetsd-ctl/node"
+	"github.com/freetsdb/freetsdb/cmd/freetsd-ctl/reindex"
 	"github.com/freetsdb/freetsdb/cmd/freetsd-ctl/restore"
 )
 
@@ -82,6 +84,16 @@ func (m *Main) Run(args ...string) error {
 		if err := name.Run(args...); err != nil {
 			return fmt.Errorf("restore: %s", err)
 		}
+	case "meta":
+		name := metadata.NewCommand()
+		if err := name.Run(args...); err != nil {
+			return fmt.Errorf("meta: %s", err)
+		}
+	case "reindex-shard":
+		name := reindex.NewCommand()
+		if err := name.Run(args...); err != nil {
+			return fmt.Errorf("reindex-shard: %s", err)
+		}
 	case "add-meta", "remove-meta", "add-data", "remove-data", "show":
 		cmd := node.NewCommand(name)
 		if err := cmd.Run(args...); err != nil {
@@ -0,0 +1,416 @@
+package metadata
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// Client is the part of the meta client used to apply a document.
+type Client interface {
+	CreateDatabaseWithShardKey(name string, rpi *meta.RetentionPolicyInfo, shardKey []string) (*meta.DatabaseInfo, error)
+	CreateRetentionPolicy(database string, rpi *meta.RetentionPolicyInfo) (*meta.RetentionPolicyInfo, error)
+	UpdateRetentionPolicy(database, name string, rpu *meta.RetentionPolicyUpdate) error
+	DropRetentionPolicy(database, name string) error
+	SetDefaultRetentionPolicy(database, name string) error
+	CreateSubscriptionWithFilter(database, rp string, si meta.SubscriptionInfo) error
+	DropSubscription(database, rp, name string) error
+	CreateContinuousQuery(database, name, query string) error
+	DropContinuousQuery(database, name string) error
+	CreateRole(name string) error
+	DropRole(name string) error
+	SetRolePrivilege(role, database string, p influxql.Privilege) error
+	CreateUser(name, password string, admin bool) (*meta.UserInfo, error)
+	DropUser(name string) error
+	SetAdminPrivilege(username string, admin bool) error
+	SetPrivilege(username, database string, p influxql.Privilege) error
+	SetUserRole(username, role string, member bool) error
+}
+
+// Change is a change of the metadata of a cluster that brings it closer to
+// a document.
+type Change struct {
+	Description string
+	apply       func(c Client) error
+}
+
+// Apply makes the change with c.
+func (ch Change) Apply(c Client) error {
+	if err := ch.apply(c); err != nil {
+		return fmt.Errorf("%s: %s", ch.Description, err)
+	}
+	return nil
+}
+
+// Plan returns the changes that make the metadata in data match doc, in the
+// order they must be applied. Objects of data that doc does not describe are
+// left alone unless prune is set, in which case they are dropped; databases
+// are never dropped, as that would delete their data. A plan computed from
+// the export of data is empty.
+func Plan(data *meta.Data, doc *Document, prune bool) ([]Change, error) {
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+
+	var p planner
+	for _, db := range doc.Databases {
+		if err := p.planDatabase(data.Database(db.Name), db, prune); err != nil {
+			return nil, err
+		}
+	}
+	p.planRoles(data, doc, prune)
+	if err := p.planUsers(data, doc, prune); err != nil {
+		return nil, err
+	}
+	return p.changes, nil
+}
+
+// planner accumulates the changes of a plan.
+type planner struct {
+	changes []Change
+}
+
+func (p *planner) add(fn func(c Client) error, format string, args ...interface{}) {
+	p.changes = append(p.changes, Change{Description: fmt.Sprintf(format, args...), apply: fn})
+}
+
+// planDatabase plans the changes of database db, whose current state is di,
+// or nil if it does not exist. The shard key of a database cannot be changed.
+func (p *planner) planDatabase(di *meta.DatabaseInfo, db Database, prune bool) error {
+	name := db.Name
+	if di == nil {
+		// Create the database with its default retention policy, if it has
+		// one, so that no other default policy is created with it.
+		var rpi *meta.RetentionPolicyInfo
+		for _, rp := range db.RetentionPolicies {
+			if rp.Name == db.DefaultRetentionPolicy {
+				rpi = retentionPolicyInfo(rp)
+			}
+		}
+		p.add(func(c Client) error {
+			_, err := c.CreateDatabaseWithShardKey(name, rpi, db.ShardKey)
+			return err
+		}, "create database %s", name)
+		di = &meta.DatabaseInfo{Name: name, ShardKey: db.ShardKey}
+		if rpi != nil {
+			di.DefaultRetentionPolicy = rpi.Name
+			di.RetentionPolicies = []meta.RetentionPolicyInfo{*rpi}
+		}
+	} else if !equalStrings(di.ShardKey, db.ShardKey) {
+		return fmt.Errorf("database %q: %s", name, meta.ErrShardKeyConflict)
+	}
+
+	for _, rp := range db.RetentionPolicies {
+		p.planRetentionPolicy(name, di.RetentionPolicy(rp.Name), rp, prune)
+	}
+	if rp := db.DefaultRetentionPolicy; rp != "" && rp != di.DefaultRetentionPolicy {
+		p.add(func(c Client) error { return c.SetDefaultRetentionPolicy(name, rp) }, "set default retention policy of database %s to %s", name, rp)
+	}
+
+	cqs := make(map[string]ContinuousQuery)
+	for _, cq := range db.ContinuousQueries {
+		cqs[cq.Name] = cq
+	}
+	for _, cqi := range di.ContinuousQueries {
+		cq, ok := cqs[cqi.Name]
+		if ok && cq.Query == cqi.Query {
+			delete(cqs, cqi.Name)
+			continue
+		} else if !ok && !prune {
+			continue
+		}
+		// Continuous queries cannot be altered, only recreated.
+		cqName := cqi.Name
+		p.add(func(c Client) error { return c.DropContinuousQuery(name, cqName) }, "drop continuous query %s on %s", cqName, name)
+	}
+	for _, cq := range db.ContinuousQueries {
+		if _, ok := cqs[cq.Name]; !ok {
+			continue
+		}
+		cq := cq
+		p.add(func(c Client) error { return c.CreateContinuousQuery(name, cq.Name, cq.Query) }, "create continuous query %s on %s", cq.Name, name)
+	}
+
+	if !prune {
+		return nil
+	}
+	for _, rpi := range di.RetentionPolicies {
+		if db.retentionPolicy(rpi.Name) == nil {
+			rpName := rpi.Name
+			p.add(func(c Client) error { return c.DropRetentionPolicy(name, rpName) }, "drop retention policy %s on %s", rpName, name)
+		}
+	}
+	return nil
+}
+
+// retentionPolicy returns the retention policy of db with the given name.
+func (db Database) retentionPolicy(name string) *RetentionPolicy {
+	for i := range db.RetentionPolicies {
+		if db.RetentionPolicies[i].Name == name {
+			return &db.RetentionPolicies[i]
+		}
+	}
+	return nil
+}
+
+// planRetentionPolicy plans the changes of retention policy rp of database,
+// whose current state is rpi, or nil if it does not exist.
+func (p *planner) planRetentionPolicy(database string, rpi *meta.RetentionPolicyInfo, rp RetentionPolicy, prune bool) {
+	if rpi == nil {
+		info := retentionPolicyInfo(rp)
+		p.add(func(c Client) error {
+			_, err := c.CreateRetentionPolicy(database, info)
+			return err
+		}, "create retention policy %s on %s", rp.Name, database)
+		rpi = &meta.RetentionPolicyInfo{Name: rp.Name}
+	} else if rpu := retentionPolicyUpdate(rpi, rp); rpu != nil {
+		p.add(func(c Client) error { return c.UpdateRetentionPolicy(database, rp.Name, rpu) }, "alter retention policy %s on %s", rp.Name, database)
+	}
+
+	subs := make(map[string]Subscription)
+	for _, s := range rp.Subscriptions {
+		subs[s.Name] = s
+	}
+	for _, si := range rpi.Subscriptions {
+		s, ok := subs[si.Name]
+		if ok && s.equal(si) {
+			delete(subs, si.Name)
+			continue
+		} else if !ok && !prune {
+			continue
+		}
+		// Subscriptions cannot be altered, only recreated.
+		subName := si.Name
+		p.add(func(c Client) error { return c.DropSubscription(database, rp.Name, subName) }, "drop subscription %s on %s.%s", subName, database, rp.Name)
+	}
+	for _, s := range rp.Subscriptions {
+		if _, ok := subs[s.Name]; !ok {
+			continue
+		}
+		si := s.info()
+		p.add(func(c Client) error { return c.CreateSubscriptionWithFilter(database, rp.Name, si) }, "create subscription %s on %s.%s", s.Name, database, rp.Name)
+	}
+}
+
+// info returns the info of a new subscription s.
+func (s Subscription) info() meta.SubscriptionInfo {
+	return meta.SubscriptionInfo{
+		Name:          s.Name,
+		Mode:          s.Mode,
+		Destinations:  s.Destinations,
+		Measurements:  s.Measurements,
+		Condition:     s.Condition,
+		StripTags:     s.StripTags,
+		ResampleEvery: time.Duration(s.ResampleEvery),
+	}
+}
+
+// equal returns true if subscription si is the same as s.
+func (s Subscription) equal(si meta.SubscriptionInfo) bool {
+	return s.Mode == si.Mode &&
+		equalStrings(s.Destinations, si.Destinations) &&
+		equalStrings(s.Measurements, si.Measurements) &&
+		s.Condition == si.Condition &&
+		equalStrings(s.StripTags, si.StripTags) &&
+		time.Duration(s.ResampleEvery) == si.ResampleEvery
+}
+
+// retentionPolicyInfo returns the info of a new retention policy rp.
+func retentionPolicyInfo(rp RetentionPolicy) *meta.RetentionPolicyInfo {
+	rpi := meta.NewRetentionPolicyInfo(rp.Name)
+	rpi.Duration = time.Duration(rp.Duration)
+	if rp.ReplicaN > 0 {
+		rpi.ReplicaN = rp.ReplicaN
+	}
+	rpi.ShardGroupDuration = time.Duration(rp.ShardGroupDuration)
+	rpi.FutureWriteLimit = time.Duration(rp.FutureWriteLimit)
+	rpi.PastWriteLimit = time.Duration(rp.PastWriteLimit)
+	return rpi
+}
+
+// retentionPolicyUpdate returns the update of rpi to rp, or nil if rpi
+// already matches it. The shard group duration and replication factor are
+// only compared if rp sets them.
+func retentionPolicyUpdate(rpi *meta.RetentionPolicyInfo, rp RetentionPolicy) *meta.RetentionPolicyUpdate {
+	var rpu meta.RetentionPolicyUpdate
+	changed := false
+	if d := time.Duration(rp.Duration); d != rpi.Duration {
+		rpu.SetDuration(d)
+		changed = true
+	}
+	if d := time.Duration(rp.ShardGroupDuration); d != 0 && d != rpi.ShardGroupDuration {
+		rpu.SetShardGroupDuration(d)
+		changed = true
+	}
+	if rp.ReplicaN > 0 && rp.ReplicaN != rpi.ReplicaN {
+		rpu.SetReplicaN(rp.ReplicaN)
+		changed = true
+	}
+	if d := time.Duration(rp.FutureWriteLimit); d != rpi.FutureWriteLimit {
+		rpu.SetFutureWriteLimit(d)
+		changed = true
+	}
+	if d := time.Duration(rp.PastWriteLimit); d != rpi.PastWriteLimit {
+		rpu.SetPastWriteLimit(d)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return &rpu
+}
+
+// planRoles plans the changes of the roles of doc.
+func (p *planner) planRoles(data *meta.Data, doc *Document, prune bool) {
+	for _, r := range doc.Roles {
+		name := r.Name
+		ri := data.Role(name)
+		if ri == nil {
+			p.add(func(c Client) error { return c.CreateRole(name) }, "create role %s", name)
+			ri = &meta.RoleInfo{Name: name}
+		}
+		p.planPrivileges(ri.Privileges, r.Privileges, prune, func(c Client, db string, priv influxql.Privilege) error {
+			return c.SetRolePrivilege(name, db, priv)
+		}, "role "+name)
+	}
+
+	if !prune {
+		return
+	}
+	for _, ri := range data.Roles {
+		if doc.role(ri.Name) == nil {
+			name := ri.Name
+			p.add(func(c Client) error { return c.DropRole(name) }, "drop role %s", name)
+		}
+	}
+}
+
+// role returns the role of doc with the given name.
+func (doc *Document) role(name string) *Role {
+	for i := range doc.Roles {
+		if doc.Roles[i].Name == name {
+			return &doc.Roles[i]
+		}
+	}
+	return nil
+}
+
+// planUsers plans the changes of the users of doc. Users that do not exist
+// must have a password.
+func (p *planner) planUsers(data *meta.Data, doc *Document, prune bool) error {
+	users := make(map[string]*meta.UserInfo, len(data.Users))
+	for i := range data.Users {
+		users[data.Users[i].Name] = &data.Users[i]
+	}
+
+	for _, u := range doc.Users {
+		u := u
+		ui := users[u.Name]
+		if ui == nil {
+			if u.Password == "" {
+				return fmt.Errorf("user %q does not exist and has no password", u.Name)
+			}
+			p.add(func(c Client) error {
+				_, err := c.CreateUser(u.Name, u.Password, u.Admin)
+				return err
+			}, "create user %s", u.Name)
+			ui = &meta.UserInfo{Name: u.Name, Admin: u.Admin}
+		}
+		delete(users, u.Name)
+
+		if ui.Admin != u.Admin {
+			p.add(func(c Client) error { return c.SetAdminPrivilege(u.Name, u.Admin) }, "set admin privilege of user %s to %t", u.Name, u.Admin)
+		}
+		p.planPrivileges(ui.Privileges, u.Privileges, prune, func(c Client, db string, priv influxql.Privilege) error {
+			return c.SetPrivilege(u.Name, db, priv)
+		}, "user "+u.Name)
+
+		member := make(map[string]bool, len(ui.Roles))
+		for _, r := range ui.Roles {
+			member[r] = true
+		}
+		for _, r := range u.Roles {
+			if member[r] {
+				delete(member, r)
+				continue
+			}
+			role := r
+			p.add(func(c Client) error { return c.SetUserRole(u.Name, role, true) }, "add user %s to role %s", u.Name, role)
+		}
+		if prune {
+			for _, r := range ui.Roles {
+				if !member[r] {
+					continue
+				}
+				role := r
+				p.add(func(c Client) error { return c.SetUserRole(u.Name, role, false) }, "remove user %s from role %s", u.Name, role)
+			}
+		}
+	}
+
+	if prune {
+		for i := range data.Users {
+			if _, ok := users[data.Users[i].Name]; ok {
+				name := data.Users[i].Name
+				p.add(func(c Client) error { return c.DropUser(name) }, "drop user %s", name)
+			}
+		}
+	}
+	return nil
+}
+
+// planPrivileges plans the changes of the privileges current of a user or
+// role to want. Privileges on databases that want does not list are only
+// revoked if prune is set.
+func (p *planner) planPrivileges(current map[string]influxql.Privilege, want map[string]Privilege, prune bool, set func(c Client, db string, priv influxql.Privilege) error, owner string) {
+	for _, db := range sortedKeys(want) {
+		db, priv := db, influxql.Privilege(want[db])
+		if current[db] == priv {
+			continue
+		}
+		p.add(func(c Client) error { return set(c, db, priv) }, "grant %s on %s to %s", priv, db, owner)
+	}
+
+	if !prune {
+		return
+	}
+	dbs := make([]string, 0, len(current))
+	for db := range current {
+		dbs = append(dbs, db)
+	}
+	sort.Strings(dbs)
+	for _, db := range dbs {
+		priv := current[db]
+		if _, ok := want[db]; ok || priv == influxql.NoPrivileges {
+			continue
+		}
+		p.add(func(c Client) error { return set(c, db, influxql.NoPrivileges) }, "revoke %s on %s from %s", priv, db, owner)
+	}
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]Privilege) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// equalStrings returns true if a and b hold the same strings in the same
+// order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
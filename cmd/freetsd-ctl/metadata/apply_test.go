@@ -0,0 +1,146 @@
+package metadata
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"gopkg.in/yaml.v2"
+)
+
+func testData() *meta.Data {
+	return &meta.Data{
+		Databases: []meta.DatabaseInfo{{
+			Name:                   "db0",
+			DefaultRetentionPolicy: "rp0",
+			RetentionPolicies: []meta.RetentionPolicyInfo{{
+				Name:               "rp0",
+				ReplicaN:           2,
+				Duration:           7 * 24 * time.Hour,
+				ShardGroupDuration: 24 * time.Hour,
+				Subscriptions: []meta.SubscriptionInfo{
+					{Name: "s0", Mode: "ANY", Destinations: []string{"udp://h0:9090"}},
+				},
+			}},
+			ContinuousQueries: []meta.ContinuousQueryInfo{
+				{Name: "cq0", Query: `CREATE CONTINUOUS QUERY cq0 ON db0 BEGIN SELECT mean(value) INTO cpu_1h FROM cpu GROUP BY time(1h) END`},
+			},
+		}},
+		Roles: []meta.RoleInfo{
+			{Name: "readers", Privileges: map[string]influxql.Privilege{"db0": influxql.ReadPrivilege}},
+		},
+		Users: []meta.UserInfo{
+			{Name: "admin", Hash: "x", Admin: true},
+			{Name: "bob", Hash: "y", Privileges: map[string]influxql.Privilege{"db0": influxql.WritePrivilege}, Roles: []string{"readers"}},
+		},
+	}
+}
+
+// Ensure an exported document survives encoding and applies no changes.
+func TestPlan_Export(t *testing.T) {
+	data := testData()
+
+	b, err := yaml.Marshal(Export(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := decode(b, "meta.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(doc, Export(data)) {
+		t.Fatalf("unexpected document:\n%s", b)
+	}
+
+	changes, err := Plan(data, doc, true)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(changes) != 0 {
+		t.Fatalf("unexpected changes: %v", descriptions(changes))
+	}
+}
+
+// Ensure the changes of a document are planned, and pruning is opt-in.
+func TestPlan_Changes(t *testing.T) {
+	doc, err := decode([]byte(`
+databases:
+- name: db0
+  default_retention_policy: rp0
+  retention_policies:
+  - name: rp0
+    duration: 30d
+    replication: 2
+  continuous_queries:
+  - name: cq0
+    query: CREATE CONTINUOUS QUERY cq0 ON db0 BEGIN SELECT max(value) INTO cpu_1h FROM cpu GROUP BY time(1h) END
+- name: db1
+  default_retention_policy: rp1
+  retention_policies:
+  - name: rp1
+    duration: INF
+roles:
+- name: readers
+  privileges:
+    db0: read
+users:
+- name: admin
+  admin: true
+- name: carol
+  password: secret
+  privileges:
+    db1: all
+`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := Plan(testData(), doc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := descriptions(changes), []string{
+		"alter retention policy rp0 on db0",
+		"drop continuous query cq0 on db0",
+		"create continuous query cq0 on db0",
+		"create database db1",
+		"create user carol",
+		"grant ALL PRIVILEGES on db1 to user carol",
+	}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected changes: %v", got)
+	}
+
+	changes, err = Plan(testData(), doc, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := descriptions(changes), []string{
+		"alter retention policy rp0 on db0",
+		"drop subscription s0 on db0.rp0",
+		"drop continuous query cq0 on db0",
+		"create continuous query cq0 on db0",
+		"create database db1",
+		"create user carol",
+		"grant ALL PRIVILEGES on db1 to user carol",
+		"drop user bob",
+	}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected changes: %v", got)
+	}
+}
+
+// Ensure a new user without a password is rejected.
+func TestPlan_UserPasswordRequired(t *testing.T) {
+	doc := &Document{Users: []User{{Name: "dave"}}}
+	if _, err := Plan(testData(), doc, false); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func descriptions(changes []Change) []string {
+	var a []string
+	for _, ch := range changes {
+		a = append(a, ch.Description)
+	}
+	return a
+}
@@ -0,0 +1,271 @@
+package metadata
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// Document is a declarative description of the metadata of a cluster. It is
+// exported with keys in a fixed order, so that two exports can be diffed, and
+// applying it again to the cluster it was exported from changes nothing.
+type Document struct {
+	Databases []Database `json:"databases,omitempty" yaml:"databases,omitempty"`
+	Roles     []Role     `json:"roles,omitempty" yaml:"roles,omitempty"`
+	Users     []User     `json:"users,omitempty" yaml:"users,omitempty"`
+}
+
+// Database describes a database with its retention policies and continuous
+// queries.
+type Database struct {
+	Name                   string            `json:"name" yaml:"name"`
+	DefaultRetentionPolicy string            `json:"default_retention_policy,omitempty" yaml:"default_retention_policy,omitempty"`
+	ShardKey               []string          `json:"shard_key,omitempty" yaml:"shard_key,omitempty"`
+	RetentionPolicies      []RetentionPolicy `json:"retention_policies,omitempty" yaml:"retention_policies,omitempty"`
+	ContinuousQueries      []ContinuousQuery `json:"continuous_queries,omitempty" yaml:"continuous_queries,omitempty"`
+}
+
+// RetentionPolicy describes a retention policy and its subscriptions.
+type RetentionPolicy struct {
+	Name               string         `json:"name" yaml:"name"`
+	Duration           Duration       `json:"duration" yaml:"duration"`
+	ShardGroupDuration Duration       `json:"shard_duration,omitempty" yaml:"shard_duration,omitempty"`
+	ReplicaN           int            `json:"replication,omitempty" yaml:"replication,omitempty"`
+	FutureWriteLimit   Duration       `json:"future_write_limit,omitempty" yaml:"future_write_limit,omitempty"`
+	PastWriteLimit     Duration       `json:"past_write_limit,omitempty" yaml:"past_write_limit,omitempty"`
+	Subscriptions      []Subscription `json:"subscriptions,omitempty" yaml:"subscriptions,omitempty"`
+}
+
+// Subscription describes a subscription of a retention policy.
+type Subscription struct {
+	Name          string   `json:"name" yaml:"name"`
+	Mode          string   `json:"mode" yaml:"mode"`
+	Destinations  []string `json:"destinations" yaml:"destinations"`
+	Measurements  []string `json:"measurements,omitempty" yaml:"measurements,omitempty"`
+	Condition     string   `json:"condition,omitempty" yaml:"condition,omitempty"`
+	StripTags     []string `json:"strip_tags,omitempty" yaml:"strip_tags,omitempty"`
+	ResampleEvery Duration `json:"resample_every,omitempty" yaml:"resample_every,omitempty"`
+}
+
+// ContinuousQuery describes a continuous query of a database.
+type ContinuousQuery struct {
+	Name  string `json:"name" yaml:"name"`
+	Query string `json:"query" yaml:"query"`
+}
+
+// Role describes a role and the privileges it grants on each database.
+type Role struct {
+	Name       string               `json:"name" yaml:"name"`
+	Privileges map[string]Privilege `json:"privileges,omitempty" yaml:"privileges,omitempty"`
+}
+
+// User describes a user. Password hashes are not exported; a password is
+// only used to create a user that does not exist yet.
+type User struct {
+	Name       string               `json:"name" yaml:"name"`
+	Password   string               `json:"password,omitempty" yaml:"password,omitempty"`
+	Admin      bool                 `json:"admin,omitempty" yaml:"admin,omitempty"`
+	Privileges map[string]Privilege `json:"privileges,omitempty" yaml:"privileges,omitempty"`
+	Roles      []string             `json:"roles,omitempty" yaml:"roles,omitempty"`
+}
+
+// Duration is a duration written as in InfluxQL, such as 7d or 1h30m.
+type Duration time.Duration
+
+// MarshalText encodes d as an InfluxQL duration.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(influxql.FormatDuration(time.Duration(d))), nil
+}
+
+// UnmarshalText decodes an InfluxQL duration, or INF for an infinite one.
+func (d *Duration) UnmarshalText(text []byte) error {
+	if strings.EqualFold(string(text), "INF") {
+		*d = 0
+		return nil
+	}
+	v, err := influxql.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %s", text, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Privilege is a privilege on a database: read, write or all.
+type Privilege influxql.Privilege
+
+// MarshalText encodes p as read, write, all or none.
+func (p Privilege) MarshalText() ([]byte, error) {
+	switch influxql.Privilege(p) {
+	case influxql.ReadPrivilege:
+		return []byte("read"), nil
+	case influxql.WritePrivilege:
+		return []byte("write"), nil
+	case influxql.AllPrivileges:
+		return []byte("all"), nil
+	default:
+		return []byte("none"), nil
+	}
+}
+
+// UnmarshalText decodes a privilege encoded by MarshalText.
+func (p *Privilege) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "read":
+		*p = Privilege(influxql.ReadPrivilege)
+	case "write":
+		*p = Privilege(influxql.WritePrivilege)
+	case "all":
+		*p = Privilege(influxql.AllPrivileges)
+	case "none":
+		*p = Privilege(influxql.NoPrivileges)
+	default:
+		return fmt.Errorf("invalid privilege %q: must be read, write, all or none", text)
+	}
+	return nil
+}
+
+// Export returns the document describing the metadata in data.
+func Export(data *meta.Data) *Document {
+	doc := &Document{}
+	for _, di := range data.Databases {
+		db := Database{
+			Name:                   di.Name,
+			DefaultRetentionPolicy: di.DefaultRetentionPolicy,
+			ShardKey:               di.ShardKey,
+		}
+		for _, rpi := range di.RetentionPolicies {
+			rp := RetentionPolicy{
+				Name:               rpi.Name,
+				Duration:           Duration(rpi.Duration),
+				ShardGroupDuration: Duration(rpi.ShardGroupDuration),
+				ReplicaN:           rpi.ReplicaN,
+				FutureWriteLimit:   Duration(rpi.FutureWriteLimit),
+				PastWriteLimit:     Duration(rpi.PastWriteLimit),
+			}
+			for _, si := range rpi.Subscriptions {
+				rp.Subscriptions = append(rp.Subscriptions, Subscription{
+					Name:          si.Name,
+					Mode:          si.Mode,
+					Destinations:  si.Destinations,
+					Measurements:  si.Measurements,
+					Condition:     si.Condition,
+					StripTags:     si.StripTags,
+					ResampleEvery: Duration(si.ResampleEvery),
+				})
+			}
+			db.RetentionPolicies = append(db.RetentionPolicies, rp)
+		}
+		for _, cqi := range di.ContinuousQueries {
+			db.ContinuousQueries = append(db.ContinuousQueries, ContinuousQuery{Name: cqi.Name, Query: cqi.Query})
+		}
+		doc.Databases = append(doc.Databases, db)
+	}
+
+	for _, ri := range data.Roles {
+		doc.Roles = append(doc.Roles, Role{Name: ri.Name, Privileges: privileges(ri.Privileges)})
+	}
+
+	for _, ui := range data.Users {
+		doc.Users = append(doc.Users, User{
+			Name:       ui.Name,
+			Admin:      ui.Admin,
+			Privileges: privileges(ui.Privileges),
+			Roles:      append([]string(nil), ui.Roles...),
+		})
+	}
+
+	doc.sort()
+	return doc
+}
+
+// privileges returns the granted privileges of m.
+func privileges(m map[string]influxql.Privilege) map[string]Privilege {
+	var a map[string]Privilege
+	for db, p := range m {
+		if p == influxql.NoPrivileges {
+			continue
+		}
+		if a == nil {
+			a = make(map[string]Privilege)
+		}
+		a[db] = Privilege(p)
+	}
+	return a
+}
+
+// sort orders every list of the document by name.
+func (doc *Document) sort() {
+	sort.Slice(doc.Databases, func(i, j int) bool { return doc.Databases[i].Name < doc.Databases[j].Name })
+	for _, db := range doc.Databases {
+		sort.Slice(db.RetentionPolicies, func(i, j int) bool { return db.RetentionPolicies[i].Name < db.RetentionPolicies[j].Name })
+		for _, rp := range db.RetentionPolicies {
+			sort.Slice(rp.Subscriptions, func(i, j int) bool { return rp.Subscriptions[i].Name < rp.Subscriptions[j].Name })
+		}
+		sort.Slice(db.ContinuousQueries, func(i, j int) bool { return db.ContinuousQueries[i].Name < db.ContinuousQueries[j].Name })
+	}
+	sort.Slice(doc.Roles, func(i, j int) bool { return doc.Roles[i].Name < doc.Roles[j].Name })
+	sort.Slice(doc.Users, func(i, j int) bool { return doc.Users[i].Name < doc.Users[j].Name })
+	for _, u := range doc.Users {
+		sort.Strings(u.Roles)
+	}
+}
+
+// Validate returns an error if the document names an object twice, or
+// refers to a retention policy or role it does not describe.
+func (doc *Document) Validate() error {
+	dbs := make(map[string]struct{})
+	for _, db := range doc.Databases {
+		if db.Name == "" {
+			return meta.ErrDatabaseNameRequired
+		} else if _, ok := dbs[db.Name]; ok {
+			return fmt.Errorf("database %q described more than once", db.Name)
+		}
+		dbs[db.Name] = struct{}{}
+
+		rps := make(map[string]struct{})
+		for _, rp := range db.RetentionPolicies {
+			if rp.Name == "" {
+				return meta.ErrRetentionPolicyNameRequired
+			} else if _, ok := rps[rp.Name]; ok {
+				return fmt.Errorf("retention policy %q of database %q described more than once", rp.Name, db.Name)
+			} else if rp.Duration != 0 && time.Duration(rp.Duration) < meta.MinRetentionPolicyDuration {
+				return fmt.Errorf("retention policy %q of database %q: %s", rp.Name, db.Name, meta.ErrRetentionPolicyDurationTooLow)
+			}
+			rps[rp.Name] = struct{}{}
+		}
+		if _, ok := rps[db.DefaultRetentionPolicy]; db.DefaultRetentionPolicy != "" && !ok {
+			return fmt.Errorf("default retention policy %q of database %q is not described", db.DefaultRetentionPolicy, db.Name)
+		}
+	}
+
+	roles := make(map[string]struct{})
+	for _, r := range doc.Roles {
+		if r.Name == "" {
+			return meta.ErrRoleNameRequired
+		} else if _, ok := roles[r.Name]; ok {
+			return fmt.Errorf("role %q described more than once", r.Name)
+		}
+		roles[r.Name] = struct{}{}
+	}
+
+	users := make(map[string]struct{})
+	for _, u := range doc.Users {
+		if u.Name == "" {
+			return meta.ErrUsernameRequired
+		} else if _, ok := users[u.Name]; ok {
+			return fmt.Errorf("user %q described more than once", u.Name)
+		}
+		users[u.Name] = struct{}{}
+		for _, r := range u.Roles {
+			if _, ok := roles[r]; !ok {
+				return fmt.Errorf("role %q of user %q is not described", r, u.Name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+// Package metadata is the meta subcommand of the freetsd-ctl command.
+package metadata
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/freetsdb/freetsdb/services/meta"
+	"gopkg.in/yaml.v2"
+)
+
+// Command represents the program execution for "freetsd-ctl meta".
+type Command struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	host   string
+	format string
+	dryRun bool
+	prune  bool
+}
+
+// NewCommand returns a new instance of Command with default settings.
+func NewCommand() *Command {
+	return &Command{
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+// Run executes the program.
+func (cmd *Command) Run(args ...string) error {
+	if len(args) == 0 {
+		cmd.printUsage()
+		return errors.New("export or apply required")
+	}
+	action, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("meta "+action, flag.ContinueOnError)
+	fs.StringVar(&cmd.host, "host", "localhost:8091", "")
+	fs.StringVar(&cmd.format, "format", "yaml", "")
+	fs.BoolVar(&cmd.dryRun, "dry-run", false, "")
+	fs.BoolVar(&cmd.prune, "prune", false, "")
+	fs.SetOutput(cmd.Stderr)
+	fs.Usage = cmd.printUsage
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if cmd.format != "yaml" && cmd.format != "json" {
+		return fmt.Errorf("invalid format %q: must be yaml or json", cmd.format)
+	}
+
+	switch action {
+	case "export":
+		if fs.NArg() != 0 {
+			cmd.printUsage()
+			return errors.New("export takes no arguments")
+		}
+		return cmd.export()
+	case "apply":
+		if fs.NArg() > 1 {
+			cmd.printUsage()
+			return errors.New("apply takes a single document")
+		}
+		return cmd.apply(fs.Arg(0))
+	default:
+		cmd.printUsage()
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
+
+// export writes the document describing the metadata of the cluster.
+func (cmd *Command) export() error {
+	c, err := cmd.openClient()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	data := c.Data()
+	doc := Export(&data)
+
+	var b []byte
+	if cmd.format == "json" {
+		if b, err = json.MarshalIndent(doc, "", "  "); err == nil {
+			b = append(b, '\n')
+		}
+	} else {
+		b, err = yaml.Marshal(doc)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = cmd.Stdout.Write(b)
+	return err
+}
+
+// apply changes the metadata of the cluster to match the document at path,
+// or on standard input if path is empty or "-".
+func (cmd *Command) apply(path string) error {
+	var b []byte
+	var err error
+	if path == "" || path == "-" {
+		b, err = ioutil.ReadAll(cmd.Stdin)
+	} else {
+		b, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	doc, err := decode(b, path)
+	if err != nil {
+		return err
+	}
+
+	c, err := cmd.openClient()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	data := c.Data()
+	changes, err := Plan(&data, doc, cmd.prune)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(cmd.Stdout, "No changes")
+		return nil
+	}
+
+	for _, ch := range changes {
+		if cmd.dryRun {
+			fmt.Fprintln(cmd.Stdout, "Would", ch.Description)
+			continue
+		}
+		if err := ch.Apply(c); err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.Stdout, strings.ToUpper(ch.Description[:1])+ch.Description[1:])
+	}
+	return nil
+}
+
+// decode decodes a document read from path. Documents in files ending in
+// .json, or starting with a brace, are decoded as JSON, others as YAML.
+func decode(b []byte, path string) (*Document, error) {
+	doc := &Document{}
+	if strings.HasSuffix(path, ".json") || bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(doc); err != nil {
+			return nil, fmt.Errorf("decode document: %s", err)
+		}
+	} else if err := yaml.UnmarshalStrict(b, doc); err != nil {
+		return nil, fmt.Errorf("decode document: %s", err)
+	}
+	return doc, nil
+}
+
+// openClient opens a meta client connected to the meta servers of the
+// cluster of host.
+func (cmd *Command) openClient() (*meta.Client, error) {
+	c := meta.NewClient(nil)
+	c.SetMetaServers([]string{cmd.host})
+	if err := c.Open(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// printUsage prints the usage message to STDERR.
+func (cmd *Command) printUsage() {
+	fmt.Fprintf(cmd.Stderr, `usage: freetsd-ctl meta export [flags]
+       freetsd-ctl meta apply [flags] [PATH]
+
+Export writes a declarative document of the databases, retention policies,
+subscriptions, continuous queries, roles and users of a cluster. Apply
+changes the cluster to match such a document, read from PATH or standard
+input. Applying a document is idempotent: applying it again changes nothing.
+
+Password hashes are not exported. A user of the document that does not exist
+yet must be given a password, which is only used to create it.
+
+Options:
+  -host <host:port>
+        The meta node to connect to. Defaults to localhost:8091.
+  -format <yaml|json>
+        The format of exported documents. Defaults to yaml. Applied documents
+        ending in .json or starting with '{' are read as JSON.
+  -dry-run
+        Apply only: print the changes without making them.
+  -prune
+        Apply only: drop the retention policies, subscriptions, continuous
+        queries, roles, users and privileges the document does not describe.
+        Databases are never dropped.
+
+`)
+}
@@ -0,0 +1,149 @@
+// Package reindex is the reindex-shard subcommand of the freetsd-ctl command.
+package reindex
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	client "github.com/freetsdb/freetsdb/client/v2"
+	"github.com/freetsdb/freetsdb/models"
+)
+
+// Command represents the program execution for "freetsd-ctl reindex-shard".
+type Command struct {
+	Stdout io.Writer
+	Stderr io.Writer
+
+	host     string
+	username string
+	password string
+	interval time.Duration
+}
+
+// NewCommand returns a new instance of Command with default settings.
+func NewCommand() *Command {
+	return &Command{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+// Run executes the program.
+func (cmd *Command) Run(args ...string) error {
+	fs := flag.NewFlagSet("reindex-shard", flag.ContinueOnError)
+	fs.StringVar(&cmd.host, "host", "localhost:8086", "")
+	fs.StringVar(&cmd.username, "username", "", "")
+	fs.StringVar(&cmd.password, "password", "", "")
+	fs.DurationVar(&cmd.interval, "interval", time.Second, "")
+	fs.SetOutput(cmd.Stderr)
+	fs.Usage = cmd.printUsage
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		cmd.printUsage()
+		return errors.New("shard ID required")
+	}
+	id, err := strconv.ParseUint(fs.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid shard ID: %s", fs.Arg(0))
+	}
+
+	c, err := client.NewHTTPClient(client.HTTPConfig{
+		Addr:     "http://" + cmd.host,
+		Username: cmd.username,
+		Password: cmd.password,
+	})
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	// Start the job, then wait for it to finish.
+	rows, err := query(c, fmt.Sprintf("REINDEX SHARD %d", id))
+	if err != nil {
+		return err
+	} else if len(rows) == 0 || len(rows[0].Values) == 0 {
+		return errors.New("no job returned")
+	}
+	job := fmt.Sprint(rows[0].Values[0][0])
+	fmt.Fprintf(cmd.Stdout, "Reindexing shard %d (job %s)\n", id, job)
+
+	for {
+		status, jobErr, err := jobStatus(c, job)
+		if err != nil {
+			return err
+		}
+		switch status {
+		case "done":
+			fmt.Fprintf(cmd.Stdout, "Reindexed shard %d\n", id)
+			return nil
+		case "failed":
+			return fmt.Errorf("job %s failed: %s", job, jobErr)
+		}
+		time.Sleep(cmd.interval)
+	}
+}
+
+// jobStatus returns the status and error of a job listed by SHOW SHARD JOBS.
+func jobStatus(c client.Client, job string) (status, jobErr string, err error) {
+	rows, err := query(c, "SHOW SHARD JOBS")
+	if err != nil {
+		return "", "", err
+	}
+	for _, row := range rows {
+		columns := make(map[string]int, len(row.Columns))
+		for i, col := range row.Columns {
+			columns[col] = i
+		}
+		for _, v := range row.Values {
+			if fmt.Sprint(v[columns["id"]]) != job {
+				continue
+			}
+			if e := v[columns["error"]]; e != nil {
+				jobErr = fmt.Sprint(e)
+			}
+			return fmt.Sprint(v[columns["status"]]), jobErr, nil
+		}
+	}
+	return "", "", fmt.Errorf("job %s not found", job)
+}
+
+// query executes a statement and returns the series of its result.
+func query(c client.Client, command string) ([]models.Row, error) {
+	resp, err := c.Query(client.NewQuery(command, "", ""))
+	if err != nil {
+		return nil, err
+	} else if err := resp.Error(); err != nil {
+		return nil, err
+	} else if len(resp.Results) == 0 {
+		return nil, nil
+	}
+	return resp.Results[0].Series, nil
+}
+
+// printUsage prints the usage message to STDERR.
+func (cmd *Command) printUsage() {
+	fmt.Fprintf(cmd.Stderr, `usage: freetsd-ctl reindex-shard [flags] <shard-id>
+
+Rebuilds the TSI index of a shard from its data on every data node that owns
+it, for instance to recover from index corruption. The shard keeps serving
+writes and queries while its index is rebuilt.
+
+Options:
+  -host <host:port>
+        The HTTP address of a data node. Defaults to localhost:8086.
+  -username <name>
+        Optional. The name of an admin user.
+  -password <password>
+        Optional. The password of the admin user.
+  -interval <duration>
+        How often the status of the reindex is checked. Defaults to 1s.
+
+`)
+}
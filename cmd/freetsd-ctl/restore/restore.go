@@ -101,6 +101,7 @@ func (cmd *Command) runOffline() error {
 }
 
 func (cmd *Command) runOnlinePortable() error {
+	cmd.uploadSeriesFilesPortable()
 	err := cmd.updateMetaPortable()
 	if err != nil {
 		cmd.StderrLogger.Printf("error updating meta: %v", err)
@@ -426,6 +427,48 @@ func (cmd *Command) uploadShardsPortable() error {
 	return nil
 }
 
+// uploadSeriesFilesPortable restores the series files of the databases
+// restored before their shards, so that the TSI indexes of the shards are
+// restored as they are. The indexes are rebuilt instead if a series file is
+// not restored, as when the database is already stored on the server.
+func (cmd *Command) uploadSeriesFilesPortable() {
+	seriesFiles, err := backup_util.LoadSeriesFiles(cmd.backupFilesPath)
+	if err != nil {
+		cmd.StderrLogger.Printf("error loading series files, indexes will be rebuilt: %v", err)
+		return
+	}
+
+	for db, file := range seriesFiles {
+		if cmd.sourceDatabase != "" && cmd.sourceDatabase != db {
+			continue
+		}
+		targetDB := cmd.destinationDatabase
+		if targetDB == "" {
+			targetDB = db
+		}
+
+		cmd.StdoutLogger.Printf("Restoring series file of %s live from backup %s\n", targetDB, file.FileName)
+		if err := cmd.uploadSeriesFile(targetDB, filepath.Join(cmd.backupFilesPath, file.FileName)); err != nil {
+			cmd.StderrLogger.Printf("error restoring series file of %s, its indexes will be rebuilt: %v", targetDB, err)
+		}
+	}
+}
+
+// uploadSeriesFile restores the series file of db from the backup file at path.
+func (cmd *Command) uploadSeriesFile(db, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	return cmd.client.UploadSeriesFile(db, gr)
+}
+
 // unpackFiles will look for backup files matching the pattern and restore them to the data dir
 func (cmd *Command) uploadShardsLegacy() error {
 	// find the destinationDatabase backup files
@@ -41,6 +41,7 @@ type Command struct {
 	Stdout io.Writer
 	Stderr io.Writer
 	Logger *zap.Logger
+	Getenv func(string) string
 
 	Server *Server
 }
@@ -54,6 +55,7 @@ func NewCommand() *Command {
 		Stdout:  os.Stdout,
 		Stderr:  os.Stderr,
 		Logger:  zap.NewNop(),
+		Getenv:  os.Getenv,
 	}
 }
 
@@ -70,6 +72,10 @@ func (cmd *Command) Run(args ...string) error {
 		return fmt.Errorf("parse config: %s", err)
 	}
 
+	if err := config.ResolveSecrets(cmd.Getenv); err != nil {
+		return fmt.Errorf("resolve secrets: %v", err)
+	}
+
 	if options.Hostname != "" {
 		config.Hostname = options.Hostname
 	}
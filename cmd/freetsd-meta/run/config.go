@@ -9,8 +9,10 @@ import (
 
 	"github.com/BurntSushi/toml"
 	"github.com/freetsdb/freetsdb/logger"
+	"github.com/freetsdb/freetsdb/pkg/netutil"
 	"github.com/freetsdb/freetsdb/pkg/tlsconfig"
 	"github.com/freetsdb/freetsdb/services/meta"
+	itoml "github.com/freetsdb/freetsdb/toml"
 	"golang.org/x/text/encoding/unicode"
 	"golang.org/x/text/transform"
 )
@@ -94,8 +96,18 @@ func (c *Config) FromToml(input string) error {
 	return err
 }
 
+// ResolveSecrets replaces "$ENV{NAME}" and "file:///path" references in
+// the settings tagged as secrets with the value of the environment variable
+// or the contents of the file.
+func (c *Config) ResolveSecrets(getenv func(string) string) error {
+	return itoml.ResolveSecrets(getenv, c)
+}
+
 // Validate returns an error if the config is invalid.
 func (c *Config) Validate() error {
+	if err := netutil.ValidateBindAddress(c.BindAddress); err != nil {
+		return fmt.Errorf("invalid bind-address: %v", err)
+	}
 
 	if err := c.Meta.Validate(); err != nil {
 		return err
@@ -36,6 +36,8 @@ Usage:
 The commands are:
 
     config               display the default configuration
+    config validate      check a configuration file for errors
+    config diff          display settings that differ from the defaults
     run                  run node with existing configuration
     version              displays the FreeTSDB version
 
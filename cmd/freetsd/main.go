@@ -80,6 +80,15 @@ func (m *Main) Run(args ...string) error {
 			return fmt.Errorf("run: %s", err)
 		}
 
+		// Reload the configuration on SIGHUP.
+		reloadCh := make(chan os.Signal, 1)
+		signal.Notify(reloadCh, syscall.SIGHUP)
+		go func() {
+			for range reloadCh {
+				cmd.Reload()
+			}
+		}()
+
 		signalCh := make(chan os.Signal, 1)
 		signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
 		cmd.Logger.Info("Listening for signals")
@@ -104,7 +113,16 @@ func (m *Main) Run(args ...string) error {
 		// goodbye.
 
 	case "config":
-		if err := run.NewPrintConfigCommand().Run(args...); err != nil {
+		var err error
+		switch sub, subargs := cmd.ParseCommandName(args); sub {
+		case "validate":
+			err = run.NewValidateConfigCommand().Run(subargs...)
+		case "diff":
+			err = run.NewDiffConfigCommand().Run(subargs...)
+		default:
+			err = run.NewPrintConfigCommand().Run(args...)
+		}
+		if err != nil {
 			return fmt.Errorf("config: %s", err)
 		}
 	case "version":
@@ -71,17 +71,9 @@ func (cmd *Command) Run(args ...string) error {
 		return err
 	}
 
-	config, err := cmd.ParseConfig(options.GetConfigPath())
+	config, err := cmd.loadConfig(options)
 	if err != nil {
-		return fmt.Errorf("parse config: %s", err)
-	}
-
-	// Apply any environment variables on top of the parsed config
-	if err := config.ApplyEnvOverrides(cmd.Getenv); err != nil {
-		return fmt.Errorf("apply env config: %v", err)
-	}
-	if options.Hostname != "" {
-		config.Hostname = options.Hostname
+		return err
 	}
 
 	// Validate the configuration.
@@ -89,8 +81,11 @@ func (cmd *Command) Run(args ...string) error {
 		return fmt.Errorf("%s. To generate a valid configuration file run `freetsd config > freetsdb.generated.conf`", err)
 	}
 
+	// The log level can be changed when the configuration is reloaded.
+	logLevel := zap.NewAtomicLevelAt(config.Logging.Level)
+
 	var logErr error
-	if cmd.Logger, logErr = config.Logging.New(cmd.Stderr); logErr != nil {
+	if cmd.Logger, logErr = config.Logging.NewAtLevel(cmd.Stderr, logLevel); logErr != nil {
 		// assign the default logger
 		cmd.Logger = logger.New(cmd.Stderr)
 	}
@@ -147,6 +142,11 @@ func (cmd *Command) Run(args ...string) error {
 	s.Logger = cmd.Logger
 	s.CPUProfile = options.CPUProfile
 	s.MemProfile = options.MemProfile
+	s.LoadConfig = func() (*Config, error) { return cmd.loadConfig(options) }
+	s.Watch(ConfigWatcherFunc(func(c *Config) error {
+		logLevel.SetLevel(c.Logging.Level)
+		return nil
+	}))
 	if err := s.Open(); err != nil {
 		return fmt.Errorf("open server: %s", err)
 	}
@@ -158,6 +158,19 @@ func (cmd *Command) Run(args ...string) error {
 	return nil
 }
 
+// Reload reloads the server configuration. Errors are logged and the
+// previous configuration stays in effect.
+func (cmd *Command) Reload() {
+	if cmd.Server == nil {
+		return
+	}
+
+	cmd.Logger.Info("Reloading configuration")
+	if err := cmd.Server.ReloadConfig(); err != nil {
+		cmd.Logger.Error("Unable to reload configuration", zap.Error(err))
+	}
+}
+
 // Close shuts down the server.
 func (cmd *Command) Close() error {
 	defer close(cmd.Closed)
@@ -199,6 +212,7 @@ func (cmd *Command) ParseFlags(args ...string) (Options, error) {
 	fs.StringVar(&options.Hostname, "hostname", "", "")
 	fs.StringVar(&options.CPUProfile, "cpuprofile", "", "")
 	fs.StringVar(&options.MemProfile, "memprofile", "", "")
+	fs.BoolVar(&options.Recover, "recover", false, "")
 	fs.Usage = func() { fmt.Fprintln(cmd.Stderr, usage) }
 	if err := fs.Parse(args); err != nil {
 		return Options{}, err
@@ -228,6 +242,30 @@ func (cmd *Command) writePIDFile(path string) error {
 	return nil
 }
 
+// loadConfig parses the config file named by options and applies the
+// environment and command line overrides.
+func (cmd *Command) loadConfig(options Options) (*Config, error) {
+	config, err := cmd.ParseConfig(options.GetConfigPath())
+	if err != nil {
+		return nil, fmt.Errorf("parse config: %s", err)
+	}
+
+	// Apply any environment variables on top of the parsed config
+	if err := config.ApplyEnvOverrides(cmd.Getenv); err != nil {
+		return nil, fmt.Errorf("apply env config: %v", err)
+	}
+	if err := config.ResolveSecrets(cmd.Getenv); err != nil {
+		return nil, fmt.Errorf("resolve secrets: %v", err)
+	}
+	if options.Hostname != "" {
+		config.Hostname = options.Hostname
+	}
+	if options.Recover {
+		config.Data.WALRecover = true
+	}
+	return config, nil
+}
+
 // ParseConfig parses the config at path.
 // It returns a demo configuration if path is blank.
 func (cmd *Command) ParseConfig(path string) (*Config, error) {
@@ -264,6 +302,11 @@ Usage: freetsd run [flags]
             Write CPU profiling information to a file.
     -memprofile <path>
             Write memory usage information to a file.
+    -recover
+            Salvage damaged WAL segments on startup. The valid entries of a
+            damaged segment are kept, and the original segment is moved to
+            the quarantine directory of the shard's WAL with a report of
+            the corrupt ranges.
 `
 
 // Options represents the command line options that can be parsed.
@@ -274,6 +317,7 @@ type Options struct {
 	Hostname   string
 	CPUProfile string
 	MemProfile string
+	Recover    bool
 }
 
 // GetConfigPath returns the config path from the options.
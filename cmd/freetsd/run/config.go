@@ -12,15 +12,24 @@ import (
 	"github.com/freetsdb/freetsdb/logger"
 	"github.com/freetsdb/freetsdb/monitor"
 	"github.com/freetsdb/freetsdb/monitor/diagnostics"
+	"github.com/freetsdb/freetsdb/pkg/netutil"
 	"github.com/freetsdb/freetsdb/pkg/tlsconfig"
+	"github.com/freetsdb/freetsdb/services/audit"
 	"github.com/freetsdb/freetsdb/services/collectd"
 	"github.com/freetsdb/freetsdb/services/continuous_querier"
+	"github.com/freetsdb/freetsdb/services/gossip"
 	"github.com/freetsdb/freetsdb/services/graphite"
+	"github.com/freetsdb/freetsdb/services/grpc"
 	"github.com/freetsdb/freetsdb/services/hh"
 	"github.com/freetsdb/freetsdb/services/httpd"
+	"github.com/freetsdb/freetsdb/services/materializer"
+	"github.com/freetsdb/freetsdb/services/meta"
 	"github.com/freetsdb/freetsdb/services/opentsdb"
 	"github.com/freetsdb/freetsdb/services/precreator"
+	"github.com/freetsdb/freetsdb/services/resources"
 	"github.com/freetsdb/freetsdb/services/retention"
+	"github.com/freetsdb/freetsdb/services/standby"
+	"github.com/freetsdb/freetsdb/services/storage"
 	"github.com/freetsdb/freetsdb/services/subscriber"
 	"github.com/freetsdb/freetsdb/services/udp"
 	itoml "github.com/freetsdb/freetsdb/toml"
@@ -48,14 +57,22 @@ type Config struct {
 	Monitor        monitor.Config    `toml:"monitor"`
 	Subscriber     subscriber.Config `toml:"subscriber"`
 	HTTPD          httpd.Config      `toml:"http"`
+	GRPC           grpc.Config       `toml:"grpc"`
+	Storage        storage.Config    `toml:"storage"`
 	Logging        logger.Config     `toml:"logging"`
 	GraphiteInputs []graphite.Config `toml:"graphite"`
 	CollectdInputs []collectd.Config `toml:"collectd"`
 	OpenTSDBInputs []opentsdb.Config `toml:"opentsdb"`
 	UDPInputs      []udp.Config      `toml:"udp"`
 
-	ContinuousQuery continuous_querier.Config `toml:"continuous_queries"`
-	HintedHandoff   hh.Config                 `toml:"hinted-handoff"`
+	ContinuousQuery   continuous_querier.Config `toml:"continuous_queries"`
+	MaterializedViews materializer.Config       `toml:"materialized_views"`
+	HintedHandoff     hh.Config                 `toml:"hinted-handoff"`
+	Audit             audit.Config              `toml:"audit"`
+	Resources         resources.Config          `toml:"resources"`
+	Standby           standby.Config            `toml:"standby"`
+	Gossip            gossip.Config             `toml:"gossip"`
+	PasswordPolicy    meta.PasswordPolicyConfig `toml:"password-policy"`
 
 	// Server reporting
 	ReportingDisabled bool `toml:"reporting-disabled"`
@@ -67,6 +84,11 @@ type Config struct {
 	// addresses.  This hostname must be resolvable from other nodes.
 	Hostname string `toml:"hostname"`
 
+	// Labels describe the node for the placement of shards, such as its
+	// zone, rack and disk class. The replicas of a shard are spread across
+	// the zones and racks of the nodes.
+	Labels map[string]string `toml:"labels"`
+
 	// TLS provides configuration options for all https endpoints.
 	TLS tlsconfig.Config `toml:"tls"`
 }
@@ -81,6 +103,8 @@ func NewConfig() *Config {
 	c.Monitor = monitor.NewConfig()
 	c.Subscriber = subscriber.NewConfig()
 	c.HTTPD = httpd.NewConfig()
+	c.GRPC = grpc.NewConfig()
+	c.Storage = storage.NewConfig()
 	c.Logging = logger.NewConfig()
 
 	c.GraphiteInputs = []graphite.Config{graphite.NewConfig()}
@@ -89,7 +113,13 @@ func NewConfig() *Config {
 	c.UDPInputs = []udp.Config{udp.NewConfig()}
 
 	c.ContinuousQuery = continuous_querier.NewConfig()
+	c.MaterializedViews = materializer.NewConfig()
 	c.Retention = retention.NewConfig()
+	c.Audit = audit.NewConfig()
+	c.Resources = resources.NewConfig()
+	c.Standby = standby.NewConfig()
+	c.Gossip = gossip.NewConfig()
+	c.PasswordPolicy = meta.NewPasswordPolicyConfig()
 	c.BindAddress = DefaultBindAddress
 
 	return c
@@ -118,19 +148,28 @@ func NewDemoConfig() (*Config, error) {
 
 // FromTomlFile loads the config from a TOML file.
 func (c *Config) FromTomlFile(fpath string) error {
-	bs, err := ioutil.ReadFile(fpath)
+	input, err := readTomlFile(fpath)
 	if err != nil {
 		return err
 	}
+	return c.FromToml(input)
+}
+
+// readTomlFile returns the contents of the TOML file at fpath.
+func readTomlFile(fpath string) (string, error) {
+	bs, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return "", err
+	}
 
 	// Handle any potential Byte-Order-Marks that may be in the config file.
 	// This is for Windows compatibility only.
 	bom := unicode.BOMOverride(transform.Nop)
 	bs, _, err = transform.Bytes(bom, bs)
 	if err != nil {
-		return err
+		return "", err
 	}
-	return c.FromToml(string(bs))
+	return string(bs), nil
 }
 
 // FromToml loads the config from TOML.
@@ -141,11 +180,24 @@ func (c *Config) FromToml(input string) error {
 
 // Validate returns an error if the config is invalid.
 func (c *Config) Validate() error {
+	if err := netutil.ValidateBindAddress(c.BindAddress); err != nil {
+		return fmt.Errorf("invalid bind-address: %v", err)
+	}
+
+	for k := range c.Labels {
+		if k == "" {
+			return fmt.Errorf("labels must not have an empty key")
+		}
+	}
 
 	if err := c.Data.Validate(); err != nil {
 		return err
 	}
 
+	if err := c.Coordinator.Validate(); err != nil {
+		return err
+	}
+
 	//if err := c.HintedHandoff.Validate(); err != nil {
 	//	return err
 	//}
@@ -159,10 +211,26 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if err := c.HTTPD.Validate(); err != nil {
+		return fmt.Errorf("invalid http config: %v", err)
+	}
+
+	if err := c.GRPC.Validate(); err != nil {
+		return fmt.Errorf("invalid grpc config: %v", err)
+	}
+
+	if err := c.Storage.Validate(); err != nil {
+		return fmt.Errorf("invalid storage config: %v", err)
+	}
+
 	if err := c.ContinuousQuery.Validate(); err != nil {
 		return err
 	}
 
+	if err := c.MaterializedViews.Validate(); err != nil {
+		return err
+	}
+
 	if err := c.Retention.Validate(); err != nil {
 		return err
 	}
@@ -181,6 +249,38 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	for _, opentsdb := range c.OpenTSDBInputs {
+		if err := opentsdb.Validate(); err != nil {
+			return fmt.Errorf("invalid opentsdb config: %v", err)
+		}
+	}
+
+	for _, udp := range c.UDPInputs {
+		if err := udp.Validate(); err != nil {
+			return fmt.Errorf("invalid udp config: %v", err)
+		}
+	}
+
+	if err := c.Audit.Validate(); err != nil {
+		return fmt.Errorf("invalid audit config: %v", err)
+	}
+
+	if err := c.Resources.Validate(); err != nil {
+		return fmt.Errorf("invalid resources config: %v", err)
+	}
+
+	if err := c.Standby.Validate(); err != nil {
+		return fmt.Errorf("invalid standby config: %v", err)
+	}
+
+	if err := c.Gossip.Validate(); err != nil {
+		return fmt.Errorf("invalid gossip config: %v", err)
+	}
+
+	if err := c.PasswordPolicy.Validate(); err != nil {
+		return fmt.Errorf("invalid password-policy config: %v", err)
+	}
+
 	if err := c.TLS.Validate(); err != nil {
 		return err
 	}
@@ -193,6 +293,15 @@ func (c *Config) ApplyEnvOverrides(getenv func(string) string) error {
 	return itoml.ApplyEnvOverrides(getenv, "FREETSDB", c)
 }
 
+// ResolveSecrets replaces "$ENV{NAME}" and "file:///path" references in
+// the secret settings, such as the shared secret of the HTTP service, with
+// the value of the environment variable or the contents of the file. It is
+// applied after environment overrides so that overrides may also use
+// references.
+func (c *Config) ResolveSecrets(getenv func(string) string) error {
+	return itoml.ResolveSecrets(getenv, c)
+}
+
 // Diagnostics returns a diagnostics representation of Config.
 func (c *Config) Diagnostics() (*diagnostics.Diagnostics, error) {
 	return diagnostics.RowFromMap(map[string]interface{}{
@@ -214,8 +323,15 @@ func (c *Config) diagnosticsClients() map[string]diagnostics.Client {
 		"config-monitor":    c.Monitor,
 		"config-subscriber": c.Subscriber,
 		"config-httpd":      c.HTTPD,
-
-		"config-cqs": c.ContinuousQuery,
+		"config-grpc":       c.GRPC,
+		"config-storage":    c.Storage,
+
+		"config-cqs":                c.ContinuousQuery,
+		"config-materialized-views": c.MaterializedViews,
+		"config-audit":              c.Audit,
+		"config-resources":          c.Resources,
+		"config-standby":            c.Standby,
+		"config-gossip":             c.Gossip,
 	}
 
 	// Config settings that can be repeated and can be disabled.
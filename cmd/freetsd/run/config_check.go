@@ -0,0 +1,426 @@
+package run
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	itoml "github.com/freetsdb/freetsdb/toml"
+)
+
+// Deprecation describes a configuration option that is no longer used.
+type Deprecation struct {
+	// Key is the deprecated option. A section name deprecates every option
+	// in the section.
+	Key string
+
+	// Replacement is the option to use instead. If Key is a section,
+	// Replacement is the section its options moved to. It is empty if the
+	// option was removed.
+	Replacement string
+
+	// Note explains a removal.
+	Note string
+}
+
+// deprecatedOptions lists options accepted by earlier versions.
+var deprecatedOptions = []Deprecation{
+	{Key: "cluster", Replacement: "coordinator"},
+	{Key: "admin", Note: "the web admin interface has been removed"},
+	{Key: "meta", Note: "meta node options belong in the freetsd-meta configuration"},
+	{Key: "data.wal-logging-enabled", Replacement: "logging.level"},
+	{Key: "data.data-logging-enabled", Replacement: "logging.level"},
+	{Key: "data.max-wal-size", Replacement: "data.cache-snapshot-memory-size"},
+	{Key: "data.wal-flush-interval", Replacement: "data.cache-snapshot-write-cold-duration"},
+	{Key: "data.wal-partition-flush-delay", Note: "the WAL is no longer partitioned"},
+}
+
+// String returns a description of the deprecated option.
+func (d Deprecation) String() string {
+	switch {
+	case d.Replacement != "":
+		return fmt.Sprintf("%s is deprecated, use %s instead", d.Key, d.Replacement)
+	case d.Note != "":
+		return fmt.Sprintf("%s is no longer supported: %s", d.Key, d.Note)
+	}
+	return fmt.Sprintf("%s is no longer supported", d.Key)
+}
+
+// lookupDeprecation returns the deprecation for key, if any.
+func lookupDeprecation(key string) (Deprecation, bool) {
+	for _, d := range deprecatedOptions {
+		if key == d.Key {
+			return d, true
+		} else if strings.HasPrefix(key, d.Key+".") {
+			// The whole section is deprecated.
+			if d.Replacement != "" {
+				d.Replacement += strings.TrimPrefix(key, d.Key)
+			}
+			d.Key = key
+			return d, true
+		}
+	}
+	return Deprecation{}, false
+}
+
+// ConfigCheck reports problems found while loading a configuration file that
+// do not prevent it from loading.
+type ConfigCheck struct {
+	// UnknownKeys are options in the file that no setting uses.
+	UnknownKeys []string
+
+	// Deprecated are options in the file that are no longer used.
+	Deprecated []Deprecation
+}
+
+// CheckTomlFile loads the config from a TOML file like FromTomlFile and
+// reports unknown and deprecated options.
+func (c *Config) CheckTomlFile(fpath string) (*ConfigCheck, error) {
+	input, err := readTomlFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := toml.Decode(input, c)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &ConfigCheck{}
+	for _, key := range md.Undecoded() {
+		// Only report options, not the tables containing them.
+		if typ := md.Type(key...); typ == "Hash" || typ == "ArrayHash" {
+			continue
+		}
+
+		if d, ok := lookupDeprecation(key.String()); ok {
+			check.Deprecated = append(check.Deprecated, d)
+		} else {
+			check.UnknownKeys = append(check.UnknownKeys, key.String())
+		}
+	}
+	return check, nil
+}
+
+// ValidateConfigCommand represents the command executed by
+// "freetsd config validate".
+type ValidateConfigCommand struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	Getenv func(string) string
+}
+
+// NewValidateConfigCommand returns a new instance of ValidateConfigCommand.
+func NewValidateConfigCommand() *ValidateConfigCommand {
+	return &ValidateConfigCommand{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Getenv: os.Getenv,
+	}
+}
+
+// Run checks the configuration file for unknown and deprecated options and
+// validates the resulting configuration.
+func (cmd *ValidateConfigCommand) Run(args ...string) error {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	configPath := fs.String("config", "", "")
+	fs.Usage = func() { fmt.Fprintln(cmd.Stderr, validateConfigUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opt := Options{ConfigPath: *configPath}
+	path := opt.GetConfigPath()
+	if path == "" {
+		return errors.New("no configuration file found")
+	}
+
+	config, err := NewDemoConfig()
+	if err != nil {
+		config = NewConfig()
+	}
+	check, err := config.CheckTomlFile(path)
+	if err != nil {
+		return fmt.Errorf("parse config: %s", err)
+	}
+	fmt.Fprintf(cmd.Stdout, "Checking %s\n", path)
+
+	for _, d := range check.Deprecated {
+		fmt.Fprintf(cmd.Stdout, "warning: %s\n", d)
+	}
+	for _, k := range check.UnknownKeys {
+		fmt.Fprintf(cmd.Stdout, "error: unknown option %s\n", k)
+	}
+
+	if err := config.ApplyEnvOverrides(cmd.Getenv); err != nil {
+		return fmt.Errorf("apply env config: %v", err)
+	}
+	if err := config.ResolveSecrets(cmd.Getenv); err != nil {
+		fmt.Fprintf(cmd.Stdout, "error: %s\n", err)
+		return errors.New("configuration is invalid")
+	}
+	if err := config.Validate(); err != nil {
+		fmt.Fprintf(cmd.Stdout, "error: %s\n", err)
+		return errors.New("configuration is invalid")
+	}
+
+	if len(check.UnknownKeys) > 0 {
+		return fmt.Errorf("configuration has %d unknown option(s)", len(check.UnknownKeys))
+	}
+	fmt.Fprintln(cmd.Stdout, "Configuration is valid")
+	return nil
+}
+
+var validateConfigUsage = `Checks a configuration file for errors.
+
+Reports unknown options, deprecated options and their replacements,
+secret references that cannot be resolved, and settings that fail
+validation after environment variable overrides are applied. Exits with a non-zero status if the configuration is invalid or has
+unknown options.
+
+Usage: freetsd config validate [flags]
+
+    -config <path>
+            Set the path to the configuration file.
+            This defaults to the environment variable FREETSDB_CONFIG_PATH,
+            ~/.freetsdb/freetsdb.conf, or /etc/freetsdb/freetsdb.conf if a file
+            is present at any of these locations.
+`
+
+// DiffConfigCommand represents the command executed by "freetsd config diff".
+type DiffConfigCommand struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	Getenv func(string) string
+}
+
+// NewDiffConfigCommand returns a new instance of DiffConfigCommand.
+func NewDiffConfigCommand() *DiffConfigCommand {
+	return &DiffConfigCommand{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Getenv: os.Getenv,
+	}
+}
+
+// Run prints the settings of the effective configuration that differ from
+// the defaults and where each was set.
+func (cmd *DiffConfigCommand) Run(args ...string) error {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	configPath := fs.String("config", "", "")
+	fs.Usage = func() { fmt.Fprintln(cmd.Stderr, diffConfigUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opt := Options{ConfigPath: *configPath}
+	path := opt.GetConfigPath()
+
+	// load returns the defaults, merged with the config file if file is set.
+	load := func(file bool) (*Config, error) {
+		c, err := NewDemoConfig()
+		if err != nil {
+			c = NewConfig()
+		}
+		if file && path != "" {
+			if err := c.FromTomlFile(path); err != nil {
+				return nil, fmt.Errorf("parse config: %s", err)
+			}
+		}
+		return c, nil
+	}
+
+	base, err := load(false)
+	if err != nil {
+		return err
+	}
+	fromFile, err := load(true)
+	if err != nil {
+		return err
+	}
+	effective, err := load(true)
+	if err != nil {
+		return err
+	}
+	if err := effective.ApplyEnvOverrides(cmd.Getenv); err != nil {
+		return fmt.Errorf("apply env config: %v", err)
+	}
+
+	defaults, err := flattenConfig(base)
+	if err != nil {
+		return err
+	}
+	file, err := flattenConfig(fromFile)
+	if err != nil {
+		return err
+	}
+	values, err := flattenConfig(effective)
+	if err != nil {
+		return err
+	}
+
+	secrets := make(map[string]bool)
+	secretKeys(secrets, reflect.ValueOf(effective), "", false)
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v, def := values[k], defaults[k]
+		if v == def {
+			continue
+		}
+
+		source := "file"
+		if v != file[k] {
+			source = "environment"
+		}
+		if secrets[k] {
+			v = redactedValue
+			if def != "" {
+				def = redactedValue
+			}
+		}
+		if def == "" {
+			def = "unset"
+		}
+		fmt.Fprintf(cmd.Stdout, "%s = %s  # default %s, set by %s\n", k, v, def, source)
+	}
+	return nil
+}
+
+// redactedValue replaces the values of secret settings in the output.
+const redactedValue = "<redacted>"
+
+// secretKeys adds the keys flattenConfig uses for the values of the fields of
+// v tagged `secret:"true"` to keys. The fields of a secret are secret too.
+func secretKeys(keys map[string]bool, v reflect.Value, key string, secret bool) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			secretKeys(keys, v.Elem(), key, secret)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() != reflect.Struct {
+			// flattenConfig keeps a list of values under a single key.
+			if secret {
+				keys[key] = true
+			}
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			secretKeys(keys, v.Index(i), key+"."+strconv.Itoa(i), secret)
+		}
+	case reflect.Struct:
+		typ := v.Type()
+		if reflect.PtrTo(typ).Implements(textMarshalerType) {
+			// The value is encoded as text, like time.Time.
+			if secret {
+				keys[key] = true
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			field := typ.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			name := field.Tag.Get("toml")
+			if i := strings.IndexByte(name, ','); i >= 0 {
+				name = name[:i]
+			}
+			if name == "-" {
+				continue
+			}
+
+			fieldKey := key
+			if name != "" || !field.Anonymous {
+				// Embedded fields without a toml tag share the parent's key.
+				if name == "" {
+					name = field.Name
+				}
+				if fieldKey != "" {
+					fieldKey += "."
+				}
+				fieldKey += name
+			}
+			secretKeys(keys, v.Field(i), fieldKey, secret || itoml.IsSecret(field))
+		}
+	default:
+		if secret {
+			keys[key] = true
+		}
+	}
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// flattenConfig returns the TOML encoding of c as a map of dotted keys to
+// encoded values. Tables in arrays are keyed by their index.
+func flattenConfig(c *Config) (map[string]string, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(c); err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	if _, err := toml.Decode(buf.String(), &m); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	flattenValue(values, "", m)
+	return values, nil
+}
+
+func flattenValue(values map[string]string, key string, v interface{}) {
+	join := func(k string) string {
+		if key == "" {
+			return k
+		}
+		return key + "." + k
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, vv := range v {
+			flattenValue(values, join(k), vv)
+		}
+	case []map[string]interface{}:
+		for i, vv := range v {
+			flattenValue(values, join(strconv.Itoa(i)), vv)
+		}
+	case string:
+		values[key] = strconv.Quote(v)
+	default:
+		values[key] = fmt.Sprint(v)
+	}
+}
+
+var diffConfigUsage = `Displays the settings that differ from the default configuration.
+
+Each setting is shown with its effective value, its default value and
+whether it was set by the configuration file or an environment variable.
+The values of secret settings, such as passwords and keys, are redacted.
+
+Usage: freetsd config diff [flags]
+
+    -config <path>
+            Set the path to the configuration file.
+            This defaults to the environment variable FREETSDB_CONFIG_PATH,
+            ~/.freetsdb/freetsdb.conf, or /etc/freetsdb/freetsdb.conf if a file
+            is present at any of these locations.
+`
@@ -80,7 +80,13 @@ func (cmd *PrintConfigCommand) parseConfig(path string) (*Config, error) {
 
 var printConfigUsage = `Displays the default configuration.
 
-Usage: freetsd config [flags]
+Usage: freetsd config [validate|diff] [flags]
+
+    validate
+            Check the configuration file for unknown, deprecated and
+            invalid options.
+    diff
+            Show the settings that differ from the defaults.
 
     -config <path>
             Set the path to the initial configuration file.
@@ -1,10 +1,13 @@
 package run_test
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -336,6 +339,96 @@ func TestConfig_InvalidSubsections(t *testing.T) {
 	}
 }
 
+// Ensure unknown and deprecated options are reported.
+func TestConfig_CheckTomlFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "freetsd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	io.WriteString(f, `
+[data]
+dir = "/tmp/data"
+wal-logging-enabled = true
+
+[cluster]
+max-select-point = 100
+
+[http]
+bind-adress = ":8087"
+`)
+	f.Close()
+
+	c := run.NewConfig()
+	check, err := c.CheckTomlFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Data.Dir != "/tmp/data" {
+		t.Fatalf("unexpected data dir: %s", c.Data.Dir)
+	}
+	if got, exp := check.UnknownKeys, []string{"http.bind-adress"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected unknown keys: got %v, exp %v", got, exp)
+	}
+
+	var got []string
+	for _, d := range check.Deprecated {
+		got = append(got, d.String())
+	}
+	exp := []string{
+		"data.wal-logging-enabled is deprecated, use logging.level instead",
+		"cluster.max-select-point is deprecated, use coordinator.max-select-point instead",
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected deprecations: got %q, exp %q", got, exp)
+	}
+}
+
+// Ensure config diff shows the changed settings without the secret values.
+func TestDiffConfigCommand_Secrets(t *testing.T) {
+	f, err := ioutil.TempFile("", "freetsd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	io.WriteString(f, `
+[http]
+bind-address = ":8087"
+shared-secret = "file-secret"
+`)
+	f.Close()
+
+	var stdout bytes.Buffer
+	cmd := run.NewDiffConfigCommand()
+	cmd.Stdout = &stdout
+	cmd.Getenv = func(s string) string {
+		if s == "FREETSDB_AUDIT_KEY" {
+			return "env-secret"
+		}
+		return ""
+	}
+	if err := cmd.Run("-config", f.Name()); err != nil {
+		t.Fatal(err)
+	}
+
+	out := stdout.String()
+	for _, exp := range []string{
+		`http.bind-address = ":8087"  # default ":8086", set by file`,
+		`http.shared-secret = <redacted>  # default unset, set by file`,
+		`audit.key = <redacted>  # default unset, set by environment`,
+	} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("expected %q in output:\n%s", exp, out)
+		}
+	}
+	if strings.Contains(out, "file-secret") || strings.Contains(out, "env-secret") {
+		t.Fatalf("unexpected secret in output:\n%s", out)
+	}
+}
+
 // Ensure the configuration can be parsed when a Byte-Order-Mark is present.
 func TestConfig_Parse_UTF8_ByteOrderMark(t *testing.T) {
 	// Parse configuration.
@@ -0,0 +1,77 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// ConfigWatcher is notified when the server configuration is reloaded.
+//
+// Only some settings can be changed without a restart: the log level, the
+// query limits in [coordinator], the write limits in [http], the check
+// interval in [retention], the writer settings in [subscriber] and the
+// settings in [resources]. Watchers apply the settings they own and ignore
+// the rest.
+type ConfigWatcher interface {
+	ConfigChanged(c *Config) error
+}
+
+// ConfigWatcherFunc adapts a function to the ConfigWatcher interface.
+type ConfigWatcherFunc func(c *Config) error
+
+// ConfigChanged calls fn(c).
+func (fn ConfigWatcherFunc) ConfigChanged(c *Config) error {
+	return fn(c)
+}
+
+// Watch registers w to be notified when the configuration is reloaded.
+func (s *Server) Watch(w ConfigWatcher) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	s.watchers = append(s.watchers, w)
+}
+
+// ReloadConfig loads the configuration with LoadConfig and passes it to
+// every watcher. The new configuration is validated first and is not applied
+// if it is invalid.
+func (s *Server) ReloadConfig() error {
+	if s.LoadConfig == nil {
+		return errors.New("configuration reload is not supported")
+	}
+
+	c, err := s.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %v", err)
+	}
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %v", err)
+	}
+
+	tlsConfig, err := c.TLS.Parse()
+	if err != nil {
+		return fmt.Errorf("tls configuration: %v", err)
+	}
+	updateTLSConfig(&c.HTTPD.TLS, tlsConfig)
+	updateTLSConfig(&c.Subscriber.TLS, tlsConfig)
+
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+
+	var firstErr error
+	for _, w := range s.watchers {
+		if err := w.ConfigChanged(c); err != nil {
+			s.Logger.Error("Failed to apply reloaded configuration", zap.Error(err))
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+
+	s.Logger.Info("Configuration reloaded")
+	return nil
+}
@@ -10,7 +10,9 @@ import (
 	"path/filepath"
 	"runtime"
 	"runtime/pprof"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/freetsdb/freetsdb"
@@ -19,19 +21,27 @@ import (
 	"github.com/freetsdb/freetsdb/logger"
 	"github.com/freetsdb/freetsdb/models"
 	"github.com/freetsdb/freetsdb/monitor"
+	"github.com/freetsdb/freetsdb/pkg/encryption"
+	"github.com/freetsdb/freetsdb/pkg/objstore"
 	"github.com/freetsdb/freetsdb/platform/storage/reads"
 	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/audit"
 	"github.com/freetsdb/freetsdb/services/collectd"
 	"github.com/freetsdb/freetsdb/services/continuous_querier"
 	"github.com/freetsdb/freetsdb/services/copier"
+	"github.com/freetsdb/freetsdb/services/gossip"
 	"github.com/freetsdb/freetsdb/services/graphite"
+	"github.com/freetsdb/freetsdb/services/grpc"
 	"github.com/freetsdb/freetsdb/services/hh"
 	"github.com/freetsdb/freetsdb/services/httpd"
+	"github.com/freetsdb/freetsdb/services/materializer"
 	"github.com/freetsdb/freetsdb/services/meta"
 	"github.com/freetsdb/freetsdb/services/opentsdb"
 	"github.com/freetsdb/freetsdb/services/precreator"
+	"github.com/freetsdb/freetsdb/services/resources"
 	"github.com/freetsdb/freetsdb/services/retention"
 	"github.com/freetsdb/freetsdb/services/snapshotter"
+	"github.com/freetsdb/freetsdb/services/standby"
 	"github.com/freetsdb/freetsdb/services/storage"
 	"github.com/freetsdb/freetsdb/services/subscriber"
 	"github.com/freetsdb/freetsdb/services/udp"
@@ -86,6 +96,9 @@ type Server struct {
 	QueryExecutor *query.Executor
 	PointsWriter  *coordinator.PointsWriter
 	ShardWriter   *coordinator.ShardWriter
+	Features      *coordinator.FeatureNegotiator
+	ReadHedger    *coordinator.ReadHedger
+	MetaExecutor  *coordinator.MetaExecutor
 	HintedHandoff *hh.Service
 	Subscriber    *subscriber.Service
 
@@ -95,6 +108,21 @@ type Server struct {
 	CoordinatorService *coordinator.Service
 	SnapshotterService *snapshotter.Service
 	CopierService      *copier.Service
+	HTTPDService       *httpd.Service
+
+	// AuditService is nil when auditing is disabled.
+	AuditService *audit.Service
+
+	// StandbyService is nil unless the node is a standby.
+	StandbyService *standby.Service
+
+	// Gossip is nil when the failure detection of data nodes is disabled.
+	Gossip *gossip.Service
+
+	// Materializer is nil when materialized views are disabled.
+	Materializer *materializer.Service
+
+	Resources *resources.Service
 
 	Monitor *monitor.Monitor
 
@@ -118,6 +146,12 @@ type Server struct {
 	tcpAddr string
 
 	config *Config
+
+	// LoadConfig rereads the configuration when it is reloaded.
+	LoadConfig func() (*Config, error)
+
+	watchMu  sync.Mutex
+	watchers []ConfigWatcher
 }
 
 // updateTLSConfig stores with into the tls config pointed at by into but only if with is not nil
@@ -139,6 +173,8 @@ func NewServer(c *Config, buildInfo *BuildInfo) (*Server, error) {
 	// Update the TLS values on each of the configs to be the parsed one if
 	// not already specified (set the default).
 	updateTLSConfig(&c.HTTPD.TLS, tlsConfig)
+	updateTLSConfig(&c.GRPC.TLS, tlsConfig)
+	updateTLSConfig(&c.Storage.TLS, tlsConfig)
 	updateTLSConfig(&c.Subscriber.TLS, tlsConfig)
 	for i := range c.OpenTSDBInputs {
 		updateTLSConfig(&c.OpenTSDBInputs[i].TLS, tlsConfig)
@@ -208,9 +244,39 @@ func NewServer(c *Config, buildInfo *BuildInfo) (*Server, error) {
 	s.TSDBStore.EngineOptions.EngineVersion = c.Data.Engine
 	s.TSDBStore.EngineOptions.IndexVersion = c.Data.Index
 
+	// Load encryption keys for data at rest.
+	if c.Data.EncryptionEnabled {
+		keys, err := encryption.NewKeyFile(c.Data.EncryptionKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load encryption keys: %s", err)
+		}
+		s.TSDBStore.EngineOptions.KeyProvider = keys
+	}
+
+	// Keep the TSM files of the shards in object storage.
+	if c.Data.ObjectStoreURL != "" {
+		bucket, prefix, err := objstore.Open(c.Data.ObjectStoreURL, objstore.Options{
+			Endpoint:  c.Data.ObjectStoreEndpoint,
+			Region:    c.Data.ObjectStoreRegion,
+			AccessKey: c.Data.ObjectStoreAccessKey,
+			SecretKey: c.Data.ObjectStoreSecretKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("open object store: %s", err)
+		}
+		s.TSDBStore.ObjectStore = tsdb.NewObjectStore(bucket, prefix, c.Data.Dir)
+	}
+
+	// Negotiate the features of the cluster protocol with the other data
+	// nodes, which may run other versions during a rolling upgrade.
+	s.Features = coordinator.NewFeatureNegotiator(buildInfo.Version)
+	s.Features.Node = s.Node
+	s.Features.MetaClient = s.MetaClient
+
 	// Set the shard writer
 	s.ShardWriter = coordinator.NewShardWriter(time.Duration(c.Coordinator.ShardWriterTimeout),
 		c.Coordinator.MaxRemoteWriteConnections)
+	s.ShardWriter.Features = s.Features
 
 	// Create the hinted handoff service
 	s.HintedHandoff = hh.NewService(c.HintedHandoff, s.ShardWriter, s.MetaClient)
@@ -218,41 +284,115 @@ func NewServer(c *Config, buildInfo *BuildInfo) (*Server, error) {
 
 	// Create the Subscriber service
 	s.Subscriber = subscriber.NewService(c.Subscriber)
+	s.Watch(ConfigWatcherFunc(func(c *Config) error {
+		return s.Subscriber.Reconfigure(c.Subscriber)
+	}))
 
 	// Initialize points writer.
 	s.PointsWriter = coordinator.NewPointsWriter()
 	s.PointsWriter.WriteTimeout = time.Duration(c.Coordinator.WriteTimeout)
+	s.PointsWriter.IdempotencyKeyTTL = time.Duration(c.Coordinator.IdempotencyKeyTTL)
+	s.PointsWriter.MaxIdempotencyKeys = c.Coordinator.MaxIdempotencyKeys
 	s.PointsWriter.TSDBStore = s.TSDBStore
 	s.PointsWriter.ShardWriter = s.ShardWriter
 	s.PointsWriter.HintedHandoff = s.HintedHandoff
 	s.PointsWriter.Subscriber = s.Subscriber
 	s.PointsWriter.Node = s.Node
 
+	// Detect the failure of the other data nodes, so that they are not
+	// written to or read from until they are alive again.
+	if c.Gossip.Enabled {
+		s.Gossip = gossip.NewService(c.Gossip)
+		s.PointsWriter.NodeHealth = s.Gossip
+	}
+
+	// Maintain the materialized views from the points written.
+	if c.MaterializedViews.Enabled {
+		s.Materializer = materializer.NewService(c.MaterializedViews)
+		s.PointsWriter.MaterializedViews = s.Materializer
+	}
+
 	// Initialize meta executor.
 	metaExecutor := coordinator.NewMetaExecutor()
 	metaExecutor.MetaClient = s.MetaClient
 	metaExecutor.Node = s.Node
+	metaExecutor.Features = s.Features
+	s.MetaExecutor = metaExecutor
 
 	// Initialize query executor.
+	s.ReadHedger = coordinator.NewReadHedger(time.Duration(c.Coordinator.HedgedReadDelay))
 	s.QueryExecutor = query.NewExecutor()
-	s.QueryExecutor.StatementExecutor = &coordinator.StatementExecutor{
-		MetaClient:  s.MetaClient,
-		TaskManager: s.QueryExecutor.TaskManager,
-		TSDBStore:   s.TSDBStore,
-		Node:        s.Node,
-		ShardMapper: &coordinator.LocalShardMapper{
-			MetaClient: s.MetaClient,
-			TSDBStore:  coordinator.LocalTSDBStore{Store: s.TSDBStore},
-		},
+	shardMapper := &coordinator.LocalShardMapper{
+		MetaClient:       s.MetaClient,
+		TSDBStore:        coordinator.LocalTSDBStore{Store: s.TSDBStore},
+		ForeignEndpoints: c.Coordinator.ForeignEndpoints,
+		ReadHedger:       s.ReadHedger,
+	}
+	if s.Gossip != nil {
+		shardMapper.NodeHealth = s.Gossip
+	}
+	statementExecutor := &coordinator.StatementExecutor{
+		MetaClient:        s.MetaClient,
+		MetaExecutor:      metaExecutor,
+		TaskManager:       s.QueryExecutor.TaskManager,
+		TSDBStore:         s.TSDBStore,
+		Node:              s.Node,
+		ShardMapper:       shardMapper,
 		Monitor:           s.Monitor,
 		PointsWriter:      s.PointsWriter,
 		MaxSelectPointN:   c.Coordinator.MaxSelectPointN,
 		MaxSelectSeriesN:  c.Coordinator.MaxSelectSeriesN,
 		MaxSelectBucketsN: c.Coordinator.MaxSelectBucketsN,
+		MaxShowTagValuesN: c.Coordinator.MaxShowTagValuesN,
+		ShardJobsPath:     filepath.Join(c.Data.Dir, "shard_jobs.json"),
+	}
+	if s.Materializer != nil {
+		statementExecutor.MaterializedViews = s.Materializer
 	}
+	s.QueryExecutor.StatementExecutor = statementExecutor
 	s.QueryExecutor.TaskManager.QueryTimeout = time.Duration(c.Coordinator.QueryTimeout)
 	s.QueryExecutor.TaskManager.LogQueriesAfter = time.Duration(c.Coordinator.LogQueriesAfter)
 	s.QueryExecutor.TaskManager.MaxConcurrentQueries = c.Coordinator.MaxConcurrentQueries
+	s.QueryExecutor.TaskManager.MaxConcurrentBatchQueries = c.Coordinator.MaxConcurrentBatchQueries
+	s.QueryExecutor.TaskManager.MaxQueuedQueries = c.Coordinator.MaxQueuedQueries
+	s.QueryExecutor.TaskManager.QueueTimeout = time.Duration(c.Coordinator.QueryQueueTimeout)
+	s.QueryExecutor.TaskManager.MaxQueryMemoryBytes = int64(c.Coordinator.MaxQueryMemory)
+	s.QueryExecutor.TaskManager.QueryMemoryBudgetBytes = int64(c.Coordinator.QueryMemoryBudget)
+	s.QueryExecutor.TaskManager.QueryMemoryQueueTimeout = time.Duration(c.Coordinator.QueryMemoryQueueTimeout)
+	s.QueryExecutor.TaskManager.QuerySpillDir = c.Coordinator.QuerySpillDir
+	s.QueryExecutor.TaskManager.QuerySpillThresholdBytes = int64(c.Coordinator.QuerySpillThreshold)
+	s.Watch(ConfigWatcherFunc(func(c *Config) error {
+		s.QueryExecutor.TaskManager.SetLimits(
+			time.Duration(c.Coordinator.QueryTimeout),
+			time.Duration(c.Coordinator.LogQueriesAfter),
+			c.Coordinator.MaxConcurrentQueries,
+		)
+		s.QueryExecutor.TaskManager.SetQueueLimits(
+			c.Coordinator.MaxConcurrentBatchQueries,
+			c.Coordinator.MaxQueuedQueries,
+			time.Duration(c.Coordinator.QueryQueueTimeout),
+		)
+		s.QueryExecutor.TaskManager.SetMemoryLimits(
+			int64(c.Coordinator.MaxQueryMemory),
+			int64(c.Coordinator.QueryMemoryBudget),
+			time.Duration(c.Coordinator.QueryMemoryQueueTimeout),
+		)
+		s.QueryExecutor.TaskManager.SetSpill(c.Coordinator.QuerySpillDir, int64(c.Coordinator.QuerySpillThreshold))
+		statementExecutor.SetSelectLimits(c.Coordinator.MaxSelectPointN, c.Coordinator.MaxSelectSeriesN, c.Coordinator.MaxSelectBucketsN)
+		if s.CoordinatorService != nil {
+			s.CoordinatorService.SetRemoteRequestLimits(c.Coordinator.MaxConcurrentRemoteRequests, time.Duration(c.Coordinator.RemoteRequestQueueTimeout))
+		}
+		return nil
+	}))
+
+	// Tune the runtime resources. The query workers override the limit of the
+	// coordinator while they are set.
+	s.Resources = resources.NewService(c.Resources)
+	s.Resources.Compactions = s.TSDBStore
+	s.Resources.Queries = s.QueryExecutor.TaskManager
+	s.Watch(ConfigWatcherFunc(func(c *Config) error {
+		return s.Resources.Apply(c.Resources)
+	}))
 
 	// Initialize the monitor
 	s.Monitor.Version = s.buildInfo.Version
@@ -270,6 +410,7 @@ func (s *Server) Statistics(tags map[string]string) []models.Statistic {
 	statistics = append(statistics, s.QueryExecutor.Statistics(tags)...)
 	statistics = append(statistics, s.TSDBStore.Statistics(tags)...)
 	statistics = append(statistics, s.PointsWriter.Statistics(tags)...)
+	statistics = append(statistics, s.ReadHedger.Statistics(tags)...)
 	statistics = append(statistics, s.Subscriber.Statistics(tags)...)
 	for _, srv := range s.Services {
 		if m, ok := srv.(monitor.Reporter); ok {
@@ -283,10 +424,29 @@ func (s *Server) appendCoordinatorService(c coordinator.Config) {
 	srv := coordinator.NewService(c)
 	srv.TSDBStore = s.TSDBStore
 	srv.MetaClient = s.MetaClient
+	// Federated requests only map the shards of this cluster, so clusters
+	// federated with each other do not query each other in a loop.
+	srv.ShardMapper = &coordinator.LocalShardMapper{
+		MetaClient: s.MetaClient,
+		TSDBStore:  coordinator.LocalTSDBStore{Store: s.TSDBStore},
+	}
+	srv.TaskManager = s.QueryExecutor.TaskManager
+	srv.Index = s.TSDBStore
+	srv.Node = s.Node
+	srv.Version = s.buildInfo.Version
 	s.Services = append(s.Services, srv)
 	s.CoordinatorService = srv
 }
 
+func (s *Server) appendGossipService() {
+	if s.Gossip == nil {
+		return
+	}
+	s.Gossip.MetaClient = s.MetaClient
+	s.Gossip.Node = s.Node
+	s.Services = append(s.Services, s.Gossip)
+}
+
 func (s *Server) appendSnapshotterService() {
 	srv := snapshotter.NewService()
 	srv.TSDBStore = s.TSDBStore
@@ -314,7 +474,51 @@ func (s *Server) appendRetentionPolicyService(c retention.Config) {
 	srv := retention.NewService(c)
 	srv.MetaClient = s.MetaClient
 	srv.TSDBStore = s.TSDBStore
+	if s.TSDBStore.ObjectStore != nil {
+		srv.ObjectStore = s.TSDBStore.ObjectStore
+	}
+	if s.AuditService != nil {
+		srv.AuditLog = s.AuditService
+	}
+	s.Services = append(s.Services, srv)
+
+	s.Watch(ConfigWatcherFunc(func(c *Config) error {
+		if c.Retention.Enabled {
+			srv.SetCheckInterval(time.Duration(c.Retention.CheckInterval))
+		}
+		return nil
+	}))
+}
+
+func (s *Server) appendMaterializerService() {
+	if s.Materializer == nil {
+		return
+	}
+	s.Materializer.MetaClient = s.MetaClient
+	s.Materializer.QueryExecutor = s.QueryExecutor
+	s.Services = append(s.Services, s.Materializer)
+}
+
+func (s *Server) appendAuditService(c audit.Config) {
+	if !c.Enabled {
+		return
+	}
+	srv := audit.NewService(c)
+	srv.PointsWriter = (*monitorPointsWriter)(s.PointsWriter)
+	s.Services = append(s.Services, srv)
+	s.AuditService = srv
+}
+
+func (s *Server) appendStandbyService(c standby.Config) {
+	if !c.Enabled {
+		return
+	}
+	srv := standby.NewService(c)
+	srv.Node = s.Node
+	srv.MetaClient = s.MetaClient
+	srv.TSDBStore = s.TSDBStore
 	s.Services = append(s.Services, srv)
+	s.StandbyService = srv
 }
 
 func (s *Server) appendHTTPDService(c httpd.Config) {
@@ -323,6 +527,7 @@ func (s *Server) appendHTTPDService(c httpd.Config) {
 	}
 	srv := httpd.NewService(c)
 	srv.Handler.MetaClient = s.MetaClient
+	srv.EnrollmentMetaClient = s.MetaClient
 	authorizer := meta.NewQueryAuthorizer(s.MetaClient)
 	srv.Handler.QueryAuthorizer = authorizer
 	srv.Handler.WriteAuthorizer = meta.NewWriteAuthorizer(s.MetaClient)
@@ -330,10 +535,77 @@ func (s *Server) appendHTTPDService(c httpd.Config) {
 	srv.Handler.Monitor = s.Monitor
 	srv.Handler.PointsWriter = s.PointsWriter
 	srv.Handler.Version = s.buildInfo.Version
+	srv.Handler.MonitorDatabase = s.config.Monitor.StoreDatabase
+	if s.AuditService != nil {
+		srv.Handler.AuditLog = s.AuditService
+	}
+	srv.Handler.ConfigReloader = s
+	srv.Handler.Features = s.Features
+	srv.Handler.Resources = s.Resources
+	if s.StandbyService != nil {
+		srv.Handler.Standby = s.StandbyService
+	}
+	if s.config.Subscriber.Enabled {
+		srv.Handler.Subscriptions = s.Subscriber
+	}
+	s.Watch(ConfigWatcherFunc(func(c *Config) error {
+		srv.Handler.SetWriteLimits(c.HTTPD.MaxConcurrentWriteLimit, c.HTTPD.MaxEnqueuedWriteLimit, c.HTTPD.EnqueuedWriteTimeout)
+		if err := srv.Handler.SetRelabelRules(c.HTTPD.Relabel); err != nil {
+			return err
+		}
+		return srv.Handler.SetRoutes(c.HTTPD.Routes)
+	}))
+	s.PointsWriter.AddCommitSubscriber(srv.Handler.StreamPoints())
 	ss := storage.NewStore(s.TSDBStore, s.MetaClient)
 	srv.Handler.Store = ss
+	srv.Handler.TSDBStore = s.TSDBStore
+	srv.Handler.Index = &coordinator.ClusterIndex{
+		Node:         s.Node,
+		MetaClient:   s.MetaClient,
+		TSDBStore:    s.TSDBStore,
+		MetaExecutor: s.MetaExecutor,
+	}
+	srv.Handler.Shards = s.TSDBStore
 	srv.Handler.Controller = control.NewController(s.MetaClient, reads.NewReader(ss), authorizer, c.AuthEnabled, s.Logger)
 
+	s.HTTPDService = srv
+	s.Services = append(s.Services, srv)
+}
+
+// inputPointsWriter returns the points writer of the services other than the
+// HTTP API that write points. It counts their points in the usage the HTTP API
+// reports, if it is enabled.
+func (s *Server) inputPointsWriter() pointsWriter {
+	if s.HTTPDService == nil {
+		return s.PointsWriter
+	}
+	return s.HTTPDService.Handler.UsagePointsWriter(s.PointsWriter)
+}
+
+func (s *Server) appendGRPCService(c grpc.Config) {
+	if !c.Enabled {
+		return
+	}
+	// Calls require the credentials the HTTP API does.
+	c.AuthEnabled = c.AuthEnabled || s.config.HTTPD.AuthEnabled
+	srv := grpc.NewService(c)
+	srv.MetaClient = s.MetaClient
+	srv.QueryAuthorizer = meta.NewQueryAuthorizer(s.MetaClient)
+	srv.WriteAuthorizer = meta.NewWriteAuthorizer(s.MetaClient)
+	srv.QueryExecutor = s.QueryExecutor
+	srv.PointsWriter = s.inputPointsWriter()
+	if s.AuditService != nil {
+		srv.AuditLog = s.AuditService
+	}
+	s.Services = append(s.Services, srv)
+}
+
+func (s *Server) appendStorageService(c storage.Config) {
+	if !c.Enabled {
+		return
+	}
+	srv := storage.NewService(c)
+	srv.Store = storage.NewStore(s.TSDBStore, s.MetaClient)
 	s.Services = append(s.Services, srv)
 }
 
@@ -343,7 +615,7 @@ func (s *Server) appendCollectdService(c collectd.Config) {
 	}
 	srv := collectd.NewService(c)
 	srv.MetaClient = s.MetaClient
-	srv.PointsWriter = s.PointsWriter
+	srv.PointsWriter = s.inputPointsWriter()
 	s.Services = append(s.Services, srv)
 }
 
@@ -355,7 +627,7 @@ func (s *Server) appendOpenTSDBService(c opentsdb.Config) error {
 	if err != nil {
 		return err
 	}
-	srv.PointsWriter = s.PointsWriter
+	srv.PointsWriter = s.inputPointsWriter()
 	srv.MetaClient = s.MetaClient
 	s.Services = append(s.Services, srv)
 	return nil
@@ -370,9 +642,23 @@ func (s *Server) appendGraphiteService(c graphite.Config) error {
 		return err
 	}
 
-	srv.PointsWriter = s.PointsWriter
+	srv.PointsWriter = s.inputPointsWriter()
 	srv.MetaClient = s.MetaClient
 	srv.Monitor = s.Monitor
+
+	// Inputs are matched across reloads by their bind address.
+	bind := c.WithDefaults().BindAddress
+	s.Watch(ConfigWatcherFunc(func(c *Config) error {
+		for _, g := range c.GraphiteInputs {
+			if g.WithDefaults().BindAddress == bind {
+				if err := srv.SetRelabelRules(g.Relabel); err != nil {
+					return err
+				}
+				return srv.SetRoutes(g.Routes)
+			}
+		}
+		return nil
+	}))
 	s.Services = append(s.Services, srv)
 	return nil
 }
@@ -392,8 +678,22 @@ func (s *Server) appendUDPService(c udp.Config) {
 		return
 	}
 	srv := udp.NewService(c)
-	srv.PointsWriter = s.PointsWriter
+	srv.PointsWriter = s.inputPointsWriter()
 	srv.MetaClient = s.MetaClient
+
+	// Inputs are matched across reloads by their bind address.
+	bind := c.WithDefaults().BindAddress
+	s.Watch(ConfigWatcherFunc(func(c *Config) error {
+		for _, u := range c.UDPInputs {
+			if u.WithDefaults().BindAddress == bind {
+				if err := srv.SetRelabelRules(u.Relabel); err != nil {
+					return err
+				}
+				return srv.SetRoutes(u.Routes)
+			}
+		}
+		return nil
+	}))
 	s.Services = append(s.Services, srv)
 }
 
@@ -442,8 +742,14 @@ func (s *Server) Open() error {
 		s.appendPrecreatorService(s.config.Precreator)
 		s.appendSnapshotterService()
 		s.appendCopierService()
+		s.appendGossipService()
 		s.appendContinuousQueryService(s.config.ContinuousQuery)
+		s.appendMaterializerService()
+		s.appendAuditService(s.config.Audit)
+		s.appendStandbyService(s.config.Standby)
 		s.appendHTTPDService(s.config.HTTPD)
+		s.appendGRPCService(s.config.GRPC)
+		s.appendStorageService(s.config.Storage)
 		s.appendRetentionPolicyService(s.config.Retention)
 
 		for _, i := range s.config.GraphiteInputs {
@@ -472,6 +778,9 @@ func (s *Server) Open() error {
 		s.CoordinatorService.Listener = mux.Listen(coordinator.MuxHeader)
 		s.SnapshotterService.Listener = mux.Listen(snapshotter.MuxHeader)
 		s.CopierService.Listener = mux.Listen(copier.MuxHeader)
+		if s.Gossip != nil {
+			s.Gossip.Listener = mux.Listen(gossip.MuxHeader)
+		}
 
 		// Configure logging for all services and clients.
 		s.MetaClient.WithLogger(s.Logger)
@@ -487,6 +796,20 @@ func (s *Server) Open() error {
 		s.SnapshotterService.WithLogger(s.Logger)
 		s.Monitor.WithLogger(s.Logger)
 
+		// Apply the runtime resources before opening the store, so it sizes
+		// its compaction limit for the CPUs pinned.
+		s.Resources.WithLogger(s.Logger)
+		if err := s.Resources.Open(); err != nil {
+			return fmt.Errorf("open resources: %s", err)
+		}
+
+		// The files this node uploads to the object store are named by its
+		// ID, known once it joined the cluster.
+		if s.TSDBStore.ObjectStore != nil {
+			s.TSDBStore.ObjectStore.Node = strconv.FormatUint(s.Node.ID, 10)
+		}
+		s.TSDBStore.EngineOptions.NodeID = s.Node.ID
+
 		// Open TSDB store.
 		if err := s.TSDBStore.Open(); err != nil {
 			return fmt.Errorf("open tsdb store: %s", err)
@@ -566,6 +889,10 @@ func (s *Server) Close() error {
 		s.Subscriber.Close()
 	}
 
+	if s.Resources != nil {
+		s.Resources.Close()
+	}
+
 	if s.MetaClient != nil {
 		s.MetaClient.Close()
 	}
@@ -763,6 +1090,7 @@ func (s *Server) initializeMetaClient() error {
 		break
 	}
 	s.MetaClient.SetTLS(s.metaUseTLS)
+	s.MetaClient.SetPasswordPolicy(s.config.PasswordPolicy)
 
 	if err := s.MetaClient.Open(); err != nil {
 		return err
@@ -771,11 +1099,39 @@ func (s *Server) initializeMetaClient() error {
 	// if the node ID is > 0 then we need to initialize the metaclient
 	if s.Node.ID > 0 {
 		s.MetaClient.WaitForDataChanged()
+		if err := s.declareLabels(); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// declareLabels stores the labels of the config on the data node of this
+// server, for the placement of shards, if they changed.
+func (s *Server) declareLabels() error {
+	ni, err := s.MetaClient.DataNode(s.Node.ID)
+	if err != nil {
+		return fmt.Errorf("set node labels: %s", err)
+	}
+	if len(ni.Labels) == len(s.config.Labels) {
+		changed := false
+		for k, v := range s.config.Labels {
+			if value, ok := ni.Labels[k]; !ok || value != v {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return nil
+		}
+	}
+	if err := s.MetaClient.SetDataNodeLabels(s.Node.ID, s.config.Labels); err != nil {
+		return fmt.Errorf("set node labels: %s", err)
+	}
+	return nil
+}
+
 // HTTPAddr returns the HTTP address used by other nodes for HTTP queries and writes.
 func (s *Server) HTTPAddr() string {
 	return s.remoteAddr(s.httpAPIAddr)
@@ -855,6 +1211,12 @@ func stopProfile() {
 	}
 }
 
+// pointsWriter writes points as a user or with no user.
+type pointsWriter interface {
+	WritePoints(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, user meta.User, points []models.Point) error
+	WritePointsPrivileged(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, points []models.Point) error
+}
+
 // monitorPointsWriter is a wrapper around `cluster.PointsWriter` that helps
 // to prevent a circular dependency between the `cluster` and `monitor` packages.
 type monitorPointsWriter coordinator.PointsWriter
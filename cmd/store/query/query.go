@@ -3,10 +3,13 @@ package query
 import (
 	"bufio"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -20,6 +23,7 @@ import (
 	"github.com/freetsdb/freetsdb/platform/storage/reads/datatypes"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 // Command represents the program execution for "store query".
@@ -30,6 +34,9 @@ type Command struct {
 	Logger *zap.Logger
 
 	addr            string
+	caCert          string
+	cert            string
+	key             string
 	cpuProfile      string
 	memProfile      string
 	database        string
@@ -85,7 +92,10 @@ func (cmd *Command) Run(args ...string) error {
 	fs := flag.NewFlagSet("query", flag.ExitOnError)
 	fs.StringVar(&cmd.cpuProfile, "cpuprofile", "", "CPU profile name")
 	fs.StringVar(&cmd.memProfile, "memprofile", "", "memory profile name")
-	fs.StringVar(&cmd.addr, "addr", ":8082", "the RPC address")
+	fs.StringVar(&cmd.addr, "addr", "localhost:8082", "the RPC address")
+	fs.StringVar(&cmd.caCert, "ca", "", "Optional: the CA certificate of the server, to connect with TLS")
+	fs.StringVar(&cmd.cert, "cert", "", "Optional: the client certificate to connect with TLS")
+	fs.StringVar(&cmd.key, "key", "", "Optional: the private key of the client certificate")
 	fs.StringVar(&cmd.database, "database", "", "the database to query")
 	fs.StringVar(&cmd.retentionPolicy, "retention", "", "Optional: the retention policy to query")
 	fs.StringVar(&start, "start", "", "Optional: the start time to query (RFC3339 format)")
@@ -143,7 +153,16 @@ func (cmd *Command) Run(args ...string) error {
 		return err
 	}
 
-	conn, err := grpc.Dial(cmd.addr, grpc.WithInsecure())
+	opt := grpc.WithInsecure()
+	if cmd.caCert != "" || cmd.cert != "" {
+		config, err := cmd.tlsConfig()
+		if err != nil {
+			return err
+		}
+		opt = grpc.WithTransportCredentials(credentials.NewTLS(config))
+	}
+
+	conn, err := grpc.Dial(cmd.addr, opt)
 	if err != nil {
 		return err
 	}
@@ -152,6 +171,33 @@ func (cmd *Command) Run(args ...string) error {
 	return cmd.query(datatypes.NewStorageClient(conn))
 }
 
+// tlsConfig returns the TLS config to connect to the server with.
+func (cmd *Command) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{}
+	if cmd.caCert != "" {
+		b, err := ioutil.ReadFile(cmd.caCert)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(b) {
+			return nil, errors.New("no certificates found in " + cmd.caCert)
+		}
+	}
+	if cmd.cert != "" {
+		key := cmd.key
+		if key == "" {
+			key = cmd.cert
+		}
+		cert, err := tls.LoadX509KeyPair(cmd.cert, key)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
 func (cmd *Command) validate() error {
 	if cmd.database == "" {
 		return fmt.Errorf("must specify a database")
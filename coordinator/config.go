@@ -3,6 +3,8 @@
 package coordinator
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/freetsdb/freetsdb/monitor/diagnostics"
@@ -32,9 +34,68 @@ const (
 	// A value of zero will make the maximum point count unlimited.
 	DefaultMaxSelectPointN = 0
 
-	// DefaultMaxSelectSeriesN is the maximum number of series a SELECT can run.
+	// DefaultMaxSelectSeriesN is the maximum number of series a SELECT can run,
+	// and a SHOW SERIES can read from each shard.
 	// A value of zero will make the maximum series count unlimited.
 	DefaultMaxSelectSeriesN = 0
+
+	// DefaultMaxShowTagValuesN is the maximum number of values a SHOW TAG
+	// VALUES can return. A value of zero will make the maximum unlimited.
+	DefaultMaxShowTagValuesN = 0
+
+	// DefaultMaxConcurrentBatchQueries is the maximum number of running batch
+	// priority queries. A value of zero only limits them by max-concurrent-queries.
+	DefaultMaxConcurrentBatchQueries = 0
+
+	// DefaultMaxConcurrentRemoteRequests is the maximum number of iterator
+	// requests of other nodes served at once. A value of zero will make the
+	// maximum unlimited.
+	DefaultMaxConcurrentRemoteRequests = 0
+
+	// DefaultRemoteRequestQueueTimeout is the time a request of another node
+	// waits for a slot.
+	DefaultRemoteRequestQueueTimeout = 30 * time.Second
+
+	// DefaultMaxQueuedQueries is the maximum number of queries waiting for a
+	// slot. A value of zero disables the queue.
+	DefaultMaxQueuedQueries = 0
+
+	// DefaultQueryQueueTimeout is the time a query waits in the queue.
+	DefaultQueryQueueTimeout = 30 * time.Second
+
+	// DefaultMaxQueryMemory is the maximum number of bytes a query can allocate.
+	// A value of zero will make the maximum query memory unlimited.
+	DefaultMaxQueryMemory = 0
+
+	// DefaultQueryMemoryBudget is the maximum number of bytes all running
+	// queries can allocate together. A value of zero disables the budget.
+	DefaultQueryMemoryBudget = 0
+
+	// DefaultQueryMemoryQueueTimeout is the time a query waits for memory
+	// when the query memory budget is used up.
+	DefaultQueryMemoryQueueTimeout = 30 * time.Second
+
+	// DefaultQuerySpillThreshold is the number of bytes of points a sort or
+	// group operator of a query may hold in memory before spilling them to
+	// disk. A value of zero disables spilling.
+	DefaultQuerySpillThreshold = 0
+
+	// DefaultIdempotencyKeyTTL is the time the idempotency key of a write is
+	// remembered after the write completed.
+	DefaultIdempotencyKeyTTL = 10 * time.Minute
+
+	// DefaultMaxIdempotencyKeys is the maximum number of idempotency keys
+	// remembered by a node.
+	DefaultMaxIdempotencyKeys = 100000
+
+	// DefaultForeignEndpointTimeout is the default time to connect to a
+	// foreign endpoint and receive the response to a request.
+	DefaultForeignEndpointTimeout = 10 * time.Second
+
+	// DefaultHedgedReadDelay is the time a read of a remote shard waits for
+	// a response before it is sent to another owner of the shard too. A
+	// value of zero disables hedged reads.
+	DefaultHedgedReadDelay = 0
 )
 
 // Config represents the configuration for the cluster service.
@@ -51,6 +112,101 @@ type Config struct {
 	MaxSelectPointN      int           `toml:"max-select-point"`
 	MaxSelectSeriesN     int           `toml:"max-select-series"`
 	MaxSelectBucketsN    int           `toml:"max-select-buckets"`
+	MaxShowTagValuesN    int           `toml:"max-show-tag-values"`
+
+	MaxConcurrentBatchQueries int           `toml:"max-concurrent-batch-queries"`
+	MaxQueuedQueries          int           `toml:"max-queued-queries"`
+	QueryQueueTimeout         toml.Duration `toml:"query-queue-timeout"`
+
+	// MaxConcurrentRemoteRequests limits the iterator requests of other
+	// nodes served at once. They are not counted by max-concurrent-queries,
+	// which only limits the queries of this node.
+	MaxConcurrentRemoteRequests int           `toml:"max-concurrent-remote-requests"`
+	RemoteRequestQueueTimeout   toml.Duration `toml:"remote-request-queue-timeout"`
+
+	MaxQueryMemory          toml.Size     `toml:"max-query-memory"`
+	QueryMemoryBudget       toml.Size     `toml:"query-memory-budget"`
+	QueryMemoryQueueTimeout toml.Duration `toml:"query-memory-queue-timeout"`
+
+	// QuerySpillDir is the directory queries spill to. The default directory
+	// for temporary files is used if it is empty.
+	QuerySpillDir       string    `toml:"query-spill-dir"`
+	QuerySpillThreshold toml.Size `toml:"query-spill-threshold"`
+
+	IdempotencyKeyTTL  toml.Duration `toml:"idempotency-key-ttl"`
+	MaxIdempotencyKeys int           `toml:"max-idempotency-keys"`
+
+	// HedgedReadDelay is the time a read of a remote shard waits for a
+	// response before it is hedged to another owner of the shard.
+	HedgedReadDelay toml.Duration `toml:"hedged-read-delay"`
+
+	ForeignEndpoints []ForeignEndpoint `toml:"foreign-endpoint"`
+}
+
+// ForeignEndpoint is a data node of another cluster whose shards are queried
+// along with the local ones, so that SELECTs read the data of both clusters.
+// The federated databases must exist in both clusters.
+type ForeignEndpoint struct {
+	Name string `toml:"name"`
+
+	// Address is the cluster TCP address of the data node.
+	Address string `toml:"address"`
+
+	// Databases are the databases queried on the endpoint. Every database
+	// is queried when it is empty.
+	Databases []string `toml:"databases"`
+
+	Timeout toml.Duration `toml:"timeout"`
+}
+
+// Validate returns an error if the endpoint is invalid.
+func (e ForeignEndpoint) Validate() error {
+	if e.Name == "" {
+		return errors.New("foreign-endpoint name is required")
+	} else if e.Address == "" {
+		return fmt.Errorf("foreign-endpoint %s: address is required", e.Name)
+	} else if e.Timeout < 0 {
+		return fmt.Errorf("foreign-endpoint %s: timeout must not be negative", e.Name)
+	}
+	return nil
+}
+
+// Federates returns true if database is queried on the endpoint.
+func (e ForeignEndpoint) Federates(database string) bool {
+	if len(e.Databases) == 0 {
+		return true
+	}
+	for _, db := range e.Databases {
+		if db == database {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if the config is invalid.
+func (c Config) Validate() error {
+	if c.MaxConcurrentRemoteRequests < 0 {
+		return errors.New("max-concurrent-remote-requests must not be negative")
+	}
+	if c.HedgedReadDelay < 0 {
+		return errors.New("hedged-read-delay must not be negative")
+	}
+	if c.MaxIdempotencyKeys < 0 {
+		return errors.New("max-idempotency-keys must not be negative")
+	}
+
+	names := make(map[string]struct{}, len(c.ForeignEndpoints))
+	for _, e := range c.ForeignEndpoints {
+		if err := e.Validate(); err != nil {
+			return err
+		}
+		if _, ok := names[e.Name]; ok {
+			return fmt.Errorf("foreign-endpoint %s is defined twice", e.Name)
+		}
+		names[e.Name] = struct{}{}
+	}
+	return nil
 }
 
 // NewConfig returns an instance of Config with defaults.
@@ -65,18 +221,47 @@ func NewConfig() Config {
 		MaxConcurrentQueries: DefaultMaxConcurrentQueries,
 		MaxSelectPointN:      DefaultMaxSelectPointN,
 		MaxSelectSeriesN:     DefaultMaxSelectSeriesN,
+		MaxShowTagValuesN:    DefaultMaxShowTagValuesN,
+
+		MaxConcurrentBatchQueries: DefaultMaxConcurrentBatchQueries,
+		MaxQueuedQueries:          DefaultMaxQueuedQueries,
+		QueryQueueTimeout:         toml.Duration(DefaultQueryQueueTimeout),
+
+		MaxConcurrentRemoteRequests: DefaultMaxConcurrentRemoteRequests,
+		RemoteRequestQueueTimeout:   toml.Duration(DefaultRemoteRequestQueueTimeout),
+
+		MaxQueryMemory:          DefaultMaxQueryMemory,
+		QueryMemoryBudget:       DefaultQueryMemoryBudget,
+		QueryMemoryQueueTimeout: toml.Duration(DefaultQueryMemoryQueueTimeout),
+
+		QuerySpillThreshold: DefaultQuerySpillThreshold,
+
+		IdempotencyKeyTTL:  toml.Duration(DefaultIdempotencyKeyTTL),
+		MaxIdempotencyKeys: DefaultMaxIdempotencyKeys,
+
+		HedgedReadDelay: toml.Duration(DefaultHedgedReadDelay),
 	}
 }
 
 // Diagnostics returns a diagnostics representation of a subset of the Config.
 func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
 	return diagnostics.RowFromMap(map[string]interface{}{
-		"write-timeout":          c.WriteTimeout,
-		"max-concurrent-queries": c.MaxConcurrentQueries,
-		"query-timeout":          c.QueryTimeout,
-		"log-queries-after":      c.LogQueriesAfter,
-		"max-select-point":       c.MaxSelectPointN,
-		"max-select-series":      c.MaxSelectSeriesN,
-		"max-select-buckets":     c.MaxSelectBucketsN,
+		"write-timeout":                  c.WriteTimeout,
+		"max-concurrent-queries":         c.MaxConcurrentQueries,
+		"query-timeout":                  c.QueryTimeout,
+		"log-queries-after":              c.LogQueriesAfter,
+		"max-select-point":               c.MaxSelectPointN,
+		"max-select-series":              c.MaxSelectSeriesN,
+		"max-select-buckets":             c.MaxSelectBucketsN,
+		"max-show-tag-values":            c.MaxShowTagValuesN,
+		"max-concurrent-batch-queries":   c.MaxConcurrentBatchQueries,
+		"max-queued-queries":             c.MaxQueuedQueries,
+		"max-concurrent-remote-requests": c.MaxConcurrentRemoteRequests,
+		"max-query-memory":               c.MaxQueryMemory,
+		"query-memory-budget":            c.QueryMemoryBudget,
+		"query-spill-threshold":          c.QuerySpillThreshold,
+		"idempotency-key-ttl":            c.IdempotencyKeyTTL,
+		"max-idempotency-keys":           c.MaxIdempotencyKeys,
+		"hedged-read-delay":              c.HedgedReadDelay,
 	}), nil
 }
@@ -25,3 +25,38 @@ write-timeout = "20s"
 		t.Fatalf("unexpected write timeout s: %s", c.WriteTimeout)
 	}
 }
+
+func TestConfig_ForeignEndpoints(t *testing.T) {
+	var c coordinator.Config
+	if _, err := toml.Decode(`
+[[foreign-endpoint]]
+name = "eu"
+address = "eu-data-0:8088"
+databases = ["telegraf"]
+timeout = "5s"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.ForeignEndpoints) != 1 {
+		t.Fatalf("unexpected foreign endpoints: %v", c.ForeignEndpoints)
+	}
+	e := c.ForeignEndpoints[0]
+	if e.Address != "eu-data-0:8088" || time.Duration(e.Timeout) != 5*time.Second {
+		t.Fatalf("unexpected foreign endpoint: %+v", e)
+	} else if !e.Federates("telegraf") || e.Federates("db0") {
+		t.Fatalf("unexpected federated databases: %v", e.Databases)
+	}
+
+	c.ForeignEndpoints = append(c.ForeignEndpoints, coordinator.ForeignEndpoint{Name: "eu", Address: "eu-data-1:8088"})
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for duplicate endpoint name")
+	}
+	c.ForeignEndpoints = []coordinator.ForeignEndpoint{{Name: "eu"}}
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for missing address")
+	}
+}
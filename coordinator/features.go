@@ -0,0 +1,305 @@
+package coordinator
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/freetsdb/freetsdb"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// ProtocolVersion is the version of the cluster protocol spoken by this
+// node. Nodes that do not answer the handshake speak version 0.
+const ProtocolVersion = 1
+
+// The features of the cluster protocol that nodes of older versions lack.
+const (
+	// FeaturePointBatches is the support of shard writes with their points
+	// encoded as a batch.
+	FeaturePointBatches = "point-batches"
+
+	// FeatureShowQueries is the support of listing the queries of a node
+	// for SHOW QUERIES.
+	FeatureShowQueries = "show-queries"
+
+	// FeatureIndexEpoch is the support of reporting the index epoch of a
+	// database for the conditional metadata queries.
+	FeatureIndexEpoch = "index-epoch"
+
+	// FeatureMeasurementStats is the support of reporting the measurement
+	// statistics of the shards of a node for SHOW MEASUREMENT STATS.
+	FeatureMeasurementStats = "measurement-stats"
+
+	// FeatureDiskUsage is the support of reporting the disk usage of the
+	// shards of a node and its history for SHOW DISK USAGE.
+	FeatureDiskUsage = "disk-usage"
+)
+
+// Features are the features of the cluster protocol supported by this node.
+var Features = []string{
+	FeaturePointBatches,
+	FeatureShowQueries,
+	FeatureIndexEpoch,
+	FeatureMeasurementStats,
+	FeatureDiskUsage,
+}
+
+const (
+	// DefaultHandshakeTimeout is the time a node has to answer the handshake
+	// before it is taken to be of a version without it.
+	DefaultHandshakeTimeout = 2 * time.Second
+
+	// DefaultFeaturesTTL is the time the features of a node are used before
+	// the handshake is repeated, to pick up the nodes upgraded since.
+	DefaultFeaturesTTL = time.Minute
+)
+
+// NodeFeatures is the version and the features of a data node.
+type NodeFeatures struct {
+	NodeID   uint64   `json:"node_id"`
+	Version  string   `json:"version,omitempty"`
+	Protocol int      `json:"protocol"`
+	Features []string `json:"features"`
+
+	// Err is set if the node could not be reached.
+	Err string `json:"error,omitempty"`
+}
+
+// Has returns true if the node supports feature.
+func (f *NodeFeatures) Has(feature string) bool {
+	for _, name := range f.Features {
+		if name == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// ClusterFeatures is the features of every data node of the cluster.
+type ClusterFeatures struct {
+	Nodes []NodeFeatures `json:"nodes"`
+
+	// Effective are the features supported by every node that could be
+	// reached, the ones the cluster as a whole can use.
+	Effective []string `json:"effective"`
+}
+
+// featuresEntry is the result of a handshake with a node.
+type featuresEntry struct {
+	ready    chan struct{}
+	features NodeFeatures
+	err      error
+	expires  time.Time
+}
+
+// FeatureNegotiator negotiates the features of the cluster protocol used
+// with each node, so that the nodes of a cluster being upgraded one by one
+// keep exchanging the messages the others understand. A nil negotiator takes
+// every node to support every feature.
+type FeatureNegotiator struct {
+	// Version is the version of this node.
+	Version string
+
+	// Timeout is the time a node has to answer the handshake.
+	Timeout time.Duration
+
+	// TTL is the time the features of a node are used before the handshake
+	// is repeated.
+	TTL time.Duration
+
+	Node *freetsdb.Node
+
+	MetaClient interface {
+		DataNode(id uint64) (ni *meta.NodeInfo, err error)
+		DataNodes() ([]meta.NodeInfo, error)
+	}
+
+	mu    sync.Mutex
+	nodes map[uint64]*featuresEntry
+}
+
+// NewFeatureNegotiator returns a FeatureNegotiator for a node of version.
+func NewFeatureNegotiator(version string) *FeatureNegotiator {
+	return &FeatureNegotiator{
+		Version: version,
+		Timeout: DefaultHandshakeTimeout,
+		TTL:     DefaultFeaturesTTL,
+		nodes:   make(map[uint64]*featuresEntry),
+	}
+}
+
+// Supports returns true if the node with nodeID supports feature. A node that
+// cannot be reached is assumed to support it, as the request will fail anyway.
+func (n *FeatureNegotiator) Supports(nodeID uint64, feature string) bool {
+	if n == nil {
+		return true
+	}
+	f, err := n.NodeFeatures(nodeID)
+	if err != nil {
+		return true
+	}
+	return f.Has(feature)
+}
+
+// Forget drops the features of the node with nodeID, so that the handshake is
+// repeated on the next request. It is called when a request to the node
+// fails, as the node may have been restarted with another version.
+func (n *FeatureNegotiator) Forget(nodeID uint64) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if e := n.nodes[nodeID]; e != nil {
+		select {
+		case <-e.ready:
+			delete(n.nodes, nodeID)
+		default:
+			// The handshake in progress picks up the current version.
+		}
+	}
+}
+
+// NodeFeatures returns the features of the node with nodeID.
+func (n *FeatureNegotiator) NodeFeatures(nodeID uint64) (NodeFeatures, error) {
+	if n.Node != nil && nodeID == n.Node.ID {
+		return n.local(), nil
+	}
+
+	n.mu.Lock()
+	e := n.nodes[nodeID]
+	if e != nil {
+		select {
+		case <-e.ready:
+			if time.Now().After(e.expires) {
+				e = nil
+			}
+		default:
+		}
+	}
+	if e != nil {
+		n.mu.Unlock()
+		<-e.ready
+		return e.features, e.err
+	}
+
+	// Only one handshake is made with a node at once.
+	e = &featuresEntry{ready: make(chan struct{})}
+	n.nodes[nodeID] = e
+	n.mu.Unlock()
+
+	e.features, e.err = n.handshake(nodeID)
+	if e.err == nil {
+		e.expires = time.Now().Add(n.TTL)
+	}
+	close(e.ready)
+	return e.features, e.err
+}
+
+// ClusterFeatures returns the features of every data node and the features
+// supported by all of them.
+func (n *FeatureNegotiator) ClusterFeatures() (*ClusterFeatures, error) {
+	nodes, err := n.MetaClient.DataNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	cf := &ClusterFeatures{Nodes: make([]NodeFeatures, len(nodes))}
+	var wg sync.WaitGroup
+	for i := range nodes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f, err := n.NodeFeatures(nodes[i].ID)
+			if err != nil {
+				f = NodeFeatures{NodeID: nodes[i].ID, Err: err.Error()}
+			}
+			cf.Nodes[i] = f
+		}(i)
+	}
+	wg.Wait()
+
+	sort.Slice(cf.Nodes, func(i, j int) bool { return cf.Nodes[i].NodeID < cf.Nodes[j].NodeID })
+	cf.Effective = effectiveFeatures(cf.Nodes)
+	return cf, nil
+}
+
+// effectiveFeatures returns the features of this node supported by all the
+// nodes that could be reached.
+func effectiveFeatures(nodes []NodeFeatures) []string {
+	effective := []string{}
+	for _, feature := range Features {
+		supported := true
+		for i := range nodes {
+			if nodes[i].Err == "" && !nodes[i].Has(feature) {
+				supported = false
+				break
+			}
+		}
+		if supported {
+			effective = append(effective, feature)
+		}
+	}
+	return effective
+}
+
+// local returns the features of this node.
+func (n *FeatureNegotiator) local() NodeFeatures {
+	return NodeFeatures{
+		NodeID:   n.Node.ID,
+		Version:  n.Version,
+		Protocol: ProtocolVersion,
+		Features: Features,
+	}
+}
+
+// handshake asks the node with nodeID for its version and features. A node
+// that does not answer in time is of a version without the handshake.
+func (n *FeatureNegotiator) handshake(nodeID uint64) (NodeFeatures, error) {
+	ni, err := n.MetaClient.DataNode(nodeID)
+	if err != nil {
+		return NodeFeatures{}, err
+	} else if ni == nil {
+		return NodeFeatures{}, fmt.Errorf("node %d does not exist", nodeID)
+	}
+
+	conn, err := net.DialTimeout("tcp", ni.TCPHost, n.Timeout)
+	if err != nil {
+		return NodeFeatures{}, err
+	}
+	defer conn.Close()
+
+	// The request has no body. Older nodes skip its bytes as unknown
+	// message types without answering.
+	conn.SetDeadline(time.Now().Add(n.Timeout))
+	if _, err := conn.Write([]byte{MuxHeader}); err != nil {
+		return NodeFeatures{}, err
+	} else if err := WriteTLV(conn, handshakeRequestMessage, nil); err != nil {
+		return NodeFeatures{}, err
+	}
+
+	var typ [1]byte
+	if _, err := io.ReadFull(conn, typ[:]); err != nil {
+		if e, ok := err.(net.Error); (ok && e.Timeout()) || err == io.EOF {
+			return NodeFeatures{NodeID: nodeID, Features: []string{}}, nil
+		}
+		return NodeFeatures{}, err
+	} else if typ[0] != handshakeResponseMessage {
+		return NodeFeatures{}, fmt.Errorf("unexpected handshake response type: %d", typ[0])
+	}
+
+	var resp HandshakeResponse
+	if err := DecodeLV(conn, &resp); err != nil {
+		return NodeFeatures{}, err
+	}
+	return NodeFeatures{
+		NodeID:   nodeID,
+		Version:  resp.Version,
+		Protocol: resp.Protocol,
+		Features: resp.Features,
+	}, nil
+}
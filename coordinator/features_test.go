@@ -0,0 +1,88 @@
+package coordinator_test
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/freetsdb/freetsdb/coordinator"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// featuresMetaClient returns a data node for each address.
+type featuresMetaClient struct {
+	hosts map[uint64]string
+}
+
+func (m *featuresMetaClient) DataNode(nodeID uint64) (*meta.NodeInfo, error) {
+	return &meta.NodeInfo{ID: nodeID, TCPHost: m.hosts[nodeID]}, nil
+}
+
+func (m *featuresMetaClient) DataNodes() ([]meta.NodeInfo, error) {
+	var nodes []meta.NodeInfo
+	for id, host := range m.hosts {
+		nodes = append(nodes, meta.NodeInfo{ID: id, TCPHost: host})
+	}
+	return nodes, nil
+}
+
+// Ensure the features of current nodes are negotiated, and nodes that do not
+// answer the handshake are taken to support none.
+func TestFeatureNegotiator_ClusterFeatures(t *testing.T) {
+	s := NewService()
+	s.Version = "1.2.3"
+	s.ln = MustListen("tcp", "127.0.0.1:0")
+	s.Listener = &muxListener{s.ln}
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// An older node reads the handshake without answering it.
+	ln := MustListen("tcp", "127.0.0.1:0")
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				var buf [64]byte
+				for {
+					if _, err := conn.Read(buf[:]); err != nil {
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	n := coordinator.NewFeatureNegotiator("1.2.3")
+	n.Timeout = 100 * time.Millisecond
+	n.MetaClient = &featuresMetaClient{hosts: map[uint64]string{
+		1: s.Addr().String(),
+		2: ln.Addr().String(),
+	}}
+
+	if !n.Supports(1, coordinator.FeaturePointBatches) {
+		t.Fatal("expected node 1 to support point batches")
+	} else if n.Supports(2, coordinator.FeaturePointBatches) {
+		t.Fatal("expected node 2 not to support point batches")
+	}
+
+	cf, err := n.ClusterFeatures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []coordinator.NodeFeatures{
+		{NodeID: 1, Version: "1.2.3", Protocol: coordinator.ProtocolVersion, Features: coordinator.Features},
+		{NodeID: 2, Features: []string{}},
+	}; !reflect.DeepEqual(cf.Nodes, exp) {
+		t.Fatalf("unexpected nodes: %+v", cf.Nodes)
+	} else if len(cf.Effective) != 0 {
+		t.Fatalf("unexpected effective features: %v", cf.Effective)
+	}
+}
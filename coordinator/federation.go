@@ -0,0 +1,147 @@
+package coordinator
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+// foreignIteratorCreator creates iterators on a data node of a foreign
+// cluster. The node maps the shards of its own cluster, so predicates and
+// aggregates are pushed down to the shards that hold the data and only the
+// partial results are streamed back.
+type foreignIteratorCreator struct {
+	endpoint         ForeignEndpoint
+	minTime, maxTime time.Time
+
+	mu     sync.Mutex
+	fields map[string]map[string]influxql.DataType
+}
+
+// newForeignIteratorCreator returns a foreignIteratorCreator for the shards of
+// endpoint between minTime and maxTime.
+func newForeignIteratorCreator(endpoint ForeignEndpoint, minTime, maxTime time.Time) *foreignIteratorCreator {
+	return &foreignIteratorCreator{
+		endpoint: endpoint,
+		minTime:  minTime,
+		maxTime:  maxTime,
+		fields:   make(map[string]map[string]influxql.DataType),
+	}
+}
+
+// error returns err as an error of the endpoint.
+func (ic *foreignIteratorCreator) error(err error) error {
+	return fmt.Errorf("foreign cluster %s: %w", ic.endpoint.Name, err)
+}
+
+// dial returns a connection to the endpoint. The deadline of the connection
+// is set to the timeout of the endpoint.
+func (ic *foreignIteratorCreator) dial() (net.Conn, error) {
+	timeout := time.Duration(ic.endpoint.Timeout)
+	if timeout == 0 {
+		timeout = DefaultForeignEndpointTimeout
+	}
+
+	conn, err := net.DialTimeout("tcp", ic.endpoint.Address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// Write the cluster multiplexing header byte
+	if _, err := conn.Write([]byte{MuxHeader}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// CreateIterator creates a streaming iterator on the foreign cluster.
+func (ic *foreignIteratorCreator) CreateIterator(ctx context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+	conn, err := ic.dial()
+	if err != nil {
+		return nil, ic.error(err)
+	}
+
+	var resp CreateIteratorResponse
+	if err := func() error {
+		if err := EncodeTLV(conn, createIteratorRequestMessage, &CreateIteratorRequest{
+			Measurement: *(m.Clone()),
+			Opt:         opt,
+			Federated:   true,
+		}); err != nil {
+			return err
+		}
+
+		if _, err := DecodeTLV(conn, &resp); err != nil {
+			return err
+		}
+		return resp.Err
+	}(); err != nil {
+		conn.Close()
+		return nil, ic.error(err)
+	}
+
+	// The points are streamed for as long as the query runs, so only the
+	// request is bound by the timeout.
+	conn.SetDeadline(time.Time{})
+
+	itr := query.NewReaderIterator(ctx, conn, resp.typ, resp.stats)
+	if ctx.Done() != nil {
+		itr = query.NewCloseInterruptIterator(itr, ctx.Done())
+	}
+	return itr, nil
+}
+
+// FieldDimensions returns the fields and dimensions of m on the foreign cluster.
+func (ic *foreignIteratorCreator) FieldDimensions(m *influxql.Measurement) (fields map[string]influxql.DataType, dimensions map[string]struct{}, err error) {
+	conn, err := ic.dial()
+	if err != nil {
+		return nil, nil, ic.error(err)
+	}
+	defer conn.Close()
+
+	if err := EncodeTLV(conn, fieldDimensionsRequestMessage, &FieldDimensionsRequest{
+		Measurement: *m,
+		Federated:   true,
+		MinTime:     ic.minTime.UnixNano(),
+		MaxTime:     ic.maxTime.UnixNano(),
+	}); err != nil {
+		return nil, nil, ic.error(err)
+	}
+
+	var resp FieldDimensionsResponse
+	if _, err := DecodeTLV(conn, &resp); err != nil {
+		return nil, nil, ic.error(err)
+	} else if resp.Err != nil {
+		return nil, nil, ic.error(resp.Err)
+	}
+
+	ic.mu.Lock()
+	ic.fields[m.String()] = resp.Fields
+	ic.mu.Unlock()
+	return resp.Fields, resp.Dimensions, nil
+}
+
+// MapType returns the type of field in m on the foreign cluster. The fields
+// of m are fetched once per query.
+func (ic *foreignIteratorCreator) MapType(m *influxql.Measurement, field string) influxql.DataType {
+	ic.mu.Lock()
+	fields, ok := ic.fields[m.String()]
+	ic.mu.Unlock()
+	if !ok {
+		var err error
+		if fields, _, err = ic.FieldDimensions(m); err != nil {
+			ic.mu.Lock()
+			ic.fields[m.String()] = nil
+			ic.mu.Unlock()
+			return influxql.Unknown
+		}
+	}
+	return fields[field]
+}
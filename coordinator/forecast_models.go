@@ -0,0 +1,213 @@
+package coordinator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+// forecastModelStore stores the forecast models of a statement in the
+// forecast models measurement of a database. The models named by the
+// statement are read before it is executed, and the models fitted while
+// executing it are written afterwards.
+//
+// The models of a name are stored as series tagged with the name of the
+// model, the measurement forecast and the tags of the group forecast.
+type forecastModelStore struct {
+	database        string
+	retentionPolicy string
+
+	// write is false if the models fitted cannot be written.
+	write bool
+
+	mu     sync.Mutex
+	models map[string]query.ForecastModel
+	points []models.Point
+}
+
+// forecastModelNames returns the names of the models of the forecast() calls
+// of a statement.
+func forecastModelNames(stmt *influxql.SelectStatement) []string {
+	var names []string
+	seen := make(map[string]struct{})
+	for _, f := range stmt.Fields {
+		influxql.WalkFunc(f.Expr, func(n influxql.Node) {
+			call, ok := n.(*influxql.Call)
+			if !ok {
+				return
+			}
+			if name := query.ForecastModelName(call); name != "" {
+				if _, ok := seen[name]; !ok {
+					seen[name] = struct{}{}
+					names = append(names, name)
+				}
+			}
+		})
+	}
+	return names
+}
+
+// forecastModelTags returns the tags of the series storing a model, and false
+// if the tags of the group forecast clash with those of the model.
+func forecastModelTags(name, measurement string, tags query.Tags) (models.Tags, bool) {
+	m := map[string]string{"model": name, "measurement": measurement}
+	for k, v := range tags.KeyValues() {
+		if _, ok := m[k]; ok {
+			return nil, false
+		}
+		m[k] = v
+	}
+	return models.NewTags(m), true
+}
+
+// forecastModels returns the store of the models named by the forecast()
+// calls of a statement, or nil if it names none. The models are stored in the
+// default retention policy of the database of the statement, and are only
+// written if the user may write to it.
+func (e *StatementExecutor) forecastModels(ctx context.Context, stmt *influxql.SelectStatement, ectx *query.ExecutionContext) (*forecastModelStore, error) {
+	names := forecastModelNames(stmt)
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	var database string
+	for _, source := range stmt.Sources {
+		if m, ok := source.(*influxql.Measurement); ok && m.Database != "" {
+			database = m.Database
+			break
+		}
+	}
+	di := e.MetaClient.Database(database)
+	if di == nil || di.DefaultRetentionPolicy == "" {
+		return nil, nil
+	}
+
+	a := ectx.ExecutionOptions.Authorizer
+	s := &forecastModelStore{
+		database:        di.Name,
+		retentionPolicy: di.DefaultRetentionPolicy,
+		write:           !ectx.ReadOnly && (query.AuthorizerIsOpen(a) || a.AuthorizeDatabase(influxql.WritePrivilege, di.Name)),
+		models:          make(map[string]query.ForecastModel),
+	}
+	if err := e.loadForecastModels(ctx, s, names, ectx.ExecutionOptions); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadForecastModels reads the models of names from the forecast models
+// measurement.
+func (e *StatementExecutor) loadForecastModels(ctx context.Context, s *forecastModelStore, names []string, opt query.ExecutionOptions) error {
+	conds := make([]string, len(names))
+	for i, name := range names {
+		conds[i] = fmt.Sprintf("model = %s", influxql.QuoteString(name))
+	}
+	q := fmt.Sprintf(`SELECT last(alpha) AS alpha, last(beta) AS beta, last(gamma) AS gamma, last(season) AS season FROM %s.%s.%s WHERE %s GROUP BY *`,
+		influxql.QuoteIdent(s.database), influxql.QuoteIdent(s.retentionPolicy), influxql.QuoteIdent(query.ForecastModelsMeasurement),
+		strings.Join(conds, " OR "))
+	stmt, err := influxql.ParseStatement(q)
+	if err != nil {
+		return err
+	}
+
+	cur, err := e.createIterators(ctx, stmt.(*influxql.SelectStatement), opt)
+	if err != nil {
+		return err
+	}
+	em := query.NewEmitter(cur, 0)
+	defer em.Close()
+
+	for {
+		row, _, err := em.Emit()
+		if err != nil {
+			return err
+		} else if row == nil {
+			return nil
+		}
+
+		// Each series has a single row with the last model saved.
+		if len(row.Values) != 1 {
+			continue
+		}
+		values := row.Values[0]
+		alpha, ok1 := values[1].(float64)
+		beta, ok2 := values[2].(float64)
+		gamma, ok3 := values[3].(float64)
+		season, ok4 := values[4].(int64)
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			continue
+		}
+		s.models[string(models.NewTags(row.Tags).HashKey())] = query.ForecastModel{
+			Season: int(season),
+			Alpha:  alpha,
+			Beta:   beta,
+			Gamma:  gamma,
+		}
+	}
+}
+
+// LoadForecastModel returns the model of a name fitted for a group.
+func (s *forecastModelStore) LoadForecastModel(name, measurement string, tags query.Tags) (query.ForecastModel, bool) {
+	t, ok := forecastModelTags(name, measurement, tags)
+	if !ok {
+		return query.ForecastModel{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.models[string(t.HashKey())]
+	return m, ok
+}
+
+// SaveForecastModel saves the model of a name fitted for a group.
+func (s *forecastModelStore) SaveForecastModel(name, measurement string, tags query.Tags, m query.ForecastModel) {
+	t, ok := forecastModelTags(name, measurement, tags)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := string(t.HashKey())
+	if prev, ok := s.models[key]; ok && prev == m {
+		return
+	}
+	s.models[key] = m
+
+	if !s.write {
+		return
+	}
+	pt, err := models.NewPoint(query.ForecastModelsMeasurement, t, models.Fields{
+		"alpha":  m.Alpha,
+		"beta":   m.Beta,
+		"gamma":  m.Gamma,
+		"season": int64(m.Season),
+	}, time.Now())
+	if err != nil {
+		return
+	}
+	s.points = append(s.points, pt)
+}
+
+// flush writes the models fitted into the forecast models measurement.
+func (s *forecastModelStore) flush(w pointsWriter) error {
+	s.mu.Lock()
+	points := s.points
+	s.points = nil
+	s.mu.Unlock()
+
+	if len(points) == 0 {
+		return nil
+	}
+	return w.WritePointsInto(&IntoWriteRequest{
+		Database:        s.database,
+		RetentionPolicy: s.retentionPolicy,
+		Points:          points,
+	})
+}
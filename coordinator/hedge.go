@@ -0,0 +1,122 @@
+package coordinator
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/freetsdb/freetsdb/models"
+)
+
+// The keys for statistics generated by the "hedge" module.
+const (
+	statRemoteReads = "remoteReads"
+	statHedgedReads = "hedgedReads"
+	statHedgeWins   = "hedgeWins"
+)
+
+// ReadHedger hedges the reads of remote shards. When an owner of a shard has
+// not responded to a read after a delay, the read is sent to another owner
+// too, and the first response is used. This bounds the latency a single slow
+// node adds to the queries reading from it.
+type ReadHedger struct {
+	// Delay is the time a read waits for a response before it is hedged.
+	// Reads are not hedged when it is zero.
+	Delay time.Duration
+
+	stats *HedgeStatistics
+}
+
+// NewReadHedger returns a ReadHedger that hedges reads after delay.
+func NewReadHedger(delay time.Duration) *ReadHedger {
+	return &ReadHedger{
+		Delay: delay,
+		stats: &HedgeStatistics{},
+	}
+}
+
+// HedgeStatistics keeps statistics related to hedged reads.
+type HedgeStatistics struct {
+	RemoteReads int64
+	HedgedReads int64
+	HedgeWins   int64
+}
+
+// Statistics returns statistics for periodic monitoring.
+func (h *ReadHedger) Statistics(tags map[string]string) []models.Statistic {
+	return []models.Statistic{{
+		Name: "hedge",
+		Tags: tags,
+		Values: map[string]interface{}{
+			statRemoteReads: atomic.LoadInt64(&h.stats.RemoteReads),
+			statHedgedReads: atomic.LoadInt64(&h.stats.HedgedReads),
+			statHedgeWins:   atomic.LoadInt64(&h.stats.HedgeWins),
+		},
+	}}
+}
+
+// hedgeResult is the result of a read of a node.
+type hedgeResult struct {
+	v      interface{}
+	err    error
+	hedged bool
+}
+
+// Read calls read with nodeID and returns its result. If read has not
+// returned after the delay, it is also called with one of alternates, and
+// the first successful result is returned. discard is called with the
+// successful result of the read that lost, once it returns. A read that
+// fails before the delay is not hedged.
+func (h *ReadHedger) Read(nodeID uint64, alternates []uint64, read func(nodeID uint64) (interface{}, error), discard func(v interface{})) (interface{}, error) {
+	if h == nil {
+		return read(nodeID)
+	}
+	atomic.AddInt64(&h.stats.RemoteReads, 1)
+	if h.Delay <= 0 || len(alternates) == 0 {
+		return read(nodeID)
+	}
+
+	ch := make(chan hedgeResult, 2)
+	call := func(id uint64, hedged bool) {
+		v, err := read(id)
+		ch <- hedgeResult{v: v, err: err, hedged: hedged}
+	}
+	go call(nodeID, false)
+
+	timer := time.NewTimer(h.Delay)
+	defer timer.Stop()
+
+	pending, hedged := 1, false
+	var firstErr error
+	for {
+		select {
+		case <-timer.C:
+			atomic.AddInt64(&h.stats.HedgedReads, 1)
+			pending, hedged = pending+1, true
+			go call(alternates[rand.Intn(len(alternates))], true)
+
+		case r := <-ch:
+			pending--
+			if r.err == nil {
+				if r.hedged {
+					atomic.AddInt64(&h.stats.HedgeWins, 1)
+				}
+				if pending > 0 {
+					go func() {
+						if r := <-ch; r.err == nil {
+							discard(r.v)
+						}
+					}()
+				}
+				return r.v, nil
+			}
+
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			if !hedged || pending == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
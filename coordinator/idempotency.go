@@ -0,0 +1,104 @@
+package coordinator
+
+import (
+	"sync"
+	"time"
+
+	"github.com/freetsdb/freetsdb/tsdb"
+)
+
+// idempotencyScope identifies the write of an idempotency key. The same key
+// sent by another user or to another retention policy is another write, so
+// one client cannot learn of or suppress the writes of another.
+type idempotencyScope struct {
+	user            string
+	database        string
+	retentionPolicy string
+	key             string
+}
+
+// idempotentWrite is the outcome of the write made with an idempotency key.
+type idempotentWrite struct {
+	scope   idempotencyScope
+	expires time.Time
+	err     error
+	done    chan struct{} // closed once the write completed
+}
+
+// idempotencyKeys records the idempotency keys of recent writes, so retries
+// of a batch are not written twice. Completed writes are kept in the order
+// they expire. The keys are only known to the node that received the write,
+// so a retry sent to another node is written again.
+type idempotencyKeys struct {
+	mu        sync.Mutex
+	writes    map[idempotencyScope]*idempotentWrite
+	completed []*idempotentWrite
+}
+
+func newIdempotencyKeys() *idempotencyKeys {
+	return &idempotencyKeys{writes: make(map[idempotencyScope]*idempotentWrite)}
+}
+
+// begin returns the write recorded for scope. If the key was not seen, a new
+// write is recorded and owner is true: the caller must write the batch and
+// then call finish.
+//
+// At most max keys are recorded, unless max is zero. The oldest completed
+// write is forgotten to record a new one, and if every key belongs to a write
+// in progress, the new write is not recorded and w is nil.
+func (k *idempotencyKeys) begin(scope idempotencyScope, now time.Time, max int) (w *idempotentWrite, owner bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.expire(now)
+	if w := k.writes[scope]; w != nil {
+		return w, false
+	}
+
+	if max > 0 && len(k.writes) >= max {
+		if len(k.completed) == 0 {
+			return nil, true
+		}
+		delete(k.writes, k.completed[0].scope)
+		k.completed = k.completed[1:]
+	}
+
+	w = &idempotentWrite{scope: scope, done: make(chan struct{})}
+	k.writes[scope] = w
+	return w, true
+}
+
+// finish records the outcome of w. Keys of failed writes are forgotten so the
+// batch can be retried, but a partial write is kept since some of its points
+// were written.
+func (k *idempotencyKeys) finish(w *idempotentWrite, err error, expires time.Time) {
+	if w == nil {
+		return
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	w.err = err
+	close(w.done)
+
+	if _, ok := err.(tsdb.PartialWriteError); err != nil && !ok {
+		delete(k.writes, w.scope)
+	} else {
+		w.expires = expires
+		k.completed = append(k.completed, w)
+	}
+}
+
+// expire forgets the keys whose writes expired before now.
+func (k *idempotencyKeys) expire(now time.Time) {
+	var n int
+	for _, w := range k.completed {
+		if w.expires.After(now) {
+			break
+		}
+		delete(k.writes, w.scope)
+		n++
+	}
+	k.completed = k.completed[n:]
+}
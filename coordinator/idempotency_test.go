@@ -0,0 +1,106 @@
+package coordinator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/freetsdb/freetsdb/tsdb"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	keys := newIdempotencyKeys()
+	now := time.Unix(0, 0)
+	scope := idempotencyScope{user: "user0", database: "db0", retentionPolicy: "rp0", key: "a"}
+
+	w, owner := keys.begin(scope, now, 0)
+	if !owner {
+		t.Fatal("expected the first write to own the key")
+	}
+
+	// A retry waits for the write in progress.
+	if retry, owner := keys.begin(scope, now, 0); owner || retry != w {
+		t.Fatal("expected the retry to wait for the first write")
+	}
+
+	// Keys are recorded for each user, database and retention policy.
+	for _, other := range []idempotencyScope{
+		{user: "user1", database: "db0", retentionPolicy: "rp0", key: "a"},
+		{user: "user0", database: "db1", retentionPolicy: "rp0", key: "a"},
+		{user: "user0", database: "db0", retentionPolicy: "rp1", key: "a"},
+	} {
+		if _, owner := keys.begin(other, now, 0); !owner {
+			t.Fatalf("expected a write to %+v to own the key", other)
+		}
+	}
+
+	keys.finish(w, nil, now.Add(time.Minute))
+	select {
+	case <-w.done:
+	default:
+		t.Fatal("expected the write to be done")
+	}
+	if retry, owner := keys.begin(scope, now.Add(time.Second), 0); owner || retry.err != nil {
+		t.Fatal("expected the retry to be deduplicated")
+	}
+
+	// The key is forgotten once it expires.
+	if _, owner := keys.begin(scope, now.Add(time.Minute), 0); !owner {
+		t.Fatal("expected the expired key to be written again")
+	}
+}
+
+func TestIdempotencyKeys_Failed(t *testing.T) {
+	keys := newIdempotencyKeys()
+	now := time.Unix(0, 0)
+	a := idempotencyScope{database: "db0", key: "a"}
+	b := idempotencyScope{database: "db0", key: "b"}
+
+	// A failed write can be retried.
+	w, _ := keys.begin(a, now, 0)
+	keys.finish(w, errors.New("write failed"), now.Add(time.Minute))
+	if _, owner := keys.begin(a, now, 0); !owner {
+		t.Fatal("expected the failed write to be retried")
+	}
+
+	// A partial write is not retried since some points were written.
+	w, _ = keys.begin(b, now, 0)
+	keys.finish(w, tsdb.PartialWriteError{Dropped: 1}, now.Add(time.Minute))
+	if retry, owner := keys.begin(b, now, 0); owner {
+		t.Fatal("expected the partial write to be deduplicated")
+	} else if _, ok := retry.err.(tsdb.PartialWriteError); !ok {
+		t.Fatalf("unexpected error: %v", retry.err)
+	}
+}
+
+func TestIdempotencyKeys_Max(t *testing.T) {
+	keys := newIdempotencyKeys()
+	now := time.Unix(0, 0)
+	a := idempotencyScope{database: "db0", key: "a"}
+	b := idempotencyScope{database: "db0", key: "b"}
+	c := idempotencyScope{database: "db0", key: "c"}
+
+	wa, _ := keys.begin(a, now, 2)
+	wb, _ := keys.begin(b, now, 2)
+
+	// Every key belongs to a write in progress, so the write isn't recorded.
+	if w, owner := keys.begin(c, now, 2); !owner || w != nil {
+		t.Fatal("expected the write not to be recorded")
+	}
+	keys.finish(nil, nil, now.Add(time.Minute))
+
+	// The oldest completed write is forgotten for a new one.
+	keys.finish(wa, nil, now.Add(time.Minute))
+	keys.finish(wb, nil, now.Add(time.Minute))
+	if w, owner := keys.begin(c, now, 2); !owner || w == nil {
+		t.Fatal("expected the write to be recorded")
+	} else if len(keys.writes) != 2 {
+		t.Fatalf("unexpected number of keys: %d", len(keys.writes))
+	}
+	if _, owner := keys.begin(b, now, 2); owner {
+		t.Fatal("expected the newer write to be remembered")
+	}
+	if _, owner := keys.begin(a, now, 2); !owner {
+		t.Fatal("expected the oldest write to be forgotten")
+	}
+}
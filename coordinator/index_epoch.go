@@ -0,0 +1,116 @@
+package coordinator
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/freetsdb/freetsdb"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// ClusterIndex reports the index epochs of the databases across the cluster.
+// The epoch of a database combines its index epoch on every data node with
+// the index of the meta data, so that it changes whenever series are written
+// to or dropped from any node, and whenever the shards, retention policies or
+// users change.
+type ClusterIndex struct {
+	Node *freetsdb.Node
+
+	MetaClient interface {
+		DataNodes() ([]meta.NodeInfo, error)
+		Index() uint64
+	}
+
+	// TSDBStore reports the index epochs of the shards of this node.
+	TSDBStore interface {
+		IndexEpoch(database string) (uint64, time.Time)
+	}
+
+	// MetaExecutor asks the other data nodes for their index epochs.
+	MetaExecutor interface {
+		IndexEpochOnNode(nodeID uint64, database string) (uint64, time.Time, error)
+	}
+
+	mu     sync.Mutex
+	epochs map[string]indexEpoch
+}
+
+// indexEpoch is the epoch of a database and the time it was first seen.
+type indexEpoch struct {
+	epoch    uint64
+	modified time.Time
+}
+
+// IndexEpoch returns the index epoch of database across the cluster and the
+// time it was first seen. An error is returned if a data node does not
+// report its epoch, as the results cached by clients could then be stale.
+func (c *ClusterIndex) IndexEpoch(database string) (uint64, time.Time, error) {
+	nodes, err := c.MetaClient.DataNodes()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+
+	var localID uint64
+	if c.Node != nil {
+		localID = c.Node.ID
+	}
+
+	// Ask every node at once.
+	epochs := make([]uint64, len(nodes))
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		if n.ID == localID || c.MetaExecutor == nil {
+			epochs[i], _ = c.TSDBStore.IndexEpoch(database)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, nodeID uint64) {
+			defer wg.Done()
+			epoch, _, err := c.MetaExecutor.IndexEpochOnNode(nodeID, database)
+			if err != nil {
+				errs[i] = fmt.Errorf("index epoch of node %d: %s", nodeID, err)
+				return
+			}
+			epochs[i] = epoch
+		}(i, n.ID)
+	}
+	wg.Wait()
+
+	h := fnv.New64a()
+	var buf [8]byte
+	write := func(v uint64) {
+		binary.BigEndian.PutUint64(buf[:], v)
+		h.Write(buf[:])
+	}
+	write(c.MetaClient.Index())
+	for i, n := range nodes {
+		if errs[i] != nil {
+			return 0, time.Time{}, errs[i]
+		}
+		write(n.ID)
+		write(epochs[i])
+	}
+	epoch := h.Sum64()
+
+	// The modification times of the nodes are not compared as their clocks
+	// may differ, so the time the epoch changed is the time it was first
+	// seen here.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.epochs == nil {
+		c.epochs = make(map[string]indexEpoch)
+	}
+	e, ok := c.epochs[database]
+	if !ok || e.epoch != epoch {
+		e = indexEpoch{epoch: epoch, modified: time.Now().UTC()}
+		c.epochs[database] = e
+	}
+	return e.epoch, e.modified, nil
+}
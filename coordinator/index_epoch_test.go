@@ -0,0 +1,95 @@
+package coordinator_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/freetsdb/freetsdb"
+	"github.com/freetsdb/freetsdb/coordinator"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+func TestClusterIndex_IndexEpoch(t *testing.T) {
+	metaIndex := uint64(1)
+	epochs := map[uint64]uint64{1: 10, 2: 20, 3: 30}
+	var nodeErr error
+
+	node := freetsdb.NewNode("/tmp/node")
+	node.ID = 1
+	c := &coordinator.ClusterIndex{
+		Node: node,
+		MetaClient: &indexMetaClient{
+			nodes: []meta.NodeInfo{{ID: 1}, {ID: 2}, {ID: 3}},
+			index: func() uint64 { return metaIndex },
+		},
+		TSDBStore: indexEpochFunc(func(database string) (uint64, time.Time) {
+			return epochs[1], time.Time{}
+		}),
+		MetaExecutor: nodeIndexEpochFunc(func(nodeID uint64, database string) (uint64, time.Time, error) {
+			if nodeID == 1 {
+				t.Fatal("unexpected request to the local node")
+			} else if database != "db0" {
+				t.Fatalf("unexpected database: %s", database)
+			}
+			if nodeID == 3 && nodeErr != nil {
+				return 0, time.Time{}, nodeErr
+			}
+			return epochs[nodeID], time.Time{}, nil
+		}),
+	}
+
+	epoch, modified, err := c.IndexEpoch("db0")
+	if err != nil {
+		t.Fatal(err)
+	} else if modified.IsZero() {
+		t.Fatal("expected a modification time")
+	}
+
+	// The epoch is unchanged until a node's index or the meta data changes.
+	if e, m, err := c.IndexEpoch("db0"); err != nil {
+		t.Fatal(err)
+	} else if e != epoch || !m.Equal(modified) {
+		t.Fatalf("unexpected epoch: %d, %s", e, m)
+	}
+
+	epochs[2]++
+	e, _, err := c.IndexEpoch("db0")
+	if err != nil {
+		t.Fatal(err)
+	} else if e == epoch {
+		t.Fatal("expected the epoch to change with a remote node")
+	}
+	epoch = e
+
+	metaIndex++
+	if e, _, err := c.IndexEpoch("db0"); err != nil {
+		t.Fatal(err)
+	} else if e == epoch {
+		t.Fatal("expected the epoch to change with the meta data")
+	}
+
+	// An unreachable node makes the epoch unknown.
+	nodeErr = errors.New("connection refused")
+	if _, _, err := c.IndexEpoch("db0"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+type indexMetaClient struct {
+	nodes []meta.NodeInfo
+	index func() uint64
+}
+
+func (c *indexMetaClient) DataNodes() ([]meta.NodeInfo, error) { return c.nodes, nil }
+func (c *indexMetaClient) Index() uint64                       { return c.index() }
+
+type indexEpochFunc func(database string) (uint64, time.Time)
+
+func (fn indexEpochFunc) IndexEpoch(database string) (uint64, time.Time) { return fn(database) }
+
+type nodeIndexEpochFunc func(nodeID uint64, database string) (uint64, time.Time, error)
+
+func (fn nodeIndexEpochFunc) IndexEpochOnNode(nodeID uint64, database string) (uint64, time.Time, error) {
+	return fn(nodeID, database)
+}
@@ -17,6 +17,15 @@ It has these top-level messages:
 	CreateIteratorResponse
 	FieldDimensionsRequest
 	FieldDimensionsResponse
+	ShowQueriesResponse
+	QueryInfo
+	HandshakeResponse
+	IndexEpochRequest
+	IndexEpochResponse
+	MeasurementStatsRequest
+	MeasurementStatsResponse
+	DiskUsageRequest
+	DiskUsageResponse
 */
 package internal
 
@@ -34,6 +43,7 @@ type WriteShardRequest struct {
 	Points           [][]byte `protobuf:"bytes,2,rep,name=Points" json:"Points,omitempty"`
 	Database         *string  `protobuf:"bytes,3,opt,name=Database" json:"Database,omitempty"`
 	RetentionPolicy  *string  `protobuf:"bytes,4,opt,name=RetentionPolicy" json:"RetentionPolicy,omitempty"`
+	Batch            []byte   `protobuf:"bytes,5,opt,name=Batch" json:"Batch,omitempty"`
 	XXX_unrecognized []byte   `json:"-"`
 }
 
@@ -69,6 +79,13 @@ func (m *WriteShardRequest) GetRetentionPolicy() string {
 	return ""
 }
 
+func (m *WriteShardRequest) GetBatch() []byte {
+	if m != nil {
+		return m.Batch
+	}
+	return nil
+}
+
 type WriteShardResponse struct {
 	Code             *int32  `protobuf:"varint,1,req,name=Code" json:"Code,omitempty"`
 	Message          *string `protobuf:"bytes,2,opt,name=Message" json:"Message,omitempty"`
@@ -147,6 +164,8 @@ type CreateIteratorRequest struct {
 	Database         []byte   `protobuf:"bytes,3,req,name=Database" json:"Database,omitempty"`
 	RetentionPolicy  []byte   `protobuf:"bytes,4,req,name=RetentionPolicy" json:"RetentionPolicy,omitempty"`
 	MeasurementName  []byte   `protobuf:"bytes,5,req,name=MeasurementName" json:"MeasurementName,omitempty"`
+	Federated        *bool    `protobuf:"varint,6,opt,name=Federated" json:"Federated,omitempty"`
+	Measurement      []byte   `protobuf:"bytes,7,opt,name=Measurement" json:"Measurement,omitempty"`
 	XXX_unrecognized []byte   `json:"-"`
 }
 
@@ -189,6 +208,20 @@ func (m *CreateIteratorRequest) GetMeasurementName() []byte {
 	return nil
 }
 
+func (m *CreateIteratorRequest) GetFederated() bool {
+	if m != nil && m.Federated != nil {
+		return *m.Federated
+	}
+	return false
+}
+
+func (m *CreateIteratorRequest) GetMeasurement() []byte {
+	if m != nil {
+		return m.Measurement
+	}
+	return nil
+}
+
 type CreateIteratorResponse struct {
 	Err              *string `protobuf:"bytes,1,opt,name=Err" json:"Err,omitempty"`
 	DataType         *int32  `protobuf:"varint,2,opt,name=DataType" json:"DataType,omitempty"`
@@ -232,6 +265,9 @@ func (m *CreateIteratorResponse) GetPointN() int32 {
 type FieldDimensionsRequest struct {
 	ShardIDs         []uint64 `protobuf:"varint,1,rep,name=ShardIDs" json:"ShardIDs,omitempty"`
 	Measurement      []byte   `protobuf:"bytes,2,req,name=Sources" json:"Sources,omitempty"`
+	Federated        *bool    `protobuf:"varint,3,opt,name=Federated" json:"Federated,omitempty"`
+	MinTime          *int64   `protobuf:"varint,4,opt,name=MinTime" json:"MinTime,omitempty"`
+	MaxTime          *int64   `protobuf:"varint,5,opt,name=MaxTime" json:"MaxTime,omitempty"`
 	XXX_unrecognized []byte   `json:"-"`
 }
 
@@ -253,6 +289,27 @@ func (m *FieldDimensionsRequest) GetMeasurement() []byte {
 	return nil
 }
 
+func (m *FieldDimensionsRequest) GetFederated() bool {
+	if m != nil && m.Federated != nil {
+		return *m.Federated
+	}
+	return false
+}
+
+func (m *FieldDimensionsRequest) GetMinTime() int64 {
+	if m != nil && m.MinTime != nil {
+		return *m.MinTime
+	}
+	return 0
+}
+
+func (m *FieldDimensionsRequest) GetMaxTime() int64 {
+	if m != nil && m.MaxTime != nil {
+		return *m.MaxTime
+	}
+	return 0
+}
+
 type FieldDimensionsResponse struct {
 	Fields           []byte   `protobuf:"bytes,1,rep,name=Fields" json:"Fields,omitempty"`
 	Dimensions       []string `protobuf:"bytes,2,rep,name=Dimensions" json:"Dimensions,omitempty"`
@@ -285,6 +342,278 @@ func (m *FieldDimensionsResponse) GetErr() string {
 	return ""
 }
 
+type ShowQueriesResponse struct {
+	Queries          []*QueryInfo `protobuf:"bytes,1,rep,name=Queries" json:"Queries,omitempty"`
+	Err              *string      `protobuf:"bytes,2,opt,name=Err" json:"Err,omitempty"`
+	XXX_unrecognized []byte       `json:"-"`
+}
+
+func (m *ShowQueriesResponse) Reset()         { *m = ShowQueriesResponse{} }
+func (m *ShowQueriesResponse) String() string { return proto.CompactTextString(m) }
+func (*ShowQueriesResponse) ProtoMessage()    {}
+
+func (m *ShowQueriesResponse) GetQueries() []*QueryInfo {
+	if m != nil {
+		return m.Queries
+	}
+	return nil
+}
+
+func (m *ShowQueriesResponse) GetErr() string {
+	if m != nil && m.Err != nil {
+		return *m.Err
+	}
+	return ""
+}
+
+type QueryInfo struct {
+	ID               *uint64 `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
+	Query            *string `protobuf:"bytes,2,req,name=Query" json:"Query,omitempty"`
+	Database         *string `protobuf:"bytes,3,opt,name=Database" json:"Database,omitempty"`
+	Duration         *int64  `protobuf:"varint,4,opt,name=Duration" json:"Duration,omitempty"`
+	Status           *string `protobuf:"bytes,5,opt,name=Status" json:"Status,omitempty"`
+	Memory           *int64  `protobuf:"varint,6,opt,name=Memory" json:"Memory,omitempty"`
+	Priority         *string `protobuf:"bytes,7,opt,name=Priority" json:"Priority,omitempty"`
+	Spilled          *int64  `protobuf:"varint,8,opt,name=Spilled" json:"Spilled,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *QueryInfo) Reset()         { *m = QueryInfo{} }
+func (m *QueryInfo) String() string { return proto.CompactTextString(m) }
+func (*QueryInfo) ProtoMessage()    {}
+
+func (m *QueryInfo) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *QueryInfo) GetQuery() string {
+	if m != nil && m.Query != nil {
+		return *m.Query
+	}
+	return ""
+}
+
+func (m *QueryInfo) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *QueryInfo) GetDuration() int64 {
+	if m != nil && m.Duration != nil {
+		return *m.Duration
+	}
+	return 0
+}
+
+func (m *QueryInfo) GetStatus() string {
+	if m != nil && m.Status != nil {
+		return *m.Status
+	}
+	return ""
+}
+
+func (m *QueryInfo) GetMemory() int64 {
+	if m != nil && m.Memory != nil {
+		return *m.Memory
+	}
+	return 0
+}
+
+func (m *QueryInfo) GetPriority() string {
+	if m != nil && m.Priority != nil {
+		return *m.Priority
+	}
+	return ""
+}
+
+func (m *QueryInfo) GetSpilled() int64 {
+	if m != nil && m.Spilled != nil {
+		return *m.Spilled
+	}
+	return 0
+}
+
+type HandshakeResponse struct {
+	Version          *string  `protobuf:"bytes,1,opt,name=Version" json:"Version,omitempty"`
+	Protocol         *uint32  `protobuf:"varint,2,opt,name=Protocol" json:"Protocol,omitempty"`
+	Features         []string `protobuf:"bytes,3,rep,name=Features" json:"Features,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *HandshakeResponse) Reset()         { *m = HandshakeResponse{} }
+func (m *HandshakeResponse) String() string { return proto.CompactTextString(m) }
+func (*HandshakeResponse) ProtoMessage()    {}
+
+func (m *HandshakeResponse) GetVersion() string {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return ""
+}
+
+func (m *HandshakeResponse) GetProtocol() uint32 {
+	if m != nil && m.Protocol != nil {
+		return *m.Protocol
+	}
+	return 0
+}
+
+func (m *HandshakeResponse) GetFeatures() []string {
+	if m != nil {
+		return m.Features
+	}
+	return nil
+}
+
+type IndexEpochRequest struct {
+	Database         *string `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *IndexEpochRequest) Reset()         { *m = IndexEpochRequest{} }
+func (m *IndexEpochRequest) String() string { return proto.CompactTextString(m) }
+func (*IndexEpochRequest) ProtoMessage()    {}
+
+func (m *IndexEpochRequest) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+type IndexEpochResponse struct {
+	Epoch            *uint64 `protobuf:"varint,1,opt,name=Epoch" json:"Epoch,omitempty"`
+	Modified         *int64  `protobuf:"varint,2,opt,name=Modified" json:"Modified,omitempty"`
+	Err              *string `protobuf:"bytes,3,opt,name=Err" json:"Err,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *IndexEpochResponse) Reset()         { *m = IndexEpochResponse{} }
+func (m *IndexEpochResponse) String() string { return proto.CompactTextString(m) }
+func (*IndexEpochResponse) ProtoMessage()    {}
+
+func (m *IndexEpochResponse) GetEpoch() uint64 {
+	if m != nil && m.Epoch != nil {
+		return *m.Epoch
+	}
+	return 0
+}
+
+func (m *IndexEpochResponse) GetModified() int64 {
+	if m != nil && m.Modified != nil {
+		return *m.Modified
+	}
+	return 0
+}
+
+func (m *IndexEpochResponse) GetErr() string {
+	if m != nil && m.Err != nil {
+		return *m.Err
+	}
+	return ""
+}
+
+type MeasurementStatsRequest struct {
+	Database         *string `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *MeasurementStatsRequest) Reset()         { *m = MeasurementStatsRequest{} }
+func (m *MeasurementStatsRequest) String() string { return proto.CompactTextString(m) }
+func (*MeasurementStatsRequest) ProtoMessage()    {}
+
+func (m *MeasurementStatsRequest) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+type MeasurementStatsResponse struct {
+	Shards           []byte  `protobuf:"bytes,1,opt,name=Shards" json:"Shards,omitempty"`
+	Err              *string `protobuf:"bytes,2,opt,name=Err" json:"Err,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *MeasurementStatsResponse) Reset()         { *m = MeasurementStatsResponse{} }
+func (m *MeasurementStatsResponse) String() string { return proto.CompactTextString(m) }
+func (*MeasurementStatsResponse) ProtoMessage()    {}
+
+func (m *MeasurementStatsResponse) GetShards() []byte {
+	if m != nil {
+		return m.Shards
+	}
+	return nil
+}
+
+func (m *MeasurementStatsResponse) GetErr() string {
+	if m != nil && m.Err != nil {
+		return *m.Err
+	}
+	return ""
+}
+
+type DiskUsageRequest struct {
+	Database         *string `protobuf:"bytes,1,opt,name=Database" json:"Database,omitempty"`
+	Since            []int64 `protobuf:"varint,2,rep,name=Since" json:"Since,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DiskUsageRequest) Reset()         { *m = DiskUsageRequest{} }
+func (m *DiskUsageRequest) String() string { return proto.CompactTextString(m) }
+func (*DiskUsageRequest) ProtoMessage()    {}
+
+func (m *DiskUsageRequest) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *DiskUsageRequest) GetSince() []int64 {
+	if m != nil {
+		return m.Since
+	}
+	return nil
+}
+
+type DiskUsageResponse struct {
+	Shards           []byte  `protobuf:"bytes,1,opt,name=Shards" json:"Shards,omitempty"`
+	History          []byte  `protobuf:"bytes,2,opt,name=History" json:"History,omitempty"`
+	Err              *string `protobuf:"bytes,3,opt,name=Err" json:"Err,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DiskUsageResponse) Reset()         { *m = DiskUsageResponse{} }
+func (m *DiskUsageResponse) String() string { return proto.CompactTextString(m) }
+func (*DiskUsageResponse) ProtoMessage()    {}
+
+func (m *DiskUsageResponse) GetShards() []byte {
+	if m != nil {
+		return m.Shards
+	}
+	return nil
+}
+
+func (m *DiskUsageResponse) GetHistory() []byte {
+	if m != nil {
+		return m.History
+	}
+	return nil
+}
+
+func (m *DiskUsageResponse) GetErr() string {
+	if m != nil && m.Err != nil {
+		return *m.Err
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*WriteShardRequest)(nil), "internal.WriteShardRequest")
 	proto.RegisterType((*WriteShardResponse)(nil), "internal.WriteShardResponse")
@@ -294,4 +623,13 @@ func init() {
 	proto.RegisterType((*CreateIteratorResponse)(nil), "internal.CreateIteratorResponse")
 	proto.RegisterType((*FieldDimensionsRequest)(nil), "internal.FieldDimensionsRequest")
 	proto.RegisterType((*FieldDimensionsResponse)(nil), "internal.FieldDimensionsResponse")
+	proto.RegisterType((*ShowQueriesResponse)(nil), "internal.ShowQueriesResponse")
+	proto.RegisterType((*QueryInfo)(nil), "internal.QueryInfo")
+	proto.RegisterType((*HandshakeResponse)(nil), "internal.HandshakeResponse")
+	proto.RegisterType((*IndexEpochRequest)(nil), "internal.IndexEpochRequest")
+	proto.RegisterType((*IndexEpochResponse)(nil), "internal.IndexEpochResponse")
+	proto.RegisterType((*MeasurementStatsRequest)(nil), "internal.MeasurementStatsRequest")
+	proto.RegisterType((*MeasurementStatsResponse)(nil), "internal.MeasurementStatsResponse")
+	proto.RegisterType((*DiskUsageRequest)(nil), "internal.DiskUsageRequest")
+	proto.RegisterType((*DiskUsageResponse)(nil), "internal.DiskUsageResponse")
 }
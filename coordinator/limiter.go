@@ -0,0 +1,100 @@
+package coordinator
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRemoteRequestInterrupted is returned when a remote request is
+// interrupted while it waits for a slot.
+var ErrRemoteRequestInterrupted = errors.New("remote request interrupted")
+
+// ErrMaxConcurrentRemoteRequestsLimitExceeded is an error when a remote
+// request cannot be served because the maximum number of remote requests
+// are being served.
+func ErrMaxConcurrentRemoteRequestsLimitExceeded(n, limit int) error {
+	return fmt.Errorf("max-concurrent-remote-requests limit exceeded(%d, %d)", n, limit)
+}
+
+// requestLimiter limits the number of requests of other nodes served at
+// once. The requests of other nodes are limited apart from the queries of
+// this node, so that the queries of one cannot starve the other.
+type requestLimiter struct {
+	mu      sync.Mutex
+	max     int
+	timeout time.Duration
+	running int
+
+	// released is closed, and replaced, when a slot is released.
+	released chan struct{}
+}
+
+// newRequestLimiter returns a limiter serving max requests at once. Requests
+// wait up to timeout for a slot. A max of zero serves any number of requests.
+func newRequestLimiter(max int, timeout time.Duration) *requestLimiter {
+	return &requestLimiter{
+		max:      max,
+		timeout:  timeout,
+		released: make(chan struct{}),
+	}
+}
+
+// SetLimits changes the limits of the limiter. Requests being served keep
+// their slot.
+func (l *requestLimiter) SetLimits(max int, timeout time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.max, l.timeout = max, timeout
+	l.broadcast()
+}
+
+// acquire blocks until a slot is free. It returns an error if no slot is
+// released within the timeout or if interrupt is closed.
+func (l *requestLimiter) acquire(interrupt <-chan struct{}) error {
+	var timerCh <-chan time.Time
+	for {
+		l.mu.Lock()
+		if l.max <= 0 || l.running < l.max {
+			l.running++
+			l.mu.Unlock()
+			return nil
+		}
+		running, max, released := l.running, l.max, l.released
+
+		if timerCh == nil {
+			if l.timeout <= 0 {
+				l.mu.Unlock()
+				return ErrMaxConcurrentRemoteRequestsLimitExceeded(running, max)
+			}
+			timer := time.NewTimer(l.timeout)
+			defer timer.Stop()
+			timerCh = timer.C
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-timerCh:
+			return ErrMaxConcurrentRemoteRequestsLimitExceeded(running, max)
+		case <-interrupt:
+			return ErrRemoteRequestInterrupted
+		}
+	}
+}
+
+// release frees the slot of a request.
+func (l *requestLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running--
+	l.broadcast()
+}
+
+// broadcast wakes the requests waiting for a slot. It must be called with
+// the lock held.
+func (l *requestLimiter) broadcast() {
+	close(l.released)
+	l.released = make(chan struct{})
+}
@@ -0,0 +1,38 @@
+package coordinator
+
+import (
+	"testing"
+	"time"
+)
+
+// Ensure requests wait for a free slot, and fail once the timeout expires.
+func TestRequestLimiter(t *testing.T) {
+	l := newRequestLimiter(1, 10*time.Millisecond)
+	if err := l.acquire(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.acquire(nil); err == nil {
+		t.Fatal("expected error")
+	}
+
+	errC := make(chan error)
+	go func() { errC <- l.acquire(nil) }()
+	l.release()
+	if err := <-errC; err != nil {
+		t.Fatal(err)
+	}
+
+	interrupt := make(chan struct{})
+	close(interrupt)
+	l.SetLimits(1, time.Minute)
+	if err := l.acquire(interrupt); err != ErrRemoteRequestInterrupted {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Raising the limit wakes waiting requests.
+	go func() { errC <- l.acquire(nil) }()
+	l.SetLimits(2, time.Minute)
+	if err := <-errC; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,353 @@
+package coordinator
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// MaterializedView is an aggregate query over a measurement whose results are
+// stored in a measurement named after the view, in the same retention policy.
+// The column of an aggregate is named after its function and field, such as
+// "sum_value", and the tags of the view are the tags it groups by.
+type MaterializedView struct {
+	Name            string
+	Database        string
+	RetentionPolicy string
+
+	// Measurement is the name of the measurement the view aggregates.
+	Measurement string
+
+	// Interval is the width of the windows of the view.
+	Interval time.Duration
+
+	// Dimensions are the tag keys the view groups by.
+	Dimensions []string
+
+	// Aggregates are the aggregates the view stores.
+	Aggregates []MaterializedAggregate
+
+	source *influxql.SelectStatement
+}
+
+// MaterializedAggregate is an aggregate of a field stored by a materialized
+// view.
+type MaterializedAggregate struct {
+	Call  string
+	Field string
+}
+
+// Column returns the name of the column the aggregate is stored in.
+func (a MaterializedAggregate) Column() string {
+	return a.Call + "_" + a.Field
+}
+
+// ParseMaterializedView returns the materialized view of database stored in
+// the meta store.
+func ParseMaterializedView(database string, mvi meta.MaterializedViewInfo) (*MaterializedView, error) {
+	stmt, err := influxql.ParseStatement(mvi.Query)
+	if err != nil {
+		return nil, err
+	}
+	create, ok := stmt.(*influxql.CreateMaterializedViewStatement)
+	if !ok {
+		return nil, fmt.Errorf("materialized view %s is not a CREATE MATERIALIZED VIEW statement", mvi.Name)
+	} else if create.Database != database {
+		return nil, fmt.Errorf("materialized view %s is not on database %s", mvi.Name, database)
+	}
+	return NewMaterializedView(create)
+}
+
+// NewMaterializedView returns the materialized view created by a normalized
+// statement. Only the aggregates that can be combined across windows and
+// series are supported, so that coarser queries can be answered by the view.
+func NewMaterializedView(stmt *influxql.CreateMaterializedViewStatement) (*MaterializedView, error) {
+	source := stmt.Source
+	if len(source.Sources) != 1 {
+		return nil, errors.New("materialized views must select from a single measurement")
+	}
+	m, ok := source.Sources[0].(*influxql.Measurement)
+	if !ok || m.Regex != nil || m.Name == "" {
+		return nil, errors.New("materialized views must select from a single measurement")
+	} else if m.Database != stmt.Database {
+		return nil, errors.New("materialized views must select from their own database")
+	} else if m.Name == stmt.Name {
+		return nil, errors.New("materialized views cannot be named after the measurement they select from")
+	}
+
+	switch {
+	case source.Condition != nil:
+		return nil, errors.New("materialized views do not support WHERE conditions")
+	case source.Fill != influxql.NullFill:
+		return nil, errors.New("materialized views do not support fill()")
+	case source.Location != nil:
+		return nil, errors.New("materialized views do not support tz()")
+	case source.Limit > 0 || source.Offset > 0 || source.SLimit > 0 || source.SOffset > 0:
+		return nil, errors.New("materialized views do not support LIMIT or OFFSET")
+	case !source.TimeAscending():
+		return nil, errors.New("materialized views do not support ORDER BY time DESC")
+	case source.SampleSeries != 0:
+		return nil, errors.New("materialized views do not support sample_series()")
+	}
+
+	v := &MaterializedView{
+		Name:            stmt.Name,
+		Database:        m.Database,
+		RetentionPolicy: m.RetentionPolicy,
+		Measurement:     m.Name,
+		source:          source,
+	}
+
+	// Group by fixed windows and tags only.
+	for _, d := range source.Dimensions {
+		switch expr := d.Expr.(type) {
+		case *influxql.Call:
+			if expr.Name != "time" || len(expr.Args) != 1 {
+				return nil, errors.New("materialized views only support GROUP BY time(...) without an offset")
+			} else if _, ok := expr.Args[0].(*influxql.DurationLiteral); !ok {
+				return nil, errors.New("materialized views do not support calendar windows")
+			}
+		case *influxql.VarRef:
+			for _, dim := range v.Dimensions {
+				if dim == expr.Val {
+					return nil, fmt.Errorf("duplicate dimension in materialized view: %s", expr.Val)
+				}
+			}
+			v.Dimensions = append(v.Dimensions, expr.Val)
+		default:
+			return nil, fmt.Errorf("materialized views only support grouping by tag keys: %s", d)
+		}
+	}
+	interval, err := source.GroupByInterval()
+	if err != nil {
+		return nil, err
+	} else if interval <= 0 {
+		return nil, errors.New("materialized views require GROUP BY time(...)")
+	}
+	v.Interval = interval
+
+	// Each field is a supported aggregate of a field.
+	for _, f := range source.Fields {
+		call, ok := f.Expr.(*influxql.Call)
+		if !ok || f.Alias != "" {
+			return nil, fmt.Errorf("materialized views only support unaliased aggregates: %s", f)
+		}
+		switch call.Name {
+		case "count", "sum", "min", "max", "mean":
+		default:
+			return nil, fmt.Errorf("materialized views do not support %s()", call.Name)
+		}
+		var ref *influxql.VarRef
+		if len(call.Args) == 1 {
+			ref, _ = call.Args[0].(*influxql.VarRef)
+		}
+		if ref == nil {
+			return nil, fmt.Errorf("materialized views only support aggregates of a field: %s", f)
+		}
+
+		agg := MaterializedAggregate{Call: call.Name, Field: ref.Val}
+		if v.aggregate(agg.Call, agg.Field) {
+			return nil, fmt.Errorf("duplicate aggregate in materialized view: %s", f)
+		}
+		v.Aggregates = append(v.Aggregates, agg)
+	}
+	return v, nil
+}
+
+// aggregate returns true if the view stores the aggregate of a field.
+func (v *MaterializedView) aggregate(call, field string) bool {
+	for _, agg := range v.Aggregates {
+		if agg.Call == call && agg.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// target returns the measurement the view is stored in.
+func (v *MaterializedView) target() *influxql.Measurement {
+	return &influxql.Measurement{
+		Database:        v.Database,
+		RetentionPolicy: v.RetentionPolicy,
+		Name:            v.Name,
+	}
+}
+
+// RefreshStatement returns the statement that writes the windows of the view
+// between start and end into it. The whole view is written if start and end
+// are zero. Both must be aligned to the interval of the view.
+func (v *MaterializedView) RefreshStatement(start, end time.Time) (*influxql.SelectStatement, error) {
+	stmt := v.source.Clone()
+	for i, agg := range v.Aggregates {
+		stmt.Fields[i].Alias = agg.Column()
+	}
+	stmt.Target = &influxql.Target{Measurement: v.target()}
+
+	// Empty windows are not written.
+	stmt.Fill = influxql.NoFill
+
+	if !start.IsZero() || !end.IsZero() {
+		if err := stmt.SetTimeRange(start, end); err != nil {
+			return nil, err
+		}
+	}
+	return stmt, nil
+}
+
+// Rewrite returns stmt rewritten to read from the view, if the view contains
+// the aggregates of the statement. The windows of the statement must be made
+// of whole windows of the view and the statement can only group by and filter
+// on the tags of the view. The series of the rewritten statement keep the name
+// of the measurement of the original statement.
+func (v *MaterializedView) Rewrite(stmt *influxql.SelectStatement, now time.Time) (*influxql.SelectStatement, bool) {
+	if stmt.Target != nil || stmt.IsRawQuery || stmt.EmitName != "" || stmt.Location != nil || stmt.SampleSeries != 0 {
+		return nil, false
+	} else if len(stmt.Sources) != 1 {
+		return nil, false
+	}
+	m, ok := stmt.Sources[0].(*influxql.Measurement)
+	if !ok || m.Regex != nil || m.Database != v.Database || m.RetentionPolicy != v.RetentionPolicy || m.Name != v.Measurement {
+		return nil, false
+	}
+
+	// The windows of the statement are made of whole windows of the view.
+	interval, err := stmt.GroupByInterval()
+	if err != nil || interval%v.Interval != 0 {
+		return nil, false
+	} else if offset, err := stmt.GroupByOffset(); err != nil || offset != 0 {
+		return nil, false
+	}
+
+	// Only the tags of the view can be grouped by.
+	dimensions := make(map[string]struct{}, len(v.Dimensions))
+	for _, dim := range v.Dimensions {
+		dimensions[dim] = struct{}{}
+	}
+	grouped := make(map[string]struct{})
+	for _, d := range stmt.Dimensions {
+		switch expr := d.Expr.(type) {
+		case *influxql.Call:
+			if expr.Name != "time" {
+				return nil, false
+			} else if _, ok := expr.Args[0].(*influxql.DurationLiteral); !ok {
+				return nil, false
+			}
+		case *influxql.VarRef:
+			if _, ok := dimensions[expr.Val]; !ok {
+				return nil, false
+			}
+			grouped[expr.Val] = struct{}{}
+		default:
+			return nil, false
+		}
+	}
+
+	// Only the tags of the view can be filtered on, and the time range must
+	// start and end on the boundaries of the windows of the view.
+	cond, timeRange, err := influxql.ConditionExpr(stmt.Condition, &influxql.NowValuer{Now: now})
+	if err != nil {
+		return nil, false
+	}
+	filtered := true
+	influxql.WalkFunc(cond, func(n influxql.Node) {
+		if ref, ok := n.(*influxql.VarRef); ok {
+			if _, ok := dimensions[ref.Val]; !ok {
+				filtered = false
+			}
+		}
+	})
+	if !filtered {
+		return nil, false
+	} else if !timeRange.Min.IsZero() && timeRange.Min.UnixNano()%int64(v.Interval) != 0 {
+		return nil, false
+	} else if !timeRange.Max.IsZero() && (timeRange.Max.UnixNano()+1)%int64(v.Interval) != 0 {
+		return nil, false
+	}
+
+	// The mean of the view can be used as is only if the statement has the
+	// windows and the series of the view.
+	exact := interval == v.Interval && len(grouped) == len(v.Dimensions)
+
+	var counts int
+	fields := make(influxql.Fields, 0, len(stmt.Fields))
+	for _, f := range stmt.Fields {
+		expr, ok := v.rewriteCall(f.Expr, exact)
+		if !ok {
+			return nil, false
+		}
+		if call, ok := f.Expr.(*influxql.Call); ok {
+			switch call.Name {
+			case "count":
+				counts++
+			case "min", "max":
+				// Without GROUP BY time, a selector returns the time of the
+				// point it selects, which the view does not keep.
+				if interval == 0 {
+					return nil, false
+				}
+			}
+		}
+		fields = append(fields, &influxql.Field{Expr: expr, Alias: f.Name()})
+	}
+
+	other := stmt.Clone()
+	other.Fields = fields
+	other.Sources = influxql.Sources{v.target()}
+	other.EmitName = v.Measurement
+
+	// Empty windows of count() are filled with zero by default, which the
+	// sum() replacing it does not do.
+	if counts > 0 && interval > 0 && stmt.Fill == influxql.NullFill {
+		if counts != len(fields) {
+			return nil, false
+		}
+		other.Fill, other.FillValue = influxql.NumberFill, int64(0)
+	}
+	return other, true
+}
+
+// rewriteCall returns the expression reading the aggregate of expr from the
+// columns of the view.
+func (v *MaterializedView) rewriteCall(expr influxql.Expr, exact bool) (influxql.Expr, bool) {
+	call, ok := expr.(*influxql.Call)
+	if !ok || len(call.Args) != 1 {
+		return nil, false
+	}
+	ref, ok := call.Args[0].(*influxql.VarRef)
+	if !ok || ref.Type != influxql.Unknown {
+		return nil, false
+	}
+
+	// column returns a call of fn on the column of an aggregate of the field.
+	column := func(fn, agg string) (influxql.Expr, bool) {
+		if !v.aggregate(agg, ref.Val) {
+			return nil, false
+		}
+		col := MaterializedAggregate{Call: agg, Field: ref.Val}.Column()
+		return &influxql.Call{Name: fn, Args: []influxql.Expr{&influxql.VarRef{Val: col}}}, true
+	}
+
+	switch call.Name {
+	case "count", "sum":
+		return column("sum", call.Name)
+	case "min", "max":
+		return column(call.Name, call.Name)
+	case "mean":
+		sum, ok := column("sum", "sum")
+		if !ok {
+			break
+		}
+		count, ok := column("sum", "count")
+		if !ok {
+			break
+		}
+		return &influxql.BinaryExpr{Op: influxql.DIV, LHS: sum, RHS: count}, true
+	}
+	if call.Name == "mean" && exact {
+		return column("mean", "mean")
+	}
+	return nil, false
+}
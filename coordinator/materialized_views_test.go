@@ -0,0 +1,148 @@
+package coordinator_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/freetsdb/freetsdb/coordinator"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+func MustParseMaterializedView(tb testing.TB, q string) *coordinator.MaterializedView {
+	tb.Helper()
+	v, err := coordinator.ParseMaterializedView("db0", meta.MaterializedViewInfo{Name: "cpu_1m", Query: q})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return v
+}
+
+func TestNewMaterializedView(t *testing.T) {
+	v := MustParseMaterializedView(t, `CREATE MATERIALIZED VIEW cpu_1m ON db0 AS SELECT count(value), sum(value) FROM db0.rp0.cpu GROUP BY time(1m), host`)
+	if v.Measurement != "cpu" || v.RetentionPolicy != "rp0" || v.Interval != time.Minute {
+		t.Fatalf("unexpected view: %+v", v)
+	} else if len(v.Dimensions) != 1 || v.Dimensions[0] != "host" {
+		t.Fatalf("unexpected dimensions: %v", v.Dimensions)
+	} else if len(v.Aggregates) != 2 || v.Aggregates[1].Column() != "sum_value" {
+		t.Fatalf("unexpected aggregates: %+v", v.Aggregates)
+	}
+
+	for _, tt := range []struct {
+		q   string
+		err string
+	}{
+		{
+			q:   `CREATE MATERIALIZED VIEW cpu_1m ON db0 AS SELECT median(value) FROM db0.rp0.cpu GROUP BY time(1m)`,
+			err: `materialized views do not support median()`,
+		},
+		{
+			q:   `CREATE MATERIALIZED VIEW cpu_1m ON db0 AS SELECT sum(value) FROM db0.rp0.cpu WHERE host = 'a' GROUP BY time(1m)`,
+			err: `materialized views do not support WHERE conditions`,
+		},
+		{
+			q:   `CREATE MATERIALIZED VIEW cpu_1m ON db0 AS SELECT sum(value) AS total FROM db0.rp0.cpu GROUP BY time(1m)`,
+			err: `materialized views only support unaliased aggregates: sum(value) AS total`,
+		},
+		{
+			q:   `CREATE MATERIALIZED VIEW cpu_1m ON db0 AS SELECT sum(value) FROM db0.rp0.cpu GROUP BY time(1m, 10s)`,
+			err: `materialized views only support GROUP BY time(...) without an offset`,
+		},
+		{
+			q:   `CREATE MATERIALIZED VIEW cpu_1m ON db0 AS SELECT sum(value) FROM db1.rp0.cpu GROUP BY time(1m)`,
+			err: `materialized views must select from their own database`,
+		},
+	} {
+		if _, err := coordinator.ParseMaterializedView("db0", meta.MaterializedViewInfo{Name: "cpu_1m", Query: tt.q}); err == nil || err.Error() != tt.err {
+			t.Errorf("%s: unexpected error: %v", tt.q, err)
+		}
+	}
+}
+
+func TestMaterializedView_RefreshStatement(t *testing.T) {
+	v := MustParseMaterializedView(t, `CREATE MATERIALIZED VIEW cpu_1m ON db0 AS SELECT count(value), sum(value) FROM db0.rp0.cpu GROUP BY time(1m), host`)
+
+	stmt, err := v.RefreshStatement(time.Unix(0, 0), time.Unix(120, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `SELECT count(value) AS count_value, sum(value) AS sum_value INTO db0.rp0.cpu_1m FROM db0.rp0.cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:02:00Z' GROUP BY time(1m), host fill(none)`
+	if got := stmt.String(); got != exp {
+		t.Fatalf("unexpected statement:\n\tgot=%s\n\texp=%s", got, exp)
+	}
+
+	// The whole view is written without a time range.
+	stmt, err = v.RefreshStatement(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	} else if stmt.Condition != nil {
+		t.Fatalf("unexpected condition: %s", stmt.Condition)
+	}
+}
+
+func TestMaterializedView_Rewrite(t *testing.T) {
+	v := MustParseMaterializedView(t, `CREATE MATERIALIZED VIEW cpu_1m ON db0 AS SELECT count(value), sum(value), max(value), mean(value) FROM db0.rp0.cpu GROUP BY time(1m), host, region`)
+	now := time.Date(2000, 1, 1, 1, 0, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		q   string
+		exp string // empty if the view does not contain the statement
+	}{
+		{
+			q:   `SELECT sum(value), max(value) FROM db0.rp0.cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T01:00:00Z' GROUP BY time(5m), host`,
+			exp: `SELECT sum(sum_value) AS sum, max(max_value) AS max FROM db0.rp0.cpu_1m WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T01:00:00Z' GROUP BY time(5m), host`,
+		},
+		{
+			q:   `SELECT mean(value) FROM db0.rp0.cpu WHERE region = 'west' AND time >= now() - 1h GROUP BY time(10m)`,
+			exp: `SELECT sum(sum_value) / sum(count_value) AS mean FROM db0.rp0.cpu_1m WHERE region = 'west' AND time >= now() - 1h GROUP BY time(10m)`,
+		},
+		{
+			q:   `SELECT count(value) FROM db0.rp0.cpu WHERE time >= '2000-01-01T00:00:00Z' GROUP BY time(1m)`,
+			exp: `SELECT sum(count_value) AS count FROM db0.rp0.cpu_1m WHERE time >= '2000-01-01T00:00:00Z' GROUP BY time(1m) fill(0)`,
+		},
+		{
+			q:   `SELECT sum(value) AS total FROM db0.rp0.cpu`,
+			exp: `SELECT sum(sum_value) AS total FROM db0.rp0.cpu_1m`,
+		},
+
+		// Selectors without GROUP BY time return the time of their point.
+		{q: `SELECT max(value) AS peak FROM db0.rp0.cpu`},
+		{q: `SELECT sum(value), min(value) FROM db0.rp0.cpu WHERE time >= '2000-01-01T00:00:00Z'`},
+
+		// Windows that are not made of whole windows of the view.
+		{q: `SELECT sum(value) FROM db0.rp0.cpu WHERE time >= '2000-01-01T00:00:00Z' GROUP BY time(90s)`},
+		{q: `SELECT sum(value) FROM db0.rp0.cpu WHERE time >= '2000-01-01T00:00:30Z' GROUP BY time(5m)`},
+		{q: `SELECT sum(value) FROM db0.rp0.cpu WHERE time >= '2000-01-01T00:00:00Z' GROUP BY time(5m, 1m)`},
+
+		// Tags, fields and aggregates the view does not have.
+		{q: `SELECT sum(value) FROM db0.rp0.cpu WHERE time >= '2000-01-01T00:00:00Z' GROUP BY time(5m), dc`},
+		{q: `SELECT sum(value) FROM db0.rp0.cpu WHERE value > 1 AND time >= '2000-01-01T00:00:00Z' GROUP BY time(5m)`},
+		{q: `SELECT median(value) FROM db0.rp0.cpu WHERE time >= '2000-01-01T00:00:00Z' GROUP BY time(5m)`},
+		{q: `SELECT min(value) FROM db0.rp0.cpu WHERE time >= '2000-01-01T00:00:00Z' GROUP BY time(5m)`},
+		{q: `SELECT sum(idle) FROM db0.rp0.cpu WHERE time >= '2000-01-01T00:00:00Z' GROUP BY time(5m)`},
+
+		// Other measurements and raw queries.
+		{q: `SELECT sum(value) FROM db0.rp0.mem WHERE time >= '2000-01-01T00:00:00Z' GROUP BY time(5m)`},
+		{q: `SELECT value FROM db0.rp0.cpu`},
+	} {
+		stmt, err := influxql.ParseStatement(tt.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		other, ok := v.Rewrite(stmt.(*influxql.SelectStatement), now)
+		if tt.exp == "" {
+			if ok {
+				t.Errorf("%s: unexpected rewrite: %s", tt.q, other)
+			}
+			continue
+		} else if !ok {
+			t.Errorf("%s: expected rewrite", tt.q)
+			continue
+		}
+		if got := other.String(); got != tt.exp {
+			t.Errorf("%s: unexpected statement:\n\tgot=%s\n\texp=%s", tt.q, got, tt.exp)
+		} else if other.EmitName != "cpu" {
+			t.Errorf("%s: unexpected name: %s", tt.q, other.EmitName)
+		}
+	}
+}
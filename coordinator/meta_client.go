@@ -9,11 +9,17 @@ import (
 
 // MetaClient is an interface for accessing meta data.
 type MetaClient interface {
+	AddShardOwner(id, nodeID uint64) error
 	CreateContinuousQuery(database, name, query string) error
+	CreateMaterializedView(database, name, query string) error
+	CreateQueryTemplate(name, query string) error
 	CreateDatabase(name string) (*meta.DatabaseInfo, error)
 	CreateDatabaseWithRetentionPolicy(name string, rpi *meta.RetentionPolicyInfo) (*meta.DatabaseInfo, error)
+	CreateDatabaseWithShardKey(name string, rpi *meta.RetentionPolicyInfo, shardKey []string) (*meta.DatabaseInfo, error)
 	CreateRetentionPolicy(database string, rpi *meta.RetentionPolicyInfo) (*meta.RetentionPolicyInfo, error)
+	CreateRole(name string) error
 	CreateSubscription(database, rp, name, mode string, destinations []string) error
+	CreateSubscriptionWithFilter(database, rp string, si meta.SubscriptionInfo) error
 	CreateUser(name, password string, admin bool) (*meta.UserInfo, error)
 	Database(name string) *meta.DatabaseInfo
 	Databases() ([]meta.DatabaseInfo, error)
@@ -26,15 +32,28 @@ type MetaClient interface {
 	DropContinuousQuery(database, name string) error
 	DropDatabase(name string) error
 	DropRetentionPolicy(database, name string) error
+	DropMaterializedView(database, name string) error
+	DropQueryTemplate(name string) error
+	DropRole(name string) error
+	DropSession(id string) error
 	DropSubscription(database, rp, name string) error
 	DropUser(name string) error
+	QueryTemplate(name string) *meta.QueryTemplateInfo
+	QueryTemplates() []meta.QueryTemplateInfo
 	RetentionPolicy(database, name string) (rpi *meta.RetentionPolicyInfo, err error)
+	Role(name string) *meta.RoleInfo
+	Roles() []meta.RoleInfo
 	SetAdminPrivilege(username string, admin bool) error
 	SetPrivilege(username, database string, p influxql.Privilege) error
+	SetRolePrivilege(role, database string, p influxql.Privilege) error
+	SetUserRole(username, role string, member bool) error
+	Sessions() []meta.SessionInfo
+	ShardOwner(shardID uint64) (database, policy string, sgi *meta.ShardGroupInfo)
 	ShardsByTimeRange(sources influxql.Sources, tmin, tmax time.Time) (a []meta.ShardInfo, err error)
 	SetDefaultRetentionPolicy(database, name string) error
 	ShardGroupsByTimeRange(database, policy string, min, max time.Time) (a []meta.ShardGroupInfo, err error)
 	TruncateShardGroups(t time.Time) error
+	TruncateDatabaseShardGroups(database, policy string, t time.Time) error
 	UpdateRetentionPolicy(database, name string, rpu *meta.RetentionPolicyUpdate) error
 	UpdateUser(name, password string) error
 	UserPrivilege(username, database string) (*influxql.Privilege, error)
@@ -9,11 +9,17 @@ import (
 
 // MetaClient is a mockable implementation of cluster.MetaClient.
 type MetaClient struct {
+	AddShardOwnerFn                     func(id, nodeID uint64) error
 	CreateContinuousQueryFn             func(database, name, query string) error
 	CreateDatabaseFn                    func(name string) (*meta.DatabaseInfo, error)
 	CreateDatabaseWithRetentionPolicyFn func(name string, rpi *meta.RetentionPolicyInfo) (*meta.DatabaseInfo, error)
+	CreateDatabaseWithShardKeyFn        func(name string, rpi *meta.RetentionPolicyInfo, shardKey []string) (*meta.DatabaseInfo, error)
 	CreateRetentionPolicyFn             func(database string, rpi *meta.RetentionPolicyInfo, makeDefault bool) (*meta.RetentionPolicyInfo, error)
+	CreateMaterializedViewFn            func(database, name, query string) error
+	CreateQueryTemplateFn               func(name, query string) error
 	CreateSubscriptionFn                func(database, rp, name, mode string, destinations []string) error
+	CreateSubscriptionWithFilterFn      func(database, rp string, si meta.SubscriptionInfo) error
+	CreateRoleFn                        func(name string) error
 	CreateUserFn                        func(name, password string, admin bool) (meta.User, error)
 	DatabaseFn                          func(name string) *meta.DatabaseInfo
 	DatabasesFn                         func() ([]meta.DatabaseInfo, error)
@@ -25,14 +31,27 @@ type MetaClient struct {
 	DropDatabaseFn                      func(name string) error
 	DropRetentionPolicyFn               func(database, name string) error
 	DropSubscriptionFn                  func(database, rp, name string) error
+	DropMaterializedViewFn              func(database, name string) error
+	DropQueryTemplateFn                 func(name string) error
+	DropRoleFn                          func(name string) error
+	DropSessionFn                       func(id string) error
 	DropShardFn                         func(id uint64) error
 	DropUserFn                          func(name string) error
 	MetaNodesFn                         func() ([]meta.NodeInfo, error)
+	QueryTemplateFn                     func(name string) *meta.QueryTemplateInfo
+	QueryTemplatesFn                    func() []meta.QueryTemplateInfo
 	RetentionPolicyFn                   func(database, name string) (rpi *meta.RetentionPolicyInfo, err error)
+	RoleFn                              func(name string) *meta.RoleInfo
+	RolesFn                             func() []meta.RoleInfo
 	SetAdminPrivilegeFn                 func(username string, admin bool) error
 	SetPrivilegeFn                      func(username, database string, p influxql.Privilege) error
+	SetRolePrivilegeFn                  func(role, database string, p influxql.Privilege) error
+	SetUserRoleFn                       func(username, role string, member bool) error
+	SessionsFn                          func() []meta.SessionInfo
+	ShardOwnerFn                        func(shardID uint64) (string, string, *meta.ShardGroupInfo)
 	ShardGroupsByTimeRangeFn            func(database, policy string, min, max time.Time) (a []meta.ShardGroupInfo, err error)
 	TruncateShardGroupsFn               func(t time.Time) error
+	TruncateDatabaseShardGroupsFn       func(database, policy string, t time.Time) error
 	UpdateRetentionPolicyFn             func(database, name string, rpu *meta.RetentionPolicyUpdate) error
 	UpdateUserFn                        func(name, password string) error
 	UserPrivilegeFn                     func(username, database string) (*influxql.Privilege, error)
@@ -40,6 +59,10 @@ type MetaClient struct {
 	UsersFn                             func() []meta.UserInfo
 }
 
+func (c *MetaClient) AddShardOwner(id, nodeID uint64) error {
+	return c.AddShardOwnerFn(id, nodeID)
+}
+
 func (c *MetaClient) CreateContinuousQuery(database, name, query string) error {
 	return c.CreateContinuousQueryFn(database, name, query)
 }
@@ -52,6 +75,10 @@ func (c *MetaClient) CreateDatabaseWithRetentionPolicy(name string, rpi *meta.Re
 	return c.CreateDatabaseWithRetentionPolicyFn(name, rpi)
 }
 
+func (c *MetaClient) CreateDatabaseWithShardKey(name string, rpi *meta.RetentionPolicyInfo, shardKey []string) (*meta.DatabaseInfo, error) {
+	return c.CreateDatabaseWithShardKeyFn(name, rpi, shardKey)
+}
+
 func (c *MetaClient) CreateRetentionPolicy(database string, rpi *meta.RetentionPolicyInfo, makeDefault bool) (*meta.RetentionPolicyInfo, error) {
 	return c.CreateRetentionPolicyFn(database, spec, makeDefault)
 }
@@ -64,6 +91,10 @@ func (c *MetaClient) CreateSubscription(database, rp, name, mode string, destina
 	return c.CreateSubscriptionFn(database, rp, name, mode, destinations)
 }
 
+func (c *MetaClient) CreateSubscriptionWithFilter(database, rp string, si meta.SubscriptionInfo) error {
+	return c.CreateSubscriptionWithFilterFn(database, rp, si)
+}
+
 func (c *MetaClient) CreateUser(name, password string, admin bool) (meta.User, error) {
 	return c.CreateUserFn(name, password, admin)
 }
@@ -128,6 +159,66 @@ func (c *MetaClient) SetPrivilege(username, database string, p influxql.Privileg
 	return c.SetPrivilegeFn(username, database, p)
 }
 
+func (c *MetaClient) SetRolePrivilege(role, database string, p influxql.Privilege) error {
+	return c.SetRolePrivilegeFn(role, database, p)
+}
+
+func (c *MetaClient) SetUserRole(username, role string, member bool) error {
+	return c.SetUserRoleFn(username, role, member)
+}
+
+func (c *MetaClient) CreateRole(name string) error {
+	return c.CreateRoleFn(name)
+}
+
+func (c *MetaClient) DropRole(name string) error {
+	return c.DropRoleFn(name)
+}
+
+func (c *MetaClient) Role(name string) *meta.RoleInfo {
+	return c.RoleFn(name)
+}
+
+func (c *MetaClient) Roles() []meta.RoleInfo {
+	return c.RolesFn()
+}
+
+func (c *MetaClient) CreateMaterializedView(database, name, query string) error {
+	return c.CreateMaterializedViewFn(database, name, query)
+}
+
+func (c *MetaClient) DropMaterializedView(database, name string) error {
+	return c.DropMaterializedViewFn(database, name)
+}
+
+func (c *MetaClient) CreateQueryTemplate(name, query string) error {
+	return c.CreateQueryTemplateFn(name, query)
+}
+
+func (c *MetaClient) DropQueryTemplate(name string) error {
+	return c.DropQueryTemplateFn(name)
+}
+
+func (c *MetaClient) QueryTemplate(name string) *meta.QueryTemplateInfo {
+	return c.QueryTemplateFn(name)
+}
+
+func (c *MetaClient) QueryTemplates() []meta.QueryTemplateInfo {
+	return c.QueryTemplatesFn()
+}
+
+func (c *MetaClient) Sessions() []meta.SessionInfo {
+	return c.SessionsFn()
+}
+
+func (c *MetaClient) DropSession(id string) error {
+	return c.DropSessionFn(id)
+}
+
+func (c *MetaClient) ShardOwner(shardID uint64) (string, string, *meta.ShardGroupInfo) {
+	return c.ShardOwnerFn(shardID)
+}
+
 func (c *MetaClient) ShardGroupsByTimeRange(database, policy string, min, max time.Time) (a []meta.ShardGroupInfo, err error) {
 	return c.ShardGroupsByTimeRangeFn(database, policy, min, max)
 }
@@ -136,6 +227,10 @@ func (c *MetaClient) TruncateShardGroups(t time.Time) error {
 	return c.TruncateShardGroupsFn(t)
 }
 
+func (c *MetaClient) TruncateDatabaseShardGroups(database, policy string, t time.Time) error {
+	return c.TruncateDatabaseShardGroupsFn(database, policy, t)
+}
+
 func (c *MetaClient) UpdateRetentionPolicy(database, name string, rpu *meta.RetentionPolicyUpdate) error {
 	return c.UpdateRetentionPolicyFn(database, name, rpu)
 }
@@ -9,13 +9,19 @@ import (
 	"time"
 
 	"github.com/freetsdb/freetsdb"
+	"github.com/freetsdb/freetsdb/query"
 	"github.com/freetsdb/freetsdb/services/influxql"
 	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/tsdb"
 )
 
 const (
 	metaExecutorWriteTimeout        = 5 * time.Second
 	metaExecutorMaxWriteConnections = 10
+
+	// metaExecutorShardJobTimeout is the time a node has to copy or reindex
+	// a shard.
+	metaExecutorShardJobTimeout = time.Hour
 )
 
 // MetaExecutor executes meta queries on all data nodes.
@@ -27,6 +33,10 @@ type MetaExecutor struct {
 	Logger         *log.Logger
 	Node           *freetsdb.Node
 
+	// Features skips the requests the nodes of older versions do not
+	// understand.
+	Features *FeatureNegotiator
+
 	nodeExecutor interface {
 		executeOnNode(stmt influxql.Statement, database string, node *meta.NodeInfo) error
 	}
@@ -99,6 +109,21 @@ func (m *MetaExecutor) ExecuteStatement(stmt influxql.Statement, database string
 	}
 }
 
+// ExecuteStatementOnNode executes a single InfluxQL statement on the data node with nodeID.
+func (m *MetaExecutor) ExecuteStatementOnNode(stmt influxql.Statement, database string, nodeID uint64) error {
+	node, err := m.MetaClient.DataNode(nodeID)
+	if err != nil {
+		return err
+	} else if node == nil {
+		return meta.ErrNodeNotFound
+	}
+
+	if err := m.nodeExecutor.executeOnNode(stmt, database, node); err != nil {
+		return remoteNodeError{id: node.ID, err: err}
+	}
+	return nil
+}
+
 // executeOnNode executes a single InfluxQL statement on a single node.
 func (m *MetaExecutor) executeOnNode(stmt influxql.Statement, database string, node *meta.NodeInfo) error {
 	// We're executing on a remote node so establish a connection.
@@ -132,8 +157,14 @@ func (m *MetaExecutor) executeOnNode(stmt influxql.Statement, database string, n
 		return err
 	}
 
-	// Read the response.
-	conn.SetReadDeadline(time.Now().Add(m.timeout))
+	// Read the response. Copying or reindexing a shard takes longer than
+	// other statements.
+	timeout := m.timeout
+	switch stmt.(type) {
+	case *influxql.CopyShardStatement, *influxql.ReindexShardStatement:
+		timeout = metaExecutorShardJobTimeout
+	}
+	conn.SetReadDeadline(time.Now().Add(timeout))
 	_, buf, err = ReadTLV(conn)
 	if err != nil {
 		conn.MarkUnusable()
@@ -153,6 +184,155 @@ func (m *MetaExecutor) executeOnNode(stmt influxql.Statement, database string, n
 	return nil
 }
 
+// QueriesOnNode returns the queries running on the data node with nodeID.
+func (m *MetaExecutor) QueriesOnNode(nodeID uint64) ([]query.QueryInfo, error) {
+	if !m.Features.Supports(nodeID, FeatureShowQueries) {
+		return nil, fmt.Errorf("node %d does not support %s", nodeID, FeatureShowQueries)
+	}
+
+	c, err := m.dial(nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, ok := c.(*pooledConn)
+	if !ok {
+		panic("wrong connection type in MetaExecutor")
+	}
+	// Return connection to pool by "closing" it.
+	defer conn.Close()
+
+	// The request has no body.
+	conn.SetWriteDeadline(time.Now().Add(m.timeout))
+	if err := WriteTLV(conn, showQueriesRequestMessage, nil); err != nil {
+		conn.MarkUnusable()
+		return nil, err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(m.timeout))
+	var resp ShowQueriesResponse
+	if _, err := DecodeTLV(conn, &resp); err != nil {
+		conn.MarkUnusable()
+		m.Features.Forget(nodeID)
+		return nil, err
+	} else if resp.Err != nil {
+		return nil, resp.Err
+	}
+	return resp.Queries, nil
+}
+
+// IndexEpochOnNode returns the index epoch of database on the data node with
+// nodeID and the time it was first seen.
+func (m *MetaExecutor) IndexEpochOnNode(nodeID uint64, database string) (uint64, time.Time, error) {
+	if !m.Features.Supports(nodeID, FeatureIndexEpoch) {
+		return 0, time.Time{}, fmt.Errorf("node %d does not support %s", nodeID, FeatureIndexEpoch)
+	}
+
+	c, err := m.dial(nodeID)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	conn, ok := c.(*pooledConn)
+	if !ok {
+		panic("wrong connection type in MetaExecutor")
+	}
+	// Return connection to pool by "closing" it.
+	defer conn.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(m.timeout))
+	if err := EncodeTLV(conn, indexEpochRequestMessage, &IndexEpochRequest{Database: database}); err != nil {
+		conn.MarkUnusable()
+		return 0, time.Time{}, err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(m.timeout))
+	var resp IndexEpochResponse
+	if _, err := DecodeTLV(conn, &resp); err != nil {
+		conn.MarkUnusable()
+		m.Features.Forget(nodeID)
+		return 0, time.Time{}, err
+	} else if resp.Err != nil {
+		return 0, time.Time{}, resp.Err
+	}
+	return resp.Epoch, resp.Modified, nil
+}
+
+// MeasurementStatsOnNode returns the measurement statistics of the shards of
+// database on the data node with nodeID.
+func (m *MetaExecutor) MeasurementStatsOnNode(nodeID uint64, database string) ([]tsdb.ShardMeasurementStats, error) {
+	if !m.Features.Supports(nodeID, FeatureMeasurementStats) {
+		return nil, fmt.Errorf("node %d does not support %s", nodeID, FeatureMeasurementStats)
+	}
+
+	c, err := m.dial(nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, ok := c.(*pooledConn)
+	if !ok {
+		panic("wrong connection type in MetaExecutor")
+	}
+	// Return connection to pool by "closing" it.
+	defer conn.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(m.timeout))
+	if err := EncodeTLV(conn, measurementStatsRequestMessage, &MeasurementStatsRequest{Database: database}); err != nil {
+		conn.MarkUnusable()
+		return nil, err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(m.timeout))
+	var resp MeasurementStatsResponse
+	if _, err := DecodeTLV(conn, &resp); err != nil {
+		conn.MarkUnusable()
+		m.Features.Forget(nodeID)
+		return nil, err
+	} else if resp.Err != nil {
+		return nil, resp.Err
+	}
+	return resp.Shards, nil
+}
+
+// DiskUsageOnNode returns the disk usage of the shards of database on the
+// data node with nodeID, and the samples of its history at the times of
+// since. The shards of every database are returned if database is empty.
+func (m *MetaExecutor) DiskUsageOnNode(nodeID uint64, database string, since []time.Time) ([]tsdb.ShardDiskUsage, []*tsdb.DiskUsageSample, error) {
+	if !m.Features.Supports(nodeID, FeatureDiskUsage) {
+		return nil, nil, fmt.Errorf("node %d does not support %s", nodeID, FeatureDiskUsage)
+	}
+
+	c, err := m.dial(nodeID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn, ok := c.(*pooledConn)
+	if !ok {
+		panic("wrong connection type in MetaExecutor")
+	}
+	// Return connection to pool by "closing" it.
+	defer conn.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(m.timeout))
+	if err := EncodeTLV(conn, diskUsageRequestMessage, &DiskUsageRequest{Database: database, Since: since}); err != nil {
+		conn.MarkUnusable()
+		return nil, nil, err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(m.timeout))
+	var resp DiskUsageResponse
+	if _, err := DecodeTLV(conn, &resp); err != nil {
+		conn.MarkUnusable()
+		m.Features.Forget(nodeID)
+		return nil, nil, err
+	} else if resp.Err != nil {
+		return nil, nil, resp.Err
+	}
+	return resp.Shards, resp.History, nil
+}
+
 // dial returns a connection to a single node in the cluster.
 func (m *MetaExecutor) dial(nodeID uint64) (net.Conn, error) {
 	// If we don't have a connection pool for that addr yet, create one
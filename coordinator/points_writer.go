@@ -1,9 +1,11 @@
 package coordinator
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -34,6 +36,8 @@ const (
 	statWritePointReqHH     = "pointReqHH"
 	statSubWriteOK          = "subWriteOk"
 	statSubWriteDrop        = "subWriteDrop"
+	statWriteDuplicate      = "writeDuplicate"
+	statWriteNodeDead       = "writeNodeDead"
 )
 
 const (
@@ -64,8 +68,26 @@ var (
 	// ErrInvalidConsistencyLevel is returned when parsing the string version
 	// of a consistency level.
 	ErrInvalidConsistencyLevel = errors.New("invalid consistency level")
+
+	// ErrDatabaseFrozen is returned when writing to a frozen database.
+	ErrDatabaseFrozen = errors.New("database is frozen")
+
+	// ErrNodeDead is returned when writing to a data node known to be dead.
+	ErrNodeDead = errors.New("data node is dead")
 )
 
+// ReadOnlyError is returned when writing to a node in read-only mode.
+type ReadOnlyError struct {
+	Reason string
+}
+
+func (e ReadOnlyError) Error() string {
+	if e.Reason == "" {
+		return "node is read-only"
+	}
+	return "node is read-only: " + e.Reason
+}
+
 // ParseConsistencyLevel converts a consistency level string to the corresponding ConsistencyLevel const
 func ParseConsistencyLevel(level string) (ConsistencyLevel, error) {
 	switch strings.ToLower(level) {
@@ -89,18 +111,26 @@ type PointsWriter struct {
 	WriteTimeout time.Duration
 	Logger       *zap.Logger
 
+	// IdempotencyKeyTTL is how long the key of a write is remembered, and
+	// MaxIdempotencyKeys how many keys are remembered, unless it is zero.
+	IdempotencyKeyTTL  time.Duration
+	MaxIdempotencyKeys int
+	idempotencyKeys    *idempotencyKeys
+
 	Node *freetsdb.Node
 
 	MetaClient interface {
 		Database(name string) (di *meta.DatabaseInfo)
 		RetentionPolicy(database, policy string) (*meta.RetentionPolicyInfo, error)
 		CreateShardGroup(database, policy string, timestamp time.Time) (*meta.ShardGroupInfo, error)
+		CreateMeasurementShardGroup(database, policy, measurement string, timestamp time.Time) (*meta.ShardGroupInfo, error)
 		ShardOwner(shardID uint64) (string, string, *meta.ShardGroupInfo)
+		DataNode(id uint64) (*meta.NodeInfo, error)
 	}
 
 	TSDBStore interface {
 		CreateShard(database, retentionPolicy string, shardID uint64, enabled bool) error
-		WriteToShard(shardID uint64, points []models.Point) error
+		WriteToShardWithContext(ctx context.Context, shardID uint64, points []models.Point) error
 	}
 
 	ShardWriter interface {
@@ -111,11 +141,27 @@ type PointsWriter struct {
 		WriteShard(shardID, ownerID uint64, points []models.Point) error
 	}
 
+	// NodeHealth reports the data nodes known to be dead. The writes to
+	// them are queued in the hinted handoff without waiting to time out.
+	NodeHealth interface {
+		Dead(nodeID uint64) bool
+	}
+
 	Subscriber interface {
 		Points() chan<- *WritePointsRequest
 	}
 	subPoints []chan<- *WritePointsRequest
 
+	// commitPoints receive the points of the writes once they are written.
+	commitPoints []chan<- *WritePointsRequest
+
+	// MaterializedViews is notified of the points written, so that the
+	// materialized views of their measurements are refreshed. Unlike
+	// subscribers, it is never skipped.
+	MaterializedViews interface {
+		MarkDirty(database, retentionPolicy string, points []models.Point)
+	}
+
 	stats *WriteStatistics
 }
 
@@ -126,6 +172,10 @@ func NewPointsWriter() *PointsWriter {
 		WriteTimeout: DefaultWriteTimeout,
 		Logger:       zap.NewNop(),
 		stats:        &WriteStatistics{},
+
+		IdempotencyKeyTTL:  DefaultIdempotencyKeyTTL,
+		MaxIdempotencyKeys: DefaultMaxIdempotencyKeys,
+		idempotencyKeys:    newIdempotencyKeys(),
 	}
 }
 
@@ -176,6 +226,7 @@ func (w *PointsWriter) Close() error {
 		// dropping any in-flight writes.
 		w.subPoints = nil
 	}
+	w.commitPoints = nil
 	return nil
 }
 
@@ -183,6 +234,14 @@ func (w *PointsWriter) AddWriteSubscriber(c chan<- *WritePointsRequest) {
 	w.subPoints = append(w.subPoints, c)
 }
 
+// AddCommitSubscriber adds a channel that receives the points of each write
+// once they are written to their shards. Unlike the write subscribers, it
+// never receives the points of a failed write or the points a shard dropped.
+// Writes are dropped while the channel is full.
+func (w *PointsWriter) AddCommitSubscriber(c chan<- *WritePointsRequest) {
+	w.commitPoints = append(w.commitPoints, c)
+}
+
 // WithLogger sets the Logger on w.
 func (w *PointsWriter) WithLogger(log *zap.Logger) {
 	w.Logger = log.With(zap.String("service", "write"))
@@ -202,6 +261,8 @@ type WriteStatistics struct {
 	WritePointReqHH     int64
 	SubWriteOK          int64
 	SubWriteDrop        int64
+	WriteDuplicate      int64
+	WriteNodeDead       int64
 }
 
 // Statistics returns statistics for periodic monitoring.
@@ -222,6 +283,8 @@ func (w *PointsWriter) Statistics(tags map[string]string) []models.Statistic {
 			statWritePointReqHH:     atomic.LoadInt64(&w.stats.WritePointReqHH),
 			statSubWriteOK:          atomic.LoadInt64(&w.stats.SubWriteOK),
 			statSubWriteDrop:        atomic.LoadInt64(&w.stats.SubWriteDrop),
+			statWriteDuplicate:      atomic.LoadInt64(&w.stats.WriteDuplicate),
+			statWriteNodeDead:       atomic.LoadInt64(&w.stats.WriteNodeDead),
 		},
 	}}
 }
@@ -237,23 +300,43 @@ func (w *PointsWriter) MapShards(wp *WritePointsRequest) (*ShardMapping, error)
 		return nil, freetsdb.ErrRetentionPolicyNotFound(wp.RetentionPolicy)
 	}
 
-	// Holds all the shard groups and shards that are required for writes.
-	list := make(sgList, 0, 8)
-	min := time.Unix(0, models.MinNanoTime)
+	var shardKey []string
+	if di := w.MetaClient.Database(wp.Database); di != nil {
+		shardKey = di.ShardKey
+	}
+
+	// Holds all the shard groups and shards that are required for writes, by
+	// the measurement they are created for.
+	lists := make(map[string]sgList)
+	now := time.Now()
+	min, max := time.Unix(0, models.MinNanoTime), time.Unix(0, models.MaxNanoTime)
 	if rp.Duration > 0 {
-		min = time.Now().Add(-rp.Duration)
+		min = now.Add(-rp.Duration)
+	}
+	if rp.PastWriteLimit > 0 && now.Add(-rp.PastWriteLimit).After(min) {
+		min = now.Add(-rp.PastWriteLimit)
+	}
+	if rp.FutureWriteLimit > 0 {
+		max = now.Add(rp.FutureWriteLimit)
 	}
 
 	for _, p := range wp.Points {
 		// Either the point is outside the scope of the RP, or we already have
 		// a suitable shard group for the point.
-		if p.Time().Before(min) || list.Covers(p.Time()) {
+		measurement := shardGroupMeasurement(rp, p.Name())
+		list := lists[measurement]
+		if p.Time().Before(min) || p.Time().After(max) || list.Covers(p.Time()) {
 			continue
 		}
 
 		// No shard groups overlap with the point's time, so we will create
 		// a new shard group for this point.
-		sg, err := w.MetaClient.CreateShardGroup(wp.Database, wp.RetentionPolicy, p.Time())
+		var sg *meta.ShardGroupInfo
+		if measurement == "" {
+			sg, err = w.MetaClient.CreateShardGroup(wp.Database, wp.RetentionPolicy, p.Time())
+		} else {
+			sg, err = w.MetaClient.CreateMeasurementShardGroup(wp.Database, wp.RetentionPolicy, measurement, p.Time())
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -261,12 +344,17 @@ func (w *PointsWriter) MapShards(wp *WritePointsRequest) (*ShardMapping, error)
 		if sg == nil {
 			return nil, errors.New("nil shard group")
 		}
-		list = list.Append(*sg)
+		lists[measurement] = list.Append(*sg)
 	}
 
 	mapping := NewShardMapping(len(wp.Points))
 	for _, p := range wp.Points {
-		sg := list.ShardGroupAt(p.Time())
+		// A shard group created for another point may cover a point outside
+		// the write limits of the RP, so check them again.
+		var sg *meta.ShardGroupInfo
+		if !p.Time().Before(min) && !p.Time().After(max) {
+			sg = lists[shardGroupMeasurement(rp, p.Name())].ShardGroupAt(p.Time())
+		}
 		if sg == nil {
 			// We didn't create a shard group because the point was outside the
 			// scope of the RP.
@@ -275,12 +363,57 @@ func (w *PointsWriter) MapShards(wp *WritePointsRequest) (*ShardMapping, error)
 			continue
 		}
 
-		sh := sg.ShardFor(p.HashID())
+		hash := shardHash(p, shardKey)
+		sh := sg.ShardFor(hash)
 		mapping.MapPoint(&sh, p)
+
+		// The points of a shard group being merged are written to the
+		// merged group as well, as they may be written after the owners of
+		// the shard copied it.
+		if m := rp.ShardGroupMergeOf(sg.ID); m != nil && len(m.Group.Shards) == len(sg.Shards) {
+			msh := m.Group.ShardFor(hash)
+			mapping.MapPoint(&msh, p)
+		}
 	}
 	return mapping, nil
 }
 
+// shardGroupMeasurement returns the measurement whose shard groups hold the
+// points of the measurement name, which is empty unless the measurement has a
+// shard group duration of its own in rp.
+func shardGroupMeasurement(rp *meta.RetentionPolicyInfo, name []byte) string {
+	if _, ok := rp.MeasurementShardGroupDurations[string(name)]; ok {
+		return string(name)
+	}
+	return ""
+}
+
+// shardHash returns the hash selecting the shard of p. With a shard key, p
+// is hashed on the values of its shard key tags, so that the points sharing
+// those values land in the same shard. Points with none of the tags are
+// hashed on their series key.
+func shardHash(p models.Point, shardKey []string) uint64 {
+	if len(shardKey) == 0 {
+		return p.HashID()
+	}
+
+	tags := p.Tags()
+	h := models.NewInlineFNV64a()
+	var found bool
+	for _, key := range shardKey {
+		v := tags.Get([]byte(key))
+		if v != nil {
+			found = true
+		}
+		h.Write(v)
+		h.Write([]byte{0})
+	}
+	if !found {
+		return p.HashID()
+	}
+	return h.Sum64()
+}
+
 // sgList is a wrapper around a meta.ShardGroupInfos where we can also check
 // if a given time is covered by any of the shard groups in the list.
 type sgList meta.ShardGroupInfos
@@ -329,11 +462,59 @@ func (w *PointsWriter) WritePoints(database, retentionPolicy string, consistency
 	return w.WritePointsPrivileged(database, retentionPolicy, consistencyLevel, points)
 }
 
+// WritePointsIdempotent writes the data like WritePoints unless the user made
+// a write with the same idempotency key to the retention policy of the
+// database within the IdempotencyKeyTTL. A retried write returns the result
+// of the first one, and waits for it if it is still in progress.
+//
+// Keys are only known to this node: a retry sent to another node, such as
+// through a load balancer, is written again. The stages of the write are
+// recorded in the write trace of ctx.
+func (w *PointsWriter) WritePointsIdempotent(ctx context.Context, key, database, retentionPolicy string, consistencyLevel ConsistencyLevel, user meta.User, points []models.Point) error {
+	if key == "" || w.IdempotencyKeyTTL <= 0 {
+		return w.writePointsPrivileged(ctx, database, retentionPolicy, consistencyLevel, points)
+	}
+
+	scope := idempotencyScope{database: database, retentionPolicy: retentionPolicy, key: key}
+	if user != nil {
+		scope.user = user.ID()
+	}
+	for {
+		iw, owner := w.idempotencyKeys.begin(scope, time.Now(), w.MaxIdempotencyKeys)
+		if owner {
+			err := w.writePointsPrivileged(ctx, database, retentionPolicy, consistencyLevel, points)
+			w.idempotencyKeys.finish(iw, err, time.Now().Add(w.IdempotencyKeyTTL))
+			return err
+		}
+
+		select {
+		case <-iw.done:
+		case <-w.closing:
+			return ErrWriteFailed
+		}
+
+		// The first write failed and its key was forgotten, so try again.
+		if _, ok := iw.err.(tsdb.PartialWriteError); iw.err != nil && !ok {
+			continue
+		}
+		atomic.AddInt64(&w.stats.WriteDuplicate, 1)
+		return iw.err
+	}
+}
+
 // WritePointsPrivileged writes the data to the underlying storage, consitencyLevel is only used for clustered scenarios
 func (w *PointsWriter) WritePointsPrivileged(database, retentionPolicy string, consistencyLevel ConsistencyLevel, points []models.Point) error {
+	return w.writePointsPrivileged(context.Background(), database, retentionPolicy, consistencyLevel, points)
+}
+
+func (w *PointsWriter) writePointsPrivileged(ctx context.Context, database, retentionPolicy string, consistencyLevel ConsistencyLevel, points []models.Point) error {
 	atomic.AddInt64(&w.stats.WriteReq, 1)
 	atomic.AddInt64(&w.stats.PointWriteReq, int64(len(points)))
 
+	if err := w.writable(database); err != nil {
+		return err
+	}
+
 	if retentionPolicy == "" {
 		db := w.MetaClient.Database(database)
 		if db == nil {
@@ -342,18 +523,21 @@ func (w *PointsWriter) WritePointsPrivileged(database, retentionPolicy string, c
 		retentionPolicy = db.DefaultRetentionPolicy
 	}
 
+	trace := tsdb.WriteTraceFromContext(ctx)
+	start := time.Now()
 	shardMappings, err := w.MapShards(&WritePointsRequest{Database: database, RetentionPolicy: retentionPolicy, Points: points})
 	if err != nil {
 		return err
 	}
+	trace.Record("shard map", 0, start)
 
 	// Write each shard in it's own goroutine and return as soon as one fails.
 	ch := make(chan error, len(shardMappings.Points))
 	for shardID, points := range shardMappings.Points {
 		go func(shard *meta.ShardInfo, database, retentionPolicy string, points []models.Point) {
-			err := w.writeToShard(shard, database, retentionPolicy, consistencyLevel, points)
+			err := w.writeToShard(ctx, shard, database, retentionPolicy, consistencyLevel, points)
 			if err == tsdb.ErrShardDeletion {
-				err = tsdb.PartialWriteError{Reason: fmt.Sprintf("shard %d is pending deletion", shard.ID), Dropped: len(points)}
+				err = droppedPointsError(fmt.Sprintf("shard %d is pending deletion", shard.ID), points)
 			}
 			ch <- err
 		}(shardMappings.Shards[shardID], database, retentionPolicy, points)
@@ -383,8 +567,7 @@ func (w *PointsWriter) WritePointsPrivileged(database, retentionPolicy string, c
 	}
 
 	if err == nil && len(shardMappings.Dropped) > 0 {
-		err = tsdb.PartialWriteError{Reason: "points beyond retention policy", Dropped: len(shardMappings.Dropped)}
-
+		err = droppedPointsError("points beyond retention policy", shardMappings.Dropped)
 	}
 	timeout := time.NewTimer(w.WriteTimeout)
 	defer timeout.Stop()
@@ -396,18 +579,99 @@ func (w *PointsWriter) WritePointsPrivileged(database, retentionPolicy string, c
 			atomic.AddInt64(&w.stats.WriteTimeout, 1)
 			// return timeout error to caller
 			return ErrTimeout
-		case err := <-ch:
-			if err != nil {
-				return err
+		case werr := <-ch:
+			// The partial writes of the shards are merged, so that all of
+			// the dropped points are reported.
+			if perr, ok := werr.(tsdb.PartialWriteError); ok {
+				if prev, ok := err.(tsdb.PartialWriteError); ok {
+					perr = tsdb.MergePartialWriteErrors(prev, perr)
+				}
+				err = perr
+			} else if werr != nil {
+				return werr
 			}
 		}
 	}
+
+	// The points of a partial write were written too, so the views of their
+	// measurements are refreshed either way.
+	if w.MaterializedViews != nil {
+		w.MaterializedViews.MarkDirty(database, retentionPolicy, points)
+	}
+	w.sendCommitted(database, retentionPolicy, points, err)
 	return err
 }
 
+// sendCommitted sends the points written by a write that returned err to the
+// commit subscribers. The points dropped by a partial write are left out, and
+// nothing is sent if they are not known.
+func (w *PointsWriter) sendCommitted(database, retentionPolicy string, points []models.Point, err error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if len(w.commitPoints) == 0 {
+		return
+	}
+
+	if perr, ok := err.(tsdb.PartialWriteError); ok {
+		if len(perr.Points) < perr.Dropped {
+			return
+		}
+		dropped := make(map[string]struct{}, len(perr.Points))
+		for _, p := range perr.Points {
+			dropped[committedPointKey(p.Point)] = struct{}{}
+		}
+		committed := make([]models.Point, 0, len(points))
+		for _, p := range points {
+			if _, ok := dropped[committedPointKey(p)]; !ok {
+				committed = append(committed, p)
+			}
+		}
+		points = committed
+	}
+	if len(points) == 0 {
+		return
+	}
+
+	req := &WritePointsRequest{Database: database, RetentionPolicy: retentionPolicy, Points: points}
+	for _, ch := range w.commitPoints {
+		select {
+		case ch <- req:
+		default:
+		}
+	}
+}
+
+// committedPointKey returns the series key and time of p.
+func committedPointKey(p models.Point) string {
+	return string(p.Key()) + "\x00" + strconv.FormatInt(p.UnixNano(), 10)
+}
+
+// writable returns an error if the node is read-only or the database is
+// frozen.
+func (w *PointsWriter) writable(database string) error {
+	if w.Node != nil {
+		if ni, err := w.MetaClient.DataNode(w.Node.ID); err == nil && ni.ReadOnly {
+			return ReadOnlyError{Reason: ni.ReadOnlyReason}
+		}
+	}
+	if di := w.MetaClient.Database(database); di != nil && di.Frozen {
+		return ErrDatabaseFrozen
+	}
+	return nil
+}
+
+// droppedPointsError returns a partial write dropping points for reason.
+func droppedPointsError(reason string, points []models.Point) tsdb.PartialWriteError {
+	dropped := make([]tsdb.DroppedPoint, len(points))
+	for i, p := range points {
+		dropped[i] = tsdb.DroppedPoint{Point: p, Reason: reason}
+	}
+	return tsdb.PartialWriteError{Reason: reason, Dropped: len(points), Points: dropped}
+}
+
 // writeToShards writes points to a shard and ensures a write consistency level has been met.  If the write
 // partially succeeds, ErrPartialWrite is returned.
-func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPolicy string,
+func (w *PointsWriter) writeToShard(ctx context.Context, shard *meta.ShardInfo, database, retentionPolicy string,
 	consistency ConsistencyLevel, points []models.Point) error {
 	atomic.AddInt64(&w.stats.PointWriteReqLocal, int64(len(points)))
 
@@ -431,7 +695,7 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPo
 		go func(shardID uint64, owner meta.ShardOwner, points []models.Point) {
 			if w.Node.ID == owner.NodeID {
 				atomic.AddInt64(&w.stats.PointWriteReqLocal, int64(len(points)))
-				err := w.TSDBStore.WriteToShard(shardID, points)
+				err := w.TSDBStore.WriteToShardWithContext(ctx, shardID, points)
 				// If we've written to shard that should exist on the current node, but the store has
 				// not actually created this shard, tell it to create it and retry the write
 				if err == tsdb.ErrShardNotFound {
@@ -440,14 +704,24 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPo
 						ch <- &AsyncWriteResult{owner, err}
 						return
 					}
-					err = w.TSDBStore.WriteToShard(shardID, points)
+					err = w.TSDBStore.WriteToShardWithContext(ctx, shardID, points)
 				}
 				ch <- &AsyncWriteResult{owner, err}
 				return
 			}
 
 			atomic.AddInt64(&w.stats.PointWriteReqRemote, int64(len(points)))
-			err := w.ShardWriter.WriteShard(shardID, owner.NodeID, points)
+			var err error
+			if w.NodeHealth != nil && w.NodeHealth.Dead(owner.NodeID) {
+				atomic.AddInt64(&w.stats.WriteNodeDead, 1)
+				err = ErrNodeDead
+			} else {
+				start := time.Now()
+				err = w.ShardWriter.WriteShard(shardID, owner.NodeID, points)
+				if trace := tsdb.WriteTraceFromContext(ctx); trace != nil {
+					trace.Record(fmt.Sprintf("remote write to node %d", owner.NodeID), shardID, start)
+				}
+			}
 			if err != nil && tsdb.IsRetryable(err) {
 				// The remote write failed so queue it via hinted handoff
 				atomic.AddInt64(&w.stats.WritePointReqHH, int64(len(points)))
@@ -473,6 +747,7 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPo
 	var wrote int
 	timeout := time.After(w.WriteTimeout)
 	var writeError error
+	var partialError error
 	for range shard.Owners {
 		select {
 		case <-w.closing:
@@ -482,6 +757,14 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPo
 			// return timeout error to caller
 			return ErrTimeout
 		case result := <-ch:
+			// A partial write wrote the points that were not dropped.
+			if _, ok := result.Err.(tsdb.PartialWriteError); ok {
+				if partialError == nil {
+					partialError = result.Err
+				}
+				result.Err = nil
+			}
+
 			// If the write returned an error, continue to the next response
 			if result.Err != nil {
 				atomic.AddInt64(&w.stats.WriteErr, 1)
@@ -499,7 +782,7 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPo
 			// We wrote the required consistency level
 			if wrote >= required {
 				atomic.AddInt64(&w.stats.WriteOK, 1)
-				return nil
+				return partialError
 			}
 		}
 	}
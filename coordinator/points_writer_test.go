@@ -1,6 +1,8 @@
 package coordinator_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"sync"
@@ -242,6 +244,204 @@ func TestPointsWriter_MapShards_Invalid(t *testing.T) {
 	}
 }
 
+// Ensures the points writer does not map points outside the write limits of
+// the retention policy.
+func TestPointsWriter_MapShards_WriteLimits(t *testing.T) {
+	ms := PointsWriterMetaClient{}
+	rp := NewRetentionPolicy("myp", 0, 3)
+	rp.FutureWriteLimit = time.Hour
+	rp.PastWriteLimit = time.Hour
+
+	ms.RetentionPolicyFn = func(db, retentionPolicy string) (*meta.RetentionPolicyInfo, error) {
+		return rp, nil
+	}
+
+	var created int
+	ms.CreateShardGroupIfNotExistsFn = func(database, policy string, timestamp time.Time) (*meta.ShardGroupInfo, error) {
+		created++
+		return &meta.ShardGroupInfo{
+			ID:        uint64(created),
+			StartTime: timestamp.Truncate(time.Hour),
+			EndTime:   timestamp.Truncate(time.Hour).Add(time.Hour),
+			Shards:    []meta.ShardInfo{{ID: uint64(created)}},
+		}, nil
+	}
+
+	c := coordinator.NewPointsWriter()
+	c.MetaClient = ms
+	defer c.Close()
+	pr := &coordinator.WritePointsRequest{
+		Database:        "mydb",
+		RetentionPolicy: "myrp",
+	}
+
+	now := time.Now()
+	pr.AddPoint("cpu", 1.0, now, nil)
+	pr.AddPoint("cpu", 2.0, now.Add(-2*time.Hour), nil)
+	pr.AddPoint("cpu", 3.0, now.AddDate(1, 0, 0), nil)
+
+	shardMappings, err := c.MapShards(pr)
+	if err != nil {
+		t.Fatalf("unexpected an error: %v", err)
+	}
+
+	if got, exp := created, 1; got != exp {
+		t.Errorf("shard groups created mismatch: got %v, exp %v", got, exp)
+	}
+	if got, exp := len(shardMappings.Dropped), 2; got != exp {
+		t.Fatalf("MapShard() dropped mismatch: got %v, exp %v", got, exp)
+	}
+}
+
+// Ensures the points writer maps points with the same shard key tag values
+// to the same shard.
+func TestPointsWriter_MapShards_ShardKey(t *testing.T) {
+	ms := PointsWriterMetaClient{}
+	rp := NewRetentionPolicy("myp", 0, 3)
+
+	ms.RetentionPolicyFn = func(db, retentionPolicy string) (*meta.RetentionPolicyInfo, error) {
+		return rp, nil
+	}
+	ms.DatabaseFn = func(database string) *meta.DatabaseInfo {
+		return &meta.DatabaseInfo{Name: database, ShardKey: []string{"host"}}
+	}
+	ms.CreateShardGroupIfNotExistsFn = func(database, policy string, timestamp time.Time) (*meta.ShardGroupInfo, error) {
+		sg := &meta.ShardGroupInfo{
+			ID:        1,
+			StartTime: timestamp.Truncate(time.Hour),
+			EndTime:   timestamp.Truncate(time.Hour).Add(time.Hour),
+		}
+		for i := 1; i <= 16; i++ {
+			sg.Shards = append(sg.Shards, meta.ShardInfo{ID: uint64(i)})
+		}
+		return sg, nil
+	}
+
+	c := coordinator.NewPointsWriter()
+	c.MetaClient = ms
+	defer c.Close()
+	pr := &coordinator.WritePointsRequest{
+		Database:        "mydb",
+		RetentionPolicy: "myrp",
+	}
+
+	now := time.Now()
+	for i := 0; i < 20; i++ {
+		pr.AddPoint(fmt.Sprintf("m%d", i), 1.0, now, map[string]string{"host": "serverA", "cpu": fmt.Sprint(i)})
+	}
+
+	shardMappings, err := c.MapShards(pr)
+	if err != nil {
+		t.Fatalf("unexpected an error: %v", err)
+	}
+	if got, exp := len(shardMappings.Points), 1; got != exp {
+		t.Fatalf("MapShards() shard count mismatch: got %v, exp %v", got, exp)
+	}
+}
+
+func TestPointsWriter_MapShards_MeasurementShardGroupDuration(t *testing.T) {
+	ms := PointsWriterMetaClient{}
+	rp := NewRetentionPolicy("myp", 0, 1)
+	rp.MeasurementShardGroupDurations = map[string]time.Duration{"busy": time.Minute}
+
+	ms.RetentionPolicyFn = func(db, retentionPolicy string) (*meta.RetentionPolicyInfo, error) {
+		return rp, nil
+	}
+	ms.CreateShardGroupIfNotExistsFn = func(database, policy string, timestamp time.Time) (*meta.ShardGroupInfo, error) {
+		return &rp.ShardGroups[0], nil
+	}
+	ms.CreateMeasurementShardGroupFn = func(database, policy, measurement string, timestamp time.Time) (*meta.ShardGroupInfo, error) {
+		if measurement != "busy" {
+			t.Fatalf("unexpected measurement: %s", measurement)
+		}
+		return &meta.ShardGroupInfo{
+			ID:          2,
+			StartTime:   timestamp.Truncate(time.Minute),
+			EndTime:     timestamp.Truncate(time.Minute).Add(time.Minute),
+			Shards:      []meta.ShardInfo{{ID: 100}},
+			Measurement: measurement,
+		}, nil
+	}
+
+	c := coordinator.NewPointsWriter()
+	c.MetaClient = ms
+	defer c.Close()
+	pr := &coordinator.WritePointsRequest{
+		Database:        "mydb",
+		RetentionPolicy: "myrp",
+	}
+	now := time.Now()
+	pr.AddPoint("busy", 1.0, now, nil)
+	pr.AddPoint("quiet", 1.0, now, nil)
+
+	shardMappings, err := c.MapShards(pr)
+	if err != nil {
+		t.Fatalf("unexpected an error: %v", err)
+	}
+	if got, exp := len(shardMappings.Points), 2; got != exp {
+		t.Fatalf("MapShards() shard count mismatch: got %v, exp %v", got, exp)
+	}
+	if p := shardMappings.Points[100]; len(p) != 1 || string(p[0].Name()) != "busy" {
+		t.Fatalf("unexpected points in the measurement shard: %v", p)
+	}
+}
+
+// Ensures the points of a shard group being merged are mapped to the merged
+// shard group as well.
+func TestPointsWriter_MapShards_ShardGroupMerge(t *testing.T) {
+	ms := PointsWriterMetaClient{}
+	start := time.Now().Add(-3 * time.Hour).Truncate(time.Hour)
+	owners := []meta.ShardOwner{{NodeID: 1}}
+	rp := &meta.RetentionPolicyInfo{
+		Name:               "myrp",
+		ReplicaN:           1,
+		ShardGroupDuration: time.Hour,
+		ShardGroups: []meta.ShardGroupInfo{
+			{ID: 1, StartTime: start, EndTime: start.Add(time.Hour), Shards: []meta.ShardInfo{{ID: 10, Owners: owners}}},
+			{ID: 2, StartTime: start.Add(time.Hour), EndTime: start.Add(2 * time.Hour), Shards: []meta.ShardInfo{{ID: 20, Owners: owners}}},
+		},
+		ShardGroupMerges: []meta.ShardGroupMergeInfo{{
+			Sources: []uint64{1},
+			Group:   meta.ShardGroupInfo{ID: 3, StartTime: start, EndTime: start.Add(time.Hour), Shards: []meta.ShardInfo{{ID: 30, Owners: owners}}},
+		}},
+	}
+
+	ms.RetentionPolicyFn = func(db, retentionPolicy string) (*meta.RetentionPolicyInfo, error) {
+		return rp, nil
+	}
+	ms.CreateShardGroupIfNotExistsFn = func(database, policy string, timestamp time.Time) (*meta.ShardGroupInfo, error) {
+		for i := range rp.ShardGroups {
+			if rp.ShardGroups[i].Contains(timestamp) {
+				return &rp.ShardGroups[i], nil
+			}
+		}
+		return nil, fmt.Errorf("no shard group for %s", timestamp)
+	}
+
+	c := coordinator.NewPointsWriter()
+	c.MetaClient = ms
+	defer c.Close()
+	pr := &coordinator.WritePointsRequest{
+		Database:        "mydb",
+		RetentionPolicy: "myrp",
+	}
+	pr.AddPoint("cpu", 1.0, start.Add(time.Minute), nil)
+	pr.AddPoint("cpu", 2.0, start.Add(time.Hour+time.Minute), nil)
+
+	shardMappings, err := c.MapShards(pr)
+	if err != nil {
+		t.Fatalf("unexpected an error: %v", err)
+	}
+	for id, n := range map[uint64]int{10: 1, 20: 1, 30: 1} {
+		if got := len(shardMappings.Points[id]); got != n {
+			t.Errorf("unexpected points mapped to shard %d: got %d, exp %d", id, got, n)
+		}
+	}
+	if got := shardMappings.Points[30]; len(got) == 1 && !got[0].Time().Equal(start.Add(time.Minute)) {
+		t.Errorf("unexpected point mapped to merged shard: %v", got[0])
+	}
+}
+
 func TestPointsWriter_WritePoints(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -426,6 +626,87 @@ func TestPointsWriter_WritePoints_Dropped(t *testing.T) {
 	}
 }
 
+// Ensure the commit subscribers receive the points written, without the
+// points dropped by the shards, and nothing for a failed write.
+func TestPointsWriter_WritePoints_CommitSubscriber(t *testing.T) {
+	pr := &coordinator.WritePointsRequest{
+		Database:        "mydb",
+		RetentionPolicy: "myrp",
+	}
+	pr.AddPoint("cpu", 1.0, time.Now(), nil)
+	pr.AddPoint("mem", 1.0, time.Now(), nil)
+
+	var writeErr error
+	store := &fakeStore{
+		WriteFn: func(shardID uint64, points []models.Point) error {
+			return writeErr
+		},
+	}
+
+	ms := NewPointsWriterMetaClient()
+	ms.DatabaseFn = func(database string) *meta.DatabaseInfo {
+		return nil
+	}
+	ms.NodeIDFn = func() uint64 { return 1 }
+
+	committed := make(chan *coordinator.WritePointsRequest, 1)
+	c := coordinator.NewPointsWriter()
+	c.MetaClient = ms
+	c.TSDBStore = store
+	c.AddCommitSubscriber(committed)
+	c.Node = &freetsdb.Node{ID: 1}
+
+	c.Open()
+	defer c.Close()
+
+	writeErr = tsdb.PartialWriteError{Reason: "field type conflict", Dropped: 1, Points: []tsdb.DroppedPoint{{Point: pr.Points[1]}}}
+	if err := c.WritePointsPrivileged(pr.Database, pr.RetentionPolicy, models.ConsistencyLevelOne, pr.Points); err == nil {
+		t.Fatal("expected a partial write")
+	}
+	select {
+	case req := <-committed:
+		if exp := []models.Point{pr.Points[0]}; !reflect.DeepEqual(req.Points, exp) {
+			t.Fatalf("unexpected points: got %v, exp %v", req.Points, exp)
+		}
+	default:
+		t.Fatal("expected the committed points")
+	}
+
+	writeErr = errors.New("write failed")
+	if err := c.WritePointsPrivileged(pr.Database, pr.RetentionPolicy, models.ConsistencyLevelOne, pr.Points); err == nil {
+		t.Fatal("expected an error")
+	}
+	select {
+	case req := <-committed:
+		t.Fatalf("unexpected points: %v", req.Points)
+	default:
+	}
+}
+
+func TestPointsWriter_WritePoints_Modes(t *testing.T) {
+	var node meta.NodeInfo
+	var db meta.DatabaseInfo
+	ms := PointsWriterMetaClient{
+		DataNodeFn: func(id uint64) (*meta.NodeInfo, error) { return &node, nil },
+		DatabaseFn: func(database string) *meta.DatabaseInfo { return &db },
+	}
+	c := coordinator.NewPointsWriter()
+	c.MetaClient = ms
+	c.Node = &freetsdb.Node{ID: 1}
+
+	node.ReadOnly, node.ReadOnlyReason = true, "disk full"
+	err := c.WritePointsPrivileged("db0", "rp0", models.ConsistencyLevelOne, nil)
+	if exp := (coordinator.ReadOnlyError{Reason: "disk full"}); err != exp {
+		t.Fatalf("unexpected error: got %v, exp %v", err, exp)
+	}
+
+	node.ReadOnly, db.Frozen = false, true
+	err = c.WritePointsPrivileged("db0", "rp0", models.ConsistencyLevelOne, nil)
+	if err != coordinator.ErrDatabaseFrozen {
+		t.Fatalf("unexpected error: got %v, exp %v", err, coordinator.ErrDatabaseFrozen)
+	}
+}
+
 type fakePointsWriter struct {
 	WritePointsIntoFn func(*coordinator.IntoWriteRequest) error
 }
@@ -551,7 +832,7 @@ type fakeStore struct {
 	CreateShardfn func(database, retentionPolicy string, shardID uint64, enabled bool) error
 }
 
-func (f *fakeStore) WriteToShard(shardID uint64, points []models.Point) error {
+func (f *fakeStore) WriteToShardWithContext(ctx context.Context, shardID uint64, points []models.Point) error {
 	return f.WriteFn(shardID, points)
 }
 
@@ -592,8 +873,10 @@ type PointsWriterMetaClient struct {
 	NodeIDFn                      func() uint64
 	RetentionPolicyFn             func(database, name string) (*meta.RetentionPolicyInfo, error)
 	CreateShardGroupIfNotExistsFn func(database, policy string, timestamp time.Time) (*meta.ShardGroupInfo, error)
+	CreateMeasurementShardGroupFn func(database, policy, measurement string, timestamp time.Time) (*meta.ShardGroupInfo, error)
 	DatabaseFn                    func(database string) *meta.DatabaseInfo
 	ShardOwnerFn                  func(shardID uint64) (string, string, *meta.ShardGroupInfo)
+	DataNodeFn                    func(id uint64) (*meta.NodeInfo, error)
 }
 
 func (m PointsWriterMetaClient) NodeID() uint64 { return m.NodeIDFn() }
@@ -606,7 +889,14 @@ func (m PointsWriterMetaClient) CreateShardGroup(database, policy string, timest
 	return m.CreateShardGroupIfNotExistsFn(database, policy, timestamp)
 }
 
+func (m PointsWriterMetaClient) CreateMeasurementShardGroup(database, policy, measurement string, timestamp time.Time) (*meta.ShardGroupInfo, error) {
+	return m.CreateMeasurementShardGroupFn(database, policy, measurement, timestamp)
+}
+
 func (m PointsWriterMetaClient) Database(database string) *meta.DatabaseInfo {
+	if m.DatabaseFn == nil {
+		return nil
+	}
 	return m.DatabaseFn(database)
 }
 
@@ -614,6 +904,13 @@ func (m PointsWriterMetaClient) ShardOwner(shardID uint64) (string, string, *met
 	return m.ShardOwnerFn(shardID)
 }
 
+func (m PointsWriterMetaClient) DataNode(id uint64) (*meta.NodeInfo, error) {
+	if m.DataNodeFn == nil {
+		return nil, meta.ErrNodeNotFound
+	}
+	return m.DataNodeFn(id)
+}
+
 type Subscriber struct {
 	PointsFn func() chan<- *coordinator.WritePointsRequest
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/freetsdb/freetsdb/models"
 	"github.com/freetsdb/freetsdb/query"
 	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/tsdb"
 	"github.com/gogo/protobuf/proto"
 )
 
@@ -36,7 +37,8 @@ func (w *WritePointsRequest) AddPoint(name string, value interface{}, timestamp
 
 // WriteShardRequest represents the a request to write a slice of points to a shard
 type WriteShardRequest struct {
-	pb internal.WriteShardRequest
+	pb     internal.WriteShardRequest
+	points []models.Point
 }
 
 // WriteShardResponse represents the response returned from a remote WriteShardRequest call
@@ -74,21 +76,36 @@ func (w *WriteShardRequest) AddPoint(name string, value interface{}, timestamp t
 
 // AddPoints adds a new time series point
 func (w *WriteShardRequest) AddPoints(points []models.Point) {
-	for _, p := range points {
-		b, err := p.MarshalBinary()
+	w.points = append(w.points, points...)
+}
+
+// MarshalBinary encodes the object to a binary format. The points are
+// encoded together as a batch, which is decoded without parsing them again.
+func (w *WriteShardRequest) MarshalBinary() ([]byte, error) {
+	if len(w.points) > 0 {
+		b, err := models.AppendPointsBinary(nil, w.points)
 		if err != nil {
-			// A error here means that we create a point higher in the stack that we could
-			// not marshal to a byte slice.  If that happens, the endpoint that created that
-			// point needs to be fixed.
-			panic(fmt.Sprintf("failed to marshal point: `%v`: %v", p, err))
+			return nil, err
 		}
-		w.pb.Points = append(w.pb.Points, b)
+		w.pb.Batch = b
 	}
+	return proto.Marshal(&w.pb)
 }
 
-// MarshalBinary encodes the object to a binary format.
-func (w *WriteShardRequest) MarshalBinary() ([]byte, error) {
-	return proto.Marshal(&w.pb)
+// MarshalLegacyBinary encodes the object to the binary format of the nodes
+// without point batches, with each point encoded on its own.
+func (w *WriteShardRequest) MarshalLegacyBinary() ([]byte, error) {
+	pb := w.pb
+	pb.Batch = nil
+	pb.Points = make([][]byte, 0, len(w.points))
+	for _, p := range w.points {
+		b, err := p.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		pb.Points = append(pb.Points, b)
+	}
+	return proto.Marshal(&pb)
 }
 
 // UnmarshalBinary populates WritePointRequest from a binary format.
@@ -100,6 +117,19 @@ func (w *WriteShardRequest) UnmarshalBinary(buf []byte) error {
 }
 
 func (w *WriteShardRequest) unmarshalPoints() []models.Point {
+	if w.points != nil {
+		return w.points
+	} else if b := w.pb.GetBatch(); b != nil {
+		points, err := models.ParsePointsBinary(b)
+		if err != nil {
+			// As with the points sent one by one, this shouldn't ever happen.
+			panic(fmt.Sprintf("failed to parse point batch: %v", err))
+		}
+		w.points = points
+		return points
+	}
+
+	// Requests from older nodes send each point on its own.
 	points := make([]models.Point, len(w.pb.GetPoints()))
 	for i, p := range w.pb.GetPoints() {
 		pt, err := models.NewPointFromBytes(p)
@@ -209,6 +239,11 @@ type CreateIteratorRequest struct {
 	ShardIDs    []uint64
 	Measurement influxql.Measurement
 	Opt         query.IteratorOptions
+
+	// Federated is set by a foreign cluster. The receiving node maps the
+	// shards of its cluster for the measurement and time range of Opt
+	// instead of reading ShardIDs.
+	Federated bool
 }
 
 // MarshalBinary encodes r to a binary format.
@@ -217,13 +252,24 @@ func (r *CreateIteratorRequest) MarshalBinary() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	return proto.Marshal(&internal.CreateIteratorRequest{
+	pb := &internal.CreateIteratorRequest{
 		ShardIDs:        r.ShardIDs,
 		Database:        []byte(r.Measurement.Database),
 		RetentionPolicy: []byte(r.Measurement.RetentionPolicy),
 		MeasurementName: []byte(r.Measurement.Name),
 		Opt:             buf,
-	})
+	}
+	if r.Federated {
+		// The whole measurement is sent so regexes are matched against
+		// the measurements of the foreign cluster.
+		mbuf, err := r.Measurement.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		pb.Federated = proto.Bool(true)
+		pb.Measurement = mbuf
+	}
+	return proto.Marshal(pb)
 }
 
 // UnmarshalBinary decodes data into r.
@@ -240,6 +286,12 @@ func (r *CreateIteratorRequest) UnmarshalBinary(data []byte) error {
 	if err := r.Opt.UnmarshalBinary(pb.GetOpt()); err != nil {
 		return err
 	}
+	r.Federated = pb.GetFederated()
+	if buf := pb.GetMeasurement(); len(buf) > 0 {
+		if err := r.Measurement.UnmarshalBinary(buf); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -281,6 +333,12 @@ func (r *CreateIteratorResponse) UnmarshalBinary(data []byte) error {
 type FieldDimensionsRequest struct {
 	ShardIDs    []uint64
 	Measurement influxql.Measurement
+
+	// Federated is set by a foreign cluster. The receiving node maps the
+	// shards of its cluster for the measurement between MinTime and MaxTime
+	// instead of reading ShardIDs.
+	Federated        bool
+	MinTime, MaxTime int64
 }
 
 // MarshalBinary encodes r to a binary format.
@@ -289,10 +347,16 @@ func (r *FieldDimensionsRequest) MarshalBinary() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	return proto.Marshal(&internal.FieldDimensionsRequest{
+	pb := &internal.FieldDimensionsRequest{
 		ShardIDs:    r.ShardIDs,
 		Measurement: buf,
-	})
+	}
+	if r.Federated {
+		pb.Federated = proto.Bool(true)
+		pb.MinTime = proto.Int64(r.MinTime)
+		pb.MaxTime = proto.Int64(r.MaxTime)
+	}
+	return proto.Marshal(pb)
 }
 
 // UnmarshalBinary decodes data into r.
@@ -306,6 +370,8 @@ func (r *FieldDimensionsRequest) UnmarshalBinary(data []byte) error {
 	if err := r.Measurement.UnmarshalBinary(pb.GetMeasurement()); err != nil {
 		return err
 	}
+	r.Federated = pb.GetFederated()
+	r.MinTime, r.MaxTime = pb.GetMinTime(), pb.GetMaxTime()
 
 	return nil
 }
@@ -360,3 +426,302 @@ func (r *FieldDimensionsResponse) UnmarshalBinary(data []byte) error {
 	}
 	return nil
 }
+
+// ShowQueriesResponse represents the queries running on a node.
+type ShowQueriesResponse struct {
+	Queries []query.QueryInfo
+	Err     error
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *ShowQueriesResponse) MarshalBinary() ([]byte, error) {
+	var pb internal.ShowQueriesResponse
+	for _, qi := range r.Queries {
+		pb.Queries = append(pb.Queries, &internal.QueryInfo{
+			ID:       proto.Uint64(qi.ID),
+			Query:    proto.String(qi.Query),
+			Database: proto.String(qi.Database),
+			Duration: proto.Int64(int64(qi.Duration)),
+			Status:   proto.String(qi.Status.String()),
+			Memory:   proto.Int64(qi.Memory),
+			Priority: proto.String(qi.Priority.String()),
+			Spilled:  proto.Int64(qi.Spilled),
+		})
+	}
+	if r.Err != nil {
+		pb.Err = proto.String(r.Err.Error())
+	}
+	return proto.Marshal(&pb)
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *ShowQueriesResponse) UnmarshalBinary(data []byte) error {
+	var pb internal.ShowQueriesResponse
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+
+	r.Queries = make([]query.QueryInfo, 0, len(pb.GetQueries()))
+	for _, qi := range pb.GetQueries() {
+		var status query.TaskStatus
+		switch qi.GetStatus() {
+		case query.RunningTask.String():
+			status = query.RunningTask
+		case query.KilledTask.String():
+			status = query.KilledTask
+		}
+		// An unknown priority is left interactive.
+		priority, _ := query.ParseQueryPriority(qi.GetPriority())
+
+		r.Queries = append(r.Queries, query.QueryInfo{
+			ID:       qi.GetID(),
+			Query:    qi.GetQuery(),
+			Database: qi.GetDatabase(),
+			Duration: time.Duration(qi.GetDuration()),
+			Status:   status,
+			Memory:   qi.GetMemory(),
+			Priority: priority,
+			Spilled:  qi.GetSpilled(),
+		})
+	}
+
+	if pb.Err != nil {
+		r.Err = errors.New(pb.GetErr())
+	}
+	return nil
+}
+
+// HandshakeResponse represents the version and features of a node.
+type HandshakeResponse struct {
+	Version  string
+	Protocol int
+	Features []string
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *HandshakeResponse) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&internal.HandshakeResponse{
+		Version:  proto.String(r.Version),
+		Protocol: proto.Uint32(uint32(r.Protocol)),
+		Features: r.Features,
+	})
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *HandshakeResponse) UnmarshalBinary(data []byte) error {
+	var pb internal.HandshakeResponse
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	r.Version = pb.GetVersion()
+	r.Protocol = int(pb.GetProtocol())
+	r.Features = pb.GetFeatures()
+	return nil
+}
+
+// IndexEpochRequest asks a node for the index epoch of a database.
+type IndexEpochRequest struct {
+	Database string
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *IndexEpochRequest) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&internal.IndexEpochRequest{
+		Database: proto.String(r.Database),
+	})
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *IndexEpochRequest) UnmarshalBinary(data []byte) error {
+	var pb internal.IndexEpochRequest
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	r.Database = pb.GetDatabase()
+	return nil
+}
+
+// IndexEpochResponse represents the index epoch of a database on a node and
+// the time it was first seen.
+type IndexEpochResponse struct {
+	Epoch    uint64
+	Modified time.Time
+	Err      error
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *IndexEpochResponse) MarshalBinary() ([]byte, error) {
+	pb := internal.IndexEpochResponse{
+		Epoch:    proto.Uint64(r.Epoch),
+		Modified: proto.Int64(r.Modified.UnixNano()),
+	}
+	if r.Err != nil {
+		pb.Err = proto.String(r.Err.Error())
+	}
+	return proto.Marshal(&pb)
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *IndexEpochResponse) UnmarshalBinary(data []byte) error {
+	var pb internal.IndexEpochResponse
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	r.Epoch = pb.GetEpoch()
+	r.Modified = time.Unix(0, pb.GetModified()).UTC()
+	if pb.Err != nil {
+		r.Err = errors.New(pb.GetErr())
+	}
+	return nil
+}
+
+// MeasurementStatsRequest asks a node for the measurement statistics of the
+// shards of a database.
+type MeasurementStatsRequest struct {
+	Database string
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *MeasurementStatsRequest) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&internal.MeasurementStatsRequest{
+		Database: proto.String(r.Database),
+	})
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *MeasurementStatsRequest) UnmarshalBinary(data []byte) error {
+	var pb internal.MeasurementStatsRequest
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	r.Database = pb.GetDatabase()
+	return nil
+}
+
+// MeasurementStatsResponse represents the measurement statistics of the
+// shards of a database on a node.
+type MeasurementStatsResponse struct {
+	Shards []tsdb.ShardMeasurementStats
+	Err    error
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *MeasurementStatsResponse) MarshalBinary() ([]byte, error) {
+	var pb internal.MeasurementStatsResponse
+
+	buf, err := json.Marshal(r.Shards)
+	if err != nil {
+		return nil, err
+	}
+	pb.Shards = buf
+
+	if r.Err != nil {
+		pb.Err = proto.String(r.Err.Error())
+	}
+	return proto.Marshal(&pb)
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *MeasurementStatsResponse) UnmarshalBinary(data []byte) error {
+	var pb internal.MeasurementStatsResponse
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+
+	if buf := pb.GetShards(); len(buf) > 0 {
+		if err := json.Unmarshal(buf, &r.Shards); err != nil {
+			return err
+		}
+	}
+
+	if pb.Err != nil {
+		r.Err = errors.New(pb.GetErr())
+	}
+	return nil
+}
+
+// DiskUsageRequest asks a node for the disk usage of its shards, and of its
+// retention policies at each time of Since.
+type DiskUsageRequest struct {
+	Database string
+	Since    []time.Time
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *DiskUsageRequest) MarshalBinary() ([]byte, error) {
+	pb := internal.DiskUsageRequest{
+		Database: proto.String(r.Database),
+	}
+	for _, t := range r.Since {
+		pb.Since = append(pb.Since, t.UnixNano())
+	}
+	return proto.Marshal(&pb)
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *DiskUsageRequest) UnmarshalBinary(data []byte) error {
+	var pb internal.DiskUsageRequest
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	r.Database = pb.GetDatabase()
+	r.Since = nil
+	for _, t := range pb.GetSince() {
+		r.Since = append(r.Since, time.Unix(0, t).UTC())
+	}
+	return nil
+}
+
+// DiskUsageResponse represents the disk usage of the shards of a node, and of
+// its retention policies at each time requested. A time the history of the
+// node does not reach has a nil sample.
+type DiskUsageResponse struct {
+	Shards  []tsdb.ShardDiskUsage
+	History []*tsdb.DiskUsageSample
+	Err     error
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *DiskUsageResponse) MarshalBinary() ([]byte, error) {
+	var pb internal.DiskUsageResponse
+
+	buf, err := json.Marshal(r.Shards)
+	if err != nil {
+		return nil, err
+	}
+	pb.Shards = buf
+
+	if buf, err = json.Marshal(r.History); err != nil {
+		return nil, err
+	}
+	pb.History = buf
+
+	if r.Err != nil {
+		pb.Err = proto.String(r.Err.Error())
+	}
+	return proto.Marshal(&pb)
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *DiskUsageResponse) UnmarshalBinary(data []byte) error {
+	var pb internal.DiskUsageResponse
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+
+	if buf := pb.GetShards(); len(buf) > 0 {
+		if err := json.Unmarshal(buf, &r.Shards); err != nil {
+			return err
+		}
+	}
+	if buf := pb.GetHistory(); len(buf) > 0 {
+		if err := json.Unmarshal(buf, &r.History); err != nil {
+			return err
+		}
+	}
+
+	if pb.Err != nil {
+		r.Err = errors.New(pb.GetErr())
+	}
+	return nil
+}
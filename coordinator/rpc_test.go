@@ -3,11 +3,14 @@ package coordinator
 import (
 	"errors"
 	"reflect"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/freetsdb/freetsdb/query"
 	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/tsdb"
 )
 
 func TestWriteShardRequestBinary(t *testing.T) {
@@ -78,6 +81,34 @@ func TestWriteShardRequestBinary(t *testing.T) {
 	}
 }
 
+// Ensure the points of a request encoded for older nodes are sent one by one.
+func TestWriteShardRequestLegacyBinary(t *testing.T) {
+	sr := &WriteShardRequest{}
+	sr.SetShardID(uint64(1))
+	sr.AddPoint("cpu", 1.0, time.Unix(0, 0), map[string]string{"host": "serverA"})
+	sr.AddPoint("cpu_load", 3.0, time.Unix(0, 0).Add(time.Hour), nil)
+
+	b, err := sr.MarshalLegacyBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := &WriteShardRequest{}
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if got.pb.Batch != nil {
+		t.Fatal("unexpected point batch")
+	} else if len(got.pb.Points) != 2 {
+		t.Fatalf("unexpected point count: %d", len(got.pb.Points))
+	}
+
+	for i, p := range got.Points() {
+		if exp := sr.Points()[i]; p.String() != exp.String() {
+			t.Errorf("point %d mismatch: got %v, exp %v", i, p, exp)
+		}
+	}
+}
+
 func TestWriteShardResponseBinary(t *testing.T) {
 	sr := &WriteShardResponse{}
 	sr.SetCode(10)
@@ -113,3 +144,155 @@ func TestWriteShardResponseBinary(t *testing.T) {
 	}
 
 }
+
+func TestCreateIteratorRequestBinary_Federated(t *testing.T) {
+	req := &CreateIteratorRequest{
+		Measurement: influxql.Measurement{
+			Database:        "db0",
+			RetentionPolicy: "rp0",
+			Regex:           &influxql.RegexLiteral{Val: regexp.MustCompile(`^cpu`)},
+		},
+		Opt: query.IteratorOptions{
+			StartTime: 10,
+			EndTime:   20,
+			Ascending: true,
+		},
+		Federated: true,
+	}
+
+	buf, err := req.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got CreateIteratorRequest
+	if err := got.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.Federated {
+		t.Fatal("expected federated request")
+	} else if got.Measurement.String() != req.Measurement.String() {
+		t.Fatalf("unexpected measurement: %s", got.Measurement.String())
+	} else if got.Opt.StartTime != 10 || got.Opt.EndTime != 20 {
+		t.Fatalf("unexpected time range: %d-%d", got.Opt.StartTime, got.Opt.EndTime)
+	}
+}
+
+func TestShowQueriesResponseBinary(t *testing.T) {
+	resp := &ShowQueriesResponse{Queries: []query.QueryInfo{{
+		ID:       3,
+		Query:    "SELECT * FROM cpu",
+		Database: "db0",
+		Duration: 2 * time.Second,
+		Status:   query.KilledTask,
+		Memory:   1024,
+		Priority: query.BatchPriority,
+		Spilled:  512,
+	}}}
+
+	b, err := resp.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got ShowQueriesResponse
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(got.Queries, resp.Queries) || got.Err != nil {
+		t.Fatalf("unexpected response: %s", spew.Sdump(got))
+	}
+
+	b, err = (&ShowQueriesResponse{Err: errors.New("marker")}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if got.Err == nil || got.Err.Error() != "marker" || len(got.Queries) != 0 {
+		t.Fatalf("unexpected response: %s", spew.Sdump(got))
+	}
+}
+
+func TestIndexEpochResponseBinary(t *testing.T) {
+	resp := &IndexEpochResponse{Epoch: 42, Modified: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b, err := resp.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got IndexEpochResponse
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if got.Epoch != resp.Epoch || !got.Modified.Equal(resp.Modified) || got.Err != nil {
+		t.Fatalf("unexpected response: %s", spew.Sdump(got))
+	}
+
+	b, err = (&IndexEpochResponse{Err: errors.New("marker")}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if got.Err == nil || got.Err.Error() != "marker" {
+		t.Fatalf("unexpected response: %s", spew.Sdump(got))
+	}
+}
+
+func TestMeasurementStatsResponseBinary(t *testing.T) {
+	resp := &MeasurementStatsResponse{Shards: []tsdb.ShardMeasurementStats{{
+		ID: 3,
+		Measurements: map[string]tsdb.MeasurementStats{
+			"cpu": {MinTime: 1, MaxTime: 2, PointN: 3, DiskSize: 4},
+		},
+	}}}
+	b, err := resp.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got MeasurementStatsResponse
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(got.Shards, resp.Shards) || got.Err != nil {
+		t.Fatalf("unexpected response: %s", spew.Sdump(got))
+	}
+
+	b, err = (&MeasurementStatsResponse{Err: errors.New("marker")}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = MeasurementStatsResponse{}
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if got.Err == nil || got.Err.Error() != "marker" || len(got.Shards) != 0 {
+		t.Fatalf("unexpected response: %s", spew.Sdump(got))
+	}
+}
+
+func TestDiskUsageResponseBinary(t *testing.T) {
+	resp := &DiskUsageResponse{
+		Shards: []tsdb.ShardDiskUsage{{
+			ID:              3,
+			Database:        "db0",
+			RetentionPolicy: "rp0",
+			DiskUsage:       tsdb.DiskUsage{TSM: 1, WAL: 2, Index: 3},
+			Measurements:    map[string]int64{"cpu": 1},
+		}},
+		History: []*tsdb.DiskUsageSample{
+			{
+				Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				Policies: []tsdb.RetentionPolicyDiskUsage{
+					{Database: "db0", RetentionPolicy: "rp0", DiskUsage: tsdb.DiskUsage{TSM: 1}},
+				},
+			},
+			nil,
+		},
+	}
+	b, err := resp.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got DiskUsageResponse
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(got.Shards, resp.Shards) || !reflect.DeepEqual(got.History, resp.History) || got.Err != nil {
+		t.Fatalf("unexpected response: %s", spew.Sdump(got))
+	}
+}
@@ -5,13 +5,14 @@ import (
 	"context"
 	"encoding"
 	"encoding/binary"
+	"errors"
 	"expvar"
 	"fmt"
 	"io"
 	"net"
 	"strings"
 	"sync"
-	//"time"
+	"time"
 
 	"github.com/freetsdb/freetsdb"
 	"github.com/freetsdb/freetsdb/query"
@@ -41,6 +42,8 @@ const (
 
 	seriesKeysReq  = "seriesKeysReq"
 	seriesKeysResp = "seriesKeysResp"
+
+	remoteReqRejected = "remoteReqRejected"
 )
 
 // Service processes data received over raw TCP connections.
@@ -54,10 +57,38 @@ type Service struct {
 
 	MetaClient interface {
 		ShardOwner(shardID uint64) (string, string, *meta.ShardGroupInfo)
+		DataNode(id uint64) (*meta.NodeInfo, error)
+		AddShardOwner(id, nodeID uint64) error
 	}
 
 	TSDBStore TSDBStore
 
+	// ShardMapper maps the shards of the cluster for the requests of
+	// federated clusters.
+	ShardMapper query.ShardMapper
+	Node        *freetsdb.Node
+
+	// Version is the version of this node, sent to the other nodes with
+	// the features it supports.
+	Version string
+
+	// TaskManager lists and kills the queries of this node for the other
+	// nodes.
+	TaskManager interface {
+		Queries() []query.QueryInfo
+		KillQuery(qid uint64) error
+	}
+
+	// Index reports the index epochs of the databases of this node for the
+	// other nodes.
+	Index interface {
+		IndexEpoch(database string) (uint64, time.Time)
+	}
+
+	// limiter limits the iterator and field dimensions requests served at
+	// once, apart from the queries of this node.
+	limiter *requestLimiter
+
 	Logger  *zap.Logger
 	statMap *expvar.Map
 }
@@ -69,9 +100,25 @@ func NewService(c Config) *Service {
 		//Logger:  log.New(os.Stderr, "[cluster] ", log.LstdFlags),
 		Logger:  zap.NewNop(),
 		statMap: freetsdb.NewStatistics("cluster", "cluster", nil),
+		limiter: newRequestLimiter(c.MaxConcurrentRemoteRequests, time.Duration(c.RemoteRequestQueueTimeout)),
 	}
 }
 
+// SetRemoteRequestLimits changes the limits of the requests of other nodes
+// while they are served.
+func (s *Service) SetRemoteRequestLimits(maxConcurrentRemoteRequests int, queueTimeout time.Duration) {
+	s.limiter.SetLimits(maxConcurrentRemoteRequests, queueTimeout)
+}
+
+// acquire waits for a slot to serve a request of another node.
+func (s *Service) acquire(interrupt <-chan struct{}) error {
+	if err := s.limiter.acquire(interrupt); err != nil {
+		s.statMap.Add(remoteReqRejected, 1)
+		return err
+	}
+	return nil
+}
+
 // Open opens the network listener and begins serving requests.
 func (s *Service) Open() error {
 
@@ -159,7 +206,7 @@ func (s *Service) handleConn(conn net.Conn) {
 
 		// Delegate message processing by type.
 		switch typ {
-		case writeShardRequestMessage:
+		case writeShardRequestMessage, writeShardBatchRequestMessage:
 			buf, err := ReadLV(conn)
 			if err != nil {
 				s.Logger.Info("unable to read length-value:", zap.Error(err))
@@ -184,6 +231,94 @@ func (s *Service) handleConn(conn net.Conn) {
 				s.Logger.Info("process execute statement error:", zap.Error(err))
 			}
 			s.writeShardResponse(conn, err)
+		case showQueriesRequestMessage:
+			if _, err := ReadLV(conn); err != nil {
+				s.Logger.Info("unable to read length-value:", zap.Error(err))
+				return
+			}
+
+			var resp ShowQueriesResponse
+			if s.TaskManager == nil {
+				resp.Err = errors.New("queries are not managed on this node")
+			} else {
+				resp.Queries = s.TaskManager.Queries()
+			}
+			if err := EncodeTLV(conn, showQueriesResponseMessage, &resp); err != nil {
+				s.Logger.Info("error writing ShowQueries response", zap.Error(err))
+				return
+			}
+		case handshakeRequestMessage:
+			if _, err := ReadLV(conn); err != nil {
+				s.Logger.Info("unable to read length-value:", zap.Error(err))
+				return
+			}
+
+			if err := EncodeTLV(conn, handshakeResponseMessage, &HandshakeResponse{
+				Version:  s.Version,
+				Protocol: ProtocolVersion,
+				Features: Features,
+			}); err != nil {
+				s.Logger.Info("error writing handshake response", zap.Error(err))
+				return
+			}
+		case indexEpochRequestMessage:
+			buf, err := ReadLV(conn)
+			if err != nil {
+				s.Logger.Info("unable to read length-value:", zap.Error(err))
+				return
+			}
+
+			var resp IndexEpochResponse
+			var req IndexEpochRequest
+			if err := req.UnmarshalBinary(buf); err != nil {
+				resp.Err = err
+			} else if s.Index == nil {
+				resp.Err = errors.New("index epochs are not available on this node")
+			} else {
+				resp.Epoch, resp.Modified = s.Index.IndexEpoch(req.Database)
+			}
+			if err := EncodeTLV(conn, indexEpochResponseMessage, &resp); err != nil {
+				s.Logger.Info("error writing IndexEpoch response", zap.Error(err))
+				return
+			}
+		case measurementStatsRequestMessage:
+			buf, err := ReadLV(conn)
+			if err != nil {
+				s.Logger.Info("unable to read length-value:", zap.Error(err))
+				return
+			}
+
+			var resp MeasurementStatsResponse
+			var req MeasurementStatsRequest
+			if err := req.UnmarshalBinary(buf); err != nil {
+				resp.Err = err
+			} else {
+				resp.Shards, resp.Err = s.TSDBStore.MeasurementStats(req.Database)
+			}
+			if err := EncodeTLV(conn, measurementStatsResponseMessage, &resp); err != nil {
+				s.Logger.Info("error writing MeasurementStats response", zap.Error(err))
+				return
+			}
+		case diskUsageRequestMessage:
+			buf, err := ReadLV(conn)
+			if err != nil {
+				s.Logger.Info("unable to read length-value:", zap.Error(err))
+				return
+			}
+
+			var resp DiskUsageResponse
+			var req DiskUsageRequest
+			if err := req.UnmarshalBinary(buf); err != nil {
+				resp.Err = err
+			} else if resp.Shards, resp.Err = s.TSDBStore.DiskUsage(req.Database); resp.Err == nil {
+				for _, t := range req.Since {
+					resp.History = append(resp.History, s.TSDBStore.DiskUsageAt(req.Database, t))
+				}
+			}
+			if err := EncodeTLV(conn, diskUsageResponseMessage, &resp); err != nil {
+				s.Logger.Info("error writing DiskUsage response", zap.Error(err))
+				return
+			}
 		case createIteratorRequestMessage:
 			s.statMap.Add(createIteratorReq, 1)
 			s.processCreateIteratorRequest(conn)
@@ -224,6 +359,17 @@ func (s *Service) executeStatement(stmt influxql.Statement, database string) err
 		return s.TSDBStore.DeleteSeries(database, t.Sources, t.Condition)
 	case *influxql.DropRetentionPolicyStatement:
 		return s.TSDBStore.DeleteRetentionPolicy(database, t.Name)
+	case *influxql.DropShardStatement:
+		return s.TSDBStore.DeleteShard(t.ID)
+	case *influxql.CopyShardStatement:
+		return copyShard(s.MetaClient, s.TSDBStore, t.ID, t.NodeID)
+	case *influxql.ReindexShardStatement:
+		return s.TSDBStore.ReindexShard(t.ID)
+	case *influxql.KillQueryStatement:
+		if s.TaskManager == nil {
+			return errors.New("queries are not managed on this node")
+		}
+		return s.TaskManager.KillQuery(t.QueryID)
 	default:
 		return fmt.Errorf("%q should not be executed across a cluster", stmt.String())
 	}
@@ -299,22 +445,64 @@ func (s *Service) writeShardResponse(w io.Writer, e error) {
 func (s *Service) processCreateIteratorRequest(conn net.Conn) {
 	defer conn.Close()
 
+	// The requesting node closes the connection once its query is
+	// interrupted, so interrupt the iterator too.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	var itr query.Iterator
+	var acquired bool
+	defer func() {
+		if acquired {
+			s.limiter.release()
+		}
+	}()
 	if err := func() error {
 		// Parse request.
 		var req CreateIteratorRequest
 		if err := DecodeLV(conn, &req); err != nil {
 			return err
 		}
+		go func() {
+			// Nothing more is sent after the request, so the read only
+			// returns once the connection is closed.
+			conn.Read(make([]byte, 1))
+			cancel()
+		}()
+		req.Opt.InterruptCh = ctx.Done()
+
+		// The slot is held until the iterator is streamed.
+		if err := s.acquire(ctx.Done()); err != nil {
+			return err
+		}
+		acquired = true
+
+		if req.Federated {
+			sg, err := s.mapFederatedShards(&req.Measurement, req.Opt.StartTime, req.Opt.EndTime)
+			if err != nil {
+				return err
+			}
+			defer sg.Close()
+
+			ic, err := sg.CreateIterator(ctx, &req.Measurement, req.Opt)
+			if err != nil {
+				return err
+			}
+			itr = ic
+			return nil
+		}
+
 		sg := s.TSDBStore.ShardGroup(req.ShardIDs)
-		ic, err := sg.CreateIterator(context.Background(), &req.Measurement, req.Opt)
+		ic, err := sg.CreateIterator(ctx, &req.Measurement, req.Opt)
 		if err != nil {
 			return err
 		}
 		itr = ic
 		return nil
 	}(); err != nil {
-		itr.Close()
+		if itr != nil {
+			itr.Close()
+		}
 		//s.Logger.Printf("error reading CreateIterator request: %s", err)
 		EncodeTLV(conn, createIteratorResponseMessage, &CreateIteratorResponse{Err: err})
 		return
@@ -368,6 +556,26 @@ func (s *Service) processFieldDimensionsRequest(conn net.Conn) {
 			return err
 		}
 
+		if err := s.acquire(s.closing); err != nil {
+			return err
+		}
+		defer s.limiter.release()
+
+		if req.Federated {
+			sg, err := s.mapFederatedShards(&req.Measurement, req.MinTime, req.MaxTime)
+			if err != nil {
+				return err
+			}
+			defer sg.Close()
+
+			f, d, err := sg.FieldDimensions(&req.Measurement)
+			if err != nil {
+				return err
+			}
+			fields, dimensions = f, d
+			return nil
+		}
+
 		sg := s.TSDBStore.ShardGroup(req.ShardIDs)
 		if sg != nil {
 			var measurements []string
@@ -400,6 +608,23 @@ func (s *Service) processFieldDimensionsRequest(conn net.Conn) {
 	}
 }
 
+// mapFederatedShards maps the shards of the cluster holding m between
+// minTime and maxTime for a foreign cluster.
+func (s *Service) mapFederatedShards(m *influxql.Measurement, minTime, maxTime int64) (query.ShardGroup, error) {
+	if s.ShardMapper == nil {
+		return nil, errors.New("federation is not enabled on this node")
+	}
+
+	var nodeID uint64
+	if s.Node != nil {
+		nodeID = s.Node.ID
+	}
+	return s.ShardMapper.MapShards(influxql.Sources{m}, influxql.TimeRange{
+		Min: time.Unix(0, minTime),
+		Max: time.Unix(0, maxTime),
+	}, query.SelectOptions{NodeID: nodeID})
+}
+
 // ReadTLV reads a type-length-value record from r.
 func ReadTLV(r io.Reader) (byte, []byte, error) {
 	typ, err := ReadType(r)
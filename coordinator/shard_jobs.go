@@ -0,0 +1,268 @@
+package coordinator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/freetsdb/freetsdb/services/copier"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// maxFinishedShardJobs is the number of finished shard jobs kept for SHOW
+// SHARD JOBS.
+const maxFinishedShardJobs = 100
+
+// ShardJob is a shard operation started by COPY SHARD, DROP SHARD or REINDEX
+// SHARD. The statements return as soon as the job is started.
+type ShardJob struct {
+	ID      uint64
+	Op      string
+	ShardID uint64
+
+	// NodeID is the node the shard is copied to.
+	NodeID uint64
+
+	Started  time.Time
+	Finished time.Time
+	Err      error
+}
+
+// Status returns "running", "done" or "failed".
+func (j *ShardJob) Status() string {
+	switch {
+	case j.Finished.IsZero():
+		return "running"
+	case j.Err != nil:
+		return "failed"
+	default:
+		return "done"
+	}
+}
+
+// errShardJobInterrupted is the error of the jobs that were running when the
+// node stopped. They are not resumed, and must be started again.
+var errShardJobInterrupted = errors.New("interrupted by a restart of the node")
+
+// shardJobs tracks the shard jobs started on this node. Once opened with a
+// path, the jobs are saved to it so that they are kept across restarts.
+type shardJobs struct {
+	once    sync.Once
+	openErr error
+
+	mu     sync.Mutex
+	path   string
+	nextID uint64
+	jobs   []*ShardJob
+}
+
+// shardJobRecord is a ShardJob as saved to disk.
+type shardJobRecord struct {
+	ID       uint64    `json:"id"`
+	Op       string    `json:"op"`
+	ShardID  uint64    `json:"shard"`
+	NodeID   uint64    `json:"node,omitempty"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Err      string    `json:"error,omitempty"`
+}
+
+// open loads the jobs saved to path, the first time it is called. The jobs
+// that were still running are marked as interrupted. Jobs are kept in memory
+// only if path is empty.
+func (s *shardJobs) open(path string) error {
+	s.once.Do(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.path = path
+		s.openErr = s.load()
+	})
+	return s.openErr
+}
+
+// load reads the jobs from s.path. s.mu must be held.
+func (s *shardJobs) load() error {
+	if s.path == "" {
+		return nil
+	}
+
+	buf, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var records []shardJobRecord
+	if err := json.Unmarshal(buf, &records); err != nil {
+		return fmt.Errorf("read shard jobs: %s", err)
+	}
+
+	var interrupted bool
+	for _, r := range records {
+		j := &ShardJob{ID: r.ID, Op: r.Op, ShardID: r.ShardID, NodeID: r.NodeID, Started: r.Started, Finished: r.Finished}
+		if r.Err != "" {
+			j.Err = errors.New(r.Err)
+		}
+		if j.Finished.IsZero() {
+			j.Err = errShardJobInterrupted
+			j.Finished = time.Now().UTC()
+			interrupted = true
+		}
+		if j.ID > s.nextID {
+			s.nextID = j.ID
+		}
+		s.jobs = append(s.jobs, j)
+	}
+
+	if interrupted {
+		return s.save()
+	}
+	return nil
+}
+
+// save writes the jobs to s.path, if set. s.mu must be held.
+func (s *shardJobs) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	records := make([]shardJobRecord, len(s.jobs))
+	for i, j := range s.jobs {
+		records[i] = shardJobRecord{ID: j.ID, Op: j.Op, ShardID: j.ShardID, NodeID: j.NodeID, Started: j.Started, Finished: j.Finished}
+		if j.Err != nil {
+			records[i].Err = j.Err.Error()
+		}
+	}
+	buf, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := s.path + "tmp"
+	if err := ioutil.WriteFile(tmpPath, buf, 0666); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+// start runs fn in the background as a new job and returns its ID. Only one
+// job can run on a shard at a time.
+func (s *shardJobs) start(op string, shardID, nodeID uint64, fn func() error) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, j := range s.jobs {
+		if j.ShardID == shardID && j.Finished.IsZero() {
+			return 0, fmt.Errorf("shard %d is busy with job %d", shardID, j.ID)
+		}
+	}
+
+	s.nextID++
+	j := &ShardJob{ID: s.nextID, Op: op, ShardID: shardID, NodeID: nodeID, Started: time.Now().UTC()}
+	s.jobs = append(s.jobs, j)
+	s.prune()
+	if err := s.save(); err != nil {
+		s.jobs = s.jobs[:len(s.jobs)-1]
+		return 0, fmt.Errorf("save shard jobs: %s", err)
+	}
+
+	go func() {
+		err := fn()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		j.Err = err
+		j.Finished = time.Now().UTC()
+
+		// The job is done whether or not it is saved. If it is not, it is
+		// reported as interrupted after a restart.
+		s.save()
+	}()
+	return j.ID, nil
+}
+
+// prune removes the oldest finished jobs beyond maxFinishedShardJobs.
+// s.mu must be held.
+func (s *shardJobs) prune() {
+	var finished int
+	for _, j := range s.jobs {
+		if !j.Finished.IsZero() {
+			finished++
+		}
+	}
+
+	jobs := s.jobs[:0]
+	for _, j := range s.jobs {
+		if !j.Finished.IsZero() && finished > maxFinishedShardJobs {
+			finished--
+			continue
+		}
+		jobs = append(jobs, j)
+	}
+	s.jobs = jobs
+}
+
+// list returns copies of the jobs in the order they were started.
+func (s *shardJobs) list() []ShardJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]ShardJob, len(s.jobs))
+	for i, j := range s.jobs {
+		jobs[i] = *j
+	}
+	return jobs
+}
+
+// copyShard copies the shard with id from another of its owners into store,
+// then makes nodeID, the node of store, an owner of the shard so that it
+// receives new writes. Points written to the shard during the copy are not
+// copied, so hot shards should be ended with TRUNCATE SHARDS NOW first.
+func copyShard(metaClient interface {
+	ShardOwner(shardID uint64) (string, string, *meta.ShardGroupInfo)
+	DataNode(id uint64) (*meta.NodeInfo, error)
+	AddShardOwner(id, nodeID uint64) error
+}, store TSDBStore, id, nodeID uint64) error {
+	database, policy, sgi := metaClient.ShardOwner(id)
+	if sgi == nil {
+		return fmt.Errorf("shard %d not found", id)
+	}
+
+	var source *meta.NodeInfo
+	for _, si := range sgi.Shards {
+		if si.ID != id {
+			continue
+		}
+		for _, owner := range si.Owners {
+			if owner.NodeID == nodeID {
+				continue
+			}
+			if ni, err := metaClient.DataNode(owner.NodeID); err == nil && ni != nil {
+				source = ni
+				break
+			}
+		}
+	}
+	if source == nil {
+		return fmt.Errorf("shard %d has no other owner to copy from", id)
+	}
+
+	r, err := copier.NewClient(source.TCPHost).ShardReader(id)
+	if err != nil {
+		return fmt.Errorf("copy shard %d from node %d: %s", id, source.ID, err)
+	}
+	defer r.Close()
+
+	if err := store.CreateShard(database, policy, id, true); err != nil {
+		return err
+	}
+	if err := store.RestoreShard(id, r); err != nil {
+		return fmt.Errorf("copy shard %d from node %d: %s", id, source.ID, err)
+	}
+	return metaClient.AddShardOwner(id, nodeID)
+}
@@ -0,0 +1,113 @@
+package coordinator
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestShardJobs(t *testing.T) {
+	var jobs shardJobs
+
+	release := make(chan struct{})
+	id, err := jobs.start("copy", 1, 2, func() error {
+		<-release
+		return errors.New("marker")
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if id != 1 {
+		t.Fatalf("unexpected job id: %d", id)
+	}
+
+	// Only one job can run on a shard at a time.
+	if _, err := jobs.start("drop", 1, 0, func() error { return nil }); err == nil {
+		t.Fatal("expected error for busy shard")
+	}
+
+	if a := jobs.list(); len(a) != 1 || a[0].Status() != "running" {
+		t.Fatalf("unexpected jobs: %+v", a)
+	}
+	close(release)
+
+	for {
+		a := jobs.list()
+		if a[0].Status() == "running" {
+			time.Sleep(time.Millisecond)
+			continue
+		} else if a[0].Status() != "failed" || a[0].Err.Error() != "marker" {
+			t.Fatalf("unexpected job: %+v", a[0])
+		}
+		break
+	}
+
+	// Finished jobs beyond the limit are pruned.
+	for i := 0; i < maxFinishedShardJobs+1; i++ {
+		for {
+			if _, err := jobs.start("drop", 2, 0, func() error { return nil }); err == nil {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if n := len(jobs.list()); n > maxFinishedShardJobs+1 {
+		t.Fatalf("unexpected number of jobs: %d", n)
+	}
+}
+
+// Ensure shard jobs are kept across restarts, and the jobs that were running
+// are reported as interrupted.
+func TestShardJobs_Open(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shard-jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "shard_jobs.json")
+
+	var jobs shardJobs
+	if err := jobs.open(path); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	if _, err := jobs.start("drop", 1, 0, func() error { defer close(done); return nil }); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	release := make(chan struct{})
+	defer close(release)
+	if _, err := jobs.start("copy", 2, 3, func() error { <-release; return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for the first job to be saved as done.
+	for {
+		if a := jobs.list(); a[0].Status() == "done" {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	var other shardJobs
+	if err := other.open(path); err != nil {
+		t.Fatal(err)
+	}
+	a := other.list()
+	if len(a) != 2 {
+		t.Fatalf("unexpected jobs: %+v", a)
+	} else if a[0].ID != 1 || a[0].Op != "drop" || a[0].Status() != "done" {
+		t.Fatalf("unexpected job: %+v", a[0])
+	} else if a[1].ID != 2 || a[1].Op != "copy" || a[1].NodeID != 3 || a[1].Status() != "failed" || a[1].Err != errShardJobInterrupted {
+		t.Fatalf("unexpected job: %+v", a[1])
+	}
+
+	// New jobs do not reuse the IDs of the saved ones.
+	if id, err := other.start("reindex", 2, 0, func() error { return nil }); err != nil {
+		t.Fatal(err)
+	} else if id != 3 {
+		t.Fatalf("unexpected job id: %d", id)
+	}
+}
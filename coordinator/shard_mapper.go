@@ -10,6 +10,7 @@ import (
 
 	"github.com/freetsdb/freetsdb/query"
 	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
 	"github.com/freetsdb/freetsdb/tsdb"
 )
 
@@ -32,13 +33,88 @@ type LocalShardMapper struct {
 		Shards(ids []uint64) []*tsdb.Shard
 		CreateShard(database, retentionPolicy string, shardID uint64, enabled bool) error
 	}
+
+	// ForeignEndpoints are the data nodes of other clusters whose shards are
+	// mapped along with the local ones.
+	ForeignEndpoints []ForeignEndpoint
+
+	// ReadHedger hedges the reads of remote shards. Reads are not hedged
+	// when it is nil.
+	ReadHedger *ReadHedger
+
+	// NodeHealth reports the data nodes known to be dead, which shards are
+	// read from like from the nodes in maintenance.
+	NodeHealth interface {
+		Dead(nodeID uint64) bool
+	}
+}
+
+// maintenanceNodes returns the IDs of the data nodes in maintenance or dead.
+func (e *LocalShardMapper) maintenanceNodes() (map[uint64]bool, error) {
+	nodes, err := e.MetaClient.DataNodes()
+	if err != nil {
+		return nil, err
+	}
+	var maintenance map[uint64]bool
+	for _, n := range nodes {
+		if n.Maintenance || (e.NodeHealth != nil && e.NodeHealth.Dead(n.ID)) {
+			if maintenance == nil {
+				maintenance = make(map[uint64]bool)
+			}
+			maintenance[n.ID] = true
+		}
+	}
+	return maintenance, nil
+}
+
+// shardReadNode returns the owner of a shard that it is read from: the local
+// node if it owns the shard, or else a random owner. Owners in maintenance
+// are only read from when all owners are. It returns false if the shard has
+// no owners.
+func shardReadNode(si meta.ShardInfo, localID uint64, maintenance map[uint64]bool) (uint64, bool) {
+	owners := si.Owners
+	if len(maintenance) > 0 {
+		available := make([]meta.ShardOwner, 0, len(owners))
+		for _, o := range owners {
+			if !maintenance[o.NodeID] {
+				available = append(available, o)
+			}
+		}
+		if len(available) > 0 {
+			owners = available
+		}
+	}
+
+	if len(owners) == 0 {
+		return 0, false
+	}
+	for _, o := range owners {
+		if o.NodeID == localID {
+			return localID, true
+		}
+	}
+	return owners[rand.Intn(len(owners))].NodeID, true
+}
+
+// shardHedgeNodes returns the owners of a shard, other than nodeID, that a
+// read of the shard from nodeID can be hedged to. Owners in maintenance are
+// left out.
+func shardHedgeNodes(si meta.ShardInfo, nodeID uint64, maintenance map[uint64]bool) []uint64 {
+	var a []uint64
+	for _, o := range si.Owners {
+		if o.NodeID != nodeID && !maintenance[o.NodeID] {
+			a = append(a, o.NodeID)
+		}
+	}
+	return a
 }
 
 // MapShards maps the sources to the appropriate shards into an IteratorCreator.
 func (e *LocalShardMapper) MapShards(sources influxql.Sources, t influxql.TimeRange, opt query.SelectOptions) (query.ShardGroup, error) {
 	a := &LocalShardMapping{
-		ShardMap:  make(map[Source]tsdb.ShardGroup),
-		RemoteICs: make(map[Source][]remoteIteratorCreator),
+		ShardMap:   make(map[Source]tsdb.ShardGroup),
+		RemoteICs:  make(map[Source][]remoteIteratorCreator),
+		ForeignICs: make(map[Source][]*foreignIteratorCreator),
 	}
 
 	tmin := time.Unix(0, t.MinTimeNano())
@@ -60,6 +136,14 @@ func (e *LocalShardMapper) mapShards(a *LocalShardMapping, sources influxql.Sour
 				Database:        s.Database,
 				RetentionPolicy: s.RetentionPolicy,
 			}
+			if _, ok := a.ShardMap[source]; !ok {
+				for _, fe := range e.ForeignEndpoints {
+					if fe.Federates(s.Database) {
+						a.ForeignICs[source] = append(a.ForeignICs[source], newForeignIteratorCreator(fe, tmin, tmax))
+					}
+				}
+			}
+
 			// Retrieve the list of shards for this database. This list of
 			// shards is always the same regardless of which measurement we are
 			// using.
@@ -75,15 +159,16 @@ func (e *LocalShardMapper) mapShards(a *LocalShardMapping, sources influxql.Sour
 				}
 				a.RemoteICs[source] = make([]remoteIteratorCreator, 0, len(groups[0].Shards)*len(groups))
 
+				maintenance, err := e.maintenanceNodes()
+				if err != nil {
+					return err
+				}
+
 				shardIDs := make([]uint64, 0, len(groups[0].Shards)*len(groups))
 				for _, g := range groups {
 					for _, si := range g.Shards {
-						var nodeID uint64
-						if si.OwnedBy(a.LocalNodeID) {
-							nodeID = a.LocalNodeID
-						} else if len(si.Owners) > 0 {
-							nodeID = si.Owners[rand.Intn(len(si.Owners))].NodeID
-						} else {
+						nodeID, ok := shardReadNode(si, a.LocalNodeID, maintenance)
+						if !ok {
 							// This should not occur but if the shard has no owners then
 							// we don't want this to panic by trying to randomly select a node.
 							continue
@@ -98,6 +183,8 @@ func (e *LocalShardMapper) mapShards(a *LocalShardMapping, sources influxql.Sour
 							}
 							remoteShardIDs := []uint64{si.ID}
 							remoteIC := newRemoteIteratorCreator(dialer, nodeID, remoteShardIDs)
+							remoteIC.hedger = e.ReadHedger
+							remoteIC.alternates = shardHedgeNodes(si, nodeID, maintenance)
 							a.RemoteICs[source] = append(a.RemoteICs[source], remoteIC)
 
 						}
@@ -128,6 +215,10 @@ type LocalShardMapping struct {
 
 	RemoteICs map[Source][]remoteIteratorCreator
 
+	// ForeignICs create the iterators of the foreign clusters federated
+	// with this one.
+	ForeignICs map[Source][]*foreignIteratorCreator
+
 	// MinTime is the minimum time that this shard mapper will allow.
 	// Any attempt to use a time before this one will automatically result in using
 	// this time instead.
@@ -149,21 +240,22 @@ func (a *LocalShardMapping) FieldDimensions(m *influxql.Measurement) (fields map
 
 	sg := a.ShardMap[source]
 	RemoteICs := a.RemoteICs[source]
-	if sg == nil && RemoteICs == nil {
+	ForeignICs := a.ForeignICs[source]
+	if sg == nil && RemoteICs == nil && ForeignICs == nil {
 		return nil, nil, nil
 	}
 
 	fields = make(map[string]influxql.DataType)
 	dimensions = make(map[string]struct{})
 
-	var measurements []string
-	if m.Regex != nil {
-		measurements = sg.MeasurementsByRegex(m.Regex.Val)
-	} else {
-		measurements = []string{m.Name}
-	}
-
 	if sg != nil {
+		var measurements []string
+		if m.Regex != nil {
+			measurements = sg.MeasurementsByRegex(m.Regex.Val)
+		} else {
+			measurements = []string{m.Name}
+		}
+
 		f, d, err := sg.FieldDimensions(measurements)
 		if err != nil {
 			return nil, nil, err
@@ -191,6 +283,21 @@ func (a *LocalShardMapping) FieldDimensions(m *influxql.Measurement) (fields map
 		}
 	}
 
+	// A foreign cluster that cannot be reached fails the query rather than
+	// leaving its data out of the results.
+	for _, foreignIC := range ForeignICs {
+		f, d, err := foreignIC.FieldDimensions(m)
+		if err != nil {
+			return nil, nil, err
+		}
+		for k, typ := range f {
+			fields[k] = typ
+		}
+		for k := range d {
+			dimensions[k] = struct{}{}
+		}
+	}
+
 	return
 }
 
@@ -200,9 +307,16 @@ func (a *LocalShardMapping) MapType(m *influxql.Measurement, field string) influ
 		RetentionPolicy: m.RetentionPolicy,
 	}
 
+	var typ influxql.DataType
+	for _, foreignIC := range a.ForeignICs[source] {
+		if t := foreignIC.MapType(m, field); typ.LessThan(t) {
+			typ = t
+		}
+	}
+
 	sg := a.ShardMap[source]
 	if sg == nil {
-		return influxql.Unknown
+		return typ
 	}
 
 	var names []string
@@ -212,7 +326,6 @@ func (a *LocalShardMapping) MapType(m *influxql.Measurement, field string) influ
 		names = []string{m.Name}
 	}
 
-	var typ influxql.DataType
 	for _, name := range names {
 		if m.SystemIterator != "" {
 			name = m.SystemIterator
@@ -244,7 +357,8 @@ func (a *LocalShardMapping) CreateIterator(ctx context.Context, m *influxql.Meas
 
 	sg := a.ShardMap[source]
 	RemoteICs := a.RemoteICs[source]
-	if sg == nil && RemoteICs == nil {
+	ForeignICs := a.ForeignICs[source]
+	if sg == nil && RemoteICs == nil && ForeignICs == nil {
 		return nil, nil
 	}
 
@@ -257,7 +371,21 @@ func (a *LocalShardMapping) CreateIterator(ctx context.Context, m *influxql.Meas
 	}
 
 	inputs := []query.Iterator{}
-	if m.Regex != nil {
+
+	// Foreign clusters expand the regex of m against their own measurements.
+	for _, foreignIC := range ForeignICs {
+		input, err := foreignIC.CreateIterator(ctx, m, opt)
+		if err != nil {
+			query.Iterators(inputs).Close()
+			return nil, err
+		} else if input != nil {
+			inputs = append(inputs, input)
+		}
+	}
+
+	if sg == nil {
+		// Only the foreign clusters hold shards for the source.
+	} else if m.Regex != nil {
 		measurements := sg.MeasurementsByRegex(m.Regex.Val)
 		if err := func() error {
 			// Create a Measurement for each returned matching measurement value
@@ -310,6 +438,11 @@ type remoteIteratorCreator struct {
 	dialer   *NodeDialer
 	nodeID   uint64
 	shardIDs []uint64
+
+	// hedger hedges the reads of nodeID to the alternates, the other
+	// owners of the shards.
+	hedger     *ReadHedger
+	alternates []uint64
 }
 
 // newRemoteIteratorCreator returns a new instance of remoteIteratorCreator for a remote shard.
@@ -321,14 +454,42 @@ func newRemoteIteratorCreator(dialer *NodeDialer, nodeID uint64, shardIDs []uint
 	}
 }
 
+// remoteIterator is a connection to a node streaming the points of an
+// iterator.
+type remoteIterator struct {
+	conn net.Conn
+	resp CreateIteratorResponse
+}
+
 // CreateIterator creates a remote streaming iterator.
 func (ic *remoteIteratorCreator) CreateIterator(ctx context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
-	conn, err := ic.dialer.DialNode(ic.nodeID)
+	v, err := ic.hedger.Read(ic.nodeID, ic.alternates, func(nodeID uint64) (interface{}, error) {
+		return ic.createIterator(nodeID, m, opt)
+	}, func(v interface{}) {
+		v.(*remoteIterator).conn.Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+	ri := v.(*remoteIterator)
+
+	// Reads block until the remote node sends points, so close the connection
+	// to stop the iterator once the query is interrupted.
+	itr := query.NewReaderIterator(ctx, ri.conn, ri.resp.typ, ri.resp.stats)
+	if ctx.Done() != nil {
+		itr = query.NewCloseInterruptIterator(itr, ctx.Done())
+	}
+	return itr, nil
+}
+
+// createIterator requests an iterator of the shards from nodeID.
+func (ic *remoteIteratorCreator) createIterator(nodeID uint64, m *influxql.Measurement, opt query.IteratorOptions) (*remoteIterator, error) {
+	conn, err := ic.dialer.DialNode(nodeID)
 	if err != nil {
 		return nil, err
 	}
 
-	var resp CreateIteratorResponse
+	ri := &remoteIterator{conn: conn}
 	if err := func() error {
 		// Write request.
 		var req = CreateIteratorRequest{
@@ -341,10 +502,10 @@ func (ic *remoteIteratorCreator) CreateIterator(ctx context.Context, m *influxql
 		}
 
 		// Read the response.
-		if _, err := DecodeTLV(conn, &resp); err != nil {
-			return err
-		} else if resp.Err != nil {
+		if _, err := DecodeTLV(conn, &ri.resp); err != nil {
 			return err
+		} else if ri.resp.Err != nil {
+			return ri.resp.Err
 		}
 
 		return nil
@@ -352,16 +513,27 @@ func (ic *remoteIteratorCreator) CreateIterator(ctx context.Context, m *influxql
 		conn.Close()
 		return nil, err
 	}
-
-	return query.NewReaderIterator(ctx, conn, resp.typ, resp.stats), nil
+	return ri, nil
 }
 
 // FieldDimensions returns the unique fields and dimensions across a list of sources.
 func (ic *remoteIteratorCreator) FieldDimensions(m *influxql.Measurement) (fields map[string]influxql.DataType, dimensions map[string]struct{}, err error) {
-	conn, err := ic.dialer.DialNode(ic.nodeID)
+	v, err := ic.hedger.Read(ic.nodeID, ic.alternates, func(nodeID uint64) (interface{}, error) {
+		return ic.fieldDimensions(nodeID, m)
+	}, func(interface{}) {})
 	if err != nil {
 		return nil, nil, err
 	}
+	resp := v.(*FieldDimensionsResponse)
+	return resp.Fields, resp.Dimensions, resp.Err
+}
+
+// fieldDimensions requests the fields and dimensions of the shards from nodeID.
+func (ic *remoteIteratorCreator) fieldDimensions(nodeID uint64, m *influxql.Measurement) (*FieldDimensionsResponse, error) {
+	conn, err := ic.dialer.DialNode(nodeID)
+	if err != nil {
+		return nil, err
+	}
 	defer conn.Close()
 
 	// Write request.
@@ -369,16 +541,15 @@ func (ic *remoteIteratorCreator) FieldDimensions(m *influxql.Measurement) (field
 		ShardIDs:    ic.shardIDs,
 		Measurement: *m,
 	}); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	// Read the response.
 	var resp FieldDimensionsResponse
 	if _, err := DecodeTLV(conn, &resp); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-
-	return resp.Fields, resp.Dimensions, resp.Err
+	return &resp, nil
 }
 
 // NodeDialer dials connections to a given node.
@@ -0,0 +1,120 @@
+package coordinator
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/toml"
+)
+
+func TestShardReadNode(t *testing.T) {
+	si := meta.ShardInfo{ID: 1, Owners: []meta.ShardOwner{{NodeID: 1}, {NodeID: 2}}}
+
+	for _, tt := range []struct {
+		name        string
+		localID     uint64
+		maintenance map[uint64]bool
+		exp         uint64
+	}{
+		{name: "local owner", localID: 1, exp: 1},
+		{name: "remote owner", localID: 3, maintenance: map[uint64]bool{1: true}, exp: 2},
+		{name: "local owner in maintenance", localID: 1, maintenance: map[uint64]bool{1: true}, exp: 2},
+		{name: "all owners in maintenance", localID: 1, maintenance: map[uint64]bool{1: true, 2: true}, exp: 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if nodeID, ok := shardReadNode(si, tt.localID, tt.maintenance); !ok || nodeID != tt.exp {
+				t.Fatalf("unexpected node: got %d, exp %d", nodeID, tt.exp)
+			}
+		})
+	}
+
+	if _, ok := shardReadNode(meta.ShardInfo{ID: 2}, 1, nil); ok {
+		t.Fatal("expected no node for a shard without owners")
+	}
+}
+
+func TestShardHedgeNodes(t *testing.T) {
+	si := meta.ShardInfo{ID: 1, Owners: []meta.ShardOwner{{NodeID: 1}, {NodeID: 2}, {NodeID: 3}}}
+	if got, exp := shardHedgeNodes(si, 2, map[uint64]bool{3: true}), []uint64{1}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected nodes: got %v, exp %v", got, exp)
+	}
+	if got := shardHedgeNodes(si, 1, map[uint64]bool{2: true, 3: true}); got != nil {
+		t.Fatalf("unexpected nodes: %v", got)
+	}
+}
+
+// Ensure a slow read is hedged to another owner, and the first response wins.
+func TestReadHedger_Read(t *testing.T) {
+	h := NewReadHedger(10 * time.Millisecond)
+
+	release := make(chan struct{})
+	discarded := make(chan interface{}, 1)
+	v, err := h.Read(1, []uint64{2}, func(nodeID uint64) (interface{}, error) {
+		if nodeID == 1 {
+			<-release
+		}
+		return nodeID, nil
+	}, func(v interface{}) { discarded <- v })
+	if err != nil {
+		t.Fatal(err)
+	} else if v != uint64(2) {
+		t.Fatalf("unexpected node: %v", v)
+	}
+
+	close(release)
+	if v := <-discarded; v != uint64(1) {
+		t.Fatalf("unexpected discarded node: %v", v)
+	}
+
+	// A fast read is not hedged, and a failed read only when it is slow.
+	if v, err := h.Read(1, []uint64{2}, func(nodeID uint64) (interface{}, error) { return nodeID, nil }, nil); err != nil || v != uint64(1) {
+		t.Fatalf("unexpected read: %v, %v", v, err)
+	}
+	if _, err := h.Read(1, []uint64{2}, func(nodeID uint64) (interface{}, error) {
+		if nodeID == 2 {
+			t.Fatal("unexpected hedged read")
+		}
+		return nil, errors.New("marker")
+	}, nil); err == nil || err.Error() != "marker" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, exp := *h.stats, (HedgeStatistics{RemoteReads: 3, HedgedReads: 1, HedgeWins: 1}); got != exp {
+		t.Fatalf("unexpected statistics: %+v", got)
+	}
+}
+
+// Ensure a foreign cluster that cannot be reached fails the query instead of
+// leaving its data out.
+func TestLocalShardMapping_ForeignUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	source := Source{Database: "db0", RetentionPolicy: "rp0"}
+	fe := ForeignEndpoint{Name: "east", Address: addr, Timeout: toml.Duration(time.Second)}
+	a := &LocalShardMapping{
+		ForeignICs: map[Source][]*foreignIteratorCreator{
+			source: {newForeignIteratorCreator(fe, time.Unix(0, 0), time.Unix(0, 1))},
+		},
+	}
+	m := &influxql.Measurement{Database: "db0", RetentionPolicy: "rp0", Name: "cpu"}
+
+	if _, _, err := a.FieldDimensions(m); err == nil || !strings.Contains(err.Error(), "foreign cluster east") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := a.CreateIterator(context.Background(), m, query.IteratorOptions{}); err == nil || !strings.Contains(err.Error(), "foreign cluster east") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -16,6 +16,7 @@ import (
 
 func TestLocalShardMapper(t *testing.T) {
 	var metaClient MetaClient
+	metaClient.DataNodesFn = func() ([]meta.NodeInfo, error) { return nil, nil }
 	metaClient.ShardGroupsByTimeRangeFn = func(database, policy string, min, max time.Time) ([]meta.ShardGroupInfo, error) {
 		if database != "db0" {
 			t.Errorf("unexpected database: %s", database)
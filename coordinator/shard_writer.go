@@ -21,6 +21,33 @@ const (
 
 	fieldDimensionsRequestMessage
 	fieldDimensionsResponseMessage
+
+	// writeShardBatchRequestMessage is a write shard request with its points
+	// encoded as a batch. Older nodes fail it rather than drop the points.
+	writeShardBatchRequestMessage
+
+	showQueriesRequestMessage
+	showQueriesResponseMessage
+
+	// handshakeRequestMessage asks a node for its version and features.
+	// Older nodes ignore it, and the request times out.
+	handshakeRequestMessage
+	handshakeResponseMessage
+
+	// indexEpochRequestMessage asks a node for the index epoch of a
+	// database, which validates the cached results of metadata queries.
+	indexEpochRequestMessage
+	indexEpochResponseMessage
+
+	// measurementStatsRequestMessage asks a node for the measurement
+	// statistics of the shards of a database.
+	measurementStatsRequestMessage
+	measurementStatsResponseMessage
+
+	// diskUsageRequestMessage asks a node for the disk usage of its shards
+	// and its history.
+	diskUsageRequestMessage
+	diskUsageResponseMessage
 )
 
 // ShardWriter writes a set of points to a shard.
@@ -29,6 +56,9 @@ type ShardWriter struct {
 	timeout        time.Duration
 	maxConnections int
 
+	// Features negotiates the encoding of the points with each node.
+	Features *FeatureNegotiator
+
 	MetaClient interface {
 		DataNode(id uint64) (ni *meta.NodeInfo, err error)
 		ShardOwner(shardID uint64) (database, policy string, sgi *meta.ShardGroupInfo)
@@ -46,6 +76,9 @@ func NewShardWriter(timeout time.Duration, maxConnections int) *ShardWriter {
 
 // WriteShard writes time series points to a shard
 func (w *ShardWriter) WriteShard(shardID, ownerID uint64, points []models.Point) error {
+	// Negotiate the encoding before taking a connection from the pool.
+	batches := w.Features.Supports(ownerID, FeaturePointBatches)
+
 	c, err := w.dial(ownerID)
 	if err != nil {
 		return err
@@ -75,16 +108,22 @@ func (w *ShardWriter) WriteShard(shardID, ownerID uint64, points []models.Point)
 	request.SetRetentionPolicy(rp)
 	request.AddPoints(points)
 
-	// Marshal into protocol buffers.
-	buf, err := request.MarshalBinary()
+	// Marshal into protocol buffers. Nodes without point batches are sent
+	// each point on its own.
+	typ, marshal := writeShardBatchRequestMessage, request.MarshalBinary
+	if !batches {
+		typ, marshal = writeShardRequestMessage, request.MarshalLegacyBinary
+	}
+	buf, err := marshal()
 	if err != nil {
 		return err
 	}
 
 	// Write request.
 	conn.SetWriteDeadline(time.Now().Add(w.timeout))
-	if err := WriteTLV(conn, writeShardRequestMessage, buf); err != nil {
+	if err := WriteTLV(conn, typ, buf); err != nil {
 		conn.MarkUnusable()
+		w.Features.Forget(ownerID)
 		return err
 	}
 
@@ -93,6 +132,7 @@ func (w *ShardWriter) WriteShard(shardID, ownerID uint64, points []models.Point)
 	_, buf, err = ReadTLV(conn)
 	if err != nil {
 		conn.MarkUnusable()
+		w.Features.Forget(ownerID)
 		return err
 	}
 
@@ -6,9 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/freetsdb/freetsdb"
@@ -36,12 +38,25 @@ type StatementExecutor struct {
 
 	MetaClient MetaClient
 
-	// TaskManager holds the StatementExecutor that handles task-related commands.
-	TaskManager query.StatementExecutor
+	// TaskManager holds the StatementExecutor that handles task-related
+	// commands, and lists and kills the queries of this node.
+	TaskManager interface {
+		query.StatementExecutor
+		Queries() []query.QueryInfo
+		KillQuery(qid uint64) error
+	}
 
 	// TSDB storage for local node.
 	TSDBStore TSDBStore
 
+	// MetaExecutor executes statements on the other data nodes.
+	MetaExecutor interface {
+		ExecuteStatementOnNode(stmt influxql.Statement, database string, nodeID uint64) error
+		QueriesOnNode(nodeID uint64) ([]query.QueryInfo, error)
+		MeasurementStatsOnNode(nodeID uint64, database string) ([]tsdb.ShardMeasurementStats, error)
+		DiskUsageOnNode(nodeID uint64, database string, since []time.Time) ([]tsdb.ShardDiskUsage, []*tsdb.DiskUsageSample, error)
+	}
+
 	// ShardMapper for mapping shards when executing a SELECT statement.
 	ShardMapper query.ShardMapper
 
@@ -53,10 +68,52 @@ type StatementExecutor struct {
 		WritePointsInto(*IntoWriteRequest) error
 	}
 
+	// MaterializedViews reports how stale the materialized views are. If
+	// set, SELECT statements that a built view contains are answered from
+	// the view.
+	MaterializedViews interface {
+		Staleness(database, name string) (time.Duration, bool)
+	}
+
 	// Select statement limits
 	MaxSelectPointN   int
 	MaxSelectSeriesN  int
 	MaxSelectBucketsN int
+
+	// MaxShowTagValuesN limits the number of values a SHOW TAG VALUES
+	// statement can return.
+	MaxShowTagValuesN int
+
+	// Guards the select statement limits after they are changed by
+	// SetSelectLimits.
+	limitsMu sync.RWMutex
+
+	// ShardJobsPath is the file the jobs started by COPY SHARD, DROP SHARD
+	// and REINDEX SHARD are saved to. They are kept in memory only if empty.
+	ShardJobsPath string
+
+	// Jobs started by COPY SHARD, DROP SHARD and REINDEX SHARD.
+	shardJobs shardJobs
+}
+
+// SetSelectLimits changes the select statement limits for new queries.
+func (e *StatementExecutor) SetSelectLimits(pointN, seriesN, bucketsN int) {
+	e.limitsMu.Lock()
+	defer e.limitsMu.Unlock()
+	e.MaxSelectPointN = pointN
+	e.MaxSelectSeriesN = seriesN
+	e.MaxSelectBucketsN = bucketsN
+}
+
+// selectOptions returns select options with the current select limits.
+func (e *StatementExecutor) selectOptions() query.SelectOptions {
+	e.limitsMu.RLock()
+	defer e.limitsMu.RUnlock()
+	return query.SelectOptions{
+		MaxSeriesN:  e.MaxSelectSeriesN,
+		MaxPointN:   e.MaxSelectPointN,
+		MaxBucketsN: e.MaxSelectBucketsN,
+	}
 }
 
 // ExecuteStatement executes the given statement with the given execution context.
@@ -85,6 +142,16 @@ func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement, ctx *query
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
 		}
 		err = e.executeCreateDatabaseStatement(stmt)
+	case *influxql.CreateMaterializedViewStatement:
+		if ctx.ReadOnly {
+			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
+		}
+		err = e.executeCreateMaterializedViewStatement(stmt)
+	case *influxql.CreateQueryTemplateStatement:
+		if ctx.ReadOnly {
+			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
+		}
+		err = e.executeCreateQueryTemplateStatement(stmt)
 	case *influxql.CreateRetentionPolicyStatement:
 		if ctx.ReadOnly {
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
@@ -100,6 +167,11 @@ func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement, ctx *query
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
 		}
 		err = e.executeCreateSubscriptionStatement(stmt)
+	case *influxql.CreateRoleStatement:
+		if ctx.ReadOnly {
+			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
+		}
+		err = e.executeCreateRoleStatement(stmt)
 	case *influxql.CreateUserStatement:
 		if ctx.ReadOnly {
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
@@ -117,11 +189,21 @@ func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement, ctx *query
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
 		}
 		err = e.executeDropDatabaseStatement(stmt)
+	case *influxql.DropMaterializedViewStatement:
+		if ctx.ReadOnly {
+			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
+		}
+		err = e.executeDropMaterializedViewStatement(stmt)
 	case *influxql.DropMeasurementStatement:
 		if ctx.ReadOnly {
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
 		}
 		err = e.executeDropMeasurementStatement(stmt, ctx.Database)
+	case *influxql.DropQueryTemplateStatement:
+		if ctx.ReadOnly {
+			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
+		}
+		err = e.executeDropQueryTemplateStatement(stmt)
 	case *influxql.DropSeriesStatement:
 		if ctx.ReadOnly {
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
@@ -136,17 +218,31 @@ func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement, ctx *query
 		if ctx.ReadOnly {
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
 		}
-		err = e.executeDropShardStatement(stmt)
+		rows, err = e.executeDropShardStatement(stmt)
+	case *influxql.CopyShardStatement:
+		if ctx.ReadOnly {
+			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
+		}
+		rows, err = e.executeCopyShardStatement(stmt)
+	case *influxql.ReindexShardStatement:
+		rows, err = e.executeReindexShardStatement(stmt)
 	case *influxql.DropSubscriptionStatement:
 		if ctx.ReadOnly {
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
 		}
 		err = e.executeDropSubscriptionStatement(stmt)
+	case *influxql.DropRoleStatement:
+		if ctx.ReadOnly {
+			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
+		}
+		err = e.executeDropRoleStatement(stmt)
 	case *influxql.DropUserStatement:
 		if ctx.ReadOnly {
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
 		}
 		err = e.executeDropUserStatement(stmt)
+	case *influxql.ExecuteQueryTemplateStatement:
+		return e.executeExecuteQueryTemplateStatement(stmt, ctx)
 	case *influxql.ExplainStatement:
 		if stmt.Analyze {
 			rows, err = e.executeExplainAnalyzeStatement(stmt, ctx)
@@ -163,6 +259,21 @@ func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement, ctx *query
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
 		}
 		err = e.executeGrantAdminStatement(stmt)
+	case *influxql.GrantRoleStatement:
+		if ctx.ReadOnly {
+			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
+		}
+		err = e.executeGrantRoleStatement(stmt)
+	case *influxql.GrantRolePrivilegeStatement:
+		if ctx.ReadOnly {
+			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
+		}
+		err = e.executeGrantRolePrivilegeStatement(stmt)
+	case *influxql.KillSessionStatement:
+		if ctx.ReadOnly {
+			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
+		}
+		err = e.executeKillSessionStatement(stmt)
 	case *influxql.RevokeStatement:
 		if ctx.ReadOnly {
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
@@ -173,24 +284,52 @@ func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement, ctx *query
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
 		}
 		err = e.executeRevokeAdminStatement(stmt)
+	case *influxql.RevokeRoleStatement:
+		if ctx.ReadOnly {
+			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
+		}
+		err = e.executeRevokeRoleStatement(stmt)
+	case *influxql.RevokeRolePrivilegeStatement:
+		if ctx.ReadOnly {
+			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
+		}
+		err = e.executeRevokeRolePrivilegeStatement(stmt)
 	case *influxql.ShowContinuousQueriesStatement:
 		rows, err = e.executeShowContinuousQueriesStatement(stmt)
 	case *influxql.ShowDatabasesStatement:
 		rows, err = e.executeShowDatabasesStatement(stmt, ctx)
 	case *influxql.ShowDiagnosticsStatement:
 		rows, err = e.executeShowDiagnosticsStatement(stmt)
+	case *influxql.ShowDiskUsageStatement:
+		return e.executeShowDiskUsageStatement(stmt, ctx)
 	case *influxql.ShowGrantsForUserStatement:
 		rows, err = e.executeShowGrantsForUserStatement(stmt)
+	case *influxql.ShowGrantsForRoleStatement:
+		rows, err = e.executeShowGrantsForRoleStatement(stmt)
+	case *influxql.ShowMaterializedViewsStatement:
+		rows, err = e.executeShowMaterializedViewsStatement(stmt)
 	case *influxql.ShowMeasurementsStatement:
 		return e.executeShowMeasurementsStatement(stmt, ctx)
 	case *influxql.ShowMeasurementCardinalityStatement:
 		rows, err = e.executeShowMeasurementCardinalityStatement(stmt)
+	case *influxql.ShowMeasurementStatsStatement:
+		return e.executeShowMeasurementStatsStatement(stmt, ctx)
+	case *influxql.ShowQueryTemplatesStatement:
+		rows, err = e.executeShowQueryTemplatesStatement(stmt)
 	case *influxql.ShowRetentionPoliciesStatement:
 		rows, err = e.executeShowRetentionPoliciesStatement(stmt)
 	case *influxql.ShowSeriesCardinalityStatement:
 		rows, err = e.executeShowSeriesCardinalityStatement(stmt)
+	case *influxql.ShowRolesStatement:
+		rows, err = e.executeShowRolesStatement(stmt)
+	case *influxql.ShowSessionsStatement:
+		rows, err = e.executeShowSessionsStatement(stmt)
 	case *influxql.ShowShardsStatement:
 		rows, err = e.executeShowShardsStatement(stmt)
+	case *influxql.ShowShardJobsStatement:
+		rows, err = e.executeShowShardJobsStatement(stmt)
+	case *influxql.ShowShardStatement:
+		rows, err = e.executeShowShardStatement(stmt)
 	case *influxql.ShowShardGroupsStatement:
 		rows, err = e.executeShowShardGroupsStatement(stmt)
 	case *influxql.ShowStatsStatement:
@@ -208,9 +347,18 @@ func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement, ctx *query
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
 		}
 		err = e.executeSetPasswordUserStatement(stmt)
-	case *influxql.ShowQueriesStatement, *influxql.KillQueryStatement:
-		// Send query related statements to the task manager.
-		return e.TaskManager.ExecuteStatement(stmt, ctx)
+	case *influxql.TruncateShardsStatement:
+		if ctx.ReadOnly {
+			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
+		}
+		err = e.executeTruncateShardsStatement(stmt)
+	case *influxql.ShowQueriesStatement:
+		return e.executeShowQueriesStatement(ctx)
+	case *influxql.KillQueryStatement:
+		if ctx.ReadOnly {
+			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
+		}
+		err = e.executeKillQueryStatement(stmt)
 	default:
 		return query.ErrInvalidQuery
 	}
@@ -230,6 +378,9 @@ func (e *StatementExecutor) executeAlterRetentionPolicyStatement(stmt *influxql.
 		Duration:           stmt.Duration,
 		ReplicaN:           stmt.Replication,
 		ShardGroupDuration: stmt.ShardGroupDuration,
+		FutureWriteLimit:   stmt.FutureWriteLimit,
+		PastWriteLimit:     stmt.PastWriteLimit,
+		Measurement:        stmt.Measurement,
 	}
 
 	// Update the retention policy.
@@ -283,6 +434,10 @@ func (e *StatementExecutor) executeCreateDatabaseStatement(stmt *influxql.Create
 	}
 
 	if !stmt.RetentionPolicyCreate {
+		if len(stmt.ShardKey) > 0 {
+			_, err := e.MetaClient.CreateDatabaseWithShardKey(stmt.Name, nil, stmt.ShardKey)
+			return err
+		}
 		_, err := e.MetaClient.CreateDatabase(stmt.Name)
 		return err
 	}
@@ -301,6 +456,10 @@ func (e *StatementExecutor) executeCreateDatabaseStatement(stmt *influxql.Create
 		ReplicaN:           *stmt.RetentionPolicyReplication,
 		ShardGroupDuration: stmt.RetentionPolicyShardGroupDuration,
 	}
+	if len(stmt.ShardKey) > 0 {
+		_, err := e.MetaClient.CreateDatabaseWithShardKey(stmt.Name, &rpi, stmt.ShardKey)
+		return err
+	}
 	_, err := e.MetaClient.CreateDatabaseWithRetentionPolicy(stmt.Name, &rpi)
 	return err
 }
@@ -317,6 +476,8 @@ func (e *StatementExecutor) executeCreateRetentionPolicyStatement(stmt *influxql
 		Duration:           stmt.Duration,
 		ReplicaN:           stmt.Replication,
 		ShardGroupDuration: stmt.ShardGroupDuration,
+		FutureWriteLimit:   stmt.FutureWriteLimit,
+		PastWriteLimit:     stmt.PastWriteLimit,
 	}
 
 	// Create new retention policy.
@@ -335,7 +496,42 @@ func (e *StatementExecutor) executeCreateRetentionPolicyStatement(stmt *influxql
 }
 
 func (e *StatementExecutor) executeCreateSubscriptionStatement(q *influxql.CreateSubscriptionStatement) error {
-	return e.MetaClient.CreateSubscription(q.Database, q.RetentionPolicy, q.Name, q.Mode, q.Destinations)
+	si := meta.SubscriptionInfo{
+		Name:          q.Name,
+		Mode:          q.Mode,
+		Destinations:  q.Destinations,
+		Measurements:  q.Measurements,
+		StripTags:     q.StripTags,
+		ResampleEvery: q.ResampleEvery,
+	}
+	if q.Condition != nil {
+		si.Condition = q.Condition.String()
+	}
+	return e.MetaClient.CreateSubscriptionWithFilter(q.Database, q.RetentionPolicy, si)
+}
+
+func (e *StatementExecutor) executeCreateRoleStatement(q *influxql.CreateRoleStatement) error {
+	return e.MetaClient.CreateRole(q.Name)
+}
+
+func (e *StatementExecutor) executeCreateMaterializedViewStatement(q *influxql.CreateMaterializedViewStatement) error {
+	v, err := NewMaterializedView(q)
+	if err != nil {
+		return err
+	}
+
+	// Verify that the retention policy of the view exists.
+	if rp, err := e.MetaClient.RetentionPolicy(v.Database, v.RetentionPolicy); err != nil {
+		return err
+	} else if rp == nil {
+		return fmt.Errorf("%s: %s.%s", meta.ErrRetentionPolicyNotFound, v.Database, v.RetentionPolicy)
+	}
+
+	return e.MetaClient.CreateMaterializedView(q.Database, q.Name, q.String())
+}
+
+func (e *StatementExecutor) executeCreateQueryTemplateStatement(q *influxql.CreateQueryTemplateStatement) error {
+	return e.MetaClient.CreateQueryTemplate(q.Name, q.Source.String())
 }
 
 func (e *StatementExecutor) executeCreateUserStatement(q *influxql.CreateUserStatement) error {
@@ -355,6 +551,14 @@ func (e *StatementExecutor) executeDeleteSeriesStatement(stmt *influxql.DeleteSe
 	return e.TSDBStore.DeleteSeries(database, stmt.Sources, stmt.Condition)
 }
 
+func (e *StatementExecutor) executeDropQueryTemplateStatement(q *influxql.DropQueryTemplateStatement) error {
+	return e.MetaClient.DropQueryTemplate(q.Name)
+}
+
+func (e *StatementExecutor) executeDropMaterializedViewStatement(q *influxql.DropMaterializedViewStatement) error {
+	return e.MetaClient.DropMaterializedView(q.Database, q.Name)
+}
+
 func (e *StatementExecutor) executeDropContinuousQueryStatement(q *influxql.DropContinuousQueryStatement) error {
 	return e.MetaClient.DropContinuousQuery(q.Database, q.Name)
 }
@@ -399,14 +603,297 @@ func (e *StatementExecutor) executeDropSeriesStatement(stmt *influxql.DropSeries
 	return e.TSDBStore.DeleteSeries(database, stmt.Sources, stmt.Condition)
 }
 
-func (e *StatementExecutor) executeDropShardStatement(stmt *influxql.DropShardStatement) error {
-	// Locally delete the shard.
-	if err := e.TSDBStore.DeleteShard(stmt.ID); err != nil {
+func (e *StatementExecutor) executeDropShardStatement(stmt *influxql.DropShardStatement) (models.Rows, error) {
+	// Look up the owners before the shard is removed from the meta store.
+	var owners []uint64
+	if _, _, sgi := e.MetaClient.ShardOwner(stmt.ID); sgi != nil {
+		for _, si := range sgi.Shards {
+			if si.ID == stmt.ID {
+				for _, owner := range si.Owners {
+					owners = append(owners, owner.NodeID)
+				}
+			}
+		}
+	}
+
+	if err := e.shardJobs.open(e.ShardJobsPath); err != nil {
+		return nil, err
+	}
+	id, err := e.shardJobs.start("drop", stmt.ID, 0, func() error {
+		// Delete the shard on the other owners, then locally.
+		for _, nodeID := range owners {
+			if e.isLocalNode(nodeID) || e.MetaExecutor == nil {
+				continue
+			}
+			if err := e.MetaExecutor.ExecuteStatementOnNode(stmt, "", nodeID); err != nil {
+				return fmt.Errorf("drop shard %d on node %d: %s", stmt.ID, nodeID, err)
+			}
+		}
+		if err := e.TSDBStore.DeleteShard(stmt.ID); err != nil {
+			return err
+		}
+
+		// Remove the shard reference from the Meta Store.
+		return e.MetaClient.DropShard(stmt.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shardJobRows(id), nil
+}
+
+func (e *StatementExecutor) executeCopyShardStatement(stmt *influxql.CopyShardStatement) (models.Rows, error) {
+	_, _, sgi := e.MetaClient.ShardOwner(stmt.ID)
+	if sgi == nil {
+		return nil, fmt.Errorf("shard %d not found", stmt.ID)
+	}
+	for _, si := range sgi.Shards {
+		if si.ID == stmt.ID && si.OwnedBy(stmt.NodeID) {
+			return nil, fmt.Errorf("node %d already owns shard %d", stmt.NodeID, stmt.ID)
+		}
+	}
+	if _, err := e.MetaClient.DataNode(stmt.NodeID); err != nil {
+		return nil, err
+	}
+
+	// The node the shard is copied to pulls it from another owner.
+	if err := e.shardJobs.open(e.ShardJobsPath); err != nil {
+		return nil, err
+	}
+	id, err := e.shardJobs.start("copy", stmt.ID, stmt.NodeID, func() error {
+		if e.isLocalNode(stmt.NodeID) {
+			return copyShard(e.MetaClient, e.TSDBStore, stmt.ID, stmt.NodeID)
+		} else if e.MetaExecutor == nil {
+			return fmt.Errorf("cannot reach node %d", stmt.NodeID)
+		}
+		return e.MetaExecutor.ExecuteStatementOnNode(stmt, "", stmt.NodeID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shardJobRows(id), nil
+}
+
+func (e *StatementExecutor) executeReindexShardStatement(stmt *influxql.ReindexShardStatement) (models.Rows, error) {
+	_, _, sgi := e.MetaClient.ShardOwner(stmt.ID)
+	if sgi == nil {
+		return nil, fmt.Errorf("shard %d not found", stmt.ID)
+	}
+	var owners []uint64
+	for _, si := range sgi.Shards {
+		if si.ID == stmt.ID {
+			for _, owner := range si.Owners {
+				owners = append(owners, owner.NodeID)
+			}
+		}
+	}
+
+	// Every owner rebuilds its copy of the index while the shard stays online.
+	if err := e.shardJobs.open(e.ShardJobsPath); err != nil {
+		return nil, err
+	}
+	id, err := e.shardJobs.start("reindex", stmt.ID, 0, func() error {
+		var local bool
+		for _, nodeID := range owners {
+			if e.isLocalNode(nodeID) {
+				local = true
+				continue
+			} else if e.MetaExecutor == nil {
+				return fmt.Errorf("cannot reach node %d", nodeID)
+			}
+			if err := e.MetaExecutor.ExecuteStatementOnNode(stmt, "", nodeID); err != nil {
+				return fmt.Errorf("reindex shard %d on node %d: %s", stmt.ID, nodeID, err)
+			}
+		}
+		if local {
+			return e.TSDBStore.ReindexShard(stmt.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shardJobRows(id), nil
+}
+
+// isLocalNode returns true if nodeID is the ID of this node.
+func (e *StatementExecutor) isLocalNode(nodeID uint64) bool {
+	return e.Node == nil || e.Node.ID == nodeID
+}
+
+// forEachRemoteNode calls fn concurrently for every data node other than this
+// one, and returns the errors of the nodes by ID. fn is not called without a
+// MetaExecutor.
+func (e *StatementExecutor) forEachRemoteNode(fn func(nodeID uint64) error) (map[uint64]error, error) {
+	if e.MetaExecutor == nil {
+		return nil, nil
+	}
+
+	nodes, err := e.MetaClient.DataNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make(map[uint64]error)
+	for _, n := range nodes {
+		if e.isLocalNode(n.ID) {
+			continue
+		}
+		wg.Add(1)
+		go func(nodeID uint64) {
+			defer wg.Done()
+			if err := fn(nodeID); err != nil {
+				mu.Lock()
+				errs[nodeID] = err
+				mu.Unlock()
+			}
+		}(n.ID)
+	}
+	wg.Wait()
+	return errs, nil
+}
+
+// nodeWarnings returns the warnings that what was not listed for the nodes
+// of errs, ordered by node ID.
+func nodeWarnings(what string, errs map[uint64]error) []*query.Message {
+	ids := make([]uint64, 0, len(errs))
+	for id := range errs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var messages []*query.Message
+	for _, id := range ids {
+		messages = append(messages, &query.Message{
+			Level: "warning",
+			Text:  fmt.Sprintf("%s of node %d not listed: %s", what, id, errs[id]),
+		})
+	}
+	return messages
+}
+
+// executeShowQueriesStatement lists the queries running on every data node.
+// The nodes that cannot be reached are reported in warnings.
+func (e *StatementExecutor) executeShowQueriesStatement(ctx *query.ExecutionContext) error {
+	var localID uint64
+	if e.Node != nil {
+		localID = e.Node.ID
+	}
+
+	type nodeQueries struct {
+		nodeID  uint64
+		queries []query.QueryInfo
+		err     error
+	}
+	all := []nodeQueries{{nodeID: localID, queries: e.TaskManager.Queries()}}
+
+	if e.MetaExecutor != nil {
+		nodes, err := e.MetaClient.DataNodes()
+		if err != nil {
+			return err
+		}
+
+		var wg sync.WaitGroup
+		remote := make([]nodeQueries, 0, len(nodes))
+		for _, n := range nodes {
+			if !e.isLocalNode(n.ID) {
+				remote = append(remote, nodeQueries{nodeID: n.ID})
+			}
+		}
+		for i := range remote {
+			wg.Add(1)
+			go func(nq *nodeQueries) {
+				defer wg.Done()
+				nq.queries, nq.err = e.MetaExecutor.QueriesOnNode(nq.nodeID)
+			}(&remote[i])
+		}
+		wg.Wait()
+		all = append(all, remote...)
+	}
+
+	var values [][]interface{}
+	var messages []*query.Message
+	for _, nq := range all {
+		if nq.err != nil {
+			messages = append(messages, &query.Message{
+				Level: "warning",
+				Text:  fmt.Sprintf("queries of node %d not listed: %s", nq.nodeID, nq.err),
+			})
+			continue
+		}
+		for _, qi := range nq.queries {
+			values = append(values, []interface{}{qi.ID, nq.nodeID, qi.Query, qi.Database, truncateDuration(qi.Duration).String(), qi.Status.String(), qi.Memory, qi.Priority.String(), qi.Spilled})
+		}
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		if values[i][1] != values[j][1] {
+			return values[i][1].(uint64) < values[j][1].(uint64)
+		}
+		return values[i][0].(uint64) < values[j][0].(uint64)
+	})
+
+	return ctx.Send(&query.Result{
+		Series: []*models.Row{{
+			Columns: []string{"qid", "node_id", "query", "database", "duration", "status", "memory", "priority", "spilled"},
+			Values:  values,
+		}},
+		Messages: messages,
+	})
+}
+
+// truncateDuration truncates d to the precision its unit is shown in.
+func truncateDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d - (d % time.Second)
+	case d >= time.Millisecond:
+		return d - (d % time.Millisecond)
+	case d >= time.Microsecond:
+		return d - (d % time.Microsecond)
+	}
+	return d
+}
+
+// executeKillQueryStatement kills a query of this node, or of the data node
+// named by the host of the statement.
+func (e *StatementExecutor) executeKillQueryStatement(stmt *influxql.KillQueryStatement) error {
+	if stmt.Host == "" {
+		return e.TaskManager.KillQuery(stmt.QueryID)
+	}
+
+	nodeID, err := e.queryNode(stmt.Host)
+	if err != nil {
 		return err
 	}
+	if e.isLocalNode(nodeID) {
+		return e.TaskManager.KillQuery(stmt.QueryID)
+	} else if e.MetaExecutor == nil {
+		return fmt.Errorf("cannot reach node %d", nodeID)
+	}
+	return e.MetaExecutor.ExecuteStatementOnNode(&influxql.KillQueryStatement{QueryID: stmt.QueryID}, "", nodeID)
+}
+
+// queryNode returns the ID of the data node named host, by its ID or its
+// HTTP or TCP address.
+func (e *StatementExecutor) queryNode(host string) (uint64, error) {
+	nodes, err := e.MetaClient.DataNodes()
+	if err != nil {
+		return 0, err
+	}
+	id, _ := strconv.ParseUint(host, 10, 64)
+	for _, n := range nodes {
+		if n.ID == id || n.Host == host || n.TCPHost == host {
+			return n.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("data node %s not found", host)
+}
 
-	// Remove the shard reference from the Meta Store.
-	return e.MetaClient.DropShard(stmt.ID)
+// shardJobRows returns the result of a statement starting the shard job id.
+func shardJobRows(id uint64) models.Rows {
+	return []*models.Row{{Columns: []string{"job"}, Values: [][]interface{}{{id}}}}
 }
 
 func (e *StatementExecutor) executeDropRetentionPolicyStatement(stmt *influxql.DropRetentionPolicyStatement) error {
@@ -431,18 +918,74 @@ func (e *StatementExecutor) executeDropSubscriptionStatement(q *influxql.DropSub
 	return e.MetaClient.DropSubscription(q.Database, q.RetentionPolicy, q.Name)
 }
 
+func (e *StatementExecutor) executeDropRoleStatement(q *influxql.DropRoleStatement) error {
+	return e.MetaClient.DropRole(q.Name)
+}
+
 func (e *StatementExecutor) executeDropUserStatement(q *influxql.DropUserStatement) error {
 	return e.MetaClient.DropUser(q.Name)
 }
 
-func (e *StatementExecutor) executeExplainStatement(q *influxql.ExplainStatement, ctx *query.ExecutionContext) (models.Rows, error) {
-	opt := query.SelectOptions{
-		NodeID:      ctx.ExecutionOptions.NodeID,
-		MaxSeriesN:  e.MaxSelectSeriesN,
-		MaxBucketsN: e.MaxSelectBucketsN,
-		Authorizer:  ctx.Authorizer,
+// executeExecuteQueryTemplateStatement binds the parameters of a version of a
+// query template to its statement and executes it as if the user had sent it.
+func (e *StatementExecutor) executeExecuteQueryTemplateStatement(q *influxql.ExecuteQueryTemplateStatement, ctx *query.ExecutionContext) error {
+	qti := e.MetaClient.QueryTemplate(q.Name)
+	if qti == nil {
+		return meta.ErrQueryTemplateNotFound
+	}
+	source, err := qti.VersionQuery(q.Version)
+	if err != nil {
+		return err
 	}
 
+	params := make(map[string]interface{}, len(q.Params))
+	for name, lit := range q.Params {
+		switch lit := lit.(type) {
+		case *influxql.BooleanLiteral:
+			params[name] = lit.Val
+		case *influxql.DurationLiteral:
+			params[name] = lit.Val
+		case *influxql.IntegerLiteral:
+			params[name] = lit.Val
+		case *influxql.NumberLiteral:
+			params[name] = lit.Val
+		case *influxql.StringLiteral:
+			params[name] = lit.Val
+		default:
+			return fmt.Errorf("invalid value for parameter %s: %s", name, lit)
+		}
+	}
+
+	p := influxql.NewParser(strings.NewReader(source))
+	p.SetParams(params)
+	stmt, err := p.ParseStatement()
+	if err != nil {
+		return fmt.Errorf("query template %s: %s", q.Name, err)
+	}
+	sel, ok := stmt.(*influxql.SelectStatement)
+	if !ok {
+		return fmt.Errorf("query template %s is not a SELECT statement", q.Name)
+	}
+
+	if err := e.NormalizeStatement(sel, ctx.Database, ctx.RetentionPolicy); err != nil {
+		return err
+	}
+
+	// The statement of the template is authorized like any other query, so
+	// a template only reads what its caller could read directly.
+	if !query.AuthorizerIsOpen(ctx.Authorizer) {
+		if err := ctx.Authorizer.AuthorizeQuery(ctx.Database, &influxql.Query{Statements: influxql.Statements{sel}}); err != nil {
+			return err
+		}
+	}
+	return e.executeSelectStatement(sel, ctx)
+}
+
+func (e *StatementExecutor) executeExplainStatement(q *influxql.ExplainStatement, ctx *query.ExecutionContext) (models.Rows, error) {
+	opt := e.selectOptions()
+	opt.NodeID = ctx.ExecutionOptions.NodeID
+	opt.Authorizer = ctx.Authorizer
+
 	// Prepare the query for execution, but do not actually execute it.
 	// This should perform any needed substitutions.
 	p, err := query.Prepare(q.Statement, e.ShardMapper, opt)
@@ -541,6 +1084,22 @@ func (e *StatementExecutor) executeGrantAdminStatement(stmt *influxql.GrantAdmin
 	return e.MetaClient.SetAdminPrivilege(stmt.User, true)
 }
 
+func (e *StatementExecutor) executeGrantRoleStatement(stmt *influxql.GrantRoleStatement) error {
+	return e.MetaClient.SetUserRole(stmt.User, stmt.Role, true)
+}
+
+func (e *StatementExecutor) executeGrantRolePrivilegeStatement(stmt *influxql.GrantRolePrivilegeStatement) error {
+	return e.MetaClient.SetRolePrivilege(stmt.Role, stmt.On, stmt.Privilege)
+}
+
+func (e *StatementExecutor) executeKillSessionStatement(stmt *influxql.KillSessionStatement) error {
+	return e.MetaClient.DropSession(stmt.ID)
+}
+
+func (e *StatementExecutor) executeTruncateShardsStatement(stmt *influxql.TruncateShardsStatement) error {
+	return e.MetaClient.TruncateDatabaseShardGroups(stmt.Database, stmt.RetentionPolicy, time.Now())
+}
+
 func (e *StatementExecutor) executeRevokeStatement(stmt *influxql.RevokeStatement) error {
 	priv := influxql.NoPrivileges
 
@@ -557,6 +1116,26 @@ func (e *StatementExecutor) executeRevokeStatement(stmt *influxql.RevokeStatemen
 	return e.MetaClient.SetPrivilege(stmt.User, stmt.On, priv)
 }
 
+func (e *StatementExecutor) executeRevokeRoleStatement(stmt *influxql.RevokeRoleStatement) error {
+	return e.MetaClient.SetUserRole(stmt.User, stmt.Role, false)
+}
+
+func (e *StatementExecutor) executeRevokeRolePrivilegeStatement(stmt *influxql.RevokeRolePrivilegeStatement) error {
+	priv := influxql.NoPrivileges
+
+	// Revoking all privileges means there's no need to look at existing role privileges.
+	if stmt.Privilege != influxql.AllPrivileges {
+		role := e.MetaClient.Role(stmt.Role)
+		if role == nil {
+			return meta.ErrRoleNotFound
+		}
+		// Bit clear (AND NOT) the role's privilege with the revoked privilege.
+		priv = role.Privileges[stmt.On] &^ stmt.Privilege
+	}
+
+	return e.MetaClient.SetRolePrivilege(stmt.Role, stmt.On, priv)
+}
+
 func (e *StatementExecutor) executeRevokeAdminStatement(stmt *influxql.RevokeAdminStatement) error {
 	return e.MetaClient.SetAdminPrivilege(stmt.User, false)
 }
@@ -566,8 +1145,29 @@ func (e *StatementExecutor) executeSetPasswordUserStatement(q *influxql.SetPassw
 }
 
 func (e *StatementExecutor) executeSelectStatement(stmt *influxql.SelectStatement, ctx *query.ExecutionContext) error {
+	// Answer the statement from a materialized view if one contains it. Views
+	// are in the database of their measurement, so reading them requires the
+	// same privilege.
+	var messages []*query.Message
+	if stmt.Target == nil && e.MaterializedViews != nil {
+		if other, msg := e.rewriteMaterializedView(stmt); other != nil {
+			stmt, messages = other, []*query.Message{msg}
+		}
+	}
 
-	cur, err := e.createIterators(ctx, stmt, ctx.ExecutionOptions)
+	// Read each shard from a single snapshot for the whole statement.
+	sctx, snapshots := tsdb.NewReadSnapshotsContext(ctx)
+	defer snapshots.Release()
+
+	// Read the forecast models named by the statement.
+	forecasts, err := e.forecastModels(sctx, stmt, ctx)
+	if err != nil {
+		return err
+	} else if forecasts != nil {
+		sctx = query.NewContextWithForecastModels(sctx, forecasts)
+	}
+
+	cur, err := e.createIterators(sctx, stmt, ctx.ExecutionOptions)
 	if err != nil {
 		return err
 	}
@@ -611,9 +1211,11 @@ func (e *StatementExecutor) executeSelectStatement(stmt *influxql.SelectStatemen
 		}
 
 		result := &query.Result{
-			Series:  []*models.Row{row},
-			Partial: partial,
+			Messages: messages,
+			Series:   []*models.Row{row},
+			Partial:  partial,
 		}
+		messages = nil
 
 		// Send results or exit if closing.
 		if err := ctx.Send(result); err != nil {
@@ -623,6 +1225,13 @@ func (e *StatementExecutor) executeSelectStatement(stmt *influxql.SelectStatemen
 		emitted = true
 	}
 
+	// Save the forecast models fitted while emitting.
+	if forecasts != nil {
+		if err := forecasts.flush(e.PointsWriter); err != nil {
+			return err
+		}
+	}
+
 	// Flush remaining points and emit write count if an INTO statement.
 	if stmt.Target != nil {
 		if err := pointsWriter.Flush(); err != nil {
@@ -647,21 +1256,58 @@ func (e *StatementExecutor) executeSelectStatement(stmt *influxql.SelectStatemen
 	// Always emit at least one result.
 	if !emitted {
 		return ctx.Send(&query.Result{
-			Series: make([]*models.Row, 0),
+			Messages: messages,
+			Series:   make([]*models.Row, 0),
 		})
 	}
 
 	return nil
 }
 
-func (e *StatementExecutor) createIterators(ctx context.Context, stmt *influxql.SelectStatement, opt query.ExecutionOptions) (query.Cursor, error) {
-	sopt := query.SelectOptions{
-		NodeID:      e.Node.ID,
-		MaxSeriesN:  e.MaxSelectSeriesN,
-		MaxPointN:   e.MaxSelectPointN,
-		MaxBucketsN: e.MaxSelectBucketsN,
-		Authorizer:  opt.Authorizer,
+// rewriteMaterializedView returns stmt rewritten to read from a built
+// materialized view that contains it, and a message reporting how stale the
+// view is. Each node refreshes the views for the writes it received, so the
+// staleness known here does not cover the writes to other nodes. It returns
+// nil if no view contains the statement.
+func (e *StatementExecutor) rewriteMaterializedView(stmt *influxql.SelectStatement) (*influxql.SelectStatement, *query.Message) {
+	if len(stmt.Sources) != 1 {
+		return nil, nil
+	}
+	m, ok := stmt.Sources[0].(*influxql.Measurement)
+	if !ok {
+		return nil, nil
+	}
+	di := e.MetaClient.Database(m.Database)
+	if di == nil {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	for _, mvi := range di.MaterializedViews {
+		staleness, ok := e.MaterializedViews.Staleness(di.Name, mvi.Name)
+		if !ok {
+			continue
+		}
+		v, err := ParseMaterializedView(di.Name, mvi)
+		if err != nil {
+			continue
+		}
+		if other, ok := v.Rewrite(stmt, now); ok {
+			return other, &query.Message{
+				Level: query.InfoLevel,
+				Text:  fmt.Sprintf("answered from materialized view %s, which may miss the last %s of writes to this node and the writes to other nodes not refreshed yet", v.Name, staleness.Truncate(time.Millisecond)),
+			}
+		}
 	}
+	return nil, nil
+}
+
+func (e *StatementExecutor) createIterators(ctx context.Context, stmt *influxql.SelectStatement, opt query.ExecutionOptions) (query.Cursor, error) {
+	sopt := e.selectOptions()
+	sopt.NodeID = e.Node.ID
+	sopt.Authorizer = opt.Authorizer
+	sopt.MaxPointsPerSeries = opt.MaxPointsPerSeries
+	sopt.SystemColumns = opt.SystemColumns
 
 	// Create a set of iterators from a selection.
 	cur, err := query.Select(ctx, stmt, e.ShardMapper, sopt)
@@ -685,6 +1331,28 @@ func (e *StatementExecutor) executeShowContinuousQueriesStatement(stmt *influxql
 	return rows, nil
 }
 
+func (e *StatementExecutor) executeShowMaterializedViewsStatement(stmt *influxql.ShowMaterializedViewsStatement) (models.Rows, error) {
+	dis, _ := e.MetaClient.Databases()
+
+	rows := []*models.Row{}
+	for _, di := range dis {
+		row := &models.Row{Columns: []string{"name", "query", "staleness"}, Name: di.Name}
+		for _, mvi := range di.MaterializedViews {
+			// The staleness only covers the writes to this node, and is
+			// unknown until the view is built.
+			var staleness interface{}
+			if e.MaterializedViews != nil {
+				if d, ok := e.MaterializedViews.Staleness(di.Name, mvi.Name); ok {
+					staleness = d.Truncate(time.Millisecond).String()
+				}
+			}
+			row.Values = append(row.Values, []interface{}{mvi.Name, mvi.Query, staleness})
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
 func (e *StatementExecutor) executeShowDatabasesStatement(q *influxql.ShowDatabasesStatement, ctx *query.ExecutionContext) (models.Rows, error) {
 	dis, _ := e.MetaClient.Databases()
 	a := ctx.ExecutionOptions.Authorizer
@@ -727,19 +1395,212 @@ func (e *StatementExecutor) executeShowDiagnosticsStatement(stmt *influxql.ShowD
 	return rows, nil
 }
 
+// executeShowDiskUsageStatement reports the disk usage of the shards on every
+// data node, summed over their replicas by database, retention policy and
+// measurement. The growth of the databases and retention policies over the
+// last day and week comes from the history of the nodes, and is null if the
+// history of a node does not reach that far. The nodes that cannot be
+// reached are reported in warnings.
+func (e *StatementExecutor) executeShowDiskUsageStatement(stmt *influxql.ShowDiskUsageStatement, ctx *query.ExecutionContext) error {
+	now := time.Now()
+	since := []time.Time{now.Add(-24 * time.Hour), now.Add(-7 * 24 * time.Hour)}
+
+	type nodeUsage struct {
+		nodeID  uint64
+		shards  []tsdb.ShardDiskUsage
+		history []*tsdb.DiskUsageSample
+	}
+	local := nodeUsage{}
+	if e.Node != nil {
+		local.nodeID = e.Node.ID
+	}
+	shards, err := e.TSDBStore.DiskUsage(stmt.Database)
+	if err != nil {
+		return err
+	}
+	local.shards = shards
+	for _, t := range since {
+		local.history = append(local.history, e.TSDBStore.DiskUsageAt(stmt.Database, t))
+	}
+	nodes := []nodeUsage{local}
+
+	var mu sync.Mutex
+	errs, err := e.forEachRemoteNode(func(nodeID uint64) error {
+		shards, history, err := e.MetaExecutor.DiskUsageOnNode(nodeID, stmt.Database, since)
+		if err != nil {
+			return err
+		} else if len(history) != len(since) {
+			return fmt.Errorf("got %d samples of history, expected %d", len(history), len(since))
+		}
+		mu.Lock()
+		nodes = append(nodes, nodeUsage{nodeID: nodeID, shards: shards, history: history})
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Sum the usage of the shards by database, retention policy and
+	// measurement. The totals of a database are keyed without a retention
+	// policy.
+	type rpKey struct{ database, rp string }
+	type measurementKey struct {
+		rpKey
+		name string
+	}
+	dbs := make(map[string]*tsdb.DiskUsage)
+	rps := make(map[rpKey]*tsdb.DiskUsage)
+	measurements := make(map[measurementKey]int64)
+	before := make([]map[rpKey]int64, len(since))
+	for i := range before {
+		before[i] = make(map[rpKey]int64)
+	}
+	shardRow := &models.Row{Name: "shards", Columns: []string{"id", "node_id", "database", "retention_policy", "tsm_bytes", "wal_bytes", "index_bytes", "total_bytes"}}
+	for _, n := range nodes {
+		for _, sh := range n.shards {
+			rk := rpKey{database: sh.Database, rp: sh.RetentionPolicy}
+			if dbs[rk.database] == nil {
+				dbs[rk.database] = &tsdb.DiskUsage{}
+			}
+			if rps[rk] == nil {
+				rps[rk] = &tsdb.DiskUsage{}
+			}
+			dbs[rk.database].Add(sh.DiskUsage)
+			rps[rk].Add(sh.DiskUsage)
+			for name, size := range sh.Measurements {
+				measurements[measurementKey{rpKey: rk, name: name}] += size
+			}
+			shardRow.Values = append(shardRow.Values, []interface{}{sh.ID, n.nodeID, sh.Database, sh.RetentionPolicy, sh.TSM, sh.WAL, sh.Index, sh.Total()})
+		}
+
+		for i, sample := range n.history {
+			if sample == nil || before[i] == nil {
+				before[i] = nil
+				continue
+			}
+			for _, u := range sample.Policies {
+				before[i][rpKey{database: u.Database}] += u.Total()
+				before[i][rpKey{database: u.Database, rp: u.RetentionPolicy}] += u.Total()
+			}
+		}
+	}
+
+	// growth returns the growth of the total of k since each time, or nil if
+	// the history does not reach the time.
+	growth := func(k rpKey, total int64) []interface{} {
+		values := make([]interface{}, len(before))
+		for i := range before {
+			if before[i] != nil {
+				values[i] = total - before[i][k]
+			}
+		}
+		return values
+	}
+
+	dbRow := &models.Row{Name: "databases", Columns: []string{"database", "tsm_bytes", "wal_bytes", "index_bytes", "total_bytes", "day_growth_bytes", "week_growth_bytes"}}
+	for db, u := range dbs {
+		values := []interface{}{db, u.TSM, u.WAL, u.Index, u.Total()}
+		dbRow.Values = append(dbRow.Values, append(values, growth(rpKey{database: db}, u.Total())...))
+	}
+	rpRow := &models.Row{Name: "retention_policies", Columns: []string{"database", "retention_policy", "tsm_bytes", "wal_bytes", "index_bytes", "total_bytes", "day_growth_bytes", "week_growth_bytes"}}
+	for k, u := range rps {
+		values := []interface{}{k.database, k.rp, u.TSM, u.WAL, u.Index, u.Total()}
+		rpRow.Values = append(rpRow.Values, append(values, growth(k, u.Total())...))
+	}
+	measurementRow := &models.Row{Name: "measurements", Columns: []string{"database", "retention_policy", "measurement", "estimated_tsm_bytes"}}
+	for k, n := range measurements {
+		measurementRow.Values = append(measurementRow.Values, []interface{}{k.database, k.rp, k.name, n})
+	}
+
+	// Shards are ordered by ID and node; the other rows by name.
+	sort.SliceStable(shardRow.Values, func(i, j int) bool {
+		a, b := shardRow.Values[i], shardRow.Values[j]
+		if a[0] != b[0] {
+			return a[0].(uint64) < b[0].(uint64)
+		}
+		return a[1].(uint64) < b[1].(uint64)
+	})
+	sortValuesByNames(dbRow.Values, 1)
+	sortValuesByNames(rpRow.Values, 2)
+	sortValuesByNames(measurementRow.Values, 3)
+
+	rows := models.Rows{}
+	for _, row := range []*models.Row{dbRow, rpRow, shardRow, measurementRow} {
+		if len(row.Values) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	return ctx.Send(&query.Result{
+		Series:   rows,
+		Messages: nodeWarnings("disk usage", errs),
+	})
+}
+
+// sortValuesByNames sorts values by their first n columns, which are strings.
+func sortValuesByNames(values [][]interface{}, n int) {
+	sort.Slice(values, func(i, j int) bool {
+		for k := 0; k < n; k++ {
+			if a, b := values[i][k].(string), values[j][k].(string); a != b {
+				return a < b
+			}
+		}
+		return false
+	})
+}
+
 func (e *StatementExecutor) executeShowGrantsForUserStatement(q *influxql.ShowGrantsForUserStatement) (models.Rows, error) {
 	priv, err := e.MetaClient.UserPrivileges(q.Name)
 	if err != nil {
 		return nil, err
 	}
 
-	row := &models.Row{Columns: []string{"database", "privilege"}}
+	row := &models.Row{Columns: []string{"database", "privilege", "role"}}
 	for d, p := range priv {
-		row.Values = append(row.Values, []interface{}{d, p.String()})
+		row.Values = append(row.Values, []interface{}{d, p.String(), ""})
+	}
+
+	// Privileges inherited from roles are listed with the granting role.
+	for _, ui := range e.MetaClient.Users() {
+		if ui.Name != q.Name {
+			continue
+		}
+		for _, name := range ui.Roles {
+			role := e.MetaClient.Role(name)
+			if role == nil {
+				continue
+			}
+			for _, d := range sortedPrivilegeDatabases(role.Privileges) {
+				row.Values = append(row.Values, []interface{}{d, role.Privileges[d].String(), role.Name})
+			}
+		}
+	}
+	return []*models.Row{row}, nil
+}
+
+func (e *StatementExecutor) executeShowGrantsForRoleStatement(q *influxql.ShowGrantsForRoleStatement) (models.Rows, error) {
+	role := e.MetaClient.Role(q.Name)
+	if role == nil {
+		return nil, meta.ErrRoleNotFound
+	}
+
+	row := &models.Row{Columns: []string{"database", "privilege"}}
+	for _, d := range sortedPrivilegeDatabases(role.Privileges) {
+		row.Values = append(row.Values, []interface{}{d, role.Privileges[d].String()})
 	}
 	return []*models.Row{row}, nil
 }
 
+// sortedPrivilegeDatabases returns the databases of privileges in order.
+func sortedPrivilegeDatabases(privileges map[string]influxql.Privilege) []string {
+	databases := make([]string, 0, len(privileges))
+	for d := range privileges {
+		databases = append(databases, d)
+	}
+	sort.Strings(databases)
+	return databases
+}
+
 func (e *StatementExecutor) executeShowMeasurementsStatement(q *influxql.ShowMeasurementsStatement, ctx *query.ExecutionContext) error {
 	if q.Database == "" {
 		return ErrDatabaseNameRequired
@@ -800,6 +1661,79 @@ func (e *StatementExecutor) executeShowMeasurementCardinalityStatement(stmt *inf
 	}}, nil
 }
 
+// executeShowMeasurementStatsStatement lists the statistics of the
+// measurements of the shards on every data node. Each shard is counted once,
+// whichever of its owners reports it. The nodes that cannot be reached are
+// reported in warnings.
+func (e *StatementExecutor) executeShowMeasurementStatsStatement(stmt *influxql.ShowMeasurementStatsStatement, ctx *query.ExecutionContext) error {
+	if stmt.Database == "" {
+		return ErrDatabaseNameRequired
+	}
+
+	shards, err := e.TSDBStore.MeasurementStats(stmt.Database)
+	if err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	errs, err := e.forEachRemoteNode(func(nodeID uint64) error {
+		ss, err := e.MetaExecutor.MeasurementStatsOnNode(nodeID, stmt.Database)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		shards = append(shards, ss...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	stats := make(map[string]tsdb.MeasurementStats)
+	seen := make(map[uint64]struct{}, len(shards))
+	for _, sh := range shards {
+		if _, ok := seen[sh.ID]; ok {
+			continue
+		}
+		seen[sh.ID] = struct{}{}
+		tsdb.MergeMeasurementStats(stats, sh.Measurements)
+	}
+
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		if m, ok := stmt.Source.(*influxql.Measurement); ok {
+			if m.Regex != nil && !m.Regex.Val.MatchString(name) {
+				continue
+			} else if m.Regex == nil && m.Name != name {
+				continue
+			}
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := &query.Result{Messages: nodeWarnings("measurement statistics", errs)}
+	if len(names) > 0 {
+		row := &models.Row{
+			Name:    "measurements",
+			Columns: []string{"name", "first", "last", "points", "disk_bytes"},
+		}
+		for _, name := range names {
+			s := stats[name]
+			row.Values = append(row.Values, []interface{}{
+				name,
+				time.Unix(0, s.MinTime).UTC().Format(time.RFC3339Nano),
+				time.Unix(0, s.MaxTime).UTC().Format(time.RFC3339Nano),
+				s.PointN,
+				s.DiskSize,
+			})
+		}
+		result.Series = models.Rows{row}
+	}
+	return ctx.Send(result)
+}
+
 func (e *StatementExecutor) executeShowRetentionPoliciesStatement(q *influxql.ShowRetentionPoliciesStatement) (models.Rows, error) {
 	if q.Database == "" {
 		return nil, ErrDatabaseNameRequired
@@ -810,9 +1744,9 @@ func (e *StatementExecutor) executeShowRetentionPoliciesStatement(q *influxql.Sh
 		return nil, freetsdb.ErrDatabaseNotFound(q.Database)
 	}
 
-	row := &models.Row{Columns: []string{"name", "duration", "shardGroupDuration", "replicaN", "default"}}
+	row := &models.Row{Columns: []string{"name", "duration", "shardGroupDuration", "replicaN", "futureWriteLimit", "pastWriteLimit", "default"}}
 	for _, rpi := range di.RetentionPolicies {
-		row.Values = append(row.Values, []interface{}{rpi.Name, rpi.Duration.String(), rpi.ShardGroupDuration.String(), rpi.ReplicaN, di.DefaultRetentionPolicy == rpi.Name})
+		row.Values = append(row.Values, []interface{}{rpi.Name, rpi.Duration.String(), rpi.ShardGroupDuration.String(), rpi.ReplicaN, rpi.FutureWriteLimit.String(), rpi.PastWriteLimit.String(), di.DefaultRetentionPolicy == rpi.Name})
 	}
 	return []*models.Row{row}, nil
 }
@@ -848,7 +1782,7 @@ func (e *StatementExecutor) executeShowShardsStatement(stmt *influxql.ShowShards
 
 	rows := []*models.Row{}
 	for _, di := range dis {
-		row := &models.Row{Columns: []string{"id", "database", "retention_policy", "shard_group", "start_time", "end_time", "expiry_time", "owners"}, Name: di.Name}
+		row := &models.Row{Columns: []string{"id", "database", "retention_policy", "shard_group", "start_time", "end_time", "expiry_time", "owners", "size"}, Name: di.Name}
 		for _, rpi := range di.RetentionPolicies {
 			for _, sgi := range rpi.ShardGroups {
 				// Shards associated with deleted shard groups are effectively deleted.
@@ -863,6 +1797,12 @@ func (e *StatementExecutor) executeShowShardsStatement(stmt *influxql.ShowShards
 						ownerIDs[i] = owner.NodeID
 					}
 
+					// The size is only known for the shards stored on this node.
+					var size interface{}
+					if n, err := e.TSDBStore.ShardDiskSize(si.ID); err == nil {
+						size = n
+					}
+
 					row.Values = append(row.Values, []interface{}{
 						si.ID,
 						di.Name,
@@ -872,6 +1812,7 @@ func (e *StatementExecutor) executeShowShardsStatement(stmt *influxql.ShowShards
 						sgi.EndTime.UTC().Format(time.RFC3339),
 						sgi.EndTime.Add(rpi.Duration).UTC().Format(time.RFC3339),
 						joinUint64(ownerIDs),
+						size,
 					})
 				}
 			}
@@ -881,6 +1822,60 @@ func (e *StatementExecutor) executeShowShardsStatement(stmt *influxql.ShowShards
 	return rows, nil
 }
 
+func (e *StatementExecutor) executeShowShardJobsStatement(stmt *influxql.ShowShardJobsStatement) (models.Rows, error) {
+	if err := e.shardJobs.open(e.ShardJobsPath); err != nil {
+		return nil, err
+	}
+
+	row := &models.Row{Columns: []string{"id", "op", "shard", "node", "status", "error", "started", "finished"}, Name: "shard jobs"}
+	for _, j := range e.shardJobs.list() {
+		var node, errStr, finished interface{}
+		if j.NodeID != 0 {
+			node = j.NodeID
+		}
+		if j.Err != nil {
+			errStr = j.Err.Error()
+		}
+		if !j.Finished.IsZero() {
+			finished = j.Finished.Format(time.RFC3339)
+		}
+		row.Values = append(row.Values, []interface{}{
+			j.ID,
+			j.Op,
+			j.ShardID,
+			node,
+			j.Status(),
+			errStr,
+			j.Started.Format(time.RFC3339),
+			finished,
+		})
+	}
+	return []*models.Row{row}, nil
+}
+
+func (e *StatementExecutor) executeShowShardStatement(stmt *influxql.ShowShardStatement) (models.Rows, error) {
+	files, err := e.TSDBStore.ShardFiles(stmt.ID)
+	if err == tsdb.ErrShardNotFound {
+		return nil, fmt.Errorf("shard %d is not stored on this node", stmt.ID)
+	} else if err != nil {
+		return nil, err
+	}
+
+	row := &models.Row{Columns: []string{"path", "size", "min_time", "max_time", "generation", "sequence", "tombstone"}, Name: "files"}
+	for _, f := range files {
+		row.Values = append(row.Values, []interface{}{
+			filepath.Base(f.Path),
+			f.Size,
+			time.Unix(0, f.MinTime).UTC().Format(time.RFC3339Nano),
+			time.Unix(0, f.MaxTime).UTC().Format(time.RFC3339Nano),
+			f.Generation,
+			f.Sequence,
+			f.HasTombstone,
+		})
+	}
+	return []*models.Row{row}, nil
+}
+
 func (e *StatementExecutor) executeShowSeriesCardinalityStatement(stmt *influxql.ShowSeriesCardinalityStatement) (models.Rows, error) {
 	if stmt.Database == "" {
 		return nil, ErrDatabaseNameRequired
@@ -1105,55 +2100,135 @@ func (e *StatementExecutor) executeShowTagValues(q *influxql.ShowTagValuesStatem
 		}
 	}
 
-	tagValues, err := e.TSDBStore.TagValues(ctx.Authorizer, shardIDs, cond)
+	itr, err := e.TSDBStore.TagValuesIterator(ctx.Authorizer, shardIDs, cond)
 	if err != nil {
 		return ctx.Send(&query.Result{Err: err})
 	}
+	defer itr.Close()
 
-	emitted := false
-	for _, m := range tagValues {
-		values := m.Values
+	// Stream the values of each measurement, so that at most a chunk of them
+	// is held in memory.
+	w := tagValuesWriter{
+		ctx:       ctx,
+		offset:    q.Offset,
+		limit:     q.Limit,
+		chunkSize: ctx.ChunkSize,
+		maxN:      e.MaxShowTagValuesN,
+	}
+	for {
+		name, kitr, err := itr.Next()
+		if err != nil {
+			return ctx.Send(&query.Result{Err: err})
+		} else if name == nil {
+			break
+		}
 
-		if q.Offset > 0 {
-			if q.Offset >= len(values) {
-				values = nil
-			} else {
-				values = values[q.Offset:]
-			}
+		err = w.writeMeasurement(string(name), kitr)
+		kitr.Close()
+		if err == errMaxShowTagValuesExceeded {
+			return ctx.Send(&query.Result{Err: fmt.Errorf("max-show-tag-values limit exceeded: (%d/%d)", w.n+1, w.maxN)})
+		} else if err != nil {
+			return ctx.Send(&query.Result{Err: err})
 		}
+	}
 
-		if q.Limit > 0 {
-			if q.Limit < len(values) {
-				values = values[:q.Limit]
-			}
+	// Ensure at least one result is emitted.
+	if w.n == 0 {
+		return ctx.Send(&query.Result{})
+	}
+	return nil
+}
+
+// errMaxShowTagValuesExceeded is returned by a tagValuesWriter once it has
+// written max-show-tag-values values.
+var errMaxShowTagValuesExceeded = errors.New("max-show-tag-values limit exceeded")
+
+// tagValuesWriter sends the results of a SHOW TAG VALUES statement, one
+// measurement at a time and in chunks of at most chunkSize rows.
+type tagValuesWriter struct {
+	ctx       *query.ExecutionContext
+	offset    int
+	limit     int
+	chunkSize int
+	maxN      int
+
+	n int // number of values written
+}
+
+// writeMeasurement writes the values of a measurement, applying the offset
+// and limit of the statement. The iterator is not read past the limit.
+func (w *tagValuesWriter) writeMeasurement(name string, itr tsdb.KeyValueIterator) error {
+	for i := 0; i < w.offset; i++ {
+		if kv, err := itr.Next(); err != nil {
+			return err
+		} else if kv == nil {
+			return nil
 		}
+	}
 
-		if len(values) == 0 {
-			continue
+	var row *models.Row
+	for written := 0; w.limit <= 0 || written < w.limit; written++ {
+		kv, err := itr.Next()
+		if err != nil {
+			return err
+		} else if kv == nil {
+			break
 		}
 
-		row := &models.Row{
-			Name:    m.Measurement,
-			Columns: []string{"key", "value"},
-			Values:  make([][]interface{}, len(values)),
+		if w.maxN > 0 && w.n >= w.maxN {
+			return errMaxShowTagValuesExceeded
 		}
-		for i, v := range values {
-			row.Values[i] = []interface{}{v.Key, v.Value}
+		w.n++
+
+		// Send the full chunk now that more values are known to follow.
+		if row != nil && w.chunkSize > 0 && len(row.Values) >= w.chunkSize {
+			row.Partial = true
+			if err := w.ctx.Send(&query.Result{Series: []*models.Row{row}, Partial: true}); err != nil {
+				return err
+			}
+			row = nil
 		}
 
-		if err := ctx.Send(&query.Result{
-			Series: []*models.Row{row},
-		}); err != nil {
-			return err
+		if row == nil {
+			row = &models.Row{
+				Name:    name,
+				Columns: []string{"key", "value"},
+			}
 		}
-		emitted = true
+		row.Values = append(row.Values, []interface{}{kv.Key, kv.Value})
 	}
 
-	// Ensure at least one result is emitted.
-	if !emitted {
-		return ctx.Send(&query.Result{})
+	if row == nil {
+		return nil
 	}
-	return nil
+	return w.ctx.Send(&query.Result{Series: []*models.Row{row}})
+}
+
+func (e *StatementExecutor) executeShowRolesStatement(q *influxql.ShowRolesStatement) (models.Rows, error) {
+	row := &models.Row{Columns: []string{"role"}}
+	for _, ri := range e.MetaClient.Roles() {
+		row.Values = append(row.Values, []interface{}{ri.Name})
+	}
+	return []*models.Row{row}, nil
+}
+
+func (e *StatementExecutor) executeShowQueryTemplatesStatement(q *influxql.ShowQueryTemplatesStatement) (models.Rows, error) {
+	row := &models.Row{Columns: []string{"name", "version", "query"}}
+	for _, qti := range e.MetaClient.QueryTemplates() {
+		for _, v := range qti.PreviousVersions {
+			row.Values = append(row.Values, []interface{}{qti.Name, v.Version, v.Query})
+		}
+		row.Values = append(row.Values, []interface{}{qti.Name, qti.Version, qti.Query})
+	}
+	return []*models.Row{row}, nil
+}
+
+func (e *StatementExecutor) executeShowSessionsStatement(q *influxql.ShowSessionsStatement) (models.Rows, error) {
+	row := &models.Row{Columns: []string{"id", "user", "created", "expires"}}
+	for _, si := range e.MetaClient.Sessions() {
+		row.Values = append(row.Values, []interface{}{si.ID, si.Username, si.CreatedAt.Format(time.RFC3339), si.ExpiresAt.Format(time.RFC3339)})
+	}
+	return []*models.Row{row}, nil
 }
 
 func (e *StatementExecutor) executeShowUsersStatement(q *influxql.ShowUsersStatement) (models.Rows, error) {
@@ -1349,6 +2424,10 @@ func (e *StatementExecutor) NormalizeStatement(stmt influxql.Statement, defaultD
 			if node.Database == "" {
 				node.Database = defaultDatabase
 			}
+		case *influxql.ShowMeasurementStatsStatement:
+			if node.Database == "" {
+				node.Database = defaultDatabase
+			}
 		case *influxql.ShowSeriesCardinalityStatement:
 			if node.Database == "" {
 				node.Database = defaultDatabase
@@ -1423,12 +2502,20 @@ type TSDBStore interface {
 	DeleteSeries(database string, sources []influxql.Source, condition influxql.Expr) error
 	DeleteShard(id uint64) error
 
+	ReindexShard(id uint64) error
+
 	MeasurementNames(auth query.Authorizer, database string, cond influxql.Expr) ([][]byte, error)
 	TagKeys(auth query.Authorizer, shardIDs []uint64, cond influxql.Expr) ([]tsdb.TagKeys, error)
-	TagValues(auth query.Authorizer, shardIDs []uint64, cond influxql.Expr) ([]tsdb.TagValues, error)
+	TagValuesIterator(auth query.Authorizer, shardIDs []uint64, cond influxql.Expr) (tsdb.TagValuesIterator, error)
 
 	SeriesCardinality(database string) (int64, error)
 	MeasurementsCardinality(database string) (int64, error)
+	MeasurementStats(database string) ([]tsdb.ShardMeasurementStats, error)
+
+	ShardDiskSize(id uint64) (int64, error)
+	ShardFiles(id uint64) ([]tsdb.ShardFile, error)
+	DiskUsage(database string) ([]tsdb.ShardDiskUsage, error)
+	DiskUsageAt(database string, t time.Time) *tsdb.DiskUsageSample
 
 	ShardGroup(ids []uint64) tsdb.ShardGroup
 }
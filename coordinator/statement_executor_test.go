@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/freetsdb/freetsdb"
 	"github.com/freetsdb/freetsdb/coordinator"
 	"github.com/freetsdb/freetsdb/services/influxql"
 	"github.com/freetsdb/freetsdb/internal"
@@ -85,6 +86,237 @@ func TestQueryExecutor_ExecuteQuery_SelectStatement(t *testing.T) {
 	}
 }
 
+// Ensure a query template is executed with the values of its parameters.
+func TestQueryExecutor_ExecuteQuery_QueryTemplate(t *testing.T) {
+	e := DefaultQueryExecutor()
+
+	var stored string
+	e.MetaClient.CreateQueryTemplateFn = func(name, query string) error {
+		if name != "dashboards.cpu" {
+			t.Fatalf("unexpected template name: %s", name)
+		}
+		stored = query
+		return nil
+	}
+	e.MetaClient.QueryTemplateFn = func(name string) *meta.QueryTemplateInfo {
+		if name != "dashboards.cpu" || stored == "" {
+			return nil
+		}
+		return &meta.QueryTemplateInfo{Name: name, Query: stored, Version: 1}
+	}
+	e.MetaClient.ShardGroupsByTimeRangeFn = func(database, policy string, min, max time.Time) (a []meta.ShardGroupInfo, err error) {
+		return []meta.ShardGroupInfo{
+			{ID: 1, Shards: []meta.ShardInfo{
+				{ID: 100, Owners: []meta.ShardOwner{{NodeID: 0}}},
+			}},
+		}, nil
+	}
+	e.TSDBStore.ShardGroupFn = func(ids []uint64) tsdb.ShardGroup {
+		var sh MockShard
+		sh.CreateIteratorFn = func(_ context.Context, _ *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+			if got, exp := opt.Condition.String(), `host = 'serverA'`; got != exp {
+				t.Fatalf("unexpected condition: got %s, exp %s", got, exp)
+			}
+			return &FloatIterator{Points: []query.FloatPoint{
+				{Name: "cpu", Time: int64(0 * time.Second), Aux: []interface{}{float64(100)}},
+			}}, nil
+		}
+		sh.FieldDimensionsFn = func(measurements []string) (fields map[string]influxql.DataType, dimensions map[string]struct{}, err error) {
+			return map[string]influxql.DataType{"value": influxql.Float}, map[string]struct{}{"host": {}}, nil
+		}
+		return &sh
+	}
+
+	if a := ReadAllResults(e.ExecuteQuery(`CREATE QUERY TEMPLATE dashboards.cpu AS SELECT value FROM cpu WHERE host = $host`, "db0", 0)); len(a) != 1 || a[0].Err != nil {
+		t.Fatalf("unexpected results: %s", spew.Sdump(a))
+	} else if exp := `SELECT value FROM cpu WHERE host = $host`; stored != exp {
+		t.Fatalf("unexpected stored query: got %s, exp %s", stored, exp)
+	}
+
+	if a := ReadAllResults(e.ExecuteQuery(`EXECUTE QUERY TEMPLATE dashboards.cpu WITH host = 'serverA'`, "db0", 0)); !reflect.DeepEqual(a, []*query.Result{
+		{
+			StatementID: 0,
+			Series: []*models.Row{{
+				Name:    "cpu",
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{time.Unix(0, 0).UTC(), float64(100)},
+				},
+			}},
+		},
+	}) {
+		t.Fatalf("unexpected results: %s", spew.Sdump(a))
+	}
+
+	// A parameter without a value is an error.
+	if a := ReadAllResults(e.ExecuteQuery(`EXECUTE QUERY TEMPLATE dashboards.cpu`, "db0", 0)); len(a) != 1 || a[0].Err == nil || a[0].Err.Error() != "query template dashboards.cpu: missing parameter: host" {
+		t.Fatalf("unexpected results: %s", spew.Sdump(a))
+	}
+
+	// A replaced version of the template is still executed when it is pinned.
+	e.MetaClient.QueryTemplateFn = func(name string) *meta.QueryTemplateInfo {
+		return &meta.QueryTemplateInfo{
+			Name:             name,
+			Query:            `SELECT value FROM mem`,
+			Version:          2,
+			PreviousVersions: []meta.QueryTemplateVersion{{Version: 1, Query: stored}},
+		}
+	}
+	if a := ReadAllResults(e.ExecuteQuery(`EXECUTE QUERY TEMPLATE dashboards.cpu VERSION 1 WITH host = 'serverA'`, "db0", 0)); len(a) != 1 || a[0].Err != nil || len(a[0].Series) != 1 || a[0].Series[0].Name != "cpu" {
+		t.Fatalf("unexpected results: %s", spew.Sdump(a))
+	}
+	if a := ReadAllResults(e.ExecuteQuery(`EXECUTE QUERY TEMPLATE dashboards.cpu VERSION 3`, "db0", 0)); len(a) != 1 || a[0].Err != meta.ErrQueryTemplateVersionNotFound {
+		t.Fatalf("unexpected results: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure a SELECT statement contained by a materialized view is answered from it.
+func TestQueryExecutor_ExecuteQuery_MaterializedView(t *testing.T) {
+	e := DefaultQueryExecutor()
+	e.StatementExecutor.MaterializedViews = &MaterializedViews{
+		StalenessFn: func(database, name string) (time.Duration, bool) {
+			return 2 * time.Second, database == "db0" && name == "cpu_1m"
+		},
+	}
+	e.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		di := DefaultMetaClientDatabaseFn(name)
+		di.MaterializedViews = []meta.MaterializedViewInfo{{
+			Name:  "cpu_1m",
+			Query: `CREATE MATERIALIZED VIEW cpu_1m ON db0 AS SELECT sum(value) FROM db0.rp0.cpu GROUP BY time(1m), host`,
+		}}
+		return di
+	}
+	e.MetaClient.ShardGroupsByTimeRangeFn = func(database, policy string, min, max time.Time) (a []meta.ShardGroupInfo, err error) {
+		return []meta.ShardGroupInfo{
+			{ID: 1, Shards: []meta.ShardInfo{
+				{ID: 100, Owners: []meta.ShardOwner{{NodeID: 0}}},
+			}},
+		}, nil
+	}
+	e.TSDBStore.ShardGroupFn = func(ids []uint64) tsdb.ShardGroup {
+		var sh MockShard
+		sh.CreateIteratorFn = func(_ context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+			if m.Name != "cpu_1m" {
+				t.Fatalf("unexpected measurement: %s", m.Name)
+			} else if got, exp := opt.Expr.String(), `sum(sum_value)`; got != exp {
+				t.Fatalf("unexpected expression: got %s, exp %s", got, exp)
+			}
+			return &FloatIterator{Points: []query.FloatPoint{
+				{Name: "cpu_1m", Time: int64(0 * time.Second), Value: 10},
+			}}, nil
+		}
+		sh.FieldDimensionsFn = func(measurements []string) (fields map[string]influxql.DataType, dimensions map[string]struct{}, err error) {
+			return map[string]influxql.DataType{"sum_value": influxql.Float}, map[string]struct{}{"host": {}}, nil
+		}
+		return &sh
+	}
+
+	if a := ReadAllResults(e.ExecuteQuery(`SELECT sum(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:01:00Z'`, "db0", 0)); !reflect.DeepEqual(a, []*query.Result{
+		{
+			StatementID: 0,
+			Messages: []*query.Message{{
+				Level: query.InfoLevel,
+				Text:  "answered from materialized view cpu_1m, which may miss the last 2s of writes to this node and the writes to other nodes not refreshed yet",
+			}},
+			Series: []*models.Row{{
+				Name:    "cpu",
+				Columns: []string{"time", "sum"},
+				Values: [][]interface{}{
+					{time.Unix(0, 0).UTC(), float64(10)},
+				},
+			}},
+		},
+	}) {
+		t.Fatalf("unexpected results: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure the models fitted by forecast() are saved and read back.
+func TestQueryExecutor_ExecuteQuery_Forecast(t *testing.T) {
+	e := DefaultQueryExecutor()
+	e.MetaClient.ShardGroupsByTimeRangeFn = func(database, policy string, min, max time.Time) (a []meta.ShardGroupInfo, err error) {
+		return []meta.ShardGroupInfo{
+			{ID: 1, Shards: []meta.ShardInfo{
+				{ID: 100, Owners: []meta.ShardOwner{{NodeID: 0}}},
+			}},
+		}, nil
+	}
+
+	var saved []models.Point
+	e.TSDBStore.ShardGroupFn = func(ids []uint64) tsdb.ShardGroup {
+		var sh MockShard
+		sh.CreateIteratorFn = func(_ context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+			switch m.Name {
+			case "cpu":
+				var points []query.FloatPoint
+				for i := 0; i < 10; i++ {
+					points = append(points, query.FloatPoint{Name: "cpu", Time: int64(i) * int64(time.Minute), Value: float64(i)})
+				}
+				return query.NewCallIterator(&FloatIterator{Points: points}, opt)
+			case query.ForecastModelsMeasurement:
+				// Return the models saved so far.
+				field := opt.Expr.(*influxql.Call).Args[0].(*influxql.VarRef).Val
+				var floats []query.FloatPoint
+				var integers []query.IntegerPoint
+				for _, pt := range saved {
+					fields, _ := pt.Fields()
+					tags := make(map[string]string)
+					for _, tag := range pt.Tags() {
+						tags[string(tag.Key)] = string(tag.Value)
+					}
+					switch v := fields[field].(type) {
+					case float64:
+						floats = append(floats, query.FloatPoint{Name: m.Name, Tags: query.NewTags(tags), Time: pt.UnixNano(), Value: v})
+					case int64:
+						integers = append(integers, query.IntegerPoint{Name: m.Name, Tags: query.NewTags(tags), Time: pt.UnixNano(), Value: v})
+					}
+				}
+				if field == "season" {
+					return query.NewCallIterator(&IntegerIterator{Points: integers}, opt)
+				}
+				return query.NewCallIterator(&FloatIterator{Points: floats}, opt)
+			}
+			t.Fatalf("unexpected measurement: %s", m.Name)
+			return nil, nil
+		}
+		sh.FieldDimensionsFn = func(measurements []string) (fields map[string]influxql.DataType, dimensions map[string]struct{}, err error) {
+			if measurements[0] == query.ForecastModelsMeasurement {
+				return map[string]influxql.DataType{
+					"alpha":  influxql.Float,
+					"beta":   influxql.Float,
+					"gamma":  influxql.Float,
+					"season": influxql.Integer,
+				}, map[string]struct{}{"model": {}, "measurement": {}}, nil
+			}
+			return map[string]influxql.DataType{"value": influxql.Float}, nil, nil
+		}
+		return &sh
+	}
+	e.StatementExecutor.PointsWriter = writePointsIntoFunc(func(req *coordinator.IntoWriteRequest) error {
+		if req.Database != "db0" || req.RetentionPolicy != "rp0" {
+			t.Fatalf("unexpected destination: %s.%s", req.Database, req.RetentionPolicy)
+		}
+		saved = append(saved, req.Points...)
+		return nil
+	})
+
+	q := `SELECT forecast(value, 2m, 0, 'capacity') FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:10:00Z' GROUP BY time(1m)`
+	if a := ReadAllResults(e.ExecuteQuery(q, "db0", 0)); len(a) != 1 || a[0].Err != nil || len(a[0].Series) != 1 || len(a[0].Series[0].Values) != 2 {
+		t.Fatalf("unexpected results: %s", spew.Sdump(a))
+	} else if len(saved) != 1 {
+		t.Fatalf("unexpected number of saved models: %d", len(saved))
+	} else if got, exp := string(saved[0].Key()), "_forecast_models,measurement=cpu,model=capacity"; got != exp {
+		t.Fatalf("unexpected series: got %s, exp %s", got, exp)
+	}
+
+	// The model saved is used instead of fitting it again.
+	if a := ReadAllResults(e.ExecuteQuery(q, "db0", 0)); len(a) != 1 || a[0].Err != nil {
+		t.Fatalf("unexpected results: %s", spew.Sdump(a))
+	} else if len(saved) != 1 {
+		t.Fatalf("unexpected number of saved models: %d", len(saved))
+	}
+}
+
 // Ensure query executor can enforce a maximum bucket selection count.
 func TestQueryExecutor_ExecuteQuery_MaxSelectBucketsN(t *testing.T) {
 	e := DefaultQueryExecutor()
@@ -446,6 +678,237 @@ func TestQueryExecutor_ExecuteQuery_ShowDatabases(t *testing.T) {
 	}
 }
 
+// Ensure SHOW RETENTION POLICIES lists the write limits of the retention policies.
+func TestQueryExecutor_ExecuteQuery_ShowRetentionPolicies(t *testing.T) {
+	e := DefaultQueryExecutor()
+	e.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		di := DefaultMetaClientDatabaseFn(name)
+		di.RetentionPolicies = []meta.RetentionPolicyInfo{
+			{Name: "rp0", Duration: 24 * time.Hour, ShardGroupDuration: time.Hour, ReplicaN: 2, FutureWriteLimit: time.Hour, PastWriteLimit: 2 * time.Hour},
+			{Name: "rp1", ShardGroupDuration: 7 * 24 * time.Hour, ReplicaN: 1},
+		}
+		return di
+	}
+
+	results := ReadAllResults(e.ExecuteQuery(`SHOW RETENTION POLICIES`, "db0", 0))
+	exp := []*query.Result{{
+		Series: []*models.Row{{
+			Columns: []string{"name", "duration", "shardGroupDuration", "replicaN", "futureWriteLimit", "pastWriteLimit", "default"},
+			Values: [][]interface{}{
+				{"rp0", "24h0m0s", "1h0m0s", 2, "1h0m0s", "2h0m0s", true},
+				{"rp1", "0s", "168h0m0s", 1, "0s", "0s", false},
+			},
+		}},
+	}}
+	if !reflect.DeepEqual(results, exp) {
+		t.Fatalf("unexpected results: exp %s, got %s", spew.Sdump(exp), spew.Sdump(results))
+	}
+}
+
+// Ensure TRUNCATE SHARDS NOW only truncates the shard groups of the database
+// or retention policy it is executed on.
+func TestQueryExecutor_ExecuteQuery_TruncateShards(t *testing.T) {
+	e := DefaultQueryExecutor()
+
+	var truncated []string
+	e.MetaClient.TruncateDatabaseShardGroupsFn = func(database, policy string, tm time.Time) error {
+		if database == "nodb" {
+			return freetsdb.ErrDatabaseNotFound(database)
+		}
+		truncated = append(truncated, database+"."+policy)
+		return nil
+	}
+	e.MetaClient.TruncateShardGroupsFn = func(tm time.Time) error {
+		t.Fatal("unexpected truncation of every shard group")
+		return nil
+	}
+
+	results := ReadAllResults(e.ExecuteQuery(`TRUNCATE SHARDS NOW ON db0; TRUNCATE SHARDS NOW ON db0.rp0`, "", 0))
+	if !reflect.DeepEqual(results, []*query.Result{{StatementID: 0}, {StatementID: 1}}) {
+		t.Fatalf("unexpected results: %s", spew.Sdump(results))
+	} else if exp := []string{"db0.", "db0.rp0"}; !reflect.DeepEqual(truncated, exp) {
+		t.Fatalf("unexpected shard groups truncated: got %v, exp %v", truncated, exp)
+	}
+
+	results = ReadAllResults(e.ExecuteQuery(`TRUNCATE SHARDS NOW ON nodb`, "", 0))
+	if len(results) != 1 || results[0].Err == nil || results[0].Err.Error() != "database not found: nodb" {
+		t.Fatalf("unexpected results: %s", spew.Sdump(results))
+	}
+}
+
+// Ensure SHOW TAG VALUES applies the limit and offset to each measurement and
+// splits the values into chunks.
+func TestQueryExecutor_ExecuteQuery_ShowTagValues(t *testing.T) {
+	e := DefaultQueryExecutor()
+	e.MetaClient.ShardGroupsByTimeRangeFn = func(database, policy string, min, max time.Time) (a []meta.ShardGroupInfo, err error) {
+		return []meta.ShardGroupInfo{
+			{ID: 1, Shards: []meta.ShardInfo{{ID: 100}}},
+		}, nil
+	}
+	e.TSDBStore.TagValuesFn = func(_ query.Authorizer, _ []uint64, _ influxql.Expr) ([]tsdb.TagValues, error) {
+		return []tsdb.TagValues{
+			{Measurement: "cpu", Values: []tsdb.KeyValue{
+				{Key: "host", Value: "a"}, {Key: "host", Value: "b"}, {Key: "host", Value: "c"}, {Key: "host", Value: "d"},
+			}},
+			{Measurement: "mem", Values: []tsdb.KeyValue{
+				{Key: "host", Value: "a"},
+			}},
+		}, nil
+	}
+
+	results := ReadAllResults(e.ExecuteQuery(`SHOW TAG VALUES WITH KEY = "host" LIMIT 3 OFFSET 1`, "db0", 2))
+	exp := []*query.Result{
+		{
+			StatementID: 0,
+			Series: []*models.Row{{
+				Name:    "cpu",
+				Columns: []string{"key", "value"},
+				Values:  [][]interface{}{{"host", "b"}, {"host", "c"}},
+				Partial: true,
+			}},
+			Partial: true,
+		},
+		{
+			StatementID: 0,
+			Series: []*models.Row{{
+				Name:    "cpu",
+				Columns: []string{"key", "value"},
+				Values:  [][]interface{}{{"host", "d"}},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(results, exp) {
+		t.Fatalf("unexpected results: exp %s, got %s", spew.Sdump(exp), spew.Sdump(results))
+	}
+
+	// The limit is exceeded by the last value.
+	e.StatementExecutor.MaxShowTagValuesN = 4
+	results = ReadAllResults(e.ExecuteQuery(`SHOW TAG VALUES WITH KEY = "host"`, "db0", 0))
+	if len(results) != 2 {
+		t.Fatalf("unexpected result count: %d", len(results))
+	} else if err := results[1].Err; err == nil || err.Error() != "max-show-tag-values limit exceeded: (5/4)" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure SHOW MEASUREMENT STATS merges the shards of every data node, counting
+// each shard once, and warns about the nodes that cannot be reached.
+func TestQueryExecutor_ExecuteQuery_ShowMeasurementStats(t *testing.T) {
+	e := DefaultQueryExecutor()
+	e.StatementExecutor.Node = &freetsdb.Node{ID: 1}
+	e.MetaClient.DataNodesFn = func() ([]meta.NodeInfo, error) {
+		return []meta.NodeInfo{{ID: 1}, {ID: 2}, {ID: 3}}, nil
+	}
+
+	shard1 := tsdb.ShardMeasurementStats{ID: 1, Measurements: map[string]tsdb.MeasurementStats{
+		"cpu": {MinTime: 10, MaxTime: 20, PointN: 2, DiskSize: 100},
+	}}
+	e.TSDBStore.MeasurementStatsFn = func(database string) ([]tsdb.ShardMeasurementStats, error) {
+		return []tsdb.ShardMeasurementStats{shard1}, nil
+	}
+	e.StatementExecutor.MetaExecutor = &MetaExecutor{
+		MeasurementStatsOnNodeFn: func(nodeID uint64, database string) ([]tsdb.ShardMeasurementStats, error) {
+			if nodeID == 3 {
+				return nil, errors.New("marker")
+			}
+			return []tsdb.ShardMeasurementStats{shard1, {ID: 2, Measurements: map[string]tsdb.MeasurementStats{
+				"cpu": {MinTime: 30, MaxTime: 40, PointN: 1, DiskSize: 50},
+				"mem": {MinTime: 5, MaxTime: 5, PointN: 1, DiskSize: 10},
+			}}}, nil
+		},
+	}
+
+	results := ReadAllResults(e.ExecuteQuery(`SHOW MEASUREMENT STATS`, "db0", 0))
+	exp := []*query.Result{{
+		Series: []*models.Row{{
+			Name:    "measurements",
+			Columns: []string{"name", "first", "last", "points", "disk_bytes"},
+			Values: [][]interface{}{
+				{"cpu", "1970-01-01T00:00:00.00000001Z", "1970-01-01T00:00:00.00000004Z", int64(3), int64(150)},
+				{"mem", "1970-01-01T00:00:00.000000005Z", "1970-01-01T00:00:00.000000005Z", int64(1), int64(10)},
+			},
+		}},
+		Messages: []*query.Message{{Level: "warning", Text: "measurement statistics of node 3 not listed: marker"}},
+	}}
+	if !reflect.DeepEqual(results, exp) {
+		t.Fatalf("unexpected results: exp %s, got %s", spew.Sdump(exp), spew.Sdump(results))
+	}
+}
+
+// Ensure SHOW DISK USAGE sums the replicas of the shards of every data node,
+// and reports the growth the history of every node reaches.
+func TestQueryExecutor_ExecuteQuery_ShowDiskUsage(t *testing.T) {
+	e := DefaultQueryExecutor()
+	e.StatementExecutor.Node = &freetsdb.Node{ID: 1}
+	e.MetaClient.DataNodesFn = func() ([]meta.NodeInfo, error) {
+		return []meta.NodeInfo{{ID: 1}, {ID: 2}, {ID: 3}}, nil
+	}
+
+	shard1 := tsdb.ShardDiskUsage{
+		ID:              1,
+		Database:        "db0",
+		RetentionPolicy: "rp0",
+		DiskUsage:       tsdb.DiskUsage{TSM: 100, WAL: 10, Index: 5},
+		Measurements:    map[string]int64{"cpu": 100},
+	}
+	sample := func(tsm int64) *tsdb.DiskUsageSample {
+		return &tsdb.DiskUsageSample{Policies: []tsdb.RetentionPolicyDiskUsage{
+			{Database: "db0", RetentionPolicy: "rp0", DiskUsage: tsdb.DiskUsage{TSM: tsm}},
+		}}
+	}
+	e.TSDBStore.DiskUsageFn = func(database string) ([]tsdb.ShardDiskUsage, error) {
+		return []tsdb.ShardDiskUsage{shard1}, nil
+	}
+	e.TSDBStore.DiskUsageAtFn = func(database string, t time.Time) *tsdb.DiskUsageSample {
+		if time.Since(t) > 2*24*time.Hour {
+			return sample(10)
+		}
+		return sample(50)
+	}
+	e.StatementExecutor.MetaExecutor = &MetaExecutor{
+		DiskUsageOnNodeFn: func(nodeID uint64, database string, since []time.Time) ([]tsdb.ShardDiskUsage, []*tsdb.DiskUsageSample, error) {
+			if nodeID == 3 {
+				return nil, nil, errors.New("marker")
+			}
+			// The history of node 2 does not reach a week.
+			return []tsdb.ShardDiskUsage{shard1}, []*tsdb.DiskUsageSample{sample(60), nil}, nil
+		},
+	}
+
+	results := ReadAllResults(e.ExecuteQuery(`SHOW DISK USAGE`, "", 0))
+	exp := []*query.Result{{
+		Series: []*models.Row{
+			{
+				Name:    "databases",
+				Columns: []string{"database", "tsm_bytes", "wal_bytes", "index_bytes", "total_bytes", "day_growth_bytes", "week_growth_bytes"},
+				Values:  [][]interface{}{{"db0", int64(200), int64(20), int64(10), int64(230), int64(120), nil}},
+			},
+			{
+				Name:    "retention_policies",
+				Columns: []string{"database", "retention_policy", "tsm_bytes", "wal_bytes", "index_bytes", "total_bytes", "day_growth_bytes", "week_growth_bytes"},
+				Values:  [][]interface{}{{"db0", "rp0", int64(200), int64(20), int64(10), int64(230), int64(120), nil}},
+			},
+			{
+				Name:    "shards",
+				Columns: []string{"id", "node_id", "database", "retention_policy", "tsm_bytes", "wal_bytes", "index_bytes", "total_bytes"},
+				Values: [][]interface{}{
+					{uint64(1), uint64(1), "db0", "rp0", int64(100), int64(10), int64(5), int64(115)},
+					{uint64(1), uint64(2), "db0", "rp0", int64(100), int64(10), int64(5), int64(115)},
+				},
+			},
+			{
+				Name:    "measurements",
+				Columns: []string{"database", "retention_policy", "measurement", "estimated_tsm_bytes"},
+				Values:  [][]interface{}{{"db0", "rp0", "cpu", int64(200)}},
+			},
+		},
+		Messages: []*query.Message{{Level: "warning", Text: "disk usage of node 3 not listed: marker"}},
+	}}
+	if !reflect.DeepEqual(results, exp) {
+		t.Fatalf("unexpected results: exp %s, got %s", spew.Sdump(exp), spew.Sdump(results))
+	}
+}
+
 // QueryExecutor is a test wrapper for coordinator.QueryExecutor.
 type QueryExecutor struct {
 	*query.Executor
@@ -476,6 +939,10 @@ func NewQueryExecutor() *QueryExecutor {
 		return nil, nil
 	}
 
+	e.MetaClient.DataNodesFn = func() ([]meta.NodeInfo, error) {
+		return nil, nil
+	}
+
 	e.StatementExecutor = &coordinator.StatementExecutor{
 		MetaClient: &e.MetaClient,
 		TSDBStore:  e.TSDBStore,
@@ -510,6 +977,40 @@ func (e *QueryExecutor) ExecuteQuery(q, database string, chunkSize int) <-chan *
 	}, make(chan struct{}))
 }
 
+// MetaExecutor is a mock of the executor of statements on the other data
+// nodes.
+type MetaExecutor struct {
+	ExecuteStatementOnNodeFn func(stmt influxql.Statement, database string, nodeID uint64) error
+	QueriesOnNodeFn          func(nodeID uint64) ([]query.QueryInfo, error)
+	MeasurementStatsOnNodeFn func(nodeID uint64, database string) ([]tsdb.ShardMeasurementStats, error)
+	DiskUsageOnNodeFn        func(nodeID uint64, database string, since []time.Time) ([]tsdb.ShardDiskUsage, []*tsdb.DiskUsageSample, error)
+}
+
+func (e *MetaExecutor) ExecuteStatementOnNode(stmt influxql.Statement, database string, nodeID uint64) error {
+	return e.ExecuteStatementOnNodeFn(stmt, database, nodeID)
+}
+
+func (e *MetaExecutor) QueriesOnNode(nodeID uint64) ([]query.QueryInfo, error) {
+	return e.QueriesOnNodeFn(nodeID)
+}
+
+func (e *MetaExecutor) MeasurementStatsOnNode(nodeID uint64, database string) ([]tsdb.ShardMeasurementStats, error) {
+	return e.MeasurementStatsOnNodeFn(nodeID, database)
+}
+
+func (e *MetaExecutor) DiskUsageOnNode(nodeID uint64, database string, since []time.Time) ([]tsdb.ShardDiskUsage, []*tsdb.DiskUsageSample, error) {
+	return e.DiskUsageOnNodeFn(nodeID, database, since)
+}
+
+// MaterializedViews is a mock of the service maintaining materialized views.
+type MaterializedViews struct {
+	StalenessFn func(database, name string) (time.Duration, bool)
+}
+
+func (v *MaterializedViews) Staleness(database, name string) (time.Duration, bool) {
+	return v.StalenessFn(database, name)
+}
+
 type MockShard struct {
 	Measurements             []string
 	FieldDimensionsFn        func(measurements []string) (fields map[string]influxql.DataType, dimensions map[string]struct{}, err error)
@@ -601,6 +1102,26 @@ func (itr *FloatIterator) Next() (*query.FloatPoint, error) {
 	return v, nil
 }
 
+// IntegerIterator is a represents an iterator that reads from a slice.
+type IntegerIterator struct {
+	Points []query.IntegerPoint
+	stats  query.IteratorStats
+}
+
+func (itr *IntegerIterator) Stats() query.IteratorStats { return itr.stats }
+func (itr *IntegerIterator) Close() error               { return nil }
+
+// Next returns the next value and shifts it off the beginning of the points slice.
+func (itr *IntegerIterator) Next() (*query.IntegerPoint, error) {
+	if len(itr.Points) == 0 {
+		return nil, nil
+	}
+
+	v := &itr.Points[0]
+	itr.Points = itr.Points[1:]
+	return v, nil
+}
+
 func ts(s string) time.Time {
 	t, err := time.Parse(time.RFC3339, s)
 	if err != nil {
@@ -0,0 +1,274 @@
+// Package embedded runs the storage and query engine of FreeTSDB inside
+// another Go process, without the HTTP API or the cluster services. The data
+// is stored in the same file format as a data node, so a directory written by
+// an embedded store can be backed up and restored like one.
+//
+// The package is not the root freetsdb package, which the meta store and the
+// coordinator import, so that it can use them.
+package embedded // import "github.com/freetsdb/freetsdb/embedded"
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/freetsdb/freetsdb"
+	"github.com/freetsdb/freetsdb/coordinator"
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/monitor"
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/services/retention"
+	"github.com/freetsdb/freetsdb/tsdb"
+	"go.uber.org/zap"
+
+	// Initialize the engine and index packages.
+	_ "github.com/freetsdb/freetsdb/tsdb/engine"
+	_ "github.com/freetsdb/freetsdb/tsdb/index"
+)
+
+// ErrClosed is returned when using a closed DB.
+var ErrClosed = errors.New("embedded store closed")
+
+// Options are the options of an embedded store.
+type Options struct {
+	// Data configures the storage engine. Its Dir and WALDir are set to the
+	// data and wal directories of the directory of the store.
+	Data tsdb.Config
+
+	// Coordinator configures the limits of the writes and queries.
+	Coordinator coordinator.Config
+
+	// Retention configures the enforcement of the retention policies.
+	Retention retention.Config
+
+	// RetentionAutoCreate creates the default retention policy of the
+	// databases created without one.
+	RetentionAutoCreate bool
+
+	Logger *zap.Logger
+}
+
+// NewOptions returns the default options of an embedded store.
+func NewOptions() Options {
+	return Options{
+		Data:                tsdb.NewConfig(),
+		Coordinator:         coordinator.NewConfig(),
+		Retention:           retention.NewConfig(),
+		RetentionAutoCreate: true,
+		Logger:              zap.NewNop(),
+	}
+}
+
+// DB is an embedded store. It keeps the meta data, the shards and the WAL in
+// the meta, data and wal directories of its directory, as a data node does.
+type DB struct {
+	mu        sync.RWMutex
+	closing   chan struct{}
+	closeOnce sync.Once
+	closed    bool
+
+	Node          *freetsdb.Node
+	MetaClient    *meta.Client
+	TSDBStore     *tsdb.Store
+	PointsWriter  *coordinator.PointsWriter
+	QueryExecutor *query.Executor
+	Retention     *retention.Service
+	Monitor       *monitor.Monitor
+
+	Logger *zap.Logger
+}
+
+// Open opens the embedded store in dir, creating it if it does not exist.
+func Open(dir string, opts Options) (*DB, error) {
+	if opts.Logger == nil {
+		opts.Logger = zap.NewNop()
+	}
+	opts.Data.Dir = filepath.Join(dir, "data")
+	opts.Data.WALDir = filepath.Join(dir, "wal")
+	if err := opts.Data.Validate(); err != nil {
+		return nil, err
+	} else if err := opts.Coordinator.Validate(); err != nil {
+		return nil, err
+	} else if err := opts.Retention.Validate(); err != nil {
+		return nil, err
+	}
+
+	db := &DB{
+		closing: make(chan struct{}),
+		Node:    freetsdb.NewNode(dir),
+		Logger:  opts.Logger,
+	}
+
+	// The meta data is kept by the process itself, which is the only data
+	// node owning the shards.
+	metaConfig := meta.NewConfig()
+	metaConfig.Dir = filepath.Join(dir, "meta")
+	metaConfig.RetentionAutoCreate = opts.RetentionAutoCreate
+	db.MetaClient = meta.NewLocalClient(metaConfig)
+	db.MetaClient.WithLogger(opts.Logger)
+	if err := db.MetaClient.Open(); err != nil {
+		return nil, fmt.Errorf("open meta data: %s", err)
+	}
+	db.Node.ID = db.MetaClient.NodeID()
+
+	db.TSDBStore = tsdb.NewStore(opts.Data.Dir)
+	db.TSDBStore.EngineOptions.Config = opts.Data
+	db.TSDBStore.EngineOptions.EngineVersion = opts.Data.Engine
+	db.TSDBStore.EngineOptions.IndexVersion = opts.Data.Index
+	db.TSDBStore.WithLogger(opts.Logger)
+	if err := db.TSDBStore.Open(); err != nil {
+		db.MetaClient.Close()
+		return nil, fmt.Errorf("open tsdb store: %s", err)
+	}
+
+	db.PointsWriter = coordinator.NewPointsWriter()
+	db.PointsWriter.WriteTimeout = time.Duration(opts.Coordinator.WriteTimeout)
+	db.PointsWriter.IdempotencyKeyTTL = time.Duration(opts.Coordinator.IdempotencyKeyTTL)
+	db.PointsWriter.MaxIdempotencyKeys = opts.Coordinator.MaxIdempotencyKeys
+	db.PointsWriter.MetaClient = db.MetaClient
+	db.PointsWriter.TSDBStore = db.TSDBStore
+	db.PointsWriter.Node = db.Node
+	db.PointsWriter.WithLogger(opts.Logger)
+	if err := db.PointsWriter.Open(); err != nil {
+		db.TSDBStore.Close()
+		db.MetaClient.Close()
+		return nil, fmt.Errorf("open points writer: %s", err)
+	}
+
+	// Without an HTTP API, the statistics are only read by SHOW STATS.
+	db.Monitor = monitor.New(db, monitor.Config{})
+
+	db.QueryExecutor = query.NewExecutor()
+	db.QueryExecutor.StatementExecutor = &coordinator.StatementExecutor{
+		MetaClient:  db.MetaClient,
+		TaskManager: db.QueryExecutor.TaskManager,
+		TSDBStore:   db.TSDBStore,
+		Node:        db.Node,
+		ShardMapper: &coordinator.LocalShardMapper{
+			MetaClient: db.MetaClient,
+			TSDBStore:  coordinator.LocalTSDBStore{Store: db.TSDBStore},
+		},
+		Monitor:           db.Monitor,
+		PointsWriter:      db.PointsWriter,
+		MaxSelectPointN:   opts.Coordinator.MaxSelectPointN,
+		MaxSelectSeriesN:  opts.Coordinator.MaxSelectSeriesN,
+		MaxSelectBucketsN: opts.Coordinator.MaxSelectBucketsN,
+		MaxShowTagValuesN: opts.Coordinator.MaxShowTagValuesN,
+	}
+	db.QueryExecutor.TaskManager.QueryTimeout = time.Duration(opts.Coordinator.QueryTimeout)
+	db.QueryExecutor.TaskManager.LogQueriesAfter = time.Duration(opts.Coordinator.LogQueriesAfter)
+	db.QueryExecutor.TaskManager.MaxConcurrentQueries = opts.Coordinator.MaxConcurrentQueries
+	db.QueryExecutor.WithLogger(opts.Logger)
+
+	db.Retention = retention.NewService(opts.Retention)
+	db.Retention.MetaClient = db.MetaClient
+	db.Retention.TSDBStore = db.TSDBStore
+	db.Retention.WithLogger(opts.Logger)
+	if err := db.Retention.Open(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open retention service: %s", err)
+	}
+
+	return db, nil
+}
+
+// Close closes the store. The queries running are interrupted.
+func (db *DB) Close() error {
+	// Interrupt the queries before waiting for them to return.
+	db.closeOnce.Do(func() { close(db.closing) })
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		return nil
+	}
+	db.closed = true
+
+	var errs []error
+	if db.Retention != nil {
+		errs = append(errs, db.Retention.Close())
+	}
+	errs = append(errs,
+		db.QueryExecutor.Close(),
+		db.PointsWriter.Close(),
+		db.TSDBStore.Close(),
+		db.MetaClient.Close(),
+	)
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// open returns ErrClosed if the store is closed. The read lock of the store
+// must be held.
+func (db *DB) open() error {
+	select {
+	case <-db.closing:
+		return ErrClosed
+	default:
+		return nil
+	}
+}
+
+// CreateDatabase creates a database, with the default retention policy if
+// the store creates it, or returns it if it already exists.
+func (db *DB) CreateDatabase(name string) (*meta.DatabaseInfo, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if err := db.open(); err != nil {
+		return nil, err
+	}
+	return db.MetaClient.CreateDatabase(name)
+}
+
+// WritePoints writes points to a retention policy of a database, or to its
+// default retention policy if retentionPolicy is empty.
+func (db *DB) WritePoints(database, retentionPolicy string, points []models.Point) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if err := db.open(); err != nil {
+		return err
+	}
+	return db.PointsWriter.WritePointsPrivileged(database, retentionPolicy, coordinator.ConsistencyLevelAll, points)
+}
+
+// Query executes the statements of an InfluxQL query against a database and
+// returns the result of each statement. The errors of the statements are
+// returned in their results.
+func (db *DB) Query(q, database string) ([]*query.Result, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if err := db.open(); err != nil {
+		return nil, err
+	}
+
+	parsed, err := influxql.ParseQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*query.Result
+	for r := range db.QueryExecutor.ExecuteQuery(parsed, query.ExecutionOptions{
+		Database:   database,
+		Authorizer: query.OpenAuthorizer,
+	}, db.closing) {
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// Statistics returns the statistics of the store.
+func (db *DB) Statistics(tags map[string]string) []models.Statistic {
+	var statistics []models.Statistic
+	statistics = append(statistics, db.QueryExecutor.Statistics(tags)...)
+	statistics = append(statistics, db.TSDBStore.Statistics(tags)...)
+	statistics = append(statistics, db.PointsWriter.Statistics(tags)...)
+	return statistics
+}
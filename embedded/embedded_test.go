@@ -0,0 +1,97 @@
+package embedded_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/embedded"
+	"github.com/freetsdb/freetsdb/models"
+)
+
+func TestDB_WriteQuery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freetsdb-embedded-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := mustOpen(t, dir)
+	if _, err := db.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	points, err := models.ParsePointsString("cpu,host=a value=1 10\ncpu,host=b value=2 20")
+	if err != nil {
+		t.Fatal(err)
+	} else if err := db.WritePoints("db0", "", points); err != nil {
+		t.Fatal(err)
+	}
+
+	const q = `SELECT sum(value) FROM cpu`
+	if values := mustQuery(t, db, q); len(values) != 1 || values[0][1] != float64(3) {
+		t.Fatalf("unexpected values: %v", values)
+	}
+
+	// The points are read back once the store is reopened.
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	} else if _, err := db.Query(q, "db0"); err != embedded.ErrClosed {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	db = mustOpen(t, dir)
+	defer db.Close()
+	if values := mustQuery(t, db, q); len(values) != 1 || values[0][1] != float64(3) {
+		t.Fatalf("unexpected values after reopen: %v", values)
+	}
+}
+
+func TestDB_Query_Error(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freetsdb-embedded-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := mustOpen(t, dir)
+	defer db.Close()
+
+	if _, err := db.Query(`SELECT`, "db0"); err == nil {
+		t.Fatal("expected parse error")
+	}
+
+	results, err := db.Query(`SELECT value FROM cpu`, "db1")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("expected database not found error: %v", results)
+	}
+}
+
+func mustOpen(t *testing.T, dir string) *embedded.DB {
+	t.Helper()
+	opts := embedded.NewOptions()
+	opts.Data.Index = "inmem"
+	db, err := embedded.Open(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// mustQuery returns the values of the single series the query returns.
+func mustQuery(t *testing.T, db *embedded.DB, q string) [][]interface{} {
+	t.Helper()
+	results, err := db.Query(q, "db0")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(results) != 1 {
+		t.Fatalf("unexpected results: %v", results)
+	} else if results[0].Err != nil {
+		t.Fatal(results[0].Err)
+	} else if len(results[0].Series) != 1 {
+		t.Fatalf("unexpected series: %v", results[0].Series)
+	}
+	return results[0].Series[0].Values
+}
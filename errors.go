@@ -12,12 +12,31 @@ var ErrFieldTypeConflict = errors.New("field type conflict")
 
 // ErrDatabaseNotFound indicates that a database operation failed on the
 // specified database because the specified database does not exist.
-func ErrDatabaseNotFound(name string) error { return fmt.Errorf("database not found: %s", name) }
+func ErrDatabaseNotFound(name string) error { return DatabaseNotFoundError{Name: name} }
 
 // ErrRetentionPolicyNotFound indicates that the named retention policy could
 // not be found in the database.
 func ErrRetentionPolicyNotFound(name string) error {
-	return fmt.Errorf("retention policy not found: %s", name)
+	return RetentionPolicyNotFoundError{Name: name}
+}
+
+// DatabaseNotFoundError is the error returned by ErrDatabaseNotFound.
+type DatabaseNotFoundError struct {
+	Name string
+}
+
+func (e DatabaseNotFoundError) Error() string {
+	return fmt.Sprintf("database not found: %s", e.Name)
+}
+
+// RetentionPolicyNotFoundError is the error returned by
+// ErrRetentionPolicyNotFound.
+type RetentionPolicyNotFoundError struct {
+	Name string
+}
+
+func (e RetentionPolicyNotFoundError) Error() string {
+	return fmt.Sprintf("retention policy not found: %s", e.Name)
 }
 
 // IsAuthorizationError indicates whether an error is due to an authorization failure
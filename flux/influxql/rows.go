@@ -0,0 +1,201 @@
+package influxql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/services/flux"
+)
+
+// series is a series of the result of a script, with its values by time and
+// column.
+type series struct {
+	name   string
+	tags   map[string]string
+	values map[int64]map[string]interface{}
+}
+
+// Rows returns the rows of the results of the script, in the layout of the
+// results of the InfluxQL statement it was transpiled from: the results of
+// the pipelines are joined by series and time, and each column of a row is
+// the value of a pipeline.
+func (s *Script) Rows(results flux.ResultIterator) ([]*models.Row, error) {
+	all := make(map[string]*series)
+	fields := make(map[string]struct{})
+
+	for results.More() {
+		res := results.Next()
+		column := res.Name()
+		if err := res.Tables().Do(func(tbl flux.Table) error {
+			ser, field := tableSeries(all, tbl.Key())
+			col := column
+			if column == wildcardColumn {
+				if field == "" {
+					return nil
+				}
+				col = field
+				fields[field] = struct{}{}
+			}
+			return tbl.Do(func(cr flux.ColReader) error {
+				return readColumn(ser, col, cr)
+			})
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if err := results.Err(); err != nil {
+		return nil, err
+	}
+
+	// The fields read by a wildcard are columns in order.
+	var columns []string
+	for _, c := range s.columns {
+		if c != wildcardColumn {
+			columns = append(columns, c)
+			continue
+		}
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		columns = append(columns, names...)
+	}
+
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	rows := make([]*models.Row, 0, len(keys))
+	for _, k := range keys {
+		ser := all[k]
+		row := &models.Row{
+			Name:    ser.name,
+			Tags:    ser.tags,
+			Columns: append([]string{"time"}, columns...),
+		}
+
+		times := make([]int64, 0, len(ser.values))
+		for t := range ser.values {
+			times = append(times, t)
+		}
+		sort.Slice(times, func(i, j int) bool {
+			if s.descending {
+				return times[i] > times[j]
+			}
+			return times[i] < times[j]
+		})
+
+		for _, t := range times {
+			values := make([]interface{}, len(row.Columns))
+			values[0] = time.Unix(0, t).UTC()
+			for i, c := range columns {
+				values[i+1] = ser.values[t][c]
+			}
+			row.Values = append(row.Values, values)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// tableSeries returns the series of a table with key, and the field of the
+// table if it has one.
+func tableSeries(all map[string]*series, key flux.GroupKey) (*series, string) {
+	var name, field string
+	tags := make(map[string]string)
+	for j, c := range key.Cols() {
+		if c.Type != flux.TString {
+			continue
+		}
+		switch c.Label {
+		case "_start", "_stop":
+		case "_measurement":
+			name = key.ValueString(j)
+		case "_field":
+			field = key.ValueString(j)
+		default:
+			tags[c.Label] = key.ValueString(j)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range sortedKeys(tags) {
+		fmt.Fprintf(&b, "\x00%s\x00%s", k, tags[k])
+	}
+	ser, ok := all[b.String()]
+	if !ok {
+		ser = &series{name: name, values: make(map[int64]map[string]interface{})}
+		if len(tags) > 0 {
+			ser.tags = tags
+		}
+		all[b.String()] = ser
+	}
+	return ser, field
+}
+
+// readColumn reads the values of column in cr into ser. The values of
+// aggregates, which have no time, are read at the start of their window.
+func readColumn(ser *series, column string, cr flux.ColReader) error {
+	timeIdx, valueIdx := -1, -1
+	for j, c := range cr.Cols() {
+		switch c.Label {
+		case "_time":
+			timeIdx = j
+		case "_start":
+			if timeIdx == -1 {
+				timeIdx = j
+			}
+		case "_value":
+			valueIdx = j
+		}
+	}
+	if timeIdx == -1 || valueIdx == -1 {
+		return fmt.Errorf("result %s has no time or value column", column)
+	}
+
+	typ := cr.Cols()[valueIdx].Type
+	times := cr.Times(timeIdx)
+	for i := 0; i < cr.Len(); i++ {
+		var v interface{}
+		switch typ {
+		case flux.TFloat:
+			v = cr.Floats(valueIdx)[i]
+		case flux.TInt:
+			v = cr.Ints(valueIdx)[i]
+		case flux.TUInt:
+			v = cr.UInts(valueIdx)[i]
+		case flux.TString:
+			v = cr.Strings(valueIdx)[i]
+		case flux.TBool:
+			v = cr.Bools(valueIdx)[i]
+		case flux.TTime:
+			v = cr.Times(valueIdx)[i].Time().UTC()
+		default:
+			return fmt.Errorf("unsupported type %s of result %s", typ, column)
+		}
+
+		t := int64(times[i])
+		if ser.values[t] == nil {
+			ser.values[t] = make(map[string]interface{})
+		}
+		ser.values[t][column] = v
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,75 @@
+package influxql_test
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	fluxinfluxql "github.com/freetsdb/freetsdb/flux/influxql"
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/services/flux/csv"
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+func TestScript_Rows(t *testing.T) {
+	stmt := influxql.MustParseStatement(`SELECT mean(usage) AS avg, max(usage) FROM cpu WHERE time >= '2019-01-01T00:00:00Z' AND time < '2019-01-01T02:00:00Z' GROUP BY time(1h), host`)
+	script, err := fluxinfluxql.Transpile(stmt, fluxinfluxql.Config{DefaultDatabase: "db0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The results of the pipelines of the script.
+	results, err := csv.NewMultiResultDecoder(csv.ResultDecoderConfig{}).Decode(ioutil.NopCloser(strings.NewReader(`#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,double,string,string,string
+#group,false,false,true,true,false,false,true,true,true
+#default,avg,,,,,,,,
+,result,table,_start,_stop,_time,_value,_field,_measurement,host
+,,0,2019-01-01T00:00:00Z,2019-01-01T02:00:00Z,2019-01-01T00:00:00Z,1.5,usage,cpu,a
+,,0,2019-01-01T00:00:00Z,2019-01-01T02:00:00Z,2019-01-01T01:00:00Z,2.5,usage,cpu,a
+,,1,2019-01-01T00:00:00Z,2019-01-01T02:00:00Z,2019-01-01T01:00:00Z,4,usage,cpu,b
+
+#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,double,string,string,string
+#group,false,false,true,true,false,false,true,true,true
+#default,max,,,,,,,,
+,result,table,_start,_stop,_time,_value,_field,_measurement,host
+,,0,2019-01-01T00:00:00Z,2019-01-01T02:00:00Z,2019-01-01T00:00:00Z,2,usage,cpu,a
+,,0,2019-01-01T00:00:00Z,2019-01-01T02:00:00Z,2019-01-01T01:00:00Z,3,usage,cpu,a
+,,1,2019-01-01T00:00:00Z,2019-01-01T02:00:00Z,2019-01-01T01:00:00Z,5,usage,cpu,b
+`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer results.Release()
+
+	rows, err := script.Rows(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := func(s string) time.Time {
+		tm, _ := time.Parse(time.RFC3339, s)
+		return tm
+	}
+	if exp := []*models.Row{
+		{
+			Name:    "cpu",
+			Tags:    map[string]string{"host": "a"},
+			Columns: []string{"time", "avg", "max"},
+			Values: [][]interface{}{
+				{ts("2019-01-01T00:00:00Z"), 1.5, 2.0},
+				{ts("2019-01-01T01:00:00Z"), 2.5, 3.0},
+			},
+		},
+		{
+			Name:    "cpu",
+			Tags:    map[string]string{"host": "b"},
+			Columns: []string{"time", "avg", "max"},
+			Values: [][]interface{}{
+				{ts("2019-01-01T01:00:00Z"), 4.0, 5.0},
+			},
+		},
+	}; !reflect.DeepEqual(rows, exp) {
+		t.Fatalf("unexpected rows:\ngot  %v\nwant %v", rows, exp)
+	}
+}
@@ -0,0 +1,470 @@
+package influxql
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+// Config configures the transpiler.
+type Config struct {
+	// DefaultDatabase and DefaultRetentionPolicy are read when a measurement
+	// does not name a database or retention policy.
+	DefaultDatabase        string
+	DefaultRetentionPolicy string
+
+	// Now is the time now() is evaluated to in the time range of a statement.
+	// The current time is used when it is zero.
+	Now time.Time
+}
+
+// aggregates maps the InfluxQL aggregates and selectors that have a Flux
+// equivalent to the Flux functions.
+var aggregates = map[string]string{
+	"count":    "count()",
+	"distinct": "distinct()",
+	"first":    "first()",
+	"last":     "last()",
+	"max":      "max()",
+	"mean":     "mean()",
+	"median":   `median(method: "exact_mean")`,
+	"min":      "min()",
+	"spread":   "spread()",
+	"stddev":   "stddev()",
+	"sum":      "sum()",
+}
+
+// Script is a Flux script transpiled from an InfluxQL SELECT statement. Each
+// field of the statement is read by its own pipeline, whose result is named
+// after the column of the field.
+type Script struct {
+	text string
+
+	// columns are the result names of the pipelines. The columns of a
+	// wildcard are the fields it reads.
+	columns []string
+
+	descending bool
+}
+
+// String returns the Flux source of the script.
+func (s *Script) String() string { return s.text }
+
+// wildcardColumn is the result name of the pipeline of a wildcard.
+const wildcardColumn = "*"
+
+// Transpile returns the Flux script equivalent to stmt. Only SELECT
+// statements reading fields, or aggregates and selectors of fields, from
+// measurements of a single database are supported. Identifiers in the WHERE
+// clause are compared against tags. Empty GROUP BY time() intervals are not
+// returned, as if fill(none) were set.
+func Transpile(stmt influxql.Statement, c Config) (*Script, error) {
+	s, ok := stmt.(*influxql.SelectStatement)
+	if !ok {
+		return nil, fmt.Errorf("unsupported statement: %s", stmt)
+	}
+	if c.Now.IsZero() {
+		c.Now = time.Now()
+	}
+	t := &transpiler{stmt: s, config: c}
+	return t.transpile()
+}
+
+type transpiler struct {
+	stmt   *influxql.SelectStatement
+	config Config
+
+	bucket       string
+	measurements string
+	cond         string
+	timeRange    influxql.TimeRange
+	interval     time.Duration
+	groupBy      []string
+	groupByAll   bool
+}
+
+func (t *transpiler) transpile() (*Script, error) {
+	s := t.stmt
+	if s.Target != nil {
+		return nil, errors.New("SELECT INTO is not supported")
+	} else if s.SLimit > 0 || s.SOffset > 0 {
+		return nil, errors.New("SLIMIT and SOFFSET are not supported")
+	} else if s.Fill != influxql.NullFill && s.Fill != influxql.NoFill {
+		return nil, errors.New("only fill(null) and fill(none) are supported")
+	}
+
+	if err := t.mapSources(); err != nil {
+		return nil, err
+	} else if err := t.mapCondition(); err != nil {
+		return nil, err
+	} else if err := t.mapDimensions(); err != nil {
+		return nil, err
+	}
+
+	script := &Script{descending: !s.TimeAscending()}
+	names := s.ColumnNames()
+	if !s.OmitTime {
+		names = names[1:]
+	}
+
+	var pipelines []string
+	for i, f := range s.Fields {
+		name := names[i]
+		if _, ok := f.Expr.(*influxql.Wildcard); ok {
+			name = wildcardColumn
+		}
+		p, err := t.pipeline(f.Expr, name)
+		if err != nil {
+			return nil, err
+		}
+		pipelines = append(pipelines, p)
+		script.columns = append(script.columns, name)
+	}
+	script.text = strings.Join(pipelines, "\n\n") + "\n"
+	return script, nil
+}
+
+// mapSources sets the bucket and the measurement filter of the sources.
+func (t *transpiler) mapSources() error {
+	var filters []string
+	for _, src := range t.stmt.Sources {
+		m, ok := src.(*influxql.Measurement)
+		if !ok {
+			return errors.New("subqueries are not supported")
+		}
+
+		db, rp := m.Database, m.RetentionPolicy
+		if db == "" {
+			db = t.config.DefaultDatabase
+		}
+		if rp == "" && m.Database == "" {
+			rp = t.config.DefaultRetentionPolicy
+		}
+		if db == "" {
+			return errors.New("database name required")
+		}
+
+		bucket := db
+		if rp != "" {
+			bucket += "/" + rp
+		}
+		if t.bucket == "" {
+			t.bucket = bucket
+		} else if t.bucket != bucket {
+			return errors.New("measurements of several databases or retention policies are not supported")
+		}
+
+		if m.Regex != nil {
+			filters = append(filters, "r._measurement =~ "+formatRegex(m.Regex.Val))
+		} else {
+			filters = append(filters, "r._measurement == "+formatString(m.Name))
+		}
+	}
+
+	if len(filters) == 0 {
+		return errors.New("no measurement")
+	} else if len(filters) == 1 {
+		t.measurements = filters[0]
+	} else {
+		t.measurements = "(" + strings.Join(filters, " or ") + ")"
+	}
+	return nil
+}
+
+// mapCondition splits the time range from the condition of the statement and
+// sets the filter of the rest.
+func (t *transpiler) mapCondition() error {
+	if t.stmt.Condition == nil {
+		return nil
+	}
+
+	cond, tr, err := influxql.ConditionExpr(t.stmt.Condition, &influxql.NowValuer{Now: t.config.Now, Location: t.stmt.Location})
+	if err != nil {
+		return err
+	}
+	t.timeRange = tr
+	if cond != nil {
+		if t.cond, err = formatExpr(cond); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapDimensions sets the window interval and the group key of the statement.
+func (t *transpiler) mapDimensions() error {
+	interval, err := t.stmt.GroupByInterval()
+	if err != nil {
+		return err
+	}
+	if offset, err := t.stmt.GroupByOffset(); err != nil {
+		return err
+	} else if offset != 0 {
+		return errors.New("GROUP BY time() offsets are not supported")
+	}
+	t.interval = interval
+
+	for _, d := range t.stmt.Dimensions {
+		switch expr := d.Expr.(type) {
+		case *influxql.Call:
+			// The time() call was read by GroupByInterval.
+		case *influxql.VarRef:
+			t.groupBy = append(t.groupBy, expr.Val)
+		case *influxql.Wildcard:
+			t.groupByAll = true
+		default:
+			return fmt.Errorf("unsupported dimension: %s", d)
+		}
+	}
+	return nil
+}
+
+// pipeline returns the Flux pipeline that reads the field expr and names its
+// result name.
+func (t *transpiler) pipeline(expr influxql.Expr, name string) (string, error) {
+	var field, fn string
+	switch expr := expr.(type) {
+	case *influxql.Wildcard:
+	case *influxql.VarRef:
+		field = expr.Val
+	case *influxql.Call:
+		ref, ok := firstArg(expr)
+		if !ok {
+			return "", fmt.Errorf("unsupported function call: %s", expr)
+		}
+		field = ref.Val
+
+		if expr.Name == "percentile" {
+			if len(expr.Args) != 2 {
+				return "", fmt.Errorf("invalid number of arguments for percentile, expected 2, got %d", len(expr.Args))
+			}
+			var p float64
+			switch arg := expr.Args[1].(type) {
+			case *influxql.IntegerLiteral:
+				p = float64(arg.Val)
+			case *influxql.NumberLiteral:
+				p = arg.Val
+			default:
+				return "", fmt.Errorf("expected float argument in percentile()")
+			}
+			fn = fmt.Sprintf(`percentile(percentile: %s, method: "exact_selector")`, formatFloat(p/100))
+		} else if f, ok := aggregates[expr.Name]; ok && len(expr.Args) == 1 {
+			fn = f
+		} else {
+			return "", fmt.Errorf("unsupported function call: %s", expr)
+		}
+	default:
+		return "", fmt.Errorf("unsupported field: %s", expr)
+	}
+	if fn == "" && t.interval > 0 {
+		return "", errors.New("GROUP BY time() requires an aggregate function")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "from(bucket: %s)\n", formatString(t.bucket))
+	fmt.Fprintf(&b, "\t|> range(%s)\n", t.formatRange())
+
+	filter := t.measurements
+	if field != "" {
+		filter += " and r._field == " + formatString(field)
+	}
+	if t.cond != "" {
+		filter += " and (" + t.cond + ")"
+	}
+	fmt.Fprintf(&b, "\t|> filter(fn: (r) => %s)\n", filter)
+
+	// Series are merged unless they are grouped by all of their tags, which
+	// is the group key they are read with.
+	if !t.groupByAll {
+		columns := append([]string{"_measurement", "_field"}, t.groupBy...)
+		for i := range columns {
+			columns[i] = formatString(columns[i])
+		}
+		fmt.Fprintf(&b, "\t|> group(columns: [%s])\n", strings.Join(columns, ", "))
+	}
+
+	switch {
+	case fn == "":
+		fmt.Fprintf(&b, "\t|> sort(columns: [\"_time\"]%s)\n", t.formatDesc())
+	case t.interval > 0:
+		// The function is wrapped as selectors do not take the columns
+		// aggregateWindow() passes.
+		fmt.Fprintf(&b, "\t|> aggregateWindow(every: %s, fn: (columns, tables=<-) => tables |> %s, timeSrc: \"_start\")\n",
+			formatDuration(t.interval), fn)
+		if t.stmt.Limit > 0 || t.stmt.Offset > 0 || !t.stmt.TimeAscending() {
+			fmt.Fprintf(&b, "\t|> sort(columns: [\"_time\"]%s)\n", t.formatDesc())
+		}
+	default:
+		fmt.Fprintf(&b, "\t|> %s\n", fn)
+	}
+
+	if t.stmt.Limit > 0 || t.stmt.Offset > 0 {
+		n := int64(t.stmt.Limit)
+		if n == 0 {
+			n = math.MaxInt64
+		}
+		if t.stmt.Offset > 0 {
+			fmt.Fprintf(&b, "\t|> limit(n: %d, offset: %d)\n", n, t.stmt.Offset)
+		} else {
+			fmt.Fprintf(&b, "\t|> limit(n: %d)\n", n)
+		}
+	}
+	fmt.Fprintf(&b, "\t|> yield(name: %s)", formatString(name))
+	return b.String(), nil
+}
+
+// formatRange returns the arguments of the range() call of the time range of
+// the statement. InfluxQL time ranges include their end, Flux ones do not.
+func (t *transpiler) formatRange() string {
+	start := time.Unix(0, 0).UTC()
+	if !t.timeRange.Min.IsZero() {
+		start = t.timeRange.Min.UTC()
+	}
+	args := "start: " + start.Format(time.RFC3339Nano)
+	if !t.timeRange.Max.IsZero() {
+		args += ", stop: " + t.timeRange.Max.Add(time.Nanosecond).UTC().Format(time.RFC3339Nano)
+	}
+	return args
+}
+
+func (t *transpiler) formatDesc() string {
+	if t.stmt.TimeAscending() {
+		return ""
+	}
+	return ", desc: true"
+}
+
+// firstArg returns the field read by call.
+func firstArg(call *influxql.Call) (*influxql.VarRef, bool) {
+	if len(call.Args) == 0 {
+		return nil, false
+	}
+	ref, ok := call.Args[0].(*influxql.VarRef)
+	return ref, ok
+}
+
+// operators maps the InfluxQL operators to the Flux ones.
+var operators = map[influxql.Token]string{
+	influxql.AND:      "and",
+	influxql.OR:       "or",
+	influxql.EQ:       "==",
+	influxql.NEQ:      "!=",
+	influxql.LT:       "<",
+	influxql.LTE:      "<=",
+	influxql.GT:       ">",
+	influxql.GTE:      ">=",
+	influxql.EQREGEX:  "=~",
+	influxql.NEQREGEX: "!~",
+	influxql.ADD:      "+",
+	influxql.SUB:      "-",
+	influxql.MUL:      "*",
+	influxql.DIV:      "/",
+}
+
+// formatExpr returns the Flux expression of a condition with the row as r.
+func formatExpr(expr influxql.Expr) (string, error) {
+	switch expr := expr.(type) {
+	case *influxql.BinaryExpr:
+		op, ok := operators[expr.Op]
+		if !ok {
+			return "", fmt.Errorf("unsupported operator: %s", expr.Op)
+		}
+		lhs, err := formatExpr(expr.LHS)
+		if err != nil {
+			return "", err
+		}
+		rhs, err := formatExpr(expr.RHS)
+		if err != nil {
+			return "", err
+		}
+		return lhs + " " + op + " " + rhs, nil
+	case *influxql.ParenExpr:
+		s, err := formatExpr(expr.Expr)
+		if err != nil {
+			return "", err
+		}
+		return "(" + s + ")", nil
+	case *influxql.VarRef:
+		return formatColumn(expr.Val), nil
+	case *influxql.StringLiteral:
+		return formatString(expr.Val), nil
+	case *influxql.IntegerLiteral:
+		return strconv.FormatInt(expr.Val, 10), nil
+	case *influxql.UnsignedLiteral:
+		return strconv.FormatUint(expr.Val, 10), nil
+	case *influxql.NumberLiteral:
+		return formatFloat(expr.Val), nil
+	case *influxql.BooleanLiteral:
+		return strconv.FormatBool(expr.Val), nil
+	case *influxql.RegexLiteral:
+		return formatRegex(expr.Val), nil
+	}
+	return "", fmt.Errorf("unsupported expression: %s", expr)
+}
+
+var identRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// keywords are the Flux keywords that cannot be used as identifiers.
+var keywords = map[string]bool{
+	"and": true, "or": true, "not": true, "empty": true, "in": true,
+	"import": true, "package": true, "return": true, "option": true, "builtin": true,
+}
+
+// formatColumn returns the Flux expression of the column name of r.
+func formatColumn(name string) string {
+	if identRegex.MatchString(name) && !keywords[name] {
+		return "r." + name
+	}
+	return "r[" + formatString(name) + "]"
+}
+
+// formatString returns s as a Flux string literal.
+func formatString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// formatFloat returns f as a Flux float literal.
+func formatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+// formatRegex returns re as a Flux regex literal.
+func formatRegex(re *regexp.Regexp) string {
+	return "/" + strings.Replace(re.String(), "/", `\/`, -1) + "/"
+}
+
+// durationUnits are the Flux duration units, largest first.
+var durationUnits = []struct {
+	unit string
+	d    time.Duration
+}{
+	{"w", 7 * 24 * time.Hour},
+	{"d", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+	{"ms", time.Millisecond},
+	{"us", time.Microsecond},
+	{"ns", time.Nanosecond},
+}
+
+// formatDuration returns d as a Flux duration literal in the largest unit
+// it is a multiple of.
+func formatDuration(d time.Duration) string {
+	for _, u := range durationUnits {
+		if d%u.d == 0 {
+			return strconv.FormatInt(int64(d/u.d), 10) + u.unit
+		}
+	}
+	return strconv.FormatInt(int64(d), 10) + "ns"
+}
@@ -0,0 +1,124 @@
+package influxql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	fluxinfluxql "github.com/freetsdb/freetsdb/flux/influxql"
+	"github.com/freetsdb/freetsdb/services/flux"
+	_ "github.com/freetsdb/freetsdb/services/flux/functions/inputs"
+	_ "github.com/freetsdb/freetsdb/services/flux/functions/transformations"
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+func init() {
+	flux.FinalizeBuiltIns()
+}
+
+func TestTranspile(t *testing.T) {
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name string
+		s    string
+		flux string
+	}{
+		{
+			name: "raw field",
+			s:    `SELECT value FROM cpu WHERE host = 'server01' AND time >= now() - 1h`,
+			flux: `from(bucket: "db0/autogen")
+	|> range(start: 2018-12-31T23:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value" and (r.host == "server01"))
+	|> group(columns: ["_measurement", "_field"])
+	|> sort(columns: ["_time"])
+	|> yield(name: "value")
+`,
+		},
+		{
+			name: "aggregate window",
+			s:    `SELECT mean(usage) AS avg, max(usage) FROM db1..cpu WHERE time >= '2019-01-01T00:00:00Z' AND time < '2019-01-02T00:00:00Z' GROUP BY time(1h), "region-id" ORDER BY time DESC LIMIT 3`,
+			flux: `from(bucket: "db1")
+	|> range(start: 2019-01-01T00:00:00Z, stop: 2019-01-02T00:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage")
+	|> group(columns: ["_measurement", "_field", "region-id"])
+	|> aggregateWindow(every: 1h, fn: (columns, tables=<-) => tables |> mean(), timeSrc: "_start")
+	|> sort(columns: ["_time"], desc: true)
+	|> limit(n: 3)
+	|> yield(name: "avg")
+
+from(bucket: "db1")
+	|> range(start: 2019-01-01T00:00:00Z, stop: 2019-01-02T00:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage")
+	|> group(columns: ["_measurement", "_field", "region-id"])
+	|> aggregateWindow(every: 1h, fn: (columns, tables=<-) => tables |> max(), timeSrc: "_start")
+	|> sort(columns: ["_time"], desc: true)
+	|> limit(n: 3)
+	|> yield(name: "max")
+`,
+		},
+		{
+			name: "wildcard",
+			s:    `SELECT * FROM /^disk/ WHERE "path" =~ /\/var/ OR "host" != 'a' GROUP BY *`,
+			flux: `from(bucket: "db0/autogen")
+	|> range(start: 1970-01-01T00:00:00Z)
+	|> filter(fn: (r) => r._measurement =~ /^disk/ and (r.path =~ /\/var/ or r.host != "a"))
+	|> sort(columns: ["_time"])
+	|> yield(name: "*")
+`,
+		},
+		{
+			name: "percentile",
+			s:    `SELECT percentile(value, 95) FROM cpu, mem`,
+			flux: `from(bucket: "db0/autogen")
+	|> range(start: 1970-01-01T00:00:00Z)
+	|> filter(fn: (r) => (r._measurement == "cpu" or r._measurement == "mem") and r._field == "value")
+	|> group(columns: ["_measurement", "_field"])
+	|> percentile(percentile: 0.95, method: "exact_selector")
+	|> yield(name: "percentile")
+`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := influxql.ParseStatement(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			script, err := fluxinfluxql.Transpile(stmt, fluxinfluxql.Config{
+				DefaultDatabase:        "db0",
+				DefaultRetentionPolicy: "autogen",
+				Now:                    now,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := script.String(); got != tt.flux {
+				t.Fatalf("unexpected flux:\ngot:\n%s\nwant:\n%s", got, tt.flux)
+			}
+
+			// The script must be valid Flux.
+			if _, err := flux.Compile(context.Background(), script.String(), now); err != nil {
+				t.Fatalf("unable to compile flux: %s", err)
+			}
+		})
+	}
+}
+
+func TestTranspile_Unsupported(t *testing.T) {
+	for _, s := range []string{
+		`SHOW DATABASES`,
+		`SELECT value INTO cpu2 FROM cpu`,
+		`SELECT value FROM (SELECT value FROM cpu)`,
+		`SELECT value * 2 FROM cpu`,
+		`SELECT derivative(value) FROM cpu`,
+		`SELECT mean(value) FROM cpu GROUP BY time(1m) fill(0)`,
+		`SELECT value FROM db0..cpu, db1..cpu`,
+	} {
+		stmt, err := influxql.ParseStatement(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fluxinfluxql.Transpile(stmt, fluxinfluxql.Config{DefaultDatabase: "db0"}); err == nil {
+			t.Errorf("expected error for %s", s)
+		}
+	}
+}
@@ -0,0 +1,423 @@
+// Package lsp implements the Language Server Protocol for Flux scripts, so
+// editors can complete the buckets, measurements, tags and fields of the live
+// schema and the built-in functions, show the signatures of functions and
+// report the errors of a script as it is written.
+package lsp // import "github.com/freetsdb/freetsdb/flux/lsp"
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/freetsdb/freetsdb/services/flux"
+	"github.com/freetsdb/freetsdb/services/flux/semantic"
+	"github.com/freetsdb/freetsdb/services/flux/values"
+)
+
+// Conn is a stream of messages between the server and a client. Each message
+// is a JSON-RPC 2.0 request, response or notification.
+type Conn interface {
+	ReadMessage() ([]byte, error)
+	WriteMessage([]byte) error
+}
+
+// Schema returns the schema completed in scripts. A bucket is a database,
+// optionally followed by a slash and a retention policy.
+type Schema interface {
+	Buckets() ([]string, error)
+	Measurements(bucket string) ([]string, error)
+	TagKeys(bucket, measurement string) ([]string, error)
+	TagValues(bucket, measurement, key string) ([]string, error)
+	FieldKeys(bucket, measurement string) ([]string, error)
+}
+
+// keywords are the Flux keywords completed with the built-in functions.
+var keywords = []string{"and", "or", "not", "import", "option", "return", "exists"}
+
+// columns are the columns of the rows read by from().
+var columns = []string{"_measurement", "_field", "_value", "_time", "_start", "_stop"}
+
+// Server serves the Language Server Protocol for Flux scripts.
+type Server struct {
+	Schema Schema
+
+	// Now returns the time now() is evaluated to when scripts are checked.
+	Now func() time.Time
+}
+
+// NewServer returns a Server completing schema.
+func NewServer(schema Schema) *Server {
+	return &Server{Schema: schema, Now: time.Now}
+}
+
+// session is the state of a client connection.
+type session struct {
+	server *Server
+	conn   Conn
+	docs   map[string]string
+}
+
+// Serve serves the requests of a client on conn until the client exits, ctx
+// is done or conn fails.
+func (s *Server) Serve(ctx context.Context, conn Conn) error {
+	ss := &session{server: s, conn: conn, docs: make(map[string]string)}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		b, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal(b, &msg); err != nil {
+			if err := ss.reply(nil, nil, &rpcError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		result, rerr := ss.handle(ctx, &msg)
+		if msg.ID == nil {
+			// Notifications are not answered.
+			continue
+		}
+		if err := ss.reply(msg.ID, result, rerr); err != nil {
+			return err
+		}
+	}
+}
+
+// handle handles msg and returns the result of a request.
+func (ss *session) handle(ctx context.Context, msg *message) (interface{}, *rpcError) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": 1, // The whole document is sent on changes.
+				"completionProvider": map[string]interface{}{
+					"triggerCharacters": []string{".", `"`, "["},
+				},
+				"hoverProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "freetsdb-flux"},
+		}, nil
+	case "initialized", "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		ss.docs[params.TextDocument.URI] = params.TextDocument.Text
+		return nil, ss.publishDiagnostics(ctx, params.TextDocument.URI)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			ss.docs[params.TextDocument.URI] = params.ContentChanges[n-1].Text
+		}
+		return nil, ss.publishDiagnostics(ctx, params.TextDocument.URI)
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		delete(ss.docs, params.TextDocument.URI)
+		return nil, nil
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		items, err := ss.server.Complete(ss.docs[params.TextDocument.URI], params.Position)
+		if err != nil {
+			return nil, &rpcError{Code: codeInternalError, Message: err.Error()}
+		}
+		return completionList{Items: items}, nil
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return ss.server.hover(ss.docs[params.TextDocument.URI], params.Position), nil
+	}
+
+	if msg.ID == nil || strings.HasPrefix(msg.Method, "$/") {
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+}
+
+func invalidParams(err error) *rpcError {
+	return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+}
+
+// reply answers the request with id.
+func (ss *session) reply(id *json.RawMessage, result interface{}, rerr *rpcError) error {
+	resp := response{JSONRPC: "2.0", ID: id, Error: rerr}
+	if rerr == nil {
+		b, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		raw := json.RawMessage(b)
+		resp.Result = &raw
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return ss.conn.WriteMessage(b)
+}
+
+// publishDiagnostics sends the problems of the document at uri to the client.
+func (ss *session) publishDiagnostics(ctx context.Context, uri string) *rpcError {
+	diagnostics := ss.server.Check(ctx, ss.docs[uri])
+	b, err := json.Marshal(notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics},
+	})
+	if err == nil {
+		err = ss.conn.WriteMessage(b)
+	}
+	if err != nil {
+		return &rpcError{Code: codeInternalError, Message: err.Error()}
+	}
+	return nil
+}
+
+// locationRegex matches the source locations in the errors of the compiler.
+var locationRegex = regexp.MustCompile(`(\d+):(\d+)-(\d+):(\d+)`)
+
+// Check compiles text and returns its errors. The compiler stops at the first
+// error, so at most one diagnostic is returned.
+func (s *Server) Check(ctx context.Context, text string) []Diagnostic {
+	now := time.Now()
+	if s.Now != nil {
+		now = s.Now()
+	}
+	if strings.TrimSpace(text) == "" {
+		return []Diagnostic{}
+	}
+	if _, err := flux.Compile(ctx, text, now); err != nil {
+		d := Diagnostic{Severity: severityError, Source: "flux", Message: err.Error()}
+		if m := locationRegex.FindStringSubmatch(err.Error()); m != nil {
+			// Compiler locations are one-based.
+			atoi := func(s string) int {
+				n, _ := strconv.Atoi(s)
+				if n > 0 {
+					n--
+				}
+				return n
+			}
+			d.Range = Range{
+				Start: Position{Line: atoi(m[1]), Character: atoi(m[2])},
+				End:   Position{Line: atoi(m[3]), Character: atoi(m[4])},
+			}
+		}
+		return []Diagnostic{d}
+	}
+	return []Diagnostic{}
+}
+
+var (
+	bucketRegex           = regexp.MustCompile(`bucket\s*:\s*"([^"]*)$`)
+	measurementValueRegex = regexp.MustCompile(`r\._measurement\s*==\s*"([^"]*)$`)
+	fieldValueRegex       = regexp.MustCompile(`r\._field\s*==\s*"([^"]*)$`)
+	tagValueRegex         = regexp.MustCompile(`r(?:\.([A-Za-z_][A-Za-z0-9_]*)|\["([^"]+)"\])\s*(?:==|!=)\s*"([^"]*)$`)
+	memberRegex           = regexp.MustCompile(`r(?:\.[A-Za-z0-9_]*|\["[^"]*)$`)
+	identRegex            = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*$`)
+
+	docBucketRegex      = regexp.MustCompile(`from\s*\(\s*bucket\s*:\s*"([^"]+)"`)
+	docMeasurementRegex = regexp.MustCompile(`r\._measurement\s*==\s*"([^"]+)"`)
+)
+
+// Complete returns the completions at pos in text. The measurements, tags and
+// fields completed are those of the bucket read by the first from() of the
+// script, and of the first measurement it filters.
+func (s *Server) Complete(text string, pos Position) ([]CompletionItem, error) {
+	before := linePrefix(text, pos)
+
+	var bucket, measurement string
+	if m := docBucketRegex.FindStringSubmatch(text); m != nil {
+		bucket = m[1]
+	}
+	if m := docMeasurementRegex.FindStringSubmatch(text); m != nil {
+		measurement = m[1]
+	}
+
+	switch {
+	case bucketRegex.MatchString(before):
+		buckets, err := s.Schema.Buckets()
+		return items(buckets, completionKindModule, "bucket"), err
+	case bucket == "":
+	case measurementValueRegex.MatchString(before):
+		measurements, err := s.Schema.Measurements(bucket)
+		return items(measurements, completionKindValue, "measurement"), err
+	case fieldValueRegex.MatchString(before):
+		fields, err := s.Schema.FieldKeys(bucket, measurement)
+		return items(fields, completionKindValue, "field"), err
+	case tagValueRegex.MatchString(before):
+		m := tagValueRegex.FindStringSubmatch(before)
+		key := m[1]
+		if key == "" {
+			key = m[2]
+		}
+		if strings.HasPrefix(key, "_") {
+			return []CompletionItem{}, nil
+		}
+		values, err := s.Schema.TagValues(bucket, measurement, key)
+		return items(values, completionKindValue, "tag value"), err
+	case memberRegex.MatchString(before):
+		keys, err := s.Schema.TagKeys(bucket, measurement)
+		if err != nil {
+			return nil, err
+		}
+		return append(items(columns, completionKindField, "column"), items(keys, completionKindField, "tag")...), nil
+	}
+
+	// Otherwise complete the identifier being written.
+	prefix := identRegex.FindString(before)
+	var a []CompletionItem
+	builtins := flux.BuiltIns()
+	for _, name := range sortedNames(builtins) {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		kind := completionKindVariable
+		if builtins[name].PolyType().Nature() == semantic.Function {
+			kind = completionKindFunction
+		}
+		a = append(a, CompletionItem{Label: name, Kind: kind, Detail: signature(name)})
+	}
+	for _, k := range keywords {
+		if strings.HasPrefix(k, prefix) {
+			a = append(a, CompletionItem{Label: k, Kind: completionKindKeyword})
+		}
+	}
+	if a == nil {
+		a = []CompletionItem{}
+	}
+	return a, nil
+}
+
+// hover returns the signature of the built-in at pos in text, or nil.
+func (s *Server) hover(text string, pos Position) *hover {
+	line := lineAt(text, pos.Line)
+	offset := byteOffset(line, pos.Character)
+
+	start, end := offset, offset
+	for start > 0 && isIdentByte(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isIdentByte(line[end]) {
+		end++
+	}
+	name := line[start:end]
+	if name == "" {
+		return nil
+	}
+	sig := signature(name)
+	if sig == "" {
+		return nil
+	}
+	return &hover{
+		Contents: markupContent{Kind: "markdown", Value: "```flux\n" + name + ": " + sig + "\n```"},
+		Range: &Range{
+			Start: Position{Line: pos.Line, Character: utf16Len(line[:start])},
+			End:   Position{Line: pos.Line, Character: utf16Len(line[:end])},
+		},
+	}
+}
+
+// signature returns the type of the built-in name, or an empty string if
+// there is none.
+func signature(name string) string {
+	v, ok := flux.BuiltIns()[name]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprint(v.PolyType())
+}
+
+func items(labels []string, kind int, detail string) []CompletionItem {
+	a := make([]CompletionItem, 0, len(labels))
+	for _, l := range labels {
+		a = append(a, CompletionItem{Label: l, Kind: kind, Detail: detail})
+	}
+	return a
+}
+
+func sortedNames(m map[string]values.Value) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// lineAt returns line n of text.
+func lineAt(text string, n int) string {
+	lines := strings.Split(text, "\n")
+	if n < 0 || n >= len(lines) {
+		return ""
+	}
+	return strings.TrimSuffix(lines[n], "\r")
+}
+
+// linePrefix returns the text of the line of pos before pos.
+func linePrefix(text string, pos Position) string {
+	line := lineAt(text, pos.Line)
+	return line[:byteOffset(line, pos.Character)]
+}
+
+// byteOffset returns the byte offset of the UTF-16 offset character in line.
+func byteOffset(line string, character int) int {
+	n := 0
+	for i, r := range line {
+		if n >= character {
+			return i
+		}
+		n += runeLen16(r)
+	}
+	return len(line)
+}
+
+// utf16Len returns the length of s in UTF-16 code units.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeLen16(r)
+	}
+	return n
+}
+
+// runeLen16 returns the number of UTF-16 code units of r.
+func runeLen16(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,145 @@
+package lsp_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/flux/lsp"
+	"github.com/freetsdb/freetsdb/services/flux"
+	_ "github.com/freetsdb/freetsdb/services/flux/functions/inputs"
+	_ "github.com/freetsdb/freetsdb/services/flux/functions/transformations"
+)
+
+func init() {
+	flux.FinalizeBuiltIns()
+}
+
+// schema is a fixed schema.
+type schema struct{}
+
+func (schema) Buckets() ([]string, error) { return []string{"db0", "db0/autogen"}, nil }
+func (schema) Measurements(bucket string) ([]string, error) {
+	return []string{"cpu", "mem"}, nil
+}
+func (schema) TagKeys(bucket, measurement string) ([]string, error) {
+	if measurement != "cpu" {
+		return nil, nil
+	}
+	return []string{"host", "region"}, nil
+}
+func (schema) TagValues(bucket, measurement, key string) ([]string, error) {
+	return []string{key + "-a", key + "-b"}, nil
+}
+func (schema) FieldKeys(bucket, measurement string) ([]string, error) {
+	return []string{"usage"}, nil
+}
+
+func labels(items []lsp.CompletionItem) []string {
+	a := make([]string, 0, len(items))
+	for _, item := range items {
+		a = append(a, item.Label)
+	}
+	return a
+}
+
+func TestServer_Complete(t *testing.T) {
+	s := lsp.NewServer(schema{})
+	doc := "from(bucket: \"db0\")\n\t|> filter(fn: (r) => r._measurement == \"cpu\" and r.host == \"\")\n\t|> me"
+
+	for _, tt := range []struct {
+		name string
+		text string
+		pos  lsp.Position
+		exp  []string
+	}{
+		{name: "bucket", text: `from(bucket: "`, pos: lsp.Position{Character: 14}, exp: []string{"db0", "db0/autogen"}},
+		{name: "measurement", text: doc, pos: lsp.Position{Line: 1, Character: 41}, exp: []string{"cpu", "mem"}},
+		{name: "tag key", text: doc, pos: lsp.Position{Line: 1, Character: 52}, exp: []string{"_measurement", "_field", "_value", "_time", "_start", "_stop", "host", "region"}},
+		{name: "tag value", text: doc, pos: lsp.Position{Line: 1, Character: 61}, exp: []string{"host-a", "host-b"}},
+		{name: "function", text: doc, pos: lsp.Position{Line: 2, Character: 6}, exp: []string{"mean", "median"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := s.Complete(tt.text, tt.pos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := labels(items); !reflect.DeepEqual(got, tt.exp) {
+				t.Fatalf("unexpected completions: %v", got)
+			}
+		})
+	}
+}
+
+func TestServer_Check(t *testing.T) {
+	s := lsp.NewServer(schema{})
+	if d := s.Check(context.Background(), `from(bucket: "db0") |> range(start: -1h)`); len(d) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", d)
+	}
+	if d := s.Check(context.Background(), `from(bucket: "db0") |> nosuchfunction()`); len(d) != 1 {
+		t.Fatalf("expected a diagnostic, got %v", d)
+	}
+}
+
+// conn is a Conn reading requests from a slice and recording what is written.
+type conn struct {
+	in  []string
+	out []map[string]interface{}
+}
+
+func (c *conn) ReadMessage() ([]byte, error) {
+	if len(c.in) == 0 {
+		return nil, io.EOF
+	}
+	b := c.in[0]
+	c.in = c.in[1:]
+	return []byte(b), nil
+}
+
+func (c *conn) WriteMessage(b []byte) error {
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	c.out = append(c.out, m)
+	return nil
+}
+
+func TestServer_Serve(t *testing.T) {
+	c := &conn{in: []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///a.flux","version":1,"text":"from(bucket: \"db0\") |> nosuch()"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///a.flux"},"position":{"line":0,"character":2}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"textDocument/definition","params":{}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	}}
+	if err := lsp.NewServer(schema{}).Serve(context.Background(), c); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.out) != 5 {
+		t.Fatalf("unexpected messages: %v", c.out)
+	}
+	if caps := c.out[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{}); caps["hoverProvider"] != true {
+		t.Fatalf("unexpected capabilities: %v", caps)
+	}
+	if m := c.out[1]; m["method"] != "textDocument/publishDiagnostics" {
+		t.Fatalf("expected diagnostics, got %v", m)
+	} else if d := m["params"].(map[string]interface{})["diagnostics"].([]interface{}); len(d) != 1 {
+		t.Fatalf("unexpected diagnostics: %v", d)
+	}
+	if h := c.out[2]["result"].(map[string]interface{})["contents"].(map[string]interface{}); !strings.Contains(h["value"].(string), "from: ") {
+		t.Fatalf("unexpected hover: %v", h)
+	}
+	if e := c.out[3]["error"].(map[string]interface{}); e["code"] != float64(-32601) {
+		t.Fatalf("unexpected error: %v", e)
+	}
+	if m := c.out[4]; m["id"] != float64(4) || m["result"] != nil {
+		t.Fatalf("unexpected shutdown response: %v", m)
+	}
+}
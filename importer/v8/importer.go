@@ -3,11 +3,16 @@ package v8 // import "github.com/freetsdb/freetsdb/importer/v8"
 import (
 	"bufio"
 	"compress/gzip"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/freetsdb/freetsdb/client"
@@ -15,12 +20,25 @@ import (
 
 const batchSize = 5000
 
+const (
+	// progressInterval is how often the import reports its progress.
+	progressInterval = 10 * time.Second
+
+	// checkpointInterval is how often the import checkpoints its progress.
+	checkpointInterval = time.Second
+)
+
 // Config is the config used to initialize a Importer importer
 type Config struct {
 	Path       string // Path to import data.
 	Version    string
 	Compressed bool // Whether import data is gzipped.
 	PPS        int  // points per second importer imports with.
+	Workers    int  // number of batches written concurrently.
+
+	// Checkpoint is the file the import progress is written to. An import
+	// with the same checkpoint file resumes where the previous one stopped.
+	Checkpoint string
 
 	client.Config
 }
@@ -37,14 +55,36 @@ type Importer struct {
 	retentionPolicy       string
 	config                Config
 	batch                 []string
-	totalInserts          int
-	failedInserts         int
 	totalCommands         int
 	throttlePointsWritten int
 	startTime             time.Time
 	lastWrite             time.Time
+	lastProgress          time.Time
 	throttle              *time.Ticker
 
+	// offset is the offset in the import data of the line last read, and
+	// resume is the checkpoint the import resumes from.
+	offset int64
+	resume *checkpoint
+
+	// read counts the bytes read from the import file, and size is its size.
+	read      *countingReader
+	readStart int64
+	size      int64
+
+	batches chan *batch
+	wg      sync.WaitGroup
+
+	mu             sync.Mutex
+	seq            int
+	pending        map[int]*batch // batches written out of order
+	next           int            // sequence of the next batch to checkpoint
+	stalled        bool           // a batch failed, so the checkpoint stays before it
+	checkpoint     checkpoint
+	lastCheckpoint time.Time
+	totalInserts   int
+	failedInserts  int
+
 	stderrLogger *log.Logger
 	stdoutLogger *log.Logger
 }
@@ -55,6 +95,7 @@ func NewImporter(config Config) *Importer {
 	return &Importer{
 		config:       config,
 		batch:        make([]string, 0, batchSize),
+		pending:      make(map[int]*batch),
 		stdoutLogger: log.New(os.Stdout, "", log.LstdFlags),
 		stderrLogger: log.New(os.Stderr, "", log.LstdFlags),
 	}
@@ -85,6 +126,18 @@ func (i *Importer) Import() error {
 		}
 	}()
 
+	// Load the checkpoint of a previous import.
+	if i.config.Checkpoint != "" {
+		c, err := readCheckpoint(i.config.Checkpoint)
+		if err != nil {
+			return err
+		}
+		i.resume = c
+		if c != nil {
+			i.checkpoint = *c
+		}
+	}
+
 	// Open the file
 	f, err := os.Open(i.config.Path)
 	if err != nil {
@@ -92,11 +145,16 @@ func (i *Importer) Import() error {
 	}
 	defer f.Close()
 
+	if fi, err := f.Stat(); err == nil {
+		i.size = fi.Size()
+	}
+	i.read = &countingReader{r: f}
+
 	var r io.Reader
 
 	// If gzipped, wrap in a gzip reader
 	if i.config.Compressed {
-		gr, err := gzip.NewReader(f)
+		gr, err := gzip.NewReader(i.read)
 		if err != nil {
 			return err
 		}
@@ -105,7 +163,7 @@ func (i *Importer) Import() error {
 		r = gr
 	} else {
 		// Standard text file so our reader can just be the file
-		r = f
+		r = i.read
 	}
 
 	// Get our reader
@@ -124,8 +182,51 @@ func (i *Importer) Import() error {
 	// Prime the last write
 	i.lastWrite = time.Now()
 
+	// Skip the data written by a previous import. A file that isn't
+	// compressed can seek past it.
+	if i.resume != nil {
+		i.database, i.retentionPolicy = i.resume.Database, i.resume.RetentionPolicy
+		if !i.config.Compressed && i.resume.Offset > i.offset {
+			if _, err := f.Seek(i.resume.Offset, io.SeekStart); err != nil {
+				return err
+			}
+			scanner.Reset(i.read)
+			atomic.StoreInt64(&i.read.n, i.resume.Offset)
+			i.offset = i.resume.Offset
+		}
+		i.stdoutLogger.Printf("Resuming import from offset %d\n", i.resume.Offset)
+	}
+
+	// Start the workers writing the batches.
+	workers := i.config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	i.batches = make(chan *batch, workers)
+	for n := 0; n < workers; n++ {
+		i.wg.Add(1)
+		go i.writeBatches()
+	}
+
 	// Process the DML
-	if err := i.processDML(scanner); err != nil {
+	err = i.processDML(scanner)
+	close(i.batches)
+	i.wg.Wait()
+	i.reportProgress()
+
+	// An import that stopped early or failed to write a batch is resumed
+	// from its checkpoint, which is before the first failed batch. Otherwise
+	// the import is complete, so it can't be resumed.
+	if i.config.Checkpoint != "" {
+		if err != nil || i.failedInserts > 0 {
+			if err := writeCheckpoint(i.config.Checkpoint, &i.checkpoint); err != nil {
+				i.stderrLogger.Printf("error writing checkpoint: %s\n", err)
+			}
+		} else if err := os.Remove(i.config.Checkpoint); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err != nil {
 		return fmt.Errorf("reading standard input: %s", err)
 	}
 
@@ -151,6 +252,7 @@ func (i *Importer) processDDL(scanner *bufio.Reader) error {
 		} else if err == io.EOF {
 			return nil
 		}
+		i.offset += int64(len(line))
 		// If we find the DML token, we are done with DDL
 		if strings.HasPrefix(line, "# DML") {
 			return nil
@@ -168,6 +270,8 @@ func (i *Importer) processDDL(scanner *bufio.Reader) error {
 
 func (i *Importer) processDML(scanner *bufio.Reader) error {
 	i.startTime = time.Now()
+	i.lastProgress = i.startTime
+	i.readStart = i.read.Count()
 	for {
 		line, err := scanner.ReadString(byte('\n'))
 		if err != nil && err != io.EOF {
@@ -177,12 +281,20 @@ func (i *Importer) processDML(scanner *bufio.Reader) error {
 			i.batchWrite()
 			return nil
 		}
-		if strings.HasPrefix(line, "# CONTEXT-DATABASE:") {
+		// The batch ends before a context line, so that an import resumed
+		// after the batch reads the new context.
+		if strings.HasPrefix(line, "# CONTEXT-DATABASE:") || strings.HasPrefix(line, "# CONTEXT-RETENTION-POLICY:") {
 			i.batchWrite()
+		}
+		i.offset += int64(len(line))
+		// Skip the lines written by the import being resumed.
+		if i.resume != nil && i.offset <= i.resume.Offset {
+			continue
+		}
+		if strings.HasPrefix(line, "# CONTEXT-DATABASE:") {
 			i.database = strings.TrimSpace(strings.Split(line, ":")[1])
 		}
 		if strings.HasPrefix(line, "# CONTEXT-RETENTION-POLICY:") {
-			i.batchWrite()
 			i.retentionPolicy = strings.TrimSpace(strings.Split(line, ":")[1])
 		}
 		if strings.HasPrefix(line, "#") {
@@ -250,24 +362,180 @@ func (i *Importer) batchWrite() {
 		return
 	}
 
-	_, e := i.client.WriteLineProtocol(strings.Join(i.batch, "\n"), i.database, i.retentionPolicy, i.config.Precision, i.config.WriteConsistency)
-	if e != nil {
-		i.stderrLogger.Println("error writing batch: ", e)
-		i.stderrLogger.Println(strings.Join(i.batch, "\n"))
-		i.failedInserts += len(i.batch)
-	} else {
-		i.totalInserts += len(i.batch)
+	i.batches <- &batch{
+		seq:             i.seq,
+		lines:           i.batch,
+		database:        i.database,
+		retentionPolicy: i.retentionPolicy,
+		offset:          i.offset,
 	}
+	i.seq++
 	i.throttlePointsWritten = 0
 	i.lastWrite = time.Now()
 
-	// Clear the batch and record the number of processed points.
-	i.batch = i.batch[:0]
-	// Give some status feedback every 100000 lines processed
+	// Start a new batch, since the workers own the lines of the last one.
+	i.batch = make([]string, 0, batchSize)
+
+	// Give some status feedback every progressInterval.
+	if time.Since(i.lastProgress) >= progressInterval {
+		i.reportProgress()
+	}
+}
+
+// batch is a batch of lines written by a worker.
+type batch struct {
+	seq             int
+	lines           []string
+	database        string
+	retentionPolicy string
+	offset          int64 // offset of the end of the batch in the import data
+	failed          bool
+}
+
+// writeBatches writes the batches sent by batchWrite until they are closed.
+func (i *Importer) writeBatches() {
+	defer i.wg.Done()
+	for b := range i.batches {
+		_, err := i.client.WriteLineProtocol(strings.Join(b.lines, "\n"), b.database, b.retentionPolicy, i.config.Precision, i.config.WriteConsistency)
+
+		i.mu.Lock()
+		if err != nil {
+			i.stderrLogger.Println("error writing batch: ", err)
+			i.stderrLogger.Println(strings.Join(b.lines, "\n"))
+			i.failedInserts += len(b.lines)
+			b.failed = true
+		} else {
+			i.totalInserts += len(b.lines)
+		}
+		i.advanceCheckpoint(b)
+		i.mu.Unlock()
+	}
+}
+
+// advanceCheckpoint records that b was written or failed. The checkpoint
+// only moves past batches once every batch before them was written too, and
+// never past a failed batch, so that a resumed import writes it again. The
+// caller must hold i.mu.
+func (i *Importer) advanceCheckpoint(b *batch) {
+	i.pending[b.seq] = b
+	for {
+		b, ok := i.pending[i.next]
+		if !ok {
+			break
+		}
+		delete(i.pending, i.next)
+		i.next++
+		if b.failed {
+			i.stalled = true
+		}
+		if !i.stalled {
+			i.checkpoint = checkpoint{Offset: b.offset, Database: b.database, RetentionPolicy: b.retentionPolicy}
+		}
+	}
+
+	if i.config.Checkpoint == "" || time.Since(i.lastCheckpoint) < checkpointInterval {
+		return
+	}
+	if err := writeCheckpoint(i.config.Checkpoint, &i.checkpoint); err != nil {
+		i.stderrLogger.Printf("error writing checkpoint: %s\n", err)
+	}
+	i.lastCheckpoint = time.Now()
+}
+
+// reportProgress prints the points imported, the throughput and, if the
+// size of the import file is known, the time the import has left.
+func (i *Importer) reportProgress() {
+	i.lastProgress = time.Now()
+	since := time.Since(i.startTime)
+
+	i.mu.Lock()
 	processed := i.totalInserts + i.failedInserts
-	if processed%100000 == 0 {
-		since := time.Since(i.startTime)
-		pps := float64(processed) / since.Seconds()
+	i.mu.Unlock()
+
+	pps := float64(processed) / since.Seconds()
+	read := i.read.Count()
+	bps := float64(read-i.readStart) / since.Seconds()
+
+	if i.size <= 0 || bps <= 0 {
 		i.stdoutLogger.Printf("Processed %d lines.  Time elapsed: %s.  Points per second (PPS): %d", processed, since.String(), int64(pps))
+		return
+	}
+
+	done := float64(read) / float64(i.size)
+	eta := time.Duration(float64(i.size-read) / bps * float64(time.Second)).Round(time.Second)
+	i.stdoutLogger.Printf("%s %5.1f%%  Processed %d lines.  Time elapsed: %s.  Points per second (PPS): %d.  %.1f MB/s.  ETA: %s",
+		progressBar(done, 30), done*100, processed, since.Round(time.Second).String(), int64(pps), bps/(1<<20), eta.String())
+}
+
+// progressBar returns a bar of the given width that is done filled.
+func progressBar(done float64, width int) string {
+	if done > 1 {
+		done = 1
+	}
+	n := int(done * float64(width))
+	return "[" + strings.Repeat("=", n) + strings.Repeat(" ", width-n) + "]"
+}
+
+// checkpoint is the progress of an import, written to Config.Checkpoint.
+type checkpoint struct {
+	Offset          int64  `json:"offset"`
+	Database        string `json:"database"`
+	RetentionPolicy string `json:"retentionPolicy"`
+}
+
+// readCheckpoint reads the checkpoint at path. A nil checkpoint is returned
+// if the file does not exist.
+func readCheckpoint(path string) (*checkpoint, error) {
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
 	}
+
+	var c checkpoint
+	if err := json.Unmarshal(buf, &c); err != nil {
+		return nil, fmt.Errorf("reading checkpoint %s: %s", path, err)
+	}
+	return &c, nil
+}
+
+// writeCheckpoint replaces the checkpoint at path with c.
+func writeCheckpoint(path string, c *checkpoint) error {
+	buf, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	atomic.AddInt64(&r.n, int64(n))
+	return n, err
+}
+
+// Count returns the number of bytes read.
+func (r *countingReader) Count() int64 {
+	return atomic.LoadInt64(&r.n)
 }
@@ -0,0 +1,210 @@
+package v8_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	v8 "github.com/freetsdb/freetsdb/importer/v8"
+)
+
+const importData = `# DDL
+CREATE DATABASE db0
+# DML
+# CONTEXT-DATABASE: db0
+# CONTEXT-RETENTION-POLICY: autogen
+cpu value=1 1
+cpu value=2 2
+# CONTEXT-DATABASE: db1
+mem value=3 3
+# CONTEXT-DATABASE: db2
+disk value=4 4
+`
+
+// Ensure an import that fails to write a batch keeps the checkpoint before
+// it, and that the import run again writes it and the batches after it.
+func TestImporter_Resume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freetsdb-importer-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "export")
+	if err := ioutil.WriteFile(path, []byte(importData), 0666); err != nil {
+		t.Fatal(err)
+	}
+	checkpoint := filepath.Join(dir, "checkpoint")
+
+	s := NewServer()
+	defer s.Close()
+
+	// The writes to db1 fail, so the checkpoint stays after db0.
+	s.Fail = "db1"
+	if err := NewImporter(s, path, checkpoint).Import(); err == nil || err.Error() != "1 point was not inserted" {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got, exp := s.Writes(), []string{
+		"db0/autogen: cpu value=1 1\ncpu value=2 2",
+		"db2/autogen: disk value=4 4",
+	}; !equal(got, exp) {
+		t.Fatalf("unexpected writes:\ngot=%q\nexp=%q", got, exp)
+	}
+
+	c := MustReadCheckpoint(t, checkpoint)
+	if c.Database != "db0" || c.RetentionPolicy != "autogen" {
+		t.Fatalf("unexpected checkpoint: %+v", c)
+	} else if exp := int64(strings.Index(importData, "# CONTEXT-DATABASE: db1")); c.Offset != exp {
+		t.Fatalf("unexpected checkpoint offset: %d, exp %d", c.Offset, exp)
+	}
+
+	// The import run again resumes at the failed batch, and removes the
+	// checkpoint once it is complete.
+	s.Reset()
+	if err := NewImporter(s, path, checkpoint).Import(); err != nil {
+		t.Fatal(err)
+	} else if got, exp := s.Writes(), []string{
+		"db1/autogen: mem value=3 3",
+		"db2/autogen: disk value=4 4",
+	}; !equal(got, exp) {
+		t.Fatalf("unexpected writes:\ngot=%q\nexp=%q", got, exp)
+	}
+	if _, err := os.Stat(checkpoint); !os.IsNotExist(err) {
+		t.Fatalf("expected checkpoint to be removed: %v", err)
+	}
+}
+
+// Ensure a checkpoint doesn't move when every batch of a resumed import fails.
+func TestImporter_Resume_Failed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freetsdb-importer-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "export")
+	if err := ioutil.WriteFile(path, []byte(importData), 0666); err != nil {
+		t.Fatal(err)
+	}
+	checkpoint := filepath.Join(dir, "checkpoint")
+	offset := int64(strings.Index(importData, "# CONTEXT-DATABASE: db1"))
+	buf, _ := json.Marshal(map[string]interface{}{"offset": offset, "database": "db0", "retentionPolicy": "autogen"})
+	if err := ioutil.WriteFile(checkpoint, buf, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewServer()
+	defer s.Close()
+	s.Fail = "db"
+	if err := NewImporter(s, path, checkpoint).Import(); err == nil {
+		t.Fatal("expected error")
+	} else if c := MustReadCheckpoint(t, checkpoint); c.Offset != offset || c.Database != "db0" {
+		t.Fatalf("unexpected checkpoint: %+v", c)
+	}
+}
+
+// NewImporter returns an importer of path writing to s.
+func NewImporter(s *Server, path, checkpoint string) *v8.Importer {
+	config := v8.NewConfig()
+	u, _ := url.Parse(s.URL)
+	config.URL = *u
+	config.Path = path
+	config.Checkpoint = checkpoint
+	return v8.NewImporter(config)
+}
+
+// Server is a test server recording the writes of an import.
+type Server struct {
+	*httptest.Server
+
+	// Fail fails the writes to the databases with this prefix.
+	Fail string
+
+	mu     sync.Mutex
+	writes []string
+}
+
+// NewServer returns a new instance of Server.
+func NewServer() *Server {
+	s := &Server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Writes returns the writes received, as "db/rp: lines".
+func (s *Server) Writes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writes
+}
+
+// Reset forgets the writes received and stops failing writes.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes, s.Fail = nil, ""
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/ping":
+		w.WriteHeader(http.StatusNoContent)
+	case "/query":
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[{}]}`))
+	case "/write":
+		body, _ := ioutil.ReadAll(r.Body)
+		db := r.URL.Query().Get("db")
+		var lines []string
+		for _, line := range strings.Split(string(body), "\n") {
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.Fail != "" && strings.HasPrefix(db, s.Fail) {
+			http.Error(w, "write failed", http.StatusInternalServerError)
+			return
+		}
+		s.writes = append(s.writes, db+"/"+r.URL.Query().Get("rp")+": "+strings.Join(lines, "\n"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// MustReadCheckpoint reads the checkpoint at path.
+func MustReadCheckpoint(t *testing.T, path string) (c struct {
+	Offset          int64  `json:"offset"`
+	Database        string `json:"database"`
+	RetentionPolicy string `json:"retentionPolicy"`
+}) {
+	t.Helper()
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := json.Unmarshal(buf, &c); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
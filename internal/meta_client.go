@@ -11,46 +11,85 @@ import (
 type MetaClientMock struct {
 	CloseFn                             func() error
 	CreateContinuousQueryFn             func(database, name, query string) error
+	CreateMaterializedViewFn            func(database, name, query string) error
 	CreateDatabaseFn                    func(name string) (*meta.DatabaseInfo, error)
 	CreateDatabaseWithRetentionPolicyFn func(name string, rpi *meta.RetentionPolicyInfo) (*meta.DatabaseInfo, error)
+	CreateDatabaseWithShardKeyFn        func(name string, rpi *meta.RetentionPolicyInfo, shardKey []string) (*meta.DatabaseInfo, error)
 	CreateRetentionPolicyFn             func(database string, rpi *meta.RetentionPolicyInfo, makeDefault bool) (*meta.RetentionPolicyInfo, error)
 	CreateShardGroupFn                  func(database, policy string, timestamp time.Time) (*meta.ShardGroupInfo, error)
+	CreateMeasurementShardGroupFn       func(database, policy, measurement string, timestamp time.Time) (*meta.ShardGroupInfo, error)
+	CreateShardGroupMergeFn             func(database, policy string, ids []uint64) error
+	CompleteShardGroupMergeFn           func(database, policy string, id, nodeID uint64) error
 	CreateSubscriptionFn                func(database, rp, name, mode string, destinations []string) error
+	CreateSubscriptionWithFilterFn      func(database, rp string, si meta.SubscriptionInfo) error
 	CreateUserFn                        func(name, password string, admin bool) (meta.User, error)
 
 	DatabaseFn  func(name string) *meta.DatabaseInfo
 	DatabasesFn func() ([]meta.DatabaseInfo, error)
 
-	DataFn                func() meta.Data
-	DeleteShardGroupFn    func(database string, policy string, id uint64) error
-	DropContinuousQueryFn func(database, name string) error
-	DropDatabaseFn        func(name string) error
-	DropRetentionPolicyFn func(database, name string) error
-	DropSubscriptionFn    func(database, rp, name string) error
-	DropShardFn           func(id uint64) error
-	DropUserFn            func(name string) error
-
-	OpenFn func() error
+	DatabaseTemplatesFn           func() []meta.DatabaseTemplateInfo
+	CreateDatabaseTemplateFn      func(tmpl *meta.DatabaseTemplateInfo) error
+	DropDatabaseTemplateFn        func(name string) error
+	InstantiateDatabaseTemplateFn func(template, database string) (*meta.DatabaseInfo, error)
+
+	QueryTemplateFn       func(name string) *meta.QueryTemplateInfo
+	QueryTemplatesFn      func() []meta.QueryTemplateInfo
+	CreateQueryTemplateFn func(name, query string) error
+	DropQueryTemplateFn   func(name string) error
+
+	RoleFn             func(name string) *meta.RoleInfo
+	RolesFn            func() []meta.RoleInfo
+	CreateRoleFn       func(name string) error
+	DropRoleFn         func(name string) error
+	SetRolePrivilegeFn func(role, database string, p influxql.Privilege) error
+	SetUserRoleFn      func(username, role string, member bool) error
+
+	SessionsFn            func() []meta.SessionInfo
+	CreateSessionFn       func(username string, lifetime time.Duration) (string, *meta.SessionInfo, error)
+	DropSessionFn         func(id string) error
+	ChangePasswordFn      func(name, password, newPassword string) error
+	AuthenticateSessionFn func(token string) (meta.User, error)
+
+	DataFn                 func() meta.Data
+	DeleteShardGroupFn     func(database string, policy string, id uint64) error
+	DropContinuousQueryFn  func(database, name string) error
+	DropMaterializedViewFn func(database, name string) error
+	DropDatabaseFn         func(name string) error
+	DropRetentionPolicyFn  func(database, name string) error
+	DropSubscriptionFn     func(database, rp, name string) error
+	AddShardOwnerFn        func(id, nodeID uint64) error
+	RemoveShardOwnerFn     func(id, nodeID uint64) error
+	DropShardFn            func(id uint64) error
+	DropUserFn             func(name string) error
+
+	NodeIDFn func() uint64
+	OpenFn   func() error
 
 	PrecreateShardGroupsFn func(from, to time.Time) error
 	PruneShardGroupsFn     func() error
 
 	RetentionPolicyFn func(database, name string) (rpi *meta.RetentionPolicyInfo, err error)
 
-	AuthenticateFn           func(username, password string) (ui meta.User, err error)
-	AdminUserExistsFn        func() bool
-	SetAdminPrivilegeFn      func(username string, admin bool) error
-	SetDataFn                func(*meta.Data) error
-	SetPrivilegeFn           func(username, database string, p influxql.Privilege) error
-	ShardGroupsByTimeRangeFn func(database, policy string, min, max time.Time) (a []meta.ShardGroupInfo, err error)
-	ShardOwnerFn             func(shardID uint64) (database, policy string, sgi *meta.ShardGroupInfo)
-	TruncateShardGroupsFn    func(t time.Time) error
-	UpdateRetentionPolicyFn  func(database, name string, rpu *meta.RetentionPolicyUpdate, makeDefault bool) error
-	UpdateUserFn             func(name, password string) error
-	UserPrivilegeFn          func(username, database string) (*influxql.Privilege, error)
-	UserPrivilegesFn         func(username string) (map[string]influxql.Privilege, error)
-	UserFn                   func(username string) (meta.User, error)
-	UsersFn                  func() []meta.UserInfo
+	AuthenticateFn                func(username, password string) (ui meta.User, err error)
+	AdminUserExistsFn             func() bool
+	SetAdminPrivilegeFn           func(username string, admin bool) error
+	SetDataFn                     func(*meta.Data) error
+	SetPrivilegeFn                func(username, database string, p influxql.Privilege) error
+	ShardGroupsByTimeRangeFn      func(database, policy string, min, max time.Time) (a []meta.ShardGroupInfo, err error)
+	ShardOwnerFn                  func(shardID uint64) (database, policy string, sgi *meta.ShardGroupInfo)
+	TruncateShardGroupsFn         func(t time.Time) error
+	TruncateDatabaseShardGroupsFn func(database, policy string, t time.Time) error
+	UpdateRetentionPolicyFn       func(database, name string, rpu *meta.RetentionPolicyUpdate, makeDefault bool) error
+	UpdateUserFn                  func(name, password string) error
+	UserPrivilegeFn               func(username, database string) (*influxql.Privilege, error)
+	UserPrivilegesFn              func(username string) (map[string]influxql.Privilege, error)
+	UserFn                        func(username string) (meta.User, error)
+	UsersFn                       func() []meta.UserInfo
+
+	DataNodesFn            func() ([]meta.NodeInfo, error)
+	SetDataNodeModeFn      func(id uint64, readOnly bool, reason string, maintenance bool) error
+	SetDatabaseFrozenFn    func(name string, frozen bool) error
+	SetDatabasePlacementFn func(name string, p meta.PlacementInfo) error
 }
 
 func (c *MetaClientMock) Close() error {
@@ -69,6 +108,22 @@ func (c *MetaClientMock) CreateDatabaseWithRetentionPolicy(name string, rpi *met
 	return c.CreateDatabaseWithRetentionPolicyFn(name, rpi)
 }
 
+func (c *MetaClientMock) CreateDatabaseWithShardKey(name string, rpi *meta.RetentionPolicyInfo, shardKey []string) (*meta.DatabaseInfo, error) {
+	return c.CreateDatabaseWithShardKeyFn(name, rpi, shardKey)
+}
+
+func (c *MetaClientMock) CreateMeasurementShardGroup(database, policy, measurement string, timestamp time.Time) (*meta.ShardGroupInfo, error) {
+	return c.CreateMeasurementShardGroupFn(database, policy, measurement, timestamp)
+}
+
+func (c *MetaClientMock) CreateShardGroupMerge(database, policy string, ids []uint64) error {
+	return c.CreateShardGroupMergeFn(database, policy, ids)
+}
+
+func (c *MetaClientMock) CompleteShardGroupMerge(database, policy string, id, nodeID uint64) error {
+	return c.CompleteShardGroupMergeFn(database, policy, id, nodeID)
+}
+
 func (c *MetaClientMock) CreateRetentionPolicy(database string, rpi *meta.RetentionPolicyInfo, makeDefault bool) (*meta.RetentionPolicyInfo, error) {
 	return c.CreateRetentionPolicyFn(database, rpi, makeDefault)
 }
@@ -81,6 +136,10 @@ func (c *MetaClientMock) CreateSubscription(database, rp, name, mode string, des
 	return c.CreateSubscriptionFn(database, rp, name, mode, destinations)
 }
 
+func (c *MetaClientMock) CreateSubscriptionWithFilter(database, rp string, si meta.SubscriptionInfo) error {
+	return c.CreateSubscriptionWithFilterFn(database, rp, si)
+}
+
 func (c *MetaClientMock) CreateUser(name, password string, admin bool) (meta.User, error) {
 	return c.CreateUserFn(name, password, admin)
 }
@@ -93,6 +152,38 @@ func (c *MetaClientMock) Databases() ([]meta.DatabaseInfo, error) {
 	return c.DatabasesFn()
 }
 
+func (c *MetaClientMock) DatabaseTemplates() []meta.DatabaseTemplateInfo {
+	return c.DatabaseTemplatesFn()
+}
+
+func (c *MetaClientMock) CreateDatabaseTemplate(tmpl *meta.DatabaseTemplateInfo) error {
+	return c.CreateDatabaseTemplateFn(tmpl)
+}
+
+func (c *MetaClientMock) DropDatabaseTemplate(name string) error {
+	return c.DropDatabaseTemplateFn(name)
+}
+
+func (c *MetaClientMock) InstantiateDatabaseTemplate(template, database string) (*meta.DatabaseInfo, error) {
+	return c.InstantiateDatabaseTemplateFn(template, database)
+}
+
+func (c *MetaClientMock) QueryTemplate(name string) *meta.QueryTemplateInfo {
+	return c.QueryTemplateFn(name)
+}
+
+func (c *MetaClientMock) QueryTemplates() []meta.QueryTemplateInfo {
+	return c.QueryTemplatesFn()
+}
+
+func (c *MetaClientMock) CreateQueryTemplate(name, query string) error {
+	return c.CreateQueryTemplateFn(name, query)
+}
+
+func (c *MetaClientMock) DropQueryTemplate(name string) error {
+	return c.DropQueryTemplateFn(name)
+}
+
 func (c *MetaClientMock) DeleteShardGroup(database string, policy string, id uint64) error {
 	return c.DeleteShardGroupFn(database, policy, id)
 }
@@ -101,6 +192,14 @@ func (c *MetaClientMock) DropContinuousQuery(database, name string) error {
 	return c.DropContinuousQueryFn(database, name)
 }
 
+func (c *MetaClientMock) CreateMaterializedView(database, name, query string) error {
+	return c.CreateMaterializedViewFn(database, name, query)
+}
+
+func (c *MetaClientMock) DropMaterializedView(database, name string) error {
+	return c.DropMaterializedViewFn(database, name)
+}
+
 func (c *MetaClientMock) DropDatabase(name string) error {
 	return c.DropDatabaseFn(name)
 }
@@ -109,6 +208,14 @@ func (c *MetaClientMock) DropRetentionPolicy(database, name string) error {
 	return c.DropRetentionPolicyFn(database, name)
 }
 
+func (c *MetaClientMock) AddShardOwner(id, nodeID uint64) error {
+	return c.AddShardOwnerFn(id, nodeID)
+}
+
+func (c *MetaClientMock) RemoveShardOwner(id, nodeID uint64) error {
+	return c.RemoveShardOwnerFn(id, nodeID)
+}
+
 func (c *MetaClientMock) DropShard(id uint64) error {
 	return c.DropShardFn(id)
 }
@@ -121,6 +228,50 @@ func (c *MetaClientMock) DropUser(name string) error {
 	return c.DropUserFn(name)
 }
 
+func (c *MetaClientMock) Role(name string) *meta.RoleInfo {
+	return c.RoleFn(name)
+}
+
+func (c *MetaClientMock) Roles() []meta.RoleInfo {
+	return c.RolesFn()
+}
+
+func (c *MetaClientMock) CreateRole(name string) error {
+	return c.CreateRoleFn(name)
+}
+
+func (c *MetaClientMock) DropRole(name string) error {
+	return c.DropRoleFn(name)
+}
+
+func (c *MetaClientMock) SetRolePrivilege(role, database string, p influxql.Privilege) error {
+	return c.SetRolePrivilegeFn(role, database, p)
+}
+
+func (c *MetaClientMock) SetUserRole(username, role string, member bool) error {
+	return c.SetUserRoleFn(username, role, member)
+}
+
+func (c *MetaClientMock) Sessions() []meta.SessionInfo {
+	return c.SessionsFn()
+}
+
+func (c *MetaClientMock) ChangePassword(name, password, newPassword string) error {
+	return c.ChangePasswordFn(name, password, newPassword)
+}
+
+func (c *MetaClientMock) CreateSession(username string, lifetime time.Duration) (string, *meta.SessionInfo, error) {
+	return c.CreateSessionFn(username, lifetime)
+}
+
+func (c *MetaClientMock) DropSession(id string) error {
+	return c.DropSessionFn(id)
+}
+
+func (c *MetaClientMock) AuthenticateSession(token string) (meta.User, error) {
+	return c.AuthenticateSessionFn(token)
+}
+
 func (c *MetaClientMock) RetentionPolicy(database, name string) (rpi *meta.RetentionPolicyInfo, err error) {
 	return c.RetentionPolicyFn(database, name)
 }
@@ -145,6 +296,26 @@ func (c *MetaClientMock) TruncateShardGroups(t time.Time) error {
 	return c.TruncateShardGroupsFn(t)
 }
 
+func (c *MetaClientMock) TruncateDatabaseShardGroups(database, policy string, t time.Time) error {
+	return c.TruncateDatabaseShardGroupsFn(database, policy, t)
+}
+
+func (c *MetaClientMock) DataNodes() ([]meta.NodeInfo, error) {
+	return c.DataNodesFn()
+}
+
+func (c *MetaClientMock) SetDataNodeMode(id uint64, readOnly bool, reason string, maintenance bool) error {
+	return c.SetDataNodeModeFn(id, readOnly, reason, maintenance)
+}
+
+func (c *MetaClientMock) SetDatabaseFrozen(name string, frozen bool) error {
+	return c.SetDatabaseFrozenFn(name, frozen)
+}
+
+func (c *MetaClientMock) SetDatabasePlacement(name string, p meta.PlacementInfo) error {
+	return c.SetDatabasePlacementFn(name, p)
+}
+
 func (c *MetaClientMock) UpdateRetentionPolicy(database, name string, rpu *meta.RetentionPolicyUpdate, makeDefault bool) error {
 	return c.UpdateRetentionPolicyFn(database, name, rpu, makeDefault)
 }
@@ -169,6 +340,7 @@ func (c *MetaClientMock) AdminUserExists() bool { return c.AdminUserExistsFn() }
 func (c *MetaClientMock) User(username string) (meta.User, error) { return c.UserFn(username) }
 func (c *MetaClientMock) Users() []meta.UserInfo                  { return c.UsersFn() }
 
+func (c *MetaClientMock) NodeID() uint64             { return c.NodeIDFn() }
 func (c *MetaClientMock) Open() error                { return c.OpenFn() }
 func (c *MetaClientMock) Data() meta.Data            { return c.DataFn() }
 func (c *MetaClientMock) SetData(d *meta.Data) error { return c.SetDataFn(d) }
@@ -15,8 +15,10 @@ import (
 type TSDBStoreMock struct {
 	BackupShardFn             func(id uint64, since time.Time, w io.Writer) error
 	BackupSeriesFileFn        func(database string, w io.Writer) error
+	BackupShardFilesFn        func(id uint64, names []string, w io.Writer) error
 	ExportShardFn             func(id uint64, ExportStart time.Time, ExportEnd time.Time, w io.Writer) error
 	CloseFn                   func() error
+	CopyShardFn               func(srcID, dstID uint64) error
 	CreateShardFn             func(database, policy string, shardID uint64, enabled bool) error
 	CreateShardSnapshotFn     func(id uint64) (string, error)
 	DatabasesFn               func() []string
@@ -30,15 +32,23 @@ type TSDBStoreMock struct {
 	ImportShardFn             func(id uint64, r io.Reader) error
 	MeasurementSeriesCountsFn func(database string) (measuments int, series int)
 	MeasurementsCardinalityFn func(database string) (int64, error)
+	MeasurementStatsFn        func(database string) ([]tsdb.ShardMeasurementStats, error)
 	MeasurementNamesFn        func(auth query.Authorizer, database string, cond influxql.Expr) ([][]byte, error)
 	OpenFn                    func() error
 	PathFn                    func() string
+	ReindexShardFn            func(id uint64) error
 	RestoreShardFn            func(id uint64, r io.Reader) error
+	RestoreSeriesFileFn       func(database string, r io.Reader) error
 	SeriesCardinalityFn       func(database string) (int64, error)
 	SetShardEnabledFn         func(shardID uint64, enabled bool) error
+	ShardDiskSizeFn           func(id uint64) (int64, error)
+	ShardFilesFn              func(id uint64) ([]tsdb.ShardFile, error)
+	DiskUsageFn               func(database string) ([]tsdb.ShardDiskUsage, error)
+	DiskUsageAtFn             func(database string, t time.Time) *tsdb.DiskUsageSample
 	ShardFn                   func(id uint64) *tsdb.Shard
 	ShardGroupFn              func(ids []uint64) tsdb.ShardGroup
 	ShardIDsFn                func() []uint64
+	ShardManifestFn           func(id uint64) (map[string]int64, error)
 	ShardNFn                  func() int
 	ShardRelativePathFn       func(id uint64) (string, error)
 	ShardsFn                  func(ids []uint64) []*tsdb.Shard
@@ -55,10 +65,16 @@ func (s *TSDBStoreMock) BackupShard(id uint64, since time.Time, w io.Writer) err
 func (s *TSDBStoreMock) BackupSeriesFile(database string, w io.Writer) error {
 	return s.BackupSeriesFileFn(database, w)
 }
+func (s *TSDBStoreMock) BackupShardFiles(id uint64, names []string, w io.Writer) error {
+	return s.BackupShardFilesFn(id, names, w)
+}
 func (s *TSDBStoreMock) ExportShard(id uint64, ExportStart time.Time, ExportEnd time.Time, w io.Writer) error {
 	return s.ExportShardFn(id, ExportStart, ExportEnd, w)
 }
 func (s *TSDBStoreMock) Close() error { return s.CloseFn() }
+func (s *TSDBStoreMock) CopyShard(srcID, dstID uint64) error {
+	return s.CopyShardFn(srcID, dstID)
+}
 func (s *TSDBStoreMock) CreateShard(database string, retentionPolicy string, shardID uint64, enabled bool) error {
 	return s.CreateShardFn(database, retentionPolicy, shardID, enabled)
 }
@@ -101,15 +117,36 @@ func (s *TSDBStoreMock) MeasurementSeriesCounts(database string) (measuments int
 func (s *TSDBStoreMock) MeasurementsCardinality(database string) (int64, error) {
 	return s.MeasurementsCardinalityFn(database)
 }
+func (s *TSDBStoreMock) MeasurementStats(database string) ([]tsdb.ShardMeasurementStats, error) {
+	return s.MeasurementStatsFn(database)
+}
+func (s *TSDBStoreMock) ShardDiskSize(id uint64) (int64, error) {
+	return s.ShardDiskSizeFn(id)
+}
+func (s *TSDBStoreMock) ShardFiles(id uint64) ([]tsdb.ShardFile, error) {
+	return s.ShardFilesFn(id)
+}
+func (s *TSDBStoreMock) DiskUsage(database string) ([]tsdb.ShardDiskUsage, error) {
+	return s.DiskUsageFn(database)
+}
+func (s *TSDBStoreMock) DiskUsageAt(database string, t time.Time) *tsdb.DiskUsageSample {
+	return s.DiskUsageAtFn(database, t)
+}
 func (s *TSDBStoreMock) Open() error {
 	return s.OpenFn()
 }
 func (s *TSDBStoreMock) Path() string {
 	return s.PathFn()
 }
+func (s *TSDBStoreMock) ReindexShard(id uint64) error {
+	return s.ReindexShardFn(id)
+}
 func (s *TSDBStoreMock) RestoreShard(id uint64, r io.Reader) error {
 	return s.RestoreShardFn(id, r)
 }
+func (s *TSDBStoreMock) RestoreSeriesFile(database string, r io.Reader) error {
+	return s.RestoreSeriesFileFn(database, r)
+}
 func (s *TSDBStoreMock) SeriesCardinality(database string) (int64, error) {
 	return s.SeriesCardinalityFn(database)
 }
@@ -125,6 +162,9 @@ func (s *TSDBStoreMock) ShardGroup(ids []uint64) tsdb.ShardGroup {
 func (s *TSDBStoreMock) ShardIDs() []uint64 {
 	return s.ShardIDsFn()
 }
+func (s *TSDBStoreMock) ShardManifest(id uint64) (map[string]int64, error) {
+	return s.ShardManifestFn(id)
+}
 func (s *TSDBStoreMock) ShardN() int {
 	return s.ShardNFn()
 }
@@ -143,6 +183,13 @@ func (s *TSDBStoreMock) TagKeys(auth query.Authorizer, shardIDs []uint64, cond i
 func (s *TSDBStoreMock) TagValues(auth query.Authorizer, shardIDs []uint64, cond influxql.Expr) ([]tsdb.TagValues, error) {
 	return s.TagValuesFn(auth, shardIDs, cond)
 }
+func (s *TSDBStoreMock) TagValuesIterator(auth query.Authorizer, shardIDs []uint64, cond influxql.Expr) (tsdb.TagValuesIterator, error) {
+	a, err := s.TagValuesFn(auth, shardIDs, cond)
+	if err != nil {
+		return nil, err
+	}
+	return tsdb.NewTagValuesSliceIterator(a), nil
+}
 func (s *TSDBStoreMock) WithLogger(log *zap.Logger) {
 	s.WithLoggerFn(log)
 }
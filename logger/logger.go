@@ -20,6 +20,13 @@ func New(w io.Writer) *zap.Logger {
 }
 
 func (c *Config) New(defaultOutput io.Writer) (*zap.Logger, error) {
+	return c.NewAtLevel(defaultOutput, c.Level)
+}
+
+// NewAtLevel returns a logger that logs entries enabled by level instead of
+// the configured level. Passing a zap.AtomicLevel allows the level to be
+// changed while the logger is in use.
+func (c *Config) NewAtLevel(defaultOutput io.Writer, level zapcore.LevelEnabler) (*zap.Logger, error) {
 	w := defaultOutput
 	format := c.Format
 	if format == "console" {
@@ -44,7 +51,7 @@ func (c *Config) New(defaultOutput io.Writer) (*zap.Logger, error) {
 	return zap.New(zapcore.NewCore(
 		encoder,
 		zapcore.Lock(zapcore.AddSync(w)),
-		c.Level,
+		level,
 	), zap.Fields(zap.String("log_id", nextID()))), nil
 }
 
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// batchVersion is the version of the binary encoding of a batch of points.
+const batchVersion = 1
+
+// ErrInvalidBatch is returned when a batch of points cannot be decoded.
+var ErrInvalidBatch = errors.New("invalid point batch")
+
+// AppendPointsBinary appends a compact binary encoding of points to dst and
+// returns the extended buffer. Unlike MarshalBinary, the points of a batch
+// share a single buffer, and decoding them with ParsePointsBinary does not
+// parse the fields again.
+func AppendPointsBinary(dst []byte, points []Point) ([]byte, error) {
+	dst = append(dst, batchVersion)
+	dst = appendUvarint(dst, uint64(len(points)))
+
+	var tmp point
+	for _, p := range points {
+		pt, ok := p.(*point)
+		if !ok {
+			// Other implementations only expose their fields through their
+			// binary encoding.
+			b, err := p.MarshalBinary()
+			if err != nil {
+				return nil, err
+			} else if err := tmp.UnmarshalBinary(b); err != nil {
+				return nil, err
+			}
+			pt = &tmp
+		}
+		if len(pt.fields) == 0 {
+			return nil, ErrPointMustHaveAField
+		}
+
+		dst = appendUvarint(dst, uint64(len(pt.key)))
+		dst = append(dst, pt.key...)
+		dst = appendUvarint(dst, uint64(len(pt.fields)))
+		dst = append(dst, pt.fields...)
+		dst = appendVarint(dst, pt.time.UnixNano())
+	}
+	return dst, nil
+}
+
+// ParsePointsBinary decodes a batch of points encoded by AppendPointsBinary.
+// The points reference b, so it must not be modified afterwards. The fields
+// are assumed to be valid, as they were when the points were encoded; invalid
+// values are only reported when the fields are read.
+func ParsePointsBinary(b []byte) ([]Point, error) {
+	if len(b) == 0 || b[0] != batchVersion {
+		return nil, ErrInvalidBatch
+	}
+	b = b[1:]
+
+	n, i := binary.Uvarint(b)
+	if i <= 0 || n > uint64(len(b)) {
+		return nil, ErrInvalidBatch
+	}
+	b = b[i:]
+
+	// Allocate the points together rather than one at a time.
+	slab := make([]point, n)
+	points := make([]Point, n)
+	for j := range slab {
+		pt := &slab[j]
+
+		var ok bool
+		if pt.key, b, ok = readBatchBytes(b); !ok || len(pt.key) == 0 {
+			return nil, ErrInvalidBatch
+		} else if pt.fields, b, ok = readBatchBytes(b); !ok || len(pt.fields) == 0 {
+			return nil, ErrInvalidBatch
+		}
+
+		ts, i := binary.Varint(b)
+		if i <= 0 {
+			return nil, ErrInvalidBatch
+		}
+		b = b[i:]
+		pt.time = time.Unix(0, ts).UTC()
+
+		points[j] = pt
+	}
+
+	if len(b) != 0 {
+		return nil, ErrInvalidBatch
+	}
+	return points, nil
+}
+
+// readBatchBytes reads a length-prefixed byte slice from b and returns it
+// with the rest of b.
+func readBatchBytes(b []byte) (v, rest []byte, ok bool) {
+	n, i := binary.Uvarint(b)
+	if i <= 0 || n > uint64(len(b)-i) {
+		return nil, nil, false
+	}
+	b = b[i:]
+	return b[:n:n], b[n:], true
+}
+
+// appendUvarint appends the varint encoding of v to b.
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+// appendVarint appends the varint encoding of v to b.
+func appendVarint(b []byte, v int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutVarint(buf[:], v)]...)
+}
@@ -0,0 +1,91 @@
+package models_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/models"
+)
+
+func TestParsePointsBinary(t *testing.T) {
+	points, err := models.ParsePointsString(`cpu,host=serverA,region=uswest value=1.5,count=2i 1000000000
+mem,host=serverB free=100u,label="a b",ok=true 2000000000
+disk value=-3 -1000`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := models.AppendPointsBinary(nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := models.ParsePointsBinary(b)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(got) != len(points) {
+		t.Fatalf("unexpected point count: %d", len(got))
+	}
+	for i := range points {
+		if got[i].String() != points[i].String() {
+			t.Fatalf("unexpected point %d: got %s, exp %s", i, got[i], points[i])
+		} else if !got[i].Time().Equal(points[i].Time()) {
+			t.Fatalf("unexpected time of point %d: %s", i, got[i].Time())
+		}
+
+		gf, err := got[i].Fields()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ef, _ := points[i].Fields()
+		if len(gf) != len(ef) {
+			t.Fatalf("unexpected fields of point %d: %v", i, gf)
+		}
+	}
+}
+
+func TestParsePointsBinary_Invalid(t *testing.T) {
+	points, err := models.ParsePointsString(`cpu value=1 1000000000`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := models.AppendPointsBinary(nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, buf := range [][]byte{
+		nil,
+		{0},
+		b[:len(b)-1],
+		append(b[:len(b):len(b)], 0),
+	} {
+		if _, err := models.ParsePointsBinary(buf); err != models.ErrInvalidBatch {
+			t.Fatalf("unexpected error for %v: %v", buf, err)
+		}
+	}
+}
+
+func BenchmarkParsePointsBinary(b *testing.B) {
+	var batch [5000]string
+	for i := 0; i < len(batch); i++ {
+		batch[i] = `cpu,host=serverA value1=1.0,value2=2i,value3="three" 1000000000`
+	}
+	points, err := models.ParsePointsString(strings.Join(batch[:], "\n"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	buf, err := models.AppendPointsBinary(nil, points)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := models.ParsePointsBinary(buf); err != nil {
+			b.Fatal(err)
+		}
+		b.SetBytes(int64(len(buf)))
+	}
+}
@@ -2,6 +2,7 @@ package models_test
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -1892,6 +1893,54 @@ func TestParsePointsWithPrecisionNoTime(t *testing.T) {
 	}
 }
 
+// Ensure the points after an invalid line are parsed on their own, and lines
+// with quoted newlines are kept whole.
+func TestParsePointsWithPrecision_InvalidLine(t *testing.T) {
+	pts, err := models.ParsePointsWithPrecision([]byte(`cpu,host=serverA value=1 1000
+cpu,host=serverB value=
+mem value=2
+disk msg="a
+b" 3000`), time.Unix(0, 2000).UTC(), "")
+	if err == nil {
+		t.Fatal("expected an error for the invalid line")
+	} else if len(pts) != 3 {
+		t.Fatalf("unexpected point count: %d", len(pts))
+	}
+
+	for i, exp := range []string{
+		"cpu,host=serverA value=1 1000",
+		"mem value=2 2000",
+		"disk msg=\"a\nb\" 3000",
+	} {
+		if got := pts[i].String(); got != exp {
+			t.Errorf("unexpected point %d: got %q, exp %q", i, got, exp)
+		}
+	}
+}
+
+func TestParsePointsWithIndexes(t *testing.T) {
+	pts, indexes, failed := models.ParsePointsWithIndexes([]byte(`cpu value=1 1000
+# comment
+
+cpu value= 2000
+disk msg="a
+b" 3000
+mem value=x`), time.Unix(0, 2000).UTC(), "")
+	if len(pts) != 2 {
+		t.Fatalf("unexpected point count: %d", len(pts))
+	} else if !reflect.DeepEqual(indexes, []int{0, 2}) {
+		t.Fatalf("unexpected indexes: %v", indexes)
+	}
+
+	if len(failed) != 2 {
+		t.Fatalf("unexpected errors: %v", failed)
+	} else if failed[0].Index != 1 || failed[0].Line != "cpu value= 2000" {
+		t.Fatalf("unexpected error: %+v", failed[0])
+	} else if failed[1].Index != 3 {
+		t.Fatalf("unexpected error: %+v", failed[1])
+	}
+}
+
 func TestParsePointsWithPrecisionComments(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -2246,14 +2295,18 @@ func TestNewPointsRejectsMaxKey(t *testing.T) {
 
 	// Test 1 byte over max key len
 	key += "a"
-	if _, err := models.NewPoint(key, nil, models.Fields{"value": 1, "ok": 2.0}, time.Now()); err == nil {
-		t.Fatalf("new point with max key. got: nil, expected: error")
+	if _, err := models.NewPoint(key, nil, models.Fields{"value": 1, "ok": 2.0}, time.Now()); !errors.Is(err, models.ErrMaxKeyLengthExceeded) {
+		t.Fatalf("new point with max key. got: %v, expected: %v", err, models.ErrMaxKeyLengthExceeded)
 	}
 
 	if _, err := models.ParsePointsString(fmt.Sprintf("%v value=1,ok=2.0", key)); err == nil {
 		t.Fatalf("parse point with max key. got: nil, expected: error")
 	}
 
+	_, _, failed := models.ParsePointsWithIndexes([]byte(fmt.Sprintf("%v value=1,ok=2.0", key)), time.Now(), "n")
+	if len(failed) != 1 || !errors.Is(failed[0], models.ErrMaxKeyLengthExceeded) {
+		t.Fatalf("parse point with max key. got: %v, expected: %v", failed, models.ErrMaxKeyLengthExceeded)
+	}
 }
 
 func TestPoint_FieldIterator_Simple(t *testing.T) {
@@ -0,0 +1,106 @@
+// Package encryption provides authenticated block encryption for data at rest.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// KeySize is the size of an AES-256 key in bytes.
+	KeySize = 32
+
+	// NonceSize is the size of the GCM nonce stored with each block.
+	NonceSize = 12
+
+	// Overhead is the number of bytes Seal adds to a plaintext: the key ID,
+	// the nonce and the GCM tag.
+	Overhead = 4 + NonceSize + 16
+)
+
+var (
+	// ErrUnknownKey is returned when a block was encrypted with a key that is
+	// no longer available.
+	ErrUnknownKey = errors.New("encryption key not found")
+
+	// ErrShortBlock is returned when an encrypted block is truncated.
+	ErrShortBlock = errors.New("encrypted block too short")
+)
+
+// Key is an encryption key. Keys are identified by ID so that blocks written
+// with an older key can still be read after rotation.
+type Key struct {
+	ID     uint32
+	Secret []byte
+}
+
+// Cipher encrypts blocks with AES-256-GCM. New blocks are sealed with the key
+// with the highest ID; any of its keys can open a block.
+type Cipher struct {
+	active uint32
+	aeads  map[uint32]cipher.AEAD
+}
+
+// NewCipher returns a Cipher using keys.
+func NewCipher(keys []Key) (*Cipher, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one encryption key is required")
+	}
+
+	c := &Cipher{aeads: make(map[uint32]cipher.AEAD, len(keys))}
+	for i, k := range keys {
+		if len(k.Secret) != KeySize {
+			return nil, fmt.Errorf("key %d: must be %d bytes, got %d", k.ID, KeySize, len(k.Secret))
+		} else if _, ok := c.aeads[k.ID]; ok {
+			return nil, fmt.Errorf("key %d: duplicate key id", k.ID)
+		}
+
+		block, err := aes.NewCipher(k.Secret)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		c.aeads[k.ID] = aead
+
+		if i == 0 || k.ID > c.active {
+			c.active = k.ID
+		}
+	}
+	return c, nil
+}
+
+// KeyID returns the ID of the key new blocks are sealed with.
+func (c *Cipher) KeyID() uint32 { return c.active }
+
+// Seal encrypts plaintext and appends the result to dst.
+func (c *Cipher) Seal(dst, plaintext []byte) ([]byte, error) {
+	var hdr [4 + NonceSize]byte
+	binary.BigEndian.PutUint32(hdr[:4], c.active)
+	if _, err := io.ReadFull(rand.Reader, hdr[4:]); err != nil {
+		return nil, err
+	}
+
+	dst = append(dst, hdr[:]...)
+	return c.aeads[c.active].Seal(dst, hdr[4:], plaintext, nil), nil
+}
+
+// Open decrypts a block produced by Seal and appends the plaintext to dst.
+func (c *Cipher) Open(dst, block []byte) ([]byte, error) {
+	if len(block) < Overhead {
+		return nil, ErrShortBlock
+	}
+
+	aead, ok := c.aeads[binary.BigEndian.Uint32(block[:4])]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return aead.Open(dst, block[4:4+NonceSize], block[4+NonceSize:], nil)
+}
@@ -0,0 +1,95 @@
+package encryption_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/pkg/encryption"
+)
+
+func TestCipher_Rotation(t *testing.T) {
+	k1 := encryption.Key{ID: 1, Secret: bytes.Repeat([]byte{1}, encryption.KeySize)}
+	k2 := encryption.Key{ID: 2, Secret: bytes.Repeat([]byte{2}, encryption.KeySize)}
+
+	old, err := encryption.NewCipher([]encryption.Key{k1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := old.Seal(nil, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(block) != len("hello")+encryption.Overhead {
+		t.Fatalf("unexpected block size: %d", len(block))
+	}
+
+	// After rotation, new blocks use the new key and old blocks still open.
+	c, err := encryption.NewCipher([]encryption.Key{k2, k1})
+	if err != nil {
+		t.Fatal(err)
+	} else if c.KeyID() != 2 {
+		t.Fatalf("unexpected active key: %d", c.KeyID())
+	}
+	if got, err := c.Open(nil, block); err != nil {
+		t.Fatal(err)
+	} else if string(got) != "hello" {
+		t.Fatalf("unexpected plaintext: %q", got)
+	}
+
+	block2, err := c.Seal(nil, []byte("world"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := old.Open(nil, block2); err == nil {
+		t.Fatal("expected error opening block sealed with an unknown key")
+	}
+
+	// Tampering is detected.
+	block[len(block)-1] ^= 0xff
+	if _, err := c.Open(nil, block); err == nil {
+		t.Fatal("expected error opening a modified block")
+	}
+}
+
+func TestKeyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "encryption")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key := func(b byte) string {
+		return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, encryption.KeySize))
+	}
+	path := filepath.Join(dir, "keys")
+	content := fmt.Sprintf("# keys\n* 1 %s\ndb0 2 %s\ndb0 1 %s\n", key(1), key(3), key(2))
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	kf, err := encryption.NewKeyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if keys, err := kf.Keys("db0"); err != nil {
+		t.Fatal(err)
+	} else if len(keys) != 2 || keys[0].ID != 1 || keys[1].ID != 2 {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+	if keys, err := kf.Keys("db1"); err != nil {
+		t.Fatal(err)
+	} else if len(keys) != 1 || keys[0].Secret[0] != 1 {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("db0 1 c2hvcnQ=\n"), 0600); err != nil {
+		t.Fatal(err)
+	} else if err := kf.Reload(); err == nil {
+		t.Fatal("expected error for short key")
+	}
+}
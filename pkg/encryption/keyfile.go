@@ -0,0 +1,109 @@
+package encryption
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// AllDatabases is the database name in a key file that applies to databases
+// without keys of their own.
+const AllDatabases = "*"
+
+// KeyProvider returns the encryption keys for a database. Implementations may
+// load keys from a file or an external key management service.
+type KeyProvider interface {
+	Keys(database string) ([]Key, error)
+}
+
+// KeyFile is a KeyProvider reading keys from a file. Each non-empty line that
+// does not start with '#' has the form:
+//
+//	<database> <key id> <base64 encoded 32 byte key>
+//
+// A database of "*" applies to every database without its own keys. To rotate
+// a key, add a line with a higher ID. Older keys must be kept until all data
+// written with them has been compacted.
+type KeyFile struct {
+	path string
+
+	mu   sync.RWMutex
+	keys map[string][]Key
+}
+
+// NewKeyFile loads the key file at path.
+func NewKeyFile(path string) (*KeyFile, error) {
+	kf := &KeyFile{path: path}
+	if err := kf.Reload(); err != nil {
+		return nil, err
+	}
+	return kf, nil
+}
+
+// Reload rereads the key file. Shards pick up new keys the next time they
+// are opened.
+func (kf *KeyFile) Reload() error {
+	f, err := os.Open(kf.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	keys := make(map[string][]Key)
+	scanner := bufio.NewScanner(f)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return fmt.Errorf("%s:%d: expected <database> <key id> <key>", kf.path, lineno)
+		}
+
+		id, err := strconv.ParseUint(fields[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("%s:%d: invalid key id: %v", kf.path, lineno, err)
+		}
+
+		secret, err := base64.StdEncoding.DecodeString(fields[2])
+		if err != nil {
+			return fmt.Errorf("%s:%d: invalid key: %v", kf.path, lineno, err)
+		} else if len(secret) != KeySize {
+			return fmt.Errorf("%s:%d: key must be %d bytes, got %d", kf.path, lineno, KeySize, len(secret))
+		}
+
+		keys[fields[0]] = append(keys[fields[0]], Key{ID: uint32(id), Secret: secret})
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for _, a := range keys {
+		sort.Slice(a, func(i, j int) bool { return a[i].ID < a[j].ID })
+	}
+
+	kf.mu.Lock()
+	kf.keys = keys
+	kf.mu.Unlock()
+	return nil
+}
+
+// Keys returns the keys for database, ordered by ID.
+func (kf *KeyFile) Keys(database string) ([]Key, error) {
+	kf.mu.RLock()
+	defer kf.mu.RUnlock()
+
+	if keys, ok := kf.keys[database]; ok {
+		return keys, nil
+	} else if keys, ok := kf.keys[AllDatabases]; ok {
+		return keys, nil
+	}
+	return nil, fmt.Errorf("no encryption keys for database %q in %s", database, kf.path)
+}
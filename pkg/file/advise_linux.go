@@ -0,0 +1,45 @@
+package file
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// AdviseSequential hints to the kernel that f is read sequentially, so it
+// reads ahead more aggressively.
+func AdviseSequential(f *os.File) error {
+	return unix.Fadvise(int(f.Fd()), 0, 0, unix.FADV_SEQUENTIAL)
+}
+
+// AdviseWillNeed hints to the kernel that the given range of f will be read
+// soon, so it starts reading it into the page cache.
+func AdviseWillNeed(f *os.File, offset, length int64) error {
+	return unix.Fadvise(int(f.Fd()), offset, length, unix.FADV_WILLNEED)
+}
+
+// AdviseDontNeed hints to the kernel that the given range of f will not be
+// read again, so its pages can be dropped from the page cache. A length of 0
+// covers the rest of the file.
+func AdviseDontNeed(f *os.File, offset, length int64) error {
+	return unix.Fadvise(int(f.Fd()), offset, length, unix.FADV_DONTNEED)
+}
+
+// SetDirectIO enables or disables direct I/O on f. With direct I/O, reads
+// and writes bypass the page cache, and must be aligned to the logical block
+// size of the filesystem. Filesystems not supporting it return an error.
+func SetDirectIO(f *os.File, enabled bool) error {
+	fd := int(f.Fd())
+	flags, err := unix.FcntlInt(uintptr(fd), unix.F_GETFL, 0)
+	if err != nil {
+		return err
+	}
+
+	if enabled {
+		flags |= unix.O_DIRECT
+	} else {
+		flags &^= unix.O_DIRECT
+	}
+	_, err = unix.FcntlInt(uintptr(fd), unix.F_SETFL, flags)
+	return err
+}
@@ -0,0 +1,30 @@
+//go:build !linux
+// +build !linux
+
+package file
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrDirectIONotSupported is returned when direct I/O is not supported by
+// the platform.
+var ErrDirectIONotSupported = errors.New("direct I/O not supported")
+
+// AdviseSequential is a no-op on this platform.
+func AdviseSequential(f *os.File) error { return nil }
+
+// AdviseWillNeed is a no-op on this platform.
+func AdviseWillNeed(f *os.File, offset, length int64) error { return nil }
+
+// AdviseDontNeed is a no-op on this platform.
+func AdviseDontNeed(f *os.File, offset, length int64) error { return nil }
+
+// SetDirectIO is not supported on this platform.
+func SetDirectIO(f *os.File, enabled bool) error {
+	if enabled {
+		return ErrDirectIONotSupported
+	}
+	return nil
+}
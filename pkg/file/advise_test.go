@@ -0,0 +1,61 @@
+package file_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/pkg/file"
+)
+
+// Ensure the hints given to the kernel about how a file is read succeed, and
+// direct I/O can be turned off.
+func TestAdvise(t *testing.T) {
+	f, err := ioutil.TempFile("", "advise")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, err := f.Write(make([]byte, 8192)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := file.AdviseSequential(f); err != nil {
+		t.Fatalf("AdviseSequential: %v", err)
+	} else if err := file.AdviseWillNeed(f, 0, 4096); err != nil {
+		t.Fatalf("AdviseWillNeed: %v", err)
+	} else if err := file.AdviseDontNeed(f, 4096, 0); err != nil {
+		t.Fatalf("AdviseDontNeed: %v", err)
+	} else if err := file.SetDirectIO(f, false); err != nil {
+		t.Fatalf("SetDirectIO: %v", err)
+	}
+}
+
+// Ensure a file with direct I/O enabled is still written to and read from
+// once it is turned off again.
+func TestSetDirectIO(t *testing.T) {
+	f, err := ioutil.TempFile("", "direct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := file.SetDirectIO(f, true); err != nil {
+		t.Skipf("direct I/O not supported: %v", err)
+	}
+	if err := file.SetDirectIO(f, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.Write([]byte("unaligned")); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(f.Name()); err != nil {
+		t.Fatal(err)
+	} else if string(b) != "unaligned" {
+		t.Fatalf("unexpected contents: %q", b)
+	}
+}
@@ -0,0 +1,282 @@
+// Package geohash encodes locations as geohashes and covers areas with the
+// geohash cells intersecting them.
+//
+// A geohash of n characters names a cell of the grid dividing the longitudes
+// into 2^ceil(5n/2) columns and the latitudes into 2^floor(5n/2) rows. The
+// geohash of a cell is a prefix of the geohashes of the cells it contains, so
+// an area covered by a set of cells matches the locations whose geohash has
+// one of the cells as a prefix.
+package geohash
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+)
+
+// MaxPrecision is the maximum number of characters of a geohash.
+const MaxPrecision = 12
+
+// EarthRadius is the mean radius of the Earth in kilometers.
+const EarthRadius = 6371.0088
+
+const alphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Box is an area bounded by latitudes and longitudes in degrees. A box whose
+// minimum longitude is greater than its maximum longitude crosses the
+// antimeridian.
+type Box struct {
+	MinLat, MinLon float64
+	MaxLat, MaxLon float64
+}
+
+// Validate returns an error if the box is out of the bounds of the latitudes
+// and longitudes.
+func (b Box) Validate() error {
+	switch {
+	case !validLat(b.MinLat) || !validLat(b.MaxLat):
+		return errors.New("latitude must be between -90 and 90")
+	case !validLon(b.MinLon) || !validLon(b.MaxLon):
+		return errors.New("longitude must be between -180 and 180")
+	case b.MinLat > b.MaxLat:
+		return errors.New("minimum latitude must not be greater than the maximum latitude")
+	}
+	return nil
+}
+
+// Center returns the center of the box.
+func (b Box) Center() (lat, lon float64) {
+	maxLon := b.MaxLon
+	if b.MinLon > maxLon {
+		maxLon += 360
+	}
+	lon = (b.MinLon + maxLon) / 2
+	if lon > 180 {
+		lon -= 360
+	}
+	return (b.MinLat + b.MaxLat) / 2, lon
+}
+
+func validLat(lat float64) bool { return lat >= -90 && lat <= 90 }
+func validLon(lon float64) bool { return lon >= -180 && lon <= 180 }
+
+// bits returns the number of bits of the longitude and the latitude of a
+// geohash of the precision.
+func bits(precision int) (lonBits, latBits uint) {
+	n := uint(5 * precision)
+	return (n + 1) / 2, n / 2
+}
+
+// Encode returns the geohash of a location with the precision, which is
+// limited to MaxPrecision characters.
+func Encode(lat, lon float64, precision int) string {
+	if precision > MaxPrecision {
+		precision = MaxPrecision
+	}
+	lonBits, latBits := bits(precision)
+	x := cellIndex(lon+180, 360, lonBits)
+	y := cellIndex(lat+90, 180, latBits)
+	return encodeCell(x, y, precision)
+}
+
+// cellIndex returns the index of the cell of the offset v in a range of the
+// width divided in 2^n cells.
+func cellIndex(v, width float64, n uint) uint64 {
+	cells := uint64(1) << n
+	if v <= 0 {
+		return 0
+	}
+	i := uint64(v / width * float64(cells))
+	if i >= cells {
+		i = cells - 1
+	}
+	return i
+}
+
+// encodeCell returns the geohash of the cell of a column and a row. The bits
+// of the geohash interleave the bits of the column and the row, starting
+// with the column.
+func encodeCell(x, y uint64, precision int) string {
+	lonBits, latBits := bits(precision)
+	buf := make([]byte, precision)
+	var c byte
+	for i := 0; i < 5*precision; i++ {
+		var bit uint64
+		if i%2 == 0 {
+			lonBits--
+			bit = (x >> lonBits) & 1
+		} else {
+			latBits--
+			bit = (y >> latBits) & 1
+		}
+		c = c<<1 | byte(bit)
+		if i%5 == 4 {
+			buf[i/5] = alphabet[c]
+			c = 0
+		}
+	}
+	return string(buf)
+}
+
+// Decode returns the cell of a geohash, and false if it is not a valid
+// geohash.
+func Decode(hash string) (Box, bool) {
+	if len(hash) > MaxPrecision {
+		return Box{}, false
+	}
+	var x, y uint64
+	for i := 0; i < 5*len(hash); i++ {
+		c := strings.IndexByte(alphabet, hash[i/5])
+		if c < 0 {
+			return Box{}, false
+		}
+		bit := uint64(c>>uint(4-i%5)) & 1
+		if i%2 == 0 {
+			x = x<<1 | bit
+		} else {
+			y = y<<1 | bit
+		}
+	}
+	return cellBox(x, y, len(hash)), true
+}
+
+// cellBox returns the box of the cell of a column and a row.
+func cellBox(x, y uint64, precision int) Box {
+	lonBits, latBits := bits(precision)
+	w := 360 / float64(uint64(1)<<lonBits)
+	h := 180 / float64(uint64(1)<<latBits)
+	return Box{
+		MinLat: float64(y)*h - 90,
+		MinLon: float64(x)*w - 180,
+		MaxLat: float64(y+1)*h - 90,
+		MaxLon: float64(x+1)*w - 180,
+	}
+}
+
+// Distance returns the great-circle distance between two locations in
+// kilometers.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := radians(lat1), radians(lat2)
+	dphi, dlambda := radians(lat2-lat1), radians(lon2-lon1)
+	a := math.Sin(dphi/2)*math.Sin(dphi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dlambda/2)*math.Sin(dlambda/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+func degrees(rad float64) float64 { return rad * 180 / math.Pi }
+
+// CoverBox returns the geohashes of the cells covering a box. The cells are
+// the smallest ones for which at most maxCells cells cover the box, and the
+// cells whose every subcell is in the cover are merged into them.
+func CoverBox(b Box, maxCells int) []string {
+	return cover(b, maxCells, nil)
+}
+
+// CoverRadius returns the geohashes of the cells covering the circle of a
+// radius in kilometers around a location. The cells cover the bounding box
+// of the circle like CoverBox, without those entirely outside of the circle.
+func CoverRadius(lat, lon, km float64, maxCells int) []string {
+	// The bounding box spans all of the longitudes if the circle contains
+	// a pole.
+	d := km / EarthRadius
+	b := Box{MinLat: lat - degrees(d), MaxLat: lat + degrees(d), MinLon: -180, MaxLon: 180}
+	if b.MinLat > -90 && b.MaxLat < 90 {
+		if s := math.Sin(d) / math.Cos(radians(lat)); s < 1 {
+			dlon := degrees(math.Asin(s))
+			b.MinLon, b.MaxLon = lon-dlon, lon+dlon
+			if b.MinLon < -180 {
+				b.MinLon += 360
+			}
+			if b.MaxLon > 180 {
+				b.MaxLon -= 360
+			}
+		}
+	}
+	b.MinLat, b.MaxLat = math.Max(b.MinLat, -90), math.Min(b.MaxLat, 90)
+
+	// A cell is outside of the circle if its center is further from the
+	// location than the radius and the distance to its corners.
+	return cover(b, maxCells, func(c Box) bool {
+		clat, clon := c.Center()
+		r := math.Max(Distance(clat, clon, c.MinLat, c.MinLon), Distance(clat, clon, c.MaxLat, c.MinLon))
+		return Distance(lat, lon, clat, clon) <= km+r
+	})
+}
+
+// cover returns the geohashes of the cells covering a box which are kept by
+// the filter.
+func cover(b Box, maxCells int, keep func(Box) bool) []string {
+	// Find the highest precision covering the box with few enough cells.
+	precision := 1
+	for p := MaxPrecision; p > 1; p-- {
+		if _, _, _, _, n := coverCells(b, p); n <= uint64(maxCells) {
+			precision = p
+			break
+		}
+	}
+
+	x0, x1, y0, y1, _ := coverCells(b, precision)
+	lonBits, _ := bits(precision)
+	columns := uint64(1) << lonBits
+	var hashes []string
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			cx := x % columns
+			if keep != nil && !keep(cellBox(cx, y, precision)) {
+				continue
+			}
+			hashes = append(hashes, encodeCell(cx, y, precision))
+		}
+	}
+	return merge(hashes)
+}
+
+// coverCells returns the columns and the rows of the cells of the precision
+// covering a box, and their number. The last column is past the last column
+// of the grid if the box crosses the antimeridian.
+func coverCells(b Box, precision int) (x0, x1, y0, y1, n uint64) {
+	lonBits, latBits := bits(precision)
+	x0 = cellIndex(b.MinLon+180, 360, lonBits)
+	x1 = cellIndex(b.MaxLon+180, 360, lonBits)
+	y0 = cellIndex(b.MinLat+90, 180, latBits)
+	y1 = cellIndex(b.MaxLat+90, 180, latBits)
+	if b.MinLon > b.MaxLon {
+		x1 += uint64(1) << lonBits
+		if x1-x0 >= uint64(1)<<lonBits {
+			x1 = x0 + uint64(1)<<lonBits - 1
+		}
+	}
+	return x0, x1, y0, y1, (x1 - x0 + 1) * (y1 - y0 + 1)
+}
+
+// merge replaces the geohashes of all of the subcells of a cell by the
+// geohash of the cell, and returns the geohashes sorted.
+func merge(hashes []string) []string {
+	for {
+		children := make(map[string]int)
+		for _, h := range hashes {
+			if len(h) > 1 {
+				children[h[:len(h)-1]]++
+			}
+		}
+
+		merged := hashes[:0:0]
+		seen := make(map[string]bool)
+		for _, h := range hashes {
+			if len(h) > 1 && children[h[:len(h)-1]] == len(alphabet) {
+				h = h[:len(h)-1]
+				if seen[h] {
+					continue
+				}
+				seen[h] = true
+			}
+			merged = append(merged, h)
+		}
+		if len(merged) == len(hashes) {
+			sort.Strings(merged)
+			return merged
+		}
+		hashes = merged
+	}
+}
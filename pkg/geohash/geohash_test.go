@@ -0,0 +1,122 @@
+package geohash_test
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/pkg/geohash"
+)
+
+func TestEncode(t *testing.T) {
+	for _, tt := range []struct {
+		lat, lon  float64
+		precision int
+		exp       string
+	}{
+		{lat: 42.6, lon: -5.6, precision: 5, exp: "ezs42"},
+		{lat: 57.64911, lon: 10.40744, precision: 11, exp: "u4pruydqqvj"},
+		{lat: -90, lon: -180, precision: 3, exp: "000"},
+		{lat: 90, lon: 180, precision: 3, exp: "zzz"},
+	} {
+		if got := geohash.Encode(tt.lat, tt.lon, tt.precision); got != tt.exp {
+			t.Errorf("Encode(%v, %v, %d) = %s, expected %s", tt.lat, tt.lon, tt.precision, got, tt.exp)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	b, ok := geohash.Decode("ezs42")
+	if !ok {
+		t.Fatal("expected a valid geohash")
+	} else if !(b.MinLat <= 42.6 && 42.6 <= b.MaxLat && b.MinLon <= -5.6 && -5.6 <= b.MaxLon) {
+		t.Fatalf("unexpected cell: %+v", b)
+	}
+	if _, ok := geohash.Decode("ezs4a"); ok {
+		t.Fatal("expected an invalid geohash")
+	}
+}
+
+func TestCoverBox(t *testing.T) {
+	for _, b := range []geohash.Box{
+		{MinLat: 37.7, MinLon: -122.52, MaxLat: 37.82, MaxLon: -122.35},
+		{MinLat: -10, MinLon: 170, MaxLat: 10, MaxLon: -170},
+		{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180},
+	} {
+		hashes := geohash.CoverBox(b, 32)
+		if len(hashes) == 0 || len(hashes) > 32 {
+			t.Fatalf("unexpected number of cells covering %+v: %d", b, len(hashes))
+		}
+
+		rng := rand.New(rand.NewSource(0))
+		for i := 0; i < 1000; i++ {
+			lat := b.MinLat + rng.Float64()*(b.MaxLat-b.MinLat)
+			width := b.MaxLon - b.MinLon
+			if width < 0 {
+				width += 360
+			}
+			lon := b.MinLon + rng.Float64()*width
+			if lon > 180 {
+				lon -= 360
+			}
+			if !covered(hashes, geohash.Encode(lat, lon, geohash.MaxPrecision)) {
+				t.Fatalf("location %v, %v of %+v is not covered by %v", lat, lon, b, hashes)
+			}
+		}
+	}
+}
+
+func TestCoverRadius(t *testing.T) {
+	for _, tt := range []struct {
+		lat, lon, km float64
+	}{
+		{lat: 37.7749, lon: -122.4194, km: 5},
+		{lat: 0, lon: 179.99, km: 50},
+		{lat: 89.9, lon: 0, km: 100},
+		{lat: 51.5, lon: -0.12, km: 0.05},
+	} {
+		hashes := geohash.CoverRadius(tt.lat, tt.lon, tt.km, 32)
+		if len(hashes) == 0 || len(hashes) > 32 {
+			t.Fatalf("unexpected number of cells: %d", len(hashes))
+		}
+
+		// Every location within the radius is covered.
+		rng := rand.New(rand.NewSource(0))
+		for i := 0; i < 1000; i++ {
+			d := tt.km * math.Sqrt(rng.Float64()) / geohash.EarthRadius
+			theta := rng.Float64() * 2 * math.Pi
+			phi := tt.lat * math.Pi / 180
+			lat := math.Asin(math.Sin(phi)*math.Cos(d) + math.Cos(phi)*math.Sin(d)*math.Cos(theta))
+			lon := tt.lon*math.Pi/180 + math.Atan2(math.Sin(theta)*math.Sin(d)*math.Cos(phi), math.Cos(d)-math.Sin(phi)*math.Sin(lat))
+			lat, lon = lat*180/math.Pi, math.Remainder(lon*180/math.Pi, 360)
+			if !covered(hashes, geohash.Encode(lat, lon, geohash.MaxPrecision)) {
+				t.Fatalf("location %v, %v within %v km of %v, %v is not covered by %v", lat, lon, tt.km, tt.lat, tt.lon, hashes)
+			}
+		}
+	}
+
+	// Locations far from the circle are not covered.
+	hashes := geohash.CoverRadius(37.7749, -122.4194, 5, 32)
+	for _, loc := range [][2]float64{{37.8715, -122.2730}, {37.3382, -121.8863}, {-37.7749, 57.5806}} {
+		if covered(hashes, geohash.Encode(loc[0], loc[1], geohash.MaxPrecision)) {
+			t.Fatalf("location %v is unexpectedly covered by %v", loc, hashes)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	// San Francisco to Los Angeles.
+	if d := geohash.Distance(37.7749, -122.4194, 34.0522, -118.2437); math.Abs(d-559) > 1 {
+		t.Fatalf("unexpected distance: %v", d)
+	}
+}
+
+func covered(hashes []string, hash string) bool {
+	for _, h := range hashes {
+		if strings.HasPrefix(hash, h) {
+			return true
+		}
+	}
+	return false
+}
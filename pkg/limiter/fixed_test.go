@@ -2,6 +2,7 @@ package limiter_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/freetsdb/freetsdb/pkg/limiter"
 )
@@ -24,3 +25,38 @@ func TestFixed_Available(t *testing.T) {
 		t.Fatalf("available mismatch: exp %v, got %v", exp, got)
 	}
 }
+
+func TestReservation_SetLimit(t *testing.T) {
+	f := limiter.NewFixed(4)
+	r := limiter.NewReservation(f)
+
+	// A token taken by someone else is not revoked by lowering the limit.
+	f.Take()
+	r.SetLimit(2)
+	if got, exp := r.Limit(), 2; got != exp {
+		t.Fatalf("limit mismatch: exp %v, got %v", exp, got)
+	}
+	f.Release()
+
+	waitAvailable(t, f, 2)
+
+	r.SetLimit(3)
+	waitAvailable(t, f, 3)
+
+	r.Close()
+	if exp, got := 4, f.Available(); exp != got {
+		t.Fatalf("available mismatch: exp %v, got %v", exp, got)
+	}
+}
+
+// waitAvailable waits for the number of available tokens of f to be n.
+func waitAvailable(t *testing.T, f limiter.Fixed, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for f.Available() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("available mismatch: exp %v, got %v", n, f.Available())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
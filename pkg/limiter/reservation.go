@@ -0,0 +1,101 @@
+package limiter
+
+import "sync"
+
+// Reservation lowers the number of tokens that may be taken from a Fixed
+// limiter at runtime by holding the tokens over the limit. Tokens held by
+// others are never revoked: the limit is reached as they are released.
+type Reservation struct {
+	t Fixed
+
+	mu     sync.Mutex
+	target int // number of tokens to hold
+	held   int // number of tokens held
+
+	changed chan struct{}
+	closing chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewReservation returns a reservation of t, initially holding no tokens.
+func NewReservation(t Fixed) *Reservation {
+	r := &Reservation{
+		t:       t,
+		changed: make(chan struct{}, 1),
+		closing: make(chan struct{}),
+	}
+	r.wg.Add(1)
+	go r.run()
+	return r
+}
+
+// SetLimit sets the number of tokens that may be taken from the limiter to n,
+// between 1 and its capacity.
+func (r *Reservation) SetLimit(n int) {
+	if n < 1 {
+		n = 1
+	} else if n > r.t.Capacity() {
+		n = r.t.Capacity()
+	}
+
+	r.mu.Lock()
+	r.target = r.t.Capacity() - n
+	r.mu.Unlock()
+
+	select {
+	case r.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Limit returns the number of tokens that may be taken from the limiter.
+func (r *Reservation) Limit() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.t.Capacity() - r.target
+}
+
+// Close releases the tokens held.
+func (r *Reservation) Close() {
+	close(r.closing)
+	r.wg.Wait()
+}
+
+func (r *Reservation) run() {
+	defer r.wg.Done()
+	for {
+		r.mu.Lock()
+		for r.held > r.target {
+			r.t.Release()
+			r.held--
+		}
+		take := r.held < r.target
+		r.mu.Unlock()
+
+		if take {
+			select {
+			case r.t <- struct{}{}:
+				r.mu.Lock()
+				r.held++
+				r.mu.Unlock()
+				continue
+			case <-r.changed:
+				continue
+			case <-r.closing:
+			}
+		} else {
+			select {
+			case <-r.changed:
+				continue
+			case <-r.closing:
+			}
+		}
+
+		r.mu.Lock()
+		for ; r.held > 0; r.held-- {
+			r.t.Release()
+		}
+		r.mu.Unlock()
+		return
+	}
+}
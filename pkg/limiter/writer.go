@@ -3,7 +3,6 @@ package limiter
 import (
 	"context"
 	"io"
-	"os"
 	"time"
 
 	"golang.org/x/time/rate"
@@ -65,14 +64,14 @@ func (s *Writer) Write(b []byte) (int, error) {
 }
 
 func (s *Writer) Sync() error {
-	if f, ok := s.w.(*os.File); ok {
+	if f, ok := s.w.(interface{ Sync() error }); ok {
 		return f.Sync()
 	}
 	return nil
 }
 
 func (s *Writer) Name() string {
-	if f, ok := s.w.(*os.File); ok {
+	if f, ok := s.w.(interface{ Name() string }); ok {
 		return f.Name()
 	}
 	return ""
@@ -0,0 +1,88 @@
+// Package netutil provides helpers for working with listener addresses.
+package netutil
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// expectedForms describes the address forms accepted by ValidateBindAddress.
+const expectedForms = `expected "host:port", ":port", "[ipv6]:port" or "[ipv6%zone]:port"`
+
+// ValidateBindAddress returns an error describing why addr cannot be used as
+// a TCP or UDP bind address.
+//
+// An empty host, "0.0.0.0" or "[::]" binds to all interfaces. Binding to
+// "[::]" or an empty host accepts both IPv4 and IPv6 connections where the
+// operating system supports dual-stack sockets. IPv6 addresses must be
+// enclosed in brackets and may be scoped to an interface with a zone, e.g.
+// "[fe80::1%eth0]:8086".
+func ValidateBindAddress(addr string) error {
+	if addr == "" {
+		return fmt.Errorf("bind address is empty; %s", expectedForms)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		switch {
+		case !strings.HasPrefix(addr, "[") && strings.Count(addr, ":") > 1:
+			return fmt.Errorf("bind address %q is invalid: IPv6 addresses must be enclosed in brackets, e.g. \"[::1]:8086\"", addr)
+		case !strings.Contains(strings.TrimPrefix(addr, "["), ":") || strings.HasSuffix(addr, "]"):
+			return fmt.Errorf("bind address %q is missing a port; %s", addr, expectedForms)
+		}
+		return fmt.Errorf("bind address %q is invalid; %s", addr, expectedForms)
+	}
+
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return fmt.Errorf("bind address %q has invalid port %q; expected a number between 0 and 65535", addr, port)
+	}
+
+	bracketed := strings.HasPrefix(addr, "[")
+	ip, zone, scoped := host, "", false
+	if i := strings.LastIndexByte(host, '%'); i >= 0 {
+		ip, zone, scoped = host[:i], host[i+1:], true
+	}
+
+	parsed := net.ParseIP(ip)
+	switch {
+	case scoped && (parsed == nil || !strings.Contains(ip, ":")):
+		return fmt.Errorf("bind address %q is invalid: an interface zone is only valid on an IPv6 address, e.g. \"[fe80::1%%eth0]:8086\"", addr)
+	case bracketed && (parsed == nil || !strings.Contains(ip, ":")):
+		return fmt.Errorf("bind address %q is invalid: brackets are only valid around an IPv6 address", addr)
+	case scoped:
+		return validateZone(addr, zone)
+	}
+	return nil
+}
+
+// validateZone returns an error if zone does not name or index an interface
+// on this host.
+func validateZone(addr, zone string) error {
+	if zone == "" {
+		return fmt.Errorf("bind address %q has an empty interface zone", addr)
+	}
+
+	if index, err := strconv.Atoi(zone); err == nil {
+		if _, err := net.InterfaceByIndex(index); err != nil {
+			return fmt.Errorf("bind address %q is invalid: no network interface with index %d", addr, index)
+		}
+		return nil
+	}
+
+	if _, err := net.InterfaceByName(zone); err != nil {
+		return fmt.Errorf("bind address %q is invalid: no network interface named %q", addr, zone)
+	}
+	return nil
+}
+
+// IsUnspecified returns true if host binds to all interfaces: an empty
+// string or an unspecified IPv4 or IPv6 address such as "0.0.0.0" or "::".
+func IsUnspecified(host string) bool {
+	if host == "" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsUnspecified()
+}
@@ -0,0 +1,69 @@
+package netutil_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/pkg/netutil"
+)
+
+func TestValidateBindAddress(t *testing.T) {
+	for _, tt := range []struct {
+		addr string
+		err  string
+	}{
+		{addr: ":8086"},
+		{addr: "0.0.0.0:8086"},
+		{addr: "127.0.0.1:8086"},
+		{addr: "localhost:8086"},
+		{addr: "[::]:8086"},
+		{addr: "[::1]:8086"},
+		{addr: "[::ffff:127.0.0.1]:8086"},
+		{addr: "", err: "bind address is empty"},
+		{addr: "::1:8086", err: "must be enclosed in brackets"},
+		{addr: "localhost", err: "missing a port"},
+		{addr: "[::1]", err: "missing a port"},
+		{addr: ":http", err: `invalid port "http"`},
+		{addr: ":65536", err: `invalid port "65536"`},
+		{addr: "[127.0.0.1]:8086", err: "brackets are only valid around an IPv6 address"},
+		{addr: "[localhost]:8086", err: "brackets are only valid around an IPv6 address"},
+		{addr: "[127.0.0.1%lo]:8086", err: "zone is only valid on an IPv6 address"},
+		{addr: "[fe80::1%]:8086", err: "empty interface zone"},
+		{addr: "[fe80::1%no-such-if0]:8086", err: `no network interface named "no-such-if0"`},
+	} {
+		err := netutil.ValidateBindAddress(tt.addr)
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%q: unexpected error: %s", tt.addr, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: unexpected error: got %v, exp %q", tt.addr, err, tt.err)
+		}
+	}
+}
+
+func TestValidateBindAddress_Zone(t *testing.T) {
+	if _, err := net.InterfaceByName("lo"); err != nil {
+		t.Skipf("no loopback interface named lo: %s", err)
+	}
+	if err := netutil.ValidateBindAddress("[::1%lo]:8086"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestIsUnspecified(t *testing.T) {
+	for host, exp := range map[string]bool{
+		"":            true,
+		"0.0.0.0":     true,
+		"::":          true,
+		"0:0:0:0::0":  true,
+		"127.0.0.1":   false,
+		"::1":         false,
+		"example.com": false,
+	} {
+		if got := netutil.IsUnspecified(host); got != exp {
+			t.Errorf("%q: got %v, exp %v", host, got, exp)
+		}
+	}
+}
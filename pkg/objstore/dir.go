@@ -0,0 +1,107 @@
+package objstore
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DirBucket is a Bucket storing each object as a file of a directory, such
+// as a mount of a network filesystem.
+type DirBucket struct {
+	dir string
+}
+
+// NewDirBucket returns a bucket storing its objects in dir.
+func NewDirBucket(dir string) *DirBucket {
+	return &DirBucket{dir: dir}
+}
+
+// path returns the path of the file of the object key.
+func (b *DirBucket) path(key string) string {
+	return filepath.Join(b.dir, filepath.FromSlash(key))
+}
+
+// Put writes the size bytes of r as the object key. The object is written to
+// a temporary file first, so that it is never seen partly written.
+func (b *DirBucket) Put(key string, r io.Reader, size int64) error {
+	path := b.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path+".part", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(f, r, size); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Get returns the content of the object key.
+func (b *DirBucket) Get(key string) (io.ReadCloser, error) {
+	f, err := os.Open(b.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete removes the object key.
+func (b *DirBucket) Delete(key string) error {
+	if err := os.Remove(b.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// List returns the objects whose keys start with prefix.
+func (b *DirBucket) List(prefix string) ([]Object, error) {
+	// Only the directory holding the prefix is walked.
+	root := b.dir
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		root = b.path(prefix[:i])
+	}
+
+	var objects []Object
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		} else if fi.IsDir() || strings.HasSuffix(path, ".part") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(b.dir, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			objects = append(objects, Object{Key: key, Size: fi.Size(), Modified: fi.ModTime()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
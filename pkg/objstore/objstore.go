@@ -0,0 +1,96 @@
+// Package objstore stores files as objects in a bucket of object storage,
+// such as Amazon S3 or a compatible service, or in a directory.
+package objstore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when reading an object that does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Object is an object of a bucket.
+type Object struct {
+	Key      string
+	Size     int64
+	Modified time.Time
+}
+
+// Bucket is a bucket of objects. Keys are paths separated by '/'.
+type Bucket interface {
+	// Put writes the size bytes of r as the object key, replacing any object
+	// with the same key.
+	Put(key string, r io.Reader, size int64) error
+
+	// Get returns the content of the object key, or ErrNotFound.
+	Get(key string) (io.ReadCloser, error)
+
+	// Delete removes the object key. Removing an object that does not exist
+	// is not an error.
+	Delete(key string) error
+
+	// List returns the objects whose keys start with prefix, ordered by key.
+	List(prefix string) ([]Object, error)
+}
+
+// Options are the options of the bucket opened by Open.
+type Options struct {
+	// Endpoint is the URL of an S3 compatible service. If it is empty, the
+	// endpoint of Amazon S3 for Region is used.
+	Endpoint string
+
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// Open opens the bucket at rawurl, of the form "s3://bucket/prefix" or
+// "file:///path". It returns the bucket and the prefix of the keys within
+// it.
+func Open(rawurl string, opt Options) (Bucket, string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch u.Scheme {
+	case "s3":
+		if u.Host == "" {
+			return nil, "", fmt.Errorf("missing bucket in object store URL %q", rawurl)
+		}
+		b, err := NewS3Bucket(S3Config{
+			Endpoint:  opt.Endpoint,
+			Region:    opt.Region,
+			Bucket:    u.Host,
+			AccessKey: opt.AccessKey,
+			SecretKey: opt.SecretKey,
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		return b, strings.Trim(u.Path, "/"), nil
+	case "file":
+		if u.Path == "" {
+			return nil, "", fmt.Errorf("missing path in object store URL %q", rawurl)
+		}
+		return NewDirBucket(u.Path), "", nil
+	default:
+		return nil, "", fmt.Errorf("unsupported object store URL scheme %q", u.Scheme)
+	}
+}
+
+// Join joins the elements of a key, skipping empty ones.
+func Join(elem ...string) string {
+	a := make([]string, 0, len(elem))
+	for _, e := range elem {
+		if e = strings.Trim(e, "/"); e != "" {
+			a = append(a, e)
+		}
+	}
+	return strings.Join(a, "/")
+}
@@ -0,0 +1,152 @@
+package objstore_test
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/pkg/objstore"
+)
+
+func TestDirBucket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "objstore-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	testBucket(t, objstore.NewDirBucket(dir))
+}
+
+func TestS3Bucket(t *testing.T) {
+	s := httptest.NewServer(newFakeS3())
+	defer s.Close()
+
+	b, err := objstore.NewS3Bucket(objstore.S3Config{
+		Endpoint:  s.URL,
+		Bucket:    "data",
+		AccessKey: "key",
+		SecretKey: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBucket(t, b)
+}
+
+func TestOpen(t *testing.T) {
+	if _, prefix, err := objstore.Open("s3://data/cluster0/", objstore.Options{Endpoint: "http://localhost:9000"}); err != nil {
+		t.Fatal(err)
+	} else if prefix != "cluster0" {
+		t.Fatalf("unexpected prefix: %q", prefix)
+	}
+	if _, _, err := objstore.Open("gs://data", objstore.Options{}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// testBucket ensures objects are written, listed, read and removed.
+func testBucket(t *testing.T, b objstore.Bucket) {
+	for _, key := range []string{"db/rp/1/a.tsm", "db/rp/1/b.tsm", "db/rp/2/a.tsm"} {
+		if err := b.Put(key, strings.NewReader(key), int64(len(key))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	objects, err := b.List("db/rp/1/")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(objects) != 2 || objects[0].Key != "db/rp/1/a.tsm" || objects[1].Key != "db/rp/1/b.tsm" || objects[0].Size != 13 {
+		t.Fatalf("unexpected objects: %+v", objects)
+	}
+
+	rc, err := b.Get("db/rp/2/a.tsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	} else if string(buf) != "db/rp/2/a.tsm" {
+		t.Fatalf("unexpected content: %q", buf)
+	}
+
+	if err := b.Delete("db/rp/1/a.tsm"); err != nil {
+		t.Fatal(err)
+	} else if err := b.Delete("db/rp/1/a.tsm"); err != nil {
+		t.Fatal(err)
+	} else if _, err := b.Get("db/rp/1/a.tsm"); err != objstore.ErrNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	} else if objects, err := b.List("db/"); err != nil {
+		t.Fatal(err)
+	} else if len(objects) != 2 {
+		t.Fatalf("unexpected objects: %+v", objects)
+	}
+}
+
+// fakeS3 is an in-memory S3 service serving a single bucket by path.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: make(map[string][]byte)}
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/data"), "/")
+	switch {
+	case r.Method == "GET" && key == "":
+		var result struct {
+			XMLName  xml.Name `xml:"ListBucketResult"`
+			Contents []struct {
+				Key  string
+				Size int
+			}
+		}
+		prefix := r.URL.Query().Get("prefix")
+		var keys []string
+		for k := range s.objects {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			result.Contents = append(result.Contents, struct {
+				Key  string
+				Size int
+			}{k, len(s.objects[k])})
+		}
+		xml.NewEncoder(w).Encode(result)
+	case r.Method == "PUT":
+		buf, _ := ioutil.ReadAll(r.Body)
+		s.objects[key] = buf
+	case r.Method == "GET":
+		buf, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(buf)
+	case r.Method == "DELETE":
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,313 @@
+package objstore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// unsignedPayload is the payload hash of requests whose body is not signed,
+// so that files are not read twice to be uploaded.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// emptyPayload is the payload hash of requests without a body.
+const emptyPayload = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Config is the configuration of a bucket of Amazon S3 or of a compatible
+// service, such as MinIO.
+type S3Config struct {
+	// Endpoint is the URL of the service. If it is empty, the endpoint of
+	// Amazon S3 for Region is used.
+	Endpoint string
+
+	Region string
+	Bucket string
+
+	// AccessKey and SecretKey sign the requests. If they are empty, the
+	// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables are
+	// used.
+	AccessKey string
+	SecretKey string
+}
+
+// S3Bucket is a Bucket of Amazon S3 or of a compatible service. Objects are
+// addressed by path, as required by most compatible services.
+type S3Bucket struct {
+	config   S3Config
+	endpoint *url.URL
+
+	// Client sends the requests.
+	Client *http.Client
+
+	// now returns the time requests are signed at.
+	now func() time.Time
+}
+
+// NewS3Bucket returns the bucket of config.
+func NewS3Bucket(config S3Config) (*S3Bucket, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("s3 bucket required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", config.Region)
+	}
+	if config.AccessKey == "" && config.SecretKey == "" {
+		config.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		config.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %s", err)
+	} else if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %q", config.Endpoint)
+	}
+
+	return &S3Bucket{
+		config:   config,
+		endpoint: endpoint,
+		Client:   http.DefaultClient,
+		now:      time.Now,
+	}, nil
+}
+
+// Put uploads the size bytes of r as the object key.
+func (b *S3Bucket) Put(key string, r io.Reader, size int64) error {
+	req, err := b.newRequest("PUT", key, nil, ioutil.NopCloser(io.LimitReader(r, size)))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+
+	resp, err := b.do(req, unsignedPayload)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get downloads the object key.
+func (b *S3Bucket) Get(key string) (io.ReadCloser, error) {
+	req, err := b.newRequest("GET", key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.do(req, emptyPayload)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes the object key.
+func (b *S3Bucket) Delete(key string) error {
+	req, err := b.newRequest("DELETE", key, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := b.do(req, emptyPayload)
+	if err == ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// listBucketResult is the response of the ListObjectsV2 request.
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List returns the objects whose keys start with prefix.
+func (b *S3Bucket) List(prefix string) ([]Object, error) {
+	var objects []Object
+	var token string
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := b.newRequest("GET", "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := b.do(req, emptyPayload)
+		if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode s3 list response: %s", err)
+		}
+
+		for _, c := range result.Contents {
+			objects = append(objects, Object{Key: c.Key, Size: c.Size, Modified: c.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// newRequest returns a request for the object key of the bucket, or for the
+// bucket itself if key is empty.
+func (b *S3Bucket) newRequest(method, key string, query url.Values, body io.ReadCloser) (*http.Request, error) {
+	u := *b.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + b.config.Bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	u.RawPath = uriEncode(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Body = body
+	}
+	return req, nil
+}
+
+// do signs and sends req. It returns ErrNotFound for a 404 response, and an
+// error with the message of the service for other unsuccessful responses.
+func (b *S3Bucket) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	b.sign(req, payloadHash, b.now().UTC())
+
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+	if xml.Unmarshal(body, &e) == nil && e.Code != "" {
+		return nil, fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, e.Code, e.Message)
+	}
+	return nil, fmt.Errorf("s3 %s %s: %s", req.Method, req.URL.Path, resp.Status)
+}
+
+// sign signs req with the AWS signature version 4.
+func (b *S3Bucket) sign(req *http.Request, payloadHash string, now time.Time) {
+	date := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", date)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if b.config.AccessKey == "" {
+		return
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + date + "\n",
+		"host;x-amz-content-sha256;x-amz-date",
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + b.config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + date + "\n" + scope + "\n" + hexSHA256(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+b.config.SecretKey), day)
+	key = hmacSHA256(key, b.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+b.config.AccessKey+"/"+scope+
+		", SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="+signature)
+}
+
+// canonicalQuery encodes query as required by the signature, with the keys
+// sorted and every reserved character escaped.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var a []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			a = append(a, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(a, "&")
+}
+
+// uriEncode escapes every character of s but the unreserved ones. Slashes
+// are escaped only if encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			buf.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			buf.WriteByte(c)
+		default:
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	h := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,372 @@
+// Package relabel rewrites the measurement, tags and fields of points as they
+// are written, using rules modeled on Prometheus relabel_configs.
+package relabel // import "github.com/freetsdb/freetsdb/pkg/relabel"
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/freetsdb/freetsdb/models"
+)
+
+// MeasurementTag is the name used in the source tags and the target tag of a
+// rule to refer to the measurement of a point.
+const MeasurementTag = "_measurement"
+
+// Rule actions.
+const (
+	// ActionReplace sets the target tag to the replacement when the regex
+	// matches the source value. An empty result removes the target tag.
+	ActionReplace = "replace"
+
+	// ActionKeep drops the points whose source value does not match the regex.
+	ActionKeep = "keep"
+
+	// ActionDrop drops the points whose source value matches the regex.
+	ActionDrop = "drop"
+
+	// ActionHashMod sets the target tag to the hash of the source value
+	// modulo the modulus.
+	ActionHashMod = "hashmod"
+
+	// ActionTagMap renames the tags whose keys match the regex to the
+	// replacement.
+	ActionTagMap = "tagmap"
+
+	// ActionTagDrop removes the tags whose keys match the regex.
+	ActionTagDrop = "tagdrop"
+
+	// ActionTagKeep removes the tags whose keys do not match the regex.
+	ActionTagKeep = "tagkeep"
+
+	// ActionFieldMap renames the fields whose keys match the regex to the
+	// replacement.
+	ActionFieldMap = "fieldmap"
+
+	// ActionFieldDrop removes the fields whose keys match the regex. Points
+	// left without fields are dropped.
+	ActionFieldDrop = "fielddrop"
+)
+
+const (
+	// DefaultSeparator is the default separator of the source values.
+	DefaultSeparator = ";"
+
+	// DefaultRegex is the default regex of a rule.
+	DefaultRegex = "(.*)"
+
+	// DefaultReplacement is the default replacement of a rule.
+	DefaultReplacement = "$1"
+)
+
+// Rule is a relabeling rule. The source value of a point is the values of its
+// source tags joined by the separator, and the regex is anchored at both
+// ends. A replace rule without source tags sets a static tag.
+type Rule struct {
+	Action      string   `toml:"action"`
+	SourceTags  []string `toml:"source-tags"`
+	Separator   *string  `toml:"separator"`
+	Regex       *string  `toml:"regex"`
+	TargetTag   string   `toml:"target-tag"`
+	Replacement *string  `toml:"replacement"`
+	Modulus     uint64   `toml:"modulus"`
+}
+
+// Validate returns an error if the rule is invalid.
+func (r Rule) Validate() error {
+	_, err := compile(r)
+	return err
+}
+
+// Validate returns an error if any of rules is invalid.
+func Validate(rules []Rule) error {
+	_, err := compileAll(rules)
+	return err
+}
+
+// rule is a rule with its defaults applied and its regex compiled.
+type rule struct {
+	action      string
+	sourceTags  []string
+	separator   string
+	regex       *regexp.Regexp
+	targetTag   string
+	replacement string
+	modulus     uint64
+}
+
+func compile(r Rule) (*rule, error) {
+	c := &rule{
+		action:      r.Action,
+		sourceTags:  r.SourceTags,
+		separator:   DefaultSeparator,
+		targetTag:   r.TargetTag,
+		replacement: DefaultReplacement,
+		modulus:     r.Modulus,
+	}
+	if c.action == "" {
+		c.action = ActionReplace
+	}
+	if r.Separator != nil {
+		c.separator = *r.Separator
+	}
+	if r.Replacement != nil {
+		c.replacement = *r.Replacement
+	}
+
+	expr := DefaultRegex
+	if r.Regex != nil {
+		expr = *r.Regex
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %s", expr, err)
+	}
+	c.regex = re
+
+	switch c.action {
+	case ActionReplace:
+		if c.targetTag == "" {
+			return nil, fmt.Errorf("%s rule requires a target-tag", c.action)
+		}
+	case ActionHashMod:
+		if c.targetTag == "" {
+			return nil, fmt.Errorf("%s rule requires a target-tag", c.action)
+		} else if c.modulus == 0 {
+			return nil, fmt.Errorf("%s rule requires a modulus", c.action)
+		}
+	case ActionKeep, ActionDrop:
+		if len(c.sourceTags) == 0 {
+			return nil, fmt.Errorf("%s rule requires source-tags", c.action)
+		}
+	case ActionTagMap, ActionFieldMap, ActionTagDrop, ActionTagKeep, ActionFieldDrop:
+	default:
+		return nil, fmt.Errorf("unknown action %q", c.action)
+	}
+	return c, nil
+}
+
+func compileAll(rules []Rule) ([]*rule, error) {
+	a := make([]*rule, 0, len(rules))
+	for i, r := range rules {
+		c, err := compile(r)
+		if err != nil {
+			return nil, fmt.Errorf("relabel rule %d: %s", i, err)
+		}
+		a = append(a, c)
+	}
+	return a, nil
+}
+
+// Relabeler applies relabeling rules to points. Its rules can be replaced
+// while it is in use. A nil Relabeler leaves points unchanged.
+type Relabeler struct {
+	mu    sync.RWMutex
+	rules []*rule
+}
+
+// New returns a Relabeler applying rules.
+func New(rules []Rule) (*Relabeler, error) {
+	r := &Relabeler{}
+	if err := r.SetRules(rules); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SetRules replaces the rules of r. The rules are left unchanged if any of
+// rules is invalid.
+func (r *Relabeler) SetRules(rules []Rule) error {
+	a, err := compileAll(rules)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.rules = a
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Relabeler) loadRules() []*rule {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.rules
+}
+
+// Apply relabels points and returns the points that were kept. points is
+// reused for the result.
+func (r *Relabeler) Apply(points []models.Point) []models.Point {
+	rules := r.loadRules()
+	if len(rules) == 0 {
+		return points
+	}
+
+	kept := points[:0]
+	for _, p := range points {
+		if p, ok := relabel(rules, p); ok {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// Relabel relabels p. It returns false if the point is dropped.
+func (r *Relabeler) Relabel(p models.Point) (models.Point, bool) {
+	rules := r.loadRules()
+	if len(rules) == 0 {
+		return p, true
+	}
+	return relabel(rules, p)
+}
+
+// labels holds the parts of a point being relabeled. The fields are only
+// parsed if a rule needs them.
+type labels struct {
+	name    string
+	tags    map[string]string
+	fields  models.Fields
+	changed bool
+}
+
+func (l *labels) get(key string) string {
+	if key == MeasurementTag {
+		return l.name
+	}
+	return l.tags[key]
+}
+
+func (l *labels) set(key, value string) {
+	if key == MeasurementTag {
+		if value != "" && value != l.name {
+			l.name, l.changed = value, true
+		}
+		return
+	}
+
+	if old, ok := l.tags[key]; value == "" {
+		if ok {
+			delete(l.tags, key)
+			l.changed = true
+		}
+	} else if !ok || old != value {
+		l.tags[key] = value
+		l.changed = true
+	}
+}
+
+func relabel(rules []*rule, p models.Point) (models.Point, bool) {
+	l := &labels{name: string(p.Name()), tags: p.Tags().Map()}
+	for _, r := range rules {
+		if !r.apply(l, p) {
+			return nil, false
+		}
+	}
+	if !l.changed {
+		return p, true
+	}
+
+	if l.fields == nil {
+		fields, err := p.Fields()
+		if err != nil {
+			return nil, false
+		}
+		l.fields = fields
+	}
+	np, err := models.NewPoint(l.name, models.NewTags(l.tags), l.fields, p.Time())
+	if err != nil {
+		return nil, false
+	}
+	return np, true
+}
+
+// apply applies r to l, the labels of p. It returns false if the point is
+// dropped.
+func (r *rule) apply(l *labels, p models.Point) bool {
+	switch r.action {
+	case ActionReplace:
+		value := r.sourceValue(l)
+		m := r.regex.FindStringSubmatchIndex(value)
+		if m == nil {
+			return true
+		}
+		l.set(r.targetTag, string(r.regex.ExpandString(nil, r.replacement, value, m)))
+	case ActionKeep:
+		return r.regex.MatchString(r.sourceValue(l))
+	case ActionDrop:
+		return !r.regex.MatchString(r.sourceValue(l))
+	case ActionHashMod:
+		h := fnv.New64a()
+		h.Write([]byte(r.sourceValue(l)))
+		l.set(r.targetTag, strconv.FormatUint(h.Sum64()%r.modulus, 10))
+	case ActionTagMap:
+		tags := make(map[string]string, len(l.tags))
+		for k, v := range l.tags {
+			if m := r.regex.FindStringSubmatchIndex(k); m != nil {
+				if nk := string(r.regex.ExpandString(nil, r.replacement, k, m)); nk != k {
+					k, l.changed = nk, true
+				}
+			}
+			if k != "" {
+				tags[k] = v
+			}
+		}
+		l.tags = tags
+	case ActionTagDrop, ActionTagKeep:
+		for k := range l.tags {
+			if r.regex.MatchString(k) == (r.action == ActionTagDrop) {
+				delete(l.tags, k)
+				l.changed = true
+			}
+		}
+	case ActionFieldMap, ActionFieldDrop:
+		if l.fields == nil {
+			fields, err := p.Fields()
+			if err != nil {
+				return false
+			}
+			l.fields = fields
+		}
+		fields := make(models.Fields, len(l.fields))
+		for k, v := range l.fields {
+			if m := r.regex.FindStringSubmatchIndex(k); m != nil {
+				if r.action == ActionFieldDrop {
+					l.changed = true
+					continue
+				}
+				if nk := string(r.regex.ExpandString(nil, r.replacement, k, m)); nk != k {
+					k, l.changed = nk, true
+				}
+			}
+			if k != "" {
+				fields[k] = v
+			}
+		}
+		l.fields = fields
+		return len(l.fields) > 0
+	}
+	return true
+}
+
+// sourceValue returns the values of the source tags of r joined by its
+// separator.
+func (r *rule) sourceValue(l *labels) string {
+	switch len(r.sourceTags) {
+	case 0:
+		return ""
+	case 1:
+		return l.get(r.sourceTags[0])
+	}
+	values := make([]string, len(r.sourceTags))
+	for i, k := range r.sourceTags {
+		values[i] = l.get(k)
+	}
+	return strings.Join(values, r.separator)
+}
@@ -0,0 +1,177 @@
+package relabel_test
+
+import (
+	"testing"
+
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/pkg/relabel"
+)
+
+func str(s string) *string { return &s }
+
+func TestRelabeler_Apply(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []relabel.Rule
+		in    string
+		out   string
+	}{
+		{
+			name:  "static tag",
+			rules: []relabel.Rule{{TargetTag: "dc", Replacement: str("west")}},
+			in:    "cpu,host=a value=1 10",
+			out:   "cpu,dc=west,host=a value=1 10",
+		},
+		{
+			name: "replace from tags",
+			rules: []relabel.Rule{{
+				SourceTags:  []string{"host", "region"},
+				Regex:       str("(.*);(.*)"),
+				TargetTag:   "instance",
+				Replacement: str("$1.$2"),
+			}},
+			in:  "cpu,host=a,region=us value=1 10",
+			out: "cpu,host=a,instance=a.us,region=us value=1 10",
+		},
+		{
+			name: "route measurement",
+			rules: []relabel.Rule{{
+				SourceTags:  []string{relabel.MeasurementTag},
+				Regex:       str("app_(.*)"),
+				TargetTag:   relabel.MeasurementTag,
+				Replacement: str("$1"),
+			}},
+			in:  "app_cpu,host=a value=1 10",
+			out: "cpu,host=a value=1 10",
+		},
+		{
+			name:  "empty replacement removes tag",
+			rules: []relabel.Rule{{SourceTags: []string{"host"}, Regex: str("a"), TargetTag: "host", Replacement: str("")}},
+			in:    "cpu,host=a value=1 10",
+			out:   "cpu value=1 10",
+		},
+		{
+			name:  "keep",
+			rules: []relabel.Rule{{Action: relabel.ActionKeep, SourceTags: []string{"host"}, Regex: str("b")}},
+			in:    "cpu,host=a value=1 10",
+		},
+		{
+			name:  "drop",
+			rules: []relabel.Rule{{Action: relabel.ActionDrop, SourceTags: []string{relabel.MeasurementTag}, Regex: str("cp.")}},
+			in:    "cpu,host=a value=1 10",
+		},
+		{
+			name:  "hashmod",
+			rules: []relabel.Rule{{Action: relabel.ActionHashMod, SourceTags: []string{"host"}, TargetTag: "bucket", Modulus: 1}},
+			in:    "cpu,host=a value=1 10",
+			out:   "cpu,bucket=0,host=a value=1 10",
+		},
+		{
+			name:  "tagmap",
+			rules: []relabel.Rule{{Action: relabel.ActionTagMap, Regex: str("k8s_(.*)")}},
+			in:    "cpu,host=a,k8s_pod=p value=1 10",
+			out:   "cpu,host=a,pod=p value=1 10",
+		},
+		{
+			name:  "tagdrop",
+			rules: []relabel.Rule{{Action: relabel.ActionTagDrop, Regex: str("tmp_.*")}},
+			in:    "cpu,host=a,tmp_id=1 value=1 10",
+			out:   "cpu,host=a value=1 10",
+		},
+		{
+			name:  "tagkeep",
+			rules: []relabel.Rule{{Action: relabel.ActionTagKeep, Regex: str("host")}},
+			in:    "cpu,host=a,tmp_id=1 value=1 10",
+			out:   "cpu,host=a value=1 10",
+		},
+		{
+			name:  "fieldmap",
+			rules: []relabel.Rule{{Action: relabel.ActionFieldMap, Regex: str("(.*)"), Replacement: str("cpu_$1")}},
+			in:    "cpu,host=a value=1 10",
+			out:   "cpu,host=a cpu_value=1 10",
+		},
+		{
+			name:  "fielddrop",
+			rules: []relabel.Rule{{Action: relabel.ActionFieldDrop, Regex: str("debug")}},
+			in:    "cpu,host=a debug=2,value=1 10",
+			out:   "cpu,host=a value=1 10",
+		},
+		{
+			name:  "fielddrop all fields",
+			rules: []relabel.Rule{{Action: relabel.ActionFieldDrop}},
+			in:    "cpu,host=a value=1 10",
+		},
+		{
+			name:  "no match",
+			rules: []relabel.Rule{{SourceTags: []string{"host"}, Regex: str("b"), TargetTag: "x"}},
+			in:    "cpu,host=a value=1 10",
+			out:   "cpu,host=a value=1 10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := relabel.New(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			points, err := models.ParsePointsString(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			points = r.Apply(points)
+			if tt.out == "" {
+				if len(points) != 0 {
+					t.Fatalf("expected point to be dropped, got %s", points[0])
+				}
+				return
+			}
+			if len(points) != 1 {
+				t.Fatalf("unexpected number of points: %d", len(points))
+			} else if got := points[0].String(); got != tt.out {
+				t.Fatalf("unexpected point:\ngot  %s\nwant %s", got, tt.out)
+			}
+		})
+	}
+}
+
+func TestRelabeler_SetRules(t *testing.T) {
+	r, err := relabel.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	points, _ := models.ParsePointsString("cpu value=1 10")
+	if p, ok := r.Relabel(points[0]); !ok || p != points[0] {
+		t.Fatal("expected point to be unchanged")
+	}
+
+	if err := r.SetRules([]relabel.Rule{{Action: relabel.ActionKeep, SourceTags: []string{"host"}, Regex: str(".+")}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Relabel(points[0]); ok {
+		t.Fatal("expected point to be dropped")
+	}
+
+	// Invalid rules leave the current rules in place.
+	if err := r.SetRules([]relabel.Rule{{Regex: str("(")}}); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, ok := r.Relabel(points[0]); ok {
+		t.Fatal("expected point to be dropped")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, rules := range [][]relabel.Rule{
+		{{Action: "unknown"}},
+		{{Action: relabel.ActionReplace}},
+		{{Action: relabel.ActionHashMod, TargetTag: "x"}},
+		{{Action: relabel.ActionDrop}},
+		{{TargetTag: "x", Regex: str("(")}},
+	} {
+		if err := relabel.Validate(rules); err == nil {
+			t.Fatalf("expected error for %+v", rules)
+		}
+	}
+}
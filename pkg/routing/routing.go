@@ -0,0 +1,180 @@
+// Package routing directs written points to databases and retention policies
+// chosen by rules on their measurement and tags, so that writers do not need
+// to know how data is laid out across databases.
+package routing // import "github.com/freetsdb/freetsdb/pkg/routing"
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/freetsdb/freetsdb/models"
+)
+
+// Rule routes the points whose measurement and tags match to a database and
+// retention policy. The measurement and the tag values are regexes anchored
+// at both ends, and a point without one of the tags matches its regex
+// against an empty value. An empty retention policy routes to the default
+// retention policy of the database.
+type Rule struct {
+	Measurement     string            `toml:"measurement"`
+	Tags            map[string]string `toml:"tags"`
+	Database        string            `toml:"database"`
+	RetentionPolicy string            `toml:"retention-policy"`
+}
+
+// Validate returns an error if any of rules is invalid.
+func Validate(rules []Rule) error {
+	_, err := compileAll(rules)
+	return err
+}
+
+// Destination is a database and retention policy points are written to.
+type Destination struct {
+	Database        string
+	RetentionPolicy string
+}
+
+// Batch is the points routed to a destination.
+type Batch struct {
+	Destination
+	Points []models.Point
+}
+
+type tagMatcher struct {
+	key   []byte
+	regex *regexp.Regexp
+}
+
+type rule struct {
+	measurement *regexp.Regexp
+	tags        []tagMatcher
+	dest        Destination
+}
+
+func anchor(expr string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %s", expr, err)
+	}
+	return re, nil
+}
+
+func compile(r Rule) (*rule, error) {
+	if r.Database == "" {
+		return nil, errors.New("database is required")
+	} else if r.Measurement == "" && len(r.Tags) == 0 {
+		return nil, errors.New("measurement or tags are required")
+	}
+
+	c := &rule{dest: Destination{Database: r.Database, RetentionPolicy: r.RetentionPolicy}}
+	if r.Measurement != "" {
+		re, err := anchor(r.Measurement)
+		if err != nil {
+			return nil, err
+		}
+		c.measurement = re
+	}
+	for k, v := range r.Tags {
+		re, err := anchor(v)
+		if err != nil {
+			return nil, err
+		}
+		c.tags = append(c.tags, tagMatcher{key: []byte(k), regex: re})
+	}
+	return c, nil
+}
+
+func compileAll(rules []Rule) ([]*rule, error) {
+	a := make([]*rule, 0, len(rules))
+	for i, r := range rules {
+		c, err := compile(r)
+		if err != nil {
+			return nil, fmt.Errorf("route %d: %s", i, err)
+		}
+		a = append(a, c)
+	}
+	return a, nil
+}
+
+func (r *rule) match(p models.Point) bool {
+	if r.measurement != nil && !r.measurement.Match(p.Name()) {
+		return false
+	}
+	if len(r.tags) > 0 {
+		tags := p.Tags()
+		for _, t := range r.tags {
+			if !t.regex.Match(tags.Get(t.key)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Router splits points by destination. Its rules can be replaced while it is
+// in use. A nil Router routes every point to the default destination.
+type Router struct {
+	mu    sync.RWMutex
+	rules []*rule
+}
+
+// New returns a Router applying rules.
+func New(rules []Rule) (*Router, error) {
+	r := &Router{}
+	if err := r.SetRules(rules); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SetRules replaces the rules of r. The rules are left unchanged if any of
+// rules is invalid.
+func (r *Router) SetRules(rules []Rule) error {
+	a, err := compileAll(rules)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.rules = a
+	r.mu.Unlock()
+	return nil
+}
+
+// Route splits points by the destination of the first rule they match. Points
+// that match no rule are routed to def. Batches are returned in the order of
+// their first point.
+func (r *Router) Route(def Destination, points []models.Point) []Batch {
+	var rules []*rule
+	if r != nil {
+		r.mu.RLock()
+		rules = r.rules
+		r.mu.RUnlock()
+	}
+	if len(rules) == 0 {
+		return []Batch{{Destination: def, Points: points}}
+	}
+
+	var batches []Batch
+	index := make(map[Destination]int)
+	for _, p := range points {
+		dest := def
+		for _, rr := range rules {
+			if rr.match(p) {
+				dest = rr.dest
+				break
+			}
+		}
+
+		i, ok := index[dest]
+		if !ok {
+			i = len(batches)
+			index[dest] = i
+			batches = append(batches, Batch{Destination: dest})
+		}
+		batches[i].Points = append(batches[i].Points, p)
+	}
+	return batches
+}
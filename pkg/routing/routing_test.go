@@ -0,0 +1,76 @@
+package routing_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/pkg/routing"
+)
+
+func TestRouter_Route(t *testing.T) {
+	r, err := routing.New([]routing.Rule{
+		{Measurement: "cpu", Tags: map[string]string{"env": "prod"}, Database: "prod_metrics"},
+		{Tags: map[string]string{"env": "dev|test"}, Database: "dev_metrics", RetentionPolicy: "short"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	points, err := models.ParsePointsString(`cpu,env=prod value=1 10
+mem,env=prod value=2 10
+cpu,env=test value=3 10
+cpu value=4 10
+cpu,env=prod value=5 10`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	def := routing.Destination{Database: "db0", RetentionPolicy: "rp0"}
+	batches := r.Route(def, points)
+
+	got := make(map[routing.Destination][]string)
+	var order []routing.Destination
+	for _, b := range batches {
+		order = append(order, b.Destination)
+		for _, p := range b.Points {
+			got[b.Destination] = append(got[b.Destination], p.String())
+		}
+	}
+
+	prod := routing.Destination{Database: "prod_metrics"}
+	dev := routing.Destination{Database: "dev_metrics", RetentionPolicy: "short"}
+	if exp := []routing.Destination{prod, def, dev}; !reflect.DeepEqual(order, exp) {
+		t.Fatalf("unexpected destinations: %v", order)
+	}
+	if exp := map[routing.Destination][]string{
+		prod: {"cpu,env=prod value=1 10", "cpu,env=prod value=5 10"},
+		def:  {"mem,env=prod value=2 10", "cpu value=4 10"},
+		dev:  {"cpu,env=test value=3 10"},
+	}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected batches: %v", got)
+	}
+}
+
+func TestRouter_NoRules(t *testing.T) {
+	points, _ := models.ParsePointsString("cpu value=1 10")
+
+	var r *routing.Router
+	def := routing.Destination{Database: "db0"}
+	if batches := r.Route(def, points); len(batches) != 1 || batches[0].Destination != def || len(batches[0].Points) != 1 {
+		t.Fatalf("unexpected batches: %v", batches)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, rules := range [][]routing.Rule{
+		{{Measurement: "cpu"}},
+		{{Database: "db0"}},
+		{{Measurement: "(", Database: "db0"}},
+		{{Tags: map[string]string{"env": "("}, Database: "db0"}},
+	} {
+		if err := routing.Validate(rules); err == nil {
+			t.Fatalf("expected error for %+v", rules)
+		}
+	}
+}
@@ -62,9 +62,21 @@ func StreamFile(f os.FileInfo, shardRelativePath, fullPath string, tw *tar.Write
 	return StreamRenameFile(f, f.Name(), shardRelativePath, fullPath, tw)
 }
 
+// StreamFileSequential is like StreamFile, but hints to the kernel that the file is
+// read sequentially and that its pages can be dropped from the page cache once
+// written, so streaming large files does not evict the pages of other readers.
+// Pages mapped in memory by other readers are not dropped.
+func StreamFileSequential(f os.FileInfo, shardRelativePath, fullPath string, tw *tar.Writer) error {
+	return streamFile(f, f.Name(), shardRelativePath, fullPath, tw, true)
+}
+
 /// Stream a single file to tw, using tarHeaderFileName instead of the actual filename
 // e.g., when we want to write a *.tmp file using the original file's non-tmp name.
 func StreamRenameFile(f os.FileInfo, tarHeaderFileName, relativePath, fullPath string, tw *tar.Writer) error {
+	return streamFile(f, tarHeaderFileName, relativePath, fullPath, tw, false)
+}
+
+func streamFile(f os.FileInfo, tarHeaderFileName, relativePath, fullPath string, tw *tar.Writer, sequential bool) error {
 	h, err := tar.FileInfoHeader(f, f.Name())
 	if err != nil {
 		return err
@@ -86,6 +98,12 @@ func StreamRenameFile(f os.FileInfo, tarHeaderFileName, relativePath, fullPath s
 
 	defer fr.Close()
 
+	// The hints are best effort, so their errors are ignored.
+	if sequential {
+		file.AdviseSequential(fr)
+		defer file.AdviseDontNeed(fr, 0, 0)
+	}
+
 	_, err = io.CopyN(tw, fr, h.Size)
 
 	return err
@@ -1104,6 +1104,12 @@ const _ = grpc.SupportPackageIsVersion4
 type StorageClient interface {
 	// Read performs a read operation using the given ReadRequest
 	Read(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (Storage_ReadClient, error)
+	// ReadFilter returns the series matching the predicate of the ReadRequest,
+	// ungrouped.
+	ReadFilter(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (Storage_ReadFilterClient, error)
+	// ReadGroup returns the series matching the predicate of the ReadRequest,
+	// grouped by its GroupKeys.
+	ReadGroup(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (Storage_ReadGroupClient, error)
 	// Capabilities returns a map of keys and values identifying the capabilities supported by the storage engine
 	Capabilities(ctx context.Context, in *types.Empty, opts ...grpc.CallOption) (*CapabilitiesResponse, error)
 	Hints(ctx context.Context, in *types.Empty, opts ...grpc.CallOption) (*HintsResponse, error)
@@ -1149,6 +1155,70 @@ func (x *storageReadClient) Recv() (*ReadResponse, error) {
 	return m, nil
 }
 
+func (c *storageClient) ReadFilter(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (Storage_ReadFilterClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Storage_serviceDesc.Streams[1], "/freetsdb.platform.storage.Storage/ReadFilter", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &storageReadFilterClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Storage_ReadFilterClient interface {
+	Recv() (*ReadResponse, error)
+	grpc.ClientStream
+}
+
+type storageReadFilterClient struct {
+	grpc.ClientStream
+}
+
+func (x *storageReadFilterClient) Recv() (*ReadResponse, error) {
+	m := new(ReadResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *storageClient) ReadGroup(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (Storage_ReadGroupClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Storage_serviceDesc.Streams[2], "/freetsdb.platform.storage.Storage/ReadGroup", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &storageReadGroupClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Storage_ReadGroupClient interface {
+	Recv() (*ReadResponse, error)
+	grpc.ClientStream
+}
+
+type storageReadGroupClient struct {
+	grpc.ClientStream
+}
+
+func (x *storageReadGroupClient) Recv() (*ReadResponse, error) {
+	m := new(ReadResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *storageClient) Capabilities(ctx context.Context, in *types.Empty, opts ...grpc.CallOption) (*CapabilitiesResponse, error) {
 	out := new(CapabilitiesResponse)
 	err := c.cc.Invoke(ctx, "/freetsdb.platform.storage.Storage/Capabilities", in, out, opts...)
@@ -1172,6 +1242,12 @@ func (c *storageClient) Hints(ctx context.Context, in *types.Empty, opts ...grpc
 type StorageServer interface {
 	// Read performs a read operation using the given ReadRequest
 	Read(*ReadRequest, Storage_ReadServer) error
+	// ReadFilter returns the series matching the predicate of the ReadRequest,
+	// ungrouped.
+	ReadFilter(*ReadRequest, Storage_ReadFilterServer) error
+	// ReadGroup returns the series matching the predicate of the ReadRequest,
+	// grouped by its GroupKeys.
+	ReadGroup(*ReadRequest, Storage_ReadGroupServer) error
 	// Capabilities returns a map of keys and values identifying the capabilities supported by the storage engine
 	Capabilities(context.Context, *types.Empty) (*CapabilitiesResponse, error)
 	Hints(context.Context, *types.Empty) (*HintsResponse, error)
@@ -1202,6 +1278,48 @@ func (x *storageReadServer) Send(m *ReadResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Storage_ReadFilter_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReadRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StorageServer).ReadFilter(m, &storageReadFilterServer{stream})
+}
+
+type Storage_ReadFilterServer interface {
+	Send(*ReadResponse) error
+	grpc.ServerStream
+}
+
+type storageReadFilterServer struct {
+	grpc.ServerStream
+}
+
+func (x *storageReadFilterServer) Send(m *ReadResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Storage_ReadGroup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReadRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StorageServer).ReadGroup(m, &storageReadGroupServer{stream})
+}
+
+type Storage_ReadGroupServer interface {
+	Send(*ReadResponse) error
+	grpc.ServerStream
+}
+
+type storageReadGroupServer struct {
+	grpc.ServerStream
+}
+
+func (x *storageReadGroupServer) Send(m *ReadResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Storage_Capabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(types.Empty)
 	if err := dec(in); err != nil {
@@ -1257,6 +1375,16 @@ var _Storage_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Storage_Read_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "ReadFilter",
+			Handler:       _Storage_ReadFilter_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ReadGroup",
+			Handler:       _Storage_ReadGroup_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "storage_common.proto",
 }
@@ -4589,102 +4717,103 @@ func init() {
 }
 
 var fileDescriptor_storage_common_01b6ac29b3fb8162 = []byte{
-	// 1539 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x57, 0x5f, 0x8f, 0x2a, 0x49,
-	0x15, 0xa7, 0xf9, 0xcf, 0xe1, 0xcf, 0xf4, 0xad, 0xc5, 0x09, 0xdb, 0xd7, 0x85, 0x5e, 0x62, 0x36,
-	0xa8, 0x2b, 0xa3, 0xec, 0x6e, 0xbc, 0xb9, 0xea, 0x03, 0xcc, 0x70, 0x07, 0x9c, 0x19, 0x98, 0x14,
-	0x8c, 0x71, 0x4d, 0x0c, 0xa9, 0x81, 0xa2, 0xb7, 0xb3, 0x4d, 0x77, 0xdb, 0xdd, 0x6c, 0x86, 0xc4,
-	0x77, 0x37, 0x3c, 0x5d, 0x5f, 0x4d, 0x48, 0x4c, 0x7c, 0xf4, 0xdd, 0xcf, 0x70, 0xdf, 0xf4, 0x13,
-	0x10, 0xc5, 0x0f, 0x61, 0xe2, 0x93, 0xa9, 0xaa, 0x6e, 0x68, 0x66, 0xae, 0x13, 0x78, 0xab, 0x3a,
-	0x7f, 0x7e, 0xbf, 0x53, 0xa7, 0xcf, 0x39, 0x55, 0x0d, 0x45, 0xd7, 0xb3, 0x1c, 0xa2, 0xd1, 0xd1,
-	0xd8, 0x9a, 0xcd, 0x2c, 0xb3, 0x6e, 0x3b, 0x96, 0x67, 0xa1, 0x97, 0xba, 0x39, 0x35, 0xe6, 0x0f,
-	0x13, 0xe2, 0x91, 0xba, 0x6d, 0x10, 0x6f, 0x6a, 0x39, 0xb3, 0xba, 0x6f, 0xa9, 0x14, 0x35, 0x4b,
-	0xb3, 0xb8, 0xdd, 0x19, 0x5b, 0x09, 0x17, 0xe5, 0xa5, 0x66, 0x59, 0x9a, 0x41, 0xcf, 0xf8, 0xee,
-	0x7e, 0x3e, 0x3d, 0xa3, 0x33, 0xdb, 0x5b, 0xf8, 0xca, 0x0f, 0x1f, 0x2b, 0x89, 0x19, 0xa8, 0x4e,
-	0x6c, 0x87, 0x4e, 0xf4, 0x31, 0xf1, 0xa8, 0x10, 0x54, 0xff, 0x93, 0x86, 0x2c, 0xa6, 0x64, 0x82,
-	0xe9, 0xef, 0xe6, 0xd4, 0xf5, 0x90, 0x01, 0x27, 0x9e, 0x3e, 0xa3, 0xae, 0x47, 0x66, 0xf6, 0xc8,
-	0x21, 0xa6, 0x46, 0x4b, 0x51, 0x55, 0xaa, 0x65, 0x1b, 0x3f, 0xac, 0x3f, 0x13, 0x65, 0x7d, 0x18,
-	0xf8, 0x60, 0xe6, 0xd2, 0x3a, 0x7d, 0xb7, 0xae, 0x44, 0x36, 0xeb, 0x4a, 0x61, 0x5f, 0x8e, 0x0b,
-	0xde, 0xde, 0x1e, 0x95, 0x01, 0x26, 0xd4, 0x1d, 0x53, 0x73, 0xa2, 0x9b, 0x5a, 0x29, 0xa6, 0x4a,
-	0xb5, 0x34, 0x0e, 0x49, 0xd0, 0xa7, 0x00, 0x9a, 0x63, 0xcd, 0xed, 0xd1, 0xd7, 0x74, 0xe1, 0x96,
-	0xe2, 0x6a, 0xac, 0x96, 0x69, 0xe5, 0x37, 0xeb, 0x4a, 0xe6, 0x92, 0x49, 0xaf, 0xe8, 0xc2, 0xc5,
-	0x19, 0x2d, 0x58, 0xa2, 0x0b, 0xc8, 0x6c, 0x8f, 0x57, 0x4a, 0xf0, 0xa8, 0x3f, 0x79, 0x36, 0xea,
-	0xdb, 0xc0, 0x1a, 0xef, 0x1c, 0x51, 0x03, 0x72, 0x2e, 0x75, 0x74, 0xea, 0x8e, 0x0c, 0x7d, 0xa6,
-	0x7b, 0xa5, 0xa4, 0x2a, 0xd5, 0x62, 0xad, 0x93, 0xcd, 0xba, 0x92, 0x1d, 0x70, 0xf9, 0x35, 0x13,
-	0xe3, 0xac, 0xbb, 0xdb, 0xa0, 0x2f, 0x20, 0xef, 0xfb, 0x58, 0xd3, 0xa9, 0x4b, 0xbd, 0x52, 0x8a,
-	0x3b, 0xc9, 0x9b, 0x75, 0x25, 0x27, 0x9c, 0xfa, 0x5c, 0x8e, 0x7d, 0x68, 0xb1, 0x63, 0x54, 0xb6,
-	0xa5, 0x9b, 0x5e, 0x40, 0x95, 0xde, 0x51, 0xdd, 0x72, 0xb9, 0x4f, 0x65, 0xef, 0x36, 0xec, 0x90,
-	0x44, 0xd3, 0x1c, 0xaa, 0xb1, 0x43, 0x66, 0x0e, 0x38, 0x64, 0x33, 0xb0, 0xc6, 0x3b, 0x47, 0x34,
-	0x84, 0x84, 0xe7, 0x90, 0x31, 0x2d, 0x81, 0x1a, 0xab, 0x65, 0x1b, 0x9f, 0x3d, 0x8b, 0x10, 0xaa,
-	0x8f, 0xfa, 0x90, 0x79, 0xb5, 0x4d, 0xcf, 0x59, 0xb4, 0x32, 0x9b, 0x75, 0x25, 0xc1, 0xf7, 0x58,
-	0x80, 0xa1, 0x0b, 0x48, 0xf0, 0xaf, 0x51, 0xca, 0xaa, 0x52, 0xad, 0xd0, 0xa8, 0x1f, 0x8c, 0xca,
-	0x3f, 0x27, 0x16, 0xce, 0xe8, 0x53, 0x48, 0x7c, 0xc5, 0xce, 0x5b, 0xca, 0xa9, 0x52, 0x2d, 0xd5,
-	0x3a, 0x65, 0x34, 0x1d, 0x26, 0xf8, 0xef, 0xba, 0x92, 0x61, 0x8b, 0x37, 0x06, 0xd1, 0x5c, 0x2c,
-	0x8c, 0x50, 0x1b, 0xb2, 0x0e, 0x25, 0x93, 0x91, 0x6b, 0xcd, 0x9d, 0x31, 0x2d, 0xe5, 0x79, 0x46,
-	0x8a, 0x75, 0xd1, 0x02, 0xf5, 0xa0, 0x05, 0xea, 0x4d, 0x73, 0xd1, 0x2a, 0x6c, 0xd6, 0x15, 0x60,
-	0xb4, 0x03, 0x6e, 0x8b, 0xc1, 0xd9, 0xae, 0x95, 0x57, 0x00, 0xbb, 0xa3, 0x21, 0x19, 0x62, 0x5f,
-	0xd3, 0x45, 0x49, 0x52, 0xa5, 0x5a, 0x06, 0xb3, 0x25, 0x2a, 0x42, 0xe2, 0x1b, 0x62, 0xcc, 0x45,
-	0x37, 0x64, 0xb0, 0xd8, 0xbc, 0x8e, 0xbe, 0x92, 0xaa, 0x7f, 0x90, 0x20, 0xc1, 0xe3, 0x47, 0x1f,
-	0x01, 0x5c, 0xe2, 0xfe, 0xdd, 0xed, 0xa8, 0xd7, 0xef, 0xb5, 0xe5, 0x88, 0x92, 0x5f, 0xae, 0x54,
-	0x51, 0xa9, 0x3d, 0xcb, 0xa4, 0xe8, 0x25, 0x64, 0x84, 0xba, 0x79, 0x7d, 0x2d, 0x4b, 0x4a, 0x6e,
-	0xb9, 0x52, 0xd3, 0x5c, 0xdb, 0x34, 0x0c, 0xf4, 0x21, 0xa4, 0x85, 0xb2, 0xf5, 0xa5, 0x1c, 0x55,
-	0xb2, 0xcb, 0x95, 0x9a, 0xe2, 0xba, 0xd6, 0x02, 0x7d, 0x0c, 0x39, 0xa1, 0x6a, 0xff, 0xfa, 0xbc,
-	0x7d, 0x3b, 0x94, 0x63, 0xca, 0xc9, 0x72, 0xa5, 0x66, 0xb9, 0xba, 0xfd, 0x30, 0xa6, 0xb6, 0xa7,
-	0xc4, 0xbf, 0xfd, 0x4b, 0x39, 0x52, 0xfd, 0xab, 0x04, 0xbb, 0xfc, 0x30, 0xba, 0x4e, 0xb7, 0x37,
-	0x0c, 0x82, 0xe1, 0x74, 0x4c, 0xcb, 0x63, 0xf9, 0x1e, 0x14, 0x7c, 0xe5, 0xe8, 0xb6, 0xdf, 0xed,
-	0x0d, 0x07, 0xb2, 0xa4, 0xc8, 0xcb, 0x95, 0x9a, 0x13, 0x16, 0xa2, 0xfa, 0xc2, 0x56, 0x83, 0x36,
-	0xee, 0xb6, 0x07, 0x72, 0x34, 0x6c, 0x25, 0x2a, 0x1b, 0x9d, 0x41, 0x91, 0x5b, 0x0d, 0xce, 0x3b,
-	0xed, 0x9b, 0x26, 0x3b, 0xdd, 0x68, 0xd8, 0xbd, 0x69, 0xcb, 0x71, 0xe5, 0x3b, 0xcb, 0x95, 0xfa,
-	0x82, 0xd9, 0x0e, 0xc6, 0x5f, 0xd1, 0x19, 0x69, 0x1a, 0x06, 0x9b, 0x07, 0x7e, 0xb4, 0x7f, 0x97,
-	0x20, 0xb3, 0xad, 0x4d, 0xd4, 0x81, 0xb8, 0xb7, 0xb0, 0x29, 0x4f, 0x79, 0xa1, 0xf1, 0xf9, 0x61,
-	0x15, 0xbd, 0x5b, 0x0d, 0x17, 0x36, 0xc5, 0x1c, 0xa1, 0xfa, 0x00, 0xf9, 0x3d, 0x31, 0xaa, 0x40,
-	0xdc, 0xcf, 0x01, 0x8f, 0x67, 0x4f, 0xc9, 0x93, 0xf1, 0x11, 0xc4, 0x06, 0x77, 0x37, 0xb2, 0xa4,
-	0x14, 0x97, 0x2b, 0x55, 0xde, 0xd3, 0x0f, 0xe6, 0x33, 0xf4, 0x31, 0x24, 0xce, 0xfb, 0x77, 0xbd,
-	0xa1, 0x1c, 0x55, 0x4e, 0x97, 0x2b, 0x15, 0xed, 0x19, 0x9c, 0x5b, 0x73, 0x33, 0xc8, 0xff, 0x8f,
-	0x20, 0x36, 0x24, 0x5a, 0xb8, 0x78, 0x72, 0xef, 0x29, 0x9e, 0x9c, 0x5f, 0x3c, 0xd5, 0x3f, 0x16,
-	0x20, 0x27, 0x9a, 0xc0, 0xb5, 0x2d, 0xd3, 0xa5, 0xe8, 0x06, 0x92, 0x53, 0x87, 0xcc, 0xa8, 0x5b,
-	0x92, 0x78, 0x57, 0x9e, 0x1d, 0xd0, 0x3f, 0xc2, 0xb5, 0xfe, 0x86, 0xf9, 0xb5, 0xe2, 0x6c, 0xec,
-	0x62, 0x1f, 0x44, 0xf9, 0x36, 0x09, 0x09, 0x2e, 0x47, 0x7d, 0x48, 0x8a, 0xb9, 0xc3, 0x83, 0xca,
-	0x36, 0xbe, 0x38, 0x1c, 0x58, 0x7c, 0x63, 0x0e, 0xd3, 0x89, 0x60, 0x1f, 0x06, 0xd9, 0x90, 0x9b,
-	0x1a, 0x16, 0xf1, 0x46, 0x62, 0x32, 0xf9, 0x57, 0xc4, 0xeb, 0x23, 0xe2, 0x65, 0xde, 0xa2, 0xca,
-	0x44, 0xe8, 0x7c, 0xe8, 0x85, 0xa4, 0x9d, 0x08, 0xce, 0x4e, 0x77, 0x5b, 0xf4, 0x00, 0x05, 0xdd,
-	0xf4, 0xa8, 0x46, 0x9d, 0x80, 0x33, 0xc6, 0x39, 0x7f, 0x7e, 0x38, 0x67, 0x57, 0xf8, 0x87, 0x59,
-	0x5f, 0x6c, 0xd6, 0x95, 0xfc, 0x9e, 0xbc, 0x13, 0xc1, 0x79, 0x3d, 0x2c, 0x40, 0xbf, 0x87, 0x93,
-	0xb9, 0xe9, 0xea, 0x9a, 0x49, 0x27, 0x01, 0x75, 0x9c, 0x53, 0xff, 0xe2, 0x70, 0xea, 0x3b, 0x1f,
-	0x20, 0xcc, 0x8d, 0xd8, 0xfd, 0xb8, 0xaf, 0xe8, 0x44, 0x70, 0x61, 0xbe, 0x27, 0x61, 0xe7, 0xbe,
-	0xb7, 0x2c, 0x83, 0x12, 0x33, 0x20, 0x4f, 0x1c, 0x7b, 0xee, 0x96, 0xf0, 0x7f, 0x72, 0xee, 0x3d,
-	0x39, 0x3b, 0xf7, 0x7d, 0x58, 0x80, 0x3c, 0xc8, 0xbb, 0x9e, 0xa3, 0x9b, 0x5a, 0x40, 0x9c, 0xe4,
-	0xc4, 0x3f, 0x3b, 0xa2, 0x76, 0xb8, 0x7b, 0x98, 0x57, 0x5c, 0x88, 0x21, 0x71, 0x27, 0x82, 0x73,
-	0x6e, 0x68, 0x8f, 0xae, 0x83, 0x2b, 0x24, 0xc5, 0xd9, 0x3e, 0x3f, 0x9c, 0x8d, 0xcf, 0xc3, 0xa0,
-	0x50, 0x05, 0x48, 0x2b, 0x09, 0x71, 0xe6, 0xa9, 0x3c, 0x00, 0xec, 0xd4, 0xe8, 0x13, 0x48, 0x7b,
-	0x44, 0x13, 0x6f, 0x0a, 0xd6, 0x69, 0xb9, 0x56, 0x76, 0xb3, 0xae, 0xa4, 0x86, 0x44, 0xe3, 0x2f,
-	0x8a, 0x94, 0x27, 0x16, 0xa8, 0x05, 0xc8, 0x26, 0x8e, 0xa7, 0x7b, 0xba, 0x65, 0x32, 0xeb, 0xd1,
-	0x37, 0xc4, 0x60, 0xb5, 0xce, 0x3c, 0x8a, 0x9b, 0x75, 0x45, 0xbe, 0x0d, 0xb4, 0x57, 0x74, 0xf1,
-	0x2b, 0x62, 0xb8, 0x58, 0xb6, 0x1f, 0x49, 0x94, 0x3f, 0x49, 0x90, 0x0d, 0xf5, 0x10, 0x7a, 0x0d,
-	0x71, 0x8f, 0x68, 0x41, 0x87, 0xab, 0xcf, 0x3f, 0xaa, 0x88, 0xe6, 0xb7, 0x34, 0xf7, 0x41, 0x7d,
-	0xc8, 0x30, 0xc3, 0x11, 0x1f, 0x94, 0x51, 0x3e, 0x28, 0x1b, 0x87, 0xe7, 0xe7, 0x82, 0x78, 0x84,
-	0x8f, 0xc9, 0xf4, 0xc4, 0x5f, 0x29, 0xbf, 0x04, 0xf9, 0x71, 0x23, 0xb2, 0x27, 0xd9, 0xf6, 0x91,
-	0x26, 0xc2, 0x94, 0x71, 0x48, 0x82, 0x4e, 0x21, 0xc9, 0xc7, 0x97, 0x48, 0x84, 0x84, 0xfd, 0x9d,
-	0x72, 0x0d, 0xe8, 0x69, 0x83, 0x1d, 0x89, 0x16, 0xdb, 0xa2, 0xdd, 0xc0, 0x07, 0xef, 0xe9, 0x99,
-	0x23, 0xe1, 0xe2, 0xe1, 0xe0, 0x9e, 0x76, 0xc1, 0x91, 0x68, 0xe9, 0x2d, 0xda, 0x15, 0xbc, 0x78,
-	0x52, 0xda, 0x47, 0x82, 0x65, 0x02, 0xb0, 0xea, 0x00, 0x32, 0x1c, 0xc0, 0xbf, 0xaa, 0x92, 0xfe,
-	0x45, 0x1b, 0x51, 0x3e, 0x58, 0xae, 0xd4, 0x93, 0xad, 0xca, 0xbf, 0x6b, 0x2b, 0x90, 0xdc, 0xde,
-	0xd7, 0xfb, 0x06, 0x22, 0x16, 0xff, 0x26, 0xfa, 0x9b, 0x04, 0xe9, 0xe0, 0x7b, 0xa3, 0xef, 0x42,
-	0xe2, 0xcd, 0x75, 0xbf, 0x39, 0x94, 0x23, 0xca, 0x8b, 0xe5, 0x4a, 0xcd, 0x07, 0x0a, 0xfe, 0xe9,
-	0x91, 0x0a, 0xa9, 0x6e, 0x6f, 0xd8, 0xbe, 0x6c, 0xe3, 0x00, 0x32, 0xd0, 0xfb, 0x9f, 0x13, 0x55,
-	0x21, 0x7d, 0xd7, 0x1b, 0x74, 0x2f, 0x7b, 0xed, 0x0b, 0x39, 0x2a, 0xee, 0xc8, 0xc0, 0x24, 0xf8,
-	0x46, 0x0c, 0xa5, 0xd5, 0xef, 0x5f, 0xb7, 0x9b, 0x3d, 0x39, 0xb6, 0x8f, 0xe2, 0xe7, 0x1d, 0x95,
-	0x21, 0x39, 0x18, 0xe2, 0x6e, 0xef, 0x52, 0x8e, 0x2b, 0x68, 0xb9, 0x52, 0x0b, 0x81, 0x81, 0x48,
-	0xa5, 0x1f, 0xf8, 0x9f, 0x25, 0x28, 0x9e, 0x13, 0x9b, 0xdc, 0xeb, 0x86, 0xee, 0xe9, 0xd4, 0xdd,
-	0xde, 0x8d, 0x7d, 0x88, 0x8f, 0x89, 0x1d, 0xf4, 0xcd, 0xf3, 0x43, 0xe8, 0x7d, 0x00, 0x4c, 0xe8,
-	0xf2, 0xc7, 0x1d, 0xe6, 0x40, 0xca, 0x4f, 0x21, 0xb3, 0x15, 0x1d, 0xf5, 0xde, 0x3b, 0x81, 0x3c,
-	0x7f, 0x8d, 0x06, 0xc8, 0xd5, 0x57, 0xf0, 0xe8, 0x37, 0x87, 0x39, 0xbb, 0x1e, 0x71, 0x3c, 0x0e,
-	0x18, 0xc3, 0x62, 0xc3, 0x48, 0xa8, 0x39, 0xe1, 0x80, 0x31, 0xcc, 0x96, 0x8d, 0xb7, 0x51, 0x48,
-	0x0d, 0x44, 0xd0, 0xe8, 0xb7, 0x10, 0x67, 0xed, 0x8a, 0x6a, 0x87, 0x3e, 0x9a, 0x95, 0xef, 0x1f,
-	0xdc, 0xfb, 0x3f, 0x96, 0xd0, 0x97, 0x90, 0x0b, 0xa7, 0x05, 0x9d, 0x3e, 0x79, 0x21, 0xb7, 0xd9,
-	0x1f, 0xa4, 0xf2, 0x93, 0xa3, 0x33, 0x8b, 0xae, 0x40, 0x3c, 0xcf, 0xff, 0x2f, 0xe6, 0x0f, 0x9e,
-	0xc5, 0xdc, 0x4b, 0x66, 0xab, 0xf2, 0xee, 0x5f, 0xe5, 0xc8, 0xbb, 0x4d, 0x59, 0xfa, 0xc7, 0xa6,
-	0x2c, 0xfd, 0x73, 0x53, 0x96, 0xde, 0xfe, 0xbb, 0x1c, 0xf9, 0x0d, 0x9f, 0x7b, 0x6c, 0xec, 0xb9,
-	0xf7, 0x49, 0x0e, 0xfe, 0xd9, 0xff, 0x02, 0x00, 0x00, 0xff, 0xff, 0x62, 0x34, 0xad, 0x1c, 0x4b,
-	0x0f, 0x00, 0x00,
+	// 1563 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x57, 0x4f, 0x6f, 0x23, 0x49,
+	0x15, 0x77, 0xc7, 0x7f, 0xe2, 0x7e, 0xfe, 0x93, 0x9e, 0xda, 0x10, 0x79, 0x7b, 0xd8, 0xb8, 0x37,
+	0x42, 0x83, 0x25, 0x16, 0x07, 0x65, 0x77, 0xc5, 0x68, 0x80, 0x83, 0x9d, 0xf1, 0xc4, 0x66, 0x12,
+	0x3b, 0x2a, 0x3b, 0xc0, 0x72, 0xc0, 0xaa, 0xd8, 0xe5, 0xde, 0xd6, 0xb6, 0xbb, 0x9b, 0xee, 0xf2,
+	0x2a, 0x96, 0xb8, 0xb3, 0xf2, 0x8d, 0x2b, 0x92, 0x25, 0x24, 0x8e, 0xdc, 0xf9, 0x0c, 0xc3, 0x09,
+	0x3e, 0x81, 0x05, 0xfe, 0x12, 0x48, 0x9c, 0x50, 0x55, 0x75, 0xdb, 0xed, 0x49, 0x30, 0xf6, 0x61,
+	0x6f, 0xf5, 0x7e, 0xef, 0xbd, 0xdf, 0xab, 0x3f, 0xef, 0xbd, 0xaa, 0x82, 0xe3, 0x80, 0xb9, 0x3e,
+	0x31, 0x69, 0x7f, 0xe0, 0x8e, 0xc7, 0xae, 0x53, 0xf5, 0x7c, 0x97, 0xb9, 0xe8, 0xb9, 0xe5, 0x8c,
+	0xec, 0xc9, 0xc3, 0x90, 0x30, 0x52, 0xf5, 0x6c, 0xc2, 0x46, 0xae, 0x3f, 0xae, 0x86, 0x96, 0xfa,
+	0xb1, 0xe9, 0x9a, 0xae, 0xb0, 0x3b, 0xe7, 0x23, 0xe9, 0xa2, 0x3f, 0x37, 0x5d, 0xd7, 0xb4, 0xe9,
+	0xb9, 0x90, 0xee, 0x27, 0xa3, 0x73, 0x3a, 0xf6, 0xd8, 0x34, 0x54, 0x7e, 0xf8, 0xbe, 0x92, 0x38,
+	0x91, 0xea, 0xc8, 0xf3, 0xe9, 0xd0, 0x1a, 0x10, 0x46, 0x25, 0x70, 0xf6, 0xef, 0x2c, 0xe4, 0x30,
+	0x25, 0x43, 0x4c, 0x7f, 0x3b, 0xa1, 0x01, 0x43, 0x0d, 0xc8, 0xf9, 0x94, 0x0c, 0xfb, 0x81, 0x3b,
+	0xf1, 0x07, 0xb4, 0x54, 0x30, 0x94, 0x4a, 0xee, 0xe2, 0xb8, 0x2a, 0x19, 0xab, 0x11, 0x63, 0xb5,
+	0xe6, 0x4c, 0xeb, 0xc5, 0xe5, 0xa2, 0x0c, 0xdc, 0xb7, 0x2b, 0x6c, 0x31, 0xf8, 0xab, 0x31, 0xb2,
+	0xe1, 0x88, 0x59, 0x63, 0x1a, 0x30, 0x32, 0xf6, 0xfa, 0x3e, 0x71, 0x4c, 0x5a, 0x3a, 0x10, 0x54,
+	0x3f, 0xa8, 0x6e, 0x59, 0x6c, 0xb5, 0x17, 0xf9, 0x60, 0xee, 0x52, 0x3f, 0x79, 0xb7, 0x28, 0x27,
+	0x96, 0x8b, 0x72, 0x71, 0x13, 0xc7, 0x45, 0xb6, 0x21, 0xa3, 0x53, 0x80, 0x21, 0x0d, 0x06, 0xd4,
+	0x19, 0x5a, 0x8e, 0x59, 0x4a, 0x1a, 0x4a, 0x25, 0x8b, 0x63, 0x08, 0xfa, 0x04, 0xc0, 0xf4, 0xdd,
+	0x89, 0xd7, 0xff, 0x8a, 0x4e, 0x83, 0x52, 0xca, 0x48, 0x56, 0xd4, 0x7a, 0x61, 0xb9, 0x28, 0xab,
+	0x57, 0x1c, 0x7d, 0x4b, 0xa7, 0x01, 0x56, 0xcd, 0x68, 0x88, 0x5e, 0x43, 0x5a, 0x08, 0xa5, 0x9c,
+	0xa1, 0x54, 0x8a, 0x17, 0xd5, 0xad, 0x33, 0x8e, 0xed, 0x5d, 0x55, 0xb0, 0x61, 0xe9, 0x8c, 0x5e,
+	0x83, 0x4a, 0x4c, 0xd3, 0xa7, 0x26, 0x61, 0xb4, 0xa4, 0x8a, 0xb5, 0xbf, 0xd8, 0xca, 0x54, 0x8b,
+	0xac, 0xf1, 0xda, 0x91, 0xb3, 0xac, 0x4e, 0xac, 0x94, 0xde, 0x81, 0xe5, 0x36, 0xb2, 0xc6, 0x6b,
+	0x47, 0x74, 0x01, 0xf9, 0x80, 0xfa, 0x16, 0x0d, 0xfa, 0xb6, 0x35, 0xb6, 0x58, 0x29, 0x63, 0x28,
+	0x95, 0x64, 0xfd, 0x68, 0xb9, 0x28, 0xe7, 0xba, 0x02, 0xbf, 0xe6, 0x30, 0xce, 0x05, 0x6b, 0x01,
+	0x7d, 0x0e, 0x85, 0xd0, 0xc7, 0x1d, 0x8d, 0x02, 0xca, 0x4a, 0x87, 0xc2, 0x49, 0x5b, 0x2e, 0xca,
+	0x79, 0xe9, 0xd4, 0x11, 0x38, 0xce, 0x07, 0x31, 0x89, 0x87, 0xf2, 0x5c, 0xcb, 0x61, 0x51, 0xa8,
+	0xec, 0x3a, 0xd4, 0xad, 0xc0, 0xc3, 0x50, 0xde, 0x5a, 0x40, 0x3d, 0x48, 0x33, 0x9f, 0x0c, 0x68,
+	0x09, 0x8c, 0x64, 0x25, 0x77, 0xf1, 0xe9, 0xce, 0x1b, 0xde, 0xe3, 0x5e, 0x0d, 0x87, 0xf9, 0xd3,
+	0xba, 0xba, 0x5c, 0x94, 0xd3, 0x42, 0xc6, 0x92, 0x0c, 0x7d, 0x02, 0xe9, 0x2f, 0x79, 0x8c, 0x52,
+	0xde, 0x50, 0x2a, 0x87, 0xf5, 0x13, 0x6e, 0xd0, 0xe4, 0xc0, 0x7f, 0x16, 0x65, 0x95, 0x0f, 0xde,
+	0xd8, 0xc4, 0x0c, 0xb0, 0x34, 0xd2, 0x5f, 0x02, 0xac, 0xd9, 0x90, 0x06, 0xc9, 0xaf, 0xe8, 0xb4,
+	0xa4, 0x18, 0x4a, 0x45, 0xc5, 0x7c, 0x88, 0x8e, 0x21, 0xfd, 0x35, 0xb1, 0x27, 0x32, 0x8d, 0x55,
+	0x2c, 0x85, 0x57, 0x07, 0x2f, 0x95, 0xb3, 0xdf, 0x2b, 0x90, 0x16, 0x27, 0x8f, 0x3e, 0x02, 0xb8,
+	0xc2, 0x9d, 0xbb, 0xdb, 0x7e, 0xbb, 0xd3, 0x6e, 0x68, 0x09, 0xbd, 0x30, 0x9b, 0x1b, 0x32, 0xc5,
+	0xda, 0xae, 0x43, 0xd1, 0x73, 0x50, 0xa5, 0xba, 0x76, 0x7d, 0xad, 0x29, 0x7a, 0x7e, 0x36, 0x37,
+	0xb2, 0x42, 0x5b, 0xb3, 0x6d, 0xf4, 0x21, 0x64, 0xa5, 0xb2, 0xfe, 0x85, 0x76, 0xa0, 0xe7, 0x66,
+	0x73, 0xe3, 0x50, 0xe8, 0xea, 0x53, 0xf4, 0x31, 0xe4, 0xa5, 0xaa, 0xf1, 0xab, 0xcb, 0xc6, 0x6d,
+	0x4f, 0x4b, 0xea, 0x47, 0xb3, 0xb9, 0x91, 0x13, 0xea, 0xc6, 0xc3, 0x80, 0x7a, 0x4c, 0x4f, 0x7d,
+	0xf3, 0xe7, 0xd3, 0xc4, 0xd9, 0x5f, 0x14, 0x58, 0x2f, 0x8c, 0x87, 0x6b, 0xb6, 0xda, 0xbd, 0x68,
+	0x32, 0x22, 0x1c, 0xd7, 0x8a, 0xb9, 0x7c, 0x0f, 0x8a, 0xa1, 0xb2, 0x7f, 0xdb, 0x69, 0xb5, 0x7b,
+	0x5d, 0x4d, 0xd1, 0xb5, 0xd9, 0xdc, 0xc8, 0x4b, 0x0b, 0x79, 0x54, 0x71, 0xab, 0x6e, 0x03, 0xb7,
+	0x1a, 0x5d, 0xed, 0x20, 0x6e, 0x25, 0xd3, 0x00, 0x9d, 0xc3, 0xb1, 0xb0, 0xea, 0x5e, 0x36, 0x1b,
+	0x37, 0x35, 0xbe, 0xba, 0x7e, 0xaf, 0x75, 0xd3, 0xd0, 0x52, 0xfa, 0x77, 0x66, 0x73, 0xe3, 0x19,
+	0xb7, 0xed, 0x0e, 0xbe, 0xa4, 0x63, 0x52, 0xb3, 0x6d, 0x5e, 0xc8, 0xe1, 0x6c, 0xff, 0xae, 0x80,
+	0xba, 0xca, 0x79, 0xd4, 0x84, 0x14, 0x9b, 0x7a, 0x54, 0x6c, 0x79, 0xf1, 0xe2, 0xb3, 0xdd, 0x2a,
+	0x65, 0x3d, 0xea, 0x4d, 0x3d, 0x8a, 0x05, 0xc3, 0xd9, 0x03, 0x14, 0x36, 0x60, 0x54, 0x86, 0x54,
+	0xb8, 0x07, 0x62, 0x3e, 0x1b, 0x4a, 0xb1, 0x19, 0x1f, 0x41, 0xb2, 0x7b, 0x77, 0xa3, 0x29, 0xfa,
+	0xf1, 0x6c, 0x6e, 0x68, 0x1b, 0xfa, 0xee, 0x64, 0x8c, 0x3e, 0x86, 0xf4, 0x65, 0xe7, 0xae, 0xdd,
+	0xd3, 0x0e, 0xf4, 0x93, 0xd9, 0xdc, 0x40, 0x1b, 0x06, 0x97, 0xee, 0xc4, 0x89, 0xf6, 0xff, 0x87,
+	0x90, 0xec, 0x11, 0x33, 0x9e, 0x3c, 0xf9, 0x27, 0x92, 0x27, 0x1f, 0x26, 0xcf, 0xd9, 0x1f, 0x8a,
+	0x90, 0x97, 0xd9, 0x1c, 0x78, 0xae, 0x13, 0x50, 0x74, 0x03, 0x99, 0x91, 0x4f, 0xc6, 0x34, 0x28,
+	0x29, 0xa2, 0x10, 0xce, 0x77, 0x28, 0x04, 0xe9, 0x5a, 0x7d, 0xc3, 0xfd, 0xea, 0x29, 0xde, 0x2f,
+	0x71, 0x48, 0xa2, 0x7f, 0x93, 0x81, 0xb4, 0xc0, 0xd1, 0x75, 0xd4, 0xd1, 0x0e, 0x45, 0x07, 0xf9,
+	0x6c, 0x77, 0x5e, 0x91, 0x64, 0x82, 0xa4, 0x99, 0x88, 0x3a, 0x5b, 0x07, 0x32, 0xb2, 0xe4, 0xc5,
+	0x12, 0x73, 0x17, 0x9f, 0xef, 0x4e, 0x27, 0x33, 0x26, 0xe2, 0x0b, 0x69, 0x90, 0x07, 0xf9, 0x91,
+	0xed, 0x12, 0xd6, 0x97, 0x4d, 0x21, 0xbc, 0x29, 0x5e, 0xed, 0xb1, 0x7a, 0xee, 0x2d, 0x73, 0x56,
+	0x6e, 0x84, 0xe8, 0x37, 0x31, 0xb4, 0x99, 0xc0, 0xb9, 0xd1, 0x5a, 0x44, 0x0f, 0x50, 0xb4, 0x1c,
+	0x46, 0x4d, 0xea, 0x47, 0x31, 0x93, 0x22, 0xe6, 0x4f, 0x77, 0x8f, 0xd9, 0x92, 0xfe, 0xf1, 0xa8,
+	0xcf, 0x96, 0x8b, 0x72, 0x61, 0x03, 0x6f, 0x26, 0x70, 0xc1, 0x8a, 0x03, 0xe8, 0x77, 0x70, 0x34,
+	0x71, 0x02, 0xcb, 0x74, 0xe8, 0x30, 0x0a, 0x9d, 0x12, 0xa1, 0x7f, 0xb6, 0x7b, 0xe8, 0xbb, 0x90,
+	0x20, 0x1e, 0x1b, 0xf1, 0x6b, 0x72, 0x53, 0xd1, 0x4c, 0xe0, 0xe2, 0x64, 0x03, 0xe1, 0xeb, 0xbe,
+	0x77, 0x5d, 0x9b, 0x12, 0x27, 0x0a, 0x9e, 0xde, 0x77, 0xdd, 0x75, 0xe9, 0xff, 0x68, 0xdd, 0x1b,
+	0x38, 0x5f, 0xf7, 0x7d, 0x1c, 0x40, 0x0c, 0x0a, 0x01, 0xf3, 0x2d, 0xc7, 0x8c, 0x02, 0x67, 0x44,
+	0xe0, 0x9f, 0xec, 0x91, 0x3b, 0xc2, 0x3d, 0x1e, 0x57, 0xde, 0x45, 0x31, 0xb8, 0x99, 0xc0, 0xf9,
+	0x20, 0x26, 0xd7, 0x33, 0x90, 0xe2, 0xcc, 0xfa, 0x03, 0xc0, 0x3a, 0x93, 0xd1, 0x0b, 0xc8, 0x32,
+	0x62, 0xca, 0xc7, 0x00, 0xaf, 0xb4, 0x7c, 0x3d, 0xb7, 0x5c, 0x94, 0x0f, 0x7b, 0xc4, 0x14, 0x4f,
+	0x81, 0x43, 0x26, 0x07, 0xa8, 0x0e, 0xc8, 0x23, 0x3e, 0xb3, 0x98, 0xe5, 0x3a, 0xdc, 0xba, 0xff,
+	0x35, 0xb1, 0x79, 0x76, 0x72, 0x8f, 0xe3, 0xe5, 0xa2, 0xac, 0xdd, 0x46, 0xda, 0xb7, 0x74, 0xfa,
+	0x0b, 0x62, 0x07, 0x58, 0xf3, 0xde, 0x43, 0xf4, 0x3f, 0x2a, 0x90, 0x8b, 0x65, 0x3d, 0x7a, 0x05,
+	0x29, 0x46, 0xcc, 0xa8, 0xc2, 0x8d, 0xed, 0xaf, 0x21, 0x62, 0x86, 0x25, 0x2d, 0x7c, 0x50, 0x07,
+	0x54, 0x6e, 0xd8, 0x17, 0x8d, 0xf2, 0x40, 0x34, 0xca, 0x8b, 0xdd, 0xf7, 0xef, 0x35, 0x61, 0x44,
+	0xb4, 0xc9, 0xec, 0x30, 0x1c, 0xe9, 0x3f, 0x07, 0xed, 0xfd, 0xd2, 0xe1, 0x6f, 0xa9, 0xd5, 0xeb,
+	0x4a, 0x4e, 0x53, 0xc3, 0x31, 0x04, 0x9d, 0x40, 0x46, 0xb4, 0x2f, 0xb9, 0x11, 0x0a, 0x0e, 0x25,
+	0xfd, 0x1a, 0xd0, 0xe3, 0x92, 0xd8, 0x93, 0x2d, 0xb9, 0x62, 0xbb, 0x81, 0x0f, 0x9e, 0xc8, 0xf2,
+	0x3d, 0xe9, 0x52, 0xf1, 0xc9, 0x3d, 0xce, 0xdb, 0x3d, 0xd9, 0xb2, 0x2b, 0xb6, 0xb7, 0xf0, 0xec,
+	0x51, 0x32, 0xee, 0x49, 0xa6, 0x46, 0x64, 0x67, 0x5d, 0x50, 0x05, 0x41, 0x78, 0x55, 0x65, 0xc2,
+	0x8b, 0x36, 0xa1, 0x7f, 0x30, 0x9b, 0x1b, 0x47, 0x2b, 0x55, 0x78, 0xd7, 0x96, 0x21, 0xb3, 0xba,
+	0xaf, 0x37, 0x0d, 0xe4, 0x5c, 0xc2, 0x9b, 0xe8, 0xaf, 0x0a, 0x64, 0xa3, 0xf3, 0x46, 0xdf, 0x85,
+	0xf4, 0x9b, 0xeb, 0x4e, 0xad, 0xa7, 0x25, 0xf4, 0x67, 0xb3, 0xb9, 0x51, 0x88, 0x14, 0xe2, 0xe8,
+	0x91, 0x01, 0x87, 0xad, 0x76, 0xaf, 0x71, 0xd5, 0xc0, 0x11, 0x65, 0xa4, 0x0f, 0x8f, 0x13, 0x9d,
+	0x41, 0xf6, 0xae, 0xdd, 0x6d, 0x5d, 0xb5, 0x1b, 0xaf, 0xb5, 0x03, 0x79, 0x47, 0x46, 0x26, 0xd1,
+	0x19, 0x71, 0x96, 0x7a, 0xa7, 0x73, 0xdd, 0xa8, 0xb5, 0xb5, 0xe4, 0x26, 0x4b, 0xb8, 0xef, 0xe8,
+	0x14, 0x32, 0xdd, 0x1e, 0x6e, 0xb5, 0xaf, 0xb4, 0x94, 0x8e, 0x66, 0x73, 0xa3, 0x18, 0x19, 0xc8,
+	0xad, 0x0c, 0x27, 0xfe, 0x27, 0x05, 0x8e, 0x2f, 0x89, 0x47, 0xee, 0x2d, 0xdb, 0x62, 0x16, 0x0d,
+	0x56, 0x77, 0x63, 0x07, 0x52, 0x03, 0xe2, 0x45, 0x75, 0xb3, 0xbd, 0x6d, 0x3c, 0x45, 0xc0, 0xc1,
+	0x40, 0x3c, 0xee, 0xb0, 0x20, 0xd2, 0x7f, 0x0c, 0xea, 0x0a, 0xda, 0xeb, 0xbd, 0x77, 0x04, 0x05,
+	0xf1, 0x8c, 0x8c, 0x98, 0xcf, 0x5e, 0xc2, 0x7b, 0xff, 0x13, 0xee, 0x1c, 0x30, 0xe2, 0x33, 0x41,
+	0x98, 0xc4, 0x52, 0xe0, 0x41, 0xa8, 0x33, 0x14, 0x84, 0x49, 0xcc, 0x87, 0x17, 0x7f, 0x4b, 0xc2,
+	0x61, 0x57, 0x4e, 0x1a, 0x7d, 0x01, 0x29, 0x5e, 0xae, 0xe8, 0x45, 0x75, 0xe4, 0x53, 0xca, 0x82,
+	0xe1, 0xfd, 0xd6, 0xb7, 0xaf, 0xfe, 0xfd, 0xff, 0x6b, 0x27, 0xa7, 0xf7, 0x23, 0x05, 0xf5, 0x41,
+	0x7c, 0xd3, 0xde, 0x58, 0x36, 0xa3, 0xfe, 0xb7, 0x11, 0xe0, 0x37, 0xa0, 0x72, 0x44, 0xbe, 0x82,
+	0xbf, 0x05, 0xfe, 0x5f, 0x42, 0x3e, 0x7e, 0xa6, 0xe8, 0xe4, 0xd1, 0x7f, 0xb4, 0xc1, 0xbf, 0xbf,
+	0xfa, 0xf9, 0x16, 0xca, 0x27, 0xb3, 0xaa, 0x05, 0xf2, 0x4b, 0xf0, 0x3f, 0x19, 0x2b, 0x5b, 0x18,
+	0x37, 0xb2, 0xa0, 0xfe, 0xfc, 0xdd, 0xbf, 0x4e, 0x13, 0xef, 0x96, 0xa7, 0xca, 0x3f, 0x96, 0xa7,
+	0xca, 0x3f, 0x97, 0xa7, 0xca, 0xaf, 0x45, 0xb3, 0xe6, 0xbd, 0x3a, 0xb8, 0xcf, 0x08, 0xda, 0x4f,
+	0xff, 0x3b, 0x00, 0x0d, 0x82, 0x7c, 0xb4, 0x00, 0x10, 0x00, 0x00,
 }
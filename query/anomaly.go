@@ -0,0 +1,357 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+// Default parameters of the anomaly_esd() function.
+const (
+	// anomalyDefaultMaxAnoms is the default upper bound on the fraction of
+	// the points of a window reported as anomalies.
+	anomalyDefaultMaxAnoms = 0.1
+
+	// anomalyDefaultAlpha is the default significance level of the test.
+	anomalyDefaultAlpha = 0.05
+)
+
+// madScale scales the median absolute deviation of a normal distribution to
+// its standard deviation.
+const madScale = 1.4826
+
+// anomalySample is a point of an anomaly detection window.
+type anomalySample struct {
+	time  int64
+	value float64
+}
+
+// anomalyReducer collects the points of a window for the anomaly detection
+// functions.
+type anomalyReducer struct {
+	samples []anomalySample
+}
+
+// AggregateFloat aggregates a point into the reducer.
+func (r *anomalyReducer) AggregateFloat(p *FloatPoint) {
+	r.samples = append(r.samples, anomalySample{time: p.Time, value: p.Value})
+}
+
+// AggregateInteger aggregates a point into the reducer.
+func (r *anomalyReducer) AggregateInteger(p *IntegerPoint) {
+	r.samples = append(r.samples, anomalySample{time: p.Time, value: float64(p.Value)})
+}
+
+// AggregateUnsigned aggregates a point into the reducer.
+func (r *anomalyReducer) AggregateUnsigned(p *UnsignedPoint) {
+	r.samples = append(r.samples, anomalySample{time: p.Time, value: float64(p.Value)})
+}
+
+// values returns the values of the window ordered by time.
+func (r *anomalyReducer) values() []float64 {
+	sort.SliceStable(r.samples, func(i, j int) bool {
+		return r.samples[i].time < r.samples[j].time
+	})
+	values := make([]float64, len(r.samples))
+	for i, s := range r.samples {
+		values[i] = s.value
+	}
+	return values
+}
+
+// anomalyMADReducer scores the points of a window with their robust z-score,
+// the distance to the median of the window in median absolute deviations
+// scaled to standard deviations. Only the points scoring at least the
+// threshold in absolute value are emitted.
+type anomalyMADReducer struct {
+	anomalyReducer
+	threshold float64
+}
+
+func newAnomalyMADReducer(threshold float64) *anomalyMADReducer {
+	return &anomalyMADReducer{threshold: threshold}
+}
+
+// Emit emits the score of each point of the window.
+func (r *anomalyMADReducer) Emit() []FloatPoint {
+	values := r.values()
+	if len(values) == 0 {
+		return nil
+	}
+
+	center, deviation := medianDeviation(values)
+	var points []FloatPoint
+	for i, v := range values {
+		var score float64
+		if deviation > 0 {
+			score = (v - center) / deviation
+		} else if v != center {
+			score = math.Copysign(math.Inf(1), v-center)
+		}
+		if math.Abs(score) >= r.threshold {
+			points = append(points, FloatPoint{Time: r.samples[i].time, Value: score})
+		}
+	}
+	return points
+}
+
+// anomalyESDReducer detects the anomalies of a window with the seasonal hybrid
+// extreme studentized deviate test. The season of the window, the median of
+// each phase of the season, and the median of the window are removed before
+// the generalized ESD test is run on the residuals with the median and the
+// median absolute deviation in place of the mean and the standard deviation.
+//
+// Each anomaly is emitted with its test statistic as its score, signed by the
+// side of the median it falls on.
+type anomalyESDReducer struct {
+	anomalyReducer
+	season   int
+	maxAnoms float64
+	alpha    float64
+}
+
+func newAnomalyESDReducer(season int, maxAnoms, alpha float64) *anomalyESDReducer {
+	return &anomalyESDReducer{season: season, maxAnoms: maxAnoms, alpha: alpha}
+}
+
+// Emit emits the anomalies of the window.
+func (r *anomalyESDReducer) Emit() []FloatPoint {
+	values := r.values()
+	n := len(values)
+	if n < 3 {
+		return nil
+	}
+
+	// Remove the season if the window has two seasons, and the median.
+	residuals := make([]float64, n)
+	copy(residuals, values)
+	if r.season >= 2 && n >= 2*r.season {
+		phases := make([][]float64, r.season)
+		for i, v := range values {
+			phases[i%r.season] = append(phases[i%r.season], v)
+		}
+		seasonal := make([]float64, r.season)
+		for i, phase := range phases {
+			seasonal[i] = median(phase)
+		}
+		for i := range residuals {
+			residuals[i] -= seasonal[i%r.season]
+		}
+	}
+	m := median(residuals)
+	for i := range residuals {
+		residuals[i] -= m
+	}
+
+	k := int(r.maxAnoms * float64(n))
+	if k > n-2 {
+		k = n - 2
+	}
+
+	// Remove the most extreme point k times, and keep the points removed
+	// until the last one whose statistic exceeds its critical value.
+	remaining := make([]int, n)
+	for i := range remaining {
+		remaining[i] = i
+	}
+	type candidate struct {
+		index int
+		score float64
+	}
+	candidates := make([]candidate, 0, k)
+	var anomalies int
+	for i := 1; i <= k; i++ {
+		rest := make([]float64, len(remaining))
+		for j, idx := range remaining {
+			rest[j] = residuals[idx]
+		}
+		center, deviation := medianDeviation(rest)
+		if deviation == 0 {
+			break
+		}
+
+		var worst int
+		var score float64
+		for j, v := range rest {
+			if s := (v - center) / deviation; math.Abs(s) > math.Abs(score) {
+				worst, score = j, s
+			}
+		}
+		candidates = append(candidates, candidate{index: remaining[worst], score: score})
+		remaining = append(remaining[:worst], remaining[worst+1:]...)
+
+		// The critical value of the i-th most extreme point.
+		p := 1 - r.alpha/(2*float64(n-i+1))
+		t := studentTQuantile(p, float64(n-i-1))
+		lambda := float64(n-i) * t / math.Sqrt((float64(n-i-1)+t*t)*float64(n-i+1))
+		if math.Abs(score) > lambda {
+			anomalies = i
+		}
+	}
+
+	points := make([]FloatPoint, anomalies)
+	for i, c := range candidates[:anomalies] {
+		points[i] = FloatPoint{Time: r.samples[c.index].time, Value: c.score}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Time < points[j].Time })
+	return points
+}
+
+// median returns the median of the values.
+func median(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	if n := len(sorted); n%2 == 0 {
+		return (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return sorted[len(sorted)/2]
+}
+
+// medianDeviation returns the median of the values and their median absolute
+// deviation from it, scaled to a standard deviation.
+func medianDeviation(values []float64) (float64, float64) {
+	m := median(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - m)
+	}
+	return m, madScale * median(deviations)
+}
+
+// studentTQuantile returns the quantile p of the Student's t-distribution
+// with df degrees of freedom, for p at least 0.5.
+func studentTQuantile(p, df float64) float64 {
+	cdf := func(t float64) float64 {
+		return 1 - 0.5*regularizedIncompleteBeta(df/2, 0.5, df/(df+t*t))
+	}
+
+	lo, hi := 0.0, 1.0
+	for cdf(hi) < p && hi < 1e12 {
+		lo, hi = hi, hi*2
+	}
+	for i := 0; i < 100; i++ {
+		mid := (lo + hi) / 2
+		if cdf(mid) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// regularizedIncompleteBeta returns the regularized incomplete beta function
+// of x, evaluated with its continued fraction.
+func regularizedIncompleteBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	} else if x >= 1 {
+		return 1
+	}
+
+	// The continued fraction converges quickly below the mean only.
+	if x > (a+1)/(a+b+2) {
+		return 1 - regularizedIncompleteBeta(b, a, 1-x)
+	}
+
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log(1-x))
+
+	const tiny = 1e-300
+	f, c, d := 1.0, 1.0, 0.0
+	for i := 0; i <= 200; i++ {
+		m := float64(i / 2)
+		var numerator float64
+		switch {
+		case i == 0:
+			numerator = 1
+		case i%2 == 0:
+			numerator = m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m))
+		default:
+			numerator = -(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1))
+		}
+
+		d = 1 + numerator*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		d = 1 / d
+		c = 1 + numerator/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		f *= c * d
+		if math.Abs(1-c*d) < 1e-12 {
+			break
+		}
+	}
+	return front * (f - 1) / a
+}
+
+// anomalyArgs returns the parameters of an anomaly detection call.
+func anomalyArgs(call *influxql.Call) (threshold float64, season int, maxAnoms, alpha float64) {
+	number := func(i int, v float64) float64 {
+		if len(call.Args) <= i {
+			return v
+		}
+		switch arg := call.Args[i].(type) {
+		case *influxql.NumberLiteral:
+			return arg.Val
+		case *influxql.IntegerLiteral:
+			return float64(arg.Val)
+		}
+		return v
+	}
+
+	switch call.Name {
+	case "anomaly_mad":
+		return number(1, 0), 0, 0, 0
+	default:
+		return 0, int(number(1, 0)), number(2, anomalyDefaultMaxAnoms), number(3, anomalyDefaultAlpha)
+	}
+}
+
+// newAnomalyIterator returns an iterator for operating on an anomaly_mad() or
+// anomaly_esd() call.
+func newAnomalyIterator(input Iterator, opt IteratorOptions, call *influxql.Call) (Iterator, error) {
+	threshold, season, maxAnoms, alpha := anomalyArgs(call)
+	newReducer := func() interface {
+		FloatPointAggregator
+		IntegerPointAggregator
+		UnsignedPointAggregator
+		FloatPointEmitter
+	} {
+		if call.Name == "anomaly_mad" {
+			return newAnomalyMADReducer(threshold)
+		}
+		return newAnomalyESDReducer(season, maxAnoms, alpha)
+	}
+
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, FloatPointEmitter) {
+			fn := newReducer()
+			return fn, fn
+		}
+		return newFloatReduceFloatIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, FloatPointEmitter) {
+			fn := newReducer()
+			return fn, fn
+		}
+		return newIntegerReduceFloatIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, FloatPointEmitter) {
+			fn := newReducer()
+			return fn, fn
+		}
+		return newUnsignedReduceFloatIterator(input, opt, createFn), nil
+	default:
+		return nil, fmt.Errorf("unsupported %s iterator type: %T", call.Name, input)
+	}
+}
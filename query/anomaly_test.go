@@ -0,0 +1,82 @@
+package query_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+func TestSelect_Anomaly(t *testing.T) {
+	// A season of 4 points with a little noise, and two anomalies: a spike
+	// and a point that is only anomalous for its phase of the season.
+	var points []query.FloatPoint
+	for i := 0; i < 32; i++ {
+		v := float64(i%3) * 0.1
+		if i%2 == 1 {
+			v += 10
+		}
+		switch i {
+		case 9:
+			v = 40
+		case 20:
+			v = 10
+		}
+		points = append(points, query.FloatPoint{Name: "cpu", Tags: ParseTags("host=A"), Time: int64(i) * Second, Value: v})
+	}
+
+	shardMapper := ShardMapper{
+		MapShardsFn: func(sources influxql.Sources, _ influxql.TimeRange) query.ShardGroup {
+			return &ShardGroup{
+				Fields:     map[string]influxql.DataType{"value": influxql.Float},
+				Dimensions: []string{"host"},
+				CreateIteratorFn: func(ctx context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+					return &FloatIterator{Points: append([]query.FloatPoint(nil), points...)}, nil
+				},
+			}
+		},
+	}
+
+	for _, tt := range []struct {
+		q     string
+		times []int64
+	}{
+		{
+			q:     `SELECT anomaly_mad(value, 3) FROM cpu WHERE time >= 0s AND time < 32s GROUP BY time(1m), host`,
+			times: []int64{9 * Second},
+		},
+		{
+			q:     `SELECT anomaly_esd(value, 4) FROM cpu WHERE time >= 0s AND time < 32s GROUP BY time(1m), host`,
+			times: []int64{9 * Second, 20 * Second},
+		},
+		{
+			q:     `SELECT anomaly_esd(value, 4, 0.2, 0.01) FROM cpu WHERE time >= 0s AND time < 32s GROUP BY time(1m), host`,
+			times: []int64{9 * Second, 20 * Second},
+		},
+	} {
+		t.Run(tt.q, func(t *testing.T) {
+			stmt := MustParseSelectStatement(tt.q)
+			stmt.OmitTime = true
+			cur, err := query.Select(context.Background(), stmt, &shardMapper, query.SelectOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows, err := ReadCursor(cur)
+			if err != nil {
+				t.Fatal(err)
+			} else if len(rows) != len(tt.times) {
+				t.Fatalf("unexpected rows: %v", rows)
+			}
+			for i, row := range rows {
+				if row.Time != tt.times[i] {
+					t.Fatalf("unexpected time: %d", row.Time)
+				} else if !row.Series.Tags.Equal(ParseTags("host=A")) {
+					t.Fatalf("unexpected tags: %v", row.Series.Tags)
+				} else if score := row.Values[0].(float64); score <= 3 {
+					t.Fatalf("unexpected score at %d: %v", row.Time, score)
+				}
+			}
+		})
+	}
+}
@@ -106,6 +106,13 @@ func newCompiler(opt CompileOptions) *compiledStatement {
 func Compile(stmt *influxql.SelectStatement, opt CompileOptions) (Statement, error) {
 	c := newCompiler(opt)
 	c.stmt = stmt.Clone()
+
+	// Read the fields of each measurement qualified by its name from a
+	// subquery so that they can be combined.
+	var err error
+	if c.stmt, err = rewriteMeasurementFields(c.stmt); err != nil {
+		return nil, err
+	}
 	if err := c.preprocess(c.stmt); err != nil {
 		return nil, err
 	}
@@ -132,8 +139,14 @@ func (c *compiledStatement) preprocess(stmt *influxql.SelectStatement) error {
 	c.Limit = stmt.Limit
 	c.HasTarget = stmt.Target != nil
 
+	// Rewrite the geo functions into conditions on the geohash tags.
+	cond, err := rewriteGeoConditions(stmt.Condition)
+	if err != nil {
+		return err
+	}
+
 	valuer := influxql.NowValuer{Now: c.Options.Now, Location: stmt.Location}
-	cond, t, err := influxql.ConditionExpr(stmt.Condition, &valuer)
+	cond, t, err := influxql.ConditionExpr(cond, &valuer)
 	if err != nil {
 		return err
 	}
@@ -295,9 +308,15 @@ func (c *compiledField) compileExpr(expr influxql.Expr) error {
 			return c.compileElapsed(expr.Args)
 		case "integral":
 			return c.compileIntegral(expr.Args)
+		case "rate":
+			return c.compileRate(expr.Args)
 		case "holt_winters", "holt_winters_with_fit":
 			withFit := expr.Name == "holt_winters_with_fit"
 			return c.compileHoltWinters(expr.Args, withFit)
+		case "forecast", "forecast_lower", "forecast_upper":
+			return c.compileForecast(expr.Name, expr.Args)
+		case "anomaly_mad", "anomaly_esd":
+			return c.compileAnomaly(expr.Name, expr.Args)
 		default:
 			return c.compileFunction(expr)
 		}
@@ -330,6 +349,34 @@ func (c *compiledField) compileExpr(expr influxql.Expr) error {
 		}
 	case *influxql.ParenExpr:
 		return c.compileExpr(expr.Expr)
+	case *influxql.CaseExpr:
+		// Disallow wildcards in case expressions for the same reason as in
+		// binary expressions.
+		c.AllowWildcard = false
+
+		exprs := make([]influxql.Expr, 0, 2*len(expr.WhenClauses)+1)
+		for _, w := range expr.WhenClauses {
+			exprs = append(exprs, w.Condition, w.Result)
+		}
+		if expr.Else != nil {
+			exprs = append(exprs, expr.Else)
+		}
+
+		// Compile the conditions and results that are not just literals.
+		var compiled bool
+		for _, e := range exprs {
+			if _, ok := e.(influxql.Literal); ok {
+				continue
+			}
+			if err := c.compileExpr(e); err != nil {
+				return err
+			}
+			compiled = true
+		}
+		if !compiled {
+			return errors.New("field must contain at least one variable")
+		}
+		return nil
 	case influxql.Literal:
 		return errors.New("field must contain at least one variable")
 	}
@@ -771,6 +818,36 @@ func (c *compiledField) compileIntegral(args []influxql.Expr) error {
 	return c.compileSymbol("integral", args[0])
 }
 
+func (c *compiledField) compileRate(args []influxql.Expr) error {
+	if min, max, got := 1, 3, len(args); got > max || got < min {
+		return fmt.Errorf("invalid number of arguments for rate, expected at least %d but no more than %d, got %d", min, max, got)
+	}
+
+	// The optional arguments are the time unit and then the counter reset mode.
+	for i, arg := range args[1:] {
+		switch arg := arg.(type) {
+		case *influxql.DurationLiteral:
+			if i > 0 {
+				return errors.New("third argument to rate must be a string")
+			} else if arg.Val <= 0 {
+				return fmt.Errorf("duration argument must be positive, got %s", influxql.FormatDuration(arg.Val))
+			}
+		case *influxql.StringLiteral:
+			if i < len(args)-2 {
+				return errors.New("second argument to rate must be a duration")
+			} else if !isRateResetMode(arg.Val) {
+				return fmt.Errorf("invalid counter reset mode for rate: %q, expected %s, %s or %s", arg.Val, RateResetCounter, RateResetSkip, RateResetNone)
+			}
+		default:
+			return fmt.Errorf("invalid argument to rate, expected a duration or a string, got %T", arg)
+		}
+	}
+	c.global.OnlySelectors = false
+
+	// Must be a variable reference, wildcard, or regexp.
+	return c.compileSymbol("rate", args[0])
+}
+
 func (c *compiledField) compileHoltWinters(args []influxql.Expr, withFit bool) error {
 	name := "holt_winters"
 	if withFit {
@@ -805,6 +882,105 @@ func (c *compiledField) compileHoltWinters(args []influxql.Expr, withFit bool) e
 	return c.compileNestedExpr(call)
 }
 
+func (c *compiledField) compileForecast(name string, args []influxql.Expr) error {
+	if min, max, got := 2, 4, len(args); got > max || got < min {
+		return fmt.Errorf("invalid number of arguments for %s, expected at least %d but no more than %d, got %d", name, min, max, got)
+	}
+
+	if d, ok := args[1].(*influxql.DurationLiteral); !ok {
+		return fmt.Errorf("expected duration argument as second arg in %s", name)
+	} else if d.Val <= 0 {
+		return fmt.Errorf("second arg to %s must be greater than 0, got %s", name, influxql.FormatDuration(d.Val))
+	}
+
+	if len(args) > 2 {
+		if s, ok := args[2].(*influxql.IntegerLiteral); !ok {
+			return fmt.Errorf("expected integer argument as third arg in %s", name)
+		} else if s.Val < 0 {
+			return fmt.Errorf("third arg to %s cannot be negative, got %d", name, s.Val)
+		}
+	}
+	if len(args) > 3 {
+		if s, ok := args[3].(*influxql.StringLiteral); !ok {
+			return fmt.Errorf("expected string argument as fourth arg in %s", name)
+		} else if s.Val == "" {
+			return fmt.Errorf("fourth arg to %s cannot be empty", name)
+		}
+	}
+	c.global.OnlySelectors = false
+
+	if c.global.Interval.IsZero() {
+		return fmt.Errorf("%s aggregate requires a GROUP BY interval", name)
+	}
+
+	// A field is forecast from its mean.
+	switch arg0 := args[0].(type) {
+	case *influxql.VarRef:
+		return c.compileNestedExpr(forecastInput(&influxql.Call{Name: name, Args: args}))
+	case *influxql.Call:
+		return c.compileNestedExpr(arg0)
+	default:
+		return fmt.Errorf("expected field or aggregate function argument in %s", name)
+	}
+}
+
+func (c *compiledField) compileAnomaly(name string, args []influxql.Expr) error {
+	max := 2
+	if name == "anomaly_esd" {
+		max = 4
+	}
+	if min, got := 1, len(args); got > max || got < min {
+		return fmt.Errorf("invalid number of arguments for %s, expected at least %d but no more than %d, got %d", name, min, max, got)
+	}
+
+	// number returns the value of a numeric argument.
+	number := func(arg influxql.Expr) (float64, bool) {
+		switch arg := arg.(type) {
+		case *influxql.NumberLiteral:
+			return arg.Val, true
+		case *influxql.IntegerLiteral:
+			return float64(arg.Val), true
+		}
+		return 0, false
+	}
+
+	if name == "anomaly_mad" {
+		if len(args) > 1 {
+			if v, ok := number(args[1]); !ok {
+				return fmt.Errorf("expected number argument as second arg in %s", name)
+			} else if v < 0 {
+				return fmt.Errorf("second arg to %s cannot be negative, got %v", name, v)
+			}
+		}
+	} else {
+		if len(args) > 1 {
+			if s, ok := args[1].(*influxql.IntegerLiteral); !ok {
+				return fmt.Errorf("expected integer argument as second arg in %s", name)
+			} else if s.Val < 0 {
+				return fmt.Errorf("second arg to %s cannot be negative, got %d", name, s.Val)
+			}
+		}
+		if len(args) > 2 {
+			if v, ok := number(args[2]); !ok {
+				return fmt.Errorf("expected number argument as third arg in %s", name)
+			} else if v <= 0 || v > 0.5 {
+				return fmt.Errorf("third arg to %s must be greater than 0 and at most 0.5, got %v", name, v)
+			}
+		}
+		if len(args) > 3 {
+			if v, ok := number(args[3]); !ok {
+				return fmt.Errorf("expected number argument as fourth arg in %s", name)
+			} else if v <= 0 || v >= 1 {
+				return fmt.Errorf("fourth arg to %s must be between 0 and 1, got %v", name, v)
+			}
+		}
+	}
+	c.global.OnlySelectors = false
+
+	// Must be a variable reference, wildcard, or regexp.
+	return c.compileSymbol(name, args[0])
+}
+
 func (c *compiledField) compileDistinct(args []influxql.Expr, nested bool) error {
 	if len(args) == 0 {
 		return errors.New("distinct function requires at least one argument")
@@ -869,16 +1045,8 @@ func (c *compiledField) compileTopBottom(call *influxql.Call) error {
 }
 
 func (c *compiledField) compileMathFunction(expr *influxql.Call) error {
-	// How many arguments are we expecting?
-	nargs := 1
-	switch expr.Name {
-	case "atan2", "pow", "log":
-		nargs = 2
-	}
-
-	// Did we get the expected number of args?
-	if got := len(expr.Args); got != nargs {
-		return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, nargs, got)
+	if err := validateMathFunctionArgs(expr); err != nil {
+		return err
 	}
 
 	// Compile all the argument expressions that are not just literals.
@@ -893,6 +1061,37 @@ func (c *compiledField) compileMathFunction(expr *influxql.Call) error {
 	return nil
 }
 
+// validateMathFunctionArgs verifies the number of arguments of a math function.
+func validateMathFunctionArgs(expr *influxql.Call) error {
+	// How many arguments are we expecting?
+	min, max := mathFunctionArgs(expr.Name)
+
+	// Did we get the expected number of args?
+	if got := len(expr.Args); got < min || (max >= 0 && got > max) {
+		if min == max {
+			return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, min, got)
+		} else if max < 0 {
+			return fmt.Errorf("invalid number of arguments for %s, expected at least %d, got %d", expr.Name, min, got)
+		}
+		return fmt.Errorf("invalid number of arguments for %s, expected %d to %d, got %d", expr.Name, min, max, got)
+	}
+
+	switch expr.Name {
+	case "round":
+		// The precision of round() must be an integer literal.
+		if len(expr.Args) == 2 {
+			if _, ok := expr.Args[1].(*influxql.IntegerLiteral); !ok {
+				return errors.New("expected integer argument as second arg in round()")
+			}
+		}
+	case "regex_extract":
+		if _, ok := expr.Args[1].(*influxql.RegexLiteral); !ok {
+			return errors.New("expected regex argument as second arg in regex_extract()")
+		}
+	}
+	return nil
+}
+
 func (c *compiledStatement) compileDimensions(stmt *influxql.SelectStatement) error {
 	for _, d := range stmt.Dimensions {
 		// Reduce the expression before attempting anything. Do not evaluate the call.
@@ -910,12 +1109,24 @@ func (c *compiledStatement) compileDimensions(stmt *influxql.SelectStatement) er
 				return errors.New("only time() calls allowed in dimensions")
 			} else if got := len(expr.Args); got < 1 || got > 2 {
 				return errors.New("time dimension expected 1 or 2 arguments")
-			} else if lit, ok := expr.Args[0].(*influxql.DurationLiteral); !ok {
+			} else if !isIntervalLiteral(expr.Args[0]) {
 				return errors.New("time dimension must have duration argument")
 			} else if c.Interval.Duration != 0 {
 				return errors.New("multiple time dimensions not allowed")
+			} else if lit, ok := expr.Args[0].(*influxql.CalendarLiteral); ok {
+				// Calendar windows vary in length, so they can only be shifted
+				// by a fixed duration.
+				c.Interval.Duration = lit.MaxDuration()
+				c.Interval.Months = lit.Months
+				if len(expr.Args) == 2 {
+					offset, ok := expr.Args[1].(*influxql.DurationLiteral)
+					if !ok {
+						return errors.New("calendar time dimension offset must be a duration")
+					}
+					c.Interval.Offset = offset.Val
+				}
 			} else {
-				c.Interval.Duration = lit.Val
+				c.Interval.Duration = expr.Args[0].(*influxql.DurationLiteral).Val
 				if len(expr.Args) == 2 {
 					switch lit := expr.Args[1].(type) {
 					case *influxql.DurationLiteral:
@@ -963,6 +1174,40 @@ func (c *compiledStatement) compileDimensions(stmt *influxql.SelectStatement) er
 	return nil
 }
 
+// systemColumns are the columns added to raw queries to debug where each
+// point was stored.
+var systemColumns = []*influxql.VarRef{
+	{Val: "_shard", Type: influxql.Integer},
+	{Val: "_replica", Type: influxql.Integer},
+	{Val: "_ingest_time", Type: influxql.Integer},
+}
+
+// addSystemColumns appends the system columns that stmt does not already
+// select to its fields.
+func addSystemColumns(stmt *influxql.SelectStatement) {
+	names := make(map[string]struct{}, len(stmt.Fields))
+	for _, f := range stmt.Fields {
+		names[f.Name()] = struct{}{}
+	}
+	for _, ref := range systemColumns {
+		if _, ok := names[ref.Val]; ok {
+			continue
+		}
+		ref := *ref
+		stmt.Fields = append(stmt.Fields, &influxql.Field{Expr: &ref})
+	}
+}
+
+// isIntervalLiteral returns true if expr is a valid time dimension interval.
+func isIntervalLiteral(expr influxql.Expr) bool {
+	switch expr.(type) {
+	case *influxql.DurationLiteral, *influxql.CalendarLiteral:
+		return true
+	default:
+		return false
+	}
+}
+
 // validateFields validates that the fields are mutually compatible with each other.
 // This runs at the end of compilation but before linking.
 func (c *compiledStatement) validateFields() error {
@@ -1020,16 +1265,8 @@ func (c *compiledStatement) validateCondition(expr influxql.Expr) error {
 			return fmt.Errorf("invalid function call in condition: %s", expr)
 		}
 
-		// How many arguments are we expecting?
-		nargs := 1
-		switch expr.Name {
-		case "atan2", "pow":
-			nargs = 2
-		}
-
-		// Did we get the expected number of args?
-		if got := len(expr.Args); got != nargs {
-			return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, nargs, got)
+		if err := validateMathFunctionArgs(expr); err != nil {
+			return err
 		}
 
 		// Are all the args valid?
@@ -1147,6 +1384,23 @@ func (c *compiledStatement) Prepare(shardMapper ShardMapper, sopt SelectOptions)
 		return nil, err
 	}
 
+	// Choose an interval that limits the points returned for each series.
+	// Like an aggregate query, the time range ends at now() if unbounded.
+	endTime := c.TimeRange.MaxTimeNano()
+	if sopt.MaxPointsPerSeries > 0 {
+		if endTime == influxql.MaxTime {
+			endTime = c.Options.Now.UnixNano()
+		}
+		if err := downsample(stmt, c.TimeRange.MinTimeNano(), endTime, sopt.MaxPointsPerSeries); err != nil {
+			shards.Close()
+			return nil, err
+		}
+	}
+
+	if sopt.SystemColumns && stmt.IsRawQuery {
+		addSystemColumns(stmt)
+	}
+
 	// Validate if the types are correct now that they have been assigned.
 	if err := validateTypes(stmt); err != nil {
 		shards.Close()
@@ -1159,7 +1413,7 @@ func (c *compiledStatement) Prepare(shardMapper ShardMapper, sopt SelectOptions)
 		shards.Close()
 		return nil, err
 	}
-	opt.StartTime, opt.EndTime = c.TimeRange.MinTimeNano(), c.TimeRange.MaxTimeNano()
+	opt.StartTime, opt.EndTime = c.TimeRange.MinTimeNano(), endTime
 	opt.Ascending = c.Ascending
 
 	if sopt.MaxBucketsN > 0 && !stmt.IsRawQuery && c.TimeRange.MinTimeNano() > influxql.MinTime {
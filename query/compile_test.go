@@ -109,6 +109,21 @@ func TestCompile_Success(t *testing.T) {
 		`SELECT log10(value) FROM cpu`,
 		`SELECT sin(value) - sin(1.3) FROM cpu`,
 		`SELECT value FROM cpu WHERE sin(value) > 0.5`,
+		`SELECT round(value, 2), clamp_max(value, 100), int(value), string(value) FROM cpu`,
+		`SELECT CASE WHEN value > 100 THEN 1 ELSE 0 END FROM cpu`,
+		`SELECT CASE WHEN mean(value) > 100 THEN 'high' WHEN mean(value) > 10 THEN 'medium' END FROM cpu GROUP BY time(1m)`,
+		`SELECT if(value > 100, 1, 0) FROM cpu`,
+		`SELECT lower(host), substr(message, 1, 10), concat(host, ':', port) FROM logs`,
+		`SELECT regex_extract(message, /level=(\w+)/), replace(message, /\d+/, 'N') FROM logs`,
+		`SELECT message FROM logs WHERE lower(host) = 'server01' AND regex_extract(message, /level=(\w+)/) = 'error'`,
+		`SELECT sum(hits.count) / sum(misses.count) FROM hits, misses WHERE time >= now() - 1h GROUP BY time(10m)`,
+		`SELECT rate(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(1m)`,
+		`SELECT rate(value, 1m, 'skip'), rate(value, 'none') FROM cpu WHERE time >= now() - 1h GROUP BY time(1m)`,
+		`SELECT forecast(value, 1h), forecast_lower(max(value), 1h, 24), forecast_upper(value, 1h, 24, 'capacity') FROM cpu WHERE time >= now() - 7d GROUP BY time(1h)`,
+		`SELECT anomaly_mad(value, 3.5) INTO cpu_anomalies FROM cpu WHERE time >= now() - 1h GROUP BY time(1h), *`,
+		`SELECT anomaly_esd(value), anomaly_esd(value, 24, 0.05, 0.01) FROM cpu WHERE time >= now() - 1d GROUP BY time(1d)`,
+		`SELECT last(lat), last(lon) FROM fleet WHERE geo.radius(37.7749, -122.4194, 5) AND time >= now() - 1h GROUP BY vehicle`,
+		`SELECT count(speed) FROM fleet WHERE (geo.within(37.7, -122.52, 37.82, -122.35, 'cell') OR geo.radius(-33.87, 151.21, 0.5)) AND speed > 10`,
 		`SELECT sum("out")/sum("in") FROM (SELECT derivative("out") AS "out", derivative("in") AS "in" FROM "m0" WHERE time >= now() - 5m GROUP BY "index") GROUP BY time(1m) fill(none)`,
 	} {
 		t.Run(tt, func(t *testing.T) {
@@ -359,7 +374,41 @@ func TestCompile_Failures(t *testing.T) {
 		{s: `SELECT log10(value, 3) FROM cpu`, err: `invalid number of arguments for log10, expected 1, got 2`},
 		{s: `SELECT pow(value, 3, 3) FROM cpu`, err: `invalid number of arguments for pow, expected 2, got 3`},
 		{s: `SELECT atan2(value, 3, 3) FROM cpu`, err: `invalid number of arguments for atan2, expected 2, got 3`},
+		{s: `SELECT round(value, 2, 3) FROM cpu`, err: `invalid number of arguments for round, expected 1 to 2, got 3`},
+		{s: `SELECT round(value, 2.5) FROM cpu`, err: `expected integer argument as second arg in round()`},
+		{s: `SELECT clamp_min(value) FROM cpu`, err: `invalid number of arguments for clamp_min, expected 2, got 1`},
+		{s: `SELECT int(value, 3) FROM cpu`, err: `invalid number of arguments for int, expected 1, got 2`},
+		{s: `SELECT value FROM cpu WHERE log(value) > 1`, err: `invalid number of arguments for log, expected 2, got 1`},
 		{s: `SELECT sin(1.3) FROM cpu`, err: `field must contain at least one variable`},
+		{s: `SELECT CASE WHEN true THEN 1 ELSE 0 END FROM cpu`, err: `field must contain at least one variable`},
+		{s: `SELECT CASE WHEN value > 100 THEN * END FROM cpu`, err: `unable to use wildcard in a binary expression`},
+		{s: `SELECT if(value > 100, 1) FROM cpu`, err: `invalid number of arguments for if, expected 3, got 2`},
+		{s: `SELECT concat(host) FROM logs`, err: `invalid number of arguments for concat, expected at least 2, got 1`},
+		{s: `SELECT substr(host) FROM logs`, err: `invalid number of arguments for substr, expected 2 to 3, got 1`},
+		{s: `SELECT regex_extract(message, 'level') FROM logs`, err: `expected regex argument as second arg in regex_extract()`},
+		{s: `SELECT hits.count / misses.count FROM hits, misses`, err: `fields of different measurements can only be combined by aggregates`},
+		{s: `SELECT sum(hits.count) / sum(total) FROM hits, misses`, err: `field total must be qualified by its measurement`},
+		{s: `SELECT rate(value, 1m, 'reset') FROM cpu`, err: `invalid counter reset mode for rate: "reset", expected counter, skip or none`},
+		{s: `SELECT rate(value, 'skip', 1m) FROM cpu`, err: `second argument to rate must be a duration`},
+		{s: `SELECT rate(value, 0s) FROM cpu`, err: `duration argument must be positive, got 0s`},
+		{s: `SELECT forecast(value) FROM cpu WHERE time >= now() - 1d GROUP BY time(1h)`, err: `invalid number of arguments for forecast, expected at least 2 but no more than 4, got 1`},
+		{s: `SELECT forecast(value, 1h) FROM cpu WHERE time >= now() - 1d`, err: `forecast aggregate requires a GROUP BY interval`},
+		{s: `SELECT forecast(value, 10) FROM cpu WHERE time >= now() - 1d GROUP BY time(1h)`, err: `expected duration argument as second arg in forecast`},
+		{s: `SELECT forecast_upper(value, 1h, -1) FROM cpu WHERE time >= now() - 1d GROUP BY time(1h)`, err: `third arg to forecast_upper cannot be negative, got -1`},
+		{s: `SELECT forecast(value, 1h, 24, 24) FROM cpu WHERE time >= now() - 1d GROUP BY time(1h)`, err: `expected string argument as fourth arg in forecast`},
+		{s: `SELECT anomaly_mad(value, 3, 1) FROM cpu`, err: `invalid number of arguments for anomaly_mad, expected at least 1 but no more than 2, got 3`},
+		{s: `SELECT anomaly_mad(value, -1) FROM cpu`, err: `second arg to anomaly_mad cannot be negative, got -1`},
+		{s: `SELECT anomaly_mad(mean(value)) FROM cpu`, err: `expected field argument in anomaly_mad()`},
+		{s: `SELECT anomaly_esd(value, 1.5) FROM cpu`, err: `expected integer argument as second arg in anomaly_esd`},
+		{s: `SELECT anomaly_esd(value, 24, 0.6) FROM cpu`, err: `third arg to anomaly_esd must be greater than 0 and at most 0.5, got 0.6`},
+		{s: `SELECT anomaly_esd(value, 24, 0.1, 1) FROM cpu`, err: `fourth arg to anomaly_esd must be between 0 and 1, got 1`},
+		{s: `SELECT value FROM fleet WHERE geo.radius(37.7749, -122.4194)`, err: `invalid number of arguments for geo.radius, expected at least 3 but no more than 4, got 2`},
+		{s: `SELECT value FROM fleet WHERE geo.radius(37.7749, -122.4194, 0)`, err: `radius must be greater than 0 in geo.radius()`},
+		{s: `SELECT value FROM fleet WHERE geo.radius(97.7749, -122.4194, 5)`, err: `invalid location in geo.radius(): latitude must be between -90 and 90`},
+		{s: `SELECT value FROM fleet WHERE geo.radius(lat, lon, 5)`, err: `expected number argument in geo.radius()`},
+		{s: `SELECT value FROM fleet WHERE geo.within(37.82, -122.52, 37.7, -122.35)`, err: `invalid box in geo.within(): minimum latitude must not be greater than the maximum latitude`},
+		{s: `SELECT value FROM fleet WHERE geo.within(37.7, -122.52, 37.82, -122.35, 5)`, err: `expected tag key string argument in geo.within()`},
+		{s: `SELECT value FROM fleet WHERE geo.within(37.7, -122.52, 37.82, -122.35) = true`, err: `invalid function call in condition: geo.within(37.700, -122.520, 37.820, -122.350)`},
 		{s: `SELECT nofunc(1.3) FROM cpu`, err: `undefined function nofunc()`},
 	} {
 		t.Run(tt.s, func(t *testing.T) {
@@ -393,6 +393,20 @@ func (cur *filterCursor) Scan(row *Row) bool {
 	return false
 }
 
+// nameCursor overrides the measurement name of the rows of a cursor.
+type nameCursor struct {
+	Cursor
+	name string
+}
+
+func (cur *nameCursor) Scan(row *Row) bool {
+	if !cur.Cursor.Scan(row) {
+		return false
+	}
+	row.Series.Name = cur.name
+	return true
+}
+
 type nullCursor struct {
 	columns []influxql.VarRef
 }
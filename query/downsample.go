@@ -0,0 +1,98 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+// downsampleUnits are the units a downsample interval is rounded up to, so
+// the intervals chosen are readable.
+var downsampleUnits = []time.Duration{
+	time.Hour,
+	time.Minute,
+	time.Second,
+	time.Millisecond,
+	time.Microsecond,
+}
+
+// downsampleInterval returns the shortest interval that divides the time
+// range between start and end into no more than n windows. A window of 0 is
+// returned if the time range must not be divided at all.
+func downsampleInterval(start, end int64, n int) time.Duration {
+	if n <= 1 {
+		return 0
+	}
+
+	// Windows are aligned to the epoch rather than to the start time, so the
+	// time range can overlap one more window than it spans.
+	span := end - start
+	d := time.Duration((span + int64(n-1) - 1) / int64(n-1))
+	if d <= 0 {
+		d = 1
+	}
+	for _, u := range downsampleUnits {
+		if d >= u {
+			return (d + u - 1) / u * u
+		}
+	}
+	return d
+}
+
+// downsample rewrites stmt so that no series returns more than n points
+// between start and end.
+//
+// The GROUP BY time() interval of an aggregate query is widened when it
+// would return more than n points. A raw query is grouped by the interval
+// and each field is replaced with its mean(), or its last() value if the
+// field is not numeric. Empty windows are not filled for raw queries.
+func downsample(stmt *influxql.SelectStatement, start, end int64, n int) error {
+	if start == influxql.MinTime {
+		return errors.New("max_points requires a lower bound on time")
+	}
+	interval := downsampleInterval(start, end, n)
+
+	if !stmt.IsRawQuery {
+		current, err := stmt.GroupByInterval()
+		if err != nil {
+			return err
+		} else if current > 0 && (interval == 0 || current < interval) {
+			stmt.SetGroupByInterval(interval)
+		}
+		return nil
+	}
+
+	for _, f := range stmt.Fields {
+		var err error
+		alias := f.Name()
+		f.Expr = influxql.RewriteExpr(f.Expr, func(expr influxql.Expr) influxql.Expr {
+			ref, ok := expr.(*influxql.VarRef)
+			if !ok {
+				return expr
+			}
+
+			switch ref.Type {
+			case influxql.Float, influxql.Integer, influxql.Unsigned:
+				return &influxql.Call{Name: "mean", Args: []influxql.Expr{ref}}
+			case influxql.Tag:
+				if err == nil {
+					err = fmt.Errorf("max_points cannot be used when selecting tag %s", ref.Val)
+				}
+				return expr
+			default:
+				return &influxql.Call{Name: "last", Args: []influxql.Expr{ref}}
+			}
+		})
+		if err != nil {
+			return err
+		}
+		f.Alias = alias
+	}
+
+	stmt.SetGroupByInterval(interval)
+	stmt.Fill = influxql.NoFill
+	stmt.IsRawQuery = false
+	return nil
+}
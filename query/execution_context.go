@@ -84,6 +84,11 @@ func (ctx *ExecutionContext) Value(key interface{}) interface{} {
 	switch key {
 	case monitorContextKey:
 		return ctx.task
+	case memoryTrackerContextKey, spillerContextKey:
+		if ctx.task == nil {
+			return nil
+		}
+		return ctx.task
 	}
 	return ctx.Context.Value(key)
 }
@@ -101,13 +106,38 @@ func (ctx *ExecutionContext) send(result *Result) error {
 }
 
 // Send sends a Result to the Results channel and will exit if the query has
-// been interrupted or aborted.
+// been interrupted or aborted. A paginated query that waits for its results
+// to be read releases its slot until they are.
 func (ctx *ExecutionContext) Send(result *Result) error {
 	result.StatementID = ctx.statementID
+	if !ctx.Paginated || ctx.task == nil || ctx.task.manager == nil {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ctx.Results <- result:
+		}
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case ctx.Results <- result:
+		return nil
+	default:
+	}
+
+	ctx.task.manager.pauseQuery(ctx.task)
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	case ctx.Results <- result:
 	}
+	if err := ctx.task.manager.resumeQuery(ctx.task, ctx.Done()); err != nil {
+		if e := ctx.Err(); e != nil {
+			return e
+		}
+		return err
+	}
 	return nil
 }
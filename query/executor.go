@@ -39,6 +39,18 @@ var (
 
 	// ErrAlreadyKilled is returned when attempting to kill a query that has already been killed.
 	ErrAlreadyKilled = errors.New("already killed")
+
+	// ErrQueryMemoryPressure is an error when a query is killed because the
+	// running queries exceeded the query memory budget and it was the largest.
+	ErrQueryMemoryPressure = errors.New("query killed to relieve memory pressure")
+
+	// ErrQueryMemoryQueueTimeout is an error when a query waited too long for
+	// running queries to release memory.
+	ErrQueryMemoryQueueTimeout = errors.New("query-memory-queue-timeout exceeded")
+
+	// ErrQueryQueueTimeout is an error when a query waited too long in the
+	// queue for a running query to finish.
+	ErrQueryQueueTimeout = errors.New("query-queue-timeout exceeded")
 )
 
 // Statistics for the Executor
@@ -49,6 +61,15 @@ const (
 	statQueryExecutionDuration = "queryDurationNs" // Total (wall) time spent executing queries.
 	statRecoveredPanics        = "recoveredPanics" // Number of panics recovered by Query Executor.
 
+	statQueriesQueued      = "queriesQueued"        // Number of queries waiting in the queue.
+	statQueriesDequeued    = "queriesDequeued"      // Number of queries that have left the queue.
+	statQueryQueueDuration = "queryQueueDurationNs" // Total time queries spent in the queue.
+	statQueueTimeouts      = "queueTimeouts"        // Number of queries that timed out in the queue.
+	statQueueRejections    = "queueRejections"      // Number of queries rejected because the queue was full.
+
+	statQueriesSpilled = "queriesSpilled" // Number of finished queries that spilled to disk.
+	statSpilledBytes   = "spilledBytes"   // Total bytes finished queries spilled to disk.
+
 	// PanicCrashEnv is the environment variable that, when set, will prevent
 	// the handler from recovering any panics.
 	PanicCrashEnv = "FREETSDB_PANIC_CRASH"
@@ -68,6 +89,31 @@ func ErrMaxConcurrentQueriesLimitExceeded(n, limit int) error {
 	return fmt.Errorf("max-concurrent-queries limit exceeded(%d, %d)", n, limit)
 }
 
+// ErrMaxConcurrentBatchQueriesLimitExceeded is an error when a batch priority
+// query cannot be run because the maximum number of batch queries has been
+// reached.
+func ErrMaxConcurrentBatchQueriesLimitExceeded(n, limit int) error {
+	return fmt.Errorf("max-concurrent-batch-queries limit exceeded(%d, %d)", n, limit)
+}
+
+// ErrMaxQueuedQueriesLimitExceeded is an error when a query cannot wait for a
+// slot because the queue is full.
+func ErrMaxQueuedQueriesLimitExceeded(n, limit int) error {
+	return fmt.Errorf("max-queued-queries limit exceeded(%d, %d)", n, limit)
+}
+
+// ErrMaxQueryMemoryLimitExceeded is an error when a query allocates more
+// memory than a single query is allowed.
+func ErrMaxQueryMemoryLimitExceeded(n, limit int64) error {
+	return fmt.Errorf("max-query-memory limit exceeded: (%d/%d)", n, limit)
+}
+
+// ErrQueryMemoryBudgetExceeded is an error when the running queries together
+// allocate more memory than the query memory budget.
+func ErrQueryMemoryBudgetExceeded(n, limit int64) error {
+	return fmt.Errorf("query-memory-budget exceeded: (%d/%d)", n, limit)
+}
+
 // Authorizer determines if certain operations are authorized.
 type Authorizer interface {
 	// AuthorizeDatabase indicates whether the given Privilege is authorized on the database with the given name.
@@ -138,8 +184,24 @@ type ExecutionOptions struct {
 	// Quiet suppresses non-essential output from the query executor.
 	Quiet bool
 
+	// Priority the query is admitted with when concurrent queries are limited.
+	Priority QueryPriority
+
+	// MaxPointsPerSeries downsamples select statements so that no series
+	// returns more than this number of points.
+	MaxPointsPerSeries int
+
+	// SystemColumns returns the shard and the data node that served each
+	// point of a raw query and the time the point was written to it.
+	SystemColumns bool
+
 	// AbortCh is a channel that signals when results are no longer desired by the caller.
 	AbortCh <-chan struct{}
+
+	// Paginated marks a query whose results are read a page at a time. The
+	// query does not hold its slot under the concurrent query limits while
+	// its results wait to be read, and waits for a slot again to go on.
+	Paginated bool
 }
 
 type contextKey int
@@ -147,6 +209,9 @@ type contextKey int
 const (
 	iteratorsContextKey contextKey = iota
 	monitorContextKey
+	memoryTrackerContextKey
+	spillerContextKey
+	forecastModelsContextKey
 )
 
 // NewContextWithIterators returns a new context.Context with the *Iterators slice added.
@@ -205,6 +270,8 @@ type Statistics struct {
 
 // Statistics returns statistics for periodic monitoring.
 func (e *Executor) Statistics(tags map[string]string) []models.Statistic {
+	queue := e.TaskManager.QueueStatistics()
+	spill := e.TaskManager.SpillStatistics()
 	return []models.Statistic{{
 		Name: "queryExecutor",
 		Tags: tags,
@@ -214,6 +281,13 @@ func (e *Executor) Statistics(tags map[string]string) []models.Statistic {
 			statQueriesFinished:        atomic.LoadInt64(&e.stats.FinishedQueries),
 			statQueryExecutionDuration: atomic.LoadInt64(&e.stats.QueryExecutionDuration),
 			statRecoveredPanics:        atomic.LoadInt64(&e.stats.RecoveredPanics),
+			statQueriesQueued:          queue.QueuedQueries,
+			statQueriesDequeued:        queue.DequeuedQueries,
+			statQueryQueueDuration:     queue.QueueDuration,
+			statQueueTimeouts:          queue.QueueTimeouts,
+			statQueueRejections:        queue.QueueRejections,
+			statQueriesSpilled:         spill.SpilledQueries,
+			statSpilledBytes:           spill.SpilledBytes,
 		},
 	}}
 }
@@ -230,15 +304,39 @@ func (e *Executor) WithLogger(log *zap.Logger) {
 
 // ExecuteQuery executes each statement within a query.
 func (e *Executor) ExecuteQuery(query *influxql.Query, opt ExecutionOptions, closing chan struct{}) <-chan *Result {
+	return e.ExecuteQueryContext(context.Background(), query, opt, closing)
+}
+
+// ExecuteQueryContext executes each statement within a query. The query is
+// interrupted once ctx is done, which stops the iterators of the statement
+// being executed.
+func (e *Executor) ExecuteQueryContext(ctx context.Context, query *influxql.Query, opt ExecutionOptions, closing chan struct{}) <-chan *Result {
 	results := make(chan *Result)
-	go e.executeQuery(query, opt, closing, results)
+	go e.executeQuery(ctx, query, opt, closing, results)
 	return results
 }
 
-func (e *Executor) executeQuery(query *influxql.Query, opt ExecutionOptions, closing <-chan struct{}, results chan *Result) {
+func (e *Executor) executeQuery(parent context.Context, query *influxql.Query, opt ExecutionOptions, closing <-chan struct{}, results chan *Result) {
 	defer close(results)
 	defer e.recover(query, results)
 
+	// Interrupt the query once the parent context is done, including while
+	// it waits to be admitted.
+	if done := parent.Done(); done != nil {
+		interrupt, finished := make(chan struct{}), make(chan struct{})
+		defer close(finished)
+		go func(closing <-chan struct{}) {
+			select {
+			case <-done:
+				close(interrupt)
+			case <-closing:
+				close(interrupt)
+			case <-finished:
+			}
+		}(closing)
+		closing = interrupt
+	}
+
 	atomic.AddInt64(&e.stats.ActiveQueries, 1)
 	atomic.AddInt64(&e.stats.ExecutedQueries, 1)
 	defer func(start time.Time) {
@@ -247,7 +345,7 @@ func (e *Executor) executeQuery(query *influxql.Query, opt ExecutionOptions, clo
 		atomic.AddInt64(&e.stats.QueryExecutionDuration, time.Since(start).Nanoseconds())
 	}(time.Now())
 
-	ctx, detach, err := e.TaskManager.AttachQuery(query, opt, closing)
+	ctx, detach, err := e.TaskManager.attachQuery(parent, query, opt, closing)
 	if err != nil {
 		select {
 		case results <- &Result{Err: err}:
@@ -407,8 +505,31 @@ func (e *Executor) recover(query *influxql.Query, results chan *Result) {
 // Task is the internal data structure for managing queries.
 // For the public use data structure that gets returned, see Task.
 type Task struct {
+	// Bytes allocated by the query and spilled to disk by it. Accessed
+	// atomically and kept first for 64-bit alignment.
+	memoryN  int64
+	spilledN int64
+
+	// Maximum bytes the query may allocate. If zero, it is unlimited.
+	memoryLimit int64
+	manager     *TaskManager
+
+	// detached is set once the query is removed from its manager, which
+	// then no longer counts its allocations. Guarded by the manager's lock.
+	detached bool
+
+	// paused is set while the query waits for its results to be read and
+	// does not hold a slot. Guarded by the manager's lock.
+	paused bool
+
+	// Bytes of points an operator of the query may hold in memory before
+	// spilling them to spillDir. If zero, operators do not spill.
+	spillThreshold int
+	spillDir       string
+
 	query     string
 	database  string
+	priority  QueryPriority
 	status    TaskStatus
 	startTime time.Time
 	closing   chan struct{}
@@ -433,6 +554,43 @@ func (q *Task) Error() error {
 	return q.err
 }
 
+// Allocate records that the query allocated another n bytes. It returns an
+// error if the query exceeded its own memory limit or the query memory budget
+// of its TaskManager.
+func (q *Task) Allocate(n int) error {
+	if q.manager != nil {
+		return q.manager.allocate(q, int64(n))
+	}
+	return q.checkMemoryLimit(atomic.AddInt64(&q.memoryN, int64(n)))
+}
+
+// checkMemoryLimit returns an error if used exceeds the memory limit of the
+// query.
+func (q *Task) checkMemoryLimit(used int64) error {
+	if q.memoryLimit > 0 && used > q.memoryLimit {
+		return ErrMaxQueryMemoryLimitExceeded(used, q.memoryLimit)
+	}
+	return nil
+}
+
+// SpillThreshold returns the number of bytes of points an operator of the
+// query may hold in memory before spilling them to disk.
+func (q *Task) SpillThreshold() int { return q.spillThreshold }
+
+// SpillDir returns the directory the query spills to.
+func (q *Task) SpillDir() string { return q.spillDir }
+
+// Spilled records that the query wrote another n bytes to disk.
+func (q *Task) Spilled(n int) {
+	atomic.AddInt64(&q.spilledN, int64(n))
+}
+
+func (q *Task) killed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.status == KilledTask
+}
+
 func (q *Task) setError(err error) {
 	q.mu.Lock()
 	q.err = err
@@ -1,6 +1,7 @@
 package query_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -277,6 +278,35 @@ func TestQueryExecutor_Interrupt(t *testing.T) {
 	}
 }
 
+// Ensure the query is interrupted once the context it executes with is done.
+func TestQueryExecutor_Context(t *testing.T) {
+	q, err := influxql.ParseQuery(`SELECT count(value) FROM cpu`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewQueryExecutor()
+	e.StatementExecutor = &StatementExecutor{
+		ExecuteStatementFn: func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(100 * time.Millisecond):
+				t.Error("cancelling the context did not close the channel after 100 milliseconds")
+				return errUnexpected
+			}
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := e.ExecuteQueryContext(ctx, q, query.ExecutionOptions{}, nil)
+	cancel()
+	result := <-results
+	if result.Err != query.ErrQueryInterrupted && result.Err != context.Canceled {
+		t.Errorf("unexpected error: %s", result.Err)
+	}
+}
+
 func TestQueryExecutor_Abort(t *testing.T) {
 	q, err := influxql.ParseQuery(`SELECT count(value) FROM cpu`)
 	if err != nil {
@@ -405,6 +435,405 @@ func TestQueryExecutor_Limit_ConcurrentQueries(t *testing.T) {
 	}
 }
 
+func TestQueryExecutor_Limit_SetLimits(t *testing.T) {
+	q, err := influxql.ParseQuery(`SELECT count(value) FROM cpu`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qid := make(chan uint64)
+
+	e := NewQueryExecutor()
+	e.StatementExecutor = &StatementExecutor{
+		ExecuteStatementFn: func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+			qid <- ctx.QueryID
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	defer e.Close()
+
+	// Start a query before the limit is lowered.
+	go discardOutput(e.ExecuteQuery(q, query.ExecutionOptions{}, nil))
+	<-qid
+
+	e.TaskManager.SetLimits(0, 0, 1)
+
+	results := e.ExecuteQuery(q, query.ExecutionOptions{}, nil)
+	select {
+	case result := <-results:
+		if result.Err == nil || !strings.Contains(result.Err.Error(), "max-concurrent-queries") {
+			t.Errorf("unexpected error: %s", result.Err)
+		}
+	case <-qid:
+		t.Errorf("unexpected statement execution for the second query")
+	}
+}
+
+func TestQueryExecutor_Limit_SetMaxConcurrentQueries(t *testing.T) {
+	q, err := influxql.ParseQuery(`SELECT count(value) FROM cpu`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qid := make(chan uint64)
+
+	e := NewQueryExecutor()
+	e.StatementExecutor = &StatementExecutor{
+		ExecuteStatementFn: func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+			qid <- ctx.QueryID
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	defer e.Close()
+
+	go discardOutput(e.ExecuteQuery(q, query.ExecutionOptions{}, nil))
+	<-qid
+
+	// The override limits the queries.
+	e.TaskManager.SetMaxConcurrentQueries(1)
+	results := e.ExecuteQuery(q, query.ExecutionOptions{}, nil)
+	select {
+	case result := <-results:
+		if result.Err == nil || !strings.Contains(result.Err.Error(), "max-concurrent-queries") {
+			t.Errorf("unexpected error: %s", result.Err)
+		}
+	case <-qid:
+		t.Errorf("unexpected statement execution for the second query")
+	}
+
+	// Removing the override restores the unlimited default.
+	e.TaskManager.SetMaxConcurrentQueries(0)
+	go discardOutput(e.ExecuteQuery(q, query.ExecutionOptions{}, nil))
+	select {
+	case <-qid:
+	case <-time.After(5 * time.Second):
+		t.Error("expected the third query to run")
+	}
+}
+
+func TestQueryExecutor_Limit_QueuedQueries(t *testing.T) {
+	q, err := influxql.ParseQuery(`SELECT count(value) FROM cpu`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan query.QueryPriority)
+	e := NewQueryExecutor()
+	e.StatementExecutor = &StatementExecutor{
+		ExecuteStatementFn: func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+			started <- ctx.Priority
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	e.TaskManager.MaxConcurrentQueries = 1
+	e.TaskManager.MaxQueuedQueries = 2
+	defer e.Close()
+
+	batch := query.ExecutionOptions{Priority: query.BatchPriority}
+	go discardOutput(e.ExecuteQuery(q, batch, nil))
+	if p := <-started; p != query.BatchPriority {
+		t.Fatalf("unexpected priority: %s", p)
+	}
+
+	// Queue a batch query and then an interactive query.
+	go discardOutput(e.ExecuteQuery(q, batch, nil))
+	waitForQueuedQueries(t, e.TaskManager, 1)
+	go discardOutput(e.ExecuteQuery(q, query.ExecutionOptions{}, nil))
+	waitForQueuedQueries(t, e.TaskManager, 2)
+
+	// The queue is full.
+	result := <-e.ExecuteQuery(q, query.ExecutionOptions{}, nil)
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "max-queued-queries") {
+		t.Errorf("unexpected error: %s", result.Err)
+	}
+
+	// The interactive query runs first.
+	if err := e.TaskManager.KillQuery(1); err != nil {
+		t.Fatal(err)
+	}
+	if p := <-started; p != query.InteractivePriority {
+		t.Fatalf("unexpected priority: %s", p)
+	}
+	if err := e.TaskManager.KillQuery(2); err != nil {
+		t.Fatal(err)
+	}
+	if p := <-started; p != query.BatchPriority {
+		t.Fatalf("unexpected priority: %s", p)
+	}
+
+	stats := e.TaskManager.QueueStatistics()
+	if stats.QueuedQueries != 0 || stats.DequeuedQueries != 2 || stats.QueueRejections != 1 {
+		t.Errorf("unexpected queue statistics: %+v", stats)
+	}
+}
+
+func TestQueryExecutor_Limit_BatchQueries(t *testing.T) {
+	q, err := influxql.ParseQuery(`SELECT count(value) FROM cpu`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan query.QueryPriority)
+	e := NewQueryExecutor()
+	e.StatementExecutor = &StatementExecutor{
+		ExecuteStatementFn: func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+			started <- ctx.Priority
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	e.TaskManager.SetQueueLimits(1, 1, 10*time.Millisecond)
+	defer e.Close()
+
+	batch := query.ExecutionOptions{Priority: query.BatchPriority}
+	go discardOutput(e.ExecuteQuery(q, batch, nil))
+	<-started
+
+	// Interactive queries are not limited by batch queries.
+	go discardOutput(e.ExecuteQuery(q, query.ExecutionOptions{}, nil))
+	<-started
+
+	// A second batch query times out in the queue.
+	result := <-e.ExecuteQuery(q, batch, nil)
+	if result.Err != query.ErrQueryQueueTimeout {
+		t.Errorf("unexpected error: %s", result.Err)
+	}
+	if stats := e.TaskManager.QueueStatistics(); stats.QueueTimeouts != 1 {
+		t.Errorf("unexpected queue statistics: %+v", stats)
+	}
+}
+
+// Ensure a paginated query does not hold its slot while its results wait to
+// be read, and waits for a slot to go on.
+func TestQueryExecutor_Limit_PaginatedQueries(t *testing.T) {
+	q, err := influxql.ParseQuery(`SELECT count(value) FROM cpu`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	e := NewQueryExecutor()
+	e.StatementExecutor = &StatementExecutor{
+		ExecuteStatementFn: func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+			if !ctx.Paginated {
+				started <- struct{}{}
+				<-release
+				return nil
+			}
+			for i := 0; i < 2; i++ {
+				if err := ctx.Send(&query.Result{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	e.TaskManager.MaxConcurrentQueries = 1
+	defer e.Close()
+
+	// The paginated query pauses until its first result is read.
+	results := e.ExecuteQuery(q, query.ExecutionOptions{Paginated: true}, nil)
+	waitForQueryStatus(t, e.TaskManager, query.PausedTask)
+
+	go discardOutput(e.ExecuteQuery(q, query.ExecutionOptions{}, nil))
+	<-started
+
+	// Reading the result resumes the query once the other query finished.
+	if r := <-results; r.Err != nil {
+		t.Fatalf("unexpected error: %s", r.Err)
+	}
+	waitForQueuedQueries(t, e.TaskManager, 1)
+	close(release)
+
+	if r := <-results; r.Err != nil {
+		t.Fatalf("unexpected error: %s", r.Err)
+	}
+	if _, ok := <-results; ok {
+		t.Fatal("expected the query to finish")
+	}
+}
+
+func waitForQueryStatus(t *testing.T, tm *query.TaskManager, status query.TaskStatus) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if queries := tm.Queries(); len(queries) == 1 && queries[0].Status == status {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected a query with status %s", status)
+}
+
+func waitForQueuedQueries(t *testing.T, tm *query.TaskManager, n int64) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if tm.QueueStatistics().QueuedQueries == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d queued queries", n)
+}
+
+func TestQueryExecutor_Limit_QueryMemory(t *testing.T) {
+	q, err := influxql.ParseQuery(`SELECT count(value) FROM cpu`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewQueryExecutor()
+	e.StatementExecutor = &StatementExecutor{
+		ExecuteStatementFn: func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+			tracker := query.MemoryTrackerFromContext(ctx)
+			if err := tracker.Allocate(512); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			return tracker.Allocate(1024)
+		},
+	}
+	e.TaskManager.MaxQueryMemoryBytes = 1024
+
+	results := e.ExecuteQuery(q, query.ExecutionOptions{}, nil)
+	result := <-results
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "max-query-memory") {
+		t.Errorf("unexpected error: %s", result.Err)
+	}
+}
+
+func TestQueryExecutor_Limit_QueryMemoryBudget(t *testing.T) {
+	q, err := influxql.ParseQuery(`SELECT count(value) FROM cpu`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allocate := make(chan int)
+	allocated := make(chan error)
+
+	e := NewQueryExecutor()
+	e.StatementExecutor = &StatementExecutor{
+		ExecuteStatementFn: func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+			allocated <- query.MemoryTrackerFromContext(ctx).Allocate(<-allocate)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	e.TaskManager.QueryMemoryBudgetBytes = 1024
+	defer e.Close()
+
+	// The largest query is killed when the budget is exceeded.
+	large := e.ExecuteQuery(q, query.ExecutionOptions{}, nil)
+	allocate <- 768
+	if err := <-allocated; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	small := e.ExecuteQuery(q, query.ExecutionOptions{}, nil)
+	allocate <- 512
+	if err := <-allocated; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	result := <-large
+	if result.Err != query.ErrQueryMemoryPressure {
+		t.Errorf("unexpected error: %s", result.Err)
+	}
+	discardOutput(large)
+
+	// New queries fail while the budget is used up.
+	e.TaskManager.SetMemoryLimits(0, 512, 0)
+	results := e.ExecuteQuery(q, query.ExecutionOptions{}, nil)
+	select {
+	case result := <-results:
+		if result.Err == nil || !strings.Contains(result.Err.Error(), "query-memory-budget") {
+			t.Errorf("unexpected error: %s", result.Err)
+		}
+	case <-allocated:
+		t.Fatal("unexpected statement execution")
+	}
+
+	// Queued queries run once memory is released.
+	e.TaskManager.SetMemoryLimits(0, 512, time.Minute)
+	queued := e.ExecuteQuery(q, query.ExecutionOptions{}, nil)
+	select {
+	case allocate <- 0:
+		t.Fatal("query did not wait for memory")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	if err := e.TaskManager.KillQuery(2); err != nil {
+		t.Fatal(err)
+	}
+	discardOutput(small)
+
+	allocate <- 128
+	if err := <-allocated; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := e.TaskManager.KillQuery(3); err != nil {
+		t.Fatal(err)
+	}
+	discardOutput(queued)
+}
+
+// Ensure the memory of a query that exceeds its own limit is released from
+// the query memory budget exactly once.
+func TestQueryExecutor_Limit_QueryMemoryBudget_AfterQueryLimit(t *testing.T) {
+	q, err := influxql.ParseQuery(`SELECT count(value) FROM cpu`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allocate := make(chan int)
+	allocated := make(chan error)
+
+	e := NewQueryExecutor()
+	e.StatementExecutor = &StatementExecutor{
+		ExecuteStatementFn: func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+			err := query.MemoryTrackerFromContext(ctx).Allocate(<-allocate)
+			allocated <- err
+			if err != nil {
+				return err
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	e.TaskManager.MaxQueryMemoryBytes = 600
+	e.TaskManager.QueryMemoryBudgetBytes = 900
+	defer e.Close()
+
+	limited := e.ExecuteQuery(q, query.ExecutionOptions{}, nil)
+	allocate <- 700
+	if err := <-allocated; err == nil || !strings.Contains(err.Error(), "max-query-memory") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	discardOutput(limited)
+
+	// The budget is still enforced once the limited query is gone.
+	large := e.ExecuteQuery(q, query.ExecutionOptions{}, nil)
+	allocate <- 550
+	if err := <-allocated; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	small := e.ExecuteQuery(q, query.ExecutionOptions{}, nil)
+	allocate <- 400
+	if err := <-allocated; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if result := <-large; result.Err != query.ErrQueryMemoryPressure {
+		t.Errorf("unexpected error: %v", result.Err)
+	}
+	discardOutput(large)
+	if err := e.TaskManager.KillQuery(3); err != nil {
+		t.Fatal(err)
+	}
+	discardOutput(small)
+}
+
 func TestQueryExecutor_Close(t *testing.T) {
 	q, err := influxql.ParseQuery(`SELECT count(value) FROM cpu`)
 	if err != nil {
@@ -0,0 +1,378 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/freetsdb/freetsdb/query/neldermead"
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+// ForecastModelsMeasurement is the name of the measurement the fitted models
+// of the forecast() functions are persisted in.
+const ForecastModelsMeasurement = "_forecast_models"
+
+// forecastZ is the quantile of the normal distribution giving the 95%
+// confidence band of forecast_lower() and forecast_upper().
+const forecastZ = 1.959964
+
+// ForecastModel is the fitted model of a series forecast by forecast(). The
+// model is an additive Holt-Winters model with the smoothing factors of the
+// level, the trend and the season.
+type ForecastModel struct {
+	Season int
+	Alpha  float64
+	Beta   float64
+	Gamma  float64
+}
+
+// ForecastModelStore loads and saves the fitted models of the series
+// forecast by forecast() when it names its model. The models of a name are
+// stored per measurement and group tags.
+type ForecastModelStore interface {
+	LoadForecastModel(name, measurement string, tags Tags) (ForecastModel, bool)
+	SaveForecastModel(name, measurement string, tags Tags, m ForecastModel)
+}
+
+// NewContextWithForecastModels returns a new context.Context with the store
+// of the forecast models added.
+func NewContextWithForecastModels(ctx context.Context, store ForecastModelStore) context.Context {
+	return context.WithValue(ctx, forecastModelsContextKey, store)
+}
+
+// ForecastModelsFromContext returns the ForecastModelStore embedded within
+// the Context if one exists.
+func ForecastModelsFromContext(ctx context.Context) ForecastModelStore {
+	v, _ := ctx.Value(forecastModelsContextKey).(ForecastModelStore)
+	return v
+}
+
+// isForecastFunction returns true if the name is one of the forecast()
+// functions.
+func isForecastFunction(name string) bool {
+	switch name {
+	case "forecast", "forecast_lower", "forecast_upper":
+		return true
+	}
+	return false
+}
+
+// ForecastModelName returns the name of the model of a forecast() call, or
+// an empty string if the call does not name its model.
+func ForecastModelName(call *influxql.Call) string {
+	if !isForecastFunction(call.Name) || len(call.Args) != 4 {
+		return ""
+	}
+	if lit, ok := call.Args[3].(*influxql.StringLiteral); ok {
+		return lit.Val
+	}
+	return ""
+}
+
+// forecastInput returns the aggregate forecast by a forecast() call. A field
+// is forecast from its mean.
+func forecastInput(call *influxql.Call) influxql.Expr {
+	if ref, ok := call.Args[0].(*influxql.VarRef); ok {
+		return &influxql.Call{Name: "mean", Args: []influxql.Expr{ref}}
+	}
+	return call.Args[0]
+}
+
+// forecastReducer forecasts a series of windows with an additive
+// Holt-Winters model. The band of the reducer is the side of the confidence
+// band it emits: -1 for the lower bound, 1 for the upper bound and 0 for the
+// forecast itself.
+type forecastReducer struct {
+	h        int
+	season   int
+	interval int64
+	band     float64
+
+	model string
+	store ForecastModelStore
+
+	name   string
+	tags   Tags
+	points []FloatPoint
+}
+
+func newForecastReducer(h, season int, interval time.Duration, band float64, model string, store ForecastModelStore) *forecastReducer {
+	return &forecastReducer{
+		h:        h,
+		season:   season,
+		interval: int64(interval),
+		band:     band,
+		model:    model,
+		store:    store,
+	}
+}
+
+func (r *forecastReducer) aggregate(p FloatPoint) {
+	if len(r.points) == 0 {
+		r.name, r.tags = p.Name, p.Tags
+	}
+	r.points = append(r.points, p)
+}
+
+// AggregateFloat aggregates a point into the reducer.
+func (r *forecastReducer) AggregateFloat(p *FloatPoint) {
+	r.aggregate(FloatPoint{Name: p.Name, Tags: p.Tags, Time: p.Time, Value: p.Value})
+}
+
+// AggregateInteger aggregates a point into the reducer.
+func (r *forecastReducer) AggregateInteger(p *IntegerPoint) {
+	r.aggregate(FloatPoint{Name: p.Name, Tags: p.Tags, Time: p.Time, Value: float64(p.Value)})
+}
+
+// AggregateUnsigned aggregates a point into the reducer.
+func (r *forecastReducer) AggregateUnsigned(p *UnsignedPoint) {
+	r.aggregate(FloatPoint{Name: p.Name, Tags: p.Tags, Time: p.Time, Value: float64(p.Value)})
+}
+
+// Emit emits the forecast of the next h windows after the last one.
+func (r *forecastReducer) Emit() []FloatPoint {
+	if len(r.points) == 0 || r.h <= 0 {
+		return nil
+	}
+
+	// The windows without a point are missing.
+	first, last := r.points[0].Time, r.points[len(r.points)-1].Time
+	y := make([]float64, (last-first)/r.interval+1)
+	for i := range y {
+		y[i] = math.NaN()
+	}
+	for _, p := range r.points {
+		y[(p.Time-first)/r.interval] = p.Value
+	}
+
+	hw, ok := newForecastHoltWinters(y, r.season)
+	if !ok {
+		return nil
+	}
+
+	// Use the persisted model of the series if it has the same season, and
+	// persist the model fitted otherwise.
+	var (
+		m      ForecastModel
+		loaded bool
+	)
+	if r.model != "" && r.store != nil {
+		m, loaded = r.store.LoadForecastModel(r.model, r.name, r.tags)
+	}
+	if !loaded || m.Season != r.season {
+		m = hw.fit()
+		m.Season = r.season
+		if r.model != "" && r.store != nil {
+			r.store.SaveForecastModel(r.model, r.name, r.tags, m)
+		}
+	}
+
+	forecast, variance := hw.forecast(m, r.h)
+	points := make([]FloatPoint, r.h)
+	for k := range points {
+		points[k] = FloatPoint{
+			Time:  last + int64(k+1)*r.interval,
+			Value: forecast[k] + r.band*forecastZ*math.Sqrt(variance[k]),
+		}
+	}
+	return points
+}
+
+// forecastHoltWinters is an additive Holt-Winters model of a series of
+// windows, some of which may be missing.
+type forecastHoltWinters struct {
+	y      []float64
+	season int
+
+	// The initial level, trend and season of the model.
+	level, trend float64
+	seasonal     []float64
+}
+
+// newForecastHoltWinters initializes the model of a series from its first
+// two seasons, or from its first two windows if it is not seasonal. It
+// returns false if the series is too short.
+func newForecastHoltWinters(y []float64, season int) (*forecastHoltWinters, bool) {
+	hw := &forecastHoltWinters{y: y, season: season}
+	if season < 2 {
+		if len(y) < 2 || math.IsNaN(y[0]) || math.IsNaN(y[1]) {
+			return nil, false
+		}
+		hw.level, hw.trend = y[0], y[1]-y[0]
+		return hw, true
+	}
+	if len(y) < 2*season {
+		return nil, false
+	}
+
+	mean := func(y []float64) (float64, bool) {
+		var sum float64
+		var n int
+		for _, v := range y {
+			if !math.IsNaN(v) {
+				sum += v
+				n++
+			}
+		}
+		return sum / float64(n), n > 0
+	}
+	m0, ok0 := mean(y[:season])
+	m1, ok1 := mean(y[season : 2*season])
+	if !ok0 || !ok1 {
+		return nil, false
+	}
+
+	// The level is the one at the end of the first season.
+	hw.trend = (m1 - m0) / float64(season)
+	hw.level = m0 + hw.trend*float64(season-1)/2
+	hw.seasonal = make([]float64, season)
+	for i, v := range y[:season] {
+		if !math.IsNaN(v) {
+			hw.seasonal[i] = v - m0
+		}
+	}
+	return hw, true
+}
+
+// run runs the model over the series from the end of its initialization and
+// returns the state at the end of the series with the sum of the squares of
+// the one-step errors and their number. A missing window takes the value
+// predicted for it.
+func (hw *forecastHoltWinters) run(m ForecastModel) (level, trend float64, seasonal []float64, sse float64, n int) {
+	level, trend = hw.level, hw.trend
+	start := 2
+	if hw.season >= 2 {
+		seasonal = make([]float64, hw.season)
+		copy(seasonal, hw.seasonal)
+		start = hw.season
+	} else {
+		// The level and trend are those of the second window.
+		level += trend
+	}
+
+	for t := start; t < len(hw.y); t++ {
+		var s float64
+		if seasonal != nil {
+			s = seasonal[t%hw.season]
+		}
+		predicted := level + trend + s
+
+		v := hw.y[t]
+		if math.IsNaN(v) {
+			v = predicted
+		} else {
+			sse += (v - predicted) * (v - predicted)
+			n++
+		}
+
+		prev := level
+		level = m.Alpha*(v-s) + (1-m.Alpha)*(level+trend)
+		trend = m.Beta*(level-prev) + (1-m.Beta)*trend
+		if seasonal != nil {
+			seasonal[t%hw.season] = m.Gamma*(v-level) + (1-m.Gamma)*s
+		}
+	}
+	return level, trend, seasonal, sse, n
+}
+
+// fit returns the smoothing factors minimizing the one-step errors of the
+// model.
+func (hw *forecastHoltWinters) fit() ForecastModel {
+	clamp := func(v float64) float64 {
+		return math.Max(0, math.Min(1, v))
+	}
+	model := func(params []float64) ForecastModel {
+		m := ForecastModel{Alpha: clamp(params[0]), Beta: clamp(params[1])}
+		if len(params) > 2 {
+			m.Gamma = clamp(params[2])
+		}
+		return m
+	}
+	sse := func(params []float64) float64 {
+		_, _, _, sse, _ := hw.run(model(params))
+		return sse
+	}
+
+	n := 2
+	if hw.season >= 2 {
+		n = 3
+	}
+
+	// Start from the corners of a grid of guesses, as the errors may have
+	// more than one minimum.
+	optim := neldermead.New()
+	best, bestSSE := ForecastModel{Alpha: 0.5, Beta: 0.1, Gamma: 0.1}, math.Inf(1)
+	for i := 0; i < 1<<uint(n); i++ {
+		start := make([]float64, n)
+		for j := range start {
+			start[j] = 0.2
+			if i&(1<<uint(j)) != 0 {
+				start[j] = 0.7
+			}
+		}
+		v, params := optim.Optimize(sse, start, hwDefaultEpsilon, 0.5)
+		if v < bestSSE {
+			best, bestSSE = model(params), v
+		}
+	}
+	return best
+}
+
+// forecast returns the forecast of the next h windows after the series and
+// the variance of the error of each of them.
+func (hw *forecastHoltWinters) forecast(m ForecastModel, h int) (forecast, variance []float64) {
+	level, trend, seasonal, sse, n := hw.run(m)
+	var sigma2 float64
+	if n > 0 {
+		sigma2 = sse / float64(n)
+	}
+
+	forecast = make([]float64, h)
+	variance = make([]float64, h)
+	var sum float64
+	for k := 1; k <= h; k++ {
+		forecast[k-1] = level + float64(k)*trend
+		if seasonal != nil {
+			forecast[k-1] += seasonal[(len(hw.y)-1+k)%hw.season]
+		}
+		variance[k-1] = sigma2 * (1 + sum)
+
+		// The error of the next forecast accumulates the error of this
+		// window through the level, the trend and the season.
+		c := m.Alpha * (1 + float64(k)*m.Beta)
+		if seasonal != nil && k%hw.season == 0 {
+			c += m.Gamma
+		}
+		sum += c * c
+	}
+	return forecast, variance
+}
+
+// newForecastIterator returns an iterator for operating on a forecast(),
+// forecast_lower() or forecast_upper() call.
+func newForecastIterator(input Iterator, opt IteratorOptions, h, season int, interval time.Duration, band float64, model string, store ForecastModelStore) (Iterator, error) {
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, FloatPointEmitter) {
+			fn := newForecastReducer(h, season, interval, band, model, store)
+			return fn, fn
+		}
+		return newFloatReduceFloatIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, FloatPointEmitter) {
+			fn := newForecastReducer(h, season, interval, band, model, store)
+			return fn, fn
+		}
+		return newIntegerReduceFloatIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, FloatPointEmitter) {
+			fn := newForecastReducer(h, season, interval, band, model, store)
+			return fn, fn
+		}
+		return newUnsignedReduceFloatIterator(input, opt, createFn), nil
+	default:
+		return nil, fmt.Errorf("unsupported forecast iterator type: %T", input)
+	}
+}
@@ -0,0 +1,157 @@
+package query_test
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+func TestSelect_Forecast(t *testing.T) {
+	// A series with a season of 4 windows and no trend.
+	season := []float64{5, -5, 3, -3}
+	var points []query.FloatPoint
+	for i := 0; i < 16; i++ {
+		points = append(points, query.FloatPoint{
+			Name:  "cpu",
+			Tags:  ParseTags("host=A"),
+			Time:  int64(i) * Second,
+			Value: 10 + season[i%4],
+		})
+	}
+
+	shardMapper := ShardMapper{
+		MapShardsFn: func(sources influxql.Sources, _ influxql.TimeRange) query.ShardGroup {
+			return &ShardGroup{
+				Fields:     map[string]influxql.DataType{"value": influxql.Float},
+				Dimensions: []string{"host"},
+				CreateIteratorFn: func(ctx context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+					input := &FloatIterator{Points: append([]query.FloatPoint(nil), points...)}
+					return query.NewCallIterator(input, opt)
+				},
+			}
+		},
+	}
+
+	store := &ForecastModelStore{}
+	ctx := query.NewContextWithForecastModels(context.Background(), store)
+
+	stmt := MustParseSelectStatement(`SELECT forecast(value, 4s, 4, 'cpu'), forecast_lower(value, 4s, 4, 'cpu'), forecast_upper(mean(value), 4s, 4, 'cpu') FROM cpu WHERE time >= 0s AND time < 16s GROUP BY time(1s), host`)
+	stmt.OmitTime = true
+	cur, err := query.Select(ctx, stmt, &shardMapper, query.SelectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := ReadCursor(cur)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(rows) != 4 {
+		t.Fatalf("unexpected number of rows: %d", len(rows))
+	}
+	for k, row := range rows {
+		if row.Time != int64(16+k)*Second {
+			t.Fatalf("unexpected time: %d", row.Time)
+		}
+		exp := 10 + season[k]
+		for i, v := range row.Values {
+			if math.Abs(v.(float64)-exp) > 1e-6 {
+				t.Fatalf("unexpected value %d at %d: %v, expected %v", i, row.Time, v, exp)
+			}
+		}
+	}
+
+	// The model fitted for the series is saved.
+	if len(store.Saved) == 0 {
+		t.Fatal("expected the model to be saved")
+	} else if key := store.Saved[0]; key != "cpu cpu host=A" {
+		t.Fatalf("unexpected saved model: %s", key)
+	} else if m := store.Models[key]; m.Season != 4 || m.Alpha < 0 || m.Alpha > 1 {
+		t.Fatalf("unexpected model: %+v", m)
+	}
+}
+
+func TestSelect_Forecast_Band(t *testing.T) {
+	// A noisy series has a confidence band widening with the horizon.
+	var points []query.FloatPoint
+	for i := 0; i < 20; i++ {
+		v := float64(i)
+		if i%2 == 0 {
+			v += 2
+		}
+		points = append(points, query.FloatPoint{Name: "cpu", Time: int64(i) * Second, Value: v})
+	}
+
+	shardMapper := ShardMapper{
+		MapShardsFn: func(sources influxql.Sources, _ influxql.TimeRange) query.ShardGroup {
+			return &ShardGroup{
+				Fields: map[string]influxql.DataType{"value": influxql.Float},
+				CreateIteratorFn: func(ctx context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+					input := &FloatIterator{Points: append([]query.FloatPoint(nil), points...)}
+					return query.NewCallIterator(input, opt)
+				},
+			}
+		},
+	}
+
+	stmt := MustParseSelectStatement(`SELECT forecast_lower(value, 3s), forecast(value, 3s), forecast_upper(value, 3s) FROM cpu WHERE time >= 0s AND time < 20s GROUP BY time(1s)`)
+	stmt.OmitTime = true
+	cur, err := query.Select(context.Background(), stmt, &shardMapper, query.SelectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := ReadCursor(cur)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(rows) != 3 {
+		t.Fatalf("unexpected number of rows: %d", len(rows))
+	}
+
+	var width float64
+	for _, row := range rows {
+		lower, forecast, upper := row.Values[0].(float64), row.Values[1].(float64), row.Values[2].(float64)
+		if !(lower < forecast && forecast < upper) {
+			t.Fatalf("unexpected band at %d: %v", row.Time, row.Values)
+		} else if math.Abs((forecast-lower)-(upper-forecast)) > 1e-9 {
+			t.Fatalf("unexpected asymmetric band at %d: %v", row.Time, row.Values)
+		} else if upper-lower < width {
+			t.Fatalf("unexpected narrower band at %d: %v", row.Time, row.Values)
+		}
+		width = upper - lower
+	}
+}
+
+// ForecastModelStore is a mock store of forecast models.
+type ForecastModelStore struct {
+	mu     sync.Mutex
+	Models map[string]query.ForecastModel
+	Saved  []string
+}
+
+func (s *ForecastModelStore) key(name, measurement string, tags query.Tags) string {
+	key := name + " " + measurement
+	for _, k := range tags.Keys() {
+		key += " " + k + "=" + tags.Value(k)
+	}
+	return key
+}
+
+func (s *ForecastModelStore) LoadForecastModel(name, measurement string, tags query.Tags) (query.ForecastModel, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.Models[s.key(name, measurement, tags)]
+	return m, ok
+}
+
+func (s *ForecastModelStore) SaveForecastModel(name, measurement string, tags query.Tags, m query.ForecastModel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Models == nil {
+		s.Models = make(map[string]query.ForecastModel)
+	}
+	key := s.key(name, measurement, tags)
+	s.Models[key] = m
+	s.Saved = append(s.Saved, key)
+}
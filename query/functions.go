@@ -73,7 +73,7 @@ func (m FunctionTypeMapper) CallType(name string, args []influxql.DataType) (inf
 
 	// Handle functions implemented by the query engine.
 	switch name {
-	case "median", "integral", "stddev",
+	case "median", "integral", "rate", "stddev",
 		"derivative", "non_negative_derivative",
 		"moving_average",
 		"exponential_moving_average",
@@ -84,7 +84,9 @@ func (m FunctionTypeMapper) CallType(name string, args []influxql.DataType) (inf
 		"kaufmans_efficiency_ratio",
 		"kaufmans_adaptive_moving_average",
 		"chande_momentum_oscillator",
-		"holt_winters", "holt_winters_with_fit":
+		"holt_winters", "holt_winters_with_fit",
+		"forecast", "forecast_lower", "forecast_upper",
+		"anomaly_mad", "anomaly_esd":
 		return influxql.Float, nil
 	case "elapsed":
 		return influxql.Integer, nil
@@ -0,0 +1,121 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/freetsdb/freetsdb/pkg/geohash"
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+// GeoHashTagKey is the default tag key of the geohash of the location of a
+// series filtered by the geo.within() and geo.radius() functions.
+const GeoHashTagKey = "geohash"
+
+// geoMaxCells is the maximum number of geohash cells covering the area of a
+// geo.within() or geo.radius() call.
+const geoMaxCells = 64
+
+// isGeoFunction returns true if the function filters series by the geohash of
+// their location.
+func isGeoFunction(name string) bool {
+	switch name {
+	case "geo.within", "geo.radius":
+		return true
+	}
+	return false
+}
+
+// rewriteGeoConditions rewrites the geo.within() and geo.radius() calls of a
+// condition into conditions on the geohash tag of the series, matching the
+// geohashes prefixed by one of the cells covering the area of the call.
+//
+// The series are matched at the granularity of the cells, so a series close
+// to the area may match it.
+func rewriteGeoConditions(expr influxql.Expr) (influxql.Expr, error) {
+	switch expr := expr.(type) {
+	case *influxql.BinaryExpr:
+		if expr.Op != influxql.AND && expr.Op != influxql.OR {
+			return expr, nil
+		}
+		lhs, err := rewriteGeoConditions(expr.LHS)
+		if err != nil {
+			return nil, err
+		}
+		rhs, err := rewriteGeoConditions(expr.RHS)
+		if err != nil {
+			return nil, err
+		}
+		return &influxql.BinaryExpr{Op: expr.Op, LHS: lhs, RHS: rhs}, nil
+	case *influxql.ParenExpr:
+		e, err := rewriteGeoConditions(expr.Expr)
+		if err != nil {
+			return nil, err
+		}
+		return &influxql.ParenExpr{Expr: e}, nil
+	case *influxql.Call:
+		if !isGeoFunction(expr.Name) {
+			return expr, nil
+		}
+		return geoCondition(expr)
+	default:
+		return expr, nil
+	}
+}
+
+// geoCondition returns the condition on the geohash tag of a geo.within() or
+// geo.radius() call.
+func geoCondition(call *influxql.Call) (influxql.Expr, error) {
+	n := 4
+	if call.Name == "geo.radius" {
+		n = 3
+	}
+	if got := len(call.Args); got < n || got > n+1 {
+		return nil, fmt.Errorf("invalid number of arguments for %s, expected at least %d but no more than %d, got %d", call.Name, n, n+1, got)
+	}
+
+	args := make([]float64, n)
+	for i := range args {
+		switch arg := call.Args[i].(type) {
+		case *influxql.NumberLiteral:
+			args[i] = arg.Val
+		case *influxql.IntegerLiteral:
+			args[i] = float64(arg.Val)
+		default:
+			return nil, fmt.Errorf("expected number argument in %s()", call.Name)
+		}
+	}
+
+	key := GeoHashTagKey
+	if len(call.Args) > n {
+		lit, ok := call.Args[n].(*influxql.StringLiteral)
+		if !ok || lit.Val == "" {
+			return nil, fmt.Errorf("expected tag key string argument in %s()", call.Name)
+		}
+		key = lit.Val
+	}
+
+	var hashes []string
+	if call.Name == "geo.radius" {
+		lat, lon, km := args[0], args[1], args[2]
+		if err := (geohash.Box{MinLat: lat, MinLon: lon, MaxLat: lat, MaxLon: lon}).Validate(); err != nil {
+			return nil, fmt.Errorf("invalid location in %s(): %s", call.Name, err)
+		} else if km <= 0 {
+			return nil, fmt.Errorf("radius must be greater than 0 in %s()", call.Name)
+		}
+		hashes = geohash.CoverRadius(lat, lon, km, geoMaxCells)
+	} else {
+		box := geohash.Box{MinLat: args[0], MinLon: args[1], MaxLat: args[2], MaxLon: args[3]}
+		if err := box.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid box in %s(): %s", call.Name, err)
+		}
+		hashes = geohash.CoverBox(box, geoMaxCells)
+	}
+
+	return &influxql.BinaryExpr{
+		Op:  influxql.EQREGEX,
+		LHS: &influxql.VarRef{Val: key},
+		RHS: &influxql.RegexLiteral{Val: regexp.MustCompile(`^(?:` + strings.Join(hashes, "|") + `)`)},
+	}, nil
+}
@@ -0,0 +1,75 @@
+package query_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+func TestSelect_GeoCondition(t *testing.T) {
+	for _, tt := range []struct {
+		q       string
+		key     string
+		match   []string
+		nomatch []string
+	}{
+		{
+			// Within 5 km of San Francisco city hall.
+			q:       `SELECT value FROM fleet WHERE geo.radius(37.7793, -122.4193, 5)`,
+			key:     "geohash",
+			match:   []string{"9q8yyk8", "9q8yw", "9q8zn2"},
+			nomatch: []string{"9q9p1", "9q8vz", "u4pruyd"},
+		},
+		{
+			// A box crossing the antimeridian.
+			q:       `SELECT value FROM fleet WHERE geo.within(-10, 170, 10, -170, 'cell') AND time >= 0s`,
+			key:     "cell",
+			match:   []string{"rzzz", "8000", "2pbp"},
+			nomatch: []string{"s000", "9q8y"},
+		},
+	} {
+		t.Run(tt.q, func(t *testing.T) {
+			var cond influxql.Expr
+			shardMapper := ShardMapper{
+				MapShardsFn: func(sources influxql.Sources, _ influxql.TimeRange) query.ShardGroup {
+					return &ShardGroup{
+						Fields: map[string]influxql.DataType{"value": influxql.Float},
+						CreateIteratorFn: func(ctx context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+							cond = opt.Condition
+							return &FloatIterator{}, nil
+						},
+					}
+				},
+			}
+
+			cur, err := query.Select(context.Background(), MustParseSelectStatement(tt.q), &shardMapper, query.SelectOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ReadCursor(cur); err != nil {
+				t.Fatal(err)
+			}
+
+			// The call is a condition on the tag only.
+			expr, ok := cond.(*influxql.BinaryExpr)
+			if !ok || expr.Op != influxql.EQREGEX {
+				t.Fatalf("unexpected condition: %s", cond)
+			} else if ref, ok := expr.LHS.(*influxql.VarRef); !ok || ref.Val != tt.key {
+				t.Fatalf("unexpected condition: %s", cond)
+			}
+			re := expr.RHS.(*influxql.RegexLiteral).Val
+			for _, hash := range tt.match {
+				if !re.MatchString(hash) {
+					t.Errorf("expected %s to match %s", hash, cond)
+				}
+			}
+			for _, hash := range tt.nomatch {
+				if re.MatchString(hash) {
+					t.Errorf("expected %s not to match %s", hash, cond)
+				}
+			}
+		})
+	}
+}
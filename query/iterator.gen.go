@@ -831,7 +831,11 @@ CONSTRUCT:
 				if err != nil {
 					return nil, err
 				} else if next != nil && next.Name == itr.window.name && next.Tags.ID() == itr.window.tags.ID() {
+					// Calendar windows vary in length, so interpolate by time.
 					interval := int64(itr.opt.Interval.Duration)
+					if itr.opt.Interval.Months > 0 {
+						interval = 1
+					}
 					start := itr.window.time / interval
 					p.Value = linearFloat(start, itr.prev.Time/interval, next.Time/interval, itr.prev.Value, next.Value)
 				} else {
@@ -859,8 +863,15 @@ CONSTRUCT:
 
 	// Advance the expected time. Do not advance to a new window here
 	// as there may be lingering points with the same timestamp in the previous
-	// window.
-	if itr.opt.Ascending {
+	// window. Calendar windows already follow the location of the query.
+	if itr.opt.Interval.Months > 0 {
+		if itr.opt.Ascending {
+			_, itr.window.time = itr.opt.Window(itr.window.time)
+		} else {
+			itr.window.time, _ = itr.opt.Window(itr.window.time - 1)
+		}
+		return p, nil
+	} else if itr.opt.Ascending {
 		itr.window.time += int64(itr.opt.Interval.Duration)
 	} else {
 		itr.window.time -= int64(itr.opt.Interval.Duration)
@@ -993,6 +1004,317 @@ func (itr *floatCloseInterruptIterator) Next() (*FloatPoint, error) {
 	return p, nil
 }
 
+// floatMemoryIterator represents a float implementation of MemoryIterator.
+type floatMemoryIterator struct {
+	input   FloatIterator
+	tracker MemoryTracker
+	n       int
+}
+
+func newFloatMemoryIterator(input FloatIterator, tracker MemoryTracker) *floatMemoryIterator {
+	return &floatMemoryIterator{input: input, tracker: tracker}
+}
+
+func (itr *floatMemoryIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *floatMemoryIterator) Close() error         { return itr.input.Close() }
+
+func (itr *floatMemoryIterator) Next() (*FloatPoint, error) {
+	p, err := itr.input.Next()
+	if p == nil || err != nil {
+		return p, err
+	}
+
+	// Report allocations to the tracker in batches.
+	itr.n += floatPointSize(p)
+	if itr.n >= memoryReportSize {
+		n := itr.n
+		itr.n = 0
+		if err := itr.tracker.Allocate(n); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// floatPointSize returns the estimated size in bytes of a point.
+func floatPointSize(p *FloatPoint) int {
+	return pointOverhead + auxSize(p.Aux)
+}
+
+// floatSpillReader reads the float points spilled to a file.
+type floatSpillReader struct {
+	file *spillFile
+	dec  *FloatPointDecoder
+}
+
+func newFloatSpillReader(file *spillFile) (*floatSpillReader, error) {
+	r, err := file.reader()
+	if err != nil {
+		return nil, err
+	}
+	return &floatSpillReader{file: file, dec: NewFloatPointDecoder(context.Background(), r)}, nil
+}
+
+// Next returns the next point of the file, or nil once all were read.
+func (r *floatSpillReader) Next() (*FloatPoint, error) {
+	var p FloatPoint
+	if err := r.dec.DecodeFloatPoint(&p); err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Close removes the file.
+func (r *floatSpillReader) Close() error { return r.file.Close() }
+
+// floatSpillPartitions spills the points of the groups a group operator
+// cannot hold in memory to partitions on disk by the hash of their group, so
+// that each partition can be reduced in memory later.
+type floatSpillPartitions struct {
+	spiller Spiller
+	files   [spillPartitionN]*spillFile
+	encs    [spillPartitionN]*FloatPointEncoder
+}
+
+func newFloatSpillPartitions(spiller Spiller) *floatSpillPartitions {
+	return &floatSpillPartitions{spiller: spiller}
+}
+
+// write spills a point of the group with id.
+func (s *floatSpillPartitions) write(id string, p *FloatPoint) error {
+	i := spillPartition(id)
+	if s.files[i] == nil {
+		f, err := createSpillFile(s.spiller)
+		if err != nil {
+			return err
+		}
+		s.files[i], s.encs[i] = f, NewFloatPointEncoder(f)
+	}
+	return s.encs[i].EncodeFloatPoint(p)
+}
+
+// open returns a reader of the points spilled to partition i, which removes
+// the partition once closed, or nil if no points were spilled to it.
+func (s *floatSpillPartitions) open(i int) (*floatSpillReader, error) {
+	f := s.files[i]
+	if f == nil {
+		return nil, nil
+	}
+	s.files[i], s.encs[i] = nil, nil
+
+	r, err := newFloatSpillReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Close removes the partitions that were not opened.
+func (s *floatSpillPartitions) Close() error {
+	for i, f := range s.files {
+		if f != nil {
+			f.Close()
+			s.files[i], s.encs[i] = nil, nil
+		}
+	}
+	return nil
+}
+
+// floatExternalSort sorts points by time and key, spilling them to disk
+// as sorted runs whenever the points held in memory exceed the spill
+// threshold. Points with the same time and key keep the order they were added
+// in.
+type floatExternalSort struct {
+	spiller Spiller
+	key     func(p *FloatPoint) string
+	items   []floatSortItem
+	size    int
+	runs    []*spillFile
+}
+
+// floatSortItem is a point and its sort key.
+type floatSortItem struct {
+	key   string
+	point FloatPoint
+}
+
+func (a *floatSortItem) less(b *floatSortItem) bool {
+	if a.point.Time != b.point.Time {
+		return a.point.Time < b.point.Time
+	}
+	return a.key < b.key
+}
+
+func newFloatExternalSort(spiller Spiller, key func(p *FloatPoint) string) *floatExternalSort {
+	return &floatExternalSort{spiller: spiller, key: key}
+}
+
+// add adds a point to sort.
+func (s *floatExternalSort) add(p *FloatPoint) error {
+	s.items = append(s.items, floatSortItem{key: s.key(p), point: *p})
+	s.size += floatPointSize(p)
+	if spillable(s.spiller, s.size) {
+		return s.spill()
+	}
+	return nil
+}
+
+// spill writes the points held in memory to disk as a sorted run.
+func (s *floatExternalSort) spill() error {
+	sort.SliceStable(s.items, func(i, j int) bool { return s.items[i].less(&s.items[j]) })
+
+	f, err := createSpillFile(s.spiller)
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, f)
+
+	enc := NewFloatPointEncoder(f)
+	for i := range s.items {
+		if err := enc.EncodeFloatPoint(&s.items[i].point); err != nil {
+			return err
+		}
+	}
+	s.items, s.size = s.items[:0], 0
+	return nil
+}
+
+// iterator returns an iterator of the sorted points, merging the runs on disk
+// with the points held in memory. The runs are removed once it is closed.
+func (s *floatExternalSort) iterator() (FloatIterator, error) {
+	itr := &floatSortMergeIterator{key: s.key}
+	for _, f := range s.runs {
+		r, err := newFloatSpillReader(f)
+		if err != nil {
+			f.Close()
+			itr.Close()
+			return nil, err
+		}
+		itr.runs = append(itr.runs, r)
+	}
+	s.runs = nil
+
+	for i := range itr.runs {
+		if err := itr.read(i); err != nil {
+			itr.Close()
+			return nil, err
+		}
+	}
+
+	// The points held in memory are the last run, and are kept in the heap.
+	sort.SliceStable(s.items, func(i, j int) bool { return s.items[i].less(&s.items[j]) })
+	for i, item := range s.items {
+		itr.heap = append(itr.heap, floatSortMergeHeapItem{item: item, run: len(itr.runs), seq: i})
+	}
+	s.items = nil
+	heap.Init(&itr.heap)
+	return itr, nil
+}
+
+// Close removes the runs spilled to disk.
+func (s *floatExternalSort) Close() error {
+	for _, f := range s.runs {
+		f.Close()
+	}
+	s.runs, s.items = nil, nil
+	return nil
+}
+
+// floatSortMergeIterator merges the sorted runs of an external sort.
+type floatSortMergeIterator struct {
+	runs []*floatSpillReader
+	heap floatSortMergeHeap
+	key  func(p *FloatPoint) string
+}
+
+// Stats returns an empty stats object, the stats of the sorted points were
+// reported by the iterators they were read from.
+func (itr *floatSortMergeIterator) Stats() IteratorStats { return IteratorStats{} }
+
+// Close removes the runs.
+func (itr *floatSortMergeIterator) Close() error {
+	for _, r := range itr.runs {
+		if r != nil {
+			r.Close()
+		}
+	}
+	itr.runs, itr.heap = nil, nil
+	return nil
+}
+
+// Next returns the next point in order.
+func (itr *floatSortMergeIterator) Next() (*FloatPoint, error) {
+	if len(itr.heap) == 0 {
+		return nil, nil
+	}
+
+	item := heap.Pop(&itr.heap).(floatSortMergeHeapItem)
+	if item.run < len(itr.runs) {
+		if err := itr.read(item.run); err != nil {
+			return nil, err
+		}
+	}
+	return &item.item.point, nil
+}
+
+// read pushes the next point of a run on disk to the heap, or removes the
+// run once all its points were read.
+func (itr *floatSortMergeIterator) read(run int) error {
+	p, err := itr.runs[run].Next()
+	if err != nil {
+		return err
+	} else if p == nil {
+		itr.runs[run].Close()
+		itr.runs[run] = nil
+		return nil
+	}
+	heap.Push(&itr.heap, floatSortMergeHeapItem{
+		item: floatSortItem{key: itr.key(p), point: *p},
+		run:  run,
+	})
+	return nil
+}
+
+// floatSortMergeHeapItem is a point of a run. Points of the same time and
+// key are ordered by run, then by their position in the run.
+type floatSortMergeHeapItem struct {
+	item floatSortItem
+	run  int
+	seq  int
+}
+
+// floatSortMergeHeap is a heap of the next points of the runs of an
+// external sort.
+type floatSortMergeHeap []floatSortMergeHeapItem
+
+func (h floatSortMergeHeap) Len() int      { return len(h) }
+func (h floatSortMergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h floatSortMergeHeap) Less(i, j int) bool {
+	if h[i].item.less(&h[j].item) {
+		return true
+	} else if h[j].item.less(&h[i].item) {
+		return false
+	} else if h[i].run != h[j].run {
+		return h[i].run < h[j].run
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h *floatSortMergeHeap) Push(x interface{}) {
+	*h = append(*h, x.(floatSortMergeHeapItem))
+}
+
+func (h *floatSortMergeHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
 // floatReduceFloatIterator executes a reducer for every interval and buffers the result.
 type floatReduceFloatIterator struct {
 	input    *bufFloatIterator
@@ -1001,6 +1323,10 @@ type floatReduceFloatIterator struct {
 	opt      IteratorOptions
 	points   []FloatPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled FloatIterator
 }
 
 func newFloatReduceFloatIterator(input FloatIterator, opt IteratorOptions, createFn func() (FloatPointAggregator, FloatPointEmitter)) *floatReduceFloatIterator {
@@ -1016,15 +1342,30 @@ func newFloatReduceFloatIterator(input FloatIterator, opt IteratorOptions, creat
 func (itr *floatReduceFloatIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *floatReduceFloatIterator) Close() error { return itr.input.Close() }
+func (itr *floatReduceFloatIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *floatReduceFloatIterator) Next() (*FloatPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -1069,12 +1410,21 @@ func (itr *floatReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*floatReduceFloatPoint)
+	var (
+		size       int
+		partitions *floatSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -1102,18 +1452,108 @@ func (itr *floatReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &floatReduceFloatPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newFloatSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateFloat(curr)
+		size += floatPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *floatReduceFloatIterator) newGroup(name string, tags Tags) *floatReduceFloatPoint {
+	aggregator, emitter := itr.create()
+	return &floatReduceFloatPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *floatReduceFloatIterator) reduceSpilled(m map[string]*floatReduceFloatPoint, partitions *floatSpillPartitions, startTime int64) (_ FloatIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newFloatExternalSort(itr.opt.Spiller, func(p *FloatPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*floatReduceFloatPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*floatReduceFloatPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateFloat(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
+	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *floatReduceFloatIterator) emit(m map[string]*floatReduceFloatPoint, startTime int64) []FloatPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -1150,7 +1590,7 @@ func (itr *floatReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		sort.Stable(sort.Reverse(floatPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // floatStreamFloatIterator streams inputs into the iterator and emits points gradually.
@@ -1281,6 +1721,10 @@ type floatReduceIntegerIterator struct {
 	opt      IteratorOptions
 	points   []IntegerPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled IntegerIterator
 }
 
 func newFloatReduceIntegerIterator(input FloatIterator, opt IteratorOptions, createFn func() (FloatPointAggregator, IntegerPointEmitter)) *floatReduceIntegerIterator {
@@ -1296,15 +1740,30 @@ func newFloatReduceIntegerIterator(input FloatIterator, opt IteratorOptions, cre
 func (itr *floatReduceIntegerIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *floatReduceIntegerIterator) Close() error { return itr.input.Close() }
+func (itr *floatReduceIntegerIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *floatReduceIntegerIterator) Next() (*IntegerPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -1349,12 +1808,21 @@ func (itr *floatReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*floatReduceIntegerPoint)
+	var (
+		size       int
+		partitions *floatSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -1382,18 +1850,108 @@ func (itr *floatReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &floatReduceIntegerPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newFloatSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateFloat(curr)
+		size += floatPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *floatReduceIntegerIterator) newGroup(name string, tags Tags) *floatReduceIntegerPoint {
+	aggregator, emitter := itr.create()
+	return &floatReduceIntegerPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *floatReduceIntegerIterator) reduceSpilled(m map[string]*floatReduceIntegerPoint, partitions *floatSpillPartitions, startTime int64) (_ IntegerIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newIntegerExternalSort(itr.opt.Spiller, func(p *IntegerPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*floatReduceIntegerPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*floatReduceIntegerPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateFloat(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
+	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *floatReduceIntegerIterator) emit(m map[string]*floatReduceIntegerPoint, startTime int64) []IntegerPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -1430,7 +1988,7 @@ func (itr *floatReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		sort.Stable(sort.Reverse(integerPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // floatStreamIntegerIterator streams inputs into the iterator and emits points gradually.
@@ -1561,6 +2119,10 @@ type floatReduceUnsignedIterator struct {
 	opt      IteratorOptions
 	points   []UnsignedPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled UnsignedIterator
 }
 
 func newFloatReduceUnsignedIterator(input FloatIterator, opt IteratorOptions, createFn func() (FloatPointAggregator, UnsignedPointEmitter)) *floatReduceUnsignedIterator {
@@ -1576,15 +2138,30 @@ func newFloatReduceUnsignedIterator(input FloatIterator, opt IteratorOptions, cr
 func (itr *floatReduceUnsignedIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *floatReduceUnsignedIterator) Close() error { return itr.input.Close() }
+func (itr *floatReduceUnsignedIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *floatReduceUnsignedIterator) Next() (*UnsignedPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -1629,12 +2206,21 @@ func (itr *floatReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*floatReduceUnsignedPoint)
+	var (
+		size       int
+		partitions *floatSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -1662,18 +2248,108 @@ func (itr *floatReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &floatReduceUnsignedPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newFloatSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateFloat(curr)
+		size += floatPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *floatReduceUnsignedIterator) newGroup(name string, tags Tags) *floatReduceUnsignedPoint {
+	aggregator, emitter := itr.create()
+	return &floatReduceUnsignedPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *floatReduceUnsignedIterator) reduceSpilled(m map[string]*floatReduceUnsignedPoint, partitions *floatSpillPartitions, startTime int64) (_ UnsignedIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newUnsignedExternalSort(itr.opt.Spiller, func(p *UnsignedPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*floatReduceUnsignedPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*floatReduceUnsignedPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateFloat(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
+	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *floatReduceUnsignedIterator) emit(m map[string]*floatReduceUnsignedPoint, startTime int64) []UnsignedPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -1710,7 +2386,7 @@ func (itr *floatReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		sort.Stable(sort.Reverse(unsignedPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // floatStreamUnsignedIterator streams inputs into the iterator and emits points gradually.
@@ -1841,6 +2517,10 @@ type floatReduceStringIterator struct {
 	opt      IteratorOptions
 	points   []StringPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled StringIterator
 }
 
 func newFloatReduceStringIterator(input FloatIterator, opt IteratorOptions, createFn func() (FloatPointAggregator, StringPointEmitter)) *floatReduceStringIterator {
@@ -1856,15 +2536,30 @@ func newFloatReduceStringIterator(input FloatIterator, opt IteratorOptions, crea
 func (itr *floatReduceStringIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *floatReduceStringIterator) Close() error { return itr.input.Close() }
+func (itr *floatReduceStringIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *floatReduceStringIterator) Next() (*StringPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -1909,12 +2604,21 @@ func (itr *floatReduceStringIterator) reduce() ([]StringPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*floatReduceStringPoint)
+	var (
+		size       int
+		partitions *floatSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -1942,18 +2646,108 @@ func (itr *floatReduceStringIterator) reduce() ([]StringPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &floatReduceStringPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newFloatSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateFloat(curr)
+		size += floatPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *floatReduceStringIterator) newGroup(name string, tags Tags) *floatReduceStringPoint {
+	aggregator, emitter := itr.create()
+	return &floatReduceStringPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *floatReduceStringIterator) reduceSpilled(m map[string]*floatReduceStringPoint, partitions *floatSpillPartitions, startTime int64) (_ StringIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newStringExternalSort(itr.opt.Spiller, func(p *StringPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*floatReduceStringPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*floatReduceStringPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateFloat(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *floatReduceStringIterator) emit(m map[string]*floatReduceStringPoint, startTime int64) []StringPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -1990,7 +2784,7 @@ func (itr *floatReduceStringIterator) reduce() ([]StringPoint, error) {
 		sort.Stable(sort.Reverse(stringPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // floatStreamStringIterator streams inputs into the iterator and emits points gradually.
@@ -2121,6 +2915,10 @@ type floatReduceBooleanIterator struct {
 	opt      IteratorOptions
 	points   []BooleanPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled BooleanIterator
 }
 
 func newFloatReduceBooleanIterator(input FloatIterator, opt IteratorOptions, createFn func() (FloatPointAggregator, BooleanPointEmitter)) *floatReduceBooleanIterator {
@@ -2136,15 +2934,30 @@ func newFloatReduceBooleanIterator(input FloatIterator, opt IteratorOptions, cre
 func (itr *floatReduceBooleanIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *floatReduceBooleanIterator) Close() error { return itr.input.Close() }
+func (itr *floatReduceBooleanIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *floatReduceBooleanIterator) Next() (*BooleanPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -2189,12 +3002,21 @@ func (itr *floatReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*floatReduceBooleanPoint)
+	var (
+		size       int
+		partitions *floatSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -2222,18 +3044,108 @@ func (itr *floatReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &floatReduceBooleanPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newFloatSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateFloat(curr)
+		size += floatPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *floatReduceBooleanIterator) newGroup(name string, tags Tags) *floatReduceBooleanPoint {
+	aggregator, emitter := itr.create()
+	return &floatReduceBooleanPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *floatReduceBooleanIterator) reduceSpilled(m map[string]*floatReduceBooleanPoint, partitions *floatSpillPartitions, startTime int64) (_ BooleanIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newBooleanExternalSort(itr.opt.Spiller, func(p *BooleanPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*floatReduceBooleanPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*floatReduceBooleanPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateFloat(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *floatReduceBooleanIterator) emit(m map[string]*floatReduceBooleanPoint, startTime int64) []BooleanPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -2270,7 +3182,7 @@ func (itr *floatReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		sort.Stable(sort.Reverse(booleanPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // floatStreamBooleanIterator streams inputs into the iterator and emits points gradually.
@@ -3455,7 +4367,11 @@ CONSTRUCT:
 				if err != nil {
 					return nil, err
 				} else if next != nil && next.Name == itr.window.name && next.Tags.ID() == itr.window.tags.ID() {
+					// Calendar windows vary in length, so interpolate by time.
 					interval := int64(itr.opt.Interval.Duration)
+					if itr.opt.Interval.Months > 0 {
+						interval = 1
+					}
 					start := itr.window.time / interval
 					p.Value = linearInteger(start, itr.prev.Time/interval, next.Time/interval, itr.prev.Value, next.Value)
 				} else {
@@ -3483,8 +4399,15 @@ CONSTRUCT:
 
 	// Advance the expected time. Do not advance to a new window here
 	// as there may be lingering points with the same timestamp in the previous
-	// window.
-	if itr.opt.Ascending {
+	// window. Calendar windows already follow the location of the query.
+	if itr.opt.Interval.Months > 0 {
+		if itr.opt.Ascending {
+			_, itr.window.time = itr.opt.Window(itr.window.time)
+		} else {
+			itr.window.time, _ = itr.opt.Window(itr.window.time - 1)
+		}
+		return p, nil
+	} else if itr.opt.Ascending {
 		itr.window.time += int64(itr.opt.Interval.Duration)
 	} else {
 		itr.window.time -= int64(itr.opt.Interval.Duration)
@@ -3617,6 +4540,317 @@ func (itr *integerCloseInterruptIterator) Next() (*IntegerPoint, error) {
 	return p, nil
 }
 
+// integerMemoryIterator represents a integer implementation of MemoryIterator.
+type integerMemoryIterator struct {
+	input   IntegerIterator
+	tracker MemoryTracker
+	n       int
+}
+
+func newIntegerMemoryIterator(input IntegerIterator, tracker MemoryTracker) *integerMemoryIterator {
+	return &integerMemoryIterator{input: input, tracker: tracker}
+}
+
+func (itr *integerMemoryIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *integerMemoryIterator) Close() error         { return itr.input.Close() }
+
+func (itr *integerMemoryIterator) Next() (*IntegerPoint, error) {
+	p, err := itr.input.Next()
+	if p == nil || err != nil {
+		return p, err
+	}
+
+	// Report allocations to the tracker in batches.
+	itr.n += integerPointSize(p)
+	if itr.n >= memoryReportSize {
+		n := itr.n
+		itr.n = 0
+		if err := itr.tracker.Allocate(n); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// integerPointSize returns the estimated size in bytes of a point.
+func integerPointSize(p *IntegerPoint) int {
+	return pointOverhead + auxSize(p.Aux)
+}
+
+// integerSpillReader reads the integer points spilled to a file.
+type integerSpillReader struct {
+	file *spillFile
+	dec  *IntegerPointDecoder
+}
+
+func newIntegerSpillReader(file *spillFile) (*integerSpillReader, error) {
+	r, err := file.reader()
+	if err != nil {
+		return nil, err
+	}
+	return &integerSpillReader{file: file, dec: NewIntegerPointDecoder(context.Background(), r)}, nil
+}
+
+// Next returns the next point of the file, or nil once all were read.
+func (r *integerSpillReader) Next() (*IntegerPoint, error) {
+	var p IntegerPoint
+	if err := r.dec.DecodeIntegerPoint(&p); err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Close removes the file.
+func (r *integerSpillReader) Close() error { return r.file.Close() }
+
+// integerSpillPartitions spills the points of the groups a group operator
+// cannot hold in memory to partitions on disk by the hash of their group, so
+// that each partition can be reduced in memory later.
+type integerSpillPartitions struct {
+	spiller Spiller
+	files   [spillPartitionN]*spillFile
+	encs    [spillPartitionN]*IntegerPointEncoder
+}
+
+func newIntegerSpillPartitions(spiller Spiller) *integerSpillPartitions {
+	return &integerSpillPartitions{spiller: spiller}
+}
+
+// write spills a point of the group with id.
+func (s *integerSpillPartitions) write(id string, p *IntegerPoint) error {
+	i := spillPartition(id)
+	if s.files[i] == nil {
+		f, err := createSpillFile(s.spiller)
+		if err != nil {
+			return err
+		}
+		s.files[i], s.encs[i] = f, NewIntegerPointEncoder(f)
+	}
+	return s.encs[i].EncodeIntegerPoint(p)
+}
+
+// open returns a reader of the points spilled to partition i, which removes
+// the partition once closed, or nil if no points were spilled to it.
+func (s *integerSpillPartitions) open(i int) (*integerSpillReader, error) {
+	f := s.files[i]
+	if f == nil {
+		return nil, nil
+	}
+	s.files[i], s.encs[i] = nil, nil
+
+	r, err := newIntegerSpillReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Close removes the partitions that were not opened.
+func (s *integerSpillPartitions) Close() error {
+	for i, f := range s.files {
+		if f != nil {
+			f.Close()
+			s.files[i], s.encs[i] = nil, nil
+		}
+	}
+	return nil
+}
+
+// integerExternalSort sorts points by time and key, spilling them to disk
+// as sorted runs whenever the points held in memory exceed the spill
+// threshold. Points with the same time and key keep the order they were added
+// in.
+type integerExternalSort struct {
+	spiller Spiller
+	key     func(p *IntegerPoint) string
+	items   []integerSortItem
+	size    int
+	runs    []*spillFile
+}
+
+// integerSortItem is a point and its sort key.
+type integerSortItem struct {
+	key   string
+	point IntegerPoint
+}
+
+func (a *integerSortItem) less(b *integerSortItem) bool {
+	if a.point.Time != b.point.Time {
+		return a.point.Time < b.point.Time
+	}
+	return a.key < b.key
+}
+
+func newIntegerExternalSort(spiller Spiller, key func(p *IntegerPoint) string) *integerExternalSort {
+	return &integerExternalSort{spiller: spiller, key: key}
+}
+
+// add adds a point to sort.
+func (s *integerExternalSort) add(p *IntegerPoint) error {
+	s.items = append(s.items, integerSortItem{key: s.key(p), point: *p})
+	s.size += integerPointSize(p)
+	if spillable(s.spiller, s.size) {
+		return s.spill()
+	}
+	return nil
+}
+
+// spill writes the points held in memory to disk as a sorted run.
+func (s *integerExternalSort) spill() error {
+	sort.SliceStable(s.items, func(i, j int) bool { return s.items[i].less(&s.items[j]) })
+
+	f, err := createSpillFile(s.spiller)
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, f)
+
+	enc := NewIntegerPointEncoder(f)
+	for i := range s.items {
+		if err := enc.EncodeIntegerPoint(&s.items[i].point); err != nil {
+			return err
+		}
+	}
+	s.items, s.size = s.items[:0], 0
+	return nil
+}
+
+// iterator returns an iterator of the sorted points, merging the runs on disk
+// with the points held in memory. The runs are removed once it is closed.
+func (s *integerExternalSort) iterator() (IntegerIterator, error) {
+	itr := &integerSortMergeIterator{key: s.key}
+	for _, f := range s.runs {
+		r, err := newIntegerSpillReader(f)
+		if err != nil {
+			f.Close()
+			itr.Close()
+			return nil, err
+		}
+		itr.runs = append(itr.runs, r)
+	}
+	s.runs = nil
+
+	for i := range itr.runs {
+		if err := itr.read(i); err != nil {
+			itr.Close()
+			return nil, err
+		}
+	}
+
+	// The points held in memory are the last run, and are kept in the heap.
+	sort.SliceStable(s.items, func(i, j int) bool { return s.items[i].less(&s.items[j]) })
+	for i, item := range s.items {
+		itr.heap = append(itr.heap, integerSortMergeHeapItem{item: item, run: len(itr.runs), seq: i})
+	}
+	s.items = nil
+	heap.Init(&itr.heap)
+	return itr, nil
+}
+
+// Close removes the runs spilled to disk.
+func (s *integerExternalSort) Close() error {
+	for _, f := range s.runs {
+		f.Close()
+	}
+	s.runs, s.items = nil, nil
+	return nil
+}
+
+// integerSortMergeIterator merges the sorted runs of an external sort.
+type integerSortMergeIterator struct {
+	runs []*integerSpillReader
+	heap integerSortMergeHeap
+	key  func(p *IntegerPoint) string
+}
+
+// Stats returns an empty stats object, the stats of the sorted points were
+// reported by the iterators they were read from.
+func (itr *integerSortMergeIterator) Stats() IteratorStats { return IteratorStats{} }
+
+// Close removes the runs.
+func (itr *integerSortMergeIterator) Close() error {
+	for _, r := range itr.runs {
+		if r != nil {
+			r.Close()
+		}
+	}
+	itr.runs, itr.heap = nil, nil
+	return nil
+}
+
+// Next returns the next point in order.
+func (itr *integerSortMergeIterator) Next() (*IntegerPoint, error) {
+	if len(itr.heap) == 0 {
+		return nil, nil
+	}
+
+	item := heap.Pop(&itr.heap).(integerSortMergeHeapItem)
+	if item.run < len(itr.runs) {
+		if err := itr.read(item.run); err != nil {
+			return nil, err
+		}
+	}
+	return &item.item.point, nil
+}
+
+// read pushes the next point of a run on disk to the heap, or removes the
+// run once all its points were read.
+func (itr *integerSortMergeIterator) read(run int) error {
+	p, err := itr.runs[run].Next()
+	if err != nil {
+		return err
+	} else if p == nil {
+		itr.runs[run].Close()
+		itr.runs[run] = nil
+		return nil
+	}
+	heap.Push(&itr.heap, integerSortMergeHeapItem{
+		item: integerSortItem{key: itr.key(p), point: *p},
+		run:  run,
+	})
+	return nil
+}
+
+// integerSortMergeHeapItem is a point of a run. Points of the same time and
+// key are ordered by run, then by their position in the run.
+type integerSortMergeHeapItem struct {
+	item integerSortItem
+	run  int
+	seq  int
+}
+
+// integerSortMergeHeap is a heap of the next points of the runs of an
+// external sort.
+type integerSortMergeHeap []integerSortMergeHeapItem
+
+func (h integerSortMergeHeap) Len() int      { return len(h) }
+func (h integerSortMergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h integerSortMergeHeap) Less(i, j int) bool {
+	if h[i].item.less(&h[j].item) {
+		return true
+	} else if h[j].item.less(&h[i].item) {
+		return false
+	} else if h[i].run != h[j].run {
+		return h[i].run < h[j].run
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h *integerSortMergeHeap) Push(x interface{}) {
+	*h = append(*h, x.(integerSortMergeHeapItem))
+}
+
+func (h *integerSortMergeHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
 // integerReduceFloatIterator executes a reducer for every interval and buffers the result.
 type integerReduceFloatIterator struct {
 	input    *bufIntegerIterator
@@ -3625,6 +4859,10 @@ type integerReduceFloatIterator struct {
 	opt      IteratorOptions
 	points   []FloatPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled FloatIterator
 }
 
 func newIntegerReduceFloatIterator(input IntegerIterator, opt IteratorOptions, createFn func() (IntegerPointAggregator, FloatPointEmitter)) *integerReduceFloatIterator {
@@ -3640,15 +4878,30 @@ func newIntegerReduceFloatIterator(input IntegerIterator, opt IteratorOptions, c
 func (itr *integerReduceFloatIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *integerReduceFloatIterator) Close() error { return itr.input.Close() }
+func (itr *integerReduceFloatIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *integerReduceFloatIterator) Next() (*FloatPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -3693,12 +4946,21 @@ func (itr *integerReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*integerReduceFloatPoint)
+	var (
+		size       int
+		partitions *integerSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -3726,18 +4988,108 @@ func (itr *integerReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &integerReduceFloatPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newIntegerSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateInteger(curr)
+		size += integerPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *integerReduceFloatIterator) newGroup(name string, tags Tags) *integerReduceFloatPoint {
+	aggregator, emitter := itr.create()
+	return &integerReduceFloatPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *integerReduceFloatIterator) reduceSpilled(m map[string]*integerReduceFloatPoint, partitions *integerSpillPartitions, startTime int64) (_ FloatIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newFloatExternalSort(itr.opt.Spiller, func(p *FloatPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*integerReduceFloatPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*integerReduceFloatPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateInteger(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *integerReduceFloatIterator) emit(m map[string]*integerReduceFloatPoint, startTime int64) []FloatPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -3774,7 +5126,7 @@ func (itr *integerReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		sort.Stable(sort.Reverse(floatPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // integerStreamFloatIterator streams inputs into the iterator and emits points gradually.
@@ -3905,6 +5257,10 @@ type integerReduceIntegerIterator struct {
 	opt      IteratorOptions
 	points   []IntegerPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled IntegerIterator
 }
 
 func newIntegerReduceIntegerIterator(input IntegerIterator, opt IteratorOptions, createFn func() (IntegerPointAggregator, IntegerPointEmitter)) *integerReduceIntegerIterator {
@@ -3920,15 +5276,30 @@ func newIntegerReduceIntegerIterator(input IntegerIterator, opt IteratorOptions,
 func (itr *integerReduceIntegerIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *integerReduceIntegerIterator) Close() error { return itr.input.Close() }
+func (itr *integerReduceIntegerIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *integerReduceIntegerIterator) Next() (*IntegerPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -3973,12 +5344,21 @@ func (itr *integerReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*integerReduceIntegerPoint)
+	var (
+		size       int
+		partitions *integerSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -4006,18 +5386,108 @@ func (itr *integerReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &integerReduceIntegerPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newIntegerSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateInteger(curr)
+		size += integerPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *integerReduceIntegerIterator) newGroup(name string, tags Tags) *integerReduceIntegerPoint {
+	aggregator, emitter := itr.create()
+	return &integerReduceIntegerPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *integerReduceIntegerIterator) reduceSpilled(m map[string]*integerReduceIntegerPoint, partitions *integerSpillPartitions, startTime int64) (_ IntegerIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newIntegerExternalSort(itr.opt.Spiller, func(p *IntegerPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*integerReduceIntegerPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*integerReduceIntegerPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateInteger(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *integerReduceIntegerIterator) emit(m map[string]*integerReduceIntegerPoint, startTime int64) []IntegerPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -4054,7 +5524,7 @@ func (itr *integerReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		sort.Stable(sort.Reverse(integerPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // integerStreamIntegerIterator streams inputs into the iterator and emits points gradually.
@@ -4185,6 +5655,10 @@ type integerReduceUnsignedIterator struct {
 	opt      IteratorOptions
 	points   []UnsignedPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled UnsignedIterator
 }
 
 func newIntegerReduceUnsignedIterator(input IntegerIterator, opt IteratorOptions, createFn func() (IntegerPointAggregator, UnsignedPointEmitter)) *integerReduceUnsignedIterator {
@@ -4200,15 +5674,30 @@ func newIntegerReduceUnsignedIterator(input IntegerIterator, opt IteratorOptions
 func (itr *integerReduceUnsignedIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *integerReduceUnsignedIterator) Close() error { return itr.input.Close() }
+func (itr *integerReduceUnsignedIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *integerReduceUnsignedIterator) Next() (*UnsignedPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -4253,12 +5742,21 @@ func (itr *integerReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*integerReduceUnsignedPoint)
+	var (
+		size       int
+		partitions *integerSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -4286,18 +5784,108 @@ func (itr *integerReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &integerReduceUnsignedPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newIntegerSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateInteger(curr)
+		size += integerPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *integerReduceUnsignedIterator) newGroup(name string, tags Tags) *integerReduceUnsignedPoint {
+	aggregator, emitter := itr.create()
+	return &integerReduceUnsignedPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *integerReduceUnsignedIterator) reduceSpilled(m map[string]*integerReduceUnsignedPoint, partitions *integerSpillPartitions, startTime int64) (_ UnsignedIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newUnsignedExternalSort(itr.opt.Spiller, func(p *UnsignedPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*integerReduceUnsignedPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*integerReduceUnsignedPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateInteger(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *integerReduceUnsignedIterator) emit(m map[string]*integerReduceUnsignedPoint, startTime int64) []UnsignedPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -4334,7 +5922,7 @@ func (itr *integerReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		sort.Stable(sort.Reverse(unsignedPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // integerStreamUnsignedIterator streams inputs into the iterator and emits points gradually.
@@ -4465,6 +6053,10 @@ type integerReduceStringIterator struct {
 	opt      IteratorOptions
 	points   []StringPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled StringIterator
 }
 
 func newIntegerReduceStringIterator(input IntegerIterator, opt IteratorOptions, createFn func() (IntegerPointAggregator, StringPointEmitter)) *integerReduceStringIterator {
@@ -4480,15 +6072,30 @@ func newIntegerReduceStringIterator(input IntegerIterator, opt IteratorOptions,
 func (itr *integerReduceStringIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *integerReduceStringIterator) Close() error { return itr.input.Close() }
+func (itr *integerReduceStringIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *integerReduceStringIterator) Next() (*StringPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -4533,51 +6140,150 @@ func (itr *integerReduceStringIterator) reduce() ([]StringPoint, error) {
 		break
 	}
 
-	// Create points by tags.
-	m := make(map[string]*integerReduceStringPoint)
-	for {
-		// Read next point.
-		curr, err := itr.input.NextInWindow(startTime, endTime)
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
+	m := make(map[string]*integerReduceStringPoint)
+	var (
+		size       int
+		partitions *integerSpillPartitions
+	)
+	for {
+		// Read next point.
+		curr, err := itr.input.NextInWindow(startTime, endTime)
+		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
+			return nil, err
+		} else if curr == nil {
+			break
+		} else if curr.Nil {
+			continue
+		} else if curr.Name != window.name {
+			itr.input.unread(curr)
+			break
+		}
+
+		// Ensure this point is within the same final window.
+		if curr.Name != window.name {
+			itr.input.unread(curr)
+			break
+		} else if tags := curr.Tags.Subset(itr.opt.Dimensions); tags.ID() != window.tags {
+			itr.input.unread(curr)
+			break
+		}
+
+		// Retrieve the tags on this point for this level of the query.
+		// This may be different than the bucket dimensions.
+		tags := curr.Tags.Subset(itr.dims)
+		id := tags.ID()
+
+		// Retrieve the aggregator for this name/tag combination or create one.
+		rp := m[id]
+		if rp == nil {
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newIntegerSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
+			}
+			rp = itr.newGroup(curr.Name, tags)
+			m[id] = rp
+		}
+		rp.Aggregator.AggregateInteger(curr)
+		size += integerPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *integerReduceStringIterator) newGroup(name string, tags Tags) *integerReduceStringPoint {
+	aggregator, emitter := itr.create()
+	return &integerReduceStringPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *integerReduceStringIterator) reduceSpilled(m map[string]*integerReduceStringPoint, partitions *integerSpillPartitions, startTime int64) (_ StringIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newStringExternalSort(itr.opt.Spiller, func(p *StringPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*integerReduceStringPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
 		if err != nil {
 			return nil, err
-		} else if curr == nil {
-			break
-		} else if curr.Nil {
+		} else if r == nil {
 			continue
-		} else if curr.Name != window.name {
-			itr.input.unread(curr)
-			break
-		}
-
-		// Ensure this point is within the same final window.
-		if curr.Name != window.name {
-			itr.input.unread(curr)
-			break
-		} else if tags := curr.Tags.Subset(itr.opt.Dimensions); tags.ID() != window.tags {
-			itr.input.unread(curr)
-			break
 		}
 
-		// Retrieve the tags on this point for this level of the query.
-		// This may be different than the bucket dimensions.
-		tags := curr.Tags.Subset(itr.dims)
-		id := tags.ID()
+		m := make(map[string]*integerReduceStringPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
 
-		// Retrieve the aggregator for this name/tag combination or create one.
-		rp := m[id]
-		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &integerReduceStringPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
 			}
-			m[id] = rp
+			rp.Aggregator.AggregateInteger(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
 		}
-		rp.Aggregator.AggregateInteger(curr)
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *integerReduceStringIterator) emit(m map[string]*integerReduceStringPoint, startTime int64) []StringPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -4614,7 +6320,7 @@ func (itr *integerReduceStringIterator) reduce() ([]StringPoint, error) {
 		sort.Stable(sort.Reverse(stringPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // integerStreamStringIterator streams inputs into the iterator and emits points gradually.
@@ -4745,6 +6451,10 @@ type integerReduceBooleanIterator struct {
 	opt      IteratorOptions
 	points   []BooleanPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled BooleanIterator
 }
 
 func newIntegerReduceBooleanIterator(input IntegerIterator, opt IteratorOptions, createFn func() (IntegerPointAggregator, BooleanPointEmitter)) *integerReduceBooleanIterator {
@@ -4760,15 +6470,30 @@ func newIntegerReduceBooleanIterator(input IntegerIterator, opt IteratorOptions,
 func (itr *integerReduceBooleanIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *integerReduceBooleanIterator) Close() error { return itr.input.Close() }
+func (itr *integerReduceBooleanIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *integerReduceBooleanIterator) Next() (*BooleanPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -4813,12 +6538,21 @@ func (itr *integerReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*integerReduceBooleanPoint)
+	var (
+		size       int
+		partitions *integerSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -4846,18 +6580,108 @@ func (itr *integerReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &integerReduceBooleanPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newIntegerSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateInteger(curr)
+		size += integerPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *integerReduceBooleanIterator) newGroup(name string, tags Tags) *integerReduceBooleanPoint {
+	aggregator, emitter := itr.create()
+	return &integerReduceBooleanPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *integerReduceBooleanIterator) reduceSpilled(m map[string]*integerReduceBooleanPoint, partitions *integerSpillPartitions, startTime int64) (_ BooleanIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newBooleanExternalSort(itr.opt.Spiller, func(p *BooleanPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*integerReduceBooleanPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*integerReduceBooleanPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateInteger(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *integerReduceBooleanIterator) emit(m map[string]*integerReduceBooleanPoint, startTime int64) []BooleanPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -4894,7 +6718,7 @@ func (itr *integerReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		sort.Stable(sort.Reverse(booleanPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // integerStreamBooleanIterator streams inputs into the iterator and emits points gradually.
@@ -6079,7 +7903,11 @@ CONSTRUCT:
 				if err != nil {
 					return nil, err
 				} else if next != nil && next.Name == itr.window.name && next.Tags.ID() == itr.window.tags.ID() {
+					// Calendar windows vary in length, so interpolate by time.
 					interval := int64(itr.opt.Interval.Duration)
+					if itr.opt.Interval.Months > 0 {
+						interval = 1
+					}
 					start := itr.window.time / interval
 					p.Value = linearUnsigned(start, itr.prev.Time/interval, next.Time/interval, itr.prev.Value, next.Value)
 				} else {
@@ -6107,8 +7935,15 @@ CONSTRUCT:
 
 	// Advance the expected time. Do not advance to a new window here
 	// as there may be lingering points with the same timestamp in the previous
-	// window.
-	if itr.opt.Ascending {
+	// window. Calendar windows already follow the location of the query.
+	if itr.opt.Interval.Months > 0 {
+		if itr.opt.Ascending {
+			_, itr.window.time = itr.opt.Window(itr.window.time)
+		} else {
+			itr.window.time, _ = itr.opt.Window(itr.window.time - 1)
+		}
+		return p, nil
+	} else if itr.opt.Ascending {
 		itr.window.time += int64(itr.opt.Interval.Duration)
 	} else {
 		itr.window.time -= int64(itr.opt.Interval.Duration)
@@ -6184,61 +8019,372 @@ func (itr *unsignedInterruptIterator) Next() (*UnsignedPoint, error) {
 		}
 	}
 
-	// Increment the counter for every point read.
-	itr.count++
-	return itr.input.Next()
+	// Increment the counter for every point read.
+	itr.count++
+	return itr.input.Next()
+}
+
+// unsignedCloseInterruptIterator represents a unsigned implementation of CloseInterruptIterator.
+type unsignedCloseInterruptIterator struct {
+	input   UnsignedIterator
+	closing <-chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newUnsignedCloseInterruptIterator(input UnsignedIterator, closing <-chan struct{}) *unsignedCloseInterruptIterator {
+	itr := &unsignedCloseInterruptIterator{
+		input:   input,
+		closing: closing,
+		done:    make(chan struct{}),
+	}
+	go itr.monitor()
+	return itr
+}
+
+func (itr *unsignedCloseInterruptIterator) monitor() {
+	select {
+	case <-itr.closing:
+		itr.Close()
+	case <-itr.done:
+	}
+}
+
+func (itr *unsignedCloseInterruptIterator) Stats() IteratorStats {
+	return itr.input.Stats()
+}
+
+func (itr *unsignedCloseInterruptIterator) Close() error {
+	itr.once.Do(func() {
+		close(itr.done)
+		itr.input.Close()
+	})
+	return nil
+}
+
+func (itr *unsignedCloseInterruptIterator) Next() (*UnsignedPoint, error) {
+	p, err := itr.input.Next()
+	if err != nil {
+		// Check if the iterator was closed.
+		select {
+		case <-itr.done:
+			return nil, nil
+		default:
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// unsignedMemoryIterator represents a unsigned implementation of MemoryIterator.
+type unsignedMemoryIterator struct {
+	input   UnsignedIterator
+	tracker MemoryTracker
+	n       int
+}
+
+func newUnsignedMemoryIterator(input UnsignedIterator, tracker MemoryTracker) *unsignedMemoryIterator {
+	return &unsignedMemoryIterator{input: input, tracker: tracker}
+}
+
+func (itr *unsignedMemoryIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *unsignedMemoryIterator) Close() error         { return itr.input.Close() }
+
+func (itr *unsignedMemoryIterator) Next() (*UnsignedPoint, error) {
+	p, err := itr.input.Next()
+	if p == nil || err != nil {
+		return p, err
+	}
+
+	// Report allocations to the tracker in batches.
+	itr.n += unsignedPointSize(p)
+	if itr.n >= memoryReportSize {
+		n := itr.n
+		itr.n = 0
+		if err := itr.tracker.Allocate(n); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// unsignedPointSize returns the estimated size in bytes of a point.
+func unsignedPointSize(p *UnsignedPoint) int {
+	return pointOverhead + auxSize(p.Aux)
+}
+
+// unsignedSpillReader reads the unsigned points spilled to a file.
+type unsignedSpillReader struct {
+	file *spillFile
+	dec  *UnsignedPointDecoder
+}
+
+func newUnsignedSpillReader(file *spillFile) (*unsignedSpillReader, error) {
+	r, err := file.reader()
+	if err != nil {
+		return nil, err
+	}
+	return &unsignedSpillReader{file: file, dec: NewUnsignedPointDecoder(context.Background(), r)}, nil
+}
+
+// Next returns the next point of the file, or nil once all were read.
+func (r *unsignedSpillReader) Next() (*UnsignedPoint, error) {
+	var p UnsignedPoint
+	if err := r.dec.DecodeUnsignedPoint(&p); err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Close removes the file.
+func (r *unsignedSpillReader) Close() error { return r.file.Close() }
+
+// unsignedSpillPartitions spills the points of the groups a group operator
+// cannot hold in memory to partitions on disk by the hash of their group, so
+// that each partition can be reduced in memory later.
+type unsignedSpillPartitions struct {
+	spiller Spiller
+	files   [spillPartitionN]*spillFile
+	encs    [spillPartitionN]*UnsignedPointEncoder
+}
+
+func newUnsignedSpillPartitions(spiller Spiller) *unsignedSpillPartitions {
+	return &unsignedSpillPartitions{spiller: spiller}
+}
+
+// write spills a point of the group with id.
+func (s *unsignedSpillPartitions) write(id string, p *UnsignedPoint) error {
+	i := spillPartition(id)
+	if s.files[i] == nil {
+		f, err := createSpillFile(s.spiller)
+		if err != nil {
+			return err
+		}
+		s.files[i], s.encs[i] = f, NewUnsignedPointEncoder(f)
+	}
+	return s.encs[i].EncodeUnsignedPoint(p)
+}
+
+// open returns a reader of the points spilled to partition i, which removes
+// the partition once closed, or nil if no points were spilled to it.
+func (s *unsignedSpillPartitions) open(i int) (*unsignedSpillReader, error) {
+	f := s.files[i]
+	if f == nil {
+		return nil, nil
+	}
+	s.files[i], s.encs[i] = nil, nil
+
+	r, err := newUnsignedSpillReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Close removes the partitions that were not opened.
+func (s *unsignedSpillPartitions) Close() error {
+	for i, f := range s.files {
+		if f != nil {
+			f.Close()
+			s.files[i], s.encs[i] = nil, nil
+		}
+	}
+	return nil
+}
+
+// unsignedExternalSort sorts points by time and key, spilling them to disk
+// as sorted runs whenever the points held in memory exceed the spill
+// threshold. Points with the same time and key keep the order they were added
+// in.
+type unsignedExternalSort struct {
+	spiller Spiller
+	key     func(p *UnsignedPoint) string
+	items   []unsignedSortItem
+	size    int
+	runs    []*spillFile
+}
+
+// unsignedSortItem is a point and its sort key.
+type unsignedSortItem struct {
+	key   string
+	point UnsignedPoint
+}
+
+func (a *unsignedSortItem) less(b *unsignedSortItem) bool {
+	if a.point.Time != b.point.Time {
+		return a.point.Time < b.point.Time
+	}
+	return a.key < b.key
+}
+
+func newUnsignedExternalSort(spiller Spiller, key func(p *UnsignedPoint) string) *unsignedExternalSort {
+	return &unsignedExternalSort{spiller: spiller, key: key}
+}
+
+// add adds a point to sort.
+func (s *unsignedExternalSort) add(p *UnsignedPoint) error {
+	s.items = append(s.items, unsignedSortItem{key: s.key(p), point: *p})
+	s.size += unsignedPointSize(p)
+	if spillable(s.spiller, s.size) {
+		return s.spill()
+	}
+	return nil
+}
+
+// spill writes the points held in memory to disk as a sorted run.
+func (s *unsignedExternalSort) spill() error {
+	sort.SliceStable(s.items, func(i, j int) bool { return s.items[i].less(&s.items[j]) })
+
+	f, err := createSpillFile(s.spiller)
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, f)
+
+	enc := NewUnsignedPointEncoder(f)
+	for i := range s.items {
+		if err := enc.EncodeUnsignedPoint(&s.items[i].point); err != nil {
+			return err
+		}
+	}
+	s.items, s.size = s.items[:0], 0
+	return nil
+}
+
+// iterator returns an iterator of the sorted points, merging the runs on disk
+// with the points held in memory. The runs are removed once it is closed.
+func (s *unsignedExternalSort) iterator() (UnsignedIterator, error) {
+	itr := &unsignedSortMergeIterator{key: s.key}
+	for _, f := range s.runs {
+		r, err := newUnsignedSpillReader(f)
+		if err != nil {
+			f.Close()
+			itr.Close()
+			return nil, err
+		}
+		itr.runs = append(itr.runs, r)
+	}
+	s.runs = nil
+
+	for i := range itr.runs {
+		if err := itr.read(i); err != nil {
+			itr.Close()
+			return nil, err
+		}
+	}
+
+	// The points held in memory are the last run, and are kept in the heap.
+	sort.SliceStable(s.items, func(i, j int) bool { return s.items[i].less(&s.items[j]) })
+	for i, item := range s.items {
+		itr.heap = append(itr.heap, unsignedSortMergeHeapItem{item: item, run: len(itr.runs), seq: i})
+	}
+	s.items = nil
+	heap.Init(&itr.heap)
+	return itr, nil
+}
+
+// Close removes the runs spilled to disk.
+func (s *unsignedExternalSort) Close() error {
+	for _, f := range s.runs {
+		f.Close()
+	}
+	s.runs, s.items = nil, nil
+	return nil
 }
 
-// unsignedCloseInterruptIterator represents a unsigned implementation of CloseInterruptIterator.
-type unsignedCloseInterruptIterator struct {
-	input   UnsignedIterator
-	closing <-chan struct{}
-	done    chan struct{}
-	once    sync.Once
+// unsignedSortMergeIterator merges the sorted runs of an external sort.
+type unsignedSortMergeIterator struct {
+	runs []*unsignedSpillReader
+	heap unsignedSortMergeHeap
+	key  func(p *UnsignedPoint) string
 }
 
-func newUnsignedCloseInterruptIterator(input UnsignedIterator, closing <-chan struct{}) *unsignedCloseInterruptIterator {
-	itr := &unsignedCloseInterruptIterator{
-		input:   input,
-		closing: closing,
-		done:    make(chan struct{}),
+// Stats returns an empty stats object, the stats of the sorted points were
+// reported by the iterators they were read from.
+func (itr *unsignedSortMergeIterator) Stats() IteratorStats { return IteratorStats{} }
+
+// Close removes the runs.
+func (itr *unsignedSortMergeIterator) Close() error {
+	for _, r := range itr.runs {
+		if r != nil {
+			r.Close()
+		}
 	}
-	go itr.monitor()
-	return itr
+	itr.runs, itr.heap = nil, nil
+	return nil
 }
 
-func (itr *unsignedCloseInterruptIterator) monitor() {
-	select {
-	case <-itr.closing:
-		itr.Close()
-	case <-itr.done:
+// Next returns the next point in order.
+func (itr *unsignedSortMergeIterator) Next() (*UnsignedPoint, error) {
+	if len(itr.heap) == 0 {
+		return nil, nil
 	}
-}
 
-func (itr *unsignedCloseInterruptIterator) Stats() IteratorStats {
-	return itr.input.Stats()
+	item := heap.Pop(&itr.heap).(unsignedSortMergeHeapItem)
+	if item.run < len(itr.runs) {
+		if err := itr.read(item.run); err != nil {
+			return nil, err
+		}
+	}
+	return &item.item.point, nil
 }
 
-func (itr *unsignedCloseInterruptIterator) Close() error {
-	itr.once.Do(func() {
-		close(itr.done)
-		itr.input.Close()
+// read pushes the next point of a run on disk to the heap, or removes the
+// run once all its points were read.
+func (itr *unsignedSortMergeIterator) read(run int) error {
+	p, err := itr.runs[run].Next()
+	if err != nil {
+		return err
+	} else if p == nil {
+		itr.runs[run].Close()
+		itr.runs[run] = nil
+		return nil
+	}
+	heap.Push(&itr.heap, unsignedSortMergeHeapItem{
+		item: unsignedSortItem{key: itr.key(p), point: *p},
+		run:  run,
 	})
 	return nil
 }
 
-func (itr *unsignedCloseInterruptIterator) Next() (*UnsignedPoint, error) {
-	p, err := itr.input.Next()
-	if err != nil {
-		// Check if the iterator was closed.
-		select {
-		case <-itr.done:
-			return nil, nil
-		default:
-			return nil, err
-		}
+// unsignedSortMergeHeapItem is a point of a run. Points of the same time and
+// key are ordered by run, then by their position in the run.
+type unsignedSortMergeHeapItem struct {
+	item unsignedSortItem
+	run  int
+	seq  int
+}
+
+// unsignedSortMergeHeap is a heap of the next points of the runs of an
+// external sort.
+type unsignedSortMergeHeap []unsignedSortMergeHeapItem
+
+func (h unsignedSortMergeHeap) Len() int      { return len(h) }
+func (h unsignedSortMergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h unsignedSortMergeHeap) Less(i, j int) bool {
+	if h[i].item.less(&h[j].item) {
+		return true
+	} else if h[j].item.less(&h[i].item) {
+		return false
+	} else if h[i].run != h[j].run {
+		return h[i].run < h[j].run
 	}
-	return p, nil
+	return h[i].seq < h[j].seq
+}
+
+func (h *unsignedSortMergeHeap) Push(x interface{}) {
+	*h = append(*h, x.(unsignedSortMergeHeapItem))
+}
+
+func (h *unsignedSortMergeHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
 }
 
 // unsignedReduceFloatIterator executes a reducer for every interval and buffers the result.
@@ -6249,6 +8395,10 @@ type unsignedReduceFloatIterator struct {
 	opt      IteratorOptions
 	points   []FloatPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled FloatIterator
 }
 
 func newUnsignedReduceFloatIterator(input UnsignedIterator, opt IteratorOptions, createFn func() (UnsignedPointAggregator, FloatPointEmitter)) *unsignedReduceFloatIterator {
@@ -6264,15 +8414,30 @@ func newUnsignedReduceFloatIterator(input UnsignedIterator, opt IteratorOptions,
 func (itr *unsignedReduceFloatIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *unsignedReduceFloatIterator) Close() error { return itr.input.Close() }
+func (itr *unsignedReduceFloatIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *unsignedReduceFloatIterator) Next() (*FloatPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -6317,12 +8482,21 @@ func (itr *unsignedReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*unsignedReduceFloatPoint)
+	var (
+		size       int
+		partitions *unsignedSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -6350,18 +8524,108 @@ func (itr *unsignedReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &unsignedReduceFloatPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newUnsignedSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateUnsigned(curr)
+		size += unsignedPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *unsignedReduceFloatIterator) newGroup(name string, tags Tags) *unsignedReduceFloatPoint {
+	aggregator, emitter := itr.create()
+	return &unsignedReduceFloatPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *unsignedReduceFloatIterator) reduceSpilled(m map[string]*unsignedReduceFloatPoint, partitions *unsignedSpillPartitions, startTime int64) (_ FloatIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newFloatExternalSort(itr.opt.Spiller, func(p *FloatPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*unsignedReduceFloatPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*unsignedReduceFloatPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateUnsigned(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *unsignedReduceFloatIterator) emit(m map[string]*unsignedReduceFloatPoint, startTime int64) []FloatPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -6398,7 +8662,7 @@ func (itr *unsignedReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		sort.Stable(sort.Reverse(floatPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // unsignedStreamFloatIterator streams inputs into the iterator and emits points gradually.
@@ -6529,6 +8793,10 @@ type unsignedReduceIntegerIterator struct {
 	opt      IteratorOptions
 	points   []IntegerPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled IntegerIterator
 }
 
 func newUnsignedReduceIntegerIterator(input UnsignedIterator, opt IteratorOptions, createFn func() (UnsignedPointAggregator, IntegerPointEmitter)) *unsignedReduceIntegerIterator {
@@ -6544,15 +8812,30 @@ func newUnsignedReduceIntegerIterator(input UnsignedIterator, opt IteratorOption
 func (itr *unsignedReduceIntegerIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *unsignedReduceIntegerIterator) Close() error { return itr.input.Close() }
+func (itr *unsignedReduceIntegerIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *unsignedReduceIntegerIterator) Next() (*IntegerPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -6597,12 +8880,21 @@ func (itr *unsignedReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*unsignedReduceIntegerPoint)
+	var (
+		size       int
+		partitions *unsignedSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -6630,18 +8922,108 @@ func (itr *unsignedReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &unsignedReduceIntegerPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newUnsignedSpillPartitions(itr.opt.Spiller)
 			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
+			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateUnsigned(curr)
+		size += unsignedPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *unsignedReduceIntegerIterator) newGroup(name string, tags Tags) *unsignedReduceIntegerPoint {
+	aggregator, emitter := itr.create()
+	return &unsignedReduceIntegerPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *unsignedReduceIntegerIterator) reduceSpilled(m map[string]*unsignedReduceIntegerPoint, partitions *unsignedSpillPartitions, startTime int64) (_ IntegerIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newIntegerExternalSort(itr.opt.Spiller, func(p *IntegerPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*unsignedReduceIntegerPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*unsignedReduceIntegerPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateUnsigned(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *unsignedReduceIntegerIterator) emit(m map[string]*unsignedReduceIntegerPoint, startTime int64) []IntegerPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -6678,7 +9060,7 @@ func (itr *unsignedReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		sort.Stable(sort.Reverse(integerPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // unsignedStreamIntegerIterator streams inputs into the iterator and emits points gradually.
@@ -6809,6 +9191,10 @@ type unsignedReduceUnsignedIterator struct {
 	opt      IteratorOptions
 	points   []UnsignedPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled UnsignedIterator
 }
 
 func newUnsignedReduceUnsignedIterator(input UnsignedIterator, opt IteratorOptions, createFn func() (UnsignedPointAggregator, UnsignedPointEmitter)) *unsignedReduceUnsignedIterator {
@@ -6824,15 +9210,30 @@ func newUnsignedReduceUnsignedIterator(input UnsignedIterator, opt IteratorOptio
 func (itr *unsignedReduceUnsignedIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *unsignedReduceUnsignedIterator) Close() error { return itr.input.Close() }
+func (itr *unsignedReduceUnsignedIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *unsignedReduceUnsignedIterator) Next() (*UnsignedPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -6877,12 +9278,21 @@ func (itr *unsignedReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*unsignedReduceUnsignedPoint)
+	var (
+		size       int
+		partitions *unsignedSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -6910,18 +9320,108 @@ func (itr *unsignedReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &unsignedReduceUnsignedPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newUnsignedSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateUnsigned(curr)
+		size += unsignedPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *unsignedReduceUnsignedIterator) newGroup(name string, tags Tags) *unsignedReduceUnsignedPoint {
+	aggregator, emitter := itr.create()
+	return &unsignedReduceUnsignedPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *unsignedReduceUnsignedIterator) reduceSpilled(m map[string]*unsignedReduceUnsignedPoint, partitions *unsignedSpillPartitions, startTime int64) (_ UnsignedIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newUnsignedExternalSort(itr.opt.Spiller, func(p *UnsignedPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*unsignedReduceUnsignedPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*unsignedReduceUnsignedPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateUnsigned(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *unsignedReduceUnsignedIterator) emit(m map[string]*unsignedReduceUnsignedPoint, startTime int64) []UnsignedPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -6958,7 +9458,7 @@ func (itr *unsignedReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		sort.Stable(sort.Reverse(unsignedPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // unsignedStreamUnsignedIterator streams inputs into the iterator and emits points gradually.
@@ -7089,6 +9589,10 @@ type unsignedReduceStringIterator struct {
 	opt      IteratorOptions
 	points   []StringPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled StringIterator
 }
 
 func newUnsignedReduceStringIterator(input UnsignedIterator, opt IteratorOptions, createFn func() (UnsignedPointAggregator, StringPointEmitter)) *unsignedReduceStringIterator {
@@ -7104,15 +9608,30 @@ func newUnsignedReduceStringIterator(input UnsignedIterator, opt IteratorOptions
 func (itr *unsignedReduceStringIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *unsignedReduceStringIterator) Close() error { return itr.input.Close() }
+func (itr *unsignedReduceStringIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *unsignedReduceStringIterator) Next() (*StringPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -7157,12 +9676,21 @@ func (itr *unsignedReduceStringIterator) reduce() ([]StringPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*unsignedReduceStringPoint)
+	var (
+		size       int
+		partitions *unsignedSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -7190,18 +9718,108 @@ func (itr *unsignedReduceStringIterator) reduce() ([]StringPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &unsignedReduceStringPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newUnsignedSpillPartitions(itr.opt.Spiller)
 			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
+			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateUnsigned(curr)
+		size += unsignedPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *unsignedReduceStringIterator) newGroup(name string, tags Tags) *unsignedReduceStringPoint {
+	aggregator, emitter := itr.create()
+	return &unsignedReduceStringPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *unsignedReduceStringIterator) reduceSpilled(m map[string]*unsignedReduceStringPoint, partitions *unsignedSpillPartitions, startTime int64) (_ StringIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newStringExternalSort(itr.opt.Spiller, func(p *StringPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*unsignedReduceStringPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
 	}
 
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*unsignedReduceStringPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateUnsigned(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
+	}
+	return sorter.iterator()
+}
+
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *unsignedReduceStringIterator) emit(m map[string]*unsignedReduceStringPoint, startTime int64) []StringPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -7238,7 +9856,7 @@ func (itr *unsignedReduceStringIterator) reduce() ([]StringPoint, error) {
 		sort.Stable(sort.Reverse(stringPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // unsignedStreamStringIterator streams inputs into the iterator and emits points gradually.
@@ -7369,6 +9987,10 @@ type unsignedReduceBooleanIterator struct {
 	opt      IteratorOptions
 	points   []BooleanPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled BooleanIterator
 }
 
 func newUnsignedReduceBooleanIterator(input UnsignedIterator, opt IteratorOptions, createFn func() (UnsignedPointAggregator, BooleanPointEmitter)) *unsignedReduceBooleanIterator {
@@ -7384,15 +10006,30 @@ func newUnsignedReduceBooleanIterator(input UnsignedIterator, opt IteratorOption
 func (itr *unsignedReduceBooleanIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *unsignedReduceBooleanIterator) Close() error { return itr.input.Close() }
+func (itr *unsignedReduceBooleanIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *unsignedReduceBooleanIterator) Next() (*BooleanPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -7437,12 +10074,21 @@ func (itr *unsignedReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*unsignedReduceBooleanPoint)
+	var (
+		size       int
+		partitions *unsignedSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -7470,18 +10116,108 @@ func (itr *unsignedReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &unsignedReduceBooleanPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newUnsignedSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateUnsigned(curr)
+		size += unsignedPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *unsignedReduceBooleanIterator) newGroup(name string, tags Tags) *unsignedReduceBooleanPoint {
+	aggregator, emitter := itr.create()
+	return &unsignedReduceBooleanPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *unsignedReduceBooleanIterator) reduceSpilled(m map[string]*unsignedReduceBooleanPoint, partitions *unsignedSpillPartitions, startTime int64) (_ BooleanIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newBooleanExternalSort(itr.opt.Spiller, func(p *BooleanPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*unsignedReduceBooleanPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*unsignedReduceBooleanPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateUnsigned(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *unsignedReduceBooleanIterator) emit(m map[string]*unsignedReduceBooleanPoint, startTime int64) []BooleanPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -7518,7 +10254,7 @@ func (itr *unsignedReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		sort.Stable(sort.Reverse(booleanPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // unsignedStreamBooleanIterator streams inputs into the iterator and emits points gradually.
@@ -8717,8 +11453,15 @@ CONSTRUCT:
 
 	// Advance the expected time. Do not advance to a new window here
 	// as there may be lingering points with the same timestamp in the previous
-	// window.
-	if itr.opt.Ascending {
+	// window. Calendar windows already follow the location of the query.
+	if itr.opt.Interval.Months > 0 {
+		if itr.opt.Ascending {
+			_, itr.window.time = itr.opt.Window(itr.window.time)
+		} else {
+			itr.window.time, _ = itr.opt.Window(itr.window.time - 1)
+		}
+		return p, nil
+	} else if itr.opt.Ascending {
 		itr.window.time += int64(itr.opt.Interval.Duration)
 	} else {
 		itr.window.time -= int64(itr.opt.Interval.Duration)
@@ -8837,18 +11580,329 @@ func (itr *stringCloseInterruptIterator) Close() error {
 	return nil
 }
 
-func (itr *stringCloseInterruptIterator) Next() (*StringPoint, error) {
-	p, err := itr.input.Next()
-	if err != nil {
-		// Check if the iterator was closed.
-		select {
-		case <-itr.done:
-			return nil, nil
-		default:
-			return nil, err
-		}
-	}
-	return p, nil
+func (itr *stringCloseInterruptIterator) Next() (*StringPoint, error) {
+	p, err := itr.input.Next()
+	if err != nil {
+		// Check if the iterator was closed.
+		select {
+		case <-itr.done:
+			return nil, nil
+		default:
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// stringMemoryIterator represents a string implementation of MemoryIterator.
+type stringMemoryIterator struct {
+	input   StringIterator
+	tracker MemoryTracker
+	n       int
+}
+
+func newStringMemoryIterator(input StringIterator, tracker MemoryTracker) *stringMemoryIterator {
+	return &stringMemoryIterator{input: input, tracker: tracker}
+}
+
+func (itr *stringMemoryIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *stringMemoryIterator) Close() error         { return itr.input.Close() }
+
+func (itr *stringMemoryIterator) Next() (*StringPoint, error) {
+	p, err := itr.input.Next()
+	if p == nil || err != nil {
+		return p, err
+	}
+
+	// Report allocations to the tracker in batches.
+	itr.n += stringPointSize(p)
+	if itr.n >= memoryReportSize {
+		n := itr.n
+		itr.n = 0
+		if err := itr.tracker.Allocate(n); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// stringPointSize returns the estimated size in bytes of a point.
+func stringPointSize(p *StringPoint) int {
+	return pointOverhead + auxSize(p.Aux) + len(p.Value)
+}
+
+// stringSpillReader reads the string points spilled to a file.
+type stringSpillReader struct {
+	file *spillFile
+	dec  *StringPointDecoder
+}
+
+func newStringSpillReader(file *spillFile) (*stringSpillReader, error) {
+	r, err := file.reader()
+	if err != nil {
+		return nil, err
+	}
+	return &stringSpillReader{file: file, dec: NewStringPointDecoder(context.Background(), r)}, nil
+}
+
+// Next returns the next point of the file, or nil once all were read.
+func (r *stringSpillReader) Next() (*StringPoint, error) {
+	var p StringPoint
+	if err := r.dec.DecodeStringPoint(&p); err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Close removes the file.
+func (r *stringSpillReader) Close() error { return r.file.Close() }
+
+// stringSpillPartitions spills the points of the groups a group operator
+// cannot hold in memory to partitions on disk by the hash of their group, so
+// that each partition can be reduced in memory later.
+type stringSpillPartitions struct {
+	spiller Spiller
+	files   [spillPartitionN]*spillFile
+	encs    [spillPartitionN]*StringPointEncoder
+}
+
+func newStringSpillPartitions(spiller Spiller) *stringSpillPartitions {
+	return &stringSpillPartitions{spiller: spiller}
+}
+
+// write spills a point of the group with id.
+func (s *stringSpillPartitions) write(id string, p *StringPoint) error {
+	i := spillPartition(id)
+	if s.files[i] == nil {
+		f, err := createSpillFile(s.spiller)
+		if err != nil {
+			return err
+		}
+		s.files[i], s.encs[i] = f, NewStringPointEncoder(f)
+	}
+	return s.encs[i].EncodeStringPoint(p)
+}
+
+// open returns a reader of the points spilled to partition i, which removes
+// the partition once closed, or nil if no points were spilled to it.
+func (s *stringSpillPartitions) open(i int) (*stringSpillReader, error) {
+	f := s.files[i]
+	if f == nil {
+		return nil, nil
+	}
+	s.files[i], s.encs[i] = nil, nil
+
+	r, err := newStringSpillReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Close removes the partitions that were not opened.
+func (s *stringSpillPartitions) Close() error {
+	for i, f := range s.files {
+		if f != nil {
+			f.Close()
+			s.files[i], s.encs[i] = nil, nil
+		}
+	}
+	return nil
+}
+
+// stringExternalSort sorts points by time and key, spilling them to disk
+// as sorted runs whenever the points held in memory exceed the spill
+// threshold. Points with the same time and key keep the order they were added
+// in.
+type stringExternalSort struct {
+	spiller Spiller
+	key     func(p *StringPoint) string
+	items   []stringSortItem
+	size    int
+	runs    []*spillFile
+}
+
+// stringSortItem is a point and its sort key.
+type stringSortItem struct {
+	key   string
+	point StringPoint
+}
+
+func (a *stringSortItem) less(b *stringSortItem) bool {
+	if a.point.Time != b.point.Time {
+		return a.point.Time < b.point.Time
+	}
+	return a.key < b.key
+}
+
+func newStringExternalSort(spiller Spiller, key func(p *StringPoint) string) *stringExternalSort {
+	return &stringExternalSort{spiller: spiller, key: key}
+}
+
+// add adds a point to sort.
+func (s *stringExternalSort) add(p *StringPoint) error {
+	s.items = append(s.items, stringSortItem{key: s.key(p), point: *p})
+	s.size += stringPointSize(p)
+	if spillable(s.spiller, s.size) {
+		return s.spill()
+	}
+	return nil
+}
+
+// spill writes the points held in memory to disk as a sorted run.
+func (s *stringExternalSort) spill() error {
+	sort.SliceStable(s.items, func(i, j int) bool { return s.items[i].less(&s.items[j]) })
+
+	f, err := createSpillFile(s.spiller)
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, f)
+
+	enc := NewStringPointEncoder(f)
+	for i := range s.items {
+		if err := enc.EncodeStringPoint(&s.items[i].point); err != nil {
+			return err
+		}
+	}
+	s.items, s.size = s.items[:0], 0
+	return nil
+}
+
+// iterator returns an iterator of the sorted points, merging the runs on disk
+// with the points held in memory. The runs are removed once it is closed.
+func (s *stringExternalSort) iterator() (StringIterator, error) {
+	itr := &stringSortMergeIterator{key: s.key}
+	for _, f := range s.runs {
+		r, err := newStringSpillReader(f)
+		if err != nil {
+			f.Close()
+			itr.Close()
+			return nil, err
+		}
+		itr.runs = append(itr.runs, r)
+	}
+	s.runs = nil
+
+	for i := range itr.runs {
+		if err := itr.read(i); err != nil {
+			itr.Close()
+			return nil, err
+		}
+	}
+
+	// The points held in memory are the last run, and are kept in the heap.
+	sort.SliceStable(s.items, func(i, j int) bool { return s.items[i].less(&s.items[j]) })
+	for i, item := range s.items {
+		itr.heap = append(itr.heap, stringSortMergeHeapItem{item: item, run: len(itr.runs), seq: i})
+	}
+	s.items = nil
+	heap.Init(&itr.heap)
+	return itr, nil
+}
+
+// Close removes the runs spilled to disk.
+func (s *stringExternalSort) Close() error {
+	for _, f := range s.runs {
+		f.Close()
+	}
+	s.runs, s.items = nil, nil
+	return nil
+}
+
+// stringSortMergeIterator merges the sorted runs of an external sort.
+type stringSortMergeIterator struct {
+	runs []*stringSpillReader
+	heap stringSortMergeHeap
+	key  func(p *StringPoint) string
+}
+
+// Stats returns an empty stats object, the stats of the sorted points were
+// reported by the iterators they were read from.
+func (itr *stringSortMergeIterator) Stats() IteratorStats { return IteratorStats{} }
+
+// Close removes the runs.
+func (itr *stringSortMergeIterator) Close() error {
+	for _, r := range itr.runs {
+		if r != nil {
+			r.Close()
+		}
+	}
+	itr.runs, itr.heap = nil, nil
+	return nil
+}
+
+// Next returns the next point in order.
+func (itr *stringSortMergeIterator) Next() (*StringPoint, error) {
+	if len(itr.heap) == 0 {
+		return nil, nil
+	}
+
+	item := heap.Pop(&itr.heap).(stringSortMergeHeapItem)
+	if item.run < len(itr.runs) {
+		if err := itr.read(item.run); err != nil {
+			return nil, err
+		}
+	}
+	return &item.item.point, nil
+}
+
+// read pushes the next point of a run on disk to the heap, or removes the
+// run once all its points were read.
+func (itr *stringSortMergeIterator) read(run int) error {
+	p, err := itr.runs[run].Next()
+	if err != nil {
+		return err
+	} else if p == nil {
+		itr.runs[run].Close()
+		itr.runs[run] = nil
+		return nil
+	}
+	heap.Push(&itr.heap, stringSortMergeHeapItem{
+		item: stringSortItem{key: itr.key(p), point: *p},
+		run:  run,
+	})
+	return nil
+}
+
+// stringSortMergeHeapItem is a point of a run. Points of the same time and
+// key are ordered by run, then by their position in the run.
+type stringSortMergeHeapItem struct {
+	item stringSortItem
+	run  int
+	seq  int
+}
+
+// stringSortMergeHeap is a heap of the next points of the runs of an
+// external sort.
+type stringSortMergeHeap []stringSortMergeHeapItem
+
+func (h stringSortMergeHeap) Len() int      { return len(h) }
+func (h stringSortMergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h stringSortMergeHeap) Less(i, j int) bool {
+	if h[i].item.less(&h[j].item) {
+		return true
+	} else if h[j].item.less(&h[i].item) {
+		return false
+	} else if h[i].run != h[j].run {
+		return h[i].run < h[j].run
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h *stringSortMergeHeap) Push(x interface{}) {
+	*h = append(*h, x.(stringSortMergeHeapItem))
+}
+
+func (h *stringSortMergeHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
 }
 
 // stringReduceFloatIterator executes a reducer for every interval and buffers the result.
@@ -8859,6 +11913,10 @@ type stringReduceFloatIterator struct {
 	opt      IteratorOptions
 	points   []FloatPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled FloatIterator
 }
 
 func newStringReduceFloatIterator(input StringIterator, opt IteratorOptions, createFn func() (StringPointAggregator, FloatPointEmitter)) *stringReduceFloatIterator {
@@ -8874,15 +11932,30 @@ func newStringReduceFloatIterator(input StringIterator, opt IteratorOptions, cre
 func (itr *stringReduceFloatIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *stringReduceFloatIterator) Close() error { return itr.input.Close() }
+func (itr *stringReduceFloatIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *stringReduceFloatIterator) Next() (*FloatPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -8927,12 +12000,21 @@ func (itr *stringReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*stringReduceFloatPoint)
+	var (
+		size       int
+		partitions *stringSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -8960,18 +12042,108 @@ func (itr *stringReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &stringReduceFloatPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newStringSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateString(curr)
+		size += stringPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *stringReduceFloatIterator) newGroup(name string, tags Tags) *stringReduceFloatPoint {
+	aggregator, emitter := itr.create()
+	return &stringReduceFloatPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *stringReduceFloatIterator) reduceSpilled(m map[string]*stringReduceFloatPoint, partitions *stringSpillPartitions, startTime int64) (_ FloatIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newFloatExternalSort(itr.opt.Spiller, func(p *FloatPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*stringReduceFloatPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*stringReduceFloatPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateString(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *stringReduceFloatIterator) emit(m map[string]*stringReduceFloatPoint, startTime int64) []FloatPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -9008,7 +12180,7 @@ func (itr *stringReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		sort.Stable(sort.Reverse(floatPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // stringStreamFloatIterator streams inputs into the iterator and emits points gradually.
@@ -9139,6 +12311,10 @@ type stringReduceIntegerIterator struct {
 	opt      IteratorOptions
 	points   []IntegerPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled IntegerIterator
 }
 
 func newStringReduceIntegerIterator(input StringIterator, opt IteratorOptions, createFn func() (StringPointAggregator, IntegerPointEmitter)) *stringReduceIntegerIterator {
@@ -9154,15 +12330,30 @@ func newStringReduceIntegerIterator(input StringIterator, opt IteratorOptions, c
 func (itr *stringReduceIntegerIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *stringReduceIntegerIterator) Close() error { return itr.input.Close() }
+func (itr *stringReduceIntegerIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *stringReduceIntegerIterator) Next() (*IntegerPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -9207,12 +12398,21 @@ func (itr *stringReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*stringReduceIntegerPoint)
+	var (
+		size       int
+		partitions *stringSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -9240,18 +12440,108 @@ func (itr *stringReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &stringReduceIntegerPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newStringSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateString(curr)
+		size += stringPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *stringReduceIntegerIterator) newGroup(name string, tags Tags) *stringReduceIntegerPoint {
+	aggregator, emitter := itr.create()
+	return &stringReduceIntegerPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *stringReduceIntegerIterator) reduceSpilled(m map[string]*stringReduceIntegerPoint, partitions *stringSpillPartitions, startTime int64) (_ IntegerIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newIntegerExternalSort(itr.opt.Spiller, func(p *IntegerPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*stringReduceIntegerPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*stringReduceIntegerPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateString(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *stringReduceIntegerIterator) emit(m map[string]*stringReduceIntegerPoint, startTime int64) []IntegerPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -9288,7 +12578,7 @@ func (itr *stringReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		sort.Stable(sort.Reverse(integerPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // stringStreamIntegerIterator streams inputs into the iterator and emits points gradually.
@@ -9419,6 +12709,10 @@ type stringReduceUnsignedIterator struct {
 	opt      IteratorOptions
 	points   []UnsignedPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled UnsignedIterator
 }
 
 func newStringReduceUnsignedIterator(input StringIterator, opt IteratorOptions, createFn func() (StringPointAggregator, UnsignedPointEmitter)) *stringReduceUnsignedIterator {
@@ -9434,15 +12728,30 @@ func newStringReduceUnsignedIterator(input StringIterator, opt IteratorOptions,
 func (itr *stringReduceUnsignedIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *stringReduceUnsignedIterator) Close() error { return itr.input.Close() }
+func (itr *stringReduceUnsignedIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *stringReduceUnsignedIterator) Next() (*UnsignedPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -9487,12 +12796,21 @@ func (itr *stringReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*stringReduceUnsignedPoint)
+	var (
+		size       int
+		partitions *stringSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -9520,18 +12838,108 @@ func (itr *stringReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &stringReduceUnsignedPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newStringSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateString(curr)
+		size += stringPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *stringReduceUnsignedIterator) newGroup(name string, tags Tags) *stringReduceUnsignedPoint {
+	aggregator, emitter := itr.create()
+	return &stringReduceUnsignedPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *stringReduceUnsignedIterator) reduceSpilled(m map[string]*stringReduceUnsignedPoint, partitions *stringSpillPartitions, startTime int64) (_ UnsignedIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newUnsignedExternalSort(itr.opt.Spiller, func(p *UnsignedPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*stringReduceUnsignedPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*stringReduceUnsignedPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateString(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *stringReduceUnsignedIterator) emit(m map[string]*stringReduceUnsignedPoint, startTime int64) []UnsignedPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -9568,7 +12976,7 @@ func (itr *stringReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		sort.Stable(sort.Reverse(unsignedPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // stringStreamUnsignedIterator streams inputs into the iterator and emits points gradually.
@@ -9699,6 +13107,10 @@ type stringReduceStringIterator struct {
 	opt      IteratorOptions
 	points   []StringPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled StringIterator
 }
 
 func newStringReduceStringIterator(input StringIterator, opt IteratorOptions, createFn func() (StringPointAggregator, StringPointEmitter)) *stringReduceStringIterator {
@@ -9714,15 +13126,30 @@ func newStringReduceStringIterator(input StringIterator, opt IteratorOptions, cr
 func (itr *stringReduceStringIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *stringReduceStringIterator) Close() error { return itr.input.Close() }
+func (itr *stringReduceStringIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *stringReduceStringIterator) Next() (*StringPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -9767,12 +13194,21 @@ func (itr *stringReduceStringIterator) reduce() ([]StringPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*stringReduceStringPoint)
+	var (
+		size       int
+		partitions *stringSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -9800,18 +13236,108 @@ func (itr *stringReduceStringIterator) reduce() ([]StringPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &stringReduceStringPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newStringSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
-		rp.Aggregator.AggregateString(curr)
+		rp.Aggregator.AggregateString(curr)
+		size += stringPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *stringReduceStringIterator) newGroup(name string, tags Tags) *stringReduceStringPoint {
+	aggregator, emitter := itr.create()
+	return &stringReduceStringPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *stringReduceStringIterator) reduceSpilled(m map[string]*stringReduceStringPoint, partitions *stringSpillPartitions, startTime int64) (_ StringIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newStringExternalSort(itr.opt.Spiller, func(p *StringPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*stringReduceStringPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*stringReduceStringPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateString(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *stringReduceStringIterator) emit(m map[string]*stringReduceStringPoint, startTime int64) []StringPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -9848,7 +13374,7 @@ func (itr *stringReduceStringIterator) reduce() ([]StringPoint, error) {
 		sort.Stable(sort.Reverse(stringPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // stringStreamStringIterator streams inputs into the iterator and emits points gradually.
@@ -9979,6 +13505,10 @@ type stringReduceBooleanIterator struct {
 	opt      IteratorOptions
 	points   []BooleanPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled BooleanIterator
 }
 
 func newStringReduceBooleanIterator(input StringIterator, opt IteratorOptions, createFn func() (StringPointAggregator, BooleanPointEmitter)) *stringReduceBooleanIterator {
@@ -9994,15 +13524,30 @@ func newStringReduceBooleanIterator(input StringIterator, opt IteratorOptions, c
 func (itr *stringReduceBooleanIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *stringReduceBooleanIterator) Close() error { return itr.input.Close() }
+func (itr *stringReduceBooleanIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *stringReduceBooleanIterator) Next() (*BooleanPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -10047,12 +13592,21 @@ func (itr *stringReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*stringReduceBooleanPoint)
+	var (
+		size       int
+		partitions *stringSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -10080,18 +13634,108 @@ func (itr *stringReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &stringReduceBooleanPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newStringSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateString(curr)
+		size += stringPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *stringReduceBooleanIterator) newGroup(name string, tags Tags) *stringReduceBooleanPoint {
+	aggregator, emitter := itr.create()
+	return &stringReduceBooleanPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
 	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *stringReduceBooleanIterator) reduceSpilled(m map[string]*stringReduceBooleanPoint, partitions *stringSpillPartitions, startTime int64) (_ BooleanIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newBooleanExternalSort(itr.opt.Spiller, func(p *BooleanPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*stringReduceBooleanPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*stringReduceBooleanPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateString(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
+	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *stringReduceBooleanIterator) emit(m map[string]*stringReduceBooleanPoint, startTime int64) []BooleanPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -10128,7 +13772,7 @@ func (itr *stringReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		sort.Stable(sort.Reverse(booleanPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // stringStreamBooleanIterator streams inputs into the iterator and emits points gradually.
@@ -11327,8 +14971,15 @@ CONSTRUCT:
 
 	// Advance the expected time. Do not advance to a new window here
 	// as there may be lingering points with the same timestamp in the previous
-	// window.
-	if itr.opt.Ascending {
+	// window. Calendar windows already follow the location of the query.
+	if itr.opt.Interval.Months > 0 {
+		if itr.opt.Ascending {
+			_, itr.window.time = itr.opt.Window(itr.window.time)
+		} else {
+			itr.window.time, _ = itr.opt.Window(itr.window.time - 1)
+		}
+		return p, nil
+	} else if itr.opt.Ascending {
 		itr.window.time += int64(itr.opt.Interval.Duration)
 	} else {
 		itr.window.time -= int64(itr.opt.Interval.Duration)
@@ -11461,6 +15112,317 @@ func (itr *booleanCloseInterruptIterator) Next() (*BooleanPoint, error) {
 	return p, nil
 }
 
+// booleanMemoryIterator represents a boolean implementation of MemoryIterator.
+type booleanMemoryIterator struct {
+	input   BooleanIterator
+	tracker MemoryTracker
+	n       int
+}
+
+func newBooleanMemoryIterator(input BooleanIterator, tracker MemoryTracker) *booleanMemoryIterator {
+	return &booleanMemoryIterator{input: input, tracker: tracker}
+}
+
+func (itr *booleanMemoryIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *booleanMemoryIterator) Close() error         { return itr.input.Close() }
+
+func (itr *booleanMemoryIterator) Next() (*BooleanPoint, error) {
+	p, err := itr.input.Next()
+	if p == nil || err != nil {
+		return p, err
+	}
+
+	// Report allocations to the tracker in batches.
+	itr.n += booleanPointSize(p)
+	if itr.n >= memoryReportSize {
+		n := itr.n
+		itr.n = 0
+		if err := itr.tracker.Allocate(n); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// booleanPointSize returns the estimated size in bytes of a point.
+func booleanPointSize(p *BooleanPoint) int {
+	return pointOverhead + auxSize(p.Aux)
+}
+
+// booleanSpillReader reads the boolean points spilled to a file.
+type booleanSpillReader struct {
+	file *spillFile
+	dec  *BooleanPointDecoder
+}
+
+func newBooleanSpillReader(file *spillFile) (*booleanSpillReader, error) {
+	r, err := file.reader()
+	if err != nil {
+		return nil, err
+	}
+	return &booleanSpillReader{file: file, dec: NewBooleanPointDecoder(context.Background(), r)}, nil
+}
+
+// Next returns the next point of the file, or nil once all were read.
+func (r *booleanSpillReader) Next() (*BooleanPoint, error) {
+	var p BooleanPoint
+	if err := r.dec.DecodeBooleanPoint(&p); err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Close removes the file.
+func (r *booleanSpillReader) Close() error { return r.file.Close() }
+
+// booleanSpillPartitions spills the points of the groups a group operator
+// cannot hold in memory to partitions on disk by the hash of their group, so
+// that each partition can be reduced in memory later.
+type booleanSpillPartitions struct {
+	spiller Spiller
+	files   [spillPartitionN]*spillFile
+	encs    [spillPartitionN]*BooleanPointEncoder
+}
+
+func newBooleanSpillPartitions(spiller Spiller) *booleanSpillPartitions {
+	return &booleanSpillPartitions{spiller: spiller}
+}
+
+// write spills a point of the group with id.
+func (s *booleanSpillPartitions) write(id string, p *BooleanPoint) error {
+	i := spillPartition(id)
+	if s.files[i] == nil {
+		f, err := createSpillFile(s.spiller)
+		if err != nil {
+			return err
+		}
+		s.files[i], s.encs[i] = f, NewBooleanPointEncoder(f)
+	}
+	return s.encs[i].EncodeBooleanPoint(p)
+}
+
+// open returns a reader of the points spilled to partition i, which removes
+// the partition once closed, or nil if no points were spilled to it.
+func (s *booleanSpillPartitions) open(i int) (*booleanSpillReader, error) {
+	f := s.files[i]
+	if f == nil {
+		return nil, nil
+	}
+	s.files[i], s.encs[i] = nil, nil
+
+	r, err := newBooleanSpillReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Close removes the partitions that were not opened.
+func (s *booleanSpillPartitions) Close() error {
+	for i, f := range s.files {
+		if f != nil {
+			f.Close()
+			s.files[i], s.encs[i] = nil, nil
+		}
+	}
+	return nil
+}
+
+// booleanExternalSort sorts points by time and key, spilling them to disk
+// as sorted runs whenever the points held in memory exceed the spill
+// threshold. Points with the same time and key keep the order they were added
+// in.
+type booleanExternalSort struct {
+	spiller Spiller
+	key     func(p *BooleanPoint) string
+	items   []booleanSortItem
+	size    int
+	runs    []*spillFile
+}
+
+// booleanSortItem is a point and its sort key.
+type booleanSortItem struct {
+	key   string
+	point BooleanPoint
+}
+
+func (a *booleanSortItem) less(b *booleanSortItem) bool {
+	if a.point.Time != b.point.Time {
+		return a.point.Time < b.point.Time
+	}
+	return a.key < b.key
+}
+
+func newBooleanExternalSort(spiller Spiller, key func(p *BooleanPoint) string) *booleanExternalSort {
+	return &booleanExternalSort{spiller: spiller, key: key}
+}
+
+// add adds a point to sort.
+func (s *booleanExternalSort) add(p *BooleanPoint) error {
+	s.items = append(s.items, booleanSortItem{key: s.key(p), point: *p})
+	s.size += booleanPointSize(p)
+	if spillable(s.spiller, s.size) {
+		return s.spill()
+	}
+	return nil
+}
+
+// spill writes the points held in memory to disk as a sorted run.
+func (s *booleanExternalSort) spill() error {
+	sort.SliceStable(s.items, func(i, j int) bool { return s.items[i].less(&s.items[j]) })
+
+	f, err := createSpillFile(s.spiller)
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, f)
+
+	enc := NewBooleanPointEncoder(f)
+	for i := range s.items {
+		if err := enc.EncodeBooleanPoint(&s.items[i].point); err != nil {
+			return err
+		}
+	}
+	s.items, s.size = s.items[:0], 0
+	return nil
+}
+
+// iterator returns an iterator of the sorted points, merging the runs on disk
+// with the points held in memory. The runs are removed once it is closed.
+func (s *booleanExternalSort) iterator() (BooleanIterator, error) {
+	itr := &booleanSortMergeIterator{key: s.key}
+	for _, f := range s.runs {
+		r, err := newBooleanSpillReader(f)
+		if err != nil {
+			f.Close()
+			itr.Close()
+			return nil, err
+		}
+		itr.runs = append(itr.runs, r)
+	}
+	s.runs = nil
+
+	for i := range itr.runs {
+		if err := itr.read(i); err != nil {
+			itr.Close()
+			return nil, err
+		}
+	}
+
+	// The points held in memory are the last run, and are kept in the heap.
+	sort.SliceStable(s.items, func(i, j int) bool { return s.items[i].less(&s.items[j]) })
+	for i, item := range s.items {
+		itr.heap = append(itr.heap, booleanSortMergeHeapItem{item: item, run: len(itr.runs), seq: i})
+	}
+	s.items = nil
+	heap.Init(&itr.heap)
+	return itr, nil
+}
+
+// Close removes the runs spilled to disk.
+func (s *booleanExternalSort) Close() error {
+	for _, f := range s.runs {
+		f.Close()
+	}
+	s.runs, s.items = nil, nil
+	return nil
+}
+
+// booleanSortMergeIterator merges the sorted runs of an external sort.
+type booleanSortMergeIterator struct {
+	runs []*booleanSpillReader
+	heap booleanSortMergeHeap
+	key  func(p *BooleanPoint) string
+}
+
+// Stats returns an empty stats object, the stats of the sorted points were
+// reported by the iterators they were read from.
+func (itr *booleanSortMergeIterator) Stats() IteratorStats { return IteratorStats{} }
+
+// Close removes the runs.
+func (itr *booleanSortMergeIterator) Close() error {
+	for _, r := range itr.runs {
+		if r != nil {
+			r.Close()
+		}
+	}
+	itr.runs, itr.heap = nil, nil
+	return nil
+}
+
+// Next returns the next point in order.
+func (itr *booleanSortMergeIterator) Next() (*BooleanPoint, error) {
+	if len(itr.heap) == 0 {
+		return nil, nil
+	}
+
+	item := heap.Pop(&itr.heap).(booleanSortMergeHeapItem)
+	if item.run < len(itr.runs) {
+		if err := itr.read(item.run); err != nil {
+			return nil, err
+		}
+	}
+	return &item.item.point, nil
+}
+
+// read pushes the next point of a run on disk to the heap, or removes the
+// run once all its points were read.
+func (itr *booleanSortMergeIterator) read(run int) error {
+	p, err := itr.runs[run].Next()
+	if err != nil {
+		return err
+	} else if p == nil {
+		itr.runs[run].Close()
+		itr.runs[run] = nil
+		return nil
+	}
+	heap.Push(&itr.heap, booleanSortMergeHeapItem{
+		item: booleanSortItem{key: itr.key(p), point: *p},
+		run:  run,
+	})
+	return nil
+}
+
+// booleanSortMergeHeapItem is a point of a run. Points of the same time and
+// key are ordered by run, then by their position in the run.
+type booleanSortMergeHeapItem struct {
+	item booleanSortItem
+	run  int
+	seq  int
+}
+
+// booleanSortMergeHeap is a heap of the next points of the runs of an
+// external sort.
+type booleanSortMergeHeap []booleanSortMergeHeapItem
+
+func (h booleanSortMergeHeap) Len() int      { return len(h) }
+func (h booleanSortMergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h booleanSortMergeHeap) Less(i, j int) bool {
+	if h[i].item.less(&h[j].item) {
+		return true
+	} else if h[j].item.less(&h[i].item) {
+		return false
+	} else if h[i].run != h[j].run {
+		return h[i].run < h[j].run
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h *booleanSortMergeHeap) Push(x interface{}) {
+	*h = append(*h, x.(booleanSortMergeHeapItem))
+}
+
+func (h *booleanSortMergeHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
 // booleanReduceFloatIterator executes a reducer for every interval and buffers the result.
 type booleanReduceFloatIterator struct {
 	input    *bufBooleanIterator
@@ -11469,6 +15431,10 @@ type booleanReduceFloatIterator struct {
 	opt      IteratorOptions
 	points   []FloatPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled FloatIterator
 }
 
 func newBooleanReduceFloatIterator(input BooleanIterator, opt IteratorOptions, createFn func() (BooleanPointAggregator, FloatPointEmitter)) *booleanReduceFloatIterator {
@@ -11484,15 +15450,30 @@ func newBooleanReduceFloatIterator(input BooleanIterator, opt IteratorOptions, c
 func (itr *booleanReduceFloatIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *booleanReduceFloatIterator) Close() error { return itr.input.Close() }
+func (itr *booleanReduceFloatIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *booleanReduceFloatIterator) Next() (*FloatPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -11537,12 +15518,21 @@ func (itr *booleanReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*booleanReduceFloatPoint)
+	var (
+		size       int
+		partitions *booleanSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -11567,21 +15557,111 @@ func (itr *booleanReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		tags := curr.Tags.Subset(itr.dims)
 		id := tags.ID()
 
-		// Retrieve the aggregator for this name/tag combination or create one.
-		rp := m[id]
-		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &booleanReduceFloatPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+		// Retrieve the aggregator for this name/tag combination or create one.
+		rp := m[id]
+		if rp == nil {
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newBooleanSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
+			}
+			rp = itr.newGroup(curr.Name, tags)
+			m[id] = rp
+		}
+		rp.Aggregator.AggregateBoolean(curr)
+		size += booleanPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *booleanReduceFloatIterator) newGroup(name string, tags Tags) *booleanReduceFloatPoint {
+	aggregator, emitter := itr.create()
+	return &booleanReduceFloatPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *booleanReduceFloatIterator) reduceSpilled(m map[string]*booleanReduceFloatPoint, partitions *booleanSpillPartitions, startTime int64) (_ FloatIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newFloatExternalSort(itr.opt.Spiller, func(p *FloatPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*booleanReduceFloatPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*booleanReduceFloatPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
 			}
-			m[id] = rp
+			rp.Aggregator.AggregateBoolean(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
 		}
-		rp.Aggregator.AggregateBoolean(curr)
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *booleanReduceFloatIterator) emit(m map[string]*booleanReduceFloatPoint, startTime int64) []FloatPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -11618,7 +15698,7 @@ func (itr *booleanReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		sort.Stable(sort.Reverse(floatPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // booleanStreamFloatIterator streams inputs into the iterator and emits points gradually.
@@ -11749,6 +15829,10 @@ type booleanReduceIntegerIterator struct {
 	opt      IteratorOptions
 	points   []IntegerPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled IntegerIterator
 }
 
 func newBooleanReduceIntegerIterator(input BooleanIterator, opt IteratorOptions, createFn func() (BooleanPointAggregator, IntegerPointEmitter)) *booleanReduceIntegerIterator {
@@ -11764,15 +15848,30 @@ func newBooleanReduceIntegerIterator(input BooleanIterator, opt IteratorOptions,
 func (itr *booleanReduceIntegerIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *booleanReduceIntegerIterator) Close() error { return itr.input.Close() }
+func (itr *booleanReduceIntegerIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *booleanReduceIntegerIterator) Next() (*IntegerPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -11817,12 +15916,21 @@ func (itr *booleanReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*booleanReduceIntegerPoint)
+	var (
+		size       int
+		partitions *booleanSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -11850,18 +15958,108 @@ func (itr *booleanReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &booleanReduceIntegerPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newBooleanSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateBoolean(curr)
+		size += booleanPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *booleanReduceIntegerIterator) newGroup(name string, tags Tags) *booleanReduceIntegerPoint {
+	aggregator, emitter := itr.create()
+	return &booleanReduceIntegerPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *booleanReduceIntegerIterator) reduceSpilled(m map[string]*booleanReduceIntegerPoint, partitions *booleanSpillPartitions, startTime int64) (_ IntegerIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newIntegerExternalSort(itr.opt.Spiller, func(p *IntegerPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*booleanReduceIntegerPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*booleanReduceIntegerPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateBoolean(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *booleanReduceIntegerIterator) emit(m map[string]*booleanReduceIntegerPoint, startTime int64) []IntegerPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -11898,7 +16096,7 @@ func (itr *booleanReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		sort.Stable(sort.Reverse(integerPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // booleanStreamIntegerIterator streams inputs into the iterator and emits points gradually.
@@ -12029,6 +16227,10 @@ type booleanReduceUnsignedIterator struct {
 	opt      IteratorOptions
 	points   []UnsignedPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled UnsignedIterator
 }
 
 func newBooleanReduceUnsignedIterator(input BooleanIterator, opt IteratorOptions, createFn func() (BooleanPointAggregator, UnsignedPointEmitter)) *booleanReduceUnsignedIterator {
@@ -12044,15 +16246,30 @@ func newBooleanReduceUnsignedIterator(input BooleanIterator, opt IteratorOptions
 func (itr *booleanReduceUnsignedIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *booleanReduceUnsignedIterator) Close() error { return itr.input.Close() }
+func (itr *booleanReduceUnsignedIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *booleanReduceUnsignedIterator) Next() (*UnsignedPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -12097,12 +16314,21 @@ func (itr *booleanReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*booleanReduceUnsignedPoint)
+	var (
+		size       int
+		partitions *booleanSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -12130,18 +16356,108 @@ func (itr *booleanReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &booleanReduceUnsignedPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newBooleanSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateBoolean(curr)
+		size += booleanPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *booleanReduceUnsignedIterator) newGroup(name string, tags Tags) *booleanReduceUnsignedPoint {
+	aggregator, emitter := itr.create()
+	return &booleanReduceUnsignedPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *booleanReduceUnsignedIterator) reduceSpilled(m map[string]*booleanReduceUnsignedPoint, partitions *booleanSpillPartitions, startTime int64) (_ UnsignedIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newUnsignedExternalSort(itr.opt.Spiller, func(p *UnsignedPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*booleanReduceUnsignedPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*booleanReduceUnsignedPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateBoolean(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *booleanReduceUnsignedIterator) emit(m map[string]*booleanReduceUnsignedPoint, startTime int64) []UnsignedPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -12178,7 +16494,7 @@ func (itr *booleanReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		sort.Stable(sort.Reverse(unsignedPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // booleanStreamUnsignedIterator streams inputs into the iterator and emits points gradually.
@@ -12309,6 +16625,10 @@ type booleanReduceStringIterator struct {
 	opt      IteratorOptions
 	points   []StringPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled StringIterator
 }
 
 func newBooleanReduceStringIterator(input BooleanIterator, opt IteratorOptions, createFn func() (BooleanPointAggregator, StringPointEmitter)) *booleanReduceStringIterator {
@@ -12324,15 +16644,30 @@ func newBooleanReduceStringIterator(input BooleanIterator, opt IteratorOptions,
 func (itr *booleanReduceStringIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *booleanReduceStringIterator) Close() error { return itr.input.Close() }
+func (itr *booleanReduceStringIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *booleanReduceStringIterator) Next() (*StringPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -12377,12 +16712,21 @@ func (itr *booleanReduceStringIterator) reduce() ([]StringPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*booleanReduceStringPoint)
+	var (
+		size       int
+		partitions *booleanSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -12410,18 +16754,108 @@ func (itr *booleanReduceStringIterator) reduce() ([]StringPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &booleanReduceStringPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newBooleanSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateBoolean(curr)
+		size += booleanPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *booleanReduceStringIterator) newGroup(name string, tags Tags) *booleanReduceStringPoint {
+	aggregator, emitter := itr.create()
+	return &booleanReduceStringPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *booleanReduceStringIterator) reduceSpilled(m map[string]*booleanReduceStringPoint, partitions *booleanSpillPartitions, startTime int64) (_ StringIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newStringExternalSort(itr.opt.Spiller, func(p *StringPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*booleanReduceStringPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*booleanReduceStringPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateBoolean(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *booleanReduceStringIterator) emit(m map[string]*booleanReduceStringPoint, startTime int64) []StringPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -12458,7 +16892,7 @@ func (itr *booleanReduceStringIterator) reduce() ([]StringPoint, error) {
 		sort.Stable(sort.Reverse(stringPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // booleanStreamStringIterator streams inputs into the iterator and emits points gradually.
@@ -12589,6 +17023,10 @@ type booleanReduceBooleanIterator struct {
 	opt      IteratorOptions
 	points   []BooleanPoint
 	keepTags bool
+
+	// spilled are the sorted points of a window whose groups were spilled
+	// to disk.
+	spilled BooleanIterator
 }
 
 func newBooleanReduceBooleanIterator(input BooleanIterator, opt IteratorOptions, createFn func() (BooleanPointAggregator, BooleanPointEmitter)) *booleanReduceBooleanIterator {
@@ -12604,15 +17042,30 @@ func newBooleanReduceBooleanIterator(input BooleanIterator, opt IteratorOptions,
 func (itr *booleanReduceBooleanIterator) Stats() IteratorStats { return itr.input.Stats() }
 
 // Close closes the iterator and all child iterators.
-func (itr *booleanReduceBooleanIterator) Close() error { return itr.input.Close() }
+func (itr *booleanReduceBooleanIterator) Close() error {
+	if itr.spilled != nil {
+		itr.spilled.Close()
+	}
+	return itr.input.Close()
+}
 
 // Next returns the minimum value for the next available interval.
 func (itr *booleanReduceBooleanIterator) Next() (*BooleanPoint, error) {
 	// Calculate next window if we have no more points.
-	if len(itr.points) == 0 {
+	for len(itr.points) == 0 {
+		// Read the points of a window reduced on disk.
+		if itr.spilled != nil {
+			p, err := itr.spilled.Next()
+			if p != nil || err != nil {
+				return p, err
+			}
+			itr.spilled.Close()
+			itr.spilled = nil
+		}
+
 		var err error
 		itr.points, err = itr.reduce()
-		if len(itr.points) == 0 {
+		if len(itr.points) == 0 && itr.spilled == nil {
 			return nil, err
 		}
 	}
@@ -12657,12 +17110,21 @@ func (itr *booleanReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. Once the points aggregated by the groups held
+	// in memory exceed the spill threshold, the points of new groups are
+	// spilled to disk.
 	m := make(map[string]*booleanReduceBooleanPoint)
+	var (
+		size       int
+		partitions *booleanSpillPartitions
+	)
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
 		if err != nil {
+			if partitions != nil {
+				partitions.Close()
+			}
 			return nil, err
 		} else if curr == nil {
 			break
@@ -12690,18 +17152,108 @@ func (itr *booleanReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		// Retrieve the aggregator for this name/tag combination or create one.
 		rp := m[id]
 		if rp == nil {
-			aggregator, emitter := itr.create()
-			rp = &booleanReduceBooleanPoint{
-				Name:       curr.Name,
-				Tags:       tags,
-				Aggregator: aggregator,
-				Emitter:    emitter,
+			if partitions == nil && spillable(itr.opt.Spiller, size) {
+				partitions = newBooleanSpillPartitions(itr.opt.Spiller)
+			}
+			if partitions != nil {
+				if err := partitions.write(id, curr); err != nil {
+					partitions.Close()
+					return nil, err
+				}
+				continue
 			}
+			rp = itr.newGroup(curr.Name, tags)
 			m[id] = rp
 		}
 		rp.Aggregator.AggregateBoolean(curr)
+		size += booleanPointSize(curr)
+	}
+
+	if partitions != nil {
+		var err error
+		itr.spilled, err = itr.reduceSpilled(m, partitions, startTime)
+		return nil, err
+	}
+	return itr.emit(m, startTime), nil
+}
+
+// newGroup returns the aggregator of a name/tag combination.
+func (itr *booleanReduceBooleanIterator) newGroup(name string, tags Tags) *booleanReduceBooleanPoint {
+	aggregator, emitter := itr.create()
+	return &booleanReduceBooleanPoint{
+		Name:       name,
+		Tags:       tags,
+		Aggregator: aggregator,
+		Emitter:    emitter,
+	}
+}
+
+// reduceSpilled reduces the groups spilled to disk one partition at a time,
+// after the groups held in memory, and sorts their points on disk too.
+func (itr *booleanReduceBooleanIterator) reduceSpilled(m map[string]*booleanReduceBooleanPoint, partitions *booleanSpillPartitions, startTime int64) (_ BooleanIterator, err error) {
+	defer partitions.Close()
+
+	sorter := newBooleanExternalSort(itr.opt.Spiller, func(p *BooleanPoint) string {
+		return p.Tags.Subset(itr.dims).ID()
+	})
+	defer func() {
+		if err != nil {
+			sorter.Close()
+		}
+	}()
+
+	// Add the points in the order they are emitted from memory.
+	add := func(m map[string]*booleanReduceBooleanPoint) error {
+		a := itr.emit(m, startTime)
+		for i := len(a) - 1; i >= 0; i-- {
+			if err := sorter.add(&a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(m); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < spillPartitionN; i++ {
+		r, err := partitions.open(i)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			continue
+		}
+
+		m := make(map[string]*booleanReduceBooleanPoint)
+		for {
+			curr, err := r.Next()
+			if err != nil {
+				r.Close()
+				return nil, err
+			} else if curr == nil {
+				break
+			}
+
+			tags := curr.Tags.Subset(itr.dims)
+			id := tags.ID()
+			rp := m[id]
+			if rp == nil {
+				rp = itr.newGroup(curr.Name, tags)
+				m[id] = rp
+			}
+			rp.Aggregator.AggregateBoolean(curr)
+		}
+		r.Close()
+
+		if err := add(m); err != nil {
+			return nil, err
+		}
 	}
+	return sorter.iterator()
+}
 
+// emit returns the points of the groups in the reverse order they are read.
+func (itr *booleanReduceBooleanIterator) emit(m map[string]*booleanReduceBooleanPoint, startTime int64) []BooleanPoint {
 	// Reverse sort points by name & tag if our output is supposed to be ordered.
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -12738,7 +17290,7 @@ func (itr *booleanReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		sort.Stable(sort.Reverse(booleanPointsByTime(a)))
 	}
 
-	return a, nil
+	return a
 }
 
 // booleanStreamBooleanIterator streams inputs into the iterator and emits points gradually.
@@ -5,7 +5,9 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
+	"math"
 	"regexp"
 	"time"
 
@@ -426,6 +428,26 @@ func NewCloseInterruptIterator(input Iterator, closing <-chan struct{}) Iterator
 	}
 }
 
+// NewMemoryIterator returns an iterator that reports the memory allocated for
+// the points read from input to tracker. Next returns the tracker's error once
+// the query may not allocate more memory.
+func NewMemoryIterator(input Iterator, tracker MemoryTracker) Iterator {
+	switch input := input.(type) {
+	case FloatIterator:
+		return newFloatMemoryIterator(input, tracker)
+	case IntegerIterator:
+		return newIntegerMemoryIterator(input, tracker)
+	case UnsignedIterator:
+		return newUnsignedMemoryIterator(input, tracker)
+	case StringIterator:
+		return newStringMemoryIterator(input, tracker)
+	case BooleanIterator:
+		return newBooleanMemoryIterator(input, tracker)
+	default:
+		panic(fmt.Sprintf("unsupported memory iterator type: %T", input))
+	}
+}
+
 // IteratorScanner is used to scan the results of an iterator into a map.
 type IteratorScanner interface {
 	// Peek retrieves information about the next point. It returns a timestamp, the name, and the tags.
@@ -596,6 +618,9 @@ type IteratorOptions struct {
 	// Limits the number of series.
 	SLimit, SOffset int
 
+	// The fraction of the series selected. All series are selected if zero.
+	SampleSeries float64
+
 	// Removes the measurement name. Useful for meta queries.
 	StripName bool
 
@@ -614,6 +639,10 @@ type IteratorOptions struct {
 
 	// Authorizer can limit access to data
 	Authorizer Authorizer
+
+	// Spiller provides the disk space operators spill their points to once
+	// they hold too many in memory. If nil, operators do not spill.
+	Spiller Spiller
 }
 
 // newIteratorOptionsStmt creates the iterator options from stmt.
@@ -652,6 +681,9 @@ func newIteratorOptionsStmt(stmt *influxql.SelectStatement, sopt SelectOptions)
 		}
 	}
 	opt.Interval.Duration = interval
+	if interval > 0 {
+		opt.Interval.Months = stmt.GroupByMonths()
+	}
 
 	// Always request an ordered output for the top level iterators.
 	// The emitter will always emit points as ordered.
@@ -680,6 +712,7 @@ func newIteratorOptionsStmt(stmt *influxql.SelectStatement, sopt SelectOptions)
 	}
 	opt.Limit, opt.Offset = stmt.Limit, stmt.Offset
 	opt.SLimit, opt.SOffset = stmt.SLimit, stmt.SOffset
+	opt.SampleSeries = stmt.SampleSeries
 	opt.MaxSeriesN = sopt.MaxSeriesN
 	opt.Authorizer = sopt.Authorizer
 
@@ -712,6 +745,7 @@ func newIteratorOptionsSubstatement(ctx context.Context, stmt *influxql.SelectSt
 		subOpt.GroupBy[d] = struct{}{}
 	}
 	subOpt.InterruptCh = opt.InterruptCh
+	subOpt.Spiller = opt.Spiller
 
 	// Extract the time range and condition from the condition.
 	cond, t, err := influxql.ConditionExpr(stmt.Condition, nil)
@@ -732,6 +766,12 @@ func newIteratorOptionsSubstatement(ctx context.Context, stmt *influxql.SelectSt
 	subOpt.SLimit += opt.SLimit
 	subOpt.SOffset += opt.SOffset
 
+	// Propagate the series sampling from the outer query. The series selected
+	// by the smaller fraction are a subset of the ones of the larger.
+	if opt.SampleSeries > 0 && (subOpt.SampleSeries == 0 || opt.SampleSeries < subOpt.SampleSeries) {
+		subOpt.SampleSeries = opt.SampleSeries
+	}
+
 	// Propagate the ordering from the parent query.
 	subOpt.Ascending = opt.Ascending
 
@@ -775,6 +815,25 @@ func (opt IteratorOptions) SeekTime() int64 {
 	return opt.EndTime
 }
 
+// SeriesSampled returns true if the series with the key is selected by the
+// series sampling. The selection only depends on the key, so the same series
+// are selected on every shard and node.
+func (opt IteratorOptions) SeriesSampled(key string) bool {
+	if opt.SampleSeries <= 0 || opt.SampleSeries >= 1 {
+		return true
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(key))
+
+	// Mix the bits of the hash so that similar keys are evenly distributed.
+	x := h.Sum64()
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x ^= x >> 31
+	return float64(x) < opt.SampleSeries*math.MaxUint64
+}
+
 // StopTime returns the time the iterator should end at.
 // For ascending iterators this is the end time, for descending iterators it's the start time.
 func (opt IteratorOptions) StopTime() int64 {
@@ -788,6 +847,8 @@ func (opt IteratorOptions) StopTime() int64 {
 func (opt IteratorOptions) Window(t int64) (start, end int64) {
 	if opt.Interval.IsZero() {
 		return opt.StartTime, opt.EndTime + 1
+	} else if opt.Interval.Months > 0 {
+		return opt.calendarWindow(t)
 	}
 
 	// Subtract the offset to the time so we calculate the correct base interval.
@@ -863,6 +924,37 @@ func (opt IteratorOptions) Window(t int64) (start, end int64) {
 	return
 }
 
+// calendarWindow returns the window of a calendar interval that t falls
+// within. Windows start at midnight on the first day of a month in the
+// location of the query, and months are counted from January 1970.
+func (opt IteratorOptions) calendarWindow(t int64) (start, end int64) {
+	loc := opt.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	// Find the number of months since the epoch and truncate it to the interval.
+	t -= int64(opt.Interval.Offset)
+	tm := time.Unix(0, t).In(loc)
+	months := (tm.Year()-1970)*12 + int(tm.Month()) - int(time.January)
+	months -= ((months % opt.Interval.Months) + opt.Interval.Months) % opt.Interval.Months
+
+	first := time.Date(1970, time.January+time.Month(months), 1, 0, 0, 0, 0, loc)
+	last := time.Date(1970, time.January+time.Month(months+opt.Interval.Months), 1, 0, 0, 0, 0, loc)
+
+	if first.Before(time.Unix(0, influxql.MinTime)) {
+		start = influxql.MinTime
+	} else {
+		start = first.UnixNano() + int64(opt.Interval.Offset)
+	}
+	if last.After(time.Unix(0, influxql.MaxTime)) {
+		end = influxql.MaxTime
+	} else {
+		end = last.UnixNano() + int64(opt.Interval.Offset)
+	}
+	return start, end
+}
+
 // DerivativeInterval returns the time interval for the derivative function.
 func (opt IteratorOptions) DerivativeInterval() Interval {
 	// Use the interval on the derivative() call, if specified.
@@ -898,6 +990,30 @@ func (opt IteratorOptions) IntegralInterval() Interval {
 	return Interval{Duration: time.Second}
 }
 
+// RateInterval returns the time unit of the rate function.
+func (opt IteratorOptions) RateInterval() Interval {
+	// Use the unit on the rate() call, if specified.
+	if expr, ok := opt.Expr.(*influxql.Call); ok && len(expr.Args) >= 2 {
+		if unit, ok := expr.Args[1].(*influxql.DurationLiteral); ok {
+			return Interval{Duration: unit.Val}
+		}
+	}
+
+	return Interval{Duration: time.Second}
+}
+
+// RateResetMode returns the counter reset mode of the rate function.
+func (opt IteratorOptions) RateResetMode() string {
+	// Use the mode on the rate() call, if specified.
+	if expr, ok := opt.Expr.(*influxql.Call); ok && len(expr.Args) >= 2 {
+		if mode, ok := expr.Args[len(expr.Args)-1].(*influxql.StringLiteral); ok {
+			return mode.Val
+		}
+	}
+
+	return RateResetCounter
+}
+
 // GetDimensions retrieves the dimensions for this query.
 func (opt IteratorOptions) GetDimensions() []string {
 	if len(opt.GroupBy) > 0 {
@@ -960,6 +1076,11 @@ func encodeIteratorOptions(opt *IteratorOptions) *internal.IteratorOptions {
 		Ordered:    proto.Bool(opt.Ordered),
 	}
 
+	// Set the series sampling, if set.
+	if opt.SampleSeries > 0 {
+		pb.SampleSeries = proto.Float64(opt.SampleSeries)
+	}
+
 	// Set expression, if set.
 	if opt.Expr != nil {
 		pb.Expr = proto.String(opt.Expr.String())
@@ -1029,6 +1150,7 @@ func decodeIteratorOptions(pb *internal.IteratorOptions) (*IteratorOptions, erro
 		MaxSeriesN: int(pb.GetMaxSeriesN()),
 		Ordered:    pb.GetOrdered(),
 	}
+	opt.SampleSeries = pb.GetSampleSeries()
 
 	// Set expression, if set.
 	if pb.Expr != nil {
@@ -1137,22 +1259,31 @@ func decodeMeasurement(pb *internal.Measurement) (*influxql.Measurement, error)
 type Interval struct {
 	Duration time.Duration
 	Offset   time.Duration
+
+	// Months is the number of calendar months in each window of a calendar
+	// interval. Duration is then the longest a window can be.
+	Months int
 }
 
 // IsZero returns true if the interval has no duration.
 func (i Interval) IsZero() bool { return i.Duration == 0 }
 
 func encodeInterval(i Interval) *internal.Interval {
-	return &internal.Interval{
+	pb := &internal.Interval{
 		Duration: proto.Int64(i.Duration.Nanoseconds()),
 		Offset:   proto.Int64(i.Offset.Nanoseconds()),
 	}
+	if i.Months > 0 {
+		pb.Months = proto.Int64(int64(i.Months))
+	}
+	return pb
 }
 
 func decodeInterval(pb *internal.Interval) Interval {
 	return Interval{
 		Duration: time.Duration(pb.GetDuration()),
 		Offset:   time.Duration(pb.GetOffset()),
+		Months:   int(pb.GetMonths()),
 	}
 }
 
@@ -3,7 +3,10 @@ package query_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
@@ -1113,6 +1116,120 @@ func (itrs Iterators) ReadAll() ([][]query.Point, error) {
 	return a, nil
 }
 
+// Ensure memory iterators report allocated memory and stop on a tracker error.
+func TestMemoryIterator(t *testing.T) {
+	points := make([]query.FloatPoint, 10000)
+	for i := range points {
+		points[i] = query.FloatPoint{Name: "cpu", Time: int64(i), Value: float64(i)}
+	}
+
+	var allocated int
+	tracker := memoryTrackerFunc(func(n int) error {
+		allocated += n
+		if allocated > 512*1024 {
+			return errors.New("out of memory")
+		}
+		return nil
+	})
+
+	itr := query.NewMemoryIterator(&FloatIterator{Points: points}, tracker).(query.FloatIterator)
+	var n int
+	for {
+		p, err := itr.Next()
+		if err != nil {
+			if err.Error() != "out of memory" {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			break
+		} else if p == nil {
+			t.Fatal("expected memory limit to be exceeded")
+		}
+		n++
+	}
+
+	if n == 0 || n == len(points) {
+		t.Fatalf("unexpected point count: %d", n)
+	} else if allocated <= 512*1024 {
+		t.Fatalf("unexpected allocation: %d", allocated)
+	}
+}
+
+// Ensure a reduce iterator spilling its groups to disk returns the same
+// points as one reducing them in memory, and removes its spill files.
+func TestReduceIterator_Spill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "query-spill-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Interleave the points of many groups in a single window, as in a
+	// subquery grouping by a high-cardinality tag.
+	var points []query.FloatPoint
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 1000; j++ {
+			points = append(points, query.FloatPoint{
+				Name:  "cpu",
+				Tags:  ParseTags(fmt.Sprintf("host=host%04d", (j*7)%1000)),
+				Time:  int64(i),
+				Value: float64(i + j),
+			})
+		}
+	}
+
+	read := func(t *testing.T, expr string, spiller query.Spiller) [][]query.Point {
+		itr, err := query.NewCallIterator(&FloatIterator{Points: points}, query.IteratorOptions{
+			Expr:      MustParseExpr(expr),
+			GroupBy:   map[string]struct{}{"host": {}},
+			Ordered:   true,
+			Ascending: true,
+			Spiller:   spiller,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := Iterators([]query.Iterator{itr}).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+
+	for _, expr := range []string{`sum("value")`, `count("value")`, `first("value")`} {
+		t.Run(expr, func(t *testing.T) {
+			spiller := &spiller{threshold: 4096, dir: dir}
+			exp, got := read(t, expr, nil), read(t, expr, spiller)
+			if len(exp) != 1000 {
+				t.Fatalf("unexpected point count: %d", len(exp))
+			} else if !deep.Equal(exp, got) {
+				t.Fatalf("unexpected points:\n\nexp=%s\n\ngot=%s\n\n", spew.Sdump(exp[:3]), spew.Sdump(got[:3]))
+			} else if spiller.n == 0 {
+				t.Fatal("expected points to be spilled")
+			}
+
+			if files, err := ioutil.ReadDir(dir); err != nil {
+				t.Fatal(err)
+			} else if len(files) != 0 {
+				t.Fatalf("unexpected spill files: %d", len(files))
+			}
+		})
+	}
+}
+
+type spiller struct {
+	threshold int
+	dir       string
+	n         int
+}
+
+func (s *spiller) SpillThreshold() int { return s.threshold }
+func (s *spiller) SpillDir() string    { return s.dir }
+func (s *spiller) Spilled(n int)       { s.n += n }
+
+type memoryTrackerFunc func(n int) error
+
+func (fn memoryTrackerFunc) Allocate(n int) error { return fn(n) }
+
 func TestIteratorOptions_Window_Interval(t *testing.T) {
 	opt := query.IteratorOptions{
 		Interval: query.Interval{
@@ -1419,6 +1536,44 @@ func TestIteratorOptions_IntegralInterval_Default(t *testing.T) {
 	}
 }
 
+// Ensure series sampling selects the same subset of series every time.
+func TestIteratorOptions_SeriesSampled(t *testing.T) {
+	stmt, err := influxql.ParseStatement(`SELECT value FROM cpu sample_series(0.25)`)
+	if err != nil {
+		t.Fatal(err)
+	} else if s := stmt.String(); s != `SELECT value FROM cpu sample_series(0.25)` {
+		t.Fatalf("unexpected statement: %s", s)
+	}
+	opt := query.IteratorOptions{SampleSeries: stmt.(*influxql.SelectStatement).SampleSeries}
+
+	var n int
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("cpu,host=server%d", i)
+		sampled := opt.SeriesSampled(key)
+		if sampled != opt.SeriesSampled(key) {
+			t.Fatalf("series %s sampled inconsistently", key)
+		} else if !(query.IteratorOptions{}).SeriesSampled(key) {
+			t.Fatalf("series %s not selected without sampling", key)
+		}
+		if sampled {
+			n++
+		}
+	}
+	if n < 2300 || n > 2700 {
+		t.Fatalf("unexpected number of sampled series: %d", n)
+	}
+
+	for _, s := range []string{
+		`SELECT value FROM cpu sample_series(0)`,
+		`SELECT value FROM cpu sample_series(1.5)`,
+		`SELECT value FROM cpu sample_series('a')`,
+	} {
+		if _, err := influxql.ParseStatement(s); err == nil {
+			t.Fatalf("expected error parsing %s", s)
+		}
+	}
+}
+
 // Ensure iterator options can be marshaled to and from a binary format.
 func TestIteratorOptions_MarshalBinary(t *testing.T) {
 	opt := &query.IteratorOptions{
@@ -3,16 +3,37 @@ package query
 import (
 	"fmt"
 	"math"
+	"strconv"
 
 	"github.com/freetsdb/freetsdb/services/influxql"
 )
 
 func isMathFunction(call *influxql.Call) bool {
 	switch call.Name {
-	case "abs", "sin", "cos", "tan", "asin", "acos", "atan", "atan2", "exp", "log", "ln", "log2", "log10", "sqrt", "pow", "floor", "ceil", "round":
+	case "abs", "sin", "cos", "tan", "asin", "acos", "atan", "atan2", "exp", "log", "ln", "log2", "log10", "sqrt", "pow", "floor", "ceil", "round",
+		"clamp_min", "clamp_max", "int", "float", "string", "if":
 		return true
 	}
-	return false
+	return isStringFunction(call.Name)
+}
+
+// mathFunctionArgs returns the minimum and maximum number of arguments of the
+// math function. The maximum is negative if the number of arguments is not
+// limited.
+func mathFunctionArgs(name string) (min, max int) {
+	switch name {
+	case "atan2", "pow", "log", "clamp_min", "clamp_max":
+		return 2, 2
+	case "round":
+		return 1, 2
+	case "if", "replace":
+		return 3, 3
+	case "substr", "regex_extract":
+		return 2, 3
+	case "concat":
+		return 2, -1
+	}
+	return 1, 1
 }
 
 type MathTypeMapper struct{}
@@ -68,16 +89,96 @@ func (MathTypeMapper) CallType(name string, args []influxql.DataType) (influxql.
 			return influxql.Unknown, fmt.Errorf("invalid argument type for the second argument in %s(): %s", name, arg1)
 		}
 	case "abs", "floor", "ceil", "round":
-		var arg0 influxql.DataType
+		var arg0, arg1 influxql.DataType
+		if len(args) > 0 {
+			arg0 = args[0]
+		}
+		if len(args) > 1 {
+			arg1 = args[1]
+		}
+		switch arg0 {
+		case influxql.Float, influxql.Integer, influxql.Unsigned, influxql.Unknown:
+			// Pass through to verify the precision of round().
+		default:
+			return influxql.Unknown, fmt.Errorf("invalid argument type for the first argument in %s(): %s", name, arg0)
+		}
+
+		switch arg1 {
+		case influxql.Integer, influxql.Unknown:
+			return arg0, nil
+		default:
+			return influxql.Unknown, fmt.Errorf("invalid argument type for the second argument in %s(): %s", name, arg1)
+		}
+	case "clamp_min", "clamp_max":
+		var arg0, arg1 influxql.DataType
 		if len(args) > 0 {
 			arg0 = args[0]
 		}
+		if len(args) > 1 {
+			arg1 = args[1]
+		}
+
 		switch arg0 {
 		case influxql.Float, influxql.Integer, influxql.Unsigned, influxql.Unknown:
-			return args[0], nil
+			// Pass through to verify the second argument.
 		default:
 			return influxql.Unknown, fmt.Errorf("invalid argument type for the first argument in %s(): %s", name, arg0)
 		}
+
+		// The value keeps its type unless it is clamped by a bound of
+		// another type, in which case it is a float.
+		switch arg1 {
+		case arg0, influxql.Unknown:
+			return arg0, nil
+		case influxql.Float, influxql.Integer, influxql.Unsigned:
+			return influxql.Float, nil
+		default:
+			return influxql.Unknown, fmt.Errorf("invalid argument type for the second argument in %s(): %s", name, arg1)
+		}
+	case "int", "float", "string":
+		var arg0 influxql.DataType
+		if len(args) > 0 {
+			arg0 = args[0]
+		}
+		switch arg0 {
+		case influxql.Float, influxql.Integer, influxql.Unsigned, influxql.String, influxql.Boolean, influxql.Tag, influxql.Unknown:
+		default:
+			return influxql.Unknown, fmt.Errorf("invalid argument type for the first argument in %s(): %s", name, arg0)
+		}
+
+		switch name {
+		case "int":
+			return influxql.Integer, nil
+		case "float":
+			return influxql.Float, nil
+		default:
+			return influxql.String, nil
+		}
+	case "lower", "upper", "substr", "replace", "concat", "regex_extract":
+		return stringCallType(name, args)
+	case "if":
+		var arg0, arg1, arg2 influxql.DataType
+		if len(args) > 2 {
+			arg0, arg1, arg2 = args[0], args[1], args[2]
+		}
+		switch arg0 {
+		case influxql.Boolean, influxql.Unknown:
+		default:
+			return influxql.Unknown, fmt.Errorf("invalid argument type for the first argument in %s(): %s", name, arg0)
+		}
+
+		// Both results must have the same type, except for numbers of
+		// different types which are floats.
+		switch {
+		case arg1 == arg2 || arg2 == influxql.Unknown:
+			return arg1, nil
+		case arg1 == influxql.Unknown:
+			return arg2, nil
+		case isNumericType(arg1) && isNumericType(arg2):
+			return influxql.Float, nil
+		default:
+			return influxql.Unknown, fmt.Errorf("invalid argument types for the results in %s(): %s and %s", name, arg1, arg2)
+		}
 	}
 	return influxql.Unknown, nil
 }
@@ -91,6 +192,10 @@ func (MathValuer) Value(key string) (interface{}, bool) {
 }
 
 func (v MathValuer) Call(name string, args []interface{}) (interface{}, bool) {
+	if isStringFunction(name) {
+		return callString(name, args), true
+	}
+
 	if len(args) == 1 {
 		arg0 := args[0]
 		switch name {
@@ -185,6 +290,21 @@ func (v MathValuer) Call(name string, args []interface{}) (interface{}, bool) {
 				return math.Sqrt(arg0), true
 			}
 			return nil, true
+		case "int":
+			if arg0, ok := castInt(arg0); ok {
+				return arg0, true
+			}
+			return nil, true
+		case "float":
+			if arg0, ok := castFloat(arg0); ok {
+				return arg0, true
+			}
+			return nil, true
+		case "string":
+			if arg0, ok := castString(arg0); ok {
+				return arg0, true
+			}
+			return nil, true
 		}
 	} else if len(args) == 2 {
 		arg0, arg1 := args[0], args[1]
@@ -204,11 +324,41 @@ func (v MathValuer) Call(name string, args []interface{}) (interface{}, bool) {
 				return math.Pow(arg0, arg1), true
 			}
 			return nil, true
+		case "round":
+			digits, ok := arg1.(int64)
+			if !ok {
+				return nil, true
+			}
+			switch arg0 := arg0.(type) {
+			case float64:
+				return roundDigits(arg0, digits), true
+			case int64:
+				return roundIntegerDigits(arg0, digits), true
+			case uint64:
+				return roundUnsignedDigits(arg0, digits), true
+			default:
+				return nil, true
+			}
+		case "clamp_min", "clamp_max":
+			return clamp(arg0, arg1, name == "clamp_min"), true
+		}
+	} else if len(args) == 3 {
+		switch name {
+		case "if":
+			if cond, ok := args[0].(bool); ok && cond {
+				return args[1], true
+			}
+			return args[2], true
 		}
 	}
 	return nil, false
 }
 
+// isNumericType returns true if the values of the type are numbers.
+func isNumericType(typ influxql.DataType) bool {
+	return typ == influxql.Float || typ == influxql.Integer || typ == influxql.Unsigned
+}
+
 func asFloat(x interface{}) (float64, bool) {
 	switch arg0 := x.(type) {
 	case float64:
@@ -241,3 +391,175 @@ func round(x float64) float64 {
 	}
 	return t
 }
+
+// roundDigits rounds x to the number of decimal digits, which rounds to a
+// power of ten when negative.
+func roundDigits(x float64, digits int64) float64 {
+	if digits == 0 {
+		return round(x)
+	}
+	p := math.Pow10(int(digits))
+	if math.IsInf(p, 0) {
+		return x
+	} else if p == 0 {
+		return 0
+	}
+	return round(x*p) / p
+}
+
+// roundIntegerDigits rounds x to a power of ten when digits is negative. A
+// result beyond the range of an integer saturates to its limit.
+func roundIntegerDigits(x int64, digits int64) int64 {
+	if digits >= 0 {
+		return x
+	} else if digits < -18 {
+		// The power of ten does not fit, so only values at least half of
+		// it would round away from zero.
+		const half = 5e18
+		if x >= half {
+			return math.MaxInt64
+		} else if x <= -half {
+			return math.MinInt64
+		}
+		return 0
+	}
+	p := int64(math.Pow10(int(-digits)))
+	q, r := x/p, x%p
+	if r >= p-r {
+		q++
+	} else if -r >= p+r {
+		q--
+	}
+	if q > math.MaxInt64/p {
+		return math.MaxInt64
+	} else if q < math.MinInt64/p {
+		return math.MinInt64
+	}
+	return q * p
+}
+
+// roundUnsignedDigits rounds x to a power of ten when digits is negative. A
+// result beyond the range of an unsigned integer saturates to its limit.
+func roundUnsignedDigits(x uint64, digits int64) uint64 {
+	if digits >= 0 {
+		return x
+	} else if digits < -19 {
+		return 0
+	}
+	p := uint64(math.Pow10(int(-digits)))
+	q, r := x/p, x%p
+	if r >= p-r {
+		q++
+	}
+	if q > math.MaxUint64/p {
+		return math.MaxUint64
+	}
+	return q * p
+}
+
+// clamp returns x limited to be at least the bound if lower is true, or at
+// most the bound otherwise. The value keeps its type, unless the bound has
+// another type in which case it is a float.
+func clamp(x, bound interface{}, lower bool) interface{} {
+	switch x := x.(type) {
+	case int64:
+		if bound, ok := bound.(int64); ok {
+			if (lower && x < bound) || (!lower && x > bound) {
+				return bound
+			}
+			return x
+		}
+	case uint64:
+		if bound, ok := bound.(uint64); ok {
+			if (lower && x < bound) || (!lower && x > bound) {
+				return bound
+			}
+			return x
+		}
+	}
+
+	xf, bf, ok := asFloats(x, bound)
+	if !ok {
+		return nil
+	}
+	if (lower && xf < bf) || (!lower && xf > bf) {
+		return bf
+	}
+	return xf
+}
+
+// castInt converts x to an integer. Floats are truncated and strings parsed.
+// Values beyond the range of an integer saturate to its limit, and NaN has no
+// integer value.
+func castInt(x interface{}) (int64, bool) {
+	switch x := x.(type) {
+	case float64:
+		return floatToInt(x)
+	case int64:
+		return x, true
+	case uint64:
+		if x > math.MaxInt64 {
+			return math.MaxInt64, true
+		}
+		return int64(x), true
+	case bool:
+		if x {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		if v, err := strconv.ParseInt(x, 10, 64); err == nil {
+			return v, true
+		} else if v, err := strconv.ParseFloat(x, 64); err == nil {
+			return floatToInt(v)
+		}
+	}
+	return 0, false
+}
+
+// floatToInt truncates x to an integer, saturating to the limits of the type.
+func floatToInt(x float64) (int64, bool) {
+	switch {
+	case math.IsNaN(x):
+		return 0, false
+	case x >= math.MaxInt64:
+		return math.MaxInt64, true
+	case x <= math.MinInt64:
+		return math.MinInt64, true
+	}
+	return int64(x), true
+}
+
+// castFloat converts x to a float. Strings are parsed.
+func castFloat(x interface{}) (float64, bool) {
+	switch x := x.(type) {
+	case bool:
+		if x {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		if v, err := strconv.ParseFloat(x, 64); err == nil {
+			return v, true
+		}
+		return 0, false
+	}
+	return asFloat(x)
+}
+
+// castString formats x as a string.
+func castString(x interface{}) (string, bool) {
+	switch x := x.(type) {
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), true
+	case int64:
+		return strconv.FormatInt(x, 10), true
+	case uint64:
+		return strconv.FormatUint(x, 10), true
+	case bool:
+		return strconv.FormatBool(x), true
+	case string:
+		return x, true
+	}
+	return "", false
+}
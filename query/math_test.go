@@ -114,6 +114,24 @@ func TestMath_TypeMapper(t *testing.T) {
 		{s: `round(u::unsigned)`, typ: influxql.Unsigned},
 		{s: `round(s::string)`, err: true},
 		{s: `round(b::boolean)`, err: true},
+		{s: `round(f::float, 2)`, typ: influxql.Float},
+		{s: `round(i::integer, -2)`, typ: influxql.Integer},
+		{s: `round(f::float, 2.5)`, err: true},
+		{s: `clamp_min(f::float, 0)`, typ: influxql.Float},
+		{s: `clamp_min(i::integer, 0)`, typ: influxql.Integer},
+		{s: `clamp_max(i::integer, 0.5)`, typ: influxql.Float},
+		{s: `clamp_max(u::unsigned, 0)`, typ: influxql.Float},
+		{s: `clamp_max(s::string, 0)`, err: true},
+		{s: `clamp_max(f::float, s::string)`, err: true},
+		{s: `int(f::float)`, typ: influxql.Integer},
+		{s: `int(s::string)`, typ: influxql.Integer},
+		{s: `float(i::integer)`, typ: influxql.Float},
+		{s: `float(b::boolean)`, typ: influxql.Float},
+		{s: `string(f::float)`, typ: influxql.String},
+		{s: `if(b::boolean, 1, 0)`, typ: influxql.Integer},
+		{s: `if(f::float > 1, i::integer, f::float)`, typ: influxql.Float},
+		{s: `if(f::float, 1, 0)`, err: true},
+		{s: `if(b::boolean, s::string, 0)`, err: true},
 	} {
 		t.Run(tt.s, func(t *testing.T) {
 			expr := MustParseExpr(tt.s)
@@ -194,6 +212,39 @@ func TestMathValuer_Call(t *testing.T) {
 		{s: `pow(f, 2)`, values: values{"f": float64(4)}, exp: math.Pow(4, 2)},
 		{s: `pow(i, 2)`, values: values{"i": int64(4)}, exp: math.Pow(4, 2)},
 		{s: `pow(u, 2)`, values: values{"u": uint64(4)}, exp: math.Pow(4, 2)},
+		{s: `round(f, 2)`, values: values{"f": float64(3.14159)}, exp: float64(3.14)},
+		{s: `round(f, -1)`, values: values{"f": float64(-25)}, exp: float64(-30)},
+		{s: `round(i, 2)`, values: values{"i": int64(1250)}, exp: int64(1250)},
+		{s: `round(i, -2)`, values: values{"i": int64(1250)}, exp: int64(1300)},
+		{s: `round(i, -2)`, values: values{"i": int64(-1249)}, exp: int64(-1200)},
+		{s: `round(u, -1)`, values: values{"u": uint64(15)}, exp: uint64(20)},
+		{s: `round(i, -1)`, values: values{"i": int64(math.MaxInt64)}, exp: int64(math.MaxInt64)},
+		{s: `round(i, -1)`, values: values{"i": int64(math.MinInt64)}, exp: int64(math.MinInt64)},
+		{s: `round(i, -19)`, values: values{"i": int64(math.MaxInt64)}, exp: int64(math.MaxInt64)},
+		{s: `round(i, -19)`, values: values{"i": int64(4e18)}, exp: int64(0)},
+		{s: `round(u, -1)`, values: values{"u": uint64(math.MaxUint64)}, exp: uint64(math.MaxUint64)},
+		{s: `round(u, -19)`, values: values{"u": uint64(math.MaxUint64)}, exp: uint64(math.MaxUint64)},
+		{s: `clamp_min(f, 0)`, values: values{"f": float64(-2)}, exp: float64(0)},
+		{s: `clamp_min(i, 0)`, values: values{"i": int64(-2)}, exp: int64(0)},
+		{s: `clamp_min(i, 0)`, values: values{"i": int64(2)}, exp: int64(2)},
+		{s: `clamp_max(i, 0.5)`, values: values{"i": int64(2)}, exp: float64(0.5)},
+		{s: `clamp_max(i, 0.5)`, values: values{"i": int64(0)}, exp: float64(0)},
+		{s: `int(f)`, values: values{"f": float64(2.7)}, exp: int64(2)},
+		{s: `int(s)`, values: values{"s": "42"}, exp: int64(42)},
+		{s: `int(s)`, values: values{"s": "a"}, exp: nil},
+		{s: `int(b)`, values: values{"b": true}, exp: int64(1)},
+		{s: `int(f)`, values: values{"f": math.NaN()}, exp: nil},
+		{s: `int(f)`, values: values{"f": float64(1e30)}, exp: int64(math.MaxInt64)},
+		{s: `int(f)`, values: values{"f": math.Inf(-1)}, exp: int64(math.MinInt64)},
+		{s: `int(u)`, values: values{"u": uint64(math.MaxUint64)}, exp: int64(math.MaxInt64)},
+		{s: `int(s)`, values: values{"s": "1e30"}, exp: int64(math.MaxInt64)},
+		{s: `float(i)`, values: values{"i": int64(2)}, exp: float64(2)},
+		{s: `float(s)`, values: values{"s": "2.5"}, exp: float64(2.5)},
+		{s: `string(f)`, values: values{"f": float64(2.5)}, exp: "2.5"},
+		{s: `string(u)`, values: values{"u": uint64(3)}, exp: "3"},
+		{s: `string(b)`, values: values{"b": false}, exp: "false"},
+		{s: `if(f > 1, 'high', 'low')`, values: values{"f": float64(2)}, exp: "high"},
+		{s: `if(f > 1, 'high', 'low')`, values: values{"f": float64(1)}, exp: "low"},
 	} {
 		t.Run(tt.s, func(t *testing.T) {
 			expr := MustParseExpr(tt.s)
@@ -0,0 +1,143 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+// rewriteMeasurementFields rewrites a statement selecting the fields of
+// several measurements qualified by their measurement name, such as
+// "SELECT sum(hits.n) / sum(misses.n) FROM hits, misses", so that the
+// fields of all measurements share one namespace. Each measurement is read by
+// a subquery aliasing its fields with their qualified names and stripping the
+// measurement name, which merges the series of the measurements with the same
+// tags so that their windows are aligned.
+//
+// The statement is returned unchanged if it does not select qualified fields.
+func rewriteMeasurementFields(stmt *influxql.SelectStatement) (*influxql.SelectStatement, error) {
+	if len(stmt.Sources) < 2 {
+		return stmt, nil
+	}
+	var names []string
+	for _, src := range stmt.Sources {
+		mm, ok := src.(*influxql.Measurement)
+		if !ok || mm.Regex != nil || mm.Name == "" {
+			return stmt, nil
+		}
+		names = append(names, mm.Name)
+	}
+
+	// Find the measurement of every variable of the fields.
+	fields := make(map[string][]influxql.VarRef)
+	var unqualified []string
+	var wildcard bool
+	influxql.WalkFunc(stmt.Fields, func(n influxql.Node) {
+		switch n := n.(type) {
+		case *influxql.VarRef:
+			name := qualifyingMeasurement(n.Val, names)
+			if name == "" {
+				unqualified = append(unqualified, n.Val)
+				return
+			}
+			ref := influxql.VarRef{Val: n.Val[len(name)+1:], Type: n.Type}
+			for _, other := range fields[name] {
+				if other == ref {
+					return
+				}
+			}
+			fields[name] = append(fields[name], ref)
+		case *influxql.Wildcard, *influxql.RegexLiteral:
+			wildcard = true
+		}
+	})
+	if len(fields) == 0 {
+		return stmt, nil
+	} else if wildcard {
+		return nil, errors.New("wildcards cannot be mixed with fields qualified by their measurement")
+	} else if len(unqualified) > 0 {
+		return nil, fmt.Errorf("field %s must be qualified by its measurement", unqualified[0])
+	} else if stmt.IsRawQuery {
+		return nil, errors.New("fields of different measurements can only be combined by aggregates")
+	}
+
+	other := stmt.Clone()
+	other.Sources = make(influxql.Sources, 0, len(fields))
+	var emitNames []string
+	for i, name := range names {
+		refs := fields[name]
+		if len(refs) == 0 {
+			continue
+		}
+
+		sub := &influxql.SelectStatement{
+			Fields:     make(influxql.Fields, len(refs)),
+			Sources:    influxql.Sources{stmt.Sources[i].(*influxql.Measurement).Clone()},
+			Condition:  influxql.CloneExpr(stmt.Condition),
+			IsRawQuery: true,
+			StripName:  true,
+		}
+		for j := range refs {
+			sub.Fields[j] = &influxql.Field{
+				Expr:  &influxql.VarRef{Val: refs[j].Val, Type: refs[j].Type},
+				Alias: name + "." + refs[j].Val,
+			}
+		}
+		other.Sources = append(other.Sources, &influxql.SubQuery{Statement: sub})
+		emitNames = append(emitNames, name)
+	}
+	other.EmitName = strings.Join(emitNames, ",")
+
+	// The subqueries filter the series, so only the time range of the
+	// condition is kept.
+	other.Condition = timeCondition(other.Condition)
+	return other, nil
+}
+
+// timeCondition returns the comparisons of the time in the condition.
+func timeCondition(expr influxql.Expr) influxql.Expr {
+	switch expr := expr.(type) {
+	case *influxql.ParenExpr:
+		return timeCondition(expr.Expr)
+	case *influxql.BinaryExpr:
+		switch expr.Op {
+		case influxql.AND:
+			lhs, rhs := timeCondition(expr.LHS), timeCondition(expr.RHS)
+			if lhs == nil {
+				return rhs
+			} else if rhs == nil {
+				return lhs
+			}
+			return &influxql.BinaryExpr{Op: influxql.AND, LHS: lhs, RHS: rhs}
+		case influxql.OR:
+			lhs, rhs := timeCondition(expr.LHS), timeCondition(expr.RHS)
+			if lhs == nil || rhs == nil {
+				return nil
+			}
+			return &influxql.BinaryExpr{Op: influxql.OR, LHS: lhs, RHS: rhs}
+		}
+		if isTimeRef(expr.LHS) || isTimeRef(expr.RHS) {
+			return expr
+		}
+	}
+	return nil
+}
+
+// qualifyingMeasurement returns the longest of the measurement names
+// qualifying the variable, or an empty string if none does.
+func qualifyingMeasurement(val string, names []string) string {
+	var qualifier string
+	for _, name := range names {
+		if len(name) > len(qualifier) && strings.HasPrefix(val, name+".") {
+			qualifier = name
+		}
+	}
+	return qualifier
+}
+
+func isTimeRef(expr influxql.Expr) bool {
+	ref, ok := expr.(*influxql.VarRef)
+	return ok && strings.ToLower(ref.Val) == "time"
+}
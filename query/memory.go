@@ -0,0 +1,67 @@
+package query
+
+import (
+	"context"
+
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+const (
+	// pointOverhead is the estimated size in bytes of a point excluding its
+	// string value and auxiliary fields.
+	pointOverhead = 96
+
+	// memoryReportSize is the number of bytes an iterator allocates before
+	// reporting them to its MemoryTracker.
+	memoryReportSize = 64 * 1024
+)
+
+// MemoryTracker accounts for the memory allocated by a query.
+type MemoryTracker interface {
+	// Allocate records that the query allocated another n bytes. If the query
+	// may not allocate more memory, an error is returned and the query should
+	// be aborted with it.
+	Allocate(n int) error
+}
+
+// MemoryTrackerFromContext returns the MemoryTracker embedded within the
+// Context if one exists.
+func MemoryTrackerFromContext(ctx context.Context) MemoryTracker {
+	v, _ := ctx.Value(memoryTrackerContextKey).(MemoryTracker)
+	return v
+}
+
+// auxSize returns the estimated size in bytes of auxiliary field values.
+func auxSize(aux []interface{}) int {
+	n := 16 * len(aux)
+	for _, v := range aux {
+		if s, ok := v.(string); ok {
+			n += len(s)
+		}
+	}
+	return n
+}
+
+// memoryIteratorCreator reports the memory allocated by the points read from
+// its iterators to a MemoryTracker.
+//
+// Memory is counted as points are read, so the total is the number of bytes
+// the query has allocated rather than the number it currently holds. This
+// overestimates streaming queries but bounds those that buffer their input,
+// such as aggregates over many series.
+type memoryIteratorCreator struct {
+	IteratorCreator
+	tracker MemoryTracker
+}
+
+func newMemoryIteratorCreator(ic IteratorCreator, tracker MemoryTracker) IteratorCreator {
+	return &memoryIteratorCreator{IteratorCreator: ic, tracker: tracker}
+}
+
+func (ic *memoryIteratorCreator) CreateIterator(ctx context.Context, source *influxql.Measurement, opt IteratorOptions) (Iterator, error) {
+	itr, err := ic.IteratorCreator.CreateIterator(ctx, source, opt)
+	if err != nil || itr == nil {
+		return itr, err
+	}
+	return NewMemoryIterator(itr, ic.tracker), nil
+}
@@ -0,0 +1,53 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QueryPriority is the class a query is admitted in when the number of
+// concurrent queries is limited. Queued interactive queries are admitted
+// before queued batch queries.
+type QueryPriority int
+
+const (
+	// InteractivePriority is for latency-sensitive queries such as those
+	// from dashboards. It is the default.
+	InteractivePriority QueryPriority = iota
+
+	// BatchPriority is for queries such as exports that can wait, and may be
+	// limited to a share of the concurrent queries.
+	BatchPriority
+
+	queryPriorityN
+)
+
+func (p QueryPriority) String() string {
+	switch p {
+	case InteractivePriority:
+		return "interactive"
+	case BatchPriority:
+		return "batch"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseQueryPriority returns the priority with the given name. An empty name
+// is the interactive priority.
+func ParseQueryPriority(s string) (QueryPriority, error) {
+	switch strings.ToLower(s) {
+	case "", "interactive":
+		return InteractivePriority, nil
+	case "batch":
+		return BatchPriority, nil
+	default:
+		return 0, fmt.Errorf("unknown query priority %q: expected \"interactive\" or \"batch\"", s)
+	}
+}
+
+// queuedQuery is a query waiting in the admission queue.
+type queuedQuery struct {
+	priority QueryPriority
+	ready    chan struct{}
+}
@@ -0,0 +1,165 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+// Counter reset modes of the rate() function. A reset is a value lower than
+// the previous one.
+const (
+	// RateResetCounter treats a reset as a counter restarting from zero, so
+	// the increase across the reset is the value after it.
+	RateResetCounter = "counter"
+
+	// RateResetSkip ignores the increase across a reset.
+	RateResetSkip = "skip"
+
+	// RateResetNone does not detect resets, which gives the rate of a gauge.
+	RateResetNone = "none"
+)
+
+// isRateResetMode returns true if the mode is a counter reset mode.
+func isRateResetMode(mode string) bool {
+	switch mode {
+	case RateResetCounter, RateResetSkip, RateResetNone:
+		return true
+	}
+	return false
+}
+
+// rateSample is a point of a rate() window.
+type rateSample struct {
+	time  int64
+	value float64
+}
+
+// rateReducer calculates the per-unit rate of increase of the points of a
+// window.
+//
+// Like the rate() of Prometheus, the increase between the first and the last
+// points is extrapolated to the boundaries of the window if the points are
+// close enough to them, and the extrapolated increase of a counter never goes
+// below zero. The rate is then the increase over the duration of the window.
+// Outside of a window bounded in time, the rate is the increase between the
+// first and the last points over the time between them.
+type rateReducer struct {
+	unit    Interval
+	mode    string
+	opt     IteratorOptions
+	samples []rateSample
+}
+
+func newRateReducer(unit Interval, mode string, opt IteratorOptions) *rateReducer {
+	return &rateReducer{unit: unit, mode: mode, opt: opt}
+}
+
+// AggregateFloat aggregates a point into the reducer.
+func (r *rateReducer) AggregateFloat(p *FloatPoint) {
+	r.samples = append(r.samples, rateSample{time: p.Time, value: p.Value})
+}
+
+// AggregateInteger aggregates a point into the reducer.
+func (r *rateReducer) AggregateInteger(p *IntegerPoint) {
+	r.samples = append(r.samples, rateSample{time: p.Time, value: float64(p.Value)})
+}
+
+// AggregateUnsigned aggregates a point into the reducer.
+func (r *rateReducer) AggregateUnsigned(p *UnsignedPoint) {
+	r.samples = append(r.samples, rateSample{time: p.Time, value: float64(p.Value)})
+}
+
+// Emit emits the rate of the window, if it has at least two points.
+func (r *rateReducer) Emit() []FloatPoint {
+	if len(r.samples) < 2 {
+		return nil
+	}
+	sort.SliceStable(r.samples, func(i, j int) bool {
+		return r.samples[i].time < r.samples[j].time
+	})
+	first, last := r.samples[0], r.samples[len(r.samples)-1]
+	sampled := float64(last.time - first.time)
+	if sampled <= 0 {
+		return nil
+	}
+
+	var increase float64
+	for i := 1; i < len(r.samples); i++ {
+		delta := r.samples[i].value - r.samples[i-1].value
+		if delta < 0 {
+			switch r.mode {
+			case RateResetCounter:
+				delta = r.samples[i].value
+			case RateResetSkip:
+				delta = 0
+			}
+		}
+		increase += delta
+	}
+
+	start, end := r.opt.Window(first.time)
+	if start < r.opt.StartTime {
+		start = r.opt.StartTime
+	}
+	if end > r.opt.EndTime+1 {
+		end = r.opt.EndTime + 1
+	}
+	if start <= influxql.MinTime || end > influxql.MaxTime {
+		return []FloatPoint{{Time: ZeroTime, Value: increase * float64(r.unit.Duration) / sampled}}
+	}
+
+	// Extrapolate the increase to the boundaries of the window that are
+	// within 110% of the average interval between the points, and by half
+	// of the average interval otherwise.
+	durationToStart := float64(first.time - start)
+	durationToEnd := float64(end - last.time)
+	if r.mode != RateResetNone && increase > 0 && first.value >= 0 {
+		// A counter was zero at most this long before the first point.
+		if durationToZero := sampled * (first.value / increase); durationToZero < durationToStart {
+			durationToStart = durationToZero
+		}
+	}
+	average := sampled / float64(len(r.samples)-1)
+	threshold := average * 1.1
+	extrapolated := sampled
+	if durationToStart < threshold {
+		extrapolated += durationToStart
+	} else {
+		extrapolated += average / 2
+	}
+	if durationToEnd < threshold {
+		extrapolated += durationToEnd
+	} else {
+		extrapolated += average / 2
+	}
+	increase *= extrapolated / sampled
+	return []FloatPoint{{Time: ZeroTime, Value: increase * float64(r.unit.Duration) / float64(end-start)}}
+}
+
+// newRateIterator returns an iterator for operating on a rate() call.
+func newRateIterator(input Iterator, opt IteratorOptions, unit Interval, mode string) (Iterator, error) {
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, FloatPointEmitter) {
+			fn := newRateReducer(unit, mode, opt)
+			return fn, fn
+		}
+		return newFloatReduceFloatIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, FloatPointEmitter) {
+			fn := newRateReducer(unit, mode, opt)
+			return fn, fn
+		}
+		return newIntegerReduceFloatIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, FloatPointEmitter) {
+			fn := newRateReducer(unit, mode, opt)
+			return fn, fn
+		}
+		return newUnsignedReduceFloatIterator(input, opt, createFn), nil
+	default:
+		return nil, fmt.Errorf("unsupported rate iterator type: %T", input)
+	}
+}
@@ -12,6 +12,10 @@ import (
 const (
 	// WarningLevel is the message level for a warning.
 	WarningLevel = "warning"
+
+	// InfoLevel is the message level for information about how a statement
+	// was executed.
+	InfoLevel = "info"
 )
 
 // TagSet is a fundamental concept within the query system. It represents a composite series,
@@ -37,6 +37,14 @@ type SelectOptions struct {
 
 	// Maximum number of buckets for a statement.
 	MaxBucketsN int
+
+	// Maximum number of points to return for each series. Statements are
+	// aggregated over an interval chosen to return no more points.
+	MaxPointsPerSeries int
+
+	// SystemColumns adds the _shard, _replica and _ingest_time columns to raw
+	// queries.
+	SystemColumns bool
 }
 
 // ShardMapper retrieves and maps shards into an IteratorCreator that can later be
@@ -116,9 +124,23 @@ func (p *preparedStatement) Select(ctx context.Context) (Cursor, error) {
 
 	opt := p.opt
 	opt.InterruptCh = ctx.Done()
-	cur, err := buildCursor(ctx, p.stmt, p.ic, opt)
+
+	// Account for the memory allocated by the query if it is tracked.
+	var ic IteratorCreator = p.ic
+	if tracker := MemoryTrackerFromContext(ctx); tracker != nil {
+		ic = newMemoryIteratorCreator(ic, tracker)
+	}
+
+	// Spill the groups of huge aggregates to disk if the query may.
+	if spiller := SpillerFromContext(ctx); spiller != nil && spiller.SpillThreshold() > 0 {
+		opt.Spiller = spiller
+	}
+
+	cur, err := buildCursor(ctx, p.stmt, ic, opt)
 	if err != nil {
 		return nil, err
+	} else if p.stmt.EmitName != "" {
+		cur = &nameCursor{Cursor: cur, name: p.stmt.EmitName}
 	}
 
 	// If a monitor exists and we are told there is a maximum number of points,
@@ -253,6 +275,42 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 		opt.Interval = Interval{}
 
 		return newHoltWintersIterator(input, opt, int(h.Val), int(m.Val), includeFitData, interval)
+	case "anomaly_mad", "anomaly_esd":
+		opt.Ordered = true
+		input, err := buildExprIterator(ctx, expr.Args[0], b.ic, b.sources, opt, b.selector, false)
+		if err != nil {
+			return nil, err
+		}
+		return newAnomalyIterator(input, opt, expr)
+	case "forecast", "forecast_lower", "forecast_upper":
+		opt.Ordered = true
+		input, err := buildExprIterator(ctx, forecastInput(expr), b.ic, b.sources, opt, b.selector, false)
+		if err != nil {
+			return nil, err
+		}
+
+		interval := opt.Interval.Duration
+		horizon := expr.Args[1].(*influxql.DurationLiteral).Val
+		h := int((horizon + interval - 1) / interval)
+		var season int
+		if len(expr.Args) > 2 {
+			season = int(expr.Args[2].(*influxql.IntegerLiteral).Val)
+		}
+
+		var band float64
+		switch expr.Name {
+		case "forecast_lower":
+			band = -1
+		case "forecast_upper":
+			band = 1
+		}
+
+		// Redefine interval to be unbounded to capture all aggregate results
+		opt.StartTime = influxql.MinTime
+		opt.EndTime = influxql.MaxTime
+		opt.Interval = Interval{}
+
+		return newForecastIterator(input, opt, h, season, interval, band, ForecastModelName(expr), ForecastModelsFromContext(ctx))
 	case "derivative", "non_negative_derivative", "difference", "non_negative_difference", "moving_average", "exponential_moving_average", "double_exponential_moving_average", "triple_exponential_moving_average", "relative_strength_index", "triple_exponential_derivative", "kaufmans_efficiency_ratio", "kaufmans_adaptive_moving_average", "chande_momentum_oscillator", "elapsed":
 		if !opt.Interval.IsZero() {
 			if opt.Ascending {
@@ -387,6 +445,13 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 		}
 		interval := opt.IntegralInterval()
 		return newIntegralIterator(input, opt, interval)
+	case "rate":
+		opt.Ordered = true
+		input, err := buildExprIterator(ctx, expr.Args[0].(*influxql.VarRef), b.ic, b.sources, opt, false, false)
+		if err != nil {
+			return nil, err
+		}
+		return newRateIterator(input, opt, opt.RateInterval(), opt.RateResetMode())
 	case "top":
 		if len(expr.Args) < 2 {
 			return nil, fmt.Errorf("top() requires 2 or more arguments, got %d", len(expr.Args))
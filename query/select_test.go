@@ -2563,6 +2563,59 @@ func TestSelect(t *testing.T) {
 				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(125)}},
 			},
 		},
+		{
+			name: "Rate_Float",
+			q:    `SELECT rate(value) FROM cpu WHERE time >= 0s AND time < 20s GROUP BY time(10s)`,
+			typ:  influxql.Float,
+			itrs: []query.Iterator{
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Time: 1 * Second, Value: 10},
+					{Name: "cpu", Time: 5 * Second, Value: 50},
+					{Name: "cpu", Time: 9 * Second, Value: 90},
+					{Name: "cpu", Time: 11 * Second, Value: 20},
+					{Name: "cpu", Time: 15 * Second, Value: 4},
+					{Name: "cpu", Time: 19 * Second, Value: 36},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(10)}},
+				{Time: 10 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(4.5)}},
+			},
+		},
+		{
+			name: "Rate_Skip_Integer",
+			q:    `SELECT rate(value, 1m, 'skip') FROM cpu WHERE time >= 0s AND time < 20s GROUP BY time(10s)`,
+			typ:  influxql.Integer,
+			itrs: []query.Iterator{
+				&IntegerIterator{Points: []query.IntegerPoint{
+					{Name: "cpu", Time: 1 * Second, Value: 10},
+					{Name: "cpu", Time: 5 * Second, Value: 50},
+					{Name: "cpu", Time: 9 * Second, Value: 90},
+					{Name: "cpu", Time: 11 * Second, Value: 20},
+					{Name: "cpu", Time: 15 * Second, Value: 4},
+					{Name: "cpu", Time: 19 * Second, Value: 36},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(600)}},
+				{Time: 10 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(240)}},
+			},
+		},
+		{
+			name: "Rate_Unbounded_Unsigned",
+			q:    `SELECT rate(value) FROM cpu WHERE time >= 0s`,
+			typ:  influxql.Unsigned,
+			itrs: []query.Iterator{
+				&UnsignedIterator{Points: []query.UnsignedPoint{
+					{Name: "cpu", Time: 0 * Second, Value: 10},
+					{Name: "cpu", Time: 5 * Second, Value: 20},
+					{Name: "cpu", Time: 10 * Second, Value: 40},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(3)}},
+			},
+		},
 		{
 			name: "MovingAverage_Float",
 			q:    `SELECT moving_average(value, 2) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:16Z'`,
@@ -2806,6 +2859,81 @@ func TestSelect(t *testing.T) {
 			},
 			now: mustParseTime("1970-01-01T00:02:30Z"),
 		},
+		{
+			name: "GroupByTime_TimeZone_DST",
+			q:    `SELECT count(value) FROM cpu WHERE time >= '2019-03-09T05:00:00Z' AND time < '2019-03-12T04:00:00Z' GROUP BY time(1d) tz('America/New_York')`,
+			typ:  influxql.Float,
+			expr: `count(value::float)`,
+			itrs: []query.Iterator{
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Time: mustParseTime("2019-03-09T05:00:00Z").UnixNano(), Value: 1},
+					{Name: "cpu", Time: mustParseTime("2019-03-10T04:59:00Z").UnixNano(), Value: 2},
+					{Name: "cpu", Time: mustParseTime("2019-03-10T05:00:00Z").UnixNano(), Value: 3},
+					{Name: "cpu", Time: mustParseTime("2019-03-11T03:59:00Z").UnixNano(), Value: 4},
+					{Name: "cpu", Time: mustParseTime("2019-03-11T04:00:00Z").UnixNano(), Value: 5},
+				}},
+			},
+			rows: []query.Row{
+				{Time: mustParseTime("2019-03-09T05:00:00Z").UnixNano(), Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(2)}},
+				{Time: mustParseTime("2019-03-10T05:00:00Z").UnixNano(), Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(2)}},
+				{Time: mustParseTime("2019-03-11T04:00:00Z").UnixNano(), Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(1)}},
+			},
+		},
+		{
+			name: "GroupByTime_TimeZoneArgument_DST",
+			q:    `SELECT count(value) FROM cpu WHERE time >= '2019-03-09T05:00:00Z' AND time < '2019-03-12T04:00:00Z' GROUP BY time(1d, tz('America/New_York'))`,
+			typ:  influxql.Float,
+			expr: `count(value::float)`,
+			itrs: []query.Iterator{
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Time: mustParseTime("2019-03-09T05:00:00Z").UnixNano(), Value: 1},
+					{Name: "cpu", Time: mustParseTime("2019-03-10T04:59:00Z").UnixNano(), Value: 2},
+					{Name: "cpu", Time: mustParseTime("2019-03-10T05:00:00Z").UnixNano(), Value: 3},
+					{Name: "cpu", Time: mustParseTime("2019-03-11T03:59:00Z").UnixNano(), Value: 4},
+					{Name: "cpu", Time: mustParseTime("2019-03-11T04:00:00Z").UnixNano(), Value: 5},
+				}},
+			},
+			rows: []query.Row{
+				{Time: mustParseTime("2019-03-09T05:00:00Z").UnixNano(), Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(2)}},
+				{Time: mustParseTime("2019-03-10T05:00:00Z").UnixNano(), Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(2)}},
+				{Time: mustParseTime("2019-03-11T04:00:00Z").UnixNano(), Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(1)}},
+			},
+		},
+		{
+			name: "GroupByTime_Months",
+			q:    `SELECT count(value) FROM cpu WHERE time >= '2019-01-01T00:00:00Z' AND time < '2019-04-01T00:00:00Z' GROUP BY time(1mo)`,
+			typ:  influxql.Float,
+			expr: `count(value::float)`,
+			itrs: []query.Iterator{
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Time: mustParseTime("2019-01-05T00:00:00Z").UnixNano(), Value: 1},
+					{Name: "cpu", Time: mustParseTime("2019-01-31T23:59:00Z").UnixNano(), Value: 2},
+					{Name: "cpu", Time: mustParseTime("2019-03-31T00:00:00Z").UnixNano(), Value: 3},
+				}},
+			},
+			rows: []query.Row{
+				{Time: mustParseTime("2019-01-01T00:00:00Z").UnixNano(), Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(2)}},
+				{Time: mustParseTime("2019-02-01T00:00:00Z").UnixNano(), Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(0)}},
+				{Time: mustParseTime("2019-03-01T00:00:00Z").UnixNano(), Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(1)}},
+			},
+		},
+		{
+			name: "GroupByTime_Quarters_TimeZone",
+			q:    `SELECT count(value) FROM cpu WHERE time >= '2019-01-01T05:00:00Z' AND time < '2019-07-01T04:00:00Z' GROUP BY time(1q) tz('America/New_York')`,
+			typ:  influxql.Float,
+			expr: `count(value::float)`,
+			itrs: []query.Iterator{
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Time: mustParseTime("2019-02-01T00:00:00Z").UnixNano(), Value: 1},
+					{Name: "cpu", Time: mustParseTime("2019-04-01T03:59:00Z").UnixNano(), Value: 2},
+					{Name: "cpu", Time: mustParseTime("2019-04-01T04:00:00Z").UnixNano(), Value: 3},
+				}},
+			},
+			rows: []query.Row{
+				{Time: mustParseTime("2019-01-01T05:00:00Z").UnixNano(), Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(2)}},
+				{Time: mustParseTime("2019-04-01T04:00:00Z").UnixNano(), Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(1)}},
+			},
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			shardMapper := ShardMapper{
@@ -2948,6 +3076,136 @@ func TestSelect_Raw(t *testing.T) {
 	}
 }
 
+// Ensure statements are downsampled when the points per series are limited.
+func TestSelect_MaxPointsPerSeries(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		q    string
+		n    int
+		expr string
+		rows []query.Row
+		err  string
+	}{
+		{
+			name: "Raw",
+			q:    `SELECT f FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:01:00Z'`,
+			n:    4,
+			expr: `mean(f::float)`,
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(1.5)}},
+				{Time: 20 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(3.5)}},
+				{Time: 40 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(5.5)}},
+			},
+		},
+		{
+			name: "Aggregate",
+			q:    `SELECT max(f) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:01:00Z' GROUP BY time(1s)`,
+			n:    4,
+			expr: `max(f::float)`,
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(2)}},
+				{Time: 20 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(4)}},
+				{Time: 40 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(6)}},
+			},
+		},
+		{
+			name: "Unbounded",
+			q:    `SELECT f FROM cpu`,
+			n:    4,
+			err:  `max_points requires a lower bound on time`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			shardMapper := ShardMapper{
+				MapShardsFn: func(sources influxql.Sources, _ influxql.TimeRange) query.ShardGroup {
+					return &ShardGroup{
+						Fields: map[string]influxql.DataType{"f": influxql.Float},
+						CreateIteratorFn: func(ctx context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+							if !reflect.DeepEqual(opt.Expr, MustParseExpr(tt.expr)) {
+								t.Fatalf("unexpected expr: %s", spew.Sdump(opt.Expr))
+							}
+							return query.NewCallIterator(&FloatIterator{Points: []query.FloatPoint{
+								{Name: "cpu", Time: 0 * Second, Value: 1},
+								{Name: "cpu", Time: 10 * Second, Value: 2},
+								{Name: "cpu", Time: 20 * Second, Value: 3},
+								{Name: "cpu", Time: 30 * Second, Value: 4},
+								{Name: "cpu", Time: 40 * Second, Value: 5},
+								{Name: "cpu", Time: 50 * Second, Value: 6},
+							}}, opt)
+						},
+					}
+				},
+			}
+
+			stmt := MustParseSelectStatement(tt.q)
+			stmt.OmitTime = true
+			cur, err := query.Select(context.Background(), stmt, &shardMapper, query.SelectOptions{MaxPointsPerSeries: tt.n})
+			if err != nil {
+				if tt.err == "" {
+					t.Fatal(err)
+				} else if have, want := err.Error(), tt.err; have != want {
+					t.Fatalf("unexpected error: have=%s want=%s", have, want)
+				}
+			} else if tt.err != "" {
+				t.Fatal("expected error")
+			} else if a, err := ReadCursor(cur); err != nil {
+				t.Fatalf("unexpected point: %s", err)
+			} else if diff := cmp.Diff(tt.rows, a); diff != "" {
+				t.Fatalf("unexpected points:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSelect_SystemColumns(t *testing.T) {
+	shardMapper := ShardMapper{
+		MapShardsFn: func(sources influxql.Sources, _ influxql.TimeRange) query.ShardGroup {
+			return &ShardGroup{
+				Fields: map[string]influxql.DataType{"f": influxql.Float},
+				CreateIteratorFn: func(ctx context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+					if diff := cmp.Diff(opt.Aux, []influxql.VarRef{
+						{Val: "_ingest_time", Type: influxql.Integer},
+						{Val: "_replica", Type: influxql.Integer},
+						{Val: "_shard", Type: influxql.Integer},
+						{Val: "f", Type: influxql.Float},
+					}); diff != "" {
+						t.Fatalf("unexpected auxiliary fields:\n%s", diff)
+					}
+					return &FloatIterator{Points: []query.FloatPoint{
+						{Name: "cpu", Time: 0 * Second, Aux: []interface{}{int64(2 * Second), int64(1), int64(3), float64(1)}},
+						{Name: "cpu", Time: 10 * Second, Aux: []interface{}{(*int64)(nil), int64(1), int64(3), float64(2)}},
+					}}, nil
+				},
+			}
+		},
+	}
+
+	stmt := MustParseSelectStatement(`SELECT f FROM cpu`)
+	cur, err := query.Select(context.Background(), stmt, &shardMapper, query.SelectOptions{SystemColumns: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := cur.Columns(), []influxql.VarRef{
+		{Val: "time", Type: influxql.Time},
+		{Val: "f", Type: influxql.Float},
+		{Val: "_shard", Type: influxql.Integer},
+		{Val: "_replica", Type: influxql.Integer},
+		{Val: "_ingest_time", Type: influxql.Integer},
+	}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("unexpected columns: %v", have)
+	}
+
+	if a, err := ReadCursor(cur); err != nil {
+		t.Fatalf("unexpected point: %s", err)
+	} else if diff := cmp.Diff([]query.Row{
+		{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{time.Unix(0, 0).UTC(), float64(1), int64(3), int64(1), int64(2 * Second)}},
+		{Time: 10 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{time.Unix(10, 0).UTC(), float64(2), int64(3), int64(1), nil}},
+	}, a); diff != "" {
+		t.Fatalf("unexpected points:\n%s", diff)
+	}
+}
+
 // Ensure a SELECT binary expr queries can be executed as floats.
 func TestSelect_BinaryExpr(t *testing.T) {
 	shardMapper := ShardMapper{
@@ -3917,6 +4175,173 @@ func TestSelect_BinaryExpr_Boolean(t *testing.T) {
 	}
 }
 
+// Ensure a SELECT with rounding, clamping and casting functions can be executed.
+func TestSelect_MathFunctions(t *testing.T) {
+	shardMapper := ShardMapper{
+		MapShardsFn: func(sources influxql.Sources, _ influxql.TimeRange) query.ShardGroup {
+			return &ShardGroup{
+				Fields: map[string]influxql.DataType{
+					"value": influxql.Float,
+				},
+				CreateIteratorFn: func(ctx context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+					if m.Name != "cpu" {
+						t.Fatalf("unexpected source: %s", m.Name)
+					}
+					return &FloatIterator{Points: []query.FloatPoint{
+						{Name: "cpu", Time: 0 * Second, Aux: []interface{}{float64(-1.25)}},
+						{Name: "cpu", Time: 5 * Second, Aux: []interface{}{float64(12.75)}},
+					}}, nil
+				},
+			}
+		},
+	}
+
+	stmt := MustParseSelectStatement(`SELECT round(value, 1), clamp_min(value, 0), int(value), string(value) FROM cpu`)
+	stmt.OmitTime = true
+	cur, err := query.Select(context.Background(), stmt, &shardMapper, query.SelectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := ReadCursor(cur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]query.Row{
+		{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(-1.3), float64(0), int64(-1), "-1.25"}},
+		{Time: 5 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(12.8), float64(12.75), int64(12), "12.75"}},
+	}, a); diff != "" {
+		t.Errorf("unexpected points:\n%s", diff)
+	}
+}
+
+// Ensure a SELECT with conditional expressions can be executed.
+func TestSelect_CaseExpr(t *testing.T) {
+	shardMapper := ShardMapper{
+		MapShardsFn: func(sources influxql.Sources, _ influxql.TimeRange) query.ShardGroup {
+			return &ShardGroup{
+				Fields: map[string]influxql.DataType{
+					"value": influxql.Float,
+				},
+				CreateIteratorFn: func(ctx context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+					if m.Name != "cpu" {
+						t.Fatalf("unexpected source: %s", m.Name)
+					}
+					makeAuxFields := func(value float64) []interface{} {
+						aux := make([]interface{}, len(opt.Aux))
+						for i := range aux {
+							aux[i] = value
+						}
+						return aux
+					}
+					return &FloatIterator{Points: []query.FloatPoint{
+						{Name: "cpu", Time: 0 * Second, Aux: makeAuxFields(20)},
+						{Name: "cpu", Time: 5 * Second, Aux: makeAuxFields(150)},
+						{Name: "cpu", Time: 9 * Second, Aux: makeAuxFields(5)},
+					}}, nil
+				},
+			}
+		},
+	}
+
+	for _, test := range []struct {
+		Name      string
+		Statement string
+		Rows      []query.Row
+	}{
+		{
+			Name:      "CaseElse",
+			Statement: `SELECT CASE WHEN value > 100 THEN 1 ELSE 0 END FROM cpu`,
+			Rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(0)}},
+				{Time: 5 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(1)}},
+				{Time: 9 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(0)}},
+			},
+		},
+		{
+			Name:      "CaseWithoutElse",
+			Statement: `SELECT CASE WHEN value > 100 THEN 'high' WHEN value > 10 THEN 'medium' END FROM cpu`,
+			Rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{"medium"}},
+				{Time: 5 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{"high"}},
+				{Time: 9 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
+			},
+		},
+		{
+			Name:      "If",
+			Statement: `SELECT if(value < 10, value * 10, value) FROM cpu`,
+			Rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(20)}},
+				{Time: 5 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(150)}},
+				{Time: 9 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(50)}},
+			},
+		},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			stmt := MustParseSelectStatement(test.Statement)
+			if s := stmt.String(); s != test.Statement {
+				t.Fatalf("unexpected statement: %s", s)
+			}
+			stmt.OmitTime = true
+			cur, err := query.Select(context.Background(), stmt, &shardMapper, query.SelectOptions{})
+			if err != nil {
+				t.Errorf("%s: parse error: %s", test.Name, err)
+			} else if a, err := ReadCursor(cur); err != nil {
+				t.Fatalf("%s: unexpected error: %s", test.Name, err)
+			} else if diff := cmp.Diff(test.Rows, a); diff != "" {
+				t.Errorf("%s: unexpected points:\n%s", test.Name, diff)
+			}
+		})
+	}
+}
+
+// Ensure the fields of different measurements can be combined.
+func TestSelect_MeasurementFields(t *testing.T) {
+	shardMapper := ShardMapper{
+		MapShardsFn: func(sources influxql.Sources, _ influxql.TimeRange) query.ShardGroup {
+			return &ShardGroup{
+				Fields: map[string]influxql.DataType{
+					"count": influxql.Float,
+				},
+				Dimensions: []string{"host"},
+				CreateIteratorFn: func(ctx context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+					if !opt.StripName {
+						t.Fatalf("expected the measurement name to be stripped")
+					}
+					points := map[string][]query.FloatPoint{
+						"hits": {
+							{Tags: ParseTags("host=A"), Time: 0 * Second, Aux: []interface{}{float64(3)}},
+							{Tags: ParseTags("host=A"), Time: 5 * Second, Aux: []interface{}{float64(3)}},
+							{Tags: ParseTags("host=A"), Time: 10 * Second, Aux: []interface{}{float64(1)}},
+						},
+						"misses": {
+							{Tags: ParseTags("host=A"), Time: 2 * Second, Aux: []interface{}{float64(2)}},
+							{Tags: ParseTags("host=A"), Time: 12 * Second, Aux: []interface{}{float64(3)}},
+						},
+					}[m.Name]
+					if points == nil {
+						t.Fatalf("unexpected source: %s", m.Name)
+					}
+					return &FloatIterator{Points: points}, nil
+				},
+			}
+		},
+	}
+
+	stmt := MustParseSelectStatement(`SELECT sum(hits.count) / (sum(hits.count) + sum(misses.count)) AS ratio FROM hits, misses WHERE time >= 0s AND time < 20s AND host = 'A' GROUP BY time(10s), host`)
+	stmt.OmitTime = true
+	cur, err := query.Select(context.Background(), stmt, &shardMapper, query.SelectOptions{})
+	if err != nil {
+		t.Fatalf("parse error: %s", err)
+	} else if a, err := ReadCursor(cur); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if diff := cmp.Diff([]query.Row{
+		{Time: 0 * Second, Series: query.Series{Name: "hits,misses", Tags: ParseTags("host=A")}, Values: []interface{}{float64(0.75)}},
+		{Time: 10 * Second, Series: query.Series{Name: "hits,misses", Tags: ParseTags("host=A")}, Values: []interface{}{float64(0.25)}},
+	}, a); diff != "" {
+		t.Errorf("unexpected points:\n%s", diff)
+	}
+}
+
 // Ensure a SELECT binary expr with nil values can be executed.
 // Nil values may be present when a field is missing from one iterator,
 // but not the other.
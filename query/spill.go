@@ -0,0 +1,99 @@
+package query
+
+import (
+	"bufio"
+	"context"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// spillPartitionN is the number of partitions a group operator spills the
+// points of its groups to, so that each partition can be reduced in memory.
+const spillPartitionN = 16
+
+// Spiller provides the disk space the group and sort operators of a query
+// spill their points to once they hold too many in memory.
+type Spiller interface {
+	// SpillThreshold returns the number of bytes of points an operator may
+	// hold in memory before spilling them to disk.
+	SpillThreshold() int
+
+	// SpillDir returns the directory of the spill files. If empty, the
+	// default directory for temporary files is used.
+	SpillDir() string
+
+	// Spilled records that the query wrote another n bytes to disk.
+	Spilled(n int)
+}
+
+// SpillerFromContext returns the Spiller embedded within the Context if one
+// exists.
+func SpillerFromContext(ctx context.Context) Spiller {
+	v, _ := ctx.Value(spillerContextKey).(Spiller)
+	return v
+}
+
+// spillable returns true if an operator holding n bytes of points must spill
+// them to disk.
+func spillable(spiller Spiller, n int) bool {
+	if spiller == nil {
+		return false
+	}
+	threshold := spiller.SpillThreshold()
+	return threshold > 0 && n >= threshold
+}
+
+// spillPartition returns the partition the points of a group are spilled to.
+func spillPartition(id string) int {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return int(h.Sum32() % spillPartitionN)
+}
+
+// spillFile is a temporary file points are spilled to. It is removed once
+// closed.
+type spillFile struct {
+	f       *os.File
+	w       *bufio.Writer
+	n       int
+	spiller Spiller
+}
+
+func createSpillFile(spiller Spiller) (*spillFile, error) {
+	f, err := ioutil.TempFile(spiller.SpillDir(), "freetsdb-spill-")
+	if err != nil {
+		return nil, err
+	}
+	return &spillFile{f: f, w: bufio.NewWriter(f), spiller: spiller}, nil
+}
+
+// Write writes p to the file.
+func (f *spillFile) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.n += n
+	return n, err
+}
+
+// reader flushes the file and returns a reader of its content, which must be
+// read before writing to the file again.
+func (f *spillFile) reader() (io.Reader, error) {
+	if err := f.w.Flush(); err != nil {
+		return nil, err
+	}
+	if _, err := f.f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return bufio.NewReader(f.f), nil
+}
+
+// Close closes and removes the file, and reports the bytes written to it.
+func (f *spillFile) Close() error {
+	f.spiller.Spilled(f.n)
+	err := f.f.Close()
+	if rerr := os.Remove(f.f.Name()); err == nil {
+		err = rerr
+	}
+	return err
+}
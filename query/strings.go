@@ -0,0 +1,156 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+// isStringFunction returns true if the function manipulates strings.
+func isStringFunction(name string) bool {
+	switch name {
+	case "lower", "upper", "substr", "replace", "concat", "regex_extract":
+		return true
+	}
+	return false
+}
+
+// stringCallType returns the type of a string function call.
+func stringCallType(name string, args []influxql.DataType) (influxql.DataType, error) {
+	for i, arg := range args {
+		var ok bool
+		switch {
+		case name == "concat":
+			ok = true
+		case i == 0:
+			ok = isStringType(arg)
+		case name == "substr", name == "regex_extract":
+			ok = arg == influxql.Integer || arg == influxql.Unknown
+		case name == "replace":
+			ok = isStringType(arg)
+		}
+		if !ok {
+			return influxql.Unknown, fmt.Errorf("invalid argument type for argument %d in %s(): %s", i+1, name, arg)
+		}
+	}
+	return influxql.String, nil
+}
+
+// isStringType returns true if the values of the type are strings.
+func isStringType(typ influxql.DataType) bool {
+	return typ == influxql.String || typ == influxql.Tag || typ == influxql.Unknown
+}
+
+// callString evaluates a string function. It returns nil if an argument is
+// nil or has the wrong type.
+func callString(name string, args []interface{}) interface{} {
+	if name == "concat" {
+		var buf strings.Builder
+		for _, arg := range args {
+			s, ok := castString(arg)
+			if !ok {
+				return nil
+			}
+			buf.WriteString(s)
+		}
+		return buf.String()
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		return nil
+	}
+
+	switch name {
+	case "lower":
+		return strings.ToLower(s)
+	case "upper":
+		return strings.ToUpper(s)
+	case "substr":
+		var start, length int64 = 1, -1
+		if len(args) > 1 {
+			if start, ok = args[1].(int64); !ok {
+				return nil
+			}
+		}
+		if len(args) > 2 {
+			if length, ok = args[2].(int64); !ok {
+				return nil
+			}
+		}
+		return substr(s, start, length)
+	case "replace":
+		if len(args) != 3 {
+			return nil
+		}
+		repl, ok := args[2].(string)
+		if !ok {
+			return nil
+		}
+		switch old := args[1].(type) {
+		case string:
+			return strings.Replace(s, old, repl, -1)
+		case *regexp.Regexp:
+			return old.ReplaceAllString(s, repl)
+		}
+		return nil
+	case "regex_extract":
+		if len(args) < 2 {
+			return nil
+		}
+		re, ok := args[1].(*regexp.Regexp)
+		if !ok {
+			return nil
+		}
+
+		// Extract the first subexpression by default, or the whole match if
+		// the expression has none.
+		group := int64(1)
+		if len(args) > 2 {
+			if group, ok = args[2].(int64); !ok {
+				return nil
+			}
+		} else if re.NumSubexp() == 0 {
+			group = 0
+		}
+		m := re.FindStringSubmatchIndex(s)
+		if m == nil || group < 0 || int(group) > re.NumSubexp() || m[2*group] < 0 {
+			return nil
+		}
+		return s[m[2*group]:m[2*group+1]]
+	}
+	return nil
+}
+
+// substr returns the length characters of s from the character at start,
+// counted from 1. The characters up to the end of s are returned if length is
+// negative.
+func substr(s string, start, length int64) string {
+	if start < 1 {
+		start = 1
+	}
+
+	// Find the byte offset of the first character.
+	i := 0
+	for n := int64(1); n < start && i < len(s); n++ {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	if length < 0 {
+		return s[i:]
+	}
+
+	// Then the byte offset after the last character.
+	j := i
+	for n := int64(0); n < length && j < len(s); n++ {
+		_, size := utf8.DecodeRuneInString(s[j:])
+		j += size
+	}
+	return s[i:j]
+}
@@ -0,0 +1,85 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+func TestStrings_TypeMapper(t *testing.T) {
+	for _, tt := range []struct {
+		s   string
+		typ influxql.DataType
+		err bool
+	}{
+		{s: `lower(s::string)`, typ: influxql.String},
+		{s: `upper(t::tag)`, typ: influxql.String},
+		{s: `lower(f::float)`, err: true},
+		{s: `substr(s::string, 2)`, typ: influxql.String},
+		{s: `substr(s::string, 2, 3)`, typ: influxql.String},
+		{s: `substr(s::string, 'a')`, err: true},
+		{s: `replace(s::string, 'a', 'b')`, typ: influxql.String},
+		{s: `replace(s::string, /a+/, 'b')`, typ: influxql.String},
+		{s: `replace(s::string, 'a', 1)`, err: true},
+		{s: `concat(s::string, ':', i::integer)`, typ: influxql.String},
+		{s: `regex_extract(s::string, /a(b)/)`, typ: influxql.String},
+		{s: `regex_extract(s::string, /a(b)/, 'a')`, err: true},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			expr := MustParseExpr(tt.s)
+
+			typmap := influxql.TypeValuerEval{
+				TypeMapper: query.MathTypeMapper{},
+			}
+			if got, err := typmap.EvalType(expr); err != nil {
+				if !tt.err {
+					t.Errorf("unexpected error: %s", err)
+				}
+			} else if tt.err {
+				t.Error("expected error")
+			} else if want := tt.typ; got != want {
+				t.Errorf("unexpected type:\n\t-: \"%s\"\n\t+: \"%s\"", want, got)
+			}
+		})
+	}
+}
+
+func TestStrings_Call(t *testing.T) {
+	type values map[string]interface{}
+	for _, tt := range []struct {
+		s      string
+		values values
+		exp    interface{}
+	}{
+		{s: `lower(s)`, values: values{"s": "Server01"}, exp: "server01"},
+		{s: `upper(s)`, values: values{"s": "Server01"}, exp: "SERVER01"},
+		{s: `upper(f)`, values: values{"f": float64(1)}, exp: nil},
+		{s: `substr(s, 2)`, values: values{"s": "héllo"}, exp: "éllo"},
+		{s: `substr(s, 2, 3)`, values: values{"s": "héllo"}, exp: "éll"},
+		{s: `substr(s, 0, 2)`, values: values{"s": "héllo"}, exp: "hé"},
+		{s: `substr(s, 10)`, values: values{"s": "héllo"}, exp: ""},
+		{s: `replace(s, 'o', '0')`, values: values{"s": "foo"}, exp: "f00"},
+		{s: `replace(s, /o+/, '0')`, values: values{"s": "foo"}, exp: "f0"},
+		{s: `concat(s, ':', i)`, values: values{"s": "host", "i": int64(8086)}, exp: "host:8086"},
+		{s: `concat(s, ':', i)`, values: values{"s": "host"}, exp: nil},
+		{s: `regex_extract(s, /level=(\w+)/)`, values: values{"s": "ts=1 level=error"}, exp: "error"},
+		{s: `regex_extract(s, /level=\w+/)`, values: values{"s": "ts=1 level=error"}, exp: "level=error"},
+		{s: `regex_extract(s, /(\w+)=(\w+)/, 2)`, values: values{"s": "level=error"}, exp: "error"},
+		{s: `regex_extract(s, /level=(\w+)/)`, values: values{"s": "ts=1"}, exp: nil},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			expr := MustParseExpr(tt.s)
+
+			valuer := influxql.ValuerEval{
+				Valuer: influxql.MultiValuer(
+					influxql.MapValuer(tt.values),
+					query.MathValuer{},
+				),
+			}
+			if got, want := valuer.Eval(expr), tt.exp; got != want {
+				t.Errorf("unexpected value: %v != %v", want, got)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/freetsdb/freetsdb/models"
@@ -28,6 +29,10 @@ const (
 	// KilledTask is set when the task is killed, but resources are still
 	// being used.
 	KilledTask
+
+	// PausedTask is set when a paginated task waits for its results to be
+	// read without holding a slot.
+	PausedTask
 )
 
 func (t TaskStatus) String() string {
@@ -36,6 +41,8 @@ func (t TaskStatus) String() string {
 		return "running"
 	case KilledTask:
 		return "killed"
+	case PausedTask:
+		return "paused"
 	default:
 		return "unknown"
 	}
@@ -51,6 +58,8 @@ func (t *TaskStatus) UnmarshalJSON(data []byte) error {
 		*t = RunningTask
 	} else if bytes.Equal(data, []byte("killed")) {
 		*t = KilledTask
+	} else if bytes.Equal(data, []byte("paused")) {
+		*t = PausedTask
 	} else if bytes.Equal(data, []byte("unknown")) {
 		*t = TaskStatus(0)
 	} else {
@@ -71,6 +80,40 @@ type TaskManager struct {
 	// Maximum number of concurrent queries.
 	MaxConcurrentQueries int
 
+	// Maximum number of concurrent batch priority queries, which keeps the
+	// remaining slots free for interactive queries. If zero, batch queries
+	// are only limited by MaxConcurrentQueries.
+	MaxConcurrentBatchQueries int
+
+	// Maximum number of queries that wait for a slot when the concurrent
+	// query limits are reached. If zero, those queries fail immediately.
+	MaxQueuedQueries int
+
+	// Time a query waits in the queue before it fails.
+	// If zero, queries wait until a slot is free.
+	QueueTimeout time.Duration
+
+	// Maximum number of bytes a single query may allocate.
+	// If zero, queries are not limited.
+	MaxQueryMemoryBytes int64
+
+	// Maximum number of bytes all running queries may allocate together.
+	// New queries wait while the budget is used up and the largest query
+	// is killed if running queries exceed it. If zero, there is no budget.
+	QueryMemoryBudgetBytes int64
+
+	// Time a new query waits for memory when the budget is used up.
+	// If zero, the query fails immediately.
+	QueryMemoryQueueTimeout time.Duration
+
+	// Bytes of points a sort or group operator of a query may hold in
+	// memory before spilling them to disk. If zero, operators do not spill.
+	QuerySpillThresholdBytes int64
+
+	// Directory of the files queries spill to. If empty, the default
+	// directory for temporary files is used.
+	QuerySpillDir string
+
 	// Logger to use for all logging.
 	// Defaults to discarding all log output.
 	Logger *zap.Logger
@@ -80,6 +123,38 @@ type TaskManager struct {
 	nextID   uint64
 	mu       sync.RWMutex
 	shutdown bool
+
+	// Bytes allocated by running queries and a channel closed when
+	// some of them are released.
+	memoryN     int64
+	memoryFreed chan struct{}
+
+	// Queries waiting for a slot ordered by priority, the number of slots
+	// given to queued queries that have not attached yet, and queue statistics.
+	queue    []*queuedQuery
+	reserved [queryPriorityN]int
+	stats    QueueStatistics
+
+	spillStats SpillStatistics
+
+	// Override of MaxConcurrentQueries set at runtime, if not zero.
+	maxConcurrentQueries int
+}
+
+// QueueStatistics keeps statistics related to the query queue.
+type QueueStatistics struct {
+	QueuedQueries   int64
+	DequeuedQueries int64
+	QueueDuration   int64
+	QueueTimeouts   int64
+	QueueRejections int64
+}
+
+// SpillStatistics keeps statistics related to the queries that spilled to
+// disk.
+type SpillStatistics struct {
+	SpilledQueries int64
+	SpilledBytes   int64
 }
 
 // NewTaskManager creates a new TaskManager.
@@ -92,6 +167,82 @@ func NewTaskManager() *TaskManager {
 	}
 }
 
+// SetLimits changes the query limits while queries are running. Running
+// queries keep the limits they started with.
+func (t *TaskManager) SetLimits(queryTimeout, logQueriesAfter time.Duration, maxConcurrentQueries int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.QueryTimeout = queryTimeout
+	t.LogQueriesAfter = logQueriesAfter
+	t.MaxConcurrentQueries = maxConcurrentQueries
+	t.dispatch()
+}
+
+// SetMaxConcurrentQueries overrides MaxConcurrentQueries while queries are
+// running. An override of 0 removes it, so MaxConcurrentQueries applies again.
+func (t *TaskManager) SetMaxConcurrentQueries(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.maxConcurrentQueries = n
+	t.dispatch()
+}
+
+// concurrentQueryLimit returns the number of queries that may run at once, or
+// 0 if they are not limited. t.mu must be held.
+func (t *TaskManager) concurrentQueryLimit() int {
+	if t.maxConcurrentQueries > 0 {
+		return t.maxConcurrentQueries
+	}
+	return t.MaxConcurrentQueries
+}
+
+// SetQueueLimits changes the batch query and queue limits while queries are
+// running.
+func (t *TaskManager) SetQueueLimits(maxConcurrentBatchQueries, maxQueuedQueries int, queueTimeout time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.MaxConcurrentBatchQueries = maxConcurrentBatchQueries
+	t.MaxQueuedQueries = maxQueuedQueries
+	t.QueueTimeout = queueTimeout
+	t.dispatch()
+}
+
+// QueueStatistics returns statistics for the query queue.
+func (t *TaskManager) QueueStatistics() QueueStatistics {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	stats := t.stats
+	stats.QueuedQueries = int64(len(t.queue))
+	return stats
+}
+
+// SpillStatistics returns statistics for the queries that spilled to disk.
+func (t *TaskManager) SpillStatistics() SpillStatistics {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.spillStats
+}
+
+// SetSpill changes where and when queries spill to disk while queries are
+// running. Running queries keep the settings they started with.
+func (t *TaskManager) SetSpill(dir string, thresholdBytes int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.QuerySpillDir = dir
+	t.QuerySpillThresholdBytes = thresholdBytes
+}
+
+// SetMemoryLimits changes the query memory limits while queries are running.
+// Running queries keep their per-query limit but share the new budget.
+func (t *TaskManager) SetMemoryLimits(maxQueryMemoryBytes, queryMemoryBudgetBytes int64, queueTimeout time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.MaxQueryMemoryBytes = maxQueryMemoryBytes
+	t.QueryMemoryBudgetBytes = queryMemoryBudgetBytes
+	t.QueryMemoryQueueTimeout = queueTimeout
+	t.notifyMemoryFreed()
+}
+
 // ExecuteStatement executes a statement containing one of the task management queries.
 func (t *TaskManager) ExecuteStatement(stmt influxql.Statement, ctx *ExecutionContext) error {
 	switch stmt := stmt.(type) {
@@ -145,11 +296,11 @@ func (t *TaskManager) executeShowQueriesStatement(q *influxql.ShowQueriesStateme
 			d = d - (d % time.Microsecond)
 		}
 
-		values = append(values, []interface{}{id, qi.query, qi.database, d.String(), qi.status.String()})
+		values = append(values, []interface{}{id, qi.query, qi.database, d.String(), t.taskStatus(qi).String(), atomic.LoadInt64(&qi.memoryN), qi.priority.String(), atomic.LoadInt64(&qi.spilledN)})
 	}
 
 	return []*models.Row{{
-		Columns: []string{"qid", "query", "database", "duration", "status"},
+		Columns: []string{"qid", "query", "database", "duration", "status", "memory", "priority", "spilled"},
 		Values:  values,
 	}}, nil
 }
@@ -171,6 +322,16 @@ func (t *TaskManager) queryError(qid uint64, err error) {
 //
 // After a query finishes running, the system is free to reuse a query id.
 func (t *TaskManager) AttachQuery(q *influxql.Query, opt ExecutionOptions, interrupt <-chan struct{}) (*ExecutionContext, func(), error) {
+	return t.attachQuery(context.Background(), q, opt, interrupt)
+}
+
+// attachQuery attaches a running query whose execution context is derived
+// from ctx.
+func (t *TaskManager) attachQuery(ctx context.Context, q *influxql.Query, opt ExecutionOptions, interrupt <-chan struct{}) (*ExecutionContext, func(), error) {
+	if err := t.waitForMemory(interrupt); err != nil {
+		return nil, nil, err
+	}
+
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
@@ -178,31 +339,36 @@ func (t *TaskManager) AttachQuery(q *influxql.Query, opt ExecutionOptions, inter
 		return nil, nil, ErrQueryEngineShutdown
 	}
 
-	if t.MaxConcurrentQueries > 0 && len(t.queries) >= t.MaxConcurrentQueries {
-		return nil, nil, ErrMaxConcurrentQueriesLimitExceeded(len(t.queries), t.MaxConcurrentQueries)
+	if err := t.waitForSlot(opt.Priority, interrupt); err != nil {
+		return nil, nil, err
 	}
 
 	qid := t.nextID
 	query := &Task{
-		query:     q.String(),
-		database:  opt.Database,
-		status:    RunningTask,
-		startTime: time.Now(),
-		closing:   make(chan struct{}),
-		monitorCh: make(chan error),
+		memoryLimit:    t.MaxQueryMemoryBytes,
+		manager:        t,
+		spillThreshold: int(t.QuerySpillThresholdBytes),
+		spillDir:       t.QuerySpillDir,
+		query:          q.String(),
+		database:       opt.Database,
+		priority:       opt.Priority,
+		status:         RunningTask,
+		startTime:      time.Now(),
+		closing:        make(chan struct{}),
+		monitorCh:      make(chan error),
 	}
 	t.queries[qid] = query
 
-	go t.waitForQuery(qid, query.closing, interrupt, query.monitorCh)
-	if t.LogQueriesAfter != 0 {
+	go t.waitForQuery(qid, t.QueryTimeout, query.closing, interrupt, query.monitorCh)
+	if logQueriesAfter := t.LogQueriesAfter; logQueriesAfter != 0 {
 		go query.monitor(func(closing <-chan struct{}) error {
-			timer := time.NewTimer(t.LogQueriesAfter)
+			timer := time.NewTimer(logQueriesAfter)
 			defer timer.Stop()
 
 			select {
 			case <-timer.C:
 				t.Logger.Warn(fmt.Sprintf("Detected slow query: %s (qid: %d, database: %s, threshold: %s)",
-					query.query, qid, query.database, t.LogQueriesAfter))
+					query.query, qid, query.database, logQueriesAfter))
 			case <-closing:
 			}
 			return nil
@@ -210,14 +376,14 @@ func (t *TaskManager) AttachQuery(q *influxql.Query, opt ExecutionOptions, inter
 	}
 	t.nextID++
 
-	ctx := &ExecutionContext{
-		Context:          context.Background(),
+	ectx := &ExecutionContext{
+		Context:          ctx,
 		QueryID:          qid,
 		task:             query,
 		ExecutionOptions: opt,
 	}
-	ctx.watch()
-	return ctx, func() { t.DetachQuery(qid) }, nil
+	ectx.watch()
+	return ectx, func() { t.DetachQuery(qid) }, nil
 }
 
 // KillQuery enters a query into the killed state and closes the channel
@@ -246,7 +412,20 @@ func (t *TaskManager) DetachQuery(qid uint64) error {
 	}
 
 	query.close()
+	query.detached = true
 	delete(t.queries, qid)
+	t.dispatch()
+
+	// Release the memory allocated by the query.
+	if n := atomic.LoadInt64(&query.memoryN); n > 0 {
+		t.memoryN -= n
+		t.notifyMemoryFreed()
+	}
+
+	if n := atomic.LoadInt64(&query.spilledN); n > 0 {
+		t.spillStats.SpilledQueries++
+		t.spillStats.SpilledBytes += n
+	}
 	return nil
 }
 
@@ -257,6 +436,9 @@ type QueryInfo struct {
 	Database string        `json:"database"`
 	Duration time.Duration `json:"duration"`
 	Status   TaskStatus    `json:"status"`
+	Memory   int64         `json:"memory"`
+	Priority QueryPriority `json:"priority"`
+	Spilled  int64         `json:"spilled"`
 }
 
 // Queries returns a list of all running queries with information about them.
@@ -272,16 +454,84 @@ func (t *TaskManager) Queries() []QueryInfo {
 			Query:    qi.query,
 			Database: qi.database,
 			Duration: now.Sub(qi.startTime),
-			Status:   qi.status,
+			Status:   t.taskStatus(qi),
+			Memory:   atomic.LoadInt64(&qi.memoryN),
+			Priority: qi.priority,
+			Spilled:  atomic.LoadInt64(&qi.spilledN),
 		})
 	}
 	return queries
 }
 
-func (t *TaskManager) waitForQuery(qid uint64, interrupt <-chan struct{}, closing <-chan struct{}, monitorCh <-chan error) {
+// taskStatus returns the status of task. It must be called with the lock held.
+func (t *TaskManager) taskStatus(task *Task) TaskStatus {
+	task.mu.Lock()
+	defer task.mu.Unlock()
+	if task.paused && task.status == RunningTask {
+		return PausedTask
+	}
+	return task.status
+}
+
+// pauseQuery releases the slot of a paginated query while its results wait
+// to be read, so that other queries can run.
+func (t *TaskManager) pauseQuery(task *Task) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if task.detached || task.paused {
+		return
+	}
+	task.paused = true
+	t.dispatch()
+}
+
+// resumeQuery waits for a slot for a paused query. Paused queries were
+// admitted before the queued ones, so they wait ahead of them, and they
+// wait even if queries are not queued.
+func (t *TaskManager) resumeQuery(task *Task, interrupt <-chan struct{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !task.paused {
+		return nil
+	} else if t.shutdown {
+		return ErrQueryEngineShutdown
+	}
+
+	if t.slotError(task.priority) != nil {
+		q := &queuedQuery{priority: task.priority, ready: make(chan struct{})}
+		t.queue = append([]*queuedQuery{q}, t.queue...)
+
+		t.mu.Unlock()
+		select {
+		case <-q.ready:
+		case <-interrupt:
+		}
+		t.mu.Lock()
+
+		select {
+		case <-q.ready:
+			t.reserved[task.priority]--
+		default:
+			for i := range t.queue {
+				if t.queue[i] == q {
+					t.queue = append(t.queue[:i], t.queue[i+1:]...)
+					break
+				}
+			}
+			t.dispatch()
+			return ErrQueryInterrupted
+		}
+	}
+	task.paused = false
+	return nil
+}
+
+func (t *TaskManager) waitForQuery(qid uint64, timeout time.Duration, interrupt <-chan struct{}, closing <-chan struct{}, monitorCh <-chan error) {
 	var timerCh <-chan time.Time
-	if t.QueryTimeout != 0 {
-		timer := time.NewTimer(t.QueryTimeout)
+	if timeout != 0 {
+		timer := time.NewTimer(timeout)
 		timerCh = timer.C
 		defer timer.Stop()
 	}
@@ -315,5 +565,236 @@ func (t *TaskManager) Close() error {
 		query.close()
 	}
 	t.queries = nil
+	t.notifyMemoryFreed()
+	t.dispatch()
+	return nil
+}
+
+// waitForSlot returns once a query with the given priority may run under the
+// concurrent query limits. If no slot is free, the query waits in the queue
+// behind queued queries of the same or a higher priority. It must be called
+// with the lock held, which is released while waiting.
+func (t *TaskManager) waitForSlot(priority QueryPriority, interrupt <-chan struct{}) error {
+	pos := len(t.queue)
+	for i, q := range t.queue {
+		if q.priority > priority {
+			pos = i
+			break
+		}
+	}
+	if pos == 0 {
+		if err := t.slotError(priority); err == nil {
+			return nil
+		} else if t.MaxQueuedQueries <= 0 {
+			return err
+		}
+	}
+
+	if len(t.queue) >= t.MaxQueuedQueries {
+		t.stats.QueueRejections++
+		return ErrMaxQueuedQueriesLimitExceeded(len(t.queue), t.MaxQueuedQueries)
+	}
+
+	q := &queuedQuery{priority: priority, ready: make(chan struct{})}
+	t.queue = append(t.queue, nil)
+	copy(t.queue[pos+1:], t.queue[pos:])
+	t.queue[pos] = q
+
+	start := time.Now()
+	var timerCh <-chan time.Time
+	if t.QueueTimeout > 0 {
+		timer := time.NewTimer(t.QueueTimeout)
+		defer timer.Stop()
+		timerCh = timer.C
+	}
+
+	t.mu.Unlock()
+	var err error
+	select {
+	case <-q.ready:
+	case <-timerCh:
+		err = ErrQueryQueueTimeout
+	case <-interrupt:
+		err = ErrQueryInterrupted
+	}
+	t.mu.Lock()
+
+	t.stats.DequeuedQueries++
+	t.stats.QueueDuration += time.Since(start).Nanoseconds()
+
+	select {
+	case <-q.ready:
+		// The query was given a slot, even if it also timed out.
+		t.reserved[priority]--
+		if t.shutdown {
+			return ErrQueryEngineShutdown
+		}
+		return nil
+	default:
+	}
+
+	if err == ErrQueryQueueTimeout {
+		t.stats.QueueTimeouts++
+	}
+	for i := range t.queue {
+		if t.queue[i] == q {
+			t.queue = append(t.queue[:i], t.queue[i+1:]...)
+			break
+		}
+	}
+	// Queries behind this one may be able to run now.
+	t.dispatch()
+	return err
+}
+
+// slotError returns an error if a query with the given priority may not run
+// under the concurrent query limits.
+func (t *TaskManager) slotError(priority QueryPriority) error {
+	var running int
+	for _, query := range t.queries {
+		if !query.paused {
+			running++
+		}
+	}
+	for _, n := range t.reserved {
+		running += n
+	}
+	if limit := t.concurrentQueryLimit(); limit > 0 && running >= limit {
+		return ErrMaxConcurrentQueriesLimitExceeded(running, limit)
+	}
+
+	if priority == BatchPriority && t.MaxConcurrentBatchQueries > 0 {
+		batch := t.reserved[BatchPriority]
+		for _, query := range t.queries {
+			if query.priority == BatchPriority && !query.paused {
+				batch++
+			}
+		}
+		if batch >= t.MaxConcurrentBatchQueries {
+			return ErrMaxConcurrentBatchQueriesLimitExceeded(batch, t.MaxConcurrentBatchQueries)
+		}
+	}
+	return nil
+}
+
+// dispatch gives free slots to queued queries in priority order. It must be
+// called with the lock held.
+func (t *TaskManager) dispatch() {
+	queue := t.queue[:0]
+	for _, q := range t.queue {
+		if t.shutdown || t.slotError(q.priority) == nil {
+			t.reserved[q.priority]++
+			close(q.ready)
+			continue
+		}
+		queue = append(queue, q)
+	}
+	for i := len(queue); i < len(t.queue); i++ {
+		t.queue[i] = nil
+	}
+	t.queue = queue
+}
+
+// waitForMemory blocks while the running queries have used up the query
+// memory budget. It returns an error if no memory is released within
+// QueryMemoryQueueTimeout or if interrupt is closed.
+func (t *TaskManager) waitForMemory(interrupt <-chan struct{}) error {
+	var timerCh <-chan time.Time
+	for {
+		t.mu.Lock()
+		budget, used := t.QueryMemoryBudgetBytes, t.memoryN
+		if budget <= 0 || used < budget || t.shutdown {
+			t.mu.Unlock()
+			return nil
+		}
+
+		if timerCh == nil {
+			if t.QueryMemoryQueueTimeout <= 0 {
+				t.mu.Unlock()
+				return ErrQueryMemoryBudgetExceeded(used, budget)
+			}
+			timer := time.NewTimer(t.QueryMemoryQueueTimeout)
+			defer timer.Stop()
+			timerCh = timer.C
+		}
+
+		if t.memoryFreed == nil {
+			t.memoryFreed = make(chan struct{})
+		}
+		freed := t.memoryFreed
+		t.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-timerCh:
+			return ErrQueryMemoryQueueTimeout
+		case <-interrupt:
+			return ErrQueryInterrupted
+		}
+	}
+}
+
+// notifyMemoryFreed wakes queries waiting for memory. It must be called
+// with the lock held.
+func (t *TaskManager) notifyMemoryFreed() {
+	if t.memoryFreed != nil {
+		close(t.memoryFreed)
+		t.memoryFreed = nil
+	}
+}
+
+// allocate records that task allocated another n bytes. If the running
+// queries exceed the query memory budget, the largest query is killed unless
+// queries that were already killed will release enough memory. If task is the
+// largest query, the error is returned to it instead.
+func (t *TaskManager) allocate(task *Task, n int64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// The bytes are charged to the query and to the manager together, so
+	// that DetachQuery releases exactly what was charged, even when the
+	// query exceeds its own limit.
+	used := atomic.AddInt64(&task.memoryN, n)
+	if task.detached {
+		return task.checkMemoryLimit(used)
+	}
+	t.memoryN += n
+	if err := task.checkMemoryLimit(used); err != nil {
+		return err
+	}
+
+	budget := t.QueryMemoryBudgetBytes
+	if budget <= 0 || t.memoryN <= budget {
+		return nil
+	}
+
+	excess := t.memoryN - budget
+	var largest *Task
+	var largestID uint64
+	var largestN int64
+	for qid, query := range t.queries {
+		used := atomic.LoadInt64(&query.memoryN)
+		if query.killed() {
+			excess -= used
+			continue
+		}
+		if largest == nil || used > largestN {
+			largest, largestID, largestN = query, qid, used
+		}
+	}
+
+	if excess <= 0 || largest == nil {
+		return nil
+	} else if largest == task {
+		return ErrQueryMemoryBudgetExceeded(t.memoryN, budget)
+	}
+
+	t.Logger.Warn("Killing query to relieve memory pressure",
+		zap.Uint64("qid", largestID),
+		zap.String("query", largest.query),
+		zap.Int64("memory", largestN),
+		zap.Int64("budget", budget))
+	largest.setError(ErrQueryMemoryPressure)
+	largest.kill()
 	return nil
 }
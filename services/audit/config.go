@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/freetsdb/freetsdb/monitor/diagnostics"
+)
+
+const (
+	// DefaultStoreDatabase is the database audit events are copied to when
+	// store-enabled is set.
+	DefaultStoreDatabase = "_internal"
+
+	// DefaultStoreMeasurement is the measurement audit events are written to.
+	DefaultStoreMeasurement = "audit"
+)
+
+// Config represents the configuration for the audit service.
+type Config struct {
+	Enabled bool `toml:"enabled"`
+
+	// Path is the append-only file audit events are written to.
+	Path string `toml:"path"`
+
+	// HeadPath is the file the last event of the log is written to. It
+	// should be kept apart from the log, such as on another volume, so that
+	// removing events from the end of the log can be detected.
+	HeadPath string `toml:"head-path"`
+
+	// Key is the key of the HMAC chaining the events of the log.
+	Key string `toml:"key" secret:"true"`
+
+	// StoreEnabled copies every event into StoreDatabase as well.
+	StoreEnabled  bool   `toml:"store-enabled"`
+	StoreDatabase string `toml:"store-database"`
+}
+
+// NewConfig returns an instance of Config with defaults.
+func NewConfig() Config {
+	return Config{
+		Enabled:       false,
+		StoreDatabase: DefaultStoreDatabase,
+	}
+}
+
+// Validate returns an error if the Config is invalid.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.Path == "" {
+		return errors.New("path must be specified")
+	} else if c.HeadPath == "" {
+		return errors.New("head-path must be specified")
+	} else if filepath.Clean(c.HeadPath) == filepath.Clean(c.Path) {
+		return errors.New("head-path must not be the log path")
+	} else if c.Key == "" {
+		return errors.New("key must be specified")
+	}
+	if c.StoreEnabled && c.StoreDatabase == "" {
+		return errors.New("store-database must be specified when store-enabled is set")
+	}
+	return nil
+}
+
+// Diagnostics returns a diagnostics representation of a subset of the Config.
+func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
+	if !c.Enabled {
+		return diagnostics.RowFromMap(map[string]interface{}{
+			"enabled": false,
+		}), nil
+	}
+
+	return diagnostics.RowFromMap(map[string]interface{}{
+		"enabled":        true,
+		"path":           c.Path,
+		"head-path":      c.HeadPath,
+		"store-enabled":  c.StoreEnabled,
+		"store-database": c.StoreDatabase,
+	}), nil
+}
@@ -0,0 +1,75 @@
+package audit_test
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/freetsdb/freetsdb/services/audit"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	var c audit.Config
+	if _, err := toml.Decode(`
+enabled = true
+path = "/var/lib/freetsdb/audit.log"
+head-path = "/var/lib/freetsdb-head/audit.head"
+key = "secret"
+store-enabled = true
+store-database = "audit"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Validate configuration.
+	if !c.Enabled {
+		t.Fatalf("unexpected enabled state: %v", c.Enabled)
+	} else if c.Path != "/var/lib/freetsdb/audit.log" {
+		t.Fatalf("unexpected path: %s", c.Path)
+	} else if c.HeadPath != "/var/lib/freetsdb-head/audit.head" {
+		t.Fatalf("unexpected head path: %s", c.HeadPath)
+	} else if c.Key != "secret" {
+		t.Fatalf("unexpected key: %s", c.Key)
+	} else if !c.StoreEnabled {
+		t.Fatalf("unexpected store enabled: %v", c.StoreEnabled)
+	} else if c.StoreDatabase != "audit" {
+		t.Fatalf("unexpected store database: %s", c.StoreDatabase)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := audit.NewConfig()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected validation fail from NewConfig: %s", err)
+	}
+
+	c = audit.NewConfig()
+	c.Enabled = true
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for empty path, got nil")
+	}
+
+	c.Path = "audit.log"
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for empty head-path, got nil")
+	}
+
+	c.HeadPath = "audit.log"
+	c.Key = "secret"
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for head-path in the log, got nil")
+	}
+
+	c.HeadPath = "audit.head"
+	c.Key = ""
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for empty key, got nil")
+	}
+
+	c.Key = "secret"
+	c.StoreEnabled = true
+	c.StoreDatabase = ""
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for empty store-database, got nil")
+	}
+}
@@ -0,0 +1,307 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Event categories.
+const (
+	// CategoryDDL covers statements that create, alter or drop databases,
+	// retention policies, continuous queries and subscriptions.
+	CategoryDDL = "ddl"
+
+	// CategoryUser covers user management and privilege changes.
+	CategoryUser = "user"
+
+	// CategoryDelete covers statements that delete data.
+	CategoryDelete = "delete"
+
+	// CategoryAdmin covers other administrative operations.
+	CategoryAdmin = "admin"
+
+	// CategoryAuth covers failed authentication attempts.
+	CategoryAuth = "auth"
+)
+
+// ErrLogCorrupt is returned when an audit log fails verification.
+var ErrLogCorrupt = errors.New("audit log is corrupt")
+
+// Event is an auditable operation.
+type Event struct {
+	Time      time.Time `json:"time"`
+	Category  string    `json:"category"`
+	User      string    `json:"user,omitempty"`
+	Addr      string    `json:"addr,omitempty"`
+	Database  string    `json:"database,omitempty"`
+	Statement string    `json:"statement,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Entry is an Event as written to the log. Each entry includes the hash of
+// the previous one, so removing or editing an entry breaks the chain.
+type Entry struct {
+	Seq uint64 `json:"seq"`
+	Event
+	Prev string `json:"prev"`
+	Hash string `json:"hash"`
+}
+
+// sum returns the HMAC of the entry keyed with key, excluding its Hash field.
+// The chain cannot be recomputed without the key.
+func (e Entry) sum(key []byte) (string, error) {
+	e.Hash = ""
+	buf, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(buf)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Head is the last entry of a log. It is kept in a file outside of the log,
+// so that removing entries from the end of the log can be detected.
+type Head struct {
+	Seq  uint64 `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// ReadHead reads the head of a log from the file at path. It returns nil if
+// the file does not exist.
+func ReadHead(path string) (*Head, error) {
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var h Head
+	if err := json.Unmarshal(buf, &h); err != nil {
+		return nil, fmt.Errorf("%v: %s: %v", ErrLogCorrupt, path, err)
+	}
+	return &h, nil
+}
+
+// writeHead replaces the head file at path with h.
+func writeHead(path string, h Head) error {
+	buf, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	} else if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	} else if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Log is an append-only audit log file, whose entries are chained with an
+// HMAC and whose last entry is anchored in a head file.
+type Log struct {
+	mu       sync.Mutex
+	f        *os.File
+	headPath string
+	key      []byte
+	seq      uint64
+	prev     string
+}
+
+// OpenLog opens the log at path, creating it if it does not exist. Entries
+// are chained with key, and the last one is written to the file at headPath.
+// An error is returned if the log does not end with its head.
+func OpenLog(path, headPath string, key []byte) (*Log, error) {
+	if len(key) == 0 {
+		return nil, errors.New("audit log key required")
+	}
+	for _, p := range []string{path, headPath} {
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			return nil, err
+		}
+	}
+
+	head, err := ReadHead(headPath)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	// Continue the chain from the last entry.
+	l := &Log{f: f, headPath: headPath, key: key}
+	last, err := lastEntry(f)
+	if err == nil {
+		err = l.checkHead(last, head)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if last != nil {
+		l.seq, l.prev = last.Seq, last.Hash
+	}
+	return l, nil
+}
+
+// checkHead returns an error unless the last entry of the log is the head,
+// or directly follows it because the head was not written after it. The head
+// is then brought up to date.
+func (l *Log) checkHead(last *Entry, head *Head) error {
+	if last == nil && head == nil {
+		return nil
+	} else if last == nil {
+		return fmt.Errorf("%v: entries up to %d are missing", ErrLogCorrupt, head.Seq)
+	} else if head == nil {
+		return fmt.Errorf("%v: head file %s is missing", ErrLogCorrupt, l.headPath)
+	}
+
+	if sum, err := last.sum(l.key); err != nil {
+		return err
+	} else if sum != last.Hash {
+		return fmt.Errorf("%v: entry %d has been modified or the key has changed", ErrLogCorrupt, last.Seq)
+	}
+
+	switch {
+	case last.Seq == head.Seq && last.Hash == head.Hash:
+		return nil
+	case last.Seq == head.Seq+1 && last.Prev == head.Hash:
+		return writeHead(l.headPath, Head{Seq: last.Seq, Hash: last.Hash})
+	default:
+		return fmt.Errorf("%v: log ends with entry %d, head is entry %d", ErrLogCorrupt, last.Seq, head.Seq)
+	}
+}
+
+// Append writes e to the log and syncs it to disk.
+func (l *Log) Append(e Event) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f == nil {
+		return Entry{}, errors.New("audit log closed")
+	}
+
+	entry := Entry{Seq: l.seq + 1, Event: e, Prev: l.prev}
+	hash, err := entry.sum(l.key)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.Hash = hash
+
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return Entry{}, err
+	}
+	if _, err := l.f.Write(append(buf, '\n')); err != nil {
+		return Entry{}, err
+	} else if err := l.f.Sync(); err != nil {
+		return Entry{}, err
+	}
+
+	l.seq, l.prev = entry.Seq, entry.Hash
+
+	// The entry is in the log even if its head cannot be written, and the
+	// head is brought up to date when the log is opened again.
+	if err := writeHead(l.headPath, Head{Seq: entry.Seq, Hash: entry.Hash}); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// Close closes the log file.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// Verify reads a log from r and checks that its entries are chained with key
+// and that it ends with head, if head is not nil. It returns the number of
+// entries read.
+func Verify(r io.Reader, key []byte, head *Head) (int, error) {
+	var n int
+	var prev string
+	err := scanEntries(r, func(e *Entry) error {
+		if e.Seq != uint64(n+1) {
+			return fmt.Errorf("%v: entry %d has sequence %d", ErrLogCorrupt, n+1, e.Seq)
+		} else if e.Prev != prev {
+			return fmt.Errorf("%v: entry %d does not follow entry %d", ErrLogCorrupt, e.Seq, n)
+		}
+		if sum, err := e.sum(key); err != nil {
+			return err
+		} else if sum != e.Hash {
+			return fmt.Errorf("%v: entry %d has been modified", ErrLogCorrupt, e.Seq)
+		}
+		n, prev = n+1, e.Hash
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+
+	if head != nil && (uint64(n) != head.Seq || prev != head.Hash) {
+		return n, fmt.Errorf("%v: log ends with entry %d, head is entry %d", ErrLogCorrupt, n, head.Seq)
+	}
+	return n, nil
+}
+
+// lastEntry returns the last entry in f, or nil if f is empty.
+func lastEntry(f *os.File) (*Entry, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var last *Entry
+	err := scanEntries(f, func(e *Entry) error {
+		last = e
+		return nil
+	})
+	return last, err
+}
+
+// scanEntries calls fn for each entry read from r.
+func scanEntries(r io.Reader, fn func(e *Entry) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("%v: %v", ErrLogCorrupt, err)
+		}
+		if err := fn(&e); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
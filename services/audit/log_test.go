@@ -0,0 +1,152 @@
+package audit_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/services/audit"
+)
+
+var key = []byte("secret")
+
+func TestLog_Verify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path, headPath := filepath.Join(dir, "audit.log"), filepath.Join(dir, "head", "audit.head")
+
+	l, err := audit.OpenLog(path, headPath, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Append(audit.Event{Category: audit.CategoryDDL, User: "admin", Statement: "CREATE DATABASE db0"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopening continues the chain.
+	l, err = audit.OpenLog(path, headPath, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, err := l.Append(audit.Event{Category: audit.CategoryDelete, User: "admin", Statement: "DROP DATABASE db0"}); err != nil {
+		t.Fatal(err)
+	} else if e.Seq != 2 || e.Prev == "" {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+	l.Close()
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := audit.ReadHead(headPath)
+	if err != nil {
+		t.Fatal(err)
+	} else if head == nil || head.Seq != 2 {
+		t.Fatalf("unexpected head: %+v", head)
+	}
+	if n, err := audit.Verify(bytes.NewReader(buf), key, head); err != nil {
+		t.Fatal(err)
+	} else if n != 2 {
+		t.Fatalf("unexpected entry count: %d", n)
+	}
+
+	// Editing an entry is detected.
+	tampered := strings.Replace(string(buf), "DROP DATABASE db0", "DROP DATABASE db1", 1)
+	if _, err := audit.Verify(strings.NewReader(tampered), key, head); err == nil || !strings.Contains(err.Error(), audit.ErrLogCorrupt.Error()) {
+		t.Fatalf("expected corrupt log error, got %v", err)
+	}
+
+	// Removing an entry is detected.
+	lines := strings.SplitAfter(string(buf), "\n")
+	if _, err := audit.Verify(strings.NewReader(lines[1]), key, head); err == nil {
+		t.Fatal("expected error for removed entry, got nil")
+	}
+
+	// So is removing the last entry.
+	if _, err := audit.Verify(strings.NewReader(lines[0]), key, head); err == nil || !strings.Contains(err.Error(), audit.ErrLogCorrupt.Error()) {
+		t.Fatalf("expected corrupt log error for truncated log, got %v", err)
+	}
+
+	// The chain cannot be verified without the key.
+	if _, err := audit.Verify(bytes.NewReader(buf), []byte("other"), head); err == nil {
+		t.Fatal("expected error for wrong key, got nil")
+	}
+}
+
+// Ensure a log that does not end with its head is not opened.
+func TestLog_Open_Truncated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path, headPath := filepath.Join(dir, "audit.log"), filepath.Join(dir, "audit.head")
+
+	l, err := audit.OpenLog(path, headPath, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{"DROP DATABASE db0", "DROP DATABASE db1"} {
+		if _, err := l.Append(audit.Event{Category: audit.CategoryDelete, User: "admin", Statement: stmt}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Close()
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(string(buf), "\n")
+	if err := ioutil.WriteFile(path, []byte(lines[0]), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := audit.OpenLog(path, headPath, key); err == nil || !strings.Contains(err.Error(), audit.ErrLogCorrupt.Error()) {
+		t.Fatalf("expected corrupt log error, got %v", err)
+	}
+
+	// A log whose head is missing is not opened either.
+	if err := ioutil.WriteFile(path, buf, 0600); err != nil {
+		t.Fatal(err)
+	} else if err := os.Remove(headPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := audit.OpenLog(path, headPath, key); err == nil {
+		t.Fatal("expected error for missing head, got nil")
+	}
+
+	// A log whose last entry was written without its head is opened, as
+	// when the node crashed in between, and its head is brought up to date.
+	var first audit.Entry
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	} else if err := ioutil.WriteFile(headPath, []byte(fmt.Sprintf(`{"seq":%d,"hash":%q}`, first.Seq, first.Hash)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if l, err = audit.OpenLog(path, headPath, key); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	if head, err := audit.ReadHead(headPath); err != nil {
+		t.Fatal(err)
+	} else if head.Seq != 2 {
+		t.Fatalf("unexpected head: %+v", head)
+	}
+
+	// A log is not opened with another key.
+	if _, err := audit.OpenLog(path, headPath, []byte("other")); err == nil {
+		t.Fatal("expected error for wrong key, got nil")
+	}
+}
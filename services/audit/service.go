@@ -0,0 +1,183 @@
+// Package audit records administrative and data-deleting operations to a
+// tamper-evident log.
+package audit // import "github.com/freetsdb/freetsdb/services/audit"
+
+import (
+	"sync"
+	"time"
+
+	"github.com/freetsdb/freetsdb/models"
+	"go.uber.org/zap"
+)
+
+// storeBatchSize is the number of events buffered for the store database.
+// Events are dropped from the store, but never from the log, when it is full.
+const storeBatchSize = 1000
+
+// Service writes audit events to the audit log.
+type Service struct {
+	// PointsWriter writes events to the store database, if enabled.
+	PointsWriter interface {
+		WritePoints(database, retentionPolicy string, points models.Points) error
+	}
+
+	config Config
+	log    *Log
+
+	points chan models.Point
+	wg     sync.WaitGroup
+	done   chan struct{}
+
+	Logger *zap.Logger
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	return &Service{
+		config: c,
+		Logger: zap.NewNop(),
+	}
+}
+
+// Open opens the audit log.
+func (s *Service) Open() error {
+	if !s.config.Enabled || s.log != nil {
+		return nil
+	}
+
+	log, err := OpenLog(s.config.Path, s.config.HeadPath, []byte(s.config.Key))
+	if err != nil {
+		return err
+	}
+	s.log = log
+	s.Logger.Info("Opened audit log", zap.String("path", s.config.Path))
+
+	if s.config.StoreEnabled {
+		s.points = make(chan models.Point, storeBatchSize)
+		s.done = make(chan struct{})
+		s.wg.Add(1)
+		go func() { defer s.wg.Done(); s.storeEvents() }()
+	}
+	return nil
+}
+
+// Close closes the audit log. Events recorded after Close are logged as
+// errors rather than written.
+func (s *Service) Close() error {
+	if s.log == nil {
+		return nil
+	}
+
+	if s.done != nil {
+		close(s.done)
+		s.wg.Wait()
+		s.done = nil
+	}
+	return s.log.Close()
+}
+
+// WithLogger sets the logger on the service.
+func (s *Service) WithLogger(log *zap.Logger) {
+	s.Logger = log.With(zap.String("service", "audit"))
+}
+
+// Record writes e to the audit log. Failures are logged rather than
+// returned so auditing never fails the operation being audited.
+func (s *Service) Record(e Event) {
+	if s.log == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+
+	entry, err := s.log.Append(e)
+	if err != nil {
+		s.Logger.Error("Failed to write audit event",
+			zap.String("category", e.Category),
+			zap.String("user", e.User),
+			zap.String("statement", e.Statement),
+			zap.Error(err))
+		return
+	}
+
+	if s.points == nil {
+		return
+	}
+	pt, err := entry.point()
+	if err != nil {
+		s.Logger.Info("Failed to create audit point", zap.Error(err))
+		return
+	}
+	select {
+	case s.points <- pt:
+	default:
+		s.Logger.Info("Audit store buffer full, dropping event", zap.Uint64("seq", entry.Seq))
+	}
+}
+
+// storeEvents writes buffered events to the store database once a second.
+func (s *Service) storeEvents() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var batch models.Points
+	flush := func() {
+		if len(batch) == 0 || s.PointsWriter == nil {
+			batch = nil
+			return
+		}
+		if err := s.PointsWriter.WritePoints(s.config.StoreDatabase, "", batch); err != nil {
+			s.Logger.Info("Failed to store audit events",
+				zap.String("db", s.config.StoreDatabase),
+				zap.Error(err))
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case pt := <-s.points:
+			batch = append(batch, pt)
+		case <-ticker.C:
+			flush()
+		case <-s.done:
+			// Drain anything still buffered.
+			for {
+				select {
+				case pt := <-s.points:
+					batch = append(batch, pt)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// point returns the entry as a point in the store measurement.
+func (e Entry) point() (models.Point, error) {
+	tags := map[string]string{"category": e.Category}
+	if e.User != "" {
+		tags["user"] = e.User
+	}
+
+	fields := map[string]interface{}{
+		"seq":  int64(e.Seq),
+		"hash": e.Hash,
+	}
+	if e.Addr != "" {
+		fields["addr"] = e.Addr
+	}
+	if e.Database != "" {
+		fields["database"] = e.Database
+	}
+	if e.Statement != "" {
+		fields["statement"] = e.Statement
+	}
+	if e.Error != "" {
+		fields["error"] = e.Error
+	}
+	return models.NewPoint(DefaultStoreMeasurement, models.NewTags(tags), fields, e.Time)
+}
@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+// StatementCategory returns the category stmt is audited under, or an empty
+// string if it is not audited.
+func StatementCategory(stmt influxql.Statement) string {
+	switch stmt.(type) {
+	case *influxql.CreateDatabaseStatement,
+		*influxql.DropDatabaseStatement,
+		*influxql.CreateRetentionPolicyStatement,
+		*influxql.AlterRetentionPolicyStatement,
+		*influxql.DropRetentionPolicyStatement,
+		*influxql.CreateContinuousQueryStatement,
+		*influxql.DropContinuousQueryStatement,
+		*influxql.CreateMaterializedViewStatement,
+		*influxql.DropMaterializedViewStatement,
+		*influxql.CreateQueryTemplateStatement,
+		*influxql.DropQueryTemplateStatement,
+		*influxql.CreateSubscriptionStatement,
+		*influxql.DropSubscriptionStatement:
+		return CategoryDDL
+	case *influxql.CreateUserStatement,
+		*influxql.DropUserStatement,
+		*influxql.SetPasswordUserStatement,
+		*influxql.GrantStatement,
+		*influxql.GrantAdminStatement,
+		*influxql.RevokeStatement,
+		*influxql.RevokeAdminStatement,
+		*influxql.CreateRoleStatement,
+		*influxql.DropRoleStatement,
+		*influxql.GrantRoleStatement,
+		*influxql.GrantRolePrivilegeStatement,
+		*influxql.RevokeRoleStatement,
+		*influxql.RevokeRolePrivilegeStatement:
+		return CategoryUser
+	case *influxql.DeleteStatement,
+		*influxql.DeleteSeriesStatement,
+		*influxql.DropSeriesStatement,
+		*influxql.DropMeasurementStatement,
+		*influxql.DropShardStatement:
+		return CategoryDelete
+	case *influxql.KillQueryStatement,
+		*influxql.KillSessionStatement,
+		*influxql.TruncateShardsStatement,
+		*influxql.CopyShardStatement,
+		*influxql.ReindexShardStatement:
+		return CategoryAdmin
+	}
+	return ""
+}
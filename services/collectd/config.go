@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/freetsdb/freetsdb/monitor/diagnostics"
+	"github.com/freetsdb/freetsdb/pkg/netutil"
 	"github.com/freetsdb/freetsdb/toml"
 )
 
@@ -129,6 +130,12 @@ func (c *Config) WithDefaults() *Config {
 
 // Validate returns an error if the Config is invalid.
 func (c *Config) Validate() error {
+	if c.BindAddress != "" {
+		if err := netutil.ValidateBindAddress(c.BindAddress); err != nil {
+			return err
+		}
+	}
+
 	switch c.SecurityLevel {
 	case "none", "sign", "encrypt":
 	default:
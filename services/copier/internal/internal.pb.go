@@ -25,6 +25,7 @@ var _ = math.Inf
 
 type Request struct {
 	ShardID          *uint64 `protobuf:"varint,1,req,name=ShardID" json:"ShardID,omitempty"`
+	Format           *string `protobuf:"bytes,2,opt,name=Format" json:"Format,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -39,8 +40,16 @@ func (m *Request) GetShardID() uint64 {
 	return 0
 }
 
+func (m *Request) GetFormat() string {
+	if m != nil && m.Format != nil {
+		return *m.Format
+	}
+	return ""
+}
+
 type Response struct {
 	Error            *string `protobuf:"bytes,1,opt,name=Error" json:"Error,omitempty"`
+	Format           *string `protobuf:"bytes,2,opt,name=Format" json:"Format,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -54,3 +63,10 @@ func (m *Response) GetError() string {
 	}
 	return ""
 }
+
+func (m *Response) GetFormat() string {
+	if m != nil && m.Format != nil {
+		return *m.Format
+	}
+	return ""
+}
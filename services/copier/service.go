@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/freetsdb/freetsdb/services/copier/internal"
@@ -21,6 +24,12 @@ import (
 // MuxHeader is the header byte used for the TCP muxer.
 const MuxHeader = 6
 
+// BackupFormat is the format of a shard streamed as a tar archive of its
+// files, as written by a backup of the shard. Clients ask for it, and nodes
+// that support it say so in the response; other nodes stream the shard with
+// its engine's WriteTo.
+const BackupFormat = "backup"
+
 // Service manages the listener for the endpoint.
 type Service struct {
 	wg  sync.WaitGroup
@@ -116,13 +125,26 @@ func (s *Service) handleConn(conn net.Conn) error {
 		return nil
 	}
 
+	// Clients that do not ask for a backup get the shard as they always did.
+	if req.GetFormat() != BackupFormat {
+		if err := s.writeResponse(conn, &internal.Response{}); err != nil {
+			return fmt.Errorf("write response: %s", err)
+		}
+		if _, err := sh.WriteTo(conn); err != nil {
+			return fmt.Errorf("write shard: %s", err)
+		}
+		return nil
+	}
+
 	// Write successful response.
-	if err := s.writeResponse(conn, &internal.Response{}); err != nil {
+	if err := s.writeResponse(conn, &internal.Response{Format: proto.String(BackupFormat)}); err != nil {
 		return fmt.Errorf("write response: %s", err)
 	}
 
-	// Write shard to response.
-	if _, err := sh.WriteTo(conn); err != nil {
+	// Write a backup of the shard to the response. The files are named
+	// relative to the store path, as RestoreShard expects them.
+	path := filepath.Join(sh.Database(), sh.RetentionPolicy(), strconv.FormatUint(sh.ID(), 10))
+	if err := sh.Backup(conn, path, time.Time{}); err != nil {
 		return fmt.Errorf("write shard: %s", err)
 	}
 
@@ -173,6 +195,10 @@ func (s *Service) writeResponse(w io.Writer, resp *internal.Response) error {
 	return nil
 }
 
+// ErrBackupFormatUnsupported is returned by ShardReader when the remote node
+// cannot stream shards in BackupFormat, as it runs an older version.
+var ErrBackupFormatUnsupported = errors.New("node does not support copying shards as backups, upgrade it first")
+
 // Client represents a client for connecting remotely to a copier service.
 type Client struct {
 	host string
@@ -185,7 +211,8 @@ func NewClient(host string) *Client {
 	}
 }
 
-// ShardReader returns a reader for streaming shard data.
+// ShardReader returns a reader for streaming shard data. The data is a tar
+// archive of the shard files, as written by a backup of the shard.
 // Returned ReadCloser must be closed by the caller.
 func (c *Client) ShardReader(id uint64) (io.ReadCloser, error) {
 	// Connect to remote server.
@@ -195,7 +222,7 @@ func (c *Client) ShardReader(id uint64) (io.ReadCloser, error) {
 	}
 
 	// Send request to server.
-	if err := c.writeRequest(conn, &internal.Request{ShardID: proto.Uint64(id)}); err != nil {
+	if err := c.writeRequest(conn, &internal.Request{ShardID: proto.Uint64(id), Format: proto.String(BackupFormat)}); err != nil {
 		return nil, fmt.Errorf("write request: %s", err)
 	}
 
@@ -211,6 +238,13 @@ func (c *Client) ShardReader(id uint64) (io.ReadCloser, error) {
 		return nil, errors.New(resp.GetError())
 	}
 
+	// A node that predates backups streams the shard in a format the
+	// caller cannot restore.
+	if resp.GetFormat() != BackupFormat {
+		conn.Close()
+		return nil, ErrBackupFormatUnsupported
+	}
+
 	// Returning remaining stream for caller to consume.
 	return conn, nil
 }
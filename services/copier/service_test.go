@@ -86,6 +86,41 @@ func TestService_handleConn_Error(t *testing.T) {
 	}
 }
 
+// Ensure the client does not read a shard from a node that cannot stream it
+// as a backup.
+func TestClient_ShardReader_FormatUnsupported(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	mux := tcp.NewMux()
+	muxln := mux.Listen(copier.MuxHeader)
+	go mux.Serve(ln)
+
+	// The node answers as nodes that predate backups do.
+	go func() {
+		conn, err := muxln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var n uint32
+		if err := binary.Read(conn, binary.BigEndian, &n); err != nil {
+			return
+		} else if _, err := io.CopyN(ioutil.Discard, conn, int64(n)); err != nil {
+			return
+		}
+		binary.Write(conn, binary.BigEndian, uint32(0))
+	}()
+
+	if _, err := copier.NewClient(ln.Addr().String()).ShardReader(123); err != copier.ErrBackupFormatUnsupported {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 // Service represents a test wrapper for copier.Service.
 type Service struct {
 	*copier.Service
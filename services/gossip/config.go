@@ -0,0 +1,85 @@
+package gossip
+
+import (
+	"errors"
+	"time"
+
+	"github.com/freetsdb/freetsdb/monitor/diagnostics"
+	"github.com/freetsdb/freetsdb/toml"
+)
+
+const (
+	// DefaultProbeInterval is the time between two probes of a node.
+	DefaultProbeInterval = time.Second
+
+	// DefaultProbeTimeout is the time a node has to acknowledge a probe.
+	DefaultProbeTimeout = 500 * time.Millisecond
+
+	// DefaultIndirectProbes is the number of nodes asked to probe a node
+	// that did not acknowledge a probe.
+	DefaultIndirectProbes = 3
+
+	// DefaultSuspicionTimeout is the time a suspected node has to refute the
+	// suspicion before it is declared dead.
+	DefaultSuspicionTimeout = 5 * time.Second
+)
+
+// Config represents the configuration for the failure detection of data
+// nodes.
+type Config struct {
+	Enabled          bool          `toml:"enabled"`
+	ProbeInterval    toml.Duration `toml:"probe-interval"`
+	ProbeTimeout     toml.Duration `toml:"probe-timeout"`
+	IndirectProbes   int           `toml:"indirect-probes"`
+	SuspicionTimeout toml.Duration `toml:"suspicion-timeout"`
+}
+
+// NewConfig returns a new Config with defaults. Failure detection is
+// disabled by default, as nodes that do not run it are declared dead.
+func NewConfig() Config {
+	return Config{
+		Enabled:          false,
+		ProbeInterval:    toml.Duration(DefaultProbeInterval),
+		ProbeTimeout:     toml.Duration(DefaultProbeTimeout),
+		IndirectProbes:   DefaultIndirectProbes,
+		SuspicionTimeout: toml.Duration(DefaultSuspicionTimeout),
+	}
+}
+
+// Validate returns an error if the Config is invalid.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.ProbeInterval <= 0 {
+		return errors.New("probe-interval must be positive")
+	}
+	if c.ProbeTimeout <= 0 {
+		return errors.New("probe-timeout must be positive")
+	}
+	if c.IndirectProbes < 0 {
+		return errors.New("indirect-probes must not be negative")
+	}
+	if c.SuspicionTimeout <= 0 {
+		return errors.New("suspicion-timeout must be positive")
+	}
+	return nil
+}
+
+// Diagnostics returns a diagnostics representation of a subset of the Config.
+func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
+	if !c.Enabled {
+		return diagnostics.RowFromMap(map[string]interface{}{
+			"enabled": false,
+		}), nil
+	}
+
+	return diagnostics.RowFromMap(map[string]interface{}{
+		"enabled":           true,
+		"probe-interval":    c.ProbeInterval,
+		"probe-timeout":     c.ProbeTimeout,
+		"indirect-probes":   c.IndirectProbes,
+		"suspicion-timeout": c.SuspicionTimeout,
+	}), nil
+}
@@ -0,0 +1,54 @@
+package gossip_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/freetsdb/freetsdb/services/gossip"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	var c gossip.Config
+	if _, err := toml.Decode(`
+enabled = true
+probe-interval = "2s"
+probe-timeout = "1s"
+indirect-probes = 2
+suspicion-timeout = "10s"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.Enabled {
+		t.Fatalf("unexpected enabled state: %v", c.Enabled)
+	} else if time.Duration(c.ProbeInterval) != 2*time.Second {
+		t.Fatalf("unexpected probe interval: %s", c.ProbeInterval)
+	} else if time.Duration(c.ProbeTimeout) != time.Second {
+		t.Fatalf("unexpected probe timeout: %s", c.ProbeTimeout)
+	} else if c.IndirectProbes != 2 {
+		t.Fatalf("unexpected indirect probes: %d", c.IndirectProbes)
+	} else if time.Duration(c.SuspicionTimeout) != 10*time.Second {
+		t.Fatalf("unexpected suspicion timeout: %s", c.SuspicionTimeout)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := gossip.NewConfig()
+	c.Enabled = true
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected validation fail from NewConfig: %s", err)
+	}
+
+	c.ProbeTimeout = 0
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for probe-timeout = 0, got nil")
+	}
+
+	c = gossip.NewConfig()
+	c.Enabled = true
+	c.IndirectProbes = -1
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for negative indirect-probes, got nil")
+	}
+}
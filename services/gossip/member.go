@@ -0,0 +1,108 @@
+package gossip
+
+import (
+	"fmt"
+	"time"
+)
+
+// State is the state of a node as seen by the other nodes.
+type State int
+
+// The states are ordered: of two states of a node with the same
+// incarnation, the greater one overrides the other.
+const (
+	// StateAlive is the state of a node acknowledging probes.
+	StateAlive State = iota
+
+	// StateSuspect is the state of a node that did not acknowledge a probe,
+	// directly or indirectly, and may still refute the suspicion.
+	StateSuspect
+
+	// StateDead is the state of a node that did not refute a suspicion in
+	// time. Nothing is sent to dead nodes until they are alive again.
+	StateDead
+)
+
+// String returns the name of s.
+func (s State) String() string {
+	switch s {
+	case StateAlive:
+		return "alive"
+	case StateSuspect:
+		return "suspect"
+	case StateDead:
+		return "dead"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes s as its name.
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a state encoded by MarshalText.
+func (s *State) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "alive":
+		*s = StateAlive
+	case "suspect":
+		*s = StateSuspect
+	case "dead":
+		*s = StateDead
+	default:
+		return fmt.Errorf("unknown node state %q", text)
+	}
+	return nil
+}
+
+// Member is the state of a data node as seen by this node.
+type Member struct {
+	NodeID uint64 `json:"node_id"`
+	Addr   string `json:"addr"`
+	State  State  `json:"state"`
+
+	// Incarnation is increased by the node to refute a suspicion of it.
+	Incarnation uint64 `json:"incarnation"`
+
+	// Changed is when the state last changed.
+	Changed time.Time `json:"changed"`
+}
+
+// update is the state of a node piggybacked on the messages between nodes.
+type update struct {
+	NodeID      uint64 `json:"node_id"`
+	State       State  `json:"state"`
+	Incarnation uint64 `json:"incarnation"`
+}
+
+// supersedes returns true if u is newer than the state of m.
+func (u update) supersedes(m *Member) bool {
+	if u.Incarnation != m.Incarnation {
+		return u.Incarnation > m.Incarnation
+	}
+	return u.State > m.State
+}
+
+// message is a message between nodes. Every message carries the state of
+// all the nodes known to its sender.
+type message struct {
+	Type string `json:"type"`
+	From uint64 `json:"from"`
+
+	// Target is the node to probe for a ping-req.
+	Target uint64 `json:"target,omitempty"`
+
+	// Acked is set on the ack of a ping-req if the target acknowledged.
+	Acked bool `json:"acked,omitempty"`
+
+	Updates []update `json:"updates"`
+}
+
+// The types of messages.
+const (
+	pingMessage    = "ping"
+	pingReqMessage = "ping-req"
+	ackMessage     = "ack"
+)
@@ -0,0 +1,467 @@
+// Package gossip detects the failure of data nodes. The data nodes probe each
+// other and disseminate the state of the cluster on the probes, as in SWIM,
+// so that a dead node is known to every node within a few seconds.
+package gossip // import "github.com/freetsdb/freetsdb/services/gossip"
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/freetsdb/freetsdb"
+	"github.com/freetsdb/freetsdb/logger"
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"go.uber.org/zap"
+)
+
+// MuxHeader is the header byte used for the TCP muxer.
+const MuxHeader = 10
+
+// Statistics keys for the gossip service.
+const (
+	statProbes         = "probes"
+	statProbeFailures  = "probeFailures"
+	statIndirectProbes = "indirectProbes"
+	statRefutes        = "refutes"
+	statMembersAlive   = "membersAlive"
+	statMembersSuspect = "membersSuspect"
+	statMembersDead    = "membersDead"
+)
+
+// Service probes the other data nodes and keeps the state of each.
+type Service struct {
+	Node *freetsdb.Node
+
+	MetaClient interface {
+		DataNodes() ([]meta.NodeInfo, error)
+	}
+
+	Listener net.Listener
+	Logger   *zap.Logger
+
+	config Config
+
+	mu          sync.RWMutex
+	incarnation uint64
+	members     map[uint64]*Member
+
+	// order is the order the members are probed in, shuffled every round.
+	order []uint64
+
+	stats   *Statistics
+	closing chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	return &Service{
+		config:  c,
+		members: make(map[uint64]*Member),
+		stats:   &Statistics{},
+		Logger:  zap.NewNop(),
+	}
+}
+
+// WithLogger sets the logger on the service.
+func (s *Service) WithLogger(log *zap.Logger) {
+	s.Logger = log.With(zap.String("service", "gossip"))
+}
+
+// Open starts probing the other data nodes.
+func (s *Service) Open() error {
+	if s.closing != nil {
+		return nil
+	}
+
+	s.Logger.Info("Starting gossip service",
+		logger.DurationLiteral("probe_interval", time.Duration(s.config.ProbeInterval)),
+		logger.DurationLiteral("suspicion_timeout", time.Duration(s.config.SuspicionTimeout)))
+
+	// The incarnation of a restarted node supersedes the one it was declared
+	// dead with.
+	s.incarnation = uint64(time.Now().UnixNano())
+	s.closing = make(chan struct{})
+
+	if s.Listener != nil {
+		s.wg.Add(1)
+		go s.serve()
+	}
+	s.wg.Add(1)
+	go s.run()
+	return nil
+}
+
+// Close stops probing the other data nodes.
+func (s *Service) Close() error {
+	if s.closing == nil {
+		return nil
+	}
+	if s.Listener != nil {
+		s.Listener.Close()
+	}
+	close(s.closing)
+	s.wg.Wait()
+	s.closing = nil
+	return nil
+}
+
+// Dead returns true if the data node with nodeID is dead. Unknown nodes are
+// not dead.
+func (s *Service) Dead(nodeID uint64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m, ok := s.members[nodeID]
+	return ok && m.State == StateDead
+}
+
+// Members returns the state of the other data nodes.
+func (s *Service) Members() []Member {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a := make([]Member, 0, len(s.members))
+	for _, m := range s.members {
+		a = append(a, *m)
+	}
+	return a
+}
+
+// Statistics maintains statistics for the gossip service.
+type Statistics struct {
+	Probes         int64
+	ProbeFailures  int64
+	IndirectProbes int64
+	Refutes        int64
+}
+
+// Statistics returns statistics for periodic monitoring.
+func (s *Service) Statistics(tags map[string]string) []models.Statistic {
+	var alive, suspect, dead int64
+	s.mu.RLock()
+	for _, m := range s.members {
+		switch m.State {
+		case StateAlive:
+			alive++
+		case StateSuspect:
+			suspect++
+		case StateDead:
+			dead++
+		}
+	}
+	s.mu.RUnlock()
+
+	return []models.Statistic{{
+		Name: "gossip",
+		Tags: tags,
+		Values: map[string]interface{}{
+			statProbes:         atomic.LoadInt64(&s.stats.Probes),
+			statProbeFailures:  atomic.LoadInt64(&s.stats.ProbeFailures),
+			statIndirectProbes: atomic.LoadInt64(&s.stats.IndirectProbes),
+			statRefutes:        atomic.LoadInt64(&s.stats.Refutes),
+			statMembersAlive:   alive,
+			statMembersSuspect: suspect,
+			statMembersDead:    dead,
+		},
+	}}
+}
+
+// nodeID returns the ID of this node.
+func (s *Service) nodeID() uint64 {
+	if s.Node == nil {
+		return 0
+	}
+	return s.Node.ID
+}
+
+// run probes a data node every probe interval.
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(s.config.ProbeInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+			if err := s.refresh(); err != nil {
+				s.Logger.Info("Failed to list data nodes", zap.Error(err))
+				continue
+			}
+			s.expireSuspects(time.Now())
+			if target, ok := s.next(); ok {
+				s.probe(target)
+			}
+		}
+	}
+}
+
+// refresh updates the members from the data nodes of the meta store. New
+// nodes are alive.
+func (s *Service) refresh() error {
+	nodes, err := s.MetaClient.DataNodes()
+	if err != nil {
+		return err
+	}
+
+	self := s.nodeID()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	known := make(map[uint64]bool, len(nodes))
+	for _, n := range nodes {
+		if n.ID == self {
+			continue
+		}
+		known[n.ID] = true
+		if m, ok := s.members[n.ID]; ok {
+			m.Addr = n.TCPHost
+			continue
+		}
+		s.members[n.ID] = &Member{NodeID: n.ID, Addr: n.TCPHost, State: StateAlive, Changed: time.Now()}
+	}
+	for id := range s.members {
+		if !known[id] {
+			delete(s.members, id)
+		}
+	}
+	return nil
+}
+
+// next returns the member to probe next. Every member is probed once per
+// round, in random order.
+func (s *Service) next() (Member, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		if len(s.order) == 0 {
+			if len(s.members) == 0 {
+				return Member{}, false
+			}
+			for id := range s.members {
+				s.order = append(s.order, id)
+			}
+			rand.Shuffle(len(s.order), func(i, j int) { s.order[i], s.order[j] = s.order[j], s.order[i] })
+		}
+
+		id := s.order[0]
+		s.order = s.order[1:]
+		if m, ok := s.members[id]; ok {
+			return *m, true
+		}
+	}
+}
+
+// probe pings target, and if it does not acknowledge, asks other members to
+// ping it. A target no one reaches is suspected.
+func (s *Service) probe(target Member) {
+	atomic.AddInt64(&s.stats.Probes, 1)
+	timeout := time.Duration(s.config.ProbeTimeout)
+
+	if _, err := s.send(target.Addr, message{Type: pingMessage}, timeout); err == nil {
+		return
+	}
+	atomic.AddInt64(&s.stats.ProbeFailures, 1)
+
+	helpers := s.helpers(target.NodeID, s.config.IndirectProbes)
+	if len(helpers) > 0 {
+		atomic.AddInt64(&s.stats.IndirectProbes, 1)
+
+		// The helpers ping the target with the probe timeout themselves.
+		acked := make(chan bool, len(helpers))
+		for _, h := range helpers {
+			go func(addr string) {
+				resp, err := s.send(addr, message{Type: pingReqMessage, Target: target.NodeID}, 2*timeout)
+				acked <- err == nil && resp.Acked
+			}(h.Addr)
+		}
+		for range helpers {
+			if <-acked {
+				return
+			}
+		}
+	}
+
+	s.suspect(target.NodeID)
+}
+
+// helpers returns up to n random alive members other than the target.
+func (s *Service) helpers(target uint64, n int) []Member {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var a []Member
+	for _, m := range s.members {
+		if m.NodeID != target && m.State == StateAlive {
+			a = append(a, *m)
+		}
+	}
+	rand.Shuffle(len(a), func(i, j int) { a[i], a[j] = a[j], a[i] })
+	if len(a) > n {
+		a = a[:n]
+	}
+	return a
+}
+
+// suspect suspects an alive member.
+func (s *Service) suspect(nodeID uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.members[nodeID]; ok && m.State == StateAlive {
+		s.setState(m, StateSuspect, m.Incarnation, time.Now())
+	}
+}
+
+// expireSuspects declares dead the members suspected for longer than the
+// suspicion timeout.
+func (s *Service) expireSuspects(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range s.members {
+		if m.State == StateSuspect && now.Sub(m.Changed) >= time.Duration(s.config.SuspicionTimeout) {
+			s.setState(m, StateDead, m.Incarnation, now)
+		}
+	}
+}
+
+// setState changes the state of m. It must be called with the lock held.
+func (s *Service) setState(m *Member, state State, incarnation uint64, now time.Time) {
+	if m.State != state {
+		s.Logger.Info("Data node state changed",
+			zap.Uint64("node_id", m.NodeID),
+			zap.String("from", m.State.String()),
+			zap.String("to", state.String()))
+		m.Changed = now
+	}
+	m.State, m.Incarnation = state, incarnation
+}
+
+// updates returns the state of this node and of the members, to piggyback
+// on a message.
+func (s *Service) updates() []update {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a := make([]update, 0, len(s.members)+1)
+	a = append(a, update{NodeID: s.nodeID(), State: StateAlive, Incarnation: s.incarnation})
+	for _, m := range s.members {
+		a = append(a, update{NodeID: m.NodeID, State: m.State, Incarnation: m.Incarnation})
+	}
+	return a
+}
+
+// merge applies the updates of another node. A suspicion of this node is
+// refuted by increasing its incarnation.
+func (s *Service) merge(updates []update) {
+	self := s.nodeID()
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range updates {
+		if u.NodeID == self {
+			if u.State != StateAlive && u.Incarnation >= s.incarnation {
+				s.incarnation = u.Incarnation + 1
+				atomic.AddInt64(&s.stats.Refutes, 1)
+			}
+			continue
+		}
+
+		// Nodes are only learned from the meta store.
+		m, ok := s.members[u.NodeID]
+		if !ok || !u.supersedes(m) {
+			continue
+		}
+		s.setState(m, u.State, u.Incarnation, now)
+	}
+}
+
+// send sends msg to the node at addr and returns its ack.
+func (s *Service) send(addr string, msg message, timeout time.Duration) (*message, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if _, err := conn.Write([]byte{MuxHeader}); err != nil {
+		return nil, err
+	}
+
+	msg.From = s.nodeID()
+	msg.Updates = s.updates()
+	if err := json.NewEncoder(conn).Encode(&msg); err != nil {
+		return nil, err
+	}
+
+	var resp message
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, err
+	}
+	s.merge(resp.Updates)
+	return &resp, nil
+}
+
+// serve answers the messages of the other nodes.
+func (s *Service) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.Listener.Accept()
+		if err != nil && strings.Contains(err.Error(), "connection closed") {
+			s.Logger.Info("Gossip listener closed")
+			return
+		} else if err != nil {
+			s.Logger.Info("Error accepting gossip message", zap.Error(err))
+			continue
+		}
+
+		s.wg.Add(1)
+		go func(conn net.Conn) {
+			defer s.wg.Done()
+			defer conn.Close()
+			if err := s.handleConn(conn); err != nil {
+				s.Logger.Info("Failed to handle gossip message", zap.Error(err))
+			}
+		}(conn)
+	}
+}
+
+// handleConn answers a message with an ack.
+func (s *Service) handleConn(conn net.Conn) error {
+	timeout := time.Duration(s.config.ProbeTimeout)
+	conn.SetDeadline(time.Now().Add(2 * timeout))
+
+	var msg message
+	if err := json.NewDecoder(conn).Decode(&msg); err != nil {
+		return err
+	}
+	s.merge(msg.Updates)
+
+	resp := message{Type: ackMessage}
+	if msg.Type == pingReqMessage {
+		s.mu.RLock()
+		target, ok := s.members[msg.Target]
+		var addr string
+		if ok {
+			addr = target.Addr
+		}
+		s.mu.RUnlock()
+
+		if ok {
+			_, err := s.send(addr, message{Type: pingMessage}, timeout)
+			resp.Acked = err == nil
+		}
+	}
+
+	resp.From = s.nodeID()
+	resp.Updates = s.updates()
+	return json.NewEncoder(conn).Encode(&resp)
+}
@@ -0,0 +1,108 @@
+package gossip_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/freetsdb/freetsdb"
+	"github.com/freetsdb/freetsdb/services/gossip"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/tcp"
+	"github.com/freetsdb/freetsdb/toml"
+)
+
+type metaClient struct {
+	nodes []meta.NodeInfo
+}
+
+func (c *metaClient) DataNodes() ([]meta.NodeInfo, error) { return c.nodes, nil }
+
+// testNode is a data node running the gossip service.
+type testNode struct {
+	ln      net.Listener
+	service *gossip.Service
+}
+
+func newTestNodes(t *testing.T, n int) []*testNode {
+	c := gossip.NewConfig()
+	c.Enabled = true
+	c.ProbeInterval = toml.Duration(10 * time.Millisecond)
+	c.ProbeTimeout = toml.Duration(50 * time.Millisecond)
+	c.SuspicionTimeout = toml.Duration(100 * time.Millisecond)
+
+	mc := &metaClient{}
+	nodes := make([]*testNode, n)
+	for i := range nodes {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		mux := tcp.NewMux()
+		go mux.Serve(ln)
+
+		s := gossip.NewService(c)
+		s.Node = &freetsdb.Node{ID: uint64(i + 1)}
+		s.MetaClient = mc
+		s.Listener = mux.Listen(gossip.MuxHeader)
+		nodes[i] = &testNode{ln: ln, service: s}
+		mc.nodes = append(mc.nodes, meta.NodeInfo{ID: uint64(i + 1), TCPHost: ln.Addr().String()})
+	}
+	for _, n := range nodes {
+		if err := n.service.Open(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return nodes
+}
+
+func (n *testNode) Close() {
+	n.service.Close()
+	n.ln.Close()
+}
+
+// waitFor waits for every node of nodes to see node id in state.
+func waitFor(t *testing.T, nodes []*testNode, id uint64, state gossip.State) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ok := true
+		for _, n := range nodes {
+			for _, m := range n.service.Members() {
+				if m.NodeID == id && m.State != state {
+					ok = false
+				}
+			}
+		}
+		if ok {
+			return
+		} else if time.Now().After(deadline) {
+			t.Fatalf("node %d not %s", id, state)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Ensure a node that stops answering probes is declared dead by every node.
+func TestService_Dead(t *testing.T) {
+	nodes := newTestNodes(t, 3)
+	defer nodes[0].Close()
+	defer nodes[1].Close()
+
+	time.Sleep(100 * time.Millisecond)
+	for _, n := range nodes {
+		if len(n.service.Members()) != 2 {
+			t.Fatalf("unexpected members: %v", n.service.Members())
+		}
+		for _, m := range n.service.Members() {
+			if m.State != gossip.StateAlive {
+				t.Fatalf("unexpected member state: %+v", m)
+			}
+		}
+	}
+
+	nodes[2].Close()
+	waitFor(t, nodes[:2], 3, gossip.StateDead)
+	if !nodes[0].service.Dead(3) || nodes[0].service.Dead(2) {
+		t.Fatal("unexpected dead nodes")
+	}
+}
@@ -7,6 +7,9 @@ import (
 
 	"github.com/freetsdb/freetsdb/models"
 	"github.com/freetsdb/freetsdb/monitor/diagnostics"
+	"github.com/freetsdb/freetsdb/pkg/netutil"
+	"github.com/freetsdb/freetsdb/pkg/relabel"
+	"github.com/freetsdb/freetsdb/pkg/routing"
 	"github.com/freetsdb/freetsdb/toml"
 )
 
@@ -52,19 +55,21 @@ const (
 
 // Config represents the configuration for Graphite endpoints.
 type Config struct {
-	Enabled          bool          `toml:"enabled"`
-	BindAddress      string        `toml:"bind-address"`
-	Database         string        `toml:"database"`
-	RetentionPolicy  string        `toml:"retention-policy"`
-	Protocol         string        `toml:"protocol"`
-	BatchSize        int           `toml:"batch-size"`
-	BatchPending     int           `toml:"batch-pending"`
-	BatchTimeout     toml.Duration `toml:"batch-timeout"`
-	ConsistencyLevel string        `toml:"consistency-level"`
-	Templates        []string      `toml:"templates"`
-	Tags             []string      `toml:"tags"`
-	Separator        string        `toml:"separator"`
-	UDPReadBuffer    int           `toml:"udp-read-buffer"`
+	Enabled          bool           `toml:"enabled"`
+	BindAddress      string         `toml:"bind-address"`
+	Database         string         `toml:"database"`
+	RetentionPolicy  string         `toml:"retention-policy"`
+	Protocol         string         `toml:"protocol"`
+	BatchSize        int            `toml:"batch-size"`
+	BatchPending     int            `toml:"batch-pending"`
+	BatchTimeout     toml.Duration  `toml:"batch-timeout"`
+	ConsistencyLevel string         `toml:"consistency-level"`
+	Templates        []string       `toml:"templates"`
+	Tags             []string       `toml:"tags"`
+	Separator        string         `toml:"separator"`
+	UDPReadBuffer    int            `toml:"udp-read-buffer"`
+	Relabel          []relabel.Rule `toml:"relabel"`
+	Routes           []routing.Rule `toml:"route"`
 }
 
 // NewConfig returns a new instance of Config with defaults.
@@ -127,6 +132,12 @@ func (c *Config) DefaultTags() models.Tags {
 
 // Validate validates the config's templates and tags.
 func (c *Config) Validate() error {
+	if c.BindAddress != "" {
+		if err := netutil.ValidateBindAddress(c.BindAddress); err != nil {
+			return err
+		}
+	}
+
 	if err := c.validateTemplates(); err != nil {
 		return err
 	}
@@ -135,6 +146,14 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if err := relabel.Validate(c.Relabel); err != nil {
+		return err
+	}
+
+	if err := routing.Validate(c.Routes); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -15,6 +15,8 @@ import (
 	"github.com/freetsdb/freetsdb/logger"
 	"github.com/freetsdb/freetsdb/models"
 	"github.com/freetsdb/freetsdb/monitor/diagnostics"
+	"github.com/freetsdb/freetsdb/pkg/relabel"
+	"github.com/freetsdb/freetsdb/pkg/routing"
 	"github.com/freetsdb/freetsdb/services/meta"
 	"github.com/freetsdb/freetsdb/tsdb"
 	"go.uber.org/zap"
@@ -55,8 +57,10 @@ type Service struct {
 	batchTimeout    time.Duration
 	udpReadBuffer   int
 
-	batcher *tsdb.PointBatcher
-	parser  *Parser
+	batcher   *tsdb.PointBatcher
+	parser    *Parser
+	relabeler *relabel.Relabeler
+	router    *routing.Router
 
 	logger      *zap.Logger
 	stats       *Statistics
@@ -123,9 +127,30 @@ func NewService(c Config) (*Service, error) {
 	}
 	s.parser = parser
 
+	s.relabeler, err = relabel.New(d.Relabel)
+	if err != nil {
+		return nil, err
+	}
+	s.router, err = routing.New(d.Routes)
+	if err != nil {
+		return nil, err
+	}
+
 	return &s, nil
 }
 
+// SetRelabelRules replaces the relabeling rules applied to the points before
+// they are written. The current rules are kept if rules are invalid.
+func (s *Service) SetRelabelRules(rules []relabel.Rule) error {
+	return s.relabeler.SetRules(rules)
+}
+
+// SetRoutes replaces the rules routing points to other databases. The current
+// rules are kept if rules are invalid.
+func (s *Service) SetRoutes(rules []routing.Rule) error {
+	return s.router.SetRules(rules)
+}
+
 // Open starts the Graphite input processing data.
 func (s *Service) Open() error {
 	s.mu.Lock()
@@ -464,13 +489,22 @@ func (s *Service) processBatches(batcher *tsdb.PointBatcher) {
 				continue
 			}
 
-			if err := s.PointsWriter.WritePointsPrivileged(s.database, s.retentionPolicy, coordinator.ConsistencyLevelAny, batch); err == nil {
-				atomic.AddInt64(&s.stats.BatchesTransmitted, 1)
-				atomic.AddInt64(&s.stats.PointsTransmitted, int64(len(batch)))
-			} else {
-				s.logger.Info("Failed to write point batch to database",
-					logger.Database(s.database), zap.Error(err))
-				atomic.AddInt64(&s.stats.BatchesTransmitFail, 1)
+			if batch = s.relabeler.Apply(batch); len(batch) == 0 {
+				continue
+			}
+
+			// Points routed to other databases are written to them as
+			// separate batches.
+			dest := routing.Destination{Database: s.database, RetentionPolicy: s.retentionPolicy}
+			for _, b := range s.router.Route(dest, batch) {
+				if err := s.PointsWriter.WritePointsPrivileged(b.Database, b.RetentionPolicy, coordinator.ConsistencyLevelAny, b.Points); err == nil {
+					atomic.AddInt64(&s.stats.BatchesTransmitted, 1)
+					atomic.AddInt64(&s.stats.PointsTransmitted, int64(len(b.Points)))
+				} else {
+					s.logger.Info("Failed to write point batch to database",
+						logger.Database(b.Database), zap.Error(err))
+					atomic.AddInt64(&s.stats.BatchesTransmitFail, 1)
+				}
 			}
 
 		case <-s.done:
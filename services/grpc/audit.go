@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"net"
+
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/audit"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/peer"
+)
+
+// queryAuditor records the audited statements of a query as their results
+// arrive, as the HTTP service does, so each event includes whether the
+// statement succeeded.
+type queryAuditor struct {
+	log   AuditLog
+	event audit.Event
+
+	stmts      influxql.Statements
+	categories map[int]string
+}
+
+// newQueryAuditor returns an auditor for q, or nil if auditing is disabled or
+// q has no audited statements.
+func (s *Service) newQueryAuditor(ctx context.Context, user meta.User, q *influxql.Query, db string) *queryAuditor {
+	if s.AuditLog == nil {
+		return nil
+	}
+
+	categories := make(map[int]string)
+	for i, stmt := range q.Statements {
+		if c := audit.StatementCategory(stmt); c != "" {
+			categories[i] = c
+		}
+	}
+	if len(categories) == 0 {
+		return nil
+	}
+
+	e := audit.Event{Addr: peerAddr(ctx), Database: db}
+	if user != nil {
+		e.User = user.ID()
+	}
+	return &queryAuditor{
+		log:        s.AuditLog,
+		event:      e,
+		stmts:      q.Statements,
+		categories: categories,
+	}
+}
+
+// record records the statement res belongs to the first time one of its
+// results is seen.
+func (a *queryAuditor) record(res *query.Result) {
+	if a == nil || res == nil {
+		return
+	}
+
+	category, ok := a.categories[res.StatementID]
+	if !ok {
+		return
+	}
+	delete(a.categories, res.StatementID)
+
+	// Statements that never ran are not audited.
+	if res.Err == query.ErrNotExecuted {
+		return
+	}
+
+	e := a.event
+	e.Category = category
+	e.Statement = a.stmts[res.StatementID].String()
+	if res.Err != nil {
+		e.Error = res.Err.Error()
+	}
+	a.log.Record(e)
+}
+
+// auditAuthFailure records a failed authentication attempt.
+func (s *Service) auditAuthFailure(ctx context.Context, username, msg string) {
+	if s.AuditLog == nil {
+		return
+	}
+	s.AuditLog.Record(audit.Event{
+		Category: audit.CategoryAuth,
+		User:     username,
+		Addr:     peerAddr(ctx),
+		Error:    msg,
+	})
+}
+
+// peerAddr returns the host of the client of the call of ctx.
+func peerAddr(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,83 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"errors"
+
+	"github.com/freetsdb/freetsdb/monitor/diagnostics"
+	"github.com/freetsdb/freetsdb/toml"
+)
+
+const (
+	// DefaultBindAddress is the default address to bind the gRPC server to.
+	DefaultBindAddress = ":8092"
+
+	// DefaultCertificate is the default location of the TLS certificate.
+	DefaultCertificate = "/etc/ssl/freetsdb.pem"
+
+	// DefaultMaxMessageSize is the default maximum size of a received message.
+	DefaultMaxMessageSize = 64 * 1024 * 1024
+)
+
+// Config represents the configuration for the gRPC service.
+type Config struct {
+	Enabled     bool   `toml:"enabled"`
+	BindAddress string `toml:"bind-address"`
+
+	// AuthEnabled requires the username and password of a user in the
+	// metadata of every call. The server also requires them when the HTTP
+	// API requires authentication, so gRPC is not a way around it.
+	AuthEnabled bool `toml:"auth-enabled"`
+
+	TLSEnabled  bool   `toml:"tls-enabled"`
+	Certificate string `toml:"certificate"`
+	PrivateKey  string `toml:"private-key"`
+
+	// MaxMessageSize limits the size of the requests, and so the number of
+	// points of a single write.
+	MaxMessageSize toml.Size `toml:"max-message-size"`
+
+	TLS *tls.Config `toml:"-"`
+}
+
+// NewConfig returns an instance of Config with defaults.
+func NewConfig() Config {
+	return Config{
+		BindAddress:    DefaultBindAddress,
+		Certificate:    DefaultCertificate,
+		MaxMessageSize: DefaultMaxMessageSize,
+	}
+}
+
+// Validate returns an error if the Config is invalid.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.BindAddress == "" {
+		return errors.New("bind-address must be specified")
+	} else if c.TLSEnabled && c.Certificate == "" {
+		return errors.New("certificate must be specified when tls-enabled is set")
+	} else if c.MaxMessageSize == 0 {
+		return errors.New("max-message-size must be positive")
+	}
+	return nil
+}
+
+// Diagnostics returns a diagnostics representation of a subset of the Config.
+func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
+	if !c.Enabled {
+		return diagnostics.RowFromMap(map[string]interface{}{
+			"enabled": false,
+		}), nil
+	}
+
+	return diagnostics.RowFromMap(map[string]interface{}{
+		"enabled":          true,
+		"bind-address":     c.BindAddress,
+		"auth-enabled":     c.AuthEnabled,
+		"tls-enabled":      c.TLSEnabled,
+		"max-message-size": c.MaxMessageSize,
+	}), nil
+}
@@ -0,0 +1,58 @@
+package grpc_test
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/freetsdb/freetsdb/services/grpc"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	c := grpc.NewConfig()
+	if _, err := toml.Decode(`
+enabled = true
+bind-address = ":9000"
+auth-enabled = true
+tls-enabled = true
+certificate = "/etc/ssl/cert.pem"
+max-message-size = "1m"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Validate configuration.
+	if !c.Enabled {
+		t.Fatalf("unexpected enabled: %v", c.Enabled)
+	} else if c.BindAddress != ":9000" {
+		t.Fatalf("unexpected bind address: %s", c.BindAddress)
+	} else if !c.AuthEnabled {
+		t.Fatalf("unexpected auth-enabled: %v", c.AuthEnabled)
+	} else if !c.TLSEnabled {
+		t.Fatalf("unexpected tls-enabled: %v", c.TLSEnabled)
+	} else if c.Certificate != "/etc/ssl/cert.pem" {
+		t.Fatalf("unexpected certificate: %s", c.Certificate)
+	} else if c.MaxMessageSize != 1<<20 {
+		t.Fatalf("unexpected max-message-size: %d", c.MaxMessageSize)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %s", err)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := grpc.NewConfig()
+	c.Enabled = true
+	c.TLSEnabled = true
+	c.Certificate = ""
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for missing certificate")
+	}
+
+	c = grpc.NewConfig()
+	c.Enabled = true
+	c.MaxMessageSize = 0
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for zero max-message-size")
+	}
+}
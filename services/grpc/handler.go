@@ -0,0 +1,298 @@
+package grpc
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/freetsdb/freetsdb"
+	"github.com/freetsdb/freetsdb/coordinator"
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/grpc/wire"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/tsdb"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// writeBatchSize is the number of points written at once. The call is
+// checked for cancellation between batches.
+const writeBatchSize = 5000
+
+// WritePoints writes the points of req. If the call is cancelled or its
+// deadline expires first, an error is returned and the batches of points not
+// written yet are abandoned, but the batch being written may still be.
+func (s *Service) WritePoints(ctx context.Context, req *wire.WritePointsRequest) (*wire.WritePointsResponse, error) {
+	atomic.AddInt64(&s.stats.WriteRequests, 1)
+	atomic.AddInt64(&s.stats.ActiveRequests, 1)
+	defer atomic.AddInt64(&s.stats.ActiveRequests, -1)
+
+	user, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Database == "" {
+		return nil, status.Error(codes.InvalidArgument, "database is required")
+	} else if di := s.MetaClient.Database(req.Database); di == nil {
+		return nil, status.Errorf(codes.NotFound, "database not found: %q", req.Database)
+	}
+
+	if s.config.AuthEnabled {
+		if err := s.WriteAuthorizer.AuthorizeWrite(user.ID(), req.Database); err != nil {
+			return nil, status.Errorf(codes.PermissionDenied, "%q user is not authorized to write to database %q", user.ID(), req.Database)
+		}
+	}
+
+	consistency := coordinator.ConsistencyLevelOne
+	if req.Consistency != "" {
+		if consistency, err = coordinator.ParseConsistencyLevel(req.Consistency); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	points, err := wire.ModelsPoints(req.Points, time.Now().UTC())
+	if err != nil {
+		atomic.AddInt64(&s.stats.PointsWrittenFail, int64(len(req.Points)))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Writes take no context, so the write is only waited for until the
+	// deadline of the call.
+	done := make(chan error, 1)
+	go func() {
+		done <- s.writeBatches(ctx, req.Database, req.RetentionPolicy, consistency, user, points)
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		return nil, contextError(ctx)
+	}
+
+	if freetsdb.IsClientError(err) {
+		atomic.AddInt64(&s.stats.PointsWrittenFail, int64(len(points)))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	} else if freetsdb.IsAuthorizationError(err) {
+		atomic.AddInt64(&s.stats.PointsWrittenFail, int64(len(points)))
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	} else if werr, ok := err.(tsdb.PartialWriteError); ok {
+		atomic.AddInt64(&s.stats.PointsWrittenOK, int64(len(points)-werr.Dropped))
+		atomic.AddInt64(&s.stats.PointsWrittenFail, int64(werr.Dropped))
+		return nil, status.Error(codes.InvalidArgument, werr.Error())
+	} else if err != nil {
+		atomic.AddInt64(&s.stats.PointsWrittenFail, int64(len(points)))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	atomic.AddInt64(&s.stats.PointsWrittenOK, int64(len(points)))
+	return &wire.WritePointsResponse{}, nil
+}
+
+// writeBatches writes points in batches of writeBatchSize, until ctx is done.
+// The errors of partial writes are merged.
+func (s *Service) writeBatches(ctx context.Context, database, retentionPolicy string, consistency coordinator.ConsistencyLevel, user meta.User, points []models.Point) error {
+	var partial *tsdb.PartialWriteError
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n := writeBatchSize
+		if n > len(points) {
+			n = len(points)
+		}
+		err := s.PointsWriter.WritePoints(database, retentionPolicy, consistency, user, points[:n])
+		if werr, ok := err.(tsdb.PartialWriteError); ok {
+			if partial == nil {
+				partial = &werr
+			} else {
+				*partial = tsdb.MergePartialWriteErrors(*partial, werr)
+			}
+		} else if err != nil {
+			return err
+		}
+
+		if points = points[n:]; len(points) == 0 {
+			break
+		}
+	}
+
+	if partial != nil {
+		return *partial
+	}
+	return nil
+}
+
+// ExecuteQuery executes the statements of req and sends their results on
+// stream. The query is aborted when the call is cancelled or its deadline
+// expires.
+func (s *Service) ExecuteQuery(req *wire.ExecuteQueryRequest, stream wire.FreeTSDB_ExecuteQueryServer) error {
+	atomic.AddInt64(&s.stats.QueryRequests, 1)
+	atomic.AddInt64(&s.stats.ActiveRequests, 1)
+	defer atomic.AddInt64(&s.stats.ActiveRequests, -1)
+
+	ctx := stream.Context()
+	user, err := s.authenticate(ctx)
+	if err != nil {
+		return err
+	}
+
+	q, err := influxql.ParseQuery(req.Query)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "error parsing query: %s", err)
+	}
+
+	opts := query.ExecutionOptions{
+		Database:        req.Database,
+		RetentionPolicy: req.RetentionPolicy,
+		ChunkSize:       int(req.ChunkSize),
+	}
+	if s.config.AuthEnabled {
+		if err := s.QueryAuthorizer.AuthorizeQuery(user, q, req.Database); err != nil {
+			return status.Errorf(codes.PermissionDenied, "error authorizing query: %s", err)
+		}
+		if user.AuthorizeUnrestricted() {
+			opts.Authorizer = query.OpenAuthorizer
+		} else {
+			opts.Authorizer = user
+		}
+	} else {
+		// Auth is disabled, so allow everything.
+		opts.Authorizer = query.OpenAuthorizer
+	}
+
+	// The query is interrupted when the client goes away, and its results are
+	// abandoned once the call is done.
+	done := make(chan struct{})
+	defer close(done)
+	opts.AbortCh = done
+
+	auditor := s.newQueryAuditor(ctx, user, q, req.Database)
+	results := s.QueryExecutor.ExecuteQueryContext(ctx, q, opts, nil)
+	for {
+		select {
+		case r, ok := <-results:
+			if !ok {
+				return nil
+			}
+			auditor.record(r)
+			if err := stream.Send(newQueryResponse(r)); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return contextError(ctx)
+		}
+	}
+}
+
+// authenticate returns the user of the credentials in the metadata of ctx.
+// It returns a nil user when authentication is disabled.
+func (s *Service) authenticate(ctx context.Context) (meta.User, error) {
+	if !s.config.AuthEnabled {
+		return nil, nil
+	}
+
+	var username, password string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md["username"]; len(v) > 0 {
+			username = v[0]
+		}
+		if v := md["password"]; len(v) > 0 {
+			password = v[0]
+		}
+	}
+	if username == "" {
+		atomic.AddInt64(&s.stats.AuthenticationFailures, 1)
+		s.auditAuthFailure(ctx, "", "username and password required")
+		return nil, status.Error(codes.Unauthenticated, "username and password required")
+	}
+
+	user, err := s.MetaClient.Authenticate(username, password)
+	if err != nil {
+		atomic.AddInt64(&s.stats.AuthenticationFailures, 1)
+		s.Logger.Info("Authentication failed", zap.String("user", username), zap.Error(err))
+		s.auditAuthFailure(ctx, username, "authorization failed")
+		return nil, status.Error(codes.Unauthenticated, "authorization failed")
+	}
+	return user, nil
+}
+
+// contextError returns the status of a call whose context is done.
+func contextError(ctx context.Context) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+	}
+	return status.Error(codes.Canceled, ctx.Err().Error())
+}
+
+// newQueryResponse converts a result of the query executor.
+func newQueryResponse(r *query.Result) *wire.ExecuteQueryResponse {
+	resp := &wire.ExecuteQueryResponse{
+		StatementId: int32(r.StatementID),
+		Partial:     r.Partial,
+	}
+	if r.Err != nil {
+		resp.Error = r.Err.Error()
+	}
+	for _, m := range r.Messages {
+		resp.Messages = append(resp.Messages, &wire.Message{Level: m.Level, Text: m.Text})
+	}
+
+	for _, row := range r.Series {
+		series := &wire.Series{
+			Name:    row.Name,
+			Columns: row.Columns,
+			Partial: row.Partial,
+		}
+
+		keys := make([]string, 0, len(row.Tags))
+		for k := range row.Tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			series.Tags = append(series.Tags, &wire.Tag{Key: k, Value: row.Tags[k]})
+		}
+
+		for _, values := range row.Values {
+			out := &wire.Row{Values: make([]*wire.Value, len(values))}
+			for i, v := range values {
+				out.Values[i] = newValue(v)
+			}
+			series.Rows = append(series.Rows, out)
+		}
+		resp.Series = append(resp.Series, series)
+	}
+	return resp
+}
+
+// newValue converts a value of a row. Times are converted to nanoseconds
+// since the epoch.
+func newValue(v interface{}) *wire.Value {
+	switch v := v.(type) {
+	case nil:
+		return &wire.Value{Type: wire.Value_NULL}
+	case float64:
+		return &wire.Value{Type: wire.Value_FLOAT, FloatValue: v}
+	case int64:
+		return &wire.Value{Type: wire.Value_INTEGER, IntegerValue: v}
+	case int:
+		return &wire.Value{Type: wire.Value_INTEGER, IntegerValue: int64(v)}
+	case uint64:
+		return &wire.Value{Type: wire.Value_UNSIGNED, UnsignedValue: v}
+	case string:
+		return &wire.Value{Type: wire.Value_STRING, StringValue: v}
+	case bool:
+		return &wire.Value{Type: wire.Value_BOOLEAN, BooleanValue: v}
+	case time.Time:
+		return &wire.Value{Type: wire.Value_INTEGER, IntegerValue: v.UnixNano()}
+	default:
+		return &wire.Value{Type: wire.Value_STRING, StringValue: fmt.Sprint(v)}
+	}
+}
@@ -0,0 +1,183 @@
+// Package grpc provides a gRPC API to write points and execute queries,
+// for clients that would rather not format and parse line protocol and JSON.
+package grpc // import "github.com/freetsdb/freetsdb/services/grpc"
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/freetsdb/freetsdb/coordinator"
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/audit"
+	"github.com/freetsdb/freetsdb/services/grpc/wire"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// statistics gathered by the gRPC service.
+const (
+	statWriteRequests  = "writeReq"
+	statPointsWritten  = "pointsWrittenOK"
+	statPointsFail     = "pointsWrittenFail"
+	statQueryRequests  = "queryReq"
+	statAuthFail       = "authFail"
+	statActiveRequests = "reqActive"
+)
+
+// AuditLog records audit events.
+type AuditLog interface {
+	Record(e audit.Event)
+}
+
+// Service serves the gRPC API.
+type Service struct {
+	ln     net.Listener
+	server *grpc.Server
+	config Config
+
+	MetaClient interface {
+		Database(name string) *meta.DatabaseInfo
+		Authenticate(username, password string) (meta.User, error)
+	}
+
+	QueryAuthorizer interface {
+		AuthorizeQuery(u meta.User, query *influxql.Query, database string) error
+	}
+
+	WriteAuthorizer interface {
+		AuthorizeWrite(username, database string) error
+	}
+
+	QueryExecutor interface {
+		ExecuteQueryContext(ctx context.Context, query *influxql.Query, opt query.ExecutionOptions, closing chan struct{}) <-chan *query.Result
+	}
+
+	PointsWriter interface {
+		WritePoints(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, user meta.User, points []models.Point) error
+	}
+
+	// AuditLog records administrative statements and failed authentication.
+	// Calls are not audited if it is nil.
+	AuditLog AuditLog
+
+	Logger *zap.Logger
+	stats  *Statistics
+	mu     sync.Mutex
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	if c.TLS == nil {
+		c.TLS = new(tls.Config)
+	}
+	if c.PrivateKey == "" {
+		c.PrivateKey = c.Certificate
+	}
+	return &Service{
+		config: c,
+		Logger: zap.NewNop(),
+		stats:  &Statistics{},
+	}
+}
+
+// Open starts the service.
+func (s *Service) Open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.server != nil {
+		return nil // Already open.
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(int(s.config.MaxMessageSize)),
+	}
+	if s.config.TLSEnabled {
+		cert, err := tls.LoadX509KeyPair(s.config.Certificate, s.config.PrivateKey)
+		if err != nil {
+			return err
+		}
+
+		tlsConfig := s.config.TLS.Clone()
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	ln, err := net.Listen("tcp", s.config.BindAddress)
+	if err != nil {
+		return err
+	}
+	s.ln = ln
+
+	s.server = grpc.NewServer(opts...)
+	wire.RegisterFreeTSDBServer(s.server, s)
+
+	s.Logger.Info("Listening on gRPC",
+		zap.Stringer("addr", s.ln.Addr()),
+		zap.Bool("tls", s.config.TLSEnabled),
+		zap.Bool("authentication", s.config.AuthEnabled))
+
+	go s.server.Serve(s.ln)
+	return nil
+}
+
+// Close stops the service, once the calls in progress are done.
+func (s *Service) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.server == nil {
+		return nil // Already closed.
+	}
+	s.server.GracefulStop()
+	s.server, s.ln = nil, nil
+	return nil
+}
+
+// WithLogger sets the logger on the service.
+func (s *Service) WithLogger(log *zap.Logger) {
+	s.Logger = log.With(zap.String("service", "grpc"))
+}
+
+// Addr returns the address the service listens on.
+func (s *Service) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ln == nil {
+		return nil
+	}
+	return s.ln.Addr()
+}
+
+// Statistics maintains statistics for the gRPC service.
+type Statistics struct {
+	WriteRequests          int64
+	PointsWrittenOK        int64
+	PointsWrittenFail      int64
+	QueryRequests          int64
+	AuthenticationFailures int64
+	ActiveRequests         int64
+}
+
+// Statistics returns statistics for periodic monitoring.
+func (s *Service) Statistics(tags map[string]string) []models.Statistic {
+	return []models.Statistic{{
+		Name: "grpc",
+		Tags: models.StatisticTags{"bind": s.config.BindAddress}.Merge(tags),
+		Values: map[string]interface{}{
+			statWriteRequests:  atomic.LoadInt64(&s.stats.WriteRequests),
+			statPointsWritten:  atomic.LoadInt64(&s.stats.PointsWrittenOK),
+			statPointsFail:     atomic.LoadInt64(&s.stats.PointsWrittenFail),
+			statQueryRequests:  atomic.LoadInt64(&s.stats.QueryRequests),
+			statAuthFail:       atomic.LoadInt64(&s.stats.AuthenticationFailures),
+			statActiveRequests: atomic.LoadInt64(&s.stats.ActiveRequests),
+		},
+	}}
+}
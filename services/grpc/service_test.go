@@ -0,0 +1,402 @@
+package grpc_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/freetsdb/freetsdb/coordinator"
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/audit"
+	"github.com/freetsdb/freetsdb/services/grpc"
+	"github.com/freetsdb/freetsdb/services/grpc/wire"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"golang.org/x/net/context"
+	ggrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Ensure points written over gRPC are passed to the points writer.
+func TestService_WritePoints(t *testing.T) {
+	s := NewTestService(grpc.NewConfig())
+	defer s.Close()
+
+	var got []models.Point
+	s.PointsWriter.WritePointsFn = func(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, user meta.User, points []models.Point) error {
+		if database != "db0" || retentionPolicy != "rp0" {
+			t.Errorf("unexpected target: %s.%s", database, retentionPolicy)
+		} else if consistencyLevel != coordinator.ConsistencyLevelAll {
+			t.Errorf("unexpected consistency level: %v", consistencyLevel)
+		}
+		got = points
+		return nil
+	}
+
+	client := s.Client(t)
+	if _, err := client.WritePoints(context.Background(), &wire.WritePointsRequest{
+		Database:        "db0",
+		RetentionPolicy: "rp0",
+		Consistency:     "all",
+		Points: []*wire.Point{{
+			Measurement: "cpu",
+			Tags:        []*wire.Tag{{Key: "host", Value: "server01"}},
+			Fields: []*wire.Field{
+				{Key: "value", Value: &wire.Value{Type: wire.Value_FLOAT, FloatValue: 1.5}},
+				{Key: "count", Value: &wire.Value{Type: wire.Value_INTEGER, IntegerValue: 10}},
+			},
+			Time: 10,
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("unexpected points: %v", got)
+	} else if exp := "cpu,host=server01 count=10i,value=1.5 10"; got[0].String() != exp {
+		t.Fatalf("unexpected point:\n\texp = %s\n\tgot = %s", exp, got[0].String())
+	}
+}
+
+// Ensure writes to unknown databases and of null fields are rejected.
+func TestService_WritePoints_Invalid(t *testing.T) {
+	s := NewTestService(grpc.NewConfig())
+	defer s.Close()
+
+	client := s.Client(t)
+	if _, err := client.WritePoints(context.Background(), &wire.WritePointsRequest{Database: "nodb"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.WritePoints(context.Background(), &wire.WritePointsRequest{
+		Database: "db0",
+		Points: []*wire.Point{{
+			Measurement: "cpu",
+			Fields:      []*wire.Field{{Key: "value", Value: &wire.Value{}}},
+		}},
+	}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a write returns once the deadline of the call expires.
+func TestService_WritePoints_Deadline(t *testing.T) {
+	s := NewTestService(grpc.NewConfig())
+	defer s.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	s.PointsWriter.WritePointsFn = func(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, user meta.User, points []models.Point) error {
+		<-done
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	client := s.Client(t)
+	if _, err := client.WritePoints(ctx, &wire.WritePointsRequest{Database: "db0"}); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure large writes are split in batches, and the batches left are not
+// written once the call is cancelled.
+func TestService_WritePoints_Batches(t *testing.T) {
+	s := NewTestService(grpc.NewConfig())
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var sizes []int
+	s.PointsWriter.WritePointsFn = func(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, user meta.User, points []models.Point) error {
+		sizes = append(sizes, len(points))
+		return nil
+	}
+
+	req := &wire.WritePointsRequest{Database: "db0"}
+	for i := 0; i < 12000; i++ {
+		req.Points = append(req.Points, &wire.Point{
+			Measurement: "cpu",
+			Fields:      []*wire.Field{{Key: "value", Value: &wire.Value{Type: wire.Value_FLOAT, FloatValue: 1}}},
+			Time:        int64(i),
+		})
+	}
+
+	client := s.Client(t)
+	if _, err := client.WritePoints(ctx, req); err != nil {
+		t.Fatal(err)
+	} else if len(sizes) != 3 || sizes[0] != 5000 || sizes[1] != 5000 || sizes[2] != 2000 {
+		t.Fatalf("unexpected batches: %v", sizes)
+	}
+
+	// The call is cancelled while the first batch is written.
+	sizes = nil
+	written := make(chan struct{})
+	s.PointsWriter.WritePointsFn = func(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, user meta.User, points []models.Point) error {
+		sizes = append(sizes, len(points))
+		cancel()
+		<-written
+		return nil
+	}
+	if _, err := client.WritePoints(ctx, req); status.Code(err) != codes.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Give the service time to see the cancellation before the first batch
+	// is written.
+	time.Sleep(50 * time.Millisecond)
+	close(written)
+
+	// Wait for the write of the first batch to return.
+	time.Sleep(100 * time.Millisecond)
+	if len(sizes) != 1 {
+		t.Fatalf("unexpected batches: %v", sizes)
+	}
+}
+
+// Ensure the results of a query are streamed back.
+func TestService_ExecuteQuery(t *testing.T) {
+	s := NewTestService(grpc.NewConfig())
+	defer s.Close()
+
+	s.QueryExecutor.ExecuteQueryContextFn = func(ctx context.Context, q *influxql.Query, opt query.ExecutionOptions, closing chan struct{}) <-chan *query.Result {
+		if opt.Database != "db0" {
+			t.Errorf("unexpected database: %s", opt.Database)
+		}
+		results := make(chan *query.Result, 2)
+		results <- &query.Result{
+			StatementID: 0,
+			Series: models.Rows{{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "server01"},
+				Columns: []string{"time", "value"},
+				Values:  [][]interface{}{{time.Unix(0, 10), 1.5}},
+			}},
+		}
+		results <- &query.Result{StatementID: 1, Err: errors.New("marker")}
+		close(results)
+		return results
+	}
+
+	client := s.Client(t)
+	stream, err := client.ExecuteQuery(context.Background(), &wire.ExecuteQueryRequest{
+		Query:    "SELECT value FROM cpu; SELECT value FROM mem",
+		Database: "db0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var responses []*wire.ExecuteQueryResponse
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, resp)
+	}
+
+	if len(responses) != 2 {
+		t.Fatalf("unexpected responses: %v", responses)
+	}
+	series := responses[0].Series
+	if len(series) != 1 || series[0].Name != "cpu" || len(series[0].Rows) != 1 {
+		t.Fatalf("unexpected series: %v", series)
+	} else if v := series[0].Rows[0].Values; v[0].IntegerValue != 10 || v[1].FloatValue != 1.5 {
+		t.Fatalf("unexpected values: %v", v)
+	}
+	if responses[1].StatementId != 1 || responses[1].Error != "marker" {
+		t.Fatalf("unexpected response: %v", responses[1])
+	}
+}
+
+// Ensure calls without valid credentials are rejected when auth is enabled.
+func TestService_Authentication(t *testing.T) {
+	c := grpc.NewConfig()
+	c.AuthEnabled = true
+	s := NewTestService(c)
+	defer s.Close()
+
+	s.MetaClient.AuthenticateFn = func(username, password string) (meta.User, error) {
+		if username != "user" || password != "secret" {
+			return nil, meta.ErrAuthenticate
+		}
+		return &meta.UserInfo{Name: "user", Admin: true}, nil
+	}
+
+	client := s.Client(t)
+	if _, err := client.WritePoints(context.Background(), &wire.WritePointsRequest{Database: "db0"}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("username", "user", "password", "wrong"))
+	if _, err := client.WritePoints(ctx, &wire.WritePointsRequest{Database: "db0"}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx = metadata.NewOutgoingContext(context.Background(), metadata.Pairs("username", "user", "password", "secret"))
+	if _, err := client.WritePoints(ctx, &wire.WritePointsRequest{Database: "db0"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure failed authentication and administrative statements are audited.
+func TestService_Audit(t *testing.T) {
+	c := grpc.NewConfig()
+	c.AuthEnabled = true
+	s := NewTestService(c)
+	defer s.Close()
+
+	var events []audit.Event
+	s.Service.AuditLog = auditLogFunc(func(e audit.Event) { events = append(events, e) })
+	s.Service.QueryAuthorizer = allowQueries{}
+	s.MetaClient.AuthenticateFn = func(username, password string) (meta.User, error) {
+		if password != "secret" {
+			return nil, meta.ErrAuthenticate
+		}
+		return &meta.UserInfo{Name: username, Admin: true}, nil
+	}
+	s.QueryExecutor.ExecuteQueryContextFn = func(ctx context.Context, q *influxql.Query, opt query.ExecutionOptions, closing chan struct{}) <-chan *query.Result {
+		results := make(chan *query.Result, 2)
+		results <- &query.Result{StatementID: 0}
+		results <- &query.Result{StatementID: 1}
+		close(results)
+		return results
+	}
+
+	client := s.Client(t)
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("username", "user", "password", "wrong"))
+	if _, err := client.WritePoints(ctx, &wire.WritePointsRequest{Database: "db0"}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx = metadata.NewOutgoingContext(context.Background(), metadata.Pairs("username", "user", "password", "secret"))
+	stream, err := client.ExecuteQuery(ctx, &wire.ExecuteQueryRequest{Query: "SELECT value FROM cpu; DROP DATABASE db1", Database: "db0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, err := stream.Recv(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("unexpected events: %+v", events)
+	} else if e := events[0]; e.Category != audit.CategoryAuth || e.User != "user" || e.Addr != "127.0.0.1" {
+		t.Fatalf("unexpected event: %+v", e)
+	} else if e := events[1]; e.Category != audit.CategoryDDL || e.User != "user" || e.Statement != "DROP DATABASE db1" {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+}
+
+// TestService is a test wrapper for Service.
+type TestService struct {
+	*grpc.Service
+	MetaClient    MetaClient
+	PointsWriter  PointsWriter
+	QueryExecutor QueryExecutor
+	conn          *ggrpc.ClientConn
+}
+
+// NewTestService returns an open service listening on a random port, with
+// the single database "db0".
+func NewTestService(c grpc.Config) *TestService {
+	c.Enabled = true
+	c.BindAddress = "127.0.0.1:0"
+
+	s := &TestService{Service: grpc.NewService(c)}
+	s.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		if name != "db0" {
+			return nil
+		}
+		return &meta.DatabaseInfo{Name: name}
+	}
+	s.PointsWriter.WritePointsFn = func(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, user meta.User, points []models.Point) error {
+		return nil
+	}
+	s.Service.MetaClient = &s.MetaClient
+	s.Service.QueryAuthorizer = meta.NewQueryAuthorizer(nil)
+	s.Service.WriteAuthorizer = allowWrites{}
+	s.Service.QueryExecutor = &s.QueryExecutor
+	s.Service.PointsWriter = &s.PointsWriter
+
+	if err := s.Service.Open(); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Client returns a client connected to the service.
+func (s *TestService) Client(t *testing.T) wire.FreeTSDBClient {
+	if s.conn == nil {
+		conn, err := ggrpc.Dial(s.Addr().String(), ggrpc.WithInsecure())
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.conn = conn
+	}
+	return wire.NewFreeTSDBClient(s.conn)
+}
+
+// Close closes the client connection and the service.
+func (s *TestService) Close() error {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	return s.Service.Close()
+}
+
+// MetaClient is a mock of the meta client of the service.
+type MetaClient struct {
+	DatabaseFn     func(name string) *meta.DatabaseInfo
+	AuthenticateFn func(username, password string) (meta.User, error)
+}
+
+func (c *MetaClient) Database(name string) *meta.DatabaseInfo { return c.DatabaseFn(name) }
+func (c *MetaClient) Authenticate(username, password string) (meta.User, error) {
+	return c.AuthenticateFn(username, password)
+}
+
+// PointsWriter is a mock of the points writer of the service.
+type PointsWriter struct {
+	WritePointsFn func(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, user meta.User, points []models.Point) error
+}
+
+func (w *PointsWriter) WritePoints(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, user meta.User, points []models.Point) error {
+	return w.WritePointsFn(database, retentionPolicy, consistencyLevel, user, points)
+}
+
+// QueryExecutor is a mock of the query executor of the service.
+type QueryExecutor struct {
+	ExecuteQueryContextFn func(ctx context.Context, q *influxql.Query, opt query.ExecutionOptions, closing chan struct{}) <-chan *query.Result
+}
+
+func (e *QueryExecutor) ExecuteQueryContext(ctx context.Context, q *influxql.Query, opt query.ExecutionOptions, closing chan struct{}) <-chan *query.Result {
+	return e.ExecuteQueryContextFn(ctx, q, opt, closing)
+}
+
+// auditLogFunc records audit events with a function.
+type auditLogFunc func(e audit.Event)
+
+func (fn auditLogFunc) Record(e audit.Event) { fn(e) }
+
+// allowQueries authorizes every query.
+type allowQueries struct{}
+
+func (allowQueries) AuthorizeQuery(u meta.User, q *influxql.Query, database string) error { return nil }
+
+// allowWrites authorizes every write.
+type allowWrites struct{}
+
+func (allowWrites) AuthorizeWrite(username, database string) error { return nil }
@@ -0,0 +1,3 @@
+package wire
+
+//go:generate protoc -I$GOPATH/src/github.com/freetsdb/freetsdb/vendor -I. --gogo_out=plugins=grpc:. wire.proto
@@ -0,0 +1,59 @@
+package wire
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/freetsdb/freetsdb/models"
+)
+
+// ModelsPoints converts points to models points. Points without a time are
+// given now.
+func ModelsPoints(a []*Point, now time.Time) ([]models.Point, error) {
+	points := make([]models.Point, 0, len(a))
+	for _, p := range a {
+		tags := make(map[string]string, len(p.Tags))
+		for _, t := range p.Tags {
+			tags[t.Key] = t.Value
+		}
+
+		fields := make(models.Fields, len(p.Fields))
+		for _, f := range p.Fields {
+			v, err := fieldValue(f.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid field %q of measurement %q: %s", f.Key, p.Measurement, err)
+			}
+			fields[f.Key] = v
+		}
+
+		t := now
+		if p.Time != 0 {
+			t = time.Unix(0, p.Time)
+		}
+
+		pt, err := models.NewPoint(p.Measurement, models.NewTags(tags), fields, t)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, pt)
+	}
+	return points, nil
+}
+
+// fieldValue returns the value of a field.
+func fieldValue(v *Value) (interface{}, error) {
+	switch v.GetType() {
+	case Value_FLOAT:
+		return v.FloatValue, nil
+	case Value_INTEGER:
+		return v.IntegerValue, nil
+	case Value_UNSIGNED:
+		return v.UnsignedValue, nil
+	case Value_STRING:
+		return v.StringValue, nil
+	case Value_BOOLEAN:
+		return v.BooleanValue, nil
+	default:
+		return nil, fmt.Errorf("field value is null")
+	}
+}
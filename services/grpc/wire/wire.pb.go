@@ -0,0 +1,683 @@
+// Code generated by protoc-gen-gogo.
+// source: wire.proto
+// DO NOT EDIT!
+
+/*
+Package wire is a generated protocol buffer package.
+
+It is generated from these files:
+	wire.proto
+
+It has these top-level messages:
+	Value
+	Tag
+	Field
+	Point
+	WritePointsRequest
+	WritePointsResponse
+	ExecuteQueryRequest
+	Row
+	Series
+	Message
+	ExecuteQueryResponse
+	Points
+*/
+package wire
+
+import proto "github.com/gogo/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
+
+type Value_Type int32
+
+const (
+	Value_NULL     Value_Type = 0
+	Value_FLOAT    Value_Type = 1
+	Value_INTEGER  Value_Type = 2
+	Value_UNSIGNED Value_Type = 3
+	Value_STRING   Value_Type = 4
+	Value_BOOLEAN  Value_Type = 5
+)
+
+var Value_Type_name = map[int32]string{
+	0: "NULL",
+	1: "FLOAT",
+	2: "INTEGER",
+	3: "UNSIGNED",
+	4: "STRING",
+	5: "BOOLEAN",
+}
+var Value_Type_value = map[string]int32{
+	"NULL":     0,
+	"FLOAT":    1,
+	"INTEGER":  2,
+	"UNSIGNED": 3,
+	"STRING":   4,
+	"BOOLEAN":  5,
+}
+
+func (x Value_Type) String() string {
+	return proto.EnumName(Value_Type_name, int32(x))
+}
+func (Value_Type) EnumDescriptor() ([]byte, []int) { return fileDescriptorWire, []int{0, 0} }
+
+// Value is a field value of a point, or a value of a row.
+type Value struct {
+	Type          Value_Type `protobuf:"varint,1,opt,name=type,proto3,enum=freetsdb.grpc.Value_Type" json:"type,omitempty"`
+	FloatValue    float64    `protobuf:"fixed64,2,opt,name=float_value,json=floatValue,proto3" json:"float_value,omitempty"`
+	IntegerValue  int64      `protobuf:"varint,3,opt,name=integer_value,json=integerValue,proto3" json:"integer_value,omitempty"`
+	UnsignedValue uint64     `protobuf:"varint,4,opt,name=unsigned_value,json=unsignedValue,proto3" json:"unsigned_value,omitempty"`
+	StringValue   string     `protobuf:"bytes,5,opt,name=string_value,json=stringValue,proto3" json:"string_value,omitempty"`
+	BooleanValue  bool       `protobuf:"varint,6,opt,name=boolean_value,json=booleanValue,proto3" json:"boolean_value,omitempty"`
+}
+
+func (m *Value) Reset()                    { *m = Value{} }
+func (m *Value) String() string            { return proto.CompactTextString(m) }
+func (*Value) ProtoMessage()               {}
+func (*Value) Descriptor() ([]byte, []int) { return fileDescriptorWire, []int{0} }
+
+func (m *Value) GetType() Value_Type {
+	if m != nil {
+		return m.Type
+	}
+	return Value_NULL
+}
+
+func (m *Value) GetFloatValue() float64 {
+	if m != nil {
+		return m.FloatValue
+	}
+	return 0
+}
+
+func (m *Value) GetIntegerValue() int64 {
+	if m != nil {
+		return m.IntegerValue
+	}
+	return 0
+}
+
+func (m *Value) GetUnsignedValue() uint64 {
+	if m != nil {
+		return m.UnsignedValue
+	}
+	return 0
+}
+
+func (m *Value) GetStringValue() string {
+	if m != nil {
+		return m.StringValue
+	}
+	return ""
+}
+
+func (m *Value) GetBooleanValue() bool {
+	if m != nil {
+		return m.BooleanValue
+	}
+	return false
+}
+
+type Tag struct {
+	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *Tag) Reset()                    { *m = Tag{} }
+func (m *Tag) String() string            { return proto.CompactTextString(m) }
+func (*Tag) ProtoMessage()               {}
+func (*Tag) Descriptor() ([]byte, []int) { return fileDescriptorWire, []int{1} }
+
+func (m *Tag) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *Tag) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+type Field struct {
+	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value *Value `protobuf:"bytes,2,opt,name=value" json:"value,omitempty"`
+}
+
+func (m *Field) Reset()                    { *m = Field{} }
+func (m *Field) String() string            { return proto.CompactTextString(m) }
+func (*Field) ProtoMessage()               {}
+func (*Field) Descriptor() ([]byte, []int) { return fileDescriptorWire, []int{2} }
+
+func (m *Field) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *Field) GetValue() *Value {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+type Point struct {
+	Measurement string   `protobuf:"bytes,1,opt,name=measurement,proto3" json:"measurement,omitempty"`
+	Tags        []*Tag   `protobuf:"bytes,2,rep,name=tags" json:"tags,omitempty"`
+	Fields      []*Field `protobuf:"bytes,3,rep,name=fields" json:"fields,omitempty"`
+	// Time of the point in nanoseconds since the epoch, or 0 for the time
+	// the point is written.
+	Time int64 `protobuf:"varint,4,opt,name=time,proto3" json:"time,omitempty"`
+}
+
+func (m *Point) Reset()                    { *m = Point{} }
+func (m *Point) String() string            { return proto.CompactTextString(m) }
+func (*Point) ProtoMessage()               {}
+func (*Point) Descriptor() ([]byte, []int) { return fileDescriptorWire, []int{3} }
+
+func (m *Point) GetMeasurement() string {
+	if m != nil {
+		return m.Measurement
+	}
+	return ""
+}
+
+func (m *Point) GetTags() []*Tag {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *Point) GetFields() []*Field {
+	if m != nil {
+		return m.Fields
+	}
+	return nil
+}
+
+func (m *Point) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+type WritePointsRequest struct {
+	Database        string `protobuf:"bytes,1,opt,name=database,proto3" json:"database,omitempty"`
+	RetentionPolicy string `protobuf:"bytes,2,opt,name=retention_policy,json=retentionPolicy,proto3" json:"retention_policy,omitempty"`
+	// Consistency of the write: any, one, quorum or all. Defaults to one.
+	Consistency string   `protobuf:"bytes,3,opt,name=consistency,proto3" json:"consistency,omitempty"`
+	Points      []*Point `protobuf:"bytes,4,rep,name=points" json:"points,omitempty"`
+}
+
+func (m *WritePointsRequest) Reset()                    { *m = WritePointsRequest{} }
+func (m *WritePointsRequest) String() string            { return proto.CompactTextString(m) }
+func (*WritePointsRequest) ProtoMessage()               {}
+func (*WritePointsRequest) Descriptor() ([]byte, []int) { return fileDescriptorWire, []int{4} }
+
+func (m *WritePointsRequest) GetDatabase() string {
+	if m != nil {
+		return m.Database
+	}
+	return ""
+}
+
+func (m *WritePointsRequest) GetRetentionPolicy() string {
+	if m != nil {
+		return m.RetentionPolicy
+	}
+	return ""
+}
+
+func (m *WritePointsRequest) GetConsistency() string {
+	if m != nil {
+		return m.Consistency
+	}
+	return ""
+}
+
+func (m *WritePointsRequest) GetPoints() []*Point {
+	if m != nil {
+		return m.Points
+	}
+	return nil
+}
+
+type WritePointsResponse struct {
+}
+
+func (m *WritePointsResponse) Reset()                    { *m = WritePointsResponse{} }
+func (m *WritePointsResponse) String() string            { return proto.CompactTextString(m) }
+func (*WritePointsResponse) ProtoMessage()               {}
+func (*WritePointsResponse) Descriptor() ([]byte, []int) { return fileDescriptorWire, []int{5} }
+
+type ExecuteQueryRequest struct {
+	Query           string `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Database        string `protobuf:"bytes,2,opt,name=database,proto3" json:"database,omitempty"`
+	RetentionPolicy string `protobuf:"bytes,3,opt,name=retention_policy,json=retentionPolicy,proto3" json:"retention_policy,omitempty"`
+	// Maximum number of rows of a response, or 0 to return the results of
+	// each statement in a single response.
+	ChunkSize int32 `protobuf:"varint,4,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
+}
+
+func (m *ExecuteQueryRequest) Reset()                    { *m = ExecuteQueryRequest{} }
+func (m *ExecuteQueryRequest) String() string            { return proto.CompactTextString(m) }
+func (*ExecuteQueryRequest) ProtoMessage()               {}
+func (*ExecuteQueryRequest) Descriptor() ([]byte, []int) { return fileDescriptorWire, []int{6} }
+
+func (m *ExecuteQueryRequest) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+func (m *ExecuteQueryRequest) GetDatabase() string {
+	if m != nil {
+		return m.Database
+	}
+	return ""
+}
+
+func (m *ExecuteQueryRequest) GetRetentionPolicy() string {
+	if m != nil {
+		return m.RetentionPolicy
+	}
+	return ""
+}
+
+func (m *ExecuteQueryRequest) GetChunkSize() int32 {
+	if m != nil {
+		return m.ChunkSize
+	}
+	return 0
+}
+
+// Row is a row of a series. Times are nanoseconds since the epoch.
+type Row struct {
+	Values []*Value `protobuf:"bytes,1,rep,name=values" json:"values,omitempty"`
+}
+
+func (m *Row) Reset()                    { *m = Row{} }
+func (m *Row) String() string            { return proto.CompactTextString(m) }
+func (*Row) ProtoMessage()               {}
+func (*Row) Descriptor() ([]byte, []int) { return fileDescriptorWire, []int{7} }
+
+func (m *Row) GetValues() []*Value {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+type Series struct {
+	Name    string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Tags    []*Tag   `protobuf:"bytes,2,rep,name=tags" json:"tags,omitempty"`
+	Columns []string `protobuf:"bytes,3,rep,name=columns" json:"columns,omitempty"`
+	Rows    []*Row   `protobuf:"bytes,4,rep,name=rows" json:"rows,omitempty"`
+	Partial bool     `protobuf:"varint,5,opt,name=partial,proto3" json:"partial,omitempty"`
+}
+
+func (m *Series) Reset()                    { *m = Series{} }
+func (m *Series) String() string            { return proto.CompactTextString(m) }
+func (*Series) ProtoMessage()               {}
+func (*Series) Descriptor() ([]byte, []int) { return fileDescriptorWire, []int{8} }
+
+func (m *Series) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Series) GetTags() []*Tag {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *Series) GetColumns() []string {
+	if m != nil {
+		return m.Columns
+	}
+	return nil
+}
+
+func (m *Series) GetRows() []*Row {
+	if m != nil {
+		return m.Rows
+	}
+	return nil
+}
+
+func (m *Series) GetPartial() bool {
+	if m != nil {
+		return m.Partial
+	}
+	return false
+}
+
+type Message struct {
+	Level string `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	Text  string `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+}
+
+func (m *Message) Reset()                    { *m = Message{} }
+func (m *Message) String() string            { return proto.CompactTextString(m) }
+func (*Message) ProtoMessage()               {}
+func (*Message) Descriptor() ([]byte, []int) { return fileDescriptorWire, []int{9} }
+
+func (m *Message) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
+}
+
+func (m *Message) GetText() string {
+	if m != nil {
+		return m.Text
+	}
+	return ""
+}
+
+type ExecuteQueryResponse struct {
+	StatementId int32      `protobuf:"varint,1,opt,name=statement_id,json=statementId,proto3" json:"statement_id,omitempty"`
+	Series      []*Series  `protobuf:"bytes,2,rep,name=series" json:"series,omitempty"`
+	Messages    []*Message `protobuf:"bytes,3,rep,name=messages" json:"messages,omitempty"`
+	// Error of the statement, if it failed.
+	Error   string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	Partial bool   `protobuf:"varint,5,opt,name=partial,proto3" json:"partial,omitempty"`
+}
+
+func (m *ExecuteQueryResponse) Reset()                    { *m = ExecuteQueryResponse{} }
+func (m *ExecuteQueryResponse) String() string            { return proto.CompactTextString(m) }
+func (*ExecuteQueryResponse) ProtoMessage()               {}
+func (*ExecuteQueryResponse) Descriptor() ([]byte, []int) { return fileDescriptorWire, []int{10} }
+
+func (m *ExecuteQueryResponse) GetStatementId() int32 {
+	if m != nil {
+		return m.StatementId
+	}
+	return 0
+}
+
+func (m *ExecuteQueryResponse) GetSeries() []*Series {
+	if m != nil {
+		return m.Series
+	}
+	return nil
+}
+
+func (m *ExecuteQueryResponse) GetMessages() []*Message {
+	if m != nil {
+		return m.Messages
+	}
+	return nil
+}
+
+func (m *ExecuteQueryResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *ExecuteQueryResponse) GetPartial() bool {
+	if m != nil {
+		return m.Partial
+	}
+	return false
+}
+
+// Points is a batch of points written to /write with the content type
+// application/x-protobuf. Times are in the precision of the write,
+// nanoseconds by default.
+type Points struct {
+	Points []*Point `protobuf:"bytes,1,rep,name=points" json:"points,omitempty"`
+}
+
+func (m *Points) Reset()                    { *m = Points{} }
+func (m *Points) String() string            { return proto.CompactTextString(m) }
+func (*Points) ProtoMessage()               {}
+func (*Points) Descriptor() ([]byte, []int) { return fileDescriptorWire, []int{11} }
+
+func (m *Points) GetPoints() []*Point {
+	if m != nil {
+		return m.Points
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Value)(nil), "freetsdb.grpc.Value")
+	proto.RegisterType((*Tag)(nil), "freetsdb.grpc.Tag")
+	proto.RegisterType((*Field)(nil), "freetsdb.grpc.Field")
+	proto.RegisterType((*Point)(nil), "freetsdb.grpc.Point")
+	proto.RegisterType((*WritePointsRequest)(nil), "freetsdb.grpc.WritePointsRequest")
+	proto.RegisterType((*WritePointsResponse)(nil), "freetsdb.grpc.WritePointsResponse")
+	proto.RegisterType((*ExecuteQueryRequest)(nil), "freetsdb.grpc.ExecuteQueryRequest")
+	proto.RegisterType((*Row)(nil), "freetsdb.grpc.Row")
+	proto.RegisterType((*Series)(nil), "freetsdb.grpc.Series")
+	proto.RegisterType((*Message)(nil), "freetsdb.grpc.Message")
+	proto.RegisterType((*ExecuteQueryResponse)(nil), "freetsdb.grpc.ExecuteQueryResponse")
+	proto.RegisterType((*Points)(nil), "freetsdb.grpc.Points")
+	proto.RegisterEnum("freetsdb.grpc.Value_Type", Value_Type_name, Value_Type_value)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for FreeTSDB service
+
+type FreeTSDBClient interface {
+	// WritePoints writes the points of the request.
+	WritePoints(ctx context.Context, in *WritePointsRequest, opts ...grpc.CallOption) (*WritePointsResponse, error)
+	// ExecuteQuery executes the InfluxQL statements of the request and
+	// streams their results.
+	ExecuteQuery(ctx context.Context, in *ExecuteQueryRequest, opts ...grpc.CallOption) (FreeTSDB_ExecuteQueryClient, error)
+}
+
+type freeTSDBClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewFreeTSDBClient(cc *grpc.ClientConn) FreeTSDBClient {
+	return &freeTSDBClient{cc}
+}
+
+func (c *freeTSDBClient) WritePoints(ctx context.Context, in *WritePointsRequest, opts ...grpc.CallOption) (*WritePointsResponse, error) {
+	out := new(WritePointsResponse)
+	err := c.cc.Invoke(ctx, "/freetsdb.grpc.FreeTSDB/WritePoints", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *freeTSDBClient) ExecuteQuery(ctx context.Context, in *ExecuteQueryRequest, opts ...grpc.CallOption) (FreeTSDB_ExecuteQueryClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_FreeTSDB_serviceDesc.Streams[0], "/freetsdb.grpc.FreeTSDB/ExecuteQuery", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &freeTSDBExecuteQueryClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type FreeTSDB_ExecuteQueryClient interface {
+	Recv() (*ExecuteQueryResponse, error)
+	grpc.ClientStream
+}
+
+type freeTSDBExecuteQueryClient struct {
+	grpc.ClientStream
+}
+
+func (x *freeTSDBExecuteQueryClient) Recv() (*ExecuteQueryResponse, error) {
+	m := new(ExecuteQueryResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Server API for FreeTSDB service
+
+type FreeTSDBServer interface {
+	// WritePoints writes the points of the request.
+	WritePoints(context.Context, *WritePointsRequest) (*WritePointsResponse, error)
+	// ExecuteQuery executes the InfluxQL statements of the request and
+	// streams their results.
+	ExecuteQuery(*ExecuteQueryRequest, FreeTSDB_ExecuteQueryServer) error
+}
+
+func RegisterFreeTSDBServer(s *grpc.Server, srv FreeTSDBServer) {
+	s.RegisterService(&_FreeTSDB_serviceDesc, srv)
+}
+
+func _FreeTSDB_WritePoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WritePointsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FreeTSDBServer).WritePoints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/freetsdb.grpc.FreeTSDB/WritePoints",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FreeTSDBServer).WritePoints(ctx, req.(*WritePointsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FreeTSDB_ExecuteQuery_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExecuteQueryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FreeTSDBServer).ExecuteQuery(m, &freeTSDBExecuteQueryServer{stream})
+}
+
+type FreeTSDB_ExecuteQueryServer interface {
+	Send(*ExecuteQueryResponse) error
+	grpc.ServerStream
+}
+
+type freeTSDBExecuteQueryServer struct {
+	grpc.ServerStream
+}
+
+func (x *freeTSDBExecuteQueryServer) Send(m *ExecuteQueryResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _FreeTSDB_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "freetsdb.grpc.FreeTSDB",
+	HandlerType: (*FreeTSDBServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "WritePoints",
+			Handler:    _FreeTSDB_WritePoints_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExecuteQuery",
+			Handler:       _FreeTSDB_ExecuteQuery_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "wire.proto",
+}
+
+func init() { proto.RegisterFile("wire.proto", fileDescriptorWire) }
+
+var fileDescriptorWire = []byte{
+	// 779 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0xe1, 0x6e, 0xe3, 0x44,
+	0x10, 0x66, 0x63, 0x3b, 0x8d, 0xc7, 0xe9, 0x61, 0xed, 0xf5, 0x50, 0xa8, 0x84, 0xf0, 0xf9, 0x04,
+	0x0a, 0x88, 0x46, 0x28, 0x95, 0xf8, 0x7f, 0xd5, 0xa5, 0x55, 0xa5, 0x92, 0x1e, 0x1b, 0x1f, 0x08,
+	0xfe, 0x54, 0x6e, 0x32, 0x35, 0xab, 0x73, 0x6c, 0xdf, 0xee, 0xfa, 0x7a, 0xb9, 0xb7, 0x40, 0xbc,
+	0x00, 0xff, 0x79, 0x04, 0x78, 0x04, 0x1e, 0x0a, 0x79, 0x77, 0x1d, 0xd2, 0x10, 0x4a, 0xff, 0xed,
+	0x7c, 0xfb, 0xcd, 0x64, 0xbe, 0x6f, 0x66, 0x63, 0x80, 0x5b, 0x2e, 0x70, 0x54, 0x89, 0x52, 0x95,
+	0x74, 0xff, 0x46, 0x20, 0x2a, 0xb9, 0xb8, 0x1e, 0x65, 0xa2, 0x9a, 0xc7, 0x7f, 0x76, 0xc0, 0xfb,
+	0x3e, 0xcd, 0x6b, 0xa4, 0x47, 0xe0, 0xaa, 0x55, 0x85, 0x03, 0x12, 0x91, 0xe1, 0xa3, 0xf1, 0xc7,
+	0xa3, 0x3b, 0xbc, 0x91, 0xe6, 0x8c, 0x92, 0x55, 0x85, 0x4c, 0xd3, 0xe8, 0xa7, 0x10, 0xdc, 0xe4,
+	0x65, 0xaa, 0xae, 0xde, 0x36, 0x37, 0x83, 0x4e, 0x44, 0x86, 0x84, 0x81, 0x86, 0x4c, 0xbd, 0x67,
+	0xb0, 0xcf, 0x0b, 0x85, 0x19, 0x0a, 0x4b, 0x71, 0x22, 0x32, 0x74, 0x58, 0xdf, 0x82, 0x86, 0xf4,
+	0x19, 0x3c, 0xaa, 0x0b, 0xc9, 0xb3, 0x02, 0x17, 0x96, 0xe5, 0x46, 0x64, 0xe8, 0xb2, 0xfd, 0x16,
+	0x35, 0xb4, 0xa7, 0xd0, 0x97, 0x4a, 0xf0, 0x22, 0xb3, 0x24, 0x2f, 0x22, 0x43, 0x9f, 0x05, 0x06,
+	0x5b, 0xff, 0xdc, 0x75, 0x59, 0xe6, 0x98, 0x16, 0x96, 0xd3, 0x8d, 0xc8, 0xb0, 0xc7, 0xfa, 0x16,
+	0xd4, 0xa4, 0xf8, 0x12, 0xdc, 0x46, 0x02, 0xed, 0x81, 0x3b, 0x7d, 0x75, 0x71, 0x11, 0x7e, 0x40,
+	0x7d, 0xf0, 0x4e, 0x2f, 0x2e, 0x9f, 0x27, 0x21, 0xa1, 0x01, 0xec, 0x9d, 0x4f, 0x93, 0xc9, 0xd9,
+	0x84, 0x85, 0x1d, 0xda, 0x87, 0xde, 0xab, 0xe9, 0xec, 0xfc, 0x6c, 0x3a, 0x79, 0x11, 0x3a, 0x14,
+	0xa0, 0x3b, 0x4b, 0xd8, 0xf9, 0xf4, 0x2c, 0x74, 0x1b, 0xda, 0xc9, 0xe5, 0xe5, 0xc5, 0xe4, 0xf9,
+	0x34, 0xf4, 0xe2, 0x23, 0x70, 0x92, 0x34, 0xa3, 0x21, 0x38, 0xaf, 0x71, 0xa5, 0xad, 0xf3, 0x59,
+	0x73, 0xa4, 0x07, 0xe0, 0xfd, 0x63, 0x8c, 0xcf, 0x4c, 0x10, 0x4f, 0xc0, 0x3b, 0xe5, 0x98, 0x2f,
+	0x76, 0x24, 0x7c, 0xb9, 0x99, 0x10, 0x8c, 0x0f, 0x76, 0xf9, 0xdf, 0x96, 0xf9, 0x95, 0x80, 0xf7,
+	0xb2, 0xe4, 0x85, 0xa2, 0x11, 0x04, 0x4b, 0x4c, 0x65, 0x2d, 0x70, 0x89, 0x85, 0xb2, 0xf5, 0x36,
+	0x21, 0xfa, 0x39, 0xb8, 0x2a, 0xcd, 0xe4, 0xa0, 0x13, 0x39, 0xc3, 0x60, 0x4c, 0xb7, 0xca, 0x26,
+	0x69, 0xc6, 0xf4, 0x3d, 0xfd, 0x0a, 0xba, 0x37, 0x4d, 0x6b, 0x72, 0xe0, 0x44, 0xce, 0x8e, 0x06,
+	0x74, 0xdf, 0xcc, 0x72, 0x28, 0x05, 0x57, 0xf1, 0xa5, 0x99, 0x96, 0xc3, 0xf4, 0x39, 0xfe, 0x9d,
+	0x00, 0xfd, 0x41, 0x70, 0x85, 0xba, 0x35, 0xc9, 0xf0, 0x4d, 0x8d, 0x52, 0xd1, 0x43, 0xe8, 0x2d,
+	0x52, 0x95, 0x5e, 0xa7, 0x12, 0x6d, 0x7f, 0xeb, 0x98, 0x7e, 0x01, 0xa1, 0x40, 0x85, 0x85, 0xe2,
+	0x65, 0x71, 0x55, 0x95, 0x39, 0x9f, 0xaf, 0xac, 0x61, 0x1f, 0xae, 0xf1, 0x97, 0x1a, 0x6e, 0x94,
+	0xce, 0xcb, 0x42, 0x72, 0xa9, 0xb0, 0x98, 0xaf, 0xf4, 0x32, 0xf9, 0x6c, 0x13, 0x6a, 0x14, 0x54,
+	0xfa, 0x97, 0x07, 0xee, 0x4e, 0x05, 0xba, 0x2d, 0x66, 0x39, 0xf1, 0x13, 0x78, 0x7c, 0xa7, 0x59,
+	0x59, 0x95, 0x85, 0xc4, 0xf8, 0x17, 0x02, 0x8f, 0x27, 0xef, 0x70, 0x5e, 0x2b, 0xfc, 0xae, 0x46,
+	0xb1, 0x6a, 0x55, 0x1c, 0x80, 0xf7, 0xa6, 0x89, 0xad, 0x04, 0x13, 0xdc, 0xd1, 0xd6, 0x79, 0x80,
+	0x36, 0x67, 0xb7, 0xb6, 0x4f, 0x00, 0xe6, 0x3f, 0xd7, 0xc5, 0xeb, 0x2b, 0xc9, 0xdf, 0x1b, 0x4f,
+	0x3d, 0xe6, 0x6b, 0x64, 0xc6, 0xdf, 0x63, 0x7c, 0x0c, 0x0e, 0x2b, 0x6f, 0x1b, 0x7d, 0x7a, 0xfc,
+	0x72, 0x40, 0x22, 0xe7, 0x3f, 0x57, 0xc4, 0x72, 0xe2, 0xdf, 0x08, 0x74, 0x67, 0x28, 0x38, 0xea,
+	0x61, 0x15, 0xe9, 0xb2, 0x75, 0x5f, 0x9f, 0x1f, 0xbc, 0x16, 0x03, 0xd8, 0x9b, 0x97, 0x79, 0xbd,
+	0x2c, 0xcc, 0x5e, 0xf8, 0xac, 0x0d, 0x9b, 0x0a, 0xa2, 0xbc, 0x6d, 0xcd, 0xde, 0xae, 0xc0, 0xca,
+	0x5b, 0xa6, 0xef, 0x9b, 0x0a, 0x55, 0x2a, 0x14, 0x4f, 0x73, 0xfd, 0x6c, 0x7b, 0xac, 0x0d, 0xe3,
+	0x63, 0xd8, 0xfb, 0x16, 0xa5, 0x4c, 0x33, 0x6c, 0xec, 0xcd, 0xf1, 0x2d, 0xe6, 0xad, 0xbd, 0x3a,
+	0xd0, 0x5b, 0x86, 0xef, 0x94, 0xb5, 0x56, 0x9f, 0xe3, 0xbf, 0x08, 0x1c, 0xdc, 0x1d, 0x90, 0x99,
+	0x9c, 0xf9, 0x8f, 0x48, 0x95, 0xde, 0xfa, 0x2b, 0xbe, 0xd0, 0x95, 0x3c, 0x16, 0xac, 0xb1, 0xf3,
+	0x05, 0x3d, 0x82, 0xae, 0xd4, 0x96, 0x58, 0xd9, 0x4f, 0xb6, 0x9a, 0x36, 0x7e, 0x31, 0x4b, 0xa2,
+	0x63, 0xe8, 0x2d, 0x4d, 0x7f, 0xed, 0xa3, 0xf8, 0x68, 0x2b, 0xc1, 0xb6, 0xcf, 0xd6, 0xbc, 0x46,
+	0x08, 0x0a, 0x51, 0x0a, 0x3d, 0x45, 0x9f, 0x99, 0xe0, 0x1e, 0x0f, 0xbe, 0x81, 0xae, 0xd9, 0xc0,
+	0x8d, 0xf5, 0x25, 0xff, 0xbf, 0xbe, 0xe3, 0x3f, 0x08, 0xf4, 0x4e, 0x05, 0x62, 0x32, 0x7b, 0x71,
+	0x42, 0x13, 0x08, 0x36, 0x76, 0x99, 0x3e, 0xdd, 0xca, 0xfc, 0xf7, 0xa3, 0x3c, 0x8c, 0xef, 0xa3,
+	0x58, 0x43, 0x7f, 0x84, 0xfe, 0xa6, 0xd1, 0x74, 0x3b, 0x67, 0xc7, 0x33, 0x39, 0x7c, 0x76, 0x2f,
+	0xc7, 0x14, 0xfe, 0x9a, 0x9c, 0x74, 0x7f, 0x72, 0x9b, 0x4f, 0xd2, 0x75, 0x57, 0x7f, 0x93, 0x8e,
+	0xff, 0x1e, 0x00, 0xca, 0xde, 0xbc, 0xf0, 0xa1, 0x06, 0x00, 0x00,
+}
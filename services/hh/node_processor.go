@@ -138,7 +138,10 @@ func (n *NodeProcessor) WriteShard(shardID uint64, points []models.Point) error
 	n.statMap.Add(writeShardReq, 1)
 	n.statMap.Add(writeShardReqPoints, int64(len(points)))
 
-	b := marshalWrite(shardID, points)
+	b, err := marshalWrite(shardID, points)
+	if err != nil {
+		return err
+	}
 	return n.queue.Append(b)
 }
 
@@ -275,14 +278,12 @@ func (n *NodeProcessor) Active() (bool, error) {
 	return nio != nil, nil
 }
 
-func marshalWrite(shardID uint64, points []models.Point) []byte {
+// marshalWrite encodes the shard ID followed by the points as a batch, so
+// they are not parsed again when the write is sent.
+func marshalWrite(shardID uint64, points []models.Point) ([]byte, error) {
 	b := make([]byte, 8)
 	binary.BigEndian.PutUint64(b, shardID)
-	for _, p := range points {
-		b = append(b, []byte(p.String())...)
-		b = append(b, '\n')
-	}
-	return b
+	return models.AppendPointsBinary(b, points)
 }
 
 func unmarshalWrite(b []byte) (uint64, []models.Point, error) {
@@ -290,6 +291,10 @@ func unmarshalWrite(b []byte) (uint64, []models.Point, error) {
 		return 0, nil, fmt.Errorf("too short: len = %d", len(b))
 	}
 	ownerID := binary.BigEndian.Uint64(b[:8])
-	points, err := models.ParsePoints(b[8:])
+	points, err := models.ParsePointsBinary(b[8:])
+	if err == models.ErrInvalidBatch {
+		// Writes queued by older versions hold line protocol.
+		points, err = models.ParsePoints(b[8:])
+	}
 	return ownerID, points, err
 }
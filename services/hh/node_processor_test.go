@@ -1,6 +1,7 @@
 package hh
 
 import (
+	"encoding/binary"
 	"io"
 	"io/ioutil"
 	"os"
@@ -153,3 +154,18 @@ func TestNodeProcessorSendBlock(t *testing.T) {
 		t.Fatalf("Node processor directory still present after purge")
 	}
 }
+
+func TestUnmarshalWrite_LineProtocol(t *testing.T) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, 100)
+	b = append(b, "cpu,host=server01 value=1 1000000000\ncpu,host=server02 value=2 2000000000\n"...)
+
+	shardID, points, err := unmarshalWrite(b)
+	if err != nil {
+		t.Fatal(err)
+	} else if shardID != 100 {
+		t.Fatalf("unexpected shard id: %d", shardID)
+	} else if len(points) != 2 || points[1].String() != "cpu,host=server02 value=2 2000000000" {
+		t.Fatalf("unexpected points: %v", points)
+	}
+}
@@ -0,0 +1,175 @@
+package httpd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/audit"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// queryAuditor records the audited statements of a query as their results
+// arrive, so each event includes whether the statement succeeded.
+type queryAuditor struct {
+	h     *Handler
+	event audit.Event
+
+	stmts      influxql.Statements
+	categories map[int]string
+}
+
+// newQueryAuditor returns an auditor for q, or nil if auditing is disabled or
+// q has no audited statements.
+func (h *Handler) newQueryAuditor(r *http.Request, user meta.User, q *influxql.Query, db string) *queryAuditor {
+	if h.AuditLog == nil {
+		return nil
+	}
+
+	categories := make(map[int]string)
+	for i, stmt := range q.Statements {
+		if c := audit.StatementCategory(stmt); c != "" {
+			categories[i] = c
+		}
+	}
+	if len(categories) == 0 {
+		return nil
+	}
+
+	return &queryAuditor{
+		h: h,
+		event: audit.Event{
+			User:     auditUserName(user),
+			Addr:     h.auditAddr(r),
+			Database: db,
+		},
+		stmts:      q.Statements,
+		categories: categories,
+	}
+}
+
+// record records the statement res belongs to the first time one of its
+// results is seen.
+func (a *queryAuditor) record(res *query.Result) {
+	if a == nil || res == nil {
+		return
+	}
+
+	category, ok := a.categories[res.StatementID]
+	if !ok {
+		return
+	}
+	delete(a.categories, res.StatementID)
+
+	// Statements that never ran are not audited.
+	if res.Err == query.ErrNotExecuted {
+		return
+	}
+
+	e := a.event
+	e.Category = category
+	e.Statement = a.stmts[res.StatementID].String()
+	if res.Err != nil {
+		e.Error = res.Err.Error()
+	}
+	a.h.AuditLog.Record(e)
+}
+
+// auditRequest records an administrative API request.
+func (h *Handler) auditRequest(r *http.Request, user meta.User, category, db string, err error) {
+	if h.AuditLog == nil {
+		return
+	}
+
+	e := audit.Event{
+		Category:  category,
+		User:      auditUserName(user),
+		Addr:      h.auditAddr(r),
+		Database:  db,
+		Statement: r.Method + " " + r.URL.Path,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	h.AuditLog.Record(e)
+}
+
+// authenticationFailed records a failed authentication attempt and responds
+// with an unauthorized error.
+func (h *Handler) authenticationFailed(w http.ResponseWriter, r *http.Request, msg string) {
+	if h.AuditLog != nil {
+		h.AuditLog.Record(audit.Event{
+			Category: audit.CategoryAuth,
+			User:     parseUsername(r),
+			Addr:     h.auditAddr(r),
+			Error:    msg,
+		})
+	}
+	h.httpError(w, msg, http.StatusUnauthorized)
+}
+
+func auditUserName(user meta.User) string {
+	if user == nil {
+		return ""
+	}
+	return user.ID()
+}
+
+// auditAddr returns the client address of r. Requests from trusted proxies
+// are preceded by the addresses they were forwarded for, which any client can
+// set otherwise.
+func (h *Handler) auditAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if xff := r.Header["X-Forwarded-For"]; xff != nil && h.isTrustedProxy(host) {
+		host = strings.Join(append(xff, host), ",")
+	}
+	return host
+}
+
+// isTrustedProxy returns true if host is the address of a trusted proxy.
+func (h *Handler) isTrustedProxy(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range h.trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies parses the addresses and networks in CIDR notation of
+// trusted proxies.
+func parseTrustedProxies(a []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(a))
+	for _, s := range a {
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address: %q", s)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
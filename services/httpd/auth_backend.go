@@ -0,0 +1,175 @@
+package httpd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"go.uber.org/zap"
+)
+
+// ErrExternalAuthenticate is returned by an authentication backend when the
+// credentials are not valid.
+var ErrExternalAuthenticate = errors.New("external authentication failed")
+
+// ExternalIdentity is a user authenticated by an external identity provider.
+type ExternalIdentity struct {
+	Username string
+	Groups   []string
+}
+
+// PasswordBackend authenticates username and password credentials against an
+// external identity provider.
+type PasswordBackend interface {
+	AuthenticatePassword(username, password string) (*ExternalIdentity, error)
+}
+
+// TokenBackend authenticates bearer tokens issued by an external identity
+// provider.
+type TokenBackend interface {
+	AuthenticateToken(token string) (*ExternalIdentity, error)
+}
+
+// externalUser is a user authenticated by a PasswordBackend or TokenBackend.
+// It does not exist in the meta store, so authorization decisions are made
+// from the privileges granted by its group mappings.
+type externalUser struct {
+	*meta.UserInfo
+}
+
+// newExternalUser returns the user for id. Privileges come from any local user
+// with the same name, including its roles, plus every group mapping matching
+// one of id's groups.
+func (h *Handler) newExternalUser(id *ExternalIdentity) *externalUser {
+	ui := &meta.UserInfo{
+		Name:       id.Username,
+		Privileges: make(map[string]influxql.Privilege),
+	}
+
+	if u, err := h.MetaClient.User(id.Username); err == nil {
+		if local, ok := u.(*meta.UserInfo); ok {
+			ui.Admin = local.Admin
+			for db, p := range local.Privileges {
+				ui.Privileges[db] = p
+			}
+			for db, p := range local.RolePrivileges() {
+				ui.Privileges[db] = mergePrivilege(ui.Privileges[db], p)
+			}
+		}
+	}
+
+	groups := make(map[string]struct{}, len(id.Groups))
+	for _, g := range id.Groups {
+		groups[strings.ToLower(g)] = struct{}{}
+	}
+
+	for _, m := range h.Config.GroupMappings {
+		if _, ok := groups[strings.ToLower(m.Group)]; !ok {
+			continue
+		}
+		if m.Admin {
+			ui.Admin = true
+			continue
+		}
+		p, err := parsePrivilege(m.Privilege)
+		if err != nil {
+			continue
+		}
+		ui.Privileges[m.Database] = mergePrivilege(ui.Privileges[m.Database], p)
+	}
+	return &externalUser{UserInfo: ui}
+}
+
+// authenticatePassword tries each password backend in turn.
+func (h *Handler) authenticatePassword(username, password string) (meta.User, error) {
+	for _, b := range h.PasswordBackends {
+		id, err := b.AuthenticatePassword(username, password)
+		if err == ErrExternalAuthenticate {
+			continue
+		} else if err != nil {
+			h.Logger.Info("External authentication error", zap.Error(err))
+			continue
+		}
+		return h.newExternalUser(id), nil
+	}
+	return nil, meta.ErrAuthenticate
+}
+
+// authenticateToken tries each token backend in turn. Why a token was
+// rejected is logged rather than returned, so it isn't told to the client.
+func (h *Handler) authenticateToken(token string) (meta.User, error) {
+	for _, b := range h.TokenBackends {
+		id, err := b.AuthenticateToken(token)
+		if err == ErrExternalAuthenticate {
+			continue
+		} else if err != nil {
+			h.Logger.Info("External token authentication error", zap.Error(err))
+			continue
+		}
+		return h.newExternalUser(id), nil
+	}
+	return nil, meta.ErrAuthenticate
+}
+
+// authorizeWrite returns an error if user may not write to database.
+func (h *Handler) authorizeWrite(user meta.User, database string) error {
+	if eu, ok := user.(*externalUser); ok {
+		if !eu.AuthorizeDatabase(influxql.WritePrivilege, database) {
+			return fmt.Errorf("%s not authorized to write to %s", eu.Name, database)
+		}
+		return nil
+	}
+	return h.WriteAuthorizer.AuthorizeWrite(user.ID(), database)
+}
+
+// isHMACToken returns true if the JWT token is signed with an HMAC algorithm,
+// meaning it should be verified with the shared secret.
+func isHMACToken(token string) bool {
+	var p jwt.Parser
+	t, _, err := p.ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return false
+	}
+	_, ok := t.Method.(*jwt.SigningMethodHMAC)
+	return ok
+}
+
+// parsePrivilege parses READ, WRITE or ALL.
+func parsePrivilege(s string) (influxql.Privilege, error) {
+	switch strings.ToUpper(s) {
+	case "READ":
+		return influxql.ReadPrivilege, nil
+	case "WRITE":
+		return influxql.WritePrivilege, nil
+	case "ALL", "ALL PRIVILEGES":
+		return influxql.AllPrivileges, nil
+	}
+	return influxql.NoPrivileges, fmt.Errorf("invalid privilege %q", s)
+}
+
+// mergePrivilege combines two grants on the same database.
+func mergePrivilege(a, b influxql.Privilege) influxql.Privilege {
+	switch {
+	case a == influxql.NoPrivileges:
+		return b
+	case b == influxql.NoPrivileges, a == b:
+		return a
+	}
+	return influxql.AllPrivileges
+}
+
+// newAuthBackends builds the external authentication backends enabled in c.
+func newAuthBackends(c Config) ([]PasswordBackend, []TokenBackend) {
+	var pbs []PasswordBackend
+	var tbs []TokenBackend
+	if c.LDAP.Enabled {
+		pbs = append(pbs, NewLDAPBackend(c.LDAP))
+	}
+	if c.OIDC.Enabled {
+		tbs = append(tbs, NewOIDCBackend(c.OIDC))
+	}
+	return pbs, tbs
+}
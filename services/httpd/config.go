@@ -5,11 +5,17 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"net/url"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/freetsdb/freetsdb/monitor/diagnostics"
+	"github.com/freetsdb/freetsdb/pkg/netutil"
+	"github.com/freetsdb/freetsdb/pkg/relabel"
+	"github.com/freetsdb/freetsdb/pkg/routing"
+	"github.com/freetsdb/freetsdb/query"
 	"github.com/freetsdb/freetsdb/toml"
 )
 
@@ -26,40 +32,387 @@ const (
 	// DefaultMaxBodySize is the default maximum size of a client request body, in bytes. Specify 0 for no limit.
 	DefaultMaxBodySize = 25e6
 
+	// DefaultReadHeaderTimeout is the maximum time to read the headers of a request.
+	DefaultReadHeaderTimeout = 10 * time.Second
+
+	// DefaultIdleTimeout is the maximum time a keep-alive connection waits for the next request.
+	DefaultIdleTimeout = 3 * time.Minute
+
+	// DefaultMaxHeaderBytes is the default maximum size of the headers of a request, in bytes.
+	DefaultMaxHeaderBytes = 1 << 20
+
+	// DefaultHTTP2MaxStreams is the default maximum number of concurrent streams of an HTTP/2 connection.
+	DefaultHTTP2MaxStreams = 250
+
 	// DefaultEnqueuedWriteTimeout is the maximum time a write request can wait to be processed.
 	DefaultEnqueuedWriteTimeout = 30 * time.Second
+
+	// DefaultLDAPGroupAttribute is the default attribute read from a user's
+	// LDAP entry to find the groups it belongs to.
+	DefaultLDAPGroupAttribute = "memberOf"
+
+	// DefaultLDAPTimeout is the default timeout for a single LDAP exchange.
+	DefaultLDAPTimeout = 5 * time.Second
+
+	// DefaultOIDCUsernameClaim is the default token claim holding the username.
+	DefaultOIDCUsernameClaim = "preferred_username"
+
+	// DefaultOIDCGroupsClaim is the default token claim holding the user's groups.
+	DefaultOIDCGroupsClaim = "groups"
+
+	// DefaultOIDCKeyRefreshInterval is how often the issuer's signing keys are refetched.
+	DefaultOIDCKeyRefreshInterval = time.Hour
+
+	// DefaultEnrollmentCertificateLifetime is how long the client certificates
+	// issued to enrolled agents are valid.
+	DefaultEnrollmentCertificateLifetime = 90 * 24 * time.Hour
+
+	// DefaultEnrollmentTokenLifetime is how long a bootstrap token can be
+	// redeemed when its lifetime is not given.
+	DefaultEnrollmentTokenLifetime = time.Hour
+
+	// DefaultSessionLifetime is how long a session started with /login lasts.
+	DefaultSessionLifetime = time.Hour
+
+	// DefaultLoginMaxFailures is how many times a client may fail to log in
+	// before it is locked out.
+	DefaultLoginMaxFailures = 5
+
+	// DefaultLoginLockout is how long a client is locked out.
+	DefaultLoginLockout = time.Minute
 )
 
 // Config represents a configuration for a HTTP service.
 type Config struct {
-	Enabled                 bool           `toml:"enabled"`
-	BindAddress             string         `toml:"bind-address"`
-	AuthEnabled             bool           `toml:"auth-enabled"`
-	LogEnabled              bool           `toml:"log-enabled"`
-	SuppressWriteLog        bool           `toml:"suppress-write-log"`
-	WriteTracing            bool           `toml:"write-tracing"`
-	FluxEnabled             bool           `toml:"flux-enabled"`
-	FluxLogEnabled          bool           `toml:"flux-log-enabled"`
-	PprofEnabled            bool           `toml:"pprof-enabled"`
-	DebugPprofEnabled       bool           `toml:"debug-pprof-enabled"`
-	HTTPSEnabled            bool           `toml:"https-enabled"`
-	HTTPSCertificate        string         `toml:"https-certificate"`
-	HTTPSPrivateKey         string         `toml:"https-private-key"`
-	MaxRowLimit             int            `toml:"max-row-limit"`
-	MaxConnectionLimit      int            `toml:"max-connection-limit"`
-	SharedSecret            string         `toml:"shared-secret"`
-	Realm                   string         `toml:"realm"`
-	UnixSocketEnabled       bool           `toml:"unix-socket-enabled"`
-	UnixSocketGroup         *toml.Group    `toml:"unix-socket-group"`
-	UnixSocketPermissions   toml.FileMode  `toml:"unix-socket-permissions"`
-	BindSocket              string         `toml:"bind-socket"`
-	MaxBodySize             int            `toml:"max-body-size"`
-	AccessLogPath           string         `toml:"access-log-path"`
-	AccessLogStatusFilters  []StatusFilter `toml:"access-log-status-filters"`
-	MaxConcurrentWriteLimit int            `toml:"max-concurrent-write-limit"`
-	MaxEnqueuedWriteLimit   int            `toml:"max-enqueued-write-limit"`
-	EnqueuedWriteTimeout    time.Duration  `toml:"enqueued-write-timeout"`
-	TLS                     *tls.Config    `toml:"-"`
+	Enabled                 bool             `toml:"enabled"`
+	BindAddress             string           `toml:"bind-address"`
+	AuthEnabled             bool             `toml:"auth-enabled"`
+	LogEnabled              bool             `toml:"log-enabled"`
+	SuppressWriteLog        bool             `toml:"suppress-write-log"`
+	WriteTracing            bool             `toml:"write-tracing"`
+	WriteTraceSampleRate    float64          `toml:"write-trace-sample-rate"`
+	FluxEnabled             bool             `toml:"flux-enabled"`
+	FluxLogEnabled          bool             `toml:"flux-log-enabled"`
+	PprofEnabled            bool             `toml:"pprof-enabled"`
+	DebugPprofEnabled       bool             `toml:"debug-pprof-enabled"`
+	HTTPSEnabled            bool             `toml:"https-enabled"`
+	HTTPSCertificate        string           `toml:"https-certificate"`
+	HTTPSPrivateKey         string           `toml:"https-private-key"`
+	MaxRowLimit             int              `toml:"max-row-limit"`
+	MaxConnectionLimit      int              `toml:"max-connection-limit"`
+	SharedSecret            string           `toml:"shared-secret" secret:"true"`
+	Realm                   string           `toml:"realm"`
+	UnixSocketEnabled       bool             `toml:"unix-socket-enabled"`
+	UnixSocketGroup         *toml.Group      `toml:"unix-socket-group"`
+	UnixSocketPermissions   toml.FileMode    `toml:"unix-socket-permissions"`
+	BindSocket              string           `toml:"bind-socket"`
+	MaxBodySize             int              `toml:"max-body-size"`
+	MaxRequestBodySize      int              `toml:"max-request-body-size"`
+	MaxHeaderBytes          int              `toml:"max-header-bytes"`
+	ReadTimeout             toml.Duration    `toml:"read-timeout"`
+	ReadHeaderTimeout       toml.Duration    `toml:"read-header-timeout"`
+	WriteTimeout            toml.Duration    `toml:"write-timeout"`
+	IdleTimeout             toml.Duration    `toml:"idle-timeout"`
+	HTTP2Enabled            bool             `toml:"http2-enabled"`
+	HTTP2MaxStreams         int              `toml:"http2-max-streams"`
+	AccessLogPath           string           `toml:"access-log-path"`
+	AccessLogStatusFilters  []StatusFilter   `toml:"access-log-status-filters"`
+	MaxConcurrentWriteLimit int              `toml:"max-concurrent-write-limit"`
+	MaxEnqueuedWriteLimit   int              `toml:"max-enqueued-write-limit"`
+	EnqueuedWriteTimeout    time.Duration    `toml:"enqueued-write-timeout"`
+	LDAP                    LDAPConfig       `toml:"ldap"`
+	OIDC                    OIDCConfig       `toml:"oidc"`
+	Enrollment              EnrollmentConfig `toml:"enrollment"`
+	CORS                    CORSConfig       `toml:"cors"`
+	DeadLetter              DeadLetterConfig `toml:"dead-letter"`
+	GroupMappings           []GroupMapping   `toml:"group-mapping"`
+	DefaultQueryPriority    string           `toml:"default-query-priority"`
+	QueryPriorities         []UserPriority   `toml:"query-priority"`
+	QueryCursorTTL          toml.Duration    `toml:"query-cursor-ttl"`
+	MaxQueryCursors         int              `toml:"max-query-cursors"`
+	MaxQueryStreams         int              `toml:"max-query-streams"`
+	MaxTagValuesLimit       int              `toml:"max-tag-values-limit"`
+	TimestampCheck          string           `toml:"timestamp-check"`
+	DuplicatePoints         string           `toml:"duplicate-points"`
+	SessionLifetime         toml.Duration    `toml:"session-lifetime"`
+	LoginMaxFailures        int              `toml:"login-max-failures"`
+	LoginLockout            toml.Duration    `toml:"login-lockout"`
+	UIEnabled               bool             `toml:"ui-enabled"`
+	Relabel                 []relabel.Rule   `toml:"relabel"`
+	Routes                  []routing.Rule   `toml:"route"`
+	Listeners               []ListenerConfig `toml:"listener"`
+	TrustedProxies          []string         `toml:"trusted-proxies"`
+	TLS                     *tls.Config      `toml:"-"`
+}
+
+// LDAPConfig configures authentication of username/password credentials with
+// an LDAP simple bind.
+type LDAPConfig struct {
+	Enabled bool `toml:"enabled"`
+
+	// URL of the directory server, either ldap:// or ldaps://.
+	URL string `toml:"url"`
+
+	// UserDNTemplate is the DN to bind as. The escaped username replaces %s.
+	UserDNTemplate string `toml:"user-dn-template"`
+
+	// GroupAttribute is read from the bound user's entry to find its groups.
+	GroupAttribute string `toml:"group-attribute"`
+
+	InsecureSkipVerify bool          `toml:"insecure-skip-verify"`
+	Timeout            toml.Duration `toml:"timeout"`
+}
+
+// Validate returns an error if the LDAP config is invalid.
+func (c LDAPConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("ldap url: %v", err)
+	} else if u.Scheme != "ldap" && u.Scheme != "ldaps" {
+		return fmt.Errorf("ldap url must use the ldap or ldaps scheme: %q", c.URL)
+	} else if u.Host == "" {
+		return fmt.Errorf("ldap url must include a host: %q", c.URL)
+	}
+	if strings.Count(c.UserDNTemplate, "%s") != 1 {
+		return errors.New("ldap user-dn-template must contain %s exactly once")
+	}
+	return nil
+}
+
+// OIDCConfig configures authentication of bearer tokens issued by an OpenID
+// Connect provider.
+type OIDCConfig struct {
+	Enabled bool `toml:"enabled"`
+
+	// Issuer is the provider's issuer URL. Its discovery document is used to
+	// find the keys that sign tokens.
+	Issuer string `toml:"issuer"`
+
+	// Audience must appear in a token's aud claim. Usually the client ID.
+	Audience string `toml:"audience"`
+
+	UsernameClaim      string        `toml:"username-claim"`
+	GroupsClaim        string        `toml:"groups-claim"`
+	KeyRefreshInterval toml.Duration `toml:"key-refresh-interval"`
+}
+
+// Validate returns an error if the OIDC config is invalid.
+func (c OIDCConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if u, err := url.Parse(c.Issuer); err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("oidc issuer must be an http or https URL: %q", c.Issuer)
+	} else if c.Audience == "" {
+		return errors.New("oidc audience is required")
+	} else if c.UsernameClaim == "" {
+		return errors.New("oidc username-claim is required")
+	}
+	return nil
+}
+
+// EnrollmentConfig configures the enrollment of agents. An agent redeems a
+// one-time bootstrap token for a client certificate signed by the enrollment
+// CA, then writes over mutual TLS to the databases the token was issued for.
+type EnrollmentConfig struct {
+	Enabled bool `toml:"enabled"`
+
+	// CACertificate and CAPrivateKey are the PEM files of the CA that signs
+	// and verifies client certificates.
+	CACertificate string `toml:"ca-certificate"`
+	CAPrivateKey  string `toml:"ca-private-key"`
+
+	CertificateLifetime toml.Duration `toml:"certificate-lifetime"`
+	TokenLifetime       toml.Duration `toml:"token-lifetime"`
+}
+
+// Validate returns an error if the enrollment config is invalid.
+func (c EnrollmentConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.CACertificate == "" {
+		return errors.New("enrollment ca-certificate is required")
+	} else if c.CAPrivateKey == "" {
+		return errors.New("enrollment ca-private-key is required")
+	} else if c.CertificateLifetime <= 0 {
+		return errors.New("enrollment certificate-lifetime must be positive")
+	} else if c.TokenLifetime <= 0 {
+		return errors.New("enrollment token-lifetime must be positive")
+	}
+	return nil
+}
+
+// CORSConfig configures the cross-origin requests of browser clients, such as
+// single-page apps querying FreeTSDB directly.
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed, such as "https://app.example.com".
+	// "*" allows any origin, and a host starting with "*." any of its
+	// subdomains. No origin is allowed if empty.
+	AllowedOrigins []string `toml:"allowed-origins"`
+
+	AllowedMethods []string `toml:"allowed-methods"`
+	AllowedHeaders []string `toml:"allowed-headers"`
+	ExposedHeaders []string `toml:"exposed-headers"`
+
+	// AllowCredentials lets browsers send cookies with the requests of the
+	// allowed origins, such as the session started with /login.
+	AllowCredentials bool `toml:"allow-credentials"`
+
+	// MaxAge is how long browsers cache the response to a preflight request.
+	// Browsers use their own default if zero.
+	MaxAge toml.Duration `toml:"max-age"`
+}
+
+// Validate returns an error if the CORS config is invalid.
+func (c CORSConfig) Validate() error {
+	for _, o := range c.AllowedOrigins {
+		if o == "*" {
+			if c.AllowCredentials {
+				return errors.New("cors allow-credentials requires explicit allowed-origins")
+			}
+			continue
+		}
+		u, err := url.Parse(strings.Replace(o, "://*.", "://", 1))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("cors allowed origin must be an http or https origin: %q", o)
+		}
+	}
+	if c.MaxAge < 0 {
+		return errors.New("cors max-age cannot be negative")
+	}
+	return nil
+}
+
+// DeadLetterConfig configures keeping the points rejected by writes, with
+// the reasons they were rejected. The rejected points of a database are
+// written to the database, in RetentionPolicy or in its default retention
+// policy, so they can only be read by the users who can read the database.
+type DeadLetterConfig struct {
+	Enabled         bool   `toml:"enabled"`
+	RetentionPolicy string `toml:"retention-policy"`
+}
+
+// GroupMapping grants privileges to users authenticated by an external
+// backend who belong to Group.
+type GroupMapping struct {
+	Group     string `toml:"group"`
+	Admin     bool   `toml:"admin"`
+	Database  string `toml:"database"`
+	Privilege string `toml:"privilege"`
+}
+
+// Validate returns an error if the mapping is invalid.
+func (m GroupMapping) Validate() error {
+	if m.Group == "" {
+		return errors.New("group-mapping requires a group")
+	} else if m.Admin {
+		return nil
+	} else if m.Database == "" {
+		return fmt.Errorf("group-mapping for %q requires admin or a database", m.Group)
+	}
+	if _, err := parsePrivilege(m.Privilege); err != nil {
+		return fmt.Errorf("group-mapping for %q: %v", m.Group, err)
+	}
+	return nil
+}
+
+// UserPriority assigns the queries of User to a priority class, either
+// "interactive" or "batch".
+type UserPriority struct {
+	User     string `toml:"user"`
+	Priority string `toml:"priority"`
+}
+
+// Validate returns an error if the priority is invalid.
+func (p UserPriority) Validate() error {
+	if p.User == "" {
+		return errors.New("query-priority requires a user")
+	}
+	if _, err := query.ParseQueryPriority(p.Priority); err != nil {
+		return fmt.Errorf("query-priority for %q: %v", p.User, err)
+	}
+	return nil
+}
+
+// Validate returns an error if the config is invalid.
+func (c Config) Validate() error {
+	if c.Enabled {
+		if err := netutil.ValidateBindAddress(c.BindAddress); err != nil {
+			return err
+		}
+	}
+	if err := c.LDAP.Validate(); err != nil {
+		return err
+	}
+	if err := c.OIDC.Validate(); err != nil {
+		return err
+	}
+	if err := c.Enrollment.Validate(); err != nil {
+		return err
+	} else if c.Enrollment.Enabled && !c.HTTPSEnabled {
+		return errors.New("enrollment requires https-enabled")
+	}
+	if err := c.CORS.Validate(); err != nil {
+		return err
+	}
+	for _, m := range c.GroupMappings {
+		if err := m.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.MaxRequestBodySize < 0 {
+		return errors.New("max-request-body-size cannot be negative")
+	} else if c.MaxHeaderBytes < 0 {
+		return errors.New("max-header-bytes cannot be negative")
+	} else if c.ReadTimeout < 0 || c.ReadHeaderTimeout < 0 || c.WriteTimeout < 0 || c.IdleTimeout < 0 {
+		return errors.New("read-timeout, read-header-timeout, write-timeout and idle-timeout cannot be negative")
+	} else if c.HTTP2MaxStreams < 0 {
+		return errors.New("http2-max-streams cannot be negative")
+	}
+	if c.WriteTraceSampleRate < 0 || c.WriteTraceSampleRate > 1 {
+		return errors.New("write-trace-sample-rate must be between 0 and 1")
+	}
+	if c.SessionLifetime <= 0 {
+		return errors.New("session-lifetime must be positive")
+	}
+	if c.LoginMaxFailures < 0 {
+		return errors.New("login-max-failures cannot be negative")
+	} else if c.LoginMaxFailures > 0 && c.LoginLockout <= 0 {
+		return errors.New("login-lockout must be positive")
+	}
+	if _, err := query.ParseQueryPriority(c.DefaultQueryPriority); err != nil {
+		return fmt.Errorf("default-query-priority: %v", err)
+	}
+	for _, p := range c.QueryPriorities {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := validateTimestampCheck(c.TimestampCheck); err != nil {
+		return err
+	}
+	if err := validateDuplicatePoints(c.DuplicatePoints); err != nil {
+		return err
+	}
+	if err := relabel.Validate(c.Relabel); err != nil {
+		return err
+	}
+	if err := routing.Validate(c.Routes); err != nil {
+		return err
+	}
+	for _, l := range c.Listeners {
+		if err := l.Validate(); err != nil {
+			return err
+		}
+	}
+	if _, err := parseTrustedProxies(c.TrustedProxies); err != nil {
+		return fmt.Errorf("trusted-proxies: %v", err)
+	}
+	return nil
 }
 
 // NewConfig returns a new Config with default settings.
@@ -80,7 +433,55 @@ func NewConfig() Config {
 		UnixSocketPermissions: 0777,
 		BindSocket:            DefaultBindSocket,
 		MaxBodySize:           DefaultMaxBodySize,
+		MaxHeaderBytes:        DefaultMaxHeaderBytes,
+		ReadHeaderTimeout:     toml.Duration(DefaultReadHeaderTimeout),
+		IdleTimeout:           toml.Duration(DefaultIdleTimeout),
+		HTTP2MaxStreams:       DefaultHTTP2MaxStreams,
 		EnqueuedWriteTimeout:  DefaultEnqueuedWriteTimeout,
+		QueryCursorTTL:        toml.Duration(DefaultQueryCursorTTL),
+		MaxQueryCursors:       DefaultMaxQueryCursors,
+		MaxQueryStreams:       DefaultMaxQueryStreams,
+		MaxTagValuesLimit:     DefaultMaxTagValuesLimit,
+		TimestampCheck:        timestampCheckOff,
+		DuplicatePoints:       duplicatePointsLast,
+		SessionLifetime:       toml.Duration(DefaultSessionLifetime),
+		LoginMaxFailures:      DefaultLoginMaxFailures,
+		LoginLockout:          toml.Duration(DefaultLoginLockout),
+		LDAP: LDAPConfig{
+			GroupAttribute: DefaultLDAPGroupAttribute,
+			Timeout:        toml.Duration(DefaultLDAPTimeout),
+		},
+		OIDC: OIDCConfig{
+			UsernameClaim:      DefaultOIDCUsernameClaim,
+			GroupsClaim:        DefaultOIDCGroupsClaim,
+			KeyRefreshInterval: toml.Duration(DefaultOIDCKeyRefreshInterval),
+		},
+		Enrollment: EnrollmentConfig{
+			CertificateLifetime: toml.Duration(DefaultEnrollmentCertificateLifetime),
+			TokenLifetime:       toml.Duration(DefaultEnrollmentTokenLifetime),
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{"DELETE", "GET", "OPTIONS", "POST", "PUT"},
+			AllowedHeaders: []string{
+				"Accept",
+				"Accept-Encoding",
+				"Authorization",
+				"Content-Length",
+				"Content-Type",
+				"Idempotency-Key",
+				"X-CSRF-Token",
+				"X-HTTP-Method-Override",
+			},
+			ExposedHeaders: []string{
+				"Date",
+				"X-FreeTSDB-Version",
+				"X-FreeTSDB-Build",
+				"X-FreeTSDB-Error",
+				"X-FreeTSDB-Error-Code",
+				"X-Request-Id",
+			},
+		},
 	}
 }
 
@@ -98,6 +499,7 @@ func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
 		"https-enabled":        c.HTTPSEnabled,
 		"max-row-limit":        c.MaxRowLimit,
 		"max-connection-limit": c.MaxConnectionLimit,
+		"http2-enabled":        c.HTTP2Enabled,
 		"access-log-path":      c.AccessLogPath,
 	}), nil
 }
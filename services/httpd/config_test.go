@@ -2,6 +2,7 @@ package httpd_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/freetsdb/freetsdb/services/httpd"
@@ -248,3 +249,218 @@ access-log-status-filters = ["4xxx"]
 		}
 	}
 }
+
+func TestConfig_ExternalAuth(t *testing.T) {
+	c := httpd.NewConfig()
+	if _, err := toml.Decode(`
+[ldap]
+  enabled = true
+  url = "ldaps://ldap.example.com"
+  user-dn-template = "uid=%s,ou=people,dc=example,dc=com"
+
+[oidc]
+  enabled = true
+  issuer = "https://idp.example.com"
+  audience = "freetsdb"
+
+[[group-mapping]]
+  group = "ops"
+  admin = true
+
+[[group-mapping]]
+  group = "analysts"
+  database = "metrics"
+  privilege = "read"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if c.LDAP.GroupAttribute != httpd.DefaultLDAPGroupAttribute {
+		t.Fatalf("unexpected ldap group attribute: %s", c.LDAP.GroupAttribute)
+	} else if c.OIDC.UsernameClaim != httpd.DefaultOIDCUsernameClaim {
+		t.Fatalf("unexpected oidc username claim: %s", c.OIDC.UsernameClaim)
+	} else if len(c.GroupMappings) != 2 {
+		t.Fatalf("unexpected group mappings: %d", len(c.GroupMappings))
+	} else if m := c.GroupMappings[1]; m.Group != "analysts" || m.Database != "metrics" || m.Privilege != "read" {
+		t.Fatalf("unexpected group mapping: %+v", m)
+	}
+}
+
+func TestConfig_ExternalAuth_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		cfg  string
+	}{
+		{
+			name: "ldap scheme",
+			cfg: `
+[ldap]
+  enabled = true
+  url = "http://ldap.example.com"
+  user-dn-template = "uid=%s"
+`,
+		},
+		{
+			name: "ldap template",
+			cfg: `
+[ldap]
+  enabled = true
+  url = "ldap://ldap.example.com"
+  user-dn-template = "uid=admin"
+`,
+		},
+		{
+			name: "oidc audience",
+			cfg: `
+[oidc]
+  enabled = true
+  issuer = "https://idp.example.com"
+`,
+		},
+		{
+			name: "mapping privilege",
+			cfg: `
+[[group-mapping]]
+  group = "ops"
+  database = "metrics"
+  privilege = "delete"
+`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := httpd.NewConfig()
+			if _, err := toml.Decode(tt.cfg, &c); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Validate(); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestConfig_Listeners(t *testing.T) {
+	c := httpd.NewConfig()
+	if _, err := toml.Decode(`
+[[listener]]
+  bind-address = "10.0.0.1:8087"
+  capabilities = ["write"]
+
+[[listener]]
+  bind-address = ":8443"
+  capabilities = ["query", "admin"]
+  https-enabled = true
+  https-certificate = "/etc/ssl/freetsdb.pem"
+  https-client-ca = "/etc/ssl/clients.pem"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if len(c.Listeners) != 2 {
+		t.Fatalf("unexpected listeners: %d", len(c.Listeners))
+	} else if l := c.Listeners[1]; l.BindAddress != ":8443" || len(l.Capabilities) != 2 || l.HTTPSClientCA != "/etc/ssl/clients.pem" {
+		t.Fatalf("unexpected listener: %+v", l)
+	}
+
+	for _, l := range []httpd.ListenerConfig{
+		{BindAddress: "", Capabilities: []string{"write"}},
+		{BindAddress: ":8087"},
+		{BindAddress: ":8087", Capabilities: []string{"delete"}},
+		{BindAddress: ":8087", Capabilities: []string{"query"}, HTTPSClientCA: "/etc/ssl/clients.pem"},
+	} {
+		c := httpd.NewConfig()
+		c.Listeners = []httpd.ListenerConfig{l}
+		if err := c.Validate(); err == nil {
+			t.Fatalf("expected error for listener: %+v", l)
+		}
+	}
+}
+
+func TestConfig_Connections(t *testing.T) {
+	c := httpd.NewConfig()
+	if time.Duration(c.ReadHeaderTimeout) != httpd.DefaultReadHeaderTimeout {
+		t.Fatalf("unexpected read header timeout: %s", c.ReadHeaderTimeout)
+	} else if c.MaxHeaderBytes != httpd.DefaultMaxHeaderBytes {
+		t.Fatalf("unexpected max header bytes: %d", c.MaxHeaderBytes)
+	}
+
+	if _, err := toml.Decode(`
+http2-enabled = true
+http2-max-streams = 100
+read-timeout = "1m"
+write-timeout = "5m"
+idle-timeout = "30s"
+max-header-bytes = 4096
+max-request-body-size = 1000000
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !c.HTTP2Enabled || c.HTTP2MaxStreams != 100 {
+		t.Fatalf("unexpected http2 settings: %v, %d", c.HTTP2Enabled, c.HTTP2MaxStreams)
+	} else if time.Duration(c.ReadTimeout) != time.Minute || time.Duration(c.WriteTimeout) != 5*time.Minute || time.Duration(c.IdleTimeout) != 30*time.Second {
+		t.Fatalf("unexpected timeouts: %s, %s, %s", c.ReadTimeout, c.WriteTimeout, c.IdleTimeout)
+	} else if c.MaxHeaderBytes != 4096 || c.MaxRequestBodySize != 1000000 {
+		t.Fatalf("unexpected limits: %d, %d", c.MaxHeaderBytes, c.MaxRequestBodySize)
+	}
+
+	c.IdleTimeout = -1
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for negative idle-timeout, got nil")
+	}
+}
+
+func TestConfig_CORS(t *testing.T) {
+	c := httpd.NewConfig()
+	if _, err := toml.Decode(`
+[cors]
+  allowed-origins = ["https://app.example.com", "https://*.example.org"]
+  allow-credentials = true
+  max-age = "10m"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if len(c.CORS.AllowedOrigins) != 2 || c.CORS.AllowedOrigins[1] != "https://*.example.org" {
+		t.Fatalf("unexpected allowed origins: %v", c.CORS.AllowedOrigins)
+	} else if !c.CORS.AllowCredentials || time.Duration(c.CORS.MaxAge) != 10*time.Minute {
+		t.Fatalf("unexpected cors config: %+v", c.CORS)
+	} else if len(c.CORS.AllowedMethods) == 0 {
+		t.Fatal("expected default allowed methods")
+	}
+
+	for _, cors := range []httpd.CORSConfig{
+		{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+		{AllowedOrigins: []string{"app.example.com"}},
+		{AllowedOrigins: []string{"https://app.example.com/path"}},
+	} {
+		c := httpd.NewConfig()
+		c.CORS = cors
+		if err := c.Validate(); err == nil {
+			t.Fatalf("expected error for cors config: %+v", cors)
+		}
+	}
+}
+
+func TestConfig_TrustedProxies(t *testing.T) {
+	c := httpd.NewConfig()
+	c.TrustedProxies = []string{"10.0.0.1", "fd00::1", "192.168.0.0/16"}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, proxy := range []string{"proxy.example.com", "10.0.0.0/33"} {
+		c.TrustedProxies = []string{proxy}
+		if err := c.Validate(); err == nil {
+			t.Fatalf("expected error for trusted proxy: %s", proxy)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package httpd
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cors adds the CORS headers to the responses to the allowed origins.
+func (h *Handler) cors(inner http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.setCORSHeaders(w, r)
+		if r.Method == "OPTIONS" {
+			return
+		}
+
+		inner.ServeHTTP(w, r)
+	})
+}
+
+// servePreflight responds to the CORS preflight requests of any route.
+func (h *Handler) servePreflight(w http.ResponseWriter, r *http.Request) {
+	h.setCORSHeaders(w, r)
+	h.writeHeader(w, http.StatusNoContent)
+}
+
+// setCORSHeaders sets the CORS headers of the response if the origin of the
+// request is allowed. Browsers refuse the responses to other origins.
+func (h *Handler) setCORSHeaders(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return
+	}
+
+	// The headers depend on the origin, so caches must not share them.
+	w.Header().Add("Vary", "Origin")
+
+	c := h.Config.CORS
+	if !c.allowOrigin(origin) {
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if c.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(c.ExposedHeaders) > 0 {
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.ExposedHeaders, ", "))
+	}
+
+	if r.Method == "OPTIONS" {
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.AllowedMethods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(c.AllowedHeaders, ", "))
+		if c.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(time.Duration(c.MaxAge)/time.Second)))
+		}
+	}
+}
+
+// allowOrigin returns true if the origin is allowed.
+func (c CORSConfig) allowOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, o := range c.AllowedOrigins {
+		o = strings.ToLower(o)
+		if o == "*" || o == origin {
+			return true
+		}
+
+		// A leading "*." in the host allows any subdomain.
+		if i := strings.Index(o, "://*."); i >= 0 {
+			scheme, suffix := o[:i+len("://")], o[i+len("://*"):]
+			if len(origin) > len(scheme)+len(suffix) && strings.HasPrefix(origin, scheme) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package httpd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/query"
+)
+
+const (
+	// DefaultQueryCursorTTL is the default time a query cursor is kept
+	// between requests.
+	DefaultQueryCursorTTL = 5 * time.Minute
+
+	// DefaultMaxQueryCursors is the default maximum number of open query cursors.
+	DefaultMaxQueryCursors = 100
+)
+
+var (
+	// errQueryCursorNotFound is returned when a cursor does not exist, has
+	// expired or belongs to another user.
+	errQueryCursorNotFound = errors.New("query cursor not found or expired")
+
+	// errTooManyQueryCursors is returned when max-query-cursors are open.
+	errTooManyQueryCursors = errors.New("max-query-cursors limit exceeded")
+)
+
+// queryCursor holds a running query between the requests that page through
+// its results. The query is paused while the cursor waits, since nothing
+// reads its results, and does not hold a slot under the concurrent query
+// limits until the next page is read.
+//
+// The pages are not a snapshot of the data. The iterators of the query read
+// the shards as they go, so a page sees the points written or deleted before
+// it is read, and points written or deleted while the cursor waits may or may
+// not be returned. Each page is only consistent with itself.
+type queryCursor struct {
+	id       string
+	user     string
+	pageSize int
+	epoch    time.Duration
+
+	results <-chan *query.Result
+	closing chan struct{}
+	auditor *queryAuditor
+
+	// pending is the rest of a result that did not fit on the last page.
+	pending *query.Result
+	timer   *time.Timer
+}
+
+// next returns the next result, or nil once the query has finished.
+func (c *queryCursor) next() *query.Result {
+	if r := c.pending; r != nil {
+		c.pending = nil
+		return r
+	}
+
+	for r := range c.results {
+		// Ignore nil results.
+		if r == nil {
+			continue
+		}
+		c.auditor.record(r)
+
+		// if requested, convert result timestamps to epoch
+		if c.epoch != 0 {
+			convertToEpoch(r, c.epoch)
+		}
+		return r
+	}
+	return nil
+}
+
+// page returns the results of the next page. more is true if the query has
+// results after the page.
+func (c *queryCursor) page() (results []*query.Result, more bool) {
+	results = make([]*query.Result, 0)
+	rows := 0
+	for rows < c.pageSize {
+		r := c.next()
+		if r == nil {
+			return results, false
+		}
+
+		// Split the result if it has more rows than are left on the page.
+		r, c.pending = splitResult(r, c.pageSize-rows)
+		for _, series := range r.Series {
+			rows += len(series.Values)
+		}
+		results = appendResult(results, r)
+	}
+
+	// Wait for the next result to know if there are more.
+	if c.pending == nil {
+		c.pending = c.next()
+	}
+	return results, c.pending != nil
+}
+
+// close aborts the query and discards its remaining results.
+func (c *queryCursor) close() {
+	close(c.closing)
+	go func() {
+		for range c.results {
+		}
+	}()
+}
+
+// splitResult splits r after n rows. The rows after the first n are returned
+// in rest, which is nil if r has no more than n rows.
+func splitResult(r *query.Result, n int) (first, rest *query.Result) {
+	for i, series := range r.Series {
+		if len(series.Values) <= n {
+			n -= len(series.Values)
+			continue
+		} else if n == 0 && i > 0 {
+			rest = &query.Result{StatementID: r.StatementID, Series: r.Series[i:], Partial: r.Partial}
+			first = &query.Result{StatementID: r.StatementID, Series: r.Series[:i], Messages: r.Messages, Partial: true}
+			return first, rest
+		}
+
+		head, tail := *series, *series
+		head.Values, head.Partial = series.Values[:n], true
+		tail.Values = series.Values[n:]
+
+		restSeries := append([]*models.Row{&tail}, r.Series[i+1:]...)
+		firstSeries := append(r.Series[:i:i], &head)
+
+		rest = &query.Result{StatementID: r.StatementID, Series: restSeries, Partial: r.Partial}
+		first = &query.Result{StatementID: r.StatementID, Series: firstSeries, Messages: r.Messages, Partial: true}
+		return first, rest
+	}
+	return r, nil
+}
+
+// queryCursorStore holds the open query cursors. Cursors are removed and their
+// queries aborted when they are not used within their TTL.
+type queryCursorStore struct {
+	mu      sync.Mutex
+	cursors map[string]*queryCursor
+}
+
+func newQueryCursorStore() *queryCursorStore {
+	return &queryCursorStore{cursors: make(map[string]*queryCursor)}
+}
+
+// add stores c under a new random ID until ttl elapses.
+func (s *queryCursorStore) add(c *queryCursor, ttl time.Duration, max int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if max > 0 && len(s.cursors) >= max {
+		return errTooManyQueryCursors
+	}
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return err
+	}
+	c.id = hex.EncodeToString(b[:])
+	s.put(c, ttl)
+	return nil
+}
+
+// put stores c until ttl elapses. It must be called with the lock held.
+func (s *queryCursorStore) put(c *queryCursor, ttl time.Duration) {
+	s.cursors[c.id] = c
+	c.timer = time.AfterFunc(ttl, func() {
+		if c := s.take(c.id, c.user); c != nil {
+			c.close()
+		}
+	})
+}
+
+// release stores c again after a page was read from it.
+func (s *queryCursorStore) release(c *queryCursor, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(c, ttl)
+}
+
+// take removes the cursor with the given ID and returns it, or nil if it does
+// not exist or was opened by another user.
+func (s *queryCursorStore) take(id, user string) *queryCursor {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.cursors[id]
+	if c == nil || c.user != user {
+		return nil
+	}
+	c.timer.Stop()
+	delete(s.cursors, id)
+	return c
+}
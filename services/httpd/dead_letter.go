@@ -0,0 +1,302 @@
+package httpd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/freetsdb/freetsdb/coordinator"
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// DeadLetterMeasurement is the measurement the points rejected by the
+	// writes to a database are written to in the database.
+	DeadLetterMeasurement = "rejected_points"
+
+	// DefaultDeadLetterLimit is the number of rejected points returned by the
+	// dead-letter endpoint when the request sets no limit.
+	DefaultDeadLetterLimit = 100
+
+	// deadLetterQueueSize is the number of writes whose rejected points can
+	// wait to be written before more are dropped.
+	deadLetterQueueSize = 64
+)
+
+// deadLetters collects the points of a write to database and retentionPolicy
+// that were rejected, with the reasons they were.
+type deadLetters struct {
+	database        string
+	retentionPolicy string
+	requestID       string
+	lines           []string
+	reasons         []string
+	codes           []ErrorCode
+}
+
+// newDeadLetters returns the collector of the points rejected by the write
+// with requestID to database and retentionPolicy, or nil if dead letters are
+// not enabled.
+func (h *Handler) newDeadLetters(database, retentionPolicy, requestID string) *deadLetters {
+	if !h.Config.DeadLetter.Enabled {
+		return nil
+	}
+	if requestID == "" {
+		requestID = uuid.TimeUUID().String()
+	}
+	return &deadLetters{database: database, retentionPolicy: retentionPolicy, requestID: requestID}
+}
+
+// add collects the line rejected for reason, with the code of its error. It
+// is a no-op on a nil collector.
+func (d *deadLetters) add(line, reason string, code ErrorCode) {
+	if d == nil {
+		return
+	}
+	d.lines = append(d.lines, line)
+	d.reasons = append(d.reasons, reason)
+	d.codes = append(d.codes, code)
+}
+
+// addPoints collects points rejected with err.
+func (d *deadLetters) addPoints(points []models.Point, err error) {
+	if d == nil {
+		return
+	}
+	code := errorCodeOf(err, http.StatusBadRequest)
+	for _, p := range points {
+		d.add(p.String(), err.Error(), code)
+	}
+}
+
+// queueDeadLetters queues the collected points to be written by the
+// dead-letter writer. They are dropped if too many writes are queued already.
+func (h *Handler) queueDeadLetters(d *deadLetters) {
+	if d == nil || len(d.lines) == 0 {
+		return
+	}
+
+	select {
+	case h.deadLetters.queue <- d:
+	default:
+		atomic.AddInt64(&h.stats.PointsDeadLetterDropped, int64(len(d.lines)))
+	}
+}
+
+// writeDeadLetters writes the collected points to the database they were
+// written to. Each rejected point is stored as a point with its line and the
+// reason it was rejected, timestamped with the time of the rejection, in a
+// series of the write it comes from. Failures are logged, as the write has
+// already been answered.
+func (h *Handler) writeDeadLetters(d *deadLetters) {
+	now := time.Now().UnixNano()
+	points := make([]models.Point, 0, len(d.lines))
+	for i, line := range d.lines {
+		tags := map[string]string{
+			"code":       string(d.codes[i]),
+			"request_id": d.requestID,
+		}
+		if d.retentionPolicy != "" {
+			tags["rp"] = d.retentionPolicy
+		}
+		fields := models.Fields{"line": line, "reason": d.reasons[i]}
+
+		// The rejected points of a write are spread over nanoseconds so
+		// they do not overwrite each other.
+		p, err := models.NewPoint(DeadLetterMeasurement, models.NewTags(tags), fields, time.Unix(0, now+int64(i)))
+		if err != nil {
+			h.Logger.Info("Unable to create dead-letter point", zap.Error(err))
+			continue
+		}
+		points = append(points, p)
+	}
+
+	if err := h.PointsWriter.WritePoints(d.database, h.Config.DeadLetter.RetentionPolicy, coordinator.ConsistencyLevelOne, nil, points); err != nil {
+		h.Logger.Info("Failed to write rejected points",
+			zap.String("db", d.database), zap.Error(err))
+		return
+	}
+	atomic.AddInt64(&h.stats.PointsDeadLettered, int64(len(points)))
+}
+
+// deadLetterWriter writes the points rejected by writes in the background,
+// so writes are answered without waiting for them.
+type deadLetterWriter struct {
+	mu      sync.Mutex
+	queue   chan *deadLetters
+	closing chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newDeadLetterWriter() *deadLetterWriter {
+	return &deadLetterWriter{queue: make(chan *deadLetters, deadLetterQueueSize)}
+}
+
+// open starts writing the queued points with write.
+func (w *deadLetterWriter) open(write func(d *deadLetters)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closing != nil {
+		return
+	}
+	closing := make(chan struct{})
+	w.closing = closing
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.run(closing, write)
+	}()
+}
+
+// close stops writing once the points queued are written.
+func (w *deadLetterWriter) close() {
+	w.mu.Lock()
+	if w.closing != nil {
+		close(w.closing)
+		w.closing = nil
+	}
+	w.mu.Unlock()
+	w.wg.Wait()
+}
+
+func (w *deadLetterWriter) run(closing <-chan struct{}, write func(d *deadLetters)) {
+	for {
+		select {
+		case d := <-w.queue:
+			write(d)
+		case <-closing:
+			for {
+				select {
+				case d := <-w.queue:
+					write(d)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deadLetter is a rejected point returned by the dead-letter endpoint.
+type deadLetter struct {
+	Time            time.Time `json:"time"`
+	RequestID       string    `json:"request_id,omitempty"`
+	RetentionPolicy string    `json:"rp,omitempty"`
+	Code            ErrorCode `json:"code"`
+	Reason          string    `json:"reason"`
+	Line            string    `json:"line"`
+}
+
+// serveDeadLetters returns the most recent points rejected by the writes to
+// a database, newest first.
+func (h *Handler) serveDeadLetters(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.Config.DeadLetter.Enabled {
+		h.httpError(w, "dead letters are not enabled", http.StatusNotFound)
+		return
+	}
+
+	db := r.FormValue("db")
+	if db == "" {
+		h.httpError(w, "database is required", http.StatusBadRequest)
+		return
+	}
+
+	limit := DefaultDeadLetterLimit
+	if s := r.FormValue("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			h.httpError(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	if h.Config.AuthEnabled {
+		if user == nil || !user.AuthorizeDatabase(influxql.ReadPrivilege, db) {
+			h.httpError(w, "user is not authorized to read from database "+strconv.Quote(db), http.StatusForbidden)
+			return
+		}
+	}
+
+	// Read one more point than the limit to know if there are more.
+	source := influxql.QuoteIdent(DeadLetterMeasurement)
+	if rp := h.Config.DeadLetter.RetentionPolicy; rp != "" {
+		source = influxql.QuoteIdent(rp, DeadLetterMeasurement)
+	}
+	q, err := influxql.ParseQuery(fmt.Sprintf(`SELECT "code", "line", "reason", "request_id", "rp" FROM %s ORDER BY time DESC LIMIT %d`,
+		source, limit+1))
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	opts := query.ExecutionOptions{
+		Database:   db,
+		ReadOnly:   true,
+		Authorizer: query.OpenAuthorizer,
+		AbortCh:    r.Context().Done(),
+	}
+	resp := struct {
+		Points    []deadLetter `json:"points"`
+		Truncated bool         `json:"truncated"`
+	}{Points: []deadLetter{}}
+	for result := range h.QueryExecutor.ExecuteQuery(q, opts, nil) {
+		if result.Err != nil {
+			h.httpError(w, result.Err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, row := range result.Series {
+			for _, values := range row.Values {
+				resp.Points = append(resp.Points, newDeadLetter(row.Columns, values))
+			}
+		}
+	}
+	if len(resp.Points) > limit {
+		resp.Points, resp.Truncated = resp.Points[:limit], true
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// newDeadLetter returns the rejected point of a row of the dead-letter
+// measurement with columns.
+func newDeadLetter(columns []string, values []interface{}) deadLetter {
+	var d deadLetter
+	for i, c := range columns {
+		if i >= len(values) {
+			break
+		}
+		switch v := values[i].(type) {
+		case time.Time:
+			if c == "time" {
+				d.Time = v
+			}
+		case string:
+			switch c {
+			case "request_id":
+				d.RequestID = v
+			case "rp":
+				d.RetentionPolicy = v
+			case "code":
+				d.Code = ErrorCode(v)
+			case "reason":
+				d.Reason = v
+			case "line":
+				d.Line = v
+			}
+		}
+	}
+	return d
+}
@@ -0,0 +1,104 @@
+package httpd
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/freetsdb/freetsdb/models"
+)
+
+// The ways points of a batch with the same series and timestamp are resolved.
+const (
+	duplicatePointsLast  = "last"
+	duplicatePointsMerge = "merge"
+)
+
+// validateDuplicatePoints returns an error if mode is not a duplicate-points mode.
+func validateDuplicatePoints(mode string) error {
+	switch mode {
+	case "", duplicatePointsLast, duplicatePointsMerge:
+		return nil
+	default:
+		return fmt.Errorf("duplicate-points must be %q or %q: %q", duplicatePointsLast, duplicatePointsMerge, mode)
+	}
+}
+
+// duplicatePointsMode returns the duplicate-points mode of a write, given by
+// the duplicates parameter of the request or by the configured default.
+func (h *Handler) duplicatePointsMode(mode string) (string, error) {
+	if mode == "" {
+		mode = h.Config.DuplicatePoints
+	}
+	if err := validateDuplicatePoints(mode); err != nil {
+		return "", fmt.Errorf("invalid duplicates: %q", mode)
+	}
+	return mode, nil
+}
+
+// duplicateKey identifies the points of a batch that are written to the same
+// series at the same time.
+type duplicateKey struct {
+	key  string
+	time int64
+}
+
+// mergeDuplicatePoints merges the fields of the points written to the same
+// series at the same time into a single point, so that fields split across
+// lines of a batch are all kept. A field set by several points takes the
+// value of the last of them. The merged point takes the place and the index
+// of the first point; indexes are the indexes of points in the batch.
+func (h *Handler) mergeDuplicatePoints(points []models.Point, indexes []int) ([]models.Point, []int, error) {
+	if len(points) < 2 {
+		return points, indexes, nil
+	}
+
+	first := make(map[duplicateKey]int, len(points))
+	fields := make(map[int]models.Fields)
+	for i, p := range points {
+		k := duplicateKey{key: string(p.Key()), time: p.UnixNano()}
+		j, ok := first[k]
+		if !ok {
+			first[k] = i
+			continue
+		}
+
+		merged, ok := fields[j]
+		if !ok {
+			f, err := points[j].Fields()
+			if err != nil {
+				return nil, nil, err
+			}
+			merged, fields[j] = f, f
+		}
+		f, err := p.Fields()
+		if err != nil {
+			return nil, nil, err
+		}
+		for name, v := range f {
+			merged[name] = v
+		}
+		points[i] = nil
+	}
+	if len(fields) == 0 {
+		return points, indexes, nil
+	}
+
+	kept, keptIdx := points[:0], indexes[:0]
+	for i, p := range points {
+		if p == nil {
+			atomic.AddInt64(&h.stats.PointsMerged, 1)
+			continue
+		}
+		if f, ok := fields[i]; ok {
+			mp, err := models.NewPoint(string(p.Name()), p.Tags(), f, time.Unix(0, p.UnixNano()))
+			if err != nil {
+				return nil, nil, err
+			}
+			p = mp
+		}
+		kept = append(kept, p)
+		keptIdx = append(keptIdx, indexes[i])
+	}
+	return kept, keptIdx, nil
+}
@@ -0,0 +1,385 @@
+package httpd
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/freetsdb/freetsdb/services/audit"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/toml"
+)
+
+// enrollmentURIScheme is the scheme of the URI SANs of client certificates
+// naming the databases the agent was enrolled for, as in
+// "freetsdb:database:telegraf". They are informational: the databases are
+// read from the meta store when authorizing the agent.
+const enrollmentURIScheme = "freetsdb"
+
+var (
+	// ErrEnrollmentTokenInvalid is returned when redeeming a bootstrap token
+	// that was never issued, was already redeemed or has expired.
+	ErrEnrollmentTokenInvalid = errors.New("invalid or expired enrollment token")
+
+	// ErrCertificateRevoked is returned when verifying the certificate of an
+	// agent that was revoked or not issued by the cluster.
+	ErrCertificateRevoked = errors.New("client certificate revoked or unknown")
+
+	// errEnrollmentDisabled is returned by the enrollment endpoints when
+	// enrollment is not enabled.
+	errEnrollmentDisabled = errors.New("enrollment is disabled")
+)
+
+// EnrollmentMetaClient stores the bootstrap tokens and the certificates of
+// enrolled agents, so any node can redeem a token and certificates can be
+// revoked cluster-wide.
+type EnrollmentMetaClient interface {
+	CreateEnrollmentToken(name string, databases []string, lifetime time.Duration) (string, *meta.EnrollmentTokenInfo, error)
+	EnrollmentToken(token string) *meta.EnrollmentTokenInfo
+	RedeemEnrollmentToken(token, serial string, expiresAt time.Time) error
+	Agents() []meta.AgentInfo
+	Agent(serial string) *meta.AgentInfo
+	RevokeAgent(name string) error
+}
+
+// Enrollment issues bootstrap tokens and redeems them for client certificates
+// signed by the enrollment CA. The identity and databases of an agent are
+// those recorded in the meta store for the serial number of its certificate,
+// not those the agent asks for.
+type Enrollment struct {
+	MetaClient EnrollmentMetaClient
+
+	caCert *x509.Certificate
+	caPEM  []byte
+	caKey  crypto.Signer
+	pool   *x509.CertPool
+
+	certLifetime  time.Duration
+	tokenLifetime time.Duration
+
+	now func() time.Time
+}
+
+// NewEnrollment returns the enrollment of c, loading its CA.
+func NewEnrollment(c EnrollmentConfig, client EnrollmentMetaClient) (*Enrollment, error) {
+	caPEM, err := ioutil.ReadFile(c.CACertificate)
+	if err != nil {
+		return nil, err
+	}
+	pair, err := tls.LoadX509KeyPair(c.CACertificate, c.CAPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	caCert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, err
+	} else if !caCert.IsCA {
+		return nil, fmt.Errorf("enrollment ca-certificate is not a CA: %s", c.CACertificate)
+	}
+	caKey, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("enrollment ca-private-key cannot sign: %s", c.CAPrivateKey)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	return &Enrollment{
+		MetaClient:    client,
+		caCert:        caCert,
+		caPEM:         caPEM,
+		caKey:         caKey,
+		pool:          pool,
+		certLifetime:  time.Duration(c.CertificateLifetime),
+		tokenLifetime: time.Duration(c.TokenLifetime),
+		now:           time.Now,
+	}, nil
+}
+
+// ClientCAs returns the pool verifying the certificates of enrolled agents.
+func (e *Enrollment) ClientCAs() *x509.CertPool { return e.pool }
+
+// configureTLS makes config verify the certificates of enrolled agents, if
+// given, and refuse revoked ones during the handshake.
+func (e *Enrollment) configureTLS(config *tls.Config) {
+	config.ClientAuth = tls.VerifyClientCertIfGiven
+	config.ClientCAs = e.pool
+	config.VerifyPeerCertificate = e.verifyPeerCertificate
+}
+
+// verifyPeerCertificate refuses client certificates revoked or not issued by
+// the cluster. It runs after the chains were verified with the CA.
+func (e *Enrollment) verifyPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	if len(verifiedChains) == 0 {
+		return nil
+	}
+	if e.agent(verifiedChains[0][0]) == nil {
+		return ErrCertificateRevoked
+	}
+	return nil
+}
+
+// agent returns the enrolled agent cert was issued to, or nil if cert was not
+// signed by the enrollment CA, is unknown to the meta store or was revoked.
+func (e *Enrollment) agent(cert *x509.Certificate) *meta.AgentInfo {
+	if cert.CheckSignatureFrom(e.caCert) != nil {
+		return nil
+	}
+	ai := e.MetaClient.Agent(cert.SerialNumber.Text(16))
+	if ai == nil || ai.Revoked {
+		return nil
+	}
+	return ai
+}
+
+// CreateToken returns a bootstrap token that the agent name can redeem once
+// for a certificate allowing writes to databases. A zero lifetime uses the
+// default.
+func (e *Enrollment) CreateToken(name string, databases []string, lifetime time.Duration) (string, time.Time, error) {
+	if name == "" {
+		return "", time.Time{}, errors.New("agent name required")
+	} else if len(databases) == 0 {
+		return "", time.Time{}, errors.New("at least one database is required")
+	}
+	for _, db := range databases {
+		if db == "" {
+			return "", time.Time{}, errors.New("database name required")
+		}
+	}
+	if lifetime < 0 {
+		return "", time.Time{}, errors.New("lifetime cannot be negative")
+	} else if lifetime == 0 {
+		lifetime = e.tokenLifetime
+	}
+
+	token, ti, err := e.MetaClient.CreateEnrollmentToken(name, databases, lifetime)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, ti.ExpiresAt, nil
+}
+
+// Redeem consumes token and returns the PEM certificate signed for the public
+// key of the PEM certificate request csr. The certificate is issued to the
+// agent named by the token; the subject of csr is ignored.
+func (e *Enrollment) Redeem(token string, csr []byte) ([]byte, error) {
+	block, _ := pem.Decode(csr)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, errors.New("csr must be a PEM certificate request")
+	}
+	req, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	} else if err := req.CheckSignature(); err != nil {
+		return nil, err
+	}
+
+	t := e.MetaClient.EnrollmentToken(token)
+	if t == nil {
+		return nil, ErrEnrollmentTokenInvalid
+	}
+	now := e.now()
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	notAfter := now.Add(e.certLifetime)
+	if notAfter.After(e.caCert.NotAfter) {
+		notAfter = e.caCert.NotAfter
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: t.Name},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, db := range t.Databases {
+		tmpl.URIs = append(tmpl.URIs, &url.URL{Scheme: enrollmentURIScheme, Opaque: "database:" + url.PathEscape(db)})
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, e.caCert, req.PublicKey, e.caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// The token is consumed by the meta store, so it is redeemed once even if
+	// redeemed with several nodes at the same time.
+	if err := e.MetaClient.RedeemEnrollmentToken(token, serial.Text(16), notAfter); err != nil {
+		if err.Error() == meta.ErrEnrollmentTokenNotFound.Error() {
+			return nil, ErrEnrollmentTokenInvalid
+		}
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+// certificateUser returns the user of the verified client certificate of r,
+// or nil if r has none or it was revoked. The user may write to the databases
+// the agent was enrolled for and nothing else.
+func (h *Handler) certificateUser(r *http.Request) meta.User {
+	if h.Enrollment == nil || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return nil
+	}
+
+	ai := h.Enrollment.agent(r.TLS.VerifiedChains[0][0])
+	if ai == nil {
+		return nil
+	}
+	ui := &meta.UserInfo{
+		Name:       ai.Name,
+		Privileges: make(map[string]influxql.Privilege),
+	}
+	for _, db := range ai.Databases {
+		ui.Privileges[db] = influxql.WritePrivilege
+	}
+	return &externalUser{UserInfo: ui}
+}
+
+// serveCreateEnrollmentToken issues a bootstrap token for the databases in
+// the request body.
+func (h *Handler) serveCreateEnrollmentToken(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+	if h.Enrollment == nil {
+		h.httpError(w, errEnrollmentDisabled.Error(), http.StatusNotImplemented)
+		return
+	}
+
+	var req struct {
+		Name      string        `json:"name"`
+		Databases []string      `json:"databases"`
+		Lifetime  toml.Duration `json:"lifetime"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.httpError(w, "error parsing token request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, expires, err := h.Enrollment.CreateToken(req.Name, req.Databases, time.Duration(req.Lifetime))
+	h.auditRequest(r, user, audit.CategoryAdmin, strings.Join(req.Databases, ","), err)
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		Token   string    `json:"token"`
+		Expires time.Time `json:"expires"`
+	}{token, expires})
+}
+
+// serveEnroll redeems the bootstrap token of the request body for a client
+// certificate. The token authenticates the request.
+func (h *Handler) serveEnroll(w http.ResponseWriter, r *http.Request) {
+	if h.Enrollment == nil {
+		h.httpError(w, errEnrollmentDisabled.Error(), http.StatusNotImplemented)
+		return
+	}
+
+	var req struct {
+		Token string `json:"token"`
+		CSR   string `json:"csr"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.httpError(w, "error parsing enrollment request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cert, err := h.Enrollment.Redeem(req.Token, []byte(req.CSR))
+	if err == ErrEnrollmentTokenInvalid {
+		h.authenticationFailed(w, r, err.Error())
+		return
+	} else if err != nil {
+		h.httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Certificate   string `json:"certificate"`
+		CACertificate string `json:"ca-certificate"`
+	}{string(cert), string(h.Enrollment.caPEM)})
+}
+
+// serveAgents lists the certificates issued to enrolled agents.
+func (h *Handler) serveAgents(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+	if h.Enrollment == nil {
+		h.httpError(w, errEnrollmentDisabled.Error(), http.StatusNotImplemented)
+		return
+	}
+
+	type agent struct {
+		Name      string    `json:"name"`
+		Serial    string    `json:"serial"`
+		Databases []string  `json:"databases"`
+		Created   time.Time `json:"created"`
+		Expires   time.Time `json:"expires"`
+		Revoked   bool      `json:"revoked"`
+	}
+	resp := struct {
+		Agents []agent `json:"agents"`
+	}{Agents: []agent{}}
+	for _, ai := range h.Enrollment.MetaClient.Agents() {
+		resp.Agents = append(resp.Agents, agent{
+			Name:      ai.Name,
+			Serial:    ai.Serial,
+			Databases: ai.Databases,
+			Created:   ai.CreatedAt,
+			Expires:   ai.ExpiresAt,
+			Revoked:   ai.Revoked,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// serveRevokeAgent revokes the certificates of an enrolled agent on every
+// node, and the tokens that would enroll it again.
+func (h *Handler) serveRevokeAgent(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+	if h.Enrollment == nil {
+		h.httpError(w, errEnrollmentDisabled.Error(), http.StatusNotImplemented)
+		return
+	}
+
+	name := r.URL.Query().Get(":name")
+	err := h.Enrollment.MetaClient.RevokeAgent(name)
+	h.auditRequest(r, user, audit.CategoryAdmin, "", err)
+	if err != nil && err.Error() == meta.ErrAgentNotFound.Error() {
+		h.httpError(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.writeHeader(w, http.StatusNoContent)
+}
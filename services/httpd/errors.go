@@ -0,0 +1,179 @@
+package httpd
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/freetsdb/freetsdb"
+	"github.com/freetsdb/freetsdb/coordinator"
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/tsdb"
+)
+
+// ErrorCode is a stable, machine-readable code for an error returned by the
+// HTTP API. It is returned in the X-FreeTSDB-Error-Code header and in the
+// "code" field of JSON error bodies, so clients can branch on errors without
+// matching their messages.
+type ErrorCode string
+
+const (
+	// Codes of specific errors.
+	ErrorCodePartialWrite            ErrorCode = "partial-write"
+	ErrorCodeFieldTypeConflict       ErrorCode = "field-type-conflict"
+	ErrorCodeMaxSeriesExceeded       ErrorCode = "max-series-exceeded"
+	ErrorCodeMaxValuesPerTagExceeded ErrorCode = "max-values-per-tag-exceeded"
+	ErrorCodeMaxKeyLengthExceeded    ErrorCode = "max-key-length-exceeded"
+	ErrorCodeDatabaseNotFound        ErrorCode = "database-not-found"
+	ErrorCodeRetentionPolicyNotFound ErrorCode = "retention-policy-not-found"
+	ErrorCodeShardNotFound           ErrorCode = "shard-not-found"
+	ErrorCodeTimeout                 ErrorCode = "timeout"
+	ErrorCodeReadOnly                ErrorCode = "read-only"
+	ErrorCodeDatabaseFrozen          ErrorCode = "database-frozen"
+
+	// Codes of the errors that only have a status.
+	ErrorCodeBadRequest      ErrorCode = "bad-request"
+	ErrorCodeUnauthorized    ErrorCode = "unauthorized"
+	ErrorCodeForbidden       ErrorCode = "forbidden"
+	ErrorCodeNotFound        ErrorCode = "not-found"
+	ErrorCodeConflict        ErrorCode = "conflict"
+	ErrorCodeRequestTooLarge ErrorCode = "request-too-large"
+	ErrorCodeTooManyRequests ErrorCode = "too-many-requests"
+	ErrorCodeUnavailable     ErrorCode = "unavailable"
+	ErrorCodeInternal        ErrorCode = "internal"
+)
+
+// errorCodes lists the codes returned by the handler.
+var errorCodes = []ErrorCode{
+	ErrorCodePartialWrite,
+	ErrorCodeFieldTypeConflict,
+	ErrorCodeMaxSeriesExceeded,
+	ErrorCodeMaxValuesPerTagExceeded,
+	ErrorCodeMaxKeyLengthExceeded,
+	ErrorCodeDatabaseNotFound,
+	ErrorCodeRetentionPolicyNotFound,
+	ErrorCodeShardNotFound,
+	ErrorCodeTimeout,
+	ErrorCodeReadOnly,
+	ErrorCodeDatabaseFrozen,
+	ErrorCodeBadRequest,
+	ErrorCodeUnauthorized,
+	ErrorCodeForbidden,
+	ErrorCodeNotFound,
+	ErrorCodeConflict,
+	ErrorCodeRequestTooLarge,
+	ErrorCodeTooManyRequests,
+	ErrorCodeUnavailable,
+	ErrorCodeInternal,
+}
+
+// errorCodeNames returns the names of errorCodes.
+func errorCodeNames() []string {
+	a := make([]string, len(errorCodes))
+	for i, c := range errorCodes {
+		a[i] = string(c)
+	}
+	return a
+}
+
+// errorCodeErrors maps sentinel errors to their codes. A partial write wraps
+// the error its points were dropped with, so that a write dropping points
+// because of a field type conflict has the code of the conflict.
+var errorCodeErrors = []struct {
+	err  error
+	code ErrorCode
+}{
+	{tsdb.ErrFieldTypeConflict, ErrorCodeFieldTypeConflict},
+	{tsdb.ErrMaxSeriesPerDatabaseExceeded, ErrorCodeMaxSeriesExceeded},
+	{tsdb.ErrMaxValuesPerTagExceeded, ErrorCodeMaxValuesPerTagExceeded},
+	{models.ErrMaxKeyLengthExceeded, ErrorCodeMaxKeyLengthExceeded},
+	{errDatabaseNotFound, ErrorCodeDatabaseNotFound},
+	{meta.ErrDatabaseNotExists, ErrorCodeDatabaseNotFound},
+	{meta.ErrRetentionPolicyNotFound, ErrorCodeRetentionPolicyNotFound},
+	{tsdb.ErrShardNotFound, ErrorCodeShardNotFound},
+	{meta.ErrShardNotFound, ErrorCodeShardNotFound},
+	{coordinator.ErrTimeout, ErrorCodeTimeout},
+	{coordinator.ErrDatabaseFrozen, ErrorCodeDatabaseFrozen},
+}
+
+// errDatabaseNotFound is wrapped by the errors of the requests to databases
+// that do not exist.
+var errDatabaseNotFound = errors.New("database not found")
+
+// codeError is an error raised by the handler with its code.
+type codeError struct {
+	msg  string
+	code ErrorCode
+}
+
+func (e codeError) Error() string { return e.msg }
+
+// pointErrors is the error of the points of a write that could not be
+// parsed. It wraps the error of the first of them.
+type pointErrors []models.PointError
+
+func (a pointErrors) Error() string {
+	msgs := make([]string, len(a))
+	for i, e := range a {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (a pointErrors) Unwrap() error { return a[0] }
+
+// errorCodeOf returns the code of err returned with the HTTP status code.
+func errorCodeOf(err error, status int) ErrorCode {
+	for _, e := range errorCodeErrors {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+
+	var (
+		cerr  codeError
+		klerr tsdb.KeyLengthError
+		dberr freetsdb.DatabaseNotFoundError
+		rperr freetsdb.RetentionPolicyNotFoundError
+		roerr coordinator.ReadOnlyError
+		pwerr tsdb.PartialWriteError
+	)
+	switch {
+	case errors.As(err, &cerr):
+		return cerr.code
+	case errors.As(err, &klerr):
+		return ErrorCodeMaxKeyLengthExceeded
+	case errors.As(err, &dberr):
+		return ErrorCodeDatabaseNotFound
+	case errors.As(err, &rperr):
+		return ErrorCodeRetentionPolicyNotFound
+	case errors.As(err, &roerr):
+		return ErrorCodeReadOnly
+	case errors.As(err, &pwerr):
+		return ErrorCodePartialWrite
+	}
+
+	switch status {
+	case http.StatusUnauthorized:
+		return ErrorCodeUnauthorized
+	case http.StatusForbidden:
+		return ErrorCodeForbidden
+	case http.StatusNotFound:
+		return ErrorCodeNotFound
+	case http.StatusConflict:
+		return ErrorCodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return ErrorCodeRequestTooLarge
+	case http.StatusTooManyRequests:
+		return ErrorCodeTooManyRequests
+	case http.StatusServiceUnavailable:
+		return ErrorCodeUnavailable
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return ErrorCodeTimeout
+	}
+	if status/100 == 4 {
+		return ErrorCodeBadRequest
+	}
+	return ErrorCodeInternal
+}
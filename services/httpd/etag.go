@@ -0,0 +1,108 @@
+package httpd
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// metadataQueryDatabases returns the databases read by the query if it only
+// holds statements reading the index, such as SHOW MEASUREMENTS or SHOW TAG
+// KEYS, whose results do not change until the index epochs of the databases
+// do. Statements with conditions relative to now() are excluded.
+func metadataQueryDatabases(q *influxql.Query, db string) ([]string, bool) {
+	var databases []string
+	for _, stmt := range q.Statements {
+		var database string
+		var cond influxql.Expr
+		switch stmt := stmt.(type) {
+		case *influxql.ShowMeasurementsStatement:
+			database, cond = stmt.Database, stmt.Condition
+		case *influxql.ShowTagKeysStatement:
+			database, cond = stmt.Database, stmt.Condition
+		case *influxql.ShowTagValuesStatement:
+			database, cond = stmt.Database, stmt.Condition
+		case *influxql.ShowFieldKeysStatement:
+			database = stmt.Database
+		case *influxql.ShowSeriesStatement:
+			database, cond = stmt.Database, stmt.Condition
+		default:
+			return nil, false
+		}
+
+		if database == "" {
+			database = db
+		}
+		if database == "" || (cond != nil && hasNow(cond)) {
+			return nil, false
+		}
+		databases = append(databases, database)
+	}
+	return databases, len(databases) > 0
+}
+
+// hasNow returns true if the expression calls now().
+func hasNow(expr influxql.Expr) bool {
+	var found bool
+	influxql.WalkFunc(expr, func(n influxql.Node) {
+		if call, ok := n.(*influxql.Call); ok && strings.ToLower(call.Name) == "now" {
+			found = true
+		}
+	})
+	return found
+}
+
+// metadataQueryETag returns the ETag of the results of a metadata query, and
+// the last time the index of its databases changed. The ETag changes with the
+// query, the user, the parameters changing how the results are encoded and
+// the index epochs of the databases across the cluster, which include the
+// privileges of the users.
+func (h *Handler) metadataQueryETag(r *http.Request, q *influxql.Query, databases []string, opts query.ExecutionOptions, user meta.User) (string, time.Time, error) {
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%s\n%s\n%s\n%s\n%d\n%s\n%s\n%v\n%d\n%s\n",
+		q.String(), opts.Database, opts.RetentionPolicy,
+		r.FormValue("chunked"), opts.ChunkSize, r.FormValue("epoch"), r.FormValue("pretty"),
+		opts.SystemColumns, opts.MaxPointsPerSeries, r.Header.Get("Accept"))
+	if user != nil {
+		fmt.Fprintf(hash, "%s\n", user.ID())
+	}
+
+	var modified time.Time
+	for _, database := range databases {
+		epoch, t, err := h.Index.IndexEpoch(database)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		fmt.Fprintf(hash, "%s\n%d\n", database, epoch)
+		if t.After(modified) {
+			modified = t
+		}
+	}
+	return fmt.Sprintf(`W/"%016x"`, hash.Sum64()), modified, nil
+}
+
+// notModified returns true if the results cached by the client of a
+// conditional request are still valid. If-None-Match takes precedence over
+// If-Modified-Since.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !modified.Truncate(time.Second).After(ims)
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package httpd
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// serveFeatures returns the version and the features of the cluster protocol
+// of each data node, and the features supported by all of them.
+func (h *Handler) serveFeatures(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+	if h.Features == nil {
+		h.httpError(w, "features are not negotiated on this node", http.StatusNotImplemented)
+		return
+	}
+
+	features, err := h.Features.ClusterFeatures()
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	json.NewEncoder(w).Encode(features)
+}
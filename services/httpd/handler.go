@@ -12,11 +12,13 @@ import (
 	"io/ioutil"
 	"log"
 	"math"
+	"net"
 	"net/http"
 	"os"
 	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -26,18 +28,24 @@ import (
 	"github.com/golang/snappy"
 	"github.com/freetsdb/freetsdb"
 	"github.com/freetsdb/freetsdb/coordinator"
+	"github.com/freetsdb/freetsdb/services/audit"
 	"github.com/freetsdb/freetsdb/services/influxql"
 	"github.com/freetsdb/freetsdb/logger"
 	"github.com/freetsdb/freetsdb/models"
 	"github.com/freetsdb/freetsdb/monitor"
 	"github.com/freetsdb/freetsdb/monitor/diagnostics"
+	"github.com/freetsdb/freetsdb/pkg/relabel"
+	"github.com/freetsdb/freetsdb/pkg/routing"
 	"github.com/freetsdb/freetsdb/platform/storage/reads"
 	"github.com/freetsdb/freetsdb/platform/storage/reads/datatypes"
 	"github.com/freetsdb/freetsdb/prometheus"
 	"github.com/freetsdb/freetsdb/prometheus/remote"
 	"github.com/freetsdb/freetsdb/query"
 	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/services/resources"
+	"github.com/freetsdb/freetsdb/services/standby"
 	"github.com/freetsdb/freetsdb/services/storage"
+	"github.com/freetsdb/freetsdb/services/subscriber"
 	"github.com/freetsdb/freetsdb/tsdb"
 	"github.com/freetsdb/freetsdb/uuid"
 	"github.com/freetsdb/freetsdb/services/flux"
@@ -69,6 +77,9 @@ const (
 
 	// Authenticate with jwt.
 	BearerAuthentication
+
+	// Authenticate with a session token issued by /login.
+	SessionAuthentication
 )
 
 // TODO: Check HTTP response codes: 400, 401, 403, 409.
@@ -88,12 +99,29 @@ type Handler struct {
 	mux     *pat.PatternServeMux
 	Version string
 
+	// MonitorDatabase is the database the monitor writes statistics to,
+	// which usage is reported from.
+	MonitorDatabase string
+
 	MetaClient interface {
 		Database(name string) *meta.DatabaseInfo
 		Databases() ([]meta.DatabaseInfo, error)
 		Authenticate(username, password string) (ui meta.User, err error)
+		AuthenticateSession(token string) (meta.User, error)
+		CreateSession(username string, lifetime time.Duration) (string, *meta.SessionInfo, error)
+		DropSession(id string) error
+		ChangePassword(name, password, newPassword string) error
 		User(username string) (meta.User, error)
 		AdminUserExists() bool
+		DatabaseTemplates() []meta.DatabaseTemplateInfo
+		CreateDatabaseTemplate(tmpl *meta.DatabaseTemplateInfo) error
+		DropDatabaseTemplate(name string) error
+		InstantiateDatabaseTemplate(template, database string) (*meta.DatabaseInfo, error)
+		TruncateDatabaseShardGroups(database, policy string, t time.Time) error
+		DataNodes() ([]meta.NodeInfo, error)
+		SetDataNodeMode(id uint64, readOnly bool, reason string, maintenance bool) error
+		SetDatabaseFrozen(name string, frozen bool) error
+		SetDatabasePlacement(name string, p meta.PlacementInfo) error
 	}
 
 	QueryAuthorizer interface {
@@ -113,10 +141,71 @@ type Handler struct {
 
 	PointsWriter interface {
 		WritePoints(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, user meta.User, points []models.Point) error
+		WritePointsIdempotent(ctx context.Context, key, database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, user meta.User, points []models.Point) error
 	}
 
 	Store Store
 
+	TSDBStore interface {
+		TagValuesByPrefix(auth query.Authorizer, database string, name, key, prefix []byte, limit int) ([]string, error)
+	}
+
+	// Index returns the index epochs of the databases across the cluster,
+	// which validate the cached results of metadata queries. Nil disables
+	// conditional queries.
+	Index interface {
+		IndexEpoch(database string) (uint64, time.Time, error)
+	}
+
+	// Shards reads the shards stored on this node.
+	Shards interface {
+		ShardDiskSize(id uint64) (int64, error)
+		ShardFiles(id uint64) ([]tsdb.ShardFile, error)
+	}
+
+	// AuditLog records administrative operations and failed authentication.
+	AuditLog interface {
+		Record(e audit.Event)
+	}
+
+	// ConfigReloader reloads the server configuration from disk.
+	ConfigReloader interface {
+		ReloadConfig() error
+	}
+
+	// Resources reads and changes the runtime resource settings.
+	Resources interface {
+		State() resources.State
+		Apply(c resources.Config) error
+	}
+
+	// Standby restores the backups of a primary until promoted. Nil if
+	// the node is not a standby.
+	Standby interface {
+		Status() standby.Status
+		Promote() error
+	}
+
+	// Subscriptions reads the delivery state of the subscriptions on this
+	// node and pauses them. Nil if the subscriber service is disabled.
+	Subscriptions interface {
+		Subscriptions() []subscriber.SubscriptionStatus
+		SetSubscriptionPaused(database, rp, name string, paused bool) error
+	}
+
+	// Features reports the features of the cluster protocol supported by
+	// the data nodes.
+	Features interface {
+		ClusterFeatures() (*coordinator.ClusterFeatures, error)
+	}
+
+	// External authentication backends, tried after the meta store.
+	PasswordBackends []PasswordBackend
+	TokenBackends    []TokenBackend
+
+	// Enrollment issues client certificates to agents. Nil if disabled.
+	Enrollment *Enrollment
+
 	// Flux services
 	Controller       Controller
 	CompilerMappings flux.CompilerMappings
@@ -128,9 +217,20 @@ type Handler struct {
 	accessLog        *os.File
 	accessLogFilters StatusFilters
 	stats            *Statistics
+	usage            *usageTracker
 
+	routes         []Route
 	requestTracker *RequestTracker
+	queryCursors   *queryCursorStore
+	queryStreams   *queryStreams
+	deadLetters    *deadLetterWriter
+	trustedProxies []*net.IPNet
+	throttleMu     sync.RWMutex
 	writeThrottler *Throttler
+
+	loginLimiter *loginLimiter
+	relabeler      *relabel.Relabeler
+	router         *routing.Router
 }
 
 // NewHandler returns a new instance of handler with routes.
@@ -141,13 +241,30 @@ func NewHandler(c Config) *Handler {
 		Logger:         zap.NewNop(),
 		CLFLogger:      log.New(os.Stderr, "[httpd] ", 0),
 		stats:          &Statistics{},
+		usage:          newUsageTracker(),
 		requestTracker: NewRequestTracker(),
+		queryCursors:   newQueryCursorStore(),
 	}
+	h.queryStreams = newQueryStreams(h.stats)
+	h.deadLetters = newDeadLetterWriter()
+	h.MonitorDatabase = monitor.DefaultStoreDatabase
+	h.PasswordBackends, h.TokenBackends = newAuthBackends(c)
 
 	// Limit the number of concurrent & enqueued write requests.
 	h.writeThrottler = NewThrottler(c.MaxConcurrentWriteLimit, c.MaxEnqueuedWriteLimit)
 	h.writeThrottler.EnqueueTimeout = c.EnqueuedWriteTimeout
 
+	h.loginLimiter = newLoginLimiter(c.LoginMaxFailures, time.Duration(c.LoginLockout))
+
+	// The trusted proxies are checked by Config.Validate.
+	h.trustedProxies, _ = parseTrustedProxies(c.TrustedProxies)
+
+	// The relabeling and routing rules are checked by Config.Validate.
+	h.relabeler = &relabel.Relabeler{}
+	h.relabeler.SetRules(c.Relabel)
+	h.router = &routing.Router{}
+	h.router.SetRules(c.Routes)
+
 	// Disable the write log if they have been suppressed.
 	writeLogEnabled := c.LogEnabled
 	if c.SuppressWriteLog {
@@ -167,6 +284,10 @@ func NewHandler(c Config) *Handler {
 			"query", // Query serving route.
 			"POST", "/query", true, true, h.serveQuery,
 		},
+		Route{
+			"query-stream", // Streaming query route.
+			"GET", "/query/stream", false, true, h.serveQueryStream,
+		},
 		Route{
 			"write-options", // Satisfy CORS checks.
 			"OPTIONS", "/write", false, true, h.serveOptions,
@@ -203,8 +324,143 @@ func NewHandler(c Config) *Handler {
 			"prometheus-metrics",
 			"GET", "/metrics", false, true, promhttp.Handler().ServeHTTP,
 		},
+		Route{ // Database templates
+			"templates",
+			"GET", "/api/v1/templates", true, true, h.serveListDatabaseTemplates,
+		},
+		Route{
+			"templates-create",
+			"POST", "/api/v1/templates", false, true, h.serveCreateDatabaseTemplate,
+		},
+		Route{
+			"templates-drop",
+			"DELETE", "/api/v1/templates/:name", false, true, h.serveDropDatabaseTemplate,
+		},
+		Route{ // Create a database from a template
+			"templates-instantiate",
+			"POST", "/api/v1/templates/:name/instantiate", false, true, h.serveInstantiateDatabaseTemplate,
+		},
+		Route{ // Reload the server configuration
+			"config-reload",
+			"POST", "/api/v1/config/reload", false, true, h.serveReloadConfig,
+		},
+		Route{ // Runtime resource settings
+			"resources",
+			"GET", "/api/v1/resources", false, true, h.serveResources,
+		},
+		Route{
+			"resources-update",
+			"PUT", "/api/v1/resources", false, true, h.serveUpdateResources,
+		},
+		Route{ // Start a session
+			"login",
+			"POST", "/login", false, true, h.serveLogin,
+		},
+		Route{ // End the session of the request
+			"logout",
+			"POST", "/logout", false, true, h.serveLogout,
+		},
+		Route{ // Change the password of the authenticating user
+			"password",
+			"POST", "/api/v1/password", false, true, h.servePassword,
+		},
+		Route{ // Bootstrap tokens for agent enrollment
+			"enrollment-tokens",
+			"POST", "/api/v1/enrollment/tokens", false, true, h.serveCreateEnrollmentToken,
+		},
+		Route{ // Redeem a bootstrap token for a client certificate
+			"enroll",
+			"POST", "/api/v1/enroll", false, true, h.serveEnroll,
+		},
+		Route{ // Certificates issued to enrolled agents
+			"enrollment-agents",
+			"GET", "/api/v1/enrollment/agents", false, true, h.serveAgents,
+		},
+		Route{ // Revoke the certificates of an enrolled agent
+			"enrollment-agent-revoke",
+			"DELETE", "/api/v1/enrollment/agents/:name", false, true, h.serveRevokeAgent,
+		},
+		Route{ // Shards of the cluster
+			"shards",
+			"GET", "/api/v1/shards", true, true, h.serveShards,
+		},
+		Route{ // Data files of a shard stored on this node
+			"shard-files",
+			"GET", "/api/v1/shards/:id", true, true, h.serveShardFiles,
+		},
+		Route{ // End the current shard groups now
+			"shards-truncate",
+			"POST", "/api/v1/shards/truncate", false, true, h.serveTruncateShards,
+		},
+		Route{ // Operational modes of the data nodes and databases
+			"modes",
+			"GET", "/api/v1/modes", true, true, h.serveModes,
+		},
+		Route{ // Set the operational mode of a data node
+			"node-mode",
+			"PUT", "/api/v1/nodes/:id/mode", false, true, h.serveSetNodeMode,
+		},
+		Route{ // Set the operational mode of a database
+			"database-mode",
+			"PUT", "/api/v1/databases/:name/mode", false, true, h.serveSetDatabaseMode,
+		},
+		Route{ // Labels of the data nodes and placements of the databases
+			"placement",
+			"GET", "/api/v1/placement", true, true, h.servePlacement,
+		},
+		Route{ // Set the placement of the shards of a database
+			"database-placement",
+			"PUT", "/api/v1/databases/:name/placement", false, true, h.serveSetDatabasePlacement,
+		},
+		Route{ // State of the standby
+			"standby",
+			"GET", "/api/v1/standby", false, true, h.serveStandby,
+		},
+		Route{ // Promote the standby to serve writes
+			"standby-promote",
+			"POST", "/api/v1/standby/promote", false, true, h.servePromoteStandby,
+		},
+		Route{ // Delivery state of the subscriptions on this node
+			"subscriptions",
+			"GET", "/api/v1/subscriptions", true, true, h.serveSubscriptions,
+		},
+		Route{ // Pause or resume a subscription on this node
+			"subscription-state",
+			"PUT", "/api/v1/subscriptions/:database/:rp/:name/state", false, true, h.serveSetSubscriptionState,
+		},
+		Route{ // Features of the cluster protocol supported by the data nodes
+			"features",
+			"GET", "/api/v1/features", false, true, h.serveFeatures,
+		},
+		Route{ // Tag values for autocompletion
+			"tag-values",
+			"GET", "/api/v1/tag-values", true, true, h.serveTagValues,
+		},
+		Route{ // Points rejected by writes
+			"dead-letter",
+			"GET", "/api/v1/dead-letter", true, true, h.serveDeadLetters,
+		},
+		Route{ // Usage of users and databases
+			"usage",
+			"GET", "/api/v1/usage", true, true, h.serveUsage,
+		},
+		Route{ // Translate InfluxQL to Flux
+			"transpile",
+			"POST", "/api/v2/transpile", true, true, h.serveTranspile,
+		},
+		Route{ // OpenAPI document of the routes
+			"openapi",
+			"GET", "/api/openapi.json", true, true, h.serveOpenAPI,
+		},
 	}...)
 
+	if c.UIEnabled {
+		h.AddRoutes(Route{ // Web UI
+			"ui",
+			"GET", "/ui", true, true, h.serveUI,
+		})
+	}
+
 	fluxRoute := Route{
 		"flux-read",
 		"POST", "/api/v2/query", true, true, nil,
@@ -219,10 +475,24 @@ func NewHandler(c Config) *Handler {
 	}
 	h.AddRoutes(fluxRoute)
 
+	lspRoute := Route{
+		"flux-lsp",
+		"GET", "/api/v2/flux/lsp", false, true, nil,
+	}
+	if !c.FluxEnabled {
+		lspRoute.HandlerFunc = fluxRoute.HandlerFunc
+	} else {
+		lspRoute.HandlerFunc = h.serveFluxLSP
+	}
+	h.AddRoutes(lspRoute)
+
 	return h
 }
 
 func (h *Handler) Open() {
+	h.queryStreams.open()
+	h.deadLetters.open(h.writeDeadLetters)
+
 	if h.Config.LogEnabled {
 		path := "stderr"
 
@@ -247,6 +517,9 @@ func (h *Handler) Open() {
 }
 
 func (h *Handler) Close() {
+	h.queryStreams.close()
+	h.deadLetters.close()
+
 	if h.accessLog != nil {
 		h.accessLog.Close()
 		h.accessLog = nil
@@ -287,11 +560,19 @@ type Statistics struct {
 	PromReadRequests             int64
 	FluxQueryRequests            int64
 	FluxQueryRequestDuration     int64
+	ActiveQueryStreams           int64
+	QueryStreamPointsDropped     int64
+	PointsTimeRejected           int64
+	PointsTimeCorrected          int64
+	PointsTimeTagged             int64
+	PointsDeadLettered           int64
+	PointsDeadLetterDropped      int64
+	PointsMerged                 int64
 }
 
 // Statistics returns statistics for periodic monitoring.
 func (h *Handler) Statistics(tags map[string]string) []models.Statistic {
-	return []models.Statistic{{
+	statistics := []models.Statistic{{
 		Name: "httpd",
 		Tags: tags,
 		Values: map[string]interface{}{
@@ -318,12 +599,22 @@ func (h *Handler) Statistics(tags map[string]string) []models.Statistic {
 			statPromReadRequest:              atomic.LoadInt64(&h.stats.PromReadRequests),
 			statFluxQueryRequests:            atomic.LoadInt64(&h.stats.FluxQueryRequests),
 			statFluxQueryRequestDuration:     atomic.LoadInt64(&h.stats.FluxQueryRequestDuration),
+			statQueryStreamsActive:           atomic.LoadInt64(&h.stats.ActiveQueryStreams),
+			statQueryStreamPointsDropped:     atomic.LoadInt64(&h.stats.QueryStreamPointsDropped),
+			statPointsTimeRejected:           atomic.LoadInt64(&h.stats.PointsTimeRejected),
+			statPointsTimeCorrected:          atomic.LoadInt64(&h.stats.PointsTimeCorrected),
+			statPointsTimeTagged:             atomic.LoadInt64(&h.stats.PointsTimeTagged),
+			statPointsDeadLettered:           atomic.LoadInt64(&h.stats.PointsDeadLettered),
+			statPointsDeadLetterDropped:      atomic.LoadInt64(&h.stats.PointsDeadLetterDropped),
+			statPointsMerged:                 atomic.LoadInt64(&h.stats.PointsMerged),
 		},
 	}}
+	return append(statistics, h.usage.statistics(tags)...)
 }
 
 // AddRoutes sets the provided routes on the handler.
 func (h *Handler) AddRoutes(routes ...Route) {
+	h.routes = append(h.routes, routes...)
 	for _, r := range routes {
 		var handler http.Handler
 
@@ -341,16 +632,23 @@ func (h *Handler) AddRoutes(routes ...Route) {
 		if r.Method == http.MethodPost {
 			switch r.Pattern {
 			case "/write", "/api/v1/prom/write":
-				handler = h.writeThrottler.Handler(handler)
+				handler = h.throttleWrites(handler)
 			default:
 			}
 		}
 
+		handler = h.requireCapability(handler, routeCapability(r.Name))
 		handler = h.responseWriter(handler)
+
+		// Limit the failed logins of the routes accepting a password.
+		switch r.Pattern {
+		case "/login", "/api/v1/password":
+			handler = h.limitLogins(handler)
+		}
 		if r.Gzipped {
 			handler = gzipFilter(handler)
 		}
-		handler = cors(handler)
+		handler = h.cors(handler)
 		handler = requestID(handler)
 		if h.Config.LogEnabled && r.LoggingEnabled {
 			handler = h.logging(handler, r.Name)
@@ -371,7 +669,19 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Add version and build header to all FreeTSDB requests.
 	w.Header().Add("X-Freetsdb-Version", h.Version)
 
-	if strings.HasPrefix(r.URL.Path, "/debug/pprof") && h.Config.PprofEnabled {
+	// Refuse request bodies larger than the limit before reading them.
+	limit := int64(h.Config.MaxRequestBodySize)
+	if limit > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+
+	if limit > 0 && r.ContentLength > limit {
+		h.httpError(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+	} else if r.Method == "OPTIONS" && r.Header.Get("Access-Control-Request-Method") != "" {
+		h.servePreflight(w, r)
+	} else if strings.HasPrefix(r.URL.Path, "/debug/") && !allowed(r, CapabilityAdmin) {
+		h.httpError(w, fmt.Sprintf("%s is not served on this listener", r.URL.Path), http.StatusForbidden)
+	} else if strings.HasPrefix(r.URL.Path, "/debug/pprof") && h.Config.PprofEnabled {
 		h.handleProfiles(w, r)
 	} else if strings.HasPrefix(r.URL.Path, "/debug/vars") {
 		h.serveExpvar(w, r)
@@ -410,6 +720,12 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user meta.U
 		rw = NewResponseWriter(w, r)
 	}
 
+	// Continue a paginated query.
+	if id := r.FormValue("cursor"); id != "" {
+		h.serveQueryCursor(rw, r, user, id)
+		return
+	}
+
 	// Retrieve the node id the query should be executed on.
 	nodeID, _ := strconv.ParseUint(r.FormValue("node_id"), 10, 64)
 
@@ -435,7 +751,11 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user meta.U
 		return
 	}
 
-	epoch := strings.TrimSpace(r.FormValue("epoch"))
+	epoch, err := parseEpoch(strings.TrimSpace(r.FormValue("epoch")))
+	if err != nil {
+		h.httpError(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	p := influxql.NewParser(qr)
 	db := r.FormValue("db")
@@ -507,12 +827,56 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user meta.U
 	// Parse whether this is an async command.
 	async := r.FormValue("async") == "true"
 
+	// Parse the page size of a paginated query.
+	var pageSize int
+	if s := r.FormValue("page_size"); s != "" {
+		if pageSize, err = parsePageSize(s); err != nil {
+			h.httpError(rw, err.Error(), http.StatusBadRequest)
+			return
+		} else if chunked || async {
+			h.httpError(rw, "page_size cannot be used with chunked or async queries", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Parse the maximum number of points to return for each series.
+	var maxPoints int
+	if s := r.FormValue("max_points"); s != "" {
+		if maxPoints, err = strconv.Atoi(s); err != nil || maxPoints <= 0 {
+			h.httpError(rw, fmt.Sprintf("max_points must be a positive integer: %q", s), http.StatusBadRequest)
+			return
+		}
+	}
+
+	priority, err := h.queryPriority(r, user)
+	if err != nil {
+		h.httpError(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Parse the engine executing the query.
+	engine := r.FormValue("engine")
+	switch engine {
+	case "", "influxql":
+	case "flux":
+		if !h.Config.FluxEnabled {
+			h.httpError(rw, "Flux query service disabled. Verify flux-enabled=true in the [http] section of the FreeTSDB config.", http.StatusForbidden)
+			return
+		}
+	default:
+		h.httpError(rw, fmt.Sprintf("unknown engine: %q", engine), http.StatusBadRequest)
+		return
+	}
+
 	opts := query.ExecutionOptions{
-		Database:        db,
-		RetentionPolicy: r.FormValue("rp"),
-		ChunkSize:       chunkSize,
-		ReadOnly:        r.Method == "GET",
-		NodeID:          nodeID,
+		Database:           db,
+		RetentionPolicy:    r.FormValue("rp"),
+		ChunkSize:          chunkSize,
+		ReadOnly:           r.Method == "GET",
+		NodeID:             nodeID,
+		Priority:           priority,
+		MaxPointsPerSeries: maxPoints,
+		SystemColumns:      r.FormValue("system_columns") == "true",
 	}
 
 	if h.Config.AuthEnabled {
@@ -527,40 +891,70 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user meta.U
 		opts.Authorizer = query.OpenAuthorizer
 	}
 
-	// Make sure if the client disconnects we signal the query to abort
-	var closing chan struct{}
-	if !async {
-		closing = make(chan struct{})
-		if notifier, ok := w.(http.CloseNotifier); ok {
-			// CloseNotify() is not guaranteed to send a notification when the query
-			// is closed. Use this channel to signal that the query is finished to
-			// prevent lingering goroutines that may be stuck.
-			done := make(chan struct{})
-			defer close(done)
-
-			notify := notifier.CloseNotify()
-			go func() {
-				// Wait for either the request to finish
-				// or for the client to disconnect
-				select {
-				case <-done:
-				case <-notify:
-					close(closing)
+	// Metadata queries are not executed again while the index of their
+	// databases is unchanged since the client cached their results. The
+	// results depend on the privileges of the user, so the ETag does too.
+	if h.Index != nil && r.Method == "GET" && engine != "flux" && pageSize == 0 && !async {
+		if databases, ok := metadataQueryDatabases(q, db); ok {
+			// Without the epoch of every node, the query is not cacheable.
+			etag, modified, err := h.metadataQueryETag(r, q, databases, opts, user)
+			if err != nil {
+				h.Logger.Info("Unable to validate cached metadata query", zap.Error(err))
+			} else {
+				rw.Header().Set("ETag", etag)
+				rw.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+				rw.Header().Set("Cache-Control", "no-cache")
+				if notModified(r, etag, modified) {
+					h.writeHeader(rw, http.StatusNotModified)
+					return
 				}
-			}()
-			opts.AbortCh = done
-		} else {
-			defer close(closing)
+			}
 		}
 	}
 
+	// Make sure if the client disconnects we signal the query to abort. The
+	// request context is done once the client goes away, which interrupts the
+	// iterators of the query. A paginated query outlives the request and is
+	// aborted by its cursor.
+	ctx := context.Background()
+	var closing chan struct{}
+	if pageSize > 0 {
+		closing = make(chan struct{})
+		opts.AbortCh = closing
+		opts.Paginated = true
+	} else if !async {
+		ctx = r.Context()
+		done := make(chan struct{})
+		defer close(done)
+		opts.AbortCh = done
+	}
+
 	// Execute query.
-	results := h.QueryExecutor.ExecuteQuery(q, opts, closing)
+	var results <-chan *query.Result
+	if engine == "flux" {
+		results = h.executeFluxQuery(ctx, q, opts, user)
+	} else {
+		results = h.QueryExecutor.ExecuteQueryContext(ctx, q, opts, closing)
+	}
+	results = h.usage.trackQuery(userID(user), db, results, opts.AbortCh)
+	auditor := h.newQueryAuditor(r, user, q, db)
+
+	if pageSize > 0 {
+		h.writeQueryPage(rw, &queryCursor{
+			user:     userID(user),
+			pageSize: pageSize,
+			epoch:    epoch,
+			results:  results,
+			closing:  closing,
+			auditor:  auditor,
+		})
+		return
+	}
 
 	// If we are running in async mode, open a goroutine to drain the results
 	// and return with a StatusNoContent.
 	if async {
-		go h.async(q, results)
+		go h.async(q, results, auditor)
 		h.writeHeader(w, http.StatusNoContent)
 		return
 	}
@@ -583,9 +977,10 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user meta.U
 		if r == nil {
 			continue
 		}
+		auditor.record(r)
 
 		// if requested, convert result timestamps to epoch
-		if epoch != "" {
+		if epoch != 0 {
 			convertToEpoch(r, epoch)
 		}
 
@@ -629,42 +1024,7 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user meta.U
 		}
 
 		// It's not chunked so buffer results in memory.
-		// Results for statements need to be combined together.
-		// We need to check if this new result is for the same statement as
-		// the last result, or for the next statement
-		l := len(resp.Results)
-		if l == 0 {
-			resp.Results = append(resp.Results, r)
-		} else if resp.Results[l-1].StatementID == r.StatementID {
-			if r.Err != nil {
-				resp.Results[l-1] = r
-				continue
-			}
-
-			cr := resp.Results[l-1]
-			rowsMerged := 0
-			if len(cr.Series) > 0 {
-				lastSeries := cr.Series[len(cr.Series)-1]
-
-				for _, row := range r.Series {
-					if !lastSeries.SameSeries(row) {
-						// Next row is for a different series than last.
-						break
-					}
-					// Values are for the same series, so append them.
-					lastSeries.Values = append(lastSeries.Values, row.Values...)
-					rowsMerged++
-				}
-			}
-
-			// Append remaining rows as new rows.
-			r.Series = r.Series[rowsMerged:]
-			cr.Series = append(cr.Series, r.Series...)
-			cr.Messages = append(cr.Messages, r.Messages...)
-			cr.Partial = r.Partial
-		} else {
-			resp.Results = append(resp.Results, r)
-		}
+		resp.Results = appendResult(resp.Results, r)
 
 		// Drop out of this loop and do not process further results when we hit the row limit.
 		if h.Config.MaxRowLimit > 0 && rows >= h.Config.MaxRowLimit {
@@ -688,9 +1048,114 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user meta.U
 	}
 }
 
+// serveQueryCursor serves the next page of a paginated query.
+func (h *Handler) serveQueryCursor(w ResponseWriter, r *http.Request, user meta.User, id string) {
+	sanitize(r)
+
+	c := h.queryCursors.take(id, userID(user))
+	if c == nil {
+		h.httpError(w, errQueryCursorNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	if s := r.FormValue("page_size"); s != "" {
+		n, err := parsePageSize(s)
+		if err != nil {
+			h.queryCursors.release(c, time.Duration(h.Config.QueryCursorTTL))
+			h.httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.pageSize = n
+	}
+	h.writeQueryPage(w, c)
+}
+
+// writeQueryPage writes the next page of results from c. If the query has
+// more results, c is stored and its ID is returned in the response so the
+// client can request the next page.
+func (h *Handler) writeQueryPage(w ResponseWriter, c *queryCursor) {
+	results, more := c.page()
+
+	resp := Response{Results: results}
+	if more {
+		ttl := time.Duration(h.Config.QueryCursorTTL)
+		if c.id == "" {
+			if err := h.queryCursors.add(c, ttl, h.Config.MaxQueryCursors); err != nil {
+				c.close()
+				h.httpError(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+		} else {
+			h.queryCursors.release(c, ttl)
+		}
+		resp.Cursor = c.id
+		w.Header().Set("X-FreeTSDB-Cursor", c.id)
+	}
+
+	h.writeHeader(w, http.StatusOK)
+	n, _ := w.WriteResponse(resp)
+	atomic.AddInt64(&h.stats.QueryRequestBytesTransmitted, int64(n))
+}
+
+// parsePageSize parses the page_size parameter.
+func parsePageSize(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("page_size must be a positive integer: %q", s)
+	}
+	return n, nil
+}
+
+// userID returns the ID of user, or an empty string if there is no user.
+func userID(user meta.User) string {
+	if user == nil {
+		return ""
+	}
+	return user.ID()
+}
+
+// appendResult appends r to results. Results for statements need to be
+// combined together, so if r is for the same statement as the last result it
+// is merged into it.
+func appendResult(results []*query.Result, r *query.Result) []*query.Result {
+	l := len(results)
+	if l == 0 || results[l-1].StatementID != r.StatementID {
+		return append(results, r)
+	}
+
+	if r.Err != nil {
+		results[l-1] = r
+		return results
+	}
+
+	cr := results[l-1]
+	rowsMerged := 0
+	if len(cr.Series) > 0 {
+		lastSeries := cr.Series[len(cr.Series)-1]
+
+		for _, row := range r.Series {
+			if !lastSeries.SameSeries(row) {
+				// Next row is for a different series than last.
+				break
+			}
+			// Values are for the same series, so append them.
+			lastSeries.Values = append(lastSeries.Values, row.Values...)
+			rowsMerged++
+		}
+	}
+
+	// Append remaining rows as new rows.
+	r.Series = r.Series[rowsMerged:]
+	cr.Series = append(cr.Series, r.Series...)
+	cr.Messages = append(cr.Messages, r.Messages...)
+	cr.Partial = r.Partial
+	return results
+}
+
 // async drains the results from an async query and logs a message if it fails.
-func (h *Handler) async(q *influxql.Query, results <-chan *query.Result) {
+func (h *Handler) async(q *influxql.Query, results <-chan *query.Result, auditor *queryAuditor) {
 	for r := range results {
+		auditor.record(r)
 		// Drain the results and do nothing with them.
 		// If it fails, log the failure so there is at least a record of it.
 		if r.Err != nil {
@@ -706,7 +1171,8 @@ func (h *Handler) async(q *influxql.Query, results <-chan *query.Result) {
 	}
 }
 
-// serveWrite receives incoming series data in line protocol format and writes it to the database.
+// serveWrite receives incoming series data in line protocol format, or as
+// protocol buffers, and writes it to the database.
 func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user meta.User) {
 	atomic.AddInt64(&h.stats.WriteRequests, 1)
 	atomic.AddInt64(&h.stats.ActiveWriteRequests, 1)
@@ -723,7 +1189,13 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user meta.U
 	}
 
 	if di := h.MetaClient.Database(database); di == nil {
-		h.httpError(w, fmt.Sprintf("database not found: %q", database), http.StatusNotFound)
+		h.writeError(w, Response{Err: fmt.Errorf("%w: %q", errDatabaseNotFound, database)}, http.StatusNotFound)
+		return
+	}
+
+	duplicates, err := h.duplicatePointsMode(r.URL.Query().Get("duplicates"))
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -733,12 +1205,21 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user meta.U
 			return
 		}
 
-		if err := h.WriteAuthorizer.AuthorizeWrite(user.ID(), database); err != nil {
+		if err := h.authorizeWrite(user, database); err != nil {
 			h.httpError(w, fmt.Sprintf("%q user is not authorized to write to database %q", user.ID(), database), http.StatusForbidden)
 			return
 		}
 	}
 
+	// Sampled writes are traced and the trace ID is returned to the client.
+	ctx := r.Context()
+	trace := h.sampleWriteTrace()
+	if trace != nil {
+		ctx = tsdb.NewContextWithWriteTrace(ctx, trace)
+		w.Header().Set("X-FreeTSDB-Trace-Id", trace.ID)
+		defer h.logWriteTrace(trace, database, time.Now())
+	}
+
 	body := r.Body
 	if h.Config.MaxBodySize > 0 {
 		body = truncateReader(body, int64(h.Config.MaxBodySize))
@@ -768,7 +1249,7 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user meta.U
 	}
 	buf := bytes.NewBuffer(bs)
 
-	_, err := buf.ReadFrom(body)
+	_, err = buf.ReadFrom(body)
 	if err != nil {
 		if err == errTruncated {
 			h.httpError(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
@@ -787,16 +1268,81 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user meta.U
 		h.Logger.Info("Write body received by handler", zap.ByteString("body", buf.Bytes()))
 	}
 
-	points, parseError := models.ParsePointsWithPrecision(buf.Bytes(), time.Now().UTC(), r.URL.Query().Get("precision"))
-	// Not points parsed correctly so return the error now
-	if parseError != nil && len(points) == 0 {
-		if parseError.Error() == "EOF" {
+	// Rejected points are written to the database once the write is
+	// answered.
+	dead := h.newDeadLetters(database, r.URL.Query().Get("rp"), r.Header.Get("Request-Id"))
+	defer h.queueDeadLetters(dead)
+
+	now := time.Now().UTC()
+	precision := r.URL.Query().Get("precision")
+
+	var points []models.Point
+	var indexes []int
+	var parseErrors []models.PointError
+	start := time.Now()
+	if isProtobufWrite(r) {
+		// Protobuf points are decoded as a whole, so an invalid point fails
+		// the write.
+		if points, err = parseProtobufPoints(buf.Bytes(), now, precision); err != nil {
+			h.httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if len(points) == 0 {
 			h.writeHeader(w, http.StatusOK)
 			return
 		}
-		h.httpError(w, parseError.Error(), http.StatusBadRequest)
+		indexes = make([]int, len(points))
+		for i := range indexes {
+			indexes[i] = i
+		}
+	} else {
+		points, indexes, parseErrors = models.ParsePointsWithIndexes(buf.Bytes(), now, precision)
+	}
+	trace.Record("parse", 0, start)
+
+	// The points of the batch that are not written are returned to the
+	// client, so that it can retry only those.
+	var partial *PartialWrite
+	n := len(points) + len(parseErrors)
+	var parseError error
+	if len(parseErrors) > 0 {
+		partial = newPartialWrite(n)
+		for _, e := range parseErrors {
+			partial.Add(e.Index, e.Err.Error())
+			dead.add(e.Line, e.Err.Error(), errorCodeOf(e.Err, http.StatusBadRequest))
+		}
+		parseError = pointErrors(parseErrors)
+	}
+	// Not points parsed correctly so return the error now
+	if parseError != nil && len(points) == 0 {
+		h.writeError(w, Response{Err: parseError, Partial: partial}, http.StatusBadRequest)
+		return
+	}
+
+	// Check for timestamps written in another precision than declared.
+	points, indexes, rejected := h.checkTimestamps(points, indexes, now.UnixNano(), precision)
+	rejectedError := tsdb.PartialWriteError{Reason: "timestamps do not match the write precision", Dropped: len(rejected)}
+	if len(rejected) > 0 {
+		if partial == nil {
+			partial = newPartialWrite(n)
+		}
+		for _, i := range rejected {
+			partial.Add(i, rejectedError.Reason)
+		}
+	}
+	if len(points) == 0 {
+		h.writeError(w, Response{Err: rejectedError, Partial: partial}, http.StatusBadRequest)
 		return
 	}
+	points, indexes = h.relabelPoints(points, indexes)
+
+	// Points of the batch written to the same series at the same time
+	// replace each other, unless their fields are merged.
+	if duplicates == duplicatePointsMerge {
+		if points, indexes, err = h.mergeDuplicatePoints(points, indexes); err != nil {
+			h.httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 
 	// Determine required consistency level.
 	level := r.URL.Query().Get("consistency")
@@ -810,30 +1356,57 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user meta.U
 		}
 	}
 
-	// Write points.
-	if err := h.PointsWriter.WritePoints(database, r.URL.Query().Get("rp"), consistency, user, points); freetsdb.IsClientError(err) {
+	// Route the points to the databases of the routing rules.
+	rp := r.URL.Query().Get("rp")
+	batches := h.router.Route(routing.Destination{Database: database, RetentionPolicy: rp}, points)
+	if err := h.authorizeRoutes(user, database, batches); err != nil {
+		h.httpError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Write points. Retries of a batch sent with the same idempotency key are
+	// only written once.
+	key := r.Header.Get("Idempotency-Key")
+	if err := h.writeBatches(ctx, key, consistency, user, batches); freetsdb.IsClientError(err) {
 		atomic.AddInt64(&h.stats.PointsWrittenFail, int64(len(points)))
-		h.httpError(w, err.Error(), http.StatusBadRequest)
+		dead.addPoints(points, err)
+		h.writeError(w, Response{Err: err}, http.StatusBadRequest)
 		return
 	} else if freetsdb.IsAuthorizationError(err) {
 		atomic.AddInt64(&h.stats.PointsWrittenFail, int64(len(points)))
 		h.httpError(w, err.Error(), http.StatusForbidden)
 		return
+	} else if code, ok := modeErrorStatus(err); ok {
+		atomic.AddInt64(&h.stats.PointsWrittenFail, int64(len(points)))
+		h.writeError(w, Response{Err: err}, code)
+		return
 	} else if werr, ok := err.(tsdb.PartialWriteError); ok {
 		atomic.AddInt64(&h.stats.PointsWrittenOK, int64(len(points)-werr.Dropped))
 		atomic.AddInt64(&h.stats.PointsWrittenDropped, int64(werr.Dropped))
-		h.httpError(w, werr.Error(), http.StatusBadRequest)
+		if partial == nil {
+			partial = newPartialWrite(n)
+		}
+		partial.addDropped(werr, points, indexes)
+		for _, dp := range werr.Points {
+			dead.add(dp.Point.String(), dp.Reason, errorCodeOf(dp.Err, http.StatusBadRequest))
+		}
+		h.writeError(w, Response{Err: werr, Partial: partial}, http.StatusBadRequest)
 		return
 	} else if err != nil {
 		atomic.AddInt64(&h.stats.PointsWrittenFail, int64(len(points)))
-		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		h.writeError(w, Response{Err: err}, http.StatusInternalServerError)
 		return
 	} else if parseError != nil {
 		// We wrote some of the points
 		atomic.AddInt64(&h.stats.PointsWrittenOK, int64(len(points)))
 		// The other points failed to parse which means the client sent invalid line protocol.  We return a 400
 		// response code as well as the lines that failed to parse.
-		h.httpError(w, tsdb.PartialWriteError{Reason: parseError.Error()}.Error(), http.StatusBadRequest)
+		h.writeError(w, Response{Err: tsdb.PartialWriteError{Reason: parseError.Error(), Err: parseError}, Partial: partial}, http.StatusBadRequest)
+		return
+	} else if len(rejected) > 0 {
+		// We wrote the points whose timestamps were not rejected.
+		atomic.AddInt64(&h.stats.PointsWrittenOK, int64(len(points)))
+		h.writeError(w, Response{Err: rejectedError, Partial: partial}, http.StatusBadRequest)
 		return
 	}
 
@@ -867,32 +1440,55 @@ func (h *Handler) serveStatus(w http.ResponseWriter, r *http.Request) {
 	h.writeHeader(w, http.StatusNoContent)
 }
 
-// convertToEpoch converts result timestamps from time.Time to the specified epoch.
-func convertToEpoch(r *query.Result, epoch string) {
-	divisor := int64(1)
-
+// parseEpoch returns the precision of the epoch parameter of a query, or
+// zero if timestamps are not returned as epochs.
+func parseEpoch(epoch string) (time.Duration, error) {
 	switch epoch {
-	case "u":
-		divisor = int64(time.Microsecond)
+	case "":
+		return 0, nil
+	case "n", "ns":
+		return time.Nanosecond, nil
+	case "u", "us", "µ", "µs":
+		return time.Microsecond, nil
 	case "ms":
-		divisor = int64(time.Millisecond)
+		return time.Millisecond, nil
 	case "s":
-		divisor = int64(time.Second)
+		return time.Second, nil
 	case "m":
-		divisor = int64(time.Minute)
+		return time.Minute, nil
 	case "h":
-		divisor = int64(time.Hour)
+		return time.Hour, nil
 	}
+	return 0, fmt.Errorf(`invalid epoch %q: must be one of "ns", "u", "ms", "s", "m" or "h"`, epoch)
+}
 
+// convertToEpoch converts result timestamps from time.Time to epochs of the
+// precision. Timestamps are truncated to the precision rather than rounded,
+// towards the past for timestamps before 1970, so a timestamp is never
+// returned later than it is and its epoch does not depend on the format of
+// the response. Every timestamp of the result is converted, whatever its
+// column.
+func convertToEpoch(r *query.Result, precision time.Duration) {
 	for _, s := range r.Series {
 		for _, v := range s.Values {
-			if ts, ok := v[0].(time.Time); ok {
-				v[0] = ts.UnixNano() / divisor
+			for i, value := range v {
+				if ts, ok := value.(time.Time); ok {
+					v[i] = epochTime(ts, precision)
+				}
 			}
 		}
 	}
 }
 
+// epochTime returns the epoch of a timestamp in the precision.
+func epochTime(ts time.Time, precision time.Duration) int64 {
+	t, d := ts.UnixNano(), int64(precision)
+	if t%d < 0 {
+		return t/d - 1
+	}
+	return t / d
+}
+
 // servePromWrite receives data in the Prometheus remote write protocol and writes it
 // to the database
 func (h *Handler) servePromWrite(w http.ResponseWriter, r *http.Request, user meta.User) {
@@ -912,7 +1508,7 @@ func (h *Handler) servePromWrite(w http.ResponseWriter, r *http.Request, user me
 	}
 
 	if di := h.MetaClient.Database(database); di == nil {
-		h.httpError(w, fmt.Sprintf("database not found: %q", database), http.StatusNotFound)
+		h.writeError(w, Response{Err: fmt.Errorf("%w: %q", errDatabaseNotFound, database)}, http.StatusNotFound)
 		return
 	}
 
@@ -922,7 +1518,7 @@ func (h *Handler) servePromWrite(w http.ResponseWriter, r *http.Request, user me
 			return
 		}
 
-		if err := h.WriteAuthorizer.AuthorizeWrite(user.ID(), database); err != nil {
+		if err := h.authorizeWrite(user, database); err != nil {
 			h.httpError(w, fmt.Sprintf("%q user is not authorized to write to database %q", user.ID(), database), http.StatusForbidden)
 			return
 		}
@@ -989,6 +1585,7 @@ func (h *Handler) servePromWrite(w http.ResponseWriter, r *http.Request, user me
 			return
 		}
 	}
+	points = h.relabeler.Apply(points)
 
 	// Determine required consistency level.
 	level := r.URL.Query().Get("consistency")
@@ -1001,23 +1598,35 @@ func (h *Handler) servePromWrite(w http.ResponseWriter, r *http.Request, user me
 		}
 	}
 
+	// Route the points to the databases of the routing rules.
+	rp := r.URL.Query().Get("rp")
+	batches := h.router.Route(routing.Destination{Database: database, RetentionPolicy: rp}, points)
+	if err := h.authorizeRoutes(user, database, batches); err != nil {
+		h.httpError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
 	// Write points.
-	if err := h.PointsWriter.WritePoints(database, r.URL.Query().Get("rp"), consistency, user, points); freetsdb.IsClientError(err) {
+	if err := h.writeBatches(r.Context(), "", consistency, user, batches); freetsdb.IsClientError(err) {
 		atomic.AddInt64(&h.stats.PointsWrittenFail, int64(len(points)))
-		h.httpError(w, err.Error(), http.StatusBadRequest)
+		h.writeError(w, Response{Err: err}, http.StatusBadRequest)
 		return
 	} else if freetsdb.IsAuthorizationError(err) {
 		atomic.AddInt64(&h.stats.PointsWrittenFail, int64(len(points)))
 		h.httpError(w, err.Error(), http.StatusForbidden)
 		return
+	} else if code, ok := modeErrorStatus(err); ok {
+		atomic.AddInt64(&h.stats.PointsWrittenFail, int64(len(points)))
+		h.writeError(w, Response{Err: err}, code)
+		return
 	} else if werr, ok := err.(tsdb.PartialWriteError); ok {
 		atomic.AddInt64(&h.stats.PointsWrittenOK, int64(len(points)-werr.Dropped))
 		atomic.AddInt64(&h.stats.PointsWrittenDropped, int64(werr.Dropped))
-		h.httpError(w, werr.Error(), http.StatusBadRequest)
+		h.writeError(w, Response{Err: werr}, http.StatusBadRequest)
 		return
 	} else if err != nil {
 		atomic.AddInt64(&h.stats.PointsWrittenFail, int64(len(points)))
-		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		h.writeError(w, Response{Err: err}, http.StatusInternalServerError)
 		return
 	}
 
@@ -1449,6 +2058,12 @@ func parseSystemDiagnostics(d *diagnostics.Diagnostics) (map[string]interface{},
 
 // httpError writes an error to the client in a standard format.
 func (h *Handler) httpError(w http.ResponseWriter, errmsg string, code int) {
+	h.writeError(w, Response{Err: errors.New(errmsg)}, code)
+}
+
+// writeError writes response, which holds an error, with the HTTP status code.
+func (h *Handler) writeError(w http.ResponseWriter, response Response, code int) {
+	errmsg := response.Err.Error()
 	if code == http.StatusUnauthorized {
 		// If an unauthorized header will be sent back, add a WWW-Authenticate header
 		// as an authorization challenge.
@@ -1458,7 +2073,10 @@ func (h *Handler) httpError(w http.ResponseWriter, errmsg string, code int) {
 		w.Header().Set("X-FreeTSDB-Error", errmsg[:int(sz)])
 	}
 
-	response := Response{Err: errors.New(errmsg)}
+	if code/100 != 2 {
+		response.Code = errorCodeOf(response.Err, code)
+		w.Header().Set("X-FreeTSDB-Error-Code", string(response.Code))
+	}
 	if rw, ok := w.(ResponseWriter); ok {
 		h.writeHeader(w, code)
 		rw.WriteResponse(response)
@@ -1520,6 +2138,11 @@ func parseCredentials(r *http.Request) (*credentials, error) {
 					Method: BearerAuthentication,
 					Token:  strs[1],
 				}, nil
+			case "Session":
+				return &credentials{
+					Method: SessionAuthentication,
+					Token:  strs[1],
+				}, nil
 			case "Token":
 				if u, p, ok := parseToken(strs[1]); ok {
 					return &credentials{
@@ -1560,10 +2183,16 @@ func authenticate(inner func(http.ResponseWriter, *http.Request, meta.User), h *
 
 		// TODO corylanou: never allow this in the future without users
 		if requireAuthentication && h.MetaClient.AdminUserExists() {
+			// Enrolled agents are authenticated by their client certificate.
+			if user = h.certificateUser(r); user != nil {
+				inner(w, r, user)
+				return
+			}
+
 			creds, err := parseCredentials(r)
 			if err != nil {
 				atomic.AddInt64(&h.stats.AuthenticationFailures, 1)
-				h.httpError(w, err.Error(), http.StatusUnauthorized)
+				h.authenticationFailed(w, r, err.Error())
 				return
 			}
 
@@ -1571,17 +2200,35 @@ func authenticate(inner func(http.ResponseWriter, *http.Request, meta.User), h *
 			case UserAuthentication:
 				if creds.Username == "" {
 					atomic.AddInt64(&h.stats.AuthenticationFailures, 1)
-					h.httpError(w, "username required", http.StatusUnauthorized)
+					h.authenticationFailed(w, r, "username required")
 					return
 				}
 
 				user, err = h.MetaClient.Authenticate(creds.Username, creds.Password)
+				if err == meta.ErrPasswordExpired {
+					atomic.AddInt64(&h.stats.AuthenticationFailures, 1)
+					h.authenticationFailed(w, r, err.Error()+": change it with POST /api/v1/password")
+					return
+				} else if err != nil && len(h.PasswordBackends) > 0 {
+					user, err = h.authenticatePassword(creds.Username, creds.Password)
+				}
 				if err != nil {
 					atomic.AddInt64(&h.stats.AuthenticationFailures, 1)
-					h.httpError(w, "authorization failed", http.StatusUnauthorized)
+					h.authenticationFailed(w, r, "authorization failed")
 					return
 				}
 			case BearerAuthentication:
+				// Tokens not signed with the shared secret are handed to the
+				// external token backends.
+				if len(h.TokenBackends) > 0 && !isHMACToken(creds.Token) {
+					if user, err = h.authenticateToken(creds.Token); err != nil {
+						atomic.AddInt64(&h.stats.AuthenticationFailures, 1)
+						h.authenticationFailed(w, r, "authorization failed")
+						return
+					}
+					break
+				}
+
 				keyLookupFn := func(token *jwt.Token) (interface{}, error) {
 					// Check for expected signing method.
 					if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -1593,10 +2240,10 @@ func authenticate(inner func(http.ResponseWriter, *http.Request, meta.User), h *
 				// Parse and validate the token.
 				token, err := jwt.Parse(creds.Token, keyLookupFn)
 				if err != nil {
-					h.httpError(w, err.Error(), http.StatusUnauthorized)
+					h.authenticationFailed(w, r, err.Error())
 					return
 				} else if !token.Valid {
-					h.httpError(w, "invalid token", http.StatusUnauthorized)
+					h.authenticationFailed(w, r, "invalid token")
 					return
 				}
 
@@ -1609,30 +2256,47 @@ func authenticate(inner func(http.ResponseWriter, *http.Request, meta.User), h *
 
 				// Make sure an expiration was set on the token.
 				if exp, ok := claims["exp"].(float64); !ok || exp <= 0.0 {
-					h.httpError(w, "token expiration required", http.StatusUnauthorized)
+					h.authenticationFailed(w, r, "token expiration required")
 					return
 				}
 
+				// A token may lower the priority of its queries, see queryPriority.
+				if s, ok := claims["priority"].(string); ok {
+					p, err := query.ParseQueryPriority(s)
+					if err != nil {
+						h.authenticationFailed(w, r, err.Error())
+						return
+					}
+					r = withQueryPriority(r, p)
+				}
+
 				// Get the username from the token.
 				username, ok := claims["username"].(string)
 				if !ok {
-					h.httpError(w, "username in token must be a string", http.StatusUnauthorized)
+					h.authenticationFailed(w, r, "username in token must be a string")
 					return
 				} else if username == "" {
-					h.httpError(w, "token must contain a username", http.StatusUnauthorized)
+					h.authenticationFailed(w, r, "token must contain a username")
 					return
 				}
 
 				// Lookup user in the metastore.
 				if user, err = h.MetaClient.User(username); err != nil {
-					h.httpError(w, err.Error(), http.StatusUnauthorized)
+					h.authenticationFailed(w, r, err.Error())
 					return
 				} else if user == nil {
-					h.httpError(w, meta.ErrUserNotFound.Error(), http.StatusUnauthorized)
+					h.authenticationFailed(w, r, meta.ErrUserNotFound.Error())
+					return
+				}
+			case SessionAuthentication:
+				if user, err = h.MetaClient.AuthenticateSession(creds.Token); err != nil {
+					atomic.AddInt64(&h.stats.AuthenticationFailures, 1)
+					h.authenticationFailed(w, r, "invalid or expired session")
 					return
 				}
 			default:
-				h.httpError(w, "unsupported authentication", http.StatusUnauthorized)
+				h.authenticationFailed(w, r, "unsupported authentication")
+				return
 			}
 
 		}
@@ -1640,45 +2304,6 @@ func authenticate(inner func(http.ResponseWriter, *http.Request, meta.User), h *
 	})
 }
 
-// cors responds to incoming requests and adds the appropriate cors headers
-// TODO: corylanou: add the ability to configure this in our config
-func cors(inner http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if origin := r.Header.Get("Origin"); origin != "" {
-			w.Header().Set(`Access-Control-Allow-Origin`, origin)
-			w.Header().Set(`Access-Control-Allow-Methods`, strings.Join([]string{
-				`DELETE`,
-				`GET`,
-				`OPTIONS`,
-				`POST`,
-				`PUT`,
-			}, ", "))
-
-			w.Header().Set(`Access-Control-Allow-Headers`, strings.Join([]string{
-				`Accept`,
-				`Accept-Encoding`,
-				`Authorization`,
-				`Content-Length`,
-				`Content-Type`,
-				`X-CSRF-Token`,
-				`X-HTTP-Method-Override`,
-			}, ", "))
-
-			w.Header().Set(`Access-Control-Expose-Headers`, strings.Join([]string{
-				`Date`,
-				`X-FreeTSDB-Version`,
-				`X-FreeTSDB-Build`,
-			}, ", "))
-		}
-
-		if r.Method == "OPTIONS" {
-			return
-		}
-
-		inner.ServeHTTP(w, r)
-	})
-}
-
 func requestID(inner http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// X-Request-Id takes priority.
@@ -1783,6 +2408,15 @@ type Store interface {
 type Response struct {
 	Results []*query.Result
 	Err     error
+
+	// Code identifies the kind of Err for clients.
+	Code ErrorCode
+
+	// Partial identifies the points that were not written by a write.
+	Partial *PartialWrite
+
+	// Cursor continues a paginated query with more results.
+	Cursor string
 }
 
 // MarshalJSON encodes a Response struct into JSON.
@@ -1791,10 +2425,16 @@ func (r Response) MarshalJSON() ([]byte, error) {
 	var o struct {
 		Results []*query.Result `json:"results,omitempty"`
 		Err     string          `json:"error,omitempty"`
+		Code    ErrorCode       `json:"code,omitempty"`
+		Partial *PartialWrite   `json:"partial_write,omitempty"`
+		Cursor  string          `json:"cursor,omitempty"`
 	}
 
 	// Copy fields to output struct.
 	o.Results = r.Results
+	o.Code = r.Code
+	o.Partial = r.Partial
+	o.Cursor = r.Cursor
 	if r.Err != nil {
 		o.Err = r.Err.Error()
 	}
@@ -1807,6 +2447,9 @@ func (r *Response) UnmarshalJSON(b []byte) error {
 	var o struct {
 		Results []*query.Result `json:"results,omitempty"`
 		Err     string          `json:"error,omitempty"`
+		Code    ErrorCode       `json:"code,omitempty"`
+		Partial *PartialWrite   `json:"partial_write,omitempty"`
+		Cursor  string          `json:"cursor,omitempty"`
 	}
 
 	err := json.Unmarshal(b, &o)
@@ -1814,6 +2457,9 @@ func (r *Response) UnmarshalJSON(b []byte) error {
 		return err
 	}
 	r.Results = o.Results
+	r.Code = o.Code
+	r.Partial = o.Partial
+	r.Cursor = o.Cursor
 	if o.Err != "" {
 		r.Err = errors.New(o.Err)
 	}
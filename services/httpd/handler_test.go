@@ -3,19 +3,33 @@ package httpd_test
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"math"
+	"math/big"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -24,18 +38,30 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/snappy"
 	"github.com/google/go-cmp/cmp"
+	"github.com/freetsdb/freetsdb"
+	"github.com/freetsdb/freetsdb/coordinator"
 	"github.com/freetsdb/freetsdb/flux/client"
 	"github.com/freetsdb/freetsdb/services/influxql"
 	"github.com/freetsdb/freetsdb/internal"
 	"github.com/freetsdb/freetsdb/logger"
 	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/pkg/relabel"
+	"github.com/freetsdb/freetsdb/pkg/routing"
 	"github.com/freetsdb/freetsdb/prometheus/remote"
 	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/audit"
+	"github.com/freetsdb/freetsdb/services/grpc/wire"
 	"github.com/freetsdb/freetsdb/services/httpd"
 	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/services/resources"
+	"github.com/freetsdb/freetsdb/services/standby"
+	"github.com/freetsdb/freetsdb/toml"
 	"github.com/freetsdb/freetsdb/tsdb"
 	"github.com/freetsdb/freetsdb/services/flux"
+	fluxcsv "github.com/freetsdb/freetsdb/services/flux/csv"
 	"github.com/freetsdb/freetsdb/services/flux/lang"
+	"github.com/tinylib/msgp/msgp"
+	"golang.org/x/net/websocket"
 )
 
 // Ensure the handler returns results from a query (including nil results).
@@ -61,6 +87,65 @@ func TestHandler_Query(t *testing.T) {
 	}
 }
 
+// Ensure the handler records audited statements in the audit log.
+func TestHandler_Query_Audit(t *testing.T) {
+	h := NewHandler(false)
+	h.StatementExecutor.ExecuteStatementFn = func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+		return ctx.Send(&query.Result{})
+	}
+
+	var events []audit.Event
+	h.AuditLog = auditRecorderFunc(func(e audit.Event) { events = append(events, e) })
+
+	w := httptest.NewRecorder()
+	req := MustNewJSONRequest("POST", "/query?db=foo&q=SELECT+*+FROM+bar%3BDROP+MEASUREMENT+bar", nil)
+	req.RemoteAddr = "10.0.0.1:4242"
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("unexpected events: %+v", events)
+	} else if e := events[0]; e.Category != audit.CategoryDelete || e.Statement != "DROP MEASUREMENT bar" || e.Addr != "10.0.0.1" || e.Database != "foo" {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+}
+
+// Ensure X-Forwarded-For is only recorded in the audit log for requests from
+// trusted proxies.
+func TestHandler_Query_Audit_TrustedProxies(t *testing.T) {
+	config := NewHandlerConfig()
+	config.TrustedProxies = []string{"10.0.0.1", "192.168.0.0/16"}
+	h := NewHandlerWithConfig(config)
+	h.StatementExecutor.ExecuteStatementFn = func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+		return ctx.Send(&query.Result{})
+	}
+
+	var events []audit.Event
+	h.AuditLog = auditRecorderFunc(func(e audit.Event) { events = append(events, e) })
+
+	for _, tt := range []struct {
+		remote string
+		addr   string
+	}{
+		{remote: "10.0.0.1:4242", addr: "172.16.0.1,10.0.0.1"},
+		{remote: "192.168.1.1:4242", addr: "172.16.0.1,192.168.1.1"},
+		{remote: "10.0.0.2:4242", addr: "10.0.0.2"},
+	} {
+		events = nil
+		req := MustNewJSONRequest("POST", "/query?db=foo&q=DROP+MEASUREMENT+bar", nil)
+		req.RemoteAddr = tt.remote
+		req.Header.Set("X-Forwarded-For", "172.16.0.1")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		if len(events) != 1 {
+			t.Fatalf("unexpected events: %+v", events)
+		} else if events[0].Addr != tt.addr {
+			t.Errorf("%s: unexpected addr: got %q, exp %q", tt.remote, events[0].Addr, tt.addr)
+		}
+	}
+}
+
 // Ensure the handler returns results from a query passed as a file.
 func TestHandler_Query_File(t *testing.T) {
 	h := NewHandler(false)
@@ -188,7 +273,7 @@ func TestHandler_Query_Auth(t *testing.T) {
 	h.ServeHTTP(w, req)
 	if w.Code != http.StatusUnauthorized {
 		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
-	} else if body := strings.TrimSpace(w.Body.String()); body != `{"error":"signature is invalid"}` {
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"error":"signature is invalid","code":"unauthorized"}` {
 		t.Fatalf("unexpected body: %s", body)
 	}
 
@@ -200,7 +285,7 @@ func TestHandler_Query_Auth(t *testing.T) {
 	h.ServeHTTP(w, req)
 	if w.Code != http.StatusUnauthorized {
 		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
-	} else if body := strings.TrimSpace(w.Body.String()); body != `{"error":"user not found"}` {
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"error":"user not found","code":"unauthorized"}` {
 		t.Fatalf("unexpected body: %s", body)
 	}
 
@@ -228,7 +313,7 @@ func TestHandler_Query_Auth(t *testing.T) {
 	h.ServeHTTP(w, req)
 	if w.Code != http.StatusUnauthorized {
 		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
-	} else if body := strings.TrimSpace(w.Body.String()); body != `{"error":"token expiration required"}` {
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"error":"token expiration required","code":"unauthorized"}` {
 		t.Fatalf("unexpected body: %s", body)
 	}
 
@@ -245,6 +330,87 @@ func TestHandler_Query_Auth(t *testing.T) {
 	}
 }
 
+// Test query with users authenticated by the external backends.
+func TestHandler_Query_ExternalAuth(t *testing.T) {
+	config := NewHandlerConfig(WithAuthentication())
+	config.GroupMappings = []httpd.GroupMapping{{Group: "analysts", Database: "foo", Privilege: "read"}}
+	h := NewHandlerWithConfig(config)
+
+	h.MetaClient.AdminUserExistsFn = func() bool { return true }
+	h.MetaClient.AuthenticateFn = func(u, p string) (meta.User, error) {
+		return nil, meta.ErrAuthenticate
+	}
+	h.MetaClient.UserFn = func(username string) (meta.User, error) {
+		return nil, meta.ErrUserNotFound
+	}
+	h.PasswordBackends = []httpd.PasswordBackend{passwordBackendFunc(func(username, password string) (*httpd.ExternalIdentity, error) {
+		if username != "user1" || password != "abcd" {
+			return nil, httpd.ErrExternalAuthenticate
+		}
+		return &httpd.ExternalIdentity{Username: username, Groups: []string{"Analysts"}}, nil
+	})}
+	h.TokenBackends = []httpd.TokenBackend{tokenBackendFunc(func(token string) (*httpd.ExternalIdentity, error) {
+		if token != mustUnsignedToken("user2") {
+			return nil, errors.New("unknown signing key: \"key0\"")
+		}
+		return &httpd.ExternalIdentity{Username: "user2", Groups: []string{"analysts"}}, nil
+	})}
+
+	var authorized meta.User
+	h.QueryAuthorizer.AuthorizeQueryFn = func(u meta.User, query *influxql.Query, database string) error {
+		authorized = u
+		return nil
+	}
+	h.StatementExecutor.ExecuteStatementFn = func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+		ctx.Results <- &query.Result{StatementID: 1}
+		return nil
+	}
+
+	// The groups of an external user grant its privileges.
+	r := MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar", nil)
+	r.SetBasicAuth("user1", "abcd")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if authorized == nil || authorized.ID() != "user1" {
+		t.Fatalf("unexpected user: %v", authorized)
+	} else if ui, ok := authorized.(interface {
+		AuthorizeDatabase(influxql.Privilege, string) bool
+	}); !ok || !ui.AuthorizeDatabase(influxql.ReadPrivilege, "foo") || ui.AuthorizeDatabase(influxql.WritePrivilege, "foo") {
+		t.Fatal("unexpected privileges")
+	}
+
+	r = MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar", nil)
+	r.SetBasicAuth("user1", "wrong")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+
+	r = MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar", nil)
+	r.Header.Set("Authorization", "Bearer "+mustUnsignedToken("user2"))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if authorized.ID() != "user2" {
+		t.Fatalf("unexpected user: %s", authorized.ID())
+	}
+
+	// Why a token was rejected is not told to the client.
+	r = MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar", nil)
+	r.Header.Set("Authorization", "Bearer "+mustUnsignedToken("user3"))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"error":"authorization failed","code":"unauthorized"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
 // Ensure the handler returns results from a query (including nil results).
 func TestHandler_QueryRegex(t *testing.T) {
 	h := NewHandler(false)
@@ -262,6 +428,66 @@ func TestHandler_QueryRegex(t *testing.T) {
 	h.ServeHTTP(w, MustNewRequest("GET", "/query?db=test&q=SELECT%20%2A%20FROM%20test%20WHERE%20url%20%3D~%20%2Fhttp%5C%3A%5C%2F%5C%2Fwww.akamai%5C.com%2F", nil))
 }
 
+// Ensure the query priority is taken from the user, the token and the request.
+func TestHandler_Query_Priority(t *testing.T) {
+	h := NewHandlerWithConfig(NewHandlerConfig(WithAuthentication(), func(c *httpd.Config) {
+		c.QueryPriorities = []httpd.UserPriority{{User: "exporter", Priority: "batch"}}
+	}))
+	h.MetaClient.AdminUserExistsFn = func() bool { return true }
+	h.MetaClient.UserFn = func(username string) (meta.User, error) {
+		return &meta.UserInfo{Name: username, Admin: true}, nil
+	}
+	h.QueryAuthorizer.AuthorizeQueryFn = func(u meta.User, query *influxql.Query, database string) error {
+		return nil
+	}
+
+	var priority query.QueryPriority
+	h.StatementExecutor.ExecuteStatementFn = func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+		priority = ctx.Priority
+		return ctx.Send(&query.Result{})
+	}
+
+	for _, tt := range []struct {
+		user   string
+		claim  string
+		param  string
+		code   int
+		expect query.QueryPriority
+	}{
+		{user: "dashboard", expect: query.InteractivePriority},
+		{user: "dashboard", param: "batch", expect: query.BatchPriority},
+		{user: "exporter", expect: query.BatchPriority},
+		{user: "exporter", param: "interactive", code: http.StatusBadRequest},
+		{user: "exporter", claim: "interactive", code: http.StatusBadRequest},
+		{user: "exporter", claim: "batch", param: "interactive", code: http.StatusBadRequest},
+		{user: "dashboard", claim: "batch", expect: query.BatchPriority},
+		{user: "dashboard", claim: "urgent", code: http.StatusUnauthorized},
+	} {
+		token, _ := MustJWTToken(tt.user, h.Config.SharedSecret, false)
+		if tt.claim != "" {
+			token.Claims.(jwt.MapClaims)["priority"] = tt.claim
+		}
+		signedToken, err := token.SignedString([]byte(h.Config.SharedSecret))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar&priority="+tt.param, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", signedToken))
+
+		priority = -1
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if code := tt.code; code == 0 && w.Code != http.StatusOK {
+			t.Errorf("%s %s %s: unexpected status: %d: %s", tt.user, tt.claim, tt.param, w.Code, w.Body.String())
+		} else if code != 0 && w.Code != code {
+			t.Errorf("%s %s %s: unexpected status: %d: %s", tt.user, tt.claim, tt.param, w.Code, w.Body.String())
+		} else if code == 0 && priority != tt.expect {
+			t.Errorf("%s %s %s: unexpected priority: %s", tt.user, tt.claim, tt.param, priority)
+		}
+	}
+}
+
 // Ensure the handler merges results from the same statement.
 func TestHandler_Query_MergeResults(t *testing.T) {
 	h := NewHandler(false)
@@ -298,6 +524,52 @@ func TestHandler_Query_MergeEmptyResults(t *testing.T) {
 	}
 }
 
+// Ensure the handler pages through results with a cursor.
+func TestHandler_Query_Cursor(t *testing.T) {
+	h := NewHandler(false)
+	h.StatementExecutor.ExecuteStatementFn = func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+		ctx.Results <- &query.Result{StatementID: 1, Series: models.Rows([]*models.Row{
+			{Name: "series0", Values: [][]interface{}{{1}, {2}, {3}}},
+			{Name: "series1", Values: [][]interface{}{{4}}},
+		})}
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar&page_size=2", nil))
+	cursor := w.Header().Get("X-FreeTSDB-Cursor")
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if cursor == "" {
+		t.Fatal("expected cursor")
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"results":[{"statement_id":1,"series":[{"name":"series0","values":[[1],[2]],"partial":true}],"partial":true}],"cursor":"`+cursor+`"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?cursor="+cursor, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Header().Get("X-FreeTSDB-Cursor") != "" {
+		t.Fatal("unexpected cursor")
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"results":[{"statement_id":1,"series":[{"name":"series0","values":[[3]]},{"name":"series1","values":[[4]]}]}]}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	// The cursor is removed once the query has finished.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?cursor="+cursor, nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar&page_size=2&chunked=true", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
 // Ensure the handler can parse chunked and chunk size query parameters.
 func TestHandler_Query_Chunked(t *testing.T) {
 	h := NewHandler(false)
@@ -356,6 +628,135 @@ func TestHandler_Query_Async(t *testing.T) {
 	}
 }
 
+// Ensure the handler answers metadata queries with an ETag and does not
+// execute them again while the index epoch of the database is unchanged.
+func TestHandler_Query_NotModified(t *testing.T) {
+	var executed int
+	h := NewHandler(false)
+	h.StatementExecutor.ExecuteStatementFn = func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+		executed++
+		ctx.Results <- &query.Result{StatementID: 1, Series: models.Rows([]*models.Row{{Name: "measurements"}})}
+		return nil
+	}
+	epoch := uint64(1)
+	modified := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var epochErr error
+	h.Handler.Index = indexEpochFunc(func(database string) (uint64, time.Time, error) {
+		if database != "foo" {
+			t.Fatalf("unexpected database: %s", database)
+		}
+		return epoch, modified, epochErr
+	})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&q=SHOW+MEASUREMENTS", nil))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if etag == "" {
+		t.Fatal("expected an ETag")
+	} else if lm := w.Header().Get("Last-Modified"); lm != "Wed, 01 Jan 2020 00:00:00 GMT" {
+		t.Fatalf("unexpected Last-Modified: %s", lm)
+	}
+
+	// The cached results are valid while the epoch is unchanged.
+	req := MustNewJSONRequest("GET", "/query?db=foo&q=SHOW+MEASUREMENTS", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.Len() != 0 {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+
+	req = MustNewJSONRequest("GET", "/query?db=foo&q=SHOW+MEASUREMENTS", nil)
+	req.Header.Set("If-Modified-Since", "Wed, 01 Jan 2020 00:00:00 GMT")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	// A change of the index invalidates them.
+	epoch, modified = 2, modified.Add(time.Minute)
+	req = MustNewJSONRequest("GET", "/query?db=foo&q=SHOW+MEASUREMENTS", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Header().Get("ETag") == etag {
+		t.Fatal("expected the ETag to change")
+	} else if executed != 2 {
+		t.Fatalf("unexpected executions: %d", executed)
+	}
+
+	// Other queries are not cached.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if etag := w.Header().Get("ETag"); etag != "" {
+		t.Fatalf("unexpected ETag: %s", etag)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&q=SHOW+TAG+KEYS+WHERE+time+>+now()+-+1h", nil))
+	if etag := w.Header().Get("ETag"); etag != "" {
+		t.Fatalf("unexpected ETag: %s", etag)
+	}
+
+	// Nor are the queries of a cluster with an unreachable node.
+	epochErr = errors.New("node 2 unreachable")
+	req = MustNewJSONRequest("GET", "/query?db=foo&q=SHOW+MEASUREMENTS", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if etag := w.Header().Get("ETag"); etag != "" {
+		t.Fatalf("unexpected ETag: %s", etag)
+	}
+}
+
+// Ensure the ETags of metadata queries differ between users, whose privileges
+// may differ.
+func TestHandler_Query_NotModified_User(t *testing.T) {
+	h := NewHandler(true)
+	h.MetaClient.AdminUserExistsFn = func() bool { return true }
+	h.MetaClient.UserFn = func(username string) (meta.User, error) {
+		return &meta.UserInfo{Name: username, Privileges: map[string]influxql.Privilege{"foo": influxql.ReadPrivilege}}, nil
+	}
+	h.MetaClient.AuthenticateFn = func(u, p string) (meta.User, error) {
+		return &meta.UserInfo{Name: u, Privileges: map[string]influxql.Privilege{"foo": influxql.ReadPrivilege}}, nil
+	}
+	h.QueryAuthorizer.AuthorizeQueryFn = func(u meta.User, query *influxql.Query, database string) error {
+		return nil
+	}
+	h.StatementExecutor.ExecuteStatementFn = func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+		ctx.Results <- &query.Result{StatementID: 1, Series: models.Rows([]*models.Row{{Name: "measurements"}})}
+		return nil
+	}
+	h.Handler.Index = indexEpochFunc(func(database string) (uint64, time.Time, error) {
+		return 1, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), nil
+	})
+
+	etags := make(map[string]string)
+	for _, username := range []string{"user0", "user1"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&q=SHOW+MEASUREMENTS&u="+username+"&p=pass", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+		} else if etags[username] = w.Header().Get("ETag"); etags[username] == "" {
+			t.Fatal("expected an ETag")
+		}
+	}
+	if etags["user0"] == etags["user1"] {
+		t.Fatalf("expected the ETags of the users to differ: %s", etags["user0"])
+	}
+}
+
 // Ensure the handler returns a status 400 if the query is not passed in.
 func TestHandler_Query_ErrQueryRequired(t *testing.T) {
 	h := NewHandler(false)
@@ -363,7 +764,7 @@ func TestHandler_Query_ErrQueryRequired(t *testing.T) {
 	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query", nil))
 	if w.Code != http.StatusBadRequest {
 		t.Fatalf("unexpected status: %d", w.Code)
-	} else if body := strings.TrimSpace(w.Body.String()); body != `{"error":"missing required parameter \"q\""}` {
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"error":"missing required parameter \"q\"","code":"bad-request"}` {
 		t.Fatalf("unexpected body: %s", body)
 	}
 }
@@ -375,7 +776,7 @@ func TestHandler_Query_ErrInvalidQuery(t *testing.T) {
 	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?q=SELECT", nil))
 	if w.Code != http.StatusBadRequest {
 		t.Fatalf("unexpected status: %d", w.Code)
-	} else if body := strings.TrimSpace(w.Body.String()); body != `{"error":"error parsing query: found EOF, expected identifier, string, number, bool at line 1, char 8"}` {
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"error":"error parsing query: found EOF, expected identifier, string, number, bool at line 1, char 8","code":"bad-request"}` {
 		t.Fatalf("unexpected body: %s", body)
 	}
 }
@@ -539,6 +940,71 @@ func TestHandler_Query_CloseNotify(t *testing.T) {
 	}
 }
 
+// Ensure a query stream receives the written points that match its query.
+func TestHandler_QueryStream(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		return &meta.DatabaseInfo{Name: name, DefaultRetentionPolicy: "autogen"}
+	}
+	h.Open()
+	defer h.Close()
+
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	values := url.Values{}
+	values.Set("q", "SELECT value FROM cpu WHERE host = 'a'")
+	values.Set("db", "db0")
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(s.URL, "http")+"/query/stream?"+values.Encode(), "", s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	points, err := models.ParsePointsString("cpu,host=a value=1 10\ncpu,host=b value=2 20\nmem,host=a value=3 30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.StreamPoints() <- &coordinator.WritePointsRequest{Database: "db0", RetentionPolicy: "autogen", Points: points}
+
+	var msg string
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := websocket.Message.Receive(ws, &msg); err != nil {
+		t.Fatal(err)
+	} else if msg != `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"a"},"columns":["time","value"],"values":[["1970-01-01T00:00:00.00000001Z",1]]}]}]}` {
+		t.Fatalf("unexpected message: %s", msg)
+	}
+}
+
+// Ensure a query stream is only opened by pages of the same host or of the
+// origins allowed by CORS.
+func TestHandler_QueryStream_Origin(t *testing.T) {
+	h := NewHandler(false)
+	h.Config.CORS.AllowedOrigins = []string{"https://app.example.com"}
+
+	for _, tt := range []struct {
+		origin string
+		code   int
+	}{
+		{origin: "https://evil.example.com", code: http.StatusForbidden},
+		{origin: "https://app.example.com", code: http.StatusBadRequest},
+		{origin: "http://example.com", code: http.StatusBadRequest},
+		{origin: "", code: http.StatusBadRequest},
+	} {
+		r := MustNewRequest("GET", "http://example.com/query/stream", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// The allowed origins fail on the missing query instead.
+		if w.Code != tt.code {
+			t.Fatalf("origin %q: unexpected status: %d", tt.origin, w.Code)
+		}
+	}
+}
+
 // Ensure the prometheus remote write works
 func TestHandler_PromWrite(t *testing.T) {
 	req := &remote.WriteRequest{
@@ -821,6 +1287,55 @@ func TestHandler_Flux_DisabledByDefault(t *testing.T) {
 	}
 }
 
+// Ensure the Flux language server completes the buckets of the cluster.
+func TestHandler_Flux_LSP(t *testing.T) {
+	h := NewHandlerWithConfig(NewHandlerConfig(WithFlux(), WithNoLog()))
+	h.MetaClient.DatabasesFn = func() ([]meta.DatabaseInfo, error) {
+		return []meta.DatabaseInfo{{Name: "db0", RetentionPolicies: []meta.RetentionPolicyInfo{{Name: "autogen"}}}}, nil
+	}
+
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(s.URL, "http")+"/api/v2/flux/lsp", "", s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	for _, req := range []string{
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///a.flux","version":1,"text":"from(bucket: \""}}}`,
+		`{"jsonrpc":"2.0","id":1,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///a.flux"},"position":{"line":0,"character":14}}}`,
+	} {
+		if err := websocket.Message.Send(ws, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Skip the diagnostics of the opened document.
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var msg string
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			t.Fatal(err)
+		} else if strings.Contains(msg, "publishDiagnostics") {
+			continue
+		} else if exp := `{"jsonrpc":"2.0","id":1,"result":{"isIncomplete":false,"items":[{"label":"db0","kind":9,"detail":"bucket"},{"label":"db0/autogen","kind":9,"detail":"bucket"}]}}`; msg != exp {
+			t.Fatalf("unexpected message: %s", msg)
+		}
+		break
+	}
+}
+
+func TestHandler_Flux_LSP_Disabled(t *testing.T) {
+	h := NewHandler(false)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v2/flux/lsp", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
 func TestHandler_Flux_QueryJSON(t *testing.T) {
 	h := NewHandlerWithConfig(NewHandlerConfig(WithFlux(), WithNoLog()))
 	called := false
@@ -1033,17 +1548,102 @@ func TestHandler_Ping(t *testing.T) {
 	}
 }
 
-// Ensure the handler returns the version correctly from the different endpoints.
-func TestHandler_Version(t *testing.T) {
-	h := NewHandler(false)
-	h.StatementExecutor.ExecuteStatementFn = func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
-		return nil
+// Ensure the handler serves an OpenAPI document of its routes.
+func TestHandler_OpenAPI(t *testing.T) {
+	h := NewHandler(true)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
 	}
-	tests := []struct {
-		method   string
-		endpoint string
-		body     io.Reader
-	}{
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Parameters []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+			Security []map[string][]string `json:"security"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	} else if doc.OpenAPI != "3.0.3" {
+		t.Fatalf("unexpected openapi version: %s", doc.OpenAPI)
+	}
+
+	if _, ok := doc.Paths["/query"]["options"]; ok {
+		t.Fatal("unexpected CORS route in the document")
+	}
+	if op, ok := doc.Paths["/write"]["post"]; !ok {
+		t.Fatal("expected /write in the document")
+	} else if len(op.Parameters) == 0 || op.Parameters[0].Name != "db" {
+		t.Fatalf("unexpected /write parameters: %+v", op.Parameters)
+	} else if len(op.Security) == 0 {
+		t.Fatal("expected /write to require authentication")
+	}
+	if op, ok := doc.Paths["/api/v1/templates/{name}"]["delete"]; !ok {
+		t.Fatal("expected the template path in the document")
+	} else if len(op.Parameters) == 0 || op.Parameters[0].Name != "name" || op.Parameters[0].In != "path" {
+		t.Fatalf("unexpected template parameters: %+v", op.Parameters)
+	}
+	if op := doc.Paths["/ping"]["get"]; len(op.Security) != 0 {
+		t.Fatal("expected /ping not to require authentication")
+	}
+}
+
+// Ensure the handler returns the tag values matching a prefix, capped at the
+// limit.
+func TestHandler_TagValues(t *testing.T) {
+	h := NewHandlerWithConfig(NewHandlerConfig(func(c *httpd.Config) { c.MaxTagValuesLimit = 2 }))
+	h.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		if name == "db0" {
+			return &meta.DatabaseInfo{Name: name}
+		}
+		return nil
+	}
+	h.Handler.TSDBStore = tagValuesByPrefixFunc(func(auth query.Authorizer, database string, name, key, prefix []byte, limit int) ([]string, error) {
+		if database != "db0" || string(name) != "cpu" || string(key) != "host" || string(prefix) != "serv" {
+			t.Fatalf("unexpected request: db=%s name=%s key=%s prefix=%s", database, name, key, prefix)
+		} else if limit != 3 {
+			t.Fatalf("unexpected limit: %d", limit)
+		}
+		return []string{"serverA", "serverB", "serverC"}, nil
+	})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/tag-values?db=db0&measurement=cpu&key=host&match=serv&limit=10", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"values":["serverA","serverB"],"truncated":true}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/tag-values?db=db1&measurement=cpu&key=host", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/tag-values?db=db0&measurement=cpu", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// Ensure the handler returns the version correctly from the different endpoints.
+func TestHandler_Version(t *testing.T) {
+	h := NewHandler(false)
+	h.StatementExecutor.ExecuteStatementFn = func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+		return nil
+	}
+	tests := []struct {
+		method   string
+		endpoint string
+		body     io.Reader
+	}{
 		{
 			method:   "GET",
 			endpoint: "/ping",
@@ -1194,260 +1794,1780 @@ func TestHandler_Write_NegativeMaxBodySize(t *testing.T) {
 	}
 }
 
-// Ensure X-Forwarded-For header writes the correct log message.
-func TestHandler_XForwardedFor(t *testing.T) {
-	var buf bytes.Buffer
-	h := NewHandler(false)
-	h.CLFLogger = log.New(&buf, "", 0)
+// Ensure sampled writes return their trace ID.
+func TestHandler_Write_Trace(t *testing.T) {
+	for _, tt := range []struct {
+		rate   float64
+		traced bool
+	}{
+		{rate: 0, traced: false},
+		{rate: 1, traced: true},
+	} {
+		h := NewHandler(false)
+		h.Config.WriteTraceSampleRate = tt.rate
+		h.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+			return &meta.DatabaseInfo{}
+		}
+		h.PointsWriter.WritePointsFn = func(_, _ string, _ models.ConsistencyLevel, _ meta.User, _ []models.Point) error {
+			return nil
+		}
 
-	req := MustNewRequest("GET", "/query", nil)
-	req.Header.Set("X-Forwarded-For", "192.168.0.1")
-	req.RemoteAddr = "127.0.0.1"
-	h.ServeHTTP(httptest.NewRecorder(), req)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", strings.NewReader("cpu value=1")))
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+		} else if id := w.Header().Get("X-FreeTSDB-Trace-Id"); (id != "") != tt.traced {
+			t.Fatalf("rate %v: unexpected trace id: %q", tt.rate, id)
+		}
+	}
+}
 
-	parts := strings.Split(buf.String(), " ")
-	if parts[0] != "192.168.0.1,127.0.0.1" {
-		t.Errorf("unexpected host ip address: %s", parts[0])
+// Ensure write errors return their error codes.
+func TestHandler_Write_ErrorCode(t *testing.T) {
+	for _, tt := range []struct {
+		db   string
+		err  error
+		code string
+	}{
+		{db: "missing", code: "database-not-found"},
+		{db: "foo", err: tsdb.PartialWriteError{Reason: "points beyond retention policy", Dropped: 1}, code: "partial-write"},
+		{db: "foo", err: tsdb.PartialWriteError{Reason: "field type conflict: input field \"value\"", Dropped: 1, Err: tsdb.ErrFieldTypeConflict}, code: "field-type-conflict"},
+		{db: "foo", err: tsdb.PartialWriteError{Reason: "max-series-per-database limit exceeded: (1)", Dropped: 1, Err: tsdb.ErrMaxSeriesPerDatabaseExceeded}, code: "max-series-exceeded"},
+		{db: "foo", err: tsdb.PartialWriteError{Reason: "max-series-key-length limit exceeded", Dropped: 1, Err: tsdb.KeyLengthError{Length: 38, Limit: 32}}, code: "max-key-length-exceeded"},
+		{db: "foo", err: freetsdb.ErrRetentionPolicyNotFound("rp0"), code: "retention-policy-not-found"},
+		{db: "foo", err: coordinator.ErrTimeout, code: "timeout"},
+		{db: "foo", err: coordinator.ReadOnlyError{Reason: "maintenance"}, code: "read-only"},
+		{db: "foo", err: coordinator.ErrDatabaseFrozen, code: "database-frozen"},
+		{db: "foo", err: errors.New("disk full"), code: "internal"},
+		// Codes are not chosen by the messages of errors.
+		{db: "foo", err: errors.New("dial tcp: i/o timeout"), code: "internal"},
+	} {
+		h := NewHandler(false)
+		h.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+			if name != "foo" {
+				return nil
+			}
+			return &meta.DatabaseInfo{}
+		}
+		h.PointsWriter.WritePointsFn = func(_, _ string, _ models.ConsistencyLevel, _ meta.User, _ []models.Point) error {
+			return tt.err
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, MustNewRequest("POST", "/write?db="+tt.db, strings.NewReader("cpu value=1")))
+		if got := w.Header().Get("X-FreeTSDB-Error-Code"); got != tt.code {
+			t.Errorf("%v: unexpected error code header: got %q, exp %q", tt.err, got, tt.code)
+		}
+
+		var resp httpd.Response
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		} else if string(resp.Code) != tt.code {
+			t.Errorf("%v: unexpected error code: got %q, exp %q", tt.err, resp.Code, tt.code)
+		}
 	}
 }
 
-func TestHandler_XRequestId(t *testing.T) {
-	var buf bytes.Buffer
+// Ensure a partial write returns the points that were not written.
+func TestHandler_Write_PartialWrite(t *testing.T) {
 	h := NewHandler(false)
-	h.CLFLogger = log.New(&buf, "", 0)
+	h.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		return &meta.DatabaseInfo{}
+	}
+	h.PointsWriter.WritePointsFn = func(_, _ string, _ models.ConsistencyLevel, _ meta.User, points []models.Point) error {
+		if len(points) != 3 {
+			t.Fatalf("unexpected points: %v", points)
+		}
+		return tsdb.PartialWriteError{
+			Reason:  "field type conflict",
+			Dropped: 1,
+			Err:     tsdb.ErrFieldTypeConflict,
+			Points:  []tsdb.DroppedPoint{{Point: points[1], Reason: "field type conflict", Err: tsdb.ErrFieldTypeConflict}},
+		}
+	}
 
-	cases := []map[string]string{
-		{"X-Request-Id": "abc123", "Request-Id": ""},          // X-Request-Id is used.
-		{"X-REQUEST-ID": "cde", "Request-Id": ""},             // X-REQUEST-ID is used.
-		{"X-Request-Id": "", "Request-Id": "foobarzoo"},       // Request-Id is used.
-		{"X-Request-Id": "abc123", "Request-Id": "foobarzoo"}, // X-Request-Id takes precedence.
-		{"X-Request-Id": "", "Request-Id": ""},                // v1 UUID generated.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", strings.NewReader("cpu value=1\ncpu value=\ncpu value=\"a\"\n# comment\ncpu value=2")))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
 	}
 
-	for _, c := range cases {
-		t.Run(fmt.Sprint(c), func(t *testing.T) {
-			buf.Reset()
-			req := MustNewRequest("GET", "/ping", nil)
-			req.RemoteAddr = "127.0.0.1"
+	var resp httpd.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	} else if resp.Partial == nil {
+		t.Fatalf("expected partial write: %s", w.Body.String())
+	}
 
-			// Set the relevant request ID headers
-			var allEmpty = true
-			for k, v := range c {
-				req.Header.Set(k, v)
-				if v != "" {
-					allEmpty = false
-				}
-			}
+	pw := resp.Partial
+	if pw.Points != 4 {
+		t.Fatalf("unexpected points: %d", pw.Points)
+	} else if !bytes.Equal(pw.Failed, []byte{0x6}) {
+		t.Fatalf("unexpected failed bitmap: %v", pw.Failed)
+	} else if pw.Unknown != 0 {
+		t.Fatalf("unexpected unknown points: %d", pw.Unknown)
+	}
+	if exp := []httpd.PartialWriteReason{
+		{Reason: "missing field value", Indexes: []int{1}},
+		{Reason: "field type conflict", Indexes: []int{2}},
+	}; !reflect.DeepEqual(pw.Errors, exp) {
+		t.Fatalf("unexpected errors: %+v", pw.Errors)
+	}
+}
 
-			w := httptest.NewRecorder()
-			h.ServeHTTP(w, req)
+// Ensure written points are relabeled and that the rules can be replaced.
+func TestHandler_Write_Relabel(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		return &meta.DatabaseInfo{}
+	}
+	var written []models.Point
+	h.PointsWriter.WritePointsFn = func(_, _ string, _ models.ConsistencyLevel, _ meta.User, points []models.Point) error {
+		written = points
+		return nil
+	}
 
-			// Split up the HTTP log line. The request ID is currently located in
-			// index 12. If the log line gets changed in the future, this test
-			// will likely break and the index will need to be updated.
-			parts := strings.Split(buf.String(), " ")
-			i := 12
+	dc, region := "west", "us"
+	if err := h.SetRelabelRules([]relabel.Rule{
+		{Action: relabel.ActionDrop, SourceTags: []string{"host"}, Regex: &region},
+		{TargetTag: "dc", Replacement: &dc},
+	}); err != nil {
+		t.Fatal(err)
+	}
 
-			// If neither header is set then we expect a v1 UUID to be generated.
-			if allEmpty {
-				if got, exp := len(parts[i]), 36; got != exp {
-					t.Fatalf("got ID of length %d, expected one of length %d", got, exp)
-				}
-			} else if c["X-Request-Id"] != "" {
-				if got, exp := parts[i], c["X-Request-Id"]; got != exp {
-					t.Fatalf("got ID of %q, expected %q", got, exp)
-				}
-			} else if c["X-REQUEST-ID"] != "" {
-				if got, exp := parts[i], c["X-REQUEST-ID"]; got != exp {
-					t.Fatalf("got ID of %q, expected %q", got, exp)
-				}
-			} else {
-				if got, exp := parts[i], c["Request-Id"]; got != exp {
-					t.Fatalf("got ID of %q, expected %q", got, exp)
-				}
-			}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", strings.NewReader("cpu,host=a value=1 10\ncpu,host=us value=2 10")))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if len(written) != 1 || written[0].String() != "cpu,dc=west,host=a value=1 10" {
+		t.Fatalf("unexpected points: %v", written)
+	}
 
-			// Check response headers
-			if got, exp := w.Header().Get("Request-Id"), parts[i]; got != exp {
-				t.Fatalf("Request-Id header was %s, expected %s", got, exp)
-			} else if got, exp := w.Header().Get("X-Request-Id"), parts[i]; got != exp {
-				t.Fatalf("X-Request-Id header was %s, expected %s", got, exp)
-			}
-		})
+	if err := h.SetRelabelRules(nil); err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", strings.NewReader("cpu,host=us value=2 10")))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if len(written) != 1 || written[0].String() != "cpu,host=us value=2 10" {
+		t.Fatalf("unexpected points: %v", written)
 	}
 }
 
-func TestThrottler_Handler(t *testing.T) {
-	t.Run("OK", func(t *testing.T) {
-		throttler := httpd.NewThrottler(2, 98)
+// Ensure the fields of points of a batch with the same series and timestamp
+// are merged when requested.
+func TestHandler_Write_MergeDuplicates(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		return &meta.DatabaseInfo{}
+	}
+	var written []string
+	h.PointsWriter.WritePointsFn = func(_, _ string, _ models.ConsistencyLevel, _ meta.User, points []models.Point) error {
+		written = written[:0]
+		for _, p := range points {
+			written = append(written, p.String())
+		}
+		return nil
+	}
 
-		// Send the total number of concurrent requests to the channel.
-		var concurrentN int32
-		concurrentCh := make(chan int)
+	body := "cpu,host=a user=1,system=2 10\nmem,host=a free=3 10\ncpu,host=a idle=4,system=5 10\ncpu,host=a user=6 20"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo&duplicates=merge", strings.NewReader(body)))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if exp := []string{
+		"cpu,host=a idle=4,system=5,user=1 10",
+		"mem,host=a free=3 10",
+		"cpu,host=a user=6 20",
+	}; !reflect.DeepEqual(written, exp) {
+		t.Fatalf("unexpected points: %v", written)
+	}
 
-		h := throttler.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			atomic.AddInt32(&concurrentN, 1)
-			concurrentCh <- int(atomic.LoadInt32(&concurrentN))
-			time.Sleep(1 * time.Millisecond)
-			atomic.AddInt32(&concurrentN, -1)
-		}))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", strings.NewReader(body)))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if len(written) != 4 {
+		t.Fatalf("unexpected points: %v", written)
+	}
 
-		// Execute requests concurrently.
-		const n = 100
-		for i := 0; i < n; i++ {
-			go func() { h.ServeHTTP(nil, nil) }()
-		}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo&duplicates=first", strings.NewReader(body)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+}
 
-		// Read the number of concurrent requests for every execution.
-		for i := 0; i < n; i++ {
-			if v := <-concurrentCh; v > 2 {
-				t.Fatalf("concurrent requests exceed maximum: %d", v)
-			}
+// Ensure written points are routed to the databases of the routing rules.
+func TestHandler_Write_Route(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		return &meta.DatabaseInfo{}
+	}
+	written := make(map[string][]string)
+	h.PointsWriter.WritePointsFn = func(db, rp string, _ models.ConsistencyLevel, _ meta.User, points []models.Point) error {
+		for _, p := range points {
+			written[db+"."+rp] = append(written[db+"."+rp], p.String())
 		}
-	})
-
-	t.Run("ErrTimeout", func(t *testing.T) {
-		throttler := httpd.NewThrottler(2, 1)
-		throttler.EnqueueTimeout = 1 * time.Millisecond
+		return nil
+	}
+	if err := h.SetRoutes([]routing.Rule{
+		{Measurement: "cpu", Tags: map[string]string{"env": "prod"}, Database: "prod_metrics", RetentionPolicy: "rp1"},
+	}); err != nil {
+		t.Fatal(err)
+	}
 
-		begin, end := make(chan struct{}), make(chan struct{})
-		h := throttler.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			begin <- struct{}{}
-			end <- struct{}{}
-		}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", strings.NewReader("cpu,env=prod value=1 10\ncpu,env=dev value=2 10\nmem,env=prod value=3 10")))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+	if exp := map[string][]string{
+		"prod_metrics.rp1": {"cpu,env=prod value=1 10"},
+		"foo.":             {"cpu,env=dev value=2 10", "mem,env=prod value=3 10"},
+	}; !reflect.DeepEqual(written, exp) {
+		t.Fatalf("unexpected writes: %v", written)
+	}
+}
 
-		// First two requests should execute immediately.
-		go func() { h.ServeHTTP(nil, nil) }()
-		go func() { h.ServeHTTP(nil, nil) }()
+// Ensure rejected points are written to their database in the background
+// and can be read back.
+func TestHandler_Write_DeadLetter(t *testing.T) {
+	h := NewHandler(false)
+	h.Config.DeadLetter.Enabled = true
+	h.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		return &meta.DatabaseInfo{}
+	}
+	var dead []models.Point
+	h.PointsWriter.WritePointsFn = func(db, _ string, _ models.ConsistencyLevel, _ meta.User, points []models.Point) error {
+		if db != "foo" {
+			t.Fatalf("unexpected db: %s", db)
+		}
+		if string(points[0].Name()) == httpd.DeadLetterMeasurement {
+			dead = append(dead, points...)
+			return nil
+		}
+		return tsdb.PartialWriteError{
+			Reason:  "field type conflict",
+			Dropped: 1,
+			Err:     tsdb.ErrFieldTypeConflict,
+			Points:  []tsdb.DroppedPoint{{Point: points[1], Reason: "field type conflict", Err: tsdb.ErrFieldTypeConflict}},
+		}
+	}
 
-		<-begin
-		<-begin
+	h.Open()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", strings.NewReader("cpu value=1 10\ncpu value=\ncpu value=\"a\" 10")))
+	// The rejected points are written once the writes queued are.
+	h.Close()
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if len(dead) != 2 {
+		t.Fatalf("unexpected dead-letter points: %v", dead)
+	}
 
-		// Third request should be enqueued but timeout.
-		w := httptest.NewRecorder()
-		h.ServeHTTP(w, nil)
-		if w.Code != http.StatusServiceUnavailable {
-			t.Fatalf("unexpected status code: %d", w.Code)
-		} else if body := w.Body.String(); body != "request throttled, exceeds timeout\n" {
-			t.Fatalf("unexpected response body: %q", body)
+	// The points rejected by a write are in a series of its own.
+	rid := w.Header().Get("X-Request-Id")
+	var got []string
+	for _, p := range dead {
+		fields, _ := p.Fields()
+		if id := p.Tags().GetString("request_id"); id != rid {
+			t.Fatalf("unexpected request id: got=%s want=%s", id, rid)
 		}
+		got = append(got, fmt.Sprintf("%s %s %v", p.Name(), p.Tags().GetString("code"), fields))
+	}
+	if exp := []string{
+		"rejected_points bad-request map[line:cpu value= reason:missing field value]",
+		"rejected_points field-type-conflict map[line:cpu value=\"a\" 10 reason:field type conflict]",
+	}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected dead-letter points:\n%s", strings.Join(got, "\n"))
+	} else if dead[0].Time().Equal(dead[1].Time()) {
+		t.Fatalf("unexpected times: %s", dead[0].Time())
+	}
 
-		// Allow 2 existing requests to complete.
+	h.StatementExecutor.ExecuteStatementFn = func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+		if exp := `SELECT code, line, reason, request_id, rp FROM rejected_points ORDER BY time DESC LIMIT 2`; stmt.String() != exp {
+			t.Fatalf("unexpected query: %s", stmt.String())
+		} else if ctx.Database != "foo" {
+			t.Fatalf("unexpected db: %s", ctx.Database)
+		}
+		return ctx.Send(&query.Result{Series: models.Rows{{
+			Name:    "rejected_points",
+			Columns: []string{"time", "code", "line", "reason", "request_id", "rp"},
+			Values: [][]interface{}{
+				{time.Unix(0, 20).UTC(), "field-type-conflict", `cpu value="a" 10`, "field type conflict", "r1", nil},
+				{time.Unix(0, 10).UTC(), "bad-request", "cpu value=", "missing field value", "r1", nil},
+			},
+		}}})
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/dead-letter?db=foo&limit=1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"points":[{"time":"1970-01-01T00:00:00.00000002Z","request_id":"r1","code":"field-type-conflict","reason":"field type conflict","line":"cpu value=\"a\" 10"}],"truncated":true}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+// Ensure rejected points are dropped rather than block writes when too many
+// are waiting to be written.
+func TestHandler_Write_DeadLetter_Full(t *testing.T) {
+	h := NewHandler(false)
+	h.Config.DeadLetter.Enabled = true
+	h.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		return &meta.DatabaseInfo{}
+	}
+	var n int
+	h.PointsWriter.WritePointsFn = func(db, _ string, _ models.ConsistencyLevel, _ meta.User, points []models.Point) error {
+		n += len(points)
+		return nil
+	}
+
+	// Nothing is written until the handler is opened.
+	for i := 0; i < 100; i++ {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", strings.NewReader("cpu value=")))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+		}
+	}
+	h.Open()
+	h.Close()
+
+	stats := h.Statistics(nil)[0].Values
+	if n != 64 {
+		t.Fatalf("unexpected points written: %d", n)
+	} else if v := stats["pointsDeadLettered"]; v != int64(64) {
+		t.Fatalf("unexpected points dead-lettered: %v", v)
+	} else if v := stats["deadLettersDropped"]; v != int64(36) {
+		t.Fatalf("unexpected points dropped: %v", v)
+	}
+}
+
+// Ensure the points written and queries executed on a database are counted
+// and reported by window.
+func TestHandler_Usage(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		return &meta.DatabaseInfo{}
+	}
+	h.PointsWriter.WritePointsFn = func(_, _ string, _ models.ConsistencyLevel, _ meta.User, _ []models.Point) error {
+		return nil
+	}
+	h.StatementExecutor.ExecuteStatementFn = func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+		return ctx.Send(&query.Result{})
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", strings.NewReader("cpu value=1 10\ncpu value=2 20")))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/query?db=foo&q=SELECT+*+FROM+cpu", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+
+	var found bool
+	for _, stat := range h.Statistics(nil) {
+		if stat.Name != "usage" {
+			continue
+		}
+		found = true
+		if !reflect.DeepEqual(stat.Tags, map[string]string{"database": "foo"}) {
+			t.Fatalf("unexpected tags: %v", stat.Tags)
+		} else if stat.Values["pointsWritten"] != int64(2) || stat.Values["queriesExecuted"] != int64(1) {
+			t.Fatalf("unexpected values: %v", stat.Values)
+		}
+	}
+	if !found {
+		t.Fatal("expected usage statistics")
+	}
+
+	var queries []string
+	h.StatementExecutor.ExecuteStatementFn = func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+		queries = append(queries, stmt.String())
+		if ctx.Database != "_internal" {
+			t.Fatalf("unexpected db: %s", ctx.Database)
+		}
+		if len(queries) == 1 {
+			return ctx.Send(&query.Result{Series: models.Rows{{
+				Name:    "usage",
+				Tags:    map[string]string{"database": "foo", "user": ""},
+				Columns: []string{"time", "pointsWritten", "queriesExecuted", "queryDurationNs"},
+				Values:  [][]interface{}{{time.Unix(0, 0).UTC(), int64(2), int64(1), int64(100)}},
+			}}})
+		}
+		return ctx.Send(&query.Result{Series: models.Rows{{
+			Name:    "shard",
+			Tags:    map[string]string{"database": "foo"},
+			Columns: []string{"time", "bytesStored"},
+			Values:  [][]interface{}{{time.Unix(0, 0).UTC(), int64(4096)}},
+		}}})
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/usage?db=foo&start=1970-01-01T00:00:00Z&end=1970-01-02T00:00:00Z&window=1d", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"usage":[{"time":"1970-01-01T00:00:00Z","user":"","database":"foo","pointsWritten":2,"queriesExecuted":1,"queryDurationNs":100}],"storage":[{"time":"1970-01-01T00:00:00Z","database":"foo","bytesStored":4096}]}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+	if len(queries) != 2 || !strings.Contains(queries[0], "non_negative_difference(pointsWritten)") || !strings.Contains(queries[0], `GROUP BY time(1d), "user", "database"`) {
+		t.Fatalf("unexpected queries: %v", queries)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/usage?window=0s", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// Ensure database templates are created, listed and instantiated with their
+// limits.
+func TestHandler_DatabaseTemplates(t *testing.T) {
+	h := NewHandler(false)
+
+	var created *meta.DatabaseTemplateInfo
+	h.MetaClient.CreateDatabaseTemplateFn = func(tmpl *meta.DatabaseTemplateInfo) error {
+		created = tmpl
+		return nil
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/templates", strings.NewReader(`{
+		"name": "tenant",
+		"retention_policies": [{"name": "raw", "duration": "7d", "replication": 2}],
+		"continuous_queries": [{"name": "cq", "query": "CREATE CONTINUOUS QUERY cq ON \"{database}\" BEGIN SELECT mean(value) INTO rollup FROM cpu GROUP BY time(1h) END"}],
+		"grants": [{"user": "{database}_*", "privilege": "READ"}],
+		"limits": {"future_write_limit": "1h", "past_write_limit": "30d"}
+	}`)))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if created == nil || created.Name != "tenant" || len(created.RetentionPolicies) != 1 || created.RetentionPolicies[0].Duration != 7*24*time.Hour {
+		t.Fatalf("unexpected template: %+v", created)
+	} else if exp := (meta.DatabaseTemplateLimits{FutureWriteLimit: time.Hour, PastWriteLimit: 30 * 24 * time.Hour}); created.Limits != exp {
+		t.Fatalf("unexpected limits: %+v", created.Limits)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/templates", strings.NewReader(`{"name": "tenant", "limits": {"past_write_limit": "a while"}}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if !strings.Contains(w.Body.String(), "past write limit") {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+
+	h.MetaClient.DatabaseTemplatesFn = func() []meta.DatabaseTemplateInfo {
+		return []meta.DatabaseTemplateInfo{*created}
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/templates", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if body := w.Body.String(); !strings.Contains(body, `"limits":{"future_write_limit":"1h","past_write_limit":"30d"}`) {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	h.MetaClient.InstantiateDatabaseTemplateFn = func(template, database string) (*meta.DatabaseInfo, error) {
+		if template != "tenant" {
+			return nil, meta.ErrDatabaseTemplateNotFound
+		} else if database != "acme" {
+			t.Fatalf("unexpected database: %s", database)
+		}
+		return &meta.DatabaseInfo{Name: database}, nil
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/templates/tenant/instantiate?db=acme", nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"database":"acme"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/templates/missing/instantiate?db=acme", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// Ensure the points written by other services are counted in the usage.
+func TestHandler_UsagePointsWriter(t *testing.T) {
+	h := NewHandler(false)
+	pw := h.UsagePointsWriter(&InputPointsWriter{})
+
+	points := []models.Point{
+		models.MustNewPoint("cpu", models.NewTags(nil), models.Fields{"value": 1.0}, time.Unix(0, 0)),
+		models.MustNewPoint("cpu", models.NewTags(nil), models.Fields{"value": 2.0}, time.Unix(1, 0)),
+	}
+	if err := pw.WritePointsPrivileged("foo", "", coordinator.ConsistencyLevelOne, points); err != nil {
+		t.Fatal(err)
+	} else if err := pw.WritePoints("foo", "", coordinator.ConsistencyLevelOne, &meta.UserInfo{Name: "bar"}, points[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]interface{})
+	for _, stat := range h.Statistics(nil) {
+		if stat.Name == "usage" {
+			got[stat.Tags["user"]] = stat.Values["pointsWritten"]
+		}
+	}
+	if exp := map[string]interface{}{"": int64(2), "bar": int64(1)}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected points written: got=%v exp=%v", got, exp)
+	}
+}
+
+// Ensure points can be written as protocol buffers in the precision of the write.
+func TestHandler_Write_Protobuf(t *testing.T) {
+	b, err := proto.Marshal(&wire.Points{Points: []*wire.Point{{
+		Measurement: "cpu",
+		Tags:        []*wire.Tag{{Key: "host", Value: "server01"}},
+		Fields:      []*wire.Field{{Key: "value", Value: &wire.Value{Type: wire.Value_FLOAT, FloatValue: 1.5}}},
+		Time:        1000,
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHandler(false)
+	h.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		return &meta.DatabaseInfo{}
+	}
+	var written []models.Point
+	h.PointsWriter.WritePointsFn = func(_, _ string, _ models.ConsistencyLevel, _ meta.User, points []models.Point) error {
+		written = points
+		return nil
+	}
+
+	req := MustNewRequest("POST", "/write?db=foo&precision=s", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+
+	if got, exp := len(written), 1; got != exp {
+		t.Fatalf("unexpected points written: got %d, exp %d", got, exp)
+	} else if got, exp := written[0].String(), "cpu,host=server01 value=1.5 1000000000000"; got != exp {
+		t.Fatalf("unexpected point: got %s, exp %s", got, exp)
+	}
+
+	// A body that is not a Points message is rejected.
+	req = MustNewRequest("POST", "/write?db=foo", strings.NewReader("cpu value=1"))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// Ensure X-Forwarded-For header writes the correct log message.
+func TestHandler_XForwardedFor(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(false)
+	h.CLFLogger = log.New(&buf, "", 0)
+
+	req := MustNewRequest("GET", "/query", nil)
+	req.Header.Set("X-Forwarded-For", "192.168.0.1")
+	req.RemoteAddr = "127.0.0.1"
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	parts := strings.Split(buf.String(), " ")
+	if parts[0] != "192.168.0.1,127.0.0.1" {
+		t.Errorf("unexpected host ip address: %s", parts[0])
+	}
+}
+
+func TestHandler_XRequestId(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(false)
+	h.CLFLogger = log.New(&buf, "", 0)
+
+	cases := []map[string]string{
+		{"X-Request-Id": "abc123", "Request-Id": ""},          // X-Request-Id is used.
+		{"X-REQUEST-ID": "cde", "Request-Id": ""},             // X-REQUEST-ID is used.
+		{"X-Request-Id": "", "Request-Id": "foobarzoo"},       // Request-Id is used.
+		{"X-Request-Id": "abc123", "Request-Id": "foobarzoo"}, // X-Request-Id takes precedence.
+		{"X-Request-Id": "", "Request-Id": ""},                // v1 UUID generated.
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprint(c), func(t *testing.T) {
+			buf.Reset()
+			req := MustNewRequest("GET", "/ping", nil)
+			req.RemoteAddr = "127.0.0.1"
+
+			// Set the relevant request ID headers
+			var allEmpty = true
+			for k, v := range c {
+				req.Header.Set(k, v)
+				if v != "" {
+					allEmpty = false
+				}
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			// Split up the HTTP log line. The request ID is currently located in
+			// index 12. If the log line gets changed in the future, this test
+			// will likely break and the index will need to be updated.
+			parts := strings.Split(buf.String(), " ")
+			i := 12
+
+			// If neither header is set then we expect a v1 UUID to be generated.
+			if allEmpty {
+				if got, exp := len(parts[i]), 36; got != exp {
+					t.Fatalf("got ID of length %d, expected one of length %d", got, exp)
+				}
+			} else if c["X-Request-Id"] != "" {
+				if got, exp := parts[i], c["X-Request-Id"]; got != exp {
+					t.Fatalf("got ID of %q, expected %q", got, exp)
+				}
+			} else if c["X-REQUEST-ID"] != "" {
+				if got, exp := parts[i], c["X-REQUEST-ID"]; got != exp {
+					t.Fatalf("got ID of %q, expected %q", got, exp)
+				}
+			} else {
+				if got, exp := parts[i], c["Request-Id"]; got != exp {
+					t.Fatalf("got ID of %q, expected %q", got, exp)
+				}
+			}
+
+			// Check response headers
+			if got, exp := w.Header().Get("Request-Id"), parts[i]; got != exp {
+				t.Fatalf("Request-Id header was %s, expected %s", got, exp)
+			} else if got, exp := w.Header().Get("X-Request-Id"), parts[i]; got != exp {
+				t.Fatalf("X-Request-Id header was %s, expected %s", got, exp)
+			}
+		})
+	}
+}
+
+func TestHandler_ReloadConfig(t *testing.T) {
+	h := NewHandler(false)
+
+	// Without a reloader the endpoint is unavailable.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/config/reload", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	var reloads int
+	h.ConfigReloader = configReloaderFunc(func() error {
+		reloads++
+		return nil
+	})
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/config/reload", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if reloads != 1 {
+		t.Fatalf("unexpected reload count: %d", reloads)
+	}
+
+	h.ConfigReloader = configReloaderFunc(func() error {
+		return errors.New("invalid config: bad value")
+	})
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/config/reload", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"error":"invalid config: bad value","code":"bad-request"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestThrottler_Handler(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		throttler := httpd.NewThrottler(2, 98)
+
+		// Send the total number of concurrent requests to the channel.
+		var concurrentN int32
+		concurrentCh := make(chan int)
+
+		h := throttler.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&concurrentN, 1)
+			concurrentCh <- int(atomic.LoadInt32(&concurrentN))
+			time.Sleep(1 * time.Millisecond)
+			atomic.AddInt32(&concurrentN, -1)
+		}))
+
+		// Execute requests concurrently.
+		const n = 100
+		for i := 0; i < n; i++ {
+			go func() { h.ServeHTTP(nil, nil) }()
+		}
+
+		// Read the number of concurrent requests for every execution.
+		for i := 0; i < n; i++ {
+			if v := <-concurrentCh; v > 2 {
+				t.Fatalf("concurrent requests exceed maximum: %d", v)
+			}
+		}
+	})
+
+	t.Run("ErrTimeout", func(t *testing.T) {
+		throttler := httpd.NewThrottler(2, 1)
+		throttler.EnqueueTimeout = 1 * time.Millisecond
+
+		begin, end := make(chan struct{}), make(chan struct{})
+		h := throttler.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			begin <- struct{}{}
+			end <- struct{}{}
+		}))
+
+		// First two requests should execute immediately.
+		go func() { h.ServeHTTP(nil, nil) }()
+		go func() { h.ServeHTTP(nil, nil) }()
+
+		<-begin
+		<-begin
+
+		// Third request should be enqueued but timeout.
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, nil)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("unexpected status code: %d", w.Code)
+		} else if body := w.Body.String(); body != "request throttled, exceeds timeout\n" {
+			t.Fatalf("unexpected response body: %q", body)
+		}
+
+		// Allow 2 existing requests to complete.
 		<-end
 		<-end
 	})
 
-	t.Run("ErrFull", func(t *testing.T) {
-		delay := 100 * time.Millisecond
-		if os.Getenv("CI") != "" {
-			delay = 2 * time.Second
+	t.Run("ErrFull", func(t *testing.T) {
+		delay := 100 * time.Millisecond
+		if os.Getenv("CI") != "" {
+			delay = 2 * time.Second
+		}
+
+		throttler := httpd.NewThrottler(2, 1)
+
+		resp := make(chan struct{})
+		h := throttler.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			resp <- struct{}{}
+		}))
+
+		// First two requests should execute immediately and third should be queued.
+		go func() { h.ServeHTTP(nil, nil) }()
+		go func() { h.ServeHTTP(nil, nil) }()
+		go func() { h.ServeHTTP(nil, nil) }()
+		time.Sleep(delay)
+
+		// Fourth request should fail when trying to enqueue.
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, nil)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("unexpected status code: %d", w.Code)
+		} else if body := w.Body.String(); body != "request throttled, queue full\n" {
+			t.Fatalf("unexpected response body: %q", body)
+		}
+
+		// Allow 3 existing requests to complete.
+		<-resp
+		<-resp
+		<-resp
+	})
+}
+
+// NewHandler represents a test wrapper for httpd.Handler.
+type Handler struct {
+	*httpd.Handler
+	MetaClient        *internal.MetaClientMock
+	StatementExecutor HandlerStatementExecutor
+	QueryAuthorizer   HandlerQueryAuthorizer
+	PointsWriter      HandlerPointsWriter
+	Store             *internal.StorageStoreMock
+	Controller        *internal.FluxControllerMock
+}
+
+type configOption func(c *httpd.Config)
+
+func WithAuthentication() configOption {
+	return func(c *httpd.Config) {
+		c.AuthEnabled = true
+		c.SharedSecret = "super secret key"
+	}
+}
+
+func WithFlux() configOption {
+	return func(c *httpd.Config) {
+		c.FluxEnabled = true
+	}
+}
+
+func WithNoLog() configOption {
+	return func(c *httpd.Config) {
+		c.LogEnabled = false
+	}
+}
+
+// NewHandlerConfig returns a new instance of httpd.Config with
+// authentication configured.
+func NewHandlerConfig(opts ...configOption) httpd.Config {
+	config := httpd.NewConfig()
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// NewHandler returns a new instance of Handler.
+func NewHandler(requireAuthentication bool) *Handler {
+	var opts []configOption
+	if requireAuthentication {
+		opts = append(opts, WithAuthentication())
+	}
+
+	return NewHandlerWithConfig(NewHandlerConfig(opts...))
+}
+
+func NewHandlerWithConfig(config httpd.Config) *Handler {
+	h := &Handler{
+		Handler: httpd.NewHandler(config),
+	}
+
+	h.MetaClient = &internal.MetaClientMock{}
+	h.Store = internal.NewStorageStoreMock()
+	h.Controller = internal.NewFluxControllerMock()
+
+	h.Handler.MetaClient = h.MetaClient
+	h.Handler.Store = h.Store
+	h.Handler.QueryExecutor = query.NewExecutor()
+	h.Handler.QueryExecutor.StatementExecutor = &h.StatementExecutor
+	h.Handler.QueryAuthorizer = &h.QueryAuthorizer
+	h.Handler.PointsWriter = &h.PointsWriter
+	h.Handler.Version = "0.0.0"
+	h.Handler.Controller = h.Controller
+
+	if testing.Verbose() {
+		l := logger.New(os.Stdout)
+		h.Handler.Logger = l
+	}
+
+	return h
+}
+
+// auditRecorderFunc records audit events with a function.
+type auditRecorderFunc func(e audit.Event)
+
+func (fn auditRecorderFunc) Record(e audit.Event) { fn(e) }
+
+// Ensure the handler shows and changes the runtime resource settings.
+func TestHandler_Resources(t *testing.T) {
+	h := NewHandler(false)
+
+	// Without the service the endpoints are unavailable.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/resources", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	r := &fakeResources{state: resources.State{Settings: resources.NewConfig(), GOMAXPROCS: 4}}
+	r.state.Settings.GOGC = 200
+	h.Resources = r
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/resources", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"settings":{"gogc":200,"memory-limit":0,"ballast-size":0,"numa-node":-1,"cpus":"","max-procs":0,"compaction-workers":0,"query-workers":0},"topology":{"cpus":0,"nodes":null},"pinned-cpus":null,"gomaxprocs":4}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	// Settings missing from the body are unchanged.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("PUT", "/api/v1/resources", strings.NewReader(`{"query-workers":8}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if c := r.state.Settings; c.GOGC != 200 || c.QueryWorkers != 8 {
+		t.Fatalf("unexpected settings: %+v", c)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("PUT", "/api/v1/resources", strings.NewReader(`{"max-procs":-1}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"error":"max-procs cannot be negative","code":"bad-request"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+// Ensure a listener restricted to capabilities only serves their routes.
+func TestHandler_Restrict(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		return &meta.DatabaseInfo{}
+	}
+	h.PointsWriter.WritePointsFn = func(db, rp string, _ models.ConsistencyLevel, _ meta.User, points []models.Point) error {
+		return nil
+	}
+	restricted := h.Handler.Restrict(httpd.CapabilityWrite)
+
+	for _, tt := range []struct {
+		method string
+		url    string
+		served bool
+	}{
+		{method: "GET", url: "/ping", served: true},
+		{method: "POST", url: "/write?db=foo", served: true},
+		{method: "GET", url: "/query?db=foo&q=SELECT+*+FROM+bar", served: false},
+		{method: "GET", url: "/api/v1/modes", served: false},
+		{method: "GET", url: "/debug/vars", served: false},
+	} {
+		w := httptest.NewRecorder()
+		restricted.ServeHTTP(w, MustNewRequest(tt.method, tt.url, strings.NewReader("cpu value=1")))
+		if served := w.Code != http.StatusForbidden; served != tt.served {
+			t.Fatalf("%s %s: unexpected status: %d", tt.method, tt.url, w.Code)
+		}
+	}
+
+	// The handler itself serves every route.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/debug/vars", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// Ensure request bodies larger than max-request-body-size are refused.
+func TestHandler_MaxRequestBodySize(t *testing.T) {
+	c := NewHandlerConfig()
+	c.MaxRequestBodySize = 16
+	h := NewHandlerWithConfig(c)
+	h.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		return &meta.DatabaseInfo{}
+	}
+	h.PointsWriter.WritePointsFn = func(db, rp string, _ models.ConsistencyLevel, _ meta.User, points []models.Point) error {
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", strings.NewReader("cpu value=1")))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", strings.NewReader("cpu value=1 1000000000")))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// Ensure the CORS headers are only sent to the allowed origins.
+func TestHandler_CORS(t *testing.T) {
+	c := NewHandlerConfig()
+	c.CORS.AllowedOrigins = []string{"https://app.example.com", "https://*.example.org"}
+	c.CORS.AllowCredentials = true
+	c.CORS.MaxAge = toml.Duration(10 * time.Minute)
+	h := NewHandlerWithConfig(c)
+
+	for _, tt := range []struct {
+		origin  string
+		allowed bool
+	}{
+		{origin: "https://app.example.com", allowed: true},
+		{origin: "https://ui.example.org", allowed: true},
+		{origin: "http://app.example.com", allowed: false},
+		{origin: "https://example.org", allowed: false},
+		{origin: "https://evil.com", allowed: false},
+	} {
+		// Preflight requests are answered for every route.
+		req := MustNewRequest("OPTIONS", "/api/v1/modes", nil)
+		req.Header.Set("Origin", tt.origin)
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("%s: unexpected status: %d", tt.origin, w.Code)
+		} else if got := w.Header().Get("Access-Control-Allow-Origin"); (got == tt.origin) != tt.allowed {
+			t.Fatalf("%s: unexpected allowed origin: %q", tt.origin, got)
+		} else if tt.allowed && w.Header().Get("Access-Control-Max-Age") != "600" {
+			t.Fatalf("%s: unexpected max age: %q", tt.origin, w.Header().Get("Access-Control-Max-Age"))
+		}
+
+		req = MustNewRequest("GET", "/ping", nil)
+		req.Header.Set("Origin", tt.origin)
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); (got == "true") != tt.allowed {
+			t.Fatalf("%s: unexpected allow credentials: %q", tt.origin, got)
+		} else if w.Header().Get("Vary") != "Origin" {
+			t.Fatalf("%s: unexpected vary: %q", tt.origin, w.Header().Get("Vary"))
+		}
+	}
+}
+
+// Ensure the standby is inspected and promoted.
+func TestHandler_Standby(t *testing.T) {
+	h := NewHandler(false)
+
+	// Without a standby the endpoints are unavailable.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/standby", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	s := &fakeStandby{status: standby.Status{Primary: "primary:8088", Shards: 2}}
+	h.Standby = s
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/standby", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"primary":"primary:8088","promoted":false,"shards":2}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/standby/promote", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"primary":"primary:8088","promoted":true,"shards":2}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+// Ensure agents redeem bootstrap tokens for client certificates that allow
+// writes to the databases of the token.
+// Ensure the shard admin endpoints list, inspect and truncate shards.
+func TestHandler_Shards(t *testing.T) {
+	h := NewHandler(false)
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.MetaClient.DatabasesFn = func() ([]meta.DatabaseInfo, error) {
+		return []meta.DatabaseInfo{{
+			Name: "db0",
+			RetentionPolicies: []meta.RetentionPolicyInfo{{
+				Name:     "rp0",
+				Duration: 24 * time.Hour,
+				ShardGroups: []meta.ShardGroupInfo{{
+					ID:        1,
+					StartTime: start,
+					EndTime:   start.Add(time.Hour),
+					Shards: []meta.ShardInfo{
+						{ID: 1, Owners: []meta.ShardOwner{{NodeID: 1}}},
+						{ID: 2, Owners: []meta.ShardOwner{{NodeID: 2}}},
+					},
+				}},
+			}},
+		}}, nil
+	}
+	h.Handler.Shards = &shardStore{
+		sizes: map[uint64]int64{1: 1024},
+		files: map[uint64][]tsdb.ShardFile{
+			1: {{Path: "/data/db0/rp0/1/000000001-000000002.tsm", Size: 1024, MinTime: start.UnixNano(), MaxTime: start.Add(time.Minute).UnixNano(), Generation: 1, Sequence: 2}},
+		},
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/shards", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+	var list struct {
+		Shards []struct {
+			ID     uint64   `json:"id"`
+			Owners []uint64 `json:"owners"`
+			Size   *int64   `json:"size"`
+		} `json:"shards"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	} else if len(list.Shards) != 2 {
+		t.Fatalf("unexpected shards: %s", w.Body.String())
+	} else if s := list.Shards[0]; s.ID != 1 || s.Size == nil || *s.Size != 1024 || !reflect.DeepEqual(s.Owners, []uint64{1}) {
+		t.Fatalf("unexpected shard: %s", w.Body.String())
+	} else if s := list.Shards[1]; s.ID != 2 || s.Size != nil {
+		t.Fatalf("unexpected shard: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/shards/1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if !strings.Contains(w.Body.String(), `"path":"000000001-000000002.tsm"`) {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+
+	// Shard 2 is stored on another node.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/shards/2", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+
+	// Only the shard groups of a database are truncated.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/shards/truncate", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+
+	var truncated bool
+	h.MetaClient.TruncateDatabaseShardGroupsFn = func(database, policy string, t time.Time) error {
+		if database == "nodb" {
+			return freetsdb.ErrDatabaseNotFound(database)
+		} else if database != "db0" || policy != "rp0" {
+			return fmt.Errorf("unexpected shard groups: %s.%s", database, policy)
+		}
+		truncated = true
+		return nil
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/shards/truncate?db=db0&rp=rp0", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if !truncated {
+		t.Fatal("expected shard groups to be truncated")
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/shards/truncate?db=nodb", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// Ensure the modes of nodes and databases are listed, set, and enforced on
+// writes.
+func TestHandler_Modes(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaClient.DataNodesFn = func() ([]meta.NodeInfo, error) {
+		return []meta.NodeInfo{{ID: 1, ReadOnly: true, ReadOnlyReason: "disk full"}, {ID: 2, Maintenance: true}}, nil
+	}
+	h.MetaClient.DatabasesFn = func() ([]meta.DatabaseInfo, error) {
+		return []meta.DatabaseInfo{{Name: "db0", Frozen: true}}, nil
+	}
+	h.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		if name != "db0" {
+			return nil
+		}
+		return &meta.DatabaseInfo{Name: name}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/modes", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if exp := `{"nodes":[{"id":1,"read_only":true,"reason":"disk full","maintenance":false},{"id":2,"read_only":false,"maintenance":true}],"databases":[{"name":"db0","frozen":true}]}`; strings.TrimSpace(w.Body.String()) != exp {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+
+	var node meta.NodeInfo
+	h.MetaClient.SetDataNodeModeFn = func(id uint64, readOnly bool, reason string, maintenance bool) error {
+		if id != 1 {
+			return meta.ErrNodeNotFound
+		}
+		node = meta.NodeInfo{ID: id, ReadOnly: readOnly, ReadOnlyReason: reason, Maintenance: maintenance}
+		return nil
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("PUT", "/api/v1/nodes/1/mode", strings.NewReader(`{"read_only":true,"reason":"migration","maintenance":true}`)))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if exp := (meta.NodeInfo{ID: 1, ReadOnly: true, ReadOnlyReason: "migration", Maintenance: true}); !reflect.DeepEqual(node, exp) {
+		t.Fatalf("unexpected mode: %+v", node)
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("PUT", "/api/v1/nodes/3/mode", strings.NewReader(`{}`)))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+
+	var frozen bool
+	h.MetaClient.SetDatabaseFrozenFn = func(name string, f bool) error {
+		frozen = f
+		return nil
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("PUT", "/api/v1/databases/db0/mode", strings.NewReader(`{"frozen":true}`)))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if !frozen {
+		t.Fatal("expected database to be frozen")
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("PUT", "/api/v1/databases/db1/mode", strings.NewReader(`{"frozen":true}`)))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+
+	// Writes rejected by the modes.
+	for _, tt := range []struct {
+		err  error
+		code int
+	}{
+		{err: coordinator.ReadOnlyError{Reason: "disk full"}, code: http.StatusServiceUnavailable},
+		{err: coordinator.ErrDatabaseFrozen, code: http.StatusForbidden},
+	} {
+		h.PointsWriter.WritePointsFn = func(_, _ string, _ models.ConsistencyLevel, _ meta.User, _ []models.Point) error {
+			return tt.err
+		}
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, MustNewRequest("POST", "/write?db=db0", strings.NewReader(`foo n=1`)))
+		if w.Code != tt.code {
+			t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+		} else if !strings.Contains(w.Body.String(), tt.err.Error()) {
+			t.Fatalf("unexpected body: %s", w.Body.String())
+		}
+	}
+}
+
+// Ensure the labels of nodes and placements of databases are listed and set.
+func TestHandler_Placement(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaClient.DataNodesFn = func() ([]meta.NodeInfo, error) {
+		return []meta.NodeInfo{{ID: 1, Labels: map[string]string{"zone": "a"}}, {ID: 2}}, nil
+	}
+	h.MetaClient.DatabasesFn = func() ([]meta.DatabaseInfo, error) {
+		return []meta.DatabaseInfo{{Name: "db0", Placement: meta.PlacementInfo{Require: map[string]string{"disk": "ssd"}}}}, nil
+	}
+	h.MetaClient.DatabaseFn = func(name string) *meta.DatabaseInfo {
+		if name != "db0" {
+			return nil
+		}
+		return &meta.DatabaseInfo{Name: name}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/placement", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if exp := `{"nodes":[{"id":1,"labels":{"zone":"a"}},{"id":2,"labels":{}}],"databases":[{"name":"db0","require":{"disk":"ssd"}}]}`; strings.TrimSpace(w.Body.String()) != exp {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+
+	var placement meta.PlacementInfo
+	h.MetaClient.SetDatabasePlacementFn = func(name string, p meta.PlacementInfo) error {
+		placement = p
+		return nil
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("PUT", "/api/v1/databases/db0/placement", strings.NewReader(`{"require":{"disk":"ssd"},"spread_by":["rack"]}`)))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if exp := (meta.PlacementInfo{Require: map[string]string{"disk": "ssd"}, SpreadBy: []string{"rack"}}); !reflect.DeepEqual(placement, exp) {
+		t.Fatalf("unexpected placement: %+v", placement)
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("PUT", "/api/v1/databases/db1/placement", strings.NewReader(`{}`)))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// Ensure the web UI is only served when enabled.
+func TestHandler_UI(t *testing.T) {
+	h := NewHandler(true)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/ui", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	config := NewHandlerConfig(WithAuthentication())
+	config.UIEnabled = true
+	h = NewHandlerWithConfig(config)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/ui", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Fatalf("unexpected content type: %s", ct)
+	} else if !strings.Contains(w.Body.String(), "<title>FreeTSDB</title>") {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure sessions started with /login authenticate later requests.
+func TestHandler_Session(t *testing.T) {
+	h := NewHandler(true)
+	user := &meta.UserInfo{Name: "user1", Admin: true}
+	sessions := make(map[string]string)
+
+	h.MetaClient.AdminUserExistsFn = func() bool { return true }
+	h.MetaClient.AuthenticateFn = func(u, p string) (meta.User, error) {
+		if u != "user1" || p != "abcd" {
+			return nil, meta.ErrAuthenticate
+		}
+		return user, nil
+	}
+	h.MetaClient.CreateSessionFn = func(username string, lifetime time.Duration) (string, *meta.SessionInfo, error) {
+		if lifetime != httpd.DefaultSessionLifetime {
+			t.Fatalf("unexpected lifetime: %s", lifetime)
+		}
+		sessions[meta.SessionID("token1")] = username
+		return "token1", &meta.SessionInfo{ID: meta.SessionID("token1"), Username: username, ExpiresAt: time.Now().Add(lifetime)}, nil
+	}
+	h.MetaClient.AuthenticateSessionFn = func(token string) (meta.User, error) {
+		if _, ok := sessions[meta.SessionID(token)]; !ok {
+			return nil, meta.ErrAuthenticate
+		}
+		return user, nil
+	}
+	h.MetaClient.DropSessionFn = func(id string) error {
+		if _, ok := sessions[id]; !ok {
+			return meta.ErrSessionNotFound
+		}
+		delete(sessions, id)
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	r := MustNewRequest("POST", "/login", nil)
+	r.SetBasicAuth("user1", "abcd")
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	} else if resp.Token != "token1" {
+		t.Fatalf("unexpected token: %s", resp.Token)
+	}
+
+	w = httptest.NewRecorder()
+	r = MustNewRequest("POST", "/logout", nil)
+	r.Header.Set("Authorization", "Session "+resp.Token)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+
+	// The token no longer authenticates once the session ended.
+	w = httptest.NewRecorder()
+	r = MustNewRequest("POST", "/logout", nil)
+	r.Header.Set("Authorization", "Session "+resp.Token)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// Ensure users can change an expired password.
+func TestHandler_Password(t *testing.T) {
+	h := NewHandler(true)
+	h.MetaClient.AdminUserExistsFn = func() bool { return true }
+	h.MetaClient.ChangePasswordFn = func(name, password, newPassword string) error {
+		if name != "user1" || password != "expired" {
+			return meta.ErrAuthenticate
+		} else if newPassword == "expired" {
+			return meta.ErrPasswordReused
+		}
+		return nil
+	}
+
+	for _, tt := range []struct {
+		password, newPassword string
+		code                  int
+	}{
+		{password: "expired", newPassword: "new", code: http.StatusNoContent},
+		{password: "wrong", newPassword: "new", code: http.StatusUnauthorized},
+		{password: "expired", newPassword: "expired", code: http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		r := MustNewRequest("POST", "/api/v1/password", strings.NewReader(`{"password":"`+tt.newPassword+`"}`))
+		r.SetBasicAuth("user1", tt.password)
+		h.ServeHTTP(w, r)
+		if w.Code != tt.code {
+			t.Fatalf("%s: unexpected status: %d: %s", tt.password, w.Code, w.Body.String())
+		}
+	}
+}
+
+// Ensure a session token cannot start another session.
+func TestHandler_Login_Session(t *testing.T) {
+	h := NewHandler(true)
+	h.MetaClient.AdminUserExistsFn = func() bool { return true }
+	h.MetaClient.AuthenticateSessionFn = func(token string) (meta.User, error) {
+		return &meta.UserInfo{Name: "user1"}, nil
+	}
+	h.MetaClient.CreateSessionFn = func(username string, lifetime time.Duration) (string, *meta.SessionInfo, error) {
+		t.Fatal("unexpected session")
+		return "", nil, nil
+	}
+
+	w := httptest.NewRecorder()
+	r := MustNewRequest("POST", "/login", nil)
+	r.Header.Set("Authorization", "Session token1")
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// Ensure clients failing to log in too often are locked out of the routes
+// accepting a password.
+func TestHandler_Login_Lockout(t *testing.T) {
+	c := NewHandlerConfig(WithAuthentication())
+	c.LoginMaxFailures = 2
+	h := NewHandlerWithConfig(c)
+	h.MetaClient.AdminUserExistsFn = func() bool { return true }
+	h.MetaClient.AuthenticateFn = func(u, p string) (meta.User, error) {
+		if p != "abcd" {
+			return nil, meta.ErrAuthenticate
+		}
+		return &meta.UserInfo{Name: u}, nil
+	}
+	h.MetaClient.CreateSessionFn = func(username string, lifetime time.Duration) (string, *meta.SessionInfo, error) {
+		return "token1", &meta.SessionInfo{ID: meta.SessionID("token1"), Username: username}, nil
+	}
+	h.MetaClient.ChangePasswordFn = func(name, password, newPassword string) error {
+		return nil
+	}
+
+	login := func(addr, path, password string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := MustNewRequest("POST", path, strings.NewReader(`{"password":"new"}`))
+		r.RemoteAddr = addr
+		r.SetBasicAuth("user1", password)
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := login("10.0.0.1:1000", "/login", "wrong"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	// The client is locked out even with the right password, on both routes.
+	for _, path := range []string{"/login", "/api/v1/password"} {
+		if w := login("10.0.0.1:1001", path, "abcd"); w.Code != http.StatusTooManyRequests {
+			t.Fatalf("%s: unexpected status: %d: %s", path, w.Code, w.Body.String())
+		} else if w.Header().Get("Retry-After") != "60" {
+			t.Fatalf("%s: unexpected Retry-After: %q", path, w.Header().Get("Retry-After"))
+		}
+	}
+
+	// Other clients are not.
+	if w := login("10.0.0.2:1000", "/login", "abcd"); w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_Enrollment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpd-enrollment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := NewHandlerConfig(WithAuthentication())
+	c.Enrollment.Enabled = true
+	c.Enrollment.CACertificate, c.Enrollment.CAPrivateKey = MustWriteCA(t, dir)
+	h := NewHandlerWithConfig(c)
+	h.MetaClient.AdminUserExistsFn = func() bool { return false }
+
+	// Without the CA the endpoints are unavailable.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/enroll", strings.NewReader(`{}`)))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	if h.Enrollment, err = httpd.NewEnrollment(c.Enrollment, newEnrollmentMetaClient()); err != nil {
+		t.Fatal(err)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/enrollment/tokens", strings.NewReader(`{"databases":["telegraf"],"lifetime":"10m"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status without a name: %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/enrollment/tokens", strings.NewReader(`{"name":"agent-1","databases":["telegraf"],"lifetime":"10m"}`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+	var token struct {
+		Token   string    `json:"token"`
+		Expires time.Time `json:"expires"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &token); err != nil {
+		t.Fatal(err)
+	} else if token.Token == "" || time.Until(token.Expires) > 10*time.Minute {
+		t.Fatalf("unexpected token: %+v", token)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "admin"}}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(map[string]string{
+		"token": token.Token,
+		"csr":   string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})),
+	})
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/enroll", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Certificate   string `json:"certificate"`
+		CACertificate string `json:"ca-certificate"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode([]byte(resp.Certificate))
+	if block == nil {
+		t.Fatalf("unexpected certificate: %s", resp.Certificate)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(resp.CACertificate)) {
+		t.Fatalf("unexpected ca certificate: %s", resp.CACertificate)
+	}
+	chains, err := cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	if err != nil {
+		t.Fatal(err)
+	} else if cert.Subject.CommonName != "agent-1" {
+		// The agent is named by the token, not by its request.
+		t.Fatalf("unexpected common name: %s", cert.Subject.CommonName)
+	}
+
+	// The token can only be redeemed once.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/enroll", bytes.NewReader(body)))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	// The certificate authenticates writes to its databases only.
+	h.MetaClient.AdminUserExistsFn = func() bool { return true }
+	h.PointsWriter.WritePointsFn = func(db, _ string, _ models.ConsistencyLevel, user meta.User, _ []models.Point) error {
+		if db != "telegraf" || user.ID() != "agent-1" {
+			t.Fatalf("unexpected write to %s by %s", db, user.ID())
+		}
+		return nil
+	}
+	for db, code := range map[string]int{"telegraf": http.StatusNoContent, "other": http.StatusForbidden} {
+		req := MustNewRequest("POST", "/write?db="+db, strings.NewReader("cpu value=1"))
+		req.TLS = &tls.ConnectionState{VerifiedChains: chains}
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != code {
+			t.Fatalf("unexpected status writing to %s: %d: %s", db, w.Code, w.Body.String())
 		}
+	}
 
-		throttler := httpd.NewThrottler(2, 1)
+	// A revoked certificate no longer authenticates.
+	h.MetaClient.AdminUserExistsFn = func() bool { return false }
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/api/v1/enrollment/agents", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if !strings.Contains(w.Body.String(), `"name":"agent-1"`) || !strings.Contains(w.Body.String(), `"revoked":false`) {
+		t.Fatalf("unexpected agents: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("DELETE", "/api/v1/enrollment/agents/agent-1", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("DELETE", "/api/v1/enrollment/agents/agent-2", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
 
-		resp := make(chan struct{})
-		h := throttler.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			resp <- struct{}{}
-		}))
+	h.MetaClient.AdminUserExistsFn = func() bool { return true }
+	req := MustNewRequest("POST", "/write?db=telegraf", strings.NewReader("cpu value=1"))
+	req.TLS = &tls.ConnectionState{VerifiedChains: chains}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+}
 
-		// First two requests should execute immediately and third should be queued.
-		go func() { h.ServeHTTP(nil, nil) }()
-		go func() { h.ServeHTTP(nil, nil) }()
-		go func() { h.ServeHTTP(nil, nil) }()
-		time.Sleep(delay)
+// enrollmentMetaClient stores the enrollment of agents in memory.
+type enrollmentMetaClient struct {
+	mu   sync.Mutex
+	data meta.Data
+}
 
-		// Fourth request should fail when trying to enqueue.
-		w := httptest.NewRecorder()
-		h.ServeHTTP(w, nil)
-		if w.Code != http.StatusServiceUnavailable {
-			t.Fatalf("unexpected status code: %d", w.Code)
-		} else if body := w.Body.String(); body != "request throttled, queue full\n" {
-			t.Fatalf("unexpected response body: %q", body)
-		}
+func newEnrollmentMetaClient() *enrollmentMetaClient {
+	return &enrollmentMetaClient{}
+}
 
-		// Allow 3 existing requests to complete.
-		<-resp
-		<-resp
-		<-resp
-	})
+func enrollmentTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
-// NewHandler represents a test wrapper for httpd.Handler.
-type Handler struct {
-	*httpd.Handler
-	MetaClient        *internal.MetaClientMock
-	StatementExecutor HandlerStatementExecutor
-	QueryAuthorizer   HandlerQueryAuthorizer
-	PointsWriter      HandlerPointsWriter
-	Store             *internal.StorageStoreMock
-	Controller        *internal.FluxControllerMock
+func (c *enrollmentMetaClient) CreateEnrollmentToken(name string, databases []string, lifetime time.Duration) (string, *meta.EnrollmentTokenInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	token := fmt.Sprintf("token-%d", len(c.data.EnrollmentTokens)+len(c.data.Agents))
+	ti := meta.EnrollmentTokenInfo{
+		Hash:      enrollmentTokenHash(token),
+		Name:      name,
+		Databases: databases,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(lifetime),
+	}
+	if err := c.data.CreateEnrollmentToken(ti); err != nil {
+		return "", nil, err
+	}
+	return token, &ti, nil
 }
 
-type configOption func(c *httpd.Config)
+func (c *enrollmentMetaClient) EnrollmentToken(token string) *meta.EnrollmentTokenInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data.EnrollmentToken(enrollmentTokenHash(token))
+}
 
-func WithAuthentication() configOption {
-	return func(c *httpd.Config) {
-		c.AuthEnabled = true
-		c.SharedSecret = "super secret key"
-	}
+func (c *enrollmentMetaClient) RedeemEnrollmentToken(token, serial string, expiresAt time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data.RedeemEnrollmentToken(enrollmentTokenHash(token), serial, time.Now(), expiresAt)
 }
 
-func WithFlux() configOption {
-	return func(c *httpd.Config) {
-		c.FluxEnabled = true
-	}
+func (c *enrollmentMetaClient) Agents() []meta.AgentInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data.CloneAgents()
 }
 
-func WithNoLog() configOption {
-	return func(c *httpd.Config) {
-		c.LogEnabled = false
-	}
+func (c *enrollmentMetaClient) Agent(serial string) *meta.AgentInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data.Agent(serial)
 }
 
-// NewHandlerConfig returns a new instance of httpd.Config with
-// authentication configured.
-func NewHandlerConfig(opts ...configOption) httpd.Config {
-	config := httpd.NewConfig()
-	for _, opt := range opts {
-		opt(&config)
-	}
-	return config
+func (c *enrollmentMetaClient) RevokeAgent(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data.RevokeAgent(name)
 }
 
-// NewHandler returns a new instance of Handler.
-func NewHandler(requireAuthentication bool) *Handler {
-	var opts []configOption
-	if requireAuthentication {
-		opts = append(opts, WithAuthentication())
+// MustWriteCA writes a self-signed CA certificate and its key to dir and
+// returns their paths.
+func MustWriteCA(t *testing.T, dir string) (certPath, keyPath string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "freetsdb enrollment"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	return NewHandlerWithConfig(NewHandlerConfig(opts...))
+	certPath, keyPath = filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca.key")
+	if err := ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	} else if err := ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
 }
 
-func NewHandlerWithConfig(config httpd.Config) *Handler {
-	h := &Handler{
-		Handler: httpd.NewHandler(config),
+// tagValuesByPrefixFunc returns tag values with a function.
+type tagValuesByPrefixFunc func(auth query.Authorizer, database string, name, key, prefix []byte, limit int) ([]string, error)
+
+func (fn tagValuesByPrefixFunc) TagValuesByPrefix(auth query.Authorizer, database string, name, key, prefix []byte, limit int) ([]string, error) {
+	return fn(auth, database, name, key, prefix, limit)
+}
+
+type indexEpochFunc func(database string) (uint64, time.Time, error)
+
+func (fn indexEpochFunc) IndexEpoch(database string) (uint64, time.Time, error) {
+	return fn(database)
+}
+
+// shardStore serves the sizes and files of the shards stored on a node.
+type shardStore struct {
+	sizes map[uint64]int64
+	files map[uint64][]tsdb.ShardFile
+}
+
+func (s *shardStore) ShardDiskSize(id uint64) (int64, error) {
+	if n, ok := s.sizes[id]; ok {
+		return n, nil
 	}
+	return 0, tsdb.ErrShardNotFound
+}
 
-	h.MetaClient = &internal.MetaClientMock{}
-	h.Store = internal.NewStorageStoreMock()
-	h.Controller = internal.NewFluxControllerMock()
+func (s *shardStore) ShardFiles(id uint64) ([]tsdb.ShardFile, error) {
+	if files, ok := s.files[id]; ok {
+		return files, nil
+	}
+	return nil, tsdb.ErrShardNotFound
+}
 
-	h.Handler.MetaClient = h.MetaClient
-	h.Handler.Store = h.Store
-	h.Handler.QueryExecutor = query.NewExecutor()
-	h.Handler.QueryExecutor.StatementExecutor = &h.StatementExecutor
-	h.Handler.QueryAuthorizer = &h.QueryAuthorizer
-	h.Handler.PointsWriter = &h.PointsWriter
-	h.Handler.Version = "0.0.0"
-	h.Handler.Controller = h.Controller
+// configReloaderFunc reloads the configuration with a function.
+type configReloaderFunc func() error
 
-	if testing.Verbose() {
-		l := logger.New(os.Stdout)
-		h.Handler.Logger = l
+func (fn configReloaderFunc) ReloadConfig() error { return fn() }
+
+// fakeResources holds the runtime resource settings in memory.
+type fakeResources struct {
+	state resources.State
+}
+
+func (r *fakeResources) State() resources.State { return r.state }
+
+func (r *fakeResources) Apply(c resources.Config) error {
+	if err := c.Validate(); err != nil {
+		return err
 	}
+	r.state.Settings = c
+	return nil
+}
 
-	return h
+// fakeStandby is a standby promoted in memory.
+type fakeStandby struct {
+	status standby.Status
+}
+
+func (s *fakeStandby) Status() standby.Status { return s.status }
+
+func (s *fakeStandby) Promote() error {
+	s.status.Promoted = true
+	return nil
 }
 
 // HandlerStatementExecutor is a mock implementation of Handler.StatementExecutor.
@@ -1476,6 +3596,22 @@ func (h *HandlerPointsWriter) WritePoints(database, retentionPolicy string, cons
 	return h.WritePointsFn(database, retentionPolicy, consistencyLevel, user, points)
 }
 
+func (h *HandlerPointsWriter) WritePointsIdempotent(ctx context.Context, key, database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, user meta.User, points []models.Point) error {
+	return h.WritePointsFn(database, retentionPolicy, consistencyLevel, user, points)
+}
+
+// InputPointsWriter is a points writer of the services other than the HTTP
+// API that writes nothing.
+type InputPointsWriter struct{}
+
+func (*InputPointsWriter) WritePoints(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, user meta.User, points []models.Point) error {
+	return nil
+}
+
+func (*InputPointsWriter) WritePointsPrivileged(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, points []models.Point) error {
+	return nil
+}
+
 // MustNewRequest returns a new HTTP request. Panic on error.
 func MustNewRequest(method, urlStr string, body io.Reader) *http.Request {
 	r, err := http.NewRequest(method, urlStr, body)
@@ -1493,6 +3629,31 @@ func MustNewJSONRequest(method, urlStr string, body io.Reader) *http.Request {
 }
 
 // MustJWTToken returns a new JWT token and signed string or panics trying.
+// passwordBackendFunc authenticates passwords with a function.
+type passwordBackendFunc func(username, password string) (*httpd.ExternalIdentity, error)
+
+func (fn passwordBackendFunc) AuthenticatePassword(username, password string) (*httpd.ExternalIdentity, error) {
+	return fn(username, password)
+}
+
+// tokenBackendFunc authenticates tokens with a function.
+type tokenBackendFunc func(token string) (*httpd.ExternalIdentity, error)
+
+func (fn tokenBackendFunc) AuthenticateToken(token string) (*httpd.ExternalIdentity, error) {
+	return fn(token)
+}
+
+// mustUnsignedToken returns an unsigned token for username, which is handed
+// to the token backends since it isn't signed with the shared secret.
+func mustUnsignedToken(username string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"sub": username})
+	signed, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
 func MustJWTToken(username, secret string, expired bool) (*jwt.Token, string) {
 	token := jwt.New(jwt.GetSigningMethod("HS512"))
 	token.Claims.(jwt.MapClaims)["username"] = username
@@ -1507,3 +3668,181 @@ func MustJWTToken(username, secret string, expired bool) (*jwt.Token, string) {
 	}
 	return token, signed
 }
+
+func TestHandler_Transpile(t *testing.T) {
+	h := NewHandler(false)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v2/transpile?db=foo&q="+url.QueryEscape(`SELECT mean(value) FROM cpu; SHOW DATABASES`), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Results []struct {
+			StatementID int    `json:"statement_id"`
+			Flux        string `json:"flux"`
+			Err         string `json:"error"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	} else if len(resp.Results) != 2 {
+		t.Fatalf("unexpected results: %s", w.Body.String())
+	} else if !strings.HasPrefix(resp.Results[0].Flux, `from(bucket: "foo")`) || !strings.Contains(resp.Results[0].Flux, "|> mean()") {
+		t.Fatalf("unexpected flux: %s", resp.Results[0].Flux)
+	} else if resp.Results[1].StatementID != 1 || resp.Results[1].Err == "" {
+		t.Fatalf("expected error for SHOW DATABASES: %s", w.Body.String())
+	}
+}
+
+func TestHandler_Query_FluxEngine(t *testing.T) {
+	h := NewHandler(false)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/query?db=foo&engine=flux&q=SELECT+value+FROM+cpu", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+
+	h = NewHandlerWithConfig(NewHandlerConfig(WithFlux(), WithNoLog()))
+	called := false
+	h.Controller.QueryFn = func(ctx context.Context, compiler flux.Compiler) (flux.Query, error) {
+		if c, ok := compiler.(lang.FluxCompiler); !ok {
+			t.Fatal("expected lang.FluxCompiler")
+		} else if !strings.HasPrefix(c.Query, `from(bucket: "foo")`) {
+			t.Fatalf("unexpected query: %s", c.Query)
+		}
+		called = true
+		return internal.NewFluxQueryMock(), nil
+	}
+	h.StatementExecutor.ExecuteStatementFn = func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+		t.Fatal("unexpected execution on the InfluxQL engine")
+		return nil
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/query?db=foo&engine=flux&q=SELECT+value+FROM+cpu", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if !called {
+		t.Fatal("expected QueryFn to be called")
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"results":[{"statement_id":0}]}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/query?db=foo&engine=sql&q=SELECT+value+FROM+cpu", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// Ensure the handler returns timestamps as epochs truncated to the precision
+// requested, the same way for every response format and query engine.
+func TestHandler_Query_Epoch(t *testing.T) {
+	// Timestamps on each side of the Unix epoch and of whole seconds.
+	times := []int64{-1500 * int64(time.Millisecond), -1, 0, 1, int64(time.Second) - 1}
+
+	h := NewHandlerWithConfig(NewHandlerConfig(WithFlux(), WithNoLog()))
+	h.StatementExecutor.ExecuteStatementFn = func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+		row := &models.Row{Name: "cpu", Columns: []string{"time", "value"}}
+		for i, ts := range times {
+			row.Values = append(row.Values, []interface{}{time.Unix(0, ts).UTC(), float64(i)})
+		}
+		ctx.Results <- &query.Result{StatementID: 0, Series: models.Rows{row}}
+		return nil
+	}
+	h.Controller.QueryFn = func(ctx context.Context, compiler flux.Compiler) (flux.Query, error) {
+		var buf bytes.Buffer
+		buf.WriteString("#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,double,string,string\n")
+		buf.WriteString("#group,false,false,true,true,false,false,true,true\n")
+		buf.WriteString("#default,value,,,,,,,\n")
+		buf.WriteString(",result,table,_start,_stop,_time,_value,_field,_measurement\n")
+		for i, ts := range times {
+			fmt.Fprintf(&buf, ",,0,1969-12-31T00:00:00Z,1970-01-02T00:00:00Z,%s,%d,value,cpu\n", time.Unix(0, ts).UTC().Format(time.RFC3339Nano), i)
+		}
+		result, err := fluxcsv.NewResultDecoder(fluxcsv.ResultDecoderConfig{}).Decode(&buf)
+		if err != nil {
+			return nil, err
+		}
+
+		q := internal.NewFluxQueryMock()
+		q.ReadyFn = func(*internal.FluxQueryMock) <-chan map[string]flux.Result {
+			ch := make(chan map[string]flux.Result, 1)
+			ch <- map[string]flux.Result{"value": result}
+			close(ch)
+			return ch
+		}
+		return q, nil
+	}
+
+	for _, tt := range []struct {
+		epoch string
+		exp   []string
+	}{
+		{epoch: "ns", exp: []string{"-1500000000", "-1", "0", "1", "999999999"}},
+		{epoch: "u", exp: []string{"-1500000", "-1", "0", "0", "999999"}},
+		{epoch: "ms", exp: []string{"-1500", "-1", "0", "0", "999"}},
+		{epoch: "s", exp: []string{"-2", "-1", "0", "0", "0"}},
+	} {
+		for _, engine := range []string{"influxql", "flux"} {
+			for _, accept := range []string{"application/json", "text/csv", "application/x-msgpack"} {
+				t.Run(fmt.Sprintf("%s/%s/%s", tt.epoch, engine, accept), func(t *testing.T) {
+					req := MustNewRequest("GET", "/query?db=foo&q=SELECT+value+FROM+cpu&engine="+engine+"&epoch="+tt.epoch, nil)
+					req.Header.Set("Accept", accept)
+					w := httptest.NewRecorder()
+					h.ServeHTTP(w, req)
+					if w.Code != http.StatusOK {
+						t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+					}
+
+					var got []string
+					switch accept {
+					case "text/csv":
+						records, err := csv.NewReader(w.Body).ReadAll()
+						if err != nil {
+							t.Fatal(err)
+						}
+						for _, record := range records[1:] {
+							got = append(got, record[2])
+						}
+					default:
+						body := w.Body
+						if accept == "application/x-msgpack" {
+							body = new(bytes.Buffer)
+							if _, err := msgp.NewReader(w.Body).WriteToJSON(body); err != nil {
+								t.Fatal(err)
+							}
+						}
+						var resp struct {
+							Results []struct {
+								Series []struct {
+									Values [][]json.Number `json:"values"`
+								} `json:"series"`
+							} `json:"results"`
+						}
+						dec := json.NewDecoder(body)
+						dec.UseNumber()
+						if err := dec.Decode(&resp); err != nil {
+							t.Fatal(err)
+						} else if len(resp.Results) != 1 || len(resp.Results[0].Series) != 1 {
+							t.Fatalf("unexpected response: %+v", resp)
+						}
+						for _, values := range resp.Results[0].Series[0].Values {
+							got = append(got, values[0].String())
+						}
+					}
+					if !reflect.DeepEqual(got, tt.exp) {
+						t.Fatalf("unexpected times: got %v, exp %v", got, tt.exp)
+					}
+				})
+			}
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/query?db=foo&q=SELECT+value+FROM+cpu&epoch=us2", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,343 @@
+package httpd
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// LDAP result codes used by LDAPBackend.
+const (
+	ldapResultSuccess            = 0
+	ldapResultInvalidCredentials = 49
+)
+
+// ldapMaxMessageSize is the largest message read from the server, so a
+// malicious or broken server can't make us allocate an arbitrary amount.
+const ldapMaxMessageSize = 4 << 20
+
+// LDAP protocol operation tags.
+const (
+	ldapTagBindRequest       = 0
+	ldapTagBindResponse      = 1
+	ldapTagUnbindRequest     = 2
+	ldapTagSearchRequest     = 3
+	ldapTagSearchResultEntry = 4
+	ldapTagSearchResultDone  = 5
+)
+
+// LDAPBackend authenticates users with an LDAP simple bind and reads their
+// groups from an attribute of their own directory entry.
+type LDAPBackend struct {
+	config LDAPConfig
+
+	// dial is overridden in tests.
+	dial func(network, addr string) (net.Conn, error)
+}
+
+// NewLDAPBackend returns a new LDAPBackend.
+func NewLDAPBackend(c LDAPConfig) *LDAPBackend {
+	return &LDAPBackend{config: c}
+}
+
+// AuthenticatePassword binds as the user and returns its identity.
+func (b *LDAPBackend) AuthenticatePassword(username, password string) (*ExternalIdentity, error) {
+	// An empty password is an unauthenticated bind, which most servers accept.
+	if username == "" || password == "" {
+		return nil, ErrExternalAuthenticate
+	}
+
+	conn, err := b.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	dn := fmt.Sprintf(b.config.UserDNTemplate, ldapEscapeDN(username))
+	if err := conn.bind(dn, password); err != nil {
+		return nil, err
+	}
+
+	id := &ExternalIdentity{Username: username}
+	if b.config.GroupAttribute != "" {
+		if id.Groups, err = conn.readAttribute(dn, b.config.GroupAttribute); err != nil {
+			return nil, err
+		}
+	}
+	return id, nil
+}
+
+func (b *LDAPBackend) connect() (*ldapConn, error) {
+	u, err := url.Parse(b.config.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "ldaps" {
+			host = net.JoinHostPort(u.Hostname(), "636")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "389")
+		}
+	}
+
+	timeout := time.Duration(b.config.Timeout)
+	dial := b.dial
+	if dial == nil {
+		dialer := &net.Dialer{Timeout: timeout}
+		dial = dialer.Dial
+	}
+
+	conn, err := dial("tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "ldaps" {
+		conn = tls.Client(conn, &tls.Config{
+			ServerName:         u.Hostname(),
+			InsecureSkipVerify: b.config.InsecureSkipVerify,
+		})
+	}
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+	return &ldapConn{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+// ldapConn is a minimal LDAPv3 client supporting simple bind and base
+// object searches.
+type ldapConn struct {
+	conn  net.Conn
+	r     *bufio.Reader
+	msgID int
+}
+
+type ldapBindRequest struct {
+	Version int
+	Name    []byte
+	Simple  []byte `asn1:"tag:0"`
+}
+
+type ldapResult struct {
+	ResultCode        asn1.Enumerated
+	MatchedDN         []byte
+	DiagnosticMessage []byte
+	Referral          asn1.RawValue `asn1:"optional,tag:3"`
+}
+
+type ldapSearchRequest struct {
+	BaseObject   []byte
+	Scope        asn1.Enumerated
+	DerefAliases asn1.Enumerated
+	SizeLimit    int
+	TimeLimit    int
+	TypesOnly    bool
+	Filter       asn1.RawValue
+	Attributes   [][]byte
+}
+
+type ldapPartialAttribute struct {
+	Type []byte
+	Vals [][]byte `asn1:"set"`
+}
+
+type ldapSearchResultEntry struct {
+	ObjectName []byte
+	Attributes []ldapPartialAttribute
+}
+
+type ldapMessage struct {
+	MessageID int
+	Op        asn1.RawValue
+}
+
+func (c *ldapConn) Close() error {
+	// Unbind is a courtesy; the connection is closed either way.
+	c.send(asn1.RawValue{Class: asn1.ClassApplication, Tag: ldapTagUnbindRequest})
+	return c.conn.Close()
+}
+
+// send writes a message containing op and returns its message ID.
+func (c *ldapConn) send(op asn1.RawValue) (int, error) {
+	c.msgID++
+	b, err := asn1.Marshal(ldapMessage{MessageID: c.msgID, Op: op})
+	if err != nil {
+		return 0, err
+	}
+	_, err = c.conn.Write(b)
+	return c.msgID, err
+}
+
+// sendOp marshals v as the application-tagged operation tag and sends it.
+func (c *ldapConn) sendOp(tag int, v interface{}) (int, error) {
+	b, err := asn1.MarshalWithParams(v, fmt.Sprintf("application,tag:%d", tag))
+	if err != nil {
+		return 0, err
+	}
+	return c.send(asn1.RawValue{FullBytes: b})
+}
+
+// recv reads the next message and returns its operation.
+func (c *ldapConn) recv(id int) (asn1.RawValue, error) {
+	b, err := readBERElement(c.r)
+	if err != nil {
+		return asn1.RawValue{}, err
+	}
+	var msg ldapMessage
+	if _, err := asn1.Unmarshal(b, &msg); err != nil {
+		return asn1.RawValue{}, err
+	} else if msg.MessageID != id {
+		return asn1.RawValue{}, fmt.Errorf("ldap: unexpected message id %d", msg.MessageID)
+	} else if msg.Op.Class != asn1.ClassApplication {
+		return asn1.RawValue{}, errors.New("ldap: malformed response")
+	}
+	return msg.Op, nil
+}
+
+func (c *ldapConn) bind(dn, password string) error {
+	id, err := c.sendOp(ldapTagBindRequest, ldapBindRequest{
+		Version: 3,
+		Name:    []byte(dn),
+		Simple:  []byte(password),
+	})
+	if err != nil {
+		return err
+	}
+
+	op, err := c.recv(id)
+	if err != nil {
+		return err
+	} else if op.Tag != ldapTagBindResponse {
+		return fmt.Errorf("ldap: unexpected response to bind: %d", op.Tag)
+	}
+
+	var res ldapResult
+	if _, err := asn1.UnmarshalWithParams(op.FullBytes, &res, fmt.Sprintf("application,tag:%d", ldapTagBindResponse)); err != nil {
+		return err
+	}
+	switch res.ResultCode {
+	case ldapResultSuccess:
+		return nil
+	case ldapResultInvalidCredentials:
+		return ErrExternalAuthenticate
+	}
+	return fmt.Errorf("ldap: bind failed with result %d: %s", res.ResultCode, res.DiagnosticMessage)
+}
+
+// readAttribute returns the values of attr on the entry named by dn.
+func (c *ldapConn) readAttribute(dn, attr string) ([]string, error) {
+	id, err := c.sendOp(ldapTagSearchRequest, ldapSearchRequest{
+		BaseObject: []byte(dn),
+		SizeLimit:  1,
+		// (objectClass=*) as a present filter.
+		Filter:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 7, Bytes: []byte("objectClass")},
+		Attributes: [][]byte{[]byte(attr)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for {
+		op, err := c.recv(id)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op.Tag {
+		case ldapTagSearchResultEntry:
+			var entry ldapSearchResultEntry
+			if _, err := asn1.UnmarshalWithParams(op.FullBytes, &entry, fmt.Sprintf("application,tag:%d", ldapTagSearchResultEntry)); err != nil {
+				return nil, err
+			}
+			for _, a := range entry.Attributes {
+				if !strings.EqualFold(string(a.Type), attr) {
+					continue
+				}
+				for _, v := range a.Vals {
+					values = append(values, string(v))
+				}
+			}
+		case ldapTagSearchResultDone:
+			var res ldapResult
+			if _, err := asn1.UnmarshalWithParams(op.FullBytes, &res, fmt.Sprintf("application,tag:%d", ldapTagSearchResultDone)); err != nil {
+				return nil, err
+			} else if res.ResultCode != ldapResultSuccess {
+				return nil, fmt.Errorf("ldap: search failed with result %d: %s", res.ResultCode, res.DiagnosticMessage)
+			}
+			return values, nil
+		default:
+			// Ignore referrals and intermediate responses.
+		}
+	}
+}
+
+// readBERElement reads a single BER encoded element from r.
+func readBERElement(r *bufio.Reader) ([]byte, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	b := []byte{tag}
+
+	l, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	b = append(b, l)
+
+	n := int(l)
+	if l&0x80 != 0 {
+		nbytes := int(l & 0x7f)
+		if nbytes == 0 || nbytes > 4 {
+			return nil, errors.New("ldap: unsupported length encoding")
+		}
+		n = 0
+		for i := 0; i < nbytes; i++ {
+			c, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			b = append(b, c)
+			n = n<<8 | int(c)
+		}
+	}
+	if n < 0 || n > ldapMaxMessageSize {
+		return nil, fmt.Errorf("ldap: message of %d bytes exceeds the limit of %d bytes", n, ldapMaxMessageSize)
+	}
+
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return append(b, body...), nil
+}
+
+// ldapEscapeDN escapes s for use as an attribute value in a DN (RFC 4514).
+func ldapEscapeDN(s string) string {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ',' || c == '+' || c == '"' || c == '\\' || c == '<' || c == '>' || c == ';' || c == '=':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case (c == '#' || c == ' ') && i == 0, c == ' ' && i == len(s)-1:
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c == 0:
+			buf.WriteString(`\00`)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,136 @@
+package httpd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/asn1"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLDAPBackend_AuthenticatePassword(t *testing.T) {
+	srv := &ldapServer{
+		passwords: map[string]string{`cn=a\,b,ou=people`: "secret"},
+		groups:    map[string][]string{`cn=a\,b,ou=people`: {"admins", "analysts"}},
+	}
+	b := NewLDAPBackend(LDAPConfig{
+		URL:            "ldap://ldap.example.com",
+		UserDNTemplate: "cn=%s,ou=people",
+		GroupAttribute: "memberOf",
+	})
+	b.dial = srv.dial
+
+	id, err := b.AuthenticatePassword("a,b", "secret")
+	if err != nil {
+		t.Fatal(err)
+	} else if id.Username != "a,b" {
+		t.Fatalf("unexpected username: %s", id.Username)
+	} else if !reflect.DeepEqual(id.Groups, []string{"admins", "analysts"}) {
+		t.Fatalf("unexpected groups: %v", id.Groups)
+	}
+
+	if _, err := b.AuthenticatePassword("a,b", "wrong"); err != ErrExternalAuthenticate {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestLDAPBackend_AuthenticatePassword_Unauthenticated(t *testing.T) {
+	b := NewLDAPBackend(LDAPConfig{URL: "ldap://ldap.example.com", UserDNTemplate: "cn=%s"})
+	b.dial = func(network, addr string) (net.Conn, error) {
+		t.Fatal("unexpected connection")
+		return nil, nil
+	}
+
+	// An empty password would be an unauthenticated bind.
+	if _, err := b.AuthenticatePassword("user", ""); err != ErrExternalAuthenticate {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestReadBERElement(t *testing.T) {
+	b, err := asn1.Marshal(ldapMessage{MessageID: 1, Op: asn1.RawValue{Class: asn1.ClassApplication, Tag: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := readBERElement(bufio.NewReader(bytes.NewReader(b))); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(got, b) {
+		t.Fatalf("unexpected element: %x", got)
+	}
+
+	// A length above the limit is rejected before anything is allocated.
+	if _, err := readBERElement(bufio.NewReader(bytes.NewReader([]byte{0x30, 0x84, 0x7f, 0xff, 0xff, 0xff}))); err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// ldapServer is an LDAP server answering binds and base object searches.
+type ldapServer struct {
+	passwords map[string]string
+	groups    map[string][]string
+}
+
+func (s *ldapServer) dial(network, addr string) (net.Conn, error) {
+	client, server := net.Pipe()
+	go s.serve(server)
+	return client, nil
+}
+
+func (s *ldapServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		b, err := readBERElement(r)
+		if err != nil {
+			return
+		}
+		var msg ldapMessage
+		if _, err := asn1.Unmarshal(b, &msg); err != nil {
+			return
+		}
+
+		switch msg.Op.Tag {
+		case ldapTagBindRequest:
+			var req ldapBindRequest
+			if _, err := asn1.UnmarshalWithParams(msg.Op.FullBytes, &req, "application,tag:0"); err != nil {
+				return
+			}
+			code := ldapResultInvalidCredentials
+			if p, ok := s.passwords[string(req.Name)]; ok && p == string(req.Simple) {
+				code = ldapResultSuccess
+			}
+			s.reply(conn, msg.MessageID, ldapTagBindResponse, ldapResult{ResultCode: asn1.Enumerated(code)})
+		case ldapTagSearchRequest:
+			var req ldapSearchRequest
+			if _, err := asn1.UnmarshalWithParams(msg.Op.FullBytes, &req, "application,tag:3"); err != nil {
+				return
+			}
+			var vals [][]byte
+			for _, g := range s.groups[string(req.BaseObject)] {
+				vals = append(vals, []byte(g))
+			}
+			s.reply(conn, msg.MessageID, ldapTagSearchResultEntry, ldapSearchResultEntry{
+				ObjectName: req.BaseObject,
+				Attributes: []ldapPartialAttribute{{Type: req.Attributes[0], Vals: vals}},
+			})
+			s.reply(conn, msg.MessageID, ldapTagSearchResultDone, ldapResult{ResultCode: ldapResultSuccess})
+		default:
+			return
+		}
+	}
+}
+
+func (s *ldapServer) reply(conn net.Conn, id, tag int, v interface{}) {
+	op, err := asn1.MarshalWithParams(v, fmt.Sprintf("application,tag:%d", tag))
+	if err != nil {
+		panic(err)
+	}
+	b, err := asn1.Marshal(ldapMessage{MessageID: id, Op: asn1.RawValue{FullBytes: op}})
+	if err != nil {
+		panic(err)
+	}
+	conn.Write(b)
+}
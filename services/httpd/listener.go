@@ -0,0 +1,163 @@
+package httpd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/freetsdb/freetsdb/pkg/netutil"
+)
+
+// Capabilities of the listeners of the HTTP service. Each route requires one
+// of them, except for the ping, status, login and OpenAPI routes which are
+// served on every listener.
+const (
+	// CapabilityWrite serves the routes writing points, and agent enrollment.
+	CapabilityWrite = "write"
+
+	// CapabilityQuery serves the routes querying data.
+	CapabilityQuery = "query"
+
+	// CapabilityAdmin serves the administration, monitoring and debug routes.
+	CapabilityAdmin = "admin"
+)
+
+// ListenerConfig configures an additional listener of the HTTP service
+// serving only the routes of its capabilities, such as write-only on an
+// internal port. The listener of bind-address serves every route.
+type ListenerConfig struct {
+	BindAddress  string   `toml:"bind-address"`
+	Capabilities []string `toml:"capabilities"`
+
+	HTTPSEnabled     bool   `toml:"https-enabled"`
+	HTTPSCertificate string `toml:"https-certificate"`
+	HTTPSPrivateKey  string `toml:"https-private-key"`
+
+	// HTTPSClientCA is the PEM file of the CAs the client certificates must
+	// be signed by. Clients without a valid certificate are refused.
+	HTTPSClientCA string `toml:"https-client-ca"`
+
+	MaxConnectionLimit int `toml:"max-connection-limit"`
+}
+
+// Validate returns an error if the listener config is invalid.
+func (c ListenerConfig) Validate() error {
+	if err := netutil.ValidateBindAddress(c.BindAddress); err != nil {
+		return fmt.Errorf("listener: %v", err)
+	}
+	if len(c.Capabilities) == 0 {
+		return fmt.Errorf("listener %s requires capabilities", c.BindAddress)
+	}
+	for _, capability := range c.Capabilities {
+		switch capability {
+		case CapabilityWrite, CapabilityQuery, CapabilityAdmin:
+		default:
+			return fmt.Errorf("listener %s: unknown capability %q, expected one of %s, %s or %s",
+				c.BindAddress, capability, CapabilityWrite, CapabilityQuery, CapabilityAdmin)
+		}
+	}
+	if c.HTTPSEnabled && c.HTTPSCertificate == "" {
+		return fmt.Errorf("listener %s requires an https-certificate", c.BindAddress)
+	} else if c.HTTPSClientCA != "" && !c.HTTPSEnabled {
+		return fmt.Errorf("listener %s: https-client-ca requires https-enabled", c.BindAddress)
+	}
+	return nil
+}
+
+// listen opens the listener. The certificates of agents enrolled by e, if not
+// nil, are verified unless the listener requires its own client CA.
+func (c ListenerConfig) listen(tlsConfig *tls.Config, e *Enrollment) (net.Listener, error) {
+	var config *tls.Config
+	if c.HTTPSEnabled {
+		key := c.HTTPSPrivateKey
+		if key == "" {
+			key = c.HTTPSCertificate
+		}
+		cert, err := tls.LoadX509KeyPair(c.HTTPSCertificate, key)
+		if err != nil {
+			return nil, err
+		}
+
+		config = tlsConfig.Clone()
+		config.Certificates = []tls.Certificate{cert}
+		if c.HTTPSClientCA != "" {
+			b, err := ioutil.ReadFile(c.HTTPSClientCA)
+			if err != nil {
+				return nil, err
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(b) {
+				return nil, errors.New("no certificates found in " + c.HTTPSClientCA)
+			}
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+			config.ClientCAs = pool
+		} else if e != nil {
+			e.configureTLS(config)
+		}
+	}
+
+	ln, err := net.Listen("tcp", c.BindAddress)
+	if err != nil {
+		return nil, err
+	}
+	if c.MaxConnectionLimit > 0 {
+		ln = LimitListener(ln, c.MaxConnectionLimit)
+	}
+	if config != nil {
+		ln = tls.NewListener(ln, config)
+	}
+	return ln, nil
+}
+
+// routeCapability returns the capability required by the route, or an empty
+// string if the route is served on every listener.
+func routeCapability(name string) string {
+	switch name {
+	case "ping", "ping-head", "status", "status-head", "query-options", "write-options", "login", "logout", "password", "openapi":
+		return ""
+	case "write", "prometheus-write", "enroll":
+		return CapabilityWrite
+	case "query", "query-stream", "prometheus-read", "flux-read", "flux-lsp", "tag-values", "transpile", "ui":
+		return CapabilityQuery
+	default:
+		return CapabilityAdmin
+	}
+}
+
+type capabilitiesKey struct{}
+
+// Restrict returns a handler serving only the routes of the capabilities.
+func (h *Handler) Restrict(capabilities ...string) http.Handler {
+	set := make(map[string]bool, len(capabilities))
+	for _, capability := range capabilities {
+		set[capability] = true
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), capabilitiesKey{}, set)
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// allowed returns true if the listener the request was received on serves the
+// routes of the capability.
+func allowed(r *http.Request, capability string) bool {
+	set, ok := r.Context().Value(capabilitiesKey{}).(map[string]bool)
+	return !ok || capability == "" || set[capability]
+}
+
+// requireCapability refuses the requests received on listeners without the
+// capability.
+func (h *Handler) requireCapability(inner http.Handler, capability string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowed(r, capability) {
+			h.httpError(w, fmt.Sprintf("%s is not served on this listener", r.URL.Path), http.StatusForbidden)
+			return
+		}
+		inner.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,128 @@
+package httpd
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxLoginClients is the number of clients whose failed logins are tracked.
+// Expired entries are dropped once it is reached.
+const maxLoginClients = 10000
+
+// loginLimiter locks out the clients that fail to log in too often, so that
+// the password of a user cannot be guessed through /login or
+// /api/v1/password. Clients are identified by their address rather than by
+// the user they log in as, so that failures cannot lock a user out.
+type loginLimiter struct {
+	maxFailures int
+	lockout     time.Duration
+	now         func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*loginFailures
+}
+
+// loginFailures are the failed logins of a client since start.
+type loginFailures struct {
+	n     int
+	start time.Time
+}
+
+// newLoginLimiter returns a limiter locking out a client for lockout after
+// maxFailures failed logins within lockout. It never locks out clients if
+// maxFailures is zero.
+func newLoginLimiter(maxFailures int, lockout time.Duration) *loginLimiter {
+	return &loginLimiter{
+		maxFailures: maxFailures,
+		lockout:     lockout,
+		now:         time.Now,
+		clients:     make(map[string]*loginFailures),
+	}
+}
+
+// retryAfter returns how long the client has to wait before logging in
+// again, or zero if it is not locked out.
+func (l *loginLimiter) retryAfter(client string) time.Duration {
+	if l.maxFailures <= 0 {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f := l.clients[client]
+	if f == nil || f.n < l.maxFailures {
+		return 0
+	}
+	if d := f.start.Add(l.lockout).Sub(l.now()); d > 0 {
+		return d
+	}
+	delete(l.clients, client)
+	return 0
+}
+
+// fail records a failed login of the client.
+func (l *loginLimiter) fail(client string) {
+	if l.maxFailures <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if f := l.clients[client]; f != nil && now.Sub(f.start) < l.lockout {
+		f.n++
+		if f.n == l.maxFailures {
+			// The lockout starts at the last failure.
+			f.start = now
+		}
+		return
+	}
+
+	if len(l.clients) >= maxLoginClients {
+		for c, f := range l.clients {
+			if now.Sub(f.start) >= l.lockout {
+				delete(l.clients, c)
+			}
+		}
+	}
+	l.clients[client] = &loginFailures{n: 1, start: now}
+}
+
+// succeed forgets the failed logins of the client.
+func (l *loginLimiter) succeed(client string) {
+	if l.maxFailures <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.clients, client)
+}
+
+// limitLogins wraps next, a route checking the credentials of a user, so that
+// the clients failing to log in too often are refused with 429 Too Many
+// Requests until their lockout ends. It wraps the response writer of the
+// route to see whether the credentials were accepted.
+func (h *Handler) limitLogins(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			client = r.RemoteAddr
+		}
+
+		if d := h.loginLimiter.retryAfter(client); d > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int((d+time.Second-1)/time.Second)))
+			h.httpError(NewResponseWriter(w, r), "too many failed logins", http.StatusTooManyRequests)
+			return
+		}
+
+		l := &responseLogger{w: w}
+		next.ServeHTTP(l, r)
+		switch status := l.Status(); {
+		case status == http.StatusUnauthorized:
+			h.loginLimiter.fail(client)
+		case status/100 == 2:
+			h.loginLimiter.succeed(client)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package httpd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newLoginLimiter(3, time.Minute)
+	l.now = func() time.Time { return now }
+
+	// A success forgets the failures before it.
+	l.fail("a")
+	l.fail("a")
+	l.succeed("a")
+	l.fail("a")
+	l.fail("a")
+	if d := l.retryAfter("a"); d != 0 {
+		t.Fatalf("unexpected lockout: %s", d)
+	}
+
+	// The lockout starts at the last failure and only locks out the client.
+	now = now.Add(30 * time.Second)
+	l.fail("a")
+	if d := l.retryAfter("a"); d != time.Minute {
+		t.Fatalf("unexpected lockout: %s", d)
+	} else if d := l.retryAfter("b"); d != 0 {
+		t.Fatalf("unexpected lockout of other client: %s", d)
+	}
+
+	now = now.Add(time.Minute)
+	if d := l.retryAfter("a"); d != 0 {
+		t.Fatalf("unexpected lockout after it ended: %s", d)
+	}
+
+	// Failures further apart than the lockout don't add up.
+	for i := 0; i < 5; i++ {
+		now = now.Add(time.Minute)
+		l.fail("a")
+	}
+	if d := l.retryAfter("a"); d != 0 {
+		t.Fatalf("unexpected lockout: %s", d)
+	}
+}
+
+func TestLoginLimiter_Disabled(t *testing.T) {
+	l := newLoginLimiter(0, 0)
+	for i := 0; i < 10; i++ {
+		l.fail("a")
+	}
+	if d := l.retryAfter("a"); d != 0 {
+		t.Fatalf("unexpected lockout: %s", d)
+	}
+}
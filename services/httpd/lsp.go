@@ -0,0 +1,156 @@
+package httpd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/freetsdb/freetsdb/flux/lsp"
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+)
+
+// serveFluxLSP upgrades the connection to a WebSocket serving the Flux
+// language server. Each WebSocket message is a JSON-RPC message.
+func (h *Handler) serveFluxLSP(w http.ResponseWriter, r *http.Request, user meta.User) {
+	schema := &fluxSchema{h: h, ctx: r.Context(), user: user, auth: query.OpenAuthorizer}
+	if h.Config.AuthEnabled && (user == nil || !user.AuthorizeUnrestricted()) {
+		schema.auth = user
+	}
+
+	websocket.Server{
+		Handler: func(ws *websocket.Conn) {
+			if err := lsp.NewServer(schema).Serve(r.Context(), lspConn{ws}); err != nil && err != io.EOF && err != context.Canceled {
+				h.Logger.Info("Error serving Flux language server", zap.Error(err))
+			}
+		},
+	}.ServeHTTP(w, r)
+}
+
+// lspConn is a language server connection over a WebSocket.
+type lspConn struct {
+	ws *websocket.Conn
+}
+
+func (c lspConn) ReadMessage() ([]byte, error) {
+	var b []byte
+	err := websocket.Message.Receive(c.ws, &b)
+	return b, err
+}
+
+func (c lspConn) WriteMessage(b []byte) error {
+	return websocket.Message.Send(c.ws, string(b))
+}
+
+// fluxSchema is the schema completed by the Flux language server: the
+// databases the user can read and the series of their measurements.
+type fluxSchema struct {
+	h    *Handler
+	ctx  context.Context
+	user meta.User
+	auth query.Authorizer
+}
+
+// Buckets returns the databases the user can read, alone and with each of
+// their retention policies.
+func (s *fluxSchema) Buckets() ([]string, error) {
+	dbs, err := s.h.MetaClient.Databases()
+	if err != nil {
+		return nil, err
+	}
+	var buckets []string
+	for _, di := range dbs {
+		if s.h.Config.AuthEnabled && (s.user == nil || !s.user.AuthorizeDatabase(influxql.ReadPrivilege, di.Name)) {
+			continue
+		}
+		buckets = append(buckets, di.Name)
+		for _, rpi := range di.RetentionPolicies {
+			buckets = append(buckets, di.Name+"/"+rpi.Name)
+		}
+	}
+	sort.Strings(buckets)
+	return buckets, nil
+}
+
+func (s *fluxSchema) Measurements(bucket string) ([]string, error) {
+	return s.show(bucket, "SHOW MEASUREMENTS", "", "name")
+}
+
+func (s *fluxSchema) TagKeys(bucket, measurement string) ([]string, error) {
+	return s.show(bucket, "SHOW TAG KEYS", measurement, "tagKey")
+}
+
+func (s *fluxSchema) TagValues(bucket, measurement, key string) ([]string, error) {
+	return s.show(bucket, "SHOW TAG VALUES", measurement, "value", "WITH KEY = "+influxql.QuoteIdent(key))
+}
+
+func (s *fluxSchema) FieldKeys(bucket, measurement string) ([]string, error) {
+	return s.show(bucket, "SHOW FIELD KEYS", measurement, "fieldKey")
+}
+
+// show executes a SHOW statement on the database of bucket, from measurement
+// if it is set, and returns the distinct values of column in order.
+func (s *fluxSchema) show(bucket, stmt, measurement, column string, clauses ...string) ([]string, error) {
+	db := bucket
+	if i := strings.IndexByte(bucket, '/'); i >= 0 {
+		db = bucket[:i]
+	}
+	qs := stmt + " ON " + influxql.QuoteIdent(db)
+	if measurement != "" {
+		qs += " FROM " + influxql.QuoteIdent(measurement)
+	}
+	for _, c := range clauses {
+		qs += " " + c
+	}
+	q, err := influxql.ParseQuery(qs)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := query.ExecutionOptions{
+		Database:   db,
+		ReadOnly:   true,
+		Authorizer: s.auth,
+		AbortCh:    s.ctx.Done(),
+	}
+	if s.h.Config.AuthEnabled {
+		if err := s.h.QueryAuthorizer.AuthorizeQuery(s.user, q, db); err != nil {
+			return nil, err
+		}
+	}
+
+	set := make(map[string]struct{})
+	for result := range s.h.QueryExecutor.ExecuteQuery(q, opts, nil) {
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		for _, row := range result.Series {
+			idx := -1
+			for i, c := range row.Columns {
+				if c == column {
+					idx = i
+				}
+			}
+			if idx == -1 {
+				continue
+			}
+			for _, values := range row.Values {
+				if v, ok := values[idx].(string); ok {
+					set[v] = struct{}{}
+				}
+			}
+		}
+	}
+
+	a := make([]string, 0, len(set))
+	for v := range set {
+		a = append(a, v)
+	}
+	sort.Strings(a)
+	return a, nil
+}
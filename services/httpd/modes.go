@@ -0,0 +1,136 @@
+package httpd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/freetsdb/freetsdb/coordinator"
+	"github.com/freetsdb/freetsdb/services/audit"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// nodeMode is the JSON encoding of the operational mode of a data node.
+type nodeMode struct {
+	ID          uint64 `json:"id,omitempty"`
+	ReadOnly    bool   `json:"read_only"`
+	Reason      string `json:"reason,omitempty"`
+	Maintenance bool   `json:"maintenance"`
+}
+
+// databaseMode is the JSON encoding of the operational mode of a database.
+type databaseMode struct {
+	Name   string `json:"name,omitempty"`
+	Frozen bool   `json:"frozen"`
+}
+
+// serveModes returns the operational modes of the data nodes and databases.
+func (h *Handler) serveModes(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+
+	nodes, err := h.MetaClient.DataNodes()
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	dis, err := h.MetaClient.Databases()
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := struct {
+		Nodes     []nodeMode     `json:"nodes"`
+		Databases []databaseMode `json:"databases"`
+	}{
+		Nodes:     make([]nodeMode, 0, len(nodes)),
+		Databases: make([]databaseMode, 0, len(dis)),
+	}
+	for _, n := range nodes {
+		resp.Nodes = append(resp.Nodes, nodeMode{
+			ID:          n.ID,
+			ReadOnly:    n.ReadOnly,
+			Reason:      n.ReadOnlyReason,
+			Maintenance: n.Maintenance,
+		})
+	}
+	for _, di := range dis {
+		resp.Databases = append(resp.Databases, databaseMode{Name: di.Name, Frozen: di.Frozen})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// serveSetNodeMode sets whether a data node is read-only, and whether it is
+// in maintenance and so not queried.
+func (h *Handler) serveSetNodeMode(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+
+	id, err := strconv.ParseUint(r.URL.Query().Get(":id"), 10, 64)
+	if err != nil {
+		h.httpError(w, "invalid node ID", http.StatusBadRequest)
+		return
+	}
+	var mode nodeMode
+	if err := json.NewDecoder(r.Body).Decode(&mode); err != nil {
+		h.httpError(w, "error parsing mode: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.MetaClient.SetDataNodeMode(id, mode.ReadOnly, mode.Reason, mode.Maintenance)
+	h.auditRequest(r, user, audit.CategoryAdmin, "", err)
+	if err != nil && err.Error() == meta.ErrNodeNotFound.Error() {
+		h.httpError(w, fmt.Sprintf("node %d not found", id), http.StatusNotFound)
+		return
+	} else if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.writeHeader(w, http.StatusNoContent)
+}
+
+// serveSetDatabaseMode sets whether a database is frozen.
+func (h *Handler) serveSetDatabaseMode(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+
+	name := r.URL.Query().Get(":name")
+	var mode databaseMode
+	if err := json.NewDecoder(r.Body).Decode(&mode); err != nil {
+		h.httpError(w, "error parsing mode: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if h.MetaClient.Database(name) == nil {
+		h.writeError(w, Response{Err: fmt.Errorf("%w: %q", errDatabaseNotFound, name)}, http.StatusNotFound)
+		return
+	}
+
+	err := h.MetaClient.SetDatabaseFrozen(name, mode.Frozen)
+	h.auditRequest(r, user, audit.CategoryAdmin, name, err)
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.writeHeader(w, http.StatusNoContent)
+}
+
+// modeErrorStatus returns the HTTP status of a write rejected because the
+// node is read-only or the database is frozen. Writes to a read-only node
+// may be retried on another node, so they are unavailable rather than
+// forbidden.
+func modeErrorStatus(err error) (int, bool) {
+	if _, ok := err.(coordinator.ReadOnlyError); ok {
+		return http.StatusServiceUnavailable, true
+	} else if err == coordinator.ErrDatabaseFrozen {
+		return http.StatusForbidden, true
+	}
+	return 0, false
+}
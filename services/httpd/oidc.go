@@ -0,0 +1,228 @@
+package httpd
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// oidcMinRefetchInterval is the least time between two fetches of the
+	// signing keys, so tokens with unknown key IDs can't make every request
+	// fetch them.
+	oidcMinRefetchInterval = time.Minute
+
+	// oidcMaxResponseSize is the largest discovery document or key set read
+	// from the issuer.
+	oidcMaxResponseSize = 1 << 20
+)
+
+// OIDCBackend validates bearer tokens signed by an OpenID Connect provider.
+// Signing keys are discovered from the issuer and cached.
+type OIDCBackend struct {
+	config OIDCConfig
+	client *http.Client
+
+	// fetchMu serializes fetches of the keys. mu only guards the cache, so
+	// tokens signed with a cached key are validated while keys are fetched.
+	fetchMu sync.Mutex
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	triedAt   time.Time
+
+	now func() time.Time
+}
+
+// NewOIDCBackend returns a new OIDCBackend.
+func NewOIDCBackend(c OIDCConfig) *OIDCBackend {
+	return &OIDCBackend{
+		config: c,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}
+}
+
+// AuthenticateToken validates token and returns the identity in its claims.
+func (b *OIDCBackend) AuthenticateToken(token string) (*ExternalIdentity, error) {
+	t, err := jwt.Parse(token, b.keyFor)
+	if err != nil {
+		return nil, err
+	} else if !t.Valid {
+		return nil, ErrExternalAuthenticate
+	}
+
+	claims, ok := t.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrExternalAuthenticate
+	}
+
+	if exp, ok := claims["exp"].(float64); !ok || exp <= 0 {
+		return nil, errors.New("token expiration required")
+	} else if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != strings.TrimSuffix(b.config.Issuer, "/") {
+		return nil, fmt.Errorf("unexpected token issuer: %q", iss)
+	} else if !oidcAudienceContains(claims["aud"], b.config.Audience) {
+		return nil, errors.New("token audience mismatch")
+	}
+
+	username, _ := claims[b.config.UsernameClaim].(string)
+	if username == "" {
+		return nil, fmt.Errorf("token must contain a %q claim", b.config.UsernameClaim)
+	}
+
+	id := &ExternalIdentity{Username: username}
+	switch groups := claims[b.config.GroupsClaim].(type) {
+	case []interface{}:
+		for _, g := range groups {
+			if s, ok := g.(string); ok {
+				id.Groups = append(id.Groups, s)
+			}
+		}
+	case string:
+		id.Groups = strings.Fields(groups)
+	}
+	return id, nil
+}
+
+// keyFor returns the public key that signed token.
+func (b *OIDCBackend) keyFor(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	kid, _ := token.Header["kid"].(string)
+
+	key, fresh := b.cachedKey(kid)
+	if key != nil && fresh {
+		return key, nil
+	}
+
+	// Refetch when the cache is stale or the key is unknown, which happens
+	// after the provider rotates its keys.
+	if err := b.refreshKeys(); err != nil && key == nil {
+		return nil, err
+	}
+	if key, _ = b.cachedKey(kid); key == nil {
+		return nil, fmt.Errorf("unknown signing key: %q", kid)
+	}
+	return key, nil
+}
+
+// cachedKey returns the cached key kid and whether the cache is fresh.
+func (b *OIDCBackend) cachedKey(kid string) (key *rsa.PublicKey, fresh bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.keys[kid], b.now().Sub(b.fetchedAt) <= time.Duration(b.config.KeyRefreshInterval)
+}
+
+// refreshKeys fetches the keys unless they were fetched, or a fetch failed,
+// less than oidcMinRefetchInterval ago. Concurrent callers wait for a single
+// fetch. A failed fetch keeps the cached keys.
+func (b *OIDCBackend) refreshKeys() error {
+	b.fetchMu.Lock()
+	defer b.fetchMu.Unlock()
+
+	b.mu.RLock()
+	triedAt := b.triedAt
+	b.mu.RUnlock()
+	if !triedAt.IsZero() && b.now().Sub(triedAt) < oidcMinRefetchInterval {
+		return nil
+	}
+
+	keys, err := b.fetchKeys()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.triedAt = b.now()
+	if err != nil {
+		return err
+	}
+	b.keys = keys
+	b.fetchedAt = b.triedAt
+	return nil
+}
+
+// fetchKeys loads the issuer's JSON Web Key Set.
+func (b *OIDCBackend) fetchKeys() (map[string]*rsa.PublicKey, error) {
+	var discovery struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := b.getJSON(strings.TrimSuffix(b.config.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	} else if discovery.JWKSURI == "" {
+		return nil, errors.New("oidc discovery document has no jwks_uri")
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := b.getJSON(discovery.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus for key %q: %v", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent for key %q: %v", k.Kid, err)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
+
+func (b *OIDCBackend) getJSON(url string, v interface{}) error {
+	resp, err := b.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, oidcMaxResponseSize)).Decode(v)
+}
+
+// oidcAudienceContains returns true if the aud claim, either a string or a
+// list of strings, contains audience.
+func oidcAudienceContains(aud interface{}, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
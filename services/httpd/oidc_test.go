@@ -0,0 +1,170 @@
+package httpd
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/freetsdb/freetsdb/toml"
+)
+
+func TestOIDCBackend_AuthenticateToken(t *testing.T) {
+	issuer := newOIDCIssuer(t)
+	defer issuer.Close()
+
+	b := NewOIDCBackend(OIDCConfig{
+		Issuer:             issuer.URL,
+		Audience:           "freetsdb",
+		UsernameClaim:      DefaultOIDCUsernameClaim,
+		GroupsClaim:        DefaultOIDCGroupsClaim,
+		KeyRefreshInterval: toml.Duration(time.Hour),
+	})
+
+	id, err := b.AuthenticateToken(issuer.token(t, "key0", jwt.MapClaims{
+		"iss":                issuer.URL,
+		"aud":                []interface{}{"other", "freetsdb"},
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"preferred_username": "user0",
+		"groups":             []interface{}{"admins", "analysts"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	} else if id.Username != "user0" {
+		t.Fatalf("unexpected username: %s", id.Username)
+	} else if !reflect.DeepEqual(id.Groups, []string{"admins", "analysts"}) {
+		t.Fatalf("unexpected groups: %v", id.Groups)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		claims jwt.MapClaims
+	}{
+		{name: "no expiration", claims: jwt.MapClaims{"iss": issuer.URL, "aud": "freetsdb", "preferred_username": "user0"}},
+		{name: "issuer", claims: jwt.MapClaims{"iss": "https://other", "aud": "freetsdb", "exp": time.Now().Add(time.Hour).Unix(), "preferred_username": "user0"}},
+		{name: "audience", claims: jwt.MapClaims{"iss": issuer.URL, "aud": "other", "exp": time.Now().Add(time.Hour).Unix(), "preferred_username": "user0"}},
+		{name: "expired", claims: jwt.MapClaims{"iss": issuer.URL, "aud": "freetsdb", "exp": time.Now().Add(-time.Hour).Unix(), "preferred_username": "user0"}},
+		{name: "no username", claims: jwt.MapClaims{"iss": issuer.URL, "aud": "freetsdb", "exp": time.Now().Add(time.Hour).Unix()}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := b.AuthenticateToken(issuer.token(t, "key0", tt.claims)); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestOIDCBackend_KeyRefresh(t *testing.T) {
+	issuer := newOIDCIssuer(t)
+	defer issuer.Close()
+
+	now := time.Unix(0, 0)
+	b := NewOIDCBackend(OIDCConfig{
+		Issuer:             issuer.URL,
+		Audience:           "freetsdb",
+		UsernameClaim:      DefaultOIDCUsernameClaim,
+		KeyRefreshInterval: toml.Duration(time.Hour),
+	})
+	b.now = func() time.Time { return now }
+
+	claims := jwt.MapClaims{
+		"iss":                issuer.URL,
+		"aud":                "freetsdb",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"preferred_username": "user0",
+	}
+	if _, err := b.AuthenticateToken(issuer.token(t, "key0", claims)); err != nil {
+		t.Fatal(err)
+	} else if n := atomic.LoadInt64(&issuer.fetches); n != 1 {
+		t.Fatalf("unexpected fetches: %d", n)
+	}
+
+	// Unknown keys refetch the key set at most once per interval.
+	for i := 0; i < 3; i++ {
+		if _, err := b.AuthenticateToken(issuer.token(t, "unknown", claims)); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if n := atomic.LoadInt64(&issuer.fetches); n != 1 {
+		t.Fatalf("unexpected fetches: %d", n)
+	}
+	now = now.Add(oidcMinRefetchInterval)
+	if _, err := b.AuthenticateToken(issuer.token(t, "unknown", claims)); err == nil {
+		t.Fatal("expected an error")
+	} else if n := atomic.LoadInt64(&issuer.fetches); n != 2 {
+		t.Fatalf("unexpected fetches: %d", n)
+	}
+
+	// Stale keys are still used while the issuer is unavailable.
+	now = now.Add(2 * time.Hour)
+	atomic.StoreInt32(&issuer.down, 1)
+	if _, err := b.AuthenticateToken(issuer.token(t, "key0", claims)); err != nil {
+		t.Fatal(err)
+	} else if n := atomic.LoadInt64(&issuer.fetches); n != 3 {
+		t.Fatalf("unexpected fetches: %d", n)
+	}
+}
+
+// oidcIssuer is an OpenID Connect provider serving the discovery document
+// and the key set.
+type oidcIssuer struct {
+	*httptest.Server
+	key     *rsa.PrivateKey
+	fetches int64 // fetches of the discovery document
+	down    int32 // fail requests if set
+}
+
+func newOIDCIssuer(t *testing.T) *oidcIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := &oidcIssuer{key: key}
+	issuer.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/.well-known/openid-configuration" {
+			atomic.AddInt64(&issuer.fetches, 1)
+		}
+		if atomic.LoadInt32(&issuer.down) != 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{"jwks_uri": issuer.URL + "/jwks"})
+		case "/jwks":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"keys": []map[string]string{{
+					"kty": "RSA",
+					"kid": "key0",
+					"use": "sig",
+					"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+					"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return issuer
+}
+
+// token returns a token with claims signed by the issuer's key as kid.
+func (issuer *oidcIssuer) token(t *testing.T, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	s, err := token.SignedString(issuer.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
@@ -0,0 +1,664 @@
+package httpd
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// openAPIOperation describes the operation of a route for the OpenAPI
+// document. The paths, methods and authentication of the document come from
+// the routes of the handler, the rest from the operation of the route name.
+type openAPIOperation struct {
+	Summary     string
+	Description string
+	Parameters  []openAPIParameter
+	Body        *openAPIBody
+	Responses   map[string]string
+	Deprecated  bool
+}
+
+// openAPIParameter is an OpenAPI parameter object.
+type openAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *openAPISchema `json:"schema"`
+}
+
+// openAPIBody is the request body of an operation.
+type openAPIBody struct {
+	Description string
+	Required    bool
+	Content     map[string]*openAPISchema
+}
+
+// openAPISchema is an OpenAPI schema object.
+type openAPISchema struct {
+	Type        string                    `json:"type,omitempty"`
+	Format      string                    `json:"format,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	Enum        []string                  `json:"enum,omitempty"`
+	Items       *openAPISchema            `json:"items,omitempty"`
+	Properties  map[string]*openAPISchema `json:"properties,omitempty"`
+	Required    []string                  `json:"required,omitempty"`
+}
+
+var (
+	openAPIString  = &openAPISchema{Type: "string"}
+	openAPIInteger = &openAPISchema{Type: "integer"}
+	openAPIBoolean = &openAPISchema{Type: "boolean"}
+	openAPIBinary  = &openAPISchema{Type: "string", Format: "binary"}
+)
+
+func openAPIEnum(values ...string) *openAPISchema {
+	return &openAPISchema{Type: "string", Enum: values}
+}
+
+// openAPISchemaOf returns the schema of the JSON encoding of t.
+func openAPISchemaOf(t reflect.Type) *openAPISchema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return openAPISchemaOf(t.Elem())
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &openAPISchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: "number"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &openAPISchema{Type: "array", Items: openAPISchemaOf(t.Elem())}
+	case reflect.Struct:
+		s := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := strings.Split(f.Tag.Get("json"), ",")
+			if f.PkgPath != "" || tag[0] == "-" {
+				continue
+			}
+			name := f.Name
+			if tag[0] != "" {
+				name = tag[0]
+			}
+			s.Properties[name] = openAPISchemaOf(f.Type)
+			if len(tag) == 1 || tag[1] != "omitempty" {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+	default:
+		return &openAPISchema{}
+	}
+}
+
+// openAPIDatabaseTemplate is the schema of the JSON form of the database
+// templates.
+var openAPIDatabaseTemplate = openAPISchemaOf(reflect.TypeOf(databaseTemplate{}))
+
+// openAPINodeMode and openAPIDatabaseMode are the schemas of the JSON form of
+// the operational modes of the data nodes and databases.
+var (
+	openAPINodeMode     = openAPISchemaOf(reflect.TypeOf(nodeMode{}))
+	openAPIDatabaseMode = openAPISchemaOf(reflect.TypeOf(databaseMode{}))
+)
+
+// openAPIDatabasePlacement is the schema of the JSON form of the placement of
+// the shards of a database.
+var openAPIDatabasePlacement = openAPISchemaOf(reflect.TypeOf(databasePlacement{}))
+
+// openAPISubscriptionState is the schema of the JSON form of the state of a
+// subscription.
+var openAPISubscriptionState = openAPISchemaOf(reflect.TypeOf(subscriptionState{}))
+
+// openAPIResourceSettings is the schema of the JSON form of the runtime
+// resource settings.
+var openAPIResourceSettings = &openAPISchema{
+	Type: "object",
+	Properties: map[string]*openAPISchema{
+		"gogc":               openAPIInteger,
+		"memory-limit":       openAPIInteger,
+		"ballast-size":       openAPIInteger,
+		"numa-node":          openAPIInteger,
+		"cpus":               openAPIString,
+		"max-procs":          openAPIInteger,
+		"compaction-workers": openAPIInteger,
+		"query-workers":      openAPIInteger,
+	},
+}
+
+// openAPIErrorSchema is the schema of the errors returned by the handler.
+var openAPIErrorSchema = &openAPISchema{
+	Type: "object",
+	Properties: map[string]*openAPISchema{
+		"error": openAPIString,
+		"code":  {Type: "string", Enum: errorCodeNames()},
+	},
+	Required: []string{"error", "code"},
+}
+
+var (
+	openAPIDatabase = openAPIParameter{
+		Name: "db", In: "query", Description: "Database to use.", Schema: openAPIString,
+	}
+	openAPIRetentionPolicy = openAPIParameter{
+		Name: "rp", In: "query", Description: "Retention policy to use, the default retention policy of the database if not set.", Schema: openAPIString,
+	}
+	openAPIQuery = openAPIParameter{
+		Name: "q", In: "query", Description: "InfluxQL statements to execute, separated by semicolons.", Required: true, Schema: openAPIString,
+	}
+	openAPIEpoch = openAPIParameter{
+		Name: "epoch", In: "query", Description: "Return timestamps as epochs of this precision instead of RFC3339, truncated to the precision.",
+		Schema: openAPIEnum("ns", "n", "u", "us", "µ", "µs", "ms", "s", "m", "h"),
+	}
+	openAPIPretty = openAPIParameter{
+		Name: "pretty", In: "query", Description: "Indent the JSON response.", Schema: openAPIBoolean,
+	}
+)
+
+// openAPIOperations documents the routes of the handler by route name.
+// Backups are taken through the snapshotter service rather than the HTTP
+// API, so they have no route to document.
+var openAPIOperations = map[string]openAPIOperation{
+	"query": {
+		Summary: "Execute InfluxQL queries",
+		Parameters: []openAPIParameter{
+			openAPIQuery,
+			openAPIDatabase,
+			openAPIRetentionPolicy,
+			openAPIEpoch,
+			openAPIPretty,
+			{Name: "params", In: "query", Description: "JSON object of the values of the bound parameters of the query.", Schema: openAPIString},
+			{Name: "chunked", In: "query", Description: "Stream the results in chunks instead of a single response.", Schema: openAPIBoolean},
+			{Name: "chunk_size", In: "query", Description: "Number of points per chunk.", Schema: openAPIInteger},
+			{Name: "async", In: "query", Description: "Return the chunks as soon as they are ready.", Schema: openAPIBoolean},
+			{Name: "page_size", In: "query", Description: "Number of rows per page, with a cursor to fetch the next page. The query is not a snapshot: each page reads the data as it is when the page is read, so points written or deleted between pages may or may not be returned.", Schema: openAPIInteger},
+			{Name: "cursor", In: "query", Description: "Cursor of the next page of a previous query.", Schema: openAPIString},
+			{Name: "max_points", In: "query", Description: "Downsample each series to at most this number of points.", Schema: openAPIInteger},
+			{Name: "system_columns", In: "query", Description: "Return the _shard, _replica and _ingest_time columns of raw queries.", Schema: openAPIBoolean},
+			{Name: "node_id", In: "query", Description: "Node to execute the query on.", Schema: openAPIInteger},
+			{Name: "priority", In: "query", Description: "Admission priority of the query.", Schema: openAPIEnum("interactive", "batch")},
+			{Name: "engine", In: "query", Description: "Engine executing the query. The flux engine runs the statements transpiled to Flux and requires flux-enabled.", Schema: openAPIEnum("influxql", "flux")},
+		},
+		Responses: map[string]string{
+			"200": "Results of the statements.",
+			"304": "The results of the metadata query cached from a previous response with its ETag or Last-Modified are unchanged.",
+			"400": "The query is invalid.",
+			"401": "Authentication failed.",
+			"403": "The user is not authorized to execute the query.",
+		},
+	},
+	"query-stream": {
+		Summary:     "Stream InfluxQL query results over a WebSocket",
+		Description: "Upgrades the connection to a WebSocket that receives the results of the query as they are produced.",
+		Parameters:  []openAPIParameter{openAPIQuery, openAPIDatabase, openAPIEpoch},
+		Responses: map[string]string{
+			"101": "Switching to the WebSocket protocol.",
+			"400": "The query is invalid.",
+		},
+	},
+	"write": {
+		Summary: "Write points in line protocol or protocol buffers",
+		Parameters: []openAPIParameter{
+			{Name: "db", In: "query", Description: "Database to write to.", Required: true, Schema: openAPIString},
+			openAPIRetentionPolicy,
+			{Name: "precision", In: "query", Description: "Precision of the timestamps of the points.", Schema: openAPIEnum("ns", "n", "u", "ms", "s", "m", "h")},
+			{Name: "consistency", In: "query", Description: "Number of owners of a shard that must acknowledge the write.", Schema: openAPIEnum("any", "one", "quorum", "all")},
+			{Name: "Idempotency-Key", In: "header", Description: "Key deduplicating the retries of a batch by the same user to the same database and retention policy. Keys are only known to the node receiving the write.", Schema: openAPIString},
+			{Name: "Content-Encoding", In: "header", Description: "Set to gzip for a compressed body.", Schema: openAPIEnum("gzip")},
+		},
+		Body: &openAPIBody{
+			Description: "Points in line protocol, one per line, or a Points message of services/grpc/wire/wire.proto.",
+			Required:    true,
+			Content: map[string]*openAPISchema{
+				"text/plain":             openAPIString,
+				"application/x-protobuf": openAPIBinary,
+			},
+		},
+		Responses: map[string]string{
+			"204": "The points were written.",
+			"400": "The points are invalid, or some of them were dropped.",
+			"401": "Authentication failed.",
+			"403": "The user is not authorized to write to the database, or the database is frozen.",
+			"404": "The database does not exist.",
+			"413": "The body is too large.",
+			"500": "The points could not be written.",
+			"503": "The write was throttled, or the node is read-only.",
+		},
+	},
+	"prometheus-write": {
+		Summary:    "Prometheus remote write",
+		Parameters: []openAPIParameter{openAPIDatabase, openAPIRetentionPolicy},
+		Body: &openAPIBody{
+			Description: "Snappy compressed Prometheus WriteRequest.",
+			Required:    true,
+			Content:     map[string]*openAPISchema{"application/x-protobuf": openAPIBinary},
+		},
+		Responses: map[string]string{
+			"204": "The samples were written.",
+			"400": "The request is invalid.",
+			"403": "The database is frozen.",
+			"503": "The node is read-only.",
+		},
+	},
+	"prometheus-read": {
+		Summary:    "Prometheus remote read",
+		Parameters: []openAPIParameter{openAPIDatabase, openAPIRetentionPolicy},
+		Body: &openAPIBody{
+			Description: "Snappy compressed Prometheus ReadRequest.",
+			Required:    true,
+			Content:     map[string]*openAPISchema{"application/x-protobuf": openAPIBinary},
+		},
+		Responses: map[string]string{
+			"200": "Snappy compressed Prometheus ReadResponse.",
+			"400": "The request is invalid.",
+		},
+	},
+	"ping": {
+		Summary: "Check that the server is up",
+		Parameters: []openAPIParameter{
+			{Name: "verbose", In: "query", Description: "Return the version of the server in the body.", Schema: openAPIBoolean},
+		},
+		Responses: map[string]string{
+			"200": "The server is up, with its version.",
+			"204": "The server is up.",
+		},
+	},
+	"ping-head": {
+		Summary:   "Check that the server is up",
+		Responses: map[string]string{"204": "The server is up."},
+	},
+	"status": {
+		Summary:    "Check that the server is up",
+		Responses:  map[string]string{"204": "The server is up."},
+		Deprecated: true,
+	},
+	"status-head": {
+		Summary:    "Check that the server is up",
+		Responses:  map[string]string{"204": "The server is up."},
+		Deprecated: true,
+	},
+	"prometheus-metrics": {
+		Summary:   "Prometheus metrics of the server",
+		Responses: map[string]string{"200": "Metrics in the Prometheus text format."},
+	},
+	"templates": {
+		Summary:   "List the database templates",
+		Responses: map[string]string{"200": "The database templates.", "403": "The user is not an admin."},
+	},
+	"templates-create": {
+		Summary: "Create or replace a database template",
+		Body: &openAPIBody{
+			Required: true,
+			Content:  map[string]*openAPISchema{"application/json": openAPIDatabaseTemplate},
+		},
+		Responses: map[string]string{"204": "The template was stored.", "400": "The template is invalid.", "403": "The user is not an admin."},
+	},
+	"templates-drop": {
+		Summary:   "Drop a database template",
+		Responses: map[string]string{"204": "The template was dropped.", "403": "The user is not an admin."},
+	},
+	"templates-instantiate": {
+		Summary: "Create a database from a template",
+		Parameters: []openAPIParameter{
+			{Name: "db", In: "query", Description: "Database to create.", Required: true, Schema: openAPIString},
+		},
+		Responses: map[string]string{"201": "The database was created.", "400": "The database could not be created.", "403": "The user is not an admin."},
+	},
+	"config-reload": {
+		Summary:   "Reload the server configuration from disk",
+		Responses: map[string]string{"204": "The configuration was reloaded.", "400": "The configuration is invalid.", "403": "The user is not an admin.", "501": "Reloading is not supported."},
+	},
+	"resources": {
+		Summary:   "Show the runtime resource settings and the CPU topology",
+		Responses: map[string]string{"200": "The settings and the topology.", "403": "The user is not an admin.", "501": "Runtime resources are not supported."},
+	},
+	"resources-update": {
+		Summary:     "Change the runtime resource settings",
+		Description: "Settings missing from the body are unchanged. The changes are lost when the configuration is reloaded.",
+		Body: &openAPIBody{
+			Required: true,
+			Content:  map[string]*openAPISchema{"application/json": openAPIResourceSettings},
+		},
+		Responses: map[string]string{"200": "The settings were applied.", "400": "The settings are invalid.", "403": "The user is not an admin.", "501": "Runtime resources are not supported."},
+	},
+	"login": {
+		Summary:     "Start a session",
+		Description: "Authenticates a local user and returns a session token lasting session-lifetime. Pass it in an \"Authorization: Session <token>\" header instead of credentials.",
+		Responses:   map[string]string{"200": "The session token, its ID and its expiry.", "400": "Sessions are unavailable to the user, or the request is authenticated with a session.", "401": "Authentication failed.", "429": "The client failed to log in too often and is locked out for login-lockout."},
+	},
+	"logout": {
+		Summary:     "End the session of the request",
+		Description: "Revokes the session token authenticating the request.",
+		Responses:   map[string]string{"204": "The session was ended.", "400": "The request is not authenticated with a session.", "404": "The session does not exist."},
+	},
+	"password": {
+		Summary:     "Change the password of the authenticating user",
+		Description: "Sets the password of the local user whose basic credentials authenticate the request, subject to the password policy. An expired password is accepted, so users can replace it.",
+		Body: &openAPIBody{
+			Required: true,
+			Content: map[string]*openAPISchema{
+				"application/json": {
+					Type:       "object",
+					Properties: map[string]*openAPISchema{"password": openAPIString},
+					Required:   []string{"password"},
+				},
+			},
+		},
+		Responses: map[string]string{"204": "The password was changed.", "400": "The new password breaks the password policy.", "401": "Authentication failed.", "429": "The client failed to log in too often and is locked out for login-lockout."},
+	},
+	"enrollment-tokens": {
+		Summary:     "Issue a bootstrap token for agent enrollment",
+		Description: "The token can be redeemed once, on any node, for a client certificate issued to the named agent and allowing writes to the databases. The lifetime defaults to the token-lifetime setting.",
+		Body: &openAPIBody{
+			Required: true,
+			Content: map[string]*openAPISchema{
+				"application/json": {
+					Type: "object",
+					Properties: map[string]*openAPISchema{
+						"name":      openAPIString,
+						"databases": {Type: "array", Items: openAPIString},
+						"lifetime":  openAPIString,
+					},
+					Required: []string{"name", "databases"},
+				},
+			},
+		},
+		Responses: map[string]string{"201": "The token and its expiry.", "400": "The request is invalid.", "403": "The user is not an admin.", "501": "Enrollment is disabled."},
+	},
+	"enroll": {
+		Summary:     "Redeem a bootstrap token for a client certificate",
+		Description: "The certificate is signed for the public key of the PEM certificate request and issued to the agent named by the token. Requests made with it over HTTPS may write to the databases of the token until it expires or is revoked.",
+		Body: &openAPIBody{
+			Required: true,
+			Content: map[string]*openAPISchema{
+				"application/json": {
+					Type:       "object",
+					Properties: map[string]*openAPISchema{"token": openAPIString, "csr": openAPIString},
+					Required:   []string{"token", "csr"},
+				},
+			},
+		},
+		Responses: map[string]string{"200": "The PEM certificate and CA certificate.", "400": "The certificate request is invalid.", "401": "The token is invalid or expired.", "501": "Enrollment is disabled."},
+	},
+	"enrollment-agents": {
+		Summary:     "List the certificates issued to enrolled agents",
+		Description: "Returns the certificates that have not expired, including revoked ones.",
+		Responses:   map[string]string{"200": "The certificates.", "403": "The user is not an admin.", "501": "Enrollment is disabled."},
+	},
+	"enrollment-agent-revoke": {
+		Summary:     "Revoke the certificates of an enrolled agent",
+		Description: "Every node refuses the certificates issued to the agent, and its tokens not redeemed yet are dropped. Enroll the agent with a new token to issue it a new certificate.",
+		Responses:   map[string]string{"204": "The certificates were revoked.", "403": "The user is not an admin.", "404": "The agent was never enrolled.", "501": "Enrollment is disabled."},
+	},
+	"flux-read": {
+		Summary: "Execute a Flux query",
+		Body: &openAPIBody{
+			Description: "Flux query, either as JSON or as the raw query.",
+			Required:    true,
+			Content: map[string]*openAPISchema{
+				"application/json": {
+					Type:       "object",
+					Properties: map[string]*openAPISchema{"query": openAPIString},
+					Required:   []string{"query"},
+				},
+				"application/vnd.flux": openAPIString,
+			},
+		},
+		Responses: map[string]string{"200": "Results as annotated CSV.", "400": "The query is invalid.", "403": "Flux is disabled."},
+	},
+	"flux-lsp": {
+		Summary:     "Serve the Flux language server over a WebSocket",
+		Description: "Upgrades the connection to a WebSocket serving the Language Server Protocol for Flux scripts: completion of buckets, measurements, tags, fields and functions, hover documentation and diagnostics. Each message is a JSON-RPC 2.0 message.",
+		Responses: map[string]string{
+			"101": "Switching to the WebSocket protocol.",
+			"403": "Flux is disabled.",
+		},
+	},
+	"shards": {
+		Summary:     "List the shards of the cluster",
+		Description: "Returns the owners and time range of every shard that is not deleted. The size in bytes is only returned for the shards stored on the node serving the request.",
+		Responses:   map[string]string{"200": "The shards.", "403": "The user is not an admin."},
+	},
+	"shard-files": {
+		Summary:     "List the data files of a shard",
+		Description: "Returns the TSM files of a shard stored on the node serving the request.",
+		Responses:   map[string]string{"200": "The files.", "400": "The shard ID is invalid.", "403": "The user is not an admin.", "404": "The shard is not stored on this node."},
+	},
+	"shards-truncate": {
+		Summary:     "End the current shard groups of a database",
+		Description: "Truncates the shard groups of a database, or of one of its retention policies, still accepting writes at the current time, so new writes go to new shard groups placed on the current data nodes.",
+		Parameters: []openAPIParameter{
+			{Name: "db", In: "query", Description: "Database whose shard groups are truncated.", Required: true, Schema: openAPIString},
+			{Name: "rp", In: "query", Description: "Retention policy whose shard groups are truncated, every retention policy of the database if not set.", Schema: openAPIString},
+		},
+		Responses: map[string]string{"204": "The shard groups were truncated.", "400": "The database is missing.", "403": "The user is not an admin.", "404": "The database or retention policy does not exist."},
+	},
+	"modes": {
+		Summary:     "List the operational modes of the data nodes and databases",
+		Description: "Returns whether each data node is read-only, with the reason, and in maintenance, and whether each database is frozen.",
+		Responses:   map[string]string{"200": "The modes.", "403": "The user is not an admin."},
+	},
+	"node-mode": {
+		Summary:     "Set the operational mode of a data node",
+		Description: "A read-only node rejects writes with a 503 and the reason. A node in maintenance is not queried for the shards it owns, unless no other owner of a shard is available. The mode is kept across restarts.",
+		Body: &openAPIBody{
+			Required: true,
+			Content:  map[string]*openAPISchema{"application/json": openAPINodeMode},
+		},
+		Responses: map[string]string{"204": "The mode was set.", "400": "The request is invalid.", "403": "The user is not an admin.", "404": "The node does not exist."},
+	},
+	"database-mode": {
+		Summary:     "Set the operational mode of a database",
+		Description: "A frozen database rejects writes with a 403, and its shard groups are not deleted by retention enforcement or eviction. The mode is kept across restarts.",
+		Body: &openAPIBody{
+			Required: true,
+			Content:  map[string]*openAPISchema{"application/json": openAPIDatabaseMode},
+		},
+		Responses: map[string]string{"204": "The mode was set.", "400": "The request is invalid.", "403": "The user is not an admin.", "404": "The database does not exist."},
+	},
+	"placement": {
+		Summary:     "List the labels of the data nodes and the placements of the databases",
+		Description: "Returns the labels each data node declared, such as its zone and rack, and the labels required of the owners of the shards of each database and the labels their replicas are spread by.",
+		Responses:   map[string]string{"200": "The labels and placements.", "403": "The user is not an admin."},
+	},
+	"database-placement": {
+		Summary:     "Set the placement of the shards of a database",
+		Description: "The shards of the shard groups created afterwards are owned only by the data nodes with every label of require, and their replicas are put on nodes with different values of the labels of spread_by, zone and rack by default, where possible.",
+		Body: &openAPIBody{
+			Required: true,
+			Content:  map[string]*openAPISchema{"application/json": openAPIDatabasePlacement},
+		},
+		Responses: map[string]string{"204": "The placement was set.", "400": "The request is invalid.", "403": "The user is not an admin.", "404": "The database does not exist."},
+	},
+	"standby": {
+		Summary:     "Get the state of the standby",
+		Description: "Returns the primary the node restores the backups of, when it last restored them, and whether it was promoted.",
+		Responses:   map[string]string{"200": "The state of the standby.", "403": "The user is not an admin.", "501": "The node is not a standby."},
+	},
+	"standby-promote": {
+		Summary:     "Promote the standby",
+		Description: "Stops restoring the backups of the primary, once the restore in progress is done, and makes the node writable. The promotion is kept across restarts.",
+		Responses:   map[string]string{"200": "The state of the promoted standby.", "403": "The user is not an admin.", "500": "The standby could not be promoted.", "501": "The node is not a standby."},
+	},
+	"subscriptions": {
+		Summary:     "List the subscriptions on this node",
+		Description: "Returns the filter, transformation and state of each subscription on the node serving the request, with the points written, filtered out and dropped, the write failures, and the points written to each destination.",
+		Responses:   map[string]string{"200": "The subscriptions.", "403": "The user is not an admin.", "501": "The subscriber service is disabled."},
+	},
+	"subscription-state": {
+		Summary:     "Pause or resume a subscription",
+		Description: "A paused subscription drops the points written to it until it is resumed. The state is kept in the meta data, so it applies to every node and is kept across restarts.",
+		Body: &openAPIBody{
+			Required: true,
+			Content:  map[string]*openAPISchema{"application/json": openAPISubscriptionState},
+		},
+		Responses: map[string]string{"204": "The state was set.", "400": "The request is invalid.", "403": "The user is not an admin.", "404": "The subscription does not exist.", "501": "The subscriber service is disabled."},
+	},
+	"features": {
+		Summary:     "List the features of the cluster protocol supported by the data nodes",
+		Description: "Returns the version, protocol version and features of each data node, and the effective features supported by every node that could be reached. During a rolling upgrade, the nodes are only sent the messages they support.",
+		Responses:   map[string]string{"200": "The features.", "403": "The user is not an admin.", "500": "The data nodes could not be listed.", "501": "Features are not negotiated on this node."},
+	},
+	"tag-values": {
+		Summary: "List the values of a tag key for autocompletion",
+		Parameters: []openAPIParameter{
+			openAPIDatabase,
+			{Name: "measurement", In: "query", Description: "Measurement of the tag key.", Required: true, Schema: openAPIString},
+			{Name: "key", In: "query", Description: "Tag key.", Required: true, Schema: openAPIString},
+			{Name: "match", In: "query", Description: "Only return the values starting with this prefix.", Schema: openAPIString},
+			{Name: "limit", In: "query", Description: "Maximum number of values, capped by max-tag-values-limit.", Schema: openAPIInteger},
+		},
+		Responses: map[string]string{"200": "The values in order, and whether more values match.", "400": "The request is invalid.", "403": "The user cannot read the database.", "404": "The database does not exist."},
+	},
+	"dead-letter": {
+		Summary:     "List the points rejected by writes to a database",
+		Description: "Returns the most recent points rejected by parse errors, field type conflicts or limits, newest first, with the reasons they were rejected and the ids of the requests that wrote them. Requires dead letters to be enabled.",
+		Parameters: []openAPIParameter{
+			openAPIDatabase,
+			{Name: "limit", In: "query", Description: "Maximum number of points.", Schema: openAPIInteger},
+		},
+		Responses: map[string]string{"200": "The rejected points, and whether more were rejected.", "400": "The request is invalid.", "403": "The user cannot read the database.", "404": "Dead letters are not enabled."},
+	},
+	"usage": {
+		Summary:     "Report the usage of users and databases",
+		Description: "Returns the points written, queries executed and time spent executing queries of each user on each database, and the bytes stored by each database, by time window. Usage is read from the statistics the monitor writes to its database, so it requires the monitor to store statistics. Users who are not admins only get their own usage.",
+		Parameters: []openAPIParameter{
+			openAPIDatabase,
+			{Name: "user", In: "query", Description: "User to report the usage of.", Schema: openAPIString},
+			{Name: "start", In: "query", Description: "Start of the period, in RFC3339. Defaults to 24 hours before the end.", Schema: openAPIString},
+			{Name: "end", In: "query", Description: "End of the period, in RFC3339. Defaults to now.", Schema: openAPIString},
+			{Name: "window", In: "query", Description: "Duration of the windows usage is reported in. Defaults to 1h.", Schema: openAPIString},
+		},
+		Responses: map[string]string{"200": "The usage and storage by window.", "400": "The request is invalid.", "403": "The user cannot read the usage of other users."},
+	},
+	"transpile": {
+		Summary:     "Translate InfluxQL to Flux",
+		Description: "Returns the Flux script equivalent to each statement of the query, or the reason it cannot be translated. The statements are not executed.",
+		Parameters:  []openAPIParameter{openAPIQuery, openAPIDatabase, openAPIRetentionPolicy},
+		Responses:   map[string]string{"200": "The Flux script of each statement.", "400": "The query is invalid."},
+	},
+	"ui": {
+		Summary:     "Web UI",
+		Description: "Page for running queries, managing databases, retention policies and users, and viewing cardinality. Served when ui-enabled is set.",
+		Responses:   map[string]string{"200": "The HTML page."},
+	},
+	"openapi": {
+		Summary:   "OpenAPI document of the HTTP API",
+		Responses: map[string]string{"200": "This document."},
+	},
+}
+
+// openAPIDocument returns the OpenAPI 3 document of the routes of h.
+func (h *Handler) openAPIDocument() map[string]interface{} {
+	paths := make(map[string]map[string]interface{})
+	for _, r := range h.routes {
+		// OPTIONS routes only satisfy CORS checks.
+		if r.Method == http.MethodOptions {
+			continue
+		}
+
+		path, params := openAPIPath(r.Pattern)
+		op := openAPIOperations[r.Name]
+		o := map[string]interface{}{
+			"operationId": r.Name + "-" + strings.ToLower(r.Method),
+			"summary":     op.Summary,
+		}
+		if op.Description != "" {
+			o["description"] = op.Description
+		}
+		if op.Deprecated {
+			o["deprecated"] = true
+		}
+		if params = append(params, op.Parameters...); len(params) > 0 {
+			o["parameters"] = params
+		}
+		if op.Body != nil {
+			content := make(map[string]interface{})
+			for typ, schema := range op.Body.Content {
+				content[typ] = map[string]interface{}{"schema": schema}
+			}
+			o["requestBody"] = map[string]interface{}{
+				"description": op.Body.Description,
+				"required":    op.Body.Required,
+				"content":     content,
+			}
+		}
+
+		responses := make(map[string]interface{})
+		for code, desc := range op.Responses {
+			resp := map[string]interface{}{"description": desc}
+			if code[0] == '4' || code[0] == '5' {
+				resp["content"] = map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": openAPIErrorSchema},
+				}
+			}
+			responses[code] = resp
+		}
+		if len(responses) == 0 {
+			responses["default"] = map[string]interface{}{"description": "Response of the operation."}
+		}
+		o["responses"] = responses
+
+		// Handlers taking the user authenticate the request.
+		if _, ok := r.HandlerFunc.(func(http.ResponseWriter, *http.Request, meta.User)); ok && h.Config.AuthEnabled {
+			o["security"] = []map[string][]string{
+				{"basicAuth": {}},
+				{"bearerAuth": {}},
+				{"username": {}, "password": {}},
+			}
+		}
+
+		if paths[path] == nil {
+			paths[path] = make(map[string]interface{})
+		}
+		paths[path][strings.ToLower(r.Method)] = o
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "FreeTSDB HTTP API",
+			"version": h.Version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"basicAuth":  map[string]string{"type": "http", "scheme": "basic"},
+				"bearerAuth": map[string]string{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"username":   map[string]string{"type": "apiKey", "in": "query", "name": "u"},
+				"password":   map[string]string{"type": "apiKey", "in": "query", "name": "p"},
+			},
+		},
+	}
+}
+
+// openAPIPath converts a route pattern to an OpenAPI path, and returns the
+// parameters of its named segments.
+func openAPIPath(pattern string) (string, []openAPIParameter) {
+	var params []openAPIParameter
+	segments := strings.Split(pattern, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") {
+			name := s[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, openAPIParameter{Name: name, In: "path", Required: true, Schema: openAPIString})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// serveOpenAPI returns the OpenAPI document of the HTTP API.
+func (h *Handler) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(h.openAPIDocument())
+}
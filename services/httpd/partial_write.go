@@ -0,0 +1,81 @@
+package httpd
+
+import (
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/tsdb"
+)
+
+// PartialWrite identifies the points of a batch that were not written, so
+// that clients can retry only those. Points are counted from 0 in the order
+// of the batch, skipping blank lines and comments.
+type PartialWrite struct {
+	// Points is the number of points in the batch.
+	Points int `json:"points"`
+
+	// Failed is a bitmap with the bit i%8 of byte i/8 set when point i was
+	// not written. It is encoded in base64 in JSON.
+	Failed []byte `json:"failed"`
+
+	// Errors groups the indexes of the points that were not written by the
+	// reason they were not.
+	Errors []PartialWriteReason `json:"errors"`
+
+	// Unknown is the number of points that were dropped without being
+	// identified, such as the points dropped by other nodes.
+	Unknown int `json:"unknown,omitempty"`
+
+	// reasons maps the reasons to their index in Errors.
+	reasons map[string]int
+}
+
+// PartialWriteReason is a reason points of a batch were not written.
+type PartialWriteReason struct {
+	Reason  string `json:"reason"`
+	Indexes []int  `json:"indexes"`
+}
+
+// Add marks the point at index as failed for reason.
+func (w *PartialWrite) Add(index int, reason string) {
+	w.Failed[index/8] |= 1 << uint(index%8)
+	if i, ok := w.reasons[reason]; ok {
+		w.Errors[i].Indexes = append(w.Errors[i].Indexes, index)
+		return
+	}
+	w.reasons[reason] = len(w.Errors)
+	w.Errors = append(w.Errors, PartialWriteReason{Reason: reason, Indexes: []int{index}})
+}
+
+// IsFailed returns true if the point at index was not written.
+func (w *PartialWrite) IsFailed(index int) bool {
+	return w.Failed[index/8]&(1<<uint(index%8)) != 0
+}
+
+// newPartialWrite returns the status of a batch of n points.
+func newPartialWrite(n int) *PartialWrite {
+	return &PartialWrite{
+		Points:  n,
+		Failed:  make([]byte, (n+7)/8),
+		Errors:  []PartialWriteReason{},
+		reasons: make(map[string]int),
+	}
+}
+
+// addDropped marks the points dropped by err as failed. points are the points
+// that were written and indexes their indexes in the batch.
+func (w *PartialWrite) addDropped(err tsdb.PartialWriteError, points []models.Point, indexes []int) {
+	index := make(map[models.Point]int, len(points))
+	for i, p := range points {
+		index[p] = indexes[i]
+	}
+
+	unknown := err.Dropped
+	for _, dp := range err.Points {
+		if i, ok := index[dp.Point]; ok && !w.IsFailed(i) {
+			w.Add(i, dp.Reason)
+			unknown--
+		}
+	}
+	if unknown > 0 {
+		w.Unknown += unknown
+	}
+}
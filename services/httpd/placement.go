@@ -0,0 +1,98 @@
+package httpd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/freetsdb/freetsdb/services/audit"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// nodeLabels is the JSON encoding of the labels of a data node.
+type nodeLabels struct {
+	ID     uint64            `json:"id"`
+	Labels map[string]string `json:"labels"`
+}
+
+// databasePlacement is the JSON encoding of the placement of the shards of a
+// database.
+type databasePlacement struct {
+	Name     string            `json:"name,omitempty"`
+	Require  map[string]string `json:"require,omitempty"`
+	SpreadBy []string          `json:"spread_by,omitempty"`
+}
+
+// servePlacement returns the labels of the data nodes and the placements of
+// the databases.
+func (h *Handler) servePlacement(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+
+	nodes, err := h.MetaClient.DataNodes()
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	dis, err := h.MetaClient.Databases()
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := struct {
+		Nodes     []nodeLabels        `json:"nodes"`
+		Databases []databasePlacement `json:"databases"`
+	}{
+		Nodes:     make([]nodeLabels, 0, len(nodes)),
+		Databases: make([]databasePlacement, 0, len(dis)),
+	}
+	for _, n := range nodes {
+		labels := n.Labels
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		resp.Nodes = append(resp.Nodes, nodeLabels{ID: n.ID, Labels: labels})
+	}
+	for _, di := range dis {
+		resp.Databases = append(resp.Databases, databasePlacement{
+			Name:     di.Name,
+			Require:  di.Placement.Require,
+			SpreadBy: di.Placement.SpreadBy,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// serveSetDatabasePlacement sets the placement of the shards of a database.
+func (h *Handler) serveSetDatabasePlacement(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+
+	name := r.URL.Query().Get(":name")
+	var p databasePlacement
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		h.httpError(w, "error parsing placement: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if h.MetaClient.Database(name) == nil {
+		h.writeError(w, Response{Err: fmt.Errorf("%w: %q", errDatabaseNotFound, name)}, http.StatusNotFound)
+		return
+	}
+
+	err := h.MetaClient.SetDatabasePlacement(name, meta.PlacementInfo{Require: p.Require, SpreadBy: p.SpreadBy})
+	h.auditRequest(r, user, audit.CategoryAdmin, name, err)
+	if err != nil && err.Error() == meta.ErrNodeLabelKeyRequired.Error() {
+		h.httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.writeHeader(w, http.StatusNoContent)
+}
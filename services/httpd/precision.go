@@ -0,0 +1,104 @@
+package httpd
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/freetsdb/freetsdb/models"
+)
+
+// The actions timestamp-check takes on points whose timestamps look like they
+// were written in a different precision than the write declared.
+const (
+	timestampCheckOff     = "off"
+	timestampCheckReject  = "reject"
+	timestampCheckCorrect = "correct"
+	timestampCheckTag     = "tag"
+)
+
+// timestampCheckTagKey is the tag added to suspect points by the "tag"
+// action. Its value is the precision the timestamp was likely written in.
+const timestampCheckTagKey = "suspect_precision"
+
+// timestampPrecisions are the precisions a timestamp may have been written in.
+var timestampPrecisions = []string{"n", "u", "ms", "s"}
+
+// validateTimestampCheck returns an error if action is not a timestamp-check action.
+func validateTimestampCheck(action string) error {
+	switch action {
+	case "", timestampCheckOff, timestampCheckReject, timestampCheckCorrect, timestampCheckTag:
+		return nil
+	default:
+		return fmt.Errorf("timestamp-check must be one of %q, %q, %q or %q: %q",
+			timestampCheckOff, timestampCheckReject, timestampCheckCorrect, timestampCheckTag, action)
+	}
+}
+
+// guessPrecision returns the precision that places the value of timestamp t,
+// written in the given precision, nearest to now, and the time it then has.
+// Precisions are a thousand times apart, so a timestamp written in another
+// precision lands decades away from now.
+func guessPrecision(t, now int64, precision string) (string, int64) {
+	v := t / models.GetPrecisionMultiplier(precision)
+	if v <= 0 || now <= 0 {
+		return precision, t
+	}
+
+	distance := func(t int64) float64 {
+		return math.Abs(math.Log10(float64(t)) - math.Log10(float64(now)))
+	}
+
+	guess, guessTime, min := precision, t, distance(t)
+	for _, p := range timestampPrecisions {
+		m := models.GetPrecisionMultiplier(p)
+		if v > math.MaxInt64/m {
+			continue
+		}
+		if d := distance(v * m); d < min {
+			guess, guessTime, min = p, v*m, d
+		}
+	}
+	return guess, guessTime
+}
+
+// checkTimestamps applies the timestamp-check action to the points whose
+// timestamps look like they were written in another precision. indexes are the
+// indexes of points in the batch. It returns the points to write, their
+// indexes, and the indexes of the points that were rejected.
+func (h *Handler) checkTimestamps(points []models.Point, indexes []int, now int64, precision string) ([]models.Point, []int, []int) {
+	action := h.Config.TimestampCheck
+	if action == "" || action == timestampCheckOff {
+		return points, indexes, nil
+	}
+	if precision == "" || precision == "ns" {
+		precision = "n"
+	}
+
+	var rejected []int
+	checked, checkedIndexes := points[:0], indexes[:0]
+	for i, p := range points {
+		index := indexes[i]
+		guess, t := guessPrecision(p.UnixNano(), now, precision)
+		if guess == precision {
+			checked, checkedIndexes = append(checked, p), append(checkedIndexes, index)
+			continue
+		}
+
+		switch action {
+		case timestampCheckReject:
+			atomic.AddInt64(&h.stats.PointsTimeRejected, 1)
+			rejected = append(rejected, index)
+			continue
+		case timestampCheckCorrect:
+			atomic.AddInt64(&h.stats.PointsTimeCorrected, 1)
+			p.SetTime(time.Unix(0, t))
+		case timestampCheckTag:
+			atomic.AddInt64(&h.stats.PointsTimeTagged, 1)
+			p.AddTag(timestampCheckTagKey, guess)
+		}
+		checked, checkedIndexes = append(checked, p), append(checkedIndexes, index)
+	}
+	return checked, checkedIndexes, rejected
+}
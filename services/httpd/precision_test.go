@@ -0,0 +1,37 @@
+package httpd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGuessPrecision(t *testing.T) {
+	now := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+	ts := time.Date(2019, 5, 31, 12, 0, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		name      string
+		t         int64
+		precision string
+		guess     string
+		time      int64
+	}{
+		{name: "Nanoseconds", t: ts.UnixNano(), precision: "n", guess: "n", time: ts.UnixNano()},
+		{name: "SecondsAsNanoseconds", t: ts.Unix(), precision: "n", guess: "s", time: ts.UnixNano()},
+		{name: "MillisecondsAsNanoseconds", t: ts.UnixNano() / int64(time.Millisecond), precision: "n", guess: "ms", time: ts.UnixNano()},
+		{name: "SecondsAsMilliseconds", t: ts.Unix() * int64(time.Millisecond), precision: "ms", guess: "s", time: ts.UnixNano()},
+		{name: "Hours", t: ts.UnixNano(), precision: "h", guess: "h", time: ts.UnixNano()},
+		{name: "Historical", t: time.Date(1975, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano(), precision: "n", guess: "n", time: time.Date(1975, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano()},
+		{name: "Epoch", t: 0, precision: "n", guess: "n", time: 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			guess, ts := guessPrecision(tt.t, now, tt.precision)
+			if guess != tt.guess {
+				t.Errorf("unexpected precision: got=%s exp=%s", guess, tt.guess)
+			}
+			if ts != tt.time {
+				t.Errorf("unexpected time: got=%s exp=%s", time.Unix(0, ts).UTC(), time.Unix(0, tt.time).UTC())
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package httpd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+type contextKey int
+
+const (
+	queryPriorityContextKey contextKey = iota
+)
+
+// withQueryPriority returns a copy of r that assigns its queries priority p.
+func withQueryPriority(r *http.Request, p query.QueryPriority) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), queryPriorityContextKey, p))
+}
+
+// queryPriority returns the priority the queries in r are admitted with.
+//
+// The priority is the default-query-priority, or the priority configured for
+// the user with query-priority. The operator's setting wins: the "priority"
+// claim of the token the request was authenticated with, and then the
+// "priority" parameter, can each lower it but not raise it, and trying to
+// raise it is an error rather than being ignored.
+func (h *Handler) queryPriority(r *http.Request, user meta.User) (query.QueryPriority, error) {
+	p, err := query.ParseQueryPriority(h.Config.DefaultQueryPriority)
+	if err != nil {
+		return 0, err
+	}
+
+	if user != nil {
+		for _, up := range h.Config.QueryPriorities {
+			if up.User == user.ID() {
+				if p, err = query.ParseQueryPriority(up.Priority); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+
+	if tp, ok := r.Context().Value(queryPriorityContextKey).(query.QueryPriority); ok {
+		if tp < p {
+			return 0, fmt.Errorf("token query priority %s is not permitted, the highest allowed is %s", tp, p)
+		}
+		p = tp
+	}
+
+	if s := r.FormValue("priority"); s != "" {
+		requested, err := query.ParseQueryPriority(s)
+		if err != nil {
+			return 0, err
+		} else if requested < p {
+			return 0, fmt.Errorf("query priority %s is not permitted, the highest allowed is %s", requested, p)
+		}
+		p = requested
+	}
+	return p, nil
+}
@@ -0,0 +1,43 @@
+package httpd
+
+import (
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/services/grpc/wire"
+	"github.com/gogo/protobuf/proto"
+)
+
+// protobufContentType is the content type of writes holding a wire.Points
+// message instead of line protocol.
+const protobufContentType = "application/x-protobuf"
+
+// isProtobufWrite returns true if the body of the write request r is a
+// wire.Points message.
+func isProtobufWrite(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == protobufContentType
+}
+
+// parseProtobufPoints decodes the wire.Points message b. The times of the
+// points are in the given precision and points without a time are given now.
+func parseProtobufPoints(b []byte, now time.Time, precision string) ([]models.Point, error) {
+	var pb wire.Points
+	if err := proto.Unmarshal(b, &pb); err != nil {
+		return nil, err
+	}
+
+	for _, p := range pb.Points {
+		if p.Time == 0 {
+			continue
+		}
+		t, err := models.SafeCalcTime(p.Time, precision)
+		if err != nil {
+			return nil, err
+		}
+		p.Time = t.UnixNano()
+	}
+	return wire.ModelsPoints(pb.Points, now)
+}
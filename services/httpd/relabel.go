@@ -0,0 +1,26 @@
+package httpd
+
+import (
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/pkg/relabel"
+)
+
+// SetRelabelRules replaces the relabeling rules applied to written points.
+// The current rules are kept if rules are invalid.
+func (h *Handler) SetRelabelRules(rules []relabel.Rule) error {
+	return h.relabeler.SetRules(rules)
+}
+
+// relabelPoints applies the relabeling rules to points, whose indexes in the
+// batch are indexes. Points dropped by the rules are removed without being
+// reported as failed.
+func (h *Handler) relabelPoints(points []models.Point, indexes []int) ([]models.Point, []int) {
+	kept, keptIdx := points[:0], indexes[:0]
+	for i, p := range points {
+		if p, ok := h.relabeler.Relabel(p); ok {
+			kept = append(kept, p)
+			keptIdx = append(keptIdx, indexes[i])
+		}
+	}
+	return kept, keptIdx
+}
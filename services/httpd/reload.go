@@ -0,0 +1,51 @@
+package httpd
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/freetsdb/freetsdb/services/audit"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// SetWriteLimits replaces the write throttler with one using the given
+// limits. Requests already being throttled finish under the old limits.
+func (h *Handler) SetWriteLimits(concurrentN, maxEnqueueN int, enqueueTimeout time.Duration) {
+	t := NewThrottler(concurrentN, maxEnqueueN)
+	t.EnqueueTimeout = enqueueTimeout
+	t.Logger = h.Logger
+
+	h.throttleMu.Lock()
+	h.writeThrottler = t
+	h.throttleMu.Unlock()
+}
+
+// throttleWrites wraps next in the current write throttler.
+func (h *Handler) throttleWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.throttleMu.RLock()
+		t := h.writeThrottler
+		h.throttleMu.RUnlock()
+		t.Handler(next).ServeHTTP(w, r)
+	})
+}
+
+// serveReloadConfig reloads the server configuration.
+func (h *Handler) serveReloadConfig(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+	if h.ConfigReloader == nil {
+		h.httpError(w, "configuration reload is not supported", http.StatusNotImplemented)
+		return
+	}
+
+	err := h.ConfigReloader.ReloadConfig()
+	h.auditRequest(r, user, audit.CategoryAdmin, "", err)
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.writeHeader(w, http.StatusNoContent)
+}
@@ -0,0 +1,56 @@
+package httpd
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/freetsdb/freetsdb/services/audit"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// serveResources returns the runtime resource settings and the CPU topology.
+func (h *Handler) serveResources(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+	if h.Resources == nil {
+		h.httpError(w, "runtime resources are not supported", http.StatusNotImplemented)
+		return
+	}
+
+	h.writeResources(w)
+}
+
+// serveUpdateResources changes the runtime resource settings given in the
+// request body. Settings missing from the body are unchanged.
+func (h *Handler) serveUpdateResources(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+	if h.Resources == nil {
+		h.httpError(w, "runtime resources are not supported", http.StatusNotImplemented)
+		return
+	}
+
+	c := h.Resources.State().Settings
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		h.httpError(w, "error parsing settings: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := h.Resources.Apply(c)
+	h.auditRequest(r, user, audit.CategoryAdmin, "", err)
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.writeResources(w)
+}
+
+// writeResources writes the state of the runtime resources.
+func (h *Handler) writeResources(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	json.NewEncoder(w).Encode(h.Resources.State())
+}
@@ -1,6 +1,7 @@
 package httpd
 
 import (
+	"bufio"
 	"fmt"
 	"net"
 	"net/http"
@@ -35,6 +36,15 @@ func (l *responseLogger) Flush() {
 	l.w.(http.Flusher).Flush()
 }
 
+func (l *responseLogger) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := l.w.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T does not support hijacking", l.w)
+	}
+	l.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
 func (l *responseLogger) Write(b []byte) (int, error) {
 	if l.status == 0 {
 		// Set status if WriteHeader has not been called
@@ -1,9 +1,12 @@
 package httpd
 
 import (
+	"bufio"
 	"encoding/csv"
 	"encoding/json"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"strconv"
 	"time"
@@ -88,6 +91,14 @@ func (w *responseWriter) CloseNotify() <-chan bool {
 	return nil
 }
 
+// Hijack hijacks the connection of the underlying http.ResponseWriter.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, fmt.Errorf("%T does not support hijacking", w.ResponseWriter)
+}
+
 type jsonFormatter struct {
 	Pretty bool
 }
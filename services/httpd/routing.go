@@ -0,0 +1,63 @@
+package httpd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/freetsdb/freetsdb/coordinator"
+	"github.com/freetsdb/freetsdb/pkg/routing"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/tsdb"
+)
+
+// SetRoutes replaces the rules routing written points to other databases.
+// The current rules are kept if rules are invalid.
+func (h *Handler) SetRoutes(rules []routing.Rule) error {
+	return h.router.SetRules(rules)
+}
+
+// authorizeRoutes returns an error if user cannot write to one of the
+// databases of batches other than database, which is already authorized.
+func (h *Handler) authorizeRoutes(user meta.User, database string, batches []routing.Batch) error {
+	if !h.Config.AuthEnabled {
+		return nil
+	}
+	for _, b := range batches {
+		if b.Database == database {
+			continue
+		}
+		if err := h.authorizeWrite(user, b.Database); err != nil {
+			return fmt.Errorf("%q user is not authorized to write to database %q", user.ID(), b.Database)
+		}
+	}
+	return nil
+}
+
+// writeBatches writes each of batches to its destination. Idempotency keys
+// are scoped by the destination of the batch, so the key of the request is
+// used for each of them. The errors of partial writes are merged, and any
+// other error is returned once every batch was written.
+func (h *Handler) writeBatches(ctx context.Context, key string, consistency coordinator.ConsistencyLevel, user meta.User, batches []routing.Batch) error {
+	var partial *tsdb.PartialWriteError
+	var firstErr error
+	for _, b := range batches {
+		err := h.PointsWriter.WritePointsIdempotent(ctx, key, b.Database, b.RetentionPolicy, consistency, user, b.Points)
+		h.usage.addWriteResult(userID(user), b.Database, len(b.Points), err)
+		if werr, ok := err.(tsdb.PartialWriteError); ok {
+			if partial == nil {
+				partial = &werr
+			} else {
+				*partial = tsdb.MergePartialWriteErrors(*partial, werr)
+			}
+		} else if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if firstErr != nil {
+		return firstErr
+	} else if partial != nil {
+		return *partial
+	}
+	return nil
+}
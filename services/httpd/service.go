@@ -15,6 +15,8 @@ import (
 
 	"github.com/freetsdb/freetsdb/models"
 	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // statistics gathered by the httpd package.
@@ -42,6 +44,14 @@ const (
 	statPromReadRequest              = "promReadReq"            // Number of read requests to the prometheus endpoint.
 	statFluxQueryRequests            = "fluxQueryReq"           // Number of flux query requests served.
 	statFluxQueryRequestDuration     = "fluxQueryReqDurationNs" // Number of (wall-time) nanoseconds spent executing Flux query requests.
+	statQueryStreamsActive           = "streamsActive"          // Number of currently open query streams.
+	statQueryStreamPointsDropped     = "streamPointsDropped"    // Number of points dropped for query streams that did not keep up.
+	statPointsTimeRejected           = "pointsTimeRejected"     // Number of points rejected because of their timestamp precision.
+	statPointsTimeCorrected          = "pointsTimeCorrected"    // Number of points whose timestamp precision was corrected.
+	statPointsTimeTagged             = "pointsTimeTagged"       // Number of points tagged with a suspect timestamp precision.
+	statPointsDeadLettered           = "pointsDeadLettered"     // Number of rejected points written to their database.
+	statPointsDeadLetterDropped      = "deadLettersDropped"     // Number of rejected points dropped as too many were waiting to be written.
+	statPointsMerged                 = "pointsMerged"           // Number of points whose fields were merged into another point of their batch.
 
 )
 
@@ -62,8 +72,24 @@ type Service struct {
 	bindSocket         string
 	unixSocketListener net.Listener
 
+	// listeners are the additional listeners, serving only the routes of
+	// their capabilities.
+	listeners []ListenerConfig
+	lns       []net.Listener
+
+	readTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	maxHeaderBytes    int
+	http2             bool
+	http2MaxStreams   uint32
+
 	Handler *Handler
 
+	// EnrollmentMetaClient stores the enrollment of agents, if enabled.
+	EnrollmentMetaClient EnrollmentMetaClient
+
 	Logger *zap.Logger
 }
 
@@ -80,12 +106,26 @@ func NewService(c Config) *Service {
 		unixSocket:     c.UnixSocketEnabled,
 		unixSocketPerm: uint32(c.UnixSocketPermissions),
 		bindSocket:     c.BindSocket,
+		listeners:      c.Listeners,
 		Handler:        NewHandler(c),
 		Logger:         zap.NewNop(),
+
+		readTimeout:       time.Duration(c.ReadTimeout),
+		readHeaderTimeout: time.Duration(c.ReadHeaderTimeout),
+		writeTimeout:      time.Duration(c.WriteTimeout),
+		idleTimeout:       time.Duration(c.IdleTimeout),
+		maxHeaderBytes:    c.MaxHeaderBytes,
+		http2:             c.HTTP2Enabled,
+		http2MaxStreams:   uint32(c.HTTP2MaxStreams),
 	}
 	if s.tlsConfig == nil {
 		s.tlsConfig = new(tls.Config)
 	}
+	if s.http2 {
+		// Negotiate HTTP/2 on the HTTPS listeners.
+		s.tlsConfig = s.tlsConfig.Clone()
+		s.tlsConfig.NextProtos = []string{http2.NextProtoTLS, "http/1.1"}
+	}
 	if s.key == "" {
 		s.key = s.cert
 	}
@@ -102,30 +142,45 @@ func (s *Service) Open() error {
 
 	s.Handler.Open()
 
+	if c := s.Handler.Config.Enrollment; c.Enabled {
+		e, err := NewEnrollment(c, s.EnrollmentMetaClient)
+		if err != nil {
+			return err
+		}
+		s.Handler.Enrollment = e
+	}
+
 	// Open listener.
+	var tlsConfig *tls.Config
 	if s.https {
 		cert, err := tls.LoadX509KeyPair(s.cert, s.key)
 		if err != nil {
 			return err
 		}
 
-		tlsConfig := s.tlsConfig.Clone()
+		tlsConfig = s.tlsConfig.Clone()
 		tlsConfig.Certificates = []tls.Certificate{cert}
-
-		listener, err := tls.Listen("tcp", s.addr, tlsConfig)
-		if err != nil {
-			return err
+		if e := s.Handler.Enrollment; e != nil {
+			// Agents without a certificate still authenticate otherwise.
+			e.configureTLS(tlsConfig)
 		}
+	}
 
-		s.ln = listener
-	} else {
-		listener, err := net.Listen("tcp", s.addr)
-		if err != nil {
-			return err
-		}
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
 
-		s.ln = listener
+	// Enforce a connection limit if one has been given. The limit wraps the
+	// TCP listener so that the connections served over HTTPS are still TLS
+	// connections, which HTTP/2 and client certificates require.
+	if s.limit > 0 {
+		listener = LimitListener(listener, s.limit)
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
 	}
+	s.ln = listener
 	s.Logger.Info("Listening on HTTP",
 		zap.Stringer("addr", s.ln.Addr()),
 		zap.Bool("https", s.https))
@@ -164,9 +219,20 @@ func (s *Service) Open() error {
 		go s.serveUnixSocket()
 	}
 
-	// Enforce a connection limit if one has been given.
-	if s.limit > 0 {
-		s.ln = LimitListener(s.ln, s.limit)
+	// Open the additional listeners.
+	for _, c := range s.listeners {
+		ln, err := c.listen(s.tlsConfig, s.Handler.Enrollment)
+		if err != nil {
+			return err
+		}
+		s.Logger.Info("Listening on HTTP",
+			zap.Stringer("addr", ln.Addr()),
+			zap.Bool("https", c.HTTPSEnabled),
+			zap.Bool("client_ca", c.HTTPSClientCA != ""),
+			zap.Strings("capabilities", c.Capabilities))
+		s.lns = append(s.lns, ln)
+
+		go s.serve(ln, s.Handler.Restrict(c.Capabilities...))
 	}
 
 	// wait for the listeners to start
@@ -201,6 +267,12 @@ func (s *Service) Close() error {
 			return err
 		}
 	}
+	for _, ln := range s.lns {
+		if err := ln.Close(); err != nil {
+			return err
+		}
+	}
+	s.lns = nil
 	return nil
 }
 
@@ -234,20 +306,56 @@ func (s *Service) BoundHTTPAddr() string {
 
 // serveTCP serves the handler from the TCP listener.
 func (s *Service) serveTCP() {
-	s.serve(s.ln)
+	s.serve(s.ln, s.Handler)
 }
 
 // serveUnixSocket serves the handler from the unix socket listener.
 func (s *Service) serveUnixSocket() {
-	s.serve(s.unixSocketListener)
+	s.serve(s.unixSocketListener, s.Handler)
 }
 
 // serve serves the handler from the listener.
-func (s *Service) serve(listener net.Listener) {
+func (s *Service) serve(listener net.Listener, handler http.Handler) {
+	srv, err := s.newServer(handler)
+	if err != nil {
+		s.err <- fmt.Errorf("listener failed: addr=%s, err=%s", listener.Addr(), err)
+		return
+	}
+
 	// The listener was closed so exit
 	// See https://github.com/golang/go/issues/4373
-	err := http.Serve(listener, s.Handler)
+	err = srv.Serve(listener)
 	if err != nil && !strings.Contains(err.Error(), "closed") {
-		s.err <- fmt.Errorf("listener failed: addr=%s, err=%s", s.Addr(), err)
+		s.err <- fmt.Errorf("listener failed: addr=%s, err=%s", listener.Addr(), err)
+	}
+}
+
+// newServer returns a server of the handler with the timeouts and limits of
+// the service. With HTTP/2 enabled, it is negotiated over TLS (h2) and
+// accepted in clear text (h2c), either with prior knowledge or an upgrade.
+func (s *Service) newServer(handler http.Handler) (*http.Server, error) {
+	srv := &http.Server{
+		Handler:           handler,
+		ReadTimeout:       s.readTimeout,
+		ReadHeaderTimeout: s.readHeaderTimeout,
+		WriteTimeout:      s.writeTimeout,
+		IdleTimeout:       s.idleTimeout,
+		MaxHeaderBytes:    s.maxHeaderBytes,
+		ErrorLog:          zap.NewStdLog(s.Logger),
+	}
+	if !s.http2 {
+		// Serve HTTP/1.1 only, even over TLS.
+		srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+		return srv, nil
+	}
+
+	h2 := &http2.Server{
+		MaxConcurrentStreams: s.http2MaxStreams,
+		IdleTimeout:          s.idleTimeout,
+	}
+	if err := http2.ConfigureServer(srv, h2); err != nil {
+		return nil, err
 	}
+	srv.Handler = h2c.NewHandler(handler, h2)
+	return srv, nil
 }
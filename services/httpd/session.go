@@ -0,0 +1,113 @@
+package httpd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/freetsdb/freetsdb/services/audit"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// errSessionRequired is returned by /logout for requests not authenticated
+// with a session token.
+var errSessionRequired = errors.New("request is not authenticated with a session")
+
+// serveLogin starts a session for the authenticated user. Later requests pass
+// the returned token in an "Authorization: Session <token>" header instead of
+// the user's credentials. Clients failing to log in too often are locked out
+// by limitLogins.
+func (h *Handler) serveLogin(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if user == nil {
+		h.httpError(w, "sessions require authentication to be enabled", http.StatusBadRequest)
+		return
+	}
+
+	// Sessions are stored in the meta store, which only knows local users.
+	if _, ok := user.(*meta.UserInfo); !ok {
+		h.httpError(w, "sessions are only available to local users", http.StatusBadRequest)
+		return
+	}
+
+	// A session only starts from the credentials of the user, so that a
+	// stolen session token cannot extend itself.
+	if creds, err := parseCredentials(r); err == nil && creds.Method == SessionAuthentication {
+		h.httpError(w, "a session cannot be started with a session token", http.StatusBadRequest)
+		return
+	}
+
+	token, si, err := h.MetaClient.CreateSession(user.ID(), time.Duration(h.Config.SessionLifetime))
+	h.auditRequest(r, user, audit.CategoryAuth, "", err)
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Token   string    `json:"token"`
+		ID      string    `json:"id"`
+		Expires time.Time `json:"expires"`
+	}{token, si.ID, si.ExpiresAt})
+}
+
+// serveLogout ends the session authenticating the request.
+func (h *Handler) serveLogout(w http.ResponseWriter, r *http.Request, user meta.User) {
+	creds, err := parseCredentials(r)
+	if err != nil || creds.Method != SessionAuthentication {
+		h.httpError(w, errSessionRequired.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.MetaClient.DropSession(meta.SessionID(creds.Token))
+	h.auditRequest(r, user, audit.CategoryAuth, "", err)
+	// Errors returned by the meta service are matched by message.
+	if err != nil && err.Error() == meta.ErrSessionNotFound.Error() {
+		h.httpError(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.writeHeader(w, http.StatusNoContent)
+}
+
+// servePassword changes the password of the user whose basic credentials
+// authenticate the request. It is not behind the authentication of the other
+// routes, which rejects expired passwords, so that users can replace an
+// expired password themselves.
+func (h *Handler) servePassword(w http.ResponseWriter, r *http.Request) {
+	creds, err := parseCredentials(r)
+	if err != nil || creds.Method != UserAuthentication || creds.Username == "" {
+		atomic.AddInt64(&h.stats.AuthenticationFailures, 1)
+		h.authenticationFailed(w, r, "username and password required")
+		return
+	}
+
+	var body struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.httpError(w, "error parsing password: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.MetaClient.ChangePassword(creds.Username, creds.Password, body.Password)
+	if err == meta.ErrAuthenticate {
+		atomic.AddInt64(&h.stats.AuthenticationFailures, 1)
+		h.authenticationFailed(w, r, "authorization failed")
+		return
+	}
+	h.auditRequest(r, &meta.UserInfo{Name: creds.Username}, audit.CategoryAuth, "", err)
+	if _, ok := err.(meta.PasswordPolicyError); ok || err == meta.ErrPasswordReused {
+		h.httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.writeHeader(w, http.StatusNoContent)
+}
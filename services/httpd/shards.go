@@ -0,0 +1,176 @@
+package httpd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/freetsdb/freetsdb"
+	"github.com/freetsdb/freetsdb/services/audit"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/tsdb"
+)
+
+// shard is the JSON encoding of a shard returned by /api/v1/shards.
+type shard struct {
+	ID              uint64     `json:"id"`
+	Database        string     `json:"database"`
+	RetentionPolicy string     `json:"retention_policy"`
+	ShardGroup      uint64     `json:"shard_group"`
+	StartTime       time.Time  `json:"start_time"`
+	EndTime         time.Time  `json:"end_time"`
+	ExpiryTime      time.Time  `json:"expiry_time"`
+	TruncatedAt     *time.Time `json:"truncated_at,omitempty"`
+	Owners          []uint64   `json:"owners"`
+
+	// Size is only known for the shards stored on this node.
+	Size *int64 `json:"size,omitempty"`
+}
+
+// shardFile is the JSON encoding of a data file of a shard.
+type shardFile struct {
+	Path       string    `json:"path"`
+	Size       int64     `json:"size"`
+	MinTime    time.Time `json:"min_time"`
+	MaxTime    time.Time `json:"max_time"`
+	Generation int       `json:"generation"`
+	Sequence   int       `json:"sequence"`
+	Tombstone  bool      `json:"tombstone"`
+}
+
+// serveShards returns the shards of the cluster that are not deleted.
+func (h *Handler) serveShards(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+
+	dis, err := h.MetaClient.Databases()
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := struct {
+		Shards []shard `json:"shards"`
+	}{Shards: []shard{}}
+	for _, di := range dis {
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() {
+					continue
+				}
+				for _, si := range sgi.Shards {
+					s := shard{
+						ID:              si.ID,
+						Database:        di.Name,
+						RetentionPolicy: rpi.Name,
+						ShardGroup:      sgi.ID,
+						StartTime:       sgi.StartTime.UTC(),
+						EndTime:         sgi.EndTime.UTC(),
+						ExpiryTime:      sgi.EndTime.Add(rpi.Duration).UTC(),
+						Owners:          make([]uint64, len(si.Owners)),
+					}
+					if sgi.Truncated() {
+						t := sgi.TruncatedAt.UTC()
+						s.TruncatedAt = &t
+					}
+					for i, owner := range si.Owners {
+						s.Owners[i] = owner.NodeID
+					}
+					if h.Shards != nil {
+						if n, err := h.Shards.ShardDiskSize(si.ID); err == nil {
+							s.Size = &n
+						}
+					}
+					resp.Shards = append(resp.Shards, s)
+				}
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// serveShardFiles returns the data files of a shard stored on this node.
+func (h *Handler) serveShardFiles(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+
+	id, err := strconv.ParseUint(r.URL.Query().Get(":id"), 10, 64)
+	if err != nil {
+		h.httpError(w, "invalid shard id", http.StatusBadRequest)
+		return
+	}
+
+	var files []tsdb.ShardFile
+	if h.Shards == nil {
+		err = tsdb.ErrShardNotFound
+	} else {
+		files, err = h.Shards.ShardFiles(id)
+	}
+	if err == tsdb.ErrShardNotFound {
+		h.writeError(w, Response{Err: codeError{fmt.Sprintf("shard %d is not stored on this node", id), ErrorCodeShardNotFound}}, http.StatusNotFound)
+		return
+	} else if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := struct {
+		Files []shardFile `json:"files"`
+	}{Files: make([]shardFile, 0, len(files))}
+	for _, f := range files {
+		resp.Files = append(resp.Files, shardFile{
+			Path:       filepath.Base(f.Path),
+			Size:       f.Size,
+			MinTime:    time.Unix(0, f.MinTime).UTC(),
+			MaxTime:    time.Unix(0, f.MaxTime).UTC(),
+			Generation: f.Generation,
+			Sequence:   f.Sequence,
+			Tombstone:  f.HasTombstone,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// serveTruncateShards ends the current shard groups of the database named by
+// the db parameter now, so that new writes go to new shard groups. The rp
+// parameter restricts them to a retention policy. This spreads hot shards
+// over the data nodes after the topology of the cluster changes.
+func (h *Handler) serveTruncateShards(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+
+	db := r.FormValue("db")
+	if db == "" {
+		h.httpError(w, "database is required", http.StatusBadRequest)
+		return
+	}
+
+	err := h.MetaClient.TruncateDatabaseShardGroups(db, r.FormValue("rp"), time.Now())
+	h.auditRequest(r, user, audit.CategoryAdmin, db, err)
+	if err != nil {
+		var (
+			dberr freetsdb.DatabaseNotFoundError
+			rperr freetsdb.RetentionPolicyNotFoundError
+		)
+		code := http.StatusInternalServerError
+		if errors.As(err, &dberr) || errors.As(err, &rperr) {
+			code = http.StatusNotFound
+		}
+		h.writeError(w, Response{Err: err}, code)
+		return
+	}
+	h.writeHeader(w, http.StatusNoContent)
+}
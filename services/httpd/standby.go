@@ -0,0 +1,49 @@
+package httpd
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/freetsdb/freetsdb/services/audit"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// serveStandby returns the state of the standby.
+func (h *Handler) serveStandby(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+	if h.Standby == nil {
+		h.httpError(w, "node is not a standby", http.StatusNotImplemented)
+		return
+	}
+
+	h.writeStandby(w)
+}
+
+// servePromoteStandby promotes the standby to serve writes.
+func (h *Handler) servePromoteStandby(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+	if h.Standby == nil {
+		h.httpError(w, "node is not a standby", http.StatusNotImplemented)
+		return
+	}
+
+	err := h.Standby.Promote()
+	h.auditRequest(r, user, audit.CategoryAdmin, "", err)
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.writeStandby(w)
+}
+
+// writeStandby writes the state of the standby.
+func (h *Handler) writeStandby(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	json.NewEncoder(w).Encode(h.Standby.Status())
+}
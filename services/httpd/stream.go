@@ -0,0 +1,449 @@
+package httpd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/freetsdb/freetsdb"
+	"github.com/freetsdb/freetsdb/coordinator"
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// DefaultMaxQueryStreams is the default maximum number of open query streams.
+	DefaultMaxQueryStreams = 100
+
+	// queryStreamBufferSize is the number of writes buffered for a query
+	// stream. Points written while the buffer is full are dropped.
+	queryStreamBufferSize = 64
+
+	// queryStreamWriteBufferSize is the number of writes buffered before they
+	// are matched against the query streams.
+	queryStreamWriteBufferSize = 1024
+)
+
+// errTooManyQueryStreams is returned when max-query-streams are open.
+var errTooManyQueryStreams = errors.New("max-query-streams limit exceeded")
+
+// queryStream is a live query registered by a /query/stream client. It
+// receives the points written to its measurement after it was registered,
+// once they are written to their shards. Only the writes received by this
+// node are streamed: in a cluster, a client streams from each node that
+// takes writes for the measurement.
+type queryStream struct {
+	dropped int64 // points dropped since the last message, updated atomically
+
+	database        string
+	retentionPolicy string
+	measurement     *influxql.Measurement
+	fields          influxql.Fields
+	columns         []string
+	condition       influxql.Expr
+	timeRange       influxql.TimeRange
+
+	points chan []models.Point
+	done   chan struct{}
+}
+
+// newQueryStream returns a stream for stmt. Streams support raw queries of a
+// single measurement, optionally filtered by tags, fields and time.
+func newQueryStream(stmt *influxql.SelectStatement, now time.Time) (*queryStream, error) {
+	if len(stmt.Sources) != 1 {
+		return nil, errors.New("streaming queries must select from a single measurement")
+	}
+	m, ok := stmt.Sources[0].(*influxql.Measurement)
+	if !ok {
+		return nil, errors.New("streaming queries must select from a single measurement")
+	} else if !stmt.IsRawQuery || stmt.Target != nil {
+		return nil, errors.New("streaming queries must be raw queries")
+	}
+
+	for _, f := range stmt.Fields {
+		var err error
+		influxql.WalkFunc(f.Expr, func(n influxql.Node) {
+			if call, ok := n.(*influxql.Call); ok && err == nil {
+				err = fmt.Errorf("streaming queries do not support function %s()", call.Name)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cond, timeRange, err := influxql.ConditionExpr(stmt.Condition, &influxql.NowValuer{Now: now})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &queryStream{
+		database:        m.Database,
+		retentionPolicy: m.RetentionPolicy,
+		measurement:     m,
+		condition:       cond,
+		timeRange:       timeRange,
+		points:          make(chan []models.Point, queryStreamBufferSize),
+		done:            make(chan struct{}),
+	}
+	if !stmt.HasWildcard() {
+		s.fields = stmt.Fields
+		s.columns = stmt.ColumnNames()
+	}
+	return s, nil
+}
+
+// match returns the points in pts that belong to the measurement of s.
+func (s *queryStream) match(pts []models.Point) []models.Point {
+	var matched []models.Point
+	for _, p := range pts {
+		if re := s.measurement.Regex; re != nil {
+			if !re.Val.Match(p.Name()) {
+				continue
+			}
+		} else if string(p.Name()) != s.measurement.Name {
+			continue
+		}
+		matched = append(matched, p)
+	}
+	return matched
+}
+
+// rows evaluates the query against pts and returns the resulting rows,
+// grouped by series.
+func (s *queryStream) rows(pts []models.Point) models.Rows {
+	var rows models.Rows
+	index := make(map[string]*models.Row)
+	for _, p := range pts {
+		if t := p.Time().UnixNano(); t < s.timeRange.MinTimeNano() || t > s.timeRange.MaxTimeNano() {
+			continue
+		}
+
+		fields, err := p.Fields()
+		if err != nil {
+			continue
+		}
+		tags := p.Tags().Map()
+		m := make(map[string]interface{}, len(tags)+len(fields))
+		for k, v := range tags {
+			m[k] = v
+		}
+		for k, v := range fields {
+			m[k] = v
+		}
+		if s.condition != nil && !influxql.EvalBool(s.condition, m) {
+			continue
+		}
+
+		// Without a field list every field of the point is selected.
+		columns := s.columns
+		if s.fields == nil {
+			columns = make([]string, 0, len(fields)+1)
+			for k := range fields {
+				columns = append(columns, k)
+			}
+			sort.Strings(columns)
+			columns = append([]string{"time"}, columns...)
+		}
+
+		values := make([]interface{}, len(columns))
+		values[0] = p.Time()
+		var n int
+		for i, name := range columns[1:] {
+			if s.fields != nil {
+				values[i+1] = influxql.Eval(s.fields[i].Expr, m)
+			} else {
+				values[i+1] = fields[name]
+			}
+			if values[i+1] != nil {
+				n++
+			}
+		}
+		if n == 0 {
+			continue
+		}
+
+		key := string(p.Key()) + "\x00" + strings.Join(columns, ",")
+		row := index[key]
+		if row == nil {
+			row = &models.Row{Name: string(p.Name()), Tags: tags, Columns: columns}
+			index[key] = row
+			rows = append(rows, row)
+		}
+		row.Values = append(row.Values, values)
+	}
+	return rows
+}
+
+// queryStreams dispatches the points written through this node to the open
+// query streams.
+type queryStreams struct {
+	mu      sync.RWMutex
+	streams map[*queryStream]struct{}
+	writes  chan *coordinator.WritePointsRequest
+	closing chan struct{}
+	wg      sync.WaitGroup
+
+	stats *Statistics
+}
+
+func newQueryStreams(stats *Statistics) *queryStreams {
+	return &queryStreams{
+		streams: make(map[*queryStream]struct{}),
+		writes:  make(chan *coordinator.WritePointsRequest, queryStreamWriteBufferSize),
+		stats:   stats,
+	}
+}
+
+// open starts dispatching writes.
+func (q *queryStreams) open() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closing != nil {
+		return
+	}
+	closing := make(chan struct{})
+	q.closing = closing
+
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		q.run(closing)
+	}()
+}
+
+// close stops dispatching writes and ends the open streams.
+func (q *queryStreams) close() {
+	q.mu.Lock()
+	if q.closing != nil {
+		close(q.closing)
+		q.closing = nil
+	}
+	for s := range q.streams {
+		q.remove(s)
+	}
+	q.mu.Unlock()
+	q.wg.Wait()
+}
+
+func (q *queryStreams) run(closing <-chan struct{}) {
+	for {
+		select {
+		case <-closing:
+			return
+		case req := <-q.writes:
+			q.dispatch(req)
+		}
+	}
+}
+
+// dispatch sends the points in req to the streams that select them. Points
+// are dropped for streams that are too far behind.
+func (q *queryStreams) dispatch(req *coordinator.WritePointsRequest) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	for s := range q.streams {
+		if s.database != req.Database || s.retentionPolicy != req.RetentionPolicy {
+			continue
+		}
+		pts := s.match(req.Points)
+		if len(pts) == 0 {
+			continue
+		}
+
+		select {
+		case s.points <- pts:
+		default:
+			atomic.AddInt64(&s.dropped, int64(len(pts)))
+			atomic.AddInt64(&q.stats.QueryStreamPointsDropped, int64(len(pts)))
+		}
+	}
+}
+
+// add registers s to receive points.
+func (q *queryStreams) add(s *queryStream, max int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if max > 0 && len(q.streams) >= max {
+		return errTooManyQueryStreams
+	}
+	q.streams[s] = struct{}{}
+	atomic.AddInt64(&q.stats.ActiveQueryStreams, 1)
+	return nil
+}
+
+// unregister stops sending points to s and ends it.
+func (q *queryStreams) unregister(s *queryStream) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.remove(s)
+}
+
+// remove removes s if it is registered. It must be called with the lock held.
+func (q *queryStreams) remove(s *queryStream) {
+	if _, ok := q.streams[s]; !ok {
+		return
+	}
+	delete(q.streams, s)
+	close(s.done)
+	atomic.AddInt64(&q.stats.ActiveQueryStreams, -1)
+}
+
+// serveQueryStream registers a query and streams the points that match it
+// over a WebSocket as they are written.
+func (h *Handler) serveQueryStream(w http.ResponseWriter, r *http.Request, user meta.User) {
+	// Browsers send the credentials of the user with WebSocket requests of
+	// any page, so only the pages of allowed origins may open a stream.
+	if !h.streamOriginAllowed(r) {
+		h.httpError(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	qs := strings.TrimSpace(r.FormValue("q"))
+	if qs == "" {
+		h.httpError(w, `missing required parameter "q"`, http.StatusBadRequest)
+		return
+	}
+	db := r.FormValue("db")
+	epoch, err := parseEpoch(strings.TrimSpace(r.FormValue("epoch")))
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Sanitize the request query params so it doesn't show up in the response logger.
+	sanitize(r)
+
+	q, err := influxql.ParseQuery(qs)
+	if err != nil {
+		h.httpError(w, "error parsing query: "+err.Error(), http.StatusBadRequest)
+		return
+	} else if len(q.Statements) != 1 {
+		h.httpError(w, "streaming queries must have exactly one statement", http.StatusBadRequest)
+		return
+	}
+	stmt, ok := q.Statements[0].(*influxql.SelectStatement)
+	if !ok {
+		h.httpError(w, "streaming queries must be SELECT statements", http.StatusBadRequest)
+		return
+	}
+
+	// Check authorization.
+	if h.Config.AuthEnabled {
+		if err := h.QueryAuthorizer.AuthorizeQuery(user, q, db); err != nil {
+			h.httpError(w, "error authorizing query: "+err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
+	s, err := newQueryStream(stmt, time.Now().UTC())
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.database == "" {
+		s.database = db
+	}
+	if s.database == "" {
+		h.httpError(w, "database name required", http.StatusBadRequest)
+		return
+	}
+	if s.retentionPolicy == "" {
+		di := h.MetaClient.Database(s.database)
+		if di == nil {
+			h.writeError(w, Response{Err: freetsdb.ErrDatabaseNotFound(s.database)}, http.StatusNotFound)
+			return
+		}
+		s.retentionPolicy = di.DefaultRetentionPolicy
+	}
+
+	if err := h.queryStreams.add(s, h.Config.MaxQueryStreams); err != nil {
+		h.httpError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer h.queryStreams.unregister(s)
+
+	websocket.Server{
+		Handler: func(ws *websocket.Conn) {
+			h.streamQuery(ws, s, epoch)
+		},
+	}.ServeHTTP(w, r)
+}
+
+// streamOriginAllowed returns true if the origin of r may open a query
+// stream. Requests without an origin are not sent by browsers, and browsers
+// may open streams from the same host or from the origins allowed by CORS.
+func (h *Handler) streamOriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return h.Config.CORS.allowOrigin(origin)
+}
+
+// streamQuery sends the results of s to ws until either is closed.
+func (h *Handler) streamQuery(ws *websocket.Conn, s *queryStream, epoch time.Duration) {
+	// Clients do not send messages, but reading notices when they disconnect.
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, ws)
+		close(closed)
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-s.done:
+			return
+		case pts := <-s.points:
+			r := &query.Result{Series: s.rows(pts)}
+			if n := atomic.SwapInt64(&s.dropped, 0); n > 0 {
+				r.Messages = append(r.Messages, &query.Message{
+					Level: query.WarningLevel,
+					Text:  fmt.Sprintf("%d points were dropped because the client did not keep up", n),
+				})
+			}
+			if len(r.Series) == 0 && len(r.Messages) == 0 {
+				continue
+			}
+
+			// if requested, convert result timestamps to epoch
+			if epoch != 0 {
+				convertToEpoch(r, epoch)
+			}
+
+			b, err := Response{Results: []*query.Result{r}}.MarshalJSON()
+			if err != nil {
+				h.Logger.Info("Error encoding query stream", zap.Error(err))
+				return
+			}
+			n, err := ws.Write(b)
+			atomic.AddInt64(&h.stats.QueryRequestBytesTransmitted, int64(n))
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// StreamPoints returns the channel that receives the writes matched against
+// open query streams. It must only receive the points of committed writes.
+func (h *Handler) StreamPoints() chan<- *coordinator.WritePointsRequest {
+	return h.queryStreams.writes
+}
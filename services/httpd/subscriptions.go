@@ -0,0 +1,65 @@
+package httpd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/freetsdb/freetsdb/services/audit"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// subscriptionState is the JSON encoding of the state of a subscription.
+type subscriptionState struct {
+	Paused bool `json:"paused"`
+}
+
+// serveSubscriptions returns the delivery state and statistics of the
+// subscriptions on this node.
+func (h *Handler) serveSubscriptions(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+	if h.Subscriptions == nil {
+		h.httpError(w, "subscriber service is disabled", http.StatusNotImplemented)
+		return
+	}
+
+	resp := struct {
+		Subscriptions interface{} `json:"subscriptions"`
+	}{h.Subscriptions.Subscriptions()}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// serveSetSubscriptionState pauses or resumes a subscription on every node.
+func (h *Handler) serveSetSubscriptionState(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+	if h.Subscriptions == nil {
+		h.httpError(w, "subscriber service is disabled", http.StatusNotImplemented)
+		return
+	}
+
+	q := r.URL.Query()
+	database, rp, name := q.Get(":database"), q.Get(":rp"), q.Get(":name")
+	var state subscriptionState
+	if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+		h.httpError(w, "error parsing state: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := h.Subscriptions.SetSubscriptionPaused(database, rp, name, state.Paused)
+	h.auditRequest(r, user, audit.CategoryAdmin, database, err)
+	if err == meta.ErrSubscriptionNotFound {
+		h.httpError(w, fmt.Sprintf("subscription not found: %q", name), http.StatusNotFound)
+		return
+	} else if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.writeHeader(w, http.StatusNoContent)
+}
@@ -0,0 +1,90 @@
+package httpd
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/freetsdb/freetsdb"
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+const (
+	// DefaultTagValuesLimit is the number of values returned by the tag
+	// values endpoint when the request sets no limit.
+	DefaultTagValuesLimit = 100
+
+	// DefaultMaxTagValuesLimit is the default maximum number of values
+	// returned by a single request to the tag values endpoint.
+	DefaultMaxTagValuesLimit = 1000
+)
+
+// serveTagValues returns the values of a tag key of a measurement that start
+// with the match prefix. It is meant for the autocompletion of UIs, so the
+// number of values is always capped and only the values of the local shards
+// are returned.
+func (h *Handler) serveTagValues(w http.ResponseWriter, r *http.Request, user meta.User) {
+	db, name, key := r.FormValue("db"), r.FormValue("measurement"), r.FormValue("key")
+	if db == "" {
+		h.httpError(w, "database is required", http.StatusBadRequest)
+		return
+	} else if name == "" {
+		h.httpError(w, "measurement is required", http.StatusBadRequest)
+		return
+	} else if key == "" {
+		h.httpError(w, "key is required", http.StatusBadRequest)
+		return
+	}
+
+	limit := DefaultTagValuesLimit
+	if s := r.FormValue("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			h.httpError(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	if max := h.Config.MaxTagValuesLimit; max > 0 && limit > max {
+		limit = max
+	}
+
+	if di := h.MetaClient.Database(db); di == nil {
+		h.writeError(w, Response{Err: freetsdb.ErrDatabaseNotFound(db)}, http.StatusNotFound)
+		return
+	}
+
+	var auth query.Authorizer = query.OpenAuthorizer
+	if h.Config.AuthEnabled {
+		if user == nil || !user.AuthorizeDatabase(influxql.ReadPrivilege, db) {
+			h.httpError(w, "user is not authorized to read from database "+strconv.Quote(db), http.StatusForbidden)
+			return
+		}
+		if !user.AuthorizeUnrestricted() {
+			auth = user
+		}
+	}
+
+	// Read one more value than the limit to know if there are more.
+	values, err := h.TSDBStore.TagValuesByPrefix(auth, db, []byte(name), []byte(key), []byte(r.FormValue("match")), limit+1)
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := struct {
+		Values    []string `json:"values"`
+		Truncated bool     `json:"truncated"`
+	}{Values: values}
+	if len(resp.Values) > limit {
+		resp.Values, resp.Truncated = resp.Values[:limit], true
+	} else if resp.Values == nil {
+		resp.Values = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
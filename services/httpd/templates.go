@@ -0,0 +1,246 @@
+package httpd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/freetsdb/freetsdb/services/audit"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// databaseTemplate is the JSON representation of a meta.DatabaseTemplateInfo
+// used by the /api/v1/templates endpoints.
+type databaseTemplate struct {
+	Name                   string                            `json:"name"`
+	DefaultRetentionPolicy string                            `json:"default_retention_policy,omitempty"`
+	RetentionPolicies      []databaseTemplateRetention       `json:"retention_policies,omitempty"`
+	ContinuousQueries      []databaseTemplateContinuousQuery `json:"continuous_queries,omitempty"`
+	Grants                 []databaseTemplateGrant           `json:"grants,omitempty"`
+	Limits                 databaseTemplateLimits            `json:"limits"`
+}
+
+type databaseTemplateRetention struct {
+	Name          string `json:"name"`
+	Duration      string `json:"duration,omitempty"`
+	ShardDuration string `json:"shard_duration,omitempty"`
+	Replication   int    `json:"replication,omitempty"`
+}
+
+type databaseTemplateContinuousQuery struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+type databaseTemplateGrant struct {
+	User      string `json:"user"`
+	Privilege string `json:"privilege"`
+}
+
+type databaseTemplateLimits struct {
+	FutureWriteLimit string `json:"future_write_limit,omitempty"`
+	PastWriteLimit   string `json:"past_write_limit,omitempty"`
+}
+
+// newDatabaseTemplate converts a template from the meta store to its JSON form.
+func newDatabaseTemplate(ti *meta.DatabaseTemplateInfo) databaseTemplate {
+	t := databaseTemplate{
+		Name:                   ti.Name,
+		DefaultRetentionPolicy: ti.DefaultRetentionPolicy,
+	}
+	for _, rpi := range ti.RetentionPolicies {
+		t.RetentionPolicies = append(t.RetentionPolicies, databaseTemplateRetention{
+			Name:          rpi.Name,
+			Duration:      influxql.FormatDuration(rpi.Duration),
+			ShardDuration: influxql.FormatDuration(rpi.ShardGroupDuration),
+			Replication:   rpi.ReplicaN,
+		})
+	}
+	for _, cq := range ti.ContinuousQueries {
+		t.ContinuousQueries = append(t.ContinuousQueries, databaseTemplateContinuousQuery{
+			Name:  cq.Name,
+			Query: cq.Query,
+		})
+	}
+	for _, g := range ti.Grants {
+		t.Grants = append(t.Grants, databaseTemplateGrant{
+			User:      g.User,
+			Privilege: g.Privilege.String(),
+		})
+	}
+	if d := ti.Limits.FutureWriteLimit; d > 0 {
+		t.Limits.FutureWriteLimit = influxql.FormatDuration(d)
+	}
+	if d := ti.Limits.PastWriteLimit; d > 0 {
+		t.Limits.PastWriteLimit = influxql.FormatDuration(d)
+	}
+	return t
+}
+
+// info converts the JSON form of a template to a meta.DatabaseTemplateInfo.
+func (t *databaseTemplate) info() (*meta.DatabaseTemplateInfo, error) {
+	ti := &meta.DatabaseTemplateInfo{
+		Name:                   t.Name,
+		DefaultRetentionPolicy: t.DefaultRetentionPolicy,
+	}
+	for _, rp := range t.RetentionPolicies {
+		rpi := meta.NewRetentionPolicyInfo(rp.Name)
+		if rp.Replication != 0 {
+			rpi.ReplicaN = rp.Replication
+		}
+		var err error
+		if rpi.Duration, err = parseTemplateDuration(rp.Duration); err != nil {
+			return nil, fmt.Errorf("retention policy %q: duration: %s", rp.Name, err)
+		}
+		if rpi.ShardGroupDuration, err = parseTemplateDuration(rp.ShardDuration); err != nil {
+			return nil, fmt.Errorf("retention policy %q: shard duration: %s", rp.Name, err)
+		}
+		ti.RetentionPolicies = append(ti.RetentionPolicies, *rpi)
+	}
+	for _, cq := range t.ContinuousQueries {
+		ti.ContinuousQueries = append(ti.ContinuousQueries, meta.ContinuousQueryInfo{
+			Name:  cq.Name,
+			Query: cq.Query,
+		})
+	}
+	for _, g := range t.Grants {
+		p, err := parsePrivilege(g.Privilege)
+		if err != nil {
+			return nil, fmt.Errorf("grant %q: %v", g.User, err)
+		}
+		ti.Grants = append(ti.Grants, meta.DatabaseTemplateGrant{User: g.User, Privilege: p})
+	}
+	var err error
+	if ti.Limits.FutureWriteLimit, err = parseTemplateDuration(t.Limits.FutureWriteLimit); err != nil {
+		return nil, fmt.Errorf("limits: future write limit: %s", err)
+	}
+	if ti.Limits.PastWriteLimit, err = parseTemplateDuration(t.Limits.PastWriteLimit); err != nil {
+		return nil, fmt.Errorf("limits: past write limit: %s", err)
+	}
+	return ti, nil
+}
+
+// parseTemplateDuration parses an InfluxQL duration literal. An empty string
+// or INF means an infinite duration.
+func parseTemplateDuration(s string) (time.Duration, error) {
+	if s == "" || strings.EqualFold(s, "inf") {
+		return 0, nil
+	}
+	return influxql.ParseDuration(s)
+}
+
+// authorizeAdmin writes an error and returns false if auth is enabled and
+// user is not an admin.
+func (h *Handler) authorizeAdmin(w http.ResponseWriter, user meta.User) bool {
+	if h.Config.AuthEnabled && (user == nil || !user.AuthorizeUnrestricted()) {
+		h.httpError(w, "admin privilege required", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// serveListDatabaseTemplates returns all database templates.
+func (h *Handler) serveListDatabaseTemplates(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+
+	tmpls := h.MetaClient.DatabaseTemplates()
+	resp := struct {
+		Templates []databaseTemplate `json:"templates"`
+	}{Templates: make([]databaseTemplate, 0, len(tmpls))}
+	for i := range tmpls {
+		resp.Templates = append(resp.Templates, newDatabaseTemplate(&tmpls[i]))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// serveCreateDatabaseTemplate stores the database template in the request body.
+func (h *Handler) serveCreateDatabaseTemplate(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+
+	var t databaseTemplate
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		h.httpError(w, "error parsing template: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ti, err := t.info()
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.MetaClient.CreateDatabaseTemplate(ti)
+	h.auditRequest(r, user, audit.CategoryDDL, "", err)
+	if err != nil {
+		h.httpError(w, err.Error(), databaseTemplateErrorCode(err))
+		return
+	}
+
+	h.writeHeader(w, http.StatusNoContent)
+}
+
+// serveDropDatabaseTemplate removes a database template.
+func (h *Handler) serveDropDatabaseTemplate(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+
+	err := h.MetaClient.DropDatabaseTemplate(r.URL.Query().Get(":name"))
+	h.auditRequest(r, user, audit.CategoryDDL, "", err)
+	if err != nil {
+		h.httpError(w, err.Error(), databaseTemplateErrorCode(err))
+		return
+	}
+
+	h.writeHeader(w, http.StatusNoContent)
+}
+
+// serveInstantiateDatabaseTemplate creates the database named by the db
+// parameter from a template.
+func (h *Handler) serveInstantiateDatabaseTemplate(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if !h.authorizeAdmin(w, user) {
+		return
+	}
+
+	db := r.FormValue("db")
+	if db == "" {
+		h.httpError(w, "database is required", http.StatusBadRequest)
+		return
+	}
+
+	_, err := h.MetaClient.InstantiateDatabaseTemplate(r.URL.Query().Get(":name"), db)
+	h.auditRequest(r, user, audit.CategoryDDL, db, err)
+	if err != nil {
+		h.httpError(w, err.Error(), databaseTemplateErrorCode(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		Database string `json:"database"`
+	}{Database: db})
+}
+
+// databaseTemplateErrorCode maps a meta store error to an HTTP status code.
+// Errors returned by the meta service lose their identity, so they are
+// matched by message.
+func databaseTemplateErrorCode(err error) int {
+	switch err.Error() {
+	case meta.ErrDatabaseTemplateNotFound.Error():
+		return http.StatusNotFound
+	case meta.ErrDatabaseTemplateExists.Error(), meta.ErrDatabaseExists.Error():
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
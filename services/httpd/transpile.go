@@ -0,0 +1,114 @@
+package httpd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	fluxinfluxql "github.com/freetsdb/freetsdb/flux/influxql"
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/flux"
+	"github.com/freetsdb/freetsdb/services/flux/lang"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// transpileResult is the Flux script of a statement returned by the
+// transpile endpoint.
+type transpileResult struct {
+	StatementID int    `json:"statement_id"`
+	Flux        string `json:"flux,omitempty"`
+	Err         string `json:"error,omitempty"`
+}
+
+// serveTranspile returns the Flux scripts equivalent to the statements of an
+// InfluxQL query, without executing them.
+func (h *Handler) serveTranspile(w http.ResponseWriter, r *http.Request, user meta.User) {
+	qs := strings.TrimSpace(r.FormValue("q"))
+	if qs == "" {
+		h.httpError(w, `missing required parameter "q"`, http.StatusBadRequest)
+		return
+	}
+	q, err := influxql.ParseQuery(qs)
+	if err != nil {
+		h.httpError(w, "error parsing query: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	config := fluxinfluxql.Config{
+		DefaultDatabase:        r.FormValue("db"),
+		DefaultRetentionPolicy: r.FormValue("rp"),
+	}
+	resp := struct {
+		Results []transpileResult `json:"results"`
+	}{Results: make([]transpileResult, 0, len(q.Statements))}
+	for i, stmt := range q.Statements {
+		result := transpileResult{StatementID: i}
+		if script, err := fluxinfluxql.Transpile(stmt, config); err != nil {
+			result.Err = err.Error()
+		} else {
+			result.Flux = script.String()
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// executeFluxQuery executes the statements of q on the Flux engine and
+// returns their results like the query executor does. Statements that cannot
+// be transpiled to Flux fail.
+func (h *Handler) executeFluxQuery(ctx context.Context, q *influxql.Query, opts query.ExecutionOptions, user meta.User) <-chan *query.Result {
+	if h.Config.AuthEnabled {
+		ctx = meta.NewContextWithUser(ctx, user)
+	}
+	config := fluxinfluxql.Config{
+		DefaultDatabase:        opts.Database,
+		DefaultRetentionPolicy: opts.RetentionPolicy,
+	}
+
+	results := make(chan *query.Result)
+	go func() {
+		defer close(results)
+		for i, stmt := range q.Statements {
+			result := &query.Result{StatementID: i}
+			if rows, err := h.executeFluxStatement(ctx, stmt, config); err != nil {
+				result.Err = err
+			} else {
+				result.Series = rows
+			}
+
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return results
+}
+
+// executeFluxStatement transpiles stmt to Flux and executes it.
+func (h *Handler) executeFluxStatement(ctx context.Context, stmt influxql.Statement, config fluxinfluxql.Config) (models.Rows, error) {
+	script, err := fluxinfluxql.Transpile(stmt, config)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := h.Controller.Query(ctx, lang.FluxCompiler{Query: script.String()})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		q.Cancel()
+		q.Done()
+	}()
+
+	results := flux.NewResultIteratorFromQuery(q)
+	defer results.Release()
+	return script.Rows(results)
+}
@@ -0,0 +1,388 @@
+package httpd
+
+import (
+	"io"
+	"net/http"
+)
+
+// serveUI returns the web UI. The page is a single document with its styles
+// and scripts inline, so it needs nothing but the handler's own endpoints:
+// it logs in with /login and sends everything else as InfluxQL to /query.
+func (h *Handler) serveUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	w.Header().Set("X-Frame-Options", "DENY")
+	h.writeHeader(w, http.StatusOK)
+	io.WriteString(w, uiPage)
+}
+
+// uiPage is the document served by serveUI.
+const uiPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>FreeTSDB</title>
+<style>
+body { font: 14px sans-serif; margin: 0; color: #222; }
+header { background: #22313f; color: #fff; padding: 8px 16px; display: flex; align-items: center; gap: 16px; }
+header h1 { font-size: 18px; margin: 0; flex: 1; }
+header button { background: none; border: 0; color: #cdd; cursor: pointer; font-size: 14px; }
+header button.active { color: #fff; border-bottom: 2px solid #fff; }
+main { padding: 16px; }
+section { display: none; }
+section.active { display: block; }
+textarea { width: 100%; height: 90px; font: 13px monospace; box-sizing: border-box; }
+table { border-collapse: collapse; margin: 8px 0 16px; }
+th, td { border: 1px solid #ccc; padding: 3px 8px; text-align: left; font: 12px monospace; }
+th { background: #eee; }
+fieldset { border: 1px solid #ccc; margin: 0 0 12px; }
+input, select { margin: 2px 4px 2px 0; }
+.error { color: #b00; white-space: pre-wrap; }
+.muted { color: #777; }
+svg { border: 1px solid #ccc; background: #fff; }
+</style>
+</head>
+<body>
+<header>
+<h1>FreeTSDB</h1>
+<button data-tab="query" class="active">Query</button>
+<button data-tab="admin">Admin</button>
+<button data-tab="cardinality">Cardinality</button>
+<span id="who" class="muted"></span>
+<button id="logout" hidden>Log out</button>
+</header>
+<main>
+<section id="login">
+<form id="login-form">
+<fieldset><legend>Log in</legend>
+<input name="u" placeholder="Username" autocomplete="username">
+<input name="p" type="password" placeholder="Password" autocomplete="current-password">
+<button>Log in</button>
+</fieldset>
+</form>
+<p class="error" id="login-error"></p>
+</section>
+
+<section id="query" class="active">
+<div>
+Database <select id="db"></select>
+Display <select id="display"><option value="table">Table</option><option value="graph">Graph</option></select>
+</div>
+<textarea id="q" placeholder="SELECT mean(value) FROM cpu WHERE time > now() - 1h GROUP BY time(1m)"></textarea>
+<div><button id="run">Run</button> <span class="muted">Ctrl+Enter runs the query</span></div>
+<div id="results"></div>
+</section>
+
+<section id="admin">
+<form data-stmt="database">
+<fieldset><legend>Databases</legend>
+<input name="name" placeholder="Database">
+<button name="op" value="create">Create</button> <button name="op" value="drop">Drop</button>
+</fieldset>
+</form>
+<form data-stmt="rp">
+<fieldset><legend>Retention policies</legend>
+<input name="name" placeholder="Policy"> on <select name="db" class="dbs"></select>
+<input name="duration" placeholder="Duration, e.g. 30d"> <input name="replication" placeholder="Replication" size="10">
+<label><input name="default" type="checkbox"> default</label>
+<button name="op" value="create">Create</button> <button name="op" value="drop">Drop</button>
+</fieldset>
+</form>
+<form data-stmt="user">
+<fieldset><legend>Users</legend>
+<input name="name" placeholder="User"> <input name="password" type="password" placeholder="Password" autocomplete="new-password">
+<label><input name="admin" type="checkbox"> admin</label>
+<button name="op" value="create">Create</button> <button name="op" value="password">Set password</button> <button name="op" value="drop">Drop</button>
+</fieldset>
+</form>
+<form data-stmt="grant">
+<fieldset><legend>Privileges</legend>
+<select name="privilege"><option>READ</option><option>WRITE</option><option>ALL</option></select>
+on <select name="db" class="dbs"></select> to <input name="name" placeholder="User">
+<button name="op" value="grant">Grant</button> <button name="op" value="revoke">Revoke</button>
+</fieldset>
+</form>
+<p class="error" id="admin-error"></p>
+<div id="admin-results"></div>
+</section>
+
+<section id="cardinality">
+<div>Database <select id="card-db" class="dbs"></select> <button id="card-run">Refresh</button></div>
+<div id="card-results"></div>
+</section>
+</main>
+
+<script>
+"use strict";
+var token = sessionStorage.getItem("freetsdb-session") || "";
+
+function $(id) { return document.getElementById(id); }
+
+function el(tag, text) {
+	var e = document.createElement(tag);
+	if (text !== undefined) e.textContent = text;
+	return e;
+}
+
+function ident(s) { return '"' + s.replace(/\\/g, "\\\\").replace(/"/g, '\\"') + '"'; }
+function str(s) { return "'" + s.replace(/\\/g, "\\\\").replace(/'/g, "\\'") + "'"; }
+
+function request(method, path, body, headers) {
+	headers = headers || {};
+	if (token && !headers.Authorization) headers.Authorization = "Session " + token;
+	return fetch(path, {method: method, body: body, headers: headers}).then(function(resp) {
+		if (resp.status === 204) return {};
+		return resp.json().then(function(data) {
+			if (resp.status === 401) showLogin(data.error);
+			if (!resp.ok) throw new Error(data.error || resp.statusText);
+			return data;
+		});
+	});
+}
+
+function query(q, db) {
+	var body = new URLSearchParams({q: q, epoch: "ms"});
+	if (db) body.set("db", db);
+	return request("POST", "/query", body).then(function(data) {
+		(data.results || []).forEach(function(r) { if (r.error) throw new Error(r.error); });
+		return data.results || [];
+	});
+}
+
+function showTab(name) {
+	document.querySelectorAll("section").forEach(function(s) { s.classList.toggle("active", s.id === name); });
+	document.querySelectorAll("header [data-tab]").forEach(function(b) { b.classList.toggle("active", b.dataset.tab === name); });
+}
+
+function showLogin(msg) {
+	token = "";
+	sessionStorage.removeItem("freetsdb-session");
+	$("who").textContent = "";
+	$("logout").hidden = true;
+	$("login-error").textContent = msg || "";
+	showTab("login");
+}
+
+function loggedIn(user) {
+	$("who").textContent = user || "";
+	$("logout").hidden = !token;
+	showTab("query");
+	loadDatabases();
+}
+
+$("login-form").addEventListener("submit", function(e) {
+	e.preventDefault();
+	var f = e.target;
+	request("POST", "/login", null, {Authorization: "Basic " + btoa(f.u.value + ":" + f.p.value)}).then(function(data) {
+		token = data.token;
+		sessionStorage.setItem("freetsdb-session", token);
+		sessionStorage.setItem("freetsdb-user", f.u.value);
+		f.p.value = "";
+		loggedIn(f.u.value);
+	}).catch(function(err) { $("login-error").textContent = err.message; });
+});
+
+$("logout").addEventListener("click", function() {
+	request("POST", "/logout").catch(function() {}).then(function() { showLogin(); });
+});
+
+document.querySelectorAll("header [data-tab]").forEach(function(b) {
+	b.addEventListener("click", function() { showTab(b.dataset.tab); });
+});
+
+function loadDatabases() {
+	return query("SHOW DATABASES").then(function(results) {
+		var names = [];
+		(results[0].series || []).forEach(function(s) {
+			(s.values || []).forEach(function(v) { names.push(v[0]); });
+		});
+		document.querySelectorAll("#db, .dbs").forEach(function(sel) {
+			var cur = sel.value;
+			sel.textContent = "";
+			names.forEach(function(n) { sel.appendChild(el("option", n)); });
+			if (names.indexOf(cur) >= 0) sel.value = cur;
+		});
+	});
+}
+
+function renderTable(series) {
+	var table = el("table"), tr = el("tr");
+	series.columns.forEach(function(c) { tr.appendChild(el("th", c)); });
+	table.appendChild(tr);
+	(series.values || []).forEach(function(row) {
+		tr = el("tr");
+		row.forEach(function(v, i) {
+			if (series.columns[i] === "time" && typeof v === "number") v = new Date(v).toISOString();
+			tr.appendChild(el("td", v === null ? "" : String(v)));
+		});
+		table.appendChild(tr);
+	});
+	return table;
+}
+
+var svgNS = "http://www.w3.org/2000/svg";
+var colors = ["#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b"];
+
+// renderGraph plots the numeric columns of every series against time.
+function renderGraph(series) {
+	var lines = [], minX = Infinity, maxX = -Infinity, minY = Infinity, maxY = -Infinity;
+	series.forEach(function(s) {
+		var t = s.columns.indexOf("time");
+		if (t < 0) return;
+		s.columns.forEach(function(c, i) {
+			if (i === t) return;
+			var pts = [];
+			(s.values || []).forEach(function(row) {
+				if (typeof row[i] !== "number") return;
+				pts.push([row[t], row[i]]);
+				minX = Math.min(minX, row[t]); maxX = Math.max(maxX, row[t]);
+				minY = Math.min(minY, row[i]); maxY = Math.max(maxY, row[i]);
+			});
+			var tags = s.tags ? " " + JSON.stringify(s.tags) : "";
+			if (pts.length) lines.push({name: s.name + "." + c + tags, pts: pts});
+		});
+	});
+	if (!lines.length) return el("p", "No numeric values to graph.");
+	if (maxX === minX) maxX = minX + 1;
+	if (maxY === minY) maxY = minY + 1;
+
+	var w = 900, h = 300, pad = 50;
+	var svg = document.createElementNS(svgNS, "svg");
+	svg.setAttribute("width", w);
+	svg.setAttribute("height", h + 20 * lines.length);
+	function text(x, y, s, color) {
+		var t = document.createElementNS(svgNS, "text");
+		t.setAttribute("x", x); t.setAttribute("y", y);
+		t.setAttribute("font-size", "11");
+		if (color) t.setAttribute("fill", color);
+		t.textContent = s;
+		svg.appendChild(t);
+	}
+	text(4, 12, String(maxY));
+	text(4, h - pad, String(minY));
+	text(pad, h - pad + 16, new Date(minX).toISOString());
+	text(w - 190, h - pad + 16, new Date(maxX).toISOString());
+	lines.forEach(function(l, n) {
+		var color = colors[n % colors.length];
+		var p = document.createElementNS(svgNS, "polyline");
+		p.setAttribute("fill", "none");
+		p.setAttribute("stroke", color);
+		p.setAttribute("points", l.pts.map(function(pt) {
+			var x = pad + (pt[0] - minX) / (maxX - minX) * (w - 2 * pad);
+			var y = (h - pad) - (pt[1] - minY) / (maxY - minY) * (h - pad - 16);
+			return x.toFixed(1) + "," + y.toFixed(1);
+		}).join(" "));
+		svg.appendChild(p);
+		text(pad, h + 20 * n, l.name, color);
+	});
+	return svg;
+}
+
+function renderResults(target, results, display) {
+	target.textContent = "";
+	results.forEach(function(r) {
+		(r.messages || []).forEach(function(m) { target.appendChild(el("p", m.level + ": " + m.text)); });
+		var series = r.series || [];
+		if (!series.length) {
+			target.appendChild(el("p", "No results.")).className = "muted";
+			return;
+		}
+		if (display === "graph") {
+			target.appendChild(renderGraph(series));
+			return;
+		}
+		series.forEach(function(s) {
+			var title = s.name || "";
+			if (s.tags) title += " " + JSON.stringify(s.tags);
+			if (title) target.appendChild(el("h4", title));
+			target.appendChild(renderTable(s));
+		});
+	});
+}
+
+function showError(target, err) {
+	target.textContent = "";
+	var p = target.appendChild(el("p", err.message));
+	p.className = "error";
+}
+
+function run() {
+	var out = $("results");
+	query($("q").value, $("db").value).then(function(results) {
+		renderResults(out, results, $("display").value);
+	}).catch(function(err) { showError(out, err); });
+}
+
+$("run").addEventListener("click", run);
+$("q").addEventListener("keydown", function(e) {
+	if (e.key === "Enter" && e.ctrlKey) run();
+});
+
+// statements builds the InfluxQL an admin form submits.
+var statements = {
+	database: function(f, op) {
+		return (op === "create" ? "CREATE" : "DROP") + " DATABASE " + ident(f.name.value);
+	},
+	rp: function(f, op) {
+		if (op === "drop") return "DROP RETENTION POLICY " + ident(f.name.value) + " ON " + ident(f.db.value);
+		return "CREATE RETENTION POLICY " + ident(f.name.value) + " ON " + ident(f.db.value) +
+			" DURATION " + (f.duration.value || "INF") + " REPLICATION " + (f.replication.value || "1") +
+			(f["default"].checked ? " DEFAULT" : "");
+	},
+	user: function(f, op) {
+		if (op === "drop") return "DROP USER " + ident(f.name.value);
+		if (op === "password") return "SET PASSWORD FOR " + ident(f.name.value) + " = " + str(f.password.value);
+		return "CREATE USER " + ident(f.name.value) + " WITH PASSWORD " + str(f.password.value) +
+			(f.admin.checked ? " WITH ALL PRIVILEGES" : "");
+	},
+	grant: function(f, op) {
+		return (op === "grant" ? "GRANT " : "REVOKE ") + f.privilege.value + " ON " + ident(f.db.value) +
+			(op === "grant" ? " TO " : " FROM ") + ident(f.name.value);
+	}
+};
+
+document.querySelectorAll("form[data-stmt]").forEach(function(f) {
+	f.addEventListener("submit", function(e) {
+		e.preventDefault();
+		var op = e.submitter ? e.submitter.value : "create";
+		var q = statements[f.dataset.stmt](f, op);
+		if (op === "drop" && !confirm(q + "?")) return;
+		$("admin-error").textContent = "";
+		query(q).then(function() {
+			if (f.password) f.password.value = "";
+			return refreshAdmin();
+		}).catch(function(err) { $("admin-error").textContent = err.message; });
+	});
+});
+
+function refreshAdmin() {
+	return loadDatabases().then(function() {
+		return query("SHOW USERS");
+	}).then(function(results) {
+		renderResults($("admin-results"), results, "table");
+	});
+}
+
+document.querySelector("header [data-tab=admin]").addEventListener("click", function() {
+	refreshAdmin().catch(function(err) { $("admin-error").textContent = err.message; });
+});
+
+function refreshCardinality() {
+	var db = $("card-db").value, out = $("card-results");
+	if (!db) return;
+	query("SHOW SERIES CARDINALITY; SHOW MEASUREMENT CARDINALITY; SHOW MEASUREMENT STATS", db).then(function(results) {
+		renderResults(out, results, "table");
+	}).catch(function(err) { showError(out, err); });
+}
+
+$("card-run").addEventListener("click", refreshCardinality);
+$("card-db").addEventListener("change", refreshCardinality);
+document.querySelector("header [data-tab=cardinality]").addEventListener("click", refreshCardinality);
+
+// Without authentication, or with a session from an earlier visit, the
+// queries succeed; otherwise the 401 shows the login form.
+loggedIn(sessionStorage.getItem("freetsdb-user"));
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,348 @@
+package httpd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/freetsdb/freetsdb/coordinator"
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/tsdb"
+)
+
+// The usage statistics are written to the monitor database with this stable
+// schema: a "usage" measurement tagged by user and database, whose fields are
+// counters since the node started. The bytes stored by a database are the
+// "diskBytes" of its shards in the "shard" measurement.
+const (
+	usageMeasurement = "usage"
+	usageUserTag     = "user"
+	usageDatabaseTag = "database"
+
+	statUsagePointsWritten   = "pointsWritten"   // Number of points written.
+	statUsageQueriesExecuted = "queriesExecuted" // Number of queries executed.
+	statUsageQueryDuration   = "queryDurationNs" // Time spent executing queries.
+)
+
+const (
+	// DefaultUsageWindow is the duration of the windows usage is reported
+	// in when the request sets none.
+	DefaultUsageWindow = time.Hour
+
+	// DefaultUsagePeriod is the period usage is reported for when the
+	// request sets no start time.
+	DefaultUsagePeriod = 24 * time.Hour
+)
+
+// usageKey is the user and database usage is counted for.
+type usageKey struct {
+	user, database string
+}
+
+// usageCounters are the usage counters of a user on a database.
+type usageCounters struct {
+	PointsWritten   int64
+	QueriesExecuted int64
+	QueryDuration   int64
+}
+
+// usageTracker counts the points written and queries executed by each user on
+// each database.
+type usageTracker struct {
+	mu       sync.Mutex
+	counters map[usageKey]*usageCounters
+}
+
+func newUsageTracker() *usageTracker {
+	return &usageTracker{counters: make(map[usageKey]*usageCounters)}
+}
+
+// get returns the counters of user on database.
+func (u *usageTracker) get(user, database string) *usageCounters {
+	key := usageKey{user: user, database: database}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	c := u.counters[key]
+	if c == nil {
+		c = &usageCounters{}
+		u.counters[key] = c
+	}
+	return c
+}
+
+// addWrite counts n points written by user to database.
+func (u *usageTracker) addWrite(user, database string, n int) {
+	if n <= 0 {
+		return
+	}
+	atomic.AddInt64(&u.get(user, database).PointsWritten, int64(n))
+}
+
+// addWriteResult counts the points of a write of n points by user to
+// database that returned err. Only the points a partial write did not drop
+// are counted.
+func (u *usageTracker) addWriteResult(user, database string, n int, err error) {
+	if err == nil {
+		u.addWrite(user, database, n)
+	} else if werr, ok := err.(tsdb.PartialWriteError); ok {
+		u.addWrite(user, database, n-werr.Dropped)
+	}
+}
+
+// trackQuery returns the results of a query executed by user on database and
+// counts the query once its results are closed. The duration of the query is
+// the time from its start until its last result, including the time its
+// results wait to be read. The results are drained if abort is closed, so the
+// query is counted even if its results are not read.
+func (u *usageTracker) trackQuery(user, database string, results <-chan *query.Result, abort <-chan struct{}) <-chan *query.Result {
+	counters := u.get(user, database)
+	start := time.Now()
+
+	out := make(chan *query.Result)
+	go func() {
+		defer close(out)
+		defer func() {
+			atomic.AddInt64(&counters.QueriesExecuted, 1)
+			atomic.AddInt64(&counters.QueryDuration, int64(time.Since(start)))
+		}()
+
+		for r := range results {
+			select {
+			case out <- r:
+			case <-abort:
+				for range results {
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// UsagePointsWriter counts the points written through PointsWriter in the
+// usage of a handler. It is used by the services other than the HTTP API that
+// write points, whose privileged writes are counted for no user.
+type UsagePointsWriter struct {
+	PointsWriter interface {
+		WritePoints(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, user meta.User, points []models.Point) error
+		WritePointsPrivileged(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, points []models.Point) error
+	}
+
+	usage *usageTracker
+}
+
+// UsagePointsWriter returns a points writer that counts the points written
+// through pw in the usage of h.
+func (h *Handler) UsagePointsWriter(pw interface {
+	WritePoints(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, user meta.User, points []models.Point) error
+	WritePointsPrivileged(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, points []models.Point) error
+}) *UsagePointsWriter {
+	return &UsagePointsWriter{PointsWriter: pw, usage: h.usage}
+}
+
+// WritePoints writes points as user and counts them.
+func (w *UsagePointsWriter) WritePoints(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, user meta.User, points []models.Point) error {
+	err := w.PointsWriter.WritePoints(database, retentionPolicy, consistencyLevel, user, points)
+	w.usage.addWriteResult(userID(user), database, len(points), err)
+	return err
+}
+
+// WritePointsPrivileged writes points with no user and counts them.
+func (w *UsagePointsWriter) WritePointsPrivileged(database, retentionPolicy string, consistencyLevel coordinator.ConsistencyLevel, points []models.Point) error {
+	err := w.PointsWriter.WritePointsPrivileged(database, retentionPolicy, consistencyLevel, points)
+	w.usage.addWriteResult("", database, len(points), err)
+	return err
+}
+
+// statistics returns the usage statistics of every user on every database.
+func (u *usageTracker) statistics(tags map[string]string) []models.Statistic {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	statistics := make([]models.Statistic, 0, len(u.counters))
+	for key, c := range u.counters {
+		// Anonymous users and queries without a database have no tag.
+		t := make(map[string]string)
+		if key.user != "" {
+			t[usageUserTag] = key.user
+		}
+		if key.database != "" {
+			t[usageDatabaseTag] = key.database
+		}
+		statistics = append(statistics, models.Statistic{
+			Name: usageMeasurement,
+			Tags: models.NewTags(t).Merge(tags).Map(),
+			Values: map[string]interface{}{
+				statUsagePointsWritten:   atomic.LoadInt64(&c.PointsWritten),
+				statUsageQueriesExecuted: atomic.LoadInt64(&c.QueriesExecuted),
+				statUsageQueryDuration:   atomic.LoadInt64(&c.QueryDuration),
+			},
+		})
+	}
+	return statistics
+}
+
+// usageRow is the usage of a user on a database during a window.
+type usageRow struct {
+	Time            time.Time   `json:"time"`
+	User            string      `json:"user"`
+	Database        string      `json:"database"`
+	PointsWritten   interface{} `json:"pointsWritten"`
+	QueriesExecuted interface{} `json:"queriesExecuted"`
+	QueryDurationNs interface{} `json:"queryDurationNs"`
+}
+
+// storageRow is the number of bytes stored by a database at the end of a
+// window.
+type storageRow struct {
+	Time        time.Time   `json:"time"`
+	Database    string      `json:"database"`
+	BytesStored interface{} `json:"bytesStored"`
+}
+
+// serveUsage returns the usage of each user and database by time window, as
+// written to the monitor database. Users who are not admins only get their
+// own usage, and the storage of the databases they can read.
+func (h *Handler) serveUsage(w http.ResponseWriter, r *http.Request, user meta.User) {
+	end := time.Now().UTC()
+	if s := r.FormValue("end"); s != "" {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			h.httpError(w, "invalid end: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		end = t
+	}
+	start := end.Add(-DefaultUsagePeriod)
+	if s := r.FormValue("start"); s != "" {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			h.httpError(w, "invalid start: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		start = t
+	}
+	if !start.Before(end) {
+		h.httpError(w, "start must be before end", http.StatusBadRequest)
+		return
+	}
+	window := DefaultUsageWindow
+	if s := r.FormValue("window"); s != "" {
+		d, err := influxql.ParseDuration(s)
+		if err != nil || d <= 0 {
+			h.httpError(w, "window must be a positive duration", http.StatusBadRequest)
+			return
+		}
+		window = d
+	}
+
+	db, username := r.FormValue("db"), r.FormValue("user")
+	admin := !h.Config.AuthEnabled || (user != nil && user.AuthorizeUnrestricted())
+	if !admin {
+		if user == nil || (username != "" && username != user.ID()) {
+			h.httpError(w, "only admins can read the usage of other users", http.StatusForbidden)
+			return
+		}
+		username = user.ID()
+	}
+
+	// Counters are summed by their increase between samples, which ignores
+	// their resets when nodes restart.
+	cond := fmt.Sprintf("time >= %d AND time < %d", start.UnixNano(), end.UnixNano())
+	filter := cond
+	if db != "" {
+		filter += fmt.Sprintf(" AND %s = %s", influxql.QuoteIdent(usageDatabaseTag), influxql.QuoteString(db))
+	}
+	usageFilter := filter
+	if username != "" {
+		usageFilter += fmt.Sprintf(" AND %s = %s", influxql.QuoteIdent(usageUserTag), influxql.QuoteString(username))
+	}
+	var fields, sums []string
+	for _, f := range []string{statUsagePointsWritten, statUsageQueriesExecuted, statUsageQueryDuration} {
+		fields = append(fields, fmt.Sprintf("non_negative_difference(%[1]s) AS %[1]s", influxql.QuoteIdent(f)))
+		sums = append(sums, fmt.Sprintf("sum(%[1]s) AS %[1]s", influxql.QuoteIdent(f)))
+	}
+	usageQuery := fmt.Sprintf("SELECT %s FROM (SELECT %s FROM %s WHERE %s GROUP BY *) WHERE %s GROUP BY time(%s), %s, %s fill(none)",
+		strings.Join(sums, ", "), strings.Join(fields, ", "), influxql.QuoteIdent(usageMeasurement), usageFilter,
+		cond, influxql.FormatDuration(window), influxql.QuoteIdent(usageUserTag), influxql.QuoteIdent(usageDatabaseTag))
+	storageQuery := fmt.Sprintf(`SELECT sum("diskBytes") AS "bytesStored" FROM (SELECT last("diskBytes") AS "diskBytes" FROM "shard" WHERE %s GROUP BY time(%s), *) WHERE %s GROUP BY time(%s), %s fill(none)`,
+		filter, influxql.FormatDuration(window), cond, influxql.FormatDuration(window), influxql.QuoteIdent(usageDatabaseTag))
+
+	resp := struct {
+		Usage   []usageRow   `json:"usage"`
+		Storage []storageRow `json:"storage"`
+	}{Usage: []usageRow{}, Storage: []storageRow{}}
+
+	rows, err := h.queryUsage(usageQuery)
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, row := range rows {
+		for _, values := range row.Values {
+			if len(values) != 4 {
+				continue
+			}
+			t, _ := values[0].(time.Time)
+			resp.Usage = append(resp.Usage, usageRow{
+				Time:            t,
+				User:            row.Tags[usageUserTag],
+				Database:        row.Tags[usageDatabaseTag],
+				PointsWritten:   values[1],
+				QueriesExecuted: values[2],
+				QueryDurationNs: values[3],
+			})
+		}
+	}
+
+	rows, err = h.queryUsage(storageQuery)
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, row := range rows {
+		database := row.Tags[usageDatabaseTag]
+		if !admin && !user.AuthorizeDatabase(influxql.ReadPrivilege, database) {
+			continue
+		}
+		for _, values := range row.Values {
+			if len(values) != 2 {
+				continue
+			}
+			t, _ := values[0].(time.Time)
+			resp.Storage = append(resp.Storage, storageRow{Time: t, Database: database, BytesStored: values[1]})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeHeader(w, http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// queryUsage executes qs on the monitor database and returns its rows.
+func (h *Handler) queryUsage(qs string) (models.Rows, error) {
+	q, err := influxql.ParseQuery(qs)
+	if err != nil {
+		return nil, err
+	}
+	opts := query.ExecutionOptions{
+		Database:   h.MonitorDatabase,
+		ReadOnly:   true,
+		Authorizer: query.OpenAuthorizer,
+	}
+	var rows models.Rows
+	for result := range h.QueryExecutor.ExecuteQuery(q, opts, nil) {
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		rows = append(rows, result.Series...)
+	}
+	return rows, nil
+}
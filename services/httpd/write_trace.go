@@ -0,0 +1,35 @@
+package httpd
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/freetsdb/freetsdb/logger"
+	"github.com/freetsdb/freetsdb/tsdb"
+	"github.com/freetsdb/freetsdb/uuid"
+	"go.uber.org/zap"
+)
+
+// sampleWriteTrace returns a new trace if the write should be traced, or nil.
+// With write-tracing enabled every write is traced, otherwise writes are
+// sampled at the write-trace-sample-rate.
+func (h *Handler) sampleWriteTrace() *tsdb.WriteTrace {
+	if !h.Config.WriteTracing && (h.Config.WriteTraceSampleRate <= 0 || rand.Float64() >= h.Config.WriteTraceSampleRate) {
+		return nil
+	}
+	return tsdb.NewWriteTrace(uuid.TimeUUID().String())
+}
+
+// logWriteTrace logs the time spent in each stage of a traced write to
+// database that started at start.
+func (h *Handler) logWriteTrace(trace *tsdb.WriteTrace, database string, start time.Time) {
+	log := h.Logger.With(zap.String("trace_id", trace.ID), logger.Database(database))
+	for _, s := range trace.Stages() {
+		fields := []zap.Field{zap.String("stage", s.Name), zap.Duration("duration", s.Duration)}
+		if s.Shard != 0 {
+			fields = append(fields, logger.Shard(s.Shard))
+		}
+		log.Info("Write trace stage", fields...)
+	}
+	log.Info("Write trace finished", zap.Duration("duration", time.Since(start)))
+}
@@ -209,54 +209,81 @@ func (Statements) node() {}
 func (*AlterRetentionPolicyStatement) node()       {}
 func (*CreateContinuousQueryStatement) node()      {}
 func (*CreateDatabaseStatement) node()             {}
+func (*CreateMaterializedViewStatement) node()     {}
+func (*CreateQueryTemplateStatement) node()        {}
 func (*CreateRetentionPolicyStatement) node()      {}
 func (*CreateSubscriptionStatement) node()         {}
+func (*CreateRoleStatement) node()                 {}
 func (*CreateUserStatement) node()                 {}
+func (*CopyShardStatement) node()                  {}
 func (*Distinct) node()                            {}
 func (*DeleteSeriesStatement) node()               {}
 func (*DeleteStatement) node()                     {}
 func (*DropContinuousQueryStatement) node()        {}
 func (*DropDatabaseStatement) node()               {}
+func (*DropMaterializedViewStatement) node()       {}
 func (*DropMeasurementStatement) node()            {}
+func (*DropQueryTemplateStatement) node()          {}
 func (*DropRetentionPolicyStatement) node()        {}
 func (*DropSeriesStatement) node()                 {}
 func (*DropShardStatement) node()                  {}
 func (*DropSubscriptionStatement) node()           {}
+func (*DropRoleStatement) node()                   {}
 func (*DropUserStatement) node()                   {}
+func (*ExecuteQueryTemplateStatement) node()       {}
 func (*ExplainStatement) node()                    {}
 func (*GrantStatement) node()                      {}
 func (*GrantAdminStatement) node()                 {}
+func (*GrantRoleStatement) node()                  {}
+func (*GrantRolePrivilegeStatement) node()         {}
 func (*KillQueryStatement) node()                  {}
+func (*KillSessionStatement) node()                {}
+func (*ReindexShardStatement) node()               {}
 func (*RevokeStatement) node()                     {}
 func (*RevokeAdminStatement) node()                {}
+func (*RevokeRoleStatement) node()                 {}
+func (*RevokeRolePrivilegeStatement) node()        {}
 func (*SelectStatement) node()                     {}
 func (*SetPasswordUserStatement) node()            {}
 func (*ShowContinuousQueriesStatement) node()      {}
+func (*ShowGrantsForRoleStatement) node()          {}
 func (*ShowGrantsForUserStatement) node()          {}
 func (*ShowServersStatement) node()                {}
 func (*ShowDatabasesStatement) node()              {}
 func (*ShowFieldKeyCardinalityStatement) node()    {}
 func (*ShowFieldKeysStatement) node()              {}
+func (*ShowMaterializedViewsStatement) node()      {}
 func (*ShowRetentionPoliciesStatement) node()      {}
 func (*ShowMeasurementCardinalityStatement) node() {}
+func (*ShowMeasurementStatsStatement) node()       {}
 func (*ShowMeasurementsStatement) node()           {}
 func (*ShowQueriesStatement) node()                {}
+func (*ShowQueryTemplatesStatement) node()         {}
+func (*ShowSessionsStatement) node()               {}
+func (*ShowRolesStatement) node()                  {}
 func (*ShowSeriesStatement) node()                 {}
 func (*ShowSeriesCardinalityStatement) node()      {}
+func (*ShowShardJobsStatement) node()              {}
+func (*ShowShardStatement) node()                  {}
 func (*ShowShardGroupsStatement) node()            {}
 func (*ShowShardsStatement) node()                 {}
 func (*ShowStatsStatement) node()                  {}
 func (*ShowSubscriptionsStatement) node()          {}
 func (*ShowDiagnosticsStatement) node()            {}
+func (*ShowDiskUsageStatement) node()              {}
 func (*ShowTagKeyCardinalityStatement) node()      {}
 func (*ShowTagKeysStatement) node()                {}
 func (*ShowTagValuesCardinalityStatement) node()   {}
 func (*ShowTagValuesStatement) node()              {}
 func (*ShowUsersStatement) node()                  {}
+func (*TruncateShardsStatement) node()             {}
 
 func (*BinaryExpr) node()      {}
 func (*BooleanLiteral) node()  {}
+func (*BoundParameter) node()  {}
+func (*CalendarLiteral) node() {}
 func (*Call) node()            {}
+func (*CaseExpr) node()        {}
 func (*Dimension) node()       {}
 func (Dimensions) node()       {}
 func (*DurationLiteral) node() {}
@@ -338,47 +365,71 @@ type ExecutionPrivileges []ExecutionPrivilege
 func (*AlterRetentionPolicyStatement) stmt()       {}
 func (*CreateContinuousQueryStatement) stmt()      {}
 func (*CreateDatabaseStatement) stmt()             {}
+func (*CreateMaterializedViewStatement) stmt()     {}
+func (*CreateQueryTemplateStatement) stmt()        {}
 func (*CreateRetentionPolicyStatement) stmt()      {}
 func (*CreateSubscriptionStatement) stmt()         {}
+func (*CreateRoleStatement) stmt()                 {}
 func (*CreateUserStatement) stmt()                 {}
+func (*CopyShardStatement) stmt()                  {}
 func (*DeleteSeriesStatement) stmt()               {}
 func (*DeleteStatement) stmt()                     {}
 func (*DropContinuousQueryStatement) stmt()        {}
 func (*DropDatabaseStatement) stmt()               {}
+func (*DropMaterializedViewStatement) stmt()       {}
 func (*DropMeasurementStatement) stmt()            {}
+func (*DropQueryTemplateStatement) stmt()          {}
 func (*DropRetentionPolicyStatement) stmt()        {}
 func (*DropSeriesStatement) stmt()                 {}
 func (*DropSubscriptionStatement) stmt()           {}
+func (*DropRoleStatement) stmt()                   {}
 func (*DropUserStatement) stmt()                   {}
+func (*ExecuteQueryTemplateStatement) stmt()       {}
 func (*ExplainStatement) stmt()                    {}
 func (*GrantStatement) stmt()                      {}
 func (*GrantAdminStatement) stmt()                 {}
+func (*GrantRoleStatement) stmt()                  {}
+func (*GrantRolePrivilegeStatement) stmt()         {}
 func (*KillQueryStatement) stmt()                  {}
+func (*KillSessionStatement) stmt()                {}
 func (*ShowContinuousQueriesStatement) stmt()      {}
+func (*ShowGrantsForRoleStatement) stmt()          {}
 func (*ShowGrantsForUserStatement) stmt()          {}
 func (*ShowServersStatement) stmt()                {}
 func (*ShowDatabasesStatement) stmt()              {}
 func (*ShowFieldKeyCardinalityStatement) stmt()    {}
 func (*ShowFieldKeysStatement) stmt()              {}
+func (*ShowMaterializedViewsStatement) stmt()      {}
 func (*ShowMeasurementCardinalityStatement) stmt() {}
+func (*ShowMeasurementStatsStatement) stmt()       {}
 func (*ShowMeasurementsStatement) stmt()           {}
 func (*ShowQueriesStatement) stmt()                {}
+func (*ShowQueryTemplatesStatement) stmt()         {}
+func (*ShowSessionsStatement) stmt()               {}
+func (*ShowRolesStatement) stmt()                  {}
 func (*ShowRetentionPoliciesStatement) stmt()      {}
 func (*ShowSeriesStatement) stmt()                 {}
 func (*ShowSeriesCardinalityStatement) stmt()      {}
+func (*ShowShardJobsStatement) stmt()              {}
+func (*ShowShardStatement) stmt()                  {}
 func (*ShowShardGroupsStatement) stmt()            {}
 func (*ShowShardsStatement) stmt()                 {}
 func (*ShowStatsStatement) stmt()                  {}
 func (*DropShardStatement) stmt()                  {}
 func (*ShowSubscriptionsStatement) stmt()          {}
 func (*ShowDiagnosticsStatement) stmt()            {}
+func (*ShowDiskUsageStatement) stmt()              {}
 func (*ShowTagKeyCardinalityStatement) stmt()      {}
 func (*ShowTagKeysStatement) stmt()                {}
 func (*ShowTagValuesCardinalityStatement) stmt()   {}
 func (*ShowTagValuesStatement) stmt()              {}
 func (*ShowUsersStatement) stmt()                  {}
+func (*TruncateShardsStatement) stmt()             {}
+func (*ReindexShardStatement) stmt()               {}
 func (*RevokeStatement) stmt()                     {}
 func (*RevokeAdminStatement) stmt()                {}
+func (*RevokeRoleStatement) stmt()                 {}
+func (*RevokeRolePrivilegeStatement) stmt()        {}
 func (*SelectStatement) stmt()                     {}
 func (*SetPasswordUserStatement) stmt()            {}
 
@@ -392,7 +443,10 @@ type Expr interface {
 
 func (*BinaryExpr) expr()      {}
 func (*BooleanLiteral) expr()  {}
+func (*BoundParameter) expr()  {}
+func (*CalendarLiteral) expr() {}
 func (*Call) expr()            {}
+func (*CaseExpr) expr()        {}
 func (*Distinct) expr()        {}
 func (*DurationLiteral) expr() {}
 func (*IntegerLiteral) expr()  {}
@@ -416,6 +470,7 @@ type Literal interface {
 }
 
 func (*BooleanLiteral) literal()  {}
+func (*CalendarLiteral) literal() {}
 func (*DurationLiteral) literal() {}
 func (*IntegerLiteral) literal()  {}
 func (*UnsignedLiteral) literal() {}
@@ -590,6 +645,9 @@ type CreateDatabaseStatement struct {
 
 	// RetentionPolicyShardGroupDuration indicates shard group duration for the new database.
 	RetentionPolicyShardGroupDuration time.Duration
+
+	// ShardKey is the tag keys whose values select the shard of a point.
+	ShardKey []string
 }
 
 // String returns a string representation of the create database statement.
@@ -616,6 +674,18 @@ func (s *CreateDatabaseStatement) String() string {
 			_, _ = buf.WriteString(QuoteIdent(s.RetentionPolicyName))
 		}
 	}
+	if len(s.ShardKey) > 0 {
+		if !s.RetentionPolicyCreate {
+			_, _ = buf.WriteString(" WITH")
+		}
+		_, _ = buf.WriteString(" SHARD KEY ")
+		for i, key := range s.ShardKey {
+			if i > 0 {
+				_, _ = buf.WriteString(", ")
+			}
+			_, _ = buf.WriteString(QuoteIdent(key))
+		}
+	}
 
 	return buf.String()
 }
@@ -703,6 +773,44 @@ func (s *CreateUserStatement) RequiredPrivileges() (ExecutionPrivileges, error)
 	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
 }
 
+// CreateRoleStatement represents a command for creating a new role.
+type CreateRoleStatement struct {
+	// Name of the role to be created.
+	Name string
+}
+
+// String returns a string representation of the create role statement.
+func (s *CreateRoleStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("CREATE ROLE ")
+	_, _ = buf.WriteString(QuoteIdent(s.Name))
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a CreateRoleStatement.
+func (s *CreateRoleStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
+// DropRoleStatement represents a command for dropping a role.
+type DropRoleStatement struct {
+	// Name of the role to drop.
+	Name string
+}
+
+// String returns a string representation of the drop role statement.
+func (s *DropRoleStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("DROP ROLE ")
+	_, _ = buf.WriteString(QuoteIdent(s.Name))
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a DropRoleStatement.
+func (s *DropRoleStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
 // DropUserStatement represents a command for dropping a user.
 type DropUserStatement struct {
 	// Name of the user to drop.
@@ -807,6 +915,65 @@ func (s *GrantAdminStatement) RequiredPrivileges() (ExecutionPrivileges, error)
 	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
 }
 
+// GrantRoleStatement represents a command for adding a user to a role.
+type GrantRoleStatement struct {
+	// The role to be granted.
+	Role string
+
+	// Who to grant the role to.
+	User string
+}
+
+// String returns a string representation of the grant role statement.
+func (s *GrantRoleStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("GRANT ")
+	_, _ = buf.WriteString(QuoteIdent(s.Role))
+	_, _ = buf.WriteString(" TO ")
+	_, _ = buf.WriteString(QuoteIdent(s.User))
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a GrantRoleStatement.
+func (s *GrantRoleStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
+// GrantRolePrivilegeStatement represents a command for granting a privilege
+// to a role.
+type GrantRolePrivilegeStatement struct {
+	// The privilege to be granted.
+	Privilege Privilege
+
+	// Database to grant the privilege to.
+	On string
+
+	// The role to grant the privilege to.
+	Role string
+}
+
+// String returns a string representation of the grant role privilege statement.
+func (s *GrantRolePrivilegeStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("GRANT ")
+	_, _ = buf.WriteString(s.Privilege.String())
+	_, _ = buf.WriteString(" ON ")
+	_, _ = buf.WriteString(QuoteIdent(s.On))
+	_, _ = buf.WriteString(" TO ROLE ")
+	_, _ = buf.WriteString(QuoteIdent(s.Role))
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a GrantRolePrivilegeStatement.
+func (s *GrantRolePrivilegeStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
+// DefaultDatabase returns the default database from the statement.
+func (s *GrantRolePrivilegeStatement) DefaultDatabase() string {
+	return s.On
+}
+
 // KillQueryStatement represents a command for killing a query.
 type KillQueryStatement struct {
 	// The query to kill.
@@ -833,6 +1000,22 @@ func (s *KillQueryStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
 	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
 }
 
+// KillSessionStatement represents a command for ending a login session.
+type KillSessionStatement struct {
+	// The session to end.
+	ID string
+}
+
+// String returns a string representation of the kill session statement.
+func (s *KillSessionStatement) String() string {
+	return "KILL SESSION " + QuoteString(s.ID)
+}
+
+// RequiredPrivileges returns the privilege required to execute a KillSessionStatement.
+func (s *KillSessionStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
 // SetPasswordUserStatement represents a command for changing user password.
 type SetPasswordUserStatement struct {
 	// Plain-text password.
@@ -910,6 +1093,65 @@ func (s *RevokeAdminStatement) RequiredPrivileges() (ExecutionPrivileges, error)
 	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
 }
 
+// RevokeRoleStatement represents a command to remove a user from a role.
+type RevokeRoleStatement struct {
+	// The role to be revoked.
+	Role string
+
+	// Who to revoke the role from.
+	User string
+}
+
+// String returns a string representation of the revoke role statement.
+func (s *RevokeRoleStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("REVOKE ")
+	_, _ = buf.WriteString(QuoteIdent(s.Role))
+	_, _ = buf.WriteString(" FROM ")
+	_, _ = buf.WriteString(QuoteIdent(s.User))
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a RevokeRoleStatement.
+func (s *RevokeRoleStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
+// RevokeRolePrivilegeStatement represents a command to revoke a privilege
+// from a role.
+type RevokeRolePrivilegeStatement struct {
+	// The privilege to be revoked.
+	Privilege Privilege
+
+	// Database to revoke the privilege from.
+	On string
+
+	// The role to revoke the privilege from.
+	Role string
+}
+
+// String returns a string representation of the revoke role privilege statement.
+func (s *RevokeRolePrivilegeStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("REVOKE ")
+	_, _ = buf.WriteString(s.Privilege.String())
+	_, _ = buf.WriteString(" ON ")
+	_, _ = buf.WriteString(QuoteIdent(s.On))
+	_, _ = buf.WriteString(" FROM ROLE ")
+	_, _ = buf.WriteString(QuoteIdent(s.Role))
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a RevokeRolePrivilegeStatement.
+func (s *RevokeRolePrivilegeStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
+// DefaultDatabase returns the default database from the statement.
+func (s *RevokeRolePrivilegeStatement) DefaultDatabase() string {
+	return s.On
+}
+
 // CreateRetentionPolicyStatement represents a command to create a retention policy.
 type CreateRetentionPolicyStatement struct {
 	// Name of policy to create.
@@ -929,6 +1171,12 @@ type CreateRetentionPolicyStatement struct {
 
 	// Shard Duration.
 	ShardGroupDuration time.Duration
+
+	// How far ahead of now points may be written.
+	FutureWriteLimit time.Duration
+
+	// How far behind now points may be written.
+	PastWriteLimit time.Duration
 }
 
 // String returns a string representation of the create retention policy.
@@ -946,6 +1194,14 @@ func (s *CreateRetentionPolicyStatement) String() string {
 		_, _ = buf.WriteString(" SHARD DURATION ")
 		_, _ = buf.WriteString(FormatDuration(s.ShardGroupDuration))
 	}
+	if s.FutureWriteLimit > 0 {
+		_, _ = buf.WriteString(" FUTURE LIMIT ")
+		_, _ = buf.WriteString(FormatDuration(s.FutureWriteLimit))
+	}
+	if s.PastWriteLimit > 0 {
+		_, _ = buf.WriteString(" PAST LIMIT ")
+		_, _ = buf.WriteString(FormatDuration(s.PastWriteLimit))
+	}
 	if s.Default {
 		_, _ = buf.WriteString(" DEFAULT")
 	}
@@ -981,6 +1237,15 @@ type AlterRetentionPolicyStatement struct {
 
 	// Duration of the Shard.
 	ShardGroupDuration *time.Duration
+
+	// How far ahead of now points may be written.
+	FutureWriteLimit *time.Duration
+
+	// How far behind now points may be written.
+	PastWriteLimit *time.Duration
+
+	// Measurement whose shard group duration is overridden, if any.
+	Measurement string
 }
 
 // String returns a string representation of the alter retention policy statement.
@@ -1001,11 +1266,26 @@ func (s *AlterRetentionPolicyStatement) String() string {
 		_, _ = buf.WriteString(strconv.Itoa(*s.Replication))
 	}
 
+	if s.Measurement != "" {
+		_, _ = buf.WriteString(" MEASUREMENT ")
+		_, _ = buf.WriteString(QuoteIdent(s.Measurement))
+	}
+
 	if s.ShardGroupDuration != nil {
 		_, _ = buf.WriteString(" SHARD DURATION ")
 		_, _ = buf.WriteString(FormatDuration(*s.ShardGroupDuration))
 	}
 
+	if s.FutureWriteLimit != nil {
+		_, _ = buf.WriteString(" FUTURE LIMIT ")
+		_, _ = buf.WriteString(FormatDuration(*s.FutureWriteLimit))
+	}
+
+	if s.PastWriteLimit != nil {
+		_, _ = buf.WriteString(" PAST LIMIT ")
+		_, _ = buf.WriteString(FormatDuration(*s.PastWriteLimit))
+	}
+
 	if s.Default {
 		_, _ = buf.WriteString(" DEFAULT")
 	}
@@ -1086,6 +1366,10 @@ type SelectStatement struct {
 	// The timezone for the query, if any.
 	Location *time.Location
 
+	// The fraction of the series selected by sample_series(). All series are
+	// selected if zero.
+	SampleSeries float64
+
 	// Renames the implicit time field name.
 	TimeAlias string
 
@@ -1786,6 +2070,9 @@ func (s *SelectStatement) String() string {
 	if s.Location != nil {
 		_, _ = fmt.Fprintf(&buf, ` TZ('%s')`, s.Location)
 	}
+	if s.SampleSeries > 0 {
+		_, _ = fmt.Fprintf(&buf, " sample_series(%s)", strconv.FormatFloat(s.SampleSeries, 'f', -1, 64))
+	}
 	return buf.String()
 }
 
@@ -1857,18 +2144,61 @@ func (s *SelectStatement) GroupByInterval() (time.Duration, error) {
 				return 0, errors.New("time dimension expected 1 or 2 arguments")
 			}
 
-			// Ensure the argument is a duration.
-			lit, ok := call.Args[0].(*DurationLiteral)
-			if !ok {
+			// Ensure the argument is a duration. Calendar intervals vary in
+			// length so they are as long as their longest window.
+			switch lit := call.Args[0].(type) {
+			case *DurationLiteral:
+				s.groupByInterval = lit.Val
+			case *CalendarLiteral:
+				s.groupByInterval = lit.MaxDuration()
+			default:
 				return 0, errors.New("time dimension must have duration argument")
 			}
-			s.groupByInterval = lit.Val
-			return lit.Val, nil
+			return s.groupByInterval, nil
 		}
 	}
 	return 0, nil
 }
 
+// GroupByMonths returns the number of months in a calendar GROUP BY time()
+// interval such as time(1mo). It returns 0 for any other interval.
+func (s *SelectStatement) GroupByMonths() int {
+	for _, d := range s.Dimensions {
+		if call, ok := d.Expr.(*Call); ok && call.Name == "time" && len(call.Args) > 0 {
+			if lit, ok := call.Args[0].(*CalendarLiteral); ok {
+				return lit.Months
+			}
+		}
+	}
+	return 0
+}
+
+// SetGroupByInterval sets the interval of the GROUP BY time() dimension. The
+// dimension is added if it does not exist, and removed if d is 0.
+func (s *SelectStatement) SetGroupByInterval(d time.Duration) {
+	s.groupByInterval = 0
+
+	dimensions := s.Dimensions[:0]
+	found := false
+	for _, dim := range s.Dimensions {
+		if call, ok := dim.Expr.(*Call); ok && call.Name == "time" {
+			if d == 0 {
+				continue
+			}
+			args := append([]Expr{&DurationLiteral{Val: d}}, call.Args[1:]...)
+			dim = &Dimension{Expr: &Call{Name: "time", Args: args}}
+			found = true
+		}
+		dimensions = append(dimensions, dim)
+	}
+	if !found && d > 0 {
+		dimensions = append(dimensions, &Dimension{
+			Expr: &Call{Name: "time", Args: []Expr{&DurationLiteral{Val: d}}},
+		})
+	}
+	s.Dimensions = dimensions
+}
+
 // GroupByOffset extracts the time interval offset, if specified.
 func (s *SelectStatement) GroupByOffset() (time.Duration, error) {
 	interval, err := s.GroupByInterval()
@@ -1886,6 +2216,10 @@ func (s *SelectStatement) GroupByOffset() (time.Duration, error) {
 			if len(call.Args) == 2 {
 				switch expr := call.Args[1].(type) {
 				case *DurationLiteral:
+					// Calendar windows vary in length so their offset is not reduced.
+					if _, ok := call.Args[0].(*CalendarLiteral); ok {
+						return expr.Val, nil
+					}
 					return expr.Val % interval, nil
 				case *TimeLiteral:
 					return expr.Val.Sub(expr.Val.Truncate(interval)), nil
@@ -1990,6 +2324,16 @@ func walkNames(exp Expr) []string {
 		return ret
 	case *ParenExpr:
 		return walkNames(expr.Expr)
+	case *CaseExpr:
+		var ret []string
+		for _, w := range expr.WhenClauses {
+			ret = append(ret, walkNames(w.Condition)...)
+			ret = append(ret, walkNames(w.Result)...)
+		}
+		if expr.Else != nil {
+			ret = append(ret, walkNames(expr.Else)...)
+		}
+		return ret
 	}
 
 	return nil
@@ -2005,15 +2349,21 @@ func walkRefs(exp Expr) []VarRef {
 			refs[*expr] = struct{}{}
 		case *Call:
 			for _, expr := range expr.Args {
-				if ref, ok := expr.(*VarRef); ok {
-					refs[*ref] = struct{}{}
-				}
+				walk(expr)
 			}
 		case *BinaryExpr:
 			walk(expr.LHS)
 			walk(expr.RHS)
 		case *ParenExpr:
 			walk(expr.Expr)
+		case *CaseExpr:
+			for _, w := range expr.WhenClauses {
+				walk(w.Condition)
+				walk(w.Result)
+			}
+			if expr.Else != nil {
+				walk(expr.Else)
+			}
 		}
 	}
 	walk(exp)
@@ -2273,6 +2623,50 @@ func (s *DropShardStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
 	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
 }
 
+// CopyShardStatement represents a command for copying a shard to another
+// data node, which becomes an owner of the shard.
+type CopyShardStatement struct {
+	// ID of the shard to be copied.
+	ID uint64
+
+	// ID of the data node to copy the shard to.
+	NodeID uint64
+}
+
+// String returns a string representation of the copy shard statement.
+func (s *CopyShardStatement) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("COPY SHARD ")
+	buf.WriteString(strconv.FormatUint(s.ID, 10))
+	buf.WriteString(" TO ")
+	buf.WriteString(strconv.FormatUint(s.NodeID, 10))
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a
+// CopyShardStatement.
+func (s *CopyShardStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
+// ReindexShardStatement represents a command for rebuilding the TSI index of
+// a shard on the data nodes that own it.
+type ReindexShardStatement struct {
+	// ID of the shard to be reindexed.
+	ID uint64
+}
+
+// String returns a string representation of the reindex shard statement.
+func (s *ReindexShardStatement) String() string {
+	return "REINDEX SHARD " + strconv.FormatUint(s.ID, 10)
+}
+
+// RequiredPrivileges returns the privilege required to execute a
+// ReindexShardStatement.
+func (s *ReindexShardStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
 // ShowSeriesCardinalityStatement represents a command for listing series cardinality.
 type ShowSeriesCardinalityStatement struct {
 	// Database to query. If blank, use the default database.
@@ -2375,6 +2769,26 @@ func (s *ShowGrantsForUserStatement) RequiredPrivileges() (ExecutionPrivileges,
 	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
 }
 
+// ShowGrantsForRoleStatement represents a command for listing role privileges.
+type ShowGrantsForRoleStatement struct {
+	// Name of the role to display privileges.
+	Name string
+}
+
+// String returns a string representation of the show grants for role.
+func (s *ShowGrantsForRoleStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("SHOW GRANTS FOR ROLE ")
+	_, _ = buf.WriteString(QuoteIdent(s.Name))
+
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a ShowGrantsForRoleStatement
+func (s *ShowGrantsForRoleStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
 // ShowServersStatement represents a command for listing all servers.
 type ShowServersStatement struct{}
 
@@ -2500,6 +2914,172 @@ func (s *DropContinuousQueryStatement) DefaultDatabase() string {
 	return s.Database
 }
 
+// CreateMaterializedViewStatement represents a command for creating a
+// materialized view.
+type CreateMaterializedViewStatement struct {
+	// Name of the view.
+	Name string
+
+	// Database the view belongs to.
+	Database string
+
+	// Aggregate query whose results the view stores.
+	Source *SelectStatement
+}
+
+// String returns a string representation of the statement.
+func (s *CreateMaterializedViewStatement) String() string {
+	return fmt.Sprintf("CREATE MATERIALIZED VIEW %s ON %s AS %s", QuoteIdent(s.Name), QuoteIdent(s.Database), s.Source.String())
+}
+
+// DefaultDatabase returns the default database from the statement.
+func (s *CreateMaterializedViewStatement) DefaultDatabase() string {
+	return s.Database
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a CreateMaterializedViewStatement.
+func (s *CreateMaterializedViewStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: false, Name: s.Database, Privilege: WritePrivilege}}, nil
+}
+
+// DropMaterializedViewStatement represents a command for removing a
+// materialized view.
+type DropMaterializedViewStatement struct {
+	Name     string
+	Database string
+}
+
+// String returns a string representation of the statement.
+func (s *DropMaterializedViewStatement) String() string {
+	return fmt.Sprintf("DROP MATERIALIZED VIEW %s ON %s", QuoteIdent(s.Name), QuoteIdent(s.Database))
+}
+
+// DefaultDatabase returns the default database from the statement.
+func (s *DropMaterializedViewStatement) DefaultDatabase() string {
+	return s.Database
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a DropMaterializedViewStatement.
+func (s *DropMaterializedViewStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: false, Name: s.Database, Privilege: WritePrivilege}}, nil
+}
+
+// ShowMaterializedViewsStatement represents a command for listing materialized views.
+type ShowMaterializedViewsStatement struct{}
+
+// String returns a string representation of the statement.
+func (s *ShowMaterializedViewsStatement) String() string { return "SHOW MATERIALIZED VIEWS" }
+
+// RequiredPrivileges returns the privilege(s) required to execute a ShowMaterializedViewsStatement.
+func (s *ShowMaterializedViewsStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: false, Name: "", Privilege: ReadPrivilege}}, nil
+}
+
+// CreateQueryTemplateStatement represents a command for storing a SELECT
+// statement under a name so that it can be executed with parameters.
+type CreateQueryTemplateStatement struct {
+	// Name of the template. Segments separated by dots group templates,
+	// such as "dashboards.cpu".
+	Name string
+
+	// Source of the template. Its bound parameters are left unresolved.
+	Source *SelectStatement
+}
+
+// String returns a string representation of the statement.
+func (s *CreateQueryTemplateStatement) String() string {
+	return fmt.Sprintf("CREATE QUERY TEMPLATE %s AS %s", quoteQueryTemplateName(s.Name), s.Source.String())
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a CreateQueryTemplateStatement.
+func (s *CreateQueryTemplateStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
+// DropQueryTemplateStatement represents a command for removing a query template.
+type DropQueryTemplateStatement struct {
+	Name string
+}
+
+// String returns a string representation of the statement.
+func (s *DropQueryTemplateStatement) String() string {
+	return fmt.Sprintf("DROP QUERY TEMPLATE %s", quoteQueryTemplateName(s.Name))
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a DropQueryTemplateStatement.
+func (s *DropQueryTemplateStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
+// ShowQueryTemplatesStatement represents a command for listing query templates.
+type ShowQueryTemplatesStatement struct{}
+
+// String returns a string representation of the statement.
+func (s *ShowQueryTemplatesStatement) String() string { return "SHOW QUERY TEMPLATES" }
+
+// RequiredPrivileges returns the privilege(s) required to execute a ShowQueryTemplatesStatement.
+func (s *ShowQueryTemplatesStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: false, Name: "", Privilege: ReadPrivilege}}, nil
+}
+
+// ExecuteQueryTemplateStatement represents a command for executing a query
+// template with values for its bound parameters.
+type ExecuteQueryTemplateStatement struct {
+	Name string
+
+	// Version of the template to execute. If zero, the current version is
+	// executed.
+	Version uint64
+
+	// Params are the values of the bound parameters by name.
+	Params map[string]Literal
+}
+
+// String returns a string representation of the statement.
+func (s *ExecuteQueryTemplateStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("EXECUTE QUERY TEMPLATE ")
+	_, _ = buf.WriteString(quoteQueryTemplateName(s.Name))
+	if s.Version > 0 {
+		_, _ = buf.WriteString(" VERSION ")
+		_, _ = buf.WriteString(strconv.FormatUint(s.Version, 10))
+	}
+	if len(s.Params) > 0 {
+		names := make([]string, 0, len(s.Params))
+		for name := range s.Params {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		_, _ = buf.WriteString(" WITH ")
+		for i, name := range names {
+			if i > 0 {
+				_, _ = buf.WriteString(", ")
+			}
+			_, _ = buf.WriteString(QuoteIdent(name))
+			_, _ = buf.WriteString(" = ")
+			_, _ = buf.WriteString(s.Params[name].String())
+		}
+	}
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute an
+// ExecuteQueryTemplateStatement. The statement of the template is authorized
+// separately when it is executed.
+func (s *ExecuteQueryTemplateStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: false, Name: "", Privilege: ReadPrivilege}}, nil
+}
+
+// quoteQueryTemplateName quotes each segment of a query template name.
+func quoteQueryTemplateName(name string) string {
+	segments := strings.Split(name, ".")
+	for i, segment := range segments {
+		segments[i] = QuoteIdent(segment)
+	}
+	return strings.Join(segments, ".")
+}
+
 // ShowMeasurementCardinalityStatement represents a command for listing measurement cardinality.
 type ShowMeasurementCardinalityStatement struct {
 	Exact         bool // If false then cardinality estimation will be used.
@@ -2560,6 +3140,47 @@ func (s *ShowMeasurementCardinalityStatement) DefaultDatabase() string {
 	return s.Database
 }
 
+// ShowMeasurementStatsStatement represents a command for listing the
+// statistics of the measurements stored on disk.
+type ShowMeasurementStatsStatement struct {
+	// Database to query. If blank, use the default database.
+	Database string
+
+	// Measurement name or regex.
+	Source Source
+}
+
+// String returns a string representation of the statement.
+func (s *ShowMeasurementStatsStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("SHOW MEASUREMENT STATS")
+
+	if s.Database != "" {
+		_, _ = buf.WriteString(" ON ")
+		_, _ = buf.WriteString(QuoteIdent(s.Database))
+	}
+	if s.Source != nil {
+		_, _ = buf.WriteString(" WITH MEASUREMENT ")
+		if m, ok := s.Source.(*Measurement); ok && m.Regex != nil {
+			_, _ = buf.WriteString("=~ ")
+		} else {
+			_, _ = buf.WriteString("= ")
+		}
+		_, _ = buf.WriteString(s.Source.String())
+	}
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a ShowMeasurementStatsStatement.
+func (s *ShowMeasurementStatsStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: false, Name: s.Database, Privilege: ReadPrivilege}}, nil
+}
+
+// DefaultDatabase returns the default database from the statement.
+func (s *ShowMeasurementStatsStatement) DefaultDatabase() string {
+	return s.Database
+}
+
 // ShowMeasurementsStatement represents a command for listing measurements.
 type ShowMeasurementsStatement struct {
 	// Database to query. If blank, use the default database.
@@ -2661,6 +3282,19 @@ func (s *ShowQueriesStatement) RequiredPrivileges() (ExecutionPrivileges, error)
 	return ExecutionPrivileges{{Admin: false, Name: "", Privilege: ReadPrivilege}}, nil
 }
 
+// ShowSessionsStatement represents a command for listing login sessions.
+type ShowSessionsStatement struct{}
+
+// String returns a string representation of the show sessions statement.
+func (s *ShowSessionsStatement) String() string {
+	return "SHOW SESSIONS"
+}
+
+// RequiredPrivileges returns the privilege required to execute a ShowSessionsStatement.
+func (s *ShowSessionsStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
 // ShowRetentionPoliciesStatement represents a command for listing retention policies.
 type ShowRetentionPoliciesStatement struct {
 	// Name of the database to list policies for.
@@ -2731,6 +3365,58 @@ func (s *ShowShardsStatement) RequiredPrivileges() (ExecutionPrivileges, error)
 	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
 }
 
+// ShowShardStatement represents a command for displaying the data files of a
+// shard stored on the node.
+type ShowShardStatement struct {
+	ID uint64
+}
+
+// String returns a string representation.
+func (s *ShowShardStatement) String() string {
+	return "SHOW SHARD " + strconv.FormatUint(s.ID, 10)
+}
+
+// RequiredPrivileges returns the privileges required to execute the statement.
+func (s *ShowShardStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
+// ShowShardJobsStatement represents a command for displaying the shard jobs
+// started by COPY SHARD, DROP SHARD and REINDEX SHARD on the node.
+type ShowShardJobsStatement struct{}
+
+// String returns a string representation.
+func (s *ShowShardJobsStatement) String() string { return "SHOW SHARD JOBS" }
+
+// RequiredPrivileges returns the privileges required to execute the statement.
+func (s *ShowShardJobsStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
+// TruncateShardsStatement represents a command for ending the current shard
+// groups of a database now, so that new writes go to new shard groups.
+type TruncateShardsStatement struct {
+	// Database whose shard groups are ended.
+	Database string
+
+	// Retention policy whose shard groups are ended. If blank, the shard
+	// groups of every retention policy of the database are ended.
+	RetentionPolicy string
+}
+
+// String returns a string representation.
+func (s *TruncateShardsStatement) String() string {
+	if s.RetentionPolicy == "" {
+		return "TRUNCATE SHARDS NOW ON " + QuoteIdent(s.Database)
+	}
+	return "TRUNCATE SHARDS NOW ON " + QuoteIdent(s.Database, s.RetentionPolicy)
+}
+
+// RequiredPrivileges returns the privileges required to execute the statement.
+func (s *TruncateShardsStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
 // ShowDiagnosticsStatement represents a command for show node diagnostics.
 type ShowDiagnosticsStatement struct {
 	// Module
@@ -2753,6 +3439,30 @@ func (s *ShowDiagnosticsStatement) RequiredPrivileges() (ExecutionPrivileges, er
 	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
 }
 
+// ShowDiskUsageStatement represents a command for displaying the disk usage
+// of the shards stored on the data nodes and its growth.
+type ShowDiskUsageStatement struct {
+	// Database to report. If blank, report every database.
+	Database string
+}
+
+// String returns a string representation of the ShowDiskUsageStatement.
+func (s *ShowDiskUsageStatement) String() string {
+	if s.Database == "" {
+		return "SHOW DISK USAGE"
+	}
+	return "SHOW DISK USAGE ON " + QuoteIdent(s.Database)
+}
+
+// RequiredPrivileges returns the privilege required to execute a
+// ShowDiskUsageStatement: reading the database, or admin for every database.
+func (s *ShowDiskUsageStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	if s.Database == "" {
+		return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+	}
+	return ExecutionPrivileges{{Admin: false, Name: s.Database, Privilege: ReadPrivilege}}, nil
+}
+
 // CreateSubscriptionStatement represents a command to add a subscription to the incoming data stream.
 type CreateSubscriptionStatement struct {
 	Name            string
@@ -2760,6 +3470,19 @@ type CreateSubscriptionStatement struct {
 	RetentionPolicy string
 	Destinations    []string
 	Mode            string
+
+	// Measurements, if set, restricts the points sent to the measurements.
+	Measurements []string
+
+	// Condition, if set, restricts the points sent to those whose tags match.
+	Condition Expr
+
+	// StripTags are the tag keys removed from the points sent.
+	StripTags []string
+
+	// ResampleEvery, if set, limits the points sent to one point per series
+	// every interval.
+	ResampleEvery time.Duration
 }
 
 // String returns a string representation of the CreateSubscriptionStatement.
@@ -2780,6 +3503,32 @@ func (s *CreateSubscriptionStatement) String() string {
 		}
 		_, _ = buf.WriteString(QuoteString(dest))
 	}
+	if len(s.Measurements) > 0 {
+		_, _ = buf.WriteString(" FROM ")
+		for i, name := range s.Measurements {
+			if i != 0 {
+				_, _ = buf.WriteString(", ")
+			}
+			_, _ = buf.WriteString(QuoteIdent(name))
+		}
+	}
+	if s.Condition != nil {
+		_, _ = buf.WriteString(" WHERE ")
+		_, _ = buf.WriteString(s.Condition.String())
+	}
+	if len(s.StripTags) > 0 {
+		_, _ = buf.WriteString(" STRIP TAG KEYS ")
+		for i, key := range s.StripTags {
+			if i != 0 {
+				_, _ = buf.WriteString(", ")
+			}
+			_, _ = buf.WriteString(QuoteIdent(key))
+		}
+	}
+	if s.ResampleEvery > 0 {
+		_, _ = buf.WriteString(" RESAMPLE EVERY ")
+		_, _ = buf.WriteString(FormatDuration(s.ResampleEvery))
+	}
 
 	return buf.String()
 }
@@ -3107,6 +3856,19 @@ func (s *ShowTagValuesCardinalityStatement) DefaultDatabase() string {
 	return s.Database
 }
 
+// ShowRolesStatement represents a command for listing roles.
+type ShowRolesStatement struct{}
+
+// String returns a string representation of the ShowRolesStatement.
+func (s *ShowRolesStatement) String() string {
+	return "SHOW ROLES"
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a ShowRolesStatement
+func (s *ShowRolesStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
 // ShowUsersStatement represents a command for listing users.
 type ShowUsersStatement struct{}
 
@@ -3258,7 +4020,7 @@ func (a Fields) Names() []string {
 			names = append(names, expr.Val)
 		case *BinaryExpr:
 			names = append(names, walkNames(expr)...)
-		case *ParenExpr:
+		case *ParenExpr, *CaseExpr:
 			names = append(names, walkNames(expr)...)
 		}
 	}
@@ -3297,6 +4059,8 @@ func (f *Field) Name() string {
 	case *ParenExpr:
 		f := Field{Expr: expr.Expr}
 		return f.Name()
+	case *CaseExpr:
+		return "case"
 	case *VarRef:
 		return expr.Val
 	}
@@ -3676,6 +4440,23 @@ type DurationLiteral struct {
 // String returns a string representation of the literal.
 func (l *DurationLiteral) String() string { return FormatDuration(l.Val) }
 
+// MaxMonthDuration is the length of the longest calendar month.
+const MaxMonthDuration = 31 * 24 * time.Hour
+
+// CalendarLiteral represents a duration of calendar months, such as 1mo, 1q
+// or 1y. Its length in time depends on the month it starts in.
+type CalendarLiteral struct {
+	Months int
+}
+
+// String returns a string representation of the literal.
+func (l *CalendarLiteral) String() string { return FormatCalendarDuration(l.Months) }
+
+// MaxDuration returns the longest time the literal can span.
+func (l *CalendarLiteral) MaxDuration() time.Duration {
+	return time.Duration(l.Months) * MaxMonthDuration
+}
+
 // NilLiteral represents a nil literal.
 // This is not available to the query language itself. It's only used internally.
 type NilLiteral struct{}
@@ -3683,6 +4464,15 @@ type NilLiteral struct{}
 // String returns a string representation of the literal.
 func (l *NilLiteral) String() string { return `nil` }
 
+// BoundParameter represents a bound parameter of a query template. It is
+// replaced by the value of the parameter when the template is executed.
+type BoundParameter struct {
+	Name string
+}
+
+// String returns a string representation of the bound parameter.
+func (p *BoundParameter) String() string { return "$" + QuoteIdent(p.Name) }
+
 // BinaryExpr represents an operation between two expressions.
 type BinaryExpr struct {
 	Op  Token
@@ -3726,6 +4516,37 @@ type ParenExpr struct {
 // String returns a string representation of the parenthesized expression.
 func (e *ParenExpr) String() string { return fmt.Sprintf("(%s)", e.Expr.String()) }
 
+// CaseExpr represents a conditional expression evaluating to the result of
+// the first condition that is true, or to the else expression if none is.
+type CaseExpr struct {
+	WhenClauses []*WhenClause
+	Else        Expr
+}
+
+// WhenClause represents a condition of a case expression and its result.
+type WhenClause struct {
+	Condition Expr
+	Result    Expr
+}
+
+// String returns a string representation of the case expression.
+func (e *CaseExpr) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("CASE")
+	for _, w := range e.WhenClauses {
+		_, _ = buf.WriteString(" WHEN ")
+		_, _ = buf.WriteString(w.Condition.String())
+		_, _ = buf.WriteString(" THEN ")
+		_, _ = buf.WriteString(w.Result.String())
+	}
+	if e.Else != nil {
+		_, _ = buf.WriteString(" ELSE ")
+		_, _ = buf.WriteString(e.Else.String())
+	}
+	_, _ = buf.WriteString(" END")
+	return buf.String()
+}
+
 // RegexLiteral represents a regular expression.
 type RegexLiteral struct {
 	Val *regexp.Regexp
@@ -3780,6 +4601,8 @@ func CloneExpr(expr Expr) Expr {
 		return &BinaryExpr{Op: expr.Op, LHS: CloneExpr(expr.LHS), RHS: CloneExpr(expr.RHS)}
 	case *BooleanLiteral:
 		return &BooleanLiteral{Val: expr.Val}
+	case *BoundParameter:
+		return &BoundParameter{Name: expr.Name}
 	case *Call:
 		args := make([]Expr, len(expr.Args))
 		for i, arg := range expr.Args {
@@ -3788,6 +4611,8 @@ func CloneExpr(expr Expr) Expr {
 		return &Call{Name: expr.Name, Args: args}
 	case *Distinct:
 		return &Distinct{Val: expr.Val}
+	case *CalendarLiteral:
+		return &CalendarLiteral{Months: expr.Months}
 	case *DurationLiteral:
 		return &DurationLiteral{Val: expr.Val}
 	case *IntegerLiteral:
@@ -3798,6 +4623,15 @@ func CloneExpr(expr Expr) Expr {
 		return &NumberLiteral{Val: expr.Val}
 	case *ParenExpr:
 		return &ParenExpr{Expr: CloneExpr(expr.Expr)}
+	case *CaseExpr:
+		other := &CaseExpr{
+			WhenClauses: make([]*WhenClause, len(expr.WhenClauses)),
+			Else:        CloneExpr(expr.Else),
+		}
+		for i, w := range expr.WhenClauses {
+			other.WhenClauses[i] = &WhenClause{Condition: CloneExpr(w.Condition), Result: CloneExpr(w.Result)}
+		}
+		return other
 	case *RegexLiteral:
 		return &RegexLiteral{Val: expr.Val}
 	case *StringLiteral:
@@ -3857,9 +4691,22 @@ func Walk(v Visitor, node Node) {
 			Walk(v, expr)
 		}
 
+	case *CaseExpr:
+		for _, w := range n.WhenClauses {
+			Walk(v, w.Condition)
+			Walk(v, w.Result)
+		}
+		Walk(v, n.Else)
+
 	case *CreateContinuousQueryStatement:
 		Walk(v, n.Source)
 
+	case *CreateMaterializedViewStatement:
+		Walk(v, n.Source)
+
+	case *CreateQueryTemplateStatement:
+		Walk(v, n.Source)
+
 	case *Dimension:
 		Walk(v, n.Expr)
 
@@ -4035,6 +4882,15 @@ func Rewrite(r Rewriter, node Node) Node {
 		for i, expr := range n.Args {
 			n.Args[i] = Rewrite(r, expr).(Expr)
 		}
+
+	case *CaseExpr:
+		for _, w := range n.WhenClauses {
+			w.Condition = Rewrite(r, w.Condition).(Expr)
+			w.Result = Rewrite(r, w.Result).(Expr)
+		}
+		if n.Else != nil {
+			n.Else = Rewrite(r, n.Else).(Expr)
+		}
 	}
 
 	return r.Rewrite(node)
@@ -4074,6 +4930,15 @@ func RewriteExpr(expr Expr, fn func(Expr) Expr) Expr {
 		for i, expr := range e.Args {
 			e.Args[i] = RewriteExpr(expr, fn)
 		}
+
+	case *CaseExpr:
+		for _, w := range e.WhenClauses {
+			w.Condition = RewriteExpr(w.Condition, fn)
+			w.Result = RewriteExpr(w.Result, fn)
+		}
+		if e.Else != nil {
+			e.Else = RewriteExpr(e.Else, fn)
+		}
 	}
 
 	return fn(expr)
@@ -4122,6 +4987,16 @@ func (v *ValuerEval) Eval(expr Expr) interface{} {
 		return expr.Val
 	case *ParenExpr:
 		return v.Eval(expr.Expr)
+	case *CaseExpr:
+		for _, w := range expr.WhenClauses {
+			if ok, _ := v.Eval(w.Condition).(bool); ok {
+				return v.Eval(w.Result)
+			}
+		}
+		if expr.Else != nil {
+			return v.Eval(expr.Else)
+		}
+		return nil
 	case *RegexLiteral:
 		return expr.Val
 	case *StringLiteral:
@@ -4604,6 +5479,8 @@ func (v *TypeValuerEval) EvalType(expr Expr) (DataType, error) {
 		return v.evalBinaryExprType(expr)
 	case *ParenExpr:
 		return v.EvalType(expr.Expr)
+	case *CaseExpr:
+		return v.evalCaseExprType(expr)
 	case *NumberLiteral:
 		return Float, nil
 	case *IntegerLiteral:
@@ -4680,6 +5557,53 @@ func (v *TypeValuerEval) evalCallExprType(expr *Call) (DataType, error) {
 	return typmap.CallType(expr.Name, args)
 }
 
+func (v *TypeValuerEval) evalCaseExprType(expr *CaseExpr) (DataType, error) {
+	results := make([]Expr, 0, len(expr.WhenClauses)+1)
+	for _, w := range expr.WhenClauses {
+		if typ, err := v.EvalType(w.Condition); err != nil {
+			return Unknown, err
+		} else if typ != Boolean && typ != Unknown {
+			return Unknown, &TypeError{
+				Expr:    expr,
+				Message: fmt.Sprintf("condition %s must be a boolean, got %s", w.Condition, typ),
+			}
+		}
+		results = append(results, w.Result)
+	}
+	if expr.Else != nil {
+		results = append(results, expr.Else)
+	}
+
+	// The results must have the same type, except for numbers of different
+	// types which are floats.
+	var typ DataType
+	for _, result := range results {
+		other, err := v.EvalType(result)
+		if err != nil {
+			return Unknown, err
+		}
+
+		switch {
+		case other == Unknown || other == typ:
+		case typ == Unknown:
+			typ = other
+		case isNumericType(typ) && isNumericType(other):
+			typ = Float
+		default:
+			return Unknown, &TypeError{
+				Expr:    expr,
+				Message: fmt.Sprintf("cannot mix %s and %s results", typ, other),
+			}
+		}
+	}
+	return typ, nil
+}
+
+// isNumericType returns true if the values of the type are numbers.
+func isNumericType(typ DataType) bool {
+	return typ == Float || typ == Integer || typ == Unsigned
+}
+
 func (v *TypeValuerEval) evalBinaryExprType(expr *BinaryExpr) (DataType, error) {
 	// Find the data type for both sides of the expression.
 	lhs, err := v.EvalType(expr.LHS)
@@ -4840,6 +5764,8 @@ func reduce(expr Expr, valuer Valuer) Expr {
 		return reduceCall(expr, valuer)
 	case *ParenExpr:
 		return reduceParenExpr(expr, valuer)
+	case *CaseExpr:
+		return reduceCaseExpr(expr, valuer)
 	case *VarRef:
 		return reduceVarRef(expr, valuer)
 	case *NilLiteral:
@@ -5325,6 +6251,13 @@ func reduceBinaryExprStringLHS(op Token, lhs *StringLiteral, rhs Expr, loc *time
 
 func reduceBinaryExprTimeLHS(op Token, lhs *TimeLiteral, rhs Expr, loc *time.Location) Expr {
 	switch rhs := rhs.(type) {
+	case *CalendarLiteral:
+		switch op {
+		case ADD:
+			return &TimeLiteral{Val: lhs.Val.In(loc).AddDate(0, rhs.Months, 0)}
+		case SUB:
+			return &TimeLiteral{Val: lhs.Val.In(loc).AddDate(0, -rhs.Months, 0)}
+		}
 	case *DurationLiteral:
 		switch op {
 		case ADD:
@@ -5411,6 +6344,40 @@ func reduceParenExpr(expr *ParenExpr, valuer Valuer) Expr {
 	return subexpr
 }
 
+func reduceCaseExpr(expr *CaseExpr, valuer Valuer) Expr {
+	other := &CaseExpr{WhenClauses: make([]*WhenClause, 0, len(expr.WhenClauses))}
+	for _, w := range expr.WhenClauses {
+		cond := reduce(w.Condition, valuer)
+		if lit, ok := cond.(*BooleanLiteral); ok {
+			if !lit.Val {
+				// Drop the clauses that never match.
+				continue
+			} else if len(other.WhenClauses) == 0 {
+				// The first clause matches, so its result is the expression.
+				return reduce(w.Result, valuer)
+			}
+			// Later clauses are never evaluated.
+			other.Else = reduce(w.Result, valuer)
+			return other
+		}
+		other.WhenClauses = append(other.WhenClauses, &WhenClause{
+			Condition: cond,
+			Result:    reduce(w.Result, valuer),
+		})
+	}
+
+	if expr.Else != nil {
+		other.Else = reduce(expr.Else, valuer)
+	}
+	if len(other.WhenClauses) == 0 {
+		if other.Else == nil {
+			return &NilLiteral{}
+		}
+		return other.Else
+	}
+	return other
+}
+
 func reduceVarRef(expr *VarRef, valuer Valuer) Expr {
 	// Ignore if there is no valuer.
 	if valuer == nil {
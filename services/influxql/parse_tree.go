@@ -2,6 +2,7 @@ package influxql
 
 import (
 	"fmt"
+	"strings"
 )
 
 var Language = &ParseTree{}
@@ -10,6 +11,13 @@ type ParseTree struct {
 	Handlers map[Token]func(*Parser) (Statement, error)
 	Tokens   map[Token]*ParseTree
 	Keys     []string
+
+	// Words holds the handlers of identifiers that are not keywords, by
+	// their upper case, and WordTrees the parse trees following them. They
+	// are not listed in Keys, so adding one does not change the error of an
+	// unexpected identifier.
+	Words     map[string]func(*Parser) (Statement, error)
+	WordTrees map[string]*ParseTree
 }
 
 // With passes the current parse tree to a function to allow nested functions.
@@ -45,6 +53,30 @@ func (t *ParseTree) Group(tokens ...Token) *ParseTree {
 	return t
 }
 
+// GroupWord groups together a set of related handlers with a common prefix of
+// identifier words, which remain valid wherever an identifier is.
+func (t *ParseTree) GroupWord(words ...string) *ParseTree {
+	for _, word := range words {
+		word = strings.ToUpper(word)
+		if subtree := t.WordTrees[word]; subtree != nil {
+			t = subtree
+			continue
+		}
+
+		if _, conflict := t.Words[word]; conflict {
+			panic(fmt.Sprintf("conflict for word %s", word))
+		}
+
+		newT := &ParseTree{}
+		if t.WordTrees == nil {
+			t.WordTrees = make(map[string]*ParseTree)
+		}
+		t.WordTrees[word] = newT
+		t = newT
+	}
+	return t
+}
+
 // Handle registers a handler to be invoked when seeing the given token.
 func (t *ParseTree) Handle(tok Token, fn func(*Parser) (Statement, error)) {
 	// Verify that there is no conflict for this token in this parse tree.
@@ -63,6 +95,24 @@ func (t *ParseTree) Handle(tok Token, fn func(*Parser) (Statement, error)) {
 	t.Keys = append(t.Keys, tok.String())
 }
 
+// HandleWord registers a handler to be invoked when seeing the identifier
+// word, which remains valid wherever an identifier is.
+func (t *ParseTree) HandleWord(word string, fn func(*Parser) (Statement, error)) {
+	word = strings.ToUpper(word)
+	if _, conflict := t.WordTrees[word]; conflict {
+		panic(fmt.Sprintf("conflict for word %s", word))
+	}
+
+	if _, conflict := t.Words[word]; conflict {
+		panic(fmt.Sprintf("conflict for word %s", word))
+	}
+
+	if t.Words == nil {
+		t.Words = make(map[string]func(*Parser) (Statement, error))
+	}
+	t.Words[word] = fn
+}
+
 // Parse parses a statement using the language defined in the parse tree.
 func (t *ParseTree) Parse(p *Parser) (Statement, error) {
 	for {
@@ -76,6 +126,17 @@ func (t *ParseTree) Parse(p *Parser) (Statement, error) {
 			return stmt(p)
 		}
 
+		if tok == IDENT {
+			if subtree := t.WordTrees[strings.ToUpper(lit)]; subtree != nil {
+				t = subtree
+				continue
+			}
+
+			if stmt := t.Words[strings.ToUpper(lit)]; stmt != nil {
+				return stmt(p)
+			}
+		}
+
 		// There were no registered handlers. Return the valid tokens in the order they were added.
 		return nil, newParseError(tokstr(tok, lit), t.Keys, pos)
 	}
@@ -96,6 +157,20 @@ func (t *ParseTree) Clone() *ParseTree {
 			newT.Tokens[tok] = subtree.Clone()
 		}
 	}
+
+	if t.Words != nil {
+		newT.Words = make(map[string]func(*Parser) (Statement, error), len(t.Words))
+		for word, handler := range t.Words {
+			newT.Words[word] = handler
+		}
+	}
+
+	if t.WordTrees != nil {
+		newT.WordTrees = make(map[string]*ParseTree, len(t.WordTrees))
+		for word, subtree := range t.WordTrees {
+			newT.WordTrees[word] = subtree.Clone()
+		}
+	}
 	return newT
 }
 
@@ -119,6 +194,9 @@ func init() {
 		show.Handle(DIAGNOSTICS, func(p *Parser) (Statement, error) {
 			return p.parseShowDiagnosticsStatement()
 		})
+		show.HandleWord("DISK", func(p *Parser) (Statement, error) {
+			return p.parseShowDiskUsageStatement()
+		})
 		show.Group(FIELD).With(func(field *ParseTree) {
 			field.Handle(KEY, func(p *Parser) (Statement, error) {
 				return p.parseShowFieldKeyCardinalityStatement()
@@ -136,21 +214,43 @@ func init() {
 		show.Group(MEASUREMENT).Handle(CARDINALITY, func(p *Parser) (Statement, error) {
 			return p.parseShowMeasurementCardinalityStatement(false)
 		})
+		show.Group(MEASUREMENT).Handle(STATS, func(p *Parser) (Statement, error) {
+			return p.parseShowMeasurementStatsStatement()
+		})
+		show.GroupWord("MATERIALIZED").HandleWord("VIEWS", func(p *Parser) (Statement, error) {
+			return p.parseShowMaterializedViewsStatement()
+		})
 		show.Handle(MEASUREMENTS, func(p *Parser) (Statement, error) {
 			return p.parseShowMeasurementsStatement()
 		})
 		show.Handle(QUERIES, func(p *Parser) (Statement, error) {
 			return p.parseShowQueriesStatement()
 		})
+		show.Group(QUERY).HandleWord("TEMPLATES", func(p *Parser) (Statement, error) {
+			return p.parseShowQueryTemplatesStatement()
+		})
+		show.HandleWord("ROLES", func(p *Parser) (Statement, error) {
+			return p.parseShowRolesStatement()
+		})
 		show.Group(RETENTION).Handle(POLICIES, func(p *Parser) (Statement, error) {
 			return p.parseShowRetentionPoliciesStatement()
 		})
 		show.Handle(SERIES, func(p *Parser) (Statement, error) {
 			return p.parseShowSeriesStatement()
 		})
+		show.Handle(SESSIONS, func(p *Parser) (Statement, error) {
+			return p.parseShowSessionsStatement()
+		})
 		show.Group(SHARD).Handle(GROUPS, func(p *Parser) (Statement, error) {
 			return p.parseShowShardGroupsStatement()
 		})
+		show.Group(SHARD).HandleWord("JOBS", func(p *Parser) (Statement, error) {
+			return p.parseShowShardJobsStatement()
+		})
+		show.Group(SHARD).Handle(INTEGER, func(p *Parser) (Statement, error) {
+			p.Unscan()
+			return p.parseShowShardStatement()
+		})
 		show.Handle(SHARDS, func(p *Parser) (Statement, error) {
 			return p.parseShowShardsStatement()
 		})
@@ -182,6 +282,15 @@ func init() {
 		create.Handle(DATABASE, func(p *Parser) (Statement, error) {
 			return p.parseCreateDatabaseStatement()
 		})
+		create.GroupWord("MATERIALIZED").HandleWord("VIEW", func(p *Parser) (Statement, error) {
+			return p.parseCreateMaterializedViewStatement()
+		})
+		create.Group(QUERY).HandleWord("TEMPLATE", func(p *Parser) (Statement, error) {
+			return p.parseCreateQueryTemplateStatement()
+		})
+		create.HandleWord("ROLE", func(p *Parser) (Statement, error) {
+			return p.parseCreateRoleStatement()
+		})
 		create.Handle(USER, func(p *Parser) (Statement, error) {
 			return p.parseCreateUserStatement()
 		})
@@ -199,12 +308,21 @@ func init() {
 		drop.Handle(DATABASE, func(p *Parser) (Statement, error) {
 			return p.parseDropDatabaseStatement()
 		})
+		drop.GroupWord("MATERIALIZED").HandleWord("VIEW", func(p *Parser) (Statement, error) {
+			return p.parseDropMaterializedViewStatement()
+		})
 		drop.Handle(MEASUREMENT, func(p *Parser) (Statement, error) {
 			return p.parseDropMeasurementStatement()
 		})
+		drop.Group(QUERY).HandleWord("TEMPLATE", func(p *Parser) (Statement, error) {
+			return p.parseDropQueryTemplateStatement()
+		})
 		drop.Group(RETENTION).Handle(POLICY, func(p *Parser) (Statement, error) {
 			return p.parseDropRetentionPolicyStatement()
 		})
+		drop.HandleWord("ROLE", func(p *Parser) (Statement, error) {
+			return p.parseDropRoleStatement()
+		})
 		drop.Handle(SERIES, func(p *Parser) (Statement, error) {
 			return p.parseDropSeriesStatement()
 		})
@@ -218,6 +336,15 @@ func init() {
 			return p.parseDropUserStatement()
 		})
 	})
+	Language.GroupWord("COPY").Handle(SHARD, func(p *Parser) (Statement, error) {
+		return p.parseCopyShardStatement()
+	})
+	Language.Group(REINDEX).Handle(SHARD, func(p *Parser) (Statement, error) {
+		return p.parseReindexShardStatement()
+	})
+	Language.GroupWord("EXECUTE").Group(QUERY).HandleWord("TEMPLATE", func(p *Parser) (Statement, error) {
+		return p.parseExecuteQueryTemplateStatement()
+	})
 	Language.Handle(EXPLAIN, func(p *Parser) (Statement, error) {
 		return p.parseExplainStatement()
 	})
@@ -236,4 +363,10 @@ func init() {
 	Language.Group(KILL).Handle(QUERY, func(p *Parser) (Statement, error) {
 		return p.parseKillQueryStatement()
 	})
+	Language.Group(KILL).Handle(SESSION, func(p *Parser) (Statement, error) {
+		return p.parseKillSessionStatement()
+	})
+	Language.GroupWord("TRUNCATE").Handle(SHARDS, func(p *Parser) (Statement, error) {
+		return p.parseTruncateShardsStatement()
+	})
 }
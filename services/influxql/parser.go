@@ -24,6 +24,10 @@ const (
 type Parser struct {
 	s      *bufScanner
 	params map[string]interface{}
+
+	// deferParams leaves bound parameters unresolved while parsing the
+	// source of a query template.
+	deferParams bool
 }
 
 // NewParser returns a new instance of Parser.
@@ -130,11 +134,17 @@ func (p *Parser) parseKillQueryStatement() (*KillQueryStatement, error) {
 		return nil, err
 	}
 
+	// The query runs on the data node named by its host or ID.
 	var host string
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == ON {
-		host, err = p.ParseIdent()
-		if err != nil {
-			return nil, err
+		if tok, _, lit := p.ScanIgnoreWhitespace(); tok == INTEGER {
+			host = lit
+		} else {
+			p.Unscan()
+			host, err = p.ParseIdent()
+			if err != nil {
+				return nil, err
+			}
 		}
 	} else {
 		p.Unscan()
@@ -142,6 +152,16 @@ func (p *Parser) parseKillQueryStatement() (*KillQueryStatement, error) {
 	return &KillQueryStatement{QueryID: qid, Host: host}, nil
 }
 
+// parseKillSessionStatement parses a string and returns a KillSessionStatement.
+// This function assumes the "KILL SESSION" tokens have already been consumed.
+func (p *Parser) parseKillSessionStatement() (*KillSessionStatement, error) {
+	id, err := p.parseString()
+	if err != nil {
+		return nil, err
+	}
+	return &KillSessionStatement{ID: id}, nil
+}
+
 // parseCreateSubscriptionStatement parses a string and returns a CreateSubscriptionStatement.
 // This function assumes the "CREATE SUBSCRIPTION" tokens have already been consumed.
 func (p *Parser) parseCreateSubscriptionStatement() (*CreateSubscriptionStatement, error) {
@@ -194,6 +214,46 @@ func (p *Parser) parseCreateSubscriptionStatement() (*CreateSubscriptionStatemen
 	}
 	stmt.Destinations = destinations
 
+	// Parse the optional measurements the points sent are restricted to.
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == FROM {
+		if stmt.Measurements, err = p.ParseIdentList(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.Unscan()
+	}
+
+	// Parse the optional condition on the tags of the points sent.
+	if stmt.Condition, err = p.parseCondition(); err != nil {
+		return nil, err
+	}
+
+	// Parse the optional tag keys removed from the points sent.
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == STRIP {
+		if err := p.parseTokens([]Token{TAG, KEYS}); err != nil {
+			return nil, err
+		}
+		if stmt.StripTags, err = p.ParseIdentList(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.Unscan()
+	}
+
+	// Parse the optional interval the points sent are resampled to.
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == RESAMPLE {
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != EVERY {
+			return nil, newParseError(tokstr(tok, lit), []string{"EVERY"}, pos)
+		}
+		if stmt.ResampleEvery, err = p.ParseDuration(); err != nil {
+			return nil, err
+		} else if stmt.ResampleEvery <= 0 {
+			return nil, fmt.Errorf("RESAMPLE EVERY must be positive: %s", FormatDuration(stmt.ResampleEvery))
+		}
+	} else {
+		p.Unscan()
+	}
+
 	return stmt, nil
 }
 
@@ -270,6 +330,29 @@ func (p *Parser) parseCreateRetentionPolicyStatement() (*CreateRetentionPolicySt
 		p.Unscan()
 	}
 
+	// Parse optional FUTURE LIMIT. FUTURE and PAST are not keywords, so they
+	// remain valid identifiers.
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok == IDENT && strings.EqualFold(lit, "FUTURE") {
+		d, err := p.parseWriteLimit()
+		if err != nil {
+			return nil, err
+		}
+		stmt.FutureWriteLimit = d
+	} else {
+		p.Unscan()
+	}
+
+	// Parse optional PAST LIMIT.
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok == IDENT && strings.EqualFold(lit, "PAST") {
+		d, err := p.parseWriteLimit()
+		if err != nil {
+			return nil, err
+		}
+		stmt.PastWriteLimit = d
+	} else {
+		p.Unscan()
+	}
+
 	// Parse optional DEFAULT token.
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == DEFAULT {
 		stmt.Default = true
@@ -308,31 +391,38 @@ func (p *Parser) parseAlterRetentionPolicyStatement() (*AlterRetentionPolicyStat
 	stmt.Database = ident
 
 	// Loop through option tokens (DURATION, REPLICATION, SHARD DURATION, DEFAULT, etc.).
-	found := make(map[Token]struct{})
+	found := make(map[string]struct{})
 Loop:
 	for {
 		tok, pos, lit := p.ScanIgnoreWhitespace()
-		if _, ok := found[tok]; ok {
+
+		// FUTURE and PAST are identifiers rather than keywords, so options
+		// are told apart by their upper case name.
+		option := tok.String()
+		if tok == IDENT {
+			option = strings.ToUpper(lit)
+		}
+		if _, ok := found[option]; ok {
 			return nil, &ParseError{
-				Message: fmt.Sprintf("found duplicate %s option", tok),
+				Message: fmt.Sprintf("found duplicate %s option", option),
 				Pos:     pos,
 			}
 		}
 
-		switch tok {
-		case DURATION:
+		switch {
+		case tok == DURATION:
 			d, err := p.ParseDuration()
 			if err != nil {
 				return nil, err
 			}
 			stmt.Duration = &d
-		case REPLICATION:
+		case tok == REPLICATION:
 			n, err := p.ParseInt(1, math.MaxInt32)
 			if err != nil {
 				return nil, err
 			}
 			stmt.Replication = &n
-		case SHARD:
+		case tok == SHARD:
 			tok, pos, lit := p.ScanIgnoreWhitespace()
 			if tok == DURATION {
 				// Check to see if they used the INF keyword
@@ -353,21 +443,58 @@ Loop:
 			} else {
 				return nil, newParseError(tokstr(tok, lit), []string{"DURATION"}, pos)
 			}
-		case DEFAULT:
+		case option == "FUTURE":
+			d, err := p.parseWriteLimit()
+			if err != nil {
+				return nil, err
+			}
+			stmt.FutureWriteLimit = &d
+		case option == "PAST":
+			d, err := p.parseWriteLimit()
+			if err != nil {
+				return nil, err
+			}
+			stmt.PastWriteLimit = &d
+		case tok == MEASUREMENT:
+			ident, err := p.ParseIdent()
+			if err != nil {
+				return nil, err
+			}
+			stmt.Measurement = ident
+		case tok == DEFAULT:
 			stmt.Default = true
 		default:
 			if len(found) == 0 {
-				return nil, newParseError(tokstr(tok, lit), []string{"DURATION", "REPLICATION", "SHARD", "DEFAULT"}, pos)
+				return nil, newParseError(tokstr(tok, lit), []string{"DURATION", "REPLICATION", "SHARD", "FUTURE", "PAST", "MEASUREMENT", "DEFAULT"}, pos)
 			}
 			p.Unscan()
 			break Loop
 		}
-		found[tok] = struct{}{}
+		found[option] = struct{}{}
+	}
+
+	// Only the shard duration can be overridden for a measurement.
+	if stmt.Measurement != "" {
+		if stmt.ShardGroupDuration == nil {
+			return nil, &ParseError{Message: "MEASUREMENT requires SHARD DURATION", Pos: pos}
+		} else if len(found) != 2 {
+			return nil, &ParseError{Message: "MEASUREMENT only supports SHARD DURATION", Pos: pos}
+		}
 	}
 
 	return stmt, nil
 }
 
+// parseWriteLimit parses the LIMIT of a FUTURE or PAST write limit and
+// returns its duration. A limit of 0 or INF removes the limit.
+// This function assumes the FUTURE or PAST token has already been consumed.
+func (p *Parser) parseWriteLimit() (time.Duration, error) {
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != LIMIT {
+		return 0, newParseError(tokstr(tok, lit), []string{"LIMIT"}, pos)
+	}
+	return p.ParseDuration()
+}
+
 // ParseInt parses a string representing a base 10 integer and returns the number.
 // It returns an error if the parsed number is outside the range [min, max].
 func (p *Parser) ParseInt(min, max int) (int, error) {
@@ -540,6 +667,12 @@ func (p *Parser) parseStringList() ([]string, error) {
 // parseRevokeStatement parses a string and returns a revoke statement.
 // This function assumes the REVOKE token has already been consumed.
 func (p *Parser) parseRevokeStatement() (Statement, error) {
+	// A role is revoked from a user by name.
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok == IDENT {
+		return p.parseRevokeRoleStatement(lit)
+	}
+	p.Unscan()
+
 	// Parse the privilege to be revoked.
 	priv, err := p.parsePrivilege()
 	if err != nil {
@@ -549,12 +682,7 @@ func (p *Parser) parseRevokeStatement() (Statement, error) {
 	// Check for ON or FROM clauses.
 	tok, pos, lit := p.ScanIgnoreWhitespace()
 	if tok == ON {
-		stmt, err := p.parseRevokeOnStatement()
-		if err != nil {
-			return nil, err
-		}
-		stmt.Privilege = priv
-		return stmt, nil
+		return p.parseRevokeOnStatement(priv)
 	} else if tok == FROM {
 		// Admin privilege is only revoked on ALL PRIVILEGES.
 		if priv != AllPrivileges {
@@ -570,17 +698,15 @@ func (p *Parser) parseRevokeStatement() (Statement, error) {
 	return nil, newParseError(tokstr(tok, lit), []string{"ON"}, pos)
 }
 
-// parseRevokeOnStatement parses a string and returns a revoke statement.
+// parseRevokeOnStatement parses a string and returns a revoke statement, or
+// a revoke role privilege statement if the privilege is revoked FROM ROLE.
 // This function assumes the [PRIVILEGE] ON tokens have already been consumed.
-func (p *Parser) parseRevokeOnStatement() (*RevokeStatement, error) {
-	stmt := &RevokeStatement{}
-
+func (p *Parser) parseRevokeOnStatement(priv Privilege) (Statement, error) {
 	// Parse the name of the database.
-	lit, err := p.ParseIdent()
+	on, err := p.ParseIdent()
 	if err != nil {
 		return nil, err
 	}
-	stmt.On = lit
 
 	// Parse FROM clause.
 	tok, pos, lit := p.ScanIgnoreWhitespace()
@@ -590,8 +716,28 @@ func (p *Parser) parseRevokeOnStatement() (*RevokeStatement, error) {
 		return nil, newParseError(tokstr(tok, lit), []string{"FROM"}, pos)
 	}
 
+	// Parse the name of the user, or of the role following ROLE.
+	name, role, err := p.parseUserOrRole()
+	if err != nil {
+		return nil, err
+	} else if role {
+		return &RevokeRolePrivilegeStatement{Privilege: priv, On: on, Role: name}, nil
+	}
+	return &RevokeStatement{Privilege: priv, On: on, User: name}, nil
+}
+
+// parseRevokeRoleStatement parses a string and returns a revoke role statement.
+// This function assumes the REVOKE <role> tokens have already been consumed.
+func (p *Parser) parseRevokeRoleStatement(role string) (*RevokeRoleStatement, error) {
+	stmt := &RevokeRoleStatement{Role: role}
+
+	// Check for required FROM token.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != FROM {
+		return nil, newParseError(tokstr(tok, lit), []string{"FROM"}, pos)
+	}
+
 	// Parse the name of the user.
-	lit, err = p.ParseIdent()
+	lit, err := p.ParseIdent()
 	if err != nil {
 		return nil, err
 	}
@@ -619,6 +765,12 @@ func (p *Parser) parseRevokeAdminStatement() (*RevokeAdminStatement, error) {
 // parseGrantStatement parses a string and returns a grant statement.
 // This function assumes the GRANT token has already been consumed.
 func (p *Parser) parseGrantStatement() (Statement, error) {
+	// A role is granted to a user by name.
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok == IDENT {
+		return p.parseGrantRoleStatement(lit)
+	}
+	p.Unscan()
+
 	// Parse the privilege to be granted.
 	priv, err := p.parsePrivilege()
 	if err != nil {
@@ -628,12 +780,7 @@ func (p *Parser) parseGrantStatement() (Statement, error) {
 	// Check for ON or TO clauses.
 	tok, pos, lit := p.ScanIgnoreWhitespace()
 	if tok == ON {
-		stmt, err := p.parseGrantOnStatement()
-		if err != nil {
-			return nil, err
-		}
-		stmt.Privilege = priv
-		return stmt, nil
+		return p.parseGrantOnStatement(priv)
 	} else if tok == TO {
 		// Admin privilege is only granted on ALL PRIVILEGES.
 		if priv != AllPrivileges {
@@ -649,17 +796,15 @@ func (p *Parser) parseGrantStatement() (Statement, error) {
 	return nil, newParseError(tokstr(tok, lit), []string{"ON"}, pos)
 }
 
-// parseGrantOnStatement parses a string and returns a grant statement.
+// parseGrantOnStatement parses a string and returns a grant statement, or a
+// grant role privilege statement if the privilege is granted TO ROLE.
 // This function assumes the [PRIVILEGE] ON tokens have already been consumed.
-func (p *Parser) parseGrantOnStatement() (*GrantStatement, error) {
-	stmt := &GrantStatement{}
-
+func (p *Parser) parseGrantOnStatement(priv Privilege) (Statement, error) {
 	// Parse the name of the database.
-	lit, err := p.ParseIdent()
+	on, err := p.ParseIdent()
 	if err != nil {
 		return nil, err
 	}
-	stmt.On = lit
 
 	// Parse TO clause.
 	tok, pos, lit := p.ScanIgnoreWhitespace()
@@ -669,8 +814,46 @@ func (p *Parser) parseGrantOnStatement() (*GrantStatement, error) {
 		return nil, newParseError(tokstr(tok, lit), []string{"TO"}, pos)
 	}
 
+	// Parse the name of the user, or of the role following ROLE.
+	name, role, err := p.parseUserOrRole()
+	if err != nil {
+		return nil, err
+	} else if role {
+		return &GrantRolePrivilegeStatement{Privilege: priv, On: on, Role: name}, nil
+	}
+	return &GrantStatement{Privilege: priv, On: on, User: name}, nil
+}
+
+// parseUserOrRole parses the name of a user, or of a role if the name follows
+// ROLE, and reports whether it is a role. ROLE is not a keyword, so it is the
+// name of a user when no name follows it.
+func (p *Parser) parseUserOrRole() (name string, role bool, err error) {
+	tok, _, lit := p.ScanIgnoreWhitespace()
+	if tok == IDENT && strings.EqualFold(lit, "ROLE") {
+		if tok, _, name := p.ScanIgnoreWhitespace(); tok == IDENT {
+			return name, true, nil
+		}
+		p.Unscan()
+		return lit, false, nil
+	}
+	p.Unscan()
+
+	name, err = p.ParseIdent()
+	return name, false, err
+}
+
+// parseGrantRoleStatement parses a string and returns a grant role statement.
+// This function assumes the GRANT <role> tokens have already been consumed.
+func (p *Parser) parseGrantRoleStatement(role string) (*GrantRoleStatement, error) {
+	stmt := &GrantRoleStatement{Role: role}
+
+	// Check for required TO token.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != TO {
+		return nil, newParseError(tokstr(tok, lit), []string{"TO"}, pos)
+	}
+
 	// Parse the name of the user.
-	lit, err = p.ParseIdent()
+	lit, err := p.ParseIdent()
 	if err != nil {
 		return nil, err
 	}
@@ -783,6 +966,22 @@ func (p *Parser) parseSelectStatement(tr targetRequirement) (*SelectStatement, e
 		return nil, err
 	}
 
+	// The timezone may also be the last argument of the time dimension:
+	// "GROUP BY time(<interval>, TZ(<timezone>))".
+	if loc, err := parseDimensionLocation(stmt.Dimensions); err != nil {
+		return nil, err
+	} else if loc != nil {
+		if stmt.Location != nil && stmt.Location.String() != loc.String() {
+			return nil, fmt.Errorf("conflicting time zones %s and %s", stmt.Location, loc)
+		}
+		stmt.Location = loc
+	}
+
+	// Parse series sampling: "sample_series(<fraction>)".
+	if stmt.SampleSeries, err = p.parseSampleSeries(); err != nil {
+		return nil, err
+	}
+
 	// Set if the query is a raw data query or one with an aggregate
 	stmt.IsRawQuery = true
 	WalkFunc(stmt.Fields, func(n Node) {
@@ -1053,6 +1252,46 @@ func (p *Parser) parseShowMeasurementCardinalityStatement(exact bool) (Statement
 	return stmt, nil
 }
 
+// parseShowMeasurementStatsStatement parses a string and returns a Statement.
+// This function assumes the "SHOW MEASUREMENT STATS" tokens have already been consumed.
+func (p *Parser) parseShowMeasurementStatsStatement() (*ShowMeasurementStatsStatement, error) {
+	stmt := &ShowMeasurementStatsStatement{}
+	var err error
+
+	// Parse optional ON clause.
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == ON {
+		if stmt.Database, err = p.ParseIdent(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.Unscan()
+	}
+
+	// Parse optional WITH clause.
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == WITH {
+		// Parse required MEASUREMENT token.
+		if err := p.parseTokens([]Token{MEASUREMENT}); err != nil {
+			return nil, err
+		}
+
+		// Parse required operator: = or =~.
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		switch tok {
+		case EQ, EQREGEX:
+			// Parse required source (measurement name or regex).
+			if stmt.Source, err = p.parseSource(false); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, newParseError(tokstr(tok, lit), []string{"=", "=~"}, pos)
+		}
+	} else {
+		p.Unscan()
+	}
+
+	return stmt, nil
+}
+
 // parseShowMeasurementsStatement parses a string and returns a Statement.
 // This function assumes the "SHOW MEASUREMENTS" tokens have already been consumed.
 func (p *Parser) parseShowMeasurementsStatement() (*ShowMeasurementsStatement, error) {
@@ -1122,6 +1361,12 @@ func (p *Parser) parseShowQueriesStatement() (*ShowQueriesStatement, error) {
 	return &ShowQueriesStatement{}, nil
 }
 
+// parseShowSessionsStatement parses a string and returns a ShowSessionsStatement.
+// This function assumes the "SHOW SESSIONS" tokens have been consumed.
+func (p *Parser) parseShowSessionsStatement() (*ShowSessionsStatement, error) {
+	return &ShowSessionsStatement{}, nil
+}
+
 // parseShowRetentionPoliciesStatement parses a string and returns a ShowRetentionPoliciesStatement.
 // This function assumes the "SHOW RETENTION POLICIES" tokens have been consumed.
 func (p *Parser) parseShowRetentionPoliciesStatement() (*ShowRetentionPoliciesStatement, error) {
@@ -1429,6 +1674,12 @@ func (p *Parser) parseShowUsersStatement() (*ShowUsersStatement, error) {
 	return &ShowUsersStatement{}, nil
 }
 
+// parseShowRolesStatement parses a string and returns a ShowRolesStatement.
+// This function assumes the "SHOW ROLES" tokens have already been consumed.
+func (p *Parser) parseShowRolesStatement() (*ShowRolesStatement, error) {
+	return &ShowRolesStatement{}, nil
+}
+
 // parseShowSubscriptionsStatement parses a string and returns a ShowSubscriptionsStatement
 // This function assumes the "SHOW SUBSCRIPTIONS" tokens have been consumed.
 func (p *Parser) parseShowSubscriptionsStatement() (*ShowSubscriptionsStatement, error) {
@@ -1614,6 +1865,39 @@ func (p *Parser) parseDropShardStatement() (*DropShardStatement, error) {
 	return stmt, nil
 }
 
+// parseCopyShardStatement parses a string and returns a
+// CopyShardStatement. This function assumes the "COPY SHARD" tokens
+// have already been consumed.
+func (p *Parser) parseCopyShardStatement() (*CopyShardStatement, error) {
+	var err error
+	stmt := &CopyShardStatement{}
+
+	// Parse the ID of the shard to be copied.
+	if stmt.ID, err = p.ParseUInt64(); err != nil {
+		return nil, err
+	}
+
+	// Parse the ID of the node to copy the shard to.
+	if err := p.parseTokens([]Token{TO}); err != nil {
+		return nil, err
+	}
+	if stmt.NodeID, err = p.ParseUInt64(); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// parseReindexShardStatement parses a string and returns a
+// ReindexShardStatement. This function assumes the "REINDEX SHARD" tokens
+// have already been consumed.
+func (p *Parser) parseReindexShardStatement() (*ReindexShardStatement, error) {
+	id, err := p.ParseUInt64()
+	if err != nil {
+		return nil, err
+	}
+	return &ReindexShardStatement{ID: id}, nil
+}
+
 // parseShowContinuousQueriesStatement parses a string and returns a ShowContinuousQueriesStatement.
 // This function assumes the "SHOW CONTINUOUS" tokens have already been consumed.
 func (p *Parser) parseShowContinuousQueriesStatement() (*ShowContinuousQueriesStatement, error) {
@@ -1627,19 +1911,18 @@ func (p *Parser) parseShowServersStatement() (*ShowServersStatement, error) {
 	return stmt, nil
 }
 
-// parseGrantsForUserStatement parses a string and returns a ShowGrantsForUserStatement.
+// parseGrantsForUserStatement parses a string and returns a ShowGrantsForUserStatement,
+// or a ShowGrantsForRoleStatement if the ROLE word follows.
 // This function assumes the "SHOW GRANTS" tokens have already been consumed.
-func (p *Parser) parseGrantsForUserStatement() (*ShowGrantsForUserStatement, error) {
-	stmt := &ShowGrantsForUserStatement{}
-
-	// Parse the name of the user to be displayed.
-	lit, err := p.ParseIdent()
+func (p *Parser) parseGrantsForUserStatement() (Statement, error) {
+	// Parse the name of the user to be displayed, or of the role following ROLE.
+	name, role, err := p.parseUserOrRole()
 	if err != nil {
 		return nil, err
+	} else if role {
+		return &ShowGrantsForRoleStatement{Name: name}, nil
 	}
-	stmt.Name = lit
-
-	return stmt, nil
+	return &ShowGrantsForUserStatement{Name: name}, nil
 }
 
 // parseShowDatabasesStatement parses a string and returns a ShowDatabasesStatement.
@@ -1742,9 +2025,6 @@ func (p *Parser) parseCreateDatabaseStatement() (*CreateDatabaseStatement, error
 		// rewind
 		p.Unscan()
 
-		// mark statement as having a RetentionPolicyInfo defined
-		stmt.RetentionPolicyCreate = true
-
 		// Look for "DURATION"
 		if err := p.parseTokens([]Token{DURATION}); err != nil {
 			p.Unscan()
@@ -1754,6 +2034,7 @@ func (p *Parser) parseCreateDatabaseStatement() (*CreateDatabaseStatement, error
 				return nil, err
 			}
 			stmt.RetentionPolicyDuration = &rpDuration
+			stmt.RetentionPolicyCreate = true
 		}
 
 		// Look for "REPLICATION"
@@ -1765,20 +2046,28 @@ func (p *Parser) parseCreateDatabaseStatement() (*CreateDatabaseStatement, error
 				return nil, err
 			}
 			stmt.RetentionPolicyReplication = &rpReplication
+			stmt.RetentionPolicyCreate = true
 		}
 
 		// Look for "SHARD"
 		if err := p.parseTokens([]Token{SHARD}); err != nil {
 			p.Unscan()
 		} else {
-			// Look for "DURATION"
+			// Look for "DURATION" or "KEY"
 			tok, pos, lit := p.ScanIgnoreWhitespace()
-			if tok != DURATION {
-				return nil, newParseError(tokstr(tok, lit), []string{"DURATION"}, pos)
-			}
-			stmt.RetentionPolicyShardGroupDuration, err = p.ParseDuration()
-			if err != nil {
-				return nil, err
+			switch tok {
+			case DURATION:
+				stmt.RetentionPolicyShardGroupDuration, err = p.ParseDuration()
+				if err != nil {
+					return nil, err
+				}
+				stmt.RetentionPolicyCreate = true
+			case KEY:
+				if stmt.ShardKey, err = p.ParseIdentList(); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, newParseError(tokstr(tok, lit), []string{"DURATION", "KEY"}, pos)
 			}
 		}
 
@@ -1790,6 +2079,18 @@ func (p *Parser) parseCreateDatabaseStatement() (*CreateDatabaseStatement, error
 			if err != nil {
 				return nil, err
 			}
+			stmt.RetentionPolicyCreate = true
+		}
+
+		// Look for "SHARD KEY"
+		if stmt.ShardKey == nil {
+			if err := p.parseTokens([]Token{SHARD}); err != nil {
+				p.Unscan()
+			} else if err := p.parseTokens([]Token{KEY}); err != nil {
+				return nil, err
+			} else if stmt.ShardKey, err = p.ParseIdentList(); err != nil {
+				return nil, err
+			}
 		}
 	} else {
 		p.Unscan()
@@ -1927,6 +2228,159 @@ func (p *Parser) parseDropUserStatement() (*DropUserStatement, error) {
 	return stmt, nil
 }
 
+// parseCreateRoleStatement parses a string and returns a CreateRoleStatement.
+// This function assumes the "CREATE ROLE" tokens have already been consumed.
+func (p *Parser) parseCreateRoleStatement() (*CreateRoleStatement, error) {
+	stmt := &CreateRoleStatement{}
+
+	// Parse the name of the role to be created.
+	lit, err := p.ParseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = lit
+
+	return stmt, nil
+}
+
+// parseDropRoleStatement parses a string and returns a DropRoleStatement.
+// This function assumes the DROP ROLE tokens have already been consumed.
+func (p *Parser) parseDropRoleStatement() (*DropRoleStatement, error) {
+	stmt := &DropRoleStatement{}
+
+	// Parse the name of the role to be dropped.
+	lit, err := p.ParseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = lit
+
+	return stmt, nil
+}
+
+// parseQueryTemplateName parses the name of a query template.
+func (p *Parser) parseQueryTemplateName() (string, error) {
+	idents, err := p.parseSegmentedIdents()
+	if err != nil {
+		return "", err
+	}
+	for _, ident := range idents {
+		if ident == "" {
+			return "", fmt.Errorf("invalid query template name: %s", strings.Join(idents, "."))
+		}
+	}
+	return strings.Join(idents, "."), nil
+}
+
+// parseCreateQueryTemplateStatement parses a string and returns a CreateQueryTemplateStatement.
+// This function assumes the "CREATE QUERY TEMPLATE" tokens have already been consumed.
+func (p *Parser) parseCreateQueryTemplateStatement() (*CreateQueryTemplateStatement, error) {
+	stmt := &CreateQueryTemplateStatement{}
+
+	// Parse the name of the template.
+	name, err := p.parseQueryTemplateName()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = name
+
+	// Expect "AS SELECT" tokens.
+	if err := p.parseTokens([]Token{AS, SELECT}); err != nil {
+		return nil, err
+	}
+
+	// Read the select statement with its bound parameters left unresolved.
+	p.deferParams = true
+	source, err := p.parseSelectStatement(targetNotRequired)
+	p.deferParams = false
+	if err != nil {
+		return nil, err
+	} else if source.Target != nil {
+		return nil, errors.New("query templates cannot select INTO a measurement")
+	}
+	stmt.Source = source
+
+	return stmt, nil
+}
+
+// parseDropQueryTemplateStatement parses a string and returns a DropQueryTemplateStatement.
+// This function assumes the "DROP QUERY TEMPLATE" tokens have already been consumed.
+func (p *Parser) parseDropQueryTemplateStatement() (*DropQueryTemplateStatement, error) {
+	name, err := p.parseQueryTemplateName()
+	if err != nil {
+		return nil, err
+	}
+	return &DropQueryTemplateStatement{Name: name}, nil
+}
+
+// parseShowQueryTemplatesStatement parses a string and returns a ShowQueryTemplatesStatement.
+// This function assumes the "SHOW QUERY TEMPLATES" tokens have already been consumed.
+func (p *Parser) parseShowQueryTemplatesStatement() (*ShowQueryTemplatesStatement, error) {
+	return &ShowQueryTemplatesStatement{}, nil
+}
+
+// parseExecuteQueryTemplateStatement parses a string and returns an ExecuteQueryTemplateStatement.
+// This function assumes the "EXECUTE QUERY TEMPLATE" tokens have already been consumed.
+func (p *Parser) parseExecuteQueryTemplateStatement() (*ExecuteQueryTemplateStatement, error) {
+	stmt := &ExecuteQueryTemplateStatement{}
+
+	// Parse the name of the template.
+	name, err := p.parseQueryTemplateName()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = name
+
+	// Parse the optional "VERSION <n>". VERSION is not a keyword.
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok == IDENT && strings.EqualFold(lit, "VERSION") {
+		version, err := p.ParseUInt64()
+		if err != nil {
+			return nil, err
+		} else if version == 0 {
+			return nil, errors.New("invalid query template version: 0")
+		}
+		stmt.Version = version
+	} else {
+		p.Unscan()
+	}
+
+	// Parse the optional parameter values: "WITH name = value, ...".
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != WITH {
+		p.Unscan()
+		return stmt, nil
+	}
+	stmt.Params = make(map[string]Literal)
+	for {
+		ident, err := p.ParseIdent()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := stmt.Params[ident]; ok {
+			return nil, fmt.Errorf("duplicate parameter: %s", ident)
+		}
+
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != EQ {
+			return nil, newParseError(tokstr(tok, lit), []string{"="}, pos)
+		}
+
+		expr, err := p.parseUnaryExpr()
+		if err != nil {
+			return nil, err
+		}
+		switch expr := expr.(type) {
+		case *BooleanLiteral, *DurationLiteral, *IntegerLiteral, *NumberLiteral, *StringLiteral:
+			stmt.Params[ident] = expr.(Literal)
+		default:
+			return nil, fmt.Errorf("invalid value for parameter %s: %s", ident, expr)
+		}
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != COMMA {
+			p.Unscan()
+			return stmt, nil
+		}
+	}
+}
+
 // parseExplainStatement parses a string and return an ExplainStatement.
 // This function assumes the EXPLAIN token has already been consumed.
 func (p *Parser) parseExplainStatement() (*ExplainStatement, error) {
@@ -1962,6 +2416,50 @@ func (p *Parser) parseShowShardsStatement() (*ShowShardsStatement, error) {
 	return &ShowShardsStatement{}, nil
 }
 
+// parseShowShardStatement parses a string and returns a ShowShardStatement.
+// This function assumes the "SHOW SHARD" tokens have already been consumed.
+func (p *Parser) parseShowShardStatement() (*ShowShardStatement, error) {
+	id, err := p.ParseUInt64()
+	if err != nil {
+		return nil, err
+	}
+	return &ShowShardStatement{ID: id}, nil
+}
+
+// parseShowShardJobsStatement parses a string and returns a ShowShardJobsStatement.
+// This function assumes the "SHOW SHARD JOBS" tokens have already been consumed.
+func (p *Parser) parseShowShardJobsStatement() (*ShowShardJobsStatement, error) {
+	return &ShowShardJobsStatement{}, nil
+}
+
+// parseTruncateShardsStatement parses a string and returns a TruncateShardsStatement.
+// This function assumes the "TRUNCATE SHARDS" tokens have already been consumed.
+func (p *Parser) parseTruncateShardsStatement() (*TruncateShardsStatement, error) {
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != IDENT || !strings.EqualFold(lit, "now") {
+		return nil, newParseError(tokstr(tok, lit), []string{"NOW"}, pos)
+	}
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != ON {
+		return nil, newParseError(tokstr(tok, lit), []string{"ON"}, pos)
+	}
+
+	// Parse the database and the optional retention policy.
+	idents, err := p.parseSegmentedIdents()
+	if err != nil {
+		return nil, err
+	}
+	for _, ident := range idents {
+		if ident == "" || len(idents) > 2 {
+			return nil, fmt.Errorf("invalid retention policy: %s", strings.Join(idents, "."))
+		}
+	}
+
+	stmt := &TruncateShardsStatement{Database: idents[0]}
+	if len(idents) == 2 {
+		stmt.RetentionPolicy = idents[1]
+	}
+	return stmt, nil
+}
+
 // parseShowStatsStatement parses a string and returns a ShowStatsStatement.
 // This function assumes the "SHOW STATS" tokens have already been consumed.
 func (p *Parser) parseShowStatsStatement() (*ShowStatsStatement, error) {
@@ -1991,6 +2489,104 @@ func (p *Parser) parseShowDiagnosticsStatement() (*ShowDiagnosticsStatement, err
 	return stmt, err
 }
 
+// parseShowDiskUsageStatement parses a string and returns a ShowDiskUsageStatement.
+// This function assumes the "SHOW DISK" tokens have already been consumed. DISK
+// and USAGE are not keywords, so they remain valid measurement names.
+func (p *Parser) parseShowDiskUsageStatement() (*ShowDiskUsageStatement, error) {
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != IDENT || !strings.EqualFold(lit, "USAGE") {
+		return nil, newParseError(tokstr(tok, lit), []string{"USAGE"}, pos)
+	}
+
+	stmt := &ShowDiskUsageStatement{}
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == ON {
+		ident, err := p.ParseIdent()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Database = ident
+	} else {
+		p.Unscan()
+	}
+	return stmt, nil
+}
+
+// parseCreateMaterializedViewStatement parses a string and returns a CreateMaterializedViewStatement.
+// This function assumes the "CREATE MATERIALIZED VIEW" tokens have already been consumed.
+func (p *Parser) parseCreateMaterializedViewStatement() (*CreateMaterializedViewStatement, error) {
+	stmt := &CreateMaterializedViewStatement{}
+
+	// Read the name of the view to create.
+	ident, err := p.ParseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = ident
+
+	// Expect an "ON" keyword.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != ON {
+		return nil, newParseError(tokstr(tok, lit), []string{"ON"}, pos)
+	}
+
+	// Read the name of the database to create the view on.
+	if ident, err = p.ParseIdent(); err != nil {
+		return nil, err
+	}
+	stmt.Database = ident
+
+	// Expect "AS SELECT" tokens.
+	if err := p.parseTokens([]Token{AS, SELECT}); err != nil {
+		return nil, err
+	}
+
+	// Read the select statement to be used as the source.
+	source, err := p.parseSelectStatement(targetNotRequired)
+	if err != nil {
+		return nil, err
+	} else if source.Target != nil {
+		return nil, errors.New("materialized views cannot select INTO a measurement")
+	}
+	if d, err := source.GroupByInterval(); err != nil {
+		return nil, err
+	} else if source.IsRawQuery || d == 0 {
+		return nil, errors.New("materialized views require an aggregate query with GROUP BY time(...)")
+	}
+	stmt.Source = source
+
+	return stmt, nil
+}
+
+// parseDropMaterializedViewStatement parses a string and returns a DropMaterializedViewStatement.
+// This function assumes the "DROP MATERIALIZED VIEW" tokens have already been consumed.
+func (p *Parser) parseDropMaterializedViewStatement() (*DropMaterializedViewStatement, error) {
+	stmt := &DropMaterializedViewStatement{}
+
+	// Read the name of the view to drop.
+	ident, err := p.ParseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = ident
+
+	// Expect an "ON" keyword.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != ON {
+		return nil, newParseError(tokstr(tok, lit), []string{"ON"}, pos)
+	}
+
+	// Read the name of the database to remove the view from.
+	if ident, err = p.ParseIdent(); err != nil {
+		return nil, err
+	}
+	stmt.Database = ident
+
+	return stmt, nil
+}
+
+// parseShowMaterializedViewsStatement parses a string and returns a ShowMaterializedViewsStatement.
+// This function assumes the "SHOW MATERIALIZED VIEWS" tokens have already been consumed.
+func (p *Parser) parseShowMaterializedViewsStatement() (*ShowMaterializedViewsStatement, error) {
+	return &ShowMaterializedViewsStatement{}, nil
+}
+
 // parseDropContinuousQueriesStatement parses a string and returns a DropContinuousQueryStatement.
 // This function assumes the "DROP CONTINUOUS" tokens have already been consumed.
 func (p *Parser) parseDropContinuousQueryStatement() (*DropContinuousQueryStatement, error) {
@@ -2087,8 +2683,28 @@ type validateField struct {
 }
 
 func (c *validateField) Visit(n Node) Visitor {
-	e, ok := n.(*BinaryExpr)
-	if !ok {
+	var e *BinaryExpr
+	switch n := n.(type) {
+	case *CaseExpr:
+		// The conditions of a case expression compare values, so only its
+		// results are validated.
+		for _, w := range n.WhenClauses {
+			Walk(c, w.Result)
+		}
+		Walk(c, n.Else)
+		return nil
+	case *Call:
+		// Likewise for the condition of if().
+		if n.Name == "if" && len(n.Args) > 0 {
+			for _, arg := range n.Args[1:] {
+				Walk(c, arg)
+			}
+			return nil
+		}
+		return c
+	case *BinaryExpr:
+		e = n
+	default:
 		return c
 	}
 
@@ -2149,6 +2765,21 @@ func (p *Parser) peekRune() rune {
 	return r
 }
 
+// scanWordAfterSpace scans whitespace followed by the identifier word and
+// reports whether they were found. Otherwise the scanned tokens are unscanned.
+func (p *Parser) scanWordAfterSpace(word string) bool {
+	if tok, _, _ := p.Scan(); tok != WS {
+		p.Unscan()
+		return false
+	}
+	if tok, _, lit := p.Scan(); tok != IDENT || !strings.EqualFold(lit, word) {
+		p.Unscan()
+		p.Unscan()
+		return false
+	}
+	return true
+}
+
 func (p *Parser) parseSource(subqueries bool) (Source, error) {
 	m := &Measurement{}
 
@@ -2349,7 +2980,71 @@ func (p *Parser) parseLocation() (*time.Location, error) {
 	tz, ok := expr.(*Call)
 	if !ok {
 		return nil, errors.New("tz must be a function call")
-	} else if len(tz.Args) != 1 {
+	}
+	return callLocation(tz)
+}
+
+// parseSampleSeries parses the fraction of the series selected by
+// sample_series(). It returns zero if the statement does not sample series.
+func (p *Parser) parseSampleSeries() (float64, error) {
+	tok, _, lit := p.ScanIgnoreWhitespace()
+	p.Unscan()
+	if tok != IDENT || strings.ToLower(lit) != "sample_series" {
+		return 0, nil
+	}
+
+	expr, err := p.ParseExpr()
+	if err != nil {
+		return 0, err
+	}
+	call, ok := expr.(*Call)
+	if !ok {
+		return 0, errors.New("sample_series must be a function call")
+	} else if len(call.Args) != 1 {
+		return 0, errors.New("sample_series requires exactly one argument")
+	}
+
+	var fraction float64
+	switch arg := call.Args[0].(type) {
+	case *NumberLiteral:
+		fraction = arg.Val
+	case *IntegerLiteral:
+		fraction = float64(arg.Val)
+	default:
+		return 0, errors.New("expected number argument in sample_series()")
+	}
+	if fraction <= 0 || fraction > 1 {
+		return 0, fmt.Errorf("sample_series fraction must be greater than 0 and at most 1, got %v", fraction)
+	}
+	return fraction, nil
+}
+
+// parseDimensionLocation removes a tz() argument from the time dimension and
+// returns its location. It returns nil if the time dimension has no tz().
+func parseDimensionLocation(dimensions Dimensions) (*time.Location, error) {
+	for _, d := range dimensions {
+		call, ok := d.Expr.(*Call)
+		if !ok || call.Name != "time" || len(call.Args) == 0 {
+			continue
+		}
+
+		tz, ok := call.Args[len(call.Args)-1].(*Call)
+		if !ok || strings.ToLower(tz.Name) != "tz" {
+			continue
+		}
+		loc, err := callLocation(tz)
+		if err != nil {
+			return nil, err
+		}
+		call.Args = call.Args[:len(call.Args)-1]
+		return loc, nil
+	}
+	return nil, nil
+}
+
+// callLocation returns the location named by the argument of a tz() call.
+func callLocation(tz *Call) (*time.Location, error) {
+	if len(tz.Args) != 1 {
 		return nil, errors.New("tz requires exactly one argument")
 	}
 
@@ -2608,6 +3303,13 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 	tok, pos, lit := p.ScanIgnoreWhitespace()
 	switch tok {
 	case IDENT:
+		// CASE is not a keyword, so it only starts a case expression if WHEN
+		// follows it. Otherwise it is a variable reference.
+		if strings.EqualFold(lit, "CASE") && p.scanWordAfterSpace("WHEN") {
+			p.Unscan()
+			return p.parseCaseExpr()
+		}
+
 		// If the next immediate token is a left parentheses, parse as function call.
 		// Otherwise parse as a variable reference.
 		if tok0, _, _ := p.Scan(); tok0 == LPAREN {
@@ -2617,8 +3319,18 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 		p.Unscan() // Unscan the last token (wasn't an LPAREN)
 		p.Unscan() // Unscan the IDENT token
 
-		// Parse it as a VarRef.
-		return p.ParseVarRef()
+		// Parse it as a VarRef, or as the call of a namespaced function
+		// such as geo.radius() if a left parentheses follows it.
+		ref, err := p.ParseVarRef()
+		if err != nil {
+			return nil, err
+		} else if ref.Type == Unknown && strings.Contains(ref.Val, ".") {
+			if tok0, _, _ := p.Scan(); tok0 == LPAREN {
+				return p.parseCall(ref.Val)
+			}
+			p.Unscan()
+		}
+		return ref, nil
 	case DISTINCT:
 		// If the next immediate token is a left parentheses, parse as function call.
 		// Otherwise parse as a Distinct expression.
@@ -2656,6 +3368,9 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 	case TRUE, FALSE:
 		return &BooleanLiteral{Val: (tok == TRUE)}, nil
 	case DURATIONVAL:
+		if months, ok := ParseCalendarDuration(lit); ok {
+			return &CalendarLiteral{Months: months}, nil
+		}
 		v, err := ParseDuration(lit)
 		if err != nil {
 			return nil, err
@@ -2687,6 +3402,10 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 			return nil, errors.New("empty bound parameter")
 		}
 
+		if p.deferParams {
+			return &BoundParameter{Name: k}, nil
+		}
+
 		v := p.params[k]
 		if v == nil {
 			return nil, fmt.Errorf("missing parameter: %s", k)
@@ -2701,6 +3420,8 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 			return &StringLiteral{Val: v}, nil
 		case bool:
 			return &BooleanLiteral{Val: v}, nil
+		case time.Duration:
+			return &DurationLiteral{Val: v}, nil
 		default:
 			return nil, fmt.Errorf("unable to bind parameter with type %T", v)
 		}
@@ -2790,6 +3511,54 @@ func (p *Parser) parseRegex() (*RegexLiteral, error) {
 	return &RegexLiteral{Val: re}, nil
 }
 
+// parseCaseExpr parses a case expression. CASE, WHEN, THEN and ELSE are
+// identifiers rather than keywords, so fields can still be named after them.
+// This function assumes the CASE word has been consumed.
+func (p *Parser) parseCaseExpr() (*CaseExpr, error) {
+	expr := &CaseExpr{}
+
+	// Parse one or more "WHEN <condition> THEN <result>" clauses.
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok != IDENT || !strings.EqualFold(lit, "WHEN") {
+			if len(expr.WhenClauses) == 0 {
+				return nil, newParseError(tokstr(tok, lit), []string{"WHEN"}, pos)
+			}
+			p.Unscan()
+			break
+		}
+
+		cond, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != IDENT || !strings.EqualFold(lit, "THEN") {
+			return nil, newParseError(tokstr(tok, lit), []string{"THEN"}, pos)
+		}
+		result, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+		expr.WhenClauses = append(expr.WhenClauses, &WhenClause{Condition: cond, Result: result})
+	}
+
+	// Parse the optional "ELSE <result>".
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok == IDENT && strings.EqualFold(lit, "ELSE") {
+		result, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+		expr.Else = result
+	} else {
+		p.Unscan()
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != END {
+		return nil, newParseError(tokstr(tok, lit), []string{"END"}, pos)
+	}
+	return expr, nil
+}
+
 // parseCall parses a function call.
 // This function assumes the function name and LPAREN have been consumed.
 func (p *Parser) parseCall(name string) (*Call, error) {
@@ -3035,6 +3804,38 @@ func FormatDuration(d time.Duration) string {
 	return fmt.Sprintf("%du", d/time.Microsecond)
 }
 
+// ParseCalendarDuration parses a duration of calendar months: months (mo),
+// quarters (q) or years (y). It returns false if s is not a calendar duration.
+func ParseCalendarDuration(s string) (int, bool) {
+	var unit int
+	switch {
+	case strings.HasSuffix(s, "mo"):
+		s, unit = strings.TrimSuffix(s, "mo"), 1
+	case strings.HasSuffix(s, "q"):
+		s, unit = strings.TrimSuffix(s, "q"), 3
+	case strings.HasSuffix(s, "y"):
+		s, unit = strings.TrimSuffix(s, "y"), 12
+	default:
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(s, 10, 32)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return int(n) * unit, true
+}
+
+// FormatCalendarDuration formats a duration of calendar months to a string.
+func FormatCalendarDuration(months int) string {
+	if months%12 == 0 {
+		return fmt.Sprintf("%dy", months/12)
+	} else if months%3 == 0 {
+		return fmt.Sprintf("%dq", months/3)
+	}
+	return fmt.Sprintf("%dmo", months)
+}
+
 // parseTokens consumes an expected sequence of tokens.
 func (p *Parser) parseTokens(toks []Token) error {
 	for _, expected := range toks {
@@ -0,0 +1,103 @@
+package influxql_test
+
+import (
+	"testing"
+
+	"github.com/freetsdb/freetsdb/services/influxql"
+)
+
+// Ensure the statements using words that are not keywords are parsed, and
+// the words remain valid identifiers.
+func TestParser_Words(t *testing.T) {
+	for _, tt := range []struct {
+		s   string
+		exp string
+	}{
+		{
+			s:   `CREATE RETENTION POLICY rp0 ON db0 DURATION 1d REPLICATION 1 future limit 1h PAST LIMIT 2h DEFAULT`,
+			exp: `CREATE RETENTION POLICY rp0 ON db0 DURATION 1d REPLICATION 1 FUTURE LIMIT 1h PAST LIMIT 2h DEFAULT`,
+		},
+		{
+			s:   `ALTER RETENTION POLICY rp0 ON db0 PAST LIMIT 2h FUTURE LIMIT 1h`,
+			exp: `ALTER RETENTION POLICY rp0 ON db0 FUTURE LIMIT 1h PAST LIMIT 2h`,
+		},
+		{
+			s:   `SELECT future, past FROM future WHERE past = 'x'`,
+			exp: `SELECT future, past FROM future WHERE past = 'x'`,
+		},
+		{s: `CREATE ROLE readers`, exp: `CREATE ROLE readers`},
+		{s: `drop role readers`, exp: `DROP ROLE readers`},
+		{s: `SHOW ROLES`, exp: `SHOW ROLES`},
+		{s: `GRANT READ ON db0 TO ROLE readers`, exp: `GRANT READ ON db0 TO ROLE readers`},
+		{s: `REVOKE READ ON db0 FROM role readers`, exp: `REVOKE READ ON db0 FROM ROLE readers`},
+		{s: `SHOW GRANTS FOR ROLE readers`, exp: `SHOW GRANTS FOR ROLE readers`},
+		{s: `GRANT READ ON db0 TO role`, exp: `GRANT READ ON db0 TO role`},
+		{s: `REVOKE READ ON db0 FROM role`, exp: `REVOKE READ ON db0 FROM role`},
+		{s: `SHOW GRANTS FOR role`, exp: `SHOW GRANTS FOR role`},
+		{s: `SELECT role, roles FROM roles`, exp: `SELECT role, roles FROM roles`},
+		{s: `TRUNCATE SHARDS NOW ON db0`, exp: `TRUNCATE SHARDS NOW ON db0`},
+		{s: `truncate shards now on db0.rp0`, exp: `TRUNCATE SHARDS NOW ON "db0".rp0`},
+		{s: `SELECT truncate FROM truncate`, exp: `SELECT truncate FROM truncate`},
+		{s: `copy shard 1 to 3`, exp: `COPY SHARD 1 TO 3`},
+		{s: `SHOW SHARD JOBS`, exp: `SHOW SHARD JOBS`},
+		{s: `SELECT copy, jobs FROM jobs`, exp: `SELECT copy, jobs FROM jobs`},
+		{s: `create query template dashboards.cpu as SELECT value FROM cpu`, exp: `CREATE QUERY TEMPLATE dashboards.cpu AS SELECT value FROM cpu`},
+		{s: `DROP QUERY TEMPLATE dashboards.cpu`, exp: `DROP QUERY TEMPLATE dashboards.cpu`},
+		{s: `SHOW QUERY TEMPLATES`, exp: `SHOW QUERY TEMPLATES`},
+		{s: `execute query template dashboards.cpu`, exp: `EXECUTE QUERY TEMPLATE dashboards.cpu`},
+		{
+			s:   `EXECUTE QUERY TEMPLATE dashboards.cpu version 2 WITH host = 'serverA'`,
+			exp: `EXECUTE QUERY TEMPLATE dashboards.cpu VERSION 2 WITH host = 'serverA'`,
+		},
+		{s: `SELECT execute, template FROM templates`, exp: `SELECT execute, template FROM templates`},
+		{
+			s:   `SELECT case when value > 1 then 'high' WHEN value > 0 THEN 'low' else 'none' END FROM cpu`,
+			exp: `SELECT CASE WHEN value > 1 THEN 'high' WHEN value > 0 THEN 'low' ELSE 'none' END FROM cpu`,
+		},
+		{
+			s:   `SELECT case, "when", then::field FROM cpu WHERE case = 'x' AND else > 1`,
+			exp: `SELECT case, when, then::field FROM cpu WHERE case = 'x' AND else > 1`,
+		},
+		{s: `SELECT case(value) FROM cpu`, exp: `SELECT case(value) FROM cpu`},
+		{
+			s:   `create materialized view cpu_1h on db0 as SELECT mean(value) FROM cpu GROUP BY time(1h)`,
+			exp: `CREATE MATERIALIZED VIEW cpu_1h ON db0 AS SELECT mean(value) FROM cpu GROUP BY time(1h)`,
+		},
+		{s: `DROP MATERIALIZED VIEW cpu_1h ON db0`, exp: `DROP MATERIALIZED VIEW cpu_1h ON db0`},
+		{s: `show materialized views`, exp: `SHOW MATERIALIZED VIEWS`},
+		{s: `SELECT materialized, view FROM views`, exp: `SELECT materialized, view FROM views`},
+	} {
+		stmt, err := influxql.ParseStatement(tt.s)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.s, err)
+		} else if got := stmt.String(); got != tt.exp {
+			t.Fatalf("%s: unexpected statement: got %s, exp %s", tt.s, got, tt.exp)
+		}
+	}
+}
+
+// Ensure the errors of statements using words that are not keywords.
+func TestParser_Words_Errors(t *testing.T) {
+	for _, tt := range []struct {
+		s   string
+		err string
+	}{
+		{
+			s:   `ALTER RETENTION POLICY rp0 ON db0 FUTURE LIMIT 1h future LIMIT 2h`,
+			err: `found duplicate FUTURE option at line 1, char 51`,
+		},
+		{
+			s:   `ALTER RETENTION POLICY rp0 ON db0 PAST 2h`,
+			err: `found 2h, expected LIMIT at line 1, char 40`,
+		},
+		{s: `TRUNCATE SHARDS NOW`, err: `found EOF, expected ON at line 1, char 21`},
+		{s: `EXECUTE QUERY TEMPLATE dashboards.cpu VERSION 0`, err: `invalid query template version: 0`},
+		{s: `SELECT CASE WHEN value > 1 'high' END FROM cpu`, err: `found high, expected THEN at line 1, char 27`},
+		{s: `SELECT CASE WHEN value > 1 THEN 'high' FROM cpu`, err: `found FROM, expected END at line 1, char 40`},
+		{s: `TRUNCATE SHARDS NOW ON db0.rp0.cpu`, err: `invalid retention policy: db0.rp0.cpu`},
+	} {
+		if _, err := influxql.ParseStatement(tt.s); err == nil || err.Error() != tt.err {
+			t.Fatalf("%s: unexpected error: got %v, exp %s", tt.s, err, tt.err)
+		}
+	}
+}
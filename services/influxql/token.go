@@ -119,12 +119,15 @@ const (
 	QUERIES
 	QUERY
 	READ
+	REINDEX
 	REPLICATION
 	RESAMPLE
 	RETENTION
 	REVOKE
 	SELECT
 	SERIES
+	SESSION
+	SESSIONS
 	SET
 	SHOW
 	SHARD
@@ -132,6 +135,7 @@ const (
 	SLIMIT
 	SOFFSET
 	STATS
+	STRIP
 	SUBSCRIPTION
 	SUBSCRIPTIONS
 	TAG
@@ -152,6 +156,7 @@ var tokens = [...]string{
 
 	IDENT:       "IDENT",
 	NUMBER:      "NUMBER",
+	INTEGER:     "INTEGER",
 	DURATIONVAL: "DURATIONVAL",
 	STRING:      "STRING",
 	BADSTRING:   "BADSTRING",
@@ -243,12 +248,15 @@ var tokens = [...]string{
 	QUERIES:       "QUERIES",
 	QUERY:         "QUERY",
 	READ:          "READ",
+	REINDEX:       "REINDEX",
 	REPLICATION:   "REPLICATION",
 	RESAMPLE:      "RESAMPLE",
 	RETENTION:     "RETENTION",
 	REVOKE:        "REVOKE",
 	SELECT:        "SELECT",
 	SERIES:        "SERIES",
+	SESSION:       "SESSION",
+	SESSIONS:      "SESSIONS",
 	SET:           "SET",
 	SHOW:          "SHOW",
 	SHARD:         "SHARD",
@@ -256,6 +264,7 @@ var tokens = [...]string{
 	SLIMIT:        "SLIMIT",
 	SOFFSET:       "SOFFSET",
 	STATS:         "STATS",
+	STRIP:         "STRIP",
 	SUBSCRIPTION:  "SUBSCRIPTION",
 	SUBSCRIPTIONS: "SUBSCRIPTIONS",
 	TAG:           "TAG",
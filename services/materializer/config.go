@@ -0,0 +1,60 @@
+package materializer
+
+import (
+	"errors"
+	"time"
+
+	"github.com/freetsdb/freetsdb/monitor/diagnostics"
+	"github.com/freetsdb/freetsdb/toml"
+)
+
+const (
+	// DefaultRefreshInterval is the default time between the refreshes of the
+	// materialized views.
+	DefaultRefreshInterval = time.Second
+)
+
+// Config represents the configuration of the materialized view service.
+type Config struct {
+	// Enabled maintains the materialized views on this node and answers
+	// queries from them.
+	Enabled bool `toml:"enabled"`
+
+	// RefreshInterval is the time between the refreshes of the windows of
+	// the views written to. It bounds the staleness of the views when the
+	// refreshes keep up with the writes.
+	RefreshInterval toml.Duration `toml:"refresh-interval"`
+}
+
+// NewConfig returns a new instance of Config with defaults.
+func NewConfig() Config {
+	return Config{
+		Enabled:         true,
+		RefreshInterval: toml.Duration(DefaultRefreshInterval),
+	}
+}
+
+// Validate returns an error if the Config is invalid.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.RefreshInterval <= 0 {
+		return errors.New("refresh-interval must be positive")
+	}
+	return nil
+}
+
+// Diagnostics returns a diagnostics representation of a subset of the Config.
+func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
+	if !c.Enabled {
+		return diagnostics.RowFromMap(map[string]interface{}{
+			"enabled": false,
+		}), nil
+	}
+
+	return diagnostics.RowFromMap(map[string]interface{}{
+		"enabled":          true,
+		"refresh-interval": c.RefreshInterval,
+	}), nil
+}
@@ -0,0 +1,333 @@
+// Package materializer provides the service maintaining materialized views.
+package materializer // import "github.com/freetsdb/freetsdb/services/materializer"
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/freetsdb/freetsdb/coordinator"
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"go.uber.org/zap"
+)
+
+// Statistics for the materialized view service.
+const (
+	statRefreshOK   = "refreshOk"
+	statRefreshFail = "refreshFail"
+)
+
+// Service maintains the materialized views. The windows of a view that the
+// points written through this node fall in are marked dirty and written again
+// into the view by the next refresh. A view is first written whole, and is
+// not used to answer queries until then.
+//
+// Each node refreshes the views for the points it wrote, so the staleness a
+// node reports only accounts for the writes it received.
+type Service struct {
+	MetaClient interface {
+		Databases() ([]meta.DatabaseInfo, error)
+	}
+	QueryExecutor *query.Executor
+	Logger        *zap.Logger
+
+	config Config
+	stats  *Statistics
+
+	mu    sync.Mutex
+	views map[viewKey]*viewState
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// viewKey identifies a view.
+type viewKey struct {
+	database, name string
+}
+
+// viewState is the state of the refreshes of a view.
+type viewState struct {
+	view  *coordinator.MaterializedView
+	query string
+
+	// built is true once the whole view was written.
+	built bool
+
+	// The windows between minTime and maxTime were written to since
+	// dirtySince.
+	dirty            bool
+	minTime, maxTime int64
+	dirtySince       time.Time
+
+	// refreshingSince is the time of the oldest write being refreshed, or
+	// zero if the view is not being refreshed.
+	refreshingSince time.Time
+}
+
+// markDirty marks the windows between min and max dirty since t.
+func (st *viewState) markDirty(min, max int64, t time.Time) {
+	if !st.dirty {
+		st.dirty = true
+		st.minTime, st.maxTime, st.dirtySince = min, max, t
+		return
+	}
+	if min < st.minTime {
+		st.minTime = min
+	}
+	if max > st.maxTime {
+		st.maxTime = max
+	}
+	if t.Before(st.dirtySince) {
+		st.dirtySince = t
+	}
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	return &Service{
+		Logger: zap.NewNop(),
+		config: c,
+		stats:  &Statistics{},
+		views:  make(map[viewKey]*viewState),
+	}
+}
+
+// Open starts the service.
+func (s *Service) Open() error {
+	s.Logger.Info("Starting materialized view service")
+
+	if s.stop != nil {
+		return nil
+	}
+	s.stop = make(chan struct{})
+	s.wg.Add(1)
+	go s.backgroundLoop()
+	return nil
+}
+
+// Close stops the service.
+func (s *Service) Close() error {
+	if s.stop == nil {
+		return nil
+	}
+	close(s.stop)
+	s.wg.Wait()
+	s.stop = nil
+	return nil
+}
+
+// WithLogger sets the logger on the service.
+func (s *Service) WithLogger(log *zap.Logger) {
+	s.Logger = log.With(zap.String("service", "materializer"))
+}
+
+// Statistics maintains the statistics for the materialized view service.
+type Statistics struct {
+	RefreshOK   int64
+	RefreshFail int64
+}
+
+// Statistics returns statistics for periodic monitoring.
+func (s *Service) Statistics(tags map[string]string) []models.Statistic {
+	return []models.Statistic{{
+		Name: "materializer",
+		Tags: tags,
+		Values: map[string]interface{}{
+			statRefreshOK:   atomic.LoadInt64(&s.stats.RefreshOK),
+			statRefreshFail: atomic.LoadInt64(&s.stats.RefreshFail),
+		},
+	}}
+}
+
+func (s *Service) backgroundLoop() {
+	defer s.wg.Done()
+
+	t := time.NewTicker(time.Duration(s.config.RefreshInterval))
+	defer t.Stop()
+	for {
+		select {
+		case <-s.stop:
+			s.Logger.Info("Terminating materialized view service")
+			return
+		case <-t.C:
+			s.Refresh()
+		}
+	}
+}
+
+// MarkDirty marks the windows of the views that the points fall in dirty.
+func (s *Service) MarkDirty(database, retentionPolicy string, points []models.Point) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, st := range s.views {
+		v := st.view
+		if key.database != database || v.RetentionPolicy != retentionPolicy {
+			continue
+		}
+
+		min, max, ok := int64(0), int64(0), false
+		for _, p := range points {
+			if string(p.Name()) != v.Measurement {
+				continue
+			}
+			t := window(p.UnixNano(), v.Interval)
+			if !ok || t < min {
+				min = t
+			}
+			if !ok || t > max {
+				max = t
+			}
+			ok = true
+		}
+		if ok {
+			st.markDirty(min, max, now)
+		}
+	}
+}
+
+// Staleness returns how long ago the oldest write not yet refreshed in a view
+// was made. It returns false if the view was not written whole yet.
+func (s *Service) Staleness(database, name string) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.views[viewKey{database: database, name: name}]
+	if st == nil || !st.built {
+		return 0, false
+	}
+
+	oldest := st.refreshingSince
+	if st.dirty && (oldest.IsZero() || st.dirtySince.Before(oldest)) {
+		oldest = st.dirtySince
+	}
+	if oldest.IsZero() {
+		return 0, true
+	}
+	return time.Since(oldest), true
+}
+
+// Refresh writes the views created since the last refresh whole, and the
+// dirty windows of the other views.
+func (s *Service) Refresh() {
+	for _, key := range s.sync() {
+		if err := s.refresh(key); err != nil {
+			s.Logger.Info("Error refreshing materialized view",
+				zap.String("db", key.database),
+				zap.String("name", key.name),
+				zap.Error(err))
+			atomic.AddInt64(&s.stats.RefreshFail, 1)
+		}
+	}
+}
+
+// sync updates the views from the meta store and returns them.
+func (s *Service) sync() []viewKey {
+	dbs, _ := s.MetaClient.Databases()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []viewKey
+	seen := make(map[viewKey]struct{})
+	for _, di := range dbs {
+		for _, mvi := range di.MaterializedViews {
+			key := viewKey{database: di.Name, name: mvi.Name}
+			seen[key] = struct{}{}
+
+			// A view created again with another query is written whole again.
+			if st := s.views[key]; st == nil || st.query != mvi.Query {
+				v, err := coordinator.ParseMaterializedView(di.Name, mvi)
+				if err != nil {
+					s.Logger.Info("Invalid materialized view",
+						zap.String("db", di.Name),
+						zap.String("name", mvi.Name),
+						zap.Error(err))
+					delete(s.views, key)
+					continue
+				}
+				s.views[key] = &viewState{view: v, query: mvi.Query}
+			}
+			keys = append(keys, key)
+		}
+	}
+	for key := range s.views {
+		if _, ok := seen[key]; !ok {
+			delete(s.views, key)
+		}
+	}
+	return keys
+}
+
+// refresh writes the dirty windows of a view, or the whole view if it was not
+// built yet.
+func (s *Service) refresh(key viewKey) error {
+	s.mu.Lock()
+	st := s.views[key]
+	if st == nil || (st.built && !st.dirty) {
+		s.mu.Unlock()
+		return nil
+	}
+
+	// The writes made while refreshing mark the view dirty again.
+	v, built := st.view, st.built
+	min, max, since := st.minTime, st.maxTime, st.dirtySince
+	if !built {
+		since = time.Now()
+	}
+	st.dirty = false
+	st.refreshingSince = since
+	s.mu.Unlock()
+
+	var start, end time.Time
+	if built {
+		start, end = time.Unix(0, min), time.Unix(0, max).Add(v.Interval)
+	}
+	err := s.execute(v, start, end)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st.refreshingSince = time.Time{}
+	if err != nil {
+		if built {
+			st.markDirty(min, max, since)
+		}
+		return err
+	}
+	st.built = true
+	atomic.AddInt64(&s.stats.RefreshOK, 1)
+	return nil
+}
+
+// execute writes the windows of a view between start and end into it.
+func (s *Service) execute(v *coordinator.MaterializedView, start, end time.Time) error {
+	stmt, err := v.RefreshStatement(start, end)
+	if err != nil {
+		return err
+	}
+	q := &influxql.Query{Statements: influxql.Statements{stmt}}
+
+	closing := make(chan struct{})
+	defer close(closing)
+
+	for res := range s.QueryExecutor.ExecuteQuery(q, query.ExecutionOptions{Database: v.Database}, closing) {
+		if res.Err != nil {
+			return res.Err
+		}
+	}
+	return nil
+}
+
+// window returns the start of the window of the interval that t falls in.
+func window(t int64, interval time.Duration) int64 {
+	m := t % int64(interval)
+	if m < 0 {
+		m += int64(interval)
+	}
+	return t - m
+}
@@ -0,0 +1,104 @@
+package materializer_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/materializer"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+func TestService_Refresh(t *testing.T) {
+	s := NewTestService(`CREATE MATERIALIZED VIEW cpu_1m ON db0 AS SELECT sum(value) FROM db0.rp0.cpu GROUP BY time(1m), host`)
+
+	var stmts []*influxql.SelectStatement
+	var fail error
+	s.QueryExecutor.StatementExecutor = &StatementExecutor{
+		ExecuteStatementFn: func(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+			stmts = append(stmts, stmt.(*influxql.SelectStatement))
+			return fail
+		},
+	}
+
+	// The view is written whole before it is used.
+	if _, ok := s.Staleness("db0", "cpu_1m"); ok {
+		t.Fatal("expected view not to be built")
+	}
+	s.Refresh()
+	if len(stmts) != 1 || stmts[0].Condition != nil {
+		t.Fatalf("unexpected statements: %v", stmts)
+	} else if d, ok := s.Staleness("db0", "cpu_1m"); !ok || d != 0 {
+		t.Fatalf("unexpected staleness: %s, %v", d, ok)
+	}
+
+	// The windows written to are refreshed, and only once.
+	s.MarkDirty("db0", "rp0", []models.Point{
+		models.MustNewPoint("cpu", models.NewTags(map[string]string{"host": "a"}), models.Fields{"value": 1.0}, time.Unix(90, 0)),
+		models.MustNewPoint("cpu", models.NewTags(map[string]string{"host": "b"}), models.Fields{"value": 1.0}, time.Unix(200, 0)),
+		models.MustNewPoint("mem", models.NewTags(nil), models.Fields{"value": 1.0}, time.Unix(1000, 0)),
+	})
+	s.MarkDirty("db0", "rp1", []models.Point{
+		models.MustNewPoint("cpu", models.NewTags(nil), models.Fields{"value": 1.0}, time.Unix(1000, 0)),
+	})
+	if _, ok := s.Staleness("db0", "cpu_1m"); !ok {
+		t.Fatal("expected view to be built")
+	}
+
+	// A failed refresh is retried.
+	fail = errors.New("marker")
+	s.Refresh()
+	fail = nil
+	s.Refresh()
+	s.Refresh()
+	if len(stmts) != 3 {
+		t.Fatalf("unexpected number of statements: %d", len(stmts))
+	}
+	for _, stmt := range stmts[1:] {
+		_, timeRange, err := influxql.ConditionExpr(stmt.Condition, nil)
+		if err != nil {
+			t.Fatal(err)
+		} else if !timeRange.Min.Equal(time.Unix(60, 0)) || !timeRange.Max.Equal(time.Unix(240, 0).Add(-1)) {
+			t.Fatalf("unexpected time range: %s - %s", timeRange.Min, timeRange.Max)
+		} else if stmt.Target == nil || stmt.Target.Measurement.Name != "cpu_1m" {
+			t.Fatalf("unexpected target: %s", stmt.Target)
+		}
+	}
+	if d, ok := s.Staleness("db0", "cpu_1m"); !ok || d != 0 {
+		t.Fatalf("unexpected staleness: %s, %v", d, ok)
+	}
+}
+
+// NewTestService returns a service maintaining a view of database db0.
+func NewTestService(q string) *materializer.Service {
+	s := materializer.NewService(materializer.NewConfig())
+	s.MetaClient = &MetaClient{DatabaseInfos: []meta.DatabaseInfo{{
+		Name:                   "db0",
+		DefaultRetentionPolicy: "rp0",
+		MaterializedViews:      []meta.MaterializedViewInfo{{Name: "cpu_1m", Query: q}},
+	}}}
+	s.QueryExecutor = query.NewExecutor()
+	return s
+}
+
+// MetaClient is a mock meta store.
+type MetaClient struct {
+	DatabaseInfos []meta.DatabaseInfo
+}
+
+// Databases returns a list of database info about each database in the cluster.
+func (c *MetaClient) Databases() ([]meta.DatabaseInfo, error) {
+	return c.DatabaseInfos, nil
+}
+
+// StatementExecutor is a mock statement executor.
+type StatementExecutor struct {
+	ExecuteStatementFn func(stmt influxql.Statement, ctx *query.ExecutionContext) error
+}
+
+func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement, ctx *query.ExecutionContext) error {
+	return e.ExecuteStatementFn(stmt, ctx)
+}
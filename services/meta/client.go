@@ -6,6 +6,9 @@ import (
 	"bytes"
 	crand "crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -73,6 +76,14 @@ type Client struct {
 
 	// Authentication cache.
 	authCache map[string]authUser
+
+	// rehashing holds the users whose password is being rehashed.
+	rehashing map[string]struct{}
+
+	// local applies the commands of a client created by NewLocalClient.
+	local *store
+
+	passwordPolicy PasswordPolicyConfig
 }
 
 type authUser struct {
@@ -92,6 +103,9 @@ func NewClient(n *freetsdb.Node) *Client {
 		changed:   make(chan struct{}),
 		logger:    zap.NewNop(),
 		authCache: make(map[string]authUser),
+		rehashing: make(map[string]struct{}),
+
+		passwordPolicy: PasswordPolicyConfig{HashCost: bcryptCost},
 
 		node: n,
 	}
@@ -99,6 +113,9 @@ func NewClient(n *freetsdb.Node) *Client {
 
 // Open a connection to a meta service cluster.
 func (c *Client) Open() error {
+	if c.local != nil {
+		return c.openLocal()
+	}
 
 	c.changed = make(chan struct{})
 	c.closing = make(chan struct{})
@@ -142,6 +159,21 @@ func (c *Client) SetMetaServers(a []string) {
 // This function is not safe for concurrent use.
 func (c *Client) SetTLS(v bool) { c.tls = v }
 
+// SetPasswordPolicy sets the policy new passwords must satisfy and the cost
+// they are hashed with.
+func (c *Client) SetPasswordPolicy(p PasswordPolicyConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.passwordPolicy = p
+}
+
+// PasswordPolicy returns the password policy of the client.
+func (c *Client) PasswordPolicy() PasswordPolicyConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.passwordPolicy
+}
+
 // Ping will hit the ping endpoint for the metaservice and return nil if
 // it returns 200. If checkAllMetaServers is set to true, it will hit the
 // ping endpoint and tell it to verify the health of all metaservers in the
@@ -192,6 +224,15 @@ func (c *Client) AcquireLease(name string) (l *Lease, err error) {
 }
 
 func (c *Client) acquireLease(name string) (*Lease, error) {
+	// A local client is the only node using its meta data.
+	if c.local != nil {
+		return &Lease{
+			Name:       name,
+			Expiration: time.Now().Add(time.Duration(c.local.config.LeaseDuration)),
+			Owner:      c.nodeID,
+		}, nil
+	}
+
 	c.mu.RLock()
 	server := c.metaServers[0]
 	c.mu.RUnlock()
@@ -404,6 +445,43 @@ func (c *Client) CreateDatabaseWithRetentionPolicy(name string, rpi *RetentionPo
 	return c.Database(name), err
 }
 
+// CreateDatabaseWithShardKey creates a database whose points are sharded by
+// the values of the tag keys in shardKey, with the specified retention policy
+// if rpi is not nil.
+func (c *Client) CreateDatabaseWithShardKey(name string, rpi *RetentionPolicyInfo, shardKey []string) (*DatabaseInfo, error) {
+	if rpi != nil && rpi.Duration < MinRetentionPolicyDuration && rpi.Duration != 0 {
+		return nil, ErrRetentionPolicyDurationTooLow
+	}
+
+	if db := c.Database(name); db != nil {
+		if !equalStrings(db.ShardKey, shardKey) {
+			return nil, ErrShardKeyConflict
+		} else if rpi == nil {
+			return db, nil
+		} else if rp := db.RetentionPolicy(rpi.Name); rp != nil {
+			if rp.ReplicaN != rpi.ReplicaN || rp.Duration != rpi.Duration {
+				return nil, ErrRetentionPolicyConflict
+			}
+			return db, nil
+		}
+	}
+
+	cmd := &internal.CreateDatabaseCommand{
+		Name:     proto.String(name),
+		ShardKey: shardKey,
+	}
+	if rpi != nil {
+		cmd.RetentionPolicy = rpi.marshal()
+	}
+
+	err := c.retryUntilExec(internal.Command_CreateDatabaseCommand, internal.E_CreateDatabaseCommand_Command, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Database(name), err
+}
+
 // DropDatabase deletes a database.
 func (c *Client) DropDatabase(name string) error {
 	cmd := &internal.DropDatabaseCommand{
@@ -487,12 +565,32 @@ func (c *Client) UpdateRetentionPolicy(database, name string, rpu *RetentionPoli
 		replicaN = &value
 	}
 
+	var shardGroupDuration, futureWriteLimit, pastWriteLimit *int64
+	if rpu.ShardGroupDuration != nil {
+		value := int64(*rpu.ShardGroupDuration)
+		shardGroupDuration = &value
+	}
+	if rpu.FutureWriteLimit != nil {
+		value := int64(*rpu.FutureWriteLimit)
+		futureWriteLimit = &value
+	}
+	if rpu.PastWriteLimit != nil {
+		value := int64(*rpu.PastWriteLimit)
+		pastWriteLimit = &value
+	}
+
 	cmd := &internal.UpdateRetentionPolicyCommand{
-		Database: proto.String(database),
-		Name:     proto.String(name),
-		NewName:  newName,
-		Duration: duration,
-		ReplicaN: replicaN,
+		Database:           proto.String(database),
+		Name:               proto.String(name),
+		NewName:            newName,
+		Duration:           duration,
+		ReplicaN:           replicaN,
+		ShardGroupDuration: shardGroupDuration,
+		FutureWriteLimit:   futureWriteLimit,
+		PastWriteLimit:     pastWriteLimit,
+	}
+	if rpu.Measurement != "" {
+		cmd.Measurement = proto.String(rpu.Measurement)
 	}
 
 	return c.retryUntilExec(internal.Command_UpdateRetentionPolicyCommand, internal.E_UpdateRetentionPolicyCommand_Command, cmd)
@@ -573,17 +671,23 @@ func (c *Client) CreateUser(name, password string, admin bool) (*UserInfo, error
 		return u, nil
 	}
 
+	policy := c.PasswordPolicy()
+	if err := policy.Check(password); err != nil {
+		return nil, err
+	}
+
 	// Hash the password before serializing it.
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), policy.HashCost)
 	if err != nil {
 		return nil, err
 	}
 
 	if err := c.retryUntilExec(internal.Command_CreateUserCommand, internal.E_CreateUserCommand_Command,
 		&internal.CreateUserCommand{
-			Name:  proto.String(name),
-			Hash:  proto.String(string(hash)),
-			Admin: proto.Bool(admin),
+			Name:            proto.String(name),
+			Hash:            proto.String(string(hash)),
+			Admin:           proto.Bool(admin),
+			PasswordChanged: proto.Int64(MarshalTime(time.Now().UTC())),
 		},
 	); err != nil {
 		return nil, err
@@ -592,20 +696,93 @@ func (c *Client) CreateUser(name, password string, admin bool) (*UserInfo, error
 }
 
 func (c *Client) UpdateUser(name, password string) error {
+	policy := c.PasswordPolicy()
+	if err := policy.Check(password); err != nil {
+		return err
+	}
+
+	if policy.PreventReuse {
+		c.mu.RLock()
+		u := c.cacheData.user(name)
+		c.mu.RUnlock()
+		if u != nil {
+			hashes := append([]string{u.Hash}, u.PreviousHashes...)
+			if n := policy.history() + 1; len(hashes) > n {
+				hashes = hashes[:n]
+			}
+			for _, h := range hashes {
+				if bcrypt.CompareHashAndPassword([]byte(h), []byte(password)) == nil {
+					return ErrPasswordReused
+				}
+			}
+		}
+	}
+
 	// Hash the password before serializing it.
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), policy.HashCost)
 	if err != nil {
 		return err
 	}
 
 	return c.retryUntilExec(internal.Command_UpdateUserCommand, internal.E_UpdateUserCommand_Command,
 		&internal.UpdateUserCommand{
-			Name: proto.String(name),
-			Hash: proto.String(string(hash)),
+			Name:            proto.String(name),
+			Hash:            proto.String(string(hash)),
+			PasswordChanged: proto.Int64(MarshalTime(time.Now().UTC())),
+			PasswordHistory: proto.Uint32(uint32(policy.history())),
 		},
 	)
 }
 
+// ChangePassword sets the password of a user who proves to know the current
+// one. Unlike Authenticate, it accepts an expired password, so users can
+// replace it themselves.
+func (c *Client) ChangePassword(name, password, newPassword string) error {
+	c.mu.RLock()
+	u := c.cacheData.user(name)
+	c.mu.RUnlock()
+	if u == nil {
+		return ErrAuthenticate
+	} else if err := bcrypt.CompareHashAndPassword([]byte(u.Hash), []byte(password)); err != nil {
+		return ErrAuthenticate
+	}
+	return c.UpdateUser(name, newPassword)
+}
+
+// rehashPassword replaces the stored hash of a user with a hash of password
+// at the configured cost, unless the password changed since hash was read.
+// Concurrent logins of a user start a single rehash.
+func (c *Client) rehashPassword(name, password, hash string, cost int) {
+	c.mu.Lock()
+	if _, ok := c.rehashing[name]; ok {
+		c.mu.Unlock()
+		return
+	}
+	c.rehashing[name] = struct{}{}
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.rehashing, name)
+		c.mu.Unlock()
+	}()
+
+	newHash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		c.logger.Info("Failed to rehash password", zap.String("user", name), zap.Error(err))
+		return
+	}
+
+	if err := c.retryUntilExec(internal.Command_UpdateUserCommand, internal.E_UpdateUserCommand_Command,
+		&internal.UpdateUserCommand{
+			Name:         proto.String(name),
+			Hash:         proto.String(string(newHash)),
+			PreviousHash: proto.String(hash),
+		},
+	); err != nil {
+		c.logger.Info("Failed to rehash password", zap.String("user", name), zap.Error(err))
+	}
+}
+
 func (c *Client) DropUser(name string) error {
 	return c.retryUntilExec(internal.Command_DropUserCommand, internal.E_DropUserCommand_Command,
 		&internal.DropUserCommand{
@@ -649,6 +826,230 @@ func (c *Client) UserPrivilege(username, database string) (*influxql.Privilege,
 	return p, nil
 }
 
+// Roles returns a list of all roles.
+func (c *Client) Roles() []RoleInfo {
+	roles := c.data().Roles
+	if roles == nil {
+		return []RoleInfo{}
+	}
+	return roles
+}
+
+// Role returns a role by name.
+func (c *Client) Role(name string) *RoleInfo {
+	return c.data().Role(name)
+}
+
+// CreateRole creates a role without privileges.
+func (c *Client) CreateRole(name string) error {
+	return c.retryUntilExec(internal.Command_CreateRoleCommand, internal.E_CreateRoleCommand_Command,
+		&internal.CreateRoleCommand{
+			Name: proto.String(name),
+		},
+	)
+}
+
+// DropRole removes a role and revokes it from its members.
+func (c *Client) DropRole(name string) error {
+	return c.retryUntilExec(internal.Command_DropRoleCommand, internal.E_DropRoleCommand_Command,
+		&internal.DropRoleCommand{
+			Name: proto.String(name),
+		},
+	)
+}
+
+// SetRolePrivilege sets the privilege of a role on a database.
+func (c *Client) SetRolePrivilege(role, database string, p influxql.Privilege) error {
+	return c.retryUntilExec(internal.Command_SetRolePrivilegeCommand, internal.E_SetRolePrivilegeCommand_Command,
+		&internal.SetRolePrivilegeCommand{
+			Role:      proto.String(role),
+			Database:  proto.String(database),
+			Privilege: proto.Int32(int32(p)),
+		},
+	)
+}
+
+// SetUserRole adds a user to a role, or removes it from the role if member
+// is false.
+func (c *Client) SetUserRole(username, role string, member bool) error {
+	return c.retryUntilExec(internal.Command_SetUserRoleCommand, internal.E_SetUserRoleCommand_Command,
+		&internal.SetUserRoleCommand{
+			Username: proto.String(username),
+			Role:     proto.String(role),
+			Member:   proto.Bool(member),
+		},
+	)
+}
+
+// Sessions returns the login sessions that have not expired.
+func (c *Client) Sessions() []SessionInfo {
+	now := time.Now()
+	var sessions []SessionInfo
+	for _, si := range c.data().Sessions {
+		if !si.Expired(now) {
+			sessions = append(sessions, si)
+		}
+	}
+	return sessions
+}
+
+// CreateSession starts a session for a local user lasting lifetime and
+// returns the token authenticating it.
+func (c *Client) CreateSession(username string, lifetime time.Duration) (string, *SessionInfo, error) {
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(crand.Reader, b); err != nil {
+		return "", nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now().UTC()
+	si := SessionInfo{
+		ID:        SessionID(token),
+		Username:  username,
+		Hash:      sessionHash(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(lifetime),
+	}
+	if err := c.retryUntilExec(internal.Command_CreateSessionCommand, internal.E_CreateSessionCommand_Command,
+		&internal.CreateSessionCommand{
+			Session: si.marshal(),
+		},
+	); err != nil {
+		return "", nil, err
+	}
+	return token, &si, nil
+}
+
+// DropSession ends a session, revoking its token.
+func (c *Client) DropSession(id string) error {
+	return c.retryUntilExec(internal.Command_DropSessionCommand, internal.E_DropSessionCommand_Command,
+		&internal.DropSessionCommand{
+			ID: proto.String(id),
+		},
+	)
+}
+
+// AuthenticateSession returns the user of the session authenticated by token.
+func (c *Client) AuthenticateSession(token string) (User, error) {
+	hash := sessionHash(token)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	si := c.cacheData.Session(SessionID(token))
+	if si == nil || subtle.ConstantTimeCompare([]byte(si.Hash), []byte(hash)) != 1 || si.Expired(time.Now()) {
+		return nil, ErrAuthenticate
+	}
+
+	u := c.cacheData.user(si.Username)
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+	return u, nil
+}
+
+// CreateEnrollmentToken issues a bootstrap token lasting lifetime that the
+// agent name redeems once for a certificate allowing writes to databases.
+func (c *Client) CreateEnrollmentToken(name string, databases []string, lifetime time.Duration) (string, *EnrollmentTokenInfo, error) {
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(crand.Reader, b); err != nil {
+		return "", nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now().UTC()
+	ti := EnrollmentTokenInfo{
+		Hash:      sessionHash(token),
+		Name:      name,
+		Databases: append([]string(nil), databases...),
+		CreatedAt: now,
+		ExpiresAt: now.Add(lifetime),
+	}
+	if err := c.retryUntilExec(internal.Command_CreateEnrollmentTokenCommand, internal.E_CreateEnrollmentTokenCommand_Command,
+		&internal.CreateEnrollmentTokenCommand{
+			Token: ti.marshal(),
+		},
+	); err != nil {
+		return "", nil, err
+	}
+	return token, &ti, nil
+}
+
+// EnrollmentToken returns the bootstrap token, or nil if it was never issued,
+// was redeemed or has expired.
+func (c *Client) EnrollmentToken(token string) *EnrollmentTokenInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ti := c.cacheData.EnrollmentToken(sessionHash(token))
+	if ti == nil || ti.Expired(time.Now()) {
+		return nil
+	}
+	other := *ti
+	return &other
+}
+
+// RedeemEnrollmentToken consumes the bootstrap token and records the
+// certificate with serial issued for it. It fails with
+// ErrEnrollmentTokenNotFound if the token was redeemed meanwhile.
+func (c *Client) RedeemEnrollmentToken(token, serial string, expiresAt time.Time) error {
+	return c.retryUntilExec(internal.Command_RedeemEnrollmentTokenCommand, internal.E_RedeemEnrollmentTokenCommand_Command,
+		&internal.RedeemEnrollmentTokenCommand{
+			Hash:      proto.String(sessionHash(token)),
+			Serial:    proto.String(serial),
+			CreatedAt: proto.Int64(MarshalTime(time.Now().UTC())),
+			ExpiresAt: proto.Int64(MarshalTime(expiresAt)),
+		},
+	)
+}
+
+// Agents returns the certificates issued to enrolled agents that have not
+// expired, including revoked ones.
+func (c *Client) Agents() []AgentInfo {
+	now := time.Now()
+	var agents []AgentInfo
+	for _, ai := range c.data().Agents {
+		if ai.ExpiresAt.After(now) {
+			agents = append(agents, ai)
+		}
+	}
+	return agents
+}
+
+// Agent returns the certificate issued to an enrolled agent by its serial
+// number, or nil if it was not issued by the cluster.
+func (c *Client) Agent(serial string) *AgentInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ai := c.cacheData.Agent(serial)
+	if ai == nil {
+		return nil
+	}
+	other := *ai
+	return &other
+}
+
+// RevokeAgent revokes the certificates issued to the agent name.
+func (c *Client) RevokeAgent(name string) error {
+	return c.retryUntilExec(internal.Command_RevokeAgentCommand, internal.E_RevokeAgentCommand_Command,
+		&internal.RevokeAgentCommand{
+			Name: proto.String(name),
+		},
+	)
+}
+
+// SessionID returns the ID of the session authenticated by token.
+func SessionID(token string) string {
+	return sessionHash(token)[:16]
+}
+
+// sessionHash returns the hex encoded SHA-256 hash of a session token.
+func sessionHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func (c *Client) AdminUserExists() bool {
 	for _, u := range c.data().Users {
 		if u.Admin {
@@ -668,6 +1069,8 @@ func (c *Client) Authenticate(username, password string) (User, error) {
 		return nil, ErrUserNotFound
 	}
 
+	policy := c.PasswordPolicy()
+
 	// Check the local auth cache first.
 	c.mu.RLock()
 	au, ok := c.authCache[username]
@@ -675,6 +1078,9 @@ func (c *Client) Authenticate(username, password string) (User, error) {
 	if ok {
 		// verify the password using the cached salt and hash
 		if bytes.Equal(c.hashWithSalt(au.salt, password), au.hash) {
+			if policy.expired(userInfo.PasswordChanged, time.Now()) {
+				return nil, ErrPasswordExpired
+			}
 			return userInfo, nil
 		}
 
@@ -686,6 +1092,16 @@ func (c *Client) Authenticate(username, password string) (User, error) {
 		return nil, ErrAuthenticate
 	}
 
+	if policy.expired(userInfo.PasswordChanged, time.Now()) {
+		return nil, ErrPasswordExpired
+	}
+
+	// Migrate hashes of another cost to the configured one now that the
+	// password is known.
+	if cost, err := bcrypt.Cost([]byte(userInfo.Hash)); err == nil && cost != policy.HashCost {
+		go c.rehashPassword(username, password, userInfo.Hash, policy.HashCost)
+	}
+
 	// generate a salt and hash of the password for the cache
 	salt, hashed, err := c.saltedHash(password)
 	if err != nil {
@@ -780,6 +1196,71 @@ func (c *Client) DropShard(id uint64) error {
 	return c.commit(data)
 }
 
+// AddShardOwner makes the node with nodeID an owner of the shard with id.
+func (c *Client) AddShardOwner(id, nodeID uint64) error {
+	return c.retryUntilExec(internal.Command_AddShardOwnerCommand, internal.E_AddShardOwnerCommand_Command,
+		&internal.AddShardOwnerCommand{
+			ID:     proto.Uint64(id),
+			NodeID: proto.Uint64(nodeID),
+		},
+	)
+}
+
+// RemoveShardOwner removes the node with nodeID from the owners of the
+// shard with id.
+func (c *Client) RemoveShardOwner(id, nodeID uint64) error {
+	return c.retryUntilExec(internal.Command_RemoveShardOwnerCommand, internal.E_RemoveShardOwnerCommand_Command,
+		&internal.RemoveShardOwnerCommand{
+			ID:     proto.Uint64(id),
+			NodeID: proto.Uint64(nodeID),
+		},
+	)
+}
+
+// SetDataNodeMode sets whether the data node with id is read-only, with the
+// reason reported to rejected writes, and whether it is in maintenance.
+func (c *Client) SetDataNodeMode(id uint64, readOnly bool, reason string, maintenance bool) error {
+	return c.retryUntilExec(internal.Command_SetDataNodeModeCommand, internal.E_SetDataNodeModeCommand_Command,
+		&internal.SetDataNodeModeCommand{
+			ID:             proto.Uint64(id),
+			ReadOnly:       proto.Bool(readOnly),
+			ReadOnlyReason: proto.String(reason),
+			Maintenance:    proto.Bool(maintenance),
+		},
+	)
+}
+
+// SetDataNodeLabels replaces the labels of the data node with id.
+func (c *Client) SetDataNodeLabels(id uint64, labels map[string]string) error {
+	return c.retryUntilExec(internal.Command_SetDataNodeLabelsCommand, internal.E_SetDataNodeLabelsCommand_Command,
+		&internal.SetDataNodeLabelsCommand{
+			ID:     proto.Uint64(id),
+			Labels: marshalLabels(labels),
+		},
+	)
+}
+
+// SetDatabasePlacement sets the placement of the shards created for the
+// database from now on.
+func (c *Client) SetDatabasePlacement(name string, p PlacementInfo) error {
+	return c.retryUntilExec(internal.Command_SetDatabasePlacementCommand, internal.E_SetDatabasePlacementCommand_Command,
+		&internal.SetDatabasePlacementCommand{
+			Name:      proto.String(name),
+			Placement: p.marshal(),
+		},
+	)
+}
+
+// SetDatabaseFrozen sets whether the database is frozen.
+func (c *Client) SetDatabaseFrozen(name string, frozen bool) error {
+	return c.retryUntilExec(internal.Command_SetDatabaseFrozenCommand, internal.E_SetDatabaseFrozenCommand_Command,
+		&internal.SetDatabaseFrozenCommand{
+			Name:   proto.String(name),
+			Frozen: proto.Bool(frozen),
+		},
+	)
+}
+
 // TruncateShardGroups truncates any shard group that could contain timestamps beyond t.
 func (c *Client) TruncateShardGroups(t time.Time) error {
 	c.mu.Lock()
@@ -790,6 +1271,19 @@ func (c *Client) TruncateShardGroups(t time.Time) error {
 	return c.commit(data)
 }
 
+// TruncateDatabaseShardGroups truncates any shard group of a database, or of
+// one of its retention policies, that could contain timestamps beyond t.
+func (c *Client) TruncateDatabaseShardGroups(database, policy string, t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+	if err := data.TruncateDatabaseShardGroups(database, policy, t); err != nil {
+		return err
+	}
+	return c.commit(data)
+}
+
 // PruneShardGroups remove deleted shard groups from the data store.
 func (c *Client) PruneShardGroups() error {
 	var changed bool
@@ -818,8 +1312,16 @@ func (c *Client) PruneShardGroups() error {
 
 // CreateShardGroup creates a shard group on a database and policy for a given timestamp.
 func (c *Client) CreateShardGroup(database, policy string, timestamp time.Time) (*ShardGroupInfo, error) {
-	if sg, _ := c.data().ShardGroupByTimestamp(database, policy, timestamp); sg != nil {
-		return sg, nil
+	return c.CreateMeasurementShardGroup(database, policy, "", timestamp)
+}
+
+// CreateMeasurementShardGroup creates the shard group on a database and
+// policy for the points of a measurement at a given timestamp.
+func (c *Client) CreateMeasurementShardGroup(database, policy, measurement string, timestamp time.Time) (*ShardGroupInfo, error) {
+	if rpi, _ := c.data().RetentionPolicy(database, policy); rpi != nil {
+		if sg := rpi.MeasurementShardGroupByTimestamp(measurement, timestamp); sg != nil {
+			return sg, nil
+		}
 	}
 
 	cmd := &internal.CreateShardGroupCommand{
@@ -827,6 +1329,9 @@ func (c *Client) CreateShardGroup(database, policy string, timestamp time.Time)
 		Policy:    proto.String(policy),
 		Timestamp: proto.Int64(timestamp.UnixNano()),
 	}
+	if measurement != "" {
+		cmd.Measurement = proto.String(measurement)
+	}
 
 	if err := c.retryUntilExec(internal.Command_CreateShardGroupCommand, internal.E_CreateShardGroupCommand_Command, cmd); err != nil {
 		return nil, err
@@ -839,7 +1344,7 @@ func (c *Client) CreateShardGroup(database, policy string, timestamp time.Time)
 		return nil, errors.New("retention policy deleted after shard group created")
 	}
 
-	return rpi.ShardGroupByTimestamp(timestamp), nil
+	return rpi.MeasurementShardGroupByTimestamp(measurement, timestamp), nil
 }
 
 // DeleteShardGroup removes a shard group from a database and retention policy by id.
@@ -853,6 +1358,31 @@ func (c *Client) DeleteShardGroup(database, policy string, id uint64) error {
 	return c.retryUntilExec(internal.Command_DeleteShardGroupCommand, internal.E_DeleteShardGroupCommand_Command, cmd)
 }
 
+// CreateShardGroupMerge starts merging the shard groups ids of a database and
+// policy into a single shard group.
+func (c *Client) CreateShardGroupMerge(database, policy string, ids []uint64) error {
+	cmd := &internal.CreateShardGroupMergeCommand{
+		Database:      proto.String(database),
+		Policy:        proto.String(policy),
+		ShardGroupIDs: ids,
+	}
+
+	return c.retryUntilExec(internal.Command_CreateShardGroupMergeCommand, internal.E_CreateShardGroupMergeCommand_Command, cmd)
+}
+
+// CompleteShardGroupMerge records that nodeID has merged its shards of the
+// merged shard group id.
+func (c *Client) CompleteShardGroupMerge(database, policy string, id, nodeID uint64) error {
+	cmd := &internal.CompleteShardGroupMergeCommand{
+		Database:     proto.String(database),
+		Policy:       proto.String(policy),
+		ShardGroupID: proto.Uint64(id),
+		NodeID:       proto.Uint64(nodeID),
+	}
+
+	return c.retryUntilExec(internal.Command_CompleteShardGroupMergeCommand, internal.E_CompleteShardGroupMergeCommand_Command, cmd)
+}
+
 // PrecreateShardGroups creates shard groups whose endtime is before the 'to' time passed in, but
 // is yet to expire before 'from'. This is to avoid the need for these shards to be created when data
 // for the corresponding time range arrives. Shard creation involves Raft consensus, and precreation
@@ -864,15 +1394,27 @@ func (c *Client) PrecreateShardGroups(from, to time.Time) error {
 				// No data was ever written to this group, or all groups have been deleted.
 				continue
 			}
-			g := rp.ShardGroups[len(rp.ShardGroups)-1] // Get the last group in time.
-			if !g.Deleted() && g.EndTime.Before(to) && g.EndTime.After(from) {
-				// Group is not deleted, will end before the future time, but is still yet to expire.
-				// This last check is important, so the system doesn't create shards groups wholly
-				// in the past.
+
+			// Get the last group in time of the policy and of each measurement
+			// with shard groups of its own.
+			last := make(map[string]ShardGroupInfo)
+			for _, g := range rp.ShardGroups {
+				if _, ok := rp.MeasurementShardGroupDurations[g.Measurement]; ok || g.Measurement == "" {
+					last[g.Measurement] = g
+				}
+			}
+
+			for _, g := range last {
+				if g.Deleted() || !g.EndTime.Before(to) || !g.EndTime.After(from) {
+					// Only groups that are not deleted, will end before the future time, but
+					// are still yet to expire get a successor. This last check is important,
+					// so the system doesn't create shards groups wholly in the past.
+					continue
+				}
 
 				// Create successive shard group.
 				nextShardGroupTime := g.EndTime.Add(1 * time.Nanosecond)
-				if newGroup, err := c.CreateShardGroup(di.Name, rp.Name, nextShardGroupTime); err != nil {
+				if newGroup, err := c.CreateMeasurementShardGroup(di.Name, rp.Name, g.Measurement, nextShardGroupTime); err != nil {
 					c.logger.Info("Failed to precreate successive shard group",
 						zap.Uint64("Group ID", g.ID),
 						zap.Error(err))
@@ -1025,6 +1567,108 @@ func (c *Client) DropContinuousQuery(database, name string) error {
 	)
 }
 
+// CreateMaterializedView adds a materialized view to a database.
+func (c *Client) CreateMaterializedView(database, name, query string) error {
+	return c.retryUntilExec(internal.Command_CreateMaterializedViewCommand, internal.E_CreateMaterializedViewCommand_Command,
+		&internal.CreateMaterializedViewCommand{
+			Database: proto.String(database),
+			Name:     proto.String(name),
+			Query:    proto.String(query),
+		},
+	)
+}
+
+// DropMaterializedView removes a materialized view from a database.
+func (c *Client) DropMaterializedView(database, name string) error {
+	return c.retryUntilExec(internal.Command_DropMaterializedViewCommand, internal.E_DropMaterializedViewCommand_Command,
+		&internal.DropMaterializedViewCommand{
+			Database: proto.String(database),
+			Name:     proto.String(name),
+		},
+	)
+}
+
+// DatabaseTemplates returns a list of all database templates.
+func (c *Client) DatabaseTemplates() []DatabaseTemplateInfo {
+	tmpls := c.data().DatabaseTemplates
+	if tmpls == nil {
+		return []DatabaseTemplateInfo{}
+	}
+	return tmpls
+}
+
+// DatabaseTemplate returns a database template by name.
+func (c *Client) DatabaseTemplate(name string) *DatabaseTemplateInfo {
+	return c.data().DatabaseTemplate(name)
+}
+
+// CreateDatabaseTemplate stores a database template.
+func (c *Client) CreateDatabaseTemplate(tmpl *DatabaseTemplateInfo) error {
+	return c.retryUntilExec(internal.Command_CreateDatabaseTemplateCommand, internal.E_CreateDatabaseTemplateCommand_Command,
+		&internal.CreateDatabaseTemplateCommand{
+			Template: tmpl.marshal(),
+		},
+	)
+}
+
+// DropDatabaseTemplate removes a database template.
+func (c *Client) DropDatabaseTemplate(name string) error {
+	return c.retryUntilExec(internal.Command_DropDatabaseTemplateCommand, internal.E_DropDatabaseTemplateCommand_Command,
+		&internal.DropDatabaseTemplateCommand{
+			Name: proto.String(name),
+		},
+	)
+}
+
+// InstantiateDatabaseTemplate creates a database from a template in a single
+// meta store command and returns the new database.
+func (c *Client) InstantiateDatabaseTemplate(template, database string) (*DatabaseInfo, error) {
+	err := c.retryUntilExec(internal.Command_InstantiateDatabaseTemplateCommand, internal.E_InstantiateDatabaseTemplateCommand_Command,
+		&internal.InstantiateDatabaseTemplateCommand{
+			Template: proto.String(template),
+			Database: proto.String(database),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return c.Database(database), nil
+}
+
+// QueryTemplates returns a list of all query templates.
+func (c *Client) QueryTemplates() []QueryTemplateInfo {
+	tmpls := c.data().QueryTemplates
+	if tmpls == nil {
+		return []QueryTemplateInfo{}
+	}
+	return tmpls
+}
+
+// QueryTemplate returns a query template by name.
+func (c *Client) QueryTemplate(name string) *QueryTemplateInfo {
+	return c.data().QueryTemplate(name)
+}
+
+// CreateQueryTemplate stores a query template, or replaces the query of an
+// existing one.
+func (c *Client) CreateQueryTemplate(name, query string) error {
+	return c.retryUntilExec(internal.Command_CreateQueryTemplateCommand, internal.E_CreateQueryTemplateCommand_Command,
+		&internal.CreateQueryTemplateCommand{
+			Name:  proto.String(name),
+			Query: proto.String(query),
+		},
+	)
+}
+
+// DropQueryTemplate removes a query template.
+func (c *Client) DropQueryTemplate(name string) error {
+	return c.retryUntilExec(internal.Command_DropQueryTemplateCommand, internal.E_DropQueryTemplateCommand_Command,
+		&internal.DropQueryTemplateCommand{
+			Name: proto.String(name),
+		},
+	)
+}
+
 func (c *Client) CreateSubscription(database, rp, name, mode string, destinations []string) error {
 	return c.retryUntilExec(internal.Command_CreateSubscriptionCommand, internal.E_CreateSubscriptionCommand_Command,
 		&internal.CreateSubscriptionCommand{
@@ -1037,6 +1681,21 @@ func (c *Client) CreateSubscription(database, rp, name, mode string, destination
 	)
 }
 
+// CreateSubscriptionWithFilter creates subscription si, which may filter and
+// transform the points it sends.
+func (c *Client) CreateSubscriptionWithFilter(database, rp string, si SubscriptionInfo) error {
+	return c.retryUntilExec(internal.Command_CreateSubscriptionCommand, internal.E_CreateSubscriptionCommand_Command,
+		&internal.CreateSubscriptionCommand{
+			Database:        proto.String(database),
+			RetentionPolicy: proto.String(rp),
+			Name:            proto.String(si.Name),
+			Mode:            proto.String(si.Mode),
+			Destinations:    si.Destinations,
+			Subscription:    si.marshal(),
+		},
+	)
+}
+
 func (c *Client) DropSubscription(database, rp, name string) error {
 	return c.retryUntilExec(internal.Command_DropSubscriptionCommand, internal.E_DropSubscriptionCommand_Command,
 		&internal.DropSubscriptionCommand{
@@ -1047,6 +1706,18 @@ func (c *Client) DropSubscription(database, rp, name string) error {
 	)
 }
 
+// SetSubscriptionPaused pauses or resumes a subscription on every data node.
+func (c *Client) SetSubscriptionPaused(database, rp, name string, paused bool) error {
+	return c.retryUntilExec(internal.Command_SetSubscriptionPausedCommand, internal.E_SetSubscriptionPausedCommand_Command,
+		&internal.SetSubscriptionPausedCommand{
+			Database:        proto.String(database),
+			RetentionPolicy: proto.String(rp),
+			Name:            proto.String(name),
+			Paused:          proto.Bool(paused),
+		},
+	)
+}
+
 func (c *Client) SetData(data *Data) error {
 	return c.retryUntilExec(internal.Command_SetDataCommand, internal.E_SetDataCommand_Command,
 		&internal.SetDataCommand{
@@ -1170,6 +1841,12 @@ func (c *Client) Load() error {
 	return nil
 }
 
+// Index returns the index of the cached meta data, which changes with every
+// change to it.
+func (c *Client) Index() uint64 {
+	return c.index()
+}
+
 func (c *Client) index() uint64 {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -1179,6 +1856,10 @@ func (c *Client) index() uint64 {
 // retryUntilExec will attempt the command on each of the metaservers until it either succeeds or
 // hits the max number of tries
 func (c *Client) retryUntilExec(typ internal.Command_Type, desc *proto.ExtensionDesc, value interface{}) error {
+	if c.local != nil {
+		return c.execLocal(typ, desc, value)
+	}
+
 	var err error
 	var index uint64
 	tries := 0
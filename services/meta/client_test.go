@@ -14,6 +14,7 @@ import (
 
 	"github.com/freetsdb/freetsdb/services/influxql"
 	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/toml"
 )
 
 func TestMetaClient_CreateDatabaseOnly(t *testing.T) {
@@ -814,6 +815,58 @@ func TestMetaClient_ContinuousQueries(t *testing.T) {
 	}
 }
 
+func TestMetaClient_ChangePassword(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	policy := meta.NewPasswordPolicyConfig()
+	policy.HashCost = 4
+	policy.PreventReuse = true
+	policy.ReuseHistory = 2
+	c.SetPasswordPolicy(policy)
+
+	if _, err := c.CreateUser("fred", "pass0", false); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"pass1", "pass2"} {
+		if err := c.UpdateUser("fred", p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The current password and the two previous ones cannot be set again.
+	for _, p := range []string{"pass2", "pass1", "pass0"} {
+		if err := c.UpdateUser("fred", p); err != meta.ErrPasswordReused {
+			t.Fatalf("%s: unexpected error: %v", p, err)
+		}
+	}
+	if err := c.UpdateUser("fred", "pass3"); err != nil {
+		t.Fatal(err)
+	} else if err := c.UpdateUser("fred", "pass0"); err != nil {
+		t.Fatalf("unexpected error setting a password older than the history: %v", err)
+	}
+
+	// Expired passwords are rejected by Authenticate, but can be changed.
+	policy.MaxAge = toml.Duration(time.Nanosecond)
+	c.SetPasswordPolicy(policy)
+	if _, err := c.Authenticate("fred", "pass0"); err != meta.ErrPasswordExpired {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := c.ChangePassword("fred", "wrong", "pass4"); err != meta.ErrAuthenticate {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := c.ChangePassword("fred", "pass0", "pass4"); err != nil {
+		t.Fatal(err)
+	}
+
+	policy.MaxAge = 0
+	c.SetPasswordPolicy(policy)
+	if _, err := c.Authenticate("fred", "pass4"); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestMetaClient_Subscriptions_Create(t *testing.T) {
 	t.Parallel()
 
@@ -916,6 +969,43 @@ func TestMetaClient_Subscriptions_Drop(t *testing.T) {
 	}
 }
 
+func TestMetaClient_Subscriptions_Pause(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := c.CreateSubscription("db0", "autogen", "sub0", "ALL", []string{"udp://example.com:9090"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := c.SetSubscriptionPaused("db0", "autogen", "foo", true)
+	if got, exp := err, meta.ErrSubscriptionNotFound; got == nil || got.Error() != exp.Error() {
+		t.Fatalf("got: %s, exp: %s", got, exp)
+	}
+
+	paused := func() bool {
+		rp, err := c.RetentionPolicy("db0", "autogen")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rp.Subscriptions[0].Paused
+	}
+	if err := c.SetSubscriptionPaused("db0", "autogen", "sub0", true); err != nil {
+		t.Fatal(err)
+	} else if !paused() {
+		t.Fatal("expected subscription to be paused")
+	}
+	if err := c.SetSubscriptionPaused("db0", "autogen", "sub0", false); err != nil {
+		t.Fatal(err)
+	} else if paused() {
+		t.Fatal("expected subscription to be resumed")
+	}
+}
+
 func TestMetaClient_Shards(t *testing.T) {
 	t.Parallel()
 
@@ -1111,7 +1201,7 @@ func TestMetaClient_PersistClusterIDAfterRestart(t *testing.T) {
 	cfg := newConfig()
 	defer os.RemoveAll(cfg.Dir)
 
-	c := meta.NewClient(cfg)
+	c := meta.NewClient(freetsdb.NewNode(cfg.Dir))
 	if err := c.Open(); err != nil {
 		t.Fatal(err)
 	}
@@ -1120,7 +1210,7 @@ func TestMetaClient_PersistClusterIDAfterRestart(t *testing.T) {
 		t.Fatal("cluster ID can't be zero")
 	}
 
-	c = meta.NewClient(cfg)
+	c = meta.NewClient(freetsdb.NewNode(cfg.Dir))
 	if err := c.Open(); err != nil {
 		t.Fatal(err)
 	}
@@ -1134,9 +1224,126 @@ func TestMetaClient_PersistClusterIDAfterRestart(t *testing.T) {
 	}
 }
 
+func TestMetaClient_Local_Databases(t *testing.T) {
+	t.Parallel()
+
+	d, c := newLocalClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if rp, err := c.RetentionPolicy("db0", "autogen"); err != nil {
+		t.Fatal(err)
+	} else if rp == nil {
+		t.Fatal("expected the default retention policy")
+	}
+
+	if _, err := c.CreateRetentionPolicy("db0", meta.NewRetentionPolicyInfo("rp0")); err != nil {
+		t.Fatal(err)
+	} else if rp, err := c.RetentionPolicy("db0", "rp0"); err != nil {
+		t.Fatal(err)
+	} else if rp == nil {
+		t.Fatal("retention policy not found")
+	}
+
+	if err := c.DropDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if dbs, err := c.Databases(); err != nil {
+		t.Fatal(err)
+	} else if len(dbs) != 0 {
+		t.Fatalf("unexpected databases: %v", dbs)
+	}
+}
+
+func TestMetaClient_Local_Users(t *testing.T) {
+	t.Parallel()
+
+	d, c := newLocalClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateUser("fred", "supersecure", true); err != nil {
+		t.Fatal(err)
+	} else if _, err := c.Authenticate("fred", "supersecure"); err != nil {
+		t.Fatal(err)
+	} else if _, err := c.Authenticate("fred", "wrong"); err == nil {
+		t.Fatal("expected authentication error")
+	} else if !c.AdminUserExists() {
+		t.Fatal("expected an admin user")
+	}
+
+	if err := c.DropUser("fred"); err != nil {
+		t.Fatal(err)
+	} else if _, err := c.User("fred"); err != meta.ErrUserNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestMetaClient_Local_Reopen(t *testing.T) {
+	t.Parallel()
+
+	d, c := newLocalClient()
+	defer os.RemoveAll(d)
+
+	if _, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if _, err := c.CreateShardGroup("db0", "autogen", time.Unix(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The data node of the client owns the shards.
+	nodeID := c.NodeID()
+	if nodeID == 0 {
+		t.Fatal("expected the data node of the client")
+	}
+	sgs, err := c.ShardGroupsByTimeRange("db0", "autogen", time.Unix(0, 0), time.Unix(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(sgs) != 1 || len(sgs[0].Shards) != 1 {
+		t.Fatalf("unexpected shard groups: %v", sgs)
+	} else if owners := sgs[0].Shards[0].Owners; len(owners) != 1 || owners[0].NodeID != nodeID {
+		t.Fatalf("unexpected shard owners: %v", owners)
+	}
+
+	// Commands that fail are not applied.
+	if _, err := c.CreateRetentionPolicy("db1", meta.NewRetentionPolicyInfo("rp0")); err == nil {
+		t.Fatal("expected error")
+	} else if c.Database("db1") != nil {
+		t.Fatal("unexpected database")
+	}
+	c.Close()
+
+	// The data is loaded from disk when the client is reopened.
+	cfg := meta.NewConfig()
+	cfg.Dir = d
+	c = meta.NewLocalClient(cfg)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if c.Database("db0") == nil {
+		t.Fatal("database not found")
+	} else if c.NodeID() != nodeID {
+		t.Fatalf("unexpected node id: %d", c.NodeID())
+	} else if nodes, _ := c.DataNodes(); len(nodes) != 1 {
+		t.Fatalf("unexpected data nodes: %v", nodes)
+	}
+}
+
 func newClient() (string, *meta.Client) {
 	cfg := newConfig()
-	c := meta.NewClient(cfg)
+	c := meta.NewClient(freetsdb.NewNode(cfg.Dir))
+	if err := c.Open(); err != nil {
+		panic(err)
+	}
+	return cfg.Dir, c
+}
+
+func newLocalClient() (string, *meta.Client) {
+	cfg := newConfig()
+	c := meta.NewLocalClient(cfg)
 	if err := c.Open(); err != nil {
 		panic(err)
 	}
@@ -2,10 +2,12 @@ package meta
 
 import (
 	"errors"
+	"fmt"
 	"net"
 	"time"
 
 	"github.com/freetsdb/freetsdb/monitor/diagnostics"
+	"github.com/freetsdb/freetsdb/pkg/netutil"
 	"github.com/freetsdb/freetsdb/toml"
 )
 
@@ -95,6 +97,12 @@ func (c *Config) Validate() error {
 	if c.Dir == "" {
 		return errors.New("Meta.Dir must be specified")
 	}
+	if err := netutil.ValidateBindAddress(c.BindAddress); err != nil {
+		return fmt.Errorf("Meta.BindAddress: %v", err)
+	}
+	if err := netutil.ValidateBindAddress(c.HTTPBindAddress); err != nil {
+		return fmt.Errorf("Meta.HTTPBindAddress: %v", err)
+	}
 	return nil
 }
 
@@ -119,7 +127,7 @@ func DefaultHost(hostname, addr string) (string, error) {
 		return "", err
 	}
 
-	if host == "" || host == "0.0.0.0" || host == "::" {
+	if netutil.IsUnspecified(host) {
 		return net.JoinHostPort(hostname, port), nil
 	}
 	return addr, nil
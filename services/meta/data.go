@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net"
 	"net/url"
+	"path"
 	"sort"
 	"strings"
 	"time"
@@ -45,6 +46,27 @@ type Data struct {
 	Databases []DatabaseInfo
 	Users     []UserInfo
 
+	// DatabaseTemplates describe databases that can be provisioned with
+	// InstantiateDatabaseTemplate.
+	DatabaseTemplates []DatabaseTemplateInfo
+
+	// Roles are named sets of privileges shared by their member users.
+	Roles []RoleInfo
+
+	// Sessions are the login sessions of local users.
+	Sessions []SessionInfo
+
+	// QueryTemplates are named SELECT statements that clients execute with
+	// parameters.
+	QueryTemplates []QueryTemplateInfo
+
+	// EnrollmentTokens are the bootstrap tokens not redeemed by an agent yet.
+	EnrollmentTokens []EnrollmentTokenInfo
+
+	// Agents are the client certificates issued to enrolled agents, kept
+	// until they expire so revoked certificates are refused.
+	Agents []AgentInfo
+
 	// adminUserExists provides a constant time mechanism for determining
 	// if there is at least one admin user.
 	adminUserExists bool
@@ -64,6 +86,38 @@ func (data *Data) DataNode(id uint64) *NodeInfo {
 	return nil
 }
 
+// SetDataNodeMode sets the operational mode of a data node. A read-only node
+// rejects writes with reason, and a node in maintenance is not queried.
+func (data *Data) SetDataNodeMode(id uint64, readOnly bool, reason string, maintenance bool) error {
+	ni := data.DataNode(id)
+	if ni == nil {
+		return ErrNodeNotFound
+	}
+	if !readOnly {
+		reason = ""
+	}
+	ni.ReadOnly = readOnly
+	ni.ReadOnlyReason = reason
+	ni.Maintenance = maintenance
+	return nil
+}
+
+// SetDataNodeLabels replaces the labels of a data node, such as its zone and
+// rack, used to place the replicas of shards in different failure domains.
+func (data *Data) SetDataNodeLabels(id uint64, labels map[string]string) error {
+	ni := data.DataNode(id)
+	if ni == nil {
+		return ErrNodeNotFound
+	}
+	for k := range labels {
+		if k == "" {
+			return ErrNodeLabelKeyRequired
+		}
+	}
+	ni.Labels = cloneLabels(labels)
+	return nil
+}
+
 // CreateDataNode adds a node to the metadata.
 func (data *Data) CreateDataNode(host, tcpHost string) error {
 	// Ensure a node with the same host doesn't already exist.
@@ -361,6 +415,45 @@ func (data *Data) CreateDatabase(name string) error {
 	return nil
 }
 
+// SetShardKey sets the tag keys whose values select the shard of the points
+// written to a database. The shard key of a database cannot be changed once
+// set, as points already written would be in other shards.
+func (data *Data) SetShardKey(name string, keys []string) error {
+	di := data.Database(name)
+	if di == nil {
+		return freetsdb.ErrDatabaseNotFound(name)
+	} else if len(di.ShardKey) > 0 && !equalStrings(di.ShardKey, keys) {
+		return ErrShardKeyConflict
+	}
+	di.ShardKey = keys
+	return nil
+}
+
+// SetDatabaseFrozen sets whether a database is frozen. A frozen database
+// accepts no writes and its data is not removed by retention enforcement.
+func (data *Data) SetDatabaseFrozen(name string, frozen bool) error {
+	di := data.Database(name)
+	if di == nil {
+		return freetsdb.ErrDatabaseNotFound(name)
+	}
+	di.Frozen = frozen
+	return nil
+}
+
+// equalStrings returns true if a and b hold the same strings in the same
+// order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // DropDatabase removes a database by name. It does not return an error
 // if the database cannot be found.
 func (data *Data) DropDatabase(name string) error {
@@ -368,10 +461,14 @@ func (data *Data) DropDatabase(name string) error {
 		if data.Databases[i].Name == name {
 			data.Databases = append(data.Databases[:i], data.Databases[i+1:]...)
 
-			// Remove all user privileges associated with this database.
+			// Remove all user and role privileges associated with this database.
 			for i := range data.Users {
 				delete(data.Users[i].Privileges, name)
 			}
+			for i := range data.Roles {
+				delete(data.Roles[i].Privileges, name)
+			}
+			data.updateRolePrivileges()
 			break
 		}
 	}
@@ -420,7 +517,8 @@ func (data *Data) CreateRetentionPolicy(database string, rpi *RetentionPolicyInf
 		return freetsdb.ErrDatabaseNotFound(database)
 	} else if rp := di.RetentionPolicy(rpi.Name); rp != nil {
 		// RP with that name already exists. Make sure they're the same.
-		if rp.ReplicaN != rpi.ReplicaN || rp.Duration != rpi.Duration || rp.ShardGroupDuration != rpi.ShardGroupDuration {
+		if rp.ReplicaN != rpi.ReplicaN || rp.Duration != rpi.Duration || rp.ShardGroupDuration != rpi.ShardGroupDuration ||
+			rp.FutureWriteLimit != rpi.FutureWriteLimit || rp.PastWriteLimit != rpi.PastWriteLimit {
 			return ErrRetentionPolicyExists
 		}
 		// if they want to make it default, and it's not the default, it's not an identical command so it's an error
@@ -467,6 +565,13 @@ type RetentionPolicyUpdate struct {
 	Duration           *time.Duration
 	ReplicaN           *int
 	ShardGroupDuration *time.Duration
+	FutureWriteLimit   *time.Duration
+	PastWriteLimit     *time.Duration
+
+	// Measurement, if set, restricts the update to the shard group duration
+	// of the measurement. A ShardGroupDuration of 0 removes it, so that the
+	// measurement uses the shard group duration of the policy again.
+	Measurement string
 }
 
 // SetName sets the RetentionPolicyUpdate.Name.
@@ -481,6 +586,12 @@ func (rpu *RetentionPolicyUpdate) SetReplicaN(v int) { rpu.ReplicaN = &v }
 // SetShardGroupDuration sets the RetentionPolicyUpdate.ShardGroupDuration.
 func (rpu *RetentionPolicyUpdate) SetShardGroupDuration(v time.Duration) { rpu.ShardGroupDuration = &v }
 
+// SetFutureWriteLimit sets the RetentionPolicyUpdate.FutureWriteLimit.
+func (rpu *RetentionPolicyUpdate) SetFutureWriteLimit(v time.Duration) { rpu.FutureWriteLimit = &v }
+
+// SetPastWriteLimit sets the RetentionPolicyUpdate.PastWriteLimit.
+func (rpu *RetentionPolicyUpdate) SetPastWriteLimit(v time.Duration) { rpu.PastWriteLimit = &v }
+
 // UpdateRetentionPolicy updates an existing retention policy.
 func (data *Data) UpdateRetentionPolicy(database, name string, rpu *RetentionPolicyUpdate, makeDefault bool) error {
 	// Find database.
@@ -495,6 +606,10 @@ func (data *Data) UpdateRetentionPolicy(database, name string, rpu *RetentionPol
 		return freetsdb.ErrRetentionPolicyNotFound(name)
 	}
 
+	if rpu.Measurement != "" {
+		return rpi.setMeasurementShardGroupDuration(rpu)
+	}
+
 	// Ensure new policy doesn't match an existing policy.
 	if rpu.Name != nil && *rpu.Name != name && di.RetentionPolicy(*rpu.Name) != nil {
 		return ErrRetentionPolicyNameExists
@@ -527,6 +642,12 @@ func (data *Data) UpdateRetentionPolicy(database, name string, rpu *RetentionPol
 	if rpu.ShardGroupDuration != nil {
 		rpi.ShardGroupDuration = normalisedShardDuration(*rpu.ShardGroupDuration, rpi.Duration)
 	}
+	if rpu.FutureWriteLimit != nil {
+		rpi.FutureWriteLimit = *rpu.FutureWriteLimit
+	}
+	if rpu.PastWriteLimit != nil {
+		rpi.PastWriteLimit = *rpu.PastWriteLimit
+	}
 
 	if di.DefaultRetentionPolicy != rpi.Name && makeDefault {
 		di.DefaultRetentionPolicy = rpi.Name
@@ -535,6 +656,30 @@ func (data *Data) UpdateRetentionPolicy(database, name string, rpu *RetentionPol
 	return nil
 }
 
+// setMeasurementShardGroupDuration applies an update of the shard group
+// duration of a measurement.
+func (rpi *RetentionPolicyInfo) setMeasurementShardGroupDuration(rpu *RetentionPolicyUpdate) error {
+	if rpu.ShardGroupDuration == nil || rpu.Name != nil || rpu.Duration != nil || rpu.ReplicaN != nil ||
+		rpu.FutureWriteLimit != nil || rpu.PastWriteLimit != nil {
+		return ErrMeasurementShardGroupDurationOnly
+	}
+
+	if *rpu.ShardGroupDuration == 0 {
+		delete(rpi.MeasurementShardGroupDurations, rpu.Measurement)
+		return nil
+	}
+
+	d := normalisedShardDuration(*rpu.ShardGroupDuration, rpi.Duration)
+	if rpi.Duration > 0 && rpi.Duration < d {
+		return ErrIncompatibleDurations
+	}
+	if rpi.MeasurementShardGroupDurations == nil {
+		rpi.MeasurementShardGroupDurations = make(map[string]time.Duration)
+	}
+	rpi.MeasurementShardGroupDurations[rpu.Measurement] = d
+	return nil
+}
+
 // SetDefaultRetentionPolicy sets the default retention policy for a database.
 func (data *Data) SetDefaultRetentionPolicy(database, name string) error {
 	// Find database and verify policy exists.
@@ -583,6 +728,63 @@ func (data *Data) DropShard(id uint64) {
 	}
 }
 
+// AddShardOwner makes the node with nodeID an owner of the shard with id.
+// Adding an existing owner is not an error.
+func (data *Data) AddShardOwner(id, nodeID uint64) error {
+	if data.DataNode(nodeID) == nil {
+		return ErrNodeNotFound
+	}
+
+	for i := range data.Databases {
+		for j := range data.Databases[i].RetentionPolicies {
+			rpi := &data.Databases[i].RetentionPolicies[j]
+			for k := range rpi.ShardGroups {
+				sgi := &rpi.ShardGroups[k]
+				for l := range sgi.Shards {
+					si := &sgi.Shards[l]
+					if si.ID != id {
+						continue
+					}
+					if !si.OwnedBy(nodeID) {
+						si.Owners = append(si.Owners, ShardOwner{NodeID: nodeID})
+					}
+					return nil
+				}
+			}
+		}
+	}
+	return ErrShardNotFound
+}
+
+// RemoveShardOwner removes the node with nodeID from the owners of the
+// shard with id. The shard and its group are kept, even if no owner is left.
+// Removing a node that is not an owner is not an error.
+func (data *Data) RemoveShardOwner(id, nodeID uint64) error {
+	for i := range data.Databases {
+		for j := range data.Databases[i].RetentionPolicies {
+			rpi := &data.Databases[i].RetentionPolicies[j]
+			for k := range rpi.ShardGroups {
+				sgi := &rpi.ShardGroups[k]
+				for l := range sgi.Shards {
+					si := &sgi.Shards[l]
+					if si.ID != id {
+						continue
+					}
+					owners := make([]ShardOwner, 0, len(si.Owners))
+					for _, o := range si.Owners {
+						if o.NodeID != nodeID {
+							owners = append(owners, o)
+						}
+					}
+					si.Owners = owners
+					return nil
+				}
+			}
+		}
+	}
+	return ErrShardNotFound
+}
+
 // ShardGroups returns a list of all shard groups on a database and retention policy.
 func (data *Data) ShardGroups(database, policy string) ([]ShardGroupInfo, error) {
 	// Find retention policy.
@@ -637,6 +839,14 @@ func (data *Data) ShardGroupByTimestamp(database, policy string, timestamp time.
 
 // CreateShardGroup creates a shard group on a database and policy for a given timestamp.
 func (data *Data) CreateShardGroup(database, policy string, timestamp time.Time) error {
+	return data.CreateMeasurementShardGroup(database, policy, "", timestamp)
+}
+
+// CreateMeasurementShardGroup creates the shard group on a database and policy
+// for the points of a measurement at a given timestamp. A measurement with a
+// shard group duration of its own gets shard groups of its own, while the
+// other measurements share the shard groups of the policy.
+func (data *Data) CreateMeasurementShardGroup(database, policy, measurement string, timestamp time.Time) error {
 	// Ensure there are nodes in the metadata.
 	if len(data.DataNodes) == 0 {
 		return nil
@@ -651,29 +861,41 @@ func (data *Data) CreateShardGroup(database, policy string, timestamp time.Time)
 	}
 
 	// Verify that shard group doesn't already exist for this timestamp.
-	if rpi.ShardGroupByTimestamp(timestamp) != nil {
+	if rpi.MeasurementShardGroupByTimestamp(measurement, timestamp) != nil {
 		return nil
 	}
+	measurement, duration := rpi.measurementShardGroupDuration(measurement)
+
+	// Only the nodes matching the placement of the database own its shards.
+	var placement PlacementInfo
+	if di := data.Database(database); di != nil {
+		placement = di.Placement
+	}
+	nodes := placement.Nodes(data.DataNodes)
+	if len(nodes) == 0 {
+		return ErrNoNodesMatchPlacement
+	}
 
 	// Require at least one replica but no more replicas than nodes.
 	replicaN := rpi.ReplicaN
 	if replicaN == 0 {
 		replicaN = 1
-	} else if replicaN > len(data.DataNodes) {
-		replicaN = len(data.DataNodes)
+	} else if replicaN > len(nodes) {
+		replicaN = len(nodes)
 	}
 
 	// Determine shard count by node count divided by replication factor.
 	// This will ensure nodes will get distributed across nodes evenly and
 	// replicated the correct number of times.
-	shardN := len(data.DataNodes) / replicaN
+	shardN := len(nodes) / replicaN
 
 	// Create the shard group.
 	data.MaxShardGroupID++
 	sgi := ShardGroupInfo{}
 	sgi.ID = data.MaxShardGroupID
-	sgi.StartTime = timestamp.Truncate(rpi.ShardGroupDuration).UTC()
-	sgi.EndTime = sgi.StartTime.Add(rpi.ShardGroupDuration).UTC()
+	sgi.StartTime = timestamp.Truncate(duration).UTC()
+	sgi.EndTime = sgi.StartTime.Add(duration).UTC()
+	sgi.Measurement = measurement
 
 	// Create shards on the group.
 	sgi.Shards = make([]ShardInfo, shardN)
@@ -682,15 +904,18 @@ func (data *Data) CreateShardGroup(database, policy string, timestamp time.Time)
 		sgi.Shards[i] = ShardInfo{ID: data.MaxShardID}
 	}
 
-	// Assign data nodes to shards via round robin.
+	// Assign data nodes to shards via round robin, spreading the replicas
+	// of a shard across failure domains.
 	// Start from a repeatably "random" place in the node list.
-	nodeIndex := int(data.Index % uint64(len(data.DataNodes)))
+	nodeIndex := int(data.Index % uint64(len(nodes)))
+	load := make(map[uint64]int, len(nodes))
 	for i := range sgi.Shards {
 		si := &sgi.Shards[i]
 		for j := 0; j < replicaN; j++ {
-			nodeID := data.DataNodes[nodeIndex%len(data.DataNodes)].ID
-			si.Owners = append(si.Owners, ShardOwner{NodeID: nodeID})
-			nodeIndex++
+			n := placement.next(nodes, nodeIndex, si.Owners, load)
+			si.Owners = append(si.Owners, ShardOwner{NodeID: nodes[n].ID})
+			load[nodes[n].ID]++
+			nodeIndex = n + 1
 		}
 	}
 
@@ -717,6 +942,13 @@ func (data *Data) DeleteShardGroup(database, policy string, id uint64) error {
 	for i := range rpi.ShardGroups {
 		if rpi.ShardGroups[i].ID == id {
 			rpi.ShardGroups[i].DeletedAt = time.Now().UTC()
+
+			// A pending merge of the group can no longer complete.
+			for j := len(rpi.ShardGroupMerges) - 1; j >= 0; j-- {
+				if rpi.ShardGroupMerges[j].hasSource(id) {
+					rpi.dropShardGroupMerge(j)
+				}
+			}
 			return nil
 		}
 	}
@@ -724,6 +956,105 @@ func (data *Data) DeleteShardGroup(database, policy string, id uint64) error {
 	return ErrShardGroupNotFound
 }
 
+// CreateShardGroupMerge starts merging the shard groups ids of a database and
+// policy into a new shard group covering all their time ranges. The new group
+// replaces them once every owner of its shards has copied the data of the
+// groups with CompleteShardGroupMerge.
+func (data *Data) CreateShardGroupMerge(database, policy string, ids []uint64) error {
+	rpi, err := data.RetentionPolicy(database, policy)
+	if err != nil {
+		return err
+	} else if rpi == nil {
+		return freetsdb.ErrRetentionPolicyNotFound(policy)
+	}
+
+	if err := rpi.ValidateShardGroupMerge(ids); err != nil {
+		return err
+	}
+
+	m := ShardGroupMergeInfo{Sources: append([]uint64(nil), ids...)}
+	for i, id := range ids {
+		sgi := rpi.shardGroup(id)
+		if i == 0 || sgi.StartTime.Before(m.Group.StartTime) {
+			m.Group.StartTime = sgi.StartTime
+		}
+		if i == 0 || sgi.EndTime.After(m.Group.EndTime) {
+			m.Group.EndTime = sgi.EndTime
+		}
+	}
+
+	// The shards of the new group keep the owners of the shards they merge,
+	// so that every owner can merge its shards locally.
+	data.MaxShardGroupID++
+	m.Group.ID = data.MaxShardGroupID
+	first := rpi.shardGroup(ids[0])
+	m.Group.Measurement = first.Measurement
+	m.Group.Shards = make([]ShardInfo, len(first.Shards))
+	for i, si := range first.Shards {
+		data.MaxShardID++
+		m.Group.Shards[i] = ShardInfo{ID: data.MaxShardID, Owners: si.clone().Owners}
+	}
+
+	rpi.ShardGroupMerges = append(rpi.ShardGroupMerges, m)
+	return nil
+}
+
+// CompleteShardGroupMerge records that nodeID has merged its shards of the
+// shard group id. Once every owner has, the merged group replaces the groups
+// it merges, which are marked as deleted.
+func (data *Data) CompleteShardGroupMerge(database, policy string, id, nodeID uint64) error {
+	rpi, err := data.RetentionPolicy(database, policy)
+	if err != nil {
+		return err
+	} else if rpi == nil {
+		return freetsdb.ErrRetentionPolicyNotFound(policy)
+	}
+
+	idx := -1
+	for i := range rpi.ShardGroupMerges {
+		if rpi.ShardGroupMerges[i].Group.ID == id {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return ErrShardGroupMergeNotFound
+	}
+
+	m := &rpi.ShardGroupMerges[idx]
+	if !m.completedBy(nodeID) {
+		m.Completed = append(m.Completed, nodeID)
+	}
+	for _, si := range m.Group.Shards {
+		for _, so := range si.Owners {
+			if !m.completedBy(so.NodeID) {
+				return nil
+			}
+		}
+	}
+
+	// Shard groups may have been created in the time range of the merge
+	// since it started, in which case the merged group would overlap them.
+	for _, sgi := range rpi.ShardGroups {
+		if !sgi.Deleted() && !m.hasSource(sgi.ID) && sgi.Measurement == m.Group.Measurement && sgi.StartTime.Before(m.Group.EndTime) && sgi.EndTime.After(m.Group.StartTime) {
+			rpi.dropShardGroupMerge(idx)
+			return nil
+		}
+	}
+
+	now := time.Now().UTC()
+	for i := range rpi.ShardGroups {
+		if m.hasSource(rpi.ShardGroups[i].ID) {
+			rpi.ShardGroups[i].DeletedAt = now
+		}
+	}
+	rpi.ShardGroups = append(rpi.ShardGroups, m.Group)
+	rpi.ShardGroupMerges = append(rpi.ShardGroupMerges[:idx], rpi.ShardGroupMerges[idx+1:]...)
+	sort.Sort(ShardGroupInfos(rpi.ShardGroups))
+
+	return nil
+}
+
 // CreateContinuousQuery adds a named continuous query to a database.
 func (data *Data) CreateContinuousQuery(database, name, query string) error {
 	di := data.Database(database)
@@ -769,6 +1100,61 @@ func (data *Data) DropContinuousQuery(database, name string) error {
 	return nil
 }
 
+// CreateMaterializedView adds a named materialized view to a database.
+func (data *Data) CreateMaterializedView(database, name, query string) error {
+	di := data.Database(database)
+	if di == nil {
+		return freetsdb.ErrDatabaseNotFound(database)
+	} else if !ValidName(name) {
+		return ErrInvalidName
+	}
+
+	if mvi := di.MaterializedView(name); mvi != nil {
+		if mvi.Query == query {
+			return nil
+		}
+		return ErrMaterializedViewExists
+	}
+
+	di.MaterializedViews = append(di.MaterializedViews, MaterializedViewInfo{
+		Name:  name,
+		Query: query,
+	})
+	return nil
+}
+
+// DropMaterializedView removes a materialized view.
+func (data *Data) DropMaterializedView(database, name string) error {
+	di := data.Database(database)
+	if di == nil {
+		return freetsdb.ErrDatabaseNotFound(database)
+	}
+
+	for i := range di.MaterializedViews {
+		if di.MaterializedViews[i].Name == name {
+			di.MaterializedViews = append(di.MaterializedViews[:i], di.MaterializedViews[i+1:]...)
+			return nil
+		}
+	}
+	return ErrMaterializedViewNotFound
+}
+
+// SetDatabasePlacement sets the placement of the shards of a database. It
+// applies to the shard groups created afterwards.
+func (data *Data) SetDatabasePlacement(name string, p PlacementInfo) error {
+	di := data.Database(name)
+	if di == nil {
+		return freetsdb.ErrDatabaseNotFound(name)
+	}
+	for k := range p.Require {
+		if k == "" {
+			return ErrNodeLabelKeyRequired
+		}
+	}
+	di.Placement = p.clone()
+	return nil
+}
+
 // validateURL returns an error if the URL does not have a port or uses a scheme other than UDP or HTTP.
 func validateURL(input string) error {
 	u, err := url.Parse(input)
@@ -790,11 +1176,24 @@ func validateURL(input string) error {
 
 // CreateSubscription adds a named subscription to a database and retention policy.
 func (data *Data) CreateSubscription(database, rp, name, mode string, destinations []string) error {
-	for _, d := range destinations {
+	return data.CreateSubscriptionWithFilter(database, rp, SubscriptionInfo{
+		Name:         name,
+		Mode:         mode,
+		Destinations: destinations,
+	})
+}
+
+// CreateSubscriptionWithFilter adds subscription si to a database and
+// retention policy. The filter and transformation of si are validated.
+func (data *Data) CreateSubscriptionWithFilter(database, rp string, si SubscriptionInfo) error {
+	for _, d := range si.Destinations {
 		if err := validateURL(d); err != nil {
 			return err
 		}
 	}
+	if err := si.validate(); err != nil {
+		return err
+	}
 
 	rpi, err := data.RetentionPolicy(database, rp)
 	if err != nil {
@@ -805,17 +1204,13 @@ func (data *Data) CreateSubscription(database, rp, name, mode string, destinatio
 
 	// Ensure the name doesn't already exist.
 	for i := range rpi.Subscriptions {
-		if rpi.Subscriptions[i].Name == name {
+		if rpi.Subscriptions[i].Name == si.Name {
 			return ErrSubscriptionExists
 		}
 	}
 
 	// Append new query.
-	rpi.Subscriptions = append(rpi.Subscriptions, SubscriptionInfo{
-		Name:         name,
-		Mode:         mode,
-		Destinations: destinations,
-	})
+	rpi.Subscriptions = append(rpi.Subscriptions, si)
 
 	return nil
 }
@@ -838,6 +1233,24 @@ func (data *Data) DropSubscription(database, rp, name string) error {
 	return ErrSubscriptionNotFound
 }
 
+// SetSubscriptionPaused pauses or resumes a subscription.
+func (data *Data) SetSubscriptionPaused(database, rp, name string, paused bool) error {
+	rpi, err := data.RetentionPolicy(database, rp)
+	if err != nil {
+		return err
+	} else if rpi == nil {
+		return freetsdb.ErrRetentionPolicyNotFound(rp)
+	}
+
+	for i := range rpi.Subscriptions {
+		if rpi.Subscriptions[i].Name == name {
+			rpi.Subscriptions[i].Paused = paused
+			return nil
+		}
+	}
+	return ErrSubscriptionNotFound
+}
+
 func (data *Data) user(username string) *UserInfo {
 	for i := range data.Users {
 		if data.Users[i].Name == username {
@@ -887,6 +1300,7 @@ func (data *Data) DropUser(name string) error {
 		if data.Users[i].Name == name {
 			wasAdmin := data.Users[i].Admin
 			data.Users = append(data.Users[:i], data.Users[i+1:]...)
+			data.DropUserSessions(name)
 
 			// Maybe we dropped the only admin user?
 			if wasAdmin {
@@ -988,49 +1402,595 @@ func (data *Data) UserPrivilege(name, database string) (*influxql.Privilege, err
 	return influxql.NewPrivilege(influxql.NoPrivileges), nil
 }
 
-// Clone returns a copy of data with a new version.
-func (data *Data) Clone() *Data {
-	other := *data
-
-	other.Databases = data.CloneDatabases()
-	other.Users = data.CloneUsers()
+// Role returns a role by name.
+func (data *Data) Role(name string) *RoleInfo {
+	for i := range data.Roles {
+		if data.Roles[i].Name == name {
+			return &data.Roles[i]
+		}
+	}
+	return nil
+}
 
-	return &other
+// CloneRoles returns a copy of the role infos.
+func (data *Data) CloneRoles() []RoleInfo {
+	if data.Roles == nil {
+		return nil
+	}
+	roles := make([]RoleInfo, len(data.Roles))
+	for i := range data.Roles {
+		roles[i] = data.Roles[i].clone()
+	}
+	return roles
 }
 
-// marshal serializes to a protobuf representation.
-func (data *Data) marshal() *internal.Data {
-	pb := &internal.Data{
-		Term:      proto.Uint64(data.Term),
-		Index:     proto.Uint64(data.Index),
-		ClusterID: proto.Uint64(data.ClusterID),
+// CreateRole creates a new role without privileges.
+func (data *Data) CreateRole(name string) error {
+	if name == "" {
+		return ErrRoleNameRequired
+	} else if data.Role(name) != nil {
+		return ErrRoleExists
+	}
 
-		MaxNodeID:       proto.Uint64(data.MaxNodeID),
-		MaxShardGroupID: proto.Uint64(data.MaxShardGroupID),
-		MaxShardID:      proto.Uint64(data.MaxShardID),
+	data.Roles = append(data.Roles, RoleInfo{Name: name})
+	return nil
+}
+
+// DropRole removes a role by name and revokes it from its members.
+func (data *Data) DropRole(name string) error {
+	for i := range data.Roles {
+		if data.Roles[i].Name == name {
+			data.Roles = append(data.Roles[:i], data.Roles[i+1:]...)
+			for j := range data.Users {
+				data.Users[j].removeRole(name)
+			}
+			data.updateRolePrivileges()
+			return nil
+		}
 	}
+	return ErrRoleNotFound
+}
 
-	pb.DataNodes = make([]*internal.NodeInfo, len(data.DataNodes))
-	for i := range data.DataNodes {
-		pb.DataNodes[i] = data.DataNodes[i].marshal()
+// SetRolePrivilege sets the privilege of a role on a database. Setting
+// NoPrivileges removes the database from the role.
+func (data *Data) SetRolePrivilege(name, database string, p influxql.Privilege) error {
+	ri := data.Role(name)
+	if ri == nil {
+		return ErrRoleNotFound
 	}
 
-	pb.MetaNodes = make([]*internal.NodeInfo, len(data.MetaNodes))
-	for i := range data.MetaNodes {
-		pb.MetaNodes[i] = data.MetaNodes[i].marshal()
+	if data.Database(database) == nil {
+		return freetsdb.ErrDatabaseNotFound(database)
 	}
 
-	pb.Databases = make([]*internal.DatabaseInfo, len(data.Databases))
-	for i := range data.Databases {
-		pb.Databases[i] = data.Databases[i].marshal()
+	if p == influxql.NoPrivileges {
+		delete(ri.Privileges, database)
+	} else {
+		if ri.Privileges == nil {
+			ri.Privileges = make(map[string]influxql.Privilege)
+		}
+		ri.Privileges[database] = p
 	}
+	data.updateRolePrivileges()
+	return nil
+}
 
-	pb.Users = make([]*internal.UserInfo, len(data.Users))
-	for i := range data.Users {
-		pb.Users[i] = data.Users[i].marshal()
+// SetUserRole adds a user to a role, or removes it from the role if member
+// is false.
+func (data *Data) SetUserRole(username, role string, member bool) error {
+	ui := data.user(username)
+	if ui == nil {
+		return ErrUserNotFound
+	} else if data.Role(role) == nil {
+		return ErrRoleNotFound
 	}
 
-	return pb
+	ui.removeRole(role)
+	if member {
+		ui.Roles = append(ui.Roles, role)
+	}
+	data.updateRolePrivileges()
+	return nil
+}
+
+// updateRolePrivileges recomputes the privileges each user is granted by its
+// roles. It must be called whenever roles or their members change.
+func (data *Data) updateRolePrivileges() {
+	for i := range data.Users {
+		ui := &data.Users[i]
+		ui.rolePrivileges = nil
+		for _, name := range ui.Roles {
+			ri := data.Role(name)
+			if ri == nil {
+				continue
+			}
+			for db, p := range ri.Privileges {
+				if ui.rolePrivileges == nil {
+					ui.rolePrivileges = make(map[string]influxql.Privilege)
+				}
+				ui.rolePrivileges[db] = mergePrivileges(ui.rolePrivileges[db], p)
+			}
+		}
+	}
+}
+
+// Session returns a session by ID.
+func (data *Data) Session(id string) *SessionInfo {
+	for i := range data.Sessions {
+		if data.Sessions[i].ID == id {
+			return &data.Sessions[i]
+		}
+	}
+	return nil
+}
+
+// CloneSessions returns a copy of the session infos.
+func (data *Data) CloneSessions() []SessionInfo {
+	if data.Sessions == nil {
+		return nil
+	}
+	sessions := make([]SessionInfo, len(data.Sessions))
+	copy(sessions, data.Sessions)
+	return sessions
+}
+
+// CreateSession adds a session for an existing user. Sessions expired when
+// si was created are removed.
+func (data *Data) CreateSession(si SessionInfo) error {
+	if si.ID == "" {
+		return ErrSessionIDRequired
+	} else if data.user(si.Username) == nil {
+		return ErrUserNotFound
+	} else if data.Session(si.ID) != nil {
+		return ErrSessionExists
+	}
+
+	sessions := data.Sessions[:0]
+	for _, other := range data.Sessions {
+		if other.ExpiresAt.After(si.CreatedAt) {
+			sessions = append(sessions, other)
+		}
+	}
+	data.Sessions = append(sessions, si)
+	return nil
+}
+
+// DropSession removes a session by ID.
+func (data *Data) DropSession(id string) error {
+	for i := range data.Sessions {
+		if data.Sessions[i].ID == id {
+			data.Sessions = append(data.Sessions[:i], data.Sessions[i+1:]...)
+			return nil
+		}
+	}
+	return ErrSessionNotFound
+}
+
+// DropUserSessions removes every session of a user.
+func (data *Data) DropUserSessions(username string) {
+	sessions := data.Sessions[:0]
+	for _, si := range data.Sessions {
+		if si.Username != username {
+			sessions = append(sessions, si)
+		}
+	}
+	data.Sessions = sessions
+}
+
+// QueryTemplate returns a query template by name.
+func (data *Data) QueryTemplate(name string) *QueryTemplateInfo {
+	for i := range data.QueryTemplates {
+		if data.QueryTemplates[i].Name == name {
+			return &data.QueryTemplates[i]
+		}
+	}
+	return nil
+}
+
+// CloneQueryTemplates returns a copy of the query template infos.
+func (data *Data) CloneQueryTemplates() []QueryTemplateInfo {
+	if data.QueryTemplates == nil {
+		return nil
+	}
+	tmpls := make([]QueryTemplateInfo, len(data.QueryTemplates))
+	for i := range data.QueryTemplates {
+		tmpls[i] = data.QueryTemplates[i].clone()
+	}
+	return tmpls
+}
+
+// CreateQueryTemplate stores a query template. Storing a different query
+// under the name of an existing template replaces its query and increments
+// its version. The replaced query is kept as a previous version.
+func (data *Data) CreateQueryTemplate(name, query string) error {
+	if name == "" {
+		return ErrQueryTemplateNameRequired
+	} else if !ValidName(name) {
+		return ErrInvalidName
+	} else if query == "" {
+		return ErrQueryTemplateQueryRequired
+	}
+
+	if t := data.QueryTemplate(name); t != nil {
+		if t.Query != query {
+			t.PreviousVersions = append(t.PreviousVersions, QueryTemplateVersion{Version: t.Version, Query: t.Query})
+			t.Query = query
+			t.Version++
+		}
+		return nil
+	}
+
+	data.QueryTemplates = append(data.QueryTemplates, QueryTemplateInfo{
+		Name:    name,
+		Query:   query,
+		Version: 1,
+	})
+	return nil
+}
+
+// DropQueryTemplate removes a query template by name.
+func (data *Data) DropQueryTemplate(name string) error {
+	for i := range data.QueryTemplates {
+		if data.QueryTemplates[i].Name == name {
+			data.QueryTemplates = append(data.QueryTemplates[:i], data.QueryTemplates[i+1:]...)
+			return nil
+		}
+	}
+	return ErrQueryTemplateNotFound
+}
+
+// EnrollmentToken returns a bootstrap token by the hash of the token.
+func (data *Data) EnrollmentToken(hash string) *EnrollmentTokenInfo {
+	for i := range data.EnrollmentTokens {
+		if data.EnrollmentTokens[i].Hash == hash {
+			return &data.EnrollmentTokens[i]
+		}
+	}
+	return nil
+}
+
+// CloneEnrollmentTokens returns a copy of the enrollment token infos.
+func (data *Data) CloneEnrollmentTokens() []EnrollmentTokenInfo {
+	if data.EnrollmentTokens == nil {
+		return nil
+	}
+	tokens := make([]EnrollmentTokenInfo, len(data.EnrollmentTokens))
+	copy(tokens, data.EnrollmentTokens)
+	return tokens
+}
+
+// CreateEnrollmentToken adds a bootstrap token. Tokens expired when ti was
+// created are removed.
+func (data *Data) CreateEnrollmentToken(ti EnrollmentTokenInfo) error {
+	if ti.Hash == "" {
+		return ErrEnrollmentTokenHashRequired
+	} else if ti.Name == "" {
+		return ErrAgentNameRequired
+	} else if len(ti.Databases) == 0 {
+		return ErrEnrollmentTokenDatabasesRequired
+	} else if data.EnrollmentToken(ti.Hash) != nil {
+		return ErrEnrollmentTokenExists
+	}
+
+	data.removeExpiredEnrollmentTokens(ti.CreatedAt)
+	data.EnrollmentTokens = append(data.EnrollmentTokens, ti)
+	return nil
+}
+
+// RedeemEnrollmentToken consumes the bootstrap token with hash and records
+// the certificate with serial issued for it at createdAt. The agent is named
+// and granted databases by the token. Tokens and certificates expired at
+// createdAt are removed.
+func (data *Data) RedeemEnrollmentToken(hash, serial string, createdAt, expiresAt time.Time) error {
+	if serial == "" {
+		return ErrAgentSerialRequired
+	} else if data.Agent(serial) != nil {
+		return ErrAgentExists
+	}
+
+	data.removeExpiredEnrollmentTokens(createdAt)
+	ti := data.EnrollmentToken(hash)
+	if ti == nil {
+		return ErrEnrollmentTokenNotFound
+	}
+	ai := AgentInfo{
+		Name:      ti.Name,
+		Serial:    serial,
+		Databases: ti.Databases,
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+	}
+
+	tokens := data.EnrollmentTokens[:0]
+	for _, other := range data.EnrollmentTokens {
+		if other.Hash != hash {
+			tokens = append(tokens, other)
+		}
+	}
+	data.EnrollmentTokens = tokens
+
+	agents := data.Agents[:0]
+	for _, other := range data.Agents {
+		if other.ExpiresAt.After(createdAt) {
+			agents = append(agents, other)
+		}
+	}
+	data.Agents = append(agents, ai)
+	return nil
+}
+
+// removeExpiredEnrollmentTokens removes the tokens expired at now.
+func (data *Data) removeExpiredEnrollmentTokens(now time.Time) {
+	tokens := data.EnrollmentTokens[:0]
+	for _, ti := range data.EnrollmentTokens {
+		if !ti.Expired(now) {
+			tokens = append(tokens, ti)
+		}
+	}
+	data.EnrollmentTokens = tokens
+}
+
+// Agent returns the certificate issued to an enrolled agent by its serial
+// number.
+func (data *Data) Agent(serial string) *AgentInfo {
+	for i := range data.Agents {
+		if data.Agents[i].Serial == serial {
+			return &data.Agents[i]
+		}
+	}
+	return nil
+}
+
+// CloneAgents returns a copy of the agent infos.
+func (data *Data) CloneAgents() []AgentInfo {
+	if data.Agents == nil {
+		return nil
+	}
+	agents := make([]AgentInfo, len(data.Agents))
+	copy(agents, data.Agents)
+	return agents
+}
+
+// RevokeAgent revokes every certificate issued to the agent name and removes
+// the tokens not redeemed yet that would enroll it again.
+func (data *Data) RevokeAgent(name string) error {
+	var found bool
+	for i := range data.Agents {
+		if data.Agents[i].Name == name {
+			data.Agents[i].Revoked = true
+			found = true
+		}
+	}
+
+	tokens := data.EnrollmentTokens[:0]
+	for _, ti := range data.EnrollmentTokens {
+		if ti.Name == name {
+			found = true
+			continue
+		}
+		tokens = append(tokens, ti)
+	}
+	data.EnrollmentTokens = tokens
+
+	if !found {
+		return ErrAgentNotFound
+	}
+	return nil
+}
+
+// mergePrivileges returns the privilege granting both a and b.
+func mergePrivileges(a, b influxql.Privilege) influxql.Privilege {
+	switch {
+	case a == b || b == influxql.NoPrivileges:
+		return a
+	case a == influxql.NoPrivileges:
+		return b
+	default:
+		return influxql.AllPrivileges
+	}
+}
+
+// DatabaseTemplate returns a database template by name.
+func (data *Data) DatabaseTemplate(name string) *DatabaseTemplateInfo {
+	for i := range data.DatabaseTemplates {
+		if data.DatabaseTemplates[i].Name == name {
+			return &data.DatabaseTemplates[i]
+		}
+	}
+	return nil
+}
+
+// CloneDatabaseTemplates returns a copy of the DatabaseTemplateInfo.
+func (data *Data) CloneDatabaseTemplates() []DatabaseTemplateInfo {
+	if data.DatabaseTemplates == nil {
+		return nil
+	}
+	tmpls := make([]DatabaseTemplateInfo, len(data.DatabaseTemplates))
+	for i := range data.DatabaseTemplates {
+		tmpls[i] = data.DatabaseTemplates[i].clone()
+	}
+	return tmpls
+}
+
+// CreateDatabaseTemplate stores a new database template.
+// It returns an error if the template is invalid or if a different template
+// with the same name already exists.
+func (data *Data) CreateDatabaseTemplate(tmpl *DatabaseTemplateInfo) error {
+	if tmpl == nil || tmpl.Name == "" {
+		return ErrDatabaseTemplateNameRequired
+	} else if !ValidName(tmpl.Name) {
+		return ErrInvalidName
+	}
+
+	other := tmpl.clone()
+	if err := other.normalize(); err != nil {
+		return err
+	}
+
+	if t := data.DatabaseTemplate(other.Name); t != nil {
+		if t.equal(&other) {
+			return nil
+		}
+		return ErrDatabaseTemplateExists
+	}
+
+	data.DatabaseTemplates = append(data.DatabaseTemplates, other)
+	return nil
+}
+
+// DropDatabaseTemplate removes a database template by name. Databases that
+// were created from the template are not affected.
+func (data *Data) DropDatabaseTemplate(name string) error {
+	for i := range data.DatabaseTemplates {
+		if data.DatabaseTemplates[i].Name == name {
+			data.DatabaseTemplates = append(data.DatabaseTemplates[:i], data.DatabaseTemplates[i+1:]...)
+			return nil
+		}
+	}
+	return ErrDatabaseTemplateNotFound
+}
+
+// InstantiateDatabaseTemplate creates database from the named template: its
+// retention policies, default retention policy, continuous queries, user
+// grants and limits. Nothing is changed if any step fails.
+func (data *Data) InstantiateDatabaseTemplate(template, database string) error {
+	tmpl := data.DatabaseTemplate(template)
+	if tmpl == nil {
+		return ErrDatabaseTemplateNotFound
+	} else if database == "" {
+		return ErrDatabaseNameRequired
+	} else if !ValidName(database) {
+		return ErrInvalidName
+	} else if data.Database(database) != nil {
+		return ErrDatabaseExists
+	}
+
+	// Work on a copy so a failure part way through leaves data untouched.
+	other := data.Clone()
+	if err := other.CreateDatabase(database); err != nil {
+		return err
+	}
+
+	for i := range tmpl.RetentionPolicies {
+		rpi := tmpl.RetentionPolicies[i].clone()
+		if rpi.FutureWriteLimit == 0 {
+			rpi.FutureWriteLimit = tmpl.Limits.FutureWriteLimit
+		}
+		if rpi.PastWriteLimit == 0 {
+			rpi.PastWriteLimit = tmpl.Limits.PastWriteLimit
+		}
+		if err := other.CreateRetentionPolicy(database, &rpi, rpi.Name == tmpl.DefaultRetentionPolicy); err != nil {
+			return err
+		}
+	}
+
+	for _, cq := range tmpl.ContinuousQueries {
+		if err := other.CreateContinuousQuery(database, cq.Name, expandDatabaseTemplate(cq.Query, database)); err != nil {
+			return err
+		}
+	}
+
+	for _, g := range tmpl.Grants {
+		pattern := expandDatabaseTemplate(g.User, database)
+		for i := range other.Users {
+			if ok, _ := path.Match(pattern, other.Users[i].Name); !ok {
+				continue
+			}
+			if err := other.SetPrivilege(other.Users[i].Name, database, g.Privilege); err != nil {
+				return err
+			}
+		}
+	}
+
+	*data = *other
+	return nil
+}
+
+// Clone returns a copy of data with a new version.
+func (data *Data) Clone() *Data {
+	other := *data
+
+	if data.DataNodes != nil {
+		other.DataNodes = make([]NodeInfo, len(data.DataNodes))
+		for i := range data.DataNodes {
+			other.DataNodes[i] = data.DataNodes[i].clone()
+		}
+	}
+	other.Databases = data.CloneDatabases()
+	other.Users = data.CloneUsers()
+	other.DatabaseTemplates = data.CloneDatabaseTemplates()
+	other.Roles = data.CloneRoles()
+	other.Sessions = data.CloneSessions()
+	other.QueryTemplates = data.CloneQueryTemplates()
+	other.EnrollmentTokens = data.CloneEnrollmentTokens()
+	other.Agents = data.CloneAgents()
+
+	return &other
+}
+
+// marshal serializes to a protobuf representation.
+func (data *Data) marshal() *internal.Data {
+	pb := &internal.Data{
+		Term:      proto.Uint64(data.Term),
+		Index:     proto.Uint64(data.Index),
+		ClusterID: proto.Uint64(data.ClusterID),
+
+		MaxNodeID:       proto.Uint64(data.MaxNodeID),
+		MaxShardGroupID: proto.Uint64(data.MaxShardGroupID),
+		MaxShardID:      proto.Uint64(data.MaxShardID),
+	}
+
+	pb.DataNodes = make([]*internal.NodeInfo, len(data.DataNodes))
+	for i := range data.DataNodes {
+		pb.DataNodes[i] = data.DataNodes[i].marshal()
+	}
+
+	pb.MetaNodes = make([]*internal.NodeInfo, len(data.MetaNodes))
+	for i := range data.MetaNodes {
+		pb.MetaNodes[i] = data.MetaNodes[i].marshal()
+	}
+
+	pb.Databases = make([]*internal.DatabaseInfo, len(data.Databases))
+	for i := range data.Databases {
+		pb.Databases[i] = data.Databases[i].marshal()
+	}
+
+	pb.Users = make([]*internal.UserInfo, len(data.Users))
+	for i := range data.Users {
+		pb.Users[i] = data.Users[i].marshal()
+	}
+
+	pb.DatabaseTemplates = make([]*internal.DatabaseTemplateInfo, len(data.DatabaseTemplates))
+	for i := range data.DatabaseTemplates {
+		pb.DatabaseTemplates[i] = data.DatabaseTemplates[i].marshal()
+	}
+
+	pb.Roles = make([]*internal.RoleInfo, len(data.Roles))
+	for i := range data.Roles {
+		pb.Roles[i] = data.Roles[i].marshal()
+	}
+
+	pb.Sessions = make([]*internal.SessionInfo, len(data.Sessions))
+	for i := range data.Sessions {
+		pb.Sessions[i] = data.Sessions[i].marshal()
+	}
+
+	pb.QueryTemplates = make([]*internal.QueryTemplateInfo, len(data.QueryTemplates))
+	for i := range data.QueryTemplates {
+		pb.QueryTemplates[i] = data.QueryTemplates[i].marshal()
+	}
+
+	pb.EnrollmentTokens = make([]*internal.EnrollmentTokenInfo, len(data.EnrollmentTokens))
+	for i := range data.EnrollmentTokens {
+		pb.EnrollmentTokens[i] = data.EnrollmentTokens[i].marshal()
+	}
+
+	pb.Agents = make([]*internal.AgentInfo, len(data.Agents))
+	for i := range data.Agents {
+		pb.Agents[i] = data.Agents[i].marshal()
+	}
+
+	return pb
 }
 
 // unmarshal deserializes from a protobuf representation.
@@ -1070,6 +2030,49 @@ func (data *Data) unmarshal(pb *internal.Data) {
 	for i, x := range pb.GetUsers() {
 		data.Users[i].unmarshal(x)
 	}
+
+	if len(pb.GetDatabaseTemplates()) > 0 {
+		data.DatabaseTemplates = make([]DatabaseTemplateInfo, len(pb.GetDatabaseTemplates()))
+		for i, x := range pb.GetDatabaseTemplates() {
+			data.DatabaseTemplates[i].unmarshal(x)
+		}
+	}
+
+	if len(pb.GetRoles()) > 0 {
+		data.Roles = make([]RoleInfo, len(pb.GetRoles()))
+		for i, x := range pb.GetRoles() {
+			data.Roles[i].unmarshal(x)
+		}
+	}
+	data.updateRolePrivileges()
+
+	if len(pb.GetSessions()) > 0 {
+		data.Sessions = make([]SessionInfo, len(pb.GetSessions()))
+		for i, x := range pb.GetSessions() {
+			data.Sessions[i].unmarshal(x)
+		}
+	}
+
+	if len(pb.GetQueryTemplates()) > 0 {
+		data.QueryTemplates = make([]QueryTemplateInfo, len(pb.GetQueryTemplates()))
+		for i, x := range pb.GetQueryTemplates() {
+			data.QueryTemplates[i].unmarshal(x)
+		}
+	}
+
+	if len(pb.GetEnrollmentTokens()) > 0 {
+		data.EnrollmentTokens = make([]EnrollmentTokenInfo, len(pb.GetEnrollmentTokens()))
+		for i, x := range pb.GetEnrollmentTokens() {
+			data.EnrollmentTokens[i].unmarshal(x)
+		}
+	}
+
+	if len(pb.GetAgents()) > 0 {
+		data.Agents = make([]AgentInfo, len(pb.GetAgents()))
+		for i, x := range pb.GetAgents() {
+			data.Agents[i].unmarshal(x)
+		}
+	}
 }
 
 // MarshalBinary encodes the metadata to a binary format.
@@ -1093,24 +2096,34 @@ func (data *Data) TruncateShardGroups(t time.Time) {
 		dbi := &data.Databases[i]
 
 		for j := range dbi.RetentionPolicies {
-			rpi := &dbi.RetentionPolicies[j]
-
-			for k := range rpi.ShardGroups {
-				sgi := &rpi.ShardGroups[k]
-
-				if !t.Before(sgi.EndTime) || sgi.Deleted() || (sgi.Truncated() && sgi.TruncatedAt.Before(t)) {
-					continue
-				}
+			dbi.RetentionPolicies[j].truncateShardGroups(t)
+		}
+	}
+}
 
-				if !t.After(sgi.StartTime) {
-					// future shardgroup
-					sgi.TruncatedAt = sgi.StartTime
-				} else {
-					sgi.TruncatedAt = t
-				}
-			}
+// TruncateDatabaseShardGroups truncates any shard group of a database that
+// could contain timestamps beyond t. If policy is not blank, only the shard
+// groups of that retention policy are truncated.
+func (data *Data) TruncateDatabaseShardGroups(database, policy string, t time.Time) error {
+	if policy != "" {
+		rpi, err := data.RetentionPolicy(database, policy)
+		if err != nil {
+			return err
+		} else if rpi == nil {
+			return freetsdb.ErrRetentionPolicyNotFound(policy)
 		}
+		rpi.truncateShardGroups(t)
+		return nil
+	}
+
+	dbi := data.Database(database)
+	if dbi == nil {
+		return freetsdb.ErrDatabaseNotFound(database)
+	}
+	for i := range dbi.RetentionPolicies {
+		dbi.RetentionPolicies[i].truncateShardGroups(t)
 	}
+	return nil
 }
 
 // hasAdminUser exhaustively checks for the presence of at least one admin
@@ -1227,10 +2240,25 @@ type NodeInfo struct {
 	ID      uint64
 	Host    string
 	TCPHost string
+
+	// ReadOnly is set when the node rejects writes, for ReadOnlyReason.
+	ReadOnly       bool
+	ReadOnlyReason string
+
+	// Maintenance is set when the node is excluded from query routing.
+	Maintenance bool
+
+	// Labels describe the failure domains of the node, such as its zone and
+	// rack, and its hardware, such as its disk class.
+	Labels map[string]string
 }
 
 // clone returns a deep copy of ni.
-func (ni NodeInfo) clone() NodeInfo { return ni }
+func (ni NodeInfo) clone() NodeInfo {
+	other := ni
+	other.Labels = cloneLabels(ni.Labels)
+	return other
+}
 
 // marshal serializes to a protobuf representation.
 func (ni NodeInfo) marshal() *internal.NodeInfo {
@@ -1238,6 +2266,14 @@ func (ni NodeInfo) marshal() *internal.NodeInfo {
 	pb.ID = proto.Uint64(ni.ID)
 	pb.Host = proto.String(ni.Host)
 	pb.TCPHost = proto.String(ni.TCPHost)
+	if ni.ReadOnly {
+		pb.ReadOnly = proto.Bool(true)
+		pb.ReadOnlyReason = proto.String(ni.ReadOnlyReason)
+	}
+	if ni.Maintenance {
+		pb.Maintenance = proto.Bool(true)
+	}
+	pb.Labels = marshalLabels(ni.Labels)
 	return pb
 }
 
@@ -1246,6 +2282,10 @@ func (ni *NodeInfo) unmarshal(pb *internal.NodeInfo) {
 	ni.ID = pb.GetID()
 	ni.Host = pb.GetHost()
 	ni.TCPHost = pb.GetTCPHost()
+	ni.ReadOnly = pb.GetReadOnly()
+	ni.ReadOnlyReason = pb.GetReadOnlyReason()
+	ni.Maintenance = pb.GetMaintenance()
+	ni.Labels = unmarshalLabels(pb.GetLabels())
 }
 
 // NodeInfos is a slice of NodeInfo used for sorting
@@ -1266,6 +2306,32 @@ type DatabaseInfo struct {
 	DefaultRetentionPolicy string
 	RetentionPolicies      []RetentionPolicyInfo
 	ContinuousQueries      []ContinuousQueryInfo
+
+	// ShardKey is the tag keys whose values select the shard of a point in
+	// a shard group. If it is empty, the series key selects the shard.
+	ShardKey []string
+
+	// Frozen is set when the database accepts no writes and is skipped by
+	// retention enforcement.
+	Frozen bool
+
+	// MaterializedViews are aggregates of a measurement that are kept up to
+	// date from the write path and used to answer matching queries.
+	MaterializedViews []MaterializedViewInfo
+
+	// Placement restricts the nodes owning the shards of the database and
+	// the labels their replicas are spread by.
+	Placement PlacementInfo
+}
+
+// MaterializedView returns a materialized view by name.
+func (di DatabaseInfo) MaterializedView(name string) *MaterializedViewInfo {
+	for i := range di.MaterializedViews {
+		if di.MaterializedViews[i].Name == name {
+			return &di.MaterializedViews[i]
+		}
+	}
+	return nil
 }
 
 // RetentionPolicy returns a retention policy by name.
@@ -1329,6 +2395,17 @@ func (di DatabaseInfo) clone() DatabaseInfo {
 		}
 	}
 
+	if di.ShardKey != nil {
+		other.ShardKey = append([]string(nil), di.ShardKey...)
+	}
+
+	if di.MaterializedViews != nil {
+		other.MaterializedViews = make([]MaterializedViewInfo, len(di.MaterializedViews))
+		copy(other.MaterializedViews, di.MaterializedViews)
+	}
+
+	other.Placement = di.Placement.clone()
+
 	return other
 }
 
@@ -1347,6 +2424,20 @@ func (di DatabaseInfo) marshal() *internal.DatabaseInfo {
 	for i := range di.ContinuousQueries {
 		pb.ContinuousQueries[i] = di.ContinuousQueries[i].marshal()
 	}
+
+	pb.ShardKey = di.ShardKey
+	if di.Frozen {
+		pb.Frozen = proto.Bool(true)
+	}
+
+	pb.MaterializedViews = make([]*internal.MaterializedViewInfo, len(di.MaterializedViews))
+	for i := range di.MaterializedViews {
+		pb.MaterializedViews[i] = di.MaterializedViews[i].marshal()
+	}
+
+	if !di.Placement.IsZero() {
+		pb.Placement = di.Placement.marshal()
+	}
 	return pb
 }
 
@@ -1368,6 +2459,20 @@ func (di *DatabaseInfo) unmarshal(pb *internal.DatabaseInfo) {
 			di.ContinuousQueries[i].unmarshal(x)
 		}
 	}
+
+	di.ShardKey = pb.GetShardKey()
+	di.Frozen = pb.GetFrozen()
+
+	if len(pb.GetMaterializedViews()) > 0 {
+		di.MaterializedViews = make([]MaterializedViewInfo, len(pb.GetMaterializedViews()))
+		for i, x := range pb.GetMaterializedViews() {
+			di.MaterializedViews[i].unmarshal(x)
+		}
+	}
+
+	if pb.Placement != nil {
+		di.Placement.unmarshal(pb.GetPlacement())
+	}
 }
 
 // RetentionPolicySpec represents the specification for a new retention policy.
@@ -1376,6 +2481,8 @@ type RetentionPolicySpec struct {
 	ReplicaN           *int
 	Duration           *time.Duration
 	ShardGroupDuration time.Duration
+	FutureWriteLimit   time.Duration
+	PastWriteLimit     time.Duration
 }
 
 // NewRetentionPolicyInfo creates a new retention policy info from the specification.
@@ -1394,6 +2501,8 @@ func (s *RetentionPolicySpec) Matches(rpi *RetentionPolicyInfo) bool {
 		return false
 	} else if s.ReplicaN != nil && *s.ReplicaN != rpi.ReplicaN {
 		return false
+	} else if s.FutureWriteLimit != rpi.FutureWriteLimit || s.PastWriteLimit != rpi.PastWriteLimit {
+		return false
 	}
 
 	// Normalise ShardDuration before comparing to any existing retention policies.
@@ -1418,6 +2527,12 @@ func (s *RetentionPolicySpec) marshal() *internal.RetentionPolicySpec {
 	if s.ReplicaN != nil {
 		pb.ReplicaN = proto.Uint32(uint32(*s.ReplicaN))
 	}
+	if s.FutureWriteLimit > 0 {
+		pb.FutureWriteLimit = proto.Int64(int64(s.FutureWriteLimit))
+	}
+	if s.PastWriteLimit > 0 {
+		pb.PastWriteLimit = proto.Int64(int64(s.PastWriteLimit))
+	}
 	return pb
 }
 
@@ -1437,6 +2552,8 @@ func (s *RetentionPolicySpec) unmarshal(pb *internal.RetentionPolicySpec) {
 		replicaN := int(pb.GetReplicaN())
 		s.ReplicaN = &replicaN
 	}
+	s.FutureWriteLimit = time.Duration(pb.GetFutureWriteLimit())
+	s.PastWriteLimit = time.Duration(pb.GetPastWriteLimit())
 }
 
 // MarshalBinary encodes RetentionPolicySpec to a binary format.
@@ -1462,6 +2579,20 @@ type RetentionPolicyInfo struct {
 	ShardGroupDuration time.Duration
 	ShardGroups        []ShardGroupInfo
 	Subscriptions      []SubscriptionInfo
+
+	// FutureWriteLimit and PastWriteLimit bound how far ahead of and behind
+	// the current time points may be written. A limit of 0 is unbounded.
+	FutureWriteLimit time.Duration
+	PastWriteLimit   time.Duration
+
+	// ShardGroupMerges are the merges of shard groups of the policy that
+	// are in progress.
+	ShardGroupMerges []ShardGroupMergeInfo
+
+	// MeasurementShardGroupDurations overrides ShardGroupDuration for the
+	// measurements it holds, whose points are written to shard groups of
+	// their own.
+	MeasurementShardGroupDurations map[string]time.Duration
 }
 
 // NewRetentionPolicyInfo returns a new instance of RetentionPolicyInfo
@@ -1487,6 +2618,8 @@ func (rpi *RetentionPolicyInfo) Apply(spec *RetentionPolicySpec) *RetentionPolic
 		ReplicaN:           rpi.ReplicaN,
 		Duration:           rpi.Duration,
 		ShardGroupDuration: rpi.ShardGroupDuration,
+		FutureWriteLimit:   spec.FutureWriteLimit,
+		PastWriteLimit:     spec.PastWriteLimit,
 	}
 	if spec.Name != "" {
 		rp.Name = spec.Name
@@ -1502,11 +2635,20 @@ func (rpi *RetentionPolicyInfo) Apply(spec *RetentionPolicySpec) *RetentionPolic
 }
 
 // ShardGroupByTimestamp returns the shard group in the policy that contains the timestamp,
-// or nil if no shard group matches.
+// or nil if no shard group matches. Shard groups of measurements with their
+// own shard group duration are not matched.
 func (rpi *RetentionPolicyInfo) ShardGroupByTimestamp(timestamp time.Time) *ShardGroupInfo {
+	return rpi.MeasurementShardGroupByTimestamp("", timestamp)
+}
+
+// MeasurementShardGroupByTimestamp returns the shard group in the policy that
+// contains the timestamp for the points of a measurement, or nil if no shard
+// group matches.
+func (rpi *RetentionPolicyInfo) MeasurementShardGroupByTimestamp(measurement string, timestamp time.Time) *ShardGroupInfo {
+	measurement, _ = rpi.measurementShardGroupDuration(measurement)
 	for i := range rpi.ShardGroups {
 		sgi := &rpi.ShardGroups[i]
-		if sgi.Contains(timestamp) && !sgi.Deleted() && (!sgi.Truncated() || timestamp.Before(sgi.TruncatedAt)) {
+		if sgi.Measurement == measurement && sgi.Contains(timestamp) && !sgi.Deleted() && (!sgi.Truncated() || timestamp.Before(sgi.TruncatedAt)) {
 			return &rpi.ShardGroups[i]
 		}
 	}
@@ -1514,6 +2656,16 @@ func (rpi *RetentionPolicyInfo) ShardGroupByTimestamp(timestamp time.Time) *Shar
 	return nil
 }
 
+// measurementShardGroupDuration returns the shard group duration for the
+// points of a measurement, and the measurement the shard groups are created
+// for, which is empty unless the measurement has a duration of its own.
+func (rpi *RetentionPolicyInfo) measurementShardGroupDuration(measurement string) (string, time.Duration) {
+	if d, ok := rpi.MeasurementShardGroupDurations[measurement]; ok && measurement != "" {
+		return measurement, d
+	}
+	return "", rpi.ShardGroupDuration
+}
+
 // ExpiredShardGroups returns the Shard Groups which are considered expired, for the given time.
 func (rpi *RetentionPolicyInfo) ExpiredShardGroups(t time.Time) []*ShardGroupInfo {
 	var groups = make([]*ShardGroupInfo, 0)
@@ -1539,6 +2691,117 @@ func (rpi *RetentionPolicyInfo) DeletedShardGroups() []*ShardGroupInfo {
 	return groups
 }
 
+// truncateShardGroups truncates any shard group of the policy that could
+// contain timestamps beyond t.
+func (rpi *RetentionPolicyInfo) truncateShardGroups(t time.Time) {
+	for i := range rpi.ShardGroups {
+		sgi := &rpi.ShardGroups[i]
+
+		if !t.Before(sgi.EndTime) || sgi.Deleted() || (sgi.Truncated() && sgi.TruncatedAt.Before(t)) {
+			continue
+		}
+
+		if !t.After(sgi.StartTime) {
+			// future shardgroup
+			sgi.TruncatedAt = sgi.StartTime
+		} else {
+			sgi.TruncatedAt = t
+		}
+	}
+}
+
+// ValidateShardGroupMerge returns an error if the shard groups ids cannot be
+// merged. The groups must exist, hold the same measurements and be neither
+// deleted, truncated nor already being merged, and shard i of every group must
+// have the same owners, as the owners merge their shards locally.
+func (rpi *RetentionPolicyInfo) ValidateShardGroupMerge(ids []uint64) error {
+	if len(ids) < 2 {
+		return ErrShardGroupMergeInvalid
+	}
+
+	var first *ShardGroupInfo
+	seen := make(map[uint64]struct{}, len(ids))
+	for _, id := range ids {
+		sgi := rpi.shardGroup(id)
+		if sgi == nil {
+			return ErrShardGroupNotFound
+		} else if _, ok := seen[id]; ok || sgi.Deleted() || sgi.Truncated() {
+			return ErrShardGroupMergeInvalid
+		}
+		seen[id] = struct{}{}
+
+		for _, m := range rpi.ShardGroupMerges {
+			if m.hasSource(id) {
+				return ErrShardGroupMergeInvalid
+			}
+		}
+
+		if first == nil {
+			first = sgi
+			continue
+		} else if sgi.Measurement != first.Measurement || len(sgi.Shards) != len(first.Shards) {
+			return ErrShardGroupMergeInvalid
+		}
+		for i := range sgi.Shards {
+			if !sameOwners(sgi.Shards[i].Owners, first.Shards[i].Owners) {
+				return ErrShardGroupMergeInvalid
+			}
+		}
+	}
+	return nil
+}
+
+// sameOwners returns true if a and b hold the same nodes in any order.
+func sameOwners(a, b []ShardOwner) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			if x.NodeID == y.NodeID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// shardGroup returns the shard group of the policy with the id, or nil.
+func (rpi *RetentionPolicyInfo) shardGroup(id uint64) *ShardGroupInfo {
+	for i := range rpi.ShardGroups {
+		if rpi.ShardGroups[i].ID == id {
+			return &rpi.ShardGroups[i]
+		}
+	}
+	return nil
+}
+
+// ShardGroupMergeOf returns the merge in progress of the shard group id, or
+// nil if the group is not being merged.
+func (rpi *RetentionPolicyInfo) ShardGroupMergeOf(id uint64) *ShardGroupMergeInfo {
+	for i := range rpi.ShardGroupMerges {
+		if rpi.ShardGroupMerges[i].hasSource(id) {
+			return &rpi.ShardGroupMerges[i]
+		}
+	}
+	return nil
+}
+
+// dropShardGroupMerge abandons the merge at index i. Its group is kept as a
+// deleted group so that the shards already created for it are deleted.
+func (rpi *RetentionPolicyInfo) dropShardGroupMerge(i int) {
+	sgi := rpi.ShardGroupMerges[i].Group
+	sgi.DeletedAt = time.Now().UTC()
+	rpi.ShardGroups = append(rpi.ShardGroups, sgi)
+	sort.Sort(ShardGroupInfos(rpi.ShardGroups))
+	rpi.ShardGroupMerges = append(rpi.ShardGroupMerges[:i], rpi.ShardGroupMerges[i+1:]...)
+}
+
 // marshal serializes to a protobuf representation.
 func (rpi *RetentionPolicyInfo) marshal() *internal.RetentionPolicyInfo {
 	pb := &internal.RetentionPolicyInfo{
@@ -1547,6 +2810,12 @@ func (rpi *RetentionPolicyInfo) marshal() *internal.RetentionPolicyInfo {
 		Duration:           proto.Int64(int64(rpi.Duration)),
 		ShardGroupDuration: proto.Int64(int64(rpi.ShardGroupDuration)),
 	}
+	if rpi.FutureWriteLimit > 0 {
+		pb.FutureWriteLimit = proto.Int64(int64(rpi.FutureWriteLimit))
+	}
+	if rpi.PastWriteLimit > 0 {
+		pb.PastWriteLimit = proto.Int64(int64(rpi.PastWriteLimit))
+	}
 
 	pb.ShardGroups = make([]*internal.ShardGroupInfo, len(rpi.ShardGroups))
 	for i, sgi := range rpi.ShardGroups {
@@ -1558,6 +2827,25 @@ func (rpi *RetentionPolicyInfo) marshal() *internal.RetentionPolicyInfo {
 		pb.Subscriptions[i] = sub.marshal()
 	}
 
+	if len(rpi.ShardGroupMerges) > 0 {
+		pb.ShardGroupMerges = make([]*internal.ShardGroupMergeInfo, len(rpi.ShardGroupMerges))
+		for i := range rpi.ShardGroupMerges {
+			pb.ShardGroupMerges[i] = rpi.ShardGroupMerges[i].marshal()
+		}
+	}
+
+	names := make([]string, 0, len(rpi.MeasurementShardGroupDurations))
+	for name := range rpi.MeasurementShardGroupDurations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pb.MeasurementShardGroupDurations = append(pb.MeasurementShardGroupDurations, &internal.MeasurementShardGroupDuration{
+			Measurement:        proto.String(name),
+			ShardGroupDuration: proto.Int64(int64(rpi.MeasurementShardGroupDurations[name])),
+		})
+	}
+
 	return pb
 }
 
@@ -1567,6 +2855,8 @@ func (rpi *RetentionPolicyInfo) unmarshal(pb *internal.RetentionPolicyInfo) {
 	rpi.ReplicaN = int(pb.GetReplicaN())
 	rpi.Duration = time.Duration(pb.GetDuration())
 	rpi.ShardGroupDuration = time.Duration(pb.GetShardGroupDuration())
+	rpi.FutureWriteLimit = time.Duration(pb.GetFutureWriteLimit())
+	rpi.PastWriteLimit = time.Duration(pb.GetPastWriteLimit())
 
 	if len(pb.GetShardGroups()) > 0 {
 		rpi.ShardGroups = make([]ShardGroupInfo, len(pb.GetShardGroups()))
@@ -1580,6 +2870,18 @@ func (rpi *RetentionPolicyInfo) unmarshal(pb *internal.RetentionPolicyInfo) {
 			rpi.Subscriptions[i].unmarshal(x)
 		}
 	}
+	if len(pb.GetShardGroupMerges()) > 0 {
+		rpi.ShardGroupMerges = make([]ShardGroupMergeInfo, len(pb.GetShardGroupMerges()))
+		for i, x := range pb.GetShardGroupMerges() {
+			rpi.ShardGroupMerges[i].unmarshal(x)
+		}
+	}
+	if len(pb.GetMeasurementShardGroupDurations()) > 0 {
+		rpi.MeasurementShardGroupDurations = make(map[string]time.Duration, len(pb.GetMeasurementShardGroupDurations()))
+		for _, x := range pb.GetMeasurementShardGroupDurations() {
+			rpi.MeasurementShardGroupDurations[x.GetMeasurement()] = time.Duration(x.GetShardGroupDuration())
+		}
+	}
 }
 
 // clone returns a deep copy of rpi.
@@ -1593,6 +2895,25 @@ func (rpi RetentionPolicyInfo) clone() RetentionPolicyInfo {
 		}
 	}
 
+	if rpi.Subscriptions != nil {
+		other.Subscriptions = make([]SubscriptionInfo, len(rpi.Subscriptions))
+		copy(other.Subscriptions, rpi.Subscriptions)
+	}
+
+	if rpi.ShardGroupMerges != nil {
+		other.ShardGroupMerges = make([]ShardGroupMergeInfo, len(rpi.ShardGroupMerges))
+		for i := range rpi.ShardGroupMerges {
+			other.ShardGroupMerges[i] = rpi.ShardGroupMerges[i].clone()
+		}
+	}
+
+	if rpi.MeasurementShardGroupDurations != nil {
+		other.MeasurementShardGroupDurations = make(map[string]time.Duration, len(rpi.MeasurementShardGroupDurations))
+		for name, d := range rpi.MeasurementShardGroupDurations {
+			other.MeasurementShardGroupDurations[name] = d
+		}
+	}
+
 	return other
 }
 
@@ -1646,6 +2967,11 @@ type ShardGroupInfo struct {
 	DeletedAt   time.Time
 	Shards      []ShardInfo
 	TruncatedAt time.Time
+
+	// Measurement is the measurement the shard group holds the points of,
+	// if it has a shard group duration of its own. The shard groups of the
+	// other measurements have no measurement.
+	Measurement string
 }
 
 // ShardGroupInfos implements sort.Interface on []ShardGroupInfo, based
@@ -1728,6 +3054,9 @@ func (sgi *ShardGroupInfo) marshal() *internal.ShardGroupInfo {
 	if !sgi.TruncatedAt.IsZero() {
 		pb.TruncatedAt = proto.Int64(MarshalTime(sgi.TruncatedAt))
 	}
+	if sgi.Measurement != "" {
+		pb.Measurement = proto.String(sgi.Measurement)
+	}
 
 	pb.Shards = make([]*internal.ShardInfo, len(sgi.Shards))
 	for i := range sgi.Shards {
@@ -1755,6 +3084,7 @@ func (sgi *ShardGroupInfo) unmarshal(pb *internal.ShardGroupInfo) {
 	if pb != nil && pb.TruncatedAt != nil {
 		sgi.TruncatedAt = UnmarshalTime(pb.GetTruncatedAt())
 	}
+	sgi.Measurement = pb.GetMeasurement()
 
 	if len(pb.GetShards()) > 0 {
 		sgi.Shards = make([]ShardInfo, len(pb.GetShards()))
@@ -1764,6 +3094,60 @@ func (sgi *ShardGroupInfo) unmarshal(pb *internal.ShardGroupInfo) {
 	}
 }
 
+// ShardGroupMergeInfo represents a merge of shard groups in progress. Group
+// replaces the Sources groups once every owner of its shards has Completed
+// copying the data of its shards of the sources into them.
+type ShardGroupMergeInfo struct {
+	Sources   []uint64
+	Group     ShardGroupInfo
+	Completed []uint64
+}
+
+// hasSource returns true if the merge merges the shard group id.
+func (m *ShardGroupMergeInfo) hasSource(id uint64) bool {
+	for _, src := range m.Sources {
+		if src == id {
+			return true
+		}
+	}
+	return false
+}
+
+// completedBy returns true if nodeID has completed the merge.
+func (m *ShardGroupMergeInfo) completedBy(nodeID uint64) bool {
+	for _, id := range m.Completed {
+		if id == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
+// clone returns a deep copy of m.
+func (m ShardGroupMergeInfo) clone() ShardGroupMergeInfo {
+	other := m
+	other.Sources = append([]uint64(nil), m.Sources...)
+	other.Group = m.Group.clone()
+	other.Completed = append([]uint64(nil), m.Completed...)
+	return other
+}
+
+// marshal serializes to a protobuf representation.
+func (m *ShardGroupMergeInfo) marshal() *internal.ShardGroupMergeInfo {
+	return &internal.ShardGroupMergeInfo{
+		Sources:   m.Sources,
+		Group:     m.Group.marshal(),
+		Completed: m.Completed,
+	}
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (m *ShardGroupMergeInfo) unmarshal(pb *internal.ShardGroupMergeInfo) {
+	m.Sources = pb.GetSources()
+	m.Group.unmarshal(pb.GetGroup())
+	m.Completed = pb.GetCompleted()
+}
+
 // ShardInfo represents metadata about a shard.
 type ShardInfo struct {
 	ID     uint64
@@ -1843,6 +3227,36 @@ type SubscriptionInfo struct {
 	Name         string
 	Mode         string
 	Destinations []string
+
+	// Measurements, if set, restricts the points sent to the measurements.
+	Measurements []string
+
+	// Condition, if set, is an InfluxQL expression on the tags of a point
+	// that restricts the points sent to those it is true for.
+	Condition string
+
+	// StripTags are the tag keys removed from the points sent.
+	StripTags []string
+
+	// ResampleEvery, if set, limits the points sent to one point per series
+	// every interval.
+	ResampleEvery time.Duration
+
+	// Paused subscriptions drop the points written to them on every node.
+	Paused bool
+}
+
+// validate returns an error if the filter or transformation of si is invalid.
+func (si SubscriptionInfo) validate() error {
+	if si.Condition != "" {
+		if _, err := influxql.ParseExpr(si.Condition); err != nil {
+			return fmt.Errorf("invalid subscription condition: %s", err)
+		}
+	}
+	if si.ResampleEvery < 0 {
+		return fmt.Errorf("invalid subscription resample interval: %s", si.ResampleEvery)
+	}
+	return nil
 }
 
 // marshal serializes to a protobuf representation.
@@ -1856,6 +3270,18 @@ func (si SubscriptionInfo) marshal() *internal.SubscriptionInfo {
 	for i := range si.Destinations {
 		pb.Destinations[i] = si.Destinations[i]
 	}
+
+	pb.Measurements = si.Measurements
+	if si.Condition != "" {
+		pb.Condition = proto.String(si.Condition)
+	}
+	pb.StripTags = si.StripTags
+	if si.ResampleEvery > 0 {
+		pb.ResampleEvery = proto.Int64(int64(si.ResampleEvery))
+	}
+	if si.Paused {
+		pb.Paused = proto.Bool(true)
+	}
 	return pb
 }
 
@@ -1868,6 +3294,18 @@ func (si *SubscriptionInfo) unmarshal(pb *internal.SubscriptionInfo) {
 		si.Destinations = make([]string, len(pb.GetDestinations()))
 		copy(si.Destinations, pb.GetDestinations())
 	}
+
+	if len(pb.GetMeasurements()) > 0 {
+		si.Measurements = make([]string, len(pb.GetMeasurements()))
+		copy(si.Measurements, pb.GetMeasurements())
+	}
+	si.Condition = pb.GetCondition()
+	if len(pb.GetStripTags()) > 0 {
+		si.StripTags = make([]string, len(pb.GetStripTags()))
+		copy(si.StripTags, pb.GetStripTags())
+	}
+	si.ResampleEvery = time.Duration(pb.GetResampleEvery())
+	si.Paused = pb.GetPaused()
 }
 
 // ShardOwner represents a node that owns a shard.
@@ -1915,6 +3353,260 @@ func (cqi *ContinuousQueryInfo) unmarshal(pb *internal.ContinuousQueryInfo) {
 	cqi.Query = pb.GetQuery()
 }
 
+// MaterializedViewInfo represents metadata about a materialized view. Query
+// is the CREATE MATERIALIZED VIEW statement that defines it.
+type MaterializedViewInfo struct {
+	Name  string
+	Query string
+}
+
+// marshal serializes to a protobuf representation.
+func (mvi MaterializedViewInfo) marshal() *internal.MaterializedViewInfo {
+	return &internal.MaterializedViewInfo{
+		Name:  proto.String(mvi.Name),
+		Query: proto.String(mvi.Query),
+	}
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (mvi *MaterializedViewInfo) unmarshal(pb *internal.MaterializedViewInfo) {
+	mvi.Name = pb.GetName()
+	mvi.Query = pb.GetQuery()
+}
+
+// DatabaseTemplatePlaceholder is replaced with the name of the database being
+// created when a template is instantiated. It may appear in continuous query
+// text and in grant user patterns.
+const DatabaseTemplatePlaceholder = "{database}"
+
+// expandDatabaseTemplate substitutes database for DatabaseTemplatePlaceholder in s.
+func expandDatabaseTemplate(s, database string) string {
+	return strings.Replace(s, DatabaseTemplatePlaceholder, database, -1)
+}
+
+// DatabaseTemplateInfo describes how to provision a new database.
+type DatabaseTemplateInfo struct {
+	Name                   string
+	DefaultRetentionPolicy string
+	RetentionPolicies      []RetentionPolicyInfo
+	ContinuousQueries      []ContinuousQueryInfo
+	Grants                 []DatabaseTemplateGrant
+	Limits                 DatabaseTemplateLimits
+}
+
+// DatabaseTemplateLimits are the limits of a database created from a
+// template. They apply to every retention policy of the template that does
+// not set its own.
+type DatabaseTemplateLimits struct {
+	// FutureWriteLimit and PastWriteLimit bound how far ahead of and behind
+	// the current time points may be written. Zero means no limit.
+	FutureWriteLimit time.Duration
+	PastWriteLimit   time.Duration
+}
+
+// DatabaseTemplateGrant grants a privilege on the new database to every user
+// whose name matches the User pattern. The pattern uses path.Match syntax.
+type DatabaseTemplateGrant struct {
+	User      string
+	Privilege influxql.Privilege
+}
+
+// normalize validates the template and fills in shard group durations and
+// the default retention policy.
+func (ti *DatabaseTemplateInfo) normalize() error {
+	seen := make(map[string]struct{}, len(ti.RetentionPolicies))
+	for i := range ti.RetentionPolicies {
+		rpi := &ti.RetentionPolicies[i]
+		if rpi.Name == "" {
+			return ErrRetentionPolicyNameRequired
+		} else if !ValidName(rpi.Name) {
+			return ErrInvalidName
+		} else if rpi.ReplicaN < 1 {
+			return ErrReplicationFactorTooLow
+		} else if rpi.Duration != 0 && rpi.Duration < MinRetentionPolicyDuration {
+			return ErrRetentionPolicyDurationTooLow
+		}
+		rpi.ShardGroupDuration = normalisedShardDuration(rpi.ShardGroupDuration, rpi.Duration)
+		if rpi.Duration > 0 && rpi.Duration < rpi.ShardGroupDuration {
+			return ErrIncompatibleDurations
+		}
+		if _, ok := seen[rpi.Name]; ok {
+			return ErrRetentionPolicyExists
+		}
+		seen[rpi.Name] = struct{}{}
+		rpi.ShardGroups, rpi.Subscriptions = nil, nil
+	}
+
+	if ti.DefaultRetentionPolicy == "" && len(ti.RetentionPolicies) > 0 {
+		ti.DefaultRetentionPolicy = ti.RetentionPolicies[0].Name
+	} else if _, ok := seen[ti.DefaultRetentionPolicy]; !ok && ti.DefaultRetentionPolicy != "" {
+		return ErrRetentionPolicyNotFound
+	}
+
+	for _, cq := range ti.ContinuousQueries {
+		if cq.Name == "" || cq.Query == "" {
+			return ErrDatabaseTemplateInvalidContinuousQuery
+		} else if err := validateTemplateContinuousQuery(cq); err != nil {
+			return fmt.Errorf("invalid continuous query %q: %s", cq.Name, err)
+		}
+	}
+
+	for _, g := range ti.Grants {
+		if g.User == "" {
+			return ErrUsernameRequired
+		} else if _, err := path.Match(g.User, ""); err != nil {
+			return err
+		}
+		switch g.Privilege {
+		case influxql.ReadPrivilege, influxql.WritePrivilege, influxql.AllPrivileges:
+		default:
+			return ErrDatabaseTemplateInvalidPrivilege
+		}
+	}
+	return nil
+}
+
+// templateDatabaseName stands for the database being created when the
+// continuous queries of a template are validated.
+const templateDatabaseName = "template_database"
+
+// validateTemplateContinuousQuery returns an error unless the query of cq is
+// a CREATE CONTINUOUS QUERY statement named cq.Name on the database being
+// created.
+func validateTemplateContinuousQuery(cq ContinuousQueryInfo) error {
+	stmt, err := influxql.ParseStatement(expandDatabaseTemplate(cq.Query, templateDatabaseName))
+	if err != nil {
+		return err
+	}
+	cqs, ok := stmt.(*influxql.CreateContinuousQueryStatement)
+	if !ok {
+		return errors.New("not a CREATE CONTINUOUS QUERY statement")
+	} else if cqs.Name != cq.Name {
+		return fmt.Errorf("statement creates continuous query %q", cqs.Name)
+	} else if cqs.Database != templateDatabaseName {
+		return fmt.Errorf("continuous query must be ON %q", DatabaseTemplatePlaceholder)
+	}
+	return nil
+}
+
+// equal returns true if ti and other describe the same template.
+func (ti *DatabaseTemplateInfo) equal(other *DatabaseTemplateInfo) bool {
+	if ti.Name != other.Name || ti.DefaultRetentionPolicy != other.DefaultRetentionPolicy ||
+		ti.Limits != other.Limits ||
+		len(ti.RetentionPolicies) != len(other.RetentionPolicies) ||
+		len(ti.ContinuousQueries) != len(other.ContinuousQueries) ||
+		len(ti.Grants) != len(other.Grants) {
+		return false
+	}
+	for i := range ti.RetentionPolicies {
+		a, b := &ti.RetentionPolicies[i], &other.RetentionPolicies[i]
+		if a.Name != b.Name || a.Duration != b.Duration || a.ShardGroupDuration != b.ShardGroupDuration || a.ReplicaN != b.ReplicaN ||
+			a.FutureWriteLimit != b.FutureWriteLimit || a.PastWriteLimit != b.PastWriteLimit {
+			return false
+		}
+	}
+	for i := range ti.ContinuousQueries {
+		if ti.ContinuousQueries[i] != other.ContinuousQueries[i] {
+			return false
+		}
+	}
+	for i := range ti.Grants {
+		if ti.Grants[i] != other.Grants[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// clone returns a deep copy of ti.
+func (ti DatabaseTemplateInfo) clone() DatabaseTemplateInfo {
+	other := ti
+
+	if ti.RetentionPolicies != nil {
+		other.RetentionPolicies = make([]RetentionPolicyInfo, len(ti.RetentionPolicies))
+		for i := range ti.RetentionPolicies {
+			other.RetentionPolicies[i] = ti.RetentionPolicies[i].clone()
+		}
+	}
+
+	if ti.ContinuousQueries != nil {
+		other.ContinuousQueries = make([]ContinuousQueryInfo, len(ti.ContinuousQueries))
+		copy(other.ContinuousQueries, ti.ContinuousQueries)
+	}
+
+	if ti.Grants != nil {
+		other.Grants = make([]DatabaseTemplateGrant, len(ti.Grants))
+		copy(other.Grants, ti.Grants)
+	}
+
+	return other
+}
+
+// marshal serializes to a protobuf representation.
+func (ti DatabaseTemplateInfo) marshal() *internal.DatabaseTemplateInfo {
+	pb := &internal.DatabaseTemplateInfo{
+		Name:                   proto.String(ti.Name),
+		DefaultRetentionPolicy: proto.String(ti.DefaultRetentionPolicy),
+	}
+
+	pb.RetentionPolicies = make([]*internal.RetentionPolicyInfo, len(ti.RetentionPolicies))
+	for i := range ti.RetentionPolicies {
+		pb.RetentionPolicies[i] = ti.RetentionPolicies[i].marshal()
+	}
+
+	pb.ContinuousQueries = make([]*internal.ContinuousQueryInfo, len(ti.ContinuousQueries))
+	for i := range ti.ContinuousQueries {
+		pb.ContinuousQueries[i] = ti.ContinuousQueries[i].marshal()
+	}
+
+	pb.Grants = make([]*internal.DatabaseTemplateGrant, len(ti.Grants))
+	for i, g := range ti.Grants {
+		pb.Grants[i] = &internal.DatabaseTemplateGrant{
+			User:      proto.String(g.User),
+			Privilege: proto.Int32(int32(g.Privilege)),
+		}
+	}
+
+	pb.Limits = &internal.DatabaseTemplateLimits{
+		FutureWriteLimit: proto.Int64(int64(ti.Limits.FutureWriteLimit)),
+		PastWriteLimit:   proto.Int64(int64(ti.Limits.PastWriteLimit)),
+	}
+	return pb
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (ti *DatabaseTemplateInfo) unmarshal(pb *internal.DatabaseTemplateInfo) {
+	ti.Name = pb.GetName()
+	ti.DefaultRetentionPolicy = pb.GetDefaultRetentionPolicy()
+
+	if len(pb.GetRetentionPolicies()) > 0 {
+		ti.RetentionPolicies = make([]RetentionPolicyInfo, len(pb.GetRetentionPolicies()))
+		for i, x := range pb.GetRetentionPolicies() {
+			ti.RetentionPolicies[i].unmarshal(x)
+		}
+	}
+
+	if len(pb.GetContinuousQueries()) > 0 {
+		ti.ContinuousQueries = make([]ContinuousQueryInfo, len(pb.GetContinuousQueries()))
+		for i, x := range pb.GetContinuousQueries() {
+			ti.ContinuousQueries[i].unmarshal(x)
+		}
+	}
+
+	if len(pb.GetGrants()) > 0 {
+		ti.Grants = make([]DatabaseTemplateGrant, len(pb.GetGrants()))
+		for i, x := range pb.GetGrants() {
+			ti.Grants[i] = DatabaseTemplateGrant{
+				User:      x.GetUser(),
+				Privilege: influxql.Privilege(x.GetPrivilege()),
+			}
+		}
+	}
+
+	ti.Limits.FutureWriteLimit = time.Duration(pb.GetLimits().GetFutureWriteLimit())
+	ti.Limits.PastWriteLimit = time.Duration(pb.GetLimits().GetPastWriteLimit())
+}
+
 var _ query.Authorizer = (*UserInfo)(nil)
 
 // UserInfo represents metadata about a user in the system.
@@ -1930,6 +3622,19 @@ type UserInfo struct {
 
 	// Map of database name to granted privilege.
 	Privileges map[string]influxql.Privilege
+
+	// Names of the roles the user is a member of.
+	Roles []string
+
+	// When the password was last set. Zero if unknown.
+	PasswordChanged time.Time
+
+	// Hashes of the passwords the user had before the current one, most
+	// recent first, kept to prevent their reuse.
+	PreviousHashes []string
+
+	// Map of database name to the privilege granted by the user's roles.
+	rolePrivileges map[string]influxql.Privilege
 }
 
 type User interface {
@@ -1947,10 +3652,28 @@ func (ui *UserInfo) AuthorizeDatabase(privilege influxql.Privilege, database str
 	if ui.Admin || privilege == influxql.NoPrivileges {
 		return true
 	}
-	p, ok := ui.Privileges[database]
+	if p, ok := ui.Privileges[database]; ok && (p == privilege || p == influxql.AllPrivileges) {
+		return true
+	}
+	p, ok := ui.rolePrivileges[database]
 	return ok && (p == privilege || p == influxql.AllPrivileges)
 }
 
+// RolePrivileges returns the privileges granted to the user by its roles.
+func (ui *UserInfo) RolePrivileges() map[string]influxql.Privilege {
+	return ui.rolePrivileges
+}
+
+// removeRole removes the user from role.
+func (ui *UserInfo) removeRole(role string) {
+	for i, name := range ui.Roles {
+		if name == role {
+			ui.Roles = append(ui.Roles[:i:i], ui.Roles[i+1:]...)
+			return
+		}
+	}
+}
+
 // AuthorizeSeriesRead is used to limit access per-series (enterprise only)
 func (u *UserInfo) AuthorizeSeriesRead(database string, measurement []byte, tags models.Tags) bool {
 	return true
@@ -1977,9 +3700,33 @@ func (ui UserInfo) clone() UserInfo {
 		}
 	}
 
+	if ui.Roles != nil {
+		other.Roles = make([]string, len(ui.Roles))
+		copy(other.Roles, ui.Roles)
+	}
+
+	if ui.PreviousHashes != nil {
+		other.PreviousHashes = make([]string, len(ui.PreviousHashes))
+		copy(other.PreviousHashes, ui.PreviousHashes)
+	}
+
 	return other
 }
 
+// rememberHash adds hash to the previous password hashes of the user,
+// keeping the n most recent.
+func (ui *UserInfo) rememberHash(hash string, n int) {
+	if n <= 0 {
+		ui.PreviousHashes = nil
+		return
+	}
+	hashes := append([]string{hash}, ui.PreviousHashes...)
+	if len(hashes) > n {
+		hashes = hashes[:n]
+	}
+	ui.PreviousHashes = hashes
+}
+
 // marshal serializes to a protobuf representation.
 func (ui UserInfo) marshal() *internal.UserInfo {
 	pb := &internal.UserInfo{
@@ -1995,6 +3742,12 @@ func (ui UserInfo) marshal() *internal.UserInfo {
 		})
 	}
 
+	pb.Roles = ui.Roles
+	if !ui.PasswordChanged.IsZero() {
+		pb.PasswordChanged = proto.Int64(MarshalTime(ui.PasswordChanged))
+	}
+	pb.PreviousHashes = ui.PreviousHashes
+
 	return pb
 }
 
@@ -2008,6 +3761,238 @@ func (ui *UserInfo) unmarshal(pb *internal.UserInfo) {
 	for _, p := range pb.GetPrivileges() {
 		ui.Privileges[p.GetDatabase()] = influxql.Privilege(p.GetPrivilege())
 	}
+
+	ui.Roles = pb.GetRoles()
+	ui.PasswordChanged = UnmarshalTime(pb.GetPasswordChanged())
+	ui.PreviousHashes = pb.GetPreviousHashes()
+}
+
+// RoleInfo represents a named set of privileges granted to the users that
+// are members of the role.
+type RoleInfo struct {
+	Name string
+
+	// Map of database name to granted privilege.
+	Privileges map[string]influxql.Privilege
+}
+
+// clone returns a deep copy of ri.
+func (ri RoleInfo) clone() RoleInfo {
+	other := ri
+
+	if ri.Privileges != nil {
+		other.Privileges = make(map[string]influxql.Privilege)
+		for k, v := range ri.Privileges {
+			other.Privileges[k] = v
+		}
+	}
+
+	return other
+}
+
+// marshal serializes to a protobuf representation.
+func (ri RoleInfo) marshal() *internal.RoleInfo {
+	pb := &internal.RoleInfo{
+		Name: proto.String(ri.Name),
+	}
+
+	for database, privilege := range ri.Privileges {
+		pb.Privileges = append(pb.Privileges, &internal.UserPrivilege{
+			Database:  proto.String(database),
+			Privilege: proto.Int32(int32(privilege)),
+		})
+	}
+
+	return pb
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (ri *RoleInfo) unmarshal(pb *internal.RoleInfo) {
+	ri.Name = pb.GetName()
+
+	ri.Privileges = make(map[string]influxql.Privilege)
+	for _, p := range pb.GetPrivileges() {
+		ri.Privileges[p.GetDatabase()] = influxql.Privilege(p.GetPrivilege())
+	}
+}
+
+// SessionInfo represents a login session of a local user. The session token
+// itself is never stored, only its SHA-256 hash.
+type SessionInfo struct {
+	ID        string
+	Username  string
+	Hash      string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Expired returns true if the session has expired at now.
+func (si SessionInfo) Expired(now time.Time) bool {
+	return !now.Before(si.ExpiresAt)
+}
+
+// marshal serializes to a protobuf representation.
+func (si SessionInfo) marshal() *internal.SessionInfo {
+	return &internal.SessionInfo{
+		ID:        proto.String(si.ID),
+		Username:  proto.String(si.Username),
+		Hash:      proto.String(si.Hash),
+		CreatedAt: proto.Int64(MarshalTime(si.CreatedAt)),
+		ExpiresAt: proto.Int64(MarshalTime(si.ExpiresAt)),
+	}
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (si *SessionInfo) unmarshal(pb *internal.SessionInfo) {
+	si.ID = pb.GetID()
+	si.Username = pb.GetUsername()
+	si.Hash = pb.GetHash()
+	si.CreatedAt = UnmarshalTime(pb.GetCreatedAt())
+	si.ExpiresAt = UnmarshalTime(pb.GetExpiresAt())
+}
+
+// EnrollmentTokenInfo represents a bootstrap token that an agent redeems once
+// for a client certificate. The token itself is never stored, only its
+// SHA-256 hash.
+type EnrollmentTokenInfo struct {
+	Hash      string
+	Name      string
+	Databases []string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Expired returns true if the token has expired at now.
+func (ti EnrollmentTokenInfo) Expired(now time.Time) bool {
+	return !now.Before(ti.ExpiresAt)
+}
+
+// marshal serializes to a protobuf representation.
+func (ti EnrollmentTokenInfo) marshal() *internal.EnrollmentTokenInfo {
+	return &internal.EnrollmentTokenInfo{
+		Hash:      proto.String(ti.Hash),
+		Name:      proto.String(ti.Name),
+		Databases: ti.Databases,
+		CreatedAt: proto.Int64(MarshalTime(ti.CreatedAt)),
+		ExpiresAt: proto.Int64(MarshalTime(ti.ExpiresAt)),
+	}
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (ti *EnrollmentTokenInfo) unmarshal(pb *internal.EnrollmentTokenInfo) {
+	ti.Hash = pb.GetHash()
+	ti.Name = pb.GetName()
+	ti.Databases = pb.GetDatabases()
+	ti.CreatedAt = UnmarshalTime(pb.GetCreatedAt())
+	ti.ExpiresAt = UnmarshalTime(pb.GetExpiresAt())
+}
+
+// AgentInfo represents a client certificate issued to an enrolled agent. The
+// agent is identified by its name and may write to its databases until the
+// certificate expires or is revoked.
+type AgentInfo struct {
+	Name      string
+	Serial    string
+	Databases []string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	Revoked   bool
+}
+
+// marshal serializes to a protobuf representation.
+func (ai AgentInfo) marshal() *internal.AgentInfo {
+	return &internal.AgentInfo{
+		Name:      proto.String(ai.Name),
+		Serial:    proto.String(ai.Serial),
+		Databases: ai.Databases,
+		CreatedAt: proto.Int64(MarshalTime(ai.CreatedAt)),
+		ExpiresAt: proto.Int64(MarshalTime(ai.ExpiresAt)),
+		Revoked:   proto.Bool(ai.Revoked),
+	}
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (ai *AgentInfo) unmarshal(pb *internal.AgentInfo) {
+	ai.Name = pb.GetName()
+	ai.Serial = pb.GetSerial()
+	ai.Databases = pb.GetDatabases()
+	ai.CreatedAt = UnmarshalTime(pb.GetCreatedAt())
+	ai.ExpiresAt = UnmarshalTime(pb.GetExpiresAt())
+	ai.Revoked = pb.GetRevoked()
+}
+
+// QueryTemplateInfo represents a named SELECT statement whose bound
+// parameters are given when it is executed.
+type QueryTemplateInfo struct {
+	Name  string
+	Query string
+
+	// Version is incremented each time the query of the template is replaced.
+	Version uint64
+
+	// PreviousVersions are the queries the template had before, so that
+	// clients can still execute the version they were written for.
+	PreviousVersions []QueryTemplateVersion
+}
+
+// QueryTemplateVersion represents a replaced query of a query template.
+type QueryTemplateVersion struct {
+	Version uint64
+	Query   string
+}
+
+// VersionQuery returns the query of a version of the template. Version 0 is
+// the current version.
+func (qti *QueryTemplateInfo) VersionQuery(version uint64) (string, error) {
+	if version == 0 || version == qti.Version {
+		return qti.Query, nil
+	}
+	for _, v := range qti.PreviousVersions {
+		if v.Version == version {
+			return v.Query, nil
+		}
+	}
+	return "", ErrQueryTemplateVersionNotFound
+}
+
+// clone returns a deep copy of qti.
+func (qti QueryTemplateInfo) clone() QueryTemplateInfo {
+	other := qti
+	if qti.PreviousVersions != nil {
+		other.PreviousVersions = make([]QueryTemplateVersion, len(qti.PreviousVersions))
+		copy(other.PreviousVersions, qti.PreviousVersions)
+	}
+	return other
+}
+
+// marshal serializes to a protobuf representation.
+func (qti QueryTemplateInfo) marshal() *internal.QueryTemplateInfo {
+	pb := &internal.QueryTemplateInfo{
+		Name:    proto.String(qti.Name),
+		Query:   proto.String(qti.Query),
+		Version: proto.Uint64(qti.Version),
+	}
+	pb.PreviousVersions = make([]*internal.QueryTemplateVersion, len(qti.PreviousVersions))
+	for i, v := range qti.PreviousVersions {
+		pb.PreviousVersions[i] = &internal.QueryTemplateVersion{
+			Version: proto.Uint64(v.Version),
+			Query:   proto.String(v.Query),
+		}
+	}
+	return pb
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (qti *QueryTemplateInfo) unmarshal(pb *internal.QueryTemplateInfo) {
+	qti.Name = pb.GetName()
+	qti.Query = pb.GetQuery()
+	qti.Version = pb.GetVersion()
+	if len(pb.GetPreviousVersions()) > 0 {
+		qti.PreviousVersions = make([]QueryTemplateVersion, len(pb.GetPreviousVersions()))
+		for i, v := range pb.GetPreviousVersions() {
+			qti.PreviousVersions[i] = QueryTemplateVersion{Version: v.GetVersion(), Query: v.GetQuery()}
+		}
+	}
 }
 
 // MarshalTime converts t to nanoseconds since epoch. A zero time returns 0.
@@ -3,6 +3,7 @@ package meta_test
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -217,6 +218,458 @@ func TestData_SetPrivilege(t *testing.T) {
 	}
 }
 
+func TestData_Roles(t *testing.T) {
+	data := meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateUser("user1", "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := data.CreateRole("readers"); err != nil {
+		t.Fatal(err)
+	} else if got, exp := data.CreateRole("readers"), meta.ErrRoleExists; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+
+	if got, exp := data.SetRolePrivilege("not a role", "db0", influxql.ReadPrivilege), meta.ErrRoleNotFound; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	} else if err := data.SetRolePrivilege("readers", "db0", influxql.ReadPrivilege); err != nil {
+		t.Fatal(err)
+	} else if err := data.SetUserRole("user1", "readers", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Members are granted the privileges of their roles.
+	if ui := data.User("user1").(*meta.UserInfo); !ui.AuthorizeDatabase(influxql.ReadPrivilege, "db0") {
+		t.Fatal("expected role privilege to authorize read")
+	} else if ui.AuthorizeDatabase(influxql.WritePrivilege, "db0") {
+		t.Fatal("expected role privilege not to authorize write")
+	}
+
+	// Role privileges survive a marshal round trip.
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	} else if ui := other.User("user1").(*meta.UserInfo); !ui.AuthorizeDatabase(influxql.ReadPrivilege, "db0") {
+		t.Fatal("expected role privilege to authorize read after unmarshal")
+	}
+
+	// Dropping the role revokes it from its members.
+	if err := data.DropRole("readers"); err != nil {
+		t.Fatal(err)
+	} else if ui := data.User("user1").(*meta.UserInfo); len(ui.Roles) != 0 || ui.AuthorizeDatabase(influxql.ReadPrivilege, "db0") {
+		t.Fatalf("unexpected roles after drop: %v", ui.Roles)
+	}
+}
+
+func TestData_Sessions(t *testing.T) {
+	data := meta.Data{}
+	if err := data.CreateUser("user1", "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	expired := meta.SessionInfo{ID: "s0", Username: "user1", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}
+	if err := data.CreateSession(expired); err != nil {
+		t.Fatal(err)
+	} else if got, exp := data.CreateSession(meta.SessionInfo{ID: "s1", Username: "nobody"}), meta.ErrUserNotFound; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+
+	// Creating a session removes the sessions already expired.
+	if err := data.CreateSession(meta.SessionInfo{ID: "s1", Username: "user1", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	} else if data.Session("s0") != nil || data.Session("s1") == nil {
+		t.Fatalf("unexpected sessions: %+v", data.Sessions)
+	}
+
+	// Dropping the user ends its sessions.
+	if err := data.DropUser("user1"); err != nil {
+		t.Fatal(err)
+	} else if len(data.Sessions) != 0 {
+		t.Fatalf("unexpected sessions: %+v", data.Sessions)
+	} else if got, exp := data.DropSession("s1"), meta.ErrSessionNotFound; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+}
+
+func TestData_Enrollment(t *testing.T) {
+	data := meta.Data{}
+	now := time.Now().UTC()
+
+	expired := meta.EnrollmentTokenInfo{Hash: "h0", Name: "agent0", Databases: []string{"db0"}, CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}
+	if err := data.CreateEnrollmentToken(expired); err != nil {
+		t.Fatal(err)
+	} else if got, exp := data.CreateEnrollmentToken(meta.EnrollmentTokenInfo{Hash: "h1", Databases: []string{"db0"}}), meta.ErrAgentNameRequired; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+
+	// Creating a token removes the tokens already expired.
+	if err := data.CreateEnrollmentToken(meta.EnrollmentTokenInfo{Hash: "h1", Name: "agent1", Databases: []string{"db1"}, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	} else if data.EnrollmentToken("h0") != nil || data.EnrollmentToken("h1") == nil {
+		t.Fatalf("unexpected tokens: %+v", data.EnrollmentTokens)
+	}
+
+	// A token is redeemed once, for the agent and databases it names.
+	if err := data.RedeemEnrollmentToken("h1", "s1", now, now.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	} else if ai := data.Agent("s1"); ai == nil || ai.Name != "agent1" || !reflect.DeepEqual(ai.Databases, []string{"db1"}) {
+		t.Fatalf("unexpected agent: %+v", ai)
+	} else if got, exp := data.RedeemEnrollmentToken("h1", "s2", now, now.Add(24*time.Hour)), meta.ErrEnrollmentTokenNotFound; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+
+	// Enrollment survives a round trip through the protobuf representation.
+	if err := data.CreateEnrollmentToken(meta.EnrollmentTokenInfo{Hash: "h2", Name: "agent1", Databases: []string{"db1"}, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	} else if ai := other.Agent("s1"); ai == nil || ai.Name != "agent1" || !ai.ExpiresAt.Equal(now.Add(24*time.Hour)) {
+		t.Fatalf("unexpected agent: %+v", ai)
+	} else if other.EnrollmentToken("h2") == nil {
+		t.Fatalf("unexpected tokens: %+v", other.EnrollmentTokens)
+	}
+
+	// Revoking an agent revokes its certificates and drops its tokens.
+	if err := data.RevokeAgent("agent1"); err != nil {
+		t.Fatal(err)
+	} else if ai := data.Agent("s1"); !ai.Revoked {
+		t.Fatal("expected the certificate to be revoked")
+	} else if data.EnrollmentToken("h2") != nil {
+		t.Fatal("expected the token to be dropped")
+	} else if got, exp := data.RevokeAgent("agent2"), meta.ErrAgentNotFound; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+
+	// Revoked certificates are kept until they expire.
+	if err := data.CreateEnrollmentToken(meta.EnrollmentTokenInfo{Hash: "h3", Name: "agent3", Databases: []string{"db3"}, CreatedAt: now.Add(48 * time.Hour), ExpiresAt: now.Add(49 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	} else if err := data.RedeemEnrollmentToken("h3", "s3", now.Add(48*time.Hour), now.Add(72*time.Hour)); err != nil {
+		t.Fatal(err)
+	} else if data.Agent("s1") != nil || data.Agent("s3") == nil {
+		t.Fatalf("unexpected agents: %+v", data.Agents)
+	}
+}
+
+func TestData_QueryTemplates(t *testing.T) {
+	data := meta.Data{}
+	const q0 = `SELECT mean(usage) FROM cpu WHERE host = $host GROUP BY time($interval)`
+	if err := data.CreateQueryTemplate("dashboards.cpu", q0); err != nil {
+		t.Fatal(err)
+	} else if qti := data.QueryTemplate("dashboards.cpu"); qti == nil || qti.Query != q0 || qti.Version != 1 {
+		t.Fatalf("unexpected template: %+v", qti)
+	} else if got, exp := data.CreateQueryTemplate("dashboards.mem", ""), meta.ErrQueryTemplateQueryRequired; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+
+	// Storing the same query keeps the version, a different one replaces it.
+	if err := data.CreateQueryTemplate("dashboards.cpu", q0); err != nil {
+		t.Fatal(err)
+	} else if qti := data.QueryTemplate("dashboards.cpu"); qti.Version != 1 {
+		t.Fatalf("unexpected version: %d", qti.Version)
+	}
+	const q1 = `SELECT max(usage) FROM cpu WHERE host = $host GROUP BY time($interval)`
+	if err := data.CreateQueryTemplate("dashboards.cpu", q1); err != nil {
+		t.Fatal(err)
+	} else if qti := data.QueryTemplate("dashboards.cpu"); qti.Query != q1 || qti.Version != 2 {
+		t.Fatalf("unexpected template: %+v", qti)
+	}
+
+	// The replaced versions are kept.
+	qti := data.QueryTemplate("dashboards.cpu")
+	if q, err := qti.VersionQuery(1); err != nil || q != q0 {
+		t.Fatalf("unexpected version 1: %q, %v", q, err)
+	} else if q, err := qti.VersionQuery(2); err != nil || q != q1 {
+		t.Fatalf("unexpected version 2: %q, %v", q, err)
+	} else if q, err := qti.VersionQuery(0); err != nil || q != q1 {
+		t.Fatalf("unexpected current version: %q, %v", q, err)
+	} else if _, err := qti.VersionQuery(3); err != meta.ErrQueryTemplateVersionNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Templates survive a round trip through the protobuf representation.
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(other.QueryTemplates, data.QueryTemplates) {
+		t.Fatalf("unexpected templates: %+v", other.QueryTemplates)
+	}
+
+	if err := data.DropQueryTemplate("dashboards.cpu"); err != nil {
+		t.Fatal(err)
+	} else if got, exp := data.DropQueryTemplate("dashboards.cpu"), meta.ErrQueryTemplateNotFound; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+}
+
+func TestData_MaterializedViews(t *testing.T) {
+	data := meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	const q0 = `CREATE MATERIALIZED VIEW cpu_1m ON db0 AS SELECT sum(usage) FROM db0.rp0.cpu GROUP BY time(1m), host`
+	if err := data.CreateMaterializedView("db0", "cpu_1m", q0); err != nil {
+		t.Fatal(err)
+	} else if mvi := data.Database("db0").MaterializedView("cpu_1m"); mvi == nil || mvi.Query != q0 {
+		t.Fatalf("unexpected view: %+v", mvi)
+	} else if got, exp := data.CreateMaterializedView("db1", "cpu_1m", q0), freetsdb.ErrDatabaseNotFound("db1"); got.Error() != exp.Error() {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+
+	// Creating the same view again is a no-op, a different one is an error.
+	if err := data.CreateMaterializedView("db0", "cpu_1m", q0); err != nil {
+		t.Fatal(err)
+	}
+	const q1 = `CREATE MATERIALIZED VIEW cpu_1m ON db0 AS SELECT max(usage) FROM db0.rp0.cpu GROUP BY time(1m)`
+	if got, exp := data.CreateMaterializedView("db0", "cpu_1m", q1), meta.ErrMaterializedViewExists; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+
+	// Views survive a round trip through the protobuf representation.
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(other.Database("db0").MaterializedViews, data.Database("db0").MaterializedViews) {
+		t.Fatalf("unexpected views: %+v", other.Database("db0").MaterializedViews)
+	}
+
+	if err := data.DropMaterializedView("db0", "cpu_1m"); err != nil {
+		t.Fatal(err)
+	} else if got, exp := data.DropMaterializedView("db0", "cpu_1m"), meta.ErrMaterializedViewNotFound; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+}
+
+func TestData_AddShardOwner(t *testing.T) {
+	data := &meta.Data{}
+
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	must(data.CreateDataNode("host0:8086", "host0:8088"))
+	must(data.CreateDatabase("db"))
+	rp := meta.NewRetentionPolicyInfo("rp")
+	must(data.CreateRetentionPolicy("db", rp, true))
+	must(data.CreateShardGroup("db", "rp", time.Unix(0, 0)))
+	must(data.CreateDataNode("host1:8086", "host1:8088"))
+
+	sgi, err := data.ShardGroupByTimestamp("db", "rp", time.Unix(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := sgi.Shards[0].ID
+	nodeID := data.DataNodes[1].ID
+
+	must(data.AddShardOwner(id, nodeID))
+	// Adding an existing owner is not an error.
+	must(data.AddShardOwner(id, nodeID))
+
+	sgi, _ = data.ShardGroupByTimestamp("db", "rp", time.Unix(0, 0))
+	if si := sgi.Shards[0]; len(si.Owners) != 2 || !si.OwnedBy(nodeID) {
+		t.Fatalf("unexpected owners: %v", si.Owners)
+	}
+
+	if err := data.AddShardOwner(id+100, nodeID); err != meta.ErrShardNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := data.AddShardOwner(id, nodeID+100); err != meta.ErrNodeNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestData_RemoveShardOwner(t *testing.T) {
+	data := &meta.Data{}
+
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	must(data.CreateDataNode("host0:8086", "host0:8088"))
+	must(data.CreateDataNode("host1:8086", "host1:8088"))
+	must(data.CreateDatabase("db"))
+	rp := meta.NewRetentionPolicyInfo("rp")
+	must(data.CreateRetentionPolicy("db", rp, true))
+	must(data.CreateShardGroup("db", "rp", time.Unix(0, 0)))
+
+	sgi, err := data.ShardGroupByTimestamp("db", "rp", time.Unix(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := sgi.Shards[0].ID
+	must(data.AddShardOwner(id, data.DataNodes[0].ID))
+	must(data.AddShardOwner(id, data.DataNodes[1].ID))
+
+	other := data.Clone()
+	must(other.RemoveShardOwner(id, data.DataNodes[0].ID))
+	// Removing a node that is not an owner is not an error.
+	must(other.RemoveShardOwner(id, data.DataNodes[0].ID))
+
+	sgi, _ = other.ShardGroupByTimestamp("db", "rp", time.Unix(0, 0))
+	if si := sgi.Shards[0]; len(si.Owners) != 1 || !si.OwnedBy(data.DataNodes[1].ID) {
+		t.Fatalf("unexpected owners: %v", si.Owners)
+	} else if sgi.Deleted() {
+		t.Fatal("unexpected shard group deleted")
+	}
+
+	// The data the command was applied to a copy of is unchanged.
+	sgi, _ = data.ShardGroupByTimestamp("db", "rp", time.Unix(0, 0))
+	if si := sgi.Shards[0]; len(si.Owners) != 2 {
+		t.Fatalf("unexpected owners of the original data: %v", si.Owners)
+	}
+
+	if err := other.RemoveShardOwner(id+100, data.DataNodes[0].ID); err != meta.ErrShardNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestData_SetModes(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateDataNode("host0:8086", "host0:8088"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateDatabase("db"); err != nil {
+		t.Fatal(err)
+	}
+	id := data.DataNodes[0].ID
+
+	if err := data.SetDataNodeMode(id, true, "disk full", true); err != nil {
+		t.Fatal(err)
+	} else if err := data.SetDatabaseFrozen("db", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// The modes survive a snapshot.
+	b, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if ni := other.DataNode(id); !ni.ReadOnly || ni.ReadOnlyReason != "disk full" || !ni.Maintenance {
+		t.Fatalf("unexpected node: %+v", ni)
+	} else if di := other.Database("db"); !di.Frozen {
+		t.Fatalf("unexpected database: %+v", di)
+	}
+
+	// Clearing read-only clears its reason.
+	if err := data.SetDataNodeMode(id, false, "disk full", false); err != nil {
+		t.Fatal(err)
+	} else if ni := data.DataNode(id); ni.ReadOnly || ni.ReadOnlyReason != "" || ni.Maintenance {
+		t.Fatalf("unexpected node: %+v", ni)
+	}
+
+	if err := data.SetDataNodeMode(id+100, true, "", false); err != meta.ErrNodeNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := data.SetDatabaseFrozen("nodb", true); err == nil || err.Error() != freetsdb.ErrDatabaseNotFound("nodb").Error() {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestData_Placement(t *testing.T) {
+	data := &meta.Data{}
+
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	labels := []map[string]string{
+		{"zone": "a", "disk": "hdd"},
+		{"zone": "a", "disk": "hdd"},
+		{"zone": "b", "disk": "ssd"},
+		{"zone": "b", "disk": "ssd"},
+	}
+	for i := range labels {
+		must(data.CreateDataNode(fmt.Sprintf("host%d:8086", i), fmt.Sprintf("host%d:8088", i)))
+		must(data.SetDataNodeLabels(data.DataNodes[i].ID, labels[i]))
+	}
+	zone := func(id uint64) string { return data.DataNode(id).Labels["zone"] }
+
+	// The replicas of every shard are in different zones.
+	must(data.CreateDatabase("db0"))
+	rp := meta.NewRetentionPolicyInfo("rp")
+	rp.ReplicaN = 2
+	must(data.CreateRetentionPolicy("db0", rp, true))
+	must(data.CreateShardGroup("db0", "rp", time.Unix(0, 0)))
+	sgi, err := data.ShardGroupByTimestamp("db0", "rp", time.Unix(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(sgi.Shards) != 2 {
+		t.Fatalf("unexpected shards: %v", sgi.Shards)
+	}
+	for _, si := range sgi.Shards {
+		if len(si.Owners) != 2 || zone(si.Owners[0].NodeID) == zone(si.Owners[1].NodeID) {
+			t.Fatalf("unexpected owners: %v", si.Owners)
+		}
+	}
+
+	// Only the nodes with the required labels own the shards.
+	must(data.CreateDatabase("db1"))
+	must(data.CreateRetentionPolicy("db1", rp, true))
+	must(data.SetDatabasePlacement("db1", meta.PlacementInfo{Require: map[string]string{"disk": "ssd"}}))
+	must(data.CreateShardGroup("db1", "rp", time.Unix(0, 0)))
+	sgi, _ = data.ShardGroupByTimestamp("db1", "rp", time.Unix(0, 0))
+	if len(sgi.Shards) != 1 {
+		t.Fatalf("unexpected shards: %v", sgi.Shards)
+	}
+	for _, o := range sgi.Shards[0].Owners {
+		if zone(o.NodeID) != "b" {
+			t.Fatalf("unexpected owners: %v", sgi.Shards[0].Owners)
+		}
+	}
+
+	must(data.SetDatabasePlacement("db1", meta.PlacementInfo{Require: map[string]string{"disk": "nvme"}}))
+	if err := data.CreateShardGroup("db1", "rp", time.Unix(0, 0).Add(30*24*time.Hour)); err != meta.ErrNoNodesMatchPlacement {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The labels and placements survive a snapshot.
+	b, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	must(other.UnmarshalBinary(b))
+	if ni := other.DataNode(data.DataNodes[2].ID); !reflect.DeepEqual(ni.Labels, labels[2]) {
+		t.Fatalf("unexpected labels: %v", ni.Labels)
+	} else if p := other.Database("db1").Placement; !reflect.DeepEqual(p.Require, map[string]string{"disk": "nvme"}) {
+		t.Fatalf("unexpected placement: %+v", p)
+	}
+
+	if err := data.SetDataNodeLabels(100, nil); err != meta.ErrNodeNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := data.SetDatabasePlacement("nodb", meta.PlacementInfo{}); err == nil || err.Error() != freetsdb.ErrDatabaseNotFound("nodb").Error() {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestData_TruncateShardGroups(t *testing.T) {
 	data := &meta.Data{}
 
@@ -315,6 +768,428 @@ func TestData_TruncateShardGroups(t *testing.T) {
 	}
 }
 
+// Ensure only the shard groups of a database, or of one of its retention
+// policies, are truncated.
+func TestData_TruncateDatabaseShardGroups(t *testing.T) {
+	data := &meta.Data{}
+
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, db := range []string{"db0", "db1"} {
+		must(data.CreateDatabase(db))
+		for _, name := range []string{"rp0", "rp1"} {
+			rp := meta.NewRetentionPolicyInfo(name)
+			rp.ShardGroupDuration = 24 * time.Hour
+			must(data.CreateRetentionPolicy(db, rp, false))
+			must(data.CreateShardGroup(db, name, time.Unix(0, 0)))
+		}
+	}
+
+	truncateTime := time.Unix(0, 0).Add(time.Hour)
+	must(data.TruncateDatabaseShardGroups("db0", "rp1", truncateTime))
+	must(data.TruncateDatabaseShardGroups("db1", "", truncateTime))
+
+	for _, tc := range []struct {
+		database, policy string
+		truncated        bool
+	}{
+		{"db0", "rp0", false},
+		{"db0", "rp1", true},
+		{"db1", "rp0", true},
+		{"db1", "rp1", true},
+	} {
+		rp, err := data.RetentionPolicy(tc.database, tc.policy)
+		if err != nil {
+			t.Fatal(err)
+		} else if sg := rp.ShardGroups[0]; sg.Truncated() != tc.truncated {
+			t.Fatalf("%s.%s: unexpected truncation: %v", tc.database, tc.policy, sg.TruncatedAt)
+		} else if tc.truncated && sg.TruncatedAt != truncateTime {
+			t.Fatalf("%s.%s: unexpected truncation time: %v", tc.database, tc.policy, sg.TruncatedAt)
+		}
+	}
+
+	if err := data.TruncateDatabaseShardGroups("nodb", "", truncateTime); err == nil || err.Error() != freetsdb.ErrDatabaseNotFound("nodb").Error() {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := data.TruncateDatabaseShardGroups("db0", "norp", truncateTime); err == nil || err.Error() != freetsdb.ErrRetentionPolicyNotFound("norp").Error() {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestData_InstantiateDatabaseTemplate(t *testing.T) {
+	data := meta.Data{
+		Users: []meta.UserInfo{
+			{Name: "acme_reader"},
+			{Name: "acme_writer"},
+			{Name: "other_reader"},
+		},
+	}
+
+	if err := data.CreateDatabaseTemplate(&meta.DatabaseTemplateInfo{
+		Name: "tenant",
+		RetentionPolicies: []meta.RetentionPolicyInfo{
+			{Name: "raw", Duration: 7 * 24 * time.Hour, ReplicaN: 2},
+			{Name: "rollup", ReplicaN: 1, PastWriteLimit: 365 * 24 * time.Hour},
+		},
+		ContinuousQueries: []meta.ContinuousQueryInfo{
+			{Name: "cq_1h", Query: `CREATE CONTINUOUS QUERY cq_1h ON "{database}" BEGIN SELECT mean(value) INTO "{database}".rollup.cpu FROM "{database}".raw.cpu GROUP BY time(1h) END`},
+		},
+		Grants: []meta.DatabaseTemplateGrant{
+			{User: "{database}_*", Privilege: influxql.ReadPrivilege},
+			{User: "{database}_writer", Privilege: influxql.AllPrivileges},
+		},
+		Limits: meta.DatabaseTemplateLimits{FutureWriteLimit: time.Hour, PastWriteLimit: 30 * 24 * time.Hour},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := data.InstantiateDatabaseTemplate("tenant", "acme"); err != nil {
+		t.Fatal(err)
+	}
+
+	di := data.Database("acme")
+	if di == nil {
+		t.Fatal("expected database to be created")
+	} else if got, exp := di.DefaultRetentionPolicy, "raw"; got != exp {
+		t.Fatalf("default retention policy: got %q, exp %q", got, exp)
+	} else if got, exp := len(di.RetentionPolicies), 2; got != exp {
+		t.Fatalf("retention policies: got %d, exp %d", got, exp)
+	} else if rp := di.RetentionPolicy("raw"); rp.ReplicaN != 2 || rp.ShardGroupDuration != 24*time.Hour {
+		t.Fatalf("unexpected retention policy: %+v", rp)
+	}
+
+	// The limits of the template apply to the retention policies that do
+	// not set their own.
+	if rp := di.RetentionPolicy("raw"); rp.FutureWriteLimit != time.Hour || rp.PastWriteLimit != 30*24*time.Hour {
+		t.Fatalf("unexpected limits: %+v", rp)
+	} else if rp := di.RetentionPolicy("rollup"); rp.FutureWriteLimit != time.Hour || rp.PastWriteLimit != 365*24*time.Hour {
+		t.Fatalf("unexpected limits: %+v", rp)
+	}
+
+	exp := `CREATE CONTINUOUS QUERY cq_1h ON "acme" BEGIN SELECT mean(value) INTO "acme".rollup.cpu FROM "acme".raw.cpu GROUP BY time(1h) END`
+	if got := di.ContinuousQueries; len(got) != 1 || got[0].Query != exp {
+		t.Fatalf("unexpected continuous queries: %+v", got)
+	}
+
+	for _, tt := range []struct {
+		user string
+		exp  influxql.Privilege
+	}{
+		{user: "acme_reader", exp: influxql.ReadPrivilege},
+		{user: "acme_writer", exp: influxql.AllPrivileges},
+		{user: "other_reader", exp: influxql.NoPrivileges},
+	} {
+		p, err := data.UserPrivilege(tt.user, "acme")
+		if err != nil {
+			t.Fatal(err)
+		} else if *p != tt.exp {
+			t.Fatalf("%s: got %s, exp %s", tt.user, *p, tt.exp)
+		}
+	}
+
+	// Instantiating over an existing database leaves it alone.
+	if err := data.InstantiateDatabaseTemplate("tenant", "acme"); err != meta.ErrDatabaseExists {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestData_InstantiateDatabaseTemplate_Atomic(t *testing.T) {
+	data := meta.Data{}
+	if err := data.CreateDatabaseTemplate(&meta.DatabaseTemplateInfo{
+		Name: "tenant",
+		ContinuousQueries: []meta.ContinuousQueryInfo{
+			{Name: "cq", Query: `CREATE CONTINUOUS QUERY cq ON "{database}" BEGIN SELECT count(value) INTO b FROM c GROUP BY time(1m) END`},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sneak a conflicting continuous query into the template so that
+	// instantiation fails after the database has been created.
+	tmpl := data.DatabaseTemplate("tenant")
+	tmpl.ContinuousQueries = append(tmpl.ContinuousQueries, meta.ContinuousQueryInfo{Name: "cq", Query: "different"})
+
+	if err := data.InstantiateDatabaseTemplate("tenant", "acme"); err != meta.ErrContinuousQueryExists {
+		t.Fatalf("unexpected error: %v", err)
+	} else if data.Database("acme") != nil {
+		t.Fatal("expected database not to be created")
+	}
+}
+
+func TestData_CreateDatabaseTemplate_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		tmpl meta.DatabaseTemplateInfo
+		err  error
+	}{
+		{
+			name: "no name",
+			tmpl: meta.DatabaseTemplateInfo{},
+			err:  meta.ErrDatabaseTemplateNameRequired,
+		},
+		{
+			name: "unknown default",
+			tmpl: meta.DatabaseTemplateInfo{
+				Name:                   "t",
+				DefaultRetentionPolicy: "missing",
+				RetentionPolicies:      []meta.RetentionPolicyInfo{{Name: "rp", ReplicaN: 1}},
+			},
+			err: meta.ErrRetentionPolicyNotFound,
+		},
+		{
+			name: "duplicate retention policy",
+			tmpl: meta.DatabaseTemplateInfo{
+				Name:              "t",
+				RetentionPolicies: []meta.RetentionPolicyInfo{{Name: "rp", ReplicaN: 1}, {Name: "rp", ReplicaN: 1}},
+			},
+			err: meta.ErrRetentionPolicyExists,
+		},
+		{
+			name: "bad privilege",
+			tmpl: meta.DatabaseTemplateInfo{
+				Name:   "t",
+				Grants: []meta.DatabaseTemplateGrant{{User: "u", Privilege: influxql.NoPrivileges}},
+			},
+			err: meta.ErrDatabaseTemplateInvalidPrivilege,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var data meta.Data
+			if err := data.CreateDatabaseTemplate(&tt.tmpl); err != tt.err {
+				t.Fatalf("got %v, exp %v", err, tt.err)
+			}
+		})
+	}
+}
+
+// Ensure the continuous queries of a template are parsed when it is created.
+func TestData_CreateDatabaseTemplate_InvalidContinuousQuery(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		query string
+		err   string
+	}{
+		{
+			name:  "syntax",
+			query: `CREATE CONTINUOUS QUERY cq ON "{database}" BEGIN SELECT FROM END`,
+			err:   `invalid continuous query "cq": found FROM, expected identifier`,
+		},
+		{
+			name:  "not a continuous query",
+			query: `DROP DATABASE "{database}"`,
+			err:   `invalid continuous query "cq": not a CREATE CONTINUOUS QUERY statement`,
+		},
+		{
+			name:  "other name",
+			query: `CREATE CONTINUOUS QUERY other ON "{database}" BEGIN SELECT count(value) INTO b FROM c GROUP BY time(1m) END`,
+			err:   `invalid continuous query "cq": statement creates continuous query "other"`,
+		},
+		{
+			name:  "other database",
+			query: `CREATE CONTINUOUS QUERY cq ON db0 BEGIN SELECT count(value) INTO b FROM c GROUP BY time(1m) END`,
+			err:   `invalid continuous query "cq": continuous query must be ON "{database}"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var data meta.Data
+			err := data.CreateDatabaseTemplate(&meta.DatabaseTemplateInfo{
+				Name:              "t",
+				ContinuousQueries: []meta.ContinuousQueryInfo{{Name: "cq", Query: tt.query}},
+			})
+			if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+				t.Fatalf("got %v, exp %s", err, tt.err)
+			}
+		})
+	}
+}
+
+// Ensure the limits of a template survive a round trip through the protobuf
+// representation.
+func TestData_DatabaseTemplates_Marshal(t *testing.T) {
+	data := meta.Data{}
+	if err := data.CreateDatabaseTemplate(&meta.DatabaseTemplateInfo{
+		Name:              "tenant",
+		RetentionPolicies: []meta.RetentionPolicyInfo{{Name: "raw", ReplicaN: 1}},
+		Limits:            meta.DatabaseTemplateLimits{FutureWriteLimit: time.Hour, PastWriteLimit: 24 * time.Hour},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(other.DatabaseTemplates, data.DatabaseTemplates) {
+		t.Fatalf("unexpected templates: %+v", other.DatabaseTemplates)
+	}
+}
+
+func TestData_SetShardKey(t *testing.T) {
+	data := &meta.Data{Databases: []meta.DatabaseInfo{{Name: "db0"}}}
+
+	if err := data.SetShardKey("db0", []string{"host"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.SetShardKey("db0", []string{"host"}); err != nil {
+		t.Fatalf("unexpected error setting the same shard key: %v", err)
+	}
+	if got, exp := data.SetShardKey("db0", []string{"region"}), meta.ErrShardKeyConflict; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+	if err := data.SetShardKey("db1", []string{"host"}); err == nil {
+		t.Fatal("expected error for a missing database")
+	}
+
+	// The shard key survives a round trip through the protobuf encoding.
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	} else if got, exp := other.Database("db0").ShardKey, []string{"host"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+}
+
+func TestData_ShardGroupMerge(t *testing.T) {
+	data := &meta.Data{
+		DataNodes: []meta.NodeInfo{{ID: 1}},
+		Databases: []meta.DatabaseInfo{{
+			Name: "db0",
+			RetentionPolicies: []meta.RetentionPolicyInfo{
+				{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour},
+			},
+		}},
+	}
+
+	t0 := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if err := data.CreateShardGroup("db0", "rp0", t0.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	groups, _ := data.ShardGroups("db0", "rp0")
+	ids := []uint64{groups[0].ID, groups[1].ID, groups[2].ID}
+
+	if got, exp := data.CreateShardGroupMerge("db0", "rp0", ids[:1]), meta.ErrShardGroupMergeInvalid; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+	if err := data.CreateShardGroupMerge("db0", "rp0", ids); err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := data.CreateShardGroupMerge("db0", "rp0", ids[1:]), meta.ErrShardGroupMergeInvalid; got != exp {
+		t.Fatalf("got %v, expected %v for groups already being merged", got, exp)
+	}
+
+	// The pending merge survives a round trip through the protobuf encoding.
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	}
+	rpi, _ := other.RetentionPolicy("db0", "rp0")
+	if len(rpi.ShardGroupMerges) != 1 {
+		t.Fatalf("unexpected merges: %+v", rpi.ShardGroupMerges)
+	}
+	m := rpi.ShardGroupMerges[0]
+	if !reflect.DeepEqual(m.Sources, ids) {
+		t.Fatalf("unexpected sources: got %v, expected %v", m.Sources, ids)
+	} else if !m.Group.StartTime.Equal(t0) || !m.Group.EndTime.Equal(t0.Add(3*time.Hour)) {
+		t.Fatalf("unexpected merged time range: %s - %s", m.Group.StartTime, m.Group.EndTime)
+	}
+
+	// The merged group replaces the groups once its owner has completed.
+	if err := data.CompleteShardGroupMerge("db0", "rp0", m.Group.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	groups, _ = data.ShardGroups("db0", "rp0")
+	if len(groups) != 1 || groups[0].ID != m.Group.ID {
+		t.Fatalf("unexpected shard groups after merge: %+v", groups)
+	}
+	if sgi, _ := data.ShardGroupByTimestamp("db0", "rp0", t0.Add(90*time.Minute)); sgi == nil || sgi.ID != m.Group.ID {
+		t.Fatalf("unexpected shard group for timestamp: %+v", sgi)
+	}
+	if got, exp := data.CompleteShardGroupMerge("db0", "rp0", m.Group.ID, 1), meta.ErrShardGroupMergeNotFound; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+}
+
+func TestData_MeasurementShardGroupDuration(t *testing.T) {
+	data := &meta.Data{
+		DataNodes: []meta.NodeInfo{{ID: 1}},
+		Databases: []meta.DatabaseInfo{{
+			Name: "db0",
+			RetentionPolicies: []meta.RetentionPolicyInfo{
+				{Name: "rp0", ReplicaN: 1, ShardGroupDuration: 24 * time.Hour},
+			},
+		}},
+	}
+
+	rpu := &meta.RetentionPolicyUpdate{Measurement: "cpu"}
+	rpu.SetReplicaN(2)
+	if got, exp := data.UpdateRetentionPolicy("db0", "rp0", rpu, false), meta.ErrMeasurementShardGroupDurationOnly; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+	rpu = &meta.RetentionPolicyUpdate{Measurement: "cpu"}
+	rpu.SetShardGroupDuration(time.Hour)
+	if err := data.UpdateRetentionPolicy("db0", "rp0", rpu, false); err != nil {
+		t.Fatal(err)
+	}
+
+	t0 := time.Date(2000, 1, 1, 0, 30, 0, 0, time.UTC)
+	if err := data.CreateMeasurementShardGroup("db0", "rp0", "cpu", t0); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateShardGroup("db0", "rp0", t0); err != nil {
+		t.Fatal(err)
+	}
+
+	// The override survives a round trip through the protobuf encoding.
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	}
+	rpi, _ := other.RetentionPolicy("db0", "rp0")
+	if got, exp := rpi.MeasurementShardGroupDurations["cpu"], time.Hour; got != exp {
+		t.Fatalf("unexpected measurement shard group duration: got %s, expected %s", got, exp)
+	}
+
+	sgi := rpi.MeasurementShardGroupByTimestamp("cpu", t0)
+	if sgi == nil || sgi.Measurement != "cpu" || sgi.EndTime.Sub(sgi.StartTime) != time.Hour {
+		t.Fatalf("unexpected measurement shard group: %+v", sgi)
+	}
+	sgi = rpi.ShardGroupByTimestamp(t0)
+	if sgi == nil || sgi.Measurement != "" || sgi.EndTime.Sub(sgi.StartTime) != 24*time.Hour {
+		t.Fatalf("unexpected shard group: %+v", sgi)
+	}
+
+	// Removing the override makes the measurement use the policy's groups.
+	rpu = &meta.RetentionPolicyUpdate{Measurement: "cpu"}
+	rpu.SetShardGroupDuration(0)
+	if err := data.UpdateRetentionPolicy("db0", "rp0", rpu, false); err != nil {
+		t.Fatal(err)
+	}
+	rpi, _ = data.RetentionPolicy("db0", "rp0")
+	if _, ok := rpi.MeasurementShardGroupDurations["cpu"]; ok {
+		t.Fatal("expected measurement shard group duration to be removed")
+	}
+}
+
 func TestUserInfo_AuthorizeDatabase(t *testing.T) {
 	emptyUser := &meta.UserInfo{}
 	if !emptyUser.AuthorizeDatabase(influxql.NoPrivileges, "anydb") {
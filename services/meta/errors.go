@@ -32,6 +32,14 @@ var (
 	ErrNodeUnableToDropFinalNode = errors.New("unable to drop the final node in a cluster")
 
 	ErrNodeUnableToDropNode = errors.New("unable to drop the node in a cluster")
+
+	// ErrNodeLabelKeyRequired is returned when setting a node label, or a
+	// placement requirement, without a key.
+	ErrNodeLabelKeyRequired = errors.New("node label key required")
+
+	// ErrNoNodesMatchPlacement is returned when creating a shard group for a
+	// database whose placement no data node matches.
+	ErrNoNodesMatchPlacement = errors.New("no data nodes match the placement of the database")
 )
 
 var (
@@ -44,6 +52,10 @@ var (
 	// ErrDatabaseNameRequired is returned when creating a database without a name.
 	ErrDatabaseNameRequired = errors.New("database name required")
 
+	// ErrShardKeyConflict is returned when creating a database that already
+	// exists with a different shard key.
+	ErrShardKeyConflict = errors.New("shard key conflicts with the existing database")
+
 	// ErrInvalidName is returned when attempting to create a database or retention policy with an invalid name
 	ErrInvalidName = errors.New("invalid name")
 )
@@ -83,6 +95,10 @@ var (
 	// duration.
 	ErrIncompatibleDurations = errors.New("retention policy duration must be greater than the shard duration")
 
+	// ErrMeasurementShardGroupDurationOnly is returned when updating a
+	// retention policy for a measurement with anything but a shard duration.
+	ErrMeasurementShardGroupDurationOnly = errors.New("only the shard duration of a retention policy can be set for a measurement")
+
 	// ErrReplicationFactorTooLow is returned when the replication factor is not in an
 	// acceptable range.
 	ErrReplicationFactorTooLow = errors.New("replication factor must be greater than 0")
@@ -95,9 +111,20 @@ var (
 	// ErrShardGroupNotFound is returned when mutating a shard group that doesn't exist.
 	ErrShardGroupNotFound = errors.New("shard group not found")
 
+	// ErrShardNotFound is returned when mutating a shard that doesn't exist.
+	ErrShardNotFound = errors.New("shard not found")
+
 	// ErrShardNotReplicated is returned if the node requested to be dropped has
 	// the last copy of a shard present and the force keyword was not used
 	ErrShardNotReplicated = errors.New("shard not replicated")
+
+	// ErrShardGroupMergeInvalid is returned when merging shard groups that
+	// cannot be merged, such as groups whose shards have different owners.
+	ErrShardGroupMergeInvalid = errors.New("shard groups cannot be merged")
+
+	// ErrShardGroupMergeNotFound is returned when completing a shard group
+	// merge that doesn't exist.
+	ErrShardGroupMergeNotFound = errors.New("shard group merge not found")
 )
 
 var (
@@ -106,6 +133,14 @@ var (
 
 	// ErrContinuousQueryNotFound is returned when removing a continuous query that doesn't exist.
 	ErrContinuousQueryNotFound = errors.New("continuous query not found")
+
+	// ErrMaterializedViewExists is returned when creating an already existing
+	// materialized view with a different query.
+	ErrMaterializedViewExists = errors.New("materialized view already exists")
+
+	// ErrMaterializedViewNotFound is returned when removing a materialized view
+	// that doesn't exist.
+	ErrMaterializedViewNotFound = errors.New("materialized view not found")
 )
 
 var (
@@ -121,6 +156,26 @@ func ErrInvalidSubscriptionURL(url string) error {
 	return fmt.Errorf("invalid subscription URL: %s", url)
 }
 
+var (
+	// ErrDatabaseTemplateExists is returned when creating a template whose
+	// name is taken by a different template.
+	ErrDatabaseTemplateExists = errors.New("database template already exists")
+
+	// ErrDatabaseTemplateNotFound is returned when using a template that doesn't exist.
+	ErrDatabaseTemplateNotFound = errors.New("database template not found")
+
+	// ErrDatabaseTemplateNameRequired is returned when creating a template without a name.
+	ErrDatabaseTemplateNameRequired = errors.New("database template name required")
+
+	// ErrDatabaseTemplateInvalidContinuousQuery is returned when a template
+	// contains a continuous query without a name or query text.
+	ErrDatabaseTemplateInvalidContinuousQuery = errors.New("database template continuous query requires a name and query")
+
+	// ErrDatabaseTemplateInvalidPrivilege is returned when a template grant
+	// does not use READ, WRITE or ALL.
+	ErrDatabaseTemplateInvalidPrivilege = errors.New("database template grant has an invalid privilege")
+)
+
 var (
 	// ErrUserExists is returned when creating an already existing user.
 	ErrUserExists = errors.New("user already exists")
@@ -133,4 +188,86 @@ var (
 
 	// ErrAuthenticate is returned when authentication fails.
 	ErrAuthenticate = errors.New("authentication failed")
+
+	// ErrPasswordExpired is returned when authenticating with a password
+	// older than the password policy's max-age.
+	ErrPasswordExpired = errors.New("password expired")
+
+	// ErrPasswordReused is returned when setting a user's password to its
+	// current one while the password policy prevents reuse.
+	ErrPasswordReused = errors.New("password cannot be reused")
+)
+
+var (
+	// ErrRoleExists is returned when creating an already existing role.
+	ErrRoleExists = errors.New("role already exists")
+
+	// ErrRoleNotFound is returned when mutating a role that doesn't exist.
+	ErrRoleNotFound = errors.New("role not found")
+
+	// ErrRoleNameRequired is returned when creating a role without a name.
+	ErrRoleNameRequired = errors.New("role name required")
+)
+
+var (
+	// ErrSessionExists is returned when creating an already existing session.
+	ErrSessionExists = errors.New("session already exists")
+
+	// ErrSessionNotFound is returned when dropping a session that doesn't exist.
+	ErrSessionNotFound = errors.New("session not found")
+
+	// ErrSessionIDRequired is returned when creating a session without an ID.
+	ErrSessionIDRequired = errors.New("session id required")
+)
+
+var (
+	// ErrEnrollmentTokenExists is returned when creating an already existing
+	// enrollment token.
+	ErrEnrollmentTokenExists = errors.New("enrollment token already exists")
+
+	// ErrEnrollmentTokenNotFound is returned when redeeming an enrollment
+	// token that was never issued, was already redeemed or has expired.
+	ErrEnrollmentTokenNotFound = errors.New("invalid or expired enrollment token")
+
+	// ErrEnrollmentTokenHashRequired is returned when creating an enrollment
+	// token without its hash.
+	ErrEnrollmentTokenHashRequired = errors.New("enrollment token hash required")
+
+	// ErrEnrollmentTokenDatabasesRequired is returned when creating an
+	// enrollment token granting no database.
+	ErrEnrollmentTokenDatabasesRequired = errors.New("at least one database is required")
+
+	// ErrAgentNameRequired is returned when creating an enrollment token
+	// without the name of the agent.
+	ErrAgentNameRequired = errors.New("agent name required")
+
+	// ErrAgentSerialRequired is returned when redeeming an enrollment token
+	// without the serial number of the certificate.
+	ErrAgentSerialRequired = errors.New("agent certificate serial number required")
+
+	// ErrAgentExists is returned when recording a certificate whose serial
+	// number was already issued.
+	ErrAgentExists = errors.New("agent certificate already exists")
+
+	// ErrAgentNotFound is returned when revoking an agent that was never
+	// enrolled.
+	ErrAgentNotFound = errors.New("agent not found")
+)
+
+var (
+	// ErrQueryTemplateNotFound is returned when using a query template that
+	// doesn't exist.
+	ErrQueryTemplateNotFound = errors.New("query template not found")
+
+	// ErrQueryTemplateVersionNotFound is returned when using a version of a
+	// query template that doesn't exist.
+	ErrQueryTemplateVersionNotFound = errors.New("query template version not found")
+
+	// ErrQueryTemplateNameRequired is returned when creating a query template
+	// without a name.
+	ErrQueryTemplateNameRequired = errors.New("query template name required")
+
+	// ErrQueryTemplateQueryRequired is returned when creating a query
+	// template without a query.
+	ErrQueryTemplateQueryRequired = errors.New("query template query required")
 )
@@ -6,9 +6,11 @@
 Package internal is a generated protocol buffer package.
 
 It is generated from these files:
+
 	internal/meta.proto
 
 It has these top-level messages:
+
 	Data
 	NodeInfo
 	DatabaseInfo
@@ -52,6 +54,45 @@ It has these top-level messages:
 	Response
 	SetMetaNodeCommand
 	DropShardCommand
+	DatabaseTemplateInfo
+	DatabaseTemplateGrant
+	DatabaseTemplateLimits
+	CreateDatabaseTemplateCommand
+	DropDatabaseTemplateCommand
+	InstantiateDatabaseTemplateCommand
+	ShardGroupMergeInfo
+	CreateShardGroupMergeCommand
+	CompleteShardGroupMergeCommand
+	MeasurementShardGroupDuration
+	RoleInfo
+	CreateRoleCommand
+	DropRoleCommand
+	SetRolePrivilegeCommand
+	SetUserRoleCommand
+	SessionInfo
+	CreateSessionCommand
+	DropSessionCommand
+	AddShardOwnerCommand
+	SetDataNodeModeCommand
+	SetDatabaseFrozenCommand
+	QueryTemplateInfo
+	QueryTemplateVersion
+	CreateQueryTemplateCommand
+	DropQueryTemplateCommand
+	MaterializedViewInfo
+	CreateMaterializedViewCommand
+	DropMaterializedViewCommand
+	NodeLabel
+	PlacementInfo
+	SetDataNodeLabelsCommand
+	SetDatabasePlacementCommand
+	SetSubscriptionPausedCommand
+	RemoveShardOwnerCommand
+	EnrollmentTokenInfo
+	AgentInfo
+	CreateEnrollmentTokenCommand
+	RedeemEnrollmentTokenCommand
+	RevokeAgentCommand
 */
 package internal
 
@@ -73,35 +114,60 @@ const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
 type Command_Type int32
 
 const (
-	Command_CreateNodeCommand                Command_Type = 1
-	Command_DeleteNodeCommand                Command_Type = 2
-	Command_CreateDatabaseCommand            Command_Type = 3
-	Command_DropDatabaseCommand              Command_Type = 4
-	Command_CreateRetentionPolicyCommand     Command_Type = 5
-	Command_DropRetentionPolicyCommand       Command_Type = 6
-	Command_SetDefaultRetentionPolicyCommand Command_Type = 7
-	Command_UpdateRetentionPolicyCommand     Command_Type = 8
-	Command_CreateShardGroupCommand          Command_Type = 9
-	Command_DeleteShardGroupCommand          Command_Type = 10
-	Command_CreateContinuousQueryCommand     Command_Type = 11
-	Command_DropContinuousQueryCommand       Command_Type = 12
-	Command_CreateUserCommand                Command_Type = 13
-	Command_DropUserCommand                  Command_Type = 14
-	Command_UpdateUserCommand                Command_Type = 15
-	Command_SetPrivilegeCommand              Command_Type = 16
-	Command_SetDataCommand                   Command_Type = 17
-	Command_SetAdminPrivilegeCommand         Command_Type = 18
-	Command_UpdateNodeCommand                Command_Type = 19
-	Command_CreateSubscriptionCommand        Command_Type = 21
-	Command_DropSubscriptionCommand          Command_Type = 22
-	Command_RemovePeerCommand                Command_Type = 23
-	Command_CreateMetaNodeCommand            Command_Type = 24
-	Command_CreateDataNodeCommand            Command_Type = 25
-	Command_UpdateDataNodeCommand            Command_Type = 26
-	Command_DeleteMetaNodeCommand            Command_Type = 27
-	Command_DeleteDataNodeCommand            Command_Type = 28
-	Command_SetMetaNodeCommand               Command_Type = 29
-	Command_DropShardCommand                 Command_Type = 30
+	Command_CreateNodeCommand                  Command_Type = 1
+	Command_DeleteNodeCommand                  Command_Type = 2
+	Command_CreateDatabaseCommand              Command_Type = 3
+	Command_DropDatabaseCommand                Command_Type = 4
+	Command_CreateRetentionPolicyCommand       Command_Type = 5
+	Command_DropRetentionPolicyCommand         Command_Type = 6
+	Command_SetDefaultRetentionPolicyCommand   Command_Type = 7
+	Command_UpdateRetentionPolicyCommand       Command_Type = 8
+	Command_CreateShardGroupCommand            Command_Type = 9
+	Command_DeleteShardGroupCommand            Command_Type = 10
+	Command_CreateContinuousQueryCommand       Command_Type = 11
+	Command_DropContinuousQueryCommand         Command_Type = 12
+	Command_CreateUserCommand                  Command_Type = 13
+	Command_DropUserCommand                    Command_Type = 14
+	Command_UpdateUserCommand                  Command_Type = 15
+	Command_SetPrivilegeCommand                Command_Type = 16
+	Command_SetDataCommand                     Command_Type = 17
+	Command_SetAdminPrivilegeCommand           Command_Type = 18
+	Command_UpdateNodeCommand                  Command_Type = 19
+	Command_CreateSubscriptionCommand          Command_Type = 21
+	Command_DropSubscriptionCommand            Command_Type = 22
+	Command_RemovePeerCommand                  Command_Type = 23
+	Command_CreateMetaNodeCommand              Command_Type = 24
+	Command_CreateDataNodeCommand              Command_Type = 25
+	Command_UpdateDataNodeCommand              Command_Type = 26
+	Command_DeleteMetaNodeCommand              Command_Type = 27
+	Command_DeleteDataNodeCommand              Command_Type = 28
+	Command_SetMetaNodeCommand                 Command_Type = 29
+	Command_DropShardCommand                   Command_Type = 30
+	Command_CreateDatabaseTemplateCommand      Command_Type = 31
+	Command_DropDatabaseTemplateCommand        Command_Type = 32
+	Command_InstantiateDatabaseTemplateCommand Command_Type = 33
+	Command_CreateShardGroupMergeCommand       Command_Type = 34
+	Command_CompleteShardGroupMergeCommand     Command_Type = 35
+	Command_CreateRoleCommand                  Command_Type = 36
+	Command_DropRoleCommand                    Command_Type = 37
+	Command_SetRolePrivilegeCommand            Command_Type = 38
+	Command_SetUserRoleCommand                 Command_Type = 39
+	Command_CreateSessionCommand               Command_Type = 40
+	Command_DropSessionCommand                 Command_Type = 41
+	Command_AddShardOwnerCommand               Command_Type = 42
+	Command_SetDataNodeModeCommand             Command_Type = 43
+	Command_SetDatabaseFrozenCommand           Command_Type = 44
+	Command_CreateQueryTemplateCommand         Command_Type = 45
+	Command_DropQueryTemplateCommand           Command_Type = 46
+	Command_CreateMaterializedViewCommand      Command_Type = 47
+	Command_DropMaterializedViewCommand        Command_Type = 48
+	Command_SetDataNodeLabelsCommand           Command_Type = 49
+	Command_SetDatabasePlacementCommand        Command_Type = 50
+	Command_SetSubscriptionPausedCommand       Command_Type = 51
+	Command_RemoveShardOwnerCommand            Command_Type = 52
+	Command_CreateEnrollmentTokenCommand       Command_Type = 53
+	Command_RedeemEnrollmentTokenCommand       Command_Type = 54
+	Command_RevokeAgentCommand                 Command_Type = 55
 )
 
 var Command_Type_name = map[int32]string{
@@ -134,37 +200,87 @@ var Command_Type_name = map[int32]string{
 	28: "DeleteDataNodeCommand",
 	29: "SetMetaNodeCommand",
 	30: "DropShardCommand",
+	31: "CreateDatabaseTemplateCommand",
+	32: "DropDatabaseTemplateCommand",
+	33: "InstantiateDatabaseTemplateCommand",
+	34: "CreateShardGroupMergeCommand",
+	35: "CompleteShardGroupMergeCommand",
+	36: "CreateRoleCommand",
+	37: "DropRoleCommand",
+	38: "SetRolePrivilegeCommand",
+	39: "SetUserRoleCommand",
+	40: "CreateSessionCommand",
+	41: "DropSessionCommand",
+	42: "AddShardOwnerCommand",
+	43: "SetDataNodeModeCommand",
+	44: "SetDatabaseFrozenCommand",
+	45: "CreateQueryTemplateCommand",
+	46: "DropQueryTemplateCommand",
+	47: "CreateMaterializedViewCommand",
+	48: "DropMaterializedViewCommand",
+	49: "SetDataNodeLabelsCommand",
+	50: "SetDatabasePlacementCommand",
+	51: "SetSubscriptionPausedCommand",
+	52: "RemoveShardOwnerCommand",
+	53: "CreateEnrollmentTokenCommand",
+	54: "RedeemEnrollmentTokenCommand",
+	55: "RevokeAgentCommand",
 }
 var Command_Type_value = map[string]int32{
-	"CreateNodeCommand":                1,
-	"DeleteNodeCommand":                2,
-	"CreateDatabaseCommand":            3,
-	"DropDatabaseCommand":              4,
-	"CreateRetentionPolicyCommand":     5,
-	"DropRetentionPolicyCommand":       6,
-	"SetDefaultRetentionPolicyCommand": 7,
-	"UpdateRetentionPolicyCommand":     8,
-	"CreateShardGroupCommand":          9,
-	"DeleteShardGroupCommand":          10,
-	"CreateContinuousQueryCommand":     11,
-	"DropContinuousQueryCommand":       12,
-	"CreateUserCommand":                13,
-	"DropUserCommand":                  14,
-	"UpdateUserCommand":                15,
-	"SetPrivilegeCommand":              16,
-	"SetDataCommand":                   17,
-	"SetAdminPrivilegeCommand":         18,
-	"UpdateNodeCommand":                19,
-	"CreateSubscriptionCommand":        21,
-	"DropSubscriptionCommand":          22,
-	"RemovePeerCommand":                23,
-	"CreateMetaNodeCommand":            24,
-	"CreateDataNodeCommand":            25,
-	"UpdateDataNodeCommand":            26,
-	"DeleteMetaNodeCommand":            27,
-	"DeleteDataNodeCommand":            28,
-	"SetMetaNodeCommand":               29,
-	"DropShardCommand":                 30,
+	"CreateNodeCommand":                  1,
+	"DeleteNodeCommand":                  2,
+	"CreateDatabaseCommand":              3,
+	"DropDatabaseCommand":                4,
+	"CreateRetentionPolicyCommand":       5,
+	"DropRetentionPolicyCommand":         6,
+	"SetDefaultRetentionPolicyCommand":   7,
+	"UpdateRetentionPolicyCommand":       8,
+	"CreateShardGroupCommand":            9,
+	"DeleteShardGroupCommand":            10,
+	"CreateContinuousQueryCommand":       11,
+	"DropContinuousQueryCommand":         12,
+	"CreateUserCommand":                  13,
+	"DropUserCommand":                    14,
+	"UpdateUserCommand":                  15,
+	"SetPrivilegeCommand":                16,
+	"SetDataCommand":                     17,
+	"SetAdminPrivilegeCommand":           18,
+	"UpdateNodeCommand":                  19,
+	"CreateSubscriptionCommand":          21,
+	"DropSubscriptionCommand":            22,
+	"RemovePeerCommand":                  23,
+	"CreateMetaNodeCommand":              24,
+	"CreateDataNodeCommand":              25,
+	"UpdateDataNodeCommand":              26,
+	"DeleteMetaNodeCommand":              27,
+	"DeleteDataNodeCommand":              28,
+	"SetMetaNodeCommand":                 29,
+	"DropShardCommand":                   30,
+	"CreateDatabaseTemplateCommand":      31,
+	"DropDatabaseTemplateCommand":        32,
+	"InstantiateDatabaseTemplateCommand": 33,
+	"CreateShardGroupMergeCommand":       34,
+	"CompleteShardGroupMergeCommand":     35,
+	"CreateRoleCommand":                  36,
+	"DropRoleCommand":                    37,
+	"SetRolePrivilegeCommand":            38,
+	"SetUserRoleCommand":                 39,
+	"CreateSessionCommand":               40,
+	"DropSessionCommand":                 41,
+	"AddShardOwnerCommand":               42,
+	"SetDataNodeModeCommand":             43,
+	"SetDatabaseFrozenCommand":           44,
+	"CreateQueryTemplateCommand":         45,
+	"DropQueryTemplateCommand":           46,
+	"CreateMaterializedViewCommand":      47,
+	"DropMaterializedViewCommand":        48,
+	"SetDataNodeLabelsCommand":           49,
+	"SetDatabasePlacementCommand":        50,
+	"SetSubscriptionPausedCommand":       51,
+	"RemoveShardOwnerCommand":            52,
+	"CreateEnrollmentTokenCommand":       53,
+	"RedeemEnrollmentTokenCommand":       54,
+	"RevokeAgentCommand":                 55,
 }
 
 func (x Command_Type) Enum() *Command_Type {
@@ -183,7 +299,7 @@ func (x *Command_Type) UnmarshalJSON(data []byte) error {
 	*x = Command_Type(value)
 	return nil
 }
-func (Command_Type) EnumDescriptor() ([]byte, []int) { return fileDescriptorMeta, []int{12, 0} }
+func (Command_Type) EnumDescriptor() ([]byte, []int) { return fileDescriptorMeta, []int{14, 0} }
 
 type Data struct {
 	Term            *uint64         `protobuf:"varint,1,req,name=Term" json:"Term,omitempty"`
@@ -196,9 +312,15 @@ type Data struct {
 	MaxShardGroupID *uint64         `protobuf:"varint,8,req,name=MaxShardGroupID" json:"MaxShardGroupID,omitempty"`
 	MaxShardID      *uint64         `protobuf:"varint,9,req,name=MaxShardID" json:"MaxShardID,omitempty"`
 	// added for 0.10.0
-	DataNodes        []*NodeInfo `protobuf:"bytes,10,rep,name=DataNodes" json:"DataNodes,omitempty"`
-	MetaNodes        []*NodeInfo `protobuf:"bytes,11,rep,name=MetaNodes" json:"MetaNodes,omitempty"`
-	XXX_unrecognized []byte      `json:"-"`
+	DataNodes         []*NodeInfo             `protobuf:"bytes,10,rep,name=DataNodes" json:"DataNodes,omitempty"`
+	MetaNodes         []*NodeInfo             `protobuf:"bytes,11,rep,name=MetaNodes" json:"MetaNodes,omitempty"`
+	DatabaseTemplates []*DatabaseTemplateInfo `protobuf:"bytes,12,rep,name=DatabaseTemplates" json:"DatabaseTemplates,omitempty"`
+	Roles             []*RoleInfo             `protobuf:"bytes,13,rep,name=Roles" json:"Roles,omitempty"`
+	Sessions          []*SessionInfo          `protobuf:"bytes,14,rep,name=Sessions" json:"Sessions,omitempty"`
+	QueryTemplates    []*QueryTemplateInfo    `protobuf:"bytes,15,rep,name=QueryTemplates" json:"QueryTemplates,omitempty"`
+	EnrollmentTokens  []*EnrollmentTokenInfo  `protobuf:"bytes,16,rep,name=EnrollmentTokens" json:"EnrollmentTokens,omitempty"`
+	Agents            []*AgentInfo            `protobuf:"bytes,17,rep,name=Agents" json:"Agents,omitempty"`
+	XXX_unrecognized  []byte                  `json:"-"`
 }
 
 func (m *Data) Reset()                    { *m = Data{} }
@@ -283,11 +405,57 @@ func (m *Data) GetMetaNodes() []*NodeInfo {
 	return nil
 }
 
+func (m *Data) GetDatabaseTemplates() []*DatabaseTemplateInfo {
+	if m != nil {
+		return m.DatabaseTemplates
+	}
+	return nil
+}
+
+func (m *Data) GetRoles() []*RoleInfo {
+	if m != nil {
+		return m.Roles
+	}
+	return nil
+}
+
+func (m *Data) GetSessions() []*SessionInfo {
+	if m != nil {
+		return m.Sessions
+	}
+	return nil
+}
+
+func (m *Data) GetQueryTemplates() []*QueryTemplateInfo {
+	if m != nil {
+		return m.QueryTemplates
+	}
+	return nil
+}
+
+func (m *Data) GetEnrollmentTokens() []*EnrollmentTokenInfo {
+	if m != nil {
+		return m.EnrollmentTokens
+	}
+	return nil
+}
+
+func (m *Data) GetAgents() []*AgentInfo {
+	if m != nil {
+		return m.Agents
+	}
+	return nil
+}
+
 type NodeInfo struct {
-	ID               *uint64 `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
-	Host             *string `protobuf:"bytes,2,req,name=Host" json:"Host,omitempty"`
-	TCPHost          *string `protobuf:"bytes,3,opt,name=TCPHost" json:"TCPHost,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
+	ID               *uint64      `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
+	Host             *string      `protobuf:"bytes,2,req,name=Host" json:"Host,omitempty"`
+	TCPHost          *string      `protobuf:"bytes,3,opt,name=TCPHost" json:"TCPHost,omitempty"`
+	ReadOnly         *bool        `protobuf:"varint,4,opt,name=ReadOnly" json:"ReadOnly,omitempty"`
+	ReadOnlyReason   *string      `protobuf:"bytes,5,opt,name=ReadOnlyReason" json:"ReadOnlyReason,omitempty"`
+	Maintenance      *bool        `protobuf:"varint,6,opt,name=Maintenance" json:"Maintenance,omitempty"`
+	Labels           []*NodeLabel `protobuf:"bytes,7,rep,name=Labels" json:"Labels,omitempty"`
+	XXX_unrecognized []byte       `json:"-"`
 }
 
 func (m *NodeInfo) Reset()                    { *m = NodeInfo{} }
@@ -316,18 +484,50 @@ func (m *NodeInfo) GetTCPHost() string {
 	return ""
 }
 
+func (m *NodeInfo) GetReadOnly() bool {
+	if m != nil && m.ReadOnly != nil {
+		return *m.ReadOnly
+	}
+	return false
+}
+
+func (m *NodeInfo) GetReadOnlyReason() string {
+	if m != nil && m.ReadOnlyReason != nil {
+		return *m.ReadOnlyReason
+	}
+	return ""
+}
+
+func (m *NodeInfo) GetMaintenance() bool {
+	if m != nil && m.Maintenance != nil {
+		return *m.Maintenance
+	}
+	return false
+}
+
+func (m *NodeInfo) GetLabels() []*NodeLabel {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
 type DatabaseInfo struct {
-	Name                   *string                `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
-	DefaultRetentionPolicy *string                `protobuf:"bytes,2,req,name=DefaultRetentionPolicy" json:"DefaultRetentionPolicy,omitempty"`
-	RetentionPolicies      []*RetentionPolicyInfo `protobuf:"bytes,3,rep,name=RetentionPolicies" json:"RetentionPolicies,omitempty"`
-	ContinuousQueries      []*ContinuousQueryInfo `protobuf:"bytes,4,rep,name=ContinuousQueries" json:"ContinuousQueries,omitempty"`
-	XXX_unrecognized       []byte                 `json:"-"`
+	Name                   *string                 `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	DefaultRetentionPolicy *string                 `protobuf:"bytes,2,req,name=DefaultRetentionPolicy" json:"DefaultRetentionPolicy,omitempty"`
+	RetentionPolicies      []*RetentionPolicyInfo  `protobuf:"bytes,3,rep,name=RetentionPolicies" json:"RetentionPolicies,omitempty"`
+	ContinuousQueries      []*ContinuousQueryInfo  `protobuf:"bytes,4,rep,name=ContinuousQueries" json:"ContinuousQueries,omitempty"`
+	ShardKey               []string                `protobuf:"bytes,5,rep,name=ShardKey" json:"ShardKey,omitempty"`
+	Frozen                 *bool                   `protobuf:"varint,6,opt,name=Frozen" json:"Frozen,omitempty"`
+	MaterializedViews      []*MaterializedViewInfo `protobuf:"bytes,7,rep,name=MaterializedViews" json:"MaterializedViews,omitempty"`
+	Placement              *PlacementInfo          `protobuf:"bytes,8,opt,name=Placement" json:"Placement,omitempty"`
+	XXX_unrecognized       []byte                  `json:"-"`
 }
 
 func (m *DatabaseInfo) Reset()                    { *m = DatabaseInfo{} }
 func (m *DatabaseInfo) String() string            { return proto.CompactTextString(m) }
 func (*DatabaseInfo) ProtoMessage()               {}
-func (*DatabaseInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{2} }
+func (*DatabaseInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{3} }
 
 func (m *DatabaseInfo) GetName() string {
 	if m != nil && m.Name != nil {
@@ -357,18 +557,48 @@ func (m *DatabaseInfo) GetContinuousQueries() []*ContinuousQueryInfo {
 	return nil
 }
 
+func (m *DatabaseInfo) GetShardKey() []string {
+	if m != nil {
+		return m.ShardKey
+	}
+	return nil
+}
+
+func (m *DatabaseInfo) GetFrozen() bool {
+	if m != nil && m.Frozen != nil {
+		return *m.Frozen
+	}
+	return false
+}
+
+func (m *DatabaseInfo) GetMaterializedViews() []*MaterializedViewInfo {
+	if m != nil {
+		return m.MaterializedViews
+	}
+	return nil
+}
+
+func (m *DatabaseInfo) GetPlacement() *PlacementInfo {
+	if m != nil {
+		return m.Placement
+	}
+	return nil
+}
+
 type RetentionPolicySpec struct {
 	Name               *string `protobuf:"bytes,1,opt,name=Name" json:"Name,omitempty"`
 	Duration           *int64  `protobuf:"varint,2,opt,name=Duration" json:"Duration,omitempty"`
 	ShardGroupDuration *int64  `protobuf:"varint,3,opt,name=ShardGroupDuration" json:"ShardGroupDuration,omitempty"`
 	ReplicaN           *uint32 `protobuf:"varint,4,opt,name=ReplicaN" json:"ReplicaN,omitempty"`
+	FutureWriteLimit   *int64  `protobuf:"varint,5,opt,name=FutureWriteLimit" json:"FutureWriteLimit,omitempty"`
+	PastWriteLimit     *int64  `protobuf:"varint,6,opt,name=PastWriteLimit" json:"PastWriteLimit,omitempty"`
 	XXX_unrecognized   []byte  `json:"-"`
 }
 
 func (m *RetentionPolicySpec) Reset()                    { *m = RetentionPolicySpec{} }
 func (m *RetentionPolicySpec) String() string            { return proto.CompactTextString(m) }
 func (*RetentionPolicySpec) ProtoMessage()               {}
-func (*RetentionPolicySpec) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{3} }
+func (*RetentionPolicySpec) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{5} }
 
 func (m *RetentionPolicySpec) GetName() string {
 	if m != nil && m.Name != nil {
@@ -398,20 +628,38 @@ func (m *RetentionPolicySpec) GetReplicaN() uint32 {
 	return 0
 }
 
+func (m *RetentionPolicySpec) GetFutureWriteLimit() int64 {
+	if m != nil && m.FutureWriteLimit != nil {
+		return *m.FutureWriteLimit
+	}
+	return 0
+}
+
+func (m *RetentionPolicySpec) GetPastWriteLimit() int64 {
+	if m != nil && m.PastWriteLimit != nil {
+		return *m.PastWriteLimit
+	}
+	return 0
+}
+
 type RetentionPolicyInfo struct {
-	Name               *string             `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
-	Duration           *int64              `protobuf:"varint,2,req,name=Duration" json:"Duration,omitempty"`
-	ShardGroupDuration *int64              `protobuf:"varint,3,req,name=ShardGroupDuration" json:"ShardGroupDuration,omitempty"`
-	ReplicaN           *uint32             `protobuf:"varint,4,req,name=ReplicaN" json:"ReplicaN,omitempty"`
-	ShardGroups        []*ShardGroupInfo   `protobuf:"bytes,5,rep,name=ShardGroups" json:"ShardGroups,omitempty"`
-	Subscriptions      []*SubscriptionInfo `protobuf:"bytes,6,rep,name=Subscriptions" json:"Subscriptions,omitempty"`
-	XXX_unrecognized   []byte              `json:"-"`
+	Name                           *string                          `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Duration                       *int64                           `protobuf:"varint,2,req,name=Duration" json:"Duration,omitempty"`
+	ShardGroupDuration             *int64                           `protobuf:"varint,3,req,name=ShardGroupDuration" json:"ShardGroupDuration,omitempty"`
+	ReplicaN                       *uint32                          `protobuf:"varint,4,req,name=ReplicaN" json:"ReplicaN,omitempty"`
+	ShardGroups                    []*ShardGroupInfo                `protobuf:"bytes,5,rep,name=ShardGroups" json:"ShardGroups,omitempty"`
+	Subscriptions                  []*SubscriptionInfo              `protobuf:"bytes,6,rep,name=Subscriptions" json:"Subscriptions,omitempty"`
+	FutureWriteLimit               *int64                           `protobuf:"varint,7,opt,name=FutureWriteLimit" json:"FutureWriteLimit,omitempty"`
+	PastWriteLimit                 *int64                           `protobuf:"varint,8,opt,name=PastWriteLimit" json:"PastWriteLimit,omitempty"`
+	ShardGroupMerges               []*ShardGroupMergeInfo           `protobuf:"bytes,9,rep,name=ShardGroupMerges" json:"ShardGroupMerges,omitempty"`
+	MeasurementShardGroupDurations []*MeasurementShardGroupDuration `protobuf:"bytes,10,rep,name=MeasurementShardGroupDurations" json:"MeasurementShardGroupDurations,omitempty"`
+	XXX_unrecognized               []byte                           `json:"-"`
 }
 
 func (m *RetentionPolicyInfo) Reset()                    { *m = RetentionPolicyInfo{} }
 func (m *RetentionPolicyInfo) String() string            { return proto.CompactTextString(m) }
 func (*RetentionPolicyInfo) ProtoMessage()               {}
-func (*RetentionPolicyInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{4} }
+func (*RetentionPolicyInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{6} }
 
 func (m *RetentionPolicyInfo) GetName() string {
 	if m != nil && m.Name != nil {
@@ -455,6 +703,34 @@ func (m *RetentionPolicyInfo) GetSubscriptions() []*SubscriptionInfo {
 	return nil
 }
 
+func (m *RetentionPolicyInfo) GetFutureWriteLimit() int64 {
+	if m != nil && m.FutureWriteLimit != nil {
+		return *m.FutureWriteLimit
+	}
+	return 0
+}
+
+func (m *RetentionPolicyInfo) GetPastWriteLimit() int64 {
+	if m != nil && m.PastWriteLimit != nil {
+		return *m.PastWriteLimit
+	}
+	return 0
+}
+
+func (m *RetentionPolicyInfo) GetShardGroupMerges() []*ShardGroupMergeInfo {
+	if m != nil {
+		return m.ShardGroupMerges
+	}
+	return nil
+}
+
+func (m *RetentionPolicyInfo) GetMeasurementShardGroupDurations() []*MeasurementShardGroupDuration {
+	if m != nil {
+		return m.MeasurementShardGroupDurations
+	}
+	return nil
+}
+
 type ShardGroupInfo struct {
 	ID               *uint64      `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
 	StartTime        *int64       `protobuf:"varint,2,req,name=StartTime" json:"StartTime,omitempty"`
@@ -462,13 +738,14 @@ type ShardGroupInfo struct {
 	DeletedAt        *int64       `protobuf:"varint,4,req,name=DeletedAt" json:"DeletedAt,omitempty"`
 	Shards           []*ShardInfo `protobuf:"bytes,5,rep,name=Shards" json:"Shards,omitempty"`
 	TruncatedAt      *int64       `protobuf:"varint,6,opt,name=TruncatedAt" json:"TruncatedAt,omitempty"`
+	Measurement      *string      `protobuf:"bytes,7,opt,name=Measurement" json:"Measurement,omitempty"`
 	XXX_unrecognized []byte       `json:"-"`
 }
 
 func (m *ShardGroupInfo) Reset()                    { *m = ShardGroupInfo{} }
 func (m *ShardGroupInfo) String() string            { return proto.CompactTextString(m) }
 func (*ShardGroupInfo) ProtoMessage()               {}
-func (*ShardGroupInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{5} }
+func (*ShardGroupInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{7} }
 
 func (m *ShardGroupInfo) GetID() uint64 {
 	if m != nil && m.ID != nil {
@@ -512,6 +789,13 @@ func (m *ShardGroupInfo) GetTruncatedAt() int64 {
 	return 0
 }
 
+func (m *ShardGroupInfo) GetMeasurement() string {
+	if m != nil && m.Measurement != nil {
+		return *m.Measurement
+	}
+	return ""
+}
+
 type ShardInfo struct {
 	ID               *uint64       `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
 	OwnerIDs         []uint64      `protobuf:"varint,2,rep,name=OwnerIDs" json:"OwnerIDs,omitempty"`
@@ -522,7 +806,7 @@ type ShardInfo struct {
 func (m *ShardInfo) Reset()                    { *m = ShardInfo{} }
 func (m *ShardInfo) String() string            { return proto.CompactTextString(m) }
 func (*ShardInfo) ProtoMessage()               {}
-func (*ShardInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{6} }
+func (*ShardInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{8} }
 
 func (m *ShardInfo) GetID() uint64 {
 	if m != nil && m.ID != nil {
@@ -549,13 +833,18 @@ type SubscriptionInfo struct {
 	Name             *string  `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
 	Mode             *string  `protobuf:"bytes,2,req,name=Mode" json:"Mode,omitempty"`
 	Destinations     []string `protobuf:"bytes,3,rep,name=Destinations" json:"Destinations,omitempty"`
+	Measurements     []string `protobuf:"bytes,4,rep,name=Measurements" json:"Measurements,omitempty"`
+	Condition        *string  `protobuf:"bytes,5,opt,name=Condition" json:"Condition,omitempty"`
+	StripTags        []string `protobuf:"bytes,6,rep,name=StripTags" json:"StripTags,omitempty"`
+	ResampleEvery    *int64   `protobuf:"varint,7,opt,name=ResampleEvery" json:"ResampleEvery,omitempty"`
+	Paused           *bool    `protobuf:"varint,8,opt,name=Paused" json:"Paused,omitempty"`
 	XXX_unrecognized []byte   `json:"-"`
 }
 
 func (m *SubscriptionInfo) Reset()                    { *m = SubscriptionInfo{} }
 func (m *SubscriptionInfo) String() string            { return proto.CompactTextString(m) }
 func (*SubscriptionInfo) ProtoMessage()               {}
-func (*SubscriptionInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{7} }
+func (*SubscriptionInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{9} }
 
 func (m *SubscriptionInfo) GetName() string {
 	if m != nil && m.Name != nil {
@@ -578,6 +867,41 @@ func (m *SubscriptionInfo) GetDestinations() []string {
 	return nil
 }
 
+func (m *SubscriptionInfo) GetMeasurements() []string {
+	if m != nil {
+		return m.Measurements
+	}
+	return nil
+}
+
+func (m *SubscriptionInfo) GetCondition() string {
+	if m != nil && m.Condition != nil {
+		return *m.Condition
+	}
+	return ""
+}
+
+func (m *SubscriptionInfo) GetStripTags() []string {
+	if m != nil {
+		return m.StripTags
+	}
+	return nil
+}
+
+func (m *SubscriptionInfo) GetResampleEvery() int64 {
+	if m != nil && m.ResampleEvery != nil {
+		return *m.ResampleEvery
+	}
+	return 0
+}
+
+func (m *SubscriptionInfo) GetPaused() bool {
+	if m != nil && m.Paused != nil {
+		return *m.Paused
+	}
+	return false
+}
+
 type ShardOwner struct {
 	NodeID           *uint64 `protobuf:"varint,1,req,name=NodeID" json:"NodeID,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
@@ -586,7 +910,7 @@ type ShardOwner struct {
 func (m *ShardOwner) Reset()                    { *m = ShardOwner{} }
 func (m *ShardOwner) String() string            { return proto.CompactTextString(m) }
 func (*ShardOwner) ProtoMessage()               {}
-func (*ShardOwner) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{8} }
+func (*ShardOwner) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{10} }
 
 func (m *ShardOwner) GetNodeID() uint64 {
 	if m != nil && m.NodeID != nil {
@@ -604,7 +928,7 @@ type ContinuousQueryInfo struct {
 func (m *ContinuousQueryInfo) Reset()                    { *m = ContinuousQueryInfo{} }
 func (m *ContinuousQueryInfo) String() string            { return proto.CompactTextString(m) }
 func (*ContinuousQueryInfo) ProtoMessage()               {}
-func (*ContinuousQueryInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{9} }
+func (*ContinuousQueryInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{11} }
 
 func (m *ContinuousQueryInfo) GetName() string {
 	if m != nil && m.Name != nil {
@@ -625,13 +949,16 @@ type UserInfo struct {
 	Hash             *string          `protobuf:"bytes,2,req,name=Hash" json:"Hash,omitempty"`
 	Admin            *bool            `protobuf:"varint,3,req,name=Admin" json:"Admin,omitempty"`
 	Privileges       []*UserPrivilege `protobuf:"bytes,4,rep,name=Privileges" json:"Privileges,omitempty"`
+	Roles            []string         `protobuf:"bytes,5,rep,name=Roles" json:"Roles,omitempty"`
+	PasswordChanged  *int64           `protobuf:"varint,6,opt,name=PasswordChanged" json:"PasswordChanged,omitempty"`
+	PreviousHashes   []string         `protobuf:"bytes,7,rep,name=PreviousHashes" json:"PreviousHashes,omitempty"`
 	XXX_unrecognized []byte           `json:"-"`
 }
 
 func (m *UserInfo) Reset()                    { *m = UserInfo{} }
 func (m *UserInfo) String() string            { return proto.CompactTextString(m) }
 func (*UserInfo) ProtoMessage()               {}
-func (*UserInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{10} }
+func (*UserInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{12} }
 
 func (m *UserInfo) GetName() string {
 	if m != nil && m.Name != nil {
@@ -661,6 +988,27 @@ func (m *UserInfo) GetPrivileges() []*UserPrivilege {
 	return nil
 }
 
+func (m *UserInfo) GetRoles() []string {
+	if m != nil {
+		return m.Roles
+	}
+	return nil
+}
+
+func (m *UserInfo) GetPasswordChanged() int64 {
+	if m != nil && m.PasswordChanged != nil {
+		return *m.PasswordChanged
+	}
+	return 0
+}
+
+func (m *UserInfo) GetPreviousHashes() []string {
+	if m != nil {
+		return m.PreviousHashes
+	}
+	return nil
+}
+
 type UserPrivilege struct {
 	Database         *string `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
 	Privilege        *int32  `protobuf:"varint,2,req,name=Privilege" json:"Privilege,omitempty"`
@@ -670,7 +1018,7 @@ type UserPrivilege struct {
 func (m *UserPrivilege) Reset()                    { *m = UserPrivilege{} }
 func (m *UserPrivilege) String() string            { return proto.CompactTextString(m) }
 func (*UserPrivilege) ProtoMessage()               {}
-func (*UserPrivilege) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{11} }
+func (*UserPrivilege) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{13} }
 
 func (m *UserPrivilege) GetDatabase() string {
 	if m != nil && m.Database != nil {
@@ -695,7 +1043,7 @@ type Command struct {
 func (m *Command) Reset()                    { *m = Command{} }
 func (m *Command) String() string            { return proto.CompactTextString(m) }
 func (*Command) ProtoMessage()               {}
-func (*Command) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{12} }
+func (*Command) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{14} }
 
 var extRange_Command = []proto.ExtensionRange{
 	{Start: 100, End: 536870911},
@@ -723,7 +1071,7 @@ type CreateNodeCommand struct {
 func (m *CreateNodeCommand) Reset()                    { *m = CreateNodeCommand{} }
 func (m *CreateNodeCommand) String() string            { return proto.CompactTextString(m) }
 func (*CreateNodeCommand) ProtoMessage()               {}
-func (*CreateNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{13} }
+func (*CreateNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{15} }
 
 func (m *CreateNodeCommand) GetHost() string {
 	if m != nil && m.Host != nil {
@@ -757,7 +1105,7 @@ type DeleteNodeCommand struct {
 func (m *DeleteNodeCommand) Reset()                    { *m = DeleteNodeCommand{} }
 func (m *DeleteNodeCommand) String() string            { return proto.CompactTextString(m) }
 func (*DeleteNodeCommand) ProtoMessage()               {}
-func (*DeleteNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{14} }
+func (*DeleteNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{16} }
 
 func (m *DeleteNodeCommand) GetID() uint64 {
 	if m != nil && m.ID != nil {
@@ -785,13 +1133,14 @@ var E_DeleteNodeCommand_Command = &proto.ExtensionDesc{
 type CreateDatabaseCommand struct {
 	Name             *string              `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
 	RetentionPolicy  *RetentionPolicyInfo `protobuf:"bytes,2,opt,name=RetentionPolicy" json:"RetentionPolicy,omitempty"`
+	ShardKey         []string             `protobuf:"bytes,3,rep,name=ShardKey" json:"ShardKey,omitempty"`
 	XXX_unrecognized []byte               `json:"-"`
 }
 
 func (m *CreateDatabaseCommand) Reset()                    { *m = CreateDatabaseCommand{} }
 func (m *CreateDatabaseCommand) String() string            { return proto.CompactTextString(m) }
 func (*CreateDatabaseCommand) ProtoMessage()               {}
-func (*CreateDatabaseCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{15} }
+func (*CreateDatabaseCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{17} }
 
 func (m *CreateDatabaseCommand) GetName() string {
 	if m != nil && m.Name != nil {
@@ -807,6 +1156,13 @@ func (m *CreateDatabaseCommand) GetRetentionPolicy() *RetentionPolicyInfo {
 	return nil
 }
 
+func (m *CreateDatabaseCommand) GetShardKey() []string {
+	if m != nil {
+		return m.ShardKey
+	}
+	return nil
+}
+
 var E_CreateDatabaseCommand_Command = &proto.ExtensionDesc{
 	ExtendedType:  (*Command)(nil),
 	ExtensionType: (*CreateDatabaseCommand)(nil),
@@ -824,7 +1180,7 @@ type DropDatabaseCommand struct {
 func (m *DropDatabaseCommand) Reset()                    { *m = DropDatabaseCommand{} }
 func (m *DropDatabaseCommand) String() string            { return proto.CompactTextString(m) }
 func (*DropDatabaseCommand) ProtoMessage()               {}
-func (*DropDatabaseCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{16} }
+func (*DropDatabaseCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{18} }
 
 func (m *DropDatabaseCommand) GetName() string {
 	if m != nil && m.Name != nil {
@@ -852,7 +1208,7 @@ func (m *CreateRetentionPolicyCommand) Reset()         { *m = CreateRetentionPol
 func (m *CreateRetentionPolicyCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateRetentionPolicyCommand) ProtoMessage()    {}
 func (*CreateRetentionPolicyCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptorMeta, []int{17}
+	return fileDescriptorMeta, []int{19}
 }
 
 func (m *CreateRetentionPolicyCommand) GetDatabase() string {
@@ -887,7 +1243,7 @@ type DropRetentionPolicyCommand struct {
 func (m *DropRetentionPolicyCommand) Reset()                    { *m = DropRetentionPolicyCommand{} }
 func (m *DropRetentionPolicyCommand) String() string            { return proto.CompactTextString(m) }
 func (*DropRetentionPolicyCommand) ProtoMessage()               {}
-func (*DropRetentionPolicyCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{18} }
+func (*DropRetentionPolicyCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{20} }
 
 func (m *DropRetentionPolicyCommand) GetDatabase() string {
 	if m != nil && m.Database != nil {
@@ -922,7 +1278,7 @@ func (m *SetDefaultRetentionPolicyCommand) Reset()         { *m = SetDefaultRete
 func (m *SetDefaultRetentionPolicyCommand) String() string { return proto.CompactTextString(m) }
 func (*SetDefaultRetentionPolicyCommand) ProtoMessage()    {}
 func (*SetDefaultRetentionPolicyCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptorMeta, []int{19}
+	return fileDescriptorMeta, []int{21}
 }
 
 func (m *SetDefaultRetentionPolicyCommand) GetDatabase() string {
@@ -949,19 +1305,23 @@ var E_SetDefaultRetentionPolicyCommand_Command = &proto.ExtensionDesc{
 }
 
 type UpdateRetentionPolicyCommand struct {
-	Database         *string `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
-	Name             *string `protobuf:"bytes,2,req,name=Name" json:"Name,omitempty"`
-	NewName          *string `protobuf:"bytes,3,opt,name=NewName" json:"NewName,omitempty"`
-	Duration         *int64  `protobuf:"varint,4,opt,name=Duration" json:"Duration,omitempty"`
-	ReplicaN         *uint32 `protobuf:"varint,5,opt,name=ReplicaN" json:"ReplicaN,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
+	Database           *string `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	Name               *string `protobuf:"bytes,2,req,name=Name" json:"Name,omitempty"`
+	NewName            *string `protobuf:"bytes,3,opt,name=NewName" json:"NewName,omitempty"`
+	Duration           *int64  `protobuf:"varint,4,opt,name=Duration" json:"Duration,omitempty"`
+	ReplicaN           *uint32 `protobuf:"varint,5,opt,name=ReplicaN" json:"ReplicaN,omitempty"`
+	FutureWriteLimit   *int64  `protobuf:"varint,6,opt,name=FutureWriteLimit" json:"FutureWriteLimit,omitempty"`
+	PastWriteLimit     *int64  `protobuf:"varint,7,opt,name=PastWriteLimit" json:"PastWriteLimit,omitempty"`
+	ShardGroupDuration *int64  `protobuf:"varint,8,opt,name=ShardGroupDuration" json:"ShardGroupDuration,omitempty"`
+	Measurement        *string `protobuf:"bytes,9,opt,name=Measurement" json:"Measurement,omitempty"`
+	XXX_unrecognized   []byte  `json:"-"`
 }
 
 func (m *UpdateRetentionPolicyCommand) Reset()         { *m = UpdateRetentionPolicyCommand{} }
 func (m *UpdateRetentionPolicyCommand) String() string { return proto.CompactTextString(m) }
 func (*UpdateRetentionPolicyCommand) ProtoMessage()    {}
 func (*UpdateRetentionPolicyCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptorMeta, []int{20}
+	return fileDescriptorMeta, []int{22}
 }
 
 func (m *UpdateRetentionPolicyCommand) GetDatabase() string {
@@ -999,6 +1359,34 @@ func (m *UpdateRetentionPolicyCommand) GetReplicaN() uint32 {
 	return 0
 }
 
+func (m *UpdateRetentionPolicyCommand) GetFutureWriteLimit() int64 {
+	if m != nil && m.FutureWriteLimit != nil {
+		return *m.FutureWriteLimit
+	}
+	return 0
+}
+
+func (m *UpdateRetentionPolicyCommand) GetPastWriteLimit() int64 {
+	if m != nil && m.PastWriteLimit != nil {
+		return *m.PastWriteLimit
+	}
+	return 0
+}
+
+func (m *UpdateRetentionPolicyCommand) GetShardGroupDuration() int64 {
+	if m != nil && m.ShardGroupDuration != nil {
+		return *m.ShardGroupDuration
+	}
+	return 0
+}
+
+func (m *UpdateRetentionPolicyCommand) GetMeasurement() string {
+	if m != nil && m.Measurement != nil {
+		return *m.Measurement
+	}
+	return ""
+}
+
 var E_UpdateRetentionPolicyCommand_Command = &proto.ExtensionDesc{
 	ExtendedType:  (*Command)(nil),
 	ExtensionType: (*UpdateRetentionPolicyCommand)(nil),
@@ -1012,13 +1400,14 @@ type CreateShardGroupCommand struct {
 	Database         *string `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
 	Policy           *string `protobuf:"bytes,2,req,name=Policy" json:"Policy,omitempty"`
 	Timestamp        *int64  `protobuf:"varint,3,req,name=Timestamp" json:"Timestamp,omitempty"`
+	Measurement      *string `protobuf:"bytes,4,opt,name=Measurement" json:"Measurement,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
 func (m *CreateShardGroupCommand) Reset()                    { *m = CreateShardGroupCommand{} }
 func (m *CreateShardGroupCommand) String() string            { return proto.CompactTextString(m) }
 func (*CreateShardGroupCommand) ProtoMessage()               {}
-func (*CreateShardGroupCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{21} }
+func (*CreateShardGroupCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{23} }
 
 func (m *CreateShardGroupCommand) GetDatabase() string {
 	if m != nil && m.Database != nil {
@@ -1041,6 +1430,13 @@ func (m *CreateShardGroupCommand) GetTimestamp() int64 {
 	return 0
 }
 
+func (m *CreateShardGroupCommand) GetMeasurement() string {
+	if m != nil && m.Measurement != nil {
+		return *m.Measurement
+	}
+	return ""
+}
+
 var E_CreateShardGroupCommand_Command = &proto.ExtensionDesc{
 	ExtendedType:  (*Command)(nil),
 	ExtensionType: (*CreateShardGroupCommand)(nil),
@@ -1060,7 +1456,7 @@ type DeleteShardGroupCommand struct {
 func (m *DeleteShardGroupCommand) Reset()                    { *m = DeleteShardGroupCommand{} }
 func (m *DeleteShardGroupCommand) String() string            { return proto.CompactTextString(m) }
 func (*DeleteShardGroupCommand) ProtoMessage()               {}
-func (*DeleteShardGroupCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{22} }
+func (*DeleteShardGroupCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{24} }
 
 func (m *DeleteShardGroupCommand) GetDatabase() string {
 	if m != nil && m.Database != nil {
@@ -1103,7 +1499,7 @@ func (m *CreateContinuousQueryCommand) Reset()         { *m = CreateContinuousQu
 func (m *CreateContinuousQueryCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateContinuousQueryCommand) ProtoMessage()    {}
 func (*CreateContinuousQueryCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptorMeta, []int{23}
+	return fileDescriptorMeta, []int{25}
 }
 
 func (m *CreateContinuousQueryCommand) GetDatabase() string {
@@ -1145,7 +1541,7 @@ type DropContinuousQueryCommand struct {
 func (m *DropContinuousQueryCommand) Reset()                    { *m = DropContinuousQueryCommand{} }
 func (m *DropContinuousQueryCommand) String() string            { return proto.CompactTextString(m) }
 func (*DropContinuousQueryCommand) ProtoMessage()               {}
-func (*DropContinuousQueryCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{24} }
+func (*DropContinuousQueryCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{26} }
 
 func (m *DropContinuousQueryCommand) GetDatabase() string {
 	if m != nil && m.Database != nil {
@@ -1174,13 +1570,14 @@ type CreateUserCommand struct {
 	Name             *string `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
 	Hash             *string `protobuf:"bytes,2,req,name=Hash" json:"Hash,omitempty"`
 	Admin            *bool   `protobuf:"varint,3,req,name=Admin" json:"Admin,omitempty"`
+	PasswordChanged  *int64  `protobuf:"varint,4,opt,name=PasswordChanged" json:"PasswordChanged,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
 func (m *CreateUserCommand) Reset()                    { *m = CreateUserCommand{} }
 func (m *CreateUserCommand) String() string            { return proto.CompactTextString(m) }
 func (*CreateUserCommand) ProtoMessage()               {}
-func (*CreateUserCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{25} }
+func (*CreateUserCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{27} }
 
 func (m *CreateUserCommand) GetName() string {
 	if m != nil && m.Name != nil {
@@ -1203,6 +1600,13 @@ func (m *CreateUserCommand) GetAdmin() bool {
 	return false
 }
 
+func (m *CreateUserCommand) GetPasswordChanged() int64 {
+	if m != nil && m.PasswordChanged != nil {
+		return *m.PasswordChanged
+	}
+	return 0
+}
+
 var E_CreateUserCommand_Command = &proto.ExtensionDesc{
 	ExtendedType:  (*Command)(nil),
 	ExtensionType: (*CreateUserCommand)(nil),
@@ -1220,7 +1624,7 @@ type DropUserCommand struct {
 func (m *DropUserCommand) Reset()                    { *m = DropUserCommand{} }
 func (m *DropUserCommand) String() string            { return proto.CompactTextString(m) }
 func (*DropUserCommand) ProtoMessage()               {}
-func (*DropUserCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{26} }
+func (*DropUserCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{28} }
 
 func (m *DropUserCommand) GetName() string {
 	if m != nil && m.Name != nil {
@@ -1241,13 +1645,16 @@ var E_DropUserCommand_Command = &proto.ExtensionDesc{
 type UpdateUserCommand struct {
 	Name             *string `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
 	Hash             *string `protobuf:"bytes,2,req,name=Hash" json:"Hash,omitempty"`
+	PasswordChanged  *int64  `protobuf:"varint,3,opt,name=PasswordChanged" json:"PasswordChanged,omitempty"`
+	PreviousHash     *string `protobuf:"bytes,4,opt,name=PreviousHash" json:"PreviousHash,omitempty"`
+	PasswordHistory  *uint32 `protobuf:"varint,5,opt,name=PasswordHistory" json:"PasswordHistory,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
 func (m *UpdateUserCommand) Reset()                    { *m = UpdateUserCommand{} }
 func (m *UpdateUserCommand) String() string            { return proto.CompactTextString(m) }
 func (*UpdateUserCommand) ProtoMessage()               {}
-func (*UpdateUserCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{27} }
+func (*UpdateUserCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{29} }
 
 func (m *UpdateUserCommand) GetName() string {
 	if m != nil && m.Name != nil {
@@ -1263,6 +1670,27 @@ func (m *UpdateUserCommand) GetHash() string {
 	return ""
 }
 
+func (m *UpdateUserCommand) GetPasswordChanged() int64 {
+	if m != nil && m.PasswordChanged != nil {
+		return *m.PasswordChanged
+	}
+	return 0
+}
+
+func (m *UpdateUserCommand) GetPreviousHash() string {
+	if m != nil && m.PreviousHash != nil {
+		return *m.PreviousHash
+	}
+	return ""
+}
+
+func (m *UpdateUserCommand) GetPasswordHistory() uint32 {
+	if m != nil && m.PasswordHistory != nil {
+		return *m.PasswordHistory
+	}
+	return 0
+}
+
 var E_UpdateUserCommand_Command = &proto.ExtensionDesc{
 	ExtendedType:  (*Command)(nil),
 	ExtensionType: (*UpdateUserCommand)(nil),
@@ -1282,7 +1710,7 @@ type SetPrivilegeCommand struct {
 func (m *SetPrivilegeCommand) Reset()                    { *m = SetPrivilegeCommand{} }
 func (m *SetPrivilegeCommand) String() string            { return proto.CompactTextString(m) }
 func (*SetPrivilegeCommand) ProtoMessage()               {}
-func (*SetPrivilegeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{28} }
+func (*SetPrivilegeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{30} }
 
 func (m *SetPrivilegeCommand) GetUsername() string {
 	if m != nil && m.Username != nil {
@@ -1322,7 +1750,7 @@ type SetDataCommand struct {
 func (m *SetDataCommand) Reset()                    { *m = SetDataCommand{} }
 func (m *SetDataCommand) String() string            { return proto.CompactTextString(m) }
 func (*SetDataCommand) ProtoMessage()               {}
-func (*SetDataCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{29} }
+func (*SetDataCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{31} }
 
 func (m *SetDataCommand) GetData() *Data {
 	if m != nil {
@@ -1349,7 +1777,7 @@ type SetAdminPrivilegeCommand struct {
 func (m *SetAdminPrivilegeCommand) Reset()                    { *m = SetAdminPrivilegeCommand{} }
 func (m *SetAdminPrivilegeCommand) String() string            { return proto.CompactTextString(m) }
 func (*SetAdminPrivilegeCommand) ProtoMessage()               {}
-func (*SetAdminPrivilegeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{30} }
+func (*SetAdminPrivilegeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{32} }
 
 func (m *SetAdminPrivilegeCommand) GetUsername() string {
 	if m != nil && m.Username != nil {
@@ -1383,7 +1811,7 @@ type UpdateNodeCommand struct {
 func (m *UpdateNodeCommand) Reset()                    { *m = UpdateNodeCommand{} }
 func (m *UpdateNodeCommand) String() string            { return proto.CompactTextString(m) }
 func (*UpdateNodeCommand) ProtoMessage()               {}
-func (*UpdateNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{31} }
+func (*UpdateNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{33} }
 
 func (m *UpdateNodeCommand) GetID() uint64 {
 	if m != nil && m.ID != nil {
@@ -1409,18 +1837,19 @@ var E_UpdateNodeCommand_Command = &proto.ExtensionDesc{
 }
 
 type CreateSubscriptionCommand struct {
-	Name             *string  `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
-	Database         *string  `protobuf:"bytes,2,req,name=Database" json:"Database,omitempty"`
-	RetentionPolicy  *string  `protobuf:"bytes,3,req,name=RetentionPolicy" json:"RetentionPolicy,omitempty"`
-	Mode             *string  `protobuf:"bytes,4,req,name=Mode" json:"Mode,omitempty"`
-	Destinations     []string `protobuf:"bytes,5,rep,name=Destinations" json:"Destinations,omitempty"`
-	XXX_unrecognized []byte   `json:"-"`
+	Name             *string           `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Database         *string           `protobuf:"bytes,2,req,name=Database" json:"Database,omitempty"`
+	RetentionPolicy  *string           `protobuf:"bytes,3,req,name=RetentionPolicy" json:"RetentionPolicy,omitempty"`
+	Mode             *string           `protobuf:"bytes,4,req,name=Mode" json:"Mode,omitempty"`
+	Destinations     []string          `protobuf:"bytes,5,rep,name=Destinations" json:"Destinations,omitempty"`
+	Subscription     *SubscriptionInfo `protobuf:"bytes,6,opt,name=Subscription" json:"Subscription,omitempty"`
+	XXX_unrecognized []byte            `json:"-"`
 }
 
 func (m *CreateSubscriptionCommand) Reset()                    { *m = CreateSubscriptionCommand{} }
 func (m *CreateSubscriptionCommand) String() string            { return proto.CompactTextString(m) }
 func (*CreateSubscriptionCommand) ProtoMessage()               {}
-func (*CreateSubscriptionCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{32} }
+func (*CreateSubscriptionCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{34} }
 
 func (m *CreateSubscriptionCommand) GetName() string {
 	if m != nil && m.Name != nil {
@@ -1457,6 +1886,13 @@ func (m *CreateSubscriptionCommand) GetDestinations() []string {
 	return nil
 }
 
+func (m *CreateSubscriptionCommand) GetSubscription() *SubscriptionInfo {
+	if m != nil {
+		return m.Subscription
+	}
+	return nil
+}
+
 var E_CreateSubscriptionCommand_Command = &proto.ExtensionDesc{
 	ExtendedType:  (*Command)(nil),
 	ExtensionType: (*CreateSubscriptionCommand)(nil),
@@ -1476,7 +1912,7 @@ type DropSubscriptionCommand struct {
 func (m *DropSubscriptionCommand) Reset()                    { *m = DropSubscriptionCommand{} }
 func (m *DropSubscriptionCommand) String() string            { return proto.CompactTextString(m) }
 func (*DropSubscriptionCommand) ProtoMessage()               {}
-func (*DropSubscriptionCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{33} }
+func (*DropSubscriptionCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{35} }
 
 func (m *DropSubscriptionCommand) GetName() string {
 	if m != nil && m.Name != nil {
@@ -1517,7 +1953,7 @@ type RemovePeerCommand struct {
 func (m *RemovePeerCommand) Reset()                    { *m = RemovePeerCommand{} }
 func (m *RemovePeerCommand) String() string            { return proto.CompactTextString(m) }
 func (*RemovePeerCommand) ProtoMessage()               {}
-func (*RemovePeerCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{34} }
+func (*RemovePeerCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{36} }
 
 func (m *RemovePeerCommand) GetID() uint64 {
 	if m != nil && m.ID != nil {
@@ -1552,7 +1988,7 @@ type CreateMetaNodeCommand struct {
 func (m *CreateMetaNodeCommand) Reset()                    { *m = CreateMetaNodeCommand{} }
 func (m *CreateMetaNodeCommand) String() string            { return proto.CompactTextString(m) }
 func (*CreateMetaNodeCommand) ProtoMessage()               {}
-func (*CreateMetaNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{35} }
+func (*CreateMetaNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{37} }
 
 func (m *CreateMetaNodeCommand) GetHTTPAddr() string {
 	if m != nil && m.HTTPAddr != nil {
@@ -1593,7 +2029,7 @@ type CreateDataNodeCommand struct {
 func (m *CreateDataNodeCommand) Reset()                    { *m = CreateDataNodeCommand{} }
 func (m *CreateDataNodeCommand) String() string            { return proto.CompactTextString(m) }
 func (*CreateDataNodeCommand) ProtoMessage()               {}
-func (*CreateDataNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{36} }
+func (*CreateDataNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{38} }
 
 func (m *CreateDataNodeCommand) GetHTTPAddr() string {
 	if m != nil && m.HTTPAddr != nil {
@@ -1628,7 +2064,7 @@ type UpdateDataNodeCommand struct {
 func (m *UpdateDataNodeCommand) Reset()                    { *m = UpdateDataNodeCommand{} }
 func (m *UpdateDataNodeCommand) String() string            { return proto.CompactTextString(m) }
 func (*UpdateDataNodeCommand) ProtoMessage()               {}
-func (*UpdateDataNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{37} }
+func (*UpdateDataNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{39} }
 
 func (m *UpdateDataNodeCommand) GetID() uint64 {
 	if m != nil && m.ID != nil {
@@ -1668,7 +2104,7 @@ type DeleteMetaNodeCommand struct {
 func (m *DeleteMetaNodeCommand) Reset()                    { *m = DeleteMetaNodeCommand{} }
 func (m *DeleteMetaNodeCommand) String() string            { return proto.CompactTextString(m) }
 func (*DeleteMetaNodeCommand) ProtoMessage()               {}
-func (*DeleteMetaNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{38} }
+func (*DeleteMetaNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{40} }
 
 func (m *DeleteMetaNodeCommand) GetID() uint64 {
 	if m != nil && m.ID != nil {
@@ -1694,7 +2130,7 @@ type DeleteDataNodeCommand struct {
 func (m *DeleteDataNodeCommand) Reset()                    { *m = DeleteDataNodeCommand{} }
 func (m *DeleteDataNodeCommand) String() string            { return proto.CompactTextString(m) }
 func (*DeleteDataNodeCommand) ProtoMessage()               {}
-func (*DeleteDataNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{39} }
+func (*DeleteDataNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{41} }
 
 func (m *DeleteDataNodeCommand) GetID() uint64 {
 	if m != nil && m.ID != nil {
@@ -1722,7 +2158,7 @@ type Response struct {
 func (m *Response) Reset()                    { *m = Response{} }
 func (m *Response) String() string            { return proto.CompactTextString(m) }
 func (*Response) ProtoMessage()               {}
-func (*Response) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{40} }
+func (*Response) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{42} }
 
 func (m *Response) GetOK() bool {
 	if m != nil && m.OK != nil {
@@ -1757,7 +2193,7 @@ type SetMetaNodeCommand struct {
 func (m *SetMetaNodeCommand) Reset()                    { *m = SetMetaNodeCommand{} }
 func (m *SetMetaNodeCommand) String() string            { return proto.CompactTextString(m) }
 func (*SetMetaNodeCommand) ProtoMessage()               {}
-func (*SetMetaNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{41} }
+func (*SetMetaNodeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{43} }
 
 func (m *SetMetaNodeCommand) GetHTTPAddr() string {
 	if m != nil && m.HTTPAddr != nil {
@@ -1797,7 +2233,7 @@ type DropShardCommand struct {
 func (m *DropShardCommand) Reset()                    { *m = DropShardCommand{} }
 func (m *DropShardCommand) String() string            { return proto.CompactTextString(m) }
 func (*DropShardCommand) ProtoMessage()               {}
-func (*DropShardCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{42} }
+func (*DropShardCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{44} }
 
 func (m *DropShardCommand) GetID() uint64 {
 	if m != nil && m.ID != nil {
@@ -1815,6 +2251,1392 @@ var E_DropShardCommand_Command = &proto.ExtensionDesc{
 	Filename:      "internal/meta.proto",
 }
 
+type DatabaseTemplateInfo struct {
+	Name                   *string                  `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	DefaultRetentionPolicy *string                  `protobuf:"bytes,2,opt,name=DefaultRetentionPolicy" json:"DefaultRetentionPolicy,omitempty"`
+	RetentionPolicies      []*RetentionPolicyInfo   `protobuf:"bytes,3,rep,name=RetentionPolicies" json:"RetentionPolicies,omitempty"`
+	ContinuousQueries      []*ContinuousQueryInfo   `protobuf:"bytes,4,rep,name=ContinuousQueries" json:"ContinuousQueries,omitempty"`
+	Grants                 []*DatabaseTemplateGrant `protobuf:"bytes,5,rep,name=Grants" json:"Grants,omitempty"`
+	Limits                 *DatabaseTemplateLimits  `protobuf:"bytes,6,opt,name=Limits" json:"Limits,omitempty"`
+	XXX_unrecognized       []byte                   `json:"-"`
+}
+
+func (m *DatabaseTemplateInfo) Reset()                    { *m = DatabaseTemplateInfo{} }
+func (m *DatabaseTemplateInfo) String() string            { return proto.CompactTextString(m) }
+func (*DatabaseTemplateInfo) ProtoMessage()               {}
+func (*DatabaseTemplateInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{45} }
+
+func (m *DatabaseTemplateInfo) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *DatabaseTemplateInfo) GetDefaultRetentionPolicy() string {
+	if m != nil && m.DefaultRetentionPolicy != nil {
+		return *m.DefaultRetentionPolicy
+	}
+	return ""
+}
+
+func (m *DatabaseTemplateInfo) GetRetentionPolicies() []*RetentionPolicyInfo {
+	if m != nil {
+		return m.RetentionPolicies
+	}
+	return nil
+}
+
+func (m *DatabaseTemplateInfo) GetContinuousQueries() []*ContinuousQueryInfo {
+	if m != nil {
+		return m.ContinuousQueries
+	}
+	return nil
+}
+
+func (m *DatabaseTemplateInfo) GetGrants() []*DatabaseTemplateGrant {
+	if m != nil {
+		return m.Grants
+	}
+	return nil
+}
+
+func (m *DatabaseTemplateInfo) GetLimits() *DatabaseTemplateLimits {
+	if m != nil {
+		return m.Limits
+	}
+	return nil
+}
+
+type DatabaseTemplateGrant struct {
+	User             *string `protobuf:"bytes,1,req,name=User" json:"User,omitempty"`
+	Privilege        *int32  `protobuf:"varint,2,req,name=Privilege" json:"Privilege,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DatabaseTemplateGrant) Reset()                    { *m = DatabaseTemplateGrant{} }
+func (m *DatabaseTemplateGrant) String() string            { return proto.CompactTextString(m) }
+func (*DatabaseTemplateGrant) ProtoMessage()               {}
+func (*DatabaseTemplateGrant) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{46} }
+
+func (m *DatabaseTemplateGrant) GetUser() string {
+	if m != nil && m.User != nil {
+		return *m.User
+	}
+	return ""
+}
+
+func (m *DatabaseTemplateGrant) GetPrivilege() int32 {
+	if m != nil && m.Privilege != nil {
+		return *m.Privilege
+	}
+	return 0
+}
+
+type DatabaseTemplateLimits struct {
+	FutureWriteLimit *int64 `protobuf:"varint,1,opt,name=FutureWriteLimit" json:"FutureWriteLimit,omitempty"`
+	PastWriteLimit   *int64 `protobuf:"varint,2,opt,name=PastWriteLimit" json:"PastWriteLimit,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *DatabaseTemplateLimits) Reset()                    { *m = DatabaseTemplateLimits{} }
+func (m *DatabaseTemplateLimits) String() string            { return proto.CompactTextString(m) }
+func (*DatabaseTemplateLimits) ProtoMessage()               {}
+func (*DatabaseTemplateLimits) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{47} }
+
+func (m *DatabaseTemplateLimits) GetFutureWriteLimit() int64 {
+	if m != nil && m.FutureWriteLimit != nil {
+		return *m.FutureWriteLimit
+	}
+	return 0
+}
+
+func (m *DatabaseTemplateLimits) GetPastWriteLimit() int64 {
+	if m != nil && m.PastWriteLimit != nil {
+		return *m.PastWriteLimit
+	}
+	return 0
+}
+
+type CreateDatabaseTemplateCommand struct {
+	Template         *DatabaseTemplateInfo `protobuf:"bytes,1,req,name=Template" json:"Template,omitempty"`
+	XXX_unrecognized []byte                `json:"-"`
+}
+
+func (m *CreateDatabaseTemplateCommand) Reset()         { *m = CreateDatabaseTemplateCommand{} }
+func (m *CreateDatabaseTemplateCommand) String() string { return proto.CompactTextString(m) }
+func (*CreateDatabaseTemplateCommand) ProtoMessage()    {}
+func (*CreateDatabaseTemplateCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptorMeta, []int{48}
+}
+
+func (m *CreateDatabaseTemplateCommand) GetTemplate() *DatabaseTemplateInfo {
+	if m != nil {
+		return m.Template
+	}
+	return nil
+}
+
+var E_CreateDatabaseTemplateCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*CreateDatabaseTemplateCommand)(nil),
+	Field:         131,
+	Name:          "internal.CreateDatabaseTemplateCommand.command",
+	Tag:           "bytes,131,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type DropDatabaseTemplateCommand struct {
+	Name             *string `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DropDatabaseTemplateCommand) Reset()         { *m = DropDatabaseTemplateCommand{} }
+func (m *DropDatabaseTemplateCommand) String() string { return proto.CompactTextString(m) }
+func (*DropDatabaseTemplateCommand) ProtoMessage()    {}
+func (*DropDatabaseTemplateCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptorMeta, []int{49}
+}
+
+func (m *DropDatabaseTemplateCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+var E_DropDatabaseTemplateCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*DropDatabaseTemplateCommand)(nil),
+	Field:         132,
+	Name:          "internal.DropDatabaseTemplateCommand.command",
+	Tag:           "bytes,132,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type InstantiateDatabaseTemplateCommand struct {
+	Template         *string `protobuf:"bytes,1,req,name=Template" json:"Template,omitempty"`
+	Database         *string `protobuf:"bytes,2,req,name=Database" json:"Database,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *InstantiateDatabaseTemplateCommand) Reset()         { *m = InstantiateDatabaseTemplateCommand{} }
+func (m *InstantiateDatabaseTemplateCommand) String() string { return proto.CompactTextString(m) }
+func (*InstantiateDatabaseTemplateCommand) ProtoMessage()    {}
+func (*InstantiateDatabaseTemplateCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptorMeta, []int{50}
+}
+
+func (m *InstantiateDatabaseTemplateCommand) GetTemplate() string {
+	if m != nil && m.Template != nil {
+		return *m.Template
+	}
+	return ""
+}
+
+func (m *InstantiateDatabaseTemplateCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+var E_InstantiateDatabaseTemplateCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*InstantiateDatabaseTemplateCommand)(nil),
+	Field:         133,
+	Name:          "internal.InstantiateDatabaseTemplateCommand.command",
+	Tag:           "bytes,133,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type ShardGroupMergeInfo struct {
+	Sources          []uint64        `protobuf:"varint,1,rep,name=Sources" json:"Sources,omitempty"`
+	Group            *ShardGroupInfo `protobuf:"bytes,2,req,name=Group" json:"Group,omitempty"`
+	Completed        []uint64        `protobuf:"varint,3,rep,name=Completed" json:"Completed,omitempty"`
+	XXX_unrecognized []byte          `json:"-"`
+}
+
+func (m *ShardGroupMergeInfo) Reset()                    { *m = ShardGroupMergeInfo{} }
+func (m *ShardGroupMergeInfo) String() string            { return proto.CompactTextString(m) }
+func (*ShardGroupMergeInfo) ProtoMessage()               {}
+func (*ShardGroupMergeInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{51} }
+
+func (m *ShardGroupMergeInfo) GetSources() []uint64 {
+	if m != nil {
+		return m.Sources
+	}
+	return nil
+}
+
+func (m *ShardGroupMergeInfo) GetGroup() *ShardGroupInfo {
+	if m != nil {
+		return m.Group
+	}
+	return nil
+}
+
+func (m *ShardGroupMergeInfo) GetCompleted() []uint64 {
+	if m != nil {
+		return m.Completed
+	}
+	return nil
+}
+
+type CreateShardGroupMergeCommand struct {
+	Database         *string  `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	Policy           *string  `protobuf:"bytes,2,req,name=Policy" json:"Policy,omitempty"`
+	ShardGroupIDs    []uint64 `protobuf:"varint,3,rep,name=ShardGroupIDs" json:"ShardGroupIDs,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *CreateShardGroupMergeCommand) Reset()         { *m = CreateShardGroupMergeCommand{} }
+func (m *CreateShardGroupMergeCommand) String() string { return proto.CompactTextString(m) }
+func (*CreateShardGroupMergeCommand) ProtoMessage()    {}
+func (*CreateShardGroupMergeCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptorMeta, []int{52}
+}
+
+func (m *CreateShardGroupMergeCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *CreateShardGroupMergeCommand) GetPolicy() string {
+	if m != nil && m.Policy != nil {
+		return *m.Policy
+	}
+	return ""
+}
+
+func (m *CreateShardGroupMergeCommand) GetShardGroupIDs() []uint64 {
+	if m != nil {
+		return m.ShardGroupIDs
+	}
+	return nil
+}
+
+var E_CreateShardGroupMergeCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*CreateShardGroupMergeCommand)(nil),
+	Field:         134,
+	Name:          "internal.CreateShardGroupMergeCommand.command",
+	Tag:           "bytes,134,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type CompleteShardGroupMergeCommand struct {
+	Database         *string `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	Policy           *string `protobuf:"bytes,2,req,name=Policy" json:"Policy,omitempty"`
+	ShardGroupID     *uint64 `protobuf:"varint,3,req,name=ShardGroupID" json:"ShardGroupID,omitempty"`
+	NodeID           *uint64 `protobuf:"varint,4,req,name=NodeID" json:"NodeID,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *CompleteShardGroupMergeCommand) Reset()         { *m = CompleteShardGroupMergeCommand{} }
+func (m *CompleteShardGroupMergeCommand) String() string { return proto.CompactTextString(m) }
+func (*CompleteShardGroupMergeCommand) ProtoMessage()    {}
+func (*CompleteShardGroupMergeCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptorMeta, []int{53}
+}
+
+func (m *CompleteShardGroupMergeCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *CompleteShardGroupMergeCommand) GetPolicy() string {
+	if m != nil && m.Policy != nil {
+		return *m.Policy
+	}
+	return ""
+}
+
+func (m *CompleteShardGroupMergeCommand) GetShardGroupID() uint64 {
+	if m != nil && m.ShardGroupID != nil {
+		return *m.ShardGroupID
+	}
+	return 0
+}
+
+func (m *CompleteShardGroupMergeCommand) GetNodeID() uint64 {
+	if m != nil && m.NodeID != nil {
+		return *m.NodeID
+	}
+	return 0
+}
+
+var E_CompleteShardGroupMergeCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*CompleteShardGroupMergeCommand)(nil),
+	Field:         135,
+	Name:          "internal.CompleteShardGroupMergeCommand.command",
+	Tag:           "bytes,135,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type MeasurementShardGroupDuration struct {
+	Measurement        *string `protobuf:"bytes,1,req,name=Measurement" json:"Measurement,omitempty"`
+	ShardGroupDuration *int64  `protobuf:"varint,2,req,name=ShardGroupDuration" json:"ShardGroupDuration,omitempty"`
+	XXX_unrecognized   []byte  `json:"-"`
+}
+
+func (m *MeasurementShardGroupDuration) Reset()         { *m = MeasurementShardGroupDuration{} }
+func (m *MeasurementShardGroupDuration) String() string { return proto.CompactTextString(m) }
+func (*MeasurementShardGroupDuration) ProtoMessage()    {}
+func (*MeasurementShardGroupDuration) Descriptor() ([]byte, []int) {
+	return fileDescriptorMeta, []int{54}
+}
+
+func (m *MeasurementShardGroupDuration) GetMeasurement() string {
+	if m != nil && m.Measurement != nil {
+		return *m.Measurement
+	}
+	return ""
+}
+
+func (m *MeasurementShardGroupDuration) GetShardGroupDuration() int64 {
+	if m != nil && m.ShardGroupDuration != nil {
+		return *m.ShardGroupDuration
+	}
+	return 0
+}
+
+type RoleInfo struct {
+	Name             *string          `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Privileges       []*UserPrivilege `protobuf:"bytes,2,rep,name=Privileges" json:"Privileges,omitempty"`
+	XXX_unrecognized []byte           `json:"-"`
+}
+
+func (m *RoleInfo) Reset()                    { *m = RoleInfo{} }
+func (m *RoleInfo) String() string            { return proto.CompactTextString(m) }
+func (*RoleInfo) ProtoMessage()               {}
+func (*RoleInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{55} }
+
+func (m *RoleInfo) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *RoleInfo) GetPrivileges() []*UserPrivilege {
+	if m != nil {
+		return m.Privileges
+	}
+	return nil
+}
+
+type CreateRoleCommand struct {
+	Name             *string `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *CreateRoleCommand) Reset()                    { *m = CreateRoleCommand{} }
+func (m *CreateRoleCommand) String() string            { return proto.CompactTextString(m) }
+func (*CreateRoleCommand) ProtoMessage()               {}
+func (*CreateRoleCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{56} }
+
+func (m *CreateRoleCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+var E_CreateRoleCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*CreateRoleCommand)(nil),
+	Field:         136,
+	Name:          "internal.CreateRoleCommand.command",
+	Tag:           "bytes,136,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type DropRoleCommand struct {
+	Name             *string `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DropRoleCommand) Reset()                    { *m = DropRoleCommand{} }
+func (m *DropRoleCommand) String() string            { return proto.CompactTextString(m) }
+func (*DropRoleCommand) ProtoMessage()               {}
+func (*DropRoleCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{57} }
+
+func (m *DropRoleCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+var E_DropRoleCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*DropRoleCommand)(nil),
+	Field:         137,
+	Name:          "internal.DropRoleCommand.command",
+	Tag:           "bytes,137,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type SetRolePrivilegeCommand struct {
+	Role             *string `protobuf:"bytes,1,req,name=Role" json:"Role,omitempty"`
+	Database         *string `protobuf:"bytes,2,req,name=Database" json:"Database,omitempty"`
+	Privilege        *int32  `protobuf:"varint,3,req,name=Privilege" json:"Privilege,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SetRolePrivilegeCommand) Reset()                    { *m = SetRolePrivilegeCommand{} }
+func (m *SetRolePrivilegeCommand) String() string            { return proto.CompactTextString(m) }
+func (*SetRolePrivilegeCommand) ProtoMessage()               {}
+func (*SetRolePrivilegeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{58} }
+
+func (m *SetRolePrivilegeCommand) GetRole() string {
+	if m != nil && m.Role != nil {
+		return *m.Role
+	}
+	return ""
+}
+
+func (m *SetRolePrivilegeCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *SetRolePrivilegeCommand) GetPrivilege() int32 {
+	if m != nil && m.Privilege != nil {
+		return *m.Privilege
+	}
+	return 0
+}
+
+var E_SetRolePrivilegeCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetRolePrivilegeCommand)(nil),
+	Field:         138,
+	Name:          "internal.SetRolePrivilegeCommand.command",
+	Tag:           "bytes,138,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+// SetUserRoleCommand adds a user to a role, or removes it if Member is false.
+type SetUserRoleCommand struct {
+	Username         *string `protobuf:"bytes,1,req,name=Username" json:"Username,omitempty"`
+	Role             *string `protobuf:"bytes,2,req,name=Role" json:"Role,omitempty"`
+	Member           *bool   `protobuf:"varint,3,req,name=Member" json:"Member,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SetUserRoleCommand) Reset()                    { *m = SetUserRoleCommand{} }
+func (m *SetUserRoleCommand) String() string            { return proto.CompactTextString(m) }
+func (*SetUserRoleCommand) ProtoMessage()               {}
+func (*SetUserRoleCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{59} }
+
+func (m *SetUserRoleCommand) GetUsername() string {
+	if m != nil && m.Username != nil {
+		return *m.Username
+	}
+	return ""
+}
+
+func (m *SetUserRoleCommand) GetRole() string {
+	if m != nil && m.Role != nil {
+		return *m.Role
+	}
+	return ""
+}
+
+func (m *SetUserRoleCommand) GetMember() bool {
+	if m != nil && m.Member != nil {
+		return *m.Member
+	}
+	return false
+}
+
+var E_SetUserRoleCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetUserRoleCommand)(nil),
+	Field:         139,
+	Name:          "internal.SetUserRoleCommand.command",
+	Tag:           "bytes,139,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+// SessionInfo is a login session. Only the SHA-256 hash of its token is stored.
+type SessionInfo struct {
+	ID               *string `protobuf:"bytes,1,req,name=ID" json:"ID,omitempty"`
+	Username         *string `protobuf:"bytes,2,req,name=Username" json:"Username,omitempty"`
+	Hash             *string `protobuf:"bytes,3,req,name=Hash" json:"Hash,omitempty"`
+	CreatedAt        *int64  `protobuf:"varint,4,req,name=CreatedAt" json:"CreatedAt,omitempty"`
+	ExpiresAt        *int64  `protobuf:"varint,5,req,name=ExpiresAt" json:"ExpiresAt,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SessionInfo) Reset()                    { *m = SessionInfo{} }
+func (m *SessionInfo) String() string            { return proto.CompactTextString(m) }
+func (*SessionInfo) ProtoMessage()               {}
+func (*SessionInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{60} }
+
+func (m *SessionInfo) GetID() string {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return ""
+}
+
+func (m *SessionInfo) GetUsername() string {
+	if m != nil && m.Username != nil {
+		return *m.Username
+	}
+	return ""
+}
+
+func (m *SessionInfo) GetHash() string {
+	if m != nil && m.Hash != nil {
+		return *m.Hash
+	}
+	return ""
+}
+
+func (m *SessionInfo) GetCreatedAt() int64 {
+	if m != nil && m.CreatedAt != nil {
+		return *m.CreatedAt
+	}
+	return 0
+}
+
+func (m *SessionInfo) GetExpiresAt() int64 {
+	if m != nil && m.ExpiresAt != nil {
+		return *m.ExpiresAt
+	}
+	return 0
+}
+
+type CreateSessionCommand struct {
+	Session          *SessionInfo `protobuf:"bytes,1,req,name=Session" json:"Session,omitempty"`
+	XXX_unrecognized []byte       `json:"-"`
+}
+
+func (m *CreateSessionCommand) Reset()                    { *m = CreateSessionCommand{} }
+func (m *CreateSessionCommand) String() string            { return proto.CompactTextString(m) }
+func (*CreateSessionCommand) ProtoMessage()               {}
+func (*CreateSessionCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{61} }
+
+func (m *CreateSessionCommand) GetSession() *SessionInfo {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
+var E_CreateSessionCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*CreateSessionCommand)(nil),
+	Field:         140,
+	Name:          "internal.CreateSessionCommand.command",
+	Tag:           "bytes,140,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type DropSessionCommand struct {
+	ID               *string `protobuf:"bytes,1,req,name=ID" json:"ID,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DropSessionCommand) Reset()                    { *m = DropSessionCommand{} }
+func (m *DropSessionCommand) String() string            { return proto.CompactTextString(m) }
+func (*DropSessionCommand) ProtoMessage()               {}
+func (*DropSessionCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{62} }
+
+func (m *DropSessionCommand) GetID() string {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return ""
+}
+
+var E_DropSessionCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*DropSessionCommand)(nil),
+	Field:         141,
+	Name:          "internal.DropSessionCommand.command",
+	Tag:           "bytes,141,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type AddShardOwnerCommand struct {
+	ID               *uint64 `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
+	NodeID           *uint64 `protobuf:"varint,2,req,name=NodeID" json:"NodeID,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *AddShardOwnerCommand) Reset()                    { *m = AddShardOwnerCommand{} }
+func (m *AddShardOwnerCommand) String() string            { return proto.CompactTextString(m) }
+func (*AddShardOwnerCommand) ProtoMessage()               {}
+func (*AddShardOwnerCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{63} }
+
+func (m *AddShardOwnerCommand) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *AddShardOwnerCommand) GetNodeID() uint64 {
+	if m != nil && m.NodeID != nil {
+		return *m.NodeID
+	}
+	return 0
+}
+
+var E_AddShardOwnerCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*AddShardOwnerCommand)(nil),
+	Field:         142,
+	Name:          "internal.AddShardOwnerCommand.command",
+	Tag:           "bytes,142,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type SetDataNodeModeCommand struct {
+	ID               *uint64 `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
+	ReadOnly         *bool   `protobuf:"varint,2,req,name=ReadOnly" json:"ReadOnly,omitempty"`
+	ReadOnlyReason   *string `protobuf:"bytes,3,opt,name=ReadOnlyReason" json:"ReadOnlyReason,omitempty"`
+	Maintenance      *bool   `protobuf:"varint,4,req,name=Maintenance" json:"Maintenance,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SetDataNodeModeCommand) Reset()                    { *m = SetDataNodeModeCommand{} }
+func (m *SetDataNodeModeCommand) String() string            { return proto.CompactTextString(m) }
+func (*SetDataNodeModeCommand) ProtoMessage()               {}
+func (*SetDataNodeModeCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{64} }
+
+func (m *SetDataNodeModeCommand) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *SetDataNodeModeCommand) GetReadOnly() bool {
+	if m != nil && m.ReadOnly != nil {
+		return *m.ReadOnly
+	}
+	return false
+}
+
+func (m *SetDataNodeModeCommand) GetReadOnlyReason() string {
+	if m != nil && m.ReadOnlyReason != nil {
+		return *m.ReadOnlyReason
+	}
+	return ""
+}
+
+func (m *SetDataNodeModeCommand) GetMaintenance() bool {
+	if m != nil && m.Maintenance != nil {
+		return *m.Maintenance
+	}
+	return false
+}
+
+var E_SetDataNodeModeCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetDataNodeModeCommand)(nil),
+	Field:         143,
+	Name:          "internal.SetDataNodeModeCommand.command",
+	Tag:           "bytes,143,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type SetDatabaseFrozenCommand struct {
+	Name             *string `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Frozen           *bool   `protobuf:"varint,2,req,name=Frozen" json:"Frozen,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SetDatabaseFrozenCommand) Reset()                    { *m = SetDatabaseFrozenCommand{} }
+func (m *SetDatabaseFrozenCommand) String() string            { return proto.CompactTextString(m) }
+func (*SetDatabaseFrozenCommand) ProtoMessage()               {}
+func (*SetDatabaseFrozenCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{65} }
+
+func (m *SetDatabaseFrozenCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *SetDatabaseFrozenCommand) GetFrozen() bool {
+	if m != nil && m.Frozen != nil {
+		return *m.Frozen
+	}
+	return false
+}
+
+var E_SetDatabaseFrozenCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetDatabaseFrozenCommand)(nil),
+	Field:         144,
+	Name:          "internal.SetDatabaseFrozenCommand.command",
+	Tag:           "bytes,144,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type QueryTemplateInfo struct {
+	Name             *string                 `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Query            *string                 `protobuf:"bytes,2,req,name=Query" json:"Query,omitempty"`
+	Version          *uint64                 `protobuf:"varint,3,req,name=Version" json:"Version,omitempty"`
+	PreviousVersions []*QueryTemplateVersion `protobuf:"bytes,4,rep,name=PreviousVersions" json:"PreviousVersions,omitempty"`
+	XXX_unrecognized []byte                  `json:"-"`
+}
+
+func (m *QueryTemplateInfo) Reset()                    { *m = QueryTemplateInfo{} }
+func (m *QueryTemplateInfo) String() string            { return proto.CompactTextString(m) }
+func (*QueryTemplateInfo) ProtoMessage()               {}
+func (*QueryTemplateInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{66} }
+
+func (m *QueryTemplateInfo) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *QueryTemplateInfo) GetQuery() string {
+	if m != nil && m.Query != nil {
+		return *m.Query
+	}
+	return ""
+}
+
+func (m *QueryTemplateInfo) GetVersion() uint64 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *QueryTemplateInfo) GetPreviousVersions() []*QueryTemplateVersion {
+	if m != nil {
+		return m.PreviousVersions
+	}
+	return nil
+}
+
+type QueryTemplateVersion struct {
+	Version          *uint64 `protobuf:"varint,1,req,name=Version" json:"Version,omitempty"`
+	Query            *string `protobuf:"bytes,2,req,name=Query" json:"Query,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *QueryTemplateVersion) Reset()                    { *m = QueryTemplateVersion{} }
+func (m *QueryTemplateVersion) String() string            { return proto.CompactTextString(m) }
+func (*QueryTemplateVersion) ProtoMessage()               {}
+func (*QueryTemplateVersion) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{67} }
+
+func (m *QueryTemplateVersion) GetVersion() uint64 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *QueryTemplateVersion) GetQuery() string {
+	if m != nil && m.Query != nil {
+		return *m.Query
+	}
+	return ""
+}
+
+type CreateQueryTemplateCommand struct {
+	Name             *string `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Query            *string `protobuf:"bytes,2,req,name=Query" json:"Query,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *CreateQueryTemplateCommand) Reset()                    { *m = CreateQueryTemplateCommand{} }
+func (m *CreateQueryTemplateCommand) String() string            { return proto.CompactTextString(m) }
+func (*CreateQueryTemplateCommand) ProtoMessage()               {}
+func (*CreateQueryTemplateCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{68} }
+
+func (m *CreateQueryTemplateCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *CreateQueryTemplateCommand) GetQuery() string {
+	if m != nil && m.Query != nil {
+		return *m.Query
+	}
+	return ""
+}
+
+var E_CreateQueryTemplateCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*CreateQueryTemplateCommand)(nil),
+	Field:         145,
+	Name:          "internal.CreateQueryTemplateCommand.command",
+	Tag:           "bytes,145,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type DropQueryTemplateCommand struct {
+	Name             *string `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DropQueryTemplateCommand) Reset()                    { *m = DropQueryTemplateCommand{} }
+func (m *DropQueryTemplateCommand) String() string            { return proto.CompactTextString(m) }
+func (*DropQueryTemplateCommand) ProtoMessage()               {}
+func (*DropQueryTemplateCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{69} }
+
+func (m *DropQueryTemplateCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+var E_DropQueryTemplateCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*DropQueryTemplateCommand)(nil),
+	Field:         146,
+	Name:          "internal.DropQueryTemplateCommand.command",
+	Tag:           "bytes,146,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type MaterializedViewInfo struct {
+	Name             *string `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Query            *string `protobuf:"bytes,2,req,name=Query" json:"Query,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *MaterializedViewInfo) Reset()                    { *m = MaterializedViewInfo{} }
+func (m *MaterializedViewInfo) String() string            { return proto.CompactTextString(m) }
+func (*MaterializedViewInfo) ProtoMessage()               {}
+func (*MaterializedViewInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{70} }
+
+func (m *MaterializedViewInfo) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *MaterializedViewInfo) GetQuery() string {
+	if m != nil && m.Query != nil {
+		return *m.Query
+	}
+	return ""
+}
+
+type CreateMaterializedViewCommand struct {
+	Database         *string `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	Name             *string `protobuf:"bytes,2,req,name=Name" json:"Name,omitempty"`
+	Query            *string `protobuf:"bytes,3,req,name=Query" json:"Query,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *CreateMaterializedViewCommand) Reset()         { *m = CreateMaterializedViewCommand{} }
+func (m *CreateMaterializedViewCommand) String() string { return proto.CompactTextString(m) }
+func (*CreateMaterializedViewCommand) ProtoMessage()    {}
+func (*CreateMaterializedViewCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptorMeta, []int{71}
+}
+
+func (m *CreateMaterializedViewCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *CreateMaterializedViewCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *CreateMaterializedViewCommand) GetQuery() string {
+	if m != nil && m.Query != nil {
+		return *m.Query
+	}
+	return ""
+}
+
+var E_CreateMaterializedViewCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*CreateMaterializedViewCommand)(nil),
+	Field:         147,
+	Name:          "internal.CreateMaterializedViewCommand.command",
+	Tag:           "bytes,147,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type DropMaterializedViewCommand struct {
+	Database         *string `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	Name             *string `protobuf:"bytes,2,req,name=Name" json:"Name,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DropMaterializedViewCommand) Reset()         { *m = DropMaterializedViewCommand{} }
+func (m *DropMaterializedViewCommand) String() string { return proto.CompactTextString(m) }
+func (*DropMaterializedViewCommand) ProtoMessage()    {}
+func (*DropMaterializedViewCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptorMeta, []int{72}
+}
+
+func (m *DropMaterializedViewCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *DropMaterializedViewCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+var E_DropMaterializedViewCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*DropMaterializedViewCommand)(nil),
+	Field:         148,
+	Name:          "internal.DropMaterializedViewCommand.command",
+	Tag:           "bytes,148,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type NodeLabel struct {
+	Key              *string `protobuf:"bytes,1,req,name=Key" json:"Key,omitempty"`
+	Value            *string `protobuf:"bytes,2,req,name=Value" json:"Value,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *NodeLabel) Reset()                    { *m = NodeLabel{} }
+func (m *NodeLabel) String() string            { return proto.CompactTextString(m) }
+func (*NodeLabel) ProtoMessage()               {}
+func (*NodeLabel) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{2} }
+
+func (m *NodeLabel) GetKey() string {
+	if m != nil && m.Key != nil {
+		return *m.Key
+	}
+	return ""
+}
+
+func (m *NodeLabel) GetValue() string {
+	if m != nil && m.Value != nil {
+		return *m.Value
+	}
+	return ""
+}
+
+type PlacementInfo struct {
+	Require          []*NodeLabel `protobuf:"bytes,1,rep,name=Require" json:"Require,omitempty"`
+	SpreadBy         []string     `protobuf:"bytes,2,rep,name=SpreadBy" json:"SpreadBy,omitempty"`
+	XXX_unrecognized []byte       `json:"-"`
+}
+
+func (m *PlacementInfo) Reset()                    { *m = PlacementInfo{} }
+func (m *PlacementInfo) String() string            { return proto.CompactTextString(m) }
+func (*PlacementInfo) ProtoMessage()               {}
+func (*PlacementInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{4} }
+
+func (m *PlacementInfo) GetRequire() []*NodeLabel {
+	if m != nil {
+		return m.Require
+	}
+	return nil
+}
+
+func (m *PlacementInfo) GetSpreadBy() []string {
+	if m != nil {
+		return m.SpreadBy
+	}
+	return nil
+}
+
+type SetDataNodeLabelsCommand struct {
+	ID               *uint64      `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
+	Labels           []*NodeLabel `protobuf:"bytes,2,rep,name=Labels" json:"Labels,omitempty"`
+	XXX_unrecognized []byte       `json:"-"`
+}
+
+func (m *SetDataNodeLabelsCommand) Reset()                    { *m = SetDataNodeLabelsCommand{} }
+func (m *SetDataNodeLabelsCommand) String() string            { return proto.CompactTextString(m) }
+func (*SetDataNodeLabelsCommand) ProtoMessage()               {}
+func (*SetDataNodeLabelsCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{73} }
+
+func (m *SetDataNodeLabelsCommand) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *SetDataNodeLabelsCommand) GetLabels() []*NodeLabel {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+var E_SetDataNodeLabelsCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetDataNodeLabelsCommand)(nil),
+	Field:         149,
+	Name:          "internal.SetDataNodeLabelsCommand.command",
+	Tag:           "bytes,149,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type SetDatabasePlacementCommand struct {
+	Name             *string        `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Placement        *PlacementInfo `protobuf:"bytes,2,opt,name=Placement" json:"Placement,omitempty"`
+	XXX_unrecognized []byte         `json:"-"`
+}
+
+func (m *SetDatabasePlacementCommand) Reset()         { *m = SetDatabasePlacementCommand{} }
+func (m *SetDatabasePlacementCommand) String() string { return proto.CompactTextString(m) }
+func (*SetDatabasePlacementCommand) ProtoMessage()    {}
+func (*SetDatabasePlacementCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptorMeta, []int{74}
+}
+
+func (m *SetDatabasePlacementCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *SetDatabasePlacementCommand) GetPlacement() *PlacementInfo {
+	if m != nil {
+		return m.Placement
+	}
+	return nil
+}
+
+var E_SetDatabasePlacementCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetDatabasePlacementCommand)(nil),
+	Field:         150,
+	Name:          "internal.SetDatabasePlacementCommand.command",
+	Tag:           "bytes,150,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type SetSubscriptionPausedCommand struct {
+	Database         *string `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	RetentionPolicy  *string `protobuf:"bytes,2,req,name=RetentionPolicy" json:"RetentionPolicy,omitempty"`
+	Name             *string `protobuf:"bytes,3,req,name=Name" json:"Name,omitempty"`
+	Paused           *bool   `protobuf:"varint,4,req,name=Paused" json:"Paused,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SetSubscriptionPausedCommand) Reset()         { *m = SetSubscriptionPausedCommand{} }
+func (m *SetSubscriptionPausedCommand) String() string { return proto.CompactTextString(m) }
+func (*SetSubscriptionPausedCommand) ProtoMessage()    {}
+func (*SetSubscriptionPausedCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptorMeta, []int{75}
+}
+
+func (m *SetSubscriptionPausedCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *SetSubscriptionPausedCommand) GetRetentionPolicy() string {
+	if m != nil && m.RetentionPolicy != nil {
+		return *m.RetentionPolicy
+	}
+	return ""
+}
+
+func (m *SetSubscriptionPausedCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *SetSubscriptionPausedCommand) GetPaused() bool {
+	if m != nil && m.Paused != nil {
+		return *m.Paused
+	}
+	return false
+}
+
+var E_SetSubscriptionPausedCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetSubscriptionPausedCommand)(nil),
+	Field:         151,
+	Name:          "internal.SetSubscriptionPausedCommand.command",
+	Tag:           "bytes,151,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type RemoveShardOwnerCommand struct {
+	ID               *uint64 `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
+	NodeID           *uint64 `protobuf:"varint,2,req,name=NodeID" json:"NodeID,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *RemoveShardOwnerCommand) Reset()                    { *m = RemoveShardOwnerCommand{} }
+func (m *RemoveShardOwnerCommand) String() string            { return proto.CompactTextString(m) }
+func (*RemoveShardOwnerCommand) ProtoMessage()               {}
+func (*RemoveShardOwnerCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{76} }
+
+func (m *RemoveShardOwnerCommand) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *RemoveShardOwnerCommand) GetNodeID() uint64 {
+	if m != nil && m.NodeID != nil {
+		return *m.NodeID
+	}
+	return 0
+}
+
+var E_RemoveShardOwnerCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*RemoveShardOwnerCommand)(nil),
+	Field:         152,
+	Name:          "internal.RemoveShardOwnerCommand.command",
+	Tag:           "bytes,152,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type EnrollmentTokenInfo struct {
+	Hash             *string  `protobuf:"bytes,1,req,name=Hash" json:"Hash,omitempty"`
+	Name             *string  `protobuf:"bytes,2,req,name=Name" json:"Name,omitempty"`
+	Databases        []string `protobuf:"bytes,3,rep,name=Databases" json:"Databases,omitempty"`
+	CreatedAt        *int64   `protobuf:"varint,4,req,name=CreatedAt" json:"CreatedAt,omitempty"`
+	ExpiresAt        *int64   `protobuf:"varint,5,req,name=ExpiresAt" json:"ExpiresAt,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *EnrollmentTokenInfo) Reset()                    { *m = EnrollmentTokenInfo{} }
+func (m *EnrollmentTokenInfo) String() string            { return proto.CompactTextString(m) }
+func (*EnrollmentTokenInfo) ProtoMessage()               {}
+func (*EnrollmentTokenInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{77} }
+
+func (m *EnrollmentTokenInfo) GetHash() string {
+	if m != nil && m.Hash != nil {
+		return *m.Hash
+	}
+	return ""
+}
+
+func (m *EnrollmentTokenInfo) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *EnrollmentTokenInfo) GetDatabases() []string {
+	if m != nil {
+		return m.Databases
+	}
+	return nil
+}
+
+func (m *EnrollmentTokenInfo) GetCreatedAt() int64 {
+	if m != nil && m.CreatedAt != nil {
+		return *m.CreatedAt
+	}
+	return 0
+}
+
+func (m *EnrollmentTokenInfo) GetExpiresAt() int64 {
+	if m != nil && m.ExpiresAt != nil {
+		return *m.ExpiresAt
+	}
+	return 0
+}
+
+type AgentInfo struct {
+	Name             *string  `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Serial           *string  `protobuf:"bytes,2,req,name=Serial" json:"Serial,omitempty"`
+	Databases        []string `protobuf:"bytes,3,rep,name=Databases" json:"Databases,omitempty"`
+	CreatedAt        *int64   `protobuf:"varint,4,req,name=CreatedAt" json:"CreatedAt,omitempty"`
+	ExpiresAt        *int64   `protobuf:"varint,5,req,name=ExpiresAt" json:"ExpiresAt,omitempty"`
+	Revoked          *bool    `protobuf:"varint,6,opt,name=Revoked" json:"Revoked,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *AgentInfo) Reset()                    { *m = AgentInfo{} }
+func (m *AgentInfo) String() string            { return proto.CompactTextString(m) }
+func (*AgentInfo) ProtoMessage()               {}
+func (*AgentInfo) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{78} }
+
+func (m *AgentInfo) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *AgentInfo) GetSerial() string {
+	if m != nil && m.Serial != nil {
+		return *m.Serial
+	}
+	return ""
+}
+
+func (m *AgentInfo) GetDatabases() []string {
+	if m != nil {
+		return m.Databases
+	}
+	return nil
+}
+
+func (m *AgentInfo) GetCreatedAt() int64 {
+	if m != nil && m.CreatedAt != nil {
+		return *m.CreatedAt
+	}
+	return 0
+}
+
+func (m *AgentInfo) GetExpiresAt() int64 {
+	if m != nil && m.ExpiresAt != nil {
+		return *m.ExpiresAt
+	}
+	return 0
+}
+
+func (m *AgentInfo) GetRevoked() bool {
+	if m != nil && m.Revoked != nil {
+		return *m.Revoked
+	}
+	return false
+}
+
+type CreateEnrollmentTokenCommand struct {
+	Token            *EnrollmentTokenInfo `protobuf:"bytes,1,req,name=Token" json:"Token,omitempty"`
+	XXX_unrecognized []byte               `json:"-"`
+}
+
+func (m *CreateEnrollmentTokenCommand) Reset()         { *m = CreateEnrollmentTokenCommand{} }
+func (m *CreateEnrollmentTokenCommand) String() string { return proto.CompactTextString(m) }
+func (*CreateEnrollmentTokenCommand) ProtoMessage()    {}
+func (*CreateEnrollmentTokenCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptorMeta, []int{79}
+}
+
+func (m *CreateEnrollmentTokenCommand) GetToken() *EnrollmentTokenInfo {
+	if m != nil {
+		return m.Token
+	}
+	return nil
+}
+
+var E_CreateEnrollmentTokenCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*CreateEnrollmentTokenCommand)(nil),
+	Field:         153,
+	Name:          "internal.CreateEnrollmentTokenCommand.command",
+	Tag:           "bytes,153,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type RedeemEnrollmentTokenCommand struct {
+	Hash             *string `protobuf:"bytes,1,req,name=Hash" json:"Hash,omitempty"`
+	Serial           *string `protobuf:"bytes,2,req,name=Serial" json:"Serial,omitempty"`
+	CreatedAt        *int64  `protobuf:"varint,3,req,name=CreatedAt" json:"CreatedAt,omitempty"`
+	ExpiresAt        *int64  `protobuf:"varint,4,req,name=ExpiresAt" json:"ExpiresAt,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *RedeemEnrollmentTokenCommand) Reset()         { *m = RedeemEnrollmentTokenCommand{} }
+func (m *RedeemEnrollmentTokenCommand) String() string { return proto.CompactTextString(m) }
+func (*RedeemEnrollmentTokenCommand) ProtoMessage()    {}
+func (*RedeemEnrollmentTokenCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptorMeta, []int{80}
+}
+
+func (m *RedeemEnrollmentTokenCommand) GetHash() string {
+	if m != nil && m.Hash != nil {
+		return *m.Hash
+	}
+	return ""
+}
+
+func (m *RedeemEnrollmentTokenCommand) GetSerial() string {
+	if m != nil && m.Serial != nil {
+		return *m.Serial
+	}
+	return ""
+}
+
+func (m *RedeemEnrollmentTokenCommand) GetCreatedAt() int64 {
+	if m != nil && m.CreatedAt != nil {
+		return *m.CreatedAt
+	}
+	return 0
+}
+
+func (m *RedeemEnrollmentTokenCommand) GetExpiresAt() int64 {
+	if m != nil && m.ExpiresAt != nil {
+		return *m.ExpiresAt
+	}
+	return 0
+}
+
+var E_RedeemEnrollmentTokenCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*RedeemEnrollmentTokenCommand)(nil),
+	Field:         154,
+	Name:          "internal.RedeemEnrollmentTokenCommand.command",
+	Tag:           "bytes,154,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type RevokeAgentCommand struct {
+	Name             *string `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *RevokeAgentCommand) Reset()                    { *m = RevokeAgentCommand{} }
+func (m *RevokeAgentCommand) String() string            { return proto.CompactTextString(m) }
+func (*RevokeAgentCommand) ProtoMessage()               {}
+func (*RevokeAgentCommand) Descriptor() ([]byte, []int) { return fileDescriptorMeta, []int{81} }
+
+func (m *RevokeAgentCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+var E_RevokeAgentCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*RevokeAgentCommand)(nil),
+	Field:         155,
+	Name:          "internal.RevokeAgentCommand.command",
+	Tag:           "bytes,155,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
 func init() {
 	proto.RegisterType((*Data)(nil), "meta.Data")
 	proto.RegisterType((*NodeInfo)(nil), "meta.NodeInfo")
@@ -1859,6 +3681,45 @@ func init() {
 	proto.RegisterType((*Response)(nil), "meta.Response")
 	proto.RegisterType((*SetMetaNodeCommand)(nil), "meta.SetMetaNodeCommand")
 	proto.RegisterType((*DropShardCommand)(nil), "meta.DropShardCommand")
+	proto.RegisterType((*DatabaseTemplateInfo)(nil), "meta.DatabaseTemplateInfo")
+	proto.RegisterType((*DatabaseTemplateGrant)(nil), "meta.DatabaseTemplateGrant")
+	proto.RegisterType((*DatabaseTemplateLimits)(nil), "meta.DatabaseTemplateLimits")
+	proto.RegisterType((*CreateDatabaseTemplateCommand)(nil), "meta.CreateDatabaseTemplateCommand")
+	proto.RegisterType((*DropDatabaseTemplateCommand)(nil), "meta.DropDatabaseTemplateCommand")
+	proto.RegisterType((*InstantiateDatabaseTemplateCommand)(nil), "meta.InstantiateDatabaseTemplateCommand")
+	proto.RegisterType((*ShardGroupMergeInfo)(nil), "meta.ShardGroupMergeInfo")
+	proto.RegisterType((*CreateShardGroupMergeCommand)(nil), "meta.CreateShardGroupMergeCommand")
+	proto.RegisterType((*CompleteShardGroupMergeCommand)(nil), "meta.CompleteShardGroupMergeCommand")
+	proto.RegisterType((*MeasurementShardGroupDuration)(nil), "meta.MeasurementShardGroupDuration")
+	proto.RegisterType((*RoleInfo)(nil), "meta.RoleInfo")
+	proto.RegisterType((*CreateRoleCommand)(nil), "meta.CreateRoleCommand")
+	proto.RegisterType((*DropRoleCommand)(nil), "meta.DropRoleCommand")
+	proto.RegisterType((*SetRolePrivilegeCommand)(nil), "meta.SetRolePrivilegeCommand")
+	proto.RegisterType((*SetUserRoleCommand)(nil), "meta.SetUserRoleCommand")
+	proto.RegisterType((*SessionInfo)(nil), "meta.SessionInfo")
+	proto.RegisterType((*CreateSessionCommand)(nil), "meta.CreateSessionCommand")
+	proto.RegisterType((*DropSessionCommand)(nil), "meta.DropSessionCommand")
+	proto.RegisterType((*AddShardOwnerCommand)(nil), "meta.AddShardOwnerCommand")
+	proto.RegisterType((*SetDataNodeModeCommand)(nil), "meta.SetDataNodeModeCommand")
+	proto.RegisterType((*SetDatabaseFrozenCommand)(nil), "meta.SetDatabaseFrozenCommand")
+	proto.RegisterType((*QueryTemplateInfo)(nil), "meta.QueryTemplateInfo")
+	proto.RegisterType((*QueryTemplateVersion)(nil), "meta.QueryTemplateVersion")
+	proto.RegisterType((*CreateQueryTemplateCommand)(nil), "meta.CreateQueryTemplateCommand")
+	proto.RegisterType((*DropQueryTemplateCommand)(nil), "meta.DropQueryTemplateCommand")
+	proto.RegisterType((*MaterializedViewInfo)(nil), "meta.MaterializedViewInfo")
+	proto.RegisterType((*CreateMaterializedViewCommand)(nil), "meta.CreateMaterializedViewCommand")
+	proto.RegisterType((*DropMaterializedViewCommand)(nil), "meta.DropMaterializedViewCommand")
+	proto.RegisterType((*NodeLabel)(nil), "meta.NodeLabel")
+	proto.RegisterType((*PlacementInfo)(nil), "meta.PlacementInfo")
+	proto.RegisterType((*SetDataNodeLabelsCommand)(nil), "meta.SetDataNodeLabelsCommand")
+	proto.RegisterType((*SetDatabasePlacementCommand)(nil), "meta.SetDatabasePlacementCommand")
+	proto.RegisterType((*SetSubscriptionPausedCommand)(nil), "meta.SetSubscriptionPausedCommand")
+	proto.RegisterType((*RemoveShardOwnerCommand)(nil), "meta.RemoveShardOwnerCommand")
+	proto.RegisterType((*EnrollmentTokenInfo)(nil), "meta.EnrollmentTokenInfo")
+	proto.RegisterType((*AgentInfo)(nil), "meta.AgentInfo")
+	proto.RegisterType((*CreateEnrollmentTokenCommand)(nil), "meta.CreateEnrollmentTokenCommand")
+	proto.RegisterType((*RedeemEnrollmentTokenCommand)(nil), "meta.RedeemEnrollmentTokenCommand")
+	proto.RegisterType((*RevokeAgentCommand)(nil), "meta.RevokeAgentCommand")
 	proto.RegisterEnum("meta.Command_Type", Command_Type_name, Command_Type_value)
 	proto.RegisterExtension(E_CreateNodeCommand_Command)
 	proto.RegisterExtension(E_DeleteNodeCommand_Command)
@@ -1889,123 +3750,260 @@ func init() {
 	proto.RegisterExtension(E_DeleteDataNodeCommand_Command)
 	proto.RegisterExtension(E_SetMetaNodeCommand_Command)
 	proto.RegisterExtension(E_DropShardCommand_Command)
+	proto.RegisterExtension(E_CreateDatabaseTemplateCommand_Command)
+	proto.RegisterExtension(E_DropDatabaseTemplateCommand_Command)
+	proto.RegisterExtension(E_InstantiateDatabaseTemplateCommand_Command)
+	proto.RegisterExtension(E_CreateShardGroupMergeCommand_Command)
+	proto.RegisterExtension(E_CompleteShardGroupMergeCommand_Command)
+	proto.RegisterExtension(E_CreateRoleCommand_Command)
+	proto.RegisterExtension(E_DropRoleCommand_Command)
+	proto.RegisterExtension(E_SetRolePrivilegeCommand_Command)
+	proto.RegisterExtension(E_SetUserRoleCommand_Command)
+	proto.RegisterExtension(E_CreateSessionCommand_Command)
+	proto.RegisterExtension(E_DropSessionCommand_Command)
+	proto.RegisterExtension(E_AddShardOwnerCommand_Command)
+	proto.RegisterExtension(E_SetDataNodeModeCommand_Command)
+	proto.RegisterExtension(E_SetDatabaseFrozenCommand_Command)
+	proto.RegisterExtension(E_CreateQueryTemplateCommand_Command)
+	proto.RegisterExtension(E_DropQueryTemplateCommand_Command)
+	proto.RegisterExtension(E_CreateMaterializedViewCommand_Command)
+	proto.RegisterExtension(E_DropMaterializedViewCommand_Command)
+	proto.RegisterExtension(E_SetDataNodeLabelsCommand_Command)
+	proto.RegisterExtension(E_SetDatabasePlacementCommand_Command)
+	proto.RegisterExtension(E_SetSubscriptionPausedCommand_Command)
+	proto.RegisterExtension(E_RemoveShardOwnerCommand_Command)
+	proto.RegisterExtension(E_CreateEnrollmentTokenCommand_Command)
+	proto.RegisterExtension(E_RedeemEnrollmentTokenCommand_Command)
+	proto.RegisterExtension(E_RevokeAgentCommand_Command)
 }
 
 func init() { proto.RegisterFile("internal/meta.proto", fileDescriptorMeta) }
 
 var fileDescriptorMeta = []byte{
-	// 1808 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x59, 0x4b, 0x6f, 0x1c, 0xc7,
-	0x11, 0x46, 0xcf, 0x3e, 0xb8, 0x5b, 0x7c, 0xaa, 0xf9, 0x1a, 0x4a, 0x14, 0xb3, 0x18, 0x08, 0xca,
-	0x22, 0x08, 0x98, 0x60, 0x03, 0xe8, 0x94, 0x97, 0xc4, 0x95, 0xc4, 0x85, 0xc0, 0x47, 0x66, 0xa9,
-	0x6b, 0x80, 0x11, 0xb7, 0x25, 0x6e, 0xb2, 0x3b, 0xb3, 0x99, 0x99, 0x95, 0xc4, 0x28, 0x4c, 0x18,
-	0x5f, 0x7c, 0xb5, 0x61, 0x18, 0x3e, 0xe8, 0x66, 0x1f, 0x7c, 0x34, 0x0c, 0x03, 0x06, 0x0c, 0x9f,
-	0x7c, 0xf7, 0x1f, 0xf0, 0x7f, 0xb0, 0xcf, 0xbe, 0x1a, 0xdd, 0x3d, 0x3d, 0xdd, 0x33, 0xd3, 0x3d,
-	0x24, 0x65, 0xf9, 0x36, 0x5d, 0x55, 0xdd, 0xf5, 0x55, 0x75, 0x75, 0x75, 0x55, 0x0f, 0x2c, 0x0f,
-	0xfd, 0x98, 0x84, 0xbe, 0x37, 0xfa, 0xdd, 0x98, 0xc4, 0xde, 0xf6, 0x24, 0x0c, 0xe2, 0x00, 0x57,
-	0xe9, 0xb7, 0xf3, 0x5e, 0x05, 0xaa, 0x5d, 0x2f, 0xf6, 0x30, 0x86, 0xea, 0x11, 0x09, 0xc7, 0x36,
-	0x6a, 0x59, 0xed, 0xaa, 0xcb, 0xbe, 0xf1, 0x0a, 0xd4, 0x7a, 0xfe, 0x80, 0xbc, 0xb4, 0x2d, 0x46,
-	0xe4, 0x03, 0xbc, 0x09, 0xcd, 0x9d, 0xd1, 0x34, 0x8a, 0x49, 0xd8, 0xeb, 0xda, 0x15, 0xc6, 0x91,
-	0x04, 0x7c, 0x0b, 0x6a, 0xfb, 0xc1, 0x80, 0x44, 0x76, 0xb5, 0x55, 0x69, 0xcf, 0x76, 0x16, 0xb6,
-	0x99, 0x4a, 0x4a, 0xea, 0xf9, 0x4f, 0x03, 0x97, 0x33, 0xf1, 0xef, 0xa1, 0x49, 0xb5, 0x3e, 0xf1,
-	0x22, 0x12, 0xd9, 0x35, 0x26, 0x89, 0xb9, 0xa4, 0x20, 0x33, 0x69, 0x29, 0x44, 0xd7, 0x7d, 0x1c,
-	0x91, 0x30, 0xb2, 0xeb, 0xea, 0xba, 0x94, 0xc4, 0xd7, 0x65, 0x4c, 0x8a, 0x6d, 0xcf, 0x7b, 0xc9,
-	0xb4, 0x75, 0xed, 0x19, 0x8e, 0x2d, 0x25, 0xe0, 0x36, 0x2c, 0xee, 0x79, 0x2f, 0xfb, 0x27, 0x5e,
-	0x38, 0x78, 0x18, 0x06, 0xd3, 0x49, 0xaf, 0x6b, 0x37, 0x98, 0x4c, 0x9e, 0x8c, 0xb7, 0x00, 0x04,
-	0xa9, 0xd7, 0xb5, 0x9b, 0x4c, 0x48, 0xa1, 0xe0, 0xdf, 0x72, 0xfc, 0xdc, 0x52, 0xd0, 0x5a, 0x2a,
-	0x05, 0xa8, 0xf4, 0x1e, 0x11, 0xd2, 0xb3, 0x7a, 0xe9, 0x54, 0xc0, 0xd9, 0x85, 0x86, 0x20, 0xe3,
-	0x05, 0xb0, 0x7a, 0xdd, 0x64, 0x4f, 0xac, 0x5e, 0x97, 0xee, 0xd2, 0x6e, 0x10, 0xc5, 0x6c, 0x43,
-	0x9a, 0x2e, 0xfb, 0xc6, 0x36, 0xcc, 0x1c, 0xed, 0x1c, 0x32, 0x72, 0xa5, 0x85, 0xda, 0x4d, 0x57,
-	0x0c, 0x9d, 0xef, 0x11, 0xcc, 0xa9, 0xfe, 0xa4, 0xd3, 0xf7, 0xbd, 0x31, 0x61, 0x0b, 0x36, 0x5d,
-	0xf6, 0x8d, 0xef, 0xc0, 0x5a, 0x97, 0x3c, 0xf5, 0xa6, 0xa3, 0xd8, 0x25, 0x31, 0xf1, 0xe3, 0x61,
-	0xe0, 0x1f, 0x06, 0xa3, 0xe1, 0xf1, 0x69, 0xa2, 0xc4, 0xc0, 0xc5, 0x0f, 0xe1, 0x5a, 0x96, 0x34,
-	0x24, 0x91, 0x5d, 0x61, 0xc6, 0x6d, 0x70, 0xe3, 0x72, 0x33, 0x98, 0x9d, 0xc5, 0x39, 0x74, 0xa1,
-	0x9d, 0xc0, 0x8f, 0x87, 0xfe, 0x34, 0x98, 0x46, 0x7f, 0x9b, 0x92, 0x70, 0x98, 0x46, 0x4f, 0xb2,
-	0x50, 0x96, 0x9d, 0x2c, 0x54, 0x98, 0xe3, 0xbc, 0x8f, 0x60, 0x39, 0xa7, 0xb3, 0x3f, 0x21, 0xc7,
-	0x8a, 0xd5, 0x28, 0xb5, 0xfa, 0x3a, 0x34, 0xba, 0xd3, 0xd0, 0xa3, 0x92, 0xb6, 0xd5, 0x42, 0xed,
-	0x8a, 0x9b, 0x8e, 0xf1, 0x36, 0x60, 0x19, 0x0c, 0xa9, 0x54, 0x85, 0x49, 0x69, 0x38, 0x74, 0x2d,
-	0x97, 0x4c, 0x46, 0xc3, 0x63, 0x6f, 0xdf, 0xae, 0xb6, 0x50, 0x7b, 0xde, 0x4d, 0xc7, 0xce, 0xbb,
-	0x56, 0x01, 0x93, 0x71, 0x27, 0xb2, 0x98, 0xac, 0x4b, 0x61, 0xb2, 0x2e, 0x85, 0xc9, 0x52, 0x31,
-	0xe1, 0x3b, 0x30, 0x2b, 0x67, 0x88, 0xe3, 0xb7, 0xc2, 0x5d, 0xad, 0x9c, 0x02, 0xea, 0x65, 0x55,
-	0x10, 0xff, 0x11, 0xe6, 0xfb, 0xd3, 0x27, 0xd1, 0x71, 0x38, 0x9c, 0x50, 0x1d, 0xe2, 0x28, 0xae,
-	0x25, 0x33, 0x15, 0x16, 0x9b, 0x9b, 0x15, 0x76, 0xbe, 0x41, 0xb0, 0x90, 0x5d, 0xbd, 0x10, 0xdd,
-	0x9b, 0xd0, 0xec, 0xc7, 0x5e, 0x18, 0x1f, 0x0d, 0xc7, 0x24, 0xf1, 0x80, 0x24, 0xd0, 0x38, 0xbf,
-	0xef, 0x0f, 0x18, 0x8f, 0xdb, 0x2d, 0x86, 0x74, 0x5e, 0x97, 0x8c, 0x48, 0x4c, 0x06, 0x77, 0x63,
-	0x66, 0x6d, 0xc5, 0x95, 0x04, 0xfc, 0x6b, 0xa8, 0x33, 0xbd, 0xc2, 0xd2, 0x45, 0xc5, 0x52, 0x06,
-	0x34, 0x61, 0xe3, 0x16, 0xcc, 0x1e, 0x85, 0x53, 0xff, 0xd8, 0xe3, 0x0b, 0xd5, 0xd9, 0x86, 0xab,
-	0x24, 0x87, 0x40, 0x33, 0x9d, 0x56, 0x40, 0xbf, 0x05, 0x8d, 0x83, 0x17, 0x3e, 0x4d, 0x82, 0x91,
-	0x6d, 0xb5, 0x2a, 0xed, 0xea, 0x3d, 0xcb, 0x46, 0x6e, 0x4a, 0xc3, 0x6d, 0xa8, 0xb3, 0x6f, 0x71,
-	0x4a, 0x96, 0x14, 0x1c, 0x8c, 0xe1, 0x26, 0x7c, 0xe7, 0xef, 0xb0, 0x94, 0xf7, 0xa6, 0x36, 0x60,
-	0x30, 0x54, 0xf7, 0x82, 0x01, 0x11, 0xd9, 0x80, 0x7e, 0x63, 0x07, 0xe6, 0xba, 0x24, 0x8a, 0x87,
-	0xbe, 0xc7, 0xf7, 0x88, 0xea, 0x6a, 0xba, 0x19, 0x9a, 0x73, 0x0b, 0x40, 0x6a, 0xc5, 0x6b, 0x50,
-	0x4f, 0x12, 0x26, 0xb7, 0x25, 0x19, 0x39, 0x7f, 0x81, 0x65, 0xcd, 0xc1, 0xd3, 0x02, 0x59, 0x81,
-	0x1a, 0x13, 0x48, 0x90, 0xf0, 0x81, 0x73, 0x06, 0x0d, 0x91, 0x9f, 0x4d, 0xf0, 0x77, 0xbd, 0xe8,
-	0x24, 0x4d, 0x66, 0x5e, 0x74, 0x42, 0x57, 0xba, 0x3b, 0x18, 0x0f, 0x79, 0x68, 0x37, 0x5c, 0x3e,
-	0xc0, 0x7f, 0x00, 0x38, 0x0c, 0x87, 0xcf, 0x87, 0x23, 0xf2, 0x2c, 0xcd, 0x0d, 0xcb, 0xf2, 0x06,
-	0x48, 0x79, 0xae, 0x22, 0xe6, 0xf4, 0x60, 0x3e, 0xc3, 0x64, 0xe7, 0x2b, 0xc9, 0x86, 0x09, 0x8e,
-	0x74, 0x4c, 0x43, 0x28, 0x15, 0x64, 0x80, 0x6a, 0xae, 0x24, 0x38, 0xdf, 0xd5, 0x61, 0x66, 0x27,
-	0x18, 0x8f, 0x3d, 0x7f, 0x80, 0x6f, 0x43, 0x35, 0x3e, 0x9d, 0xf0, 0x15, 0x16, 0xc4, 0xad, 0x95,
-	0x30, 0xb7, 0x8f, 0x4e, 0x27, 0xc4, 0x65, 0x7c, 0xe7, 0x75, 0x1d, 0xaa, 0x74, 0x88, 0x57, 0xe1,
-	0xda, 0x4e, 0x48, 0xbc, 0x98, 0x50, 0xbf, 0x26, 0x82, 0x4b, 0x88, 0x92, 0x79, 0x8c, 0xaa, 0x64,
-	0x0b, 0x6f, 0xc0, 0x2a, 0x97, 0x16, 0xd0, 0x04, 0xab, 0x82, 0xd7, 0x61, 0xb9, 0x1b, 0x06, 0x93,
-	0x3c, 0xa3, 0x8a, 0x5b, 0xb0, 0xc9, 0xe7, 0xe4, 0x32, 0x8d, 0x90, 0xa8, 0xe1, 0x2d, 0xb8, 0x4e,
-	0xa7, 0x1a, 0xf8, 0x75, 0x7c, 0x0b, 0x5a, 0x7d, 0x12, 0xeb, 0x33, 0xbd, 0x90, 0x9a, 0xa1, 0x7a,
-	0x1e, 0x4f, 0x06, 0x66, 0x3d, 0x0d, 0x7c, 0x03, 0xd6, 0x39, 0x12, 0x79, 0xd2, 0x05, 0xb3, 0x49,
-	0x99, 0xdc, 0xe2, 0x22, 0x13, 0xa4, 0x0d, 0xb9, 0x98, 0x13, 0x12, 0xb3, 0xc2, 0x06, 0x03, 0x7f,
-	0x4e, 0xfa, 0x99, 0xee, 0xba, 0x20, 0xcf, 0xe3, 0x65, 0x58, 0xa4, 0xd3, 0x54, 0xe2, 0x02, 0x95,
-	0xe5, 0x96, 0xa8, 0xe4, 0x45, 0xea, 0xe1, 0x3e, 0x89, 0xd3, 0x7d, 0x17, 0x8c, 0x25, 0x8c, 0x61,
-	0x81, 0xfa, 0xc7, 0x8b, 0x3d, 0x41, 0xbb, 0x86, 0x37, 0xc1, 0xee, 0x93, 0x98, 0x05, 0x68, 0x61,
-	0x06, 0x96, 0x1a, 0xd4, 0xed, 0x5d, 0xc6, 0x37, 0x61, 0x23, 0x71, 0x90, 0x72, 0xc0, 0x05, 0x7b,
-	0x95, 0xb9, 0x28, 0x0c, 0x26, 0x3a, 0xe6, 0x1a, 0x5d, 0xd2, 0x25, 0xe3, 0xe0, 0x39, 0x39, 0x24,
-	0x12, 0xf4, 0xba, 0x8c, 0x18, 0x51, 0x42, 0x08, 0x96, 0x9d, 0x0d, 0x26, 0x95, 0xb5, 0x41, 0x59,
-	0x1c, 0x5f, 0x9e, 0x75, 0x9d, 0xb2, 0xf8, 0x3e, 0xe5, 0x17, 0xbc, 0x21, 0x59, 0xf9, 0x59, 0x9b,
-	0x78, 0x0d, 0x70, 0x9f, 0xc4, 0xf9, 0x29, 0x37, 0xf1, 0x0a, 0x2c, 0x31, 0x93, 0xe8, 0x9e, 0x0b,
-	0xea, 0xd6, 0x6f, 0x1a, 0x8d, 0xc1, 0xd2, 0xf9, 0xf9, 0xf9, 0xb9, 0xe5, 0x9c, 0x69, 0x8e, 0x47,
-	0x5a, 0xe7, 0x20, 0xa5, 0xce, 0xc1, 0x50, 0x75, 0x3d, 0x7f, 0x90, 0x14, 0xa3, 0xec, 0xbb, 0xf3,
-	0x57, 0x98, 0x39, 0x4e, 0xa6, 0xcc, 0x67, 0x4e, 0xa2, 0x4d, 0x5a, 0xa8, 0x3d, 0xdb, 0x59, 0x4f,
-	0x88, 0x79, 0x05, 0xae, 0x98, 0xe6, 0xbc, 0xd2, 0x1c, 0xc3, 0x42, 0x6a, 0x5f, 0x81, 0xda, 0x83,
-	0x20, 0x3c, 0xe6, 0x99, 0xa1, 0xe1, 0xf2, 0x41, 0x89, 0xf2, 0xa7, 0xaa, 0xf2, 0xc2, 0xf2, 0x52,
-	0xf9, 0x97, 0xc8, 0x70, 0xda, 0xb5, 0xf9, 0x72, 0x07, 0x16, 0x8b, 0x25, 0x1a, 0x2a, 0xaf, 0xb7,
-	0xf2, 0x33, 0x3a, 0x5d, 0x23, 0xe8, 0x67, 0x6c, 0xad, 0x1b, 0xaa, 0xc7, 0x72, 0xa8, 0x24, 0xf0,
-	0xb1, 0x36, 0x15, 0xe9, 0x50, 0x77, 0xee, 0x19, 0x15, 0x9e, 0xa8, 0xe0, 0x35, 0xcb, 0x49, 0x75,
-	0xdf, 0xa2, 0xf2, 0x0c, 0x57, 0x9a, 0xda, 0xb5, 0x6e, 0xb3, 0xae, 0xe8, 0xb6, 0x47, 0x46, 0x2b,
-	0x86, 0xcc, 0x0a, 0x47, 0x75, 0x9b, 0x1e, 0xa4, 0x34, 0xe7, 0x23, 0x54, 0x96, 0x8e, 0x4b, 0x8d,
-	0x11, 0x1e, 0xb6, 0x14, 0x0f, 0xf7, 0x8c, 0xd8, 0xfe, 0xc1, 0xb0, 0xb5, 0xa4, 0x87, 0x2f, 0x42,
-	0xf6, 0x09, 0xba, 0xf8, 0x22, 0xb8, 0x32, 0xbe, 0x03, 0x23, 0xbe, 0x7f, 0x32, 0x7c, 0xb7, 0x93,
-	0x42, 0xe8, 0x02, 0xbd, 0x12, 0xe5, 0x0f, 0xa8, 0xfc, 0x22, 0xba, 0x2a, 0x42, 0x5a, 0x5a, 0xee,
-	0x93, 0x17, 0x8c, 0x9c, 0xb4, 0x50, 0xc9, 0x30, 0x53, 0x93, 0x57, 0x73, 0x7d, 0x82, 0x5a, 0x63,
-	0xd7, 0xb2, 0x75, 0x7f, 0x49, 0xbc, 0x8c, 0xd4, 0x78, 0x29, 0xb3, 0x42, 0xda, 0xfb, 0x05, 0x32,
-	0x5e, 0xab, 0xa5, 0xa6, 0xae, 0x41, 0x3d, 0xd3, 0xca, 0x25, 0x23, 0x5a, 0xec, 0xd0, 0xba, 0x39,
-	0x8a, 0xbd, 0xf1, 0x24, 0xa9, 0xa5, 0x25, 0xa1, 0xf3, 0xc0, 0x08, 0x7d, 0xcc, 0xa0, 0xdf, 0x54,
-	0x43, 0xbd, 0x00, 0x48, 0xa2, 0xfe, 0x0a, 0x19, 0xef, 0xfb, 0x37, 0x42, 0xed, 0xc0, 0x5c, 0xa6,
-	0x75, 0xe7, 0x4f, 0x0f, 0x19, 0x5a, 0x09, 0x76, 0x5f, 0xc5, 0x6e, 0x80, 0x25, 0xb1, 0x7f, 0x8e,
-	0xca, 0xcb, 0x91, 0x2b, 0x47, 0x58, 0x5a, 0x21, 0x57, 0x94, 0x0a, 0xb9, 0x24, 0x4a, 0x82, 0x62,
-	0x56, 0xd1, 0x23, 0x29, 0x66, 0x95, 0xb7, 0x83, 0xb8, 0x24, 0xab, 0x4c, 0xf2, 0x59, 0xe5, 0x22,
-	0x64, 0x1f, 0x20, 0x4d, 0x69, 0xf6, 0xf3, 0x5a, 0x82, 0x92, 0xcb, 0xf7, 0x5f, 0xc5, 0x9b, 0x5f,
-	0x51, 0x2b, 0x51, 0x91, 0x42, 0x61, 0xa8, 0xbd, 0xbf, 0xfe, 0x6c, 0x54, 0x14, 0x32, 0x45, 0xab,
-	0xd2, 0x0f, 0x5a, 0x35, 0x67, 0x9a, 0x52, 0xf3, 0xb2, 0xb6, 0x97, 0x58, 0x19, 0xa9, 0x56, 0x16,
-	0x14, 0x48, 0xf5, 0x9f, 0x21, 0x6d, 0x4d, 0x4b, 0xc3, 0x81, 0xca, 0xfb, 0x12, 0x45, 0x3a, 0xce,
-	0x84, 0x8a, 0x55, 0xd6, 0x28, 0x55, 0x72, 0x8d, 0x52, 0xc9, 0x65, 0x1f, 0xab, 0x97, 0xbd, 0x06,
-	0x90, 0x44, 0x1c, 0xe4, 0x6b, 0x6d, 0xbc, 0xc5, 0xdf, 0x28, 0x19, 0xce, 0xd9, 0x0e, 0xc8, 0x87,
-	0x42, 0x97, 0xd1, 0x3b, 0x7f, 0x32, 0x6a, 0x9d, 0x32, 0xad, 0x2b, 0xf2, 0x82, 0x91, 0xab, 0x4a,
-	0x85, 0x1f, 0x22, 0x73, 0x25, 0x5f, 0xea, 0xa7, 0x34, 0x32, 0x2d, 0x35, 0x32, 0x1f, 0x1a, 0xd1,
-	0x3c, 0x67, 0x68, 0xb6, 0x52, 0x34, 0x5a, 0x8d, 0x12, 0xd7, 0xa9, 0xa6, 0x85, 0xb8, 0xcc, 0x8b,
-	0x60, 0x49, 0xd4, 0xbc, 0x28, 0x46, 0x8d, 0xb6, 0x30, 0xfd, 0x11, 0x95, 0xf4, 0x29, 0xc6, 0xc7,
-	0x2b, 0x53, 0xcc, 0xb4, 0x8b, 0x15, 0x18, 0x4f, 0x83, 0x79, 0x72, 0xfa, 0xa2, 0x51, 0x2d, 0x79,
-	0xd1, 0xa8, 0x15, 0x5f, 0x34, 0x3a, 0xbb, 0x46, 0x8b, 0x4f, 0x99, 0xc5, 0xbf, 0xca, 0xdc, 0x59,
-	0x45, 0x93, 0xa4, 0xe5, 0x5f, 0x23, 0x63, 0x0b, 0xf6, 0xcb, 0xd9, 0x5d, 0x72, 0x6f, 0xfd, 0x3b,
-	0x73, 0x6f, 0xe9, 0x81, 0x65, 0x42, 0xa6, 0xd0, 0x22, 0xa6, 0x21, 0x83, 0x64, 0xc8, 0xdc, 0x1d,
-	0x0c, 0x42, 0x11, 0x32, 0xf4, 0xbb, 0x24, 0x64, 0x5e, 0xa9, 0x21, 0x53, 0x58, 0x5c, 0xaa, 0xfe,
-	0x14, 0x19, 0xfa, 0x50, 0xea, 0xa2, 0xdd, 0xa3, 0xa3, 0x43, 0xa6, 0x33, 0x39, 0x42, 0x62, 0x9c,
-	0x3c, 0x5e, 0x2b, 0x70, 0xc4, 0x30, 0x6d, 0xf7, 0x2a, 0x4a, 0xbb, 0x67, 0x6e, 0x5e, 0xfe, 0x53,
-	0x6c, 0x5e, 0x72, 0x30, 0x32, 0xd7, 0x91, 0xbe, 0x2d, 0x7e, 0x33, 0xa4, 0x25, 0xa8, 0xce, 0xf4,
-	0x2d, 0x95, 0x16, 0xd5, 0x6b, 0x64, 0xe8, 0xc8, 0xaf, 0xfe, 0x13, 0xc0, 0x52, 0x7e, 0x02, 0x94,
-	0xa0, 0xfb, 0xaf, 0x8a, 0x4e, 0xab, 0x5a, 0x6d, 0xf8, 0xf4, 0x6f, 0x02, 0x79, 0x70, 0x25, 0xea,
-	0xfe, 0xa7, 0xaa, 0xd3, 0x2e, 0x26, 0xd5, 0xf9, 0x86, 0x77, 0x86, 0x82, 0xba, 0xfb, 0x46, 0x75,
-	0xe7, 0xa8, 0xa8, 0xcf, 0x68, 0xde, 0x03, 0x5a, 0xca, 0x47, 0x93, 0xc0, 0x8f, 0x08, 0x55, 0x71,
-	0xf0, 0x88, 0xa9, 0x68, 0xb8, 0xd6, 0xc1, 0x23, 0x9a, 0xe5, 0xef, 0x87, 0x61, 0x10, 0xb2, 0x66,
-	0xbb, 0xe9, 0xf2, 0x81, 0xfc, 0x37, 0x56, 0x61, 0xe7, 0x8a, 0x0f, 0x9c, 0x8f, 0x91, 0xee, 0x15,
-	0xe4, 0x2d, 0x9e, 0x00, 0xf3, 0x05, 0xfb, 0x7f, 0x6e, 0xaf, 0x9d, 0xde, 0x2e, 0x46, 0xe7, 0x0e,
-	0x8a, 0x2f, 0x32, 0x05, 0xbf, 0x9a, 0xf3, 0xc1, 0x3b, 0x5c, 0xcf, 0x9a, 0x92, 0x91, 0x94, 0x85,
-	0x52, 0x2d, 0x3f, 0x05, 0x00, 0x00, 0xff, 0xff, 0xc7, 0x04, 0x86, 0xd9, 0x75, 0x1c, 0x00, 0x00,
+	// 3597 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x1b, 0x4b, 0x73, 0x1c, 0x47,
+	0xb9, 0x7a, 0x77, 0x25, 0xed, 0xb6, 0xde, 0x2d, 0x59, 0x1e, 0xcb, 0xb2, 0xa2, 0x4c, 0x84, 0xa3,
+	0x38, 0x89, 0x93, 0xac, 0x83, 0xa9, 0xa2, 0x80, 0x44, 0xd1, 0xc3, 0x16, 0x8e, 0x6c, 0x31, 0xab,
+	0x98, 0xf3, 0x58, 0xdb, 0xb6, 0x27, 0xde, 0x9d, 0xd9, 0xcc, 0xcc, 0xda, 0x56, 0x42, 0x82, 0x81,
+	0xf0, 0x48, 0x78, 0x24, 0x24, 0x84, 0x40, 0x52, 0x29, 0x28, 0xa8, 0x82, 0x03, 0x07, 0x42, 0xc1,
+	0x85, 0xa2, 0xb8, 0x72, 0xa2, 0x38, 0x50, 0x3c, 0x6e, 0x90, 0x2a, 0x8a, 0x9f, 0xc0, 0x8d, 0x03,
+	0xd5, 0xaf, 0xe9, 0x9e, 0x99, 0xee, 0xd6, 0x2a, 0x8f, 0x03, 0xb7, 0xe9, 0xef, 0xfb, 0xba, 0xbf,
+	0x47, 0x7f, 0xfd, 0xf8, 0xbe, 0xfe, 0x06, 0xce, 0x04, 0x61, 0x8a, 0xe3, 0xd0, 0xef, 0x3c, 0xd4,
+	0xc5, 0xa9, 0x7f, 0xba, 0x17, 0x47, 0x69, 0x84, 0x6a, 0xe4, 0xdb, 0x7d, 0x6f, 0x08, 0xd6, 0xd6,
+	0xfd, 0xd4, 0x47, 0x08, 0xd6, 0x76, 0x71, 0xdc, 0x75, 0xc0, 0x52, 0x65, 0xa5, 0xe6, 0xd1, 0x6f,
+	0x34, 0x0b, 0x87, 0xb6, 0xc2, 0x36, 0xbe, 0xed, 0x54, 0x28, 0x90, 0x35, 0xd0, 0x02, 0x6c, 0xac,
+	0x75, 0xfa, 0x49, 0x8a, 0xe3, 0xad, 0x75, 0xa7, 0x4a, 0x31, 0x12, 0x80, 0x96, 0xe1, 0xd0, 0xc5,
+	0xa8, 0x8d, 0x13, 0xa7, 0xb6, 0x54, 0x5d, 0x19, 0x6d, 0x4e, 0x9c, 0xa6, 0x2c, 0x09, 0x68, 0x2b,
+	0xbc, 0x1a, 0x79, 0x0c, 0x89, 0x1e, 0x86, 0x0d, 0xc2, 0xf5, 0x8a, 0x9f, 0xe0, 0xc4, 0x19, 0xa2,
+	0x94, 0x88, 0x51, 0x0a, 0x30, 0xa5, 0x96, 0x44, 0x64, 0xdc, 0xa7, 0x12, 0x1c, 0x27, 0xce, 0xb0,
+	0x3a, 0x2e, 0x01, 0xb1, 0x71, 0x29, 0x92, 0xc8, 0xb6, 0xed, 0xdf, 0xa6, 0xdc, 0xd6, 0x9d, 0x11,
+	0x26, 0x5b, 0x06, 0x40, 0x2b, 0x70, 0x72, 0xdb, 0xbf, 0xdd, 0xba, 0xee, 0xc7, 0xed, 0x73, 0x71,
+	0xd4, 0xef, 0x6d, 0xad, 0x3b, 0x75, 0x4a, 0x53, 0x04, 0xa3, 0x45, 0x08, 0x05, 0x68, 0x6b, 0xdd,
+	0x69, 0x50, 0x22, 0x05, 0x82, 0x1e, 0x60, 0xf2, 0x33, 0x4d, 0xa1, 0x56, 0x53, 0x49, 0x40, 0xa8,
+	0xb7, 0xb1, 0xa0, 0x1e, 0xd5, 0x53, 0x67, 0x04, 0xe8, 0x3c, 0x9c, 0x16, 0x6a, 0xef, 0xe2, 0x6e,
+	0xaf, 0xe3, 0xa7, 0x38, 0x71, 0xc6, 0x68, 0xaf, 0xf9, 0xbc, 0x8d, 0x04, 0x9a, 0x8e, 0x50, 0xee,
+	0x44, 0x6c, 0xe6, 0x45, 0x1d, 0x9c, 0x38, 0xe3, 0x2a, 0x4f, 0x02, 0x62, 0x36, 0xa3, 0x48, 0xf4,
+	0x20, 0xac, 0xb7, 0x70, 0x92, 0x04, 0x51, 0x98, 0x38, 0x13, 0x94, 0x70, 0x9a, 0x11, 0x72, 0x28,
+	0xa5, 0xcd, 0x48, 0xd0, 0x63, 0x70, 0xe2, 0x73, 0x7d, 0x1c, 0xef, 0x4b, 0xd9, 0x26, 0x69, 0xa7,
+	0xa3, 0xac, 0x53, 0x0e, 0x47, 0xbb, 0x16, 0xc8, 0xd1, 0x06, 0x9c, 0xda, 0x08, 0xe3, 0xa8, 0xd3,
+	0xe9, 0xe2, 0x30, 0xdd, 0x8d, 0x6e, 0xe0, 0x30, 0x71, 0xa6, 0xe8, 0x10, 0xc7, 0xd8, 0x10, 0x05,
+	0x2c, 0x1d, 0xa4, 0xd4, 0x05, 0xdd, 0x0b, 0x87, 0x57, 0xaf, 0xe1, 0x30, 0x4d, 0x9c, 0x69, 0xda,
+	0x79, 0x92, 0x75, 0xa6, 0x30, 0xda, 0x85, 0xa3, 0xdd, 0xbf, 0x03, 0x58, 0x17, 0x76, 0x46, 0x13,
+	0xb0, 0xb2, 0xb5, 0xce, 0x9d, 0xbc, 0xb2, 0xb5, 0x4e, 0xdc, 0xfe, 0x7c, 0x94, 0xa4, 0xd4, 0xc3,
+	0x1b, 0x1e, 0xfd, 0x46, 0x0e, 0x1c, 0xd9, 0x5d, 0xdb, 0xa1, 0xe0, 0xea, 0x12, 0x58, 0x69, 0x78,
+	0xa2, 0x89, 0xe6, 0x61, 0xdd, 0xc3, 0x7e, 0xfb, 0x52, 0xd8, 0xd9, 0x77, 0x6a, 0x4b, 0x60, 0xa5,
+	0xee, 0x65, 0x6d, 0x74, 0x12, 0x4e, 0x88, 0x6f, 0x0f, 0xfb, 0x49, 0x14, 0x3a, 0x43, 0xb4, 0x73,
+	0x01, 0x8a, 0x96, 0xe0, 0xe8, 0xb6, 0x4f, 0x16, 0x64, 0xe8, 0x87, 0x7b, 0xd8, 0x19, 0xa6, 0xc3,
+	0xa8, 0x20, 0xa2, 0xd9, 0x93, 0xfe, 0x15, 0xdc, 0x49, 0x9c, 0x11, 0x55, 0x33, 0xa2, 0x03, 0x85,
+	0x7b, 0x1c, 0xed, 0x9e, 0x81, 0x8d, 0x0c, 0x88, 0xa6, 0x60, 0xf5, 0x02, 0xde, 0xa7, 0xaa, 0x35,
+	0x3c, 0xf2, 0x49, 0x96, 0xef, 0x65, 0xbf, 0xd3, 0xc7, 0x5c, 0x39, 0xd6, 0x70, 0xdf, 0xa9, 0xc2,
+	0x31, 0x75, 0x91, 0x11, 0x13, 0x5c, 0xf4, 0xbb, 0x98, 0xf7, 0xa4, 0xdf, 0xe8, 0x2c, 0x9c, 0x5b,
+	0xc7, 0x57, 0xfd, 0x7e, 0x27, 0xf5, 0x70, 0x8a, 0xc3, 0x34, 0x88, 0xc2, 0x9d, 0xa8, 0x13, 0xec,
+	0xed, 0xf3, 0xb1, 0x0c, 0x58, 0x74, 0x0e, 0x4e, 0xe7, 0x41, 0x01, 0x4e, 0x9c, 0xaa, 0x3a, 0xb9,
+	0x85, 0x1e, 0xcc, 0x75, 0x4b, 0x7d, 0xc8, 0x40, 0x6b, 0x51, 0x98, 0x06, 0x61, 0x3f, 0xea, 0x27,
+	0xc4, 0x81, 0x82, 0x6c, 0x4b, 0xe1, 0x03, 0xe5, 0xd1, 0x7c, 0xa0, 0x52, 0x1f, 0x32, 0x65, 0x74,
+	0xd1, 0x12, 0xdb, 0x90, 0x8d, 0xa6, 0xe1, 0x65, 0x6d, 0x34, 0x07, 0x87, 0x37, 0xe3, 0xe8, 0x59,
+	0x1c, 0xf2, 0x59, 0xe0, 0x2d, 0xb2, 0x02, 0xb7, 0xfd, 0x14, 0xc7, 0x81, 0xdf, 0x09, 0x9e, 0xc5,
+	0xed, 0xcb, 0x01, 0xbe, 0x25, 0xe6, 0x82, 0xaf, 0xc0, 0x22, 0x9a, 0x71, 0x2f, 0x75, 0x42, 0x8f,
+	0xc0, 0xc6, 0x4e, 0xc7, 0xdf, 0xc3, 0xc4, 0x6f, 0x9d, 0xfa, 0x12, 0x58, 0x19, 0x6d, 0xce, 0xb0,
+	0x11, 0x32, 0x30, 0x5b, 0xfe, 0x59, 0xd3, 0xbd, 0x0c, 0xc7, 0x73, 0x38, 0x74, 0x1f, 0x1c, 0xf1,
+	0xf0, 0x33, 0xfd, 0x20, 0x26, 0x53, 0xa4, 0xf5, 0x07, 0x81, 0xa7, 0xca, 0xf6, 0x62, 0xec, 0xb7,
+	0x9f, 0x20, 0x13, 0xc5, 0x94, 0xe5, 0x6d, 0xf7, 0xdf, 0x00, 0xce, 0x14, 0x8c, 0xdf, 0xea, 0xe1,
+	0x3d, 0x65, 0xfa, 0x41, 0x36, 0xfd, 0xf3, 0xb0, 0xbe, 0xde, 0x8f, 0x7d, 0x42, 0xe9, 0x54, 0x96,
+	0xc0, 0x4a, 0xd5, 0xcb, 0xda, 0xe8, 0x34, 0x44, 0x72, 0xab, 0xcc, 0xa8, 0xaa, 0x94, 0x4a, 0x83,
+	0x61, 0x6b, 0xa6, 0xd7, 0x09, 0xf6, 0xfc, 0x8b, 0x74, 0xcd, 0x8c, 0x7b, 0x59, 0x1b, 0x9d, 0x82,
+	0x53, 0x9b, 0xfd, 0xb4, 0x1f, 0xe3, 0xcf, 0xc7, 0x41, 0x8a, 0x9f, 0x0c, 0xba, 0x41, 0x4a, 0x57,
+	0x4d, 0xd5, 0x2b, 0xc1, 0xc9, 0xfa, 0xda, 0xf1, 0x93, 0x54, 0xa1, 0x1c, 0xa6, 0x94, 0x05, 0xa8,
+	0xfb, 0x4a, 0xad, 0xa4, 0xa7, 0xd1, 0xcd, 0xf3, 0x7a, 0x56, 0x06, 0xd2, 0xb3, 0x32, 0x90, 0x9e,
+	0x95, 0x9c, 0x9e, 0x67, 0xe1, 0xa8, 0xec, 0x21, 0x0e, 0xbc, 0x59, 0xbe, 0xcb, 0xca, 0x73, 0x87,
+	0x78, 0x82, 0x4a, 0x88, 0x3e, 0x05, 0xc7, 0x5b, 0xfd, 0x2b, 0xc9, 0x5e, 0x1c, 0xf4, 0x52, 0xba,
+	0x3f, 0xb3, 0xc3, 0x6f, 0x8e, 0xf7, 0x54, 0x50, 0xb4, 0x6f, 0x9e, 0x58, 0x6b, 0xdd, 0x91, 0x81,
+	0xad, 0x5b, 0xd7, 0x59, 0x97, 0x6c, 0xde, 0x52, 0xc0, 0x6d, 0x1c, 0x5f, 0xc3, 0x89, 0xd3, 0x50,
+	0x97, 0x65, 0x01, 0xcb, 0x36, 0xef, 0x62, 0x17, 0x74, 0x03, 0x2e, 0x6e, 0x63, 0x3f, 0xe9, 0xc7,
+	0xd4, 0xcd, 0xcb, 0xd6, 0x14, 0x87, 0xea, 0x3d, 0x7c, 0xb9, 0xd9, 0x68, 0xbd, 0x03, 0x86, 0x72,
+	0xff, 0x05, 0xe0, 0x44, 0xde, 0xca, 0xa5, 0x63, 0x60, 0x01, 0x36, 0x5a, 0xa9, 0x1f, 0xa7, 0xbb,
+	0x41, 0x17, 0x73, 0x4f, 0x90, 0x00, 0x72, 0x20, 0x6c, 0x84, 0x6d, 0x8a, 0x63, 0xf3, 0x2f, 0x9a,
+	0xa4, 0xdf, 0x3a, 0xee, 0xe0, 0x14, 0xb7, 0x57, 0x53, 0x3a, 0xeb, 0x55, 0x4f, 0x02, 0xc8, 0x46,
+	0x4e, 0xf9, 0x8a, 0x19, 0x9f, 0x54, 0x4c, 0xc4, 0x8e, 0x28, 0x86, 0x26, 0x67, 0xc2, 0x6e, 0xdc,
+	0x0f, 0xf7, 0x7c, 0x36, 0x10, 0x73, 0x6c, 0x15, 0x44, 0x4f, 0x0d, 0xa9, 0x25, 0x9d, 0xc6, 0x86,
+	0xa7, 0x82, 0x5c, 0x0c, 0x1b, 0xd9, 0xc0, 0x25, 0xfd, 0x16, 0x61, 0xfd, 0xd2, 0xad, 0x90, 0x5c,
+	0xd0, 0x12, 0xba, 0x31, 0xd4, 0x9e, 0xa8, 0x38, 0xc0, 0xcb, 0x60, 0x68, 0x05, 0x0e, 0xd3, 0x6f,
+	0xb1, 0x59, 0x4f, 0x29, 0x92, 0x52, 0x84, 0xc7, 0xf1, 0xee, 0x7f, 0x01, 0x9c, 0x2a, 0x3a, 0x9e,
+	0x76, 0x6d, 0x21, 0x58, 0xdb, 0x8e, 0xda, 0xe2, 0xf0, 0xa1, 0xdf, 0xc8, 0x85, 0x63, 0xeb, 0x38,
+	0x49, 0x83, 0x90, 0x4f, 0x72, 0x95, 0xee, 0x51, 0x39, 0x18, 0xa1, 0x51, 0xd4, 0x62, 0x9b, 0x7e,
+	0xc3, 0xcb, 0xc1, 0xe8, 0x15, 0x34, 0x0a, 0xdb, 0x01, 0x5d, 0x92, 0xec, 0x98, 0x95, 0x00, 0x36,
+	0x99, 0x71, 0xd0, 0xdb, 0xf5, 0xaf, 0xb1, 0x15, 0xd3, 0xf0, 0x24, 0x00, 0x2d, 0xc3, 0x71, 0x0f,
+	0x27, 0x7e, 0xb7, 0xd7, 0xc1, 0x1b, 0x37, 0x71, 0xbc, 0xcf, 0x97, 0x44, 0x1e, 0x48, 0x8e, 0x86,
+	0x1d, 0xbf, 0x9f, 0xe0, 0x36, 0x5d, 0x07, 0x75, 0x8f, 0xb7, 0xdc, 0x65, 0x08, 0xa5, 0x51, 0x08,
+	0x15, 0xbf, 0x6b, 0x32, 0x53, 0xf3, 0x96, 0xfb, 0x18, 0x9c, 0xd1, 0x1c, 0x4f, 0x5a, 0x33, 0xcd,
+	0xc2, 0x21, 0x4a, 0x20, 0x0e, 0x69, 0xda, 0x20, 0x9b, 0x75, 0x5d, 0xdc, 0x6d, 0x4d, 0xd6, 0x3d,
+	0xef, 0x27, 0xd7, 0xb3, 0x7b, 0x8b, 0x9f, 0x5c, 0x27, 0x43, 0xad, 0xb6, 0xbb, 0x01, 0xdb, 0xa4,
+	0xea, 0x1e, 0x6b, 0xa0, 0x33, 0x10, 0xee, 0xc4, 0xc1, 0xcd, 0xa0, 0x83, 0xaf, 0x65, 0x47, 0xe8,
+	0x8c, 0xbc, 0x3d, 0x67, 0x38, 0x4f, 0x21, 0x23, 0x43, 0xb1, 0x9b, 0x23, 0x3b, 0x32, 0x59, 0x83,
+	0xdc, 0x9f, 0x77, 0xfc, 0x24, 0xb9, 0x15, 0xc5, 0xed, 0xb5, 0xeb, 0x7e, 0x78, 0x0d, 0xb7, 0xb9,
+	0xab, 0x16, 0xc1, 0x74, 0x3b, 0x89, 0xf1, 0xcd, 0x20, 0xea, 0x27, 0x44, 0x34, 0xcc, 0x8e, 0xcf,
+	0x86, 0x57, 0x80, 0xba, 0x5b, 0x70, 0x3c, 0x27, 0x04, 0xdd, 0x91, 0xf9, 0xe5, 0x84, 0xeb, 0x9b,
+	0xb5, 0xc9, 0xbc, 0x66, 0x84, 0x54, 0xf1, 0x21, 0x4f, 0x02, 0xdc, 0x3f, 0x8d, 0xc1, 0x91, 0xb5,
+	0xa8, 0xdb, 0xf5, 0x43, 0xc2, 0xbe, 0x96, 0xee, 0xf7, 0xd8, 0x08, 0x13, 0x22, 0xb2, 0xe0, 0xc8,
+	0xd3, 0xbb, 0xfb, 0x3d, 0xec, 0x51, 0xbc, 0xfb, 0xea, 0x18, 0xac, 0x91, 0x26, 0x3a, 0x02, 0xa7,
+	0xd7, 0x62, 0xec, 0xa7, 0x98, 0x4c, 0x20, 0x27, 0x9c, 0x02, 0x04, 0xcc, 0x56, 0xb3, 0x0a, 0xae,
+	0xa0, 0x63, 0xf0, 0x08, 0xa3, 0x16, 0xa2, 0x09, 0x54, 0x15, 0x1d, 0x85, 0x33, 0xeb, 0x71, 0xd4,
+	0x2b, 0x22, 0x6a, 0x68, 0x09, 0x2e, 0xb0, 0x3e, 0x85, 0xb3, 0x49, 0x50, 0x0c, 0xa1, 0x45, 0x38,
+	0x4f, 0xba, 0x1a, 0xf0, 0xc3, 0x68, 0x19, 0x2e, 0xb5, 0x70, 0xaa, 0xbf, 0x78, 0x09, 0xaa, 0x11,
+	0xc2, 0xe7, 0xa9, 0x5e, 0xdb, 0xcc, 0xa7, 0x8e, 0x8e, 0xc3, 0xa3, 0x4c, 0x12, 0xb9, 0x27, 0x0a,
+	0x64, 0x83, 0x20, 0x99, 0xc6, 0x65, 0x24, 0x94, 0x3a, 0x14, 0x9c, 0x5b, 0x50, 0x8c, 0x0a, 0x1d,
+	0x0c, 0xf8, 0x31, 0x69, 0x67, 0x32, 0xeb, 0x02, 0x3c, 0x8e, 0x66, 0xe0, 0x24, 0xe9, 0xa6, 0x02,
+	0x27, 0x08, 0x2d, 0xd3, 0x44, 0x05, 0x4f, 0x12, 0x0b, 0xb7, 0x70, 0x9a, 0xcd, 0xbb, 0x40, 0x4c,
+	0x21, 0x04, 0x27, 0x88, 0x7d, 0xfc, 0xd4, 0x17, 0xb0, 0x69, 0xb4, 0x00, 0x9d, 0x16, 0x4e, 0xe9,
+	0x42, 0x28, 0xf5, 0x40, 0x92, 0x83, 0x3a, 0xbd, 0x33, 0xe8, 0x04, 0x3c, 0xc6, 0x0d, 0xa4, 0xec,
+	0x73, 0x02, 0x7d, 0x84, 0x9a, 0x28, 0x8e, 0x7a, 0x3a, 0xe4, 0x1c, 0x19, 0xd2, 0xc3, 0xdd, 0xe8,
+	0x26, 0xde, 0xc1, 0x52, 0xe8, 0xa3, 0xd2, 0x63, 0x44, 0x98, 0x27, 0x50, 0x4e, 0xde, 0x99, 0x54,
+	0xd4, 0x31, 0x82, 0x62, 0xf2, 0x15, 0x51, 0xf3, 0x04, 0xc5, 0xe6, 0xa9, 0x38, 0xe0, 0x71, 0x89,
+	0x2a, 0xf6, 0x5a, 0x40, 0x73, 0x10, 0xb5, 0x70, 0x5a, 0xec, 0x72, 0x02, 0xcd, 0xc2, 0x29, 0xaa,
+	0x12, 0x99, 0x73, 0x01, 0x5d, 0x44, 0x77, 0xc3, 0x13, 0x79, 0x37, 0x17, 0x31, 0x9c, 0x20, 0xb9,
+	0x0b, 0xdd, 0x05, 0x8f, 0xab, 0xee, 0x5e, 0x24, 0x58, 0x42, 0x27, 0xa1, 0xbb, 0x15, 0x26, 0xa9,
+	0x1f, 0xa6, 0x81, 0x65, 0xa0, 0xbb, 0xa5, 0x6b, 0x15, 0xae, 0x0a, 0x82, 0xc2, 0x45, 0x2e, 0x5c,
+	0x5c, 0x8b, 0xc8, 0x06, 0x6d, 0xa4, 0xb9, 0x47, 0xba, 0x17, 0xd9, 0xaf, 0x04, 0x78, 0x59, 0xb8,
+	0x97, 0x0a, 0xfc, 0x18, 0x99, 0xc6, 0x16, 0x4e, 0x09, 0xac, 0xe4, 0x19, 0x27, 0xb9, 0xa1, 0x88,
+	0xe3, 0xa9, 0x9d, 0xee, 0x45, 0x0e, 0x9c, 0xe5, 0x62, 0xb2, 0x70, 0x58, 0x60, 0x56, 0x48, 0x0f,
+	0x6a, 0xc2, 0x3c, 0xfc, 0x3e, 0xd2, 0x63, 0xb5, 0xdd, 0x96, 0x67, 0x86, 0xc0, 0x9c, 0x42, 0xf3,
+	0x70, 0x8e, 0xfb, 0x2b, 0x99, 0x8c, 0x6d, 0x65, 0x42, 0xee, 0xe7, 0x7e, 0x2b, 0xcc, 0xc5, 0xc2,
+	0x12, 0x81, 0x7d, 0x80, 0xac, 0x32, 0x26, 0x45, 0x2e, 0xb2, 0x16, 0xf8, 0x07, 0x49, 0x6f, 0x22,
+	0x8b, 0x16, 0x7b, 0x5a, 0x4e, 0x6b, 0x31, 0x5c, 0x11, 0x24, 0x0f, 0x89, 0x69, 0x35, 0x11, 0x3c,
+	0xac, 0xc8, 0x97, 0x45, 0x21, 0x89, 0xc0, 0x3e, 0x42, 0xba, 0x2b, 0xd2, 0x67, 0xd1, 0x8c, 0x20,
+	0x68, 0x92, 0xd9, 0x6e, 0xe1, 0x54, 0x5d, 0x41, 0xec, 0x78, 0x15, 0x14, 0x67, 0xc8, 0xec, 0xb0,
+	0x75, 0x54, 0xb6, 0xdc, 0xa3, 0xd2, 0x59, 0x0a, 0x49, 0x01, 0x41, 0xf1, 0x71, 0x42, 0xe1, 0xe1,
+	0x36, 0xc6, 0x5d, 0x03, 0xc5, 0x59, 0x32, 0x5f, 0x1e, 0xbe, 0x19, 0xdd, 0xc0, 0x34, 0x4b, 0x20,
+	0xe0, 0x9f, 0x38, 0x55, 0xaf, 0xb7, 0xa7, 0xee, 0xdc, 0xb9, 0x73, 0xa7, 0xe2, 0x3e, 0xaf, 0x39,
+	0x13, 0xb2, 0x7c, 0x01, 0x50, 0xf2, 0x05, 0x08, 0xd6, 0x3c, 0x3f, 0x6c, 0xf3, 0x2c, 0x19, 0xfd,
+	0x6e, 0x3e, 0x0e, 0x47, 0xf6, 0x78, 0x97, 0xf1, 0xdc, 0xf1, 0xe3, 0xe0, 0x25, 0x20, 0xb3, 0x25,
+	0x25, 0x06, 0x9e, 0xe8, 0xe6, 0x3e, 0xa7, 0x39, 0x7b, 0x4a, 0xf7, 0xba, 0x59, 0x38, 0xb4, 0x19,
+	0xc5, 0x7b, 0xec, 0x38, 0xac, 0x7b, 0xac, 0x61, 0x61, 0x7e, 0x55, 0x65, 0x5e, 0x1a, 0x5e, 0x32,
+	0xff, 0x33, 0x30, 0x1c, 0x71, 0xda, 0xcb, 0xc8, 0x1a, 0x9c, 0x2c, 0xa7, 0x09, 0x80, 0x3d, 0xe6,
+	0x2f, 0xf6, 0xc8, 0x05, 0xea, 0xd5, 0x7c, 0xa0, 0xde, 0x5c, 0x37, 0x2a, 0x74, 0x8d, 0xf2, 0x39,
+	0xae, 0x5a, 0xb3, 0x20, 0xb1, 0x54, 0xaa, 0xab, 0x3d, 0x9b, 0x75, 0x1a, 0x35, 0x9f, 0x30, 0x32,
+	0xbc, 0xae, 0x2a, 0xa6, 0x19, 0x4e, 0xb2, 0xfb, 0x23, 0xb0, 0x1f, 0xf9, 0xd6, 0xbb, 0x8e, 0xd6,
+	0xa4, 0x95, 0xc3, 0x99, 0xb4, 0x79, 0xc1, 0xa8, 0x45, 0x40, 0xb5, 0x70, 0x55, 0xb3, 0xe9, 0x85,
+	0x94, 0xea, 0xbc, 0x09, 0x6c, 0xf7, 0x13, 0xab, 0x32, 0xc2, 0xc2, 0x15, 0xc5, 0xc2, 0x5b, 0x46,
+	0xd9, 0x9e, 0xa6, 0xb2, 0x2d, 0x49, 0x0b, 0x1f, 0x24, 0xd9, 0x4f, 0xc1, 0xc1, 0x37, 0xa3, 0x43,
+	0xcb, 0x77, 0xc9, 0x28, 0xdf, 0x0d, 0x2a, 0xdf, 0x49, 0x91, 0x23, 0xb5, 0xf3, 0x95, 0x52, 0xbe,
+	0x5e, 0xb5, 0xdf, 0xcc, 0x0e, 0x2b, 0x21, 0x89, 0x4a, 0x2f, 0xe2, 0x5b, 0x14, 0xcc, 0xd3, 0x94,
+	0xbc, 0x99, 0x4b, 0x6b, 0xd4, 0x0a, 0xe9, 0x1b, 0x35, 0x4d, 0x31, 0x34, 0x40, 0x3a, 0x66, 0x78,
+	0xe0, 0x84, 0xc1, 0x88, 0x36, 0x61, 0xa0, 0x4f, 0xa3, 0xd4, 0x8d, 0xe9, 0xa2, 0x42, 0xa0, 0xdb,
+	0x28, 0x05, 0xba, 0x16, 0xaf, 0xee, 0xa8, 0x5e, 0x6d, 0xb3, 0xb5, 0x9c, 0x95, 0xbf, 0x02, 0xe3,
+	0x6d, 0xd8, 0x3a, 0x21, 0x24, 0x3e, 0x54, 0x13, 0xa2, 0xbc, 0x45, 0x62, 0x14, 0x92, 0x18, 0x48,
+	0x52, 0xbf, 0xdb, 0xe3, 0xc9, 0x02, 0x09, 0x28, 0x2a, 0x57, 0x2b, 0x2b, 0xb7, 0x69, 0x54, 0xae,
+	0x4b, 0x95, 0x3b, 0xa1, 0x2e, 0xd9, 0x92, 0xc8, 0x52, 0xaf, 0xdf, 0x02, 0xe3, 0x45, 0xfe, 0x7d,
+	0xe9, 0xe5, 0xc2, 0xb1, 0xdc, 0xbb, 0x09, 0x7b, 0xf7, 0xc9, 0xc1, 0x2c, 0xb2, 0x87, 0xaa, 0xec,
+	0x06, 0xb1, 0xa4, 0xec, 0xbf, 0x02, 0xf6, 0x38, 0xe3, 0xd0, 0x2b, 0x25, 0x8b, 0xb1, 0xab, 0x4a,
+	0x8c, 0x6d, 0xf1, 0xa3, 0xa8, 0xbc, 0x3b, 0xea, 0x25, 0x29, 0xef, 0x8e, 0x1f, 0x8e, 0xc4, 0x96,
+	0xdd, 0xb1, 0x57, 0xdc, 0x1d, 0x0f, 0x92, 0xec, 0xf7, 0x40, 0x13, 0x73, 0x7d, 0xc0, 0x9c, 0x82,
+	0x26, 0x11, 0x50, 0xd3, 0x26, 0x02, 0x2c, 0x57, 0x91, 0x67, 0xca, 0xf7, 0x20, 0x45, 0x40, 0x29,
+	0x3f, 0x2e, 0xc5, 0x86, 0xda, 0x13, 0xfb, 0x33, 0x46, 0x46, 0x31, 0x65, 0x74, 0x44, 0x5a, 0x4c,
+	0xcb, 0xe6, 0x3f, 0x40, 0x13, 0x6e, 0x0e, 0x6c, 0x26, 0x8d, 0x41, 0xaa, 0xfa, 0xcc, 0x88, 0x0b,
+	0xc7, 0xd4, 0x1c, 0x08, 0xdf, 0x03, 0x72, 0x30, 0x75, 0xb4, 0xf3, 0x41, 0x92, 0x46, 0xf1, 0x3e,
+	0xdf, 0xaa, 0x8b, 0x60, 0x8b, 0x79, 0x13, 0xd5, 0xbc, 0x25, 0xc5, 0xa4, 0xde, 0xbf, 0x04, 0xda,
+	0x78, 0x9a, 0x78, 0x2c, 0xa1, 0x0f, 0xa5, 0xf6, 0x59, 0x3b, 0xe7, 0xcd, 0x15, 0x5b, 0x92, 0xa6,
+	0x5a, 0x48, 0xd2, 0x58, 0xee, 0x55, 0xa9, 0x7a, 0xaf, 0xd2, 0x08, 0x24, 0x25, 0x8e, 0x8a, 0x71,
+	0x3e, 0x5a, 0x64, 0x6f, 0xd8, 0x54, 0xce, 0xd1, 0x26, 0x94, 0x8f, 0xa4, 0x1e, 0x85, 0x37, 0x3f,
+	0x6d, 0xe4, 0xda, 0x5f, 0x02, 0x4a, 0x26, 0x3e, 0x37, 0xaa, 0x64, 0xf8, 0x06, 0x30, 0x67, 0x11,
+	0xac, 0x76, 0xca, 0x16, 0x4f, 0x45, 0x59, 0x3c, 0xcd, 0x73, 0x46, 0x69, 0x6e, 0x52, 0x69, 0x16,
+	0x33, 0x69, 0xb4, 0x1c, 0xa5, 0x5c, 0xfb, 0x9a, 0xf4, 0xc5, 0x20, 0x0f, 0x9c, 0x16, 0xaf, 0xb9,
+	0x55, 0xf6, 0x1a, 0x6d, 0x7c, 0xf0, 0xeb, 0x8a, 0x25, 0x47, 0x62, 0x7c, 0x6a, 0x31, 0xf9, 0xcc,
+	0x4a, 0xf9, 0xb2, 0xcb, 0x76, 0xea, 0x22, 0x38, 0x4b, 0x2a, 0xd7, 0x2c, 0x49, 0xe5, 0x21, 0x4d,
+	0x52, 0xf9, 0x93, 0x70, 0x4c, 0x15, 0x94, 0xde, 0x6a, 0xcc, 0xef, 0x28, 0x39, 0xda, 0xe6, 0x79,
+	0xa3, 0xb5, 0xf6, 0xe9, 0x28, 0x77, 0xe5, 0x8e, 0xe4, 0xb2, 0x39, 0xa4, 0xd5, 0x7e, 0x07, 0x8c,
+	0xa9, 0xa3, 0x8f, 0xce, 0x66, 0x96, 0x63, 0xf9, 0xd9, 0xdc, 0xb1, 0xac, 0x17, 0x2c, 0xe7, 0x6e,
+	0xa5, 0xd4, 0x56, 0xe6, 0x6e, 0x40, 0xba, 0xdb, 0x6a, 0xbb, 0x1d, 0x0b, 0x77, 0x23, 0xdf, 0x16,
+	0x77, 0x7b, 0x4e, 0x75, 0xb7, 0xd2, 0xe0, 0x92, 0xf5, 0xcf, 0x81, 0x21, 0x7f, 0x46, 0x4c, 0x74,
+	0x7e, 0x77, 0x77, 0x87, 0xf2, 0xe4, 0xcb, 0x4f, 0xb4, 0xf9, 0x3b, 0xbe, 0x22, 0x8e, 0x68, 0x66,
+	0x11, 0x7b, 0x55, 0x89, 0xd8, 0xcd, 0x31, 0xe6, 0x17, 0xca, 0x31, 0x66, 0x41, 0x0c, 0xe5, 0x96,
+	0x0f, 0x0c, 0xe9, 0xbc, 0xf7, 0x27, 0xa9, 0x45, 0xaa, 0xe7, 0xf5, 0x91, 0xaf, 0x56, 0xaa, 0xb7,
+	0x81, 0x21, 0x93, 0x78, 0xf8, 0x7a, 0x88, 0x8a, 0x52, 0x0f, 0x61, 0x91, 0xee, 0x05, 0x55, 0x3a,
+	0x2d, 0x6b, 0x35, 0x2e, 0xd7, 0xe7, 0x32, 0x8b, 0xc2, 0x59, 0xd8, 0x7d, 0x51, 0x65, 0xa7, 0x1d,
+	0x4c, 0xb2, 0x0b, 0x0d, 0xf9, 0xd1, 0x12, 0xbb, 0x0d, 0x23, 0xbb, 0x3b, 0xa0, 0xcc, 0xcf, 0xa8,
+	0xde, 0x26, 0x89, 0xb8, 0x92, 0x5e, 0x14, 0x26, 0x98, 0xb0, 0xb8, 0x74, 0x81, 0xb2, 0xa8, 0x7b,
+	0x95, 0x4b, 0x17, 0xc8, 0x09, 0xb1, 0x11, 0xc7, 0x51, 0x4c, 0xf3, 0x25, 0x0d, 0x8f, 0x35, 0x64,
+	0xdd, 0x55, 0x95, 0xae, 0x2b, 0xd6, 0x70, 0x7f, 0x02, 0x74, 0xd9, 0xdb, 0x0f, 0x71, 0x05, 0x98,
+	0x0f, 0xe7, 0x2f, 0x31, 0x7d, 0x9d, 0xec, 0x64, 0x32, 0x1a, 0xb7, 0x5d, 0xce, 0x24, 0x97, 0xec,
+	0x6a, 0xde, 0x0f, 0xbe, 0x0c, 0xd4, 0x7d, 0xb9, 0x38, 0x90, 0xe4, 0xf2, 0xcf, 0x0a, 0x9c, 0xd5,
+	0x15, 0x41, 0x1d, 0xba, 0x96, 0x05, 0xfc, 0x5f, 0xd5, 0xb2, 0x9c, 0x81, 0xc3, 0xe7, 0x62, 0x3f,
+	0x4c, 0xd9, 0x19, 0x27, 0xfd, 0xaf, 0x60, 0x09, 0x4a, 0xe3, 0x71, 0x52, 0xf4, 0x28, 0x1c, 0xa6,
+	0x91, 0x78, 0xc2, 0x0f, 0xbd, 0x05, 0x7d, 0x27, 0x46, 0xe3, 0x71, 0x5a, 0x77, 0x0b, 0x1e, 0xd1,
+	0x0e, 0x4b, 0x2c, 0x4c, 0xee, 0x37, 0xc2, 0xc2, 0xe4, 0xfb, 0x80, 0x87, 0xb9, 0x0e, 0x9c, 0xd3,
+	0x33, 0xd3, 0xe6, 0x1b, 0xc0, 0xc0, 0xf9, 0x86, 0x8a, 0xb6, 0xfc, 0xe3, 0x67, 0xe0, 0x80, 0x57,
+	0x0b, 0x74, 0x16, 0xd6, 0x05, 0x88, 0xdf, 0x18, 0x6d, 0x65, 0x75, 0x19, 0x6d, 0x73, 0xdb, 0xe8,
+	0xb6, 0x5f, 0x61, 0x6e, 0x7b, 0x8f, 0x2e, 0x0b, 0x59, 0xe0, 0x2e, 0x7d, 0xf8, 0x05, 0xeb, 0xd3,
+	0x89, 0x36, 0xc6, 0x31, 0x47, 0xac, 0x2f, 0x32, 0x09, 0xee, 0x2e, 0xa7, 0x25, 0x8d, 0xfc, 0xdf,
+	0x05, 0x83, 0x3c, 0xcd, 0x90, 0xed, 0x25, 0x67, 0xad, 0x86, 0xb4, 0x88, 0xed, 0x7e, 0xd2, 0xf4,
+	0x8c, 0xb2, 0x7e, 0x95, 0xc9, 0xba, 0xc2, 0xa0, 0x07, 0x8b, 0xa0, 0xde, 0x40, 0x66, 0x34, 0xe5,
+	0x25, 0x64, 0x97, 0x6b, 0x45, 0xfd, 0x78, 0x0f, 0x27, 0xb4, 0x40, 0xaa, 0xe6, 0x89, 0x26, 0x3a,
+	0x05, 0x87, 0x28, 0x2d, 0xcf, 0x9d, 0xea, 0x2b, 0x6e, 0x18, 0x09, 0xab, 0x29, 0x60, 0xef, 0x4b,
+	0x6d, 0xba, 0xcc, 0x6b, 0x9e, 0x04, 0xb8, 0x7f, 0x00, 0xf6, 0x07, 0xaa, 0xf7, 0x95, 0x54, 0x59,
+	0x86, 0xe3, 0x6a, 0x02, 0x25, 0xe1, 0x6c, 0xf3, 0xc0, 0xe6, 0x93, 0x46, 0x4b, 0x7e, 0x0d, 0x94,
+	0x13, 0x15, 0x7a, 0xf1, 0xa4, 0x0d, 0xdf, 0x03, 0x07, 0xbd, 0xa3, 0x7d, 0x54, 0xf9, 0x21, 0xa5,
+	0x5a, 0xa2, 0xa6, 0x56, 0x4b, 0x34, 0x2f, 0x1a, 0x15, 0xfc, 0x3a, 0x53, 0x70, 0x39, 0x83, 0x5a,
+	0xc4, 0x96, 0x2a, 0x3e, 0x03, 0x4f, 0x58, 0x2b, 0x82, 0x8a, 0x69, 0x38, 0xa6, 0xa3, 0x0a, 0x32,
+	0x64, 0x2d, 0x2b, 0xa6, 0xe2, 0x2f, 0xb7, 0x05, 0xeb, 0xa2, 0xac, 0x56, 0x7b, 0x06, 0xe5, 0x8b,
+	0x30, 0x2a, 0x03, 0x15, 0x61, 0xb8, 0x4f, 0x6b, 0x5e, 0x33, 0xb5, 0xfb, 0xc2, 0xaa, 0xd1, 0x80,
+	0xdf, 0x00, 0xe5, 0x2c, 0x8b, 0x32, 0x9a, 0xb4, 0xd9, 0xd5, 0xd2, 0x13, 0xa9, 0x96, 0xd3, 0x63,
+	0x46, 0x4e, 0x2f, 0x81, 0x62, 0x9a, 0x45, 0xcb, 0xe7, 0x5d, 0x60, 0x7c, 0x76, 0x25, 0x0c, 0x09,
+	0x5c, 0x30, 0x24, 0xdf, 0x1f, 0x20, 0xd5, 0x60, 0x0e, 0xb3, 0x5f, 0x06, 0x6a, 0xdc, 0x63, 0x90,
+	0x46, 0x8a, 0xfc, 0x63, 0xa0, 0x7b, 0x0c, 0xb6, 0x06, 0xfe, 0x42, 0x93, 0x8a, 0xa2, 0xc9, 0x1c,
+	0x1c, 0xde, 0xc6, 0xdd, 0x2b, 0x38, 0xe6, 0xa9, 0x34, 0xde, 0xb2, 0xdc, 0xba, 0xbe, 0x59, 0xbc,
+	0x75, 0x15, 0x44, 0x90, 0x22, 0xbe, 0x04, 0xe0, 0xa8, 0x52, 0xad, 0xad, 0xdc, 0xb8, 0x1a, 0xf4,
+	0x56, 0xaf, 0xca, 0x5a, 0x29, 0xcb, 0x4a, 0x13, 0x51, 0x55, 0x25, 0x9d, 0x45, 0xf6, 0xc2, 0x18,
+	0xf3, 0x6a, 0x34, 0x5e, 0xd6, 0x96, 0x01, 0x08, 0x76, 0xe3, 0x76, 0x2f, 0x88, 0x71, 0xb2, 0x4a,
+	0xca, 0x35, 0x29, 0x36, 0x03, 0x10, 0x59, 0xb4, 0x6f, 0xe4, 0xe8, 0x7e, 0x38, 0xc2, 0x21, 0xfc,
+	0xd8, 0xd5, 0x94, 0x99, 0x0b, 0x0a, 0xcb, 0x55, 0xff, 0x5b, 0xcc, 0x2a, 0xf3, 0xb9, 0x4d, 0x2f,
+	0xc7, 0x49, 0xda, 0xe5, 0xba, 0xee, 0x51, 0xbe, 0x68, 0x1d, 0xcb, 0x0c, 0x7c, 0x3b, 0x37, 0x03,
+	0xe5, 0xa1, 0x24, 0xa7, 0x17, 0x81, 0xfe, 0x9d, 0xbf, 0x14, 0x60, 0xc9, 0x4d, 0xb0, 0x92, 0xdb,
+	0x04, 0xcd, 0x0a, 0x7f, 0x27, 0xa7, 0xb0, 0x8e, 0x89, 0x14, 0xe3, 0x6f, 0xc0, 0x54, 0x54, 0x50,
+	0x12, 0x44, 0xad, 0x65, 0x67, 0xf9, 0x29, 0x5b, 0x2d, 0x7b, 0x75, 0x90, 0x5a, 0xf6, 0x1a, 0x1d,
+	0x46, 0x05, 0x59, 0x92, 0x0f, 0xaf, 0x00, 0xf5, 0x3a, 0xaa, 0x17, 0x5a, 0x2a, 0xf6, 0x2a, 0x30,
+	0x57, 0x44, 0x68, 0x77, 0x5c, 0x59, 0xdb, 0xcd, 0x94, 0xe3, 0x2d, 0x4b, 0x36, 0xe7, 0x55, 0x50,
+	0x48, 0xbf, 0x69, 0x99, 0x49, 0x91, 0x7e, 0x04, 0xe0, 0x74, 0xe9, 0x6f, 0x87, 0xc1, 0x6b, 0xfc,
+	0xc8, 0xb5, 0xe5, 0x32, 0x8e, 0x13, 0x51, 0x55, 0x5c, 0xf3, 0x44, 0x13, 0x6d, 0xc2, 0x29, 0x91,
+	0x0f, 0xe6, 0x20, 0x11, 0x2f, 0xcc, 0x6b, 0x7e, 0xb2, 0xe0, 0x24, 0x5e, 0xa9, 0x8f, 0xbb, 0x09,
+	0x67, 0x75, 0x94, 0x2a, 0x67, 0x90, 0xe7, 0xac, 0xaf, 0x46, 0x7c, 0x0d, 0xd8, 0x0a, 0x4e, 0x06,
+	0x57, 0xb9, 0xf9, 0x59, 0xa3, 0xf1, 0xbf, 0x0b, 0xd4, 0x77, 0x0d, 0x33, 0x33, 0x69, 0xfe, 0xdb,
+	0xe6, 0x22, 0x17, 0xed, 0xd1, 0x65, 0x9e, 0xf8, 0xd7, 0x72, 0x13, 0x6f, 0x1a, 0x54, 0x72, 0x7e,
+	0x1c, 0xce, 0xea, 0xea, 0xff, 0x0f, 0x51, 0xde, 0xf9, 0x1b, 0x70, 0x40, 0x0d, 0xce, 0x87, 0xf4,
+	0xc4, 0x65, 0x0e, 0x59, 0x5e, 0xd7, 0x84, 0x2c, 0x06, 0x59, 0xa4, 0xe2, 0x6f, 0x01, 0x6b, 0x5d,
+	0xd0, 0xa1, 0x5f, 0xb9, 0xcc, 0xf1, 0xcc, 0xf7, 0x4a, 0xf1, 0xcc, 0x81, 0xc2, 0xbd, 0x03, 0xcc,
+	0x35, 0x49, 0xa5, 0xcd, 0x4f, 0xfe, 0x62, 0x53, 0xb1, 0xfe, 0x62, 0x63, 0xf1, 0x9a, 0x37, 0x74,
+	0xdb, 0x45, 0x89, 0x73, 0xee, 0x55, 0xd3, 0x56, 0x15, 0xa5, 0xf5, 0x9e, 0xdc, 0xef, 0x23, 0x95,
+	0x41, 0x7e, 0x1f, 0xb1, 0xd8, 0xf4, 0xfb, 0x39, 0x9b, 0x5a, 0x44, 0x91, 0x32, 0xff, 0x03, 0xd8,
+	0x0b, 0xb5, 0xac, 0x33, 0xbe, 0xa2, 0x2f, 0x61, 0xd1, 0x67, 0xf5, 0x79, 0x19, 0x43, 0x6e, 0xff,
+	0x66, 0xac, 0xf8, 0xa9, 0xc2, 0x5b, 0x96, 0x68, 0xe8, 0xcd, 0x5c, 0x34, 0x64, 0x13, 0x5b, 0x2a,
+	0xf8, 0x32, 0x30, 0xd6, 0x99, 0x0d, 0x7c, 0x72, 0x9b, 0x2f, 0x9a, 0x3f, 0xc8, 0x5d, 0x34, 0x0d,
+	0x7c, 0x72, 0x6f, 0xc8, 0x33, 0x9a, 0x7f, 0xdf, 0xb2, 0x1b, 0x1a, 0x50, 0x6e, 0x68, 0xba, 0x3d,
+	0x60, 0x41, 0xfd, 0xa9, 0x92, 0x95, 0x50, 0x49, 0xc0, 0x07, 0xba, 0xd3, 0xfd, 0x02, 0xc0, 0x46,
+	0xf6, 0x63, 0x9d, 0xe9, 0xb8, 0x6d, 0xd1, 0xf5, 0x29, 0xe2, 0x42, 0xd6, 0xfa, 0xe8, 0x64, 0x22,
+	0x87, 0x18, 0x2b, 0xe0, 0x6b, 0xf3, 0xbf, 0xb7, 0x44, 0xd3, 0x7d, 0x27, 0x8b, 0xd5, 0xf5, 0xd5,
+	0x7f, 0xe8, 0x21, 0x38, 0x44, 0xdb, 0xfc, 0x1e, 0x6a, 0xf9, 0xed, 0x90, 0xd1, 0x59, 0x9c, 0xee,
+	0x87, 0x9a, 0x10, 0x5c, 0xcf, 0x55, 0xce, 0xf3, 0x5f, 0x80, 0xbd, 0x3a, 0x51, 0x3b, 0xe1, 0x16,
+	0x03, 0x4b, 0x13, 0x56, 0xad, 0x26, 0xac, 0x15, 0x4c, 0x68, 0x51, 0xeb, 0xad, 0x9c, 0x5a, 0x36,
+	0x61, 0xa5, 0x5a, 0x1d, 0x5d, 0x45, 0xe5, 0x21, 0xab, 0xeb, 0xde, 0xce, 0x5d, 0xb8, 0xcb, 0xc3,
+	0x65, 0xdc, 0xfe, 0x37, 0x00, 0x2f, 0x87, 0x3a, 0x6e, 0xd4, 0x3c, 0x00, 0x00,
 }
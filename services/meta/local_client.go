@@ -0,0 +1,87 @@
+package meta
+
+import (
+	"os"
+
+	"github.com/freetsdb/freetsdb"
+	"github.com/freetsdb/freetsdb/services/meta/internal"
+	"github.com/gogo/protobuf/proto"
+)
+
+// localNodeHost is the address of the data node of a local client, which is
+// never dialed.
+const localNodeHost = "local"
+
+// NewLocalClient returns a client that keeps the meta data itself, in the
+// meta.db file of c.Dir, instead of reading it from a meta service cluster.
+// Commands are applied to the data as the meta service applies them, so a
+// single process, such as an embedded store, can use the meta data without
+// running the meta service.
+func NewLocalClient(c *Config) *Client {
+	client := NewClient(freetsdb.NewNode(c.Dir))
+	client.local = &store{
+		config:      c,
+		dataChanged: make(chan struct{}),
+		path:        c.Dir,
+	}
+	return client
+}
+
+// openLocal loads the meta data of a local client from disk. The data has a
+// single data node, the process of the client, which is created the first
+// time the client is opened.
+func (c *Client) openLocal() error {
+	if err := os.MkdirAll(c.node.Path, 0777); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.changed = make(chan struct{})
+	c.closing = make(chan struct{})
+	if err := c.Load(); err != nil {
+		return err
+	}
+
+	if len(c.cacheData.DataNodes) == 0 {
+		data := c.cacheData.Clone()
+		if err := data.CreateDataNode(localNodeHost, localNodeHost); err != nil {
+			return err
+		}
+		if err := c.commit(data); err != nil {
+			return err
+		}
+	}
+	c.nodeID = c.cacheData.DataNodes[0].ID
+	return nil
+}
+
+// execLocal applies a command to the meta data of a local client and saves
+// the data.
+func (c *Client) execLocal(typ internal.Command_Type, desc *proto.ExtensionDesc, value interface{}) error {
+	cmd := &internal.Command{Type: &typ}
+	if err := proto.SetExtension(cmd, desc, value); err != nil {
+		panic(err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	select {
+	case <-c.closing:
+		return ErrServiceUnavailable
+	default:
+	}
+
+	// The command is applied to a copy, so that readers of the current data
+	// never see it change.
+	c.local.data = c.cacheData.Clone()
+	if err, ok := (*storeFSM)(c.local).apply(cmd).(error); ok && err != nil {
+		return err
+	}
+	if err := c.commit(c.local.data); err != nil {
+		return err
+	}
+	c.updateAuthCache()
+	return nil
+}
@@ -0,0 +1,127 @@
+package meta
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode"
+
+	"github.com/freetsdb/freetsdb/toml"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// DefaultPasswordHashCost is the default bcrypt cost of password hashes.
+	DefaultPasswordHashCost = bcrypt.DefaultCost
+
+	// DefaultPasswordReuseHistory is the default number of previous
+	// passwords a user cannot set again when reuse is prevented.
+	DefaultPasswordReuseHistory = 5
+)
+
+// PasswordPolicyConfig represents the policy passwords set with CREATE USER
+// and SET PASSWORD must satisfy, and how they are hashed.
+type PasswordPolicyConfig struct {
+	// HashCost is the bcrypt cost of new password hashes. Stored hashes of a
+	// different cost are rehashed the next time their user logs in.
+	HashCost int `toml:"hash-cost"`
+
+	MinLength        int  `toml:"min-length"`
+	RequireUppercase bool `toml:"require-uppercase"`
+	RequireLowercase bool `toml:"require-lowercase"`
+	RequireDigit     bool `toml:"require-digit"`
+	RequireSymbol    bool `toml:"require-symbol"`
+
+	// MaxAge is how long a password may be used before it must be changed.
+	// Logins with an older password fail; the user can still change it
+	// with the /api/v1/password endpoint, or an admin can set a new one.
+	// Zero disables expiry.
+	MaxAge toml.Duration `toml:"max-age"`
+
+	// PreventReuse rejects setting a user's password to its current one or
+	// to one of its ReuseHistory previous ones.
+	PreventReuse bool `toml:"prevent-reuse"`
+	ReuseHistory int  `toml:"reuse-history"`
+}
+
+// NewPasswordPolicyConfig returns an instance of PasswordPolicyConfig with
+// defaults. The defaults place no restriction on passwords.
+func NewPasswordPolicyConfig() PasswordPolicyConfig {
+	return PasswordPolicyConfig{
+		HashCost:     DefaultPasswordHashCost,
+		ReuseHistory: DefaultPasswordReuseHistory,
+	}
+}
+
+// Validate returns an error if the config is invalid.
+func (c PasswordPolicyConfig) Validate() error {
+	if c.HashCost < bcrypt.MinCost || c.HashCost > bcrypt.MaxCost {
+		return fmt.Errorf("hash-cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	if c.MinLength < 0 {
+		return errors.New("min-length cannot be negative")
+	}
+	if c.MaxAge < 0 {
+		return errors.New("max-age cannot be negative")
+	}
+	if c.ReuseHistory < 0 {
+		return errors.New("reuse-history cannot be negative")
+	}
+	return nil
+}
+
+// Check returns an error describing the first rule password breaks.
+func (c PasswordPolicyConfig) Check(password string) error {
+	if len([]rune(password)) < c.MinLength {
+		return PasswordPolicyError(fmt.Sprintf("must be at least %d characters", c.MinLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+
+	switch {
+	case c.RequireUppercase && !upper:
+		return PasswordPolicyError("must contain an uppercase letter")
+	case c.RequireLowercase && !lower:
+		return PasswordPolicyError("must contain a lowercase letter")
+	case c.RequireDigit && !digit:
+		return PasswordPolicyError("must contain a digit")
+	case c.RequireSymbol && !symbol:
+		return PasswordPolicyError("must contain a symbol")
+	}
+	return nil
+}
+
+// expired returns true if a password changed at changed has expired at now.
+// Passwords with an unknown change time, set before expiry was tracked,
+// never expire.
+func (c PasswordPolicyConfig) expired(changed, now time.Time) bool {
+	return c.MaxAge > 0 && !changed.IsZero() && now.Sub(changed) > time.Duration(c.MaxAge)
+}
+
+// history returns the number of previous password hashes to keep.
+func (c PasswordPolicyConfig) history() int {
+	if !c.PreventReuse {
+		return 0
+	}
+	return c.ReuseHistory
+}
+
+// PasswordPolicyError is returned when a password breaks the password policy.
+type PasswordPolicyError string
+
+// Error returns the string representation of the error.
+func (e PasswordPolicyError) Error() string {
+	return "password " + string(e)
+}
@@ -0,0 +1,65 @@
+package meta_test
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+func TestPasswordPolicyConfig_Parse(t *testing.T) {
+	c := meta.NewPasswordPolicyConfig()
+	if _, err := toml.Decode(`
+hash-cost = 12
+min-length = 10
+require-digit = true
+max-age = "2160h"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	} else if c.HashCost != 12 || c.MinLength != 10 || !c.RequireDigit || c.RequireSymbol {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+
+	c.HashCost = 100
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for out of range hash-cost")
+	}
+}
+
+func TestPasswordPolicyConfig_Check(t *testing.T) {
+	c := meta.NewPasswordPolicyConfig()
+	c.MinLength = 8
+	c.RequireUppercase = true
+	c.RequireLowercase = true
+	c.RequireDigit = true
+	c.RequireSymbol = true
+
+	for _, tt := range []struct {
+		password string
+		exp      string
+	}{
+		{password: "Sh0rt!", exp: "password must be at least 8 characters"},
+		{password: "lowercase1!", exp: "password must contain an uppercase letter"},
+		{password: "UPPERCASE1!", exp: "password must contain a lowercase letter"},
+		{password: "NoDigits!!", exp: "password must contain a digit"},
+		{password: "NoSymbols1", exp: "password must contain a symbol"},
+		{password: "Acceptable1!"},
+	} {
+		err := c.Check(tt.password)
+		if tt.exp == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", tt.password, err)
+			}
+		} else if err == nil || err.Error() != tt.exp {
+			t.Errorf("%s: got %v, expected %s", tt.password, err, tt.exp)
+		}
+	}
+
+	if err := meta.NewPasswordPolicyConfig().Check(""); err != nil {
+		t.Fatalf("default policy rejected password: %s", err)
+	}
+}
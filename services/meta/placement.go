@@ -0,0 +1,200 @@
+package meta
+
+import (
+	"sort"
+
+	"github.com/freetsdb/freetsdb/services/meta/internal"
+	"github.com/gogo/protobuf/proto"
+)
+
+// DefaultPlacementSpreadBy are the node labels the replicas of a shard are
+// spread by when the placement of its database names none. The replicas are
+// put in different zones first, then in different racks.
+var DefaultPlacementSpreadBy = []string{"zone", "rack"}
+
+// PlacementInfo is the placement of the shards of a database on data nodes.
+type PlacementInfo struct {
+	// Require are the labels a node must have, with the same values, to own
+	// the shards of the database.
+	Require map[string]string
+
+	// SpreadBy are the node labels the replicas of a shard are spread by, in
+	// order of importance. If it is empty, DefaultPlacementSpreadBy is used.
+	SpreadBy []string
+}
+
+// IsZero returns true if p puts no constraint on the placement.
+func (p PlacementInfo) IsZero() bool {
+	return len(p.Require) == 0 && len(p.SpreadBy) == 0
+}
+
+// Matches returns true if the node ni may own the shards of the database.
+func (p PlacementInfo) Matches(ni *NodeInfo) bool {
+	for k, v := range p.Require {
+		if value, ok := ni.Labels[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Nodes returns the nodes that may own the shards of the database, in the
+// order of nodes.
+func (p PlacementInfo) Nodes(nodes []NodeInfo) []NodeInfo {
+	if len(p.Require) == 0 {
+		return nodes
+	}
+
+	var a []NodeInfo
+	for i := range nodes {
+		if p.Matches(&nodes[i]) {
+			a = append(a, nodes[i])
+		}
+	}
+	return a
+}
+
+// spreadBy returns the labels the replicas are spread by.
+func (p PlacementInfo) spreadBy() []string {
+	if len(p.SpreadBy) == 0 {
+		return DefaultPlacementSpreadBy
+	}
+	return p.SpreadBy
+}
+
+// next returns the index of the node of nodes to own the next replica of a
+// shard with owners. The nodes are scanned from start, and the first one
+// that is not an owner yet, whose labels differ the most from the ones of the
+// owners and that owns the fewest of the shards of the group, as counted by
+// load, is returned. If no node has labels, this is the node at start, as in
+// a plain round robin.
+func (p PlacementInfo) next(nodes []NodeInfo, start int, owners []ShardOwner, load map[uint64]int) int {
+	spreadBy := p.spreadBy()
+
+	// The label values already used by the owners of the shard.
+	used := make([]map[string]struct{}, len(spreadBy))
+	for i := range used {
+		used[i] = make(map[string]struct{})
+	}
+	isOwner := make(map[uint64]struct{}, len(owners))
+	for _, o := range owners {
+		isOwner[o.NodeID] = struct{}{}
+		for i := range nodes {
+			if nodes[i].ID != o.NodeID {
+				continue
+			}
+			for j, k := range spreadBy {
+				if v, ok := nodes[i].Labels[k]; ok {
+					used[j][v] = struct{}{}
+				}
+			}
+		}
+	}
+
+	best, bestScore := -1, []int(nil)
+	for n := 0; n < len(nodes); n++ {
+		i := (start + n) % len(nodes)
+		if _, ok := isOwner[nodes[i].ID]; ok {
+			continue
+		}
+
+		// A node scores for each label whose value no owner has yet, with
+		// the first labels weighing the most, and then for owning few
+		// shards of the group.
+		score := make([]int, len(spreadBy)+1)
+		for j, k := range spreadBy {
+			if v, ok := nodes[i].Labels[k]; ok {
+				if _, dup := used[j][v]; !dup {
+					score[j] = 1
+				}
+			}
+		}
+		score[len(spreadBy)] = -load[nodes[i].ID]
+
+		if best == -1 || betterScore(score, bestScore) {
+			best, bestScore = i, score
+		}
+	}
+
+	// All the nodes own the shard already, which the replica count prevents.
+	if best == -1 {
+		return start % len(nodes)
+	}
+	return best
+}
+
+// betterScore returns true if the score a is lexicographically greater than b.
+func betterScore(a, b []int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] > b[i]
+		}
+	}
+	return false
+}
+
+// clone returns a deep copy of p.
+func (p PlacementInfo) clone() PlacementInfo {
+	other := PlacementInfo{Require: cloneLabels(p.Require)}
+	if p.SpreadBy != nil {
+		other.SpreadBy = append([]string(nil), p.SpreadBy...)
+	}
+	return other
+}
+
+// marshal serializes to a protobuf representation.
+func (p PlacementInfo) marshal() *internal.PlacementInfo {
+	return &internal.PlacementInfo{
+		Require:  marshalLabels(p.Require),
+		SpreadBy: p.SpreadBy,
+	}
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (p *PlacementInfo) unmarshal(pb *internal.PlacementInfo) {
+	p.Require = unmarshalLabels(pb.GetRequire())
+	p.SpreadBy = pb.GetSpreadBy()
+}
+
+// cloneLabels returns a copy of labels.
+func cloneLabels(labels map[string]string) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+	other := make(map[string]string, len(labels))
+	for k, v := range labels {
+		other[k] = v
+	}
+	return other
+}
+
+// marshalLabels serializes labels to a protobuf representation, sorted by
+// key so that equal labels always encode the same.
+func marshalLabels(labels map[string]string) []*internal.NodeLabel {
+	if len(labels) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pb := make([]*internal.NodeLabel, len(keys))
+	for i, k := range keys {
+		pb[i] = &internal.NodeLabel{Key: proto.String(k), Value: proto.String(labels[k])}
+	}
+	return pb
+}
+
+// unmarshalLabels deserializes labels from a protobuf representation.
+func unmarshalLabels(pb []*internal.NodeLabel) map[string]string {
+	if len(pb) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(pb))
+	for _, l := range pb {
+		labels[l.GetKey()] = l.GetValue()
+	}
+	return labels
+}
@@ -26,72 +26,7 @@ func (fsm *storeFSM) Apply(l *raft.Log) interface{} {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	err := func() interface{} {
-		switch cmd.GetType() {
-		case internal.Command_RemovePeerCommand:
-			return fsm.applyRemovePeerCommand(&cmd)
-		case internal.Command_CreateNodeCommand:
-			// create node was in < 0.10.0 servers, we need the peers
-			// list to convert to the appropriate data/meta nodes now
-			peers, err := s.raftState.peers()
-			if err != nil {
-				return err
-			}
-			return fsm.applyCreateNodeCommand(&cmd, peers)
-		case internal.Command_DeleteNodeCommand:
-			return fsm.applyDeleteNodeCommand(&cmd)
-		case internal.Command_CreateDatabaseCommand:
-			return fsm.applyCreateDatabaseCommand(&cmd)
-		case internal.Command_DropDatabaseCommand:
-			return fsm.applyDropDatabaseCommand(&cmd)
-		case internal.Command_CreateRetentionPolicyCommand:
-			return fsm.applyCreateRetentionPolicyCommand(&cmd)
-		case internal.Command_DropRetentionPolicyCommand:
-			return fsm.applyDropRetentionPolicyCommand(&cmd)
-		case internal.Command_SetDefaultRetentionPolicyCommand:
-			return fsm.applySetDefaultRetentionPolicyCommand(&cmd)
-		case internal.Command_UpdateRetentionPolicyCommand:
-			return fsm.applyUpdateRetentionPolicyCommand(&cmd)
-		case internal.Command_CreateShardGroupCommand:
-			return fsm.applyCreateShardGroupCommand(&cmd)
-		case internal.Command_DeleteShardGroupCommand:
-			return fsm.applyDeleteShardGroupCommand(&cmd)
-		case internal.Command_CreateContinuousQueryCommand:
-			return fsm.applyCreateContinuousQueryCommand(&cmd)
-		case internal.Command_DropContinuousQueryCommand:
-			return fsm.applyDropContinuousQueryCommand(&cmd)
-		case internal.Command_CreateSubscriptionCommand:
-			return fsm.applyCreateSubscriptionCommand(&cmd)
-		case internal.Command_DropSubscriptionCommand:
-			return fsm.applyDropSubscriptionCommand(&cmd)
-		case internal.Command_CreateUserCommand:
-			return fsm.applyCreateUserCommand(&cmd)
-		case internal.Command_DropUserCommand:
-			return fsm.applyDropUserCommand(&cmd)
-		case internal.Command_UpdateUserCommand:
-			return fsm.applyUpdateUserCommand(&cmd)
-		case internal.Command_SetPrivilegeCommand:
-			return fsm.applySetPrivilegeCommand(&cmd)
-		case internal.Command_SetAdminPrivilegeCommand:
-			return fsm.applySetAdminPrivilegeCommand(&cmd)
-		case internal.Command_SetDataCommand:
-			return fsm.applySetDataCommand(&cmd)
-		case internal.Command_UpdateNodeCommand:
-			return fsm.applyUpdateNodeCommand(&cmd)
-		case internal.Command_CreateMetaNodeCommand:
-			return fsm.applyCreateMetaNodeCommand(&cmd)
-		case internal.Command_DeleteMetaNodeCommand:
-			return fsm.applyDeleteMetaNodeCommand(&cmd, s)
-		case internal.Command_SetMetaNodeCommand:
-			return fsm.applySetMetaNodeCommand(&cmd)
-		case internal.Command_CreateDataNodeCommand:
-			return fsm.applyCreateDataNodeCommand(&cmd)
-		case internal.Command_DeleteDataNodeCommand:
-			return fsm.applyDeleteDataNodeCommand(&cmd)
-		default:
-			panic(fmt.Errorf("cannot apply command: %x", l.Data))
-		}
-	}()
+	err := fsm.apply(&cmd)
 
 	// Copy term and index to new metadata.
 	fsm.data.Term = l.Term
@@ -104,6 +39,125 @@ func (fsm *storeFSM) Apply(l *raft.Log) interface{} {
 	return err
 }
 
+// apply applies cmd to the meta data. The store must be locked.
+func (fsm *storeFSM) apply(cmd *internal.Command) interface{} {
+	s := (*store)(fsm)
+	switch cmd.GetType() {
+	case internal.Command_RemovePeerCommand:
+		return fsm.applyRemovePeerCommand(cmd)
+	case internal.Command_CreateNodeCommand:
+		// create node was in < 0.10.0 servers, we need the peers
+		// list to convert to the appropriate data/meta nodes now
+		peers, err := s.raftState.peers()
+		if err != nil {
+			return err
+		}
+		return fsm.applyCreateNodeCommand(cmd, peers)
+	case internal.Command_DeleteNodeCommand:
+		return fsm.applyDeleteNodeCommand(cmd)
+	case internal.Command_CreateDatabaseCommand:
+		return fsm.applyCreateDatabaseCommand(cmd)
+	case internal.Command_DropDatabaseCommand:
+		return fsm.applyDropDatabaseCommand(cmd)
+	case internal.Command_CreateRetentionPolicyCommand:
+		return fsm.applyCreateRetentionPolicyCommand(cmd)
+	case internal.Command_DropRetentionPolicyCommand:
+		return fsm.applyDropRetentionPolicyCommand(cmd)
+	case internal.Command_SetDefaultRetentionPolicyCommand:
+		return fsm.applySetDefaultRetentionPolicyCommand(cmd)
+	case internal.Command_UpdateRetentionPolicyCommand:
+		return fsm.applyUpdateRetentionPolicyCommand(cmd)
+	case internal.Command_CreateShardGroupCommand:
+		return fsm.applyCreateShardGroupCommand(cmd)
+	case internal.Command_DeleteShardGroupCommand:
+		return fsm.applyDeleteShardGroupCommand(cmd)
+	case internal.Command_CreateShardGroupMergeCommand:
+		return fsm.applyCreateShardGroupMergeCommand(cmd)
+	case internal.Command_CompleteShardGroupMergeCommand:
+		return fsm.applyCompleteShardGroupMergeCommand(cmd)
+	case internal.Command_CreateContinuousQueryCommand:
+		return fsm.applyCreateContinuousQueryCommand(cmd)
+	case internal.Command_DropContinuousQueryCommand:
+		return fsm.applyDropContinuousQueryCommand(cmd)
+	case internal.Command_CreateMaterializedViewCommand:
+		return fsm.applyCreateMaterializedViewCommand(cmd)
+	case internal.Command_DropMaterializedViewCommand:
+		return fsm.applyDropMaterializedViewCommand(cmd)
+	case internal.Command_SetDataNodeLabelsCommand:
+		return fsm.applySetDataNodeLabelsCommand(cmd)
+	case internal.Command_SetDatabasePlacementCommand:
+		return fsm.applySetDatabasePlacementCommand(cmd)
+	case internal.Command_CreateSubscriptionCommand:
+		return fsm.applyCreateSubscriptionCommand(cmd)
+	case internal.Command_DropSubscriptionCommand:
+		return fsm.applyDropSubscriptionCommand(cmd)
+	case internal.Command_SetSubscriptionPausedCommand:
+		return fsm.applySetSubscriptionPausedCommand(cmd)
+	case internal.Command_CreateUserCommand:
+		return fsm.applyCreateUserCommand(cmd)
+	case internal.Command_DropUserCommand:
+		return fsm.applyDropUserCommand(cmd)
+	case internal.Command_UpdateUserCommand:
+		return fsm.applyUpdateUserCommand(cmd)
+	case internal.Command_SetPrivilegeCommand:
+		return fsm.applySetPrivilegeCommand(cmd)
+	case internal.Command_SetAdminPrivilegeCommand:
+		return fsm.applySetAdminPrivilegeCommand(cmd)
+	case internal.Command_SetDataCommand:
+		return fsm.applySetDataCommand(cmd)
+	case internal.Command_UpdateNodeCommand:
+		return fsm.applyUpdateNodeCommand(cmd)
+	case internal.Command_CreateMetaNodeCommand:
+		return fsm.applyCreateMetaNodeCommand(cmd)
+	case internal.Command_DeleteMetaNodeCommand:
+		return fsm.applyDeleteMetaNodeCommand(cmd, s)
+	case internal.Command_SetMetaNodeCommand:
+		return fsm.applySetMetaNodeCommand(cmd)
+	case internal.Command_CreateDataNodeCommand:
+		return fsm.applyCreateDataNodeCommand(cmd)
+	case internal.Command_DeleteDataNodeCommand:
+		return fsm.applyDeleteDataNodeCommand(cmd)
+	case internal.Command_CreateDatabaseTemplateCommand:
+		return fsm.applyCreateDatabaseTemplateCommand(cmd)
+	case internal.Command_DropDatabaseTemplateCommand:
+		return fsm.applyDropDatabaseTemplateCommand(cmd)
+	case internal.Command_InstantiateDatabaseTemplateCommand:
+		return fsm.applyInstantiateDatabaseTemplateCommand(cmd)
+	case internal.Command_CreateRoleCommand:
+		return fsm.applyCreateRoleCommand(cmd)
+	case internal.Command_DropRoleCommand:
+		return fsm.applyDropRoleCommand(cmd)
+	case internal.Command_SetRolePrivilegeCommand:
+		return fsm.applySetRolePrivilegeCommand(cmd)
+	case internal.Command_SetUserRoleCommand:
+		return fsm.applySetUserRoleCommand(cmd)
+	case internal.Command_CreateSessionCommand:
+		return fsm.applyCreateSessionCommand(cmd)
+	case internal.Command_DropSessionCommand:
+		return fsm.applyDropSessionCommand(cmd)
+	case internal.Command_AddShardOwnerCommand:
+		return fsm.applyAddShardOwnerCommand(cmd)
+	case internal.Command_RemoveShardOwnerCommand:
+		return fsm.applyRemoveShardOwnerCommand(cmd)
+	case internal.Command_SetDataNodeModeCommand:
+		return fsm.applySetDataNodeModeCommand(cmd)
+	case internal.Command_SetDatabaseFrozenCommand:
+		return fsm.applySetDatabaseFrozenCommand(cmd)
+	case internal.Command_CreateQueryTemplateCommand:
+		return fsm.applyCreateQueryTemplateCommand(cmd)
+	case internal.Command_DropQueryTemplateCommand:
+		return fsm.applyDropQueryTemplateCommand(cmd)
+	case internal.Command_CreateEnrollmentTokenCommand:
+		return fsm.applyCreateEnrollmentTokenCommand(cmd)
+	case internal.Command_RedeemEnrollmentTokenCommand:
+		return fsm.applyRedeemEnrollmentTokenCommand(cmd)
+	case internal.Command_RevokeAgentCommand:
+		return fsm.applyRevokeAgentCommand(cmd)
+	default:
+		panic(fmt.Errorf("cannot apply command: %s", cmd.GetType()))
+	}
+}
+
 func (fsm *storeFSM) applyRemovePeerCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_RemovePeerCommand_Command)
 	v := ext.(*internal.RemovePeerCommand)
@@ -206,6 +260,11 @@ func (fsm *storeFSM) applyCreateDatabaseCommand(cmd *internal.Command) interface
 	if err := other.CreateDatabase(v.GetName()); err != nil {
 		return err
 	}
+	if keys := v.GetShardKey(); len(keys) > 0 {
+		if err := other.SetShardKey(v.GetName(), keys); err != nil {
+			return err
+		}
+	}
 
 	s := (*store)(fsm)
 	if rpi := v.GetRetentionPolicy(); rpi != nil {
@@ -214,6 +273,8 @@ func (fsm *storeFSM) applyCreateDatabaseCommand(cmd *internal.Command) interface
 			ReplicaN:           int(rpi.GetReplicaN()),
 			Duration:           time.Duration(rpi.GetDuration()),
 			ShardGroupDuration: time.Duration(rpi.GetShardGroupDuration()),
+			FutureWriteLimit:   time.Duration(rpi.GetFutureWriteLimit()),
+			PastWriteLimit:     time.Duration(rpi.GetPastWriteLimit()),
 		}, false); err != nil {
 			if err == ErrRetentionPolicyExists {
 				return ErrRetentionPolicyConflict
@@ -281,6 +342,8 @@ func (fsm *storeFSM) applyCreateRetentionPolicyCommand(cmd *internal.Command) in
 			ReplicaN:           int(pb.GetReplicaN()),
 			Duration:           time.Duration(pb.GetDuration()),
 			ShardGroupDuration: time.Duration(pb.GetShardGroupDuration()),
+			FutureWriteLimit:   time.Duration(pb.GetFutureWriteLimit()),
+			PastWriteLimit:     time.Duration(pb.GetPastWriteLimit()),
 		}, false); err != nil {
 		return err
 	}
@@ -322,7 +385,7 @@ func (fsm *storeFSM) applyUpdateRetentionPolicyCommand(cmd *internal.Command) in
 	v := ext.(*internal.UpdateRetentionPolicyCommand)
 
 	// Create update object.
-	rpu := RetentionPolicyUpdate{Name: v.NewName}
+	rpu := RetentionPolicyUpdate{Name: v.NewName, Measurement: v.GetMeasurement()}
 	if v.Duration != nil {
 		value := time.Duration(v.GetDuration())
 		rpu.Duration = &value
@@ -331,6 +394,18 @@ func (fsm *storeFSM) applyUpdateRetentionPolicyCommand(cmd *internal.Command) in
 		value := int(v.GetReplicaN())
 		rpu.ReplicaN = &value
 	}
+	if v.FutureWriteLimit != nil {
+		value := time.Duration(v.GetFutureWriteLimit())
+		rpu.FutureWriteLimit = &value
+	}
+	if v.PastWriteLimit != nil {
+		value := time.Duration(v.GetPastWriteLimit())
+		rpu.PastWriteLimit = &value
+	}
+	if v.ShardGroupDuration != nil {
+		value := time.Duration(v.GetShardGroupDuration())
+		rpu.ShardGroupDuration = &value
+	}
 
 	// Copy data and update.
 	other := fsm.data.Clone()
@@ -348,7 +423,7 @@ func (fsm *storeFSM) applyCreateShardGroupCommand(cmd *internal.Command) interfa
 
 	// Copy data and update.
 	other := fsm.data.Clone()
-	if err := other.CreateShardGroup(v.GetDatabase(), v.GetPolicy(), time.Unix(0, v.GetTimestamp())); err != nil {
+	if err := other.CreateMeasurementShardGroup(v.GetDatabase(), v.GetPolicy(), v.GetMeasurement(), time.Unix(0, v.GetTimestamp())); err != nil {
 		return err
 	}
 	fsm.data = other
@@ -370,6 +445,34 @@ func (fsm *storeFSM) applyDeleteShardGroupCommand(cmd *internal.Command) interfa
 	return nil
 }
 
+func (fsm *storeFSM) applyCreateShardGroupMergeCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_CreateShardGroupMergeCommand_Command)
+	v := ext.(*internal.CreateShardGroupMergeCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.CreateShardGroupMerge(v.GetDatabase(), v.GetPolicy(), v.GetShardGroupIDs()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyCompleteShardGroupMergeCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_CompleteShardGroupMergeCommand_Command)
+	v := ext.(*internal.CompleteShardGroupMergeCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.CompleteShardGroupMerge(v.GetDatabase(), v.GetPolicy(), v.GetShardGroupID(), v.GetNodeID()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
 func (fsm *storeFSM) applyCreateContinuousQueryCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_CreateContinuousQueryCommand_Command)
 	v := ext.(*internal.CreateContinuousQueryCommand)
@@ -398,13 +501,90 @@ func (fsm *storeFSM) applyDropContinuousQueryCommand(cmd *internal.Command) inte
 	return nil
 }
 
+func (fsm *storeFSM) applyCreateMaterializedViewCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_CreateMaterializedViewCommand_Command)
+	v := ext.(*internal.CreateMaterializedViewCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.CreateMaterializedView(v.GetDatabase(), v.GetName(), v.GetQuery()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyDropMaterializedViewCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_DropMaterializedViewCommand_Command)
+	v := ext.(*internal.DropMaterializedViewCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.DropMaterializedView(v.GetDatabase(), v.GetName()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyCreateDatabaseTemplateCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_CreateDatabaseTemplateCommand_Command)
+	v := ext.(*internal.CreateDatabaseTemplateCommand)
+
+	var tmpl DatabaseTemplateInfo
+	tmpl.unmarshal(v.GetTemplate())
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.CreateDatabaseTemplate(&tmpl); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyDropDatabaseTemplateCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_DropDatabaseTemplateCommand_Command)
+	v := ext.(*internal.DropDatabaseTemplateCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.DropDatabaseTemplate(v.GetName()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyInstantiateDatabaseTemplateCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_InstantiateDatabaseTemplateCommand_Command)
+	v := ext.(*internal.InstantiateDatabaseTemplateCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.InstantiateDatabaseTemplate(v.GetTemplate(), v.GetDatabase()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
 func (fsm *storeFSM) applyCreateSubscriptionCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_CreateSubscriptionCommand_Command)
 	v := ext.(*internal.CreateSubscriptionCommand)
 
 	// Copy data and update.
 	other := fsm.data.Clone()
-	if err := other.CreateSubscription(v.GetDatabase(), v.GetRetentionPolicy(), v.GetName(), v.GetMode(), v.GetDestinations()); err != nil {
+	si := SubscriptionInfo{Name: v.GetName(), Mode: v.GetMode(), Destinations: v.GetDestinations()}
+	if pb := v.GetSubscription(); pb != nil {
+		si.unmarshal(pb)
+	}
+	if err := other.CreateSubscriptionWithFilter(v.GetDatabase(), v.GetRetentionPolicy(), si); err != nil {
 		return err
 	}
 	fsm.data = other
@@ -426,6 +606,20 @@ func (fsm *storeFSM) applyDropSubscriptionCommand(cmd *internal.Command) interfa
 	return nil
 }
 
+func (fsm *storeFSM) applySetSubscriptionPausedCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetSubscriptionPausedCommand_Command)
+	v := ext.(*internal.SetSubscriptionPausedCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetSubscriptionPaused(v.GetDatabase(), v.GetRetentionPolicy(), v.GetName(), v.GetPaused()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
 func (fsm *storeFSM) applyCreateUserCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_CreateUserCommand_Command)
 	v := ext.(*internal.CreateUserCommand)
@@ -435,6 +629,7 @@ func (fsm *storeFSM) applyCreateUserCommand(cmd *internal.Command) interface{} {
 	if err := other.CreateUser(v.GetName(), v.GetHash(), v.GetAdmin()); err != nil {
 		return err
 	}
+	other.user(v.GetName()).PasswordChanged = UnmarshalTime(v.GetPasswordChanged())
 	fsm.data = other
 
 	return nil
@@ -457,11 +652,29 @@ func (fsm *storeFSM) applyUpdateUserCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_UpdateUserCommand_Command)
 	v := ext.(*internal.UpdateUserCommand)
 
+	// A rehash only replaces the hash it was computed from, so it cannot
+	// undo a password change committed in the meantime.
+	if v.PreviousHash != nil {
+		if ui := fsm.data.user(v.GetName()); ui == nil || ui.Hash != v.GetPreviousHash() {
+			return nil
+		}
+	}
+
 	// Copy data and update.
 	other := fsm.data.Clone()
+	var previous string
+	if ui := other.user(v.GetName()); ui != nil {
+		previous = ui.Hash
+	}
 	if err := other.UpdateUser(v.GetName(), v.GetHash()); err != nil {
 		return err
 	}
+	if v.PasswordChanged != nil {
+		ui := other.user(v.GetName())
+		ui.PasswordChanged = UnmarshalTime(v.GetPasswordChanged())
+		ui.rememberHash(previous, int(v.GetPasswordHistory()))
+		other.DropUserSessions(v.GetName())
+	}
 	fsm.data = other
 	return nil
 }
@@ -492,6 +705,238 @@ func (fsm *storeFSM) applySetAdminPrivilegeCommand(cmd *internal.Command) interf
 	return nil
 }
 
+func (fsm *storeFSM) applyCreateRoleCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_CreateRoleCommand_Command)
+	v := ext.(*internal.CreateRoleCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.CreateRole(v.GetName()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applyDropRoleCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_DropRoleCommand_Command)
+	v := ext.(*internal.DropRoleCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.DropRole(v.GetName()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applySetRolePrivilegeCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetRolePrivilegeCommand_Command)
+	v := ext.(*internal.SetRolePrivilegeCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetRolePrivilege(v.GetRole(), v.GetDatabase(), influxql.Privilege(v.GetPrivilege())); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applySetUserRoleCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetUserRoleCommand_Command)
+	v := ext.(*internal.SetUserRoleCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetUserRole(v.GetUsername(), v.GetRole(), v.GetMember()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applyCreateSessionCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_CreateSessionCommand_Command)
+	v := ext.(*internal.CreateSessionCommand)
+
+	var si SessionInfo
+	si.unmarshal(v.GetSession())
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.CreateSession(si); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applyDropSessionCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_DropSessionCommand_Command)
+	v := ext.(*internal.DropSessionCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.DropSession(v.GetID()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applyAddShardOwnerCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_AddShardOwnerCommand_Command)
+	v := ext.(*internal.AddShardOwnerCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.AddShardOwner(v.GetID(), v.GetNodeID()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applyRemoveShardOwnerCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_RemoveShardOwnerCommand_Command)
+	v := ext.(*internal.RemoveShardOwnerCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.RemoveShardOwner(v.GetID(), v.GetNodeID()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applyCreateEnrollmentTokenCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_CreateEnrollmentTokenCommand_Command)
+	v := ext.(*internal.CreateEnrollmentTokenCommand)
+
+	var ti EnrollmentTokenInfo
+	ti.unmarshal(v.GetToken())
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.CreateEnrollmentToken(ti); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applyRedeemEnrollmentTokenCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_RedeemEnrollmentTokenCommand_Command)
+	v := ext.(*internal.RedeemEnrollmentTokenCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.RedeemEnrollmentToken(v.GetHash(), v.GetSerial(), UnmarshalTime(v.GetCreatedAt()), UnmarshalTime(v.GetExpiresAt())); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applyRevokeAgentCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_RevokeAgentCommand_Command)
+	v := ext.(*internal.RevokeAgentCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.RevokeAgent(v.GetName()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applySetDataNodeModeCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetDataNodeModeCommand_Command)
+	v := ext.(*internal.SetDataNodeModeCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetDataNodeMode(v.GetID(), v.GetReadOnly(), v.GetReadOnlyReason(), v.GetMaintenance()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applySetDataNodeLabelsCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetDataNodeLabelsCommand_Command)
+	v := ext.(*internal.SetDataNodeLabelsCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetDataNodeLabels(v.GetID(), unmarshalLabels(v.GetLabels())); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applySetDatabasePlacementCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetDatabasePlacementCommand_Command)
+	v := ext.(*internal.SetDatabasePlacementCommand)
+
+	var p PlacementInfo
+	if v.Placement != nil {
+		p.unmarshal(v.GetPlacement())
+	}
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetDatabasePlacement(v.GetName(), p); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applySetDatabaseFrozenCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetDatabaseFrozenCommand_Command)
+	v := ext.(*internal.SetDatabaseFrozenCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetDatabaseFrozen(v.GetName(), v.GetFrozen()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applyCreateQueryTemplateCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_CreateQueryTemplateCommand_Command)
+	v := ext.(*internal.CreateQueryTemplateCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.CreateQueryTemplate(v.GetName(), v.GetQuery()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applyDropQueryTemplateCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_DropQueryTemplateCommand_Command)
+	v := ext.(*internal.DropQueryTemplateCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.DropQueryTemplate(v.GetName()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
 func (fsm *storeFSM) applySetDataCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_SetDataCommand_Command)
 	v := ext.(*internal.SetDataCommand)
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/freetsdb/freetsdb/monitor/diagnostics"
+	"github.com/freetsdb/freetsdb/pkg/netutil"
 	"github.com/freetsdb/freetsdb/toml"
 )
 
@@ -98,6 +99,16 @@ func (c *Config) WithDefaults() *Config {
 	return &d
 }
 
+// Validate returns an error if the Config is invalid.
+func (c *Config) Validate() error {
+	if c.BindAddress != "" {
+		if err := netutil.ValidateBindAddress(c.BindAddress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Configs wraps a slice of Config to aggregate diagnostics.
 type Configs []Config
 
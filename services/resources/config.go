@@ -0,0 +1,149 @@
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/freetsdb/freetsdb/monitor/diagnostics"
+	"github.com/freetsdb/freetsdb/toml"
+)
+
+const (
+	// DefaultNUMANode is the default NUMA node the server is pinned to: none.
+	DefaultNUMANode = -1
+)
+
+// Config represents the configuration of the runtime resources of the
+// server. The zero value of every setting leaves the runtime default.
+type Config struct {
+	// GOGC sets the garbage collection target percentage. A negative value
+	// disables the garbage collector.
+	GOGC int `toml:"gogc"`
+
+	// MemoryLimit sets the soft memory limit of the runtime.
+	MemoryLimit toml.Size `toml:"memory-limit"`
+
+	// BallastSize is the size of a memory ballast allocated to delay garbage
+	// collections on small heaps. The ballast is never written, so it does not
+	// take physical memory.
+	BallastSize toml.Size `toml:"ballast-size"`
+
+	// NUMANode pins the server to the CPUs of a NUMA node.
+	NUMANode int `toml:"numa-node"`
+
+	// CPUs pins the server to a list of CPUs, such as "0-3,8-11". It takes
+	// precedence over NUMANode.
+	CPUs string `toml:"cpus"`
+
+	// MaxProcs sets GOMAXPROCS. It defaults to the number of CPUs pinned.
+	MaxProcs int `toml:"max-procs"`
+
+	// CompactionWorkers limits the number of concurrent compactions.
+	CompactionWorkers int `toml:"compaction-workers"`
+
+	// QueryWorkers limits the number of concurrent queries, overriding
+	// max-concurrent-queries in [coordinator].
+	QueryWorkers int `toml:"query-workers"`
+}
+
+// NewConfig returns a new Config with defaults.
+func NewConfig() Config {
+	return Config{
+		NUMANode: DefaultNUMANode,
+	}
+}
+
+// Validate returns an error if the Config is invalid.
+func (c Config) Validate() error {
+	if c.NUMANode < -1 {
+		return errors.New("numa-node must be -1 or a node number")
+	}
+	if _, err := ParseCPUList(c.CPUs); err != nil {
+		return fmt.Errorf("cpus: %v", err)
+	}
+	if c.MaxProcs < 0 {
+		return errors.New("max-procs cannot be negative")
+	}
+	if c.CompactionWorkers < 0 {
+		return errors.New("compaction-workers cannot be negative")
+	}
+	if c.QueryWorkers < 0 {
+		return errors.New("query-workers cannot be negative")
+	}
+	return nil
+}
+
+// Diagnostics returns a diagnostics representation of a subset of the Config.
+func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
+	return diagnostics.RowFromMap(map[string]interface{}{
+		"gogc":               c.GOGC,
+		"memory-limit":       c.MemoryLimit,
+		"ballast-size":       c.BallastSize,
+		"numa-node":          c.NUMANode,
+		"cpus":               c.CPUs,
+		"max-procs":          c.MaxProcs,
+		"compaction-workers": c.CompactionWorkers,
+		"query-workers":      c.QueryWorkers,
+	}), nil
+}
+
+// jsonConfig is the JSON representation of a Config, with sizes in bytes.
+type jsonConfig struct {
+	GOGC              int    `json:"gogc"`
+	MemoryLimit       int64  `json:"memory-limit"`
+	BallastSize       int64  `json:"ballast-size"`
+	NUMANode          int    `json:"numa-node"`
+	CPUs              string `json:"cpus"`
+	MaxProcs          int    `json:"max-procs"`
+	CompactionWorkers int    `json:"compaction-workers"`
+	QueryWorkers      int    `json:"query-workers"`
+}
+
+// MarshalJSON encodes the Config as JSON.
+func (c Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonConfig{
+		GOGC:              c.GOGC,
+		MemoryLimit:       int64(c.MemoryLimit),
+		BallastSize:       int64(c.BallastSize),
+		NUMANode:          c.NUMANode,
+		CPUs:              c.CPUs,
+		MaxProcs:          c.MaxProcs,
+		CompactionWorkers: c.CompactionWorkers,
+		QueryWorkers:      c.QueryWorkers,
+	})
+}
+
+// UnmarshalJSON decodes the Config from JSON. Settings missing from the JSON
+// keep their value, so a partial document updates the Config.
+func (c *Config) UnmarshalJSON(b []byte) error {
+	v := jsonConfig{
+		GOGC:              c.GOGC,
+		MemoryLimit:       int64(c.MemoryLimit),
+		BallastSize:       int64(c.BallastSize),
+		NUMANode:          c.NUMANode,
+		CPUs:              c.CPUs,
+		MaxProcs:          c.MaxProcs,
+		CompactionWorkers: c.CompactionWorkers,
+		QueryWorkers:      c.QueryWorkers,
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	} else if v.MemoryLimit < 0 {
+		return errors.New("memory-limit cannot be negative")
+	} else if v.BallastSize < 0 {
+		return errors.New("ballast-size cannot be negative")
+	}
+
+	*c = Config{
+		GOGC:              v.GOGC,
+		MemoryLimit:       toml.Size(v.MemoryLimit),
+		BallastSize:       toml.Size(v.BallastSize),
+		NUMANode:          v.NUMANode,
+		CPUs:              v.CPUs,
+		MaxProcs:          v.MaxProcs,
+		CompactionWorkers: v.CompactionWorkers,
+		QueryWorkers:      v.QueryWorkers,
+	}
+	return nil
+}
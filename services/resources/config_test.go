@@ -0,0 +1,122 @@
+package resources_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/freetsdb/freetsdb/services/resources"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	c := resources.NewConfig()
+	if _, err := toml.Decode(`
+gogc = 200
+memory-limit = "4g"
+ballast-size = "256m"
+numa-node = 1
+cpus = "0-3,8"
+max-procs = 4
+compaction-workers = 2
+query-workers = 8
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Validate configuration.
+	if c.GOGC != 200 {
+		t.Fatalf("unexpected gogc: %d", c.GOGC)
+	} else if c.MemoryLimit != 4<<30 {
+		t.Fatalf("unexpected memory limit: %d", c.MemoryLimit)
+	} else if c.BallastSize != 256<<20 {
+		t.Fatalf("unexpected ballast size: %d", c.BallastSize)
+	} else if c.NUMANode != 1 {
+		t.Fatalf("unexpected numa node: %d", c.NUMANode)
+	} else if c.CPUs != "0-3,8" {
+		t.Fatalf("unexpected cpus: %s", c.CPUs)
+	} else if c.MaxProcs != 4 {
+		t.Fatalf("unexpected max procs: %d", c.MaxProcs)
+	} else if c.CompactionWorkers != 2 {
+		t.Fatalf("unexpected compaction workers: %d", c.CompactionWorkers)
+	} else if c.QueryWorkers != 8 {
+		t.Fatalf("unexpected query workers: %d", c.QueryWorkers)
+	} else if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %s", err)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	if err := resources.NewConfig().Validate(); err != nil {
+		t.Fatalf("unexpected validation fail from NewConfig: %s", err)
+	}
+
+	for _, fn := range []func(c *resources.Config){
+		func(c *resources.Config) { c.NUMANode = -2 },
+		func(c *resources.Config) { c.CPUs = "3-1" },
+		func(c *resources.Config) { c.CPUs = "a" },
+		func(c *resources.Config) { c.MaxProcs = -1 },
+		func(c *resources.Config) { c.CompactionWorkers = -1 },
+		func(c *resources.Config) { c.QueryWorkers = -1 },
+	} {
+		c := resources.NewConfig()
+		fn(&c)
+		if err := c.Validate(); err == nil {
+			t.Fatalf("expected validation error for %+v", c)
+		}
+	}
+}
+
+func TestConfig_JSON(t *testing.T) {
+	c := resources.NewConfig()
+	c.GOGC = 50
+	c.MemoryLimit = 1 << 30
+
+	// A partial document only changes the settings it holds.
+	if err := json.Unmarshal([]byte(`{"ballast-size":1024,"query-workers":4}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	exp := resources.NewConfig()
+	exp.GOGC = 50
+	exp.MemoryLimit = 1 << 30
+	exp.BallastSize = 1024
+	exp.QueryWorkers = 4
+	if !reflect.DeepEqual(c, exp) {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got resources.Config
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected config after round trip: %+v", got)
+	}
+
+	if err := json.Unmarshal([]byte(`{"memory-limit":-1}`), &got); err == nil {
+		t.Fatal("expected error for a negative memory limit")
+	}
+}
+
+func TestParseCPUList(t *testing.T) {
+	for _, tt := range []struct {
+		s   string
+		exp []int
+	}{
+		{s: "", exp: nil},
+		{s: "0", exp: []int{0}},
+		{s: "0-3,8-9\n", exp: []int{0, 1, 2, 3, 8, 9}},
+		{s: "4,1-2,2", exp: []int{1, 2, 4}},
+	} {
+		cpus, err := resources.ParseCPUList(tt.s)
+		if err != nil {
+			t.Fatalf("%q: %s", tt.s, err)
+		} else if !reflect.DeepEqual(cpus, tt.exp) {
+			t.Fatalf("%q: unexpected cpus: %v", tt.s, cpus)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+//go:build go1.19
+// +build go1.19
+
+package resources
+
+import (
+	"math"
+	"runtime/debug"
+)
+
+// setMemoryLimit sets the soft memory limit of the runtime. A limit of 0
+// removes the limit.
+func setMemoryLimit(n int64) error {
+	if n == 0 {
+		n = math.MaxInt64
+	}
+	debug.SetMemoryLimit(n)
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build !go1.19
+// +build !go1.19
+
+package resources
+
+import "errors"
+
+// setMemoryLimit returns an error if n is set: the runtime has no soft memory
+// limit before Go 1.19.
+func setMemoryLimit(n int64) error {
+	if n == 0 {
+		return nil
+	}
+	return errors.New("memory-limit requires Go 1.19 or later")
+}
@@ -0,0 +1,229 @@
+// Package resources provides the service tuning the runtime resources of the
+// server: the garbage collector, the memory limit, CPU pinning and the number
+// of compaction and query workers.
+package resources // import "github.com/freetsdb/freetsdb/services/resources"
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// State is the current state of the runtime resources.
+type State struct {
+	Settings   Config   `json:"settings"`
+	Topology   Topology `json:"topology"`
+	PinnedCPUs []int    `json:"pinned-cpus"`
+	GOMAXPROCS int      `json:"gomaxprocs"`
+}
+
+// Service applies the runtime resource settings and changes them at runtime.
+type Service struct {
+	mu       sync.Mutex
+	config   Config
+	opened   bool
+	topology Topology
+	pinned   []int
+	ballast  []byte
+
+	// The runtime settings when the service was opened, restored when the
+	// settings overriding them are unset.
+	gcPercent int
+	maxProcs  int
+
+	Compactions interface {
+		SetMaxConcurrentCompactions(n int)
+	}
+	Queries interface {
+		SetMaxConcurrentQueries(n int)
+	}
+
+	Logger *zap.Logger
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	return &Service{
+		config: c,
+		Logger: zap.NewNop(),
+	}
+}
+
+// WithLogger sets the logger for the service.
+func (s *Service) WithLogger(log *zap.Logger) {
+	s.Logger = log.With(zap.String("service", "resources"))
+}
+
+// Open detects the CPU topology and applies the settings. It should be called
+// before the store is opened, so the store sizes its workers for the CPUs
+// pinned.
+func (s *Service) Open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.opened {
+		return nil
+	}
+
+	s.topology = detectTopology()
+	s.gcPercent = debug.SetGCPercent(100)
+	debug.SetGCPercent(s.gcPercent)
+	s.maxProcs = runtime.GOMAXPROCS(0)
+
+	s.Logger.Info("Detected CPU topology",
+		zap.Int("cpus", s.topology.CPUs),
+		zap.Int("numa_nodes", len(s.topology.Nodes)))
+
+	if err := s.apply(NewConfig(), s.config); err != nil {
+		return err
+	}
+	s.opened = true
+	return nil
+}
+
+// Close releases the memory ballast.
+func (s *Service) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ballast = nil
+	s.opened = false
+	return nil
+}
+
+// State returns the current settings and the CPU topology.
+func (s *Service) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return State{
+		Settings:   s.config,
+		Topology:   s.topology,
+		PinnedCPUs: s.pinned,
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+	}
+}
+
+// Apply validates c and applies the settings that changed. Settings that are
+// unset are restored to the values the service was opened with, and unsetting
+// the query workers restores the limit of [coordinator]. If a setting cannot
+// be applied, none of them are changed.
+func (s *Service) Apply(c Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.opened {
+		s.config = c
+		return nil
+	}
+	return s.apply(s.config, c)
+}
+
+// apply changes the settings from prev to c. s.mu must be held.
+func (s *Service) apply(prev, c Config) error {
+	cpus, err := ParseCPUList(c.CPUs)
+	if err != nil {
+		return err
+	}
+	if len(cpus) == 0 && c.NUMANode >= 0 {
+		n := s.topology.Node(c.NUMANode)
+		if n == nil {
+			return fmt.Errorf("numa node %d does not exist", c.NUMANode)
+		}
+		cpus = n.CPUs
+	}
+
+	// The settings that may fail are applied first, and undone if a later
+	// one fails, so the settings are not left half-applied.
+	if c.MemoryLimit != prev.MemoryLimit {
+		if err := setMemoryLimit(memoryLimit(c)); err != nil {
+			return err
+		}
+	}
+	if err := s.pin(cpus); err != nil {
+		if c.MemoryLimit != prev.MemoryLimit {
+			setMemoryLimit(memoryLimit(prev))
+		}
+		return fmt.Errorf("pin cpus: %v", err)
+	}
+
+	procs := c.MaxProcs
+	if procs == 0 && len(s.pinned) > 0 {
+		procs = len(s.pinned)
+	} else if procs == 0 {
+		procs = s.maxProcs
+	}
+	runtime.GOMAXPROCS(procs)
+
+	if c.GOGC != 0 {
+		debug.SetGCPercent(c.GOGC)
+	} else if prev.GOGC != 0 {
+		debug.SetGCPercent(s.gcPercent)
+	}
+
+	if c.BallastSize != prev.BallastSize {
+		s.ballast = nil
+		if c.BallastSize > 0 {
+			s.ballast = make([]byte, c.BallastSize)
+		}
+	}
+
+	if s.Compactions != nil {
+		s.Compactions.SetMaxConcurrentCompactions(c.CompactionWorkers)
+	}
+	if s.Queries != nil {
+		s.Queries.SetMaxConcurrentQueries(c.QueryWorkers)
+	}
+
+	s.config = c
+	s.Logger.Info("Applied runtime resource settings",
+		zap.Int("gogc", c.GOGC),
+		zap.Uint64("memory_limit", uint64(c.MemoryLimit)),
+		zap.Uint64("ballast_size", uint64(c.BallastSize)),
+		zap.Ints("pinned_cpus", s.pinned),
+		zap.Int("gomaxprocs", procs),
+		zap.Int("compaction_workers", c.CompactionWorkers),
+		zap.Int("query_workers", c.QueryWorkers))
+	return nil
+}
+
+// pin pins the process to cpus, or unpins it by allowing all the CPUs of the
+// host if cpus is empty. The previous CPUs are restored if pinning fails
+// part way. s.mu must be held.
+func (s *Service) pin(cpus []int) error {
+	if len(cpus) == 0 && len(s.pinned) == 0 {
+		return nil
+	}
+	if err := pinCPUs(s.cpuSet(cpus)); err != nil {
+		pinCPUs(s.cpuSet(s.pinned))
+		return err
+	}
+	s.pinned = cpus
+	return nil
+}
+
+// cpuSet returns cpus, or all the CPUs of the host if cpus is empty.
+func (s *Service) cpuSet(cpus []int) []int {
+	if len(cpus) > 0 {
+		return cpus
+	}
+	var set []int
+	for _, n := range s.topology.Nodes {
+		set = append(set, n.CPUs...)
+	}
+	return set
+}
+
+// memoryLimit returns the memory limit of c for the runtime.
+func memoryLimit(c Config) int64 {
+	if c.MemoryLimit >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(c.MemoryLimit)
+}
@@ -0,0 +1,78 @@
+package resources_test
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/services/resources"
+)
+
+func TestService_Apply(t *testing.T) {
+	gcPercent := debug.SetGCPercent(100)
+	debug.SetGCPercent(gcPercent)
+	maxProcs := runtime.GOMAXPROCS(0)
+
+	var compactions, queries int
+	c := resources.NewConfig()
+	c.GOGC = 300
+	c.CompactionWorkers = 2
+	c.QueryWorkers = 3
+	s := resources.NewService(c)
+	s.Compactions = maxCompactionsFunc(func(n int) { compactions = n })
+	s.Queries = maxQueriesFunc(func(n int) { queries = n })
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if got := debug.SetGCPercent(300); got != 300 {
+		t.Fatalf("unexpected gc percent: %d", got)
+	} else if compactions != 2 {
+		t.Fatalf("unexpected compaction workers: %d", compactions)
+	} else if queries != 3 {
+		t.Fatalf("unexpected query workers: %d", queries)
+	}
+
+	// Unset settings are restored.
+	c = resources.NewConfig()
+	c.MaxProcs = 1
+	if err := s.Apply(c); err != nil {
+		t.Fatal(err)
+	}
+	if got := debug.SetGCPercent(gcPercent); got != gcPercent {
+		t.Fatalf("unexpected gc percent: %d", got)
+	} else if compactions != 0 {
+		t.Fatalf("unexpected compaction workers: %d", compactions)
+	} else if queries != 0 {
+		t.Fatalf("unexpected query workers: %d", queries)
+	} else if got := runtime.GOMAXPROCS(0); got != 1 {
+		t.Fatalf("unexpected GOMAXPROCS: %d", got)
+	}
+
+	if err := s.Apply(resources.NewConfig()); err != nil {
+		t.Fatal(err)
+	} else if got := runtime.GOMAXPROCS(0); got != maxProcs {
+		t.Fatalf("unexpected GOMAXPROCS: %d", got)
+	}
+
+	// Invalid settings are not applied.
+	c = resources.NewConfig()
+	c.GOGC = 50
+	c.NUMANode = 1 << 20
+	if err := s.Apply(c); err == nil {
+		t.Fatal("expected error for a missing numa node")
+	} else if st := s.State(); st.Settings.GOGC != 0 {
+		t.Fatalf("unexpected settings: %+v", st.Settings)
+	} else if len(st.Topology.Nodes) == 0 {
+		t.Fatal("expected at least one numa node")
+	}
+}
+
+type maxCompactionsFunc func(n int)
+
+func (fn maxCompactionsFunc) SetMaxConcurrentCompactions(n int) { fn(n) }
+
+type maxQueriesFunc func(n int)
+
+func (fn maxQueriesFunc) SetMaxConcurrentQueries(n int) { fn(n) }
@@ -0,0 +1,81 @@
+package resources
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Topology describes the CPUs of the host.
+type Topology struct {
+	// CPUs is the number of CPUs available to the process when it started.
+	CPUs int `json:"cpus"`
+
+	// Nodes are the NUMA nodes of the host. There is a single node on hosts
+	// without NUMA or where it cannot be detected.
+	Nodes []NUMANode `json:"nodes"`
+}
+
+// NUMANode is a NUMA node and its CPUs.
+type NUMANode struct {
+	ID   int   `json:"id"`
+	CPUs []int `json:"cpus"`
+}
+
+// Node returns the NUMA node with the given id, or nil if there is none.
+func (t *Topology) Node(id int) *NUMANode {
+	for i := range t.Nodes {
+		if t.Nodes[i].ID == id {
+			return &t.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// defaultNode returns a node holding as many CPUs as the process can use.
+func defaultNode() NUMANode {
+	cpus := make([]int, runtime.NumCPU())
+	for i := range cpus {
+		cpus[i] = i
+	}
+	return NUMANode{CPUs: cpus}
+}
+
+// ParseCPUList parses a list of CPUs in the format of the Linux cpulist files,
+// such as "0-3,8-11". The CPUs are returned sorted and without duplicates.
+func ParseCPUList(s string) ([]int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	seen := make(map[int]struct{})
+	for _, part := range strings.Split(s, ",") {
+		lo, hi := part, part
+		if i := strings.IndexByte(part, '-'); i >= 0 {
+			lo, hi = part[:i], part[i+1:]
+		}
+
+		first, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil || first < 0 {
+			return nil, fmt.Errorf("invalid cpu list: %q", s)
+		}
+		last, err := strconv.Atoi(strings.TrimSpace(hi))
+		if err != nil || last < first {
+			return nil, fmt.Errorf("invalid cpu list: %q", s)
+		}
+
+		for cpu := first; cpu <= last; cpu++ {
+			seen[cpu] = struct{}{}
+		}
+	}
+
+	cpus := make([]int, 0, len(seen))
+	for cpu := range seen {
+		cpus = append(cpus, cpu)
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
@@ -0,0 +1,66 @@
+package resources
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// detectTopology reads the NUMA nodes of the host from sysfs.
+func detectTopology() Topology {
+	t := Topology{CPUs: runtime.NumCPU()}
+
+	paths, _ := filepath.Glob("/sys/devices/system/node/node*/cpulist")
+	for _, path := range paths {
+		id, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(filepath.Dir(path)), "node"))
+		if err != nil {
+			continue
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		cpus, err := ParseCPUList(string(b))
+		if err != nil || len(cpus) == 0 {
+			continue
+		}
+		t.Nodes = append(t.Nodes, NUMANode{ID: id, CPUs: cpus})
+	}
+	sort.Slice(t.Nodes, func(i, j int) bool { return t.Nodes[i].ID < t.Nodes[j].ID })
+
+	if len(t.Nodes) == 0 {
+		t.Nodes = []NUMANode{defaultNode()}
+	}
+	return t
+}
+
+// pinCPUs sets the CPU affinity of every thread of the process to cpus. New
+// threads inherit the affinity of the thread creating them.
+func pinCPUs(cpus []int) error {
+	var set unix.CPUSet
+	for _, cpu := range cpus {
+		set.Set(cpu)
+	}
+
+	tasks, err := ioutil.ReadDir("/proc/self/task")
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		// Threads may exit while they are being pinned.
+		if err := unix.SchedSetaffinity(tid, &set); err != nil && err != unix.ESRCH {
+			return os.NewSyscallError("sched_setaffinity", err)
+		}
+	}
+	return nil
+}
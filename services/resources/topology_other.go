@@ -0,0 +1,22 @@
+//go:build !linux
+// +build !linux
+
+package resources
+
+import (
+	"errors"
+	"runtime"
+)
+
+// detectTopology returns a single node with the CPUs of the process.
+func detectTopology() Topology {
+	return Topology{
+		CPUs:  runtime.NumCPU(),
+		Nodes: []NUMANode{defaultNode()},
+	}
+}
+
+// pinCPUs returns an error: CPU pinning is only supported on Linux.
+func pinCPUs(cpus []int) error {
+	return errors.New("cpu pinning is not supported on " + runtime.GOOS)
+}
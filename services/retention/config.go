@@ -2,21 +2,56 @@ package retention
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/freetsdb/freetsdb/monitor/diagnostics"
 	"github.com/freetsdb/freetsdb/toml"
 )
 
+const (
+	// DefaultMergeAfter is how long after they end shard groups are merged.
+	DefaultMergeAfter = 7 * 24 * time.Hour
+
+	// DefaultMergeDuration is the duration of merged shard groups.
+	DefaultMergeDuration = 7 * 24 * time.Hour
+)
+
 // Config represents the configuration for the retention service.
 type Config struct {
 	Enabled       bool          `toml:"enabled"`
 	CheckInterval toml.Duration `toml:"check-interval"`
+
+	// MergeEnabled merges the shard groups of retention policies with a
+	// shard group duration shorter than MergeDuration into shard groups of
+	// MergeDuration, once they have ended for MergeAfter.
+	MergeEnabled  bool          `toml:"merge-enabled"`
+	MergeAfter    toml.Duration `toml:"merge-after"`
+	MergeDuration toml.Duration `toml:"merge-duration"`
+
+	// DiskWatermark is the percentage of the disk holding the data directory
+	// above which this node evicts its shards of the oldest shard groups of
+	// EvictPolicies, until the usage is below it again. The copies of other
+	// nodes are kept. Zero disables eviction.
+	DiskWatermark int `toml:"disk-watermark"`
+
+	// EvictPolicies lists the retention policies that shard groups may be
+	// evicted from, as "database.retention-policy".
+	EvictPolicies []string `toml:"evict-policies"`
+
+	// EvictArchiveDir, if set, is where the local shards of evicted shard
+	// groups are archived, as backup tar files, before they are deleted.
+	EvictArchiveDir string `toml:"evict-archive-dir"`
 }
 
 // NewConfig returns an instance of Config with defaults.
 func NewConfig() Config {
-	return Config{Enabled: true, CheckInterval: toml.Duration(30 * time.Minute)}
+	return Config{
+		Enabled:       true,
+		CheckInterval: toml.Duration(30 * time.Minute),
+		MergeAfter:    toml.Duration(DefaultMergeAfter),
+		MergeDuration: toml.Duration(DefaultMergeDuration),
+	}
 }
 
 // Validate returns an error if the Config is invalid.
@@ -31,6 +66,25 @@ func (c Config) Validate() error {
 		return errors.New("check-interval must be positive")
 	}
 
+	if c.MergeEnabled {
+		if c.MergeAfter < 0 {
+			return errors.New("merge-after must not be negative")
+		} else if c.MergeDuration <= 0 {
+			return errors.New("merge-duration must be positive")
+		}
+	}
+
+	if c.DiskWatermark < 0 || c.DiskWatermark > 100 {
+		return errors.New("disk-watermark must be between 0 and 100")
+	} else if c.DiskWatermark > 0 && len(c.EvictPolicies) == 0 {
+		return errors.New("evict-policies must be set with disk-watermark")
+	}
+	for _, p := range c.EvictPolicies {
+		if _, _, ok := splitPolicy(p); !ok {
+			return fmt.Errorf("invalid evict policy %q: must be database.retention-policy", p)
+		}
+	}
+
 	return nil
 }
 
@@ -45,5 +99,9 @@ func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
 	return diagnostics.RowFromMap(map[string]interface{}{
 		"enabled":        true,
 		"check-interval": c.CheckInterval,
+		"merge-enabled":  c.MergeEnabled,
+		"merge-after":    c.MergeAfter,
+		"merge-duration": c.MergeDuration,
+		"disk-watermark": c.DiskWatermark,
 	}), nil
 }
@@ -44,6 +44,35 @@ func TestConfig_Validate(t *testing.T) {
 		t.Fatal("expected error for negative check-interval, got nil")
 	}
 
+	c = retention.NewConfig()
+	c.MergeEnabled = true
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected validation fail with merges enabled: %s", err)
+	}
+	c.MergeDuration = 0
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for merge-duration = 0, got nil")
+	}
+
+	c = retention.NewConfig()
+	c.DiskWatermark = 90
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for disk-watermark without evict-policies, got nil")
+	}
+	c.EvictPolicies = []string{"db0.rp0"}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected validation fail with a disk watermark: %s", err)
+	}
+	c.EvictPolicies = []string{"db0"}
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for evict policy without retention policy, got nil")
+	}
+	c.EvictPolicies = []string{"db0.rp0"}
+	c.DiskWatermark = 101
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for disk-watermark = 101, got nil")
+	}
+
 	c.Enabled = false
 	if err := c.Validate(); err != nil {
 		t.Fatalf("unexpected validation fail from disabled config: %s", err)
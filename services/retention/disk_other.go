@@ -0,0 +1,11 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!windows
+
+package retention
+
+import "errors"
+
+// diskUsage returns an error, as the disk usage is not known on this platform.
+func diskUsage(path string) (float64, error) {
+	return 0, errors.New("disk usage is not supported on this platform")
+}
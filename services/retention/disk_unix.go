@@ -0,0 +1,22 @@
+//go:build darwin || dragonfly || freebsd || linux
+// +build darwin dragonfly freebsd linux
+
+package retention
+
+import "syscall"
+
+// diskUsage returns the percentage of the disk holding path that is used,
+// as reported by df.
+func diskUsage(path string) (float64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+
+	used := float64(st.Blocks) - float64(st.Bfree)
+	avail := float64(st.Bavail)
+	if used+avail <= 0 {
+		return 0, nil
+	}
+	return used / (used + avail) * 100, nil
+}
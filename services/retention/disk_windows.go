@@ -0,0 +1,31 @@
+package retention
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var getDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// diskUsage returns the percentage of the disk holding path that is used.
+func diskUsage(path string) (float64, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var avail, total, free uint64
+	if r, _, err := getDiskFreeSpaceEx.Call(
+		uintptr(unsafe.Pointer(p)),
+		uintptr(unsafe.Pointer(&avail)),
+		uintptr(unsafe.Pointer(&total)),
+		uintptr(unsafe.Pointer(&free)),
+	); r == 0 {
+		return 0, err
+	}
+
+	if total == 0 {
+		return 0, nil
+	}
+	return float64(total-free) / float64(total) * 100, nil
+}
@@ -0,0 +1,196 @@
+package retention
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/freetsdb/freetsdb/logger"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"go.uber.org/zap"
+)
+
+// evictShardGroups evicts the shards this node holds of the oldest shard
+// groups of policies, while the disk holding the data directory is used
+// above watermark percent. The node is removed from the owners of the shards
+// it deletes; the groups are not deleted, so the copies on other nodes are
+// kept. The local shards are archived to archiveDir first, if set. It
+// returns true if an error occurred.
+func (s *Service) evictShardGroups(log *zap.Logger, watermark float64, policies []string, archiveDir string) bool {
+	path := s.TSDBStore.Path()
+	usage, err := s.DiskUsage(path)
+	if err != nil {
+		log.Info("Failed to determine disk usage", zap.String("path", path), zap.Error(err))
+		return true
+	} else if usage < watermark {
+		return false
+	}
+
+	log.Warn("Disk usage is above the watermark, evicting the oldest shard groups",
+		zap.String("path", path),
+		zap.Float64("usage", usage),
+		zap.Float64("watermark", watermark))
+
+	dbs, err := s.MetaClient.Databases()
+	if err != nil {
+		log.Info("Failed to list databases", zap.Error(err))
+		return true
+	}
+
+	local := make(map[uint64]struct{})
+	for _, id := range s.TSDBStore.ShardIDs() {
+		local[id] = struct{}{}
+	}
+
+	for _, c := range planEvictions(dbs, policies, local, time.Now().UTC()) {
+		if archiveDir != "" {
+			if err := s.archiveShards(archiveDir, c, local); err != nil {
+				log.Info("Failed to archive shard group",
+					logger.Database(c.db),
+					logger.ShardGroup(c.sgi.ID),
+					logger.RetentionPolicy(c.rp),
+					zap.Error(err))
+				return true
+			}
+		}
+
+		// Only the copies on this node are evicted: the node stops owning
+		// them, and the group and the copies of other nodes are kept.
+		nodeID := s.MetaClient.NodeID()
+		for _, sh := range c.sgi.Shards {
+			if _, ok := local[sh.ID]; !ok {
+				continue
+			}
+			if err := s.MetaClient.RemoveShardOwner(sh.ID, nodeID); err != nil {
+				log.Info("Failed to remove shard owner",
+					logger.Database(c.db),
+					logger.Shard(sh.ID),
+					logger.RetentionPolicy(c.rp),
+					zap.Error(err))
+				return true
+			}
+			err := s.TSDBStore.DeleteShard(sh.ID)
+			s.audit(c.db, fmt.Sprintf("evict shard %d of shard group %d of retention policy %q", sh.ID, c.sgi.ID, c.rp), err)
+			if err != nil {
+				log.Info("Failed to delete shard",
+					logger.Database(c.db),
+					logger.Shard(sh.ID),
+					logger.RetentionPolicy(c.rp),
+					zap.Error(err))
+				return true
+			}
+		}
+		log.Info("Evicted local shards of shard group",
+			logger.Database(c.db),
+			logger.ShardGroup(c.sgi.ID),
+			logger.RetentionPolicy(c.rp))
+
+		if usage, err = s.DiskUsage(path); err != nil {
+			log.Info("Failed to determine disk usage", zap.String("path", path), zap.Error(err))
+			return true
+		} else if usage < watermark {
+			return false
+		}
+	}
+
+	log.Error("Disk usage is above the watermark with no shard groups left to evict",
+		zap.String("path", path),
+		zap.Float64("usage", usage),
+		zap.Float64("watermark", watermark))
+	return false
+}
+
+// archiveShards writes a backup of the local shards of c to dir.
+func (s *Service) archiveShards(dir string, c eviction, local map[uint64]struct{}) error {
+	dir = filepath.Join(dir, c.db, c.rp)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+
+	for _, sh := range c.sgi.Shards {
+		if _, ok := local[sh.ID]; !ok {
+			continue
+		}
+
+		path := filepath.Join(dir, fmt.Sprintf("%d.tar", sh.ID))
+		f, err := os.Create(path + ".tmp")
+		if err != nil {
+			return err
+		}
+		if err := s.TSDBStore.BackupShard(sh.ID, time.Time{}, f); err != nil {
+			f.Close()
+			return err
+		} else if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		} else if err := f.Close(); err != nil {
+			return err
+		}
+		if err := os.Rename(path+".tmp", path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eviction is a shard group that can be evicted.
+type eviction struct {
+	db  string
+	rp  string
+	sgi meta.ShardGroupInfo
+}
+
+// planEvictions returns the shard groups of policies with shards in local,
+// oldest first. The groups that have not ended yet are never evicted, as
+// they still take writes, and neither are those of frozen databases.
+func planEvictions(dbs []meta.DatabaseInfo, policies []string, local map[uint64]struct{}, now time.Time) []eviction {
+	evictable := make(map[[2]string]struct{})
+	for _, p := range policies {
+		if db, rp, ok := splitPolicy(p); ok {
+			evictable[[2]string{db, rp}] = struct{}{}
+		}
+	}
+
+	var a []eviction
+	for _, d := range dbs {
+		if d.Frozen {
+			continue
+		}
+		for _, r := range d.RetentionPolicies {
+			if _, ok := evictable[[2]string{d.Name, r.Name}]; !ok {
+				continue
+			}
+			for _, sgi := range r.ShardGroups {
+				if sgi.Deleted() || sgi.EndTime.After(now) {
+					continue
+				}
+				for _, sh := range sgi.Shards {
+					if _, ok := local[sh.ID]; ok {
+						a = append(a, eviction{db: d.Name, rp: r.Name, sgi: sgi})
+						break
+					}
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(a, func(i, j int) bool {
+		if !a[i].sgi.EndTime.Equal(a[j].sgi.EndTime) {
+			return a[i].sgi.EndTime.Before(a[j].sgi.EndTime)
+		}
+		return a[i].sgi.ID < a[j].sgi.ID
+	})
+	return a
+}
+
+// splitPolicy splits a "database.retention-policy" policy name.
+func splitPolicy(s string) (db, rp string, ok bool) {
+	i := strings.Index(s, ".")
+	if i <= 0 || i == len(s)-1 {
+		return "", "", false
+	}
+	return s[:i], s[i+1:], true
+}
@@ -0,0 +1,159 @@
+package retention
+
+import (
+	"time"
+
+	"github.com/freetsdb/freetsdb/logger"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"go.uber.org/zap"
+)
+
+// mergeShardGroups merges the shards this node owns in the shard group merges
+// in progress, then starts merging the shard groups that are due. It returns
+// true if an error occurred.
+func (s *Service) mergeShardGroups(log *zap.Logger, after, duration time.Duration) (retryNeeded bool) {
+	nodeID := s.MetaClient.NodeID()
+	local := make(map[uint64]struct{})
+	for _, id := range s.TSDBStore.ShardIDs() {
+		local[id] = struct{}{}
+	}
+
+	dbs, _ := s.MetaClient.Databases()
+	for _, d := range dbs {
+		for i := range d.RetentionPolicies {
+			r := &d.RetentionPolicies[i]
+			for _, m := range r.ShardGroupMerges {
+				if err := s.mergeShards(d.Name, r, m, nodeID, local); err != nil {
+					log.Info("Failed to merge shard group",
+						logger.Database(d.Name),
+						logger.ShardGroup(m.Group.ID),
+						logger.RetentionPolicy(r.Name),
+						zap.Error(err))
+					retryNeeded = true
+				}
+			}
+
+			// Merges are started once the previous ones of the policy are
+			// done, so that a group is not merged twice.
+			if len(r.ShardGroupMerges) > 0 {
+				continue
+			}
+
+			for _, ids := range planShardGroupMerges(r, time.Now().UTC(), after, duration) {
+				if err := s.MetaClient.CreateShardGroupMerge(d.Name, r.Name, ids); err != nil {
+					log.Info("Failed to start merging shard groups",
+						logger.Database(d.Name),
+						logger.RetentionPolicy(r.Name),
+						zap.Uint64s("shard_groups", ids),
+						zap.Error(err))
+					retryNeeded = true
+					continue
+				}
+				log.Info("Started merging shard groups",
+					logger.Database(d.Name),
+					logger.RetentionPolicy(r.Name),
+					zap.Uint64s("shard_groups", ids))
+			}
+		}
+	}
+	return retryNeeded
+}
+
+// mergeShards copies the data of the local shards of the groups merged by m
+// into the shards of the merged group this node owns, then marks the merge as
+// completed by this node. The points writer writes the points of the groups
+// to the merged group too while the merge is in progress, so the points
+// written after the copy are not lost when the merged group replaces them.
+func (s *Service) mergeShards(database string, rpi *meta.RetentionPolicyInfo, m meta.ShardGroupMergeInfo, nodeID uint64, local map[uint64]struct{}) error {
+	for _, id := range m.Completed {
+		if id == nodeID {
+			return nil
+		}
+	}
+
+	var owned bool
+	for i, si := range m.Group.Shards {
+		if !si.OwnedBy(nodeID) {
+			continue
+		}
+		owned = true
+
+		// The shard may already hold the points written to the groups
+		// since the merge started, so it is not emptied. Copying again the
+		// points of an earlier attempt that failed part way through only
+		// overwrites them.
+		if err := s.TSDBStore.CreateShard(database, rpi.Name, si.ID, true); err != nil {
+			return err
+		}
+
+		for _, sgi := range rpi.ShardGroups {
+			if !containsID(m.Sources, sgi.ID) || i >= len(sgi.Shards) {
+				continue
+			}
+			// A shard that was never written to does not exist locally.
+			src := sgi.Shards[i].ID
+			if _, ok := local[src]; !ok {
+				continue
+			}
+			if err := s.TSDBStore.CopyShard(src, si.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !owned {
+		return nil
+	}
+	return s.MetaClient.CompleteShardGroupMerge(database, rpi.Name, m.Group.ID, nodeID)
+}
+
+// planShardGroupMerges returns the shard groups of rpi to merge into groups of
+// duration, as lists of shard group IDs. Only the groups that ended at least
+// after ago are merged, with the other groups of the same window of duration.
+func planShardGroupMerges(rpi *meta.RetentionPolicyInfo, now time.Time, after, duration time.Duration) [][]uint64 {
+	// Groups of different measurements are never merged together, so the
+	// windows are keyed by measurement as well.
+	type window struct {
+		measurement string
+		start       time.Time
+	}
+	windows := make(map[window][]uint64)
+	var order []window
+
+	cutoff := now.Add(-after)
+	for _, sgi := range rpi.ShardGroups {
+		if sgi.Deleted() || sgi.Truncated() || sgi.EndTime.After(cutoff) {
+			continue
+		}
+		if sgi.EndTime.Sub(sgi.StartTime) >= duration {
+			continue
+		}
+		start := sgi.StartTime.Truncate(duration)
+		if sgi.EndTime.After(start.Add(duration)) {
+			continue
+		}
+		w := window{measurement: sgi.Measurement, start: start}
+		if _, ok := windows[w]; !ok {
+			order = append(order, w)
+		}
+		windows[w] = append(windows[w], sgi.ID)
+	}
+
+	var plans [][]uint64
+	for _, w := range order {
+		ids := windows[w]
+		if len(ids) > 1 && rpi.ValidateShardGroupMerge(ids) == nil {
+			plans = append(plans, ids)
+		}
+	}
+	return plans
+}
+
+func containsID(ids []uint64, id uint64) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
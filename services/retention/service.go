@@ -2,38 +2,70 @@
 package retention // import "github.com/freetsdb/freetsdb/services/retention"
 
 import (
+	"fmt"
+	"io"
 	"sync"
 	"time"
 
 	"github.com/freetsdb/freetsdb/logger"
+	"github.com/freetsdb/freetsdb/services/audit"
 	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/toml"
 	"go.uber.org/zap"
 )
 
 // Service represents the retention policy enforcement service.
 type Service struct {
 	MetaClient interface {
+		NodeID() uint64
 		Databases() ([]meta.DatabaseInfo, error)
 		DeleteShardGroup(database, policy string, id uint64) error
+		RemoveShardOwner(id, nodeID uint64) error
 		PruneShardGroups() error
+		CreateShardGroupMerge(database, policy string, ids []uint64) error
+		CompleteShardGroupMerge(database, policy string, id, nodeID uint64) error
 	}
 	TSDBStore interface {
+		Path() string
 		ShardIDs() []uint64
+		CreateShard(database, retentionPolicy string, shardID uint64, enabled bool) error
+		CopyShard(srcID, dstID uint64) error
 		DeleteShard(shardID uint64) error
+		BackupShard(id uint64, since time.Time, w io.Writer) error
 	}
 
+	// ObjectStore removes the files of deleted shards from object storage,
+	// if set.
+	ObjectStore interface {
+		DeleteShard(database, rp string, id uint64) error
+	}
+
+	// DiskUsage returns the percentage of the disk holding path that is used.
+	DiskUsage func(path string) (float64, error)
+
+	// AuditLog records the shard groups expired and evicted, if set.
+	AuditLog interface {
+		Record(e audit.Event)
+	}
+
+	mu     sync.Mutex
 	config Config
 	wg     sync.WaitGroup
 	done   chan struct{}
 
+	// intervalChanged signals run to restart its ticker.
+	intervalChanged chan struct{}
+
 	logger *zap.Logger
 }
 
 // NewService returns a configured retention policy enforcement service.
 func NewService(c Config) *Service {
 	return &Service{
-		config: c,
-		logger: zap.NewNop(),
+		config:          c,
+		intervalChanged: make(chan struct{}, 1),
+		DiskUsage:       diskUsage,
+		logger:          zap.NewNop(),
 	}
 }
 
@@ -44,7 +76,7 @@ func (s *Service) Open() error {
 	}
 
 	s.logger.Info("Starting retention policy enforcement service",
-		logger.DurationLiteral("check_interval", time.Duration(s.config.CheckInterval)))
+		logger.DurationLiteral("check_interval", s.checkInterval()))
 	s.done = make(chan struct{})
 
 	s.wg.Add(1)
@@ -66,19 +98,60 @@ func (s *Service) Close() error {
 	return nil
 }
 
+// audit records a deletion of data in database in the audit log, if set.
+func (s *Service) audit(database, statement string, err error) {
+	if s.AuditLog == nil {
+		return
+	}
+
+	e := audit.Event{
+		Category:  audit.CategoryDelete,
+		Database:  database,
+		Statement: statement,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	s.AuditLog.Record(e)
+}
+
 // WithLogger sets the logger on the service.
 func (s *Service) WithLogger(log *zap.Logger) {
 	s.logger = log.With(zap.String("service", "retention"))
 }
 
+// SetCheckInterval changes how often retention policies are enforced.
+func (s *Service) SetCheckInterval(d time.Duration) {
+	s.mu.Lock()
+	s.config.CheckInterval = toml.Duration(d)
+	s.mu.Unlock()
+
+	select {
+	case s.intervalChanged <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Service) checkInterval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Duration(s.config.CheckInterval)
+}
+
 func (s *Service) run() {
-	ticker := time.NewTicker(time.Duration(s.config.CheckInterval))
-	defer ticker.Stop()
+	ticker := time.NewTicker(s.checkInterval())
+	defer func() { ticker.Stop() }()
 	for {
 		select {
 		case <-s.done:
 			return
 
+		case <-s.intervalChanged:
+			ticker.Stop()
+			ticker = time.NewTicker(s.checkInterval())
+			s.logger.Info("Changed retention policy check interval",
+				logger.DurationLiteral("check_interval", s.checkInterval()))
+
 		case <-ticker.C:
 			log, logEnd := logger.NewOperation(s.logger, "Retention policy deletion check", "retention_delete_check")
 
@@ -93,6 +166,20 @@ func (s *Service) run() {
 			// Without the message, they may see the error message and assume they
 			// have to do it manually.
 			var retryNeeded bool
+
+			// Shard groups replaced by merged groups are deleted below.
+			if s.config.MergeEnabled {
+				retryNeeded = s.mergeShardGroups(log, time.Duration(s.config.MergeAfter), time.Duration(s.config.MergeDuration))
+			}
+
+			// Evicted shard groups are deleted, and their local shards with
+			// them, right away to free the disk.
+			if s.config.DiskWatermark > 0 {
+				if s.evictShardGroups(log, float64(s.config.DiskWatermark), s.config.EvictPolicies, s.config.EvictArchiveDir) {
+					retryNeeded = true
+				}
+			}
+
 			dbs, _ := s.MetaClient.Databases()
 			for _, d := range dbs {
 				for _, r := range d.RetentionPolicies {
@@ -104,8 +191,14 @@ func (s *Service) run() {
 					}
 
 					// Determine all shards that have expired and need to be deleted.
+					// The data of frozen databases is kept.
+					if d.Frozen {
+						continue
+					}
 					for _, g := range r.ExpiredShardGroups(time.Now().UTC()) {
-						if err := s.MetaClient.DeleteShardGroup(d.Name, r.Name, g.ID); err != nil {
+						err := s.MetaClient.DeleteShardGroup(d.Name, r.Name, g.ID)
+						s.audit(d.Name, fmt.Sprintf("expire shard group %d of retention policy %q", g.ID, r.Name), err)
+						if err != nil {
 							log.Info("Failed to delete shard group",
 								logger.Database(d.Name),
 								logger.ShardGroup(g.ID),
@@ -147,6 +240,21 @@ func (s *Service) run() {
 				}
 			}
 
+			// The files of deleted shards are removed from object storage
+			// whether or not this node stores them.
+			if s.ObjectStore != nil {
+				for id, info := range deletedShardIDs {
+					if err := s.ObjectStore.DeleteShard(info.db, info.rp, id); err != nil {
+						log.Info("Failed to delete shard from object storage",
+							logger.Database(info.db),
+							logger.Shard(id),
+							logger.RetentionPolicy(info.rp),
+							zap.Error(err))
+						retryNeeded = true
+					}
+				}
+			}
+
 			if err := s.MetaClient.PruneShardGroups(); err != nil {
 				log.Info("Problem pruning shard groups", zap.Error(err))
 				retryNeeded = true
@@ -3,6 +3,10 @@ package retention_test
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sync"
 	"testing"
@@ -10,6 +14,7 @@ import (
 
 	"github.com/freetsdb/freetsdb/internal"
 	"github.com/freetsdb/freetsdb/logger"
+	"github.com/freetsdb/freetsdb/services/audit"
 	"github.com/freetsdb/freetsdb/services/meta"
 	"github.com/freetsdb/freetsdb/services/retention"
 	"github.com/freetsdb/freetsdb/toml"
@@ -162,6 +167,9 @@ func TestService_CheckShards(t *testing.T) {
 		return nil
 	}
 
+	auditLog := &AuditLog{}
+	s.AuditLog = auditLog
+
 	deletedShards := make(map[uint64]struct{})
 	s.TSDBStore.ShardIDsFn = func() []uint64 {
 		return []uint64{2, 3, 5, 6}
@@ -204,6 +212,246 @@ func TestService_CheckShards(t *testing.T) {
 	}; !reflect.DeepEqual(got, want) {
 		t.Errorf("unexpected deleted shards: got=%#v want=%#v", got, want)
 	}
+
+	if got, want := auditLog.Events(), []audit.Event{{
+		Category:  audit.CategoryDelete,
+		Database:  "db0",
+		Statement: `expire shard group 1 of retention policy "rp0"`,
+	}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected audit events: got=%#v want=%#v", got, want)
+	}
+}
+
+func TestService_MergeShardGroups(t *testing.T) {
+	day := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	owners := []meta.ShardOwner{{NodeID: 1}}
+	group := func(id uint64, start time.Time) meta.ShardGroupInfo {
+		return meta.ShardGroupInfo{
+			ID:        id,
+			StartTime: start,
+			EndTime:   start.Add(time.Hour),
+			Shards:    []meta.ShardInfo{{ID: id * 10, Owners: owners}},
+		}
+	}
+
+	data := []meta.DatabaseInfo{
+		{
+			Name: "db0",
+			RetentionPolicies: []meta.RetentionPolicyInfo{
+				{
+					// rp0 has a merge in progress.
+					Name:               "rp0",
+					ShardGroupDuration: time.Hour,
+					ShardGroups:        []meta.ShardGroupInfo{group(1, day), group(2, day.Add(time.Hour))},
+					ShardGroupMerges: []meta.ShardGroupMergeInfo{{
+						Sources: []uint64{1, 2},
+						Group: meta.ShardGroupInfo{
+							ID:        3,
+							StartTime: day,
+							EndTime:   day.Add(2 * time.Hour),
+							Shards:    []meta.ShardInfo{{ID: 30, Owners: owners}},
+						},
+					}},
+				},
+				{
+					// rp1 has the groups of two days to merge, and a group
+					// that is too recent to merge.
+					Name:               "rp1",
+					ShardGroupDuration: time.Hour,
+					ShardGroups: []meta.ShardGroupInfo{
+						group(4, day), group(5, day.Add(time.Hour)),
+						group(6, day.Add(24*time.Hour)), group(7, day.Add(25*time.Hour)), group(8, day.Add(26*time.Hour)),
+						group(9, time.Now().Truncate(time.Hour)),
+					},
+				},
+			},
+		},
+	}
+
+	config := retention.NewConfig()
+	config.CheckInterval = toml.Duration(10 * time.Millisecond)
+	config.MergeEnabled = true
+	config.MergeAfter = toml.Duration(time.Hour)
+	config.MergeDuration = toml.Duration(24 * time.Hour)
+	s := NewService(config)
+
+	var mu sync.Mutex
+	var copied [][2]uint64
+	var created [][]uint64
+	completed := make(chan struct{})
+	s.MetaClient.NodeIDFn = func() uint64 { return 1 }
+	s.MetaClient.DatabasesFn = func() ([]meta.DatabaseInfo, error) { return data, nil }
+	s.MetaClient.DeleteShardGroupFn = func(database, policy string, id uint64) error { return nil }
+	s.MetaClient.PruneShardGroupsFn = func() error { return nil }
+	s.MetaClient.CreateShardGroupMergeFn = func(database, policy string, ids []uint64) error {
+		mu.Lock()
+		defer mu.Unlock()
+		created = append(created, ids)
+		return nil
+	}
+	s.MetaClient.CompleteShardGroupMergeFn = func(database, policy string, id, nodeID uint64) error {
+		if database != "db0" || policy != "rp0" || id != 3 || nodeID != 1 {
+			t.Errorf("unexpected merge completed: %s.%s %d by %d", database, policy, id, nodeID)
+		}
+		select {
+		case <-completed:
+		default:
+			close(completed)
+		}
+		return nil
+	}
+	s.TSDBStore.ShardIDsFn = func() []uint64 { return []uint64{10, 20} }
+	s.TSDBStore.DeleteShardFn = func(id uint64) error { return nil }
+	s.TSDBStore.CreateShardFn = func(database, policy string, id uint64, enabled bool) error { return nil }
+	s.TSDBStore.CopyShardFn = func(srcID, dstID uint64) error {
+		mu.Lock()
+		defer mu.Unlock()
+		copied = append(copied, [2]uint64{srcID, dstID})
+		return nil
+	}
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-completed:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for the merge to complete")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if exp := [][2]uint64{{10, 30}, {20, 30}}; !reflect.DeepEqual(copied[:2], exp) {
+		t.Fatalf("unexpected shards copied: got=%v exp=%v", copied, exp)
+	}
+	if exp := [][]uint64{{4, 5}, {6, 7, 8}}; !reflect.DeepEqual(created[:2], exp) {
+		t.Fatalf("unexpected merges created: got=%v exp=%v", created, exp)
+	}
+}
+
+func TestService_EvictShardGroups(t *testing.T) {
+	day := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	group := func(id uint64, start time.Time) meta.ShardGroupInfo {
+		return meta.ShardGroupInfo{
+			ID:        id,
+			StartTime: start,
+			EndTime:   start.Add(time.Hour),
+			Shards:    []meta.ShardInfo{{ID: id * 10}, {ID: id*10 + 1}},
+		}
+	}
+
+	data := []meta.DatabaseInfo{
+		{
+			Name: "db0",
+			RetentionPolicies: []meta.RetentionPolicyInfo{
+				{
+					Name:        "rp0",
+					ShardGroups: []meta.ShardGroupInfo{group(1, day), group(2, day.Add(time.Hour)), group(3, time.Now())},
+				},
+				{
+					// rp1 is not evictable, even though its group is older.
+					Name:        "rp1",
+					ShardGroups: []meta.ShardGroupInfo{group(4, day.Add(-time.Hour))},
+				},
+			},
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "freetsdb-retention-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := retention.NewConfig()
+	config.CheckInterval = toml.Duration(10 * time.Millisecond)
+	config.DiskWatermark = 90
+	config.EvictPolicies = []string{"db0.rp0"}
+	config.EvictArchiveDir = dir
+	s := NewService(config)
+
+	var mu sync.Mutex
+	var removedOwners, deletedShards []uint64
+	usage := 95.0
+	evicted := make(chan struct{})
+	s.DiskUsage = func(path string) (float64, error) {
+		if path != "/data" {
+			t.Errorf("unexpected path: %s", path)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return usage, nil
+	}
+	s.MetaClient.NodeIDFn = func() uint64 { return 1 }
+	s.MetaClient.DatabasesFn = func() ([]meta.DatabaseInfo, error) { return data, nil }
+	s.MetaClient.DeleteShardGroupFn = func(database, policy string, id uint64) error {
+		t.Errorf("unexpected shard group deleted: %d", id)
+		return nil
+	}
+	s.MetaClient.RemoveShardOwnerFn = func(id, nodeID uint64) error {
+		if nodeID != 1 {
+			t.Errorf("unexpected node removed: %d", nodeID)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		removedOwners = append(removedOwners, id)
+		return nil
+	}
+	s.MetaClient.PruneShardGroupsFn = func() error {
+		select {
+		case <-evicted:
+		default:
+			close(evicted)
+		}
+		return nil
+	}
+	s.TSDBStore.PathFn = func() string { return "/data" }
+	s.TSDBStore.ShardIDsFn = func() []uint64 { return []uint64{10, 20, 30, 40} }
+	s.TSDBStore.DeleteShardFn = func(id uint64) error {
+		mu.Lock()
+		defer mu.Unlock()
+		deletedShards = append(deletedShards, id)
+		usage = 85
+		return nil
+	}
+	s.TSDBStore.BackupShardFn = func(id uint64, since time.Time, w io.Writer) error {
+		_, err := fmt.Fprintf(w, "shard %d", id)
+		return err
+	}
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-evicted:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for the eviction")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if exp := []uint64{10}; !reflect.DeepEqual(removedOwners, exp) {
+		t.Fatalf("unexpected shard owners removed: got=%v exp=%v", removedOwners, exp)
+	}
+	if exp := []uint64{10}; !reflect.DeepEqual(deletedShards, exp) {
+		t.Fatalf("unexpected shards deleted: got=%v exp=%v", deletedShards, exp)
+	}
+	if buf, err := ioutil.ReadFile(filepath.Join(dir, "db0", "rp0", "10.tar")); err != nil {
+		t.Fatal(err)
+	} else if string(buf) != "shard 10" {
+		t.Fatalf("unexpected archive: %q", buf)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "db0", "rp0", "11.tar")); !os.IsNotExist(err) {
+		t.Fatalf("expected shard not stored on the node not to be archived, got %v", err)
+	}
 }
 
 // This reproduces https://github.com/freetsdb/freetsdb/issues/8819
@@ -395,3 +643,21 @@ func NewService(c retention.Config) *Service {
 	s.Service.TSDBStore = s.TSDBStore
 	return s
 }
+
+// AuditLog records the audit events of the service.
+type AuditLog struct {
+	mu     sync.Mutex
+	events []audit.Event
+}
+
+func (l *AuditLog) Record(e audit.Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, e)
+}
+
+func (l *AuditLog) Events() []audit.Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]audit.Event(nil), l.events...)
+}
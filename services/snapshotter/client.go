@@ -13,6 +13,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/freetsdb/freetsdb/services/meta"
 	"github.com/freetsdb/freetsdb/tcp"
@@ -156,6 +157,50 @@ func (c *Client) UploadShard(shardID, newShardID uint64, destinationDatabase, re
 	return nil
 }
 
+// UploadSeriesFile restores the series file of database from a series file
+// backup read from r. The database must not be stored on the host yet.
+func (c *Client) UploadSeriesFile(database string, r io.Reader) error {
+	conn, err := tcp.Dial("tcp", c.host, MuxHeader)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	req := &Request{
+		Type:            RequestSeriesFileUpdate,
+		RestoreDatabase: database,
+	}
+	// The request is marshaled rather than encoded, as the encoder follows
+	// it with a newline the archive would start with.
+	b, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("encode series file request: %s", err)
+	}
+	if _, err := conn.Write([]byte{byte(req.Type)}); err != nil {
+		return err
+	} else if _, err := conn.Write(b); err != nil {
+		return err
+	} else if _, err := io.Copy(conn, r); err != nil {
+		return err
+	}
+
+	// The host reads the archive to its end before answering.
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		if err := cw.CloseWrite(); err != nil {
+			return err
+		}
+	}
+
+	// The host answers with the error restoring the series file, if any.
+	b, err = ioutil.ReadAll(conn)
+	if err != nil {
+		return err
+	} else if len(b) > 0 {
+		return errors.New(string(b))
+	}
+	return nil
+}
+
 // MetastoreBackup returns a snapshot of the meta store.
 func (c *Client) MetastoreBackup() (*meta.Data, error) {
 	req := &Request{
@@ -188,6 +233,102 @@ func (c *Client) MetastoreBackup() (*meta.Data, error) {
 	return &data, nil
 }
 
+// ShardBackup writes a backup of the files of a shard modified after since
+// to w. Nothing is written if the shard is not stored on the host.
+func (c *Client) ShardBackup(id uint64, since time.Time, w io.Writer) error {
+	req := &Request{
+		Type:    RequestShardBackup,
+		ShardID: id,
+		Since:   since,
+	}
+
+	conn, err := tcp.Dial("tcp", c.host, MuxHeader)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte{byte(req.Type)}); err != nil {
+		return err
+	}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return fmt.Errorf("encode snapshot request: %s", err)
+	}
+
+	_, err = io.Copy(w, conn)
+	return err
+}
+
+// ShardManifest returns the sizes of the data files of a shard by name.
+func (c *Client) ShardManifest(id uint64) (map[string]int64, error) {
+	b, err := c.doRequest(&Request{
+		Type:    RequestShardManifest,
+		ShardID: id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]int64
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("decode shard manifest: %s", err)
+	} else if m == nil {
+		return nil, fmt.Errorf("no manifest received for shard %d", id)
+	}
+	return m, nil
+}
+
+// ShardFilesBackup writes a backup of the data files of a shard named in
+// names to w. The backup is empty if a file is not part of the shard anymore.
+func (c *Client) ShardFilesBackup(id uint64, names []string, w io.Writer) error {
+	req := &Request{
+		Type:    RequestShardFilesBackup,
+		ShardID: id,
+		Files:   names,
+	}
+
+	conn, err := tcp.Dial("tcp", c.host, MuxHeader)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte{byte(req.Type)}); err != nil {
+		return err
+	}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return fmt.Errorf("encode snapshot request: %s", err)
+	}
+
+	_, err = io.Copy(w, conn)
+	return err
+}
+
+// SeriesFileBackup writes a backup of the series file of database to w.
+// Nothing is written if the database is not stored on the host.
+func (c *Client) SeriesFileBackup(database string, w io.Writer) error {
+	req := &Request{
+		Type:           RequestSeriesFileBackup,
+		BackupDatabase: database,
+	}
+
+	conn, err := tcp.Dial("tcp", c.host, MuxHeader)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte{byte(req.Type)}); err != nil {
+		return err
+	}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return fmt.Errorf("encode snapshot request: %s", err)
+	}
+
+	_, err = io.Copy(w, conn)
+	return err
+}
+
 // doRequest sends a request to the snapshotter service and returns the result.
 func (c *Client) doRequest(req *Request) ([]byte, error) {
 	// Connect to snapshotter service.
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"strings"
 	"sync"
@@ -47,6 +48,10 @@ type Service struct {
 		SetShardEnabled(shardID uint64, enabled bool) error
 		RestoreShard(id uint64, r io.Reader) error
 		CreateShard(database, retentionPolicy string, shardID uint64, enabled bool) error
+		BackupSeriesFile(database string, w io.Writer) error
+		RestoreSeriesFile(database string, r io.Reader) error
+		ShardManifest(id uint64) (map[string]int64, error)
+		BackupShardFiles(id uint64, names []string, w io.Writer) error
 	}
 
 	Listener net.Listener
@@ -123,6 +128,8 @@ func (s *Service) handleConn(conn net.Conn) error {
 
 	if RequestType(typ[0]) == RequestShardUpdate {
 		return s.updateShardsLive(conn)
+	} else if RequestType(typ[0]) == RequestSeriesFileUpdate {
+		return s.updateSeriesFile(conn)
 	}
 
 	r, bytes, err := s.readRequest(conn)
@@ -143,6 +150,16 @@ func (s *Service) handleConn(conn net.Conn) error {
 		if err := s.writeMetaStore(conn); err != nil {
 			return err
 		}
+	case RequestSeriesFileBackup:
+		if err := s.TSDBStore.BackupSeriesFile(r.BackupDatabase, conn); err != nil {
+			return err
+		}
+	case RequestShardManifest:
+		return s.writeShardManifest(conn, r.ShardID)
+	case RequestShardFilesBackup:
+		if err := s.TSDBStore.BackupShardFiles(r.ShardID, r.Files, conn); err != nil {
+			return err
+		}
 	case RequestDatabaseInfo:
 		return s.writeDatabaseInfo(conn, r.BackupDatabase)
 	case RequestRetentionPolicyInfo:
@@ -172,6 +189,35 @@ func (s *Service) updateShardsLive(conn net.Conn) error {
 	return s.TSDBStore.RestoreShard(sid, conn)
 }
 
+// updateSeriesFile restores the series file of a database from the archive
+// following the request, and answers with the error restoring it, if any.
+// The archive is read to its end either way.
+func (s *Service) updateSeriesFile(conn net.Conn) error {
+	var r Request
+	d := json.NewDecoder(conn)
+	if err := d.Decode(&r); err != nil {
+		return err
+	}
+
+	// A request encoded with a json.Encoder is followed by a newline, which
+	// is not part of the archive.
+	buffered, err := ioutil.ReadAll(d.Buffered())
+	if err != nil {
+		return err
+	}
+	archive := io.MultiReader(bytes.NewReader(bytes.TrimLeft(buffered, " \t\r\n")), conn)
+
+	err = s.TSDBStore.RestoreSeriesFile(r.RestoreDatabase, archive)
+	if _, e := io.Copy(ioutil.Discard, archive); e != nil {
+		return e
+	}
+	if err != nil {
+		_, err = conn.Write([]byte(err.Error()))
+		return err
+	}
+	return nil
+}
+
 func (s *Service) updateMetaStore(conn net.Conn, bits []byte, backupDBName, restoreDBName, backupRPName, restoreRPName string) error {
 	md := meta.Data{}
 	err := md.UnmarshalBinary(bits)
@@ -287,6 +333,19 @@ func (s *Service) writeMetaStore(conn net.Conn) error {
 	return nil
 }
 
+// writeShardManifest writes the sizes of the data files of a shard by name
+// into the connection. Nothing is written if the shard is not on this server.
+func (s *Service) writeShardManifest(conn net.Conn, id uint64) error {
+	m, err := s.TSDBStore.ShardManifest(id)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(conn).Encode(m); err != nil {
+		return fmt.Errorf("encode response: %s", err.Error())
+	}
+	return nil
+}
+
 // writeDatabaseInfo will write the relative paths of all shards in the database on
 // this server into the connection.
 func (s *Service) writeDatabaseInfo(conn net.Conn, database string) error {
@@ -440,6 +499,18 @@ const (
 	// RequestShardUpdate will initiate the upload of a shard data tar file
 	// and have the engine import the data.
 	RequestShardUpdate
+
+	// RequestSeriesFileUpdate will initiate the upload of a series file tar
+	// file for a database not stored on the server yet.
+	RequestSeriesFileUpdate
+
+	// RequestShardManifest represents a request for the sizes of the data
+	// files of a shard.
+	RequestShardManifest
+
+	// RequestShardFilesBackup represents a request for a backup of the data
+	// files of a shard named in the request.
+	RequestShardFilesBackup
 )
 
 // Request represents a request for a specific backup or for information
@@ -455,6 +526,7 @@ type Request struct {
 	ExportStart            time.Time
 	ExportEnd              time.Time
 	UploadSize             int64
+	Files                  []string `json:",omitempty"`
 }
 
 // Response contains the relative paths for all the shards on this server
@@ -1,13 +1,16 @@
 package snapshotter_test
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -379,6 +382,100 @@ func TestSnapshotter_RequestRetentionPolicyInfo(t *testing.T) {
 	}
 }
 
+func TestSnapshotter_RequestSeriesFileBackup(t *testing.T) {
+	s, l, err := NewTestService()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var tsdb internal.TSDBStoreMock
+	tsdb.BackupSeriesFileFn = func(database string, w io.Writer) error {
+		if database != "db0" {
+			t.Errorf("unexpected database: got=%q want=%q", database, "db0")
+		}
+		w.Write([]byte("series file"))
+		return nil
+	}
+	s.TSDBStore = &tsdb
+
+	if err := s.Open(); err != nil {
+		t.Fatalf("unexpected open error: %s", err)
+	}
+	defer s.Close()
+
+	var buf bytes.Buffer
+	c := snapshotter.NewClient(l.Addr().String())
+	if err := c.SeriesFileBackup("db0", &buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got, want := buf.String(), "series file"; got != want {
+		t.Errorf("unexpected series file data: got=%q want=%q", got, want)
+	}
+}
+
+func TestSnapshotter_RequestSeriesFileUpdate(t *testing.T) {
+	s, l, err := NewTestService()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var restoreErr error
+	var tsdb internal.TSDBStoreMock
+	tsdb.RestoreSeriesFileFn = func(database string, r io.Reader) error {
+		if database != "db1" {
+			t.Errorf("unexpected database: got=%q want=%q", database, "db1")
+		}
+		// Only read part of the archive on an error.
+		if restoreErr != nil {
+			r.Read(make([]byte, 1))
+			return restoreErr
+		}
+		if b, err := ioutil.ReadAll(r); err != nil {
+			t.Errorf("unexpected error reading archive: %s", err)
+		} else if got, want := string(b), "series file"; got != want {
+			t.Errorf("unexpected archive: got=%q want=%q", got, want)
+		}
+		return nil
+	}
+	s.TSDBStore = &tsdb
+
+	if err := s.Open(); err != nil {
+		t.Fatalf("unexpected open error: %s", err)
+	}
+	defer s.Close()
+
+	c := snapshotter.NewClient(l.Addr().String())
+	if err := c.UploadSeriesFile("db1", strings.NewReader("series file")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The newline following a request written with a json.Encoder is not
+	// part of the archive.
+	conn, err := tcp.Dial("tcp", l.Addr().String(), snapshotter.MuxHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	req := &snapshotter.Request{Type: snapshotter.RequestSeriesFileUpdate, RestoreDatabase: "db1"}
+	conn.Write([]byte{byte(req.Type)})
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(conn, "series file")
+	conn.(*net.TCPConn).CloseWrite()
+	if b, err := ioutil.ReadAll(conn); err != nil {
+		t.Fatal(err)
+	} else if len(b) > 0 {
+		t.Fatalf("unexpected error: %s", b)
+	}
+
+	restoreErr = errors.New("series file already exists")
+	if err := c.UploadSeriesFile("db1", strings.NewReader("series file")); err == nil || err.Error() != restoreErr.Error() {
+		t.Errorf("unexpected error: got=%v want=%v", err, restoreErr)
+	}
+}
+
 func TestSnapshotter_InvalidRequest(t *testing.T) {
 	s, l, err := NewTestService()
 	if err != nil {
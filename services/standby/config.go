@@ -0,0 +1,63 @@
+package standby
+
+import (
+	"errors"
+	"time"
+
+	"github.com/freetsdb/freetsdb/monitor/diagnostics"
+	"github.com/freetsdb/freetsdb/toml"
+)
+
+// DefaultSyncInterval is how often the backups of the primary are restored.
+const DefaultSyncInterval = time.Minute
+
+// Config represents the configuration for the standby service.
+type Config struct {
+	Enabled bool `toml:"enabled"`
+
+	// Primary is the bind address of the node whose backups are restored,
+	// such as "primary:8088".
+	Primary string `toml:"primary"`
+
+	// SyncInterval is how often the incremental backups of the primary are
+	// restored.
+	SyncInterval toml.Duration `toml:"sync-interval"`
+}
+
+// NewConfig returns an instance of Config with defaults.
+func NewConfig() Config {
+	return Config{
+		Enabled:      false,
+		SyncInterval: toml.Duration(DefaultSyncInterval),
+	}
+}
+
+// Validate returns an error if the Config is invalid.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.Primary == "" {
+		return errors.New("primary must be specified")
+	}
+	if c.SyncInterval <= 0 {
+		return errors.New("sync-interval must be positive")
+	}
+	return nil
+}
+
+// Diagnostics returns a diagnostics representation of a subset of the Config.
+func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
+	if !c.Enabled {
+		return diagnostics.RowFromMap(map[string]interface{}{
+			"enabled": false,
+		}), nil
+	}
+
+	return diagnostics.RowFromMap(map[string]interface{}{
+		"enabled":       true,
+		"primary":       c.Primary,
+		"sync-interval": c.SyncInterval,
+	}), nil
+}
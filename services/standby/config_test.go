@@ -0,0 +1,48 @@
+package standby_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/freetsdb/freetsdb/services/standby"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	var c standby.Config
+	if _, err := toml.Decode(`
+enabled = true
+primary = "primary:8088"
+sync-interval = "30s"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Validate configuration.
+	if !c.Enabled {
+		t.Fatalf("unexpected enabled state: %v", c.Enabled)
+	} else if c.Primary != "primary:8088" {
+		t.Fatalf("unexpected primary: %s", c.Primary)
+	} else if time.Duration(c.SyncInterval) != 30*time.Second {
+		t.Fatalf("unexpected sync interval: %s", c.SyncInterval)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := standby.NewConfig()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected validation fail from NewConfig: %s", err)
+	}
+
+	c.Enabled = true
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for empty primary, got nil")
+	}
+
+	c.Primary = "primary:8088"
+	c.SyncInterval = 0
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for non-positive sync-interval, got nil")
+	}
+}
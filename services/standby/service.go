@@ -0,0 +1,570 @@
+// Package standby keeps a node as a warm standby of a primary by continuously
+// restoring its incremental backups, until the node is promoted.
+package standby // import "github.com/freetsdb/freetsdb/services/standby"
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/freetsdb/freetsdb"
+	"github.com/freetsdb/freetsdb/logger"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/services/snapshotter"
+	"go.uber.org/zap"
+)
+
+// stateFile is the file of the data directory the state of the standby is
+// saved to, so that restores stay incremental and promotion is kept across
+// restarts.
+const stateFile = "standby.json"
+
+// ErrPromoted is returned when syncing a standby that was promoted.
+var ErrPromoted = errors.New("standby was promoted")
+
+// Status is the state of a standby.
+type Status struct {
+	Primary  string     `json:"primary"`
+	Promoted bool       `json:"promoted"`
+	LastSync *time.Time `json:"last_sync,omitempty"`
+	Err      string     `json:"error,omitempty"`
+
+	// Shards is the number of shards of the primary restored.
+	Shards int `json:"shards"`
+}
+
+// state is the state of a standby saved to disk.
+type state struct {
+	Promoted bool       `json:"promoted"`
+	LastSync *time.Time `json:"last_sync,omitempty"`
+
+	// Shards holds the sizes of the data files of each shard of the primary
+	// by name, as they were when the shard was last synced.
+	Shards map[uint64]map[string]int64 `json:"shards"`
+}
+
+// Service restores the meta data and shards of a primary node on this node,
+// which is read-only until promoted.
+//
+// Shards are synced by the names and sizes of their TSM and tombstone files
+// on the primary. The files added to a shard since it was last synced are
+// restored under their names, and a shard whose files were compacted or got
+// tombstones is restored in full, so that deletes are replayed. The shards,
+// retention policies and databases dropped on the primary are dropped too.
+type Service struct {
+	Node *freetsdb.Node
+
+	MetaClient interface {
+		Data() meta.Data
+		SetData(data *meta.Data) error
+		DataNode(id uint64) (*meta.NodeInfo, error)
+		SetDataNodeMode(id uint64, readOnly bool, reason string, maintenance bool) error
+	}
+
+	TSDBStore interface {
+		CreateShard(database, retentionPolicy string, shardID uint64, enabled bool) error
+		RestoreShard(id uint64, r io.Reader) error
+		DeleteShard(id uint64) error
+		DeleteRetentionPolicy(database, name string) error
+		DeleteDatabase(name string) error
+		ShardIDs() []uint64
+		Databases() []string
+	}
+
+	// Primary reads the backups of the primary.
+	Primary interface {
+		MetastoreBackup() (*meta.Data, error)
+		ShardManifest(id uint64) (map[string]int64, error)
+		ShardFilesBackup(id uint64, names []string, w io.Writer) error
+	}
+
+	config Config
+	Logger *zap.Logger
+
+	// syncMu is held while syncing, so a promotion waits for the current
+	// sync to finish.
+	syncMu sync.Mutex
+
+	mu      sync.RWMutex
+	state   state
+	lastErr error
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	return &Service{
+		Primary: snapshotter.NewClient(c.Primary),
+		config:  c,
+		state:   state{Shards: make(map[uint64]map[string]int64)},
+		Logger:  zap.NewNop(),
+	}
+}
+
+// WithLogger sets the logger on the service.
+func (s *Service) WithLogger(log *zap.Logger) {
+	s.Logger = log.With(zap.String("service", "standby"))
+}
+
+// Open loads the state of the standby and, unless it was promoted, makes the
+// node read-only and starts restoring the backups of the primary.
+func (s *Service) Open() error {
+	if !s.config.Enabled || s.done != nil {
+		return nil
+	}
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	if s.state.Promoted {
+		s.Logger.Info("Standby was promoted, not restoring from primary", zap.String("primary", s.config.Primary))
+		return nil
+	}
+	if err := s.setReadOnly(true); err != nil {
+		return err
+	}
+
+	s.Logger.Info("Starting standby service",
+		zap.String("primary", s.config.Primary),
+		logger.DurationLiteral("sync_interval", time.Duration(s.config.SyncInterval)))
+
+	s.done = make(chan struct{})
+	s.wg.Add(1)
+	go s.run()
+	return nil
+}
+
+// Close stops restoring the backups of the primary.
+func (s *Service) Close() error {
+	if s.done == nil {
+		return nil
+	}
+
+	close(s.done)
+	s.wg.Wait()
+	s.done = nil
+	return nil
+}
+
+// Status returns the state of the standby.
+func (s *Service) Status() Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st := Status{
+		Primary:  s.config.Primary,
+		Promoted: s.state.Promoted,
+		LastSync: s.state.LastSync,
+		Shards:   len(s.state.Shards),
+	}
+	if s.lastErr != nil {
+		st.Err = s.lastErr.Error()
+	}
+	return st
+}
+
+// Promote stops restoring the backups of the primary and makes the node
+// writable, once the sync in progress, if any, is done. Promoting a promoted
+// standby does nothing.
+func (s *Service) Promote() error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	if s.promoted() {
+		return nil
+	}
+	if err := s.setReadOnly(false); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.state.Promoted = true
+	err := s.save()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	s.Logger.Info("Promoted standby", zap.String("primary", s.config.Primary))
+	return nil
+}
+
+// run restores the backups of the primary every sync interval until the
+// service is closed or the standby promoted.
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(s.config.SyncInterval))
+	defer ticker.Stop()
+	for {
+		if err := s.Sync(); err == ErrPromoted {
+			return
+		} else if err != nil {
+			s.Logger.Info("Failed to restore from primary", zap.String("primary", s.config.Primary), zap.Error(err))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Sync restores the meta data of the primary, and the files of its shards
+// that changed since the previous sync.
+func (s *Service) Sync() error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	if s.promoted() {
+		return ErrPromoted
+	}
+
+	start := time.Now().UTC()
+	err := s.sync()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = err
+	if err == nil {
+		s.state.LastSync = &start
+	}
+	if serr := s.save(); err == nil {
+		err = serr
+	}
+	return err
+}
+
+func (s *Service) sync() error {
+	primary, err := s.Primary.MetastoreBackup()
+	if err != nil {
+		return err
+	}
+
+	local := s.MetaClient.Data()
+	data := mirror(local, *primary, s.Node.ID)
+	if changed, err := metaChanged(&local, data); err != nil {
+		return err
+	} else if changed {
+		if err := s.MetaClient.SetData(data); err != nil {
+			return err
+		}
+	}
+
+	if err := s.dropDeleted(&local, primary); err != nil {
+		return err
+	}
+
+	type shard struct {
+		database, retentionPolicy string
+		id                        uint64
+	}
+	var shards []shard
+	ids := make(map[uint64]struct{})
+	for _, di := range primary.Databases {
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() {
+					continue
+				}
+				for _, si := range sgi.Shards {
+					shards = append(shards, shard{di.Name, rpi.Name, si.ID})
+					ids[si.ID] = struct{}{}
+				}
+			}
+		}
+	}
+
+	// Drop the shards deleted from the primary.
+	for _, id := range s.TSDBStore.ShardIDs() {
+		if _, ok := ids[id]; ok {
+			continue
+		}
+		if err := s.TSDBStore.DeleteShard(id); err != nil {
+			return err
+		}
+		s.Logger.Info("Dropped shard deleted from primary", logger.Shard(id))
+	}
+	s.mu.Lock()
+	for id := range s.state.Shards {
+		if _, ok := ids[id]; !ok {
+			delete(s.state.Shards, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, sh := range shards {
+		if err := s.syncShard(sh.database, sh.retentionPolicy, sh.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropDeleted drops the databases and retention policies stored on this node
+// that were dropped from the primary. local is the meta data of the node
+// before the meta data of the primary was restored.
+func (s *Service) dropDeleted(local, primary *meta.Data) error {
+	for _, name := range s.TSDBStore.Databases() {
+		pdi := primary.Database(name)
+		if pdi == nil {
+			if err := s.TSDBStore.DeleteDatabase(name); err != nil {
+				return err
+			}
+			s.Logger.Info("Dropped database deleted from primary", logger.Database(name))
+			continue
+		}
+
+		ldi := local.Database(name)
+		if ldi == nil {
+			continue
+		}
+		for _, rpi := range ldi.RetentionPolicies {
+			if pdi.RetentionPolicy(rpi.Name) != nil {
+				continue
+			}
+			if err := s.TSDBStore.DeleteRetentionPolicy(name, rpi.Name); err != nil {
+				return err
+			}
+			s.Logger.Info("Dropped retention policy deleted from primary",
+				logger.Database(name),
+				logger.RetentionPolicy(rpi.Name))
+		}
+	}
+	return nil
+}
+
+// syncShard restores the files of a shard of the primary added since it was
+// last synced, or the whole shard if it was never synced or files were
+// compacted or deleted from since.
+func (s *Service) syncShard(database, retentionPolicy string, id uint64) error {
+	manifest, err := s.Primary.ShardManifest(id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	synced, ok := s.state.Shards[id]
+	s.mu.RUnlock()
+
+	// A shard that was never synced is restored in full, as it may hold files
+	// written before this node was a standby.
+	names, full := diffManifest(synced, manifest)
+	full = full || !ok
+	if len(names) == 0 && !full {
+		return nil
+	}
+
+	// Download the files before restoring them, so the shard is not locked
+	// or missing while they are transferred.
+	f, err := ioutil.TempFile("", "freetsdb-standby-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if len(names) > 0 {
+		if err := s.Primary.ShardFilesBackup(id, names, f); err != nil {
+			return err
+		} else if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		} else if err := checkBackup(f, names); err != nil {
+			return fmt.Errorf("shard %d: %s", id, err)
+		} else if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	if full {
+		if err := s.TSDBStore.DeleteShard(id); err != nil {
+			return err
+		}
+	}
+	if err := s.TSDBStore.CreateShard(database, retentionPolicy, id, true); err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		if err := s.TSDBStore.RestoreShard(id, f); err != nil {
+			return err
+		}
+	}
+	s.Logger.Info("Restored shard from primary",
+		logger.Database(database),
+		logger.RetentionPolicy(retentionPolicy),
+		logger.Shard(id),
+		zap.Int("files", len(names)),
+		zap.Bool("full", full))
+
+	s.mu.Lock()
+	s.state.Shards[id] = manifest
+	s.mu.Unlock()
+	return nil
+}
+
+// diffManifest returns the sorted names of the files of the manifest of a
+// shard on the primary that are not in the manifest it was last synced with.
+// If a file was removed, changed or got a tombstone since, full is true and
+// every file of the shard is returned.
+func diffManifest(synced, primary map[string]int64) (names []string, full bool) {
+	for name, size := range synced {
+		if n, ok := primary[name]; !ok || n != size {
+			full = true
+			break
+		}
+	}
+	for name := range primary {
+		if _, ok := synced[name]; ok {
+			continue
+		}
+		if strings.HasSuffix(name, ".tombstone") {
+			if _, ok := synced[strings.TrimSuffix(name, ".tombstone")+".tsm"]; ok {
+				full = true
+			}
+		}
+	}
+
+	for name := range primary {
+		if _, ok := synced[name]; full || !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, full
+}
+
+// checkBackup returns an error if a file named in names is not in the backup
+// read from r, as the primary leaves out the files compacted since.
+func checkBackup(r io.Reader, names []string) error {
+	files := make(map[string]struct{}, len(names))
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		files[path.Base(hdr.Name)] = struct{}{}
+	}
+
+	for _, name := range names {
+		if _, ok := files[name]; !ok {
+			return fmt.Errorf("file %s is not on the primary anymore", name)
+		}
+	}
+	return nil
+}
+
+// mirror returns the local meta data with the databases, users, roles and
+// templates of the primary. The shards of the primary are owned by the node
+// with nodeID.
+func mirror(local, primary meta.Data, nodeID uint64) *meta.Data {
+	data := local.Clone()
+	data.Databases = primary.Databases
+	data.Users = primary.Users
+	data.Roles = primary.Roles
+	data.DatabaseTemplates = primary.DatabaseTemplates
+
+	for i := range data.Databases {
+		for j := range data.Databases[i].RetentionPolicies {
+			rpi := &data.Databases[i].RetentionPolicies[j]
+			for k := range rpi.ShardGroups {
+				sgi := &rpi.ShardGroups[k]
+				for l := range sgi.Shards {
+					sgi.Shards[l].Owners = []meta.ShardOwner{{NodeID: nodeID}}
+				}
+			}
+		}
+	}
+
+	if primary.MaxShardGroupID > data.MaxShardGroupID {
+		data.MaxShardGroupID = primary.MaxShardGroupID
+	}
+	if primary.MaxShardID > data.MaxShardID {
+		data.MaxShardID = primary.MaxShardID
+	}
+	return data
+}
+
+// metaChanged returns true if the meta data a and b differ.
+func metaChanged(a, b *meta.Data) (bool, error) {
+	ab, err := a.MarshalBinary()
+	if err != nil {
+		return false, err
+	}
+	bb, err := b.MarshalBinary()
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(ab, bb), nil
+}
+
+// setReadOnly sets whether the node is read-only, keeping its maintenance
+// mode.
+func (s *Service) setReadOnly(readOnly bool) error {
+	ni, err := s.MetaClient.DataNode(s.Node.ID)
+	if err != nil {
+		return err
+	}
+	var reason string
+	if readOnly {
+		reason = "standby of " + s.config.Primary
+	}
+	return s.MetaClient.SetDataNodeMode(ni.ID, readOnly, reason, ni.Maintenance)
+}
+
+func (s *Service) promoted() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state.Promoted
+}
+
+// load reads the state of the standby.
+func (s *Service) load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state = state{Shards: make(map[uint64]map[string]int64)}
+	b, err := ioutil.ReadFile(filepath.Join(s.Node.Path, stateFile))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &s.state); err != nil {
+		return err
+	}
+	if s.state.Shards == nil {
+		s.state.Shards = make(map[uint64]map[string]int64)
+	}
+	return nil
+}
+
+// save writes the state of the standby. The caller must hold s.mu.
+func (s *Service) save() error {
+	b, err := json.Marshal(s.state)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(s.Node.Path, stateFile)
+	if err := ioutil.WriteFile(path+".tmp", b, 0666); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,102 @@
+package standby
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// Ensure the meta data of the primary is mirrored with its shards owned by
+// the standby.
+func TestMirror(t *testing.T) {
+	local := meta.Data{
+		Index:      4,
+		DataNodes:  []meta.NodeInfo{{ID: 2, Host: "standby:8088"}},
+		MaxShardID: 9,
+	}
+	primary := meta.Data{
+		DataNodes: []meta.NodeInfo{{ID: 1, Host: "primary:8088"}},
+		Databases: []meta.DatabaseInfo{{
+			Name: "db0",
+			RetentionPolicies: []meta.RetentionPolicyInfo{{
+				Name: "rp0",
+				ShardGroups: []meta.ShardGroupInfo{{
+					ID:     3,
+					Shards: []meta.ShardInfo{{ID: 5, Owners: []meta.ShardOwner{{NodeID: 1}}}},
+				}},
+			}},
+		}},
+		Users:           []meta.UserInfo{{Name: "admin", Admin: true}},
+		MaxShardGroupID: 3,
+		MaxShardID:      5,
+	}
+
+	data := mirror(local, primary, 2)
+	if len(data.DataNodes) != 1 || data.DataNodes[0].ID != 2 {
+		t.Fatalf("unexpected data nodes: %+v", data.DataNodes)
+	} else if len(data.Users) != 1 || data.Users[0].Name != "admin" {
+		t.Fatalf("unexpected users: %+v", data.Users)
+	} else if data.MaxShardGroupID != 3 || data.MaxShardID != 9 {
+		t.Fatalf("unexpected max IDs: %d, %d", data.MaxShardGroupID, data.MaxShardID)
+	}
+
+	owners := data.Databases[0].RetentionPolicies[0].ShardGroups[0].Shards[0].Owners
+	if len(owners) != 1 || owners[0].NodeID != 2 {
+		t.Fatalf("unexpected owners: %+v", owners)
+	}
+}
+
+// Ensure only the files added to a shard are restored, unless files were
+// compacted or got tombstones.
+func TestDiffManifest(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		synced  map[string]int64
+		primary map[string]int64
+		names   []string
+		full    bool
+	}{
+		{
+			name:    "unchanged",
+			synced:  map[string]int64{"000000001-000000001.tsm": 10},
+			primary: map[string]int64{"000000001-000000001.tsm": 10},
+		},
+		{
+			name:    "added",
+			synced:  map[string]int64{"000000001-000000001.tsm": 10},
+			primary: map[string]int64{"000000001-000000001.tsm": 10, "000000002-000000001.tsm": 20, "000000002-000000001.tombstone": 5},
+			names:   []string{"000000002-000000001.tombstone", "000000002-000000001.tsm"},
+		},
+		{
+			name:    "compacted",
+			synced:  map[string]int64{"000000001-000000001.tsm": 10, "000000002-000000001.tsm": 20},
+			primary: map[string]int64{"000000002-000000002.tsm": 25, "000000003-000000001.tsm": 5},
+			names:   []string{"000000002-000000002.tsm", "000000003-000000001.tsm"},
+			full:    true,
+		},
+		{
+			name:    "deleted",
+			synced:  map[string]int64{"000000001-000000001.tsm": 10},
+			primary: map[string]int64{"000000001-000000001.tsm": 10, "000000001-000000001.tombstone": 5},
+			names:   []string{"000000001-000000001.tombstone", "000000001-000000001.tsm"},
+			full:    true,
+		},
+		{
+			name:    "deleted again",
+			synced:  map[string]int64{"000000001-000000001.tsm": 10, "000000001-000000001.tombstone": 5},
+			primary: map[string]int64{"000000001-000000001.tsm": 10, "000000001-000000001.tombstone": 9},
+			names:   []string{"000000001-000000001.tombstone", "000000001-000000001.tsm"},
+			full:    true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			names, full := diffManifest(tt.synced, tt.primary)
+			if !reflect.DeepEqual(names, tt.names) {
+				t.Fatalf("unexpected names: got=%v want=%v", names, tt.names)
+			} else if full != tt.full {
+				t.Fatalf("unexpected full: got=%v want=%v", full, tt.full)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package standby_test
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/freetsdb/freetsdb"
+	"github.com/freetsdb/freetsdb/services/meta"
+	"github.com/freetsdb/freetsdb/services/standby"
+)
+
+// Ensure the files added to the shards of the primary are restored as they
+// are, and that compactions, deletes and drops are replayed.
+func TestService_Sync(t *testing.T) {
+	s, primary, store := NewService(t)
+	defer os.RemoveAll(s.Node.Path)
+
+	primary.files[2] = map[string]string{"000000001-000000001.tsm": "a"}
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	store.check(t, 2, "000000001-000000001.tsm")
+	if n := s.Status().Shards; n != 1 {
+		t.Fatalf("unexpected shards: %d", n)
+	}
+
+	// Only the new files are restored.
+	primary.files[2]["000000002-000000001.tsm"] = "b"
+	store.restored = nil
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	store.check(t, 2, "000000001-000000001.tsm", "000000002-000000001.tsm")
+	if got, want := store.restored, []string{"000000002-000000001.tsm"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected files restored: got=%v want=%v", got, want)
+	}
+
+	// Nothing is restored until the files change.
+	store.restored = nil
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	} else if len(store.restored) != 0 {
+		t.Fatalf("unexpected files restored: %v", store.restored)
+	}
+
+	// A compacted shard is restored in full.
+	primary.files[2] = map[string]string{"000000002-000000002.tsm": "ab"}
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	store.check(t, 2, "000000002-000000002.tsm")
+
+	// So is a shard points were deleted from.
+	primary.files[2]["000000002-000000002.tombstone"] = "a"
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	store.check(t, 2, "000000002-000000002.tombstone", "000000002-000000002.tsm")
+
+	// The shards, retention policies and databases dropped on the primary
+	// are dropped.
+	store.shards[7] = map[string]string{"000000001-000000001.tsm": "c"}
+	primary.data.Databases[0].RetentionPolicies[0].ShardGroups[0].DeletedAt = time.Now()
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	} else if len(store.shards) != 0 {
+		t.Fatalf("unexpected shards: %v", store.shards)
+	} else if n := s.Status().Shards; n != 0 {
+		t.Fatalf("unexpected shards: %d", n)
+	}
+
+	primary.data.Databases[0].RetentionPolicies = nil
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	} else if got, want := store.dropped, []string{"db0/rp0"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected drops: got=%v want=%v", got, want)
+	}
+
+	primary.data.Databases = nil
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	} else if got, want := store.dropped, []string{"db0/rp0", "db0"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected drops: got=%v want=%v", got, want)
+	}
+}
+
+// Ensure a shard is not marked as synced when files of the primary were
+// compacted while they were backed up.
+func TestService_Sync_Compacted(t *testing.T) {
+	s, primary, store := NewService(t)
+	defer os.RemoveAll(s.Node.Path)
+
+	primary.files[2] = map[string]string{"000000001-000000001.tsm": "a"}
+	primary.missing = "000000001-000000001.tsm"
+	if err := s.Sync(); err == nil {
+		t.Fatal("expected an error")
+	} else if n := s.Status().Shards; n != 0 {
+		t.Fatalf("unexpected shards: %d", n)
+	}
+
+	primary.missing = ""
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	store.check(t, 2, "000000001-000000001.tsm")
+}
+
+// Ensure a promoted standby is writable and stops syncing, across restarts.
+func TestService_Promote(t *testing.T) {
+	s, _, _ := NewService(t)
+	defer os.RemoveAll(s.Node.Path)
+	mc := s.MetaClient.(*MetaClient)
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	} else if !mc.readOnly {
+		t.Fatal("expected the standby to be read-only")
+	}
+
+	if err := s.Promote(); err != nil {
+		t.Fatal(err)
+	} else if mc.readOnly {
+		t.Fatal("expected the promoted standby to be writable")
+	} else if err := s.Sync(); err != standby.ErrPromoted {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The promotion is kept when the node restarts.
+	other, _, _ := NewService(t)
+	defer os.RemoveAll(other.Node.Path)
+	other.Node = s.Node
+	other.MetaClient = mc
+	if err := other.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if mc.readOnly {
+		t.Fatal("expected the promoted standby to stay writable")
+	} else if !other.Status().Promoted {
+		t.Fatal("expected the standby to be promoted")
+	}
+}
+
+// NewService returns a standby service syncing from a primary with shard 2
+// of db0.rp0 and storing its shards in memory.
+func NewService(t *testing.T) (*standby.Service, *Primary, *TSDBStore) {
+	dir, err := ioutil.TempDir("", "standby-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := standby.NewConfig()
+	c.Enabled = true
+	c.Primary = "primary:8088"
+
+	primary := &Primary{
+		data: meta.Data{
+			Databases: []meta.DatabaseInfo{{
+				Name: "db0",
+				RetentionPolicies: []meta.RetentionPolicyInfo{{
+					Name: "rp0",
+					ShardGroups: []meta.ShardGroupInfo{{
+						ID:     1,
+						Shards: []meta.ShardInfo{{ID: 2}},
+					}},
+				}},
+			}},
+		},
+		files: make(map[uint64]map[string]string),
+	}
+	store := &TSDBStore{shards: make(map[uint64]map[string]string)}
+
+	s := standby.NewService(c)
+	s.Node = freetsdb.NewNode(dir)
+	s.Node.ID = 1
+	s.MetaClient = &MetaClient{data: meta.Data{DataNodes: []meta.NodeInfo{{ID: 1}}}}
+	s.TSDBStore = store
+	s.Primary = primary
+	return s, primary, store
+}
+
+// Primary is the primary of a standby, holding the files of its shards.
+type Primary struct {
+	data  meta.Data
+	files map[uint64]map[string]string
+
+	// missing is left out of the backups, as if it was compacted.
+	missing string
+}
+
+func (p *Primary) MetastoreBackup() (*meta.Data, error) {
+	return p.data.Clone(), nil
+}
+
+func (p *Primary) ShardManifest(id uint64) (map[string]int64, error) {
+	m := make(map[string]int64)
+	for name, data := range p.files[id] {
+		m[name] = int64(len(data))
+	}
+	return m, nil
+}
+
+func (p *Primary) ShardFilesBackup(id uint64, names []string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	for _, name := range names {
+		data, ok := p.files[id][name]
+		if !ok || name == p.missing {
+			continue
+		}
+		hdr := &tar.Header{Name: fmt.Sprintf("db0/rp0/%d/%s", id, name), Mode: 0666, Size: int64(len(data))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		} else if _, err := tw.Write([]byte(data)); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// TSDBStore stores the files of the shards restored in memory.
+type TSDBStore struct {
+	shards   map[uint64]map[string]string
+	restored []string
+	dropped  []string
+}
+
+func (s *TSDBStore) CreateShard(database, retentionPolicy string, shardID uint64, enabled bool) error {
+	if _, ok := s.shards[shardID]; !ok {
+		s.shards[shardID] = make(map[string]string)
+	}
+	return nil
+}
+
+func (s *TSDBStore) RestoreShard(id uint64, r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		s.shards[id][path.Base(hdr.Name)] = string(data)
+		s.restored = append(s.restored, path.Base(hdr.Name))
+	}
+}
+
+func (s *TSDBStore) DeleteShard(id uint64) error {
+	delete(s.shards, id)
+	return nil
+}
+
+func (s *TSDBStore) DeleteRetentionPolicy(database, name string) error {
+	s.dropped = append(s.dropped, database+"/"+name)
+	return nil
+}
+
+func (s *TSDBStore) DeleteDatabase(name string) error {
+	s.dropped = append(s.dropped, name)
+	return nil
+}
+
+func (s *TSDBStore) ShardIDs() []uint64 {
+	var ids []uint64
+	for id := range s.shards {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (s *TSDBStore) Databases() []string {
+	for _, name := range s.dropped {
+		if name == "db0" {
+			return nil
+		}
+	}
+	return []string{"db0"}
+}
+
+// check fails the test unless the shard with id holds exactly names.
+func (s *TSDBStore) check(t *testing.T, id uint64, names ...string) {
+	t.Helper()
+	var got []string
+	for name := range s.shards[id] {
+		got = append(got, name)
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, names) {
+		t.Fatalf("unexpected files of shard %d: got=%v want=%v", id, got, names)
+	}
+}
+
+// MetaClient holds the meta data of the standby.
+type MetaClient struct {
+	data     meta.Data
+	readOnly bool
+}
+
+func (c *MetaClient) Data() meta.Data { return *c.data.Clone() }
+
+func (c *MetaClient) SetData(data *meta.Data) error {
+	c.data = *data.Clone()
+	return nil
+}
+
+func (c *MetaClient) DataNode(id uint64) (*meta.NodeInfo, error) {
+	return &meta.NodeInfo{ID: id}, nil
+}
+
+func (c *MetaClient) SetDataNodeMode(id uint64, readOnly bool, reason string, maintenance bool) error {
+	c.readOnly = readOnly
+	return nil
+}
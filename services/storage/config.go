@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/freetsdb/freetsdb/monitor/diagnostics"
+)
+
+const (
+	// DefaultBindAddress is the default address to bind the storage read
+	// service to. It is only reachable from the node itself.
+	DefaultBindAddress = "127.0.0.1:8082"
+
+	// DefaultCertificate is the default location of the TLS certificate.
+	DefaultCertificate = "/etc/ssl/freetsdb.pem"
+)
+
+// Config represents the configuration for the storage read service.
+//
+// The service reads every database without authorization, so its callers
+// are authenticated by their client certificate: an address reachable from
+// other hosts requires TLS with a client CA.
+type Config struct {
+	Enabled     bool   `toml:"enabled"`
+	BindAddress string `toml:"bind-address"`
+
+	TLSEnabled  bool   `toml:"tls-enabled"`
+	Certificate string `toml:"certificate"`
+	PrivateKey  string `toml:"private-key"`
+
+	// ClientCA is the PEM file of the CAs the client certificates of the
+	// callers must be signed by.
+	ClientCA string `toml:"client-ca"`
+
+	TLS *tls.Config `toml:"-"`
+}
+
+// NewConfig returns a new Config with defaults.
+func NewConfig() Config {
+	return Config{
+		BindAddress: DefaultBindAddress,
+		Certificate: DefaultCertificate,
+	}
+}
+
+// Validate returns an error if the Config is invalid.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.BindAddress == "" {
+		return errors.New("bind-address must be specified")
+	} else if c.TLSEnabled && c.Certificate == "" {
+		return errors.New("certificate must be specified when tls-enabled is set")
+	} else if c.ClientCA != "" && !c.TLSEnabled {
+		return errors.New("client-ca requires tls-enabled")
+	}
+
+	if !isLoopback(c.BindAddress) && c.ClientCA == "" {
+		return fmt.Errorf("bind-address %q is reachable from other hosts: set tls-enabled and client-ca to authenticate the callers", c.BindAddress)
+	}
+	return nil
+}
+
+// isLoopback returns true if addr only listens on the loopback interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	} else if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Diagnostics returns a diagnostics representation of a subset of the Config.
+func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
+	if !c.Enabled {
+		return diagnostics.RowFromMap(map[string]interface{}{
+			"enabled": false,
+		}), nil
+	}
+
+	return diagnostics.RowFromMap(map[string]interface{}{
+		"enabled":      true,
+		"bind-address": c.BindAddress,
+		"tls-enabled":  c.TLSEnabled,
+		"client-ca":    c.ClientCA,
+	}), nil
+}
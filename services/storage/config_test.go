@@ -0,0 +1,63 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/freetsdb/freetsdb/services/storage"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	c := storage.NewConfig()
+	if _, err := toml.Decode(`
+enabled = true
+bind-address = ":9000"
+tls-enabled = true
+certificate = "/etc/ssl/cert.pem"
+client-ca = "/etc/ssl/ca.pem"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Validate configuration.
+	if !c.Enabled {
+		t.Fatalf("unexpected enabled: %v", c.Enabled)
+	} else if c.BindAddress != ":9000" {
+		t.Fatalf("unexpected bind address: %s", c.BindAddress)
+	} else if !c.TLSEnabled {
+		t.Fatalf("unexpected tls-enabled: %v", c.TLSEnabled)
+	} else if c.Certificate != "/etc/ssl/cert.pem" {
+		t.Fatalf("unexpected certificate: %s", c.Certificate)
+	} else if c.ClientCA != "/etc/ssl/ca.pem" {
+		t.Fatalf("unexpected client-ca: %s", c.ClientCA)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %s", err)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		c    storage.Config
+		ok   bool
+	}{
+		{name: "default", c: storage.Config{Enabled: true, BindAddress: storage.DefaultBindAddress}, ok: true},
+		{name: "localhost", c: storage.Config{Enabled: true, BindAddress: "localhost:8082"}, ok: true},
+		{name: "ipv6 loopback", c: storage.Config{Enabled: true, BindAddress: "[::1]:8082"}, ok: true},
+		{name: "all interfaces", c: storage.Config{Enabled: true, BindAddress: ":8082"}},
+		{name: "remote without client ca", c: storage.Config{Enabled: true, BindAddress: "10.0.0.1:8082", TLSEnabled: true, Certificate: "cert.pem"}},
+		{name: "client ca without tls", c: storage.Config{Enabled: true, BindAddress: ":8082", ClientCA: "ca.pem"}},
+		{name: "remote with client ca", c: storage.Config{Enabled: true, BindAddress: ":8082", TLSEnabled: true, Certificate: "cert.pem", ClientCA: "ca.pem"}, ok: true},
+		{name: "disabled", c: storage.Config{BindAddress: ":8082"}, ok: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.Validate(); tt.ok && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if !tt.ok && err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
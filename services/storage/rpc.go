@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/freetsdb/freetsdb/platform/storage/reads"
+	"github.com/freetsdb/freetsdb/platform/storage/reads/datatypes"
+	"github.com/gogo/protobuf/types"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// rpcService implements the Storage gRPC service on top of a reads.Store.
+type rpcService struct {
+	Store  reads.Store
+	Logger *zap.Logger
+}
+
+// Capabilities returns the capabilities of the service. Predicates are
+// pushed down to the index, and so are projections, expressed as _field
+// predicates.
+func (r *rpcService) Capabilities(context.Context, *types.Empty) (*datatypes.CapabilitiesResponse, error) {
+	return &datatypes.CapabilitiesResponse{Caps: map[string]string{
+		"ReadFilter": "1",
+		"ReadGroup":  "1",
+		"Predicate":  "1",
+		"Projection": "_field",
+	}}, nil
+}
+
+func (r *rpcService) Hints(context.Context, *types.Empty) (*datatypes.HintsResponse, error) {
+	return &datatypes.HintsResponse{}, nil
+}
+
+// Read serves both kinds of reads, depending on the Group of req.
+func (r *rpcService) Read(req *datatypes.ReadRequest, stream datatypes.Storage_ReadServer) error {
+	if req.Group == datatypes.GroupAll {
+		return r.ReadFilter(req, stream)
+	}
+	return r.ReadGroup(req, stream)
+}
+
+func (r *rpcService) ReadFilter(req *datatypes.ReadRequest, stream datatypes.Storage_ReadFilterServer) error {
+	if len(req.GroupKeys) > 0 {
+		return status.Error(codes.InvalidArgument, "ReadFilter: group keys are not supported")
+	}
+
+	rs, err := r.Store.Read(stream.Context(), req)
+	if err != nil {
+		r.Logger.Info("Failed to read series", zap.Error(err))
+		return err
+	} else if rs == nil {
+		return nil
+	}
+	defer rs.Close()
+
+	w := reads.NewResponseWriter(stream, req.Hints)
+	if err := w.WriteResultSet(rs); err != nil {
+		return err
+	}
+	w.Flush()
+	if err := w.Err(); err != nil {
+		return err
+	}
+	return rs.Err()
+}
+
+func (r *rpcService) ReadGroup(req *datatypes.ReadRequest, stream datatypes.Storage_ReadGroupServer) error {
+	switch req.Group {
+	case datatypes.GroupNone, datatypes.GroupBy:
+	default:
+		return status.Errorf(codes.InvalidArgument, "ReadGroup: unsupported group %s", req.Group)
+	}
+
+	rs, err := r.Store.GroupRead(stream.Context(), req)
+	if err != nil {
+		r.Logger.Info("Failed to read groups", zap.Error(err))
+		return err
+	} else if rs == nil {
+		return nil
+	}
+	defer rs.Close()
+
+	w := reads.NewResponseWriter(stream, req.Hints)
+	if err := w.WriteGroupResultSet(rs); err != nil {
+		return err
+	}
+	w.Flush()
+	if err := w.Err(); err != nil {
+		return err
+	}
+	return rs.Err()
+}
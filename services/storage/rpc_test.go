@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/platform/query/functions/inputs/storage"
+	"github.com/freetsdb/freetsdb/platform/storage/reads"
+	"github.com/freetsdb/freetsdb/platform/storage/reads/datatypes"
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRPCService_Read(t *testing.T) {
+	var called string
+	r := &rpcService{
+		Store: &mockStore{
+			ReadFn: func(ctx context.Context, req *datatypes.ReadRequest) (reads.ResultSet, error) {
+				called = "Read"
+				return nil, nil
+			},
+			GroupReadFn: func(ctx context.Context, req *datatypes.ReadRequest) (reads.GroupResultSet, error) {
+				called = "GroupRead"
+				return nil, nil
+			},
+		},
+		Logger: zap.NewNop(),
+	}
+
+	for _, tt := range []struct {
+		group datatypes.ReadRequest_Group
+		exp   string
+	}{
+		{group: datatypes.GroupAll, exp: "Read"},
+		{group: datatypes.GroupNone, exp: "GroupRead"},
+		{group: datatypes.GroupBy, exp: "GroupRead"},
+	} {
+		called = ""
+		if err := r.Read(&datatypes.ReadRequest{Group: tt.group}, &mockStream{}); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.group, err)
+		} else if called != tt.exp {
+			t.Fatalf("%s: unexpected store call: got %q, exp %q", tt.group, called, tt.exp)
+		}
+	}
+
+	if err := r.ReadFilter(&datatypes.ReadRequest{GroupKeys: []string{"host"}}, &mockStream{}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.ReadGroup(&datatypes.ReadRequest{Group: datatypes.GroupAll}, &mockStream{}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type mockStore struct {
+	ReadFn      func(ctx context.Context, req *datatypes.ReadRequest) (reads.ResultSet, error)
+	GroupReadFn func(ctx context.Context, req *datatypes.ReadRequest) (reads.GroupResultSet, error)
+}
+
+func (s *mockStore) Read(ctx context.Context, req *datatypes.ReadRequest) (reads.ResultSet, error) {
+	return s.ReadFn(ctx, req)
+}
+
+func (s *mockStore) GroupRead(ctx context.Context, req *datatypes.ReadRequest) (reads.GroupResultSet, error) {
+	return s.GroupReadFn(ctx, req)
+}
+
+func (s *mockStore) GetSource(rs storage.ReadSpec) (proto.Message, error) {
+	return &ReadSource{Database: rs.Database, RetentionPolicy: rs.RetentionPolicy}, nil
+}
+
+// mockStream is a server stream that discards the responses.
+type mockStream struct {
+	grpc.ServerStream
+}
+
+func (s *mockStream) Context() context.Context           { return context.Background() }
+func (s *mockStream) Send(*datatypes.ReadResponse) error { return nil }
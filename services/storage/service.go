@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"sync"
+
+	"github.com/freetsdb/freetsdb/platform/storage/reads"
+	"github.com/freetsdb/freetsdb/platform/storage/reads/datatypes"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Service serves the storage read API over gRPC, so that external query
+// engines read series the same way as the flux runtime of this node.
+type Service struct {
+	ln     net.Listener
+	server *grpc.Server
+	config Config
+	mu     sync.Mutex
+
+	Store  reads.Store
+	Logger *zap.Logger
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	if c.TLS == nil {
+		c.TLS = new(tls.Config)
+	}
+	if c.PrivateKey == "" {
+		c.PrivateKey = c.Certificate
+	}
+	return &Service{
+		config: c,
+		Logger: zap.NewNop(),
+	}
+}
+
+// WithLogger sets the logger for the service.
+func (s *Service) WithLogger(log *zap.Logger) {
+	s.Logger = log.With(zap.String("service", "storage"))
+}
+
+// Open starts the service.
+func (s *Service) Open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.server != nil {
+		return nil // Already open.
+	}
+
+	var opts []grpc.ServerOption
+	if s.config.TLSEnabled {
+		tlsConfig, err := s.tlsConfig()
+		if err != nil {
+			return err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	ln, err := net.Listen("tcp", s.config.BindAddress)
+	if err != nil {
+		return err
+	}
+	s.ln = ln
+
+	s.server = grpc.NewServer(opts...)
+	datatypes.RegisterStorageServer(s.server, &rpcService{
+		Store:  s.Store,
+		Logger: s.Logger,
+	})
+
+	s.Logger.Info("Listening on storage RPC",
+		zap.Stringer("addr", s.ln.Addr()),
+		zap.Bool("tls", s.config.TLSEnabled),
+		zap.Bool("client-certificates", s.config.ClientCA != ""))
+
+	go s.server.Serve(s.ln)
+	return nil
+}
+
+// tlsConfig returns the TLS config of the server. Callers must present a
+// certificate signed by the client CA if one is set.
+func (s *Service) tlsConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(s.config.Certificate, s.config.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	config := s.config.TLS.Clone()
+	config.Certificates = []tls.Certificate{cert}
+	if s.config.ClientCA != "" {
+		b, err := ioutil.ReadFile(s.config.ClientCA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, errors.New("no certificates found in " + s.config.ClientCA)
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.ClientCAs = pool
+	}
+	return config, nil
+}
+
+// Close stops the service, once the reads in progress are done.
+func (s *Service) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.server == nil {
+		return nil // Already closed.
+	}
+	s.server.GracefulStop()
+	s.server, s.ln = nil, nil
+	return nil
+}
+
+// Addr returns the address the service listens on.
+func (s *Service) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ln == nil {
+		return nil
+	}
+	return s.ln.Addr()
+}
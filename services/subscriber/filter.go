@@ -0,0 +1,151 @@
+package subscriber
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// pointFilter selects the points sent to a subscription and transforms them.
+type pointFilter struct {
+	measurements map[string]struct{}
+	condition    influxql.Expr
+	stripTags    map[string]struct{}
+	every        time.Duration
+
+	mu sync.Mutex
+	// last holds the start of the interval of the last point sent for each
+	// series, and latest the start of the latest interval points were sent in.
+	last   map[string]int64
+	latest int64
+}
+
+// newPointFilter returns the filter of subscription si, or nil if si sends
+// all points unchanged.
+func newPointFilter(si meta.SubscriptionInfo) (*pointFilter, error) {
+	if len(si.Measurements) == 0 && si.Condition == "" && len(si.StripTags) == 0 && si.ResampleEvery <= 0 {
+		return nil, nil
+	}
+
+	f := &pointFilter{every: si.ResampleEvery}
+	if len(si.Measurements) > 0 {
+		f.measurements = make(map[string]struct{}, len(si.Measurements))
+		for _, name := range si.Measurements {
+			f.measurements[name] = struct{}{}
+		}
+	}
+	if si.Condition != "" {
+		expr, err := influxql.ParseExpr(si.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition: %s", err)
+		}
+		f.condition = expr
+	}
+	if len(si.StripTags) > 0 {
+		f.stripTags = make(map[string]struct{}, len(si.StripTags))
+		for _, key := range si.StripTags {
+			f.stripTags[key] = struct{}{}
+		}
+	}
+	if f.every > 0 {
+		f.last = make(map[string]int64)
+	}
+	return f, nil
+}
+
+// Filter returns the points to send of points. Points are not modified; the
+// points with stripped tags are copies.
+func (f *pointFilter) Filter(points []models.Point) []models.Point {
+	var kept []models.Point
+	for _, p := range points {
+		if !f.match(p) {
+			continue
+		}
+		if len(f.stripTags) > 0 {
+			sp, err := f.strip(p)
+			if err != nil {
+				continue
+			}
+			p = sp
+		}
+		if f.every > 0 && !f.resample(p) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
+
+// match returns true if p is of one of the measurements of the filter and
+// its tags match the condition. The measurement is available to the
+// condition as _name.
+func (f *pointFilter) match(p models.Point) bool {
+	if f.measurements != nil {
+		if _, ok := f.measurements[string(p.Name())]; !ok {
+			return false
+		}
+	}
+	if f.condition == nil {
+		return true
+	}
+
+	m := map[string]interface{}{"_name": string(p.Name())}
+	p.ForEachTag(func(k, v []byte) bool {
+		m[string(k)] = string(v)
+		return true
+	})
+	return influxql.EvalBool(f.condition, m)
+}
+
+// strip returns a copy of p without the stripped tags.
+func (f *pointFilter) strip(p models.Point) (models.Point, error) {
+	tags := p.Tags()
+	kept := make(models.Tags, 0, len(tags))
+	for _, t := range tags {
+		if _, ok := f.stripTags[string(t.Key)]; !ok {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == len(tags) {
+		return p, nil
+	}
+
+	fields, err := p.Fields()
+	if err != nil {
+		return nil, err
+	}
+	return models.NewPoint(string(p.Name()), kept, fields, p.Time())
+}
+
+// resample returns true if p is the first point of its series in its
+// interval, and so is sent. State is only kept for the series of the latest
+// two intervals, so points more than an interval older than the latest
+// points sent are dropped.
+func (f *pointFilter) resample(p models.Point) bool {
+	start := p.Time().Truncate(f.every).UnixNano()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if start > f.latest {
+		f.latest = start
+		for k, t := range f.last {
+			if t < start-int64(f.every) {
+				delete(f.last, k)
+			}
+		}
+	} else if start < f.latest-int64(f.every) {
+		return false
+	}
+
+	key := string(p.Key())
+	if t, ok := f.last[key]; ok && t >= start {
+		return false
+	}
+	f.last[key] = start
+	return true
+}
@@ -23,6 +23,8 @@ const (
 	statCreateFailures = "createFailures"
 	statPointsWritten  = "pointsWritten"
 	statWriteFailures  = "writeFailures"
+	statPointsFiltered = "pointsFiltered"
+	statPointsDropped  = "pointsDropped"
 )
 
 // PointsWriter is an interface for writing points to a subscription destination.
@@ -46,10 +48,12 @@ type Service struct {
 	MetaClient interface {
 		Databases() ([]meta.DatabaseInfo, error)
 		WaitForDataChanged() chan struct{}
+		SetSubscriptionPaused(database, rp, name string, paused bool) error
 	}
 	NewPointsWriter func(u url.URL) (PointsWriter, error)
 	Logger          *zap.Logger
 	update          chan struct{}
+	reconfigure     chan Config
 	stats           *Statistics
 	points          chan *coordinator.WritePointsRequest
 	wg              sync.WaitGroup
@@ -90,6 +94,7 @@ func (s *Service) Open() error {
 
 	s.closing = make(chan struct{})
 	s.update = make(chan struct{})
+	s.reconfigure = make(chan Config)
 	s.points = make(chan *coordinator.WritePointsRequest, 100)
 
 	s.wg.Add(2)
@@ -136,6 +141,8 @@ type Statistics struct {
 	CreateFailures int64
 	PointsWritten  int64
 	WriteFailures  int64
+	PointsFiltered int64
+	PointsDropped  int64
 }
 
 // Statistics returns statistics for periodic monitoring.
@@ -147,6 +154,8 @@ func (s *Service) Statistics(tags map[string]string) []models.Statistic {
 			statCreateFailures: atomic.LoadInt64(&s.stats.CreateFailures),
 			statPointsWritten:  atomic.LoadInt64(&s.stats.PointsWritten),
 			statWriteFailures:  atomic.LoadInt64(&s.stats.WriteFailures),
+			statPointsFiltered: atomic.LoadInt64(&s.stats.PointsFiltered),
+			statPointsDropped:  atomic.LoadInt64(&s.stats.PointsDropped),
 		},
 	}}
 
@@ -185,6 +194,31 @@ func (s *Service) Update() error {
 	}
 }
 
+// Reconfigure applies c to the service and recreates the writers of all
+// subscriptions with the new settings. Whether the service is enabled cannot
+// be changed.
+func (s *Service) Reconfigure(c Config) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subMu.Lock()
+	c.Enabled = s.conf.Enabled
+	if s.closed {
+		s.conf = c
+	}
+	s.subMu.Unlock()
+	if s.closed {
+		return nil
+	}
+
+	select {
+	case s.reconfigure <- c:
+		return nil
+	case <-s.closing:
+		return errors.New("service closed cannot reconfigure")
+	}
+}
+
 func (s *Service) createSubscription(se subEntry, mode string, destinations []string) (PointsWriter, error) {
 	var bm BalanceMode
 	switch mode {
@@ -239,6 +273,14 @@ func (s *Service) run() {
 		select {
 		case <-s.update:
 			s.updateSubs(&wg)
+		case c := <-s.reconfigure:
+			// Stop the writers using the old settings before recreating them.
+			s.close(&wg)
+			s.subMu.Lock()
+			s.conf = c
+			s.subMu.Unlock()
+			s.updateSubs(&wg)
+			s.Logger.Info("Reconfigured subscriptions")
 		case p, ok := <-s.points:
 			if !ok {
 				// Close out all chanWriters
@@ -247,10 +289,15 @@ func (s *Service) run() {
 			}
 			for se, cw := range s.subs {
 				if p.Database == se.db && p.RetentionPolicy == se.rp {
+					if cw.sub.isPaused() {
+						cw.sub.dropped(s.stats, len(p.Points))
+						continue
+					}
 					select {
 					case cw.writeRequests <- p:
 					default:
 						atomic.AddInt64(&s.stats.WriteFailures, 1)
+						cw.sub.dropped(s.stats, len(p.Points))
 					}
 				}
 			}
@@ -291,7 +338,14 @@ func (s *Service) updateSubs(wg *sync.WaitGroup) {
 					name: si.Name,
 				}
 				allEntries[se] = true
-				if _, ok := s.subs[se]; ok {
+				if cw, ok := s.subs[se]; ok {
+					cw.sub.setPaused(si.Paused)
+					continue
+				}
+				filter, err := newPointFilter(si)
+				if err != nil {
+					atomic.AddInt64(&s.stats.CreateFailures, 1)
+					s.Logger.Info("Subscription creation failed", zap.String("name", si.Name), zap.Error(err))
 					continue
 				}
 				sub, err := s.createSubscription(se, si.Mode, si.Destinations)
@@ -300,11 +354,14 @@ func (s *Service) updateSubs(wg *sync.WaitGroup) {
 					s.Logger.Info("Subscription creation failed", zap.String("name", si.Name), zap.Error(err))
 					continue
 				}
+				state := &subscription{info: si}
+				state.setPaused(si.Paused)
 				cw := chanWriter{
 					writeRequests: make(chan *coordinator.WritePointsRequest, s.conf.WriteBufferSize),
 					pw:            sub,
-					pointsWritten: &s.stats.PointsWritten,
-					failures:      &s.stats.WriteFailures,
+					filter:        filter,
+					sub:           state,
+					stats:         s.stats,
 					logger:        s.Logger,
 				}
 				for i := 0; i < s.conf.WriteConcurrency; i++ {
@@ -358,8 +415,9 @@ func (s *Service) newPointsWriter(u url.URL) (PointsWriter, error) {
 type chanWriter struct {
 	writeRequests chan *coordinator.WritePointsRequest
 	pw            PointsWriter
-	pointsWritten *int64
-	failures      *int64
+	filter        *pointFilter
+	sub           *subscription
+	stats         *Statistics
 	logger        *zap.Logger
 }
 
@@ -370,12 +428,28 @@ func (c chanWriter) Close() {
 
 func (c chanWriter) Run() {
 	for wr := range c.writeRequests {
+		if c.filter != nil {
+			points := c.filter.Filter(wr.Points)
+			if n := int64(len(wr.Points) - len(points)); n > 0 {
+				atomic.AddInt64(&c.stats.PointsFiltered, n)
+				atomic.AddInt64(&c.sub.pointsFiltered, n)
+			}
+			if len(points) == 0 {
+				continue
+			}
+			other := *wr
+			other.Points = points
+			wr = &other
+		}
+
 		err := c.pw.WritePoints(wr)
 		if err != nil {
 			c.logger.Info(err.Error())
-			atomic.AddInt64(c.failures, 1)
+			atomic.AddInt64(&c.stats.WriteFailures, 1)
+			atomic.AddInt64(&c.sub.writeFailures, 1)
 		} else {
-			atomic.AddInt64(c.pointsWritten, int64(len(wr.Points)))
+			atomic.AddInt64(&c.stats.PointsWritten, int64(len(wr.Points)))
+			atomic.AddInt64(&c.sub.pointsWritten, int64(len(wr.Points)))
 		}
 	}
 }
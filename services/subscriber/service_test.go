@@ -1,11 +1,13 @@
 package subscriber_test
 
 import (
+	"errors"
 	"net/url"
 	"testing"
 	"time"
 
 	"github.com/freetsdb/freetsdb/coordinator"
+	"github.com/freetsdb/freetsdb/models"
 	"github.com/freetsdb/freetsdb/services/meta"
 	"github.com/freetsdb/freetsdb/services/subscriber"
 )
@@ -13,8 +15,9 @@ import (
 const testTimeout = 10 * time.Second
 
 type MetaClient struct {
-	DatabasesFn          func() ([]meta.DatabaseInfo, error)
-	WaitForDataChangedFn func() chan struct{}
+	DatabasesFn             func() ([]meta.DatabaseInfo, error)
+	WaitForDataChangedFn    func() chan struct{}
+	SetSubscriptionPausedFn func(database, rp, name string, paused bool) error
 }
 
 func (m MetaClient) Databases() ([]meta.DatabaseInfo, error) {
@@ -25,6 +28,10 @@ func (m MetaClient) WaitForDataChanged() chan struct{} {
 	return m.WaitForDataChangedFn()
 }
 
+func (m MetaClient) SetSubscriptionPaused(database, rp, name string, paused bool) error {
+	return m.SetSubscriptionPausedFn(database, rp, name, paused)
+}
+
 type Subscription struct {
 	WritePointsFn func(*coordinator.WritePointsRequest) error
 }
@@ -268,6 +275,119 @@ func TestService_ModeANY(t *testing.T) {
 	close(dataChanged)
 }
 
+// Ensure a subscription sends only the points matching its filter,
+// transformed, and none while paused.
+func TestService_Filter(t *testing.T) {
+	dataChanged := make(chan struct{})
+	ms := MetaClient{}
+	ms.WaitForDataChangedFn = func() chan struct{} {
+		return dataChanged
+	}
+	ms.DatabasesFn = func() ([]meta.DatabaseInfo, error) {
+		return []meta.DatabaseInfo{
+			{
+				Name: "db0",
+				RetentionPolicies: []meta.RetentionPolicyInfo{
+					{
+						Name: "rp0",
+						Subscriptions: []meta.SubscriptionInfo{
+							{
+								Name:          "s0",
+								Mode:          "ALL",
+								Destinations:  []string{"udp://h0:9093"},
+								Measurements:  []string{"cpu"},
+								Condition:     "region = 'east'",
+								StripTags:     []string{"host"},
+								ResampleEvery: time.Minute,
+							},
+						},
+					},
+				},
+			},
+		}, nil
+	}
+
+	var paused []string
+	ms.SetSubscriptionPausedFn = func(database, rp, name string, p bool) error {
+		if name != "s0" {
+			return errors.New(meta.ErrSubscriptionNotFound.Error())
+		}
+		paused = append(paused, name)
+		return nil
+	}
+
+	prs := make(chan *coordinator.WritePointsRequest, 2)
+	newPointsWriter := func(u url.URL) (subscriber.PointsWriter, error) {
+		sub := Subscription{}
+		sub.WritePointsFn = func(p *coordinator.WritePointsRequest) error {
+			prs <- p
+			return nil
+		}
+		return sub, nil
+	}
+
+	s := subscriber.NewService(subscriber.NewConfig())
+	s.MetaClient = ms
+	s.NewPointsWriter = newPointsWriter
+	s.Open()
+	defer s.Close()
+	defer close(dataChanged)
+
+	points := func() []models.Point {
+		points, _ := models.ParsePointsString(`cpu,host=a,region=east value=1 60000000000
+cpu,host=b,region=east value=2 70000000000
+cpu,host=a,region=west value=3 60000000000
+mem,host=a,region=east value=4 60000000000`)
+		return points
+	}
+	s.Points() <- &coordinator.WritePointsRequest{Database: "db0", RetentionPolicy: "rp0", Points: points()}
+
+	select {
+	case pr := <-prs:
+		if len(pr.Points) != 1 {
+			t.Fatalf("unexpected points: %v", pr.Points)
+		} else if got, exp := pr.Points[0].String(), "cpu,region=east value=1 60000000000"; got != exp {
+			t.Fatalf("unexpected point: got %s, exp %s", got, exp)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("expected points request")
+	}
+
+	if err := s.SetSubscriptionPaused("db0", "rp0", "s0", true); err != nil {
+		t.Fatal(err)
+	} else if err := s.SetSubscriptionPaused("db0", "rp0", "s1", true); err != meta.ErrSubscriptionNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(paused) != 1 {
+		t.Fatalf("expected the meta data to be updated: %v", paused)
+	}
+	s.Points() <- &coordinator.WritePointsRequest{Database: "db0", RetentionPolicy: "rp0", Points: points()}
+
+	deadline := time.Now().Add(testTimeout)
+	for {
+		subs := s.Subscriptions()
+		if len(subs) != 1 {
+			t.Fatalf("unexpected subscriptions: %+v", subs)
+		} else if !subs[0].Paused {
+			t.Fatal("expected subscription to be paused")
+		}
+		if subs[0].PointsDropped == 4 {
+			if subs[0].PointsWritten != 1 || subs[0].PointsFiltered != 3 {
+				t.Fatalf("unexpected statistics: %+v", subs[0])
+			}
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("expected dropped points: %+v", subs[0])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case pr := <-prs:
+		t.Fatalf("unexpected points request %v", pr)
+	default:
+	}
+}
+
 func TestService_Multiple(t *testing.T) {
 	dataChanged := make(chan struct{})
 	ms := MetaClient{}
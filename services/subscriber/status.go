@@ -0,0 +1,143 @@
+package subscriber
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"github.com/freetsdb/freetsdb/services/meta"
+)
+
+// subscription is the delivery state of a subscription on this node.
+type subscription struct {
+	info   meta.SubscriptionInfo
+	paused int32
+
+	pointsWritten  int64
+	pointsFiltered int64
+	pointsDropped  int64
+	writeFailures  int64
+}
+
+func (s *subscription) isPaused() bool { return atomic.LoadInt32(&s.paused) == 1 }
+
+func (s *subscription) setPaused(paused bool) {
+	var v int32
+	if paused {
+		v = 1
+	}
+	atomic.StoreInt32(&s.paused, v)
+}
+
+// dropped counts n points that were not sent, because the subscription is
+// paused or its buffer is full.
+func (s *subscription) dropped(stats *Statistics, n int) {
+	atomic.AddInt64(&stats.PointsDropped, int64(n))
+	atomic.AddInt64(&s.pointsDropped, int64(n))
+}
+
+// SubscriptionStatus is the state and the delivery statistics of a
+// subscription on this node.
+type SubscriptionStatus struct {
+	Database        string `json:"database"`
+	RetentionPolicy string `json:"retention_policy"`
+	Name            string `json:"name"`
+	Mode            string `json:"mode"`
+
+	Measurements  []string `json:"measurements,omitempty"`
+	Condition     string   `json:"condition,omitempty"`
+	StripTags     []string `json:"strip_tags,omitempty"`
+	ResampleEvery string   `json:"resample_every,omitempty"`
+
+	// Paused subscriptions drop the points written to them.
+	Paused bool `json:"paused"`
+
+	// Queued is the number of write requests waiting to be sent.
+	Queued int `json:"queued"`
+
+	PointsWritten  int64 `json:"points_written"`
+	PointsFiltered int64 `json:"points_filtered"`
+	PointsDropped  int64 `json:"points_dropped"`
+	WriteFailures  int64 `json:"write_failures"`
+
+	Destinations []DestinationStatus `json:"destinations"`
+}
+
+// DestinationStatus holds the delivery statistics of a destination of a
+// subscription.
+type DestinationStatus struct {
+	URL           string `json:"url"`
+	PointsWritten int64  `json:"points_written"`
+	WriteFailures int64  `json:"write_failures"`
+}
+
+// Subscriptions returns the status of the subscriptions on this node,
+// ordered by database, retention policy and name.
+func (s *Service) Subscriptions() []SubscriptionStatus {
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+
+	a := make([]SubscriptionStatus, 0, len(s.subs))
+	for se, cw := range s.subs {
+		st := SubscriptionStatus{
+			Database:        se.db,
+			RetentionPolicy: se.rp,
+			Name:            se.name,
+			Mode:            cw.sub.info.Mode,
+			Measurements:    cw.sub.info.Measurements,
+			Condition:       cw.sub.info.Condition,
+			StripTags:       cw.sub.info.StripTags,
+			Paused:          cw.sub.isPaused(),
+			Queued:          len(cw.writeRequests),
+			PointsWritten:   atomic.LoadInt64(&cw.sub.pointsWritten),
+			PointsFiltered:  atomic.LoadInt64(&cw.sub.pointsFiltered),
+			PointsDropped:   atomic.LoadInt64(&cw.sub.pointsDropped),
+			WriteFailures:   atomic.LoadInt64(&cw.sub.writeFailures),
+		}
+		if d := cw.sub.info.ResampleEvery; d > 0 {
+			st.ResampleEvery = d.String()
+		}
+		if bw, ok := cw.pw.(*balancewriter); ok {
+			for i := range bw.stats {
+				st.Destinations = append(st.Destinations, DestinationStatus{
+					URL:           bw.stats[i].dest,
+					PointsWritten: atomic.LoadInt64(&bw.stats[i].pointsWritten),
+					WriteFailures: atomic.LoadInt64(&bw.stats[i].failures),
+				})
+			}
+		}
+		a = append(a, st)
+	}
+
+	sort.Slice(a, func(i, j int) bool {
+		if a[i].Database != a[j].Database {
+			return a[i].Database < a[j].Database
+		} else if a[i].RetentionPolicy != a[j].RetentionPolicy {
+			return a[i].RetentionPolicy < a[j].RetentionPolicy
+		}
+		return a[i].Name < a[j].Name
+	})
+	return a
+}
+
+// SetSubscriptionPaused pauses or resumes a subscription. The points written
+// to a paused subscription are dropped, not queued. The state is kept in the
+// meta data, so the subscription is paused on every node, and stays paused
+// across restarts until resumed or dropped.
+func (s *Service) SetSubscriptionPaused(database, rp, name string, paused bool) error {
+	if err := s.MetaClient.SetSubscriptionPaused(database, rp, name, paused); err != nil {
+		// The meta service returns its errors as text.
+		if err.Error() == meta.ErrSubscriptionNotFound.Error() {
+			return meta.ErrSubscriptionNotFound
+		}
+		return err
+	}
+
+	// Apply the state on this node now rather than when the meta data
+	// change is seen.
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+	if cw, ok := s.subs[subEntry{db: database, rp: rp, name: name}]; ok {
+		cw.sub.setPaused(paused)
+	}
+	return nil
+}
@@ -4,6 +4,9 @@ import (
 	"time"
 
 	"github.com/freetsdb/freetsdb/monitor/diagnostics"
+	"github.com/freetsdb/freetsdb/pkg/netutil"
+	"github.com/freetsdb/freetsdb/pkg/relabel"
+	"github.com/freetsdb/freetsdb/pkg/routing"
 	"github.com/freetsdb/freetsdb/toml"
 )
 
@@ -48,13 +51,15 @@ type Config struct {
 	Enabled     bool   `toml:"enabled"`
 	BindAddress string `toml:"bind-address"`
 
-	Database        string        `toml:"database"`
-	RetentionPolicy string        `toml:"retention-policy"`
-	BatchSize       int           `toml:"batch-size"`
-	BatchPending    int           `toml:"batch-pending"`
-	ReadBuffer      int           `toml:"read-buffer"`
-	BatchTimeout    toml.Duration `toml:"batch-timeout"`
-	Precision       string        `toml:"precision"`
+	Database        string         `toml:"database"`
+	RetentionPolicy string         `toml:"retention-policy"`
+	BatchSize       int            `toml:"batch-size"`
+	BatchPending    int            `toml:"batch-pending"`
+	ReadBuffer      int            `toml:"read-buffer"`
+	BatchTimeout    toml.Duration  `toml:"batch-timeout"`
+	Precision       string         `toml:"precision"`
+	Relabel         []relabel.Rule `toml:"relabel"`
+	Routes          []routing.Rule `toml:"route"`
 }
 
 // NewConfig returns a new instance of Config with defaults.
@@ -94,6 +99,19 @@ func (c *Config) WithDefaults() *Config {
 	return &d
 }
 
+// Validate returns an error if the Config is invalid.
+func (c *Config) Validate() error {
+	if c.BindAddress != "" {
+		if err := netutil.ValidateBindAddress(c.BindAddress); err != nil {
+			return err
+		}
+	}
+	if err := relabel.Validate(c.Relabel); err != nil {
+		return err
+	}
+	return routing.Validate(c.Routes)
+}
+
 // Configs wraps a slice of Config to aggregate diagnostics.
 type Configs []Config
 
@@ -11,6 +11,8 @@ import (
 	"github.com/freetsdb/freetsdb/coordinator"
 	"github.com/freetsdb/freetsdb/logger"
 	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/pkg/relabel"
+	"github.com/freetsdb/freetsdb/pkg/routing"
 	"github.com/freetsdb/freetsdb/services/meta"
 	"github.com/freetsdb/freetsdb/tsdb"
 	"go.uber.org/zap"
@@ -47,6 +49,8 @@ type Service struct {
 
 	parserChan chan []byte
 	batcher    *tsdb.PointBatcher
+	relabeler  *relabel.Relabeler
+	router     *routing.Router
 	config     Config
 
 	PointsWriter interface {
@@ -65,13 +69,32 @@ type Service struct {
 // NewService returns a new instance of Service.
 func NewService(c Config) *Service {
 	d := *c.WithDefaults()
-	return &Service{
+	s := &Service{
 		config:      d,
 		parserChan:  make(chan []byte, parserChanLen),
+		relabeler:   &relabel.Relabeler{},
+		router:      &routing.Router{},
 		Logger:      zap.NewNop(),
 		stats:       &Statistics{},
 		defaultTags: models.StatisticTags{"bind": d.BindAddress},
 	}
+
+	// The relabeling and routing rules are checked by Config.Validate.
+	s.relabeler.SetRules(d.Relabel)
+	s.router.SetRules(d.Routes)
+	return s
+}
+
+// SetRelabelRules replaces the relabeling rules applied to the points before
+// they are written. The current rules are kept if rules are invalid.
+func (s *Service) SetRelabelRules(rules []relabel.Rule) error {
+	return s.relabeler.SetRules(rules)
+}
+
+// SetRoutes replaces the rules routing points to other databases. The current
+// rules are kept if rules are invalid.
+func (s *Service) SetRoutes(rules []routing.Rule) error {
+	return s.router.SetRules(rules)
 }
 
 // Open starts the service.
@@ -167,13 +190,22 @@ func (s *Service) writer() {
 				continue
 			}
 
-			if err := s.PointsWriter.WritePointsPrivileged(s.config.Database, s.config.RetentionPolicy, coordinator.ConsistencyLevelAny, batch); err == nil {
-				atomic.AddInt64(&s.stats.BatchesTransmitted, 1)
-				atomic.AddInt64(&s.stats.PointsTransmitted, int64(len(batch)))
-			} else {
-				s.Logger.Info("Failed to write point batch to database",
-					logger.Database(s.config.Database), zap.Error(err))
-				atomic.AddInt64(&s.stats.BatchesTransmitFail, 1)
+			if batch = s.relabeler.Apply(batch); len(batch) == 0 {
+				continue
+			}
+
+			// Points routed to other databases are written to them as
+			// separate batches.
+			dest := routing.Destination{Database: s.config.Database, RetentionPolicy: s.config.RetentionPolicy}
+			for _, b := range s.router.Route(dest, batch) {
+				if err := s.PointsWriter.WritePointsPrivileged(b.Database, b.RetentionPolicy, coordinator.ConsistencyLevelAny, b.Points); err == nil {
+					atomic.AddInt64(&s.stats.BatchesTransmitted, 1)
+					atomic.AddInt64(&s.stats.PointsTransmitted, int64(len(b.Points)))
+				} else {
+					s.Logger.Info("Failed to write point batch to database",
+						logger.Database(b.Database), zap.Error(err))
+					atomic.AddInt64(&s.stats.BatchesTransmitFail, 1)
+				}
 			}
 
 		case <-s.done:
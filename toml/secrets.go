@@ -0,0 +1,128 @@
+package toml
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// fileRefPrefix marks a value that is read from a file.
+const fileRefPrefix = "file://"
+
+// envRefRegex matches a reference to an environment variable.
+var envRefRegex = regexp.MustCompile(`\$ENV\{([^}]*)\}`)
+
+// ResolveSecrets replaces references to secrets in the string fields of val
+// tagged `secret:"true"`, so that sensitive values do not need to be stored in
+// the configuration file. Other fields are left as they are, since a value
+// such as a "file:///path" URL is not a reference.
+//
+// Every "$ENV{NAME}" in a value is replaced by the environment variable NAME
+// as returned by getenv. A value of the form "file:///path/to/secret" is
+// replaced by the contents of the file, without trailing newlines. The path
+// must be absolute and may itself contain environment variable references.
+// A reference to an unset variable or an unreadable file is an error.
+func ResolveSecrets(getenv func(string) string, val interface{}) error {
+	return resolveSecrets(getenv, reflect.ValueOf(val), "", false)
+}
+
+// IsSecret returns true if the struct field holds a secret.
+func IsSecret(field reflect.StructField) bool {
+	return field.Tag.Get("secret") == "true"
+}
+
+func resolveSecrets(getenv func(string) string, spec reflect.Value, key string, secret bool) error {
+	switch spec.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if spec.IsNil() {
+			return nil
+		}
+		return resolveSecrets(getenv, spec.Elem(), key, secret)
+	case reflect.String:
+		if !secret || !spec.CanSet() {
+			return nil
+		}
+		value, err := resolveSecret(getenv, spec.String())
+		if err != nil {
+			return fmt.Errorf("%s: %s", key, err)
+		}
+		spec.SetString(value)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < spec.Len(); i++ {
+			if err := resolveSecrets(getenv, spec.Index(i), fmt.Sprintf("%s[%d]", key, i), secret); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		typeOfSpec := spec.Type()
+		for i := 0; i < spec.NumField(); i++ {
+			structField := typeOfSpec.Field(i)
+			if structField.PkgPath != "" {
+				// Skip unexported fields.
+				continue
+			}
+
+			configName := structField.Tag.Get("toml")
+			if i := strings.IndexByte(configName, ','); i >= 0 {
+				configName = configName[:i]
+			}
+			if configName == "-" {
+				continue
+			}
+
+			fieldKey := key
+			if configName != "" || !structField.Anonymous {
+				// Embedded fields without a toml tag share the parent's key.
+				if configName == "" {
+					configName = structField.Name
+				}
+				if fieldKey != "" {
+					fieldKey += "."
+				}
+				fieldKey += configName
+			}
+
+			if err := resolveSecrets(getenv, spec.Field(i), fieldKey, secret || IsSecret(structField)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveSecret returns s with its secret references replaced.
+func resolveSecret(getenv func(string) string, s string) (string, error) {
+	var err error
+	s = envRefRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		name := envRefRegex.FindStringSubmatch(ref)[1]
+		value := getenv(name)
+		if value == "" && err == nil {
+			if name == "" {
+				err = fmt.Errorf("%s is missing a variable name", ref)
+			} else {
+				err = fmt.Errorf("environment variable %s is not set", name)
+			}
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(s, fileRefPrefix) {
+		return s, nil
+	}
+
+	path := strings.TrimPrefix(s, fileRefPrefix)
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("secret file %q must be an absolute path, e.g. \"file:///run/secrets/name\"", path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read secret file: %s", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,119 @@
+//go:build !windows
+// +build !windows
+
+package toml_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	itoml "github.com/freetsdb/freetsdb/toml"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	secretPath := filepath.Join(dir, "shared-secret")
+	if err := ioutil.WriteFile(secretPath, []byte("file secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	env := func(s string) string {
+		return map[string]string{
+			"USER":        "admin",
+			"PASSWORD":    "env secret",
+			"SECRETS_DIR": dir,
+		}[s]
+	}
+
+	type nested struct {
+		Password string `toml:"password" secret:"true"`
+		Name     string `toml:"name"`
+	}
+	type Embedded struct {
+		ES string `toml:"es" secret:"true"`
+	}
+	type all struct {
+		Plain    string   `toml:"plain"`
+		PlainURL string   `toml:"plain-url"`
+		PlainEnv string   `toml:"plain-env"`
+		URL      string   `toml:"url" secret:"true"`
+		Secret   string   `toml:"secret" secret:"true"`
+		FromEnv  string   `toml:"from-env" secret:"true"`
+		Nested   *nested  `toml:"nested"`
+		Nil      *nested  `toml:"nil"`
+		Slice    []nested `toml:"slice"`
+		Strings  []string `toml:"strings" secret:"true"`
+
+		Embedded
+	}
+
+	got := all{
+		Plain:    "/etc/ssl/server.pem",
+		PlainURL: "file:///var/lib/freetsdb/objects",
+		PlainEnv: "$ENV{USER}",
+		URL:      "https://$ENV{USER}@example.com",
+		Secret:   "file://" + secretPath,
+		FromEnv:  "file://$ENV{SECRETS_DIR}/shared-secret",
+		Nested:   &nested{Password: "$ENV{PASSWORD}", Name: "$ENV{USER}"},
+		Slice:    []nested{{Password: "plain"}, {Password: "$ENV{PASSWORD}"}},
+		Strings:  []string{"$ENV{USER}"},
+		Embedded: Embedded{
+			ES: "$ENV{USER}",
+		},
+	}
+	if err := itoml.ResolveSecrets(env, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := all{
+		Plain:    "/etc/ssl/server.pem",
+		PlainURL: "file:///var/lib/freetsdb/objects",
+		PlainEnv: "$ENV{USER}",
+		URL:      "https://admin@example.com",
+		Secret:   "file secret",
+		FromEnv:  "file secret",
+		Nested:   &nested{Password: "env secret", Name: "$ENV{USER}"},
+		Slice:    []nested{{Password: "plain"}, {Password: "env secret"}},
+		Strings:  []string{"admin"},
+		Embedded: Embedded{
+			ES: "admin",
+		},
+	}
+	if diff := cmp.Diff(got, exp); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestResolveSecrets_Errors(t *testing.T) {
+	type nested struct {
+		Password string `toml:"password" secret:"true"`
+	}
+	type config struct {
+		Nested []nested `toml:"nested"`
+	}
+
+	for _, tt := range []struct {
+		value string
+		err   string
+	}{
+		{value: "$ENV{UNSET}", err: "nested[0].password: environment variable UNSET is not set"},
+		{value: "$ENV{}", err: "$ENV{} is missing a variable name"},
+		{value: "file://secret", err: `secret file "secret" must be an absolute path`},
+		{value: "file:///no/such/secret", err: "read secret file"},
+	} {
+		c := config{Nested: []nested{{Password: tt.value}}}
+		err := itoml.ResolveSecrets(func(string) string { return "" }, &c)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: unexpected error: got %v, exp %q", tt.value, err, tt.err)
+		}
+	}
+}
@@ -3,8 +3,10 @@ package tsdb
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"time"
 
+	"github.com/freetsdb/freetsdb/models"
 	"github.com/freetsdb/freetsdb/monitor/diagnostics"
 	"github.com/freetsdb/freetsdb/toml"
 )
@@ -69,6 +71,20 @@ const (
 
 	// DefaultSeriesIDSetCacheSize is the default number of series ID sets to cache in the TSI index.
 	DefaultSeriesIDSetCacheSize = 100
+
+	// TSMReadModeMmap reads TSM files through memory maps.
+	TSMReadModeMmap = "mmap"
+
+	// TSMReadModePread reads TSM files with positioned reads, caching the
+	// blocks read in memory.
+	TSMReadModePread = "pread"
+
+	// DefaultTSMReadMode is the default way TSM files are read.
+	DefaultTSMReadMode = TSMReadModeMmap
+
+	// DefaultTSMBlockCacheSize is the default size of the cache of blocks read
+	// from TSM files when they are not memory mapped.
+	DefaultTSMBlockCacheSize = 256 * 1024 * 1024 // 256MB
 )
 
 // Config holds the configuration for the tsbd package.
@@ -85,6 +101,16 @@ type Config struct {
 	// disks or when WAL write contention is seen.  A value of 0 fsyncs every write to the WAL.
 	WALFsyncDelay toml.Duration `toml:"wal-fsync-delay"`
 
+	// WALGroupCommitDelay is the longest that an fsync of the WAL waits for concurrent
+	// writes to be appended, so that they share it.  Unlike WALFsyncDelay, the fsync
+	// happens as soon as no other write is in progress.  A value of 0 disables waiting.
+	WALGroupCommitDelay toml.Duration `toml:"wal-group-commit-delay"`
+
+	// WALRecover salvages the valid entries of damaged WAL segments on startup
+	// instead of truncating them at their first corrupt entry. It is set by
+	// the -recover flag of "freetsd run" rather than the configuration file.
+	WALRecover bool `toml:"-"`
+
 	// Enables unicode validation on series keys on write.
 	ValidateKeys bool `toml:"validate-keys"`
 
@@ -111,6 +137,15 @@ type Config struct {
 	// A value of 0 disables the limit.
 	MaxValuesPerTag int `toml:"max-values-per-tag"`
 
+	// MaxSeriesKeyLength is the maximum length in bytes of the series key of a point,
+	// its measurement and tags in line protocol.  Points with longer keys are dropped
+	// on write.  A value of 0 limits keys to the 65535 bytes the storage engine supports.
+	MaxSeriesKeyLength int `toml:"max-series-key-length"`
+
+	// MaxTagValueLength is the maximum length in bytes of a tag value.  Points with
+	// longer tag values are dropped on write.  A value of 0 disables the limit.
+	MaxTagValueLength int `toml:"max-tag-value-length"`
+
 	// MaxConcurrentCompactions is the maximum number of concurrent level and full compactions
 	// that can be running at one time across all shards.  Compactions scheduled to run when the
 	// limit is reached are blocked until a running compaction completes.  Snapshot compactions are
@@ -135,6 +170,89 @@ type Config struct {
 	// been found to be problematic in some cases. It may help users who have
 	// slow disks.
 	TSMWillNeed bool `toml:"tsm-use-madv-willneed"`
+
+	// TSMReadMode controls how TSM files are read.  The default, "mmap", memory maps
+	// them.  "pread" reads blocks with positioned reads into a block cache instead,
+	// for environments with a low vm.max_map_count or filesystems where memory maps
+	// misbehave.
+	TSMReadMode string `toml:"tsm-read-mode"`
+
+	// TSMBlockCacheSize is the maximum size of the block cache shared by the shards
+	// of a node when TSMReadMode is "pread".  A value of 0 disables caching.
+	TSMBlockCacheSize toml.Size `toml:"tsm-block-cache-size"`
+
+	// CompactDirectIO writes the TSM files of compactions with direct I/O, bypassing
+	// the page cache so compactions do not evict the pages that queries rely on.
+	// It is only supported on Linux, and ignored by filesystems not supporting it.
+	CompactDirectIO bool `toml:"compact-direct-io"`
+
+	// ExportFadviseSequential hints to the kernel that files streamed by backups and
+	// exports are read sequentially, and drops their pages from the page cache once
+	// streamed.
+	ExportFadviseSequential bool `toml:"export-fadvise-sequential"`
+
+	// QueryReadaheadSize is the number of bytes of a TSM file following a block read
+	// by a query that the kernel is asked to read ahead.  A value of 0 leaves
+	// readahead to the kernel.
+	QueryReadaheadSize toml.Size `toml:"query-readahead-size"`
+
+	// EncryptionEnabled encrypts TSM files, including their index, and WAL
+	// data written by new and compacted shards using keys from
+	// EncryptionKeyFile. The series file, the TSI index and the field index
+	// are not encrypted, so the names of measurements, tags and fields and
+	// the tag values stay in plaintext; only the field values are protected.
+	EncryptionEnabled bool `toml:"encryption-enabled"`
+
+	// EncryptionKeyFile is the path of the file holding per-database
+	// encryption keys.
+	EncryptionKeyFile string `toml:"encryption-key-file"`
+
+	// RecordIngestTime stores the time each point was written to a shard in
+	// its _ingest_time field. The field is hidden from wildcard queries, and
+	// points setting it themselves are dropped.
+	RecordIngestTime bool `toml:"record-ingest-time"`
+
+	// RetentionPolicyEngines selects the engine of the new shards of a
+	// retention policy. Shards of other retention policies use Engine.
+	RetentionPolicyEngines []RetentionPolicyEngine `toml:"retention-policy-engine"`
+
+	// ObjectStoreURL is the bucket of object storage keeping the TSM files
+	// of the shards, as "s3://bucket/prefix" or "file:///path". The data
+	// directory caches them locally and the WAL stays in WALDir, so a shard
+	// moves to another node by changing its owners. Experimental.
+	ObjectStoreURL string `toml:"object-store-url"`
+
+	// ObjectStoreEndpoint is the URL of an S3 compatible service. If it is
+	// empty, Amazon S3 is used.
+	ObjectStoreEndpoint string `toml:"object-store-endpoint"`
+
+	// ObjectStoreRegion is the region of the bucket.
+	ObjectStoreRegion string `toml:"object-store-region"`
+
+	// ObjectStoreAccessKey and ObjectStoreSecretKey sign the requests to
+	// the service. If they are empty, the AWS_ACCESS_KEY_ID and
+	// AWS_SECRET_ACCESS_KEY environment variables are used.
+	ObjectStoreAccessKey string `toml:"object-store-access-key" secret:"true"`
+	ObjectStoreSecretKey string `toml:"object-store-secret-key" secret:"true"`
+}
+
+// RetentionPolicyEngine selects the engine of the new shards of a retention
+// policy. The engine must be registered with RegisterEngine.
+type RetentionPolicyEngine struct {
+	Database        string `toml:"database"`
+	RetentionPolicy string `toml:"retention-policy"`
+	Engine          string `toml:"engine"`
+}
+
+// EngineFor returns the engine of the new shards of the retention policy rp
+// on database.
+func (c *Config) EngineFor(database, rp string) string {
+	for _, e := range c.RetentionPolicyEngines {
+		if e.Database == database && e.RetentionPolicy == rp {
+			return e.Engine
+		}
+	}
+	return c.Engine
 }
 
 // NewConfig returns the default configuration for tsdb.
@@ -161,6 +279,8 @@ func NewConfig() Config {
 
 		TraceLoggingEnabled: false,
 		TSMWillNeed:         false,
+		TSMReadMode:         DefaultTSMReadMode,
+		TSMBlockCacheSize:   toml.Size(DefaultTSMBlockCacheSize),
 	}
 }
 
@@ -176,10 +296,42 @@ func (c *Config) Validate() error {
 		return errors.New("max-concurrent-compactions must be non-negative")
 	}
 
+	if c.MaxSeriesKeyLength < 0 || c.MaxSeriesKeyLength > models.MaxKeyLength {
+		return fmt.Errorf("max-series-key-length must be between 0 and %d", models.MaxKeyLength)
+	}
+
+	if c.MaxTagValueLength < 0 {
+		return errors.New("max-tag-value-length must be non-negative")
+	}
+
 	if c.SeriesIDSetCacheSize < 0 {
 		return errors.New("series-id-set-cache-size must be non-negative")
 	}
 
+	switch c.TSMReadMode {
+	case "", TSMReadModeMmap, TSMReadModePread:
+	default:
+		return fmt.Errorf("unrecognized tsm-read-mode %s", c.TSMReadMode)
+	}
+
+	if c.EncryptionEnabled && c.EncryptionKeyFile == "" {
+		return errors.New("encryption-key-file must be specified when encryption is enabled")
+	}
+
+	if c.ObjectStoreURL != "" {
+		if u, err := url.Parse(c.ObjectStoreURL); err != nil {
+			return fmt.Errorf("invalid object-store-url: %s", err)
+		} else if u.Scheme != "s3" && u.Scheme != "file" {
+			return fmt.Errorf("unsupported object-store-url scheme %q", u.Scheme)
+		}
+
+		// The TSI index of a shard is not kept in object storage, so it
+		// could not be restored with the TSM files.
+		if c.Index != InmemIndexName {
+			return fmt.Errorf("object-store-url requires the %s index", InmemIndexName)
+		}
+	}
+
 	valid := false
 	for _, e := range RegisteredEngines() {
 		if e == c.Engine {
@@ -191,6 +343,14 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("unrecognized engine %s", c.Engine)
 	}
 
+	for _, e := range c.RetentionPolicyEngines {
+		if e.Database == "" || e.RetentionPolicy == "" {
+			return errors.New("retention-policy-engine requires a database and a retention-policy")
+		} else if newEngineFuncs[e.Engine] == nil {
+			return fmt.Errorf("unrecognized engine %s for retention policy %s.%s", e.Engine, e.Database, e.RetentionPolicy)
+		}
+	}
+
 	valid = false
 	for _, e := range RegisteredIndexes() {
 		if e == c.Index {
@@ -211,14 +371,25 @@ func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
 		"dir":                                c.Dir,
 		"wal-dir":                            c.WALDir,
 		"wal-fsync-delay":                    c.WALFsyncDelay,
+		"wal-group-commit-delay":             c.WALGroupCommitDelay,
 		"cache-max-memory-size":              c.CacheMaxMemorySize,
 		"cache-snapshot-memory-size":         c.CacheSnapshotMemorySize,
 		"cache-snapshot-write-cold-duration": c.CacheSnapshotWriteColdDuration,
 		"compact-full-write-cold-duration":   c.CompactFullWriteColdDuration,
 		"max-series-per-database":            c.MaxSeriesPerDatabase,
 		"max-values-per-tag":                 c.MaxValuesPerTag,
+		"max-series-key-length":              c.MaxSeriesKeyLength,
+		"max-tag-value-length":               c.MaxTagValueLength,
 		"max-concurrent-compactions":         c.MaxConcurrentCompactions,
 		"max-index-log-file-size":            c.MaxIndexLogFileSize,
 		"series-id-set-cache-size":           c.SeriesIDSetCacheSize,
+		"tsm-read-mode":                      c.TSMReadMode,
+		"tsm-block-cache-size":               c.TSMBlockCacheSize,
+		"compact-direct-io":                  c.CompactDirectIO,
+		"export-fadvise-sequential":          c.ExportFadviseSequential,
+		"query-readahead-size":               c.QueryReadaheadSize,
+		"encryption-enabled":                 c.EncryptionEnabled,
+		"record-ingest-time":                 c.RecordIngestTime,
+		"object-store-url":                   c.ObjectStoreURL,
 	}), nil
 }
@@ -15,6 +15,7 @@ func TestConfig_Parse(t *testing.T) {
 dir = "/var/lib/freetsdb/data"
 wal-dir = "/var/lib/freetsdb/wal"
 wal-fsync-delay = "10s"
+wal-group-commit-delay = "2ms"
 tsm-use-madv-willneed = true
 `, &c); err != nil {
 		t.Fatal(err)
@@ -33,6 +34,9 @@ tsm-use-madv-willneed = true
 	if got, exp := c.WALFsyncDelay, time.Duration(10*time.Second); time.Duration(got).Nanoseconds() != exp.Nanoseconds() {
 		t.Errorf("unexpected wal-fsync-delay:\n\nexp=%v\n\ngot=%v\n\n", exp, got)
 	}
+	if got, exp := c.WALGroupCommitDelay, time.Duration(2*time.Millisecond); time.Duration(got).Nanoseconds() != exp.Nanoseconds() {
+		t.Errorf("unexpected wal-group-commit-delay:\n\nexp=%v\n\ngot=%v\n\n", exp, got)
+	}
 	if got, exp := c.TSMWillNeed, true; got != exp {
 		t.Errorf("unexpected tsm-madv-willneed:\n\nexp=%v\n\ngot=%v\n\n", exp, got)
 	}
@@ -75,6 +79,82 @@ func TestConfig_Validate_Error(t *testing.T) {
 	if err := c.Validate(); err == nil || err.Error() != "series-id-set-cache-size must be non-negative" {
 		t.Errorf("unexpected error: %s", err)
 	}
+
+	c.SeriesIDSetCacheSize = 0
+	c.MaxSeriesKeyLength = 1 << 16
+	if err := c.Validate(); err == nil || err.Error() != "max-series-key-length must be between 0 and 65535" {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	c.MaxSeriesKeyLength = 0
+	c.MaxTagValueLength = -1
+	if err := c.Validate(); err == nil || err.Error() != "max-tag-value-length must be non-negative" {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	c.MaxTagValueLength = 0
+	c.TSMReadMode = tsdb.TSMReadModePread
+	if err := c.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	c.TSMReadMode = "mmap2"
+	if err := c.Validate(); err == nil || err.Error() != "unrecognized tsm-read-mode mmap2" {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	c.TSMReadMode = ""
+	c.ObjectStoreURL = "s3://bucket/cluster0"
+	if err := c.Validate(); err == nil || err.Error() != "object-store-url requires the inmem index" {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	c.Index = tsdb.InmemIndexName
+	if err := c.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	c.ObjectStoreURL = "gs://bucket"
+	if err := c.Validate(); err == nil || err.Error() != `unsupported object-store-url scheme "gs"` {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestConfig_RetentionPolicyEngine(t *testing.T) {
+	c := tsdb.NewConfig()
+	if _, err := toml.Decode(`
+dir = "/var/lib/freetsdb/data"
+wal-dir = "/var/lib/freetsdb/wal"
+
+[[retention-policy-engine]]
+  database = "db0"
+  retention-policy = "rp0"
+  engine = "tsm1"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Validate(); err != nil {
+		t.Errorf("unexpected validate error: %s", err)
+	}
+	if got, exp := c.EngineFor("db0", "rp0"), "tsm1"; got != exp {
+		t.Errorf("unexpected engine: got=%s exp=%s", got, exp)
+	}
+	c.Engine = "default"
+	if got, exp := c.EngineFor("db0", "rp1"), "default"; got != exp {
+		t.Errorf("unexpected default engine: got=%s exp=%s", got, exp)
+	}
+	c.Engine = tsdb.DefaultEngine
+
+	c.RetentionPolicyEngines[0].Engine = "fake1"
+	if err := c.Validate(); err == nil || err.Error() != "unrecognized engine fake1 for retention policy db0.rp0" {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	c.RetentionPolicyEngines[0] = tsdb.RetentionPolicyEngine{Database: "db0", Engine: "tsm1"}
+	if err := c.Validate(); err == nil || err.Error() != "retention-policy-engine requires a database and a retention-policy" {
+		t.Errorf("unexpected error: %s", err)
+	}
 }
 
 func TestConfig_ByteSizes(t *testing.T) {
@@ -0,0 +1,186 @@
+package tsdb
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/freetsdb/freetsdb/pkg/file"
+)
+
+const (
+	// DiskUsageHistoryFile is the file of the store directory holding the
+	// history of the disk usage of its retention policies.
+	DiskUsageHistoryFile = "disk_usage.json"
+
+	// DiskUsageSampleInterval is the interval between the samples of the
+	// disk usage history.
+	DiskUsageSampleInterval = time.Hour
+
+	// diskUsageHourlyAge is the age from which the samples of the history
+	// are kept one per day rather than one per hour.
+	diskUsageHourlyAge = 8 * 24 * time.Hour
+
+	// diskUsageHistoryAge is the age from which the samples of the history
+	// are dropped.
+	diskUsageHistoryAge = 31 * 24 * time.Hour
+)
+
+// DiskUsage is the number of bytes stored on disk, by kind of file.
+type DiskUsage struct {
+	TSM   int64 // TSM files
+	WAL   int64 // WAL segments
+	Index int64 // TSI index files, none for the in-memory index
+}
+
+// Total returns the number of bytes of all the files.
+func (u DiskUsage) Total() int64 {
+	return u.TSM + u.WAL + u.Index
+}
+
+// Add adds the bytes of o to u.
+func (u *DiskUsage) Add(o DiskUsage) {
+	u.TSM += o.TSM
+	u.WAL += o.WAL
+	u.Index += o.Index
+}
+
+// ShardDiskUsage is the disk usage of a shard.
+type ShardDiskUsage struct {
+	ID              uint64
+	Database        string
+	RetentionPolicy string
+	DiskUsage
+
+	// Measurements holds the estimated number of bytes of the TSM files
+	// used by each measurement. See MeasurementStats.
+	Measurements map[string]int64
+}
+
+// RetentionPolicyDiskUsage is the disk usage of the shards of a retention
+// policy.
+type RetentionPolicyDiskUsage struct {
+	Database        string
+	RetentionPolicy string
+	DiskUsage
+}
+
+// DiskUsageSample is the disk usage of the retention policies of a store at
+// a time.
+type DiskUsageSample struct {
+	Time     time.Time
+	Policies []RetentionPolicyDiskUsage
+}
+
+// filter returns the sample with the retention policies of database only,
+// or of every database if database is empty.
+func (s *DiskUsageSample) filter(database string) *DiskUsageSample {
+	if database == "" {
+		return s
+	}
+	other := &DiskUsageSample{Time: s.Time}
+	for _, u := range s.Policies {
+		if u.Database == database {
+			other.Policies = append(other.Policies, u)
+		}
+	}
+	return other
+}
+
+// newDiskUsageSample sums the disk usage of shards by retention policy.
+func newDiskUsageSample(now time.Time, shards []ShardDiskUsage) *DiskUsageSample {
+	type rpKey struct{ database, rp string }
+	policies := make(map[rpKey]*DiskUsage)
+	for _, sh := range shards {
+		k := rpKey{database: sh.Database, rp: sh.RetentionPolicy}
+		if policies[k] == nil {
+			policies[k] = &DiskUsage{}
+		}
+		policies[k].Add(sh.DiskUsage)
+	}
+
+	s := &DiskUsageSample{Time: now.UTC()}
+	for k, u := range policies {
+		s.Policies = append(s.Policies, RetentionPolicyDiskUsage{Database: k.database, RetentionPolicy: k.rp, DiskUsage: *u})
+	}
+	sort.Slice(s.Policies, func(i, j int) bool {
+		if s.Policies[i].Database != s.Policies[j].Database {
+			return s.Policies[i].Database < s.Policies[j].Database
+		}
+		return s.Policies[i].RetentionPolicy < s.Policies[j].RetentionPolicy
+	})
+	return s
+}
+
+// diskUsageHistory is the history of the disk usage of a store, sampled
+// every hour for the last week and every day for the last month. The sizes
+// of the files are maintained as they are written, so a sample does not read
+// the disk.
+type diskUsageHistory struct {
+	path    string
+	samples []*DiskUsageSample // ordered by time
+}
+
+// loadDiskUsageHistory loads the history saved at path. A history that
+// cannot be read is started over.
+func loadDiskUsageHistory(path string) (*diskUsageHistory, error) {
+	h := &diskUsageHistory{path: path}
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(buf, &h.samples); err != nil {
+		h.samples = nil
+	}
+	return h, nil
+}
+
+// add adds a sample to the history, thins the samples that have aged and
+// saves the history.
+func (h *diskUsageHistory) add(s *DiskUsageSample) error {
+	h.samples = append(h.samples, s)
+
+	samples := h.samples[:0]
+	var lastDay time.Time
+	for _, other := range h.samples {
+		age := s.Time.Sub(other.Time)
+		if age >= diskUsageHistoryAge {
+			continue
+		} else if age >= diskUsageHourlyAge {
+			day := other.Time.Truncate(24 * time.Hour)
+			if day.Equal(lastDay) {
+				continue
+			}
+			lastDay = day
+		}
+		samples = append(samples, other)
+	}
+	for i := len(samples); i < len(h.samples); i++ {
+		h.samples[i] = nil
+	}
+	h.samples = samples
+
+	buf, err := json.Marshal(h.samples)
+	if err != nil {
+		return err
+	}
+	tmp := h.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf, 0666); err != nil {
+		return err
+	}
+	return file.RenameFile(tmp, h.path)
+}
+
+// at returns the last sample taken at or before t, or nil if the history
+// does not reach t.
+func (h *diskUsageHistory) at(t time.Time) *DiskUsageSample {
+	i := sort.Search(len(h.samples), func(i int) bool { return h.samples[i].Time.After(t) })
+	if i == 0 {
+		return nil
+	}
+	return h.samples[i-1]
+}
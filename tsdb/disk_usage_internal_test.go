@@ -0,0 +1,63 @@
+package tsdb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Ensure the samples of the disk usage history are kept every hour for a
+// week, every day for a month, and are saved.
+func TestDiskUsageHistory_Add(t *testing.T) {
+	dir, err := ioutil.TempDir("", "disk-usage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, DiskUsageHistoryFile)
+	h, err := loadDiskUsageHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(40 * 24 * time.Hour)
+	for now := start; !now.After(end); now = now.Add(DiskUsageSampleInterval) {
+		sample := newDiskUsageSample(now, []ShardDiskUsage{
+			{ID: 1, Database: "db0", RetentionPolicy: "rp0", DiskUsage: DiskUsage{TSM: now.Sub(start).Nanoseconds()}},
+		})
+		if err := h.add(sample); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 8 days of hourly samples, and the first sample of each of the 23 days
+	// before.
+	if got, exp := len(h.samples), 8*24+23; got != exp {
+		t.Fatalf("unexpected number of samples: got %d, exp %d", got, exp)
+	}
+
+	week := end.Add(-7 * 24 * time.Hour)
+	if s := h.at(week); s == nil || !s.Time.Equal(week) {
+		t.Fatalf("unexpected sample a week ago: %+v", s)
+	}
+	month := end.Add(-30 * 24 * time.Hour)
+	if s := h.at(month); s == nil || month.Sub(s.Time) >= 24*time.Hour {
+		t.Fatalf("unexpected sample a month ago: %+v", s)
+	}
+	if s := h.at(end.Add(-32 * 24 * time.Hour)); s != nil {
+		t.Fatalf("unexpected sample: %+v", s)
+	}
+
+	other, err := loadDiskUsageHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(other.samples) != len(h.samples) {
+		t.Fatalf("unexpected number of samples loaded: %d", len(other.samples))
+	} else if u := other.samples[len(other.samples)-1].Policies[0]; u.TSM != end.Sub(start).Nanoseconds() {
+		t.Fatalf("unexpected usage loaded: %+v", u)
+	}
+}
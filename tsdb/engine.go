@@ -5,13 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/pkg/encryption"
 	"github.com/freetsdb/freetsdb/pkg/estimator"
 	"github.com/freetsdb/freetsdb/pkg/limiter"
 	"github.com/freetsdb/freetsdb/query"
@@ -30,6 +34,14 @@ var (
 )
 
 // Engine represents a swappable storage engine for the shard.
+//
+// Engines other than tsm1 can be compiled in by registering a constructor
+// with RegisterEngine from an init function, and are selected for the new
+// shards of a retention policy by a retention-policy-engine entry of the
+// data config.
+// The interface is stable across minor releases: methods are only added in a
+// major release, and the enginetest package checks that an engine behaves as
+// the shard and the query engine expect.
 type Engine interface {
 	Open() error
 	Close() error
@@ -52,6 +64,7 @@ type Engine interface {
 	CreateCursorIterator(ctx context.Context) (CursorIterator, error)
 	IteratorCost(measurement string, opt query.IteratorOptions) (query.IteratorCost, error)
 	WritePoints(points []models.Point) error
+	WritePointsWithContext(ctx context.Context, points []models.Point) error
 
 	CreateSeriesIfNotExists(key, name []byte, tags models.Tags) error
 	CreateSeriesListIfNotExists(keys, names [][]byte, tags []models.Tags) error
@@ -63,6 +76,8 @@ type Engine interface {
 	SeriesN() int64
 
 	MeasurementExists(name []byte) (bool, error)
+	MeasurementStats() (map[string]MeasurementStats, error)
+	Files() []ShardFile
 
 	MeasurementNamesByRegex(re *regexp.Regexp) ([][]byte, error)
 	MeasurementFieldSet() *MeasurementFieldSet
@@ -78,6 +93,8 @@ type Engine interface {
 	Statistics(tags map[string]string) []models.Statistic
 	LastModified() time.Time
 	DiskSize() int64
+	DiskUsage() DiskUsage
+	IndexSeries(index Index, seen map[string]struct{}) error
 	IsIdle() bool
 	Free() error
 
@@ -97,13 +114,25 @@ const (
 	TSM1Format EngineFormat = 2
 )
 
-// NewEngineFunc creates a new engine.
+// EngineFile is the name of the file holding the name of the engine of a
+// shard created by an engine other than tsm1. Shards without it are tsm1.
+const EngineFile = "ENGINE"
+
+// RestoredIndexDirectory is the directory of a shard holding an index restored
+// from a backup, which replaces the index of the shard when it is opened.
+const RestoredIndexDirectory = "index.restored"
+
+// NewEngineFunc creates a new engine. The engine stores its data in the
+// directory at path and its write-ahead log, if any, under walPath. It must
+// not touch either until it is opened.
 type NewEngineFunc func(id uint64, i Index, path string, walPath string, sfile *SeriesFile, options EngineOptions) Engine
 
 // newEngineFuncs is a lookup of engine constructors by name.
 var newEngineFuncs = make(map[string]NewEngineFunc)
 
-// RegisterEngine registers a storage engine initializer by name.
+// RegisterEngine registers a storage engine initializer by name. It is
+// intended to be called from the init function of the engine's package, and
+// panics if the name is already registered.
 func RegisterEngine(name string, fn NewEngineFunc) {
 	if _, ok := newEngineFuncs[name]; ok {
 		panic("engine already registered: " + name)
@@ -122,25 +151,20 @@ func RegisteredEngines() []string {
 }
 
 // NewEngine returns an instance of an engine based on its format.
-// If the path does not exist then the DefaultFormat is used.
+// If the path does not exist then options.EngineVersion is used, otherwise
+// the engine recorded in the EngineFile of the shard, or tsm1.
 func NewEngine(id uint64, i Index, path string, walPath string, sfile *SeriesFile, options EngineOptions) (Engine, error) {
-	// Create a new engine
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		engine := newEngineFuncs[options.EngineVersion](id, i, path, walPath, sfile, options)
-		if options.OnNewEngine != nil {
-			options.OnNewEngine(engine)
-		}
-		return engine, nil
-	}
-
-	// If it's a dir then it's a tsm1 engine
-	format := DefaultEngine
-	if fi, err := os.Stat(path); err != nil {
+	format := "tsm1"
+	if fi, err := os.Stat(path); os.IsNotExist(err) {
+		format = options.EngineVersion
+	} else if err != nil {
 		return nil, err
 	} else if !fi.Mode().IsDir() {
 		return nil, ErrUnknownEngineFormat
-	} else {
-		format = "tsm1"
+	} else if buf, err := ioutil.ReadFile(filepath.Join(path, EngineFile)); err == nil {
+		format = strings.TrimSpace(string(buf))
+	} else if !os.IsNotExist(err) {
+		return nil, err
 	}
 
 	// Lookup engine by format.
@@ -156,6 +180,39 @@ func NewEngine(id uint64, i Index, path string, walPath string, sfile *SeriesFil
 	return engine, nil
 }
 
+// createEngineFile records the engine of a new shard at path in its
+// EngineFile. Nothing is recorded for tsm1 or for existing shards.
+func createEngineFile(path, format string) error {
+	if format == "tsm1" {
+		return nil
+	} else if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return err
+	} else if newEngineFuncs[format] == nil {
+		return fmt.Errorf("invalid engine format: %q", format)
+	}
+
+	if err := os.MkdirAll(path, 0777); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(path, EngineFile), []byte(format+"\n"), 0666)
+}
+
+// installRestoredIndex replaces the index at indexPath with the index restored
+// in the shard at path, if any.
+func installRestoredIndex(path, indexPath string) error {
+	restored := filepath.Join(path, RestoredIndexDirectory)
+	if _, err := os.Stat(restored); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(indexPath); err != nil {
+		return err
+	}
+	return os.Rename(restored, indexPath)
+}
+
 // EngineOptions represents the options used to initialize the engine.
 type EngineOptions struct {
 	EngineVersion string
@@ -163,9 +220,16 @@ type EngineOptions struct {
 	ShardID       uint64
 	InmemIndex    interface{} // shared in-memory index
 
+	// NodeID is the ID of the data node the shards are stored on.
+	NodeID uint64
+
 	// Limits the concurrent number of TSM files that can be loaded at once.
 	OpenLimiter limiter.Fixed
 
+	// TSMBlockCache is the block cache shared by the shards reading TSM files
+	// with the "pread" read mode.
+	TSMBlockCache interface{}
+
 	// CompactionDisabled specifies shards should not schedule compactions.
 	// This option is intended for offline tooling.
 	CompactionDisabled          bool
@@ -194,6 +258,14 @@ type EngineOptions struct {
 	OnNewEngine func(Engine)
 
 	FileStoreObserver FileStoreObserver
+
+	// KeyProvider supplies per-database encryption keys. If nil, shards are
+	// written unencrypted.
+	KeyProvider encryption.KeyProvider
+
+	// Cipher encrypts the data of a single shard. It is set by the store
+	// from KeyProvider when the shard is opened.
+	Cipher *encryption.Cipher
 }
 
 // NewEngineOptions constructs an EngineOptions object with safe default values.
@@ -211,6 +283,10 @@ func NewEngineOptions() EngineOptions {
 // NewInmemIndex returns a new "inmem" index type.
 var NewInmemIndex func(name string, sfile *SeriesFile) (interface{}, error)
 
+// NewTSMBlockCache returns a new block cache of at most size bytes for TSM
+// files read with the "pread" read mode.
+var NewTSMBlockCache func(size int64) interface{}
+
 type CompactionPlannerCreator func(cfg Config) interface{}
 
 // FileStoreObserver is passed notifications before the file store adds or deletes files. In this way, it can
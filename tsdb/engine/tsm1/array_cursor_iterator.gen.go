@@ -17,8 +17,7 @@ import (
 // buildFloatArrayCursor creates an array cursor for a float field.
 func (q *arrayCursorIterator) buildFloatArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions) tsdb.FloatArrayCursor {
 	key := q.seriesFieldKeyBytes(name, tags, field)
-	cacheValues := q.e.Cache.Values(key)
-	keyCursor := q.e.KeyCursor(ctx, key, opt.SeekTime(), opt.Ascending)
+	cacheValues, keyCursor := q.e.readValues(ctx, key, opt.SeekTime(), opt.Ascending)
 	if opt.Ascending {
 		if q.asc.Float == nil {
 			q.asc.Float = newFloatArrayAscendingCursor()
@@ -37,8 +36,7 @@ func (q *arrayCursorIterator) buildFloatArrayCursor(ctx context.Context, name []
 // buildIntegerArrayCursor creates an array cursor for a integer field.
 func (q *arrayCursorIterator) buildIntegerArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions) tsdb.IntegerArrayCursor {
 	key := q.seriesFieldKeyBytes(name, tags, field)
-	cacheValues := q.e.Cache.Values(key)
-	keyCursor := q.e.KeyCursor(ctx, key, opt.SeekTime(), opt.Ascending)
+	cacheValues, keyCursor := q.e.readValues(ctx, key, opt.SeekTime(), opt.Ascending)
 	if opt.Ascending {
 		if q.asc.Integer == nil {
 			q.asc.Integer = newIntegerArrayAscendingCursor()
@@ -57,8 +55,7 @@ func (q *arrayCursorIterator) buildIntegerArrayCursor(ctx context.Context, name
 // buildUnsignedArrayCursor creates an array cursor for a unsigned field.
 func (q *arrayCursorIterator) buildUnsignedArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions) tsdb.UnsignedArrayCursor {
 	key := q.seriesFieldKeyBytes(name, tags, field)
-	cacheValues := q.e.Cache.Values(key)
-	keyCursor := q.e.KeyCursor(ctx, key, opt.SeekTime(), opt.Ascending)
+	cacheValues, keyCursor := q.e.readValues(ctx, key, opt.SeekTime(), opt.Ascending)
 	if opt.Ascending {
 		if q.asc.Unsigned == nil {
 			q.asc.Unsigned = newUnsignedArrayAscendingCursor()
@@ -77,8 +74,7 @@ func (q *arrayCursorIterator) buildUnsignedArrayCursor(ctx context.Context, name
 // buildStringArrayCursor creates an array cursor for a string field.
 func (q *arrayCursorIterator) buildStringArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions) tsdb.StringArrayCursor {
 	key := q.seriesFieldKeyBytes(name, tags, field)
-	cacheValues := q.e.Cache.Values(key)
-	keyCursor := q.e.KeyCursor(ctx, key, opt.SeekTime(), opt.Ascending)
+	cacheValues, keyCursor := q.e.readValues(ctx, key, opt.SeekTime(), opt.Ascending)
 	if opt.Ascending {
 		if q.asc.String == nil {
 			q.asc.String = newStringArrayAscendingCursor()
@@ -97,8 +93,7 @@ func (q *arrayCursorIterator) buildStringArrayCursor(ctx context.Context, name [
 // buildBooleanArrayCursor creates an array cursor for a boolean field.
 func (q *arrayCursorIterator) buildBooleanArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions) tsdb.BooleanArrayCursor {
 	key := q.seriesFieldKeyBytes(name, tags, field)
-	cacheValues := q.e.Cache.Values(key)
-	keyCursor := q.e.KeyCursor(ctx, key, opt.SeekTime(), opt.Ascending)
+	cacheValues, keyCursor := q.e.readValues(ctx, key, opt.SeekTime(), opt.Ascending)
 	if opt.Ascending {
 		if q.asc.Boolean == nil {
 			q.asc.Boolean = newBooleanArrayAscendingCursor()
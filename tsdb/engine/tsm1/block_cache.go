@@ -0,0 +1,96 @@
+package tsm1
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// blockCache is a least recently used cache of the blocks read from TSM files
+// that are not memory mapped. It is safe for concurrent use and is shared by
+// all the readers of a node, so its size bounds the memory used for blocks.
+type blockCache struct {
+	// fileID is the last identifier given to a file using the cache.
+	fileID uint64
+
+	mu      sync.Mutex
+	maxSize int64
+	size    int64
+	lru     *list.List // of *blockCacheEntry, most recently used first
+	entries map[blockCacheKey]*list.Element
+}
+
+// blockCacheKey identifies a block in the cache.
+type blockCacheKey struct {
+	file   uint64
+	offset int64
+}
+
+type blockCacheEntry struct {
+	key blockCacheKey
+	b   []byte
+}
+
+// newBlockCache returns a new cache holding at most maxSize bytes of blocks.
+// A maxSize of 0 or less disables caching.
+func newBlockCache(maxSize int64) *blockCache {
+	return &blockCache{
+		maxSize: maxSize,
+		lru:     list.New(),
+		entries: make(map[blockCacheKey]*list.Element),
+	}
+}
+
+// newFileID returns a new identifier for the blocks of a file. Identifiers are
+// never reused, so the blocks of closed files are never returned and age out
+// of the cache.
+func (c *blockCache) newFileID() uint64 {
+	return atomic.AddUint64(&c.fileID, 1)
+}
+
+// get returns the cached block of file at offset. The returned slice must not
+// be modified.
+func (c *blockCache) get(file uint64, offset int64) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.entries[blockCacheKey{file: file, offset: offset}]
+	if e == nil {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*blockCacheEntry).b, true
+}
+
+// put adds the block of file at offset to the cache, evicting the least
+// recently used blocks if the cache is full. b must not be modified afterwards.
+func (c *blockCache) put(file uint64, offset int64, b []byte) {
+	if int64(len(b)) > c.maxSize {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := blockCacheKey{file: file, offset: offset}
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&blockCacheEntry{key: key, b: b})
+	c.size += int64(len(b))
+
+	for c.size > c.maxSize {
+		e := c.lru.Back()
+		entry := e.Value.(*blockCacheEntry)
+		c.lru.Remove(e)
+		delete(c.entries, entry.key)
+		c.size -= int64(len(entry.b))
+	}
+}
+
+// used returns the number of bytes held by the cache.
+func (c *blockCache) used() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
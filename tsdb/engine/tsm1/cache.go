@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/pkg/encryption"
 	"github.com/freetsdb/freetsdb/tsdb"
 	"github.com/freetsdb/freetsdb/services/influxql"
 	"go.uber.org/zap"
@@ -423,6 +424,20 @@ func (c *Cache) Snapshot() (*Cache, error) {
 	return c.snapshot, nil
 }
 
+// readStores returns the live store and the store of the snapshot being
+// written, if any. Neither store is reset while referenced: the live store
+// becomes the snapshot store, and the snapshot store is dropped once written.
+func (c *Cache) readStores() (store, snapshot storer) {
+	c.init()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.snapshot != nil {
+		snapshot = c.snapshot.store
+	}
+	return c.store, snapshot
+}
+
 // Deduplicate sorts the snapshot before returning it. The compactor and any queries
 // coming in while it writes will need the values sorted.
 func (c *Cache) Deduplicate() {
@@ -440,15 +455,6 @@ func (c *Cache) Deduplicate() {
 func (c *Cache) ClearSnapshot(success bool) {
 	c.init()
 
-	c.mu.RLock()
-	snapStore := c.snapshot.store
-	c.mu.RUnlock()
-
-	// reset the snapshot store outside of the write lock
-	if success {
-		snapStore.reset()
-	}
-
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -458,10 +464,10 @@ func (c *Cache) ClearSnapshot(success bool) {
 		c.snapshotAttempts = 0
 		c.updateMemSize(-int64(atomic.LoadUint64(&c.snapshotSize))) // decrement the number of bytes in cache
 
-		// Reset the snapshot to a fresh Cache.
-		c.snapshot = &Cache{
-			store: c.snapshot.store,
-		}
+		// Drop the snapshot rather than resetting its store, as read
+		// snapshots may still reference it. The next snapshot creates a new
+		// store.
+		c.snapshot = nil
 
 		atomic.StoreUint64(&c.snapshotSize, 0)
 		c.updateSnapshots()
@@ -562,6 +568,12 @@ func (c *Cache) Values(key []byte) Values {
 	}
 	c.mu.RUnlock()
 
+	return entryValues(e, snapshotEntries)
+}
+
+// entryValues returns a copy of the values of the live and snapshot entries
+// of a key, deduped and sorted. Either entry may be nil.
+func entryValues(e, snapshotEntries *entry) Values {
 	if e == nil {
 		if snapshotEntries == nil {
 			// No values in hot cache or snapshots.
@@ -686,6 +698,16 @@ func (c *Cache) ApplyEntryFn(f func(key []byte, entry *entry) error) error {
 type CacheLoader struct {
 	files []string
 
+	// Cipher decrypts encrypted segment entries.
+	Cipher *encryption.Cipher
+
+	// Recover salvages the valid entries following a corrupt entry of a
+	// segment, instead of truncating the segment at the corrupt entry.
+	Recover bool
+
+	// salvaged is the number of segments salvaged.
+	salvaged int
+
 	Logger *zap.Logger
 }
 
@@ -726,6 +748,7 @@ func (cl *CacheLoader) Load(cache *Cache) error {
 
 			if r == nil {
 				r = NewWALSegmentReader(f)
+				r.cipher = cl.Cipher
 				defer r.Close()
 			} else {
 				r.Reset(f)
@@ -733,24 +756,23 @@ func (cl *CacheLoader) Load(cache *Cache) error {
 
 			for r.Next() {
 				entry, err := r.Read()
-				if err != nil {
+				if err == ErrWALEncrypted || err == encryption.ErrUnknownKey {
+					// The segment is not corrupt, so don't truncate it.
+					return fmt.Errorf("%s: %v", f.Name(), err)
+				} else if err != nil {
 					n := r.Count()
 					cl.Logger.Info("File corrupt", zap.Error(err), zap.String("path", f.Name()), zap.Int64("pos", n))
+					if cl.Recover {
+						return cl.salvage(f.Name(), n, cache)
+					}
 					if err := f.Truncate(n); err != nil {
 						return err
 					}
 					break
 				}
 
-				switch t := entry.(type) {
-				case *WriteWALEntry:
-					if err := cache.WriteMulti(t.Values); err != nil {
-						return err
-					}
-				case *DeleteRangeWALEntry:
-					cache.DeleteRange(t.Keys, t.Min, t.Max)
-				case *DeleteWALEntry:
-					cache.Delete(t.Keys)
+				if err := applyWALEntry(cache, entry); err != nil {
+					return err
 				}
 			}
 
@@ -762,6 +784,26 @@ func (cl *CacheLoader) Load(cache *Cache) error {
 	return nil
 }
 
+// Salvaged returns the number of segments salvaged by Load.
+func (cl *CacheLoader) Salvaged() int {
+	return cl.salvaged
+}
+
+// applyWALEntry applies a WAL entry to cache.
+func applyWALEntry(cache *Cache, entry WALEntry) error {
+	switch t := entry.(type) {
+	case *WriteWALEntry:
+		if err := cache.WriteMulti(t.Values); err != nil {
+			return err
+		}
+	case *DeleteRangeWALEntry:
+		cache.DeleteRange(t.Keys, t.Min, t.Max)
+	case *DeleteWALEntry:
+		cache.Delete(t.Keys)
+	}
+	return nil
+}
+
 // WithLogger sets the logger on the CacheLoader.
 func (cl *CacheLoader) WithLogger(log *zap.Logger) {
 	cl.Logger = log.With(zap.String("service", "cacheloader"))
@@ -8,6 +8,7 @@ import (
 	"math"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"strings"
@@ -802,6 +803,74 @@ func TestCacheLoader_LoadDeleted(t *testing.T) {
 	}
 }
 
+// Ensure the CacheLoader recovers the entries following a corrupt entry and
+// quarantines the damaged segment.
+func TestCacheLoader_LoadRecover(t *testing.T) {
+	dir := mustTempDir()
+	defer os.RemoveAll(dir)
+	f := mustTempFile(dir)
+	w := NewWALSegmentWriter(f)
+
+	p1 := NewValue(1, 1.1)
+	p2 := NewValue(2, int64(2))
+	if err := w.Write(mustMarshalEntry(&WriteWALEntry{Values: map[string][]Value{"foo": {p1}}})); err != nil {
+		t.Fatal("write points", err)
+	} else if err := w.Flush(); err != nil {
+		t.Fatalf("flush error: %v", err)
+	}
+
+	// Corrupt the middle of the WAL segment.
+	if _, err := f.Write([]byte{1, 4, 0, 0, 0, 0xff, 0xff}); err != nil {
+		t.Fatalf("corrupt WAL segment: %s", err.Error())
+	}
+	if err := w.Write(mustMarshalEntry(&WriteWALEntry{Values: map[string][]Value{"bar": {p2}}})); err != nil {
+		t.Fatal("write points", err)
+	} else if err := w.Flush(); err != nil {
+		t.Fatalf("flush error: %v", err)
+	}
+	f.Close()
+
+	cache := NewCache(1024)
+	loader := NewCacheLoader([]string{f.Name()})
+	loader.Recover = true
+	if err := loader.Load(cache); err != nil {
+		t.Fatalf("failed to load cache: %s", err.Error())
+	} else if loader.Salvaged() != 1 {
+		t.Fatalf("got %d salvaged segments, expected 1", loader.Salvaged())
+	}
+	if values := cache.Values([]byte("foo")); !reflect.DeepEqual(values, Values{p1}) {
+		t.Fatalf("cache key foo not as expected, got %v, exp %v", values, Values{p1})
+	}
+	if values := cache.Values([]byte("bar")); !reflect.DeepEqual(values, Values{p2}) {
+		t.Fatalf("cache key bar not as expected, got %v, exp %v", values, Values{p2})
+	}
+
+	// The original segment and a report are quarantined.
+	name := filepath.Base(f.Name())
+	if _, err := os.Stat(filepath.Join(dir, WALQuarantineDir, name)); err != nil {
+		t.Fatal(err)
+	}
+	report, err := ioutil.ReadFile(filepath.Join(dir, WALQuarantineDir, name+".report"))
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(report), "entries recovered after corruption: 1\n") {
+		t.Fatalf("unexpected report:\n%s", report)
+	}
+
+	// The salvaged segment loads without corruption.
+	cache = NewCache(1024)
+	loader = NewCacheLoader([]string{f.Name()})
+	loader.Recover = true
+	if err := loader.Load(cache); err != nil {
+		t.Fatalf("failed to load cache: %s", err.Error())
+	} else if loader.Salvaged() != 0 {
+		t.Fatalf("got %d salvaged segments, expected 0", loader.Salvaged())
+	}
+	if values := cache.Values([]byte("bar")); !reflect.DeepEqual(values, Values{p2}) {
+		t.Fatalf("cache key bar not as expected, got %v, exp %v", values, Values{p2})
+	}
+}
+
 func TestCache_Split(t *testing.T) {
 	v0 := NewValue(1, 1.0)
 	v1 := NewValue(2, 2.0)
@@ -25,6 +25,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/freetsdb/freetsdb/pkg/encryption"
+	"github.com/freetsdb/freetsdb/pkg/file"
 	"github.com/freetsdb/freetsdb/pkg/limiter"
 	"github.com/freetsdb/freetsdb/tsdb"
 )
@@ -692,6 +694,14 @@ type Compactor struct {
 	// RateLimit is the limit for disk writes for all concurrent compactions.
 	RateLimit limiter.Rate
 
+	// Cipher encrypts the TSM files written, if set.
+	Cipher *encryption.Cipher
+
+	// DirectIO writes TSM files with direct I/O, so compactions do not evict
+	// the pages of queries from the page cache.  Files are written through the
+	// page cache where direct I/O is not supported.
+	DirectIO bool
+
 	formatFileName FormatFileNameFunc
 	parseFileName  ParseFileNameFunc
 
@@ -1052,6 +1062,8 @@ func (c *Compactor) writeNewFiles(generation, sequence int, src []string, iter K
 			for _, f := range files {
 				if err := os.RemoveAll(f); err != nil {
 					return nil, err
+				} else if err := os.RemoveAll(measurementStatsPath(f)); err != nil {
+					return nil, err
 				}
 			}
 			// We hit an error and didn't finish the compaction.  Remove the temp file and abort.
@@ -1085,27 +1097,36 @@ func (c *Compactor) write(path string, iter KeyIterator, throttle bool) (err err
 	// Create the write for the new TSM file.
 	var (
 		w           TSMWriter
-		limitWriter syncingWriter = fd
+		limitWriter syncingWriter  = fd
+		out         io.WriteCloser = fd
 	)
 
+	// Fall back to writing through the page cache if the filesystem does not
+	// support direct I/O.
+	if c.DirectIO && file.SetDirectIO(fd, true) == nil {
+		dw := newDirectWriter(fd)
+		limitWriter, out = dw, dw
+	}
+
 	if c.RateLimit != nil && throttle {
-		limitWriter = limiter.NewWriterWithRate(fd, c.RateLimit)
+		limitWriter = limiter.NewWriterWithRate(out, c.RateLimit)
 	}
 
 	// Use a disk based TSM buffer if it looks like we might create a big index
 	// in memory.
 	if iter.EstimatedIndexSize() > 64*1024*1024 {
-		w, err = NewTSMWriterWithDiskBuffer(limitWriter)
+		w, err = NewTSMWriterWithDiskBuffer(limitWriter, WithWriterCipher(c.Cipher))
 		if err != nil {
 			return err
 		}
 	} else {
-		w, err = NewTSMWriter(limitWriter)
+		w, err = NewTSMWriter(limitWriter, WithWriterCipher(c.Cipher))
 		if err != nil {
 			return err
 		}
 	}
 
+	stats := newMeasurementStatsBuilder()
 	defer func() {
 		closeErr := w.Close()
 		if err == nil {
@@ -1116,13 +1137,17 @@ func (c *Compactor) write(path string, iter KeyIterator, throttle bool) (err err
 		_, inProgress := err.(errCompactionInProgress)
 		maxBlocks := err == ErrMaxBlocksExceeded
 		maxFileSize := err == errMaxFileExceeded
-		if inProgress || maxBlocks || maxFileSize {
+		if inProgress {
 			return
-		}
-
-		if err != nil {
+		} else if err != nil && !maxBlocks && !maxFileSize {
 			w.Remove()
+			return
 		}
+
+		// The file is kept, so write the statistics of its measurements. They
+		// are only a summary of the file and are rebuilt from its index if
+		// they cannot be written.
+		writeMeasurementStats(measurementStatsPath(path), stats.build())
 	}()
 
 	for iter.Next() {
@@ -1154,6 +1179,7 @@ func (c *Compactor) write(path string, iter KeyIterator, throttle bool) (err err
 		} else if err != nil {
 			return err
 		}
+		stats.addBlock(key, minTime, maxTime, block)
 
 		// If we have a max file size configured and we're over it, close out the file
 		// and return the error.
@@ -1401,8 +1427,9 @@ RETRY:
 				}
 
 				// This block may have ranges of time removed from it that would
-				// reduce the block min and max time.
-				tombstones := iter.r.TombstoneRange(key)
+				// reduce the block min and max time. Blocks without any are not
+				// decoded.
+				tombstones := overlappingTimeRanges(iter.r.TombstoneRange(key), minTime, maxTime)
 
 				var blk *block
 				if cap(k.buf[i]) > len(k.buf[i]) {
@@ -1433,7 +1460,7 @@ RETRY:
 						k.err = err
 					}
 
-					tombstones := iter.r.TombstoneRange(key)
+					tombstones := overlappingTimeRanges(iter.r.TombstoneRange(key), minTime, maxTime)
 
 					var blk *block
 					if cap(k.buf[i]) > len(k.buf[i]) {
@@ -1693,8 +1720,9 @@ RETRY:
 			}
 
 			// This block may have ranges of time removed from it that would
-			// reduce the block min and max time.
-			tombstones := iter.r.TombstoneRange(key)
+			// reduce the block min and max time. Blocks without any are not
+			// decoded.
+			tombstones := overlappingTimeRanges(iter.r.TombstoneRange(key), minTime, maxTime)
 
 			var blk *block
 			if cap(k.buf[i]) > len(k.buf[i]) {
@@ -1725,7 +1753,7 @@ RETRY:
 					k.err = err
 				}
 
-				tombstones := iter.r.TombstoneRange(key)
+				tombstones := overlappingTimeRanges(iter.r.TombstoneRange(key), minTime, maxTime)
 
 				var blk *block
 				if cap(k.buf[i]) > len(k.buf[i]) {
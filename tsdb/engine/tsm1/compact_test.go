@@ -88,6 +88,110 @@ func TestCompactor_Snapshot(t *testing.T) {
 	}
 }
 
+// Tests the measurement statistics written with a snapshot and rebuilt from
+// the index when missing.
+func TestCompactor_Snapshot_MeasurementStats(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	c := tsm1.NewCache(0)
+	for k, v := range map[string][]tsm1.Value{
+		"cpu,host=A#!~#value": {tsm1.NewValue(1, 1.0), tsm1.NewValue(2, 2.0)},
+		"cpu,host=B#!~#value": {tsm1.NewValue(3, 3.0)},
+		"mem#!~#value":        {tsm1.NewValue(10, int64(10))},
+	} {
+		if err := c.Write([]byte(k), v); err != nil {
+			t.Fatalf("failed to write key foo to cache: %s", err.Error())
+		}
+	}
+
+	compactor := tsm1.NewCompactor()
+	compactor.Dir = dir
+	compactor.FileStore = &fakeFileStore{}
+	compactor.Open()
+
+	files, err := compactor.WriteSnapshot(c)
+	if err != nil {
+		t.Fatalf("unexpected error writing snapshot: %v", err)
+	}
+
+	r := MustOpenTSMReader(files[0])
+	stats := r.MeasurementStats()
+	r.Close()
+
+	if got, exp := len(stats), 2; got != exp {
+		t.Fatalf("measurements length mismatch: got %v, exp %v", got, exp)
+	}
+	if s := stats["cpu"]; s.MinTime != 1 || s.MaxTime != 3 || s.PointN != 3 || s.DiskSize == 0 {
+		t.Fatalf("unexpected cpu statistics: %+v", s)
+	}
+	if s := stats["mem"]; s.MinTime != 10 || s.MaxTime != 10 || s.PointN != 1 || s.DiskSize == 0 {
+		t.Fatalf("unexpected mem statistics: %+v", s)
+	}
+
+	// Without the statistics file, the statistics are rebuilt from the index
+	// without counting points.
+	statsPath := filepath.Join(dir, "000000001-000000001."+tsm1.MeasurementStatsFileExtension)
+	if err := os.Remove(statsPath); err != nil {
+		t.Fatalf("unexpected error removing statistics: %v", err)
+	}
+
+	r = MustOpenTSMReader(files[0])
+	defer r.Close()
+	rebuilt := r.MeasurementStats()
+	for name, s := range stats {
+		s.PointN = 0
+		if got := rebuilt[name]; got != s {
+			t.Fatalf("rebuilt %s statistics mismatch: got %+v, exp %+v", name, got, s)
+		}
+	}
+}
+
+// Tests the measurement statistics of a file leave out the points deleted.
+func TestCompactor_Snapshot_MeasurementStats_Tombstones(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	c := tsm1.NewCache(0)
+	for k, v := range map[string][]tsm1.Value{
+		"cpu,host=A#!~#value": {tsm1.NewValue(1, 1.0), tsm1.NewValue(2, 2.0)},
+		"cpu,host=B#!~#value": {tsm1.NewValue(3, 3.0)},
+		"mem#!~#value":        {tsm1.NewValue(10, int64(10))},
+	} {
+		if err := c.Write([]byte(k), v); err != nil {
+			t.Fatalf("failed to write key foo to cache: %s", err.Error())
+		}
+	}
+
+	compactor := tsm1.NewCompactor()
+	compactor.Dir = dir
+	compactor.FileStore = &fakeFileStore{}
+	compactor.Open()
+
+	files, err := compactor.WriteSnapshot(c)
+	if err != nil {
+		t.Fatalf("unexpected error writing snapshot: %v", err)
+	}
+
+	r := MustOpenTSMReader(files[0])
+	defer r.Close()
+	if err := r.Delete([][]byte{[]byte("mem#!~#value")}); err != nil {
+		t.Fatalf("unexpected error deleting: %v", err)
+	} else if err := r.DeleteRange([][]byte{[]byte("cpu,host=B#!~#value")}, 3, 3); err != nil {
+		t.Fatalf("unexpected error deleting: %v", err)
+	} else if err := r.DeleteRange([][]byte{[]byte("cpu,host=A#!~#value")}, 2, 2); err != nil {
+		t.Fatalf("unexpected error deleting: %v", err)
+	}
+
+	stats := r.MeasurementStats()
+	if got, exp := len(stats), 1; got != exp {
+		t.Fatalf("measurements length mismatch: got %v, exp %v", got, exp)
+	}
+	if s := stats["cpu"]; s.MinTime != 1 || s.MaxTime != 2 || s.PointN <= 0 || s.PointN >= 3 || s.DiskSize == 0 {
+		t.Fatalf("unexpected cpu statistics: %+v", s)
+	}
+}
+
 func TestCompactor_CompactFullLastTimestamp(t *testing.T) {
 	dir := MustTempDir()
 	defer os.RemoveAll(dir)
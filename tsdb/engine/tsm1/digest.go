@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"sort"
 	"time"
+
+	"github.com/freetsdb/freetsdb/pkg/encryption"
 )
 
 const (
@@ -18,6 +20,9 @@ const (
 type DigestOptions struct {
 	MinTime, MaxTime int64
 	MinKey, MaxKey   []byte
+
+	// Cipher decrypts encrypted TSM files.
+	Cipher *encryption.Cipher
 }
 
 // DigestWithOptions writes a digest of dir to w using options to filter by
@@ -42,7 +47,7 @@ func DigestWithOptions(dir string, files []string, opts DigestOptions, w io.Writ
 			return err
 		}
 
-		r, err := NewTSMReader(f)
+		r, err := NewTSMReader(f, WithReaderCipher(opts.Cipher))
 		if err != nil {
 			return err
 		}
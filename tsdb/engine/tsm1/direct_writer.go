@@ -0,0 +1,116 @@
+package tsm1
+
+import (
+	"os"
+	"unsafe"
+
+	"github.com/freetsdb/freetsdb/pkg/file"
+)
+
+const (
+	// directIOAlignment is the alignment of the memory, offsets and sizes of
+	// direct I/O writes. It is a multiple of the logical block size of common
+	// filesystems.
+	directIOAlignment = 4096
+
+	// directIOBufferSize is the size of the writes of a directWriter.
+	directIOBufferSize = 1024 * 1024
+)
+
+// directWriter writes a file opened for direct I/O, so the data written does
+// not go through the page cache. Data is buffered until a full aligned block
+// can be written, and the unaligned tail of the file is written without
+// direct I/O when it is closed.
+type directWriter struct {
+	f   *os.File
+	buf []byte
+	n   int
+}
+
+// newDirectWriter returns a new writer to f, which must be empty and have
+// direct I/O enabled.
+func newDirectWriter(f *os.File) *directWriter {
+	return &directWriter{f: f, buf: alignedBuffer(directIOBufferSize)}
+}
+
+// alignedBuffer returns a buffer of size bytes aligned for direct I/O.
+func alignedBuffer(size int) []byte {
+	b := make([]byte, size+directIOAlignment)
+	var off int
+	if rem := int(uintptr(unsafe.Pointer(&b[0])) & (directIOAlignment - 1)); rem != 0 {
+		off = directIOAlignment - rem
+	}
+	return b[off : off+size : off+size]
+}
+
+// Write buffers p, writing the buffer to the file whenever it is full.
+func (w *directWriter) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		n := copy(w.buf[w.n:], p)
+		w.n += n
+		written += n
+		p = p[n:]
+
+		if w.n == len(w.buf) {
+			if err := w.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// flush writes the aligned part of the buffer to the file and keeps the rest.
+func (w *directWriter) flush() error {
+	n := w.n &^ (directIOAlignment - 1)
+	if n == 0 {
+		return nil
+	}
+
+	if _, err := w.f.Write(w.buf[:n]); err != nil {
+		return err
+	}
+	w.n = copy(w.buf, w.buf[n:w.n])
+	return nil
+}
+
+// Sync writes the aligned part of the buffer and fsyncs the file. The
+// unaligned tail is only written by Close.
+func (w *directWriter) Sync() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.f.Sync()
+}
+
+// Name returns the name of the file.
+func (w *directWriter) Name() string {
+	return w.f.Name()
+}
+
+// Close writes the rest of the buffer, fsyncs and closes the file.
+func (w *directWriter) Close() error {
+	if err := w.flush(); err != nil {
+		w.f.Close()
+		return err
+	}
+
+	if w.n > 0 {
+		// The tail is not a multiple of the alignment, so it cannot be written
+		// with direct I/O.
+		if err := file.SetDirectIO(w.f, false); err != nil {
+			w.f.Close()
+			return err
+		} else if _, err := w.f.Write(w.buf[:w.n]); err != nil {
+			w.f.Close()
+			return err
+		} else if err := w.f.Sync(); err != nil {
+			w.f.Close()
+			return err
+		}
+		w.n = 0
+	}
+
+	return w.f.Close()
+}
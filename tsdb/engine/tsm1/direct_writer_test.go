@@ -0,0 +1,52 @@
+package tsm1
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/pkg/file"
+)
+
+func TestDirectWriter(t *testing.T) {
+	dir := mustTempDir()
+	defer os.RemoveAll(dir)
+	f := mustTempFile(dir)
+
+	if err := file.SetDirectIO(f, true); err != nil {
+		f.Close()
+		t.Skipf("direct I/O not supported: %v", err)
+	}
+
+	// Write unaligned chunks, syncing in between, so the file ends with an
+	// unaligned tail.
+	w := newDirectWriter(f)
+	var exp []byte
+	for i := 0; i < 100; i++ {
+		b := make([]byte, rand.Intn(3*directIOAlignment)+1)
+		rand.Read(b)
+		exp = append(exp, b...)
+
+		if _, err := w.Write(b); err != nil {
+			t.Fatalf("unexpected error writing: %v", err)
+		}
+		if i%10 == 0 {
+			if err := w.Sync(); err != nil {
+				t.Fatalf("unexpected error syncing: %v", err)
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error closing: %v", err)
+	}
+
+	got, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error reading: %v", err)
+	}
+	if !bytes.Equal(got, exp) {
+		t.Fatalf("file mismatch: got %d bytes, exp %d", len(got), len(exp))
+	}
+}
@@ -48,6 +48,7 @@ import (
 
 func init() {
 	tsdb.RegisterEngine("tsm1", NewEngine)
+	tsdb.NewTSMBlockCache = func(size int64) interface{} { return newBlockCache(size) }
 }
 
 var (
@@ -87,6 +88,10 @@ const (
 
 	// deleteFlushThreshold is the size in bytes of a batch of series keys to delete.
 	deleteFlushThreshold = 50 * 1024 * 1024
+
+	// BackupWALDirectory is the directory of a shard backup holding the WAL
+	// segments whose points were not yet written to TSM files.
+	BackupWALDirectory = "wal"
 )
 
 // Statistics gathered by the engine.
@@ -131,6 +136,11 @@ const (
 type Engine struct {
 	mu sync.RWMutex
 
+	// readMu is held exclusively while the files written from a cache
+	// snapshot are added and the snapshot is cleared, so read snapshots see
+	// its points either in the cache or in the files.
+	readMu sync.RWMutex
+
 	index tsdb.Index
 
 	// The following group of fields is used to track the state of level compactions within the
@@ -149,6 +159,7 @@ type Engine struct {
 	snapWG   *sync.WaitGroup // waitgroup for running snapshot compactions
 
 	id           uint64
+	nodeID       uint64
 	path         string
 	sfile        *tsdb.SeriesFile
 	logger       *zap.Logger // Logger to be used for important messages
@@ -181,6 +192,13 @@ type Engine struct {
 	// Invoked when creating a backup file "as new".
 	formatFileName FormatFileNameFunc
 
+	// exportSequential hints to the kernel that the files streamed by backups and
+	// exports are read sequentially.
+	exportSequential bool
+
+	// walRecover salvages damaged WAL segments when the cache is reloaded.
+	walRecover bool
+
 	// Controls whether to enabled compactions when the engine is open
 	enableCompactionsOnOpen bool
 
@@ -204,6 +222,8 @@ func NewEngine(id uint64, idx tsdb.Index, path string, walPath string, sfile *ts
 	if opt.WALEnabled {
 		wal = NewWAL(walPath)
 		wal.syncDelay = time.Duration(opt.Config.WALFsyncDelay)
+		wal.groupCommitDelay = time.Duration(opt.Config.WALGroupCommitDelay)
+		wal.cipher = opt.Cipher
 	}
 
 	fs := NewFileStore(path)
@@ -212,6 +232,16 @@ func NewEngine(id uint64, idx tsdb.Index, path string, walPath string, sfile *ts
 		fs.WithObserver(opt.FileStoreObserver)
 	}
 	fs.tsmMMAPWillNeed = opt.Config.TSMWillNeed
+	fs.cipher = opt.Cipher
+	fs.readahead = int64(opt.Config.QueryReadaheadSize)
+	if opt.Config.TSMReadMode == tsdb.TSMReadModePread {
+		// Fall back to a cache of the shard when no shared cache was provided.
+		c, ok := opt.TSMBlockCache.(*blockCache)
+		if !ok {
+			c = newBlockCache(int64(opt.Config.TSMBlockCacheSize))
+		}
+		fs.blockCache = c
+	}
 
 	cache := NewCache(uint64(opt.Config.CacheMaxMemorySize))
 
@@ -219,6 +249,8 @@ func NewEngine(id uint64, idx tsdb.Index, path string, walPath string, sfile *ts
 	c.Dir = path
 	c.FileStore = fs
 	c.RateLimit = opt.CompactionThroughputLimiter
+	c.Cipher = opt.Cipher
+	c.DirectIO = opt.Config.CompactDirectIO
 
 	var planner CompactionPlanner = NewDefaultPlanner(fs, time.Duration(opt.Config.CompactFullWriteColdDuration))
 	if opt.CompactionPlannerCreator != nil {
@@ -230,6 +262,7 @@ func NewEngine(id uint64, idx tsdb.Index, path string, walPath string, sfile *ts
 	stats := &EngineStatistics{}
 	e := &Engine{
 		id:           id,
+		nodeID:       opt.NodeID,
 		path:         path,
 		index:        idx,
 		sfile:        sfile,
@@ -253,6 +286,8 @@ func NewEngine(id uint64, idx tsdb.Index, path string, walPath string, sfile *ts
 		compactionLimiter:             opt.CompactionLimiter,
 		scheduler:                     newScheduler(stats, opt.CompactionLimiter.Capacity()),
 		seriesIDSets:                  opt.SeriesIDSets,
+		exportSequential:              opt.Config.ExportFadviseSequential,
+		walRecover:                    opt.Config.WALRecover,
 	}
 
 	// Feature flag to enable per-series type checking, by default this is off and
@@ -334,7 +369,11 @@ func (e *Engine) Digest() (io.ReadCloser, int64, error) {
 	}
 
 	// Write the new digest to the tmp file.
-	if err := Digest(e.path, tsmfiles, tf); err != nil {
+	if err := DigestWithOptions(e.path, tsmfiles, DigestOptions{
+		MinTime: math.MinInt64,
+		MaxTime: math.MaxInt64,
+		Cipher:  e.FileStore.cipher,
+	}, tf); err != nil {
 		log.Info("Digest aborted, problem writing tmp digest", zap.Error(err))
 		tf.Close()
 		os.Remove(tf.Name())
@@ -567,6 +606,39 @@ func (e *Engine) MeasurementExists(name []byte) (bool, error) {
 	return e.index.MeasurementExists(name)
 }
 
+// MeasurementStats returns the statistics of the measurements in the TSM
+// files. Measurements dropped but not yet compacted away are left out.
+func (e *Engine) MeasurementStats() (map[string]tsdb.MeasurementStats, error) {
+	stats := e.FileStore.MeasurementStats()
+	for name := range stats {
+		if ok, err := e.index.MeasurementExists([]byte(name)); err != nil {
+			return nil, err
+		} else if !ok {
+			delete(stats, name)
+		}
+	}
+	return stats, nil
+}
+
+// Files returns the TSM files of the engine.
+func (e *Engine) Files() []tsdb.ShardFile {
+	stats := e.FileStore.Stats()
+	files := make([]tsdb.ShardFile, 0, len(stats))
+	for _, st := range stats {
+		gen, seq, _ := e.FileStore.ParseFileName(st.Path)
+		files = append(files, tsdb.ShardFile{
+			Path:         st.Path,
+			Size:         int64(st.Size),
+			MinTime:      st.MinTime,
+			MaxTime:      st.MaxTime,
+			Generation:   gen,
+			Sequence:     seq,
+			HasTombstone: st.HasTombstone,
+		})
+	}
+	return files
+}
+
 func (e *Engine) MeasurementNamesByRegex(re *regexp.Regexp) ([][]byte, error) {
 	return e.index.MeasurementNamesByRegex(re)
 }
@@ -710,6 +782,96 @@ func (e *Engine) DiskSize() int64 {
 	return e.FileStore.DiskSizeBytes() + walDiskSizeBytes
 }
 
+// DiskUsage returns the bytes of the TSM files, WAL segments and index files
+// of the engine. The sizes are maintained as files are written, so they are
+// not read from disk.
+func (e *Engine) DiskUsage() tsdb.DiskUsage {
+	u := tsdb.DiskUsage{TSM: e.FileStore.DiskSizeBytes()}
+	if e.WALEnabled {
+		u.WAL = e.WAL.DiskSizeBytes()
+	}
+	if e.index != nil {
+		u.Index = e.index.DiskSizeBytes()
+	}
+	return u
+}
+
+// IndexSeries adds the series of the TSM files and cache of the engine to
+// index. TSM files whose path is in seen are skipped, and the paths of the
+// files added are recorded in seen so that later calls only add the series
+// of new files.
+func (e *Engine) IndexSeries(index tsdb.Index, seen map[string]struct{}) error {
+	e.FileStore.mu.RLock()
+	var files []TSMFile
+	for _, f := range e.FileStore.files {
+		if _, ok := seen[f.Path()]; ok {
+			continue
+		}
+		// Ensure files are not unmapped while we're iterating over them.
+		f.Ref()
+		defer f.Unref()
+		files = append(files, f)
+	}
+	e.FileStore.mu.RUnlock()
+
+	keys := make([][]byte, 0, 10000)
+	add := func(key []byte) error {
+		seriesKey, _ := SeriesAndFieldFromCompositeKey(key)
+		if n := len(keys); n > 0 && bytes.Equal(keys[n-1], seriesKey) {
+			return nil
+		}
+		keys = append(keys, seriesKey)
+		if len(keys) == cap(keys) {
+			return e.indexSeriesKeys(index, &keys)
+		}
+		return nil
+	}
+
+	for _, f := range files {
+		for i := 0; i < f.KeyCount(); i++ {
+			key, _ := f.KeyAt(i)
+			if err := add(key); err != nil {
+				return err
+			}
+		}
+		seen[f.Path()] = struct{}{}
+	}
+
+	// Include the snapshot being written, whose TSM file may not be in the
+	// file store yet.
+	store, snapshot := e.Cache.readStores()
+	for _, st := range []storer{store, snapshot} {
+		if st == nil {
+			continue
+		}
+		if err := st.applySerial(func(key []byte, _ *entry) error {
+			return add(key)
+		}); err != nil {
+			return err
+		}
+	}
+	return e.indexSeriesKeys(index, &keys)
+}
+
+// indexSeriesKeys adds a batch of series keys to index and resets the batch.
+func (e *Engine) indexSeriesKeys(index tsdb.Index, keys *[][]byte) error {
+	if len(*keys) == 0 {
+		return nil
+	}
+	names := make([][]byte, 0, len(*keys))
+	tags := make([]models.Tags, 0, len(*keys))
+	for _, key := range *keys {
+		name, t := models.ParseKeyBytes(key)
+		names = append(names, name)
+		tags = append(tags, t)
+	}
+	if err := index.CreateSeriesListIfNotExists(*keys, names, tags); err != nil {
+		return err
+	}
+	*keys = make([][]byte, 0, cap(*keys))
+	return nil
+}
+
 // Open opens and initializes the engine.
 func (e *Engine) Open() error {
 	if err := os.MkdirAll(e.path, 0777); err != nil {
@@ -900,26 +1062,42 @@ func (e *Engine) Free() error {
 
 // Backup writes a tar archive of any TSM files modified since the passed
 // in time to the passed in writer. The basePath will be prepended to the names
-// of the files in the archive. It will force a snapshot of the WAL first
-// then perform the backup with a read lock against the file store. This means
-// that new TSM files will not be able to be created in this shard while the
-// backup is running. For shards that are still acively getting writes, this
-// could cause the WAL to backup, increasing memory usage and evenutally rejecting writes.
+// of the files in the archive. It tries to snapshot the WAL first, then takes
+// a consistent snapshot of the TSM files and of the WAL segments that were not
+// yet written to them, so that shards still actively getting writes are backed
+// up in full while writes continue.
 func (e *Engine) Backup(w io.Writer, basePath string, since time.Time) error {
-	path, err := e.CreateSnapshot()
+	path, err := e.createBackupSnapshot()
 	if err != nil {
 		return err
 	}
 	// Remove the temporary snapshot dir
 	defer os.RemoveAll(path)
 
-	return intar.Stream(w, path, basePath, intar.SinceFilterTarFile(since))
+	filter := intar.SinceFilterTarFile(since)
+	if e.exportSequential {
+		filter = func(fi os.FileInfo, shardRelativePath, fullPath string, tw *tar.Writer) error {
+			if !fi.ModTime().After(since) {
+				return nil
+			}
+			return intar.StreamFileSequential(fi, shardRelativePath, fullPath, tw)
+		}
+	}
+	return intar.Stream(w, path, basePath, filter)
+}
+
+// streamFile writes a file of a backup or an export to tw.
+func (e *Engine) streamFile(fi os.FileInfo, shardRelativePath, fullPath string, tw *tar.Writer) error {
+	if e.exportSequential {
+		return intar.StreamFileSequential(fi, shardRelativePath, fullPath, tw)
+	}
+	return intar.StreamFile(fi, shardRelativePath, fullPath, tw)
 }
 
 func (e *Engine) timeStampFilterTarFile(start, end time.Time) func(f os.FileInfo, shardRelativePath, fullPath string, tw *tar.Writer) error {
 	return func(fi os.FileInfo, shardRelativePath, fullPath string, tw *tar.Writer) error {
 		if !strings.HasSuffix(fi.Name(), ".tsm") {
-			return intar.StreamFile(fi, shardRelativePath, fullPath, tw)
+			return e.streamFile(fi, shardRelativePath, fullPath, tw)
 		}
 
 		var tombstonePath string
@@ -927,7 +1105,7 @@ func (e *Engine) timeStampFilterTarFile(start, end time.Time) func(f os.FileInfo
 		if err != nil {
 			return err
 		}
-		r, err := NewTSMReader(f)
+		r, err := NewTSMReader(f, WithReaderCipher(e.FileStore.cipher))
 		if err != nil {
 			return err
 		}
@@ -935,7 +1113,7 @@ func (e *Engine) timeStampFilterTarFile(start, end time.Time) func(f os.FileInfo
 		// Grab the tombstone file if one exists.
 		if r.HasTombstones() {
 			tombstonePath = filepath.Base(r.TombstoneFiles()[0].Path)
-			return intar.StreamFile(fi, shardRelativePath, tombstonePath, tw)
+			return e.streamFile(fi, shardRelativePath, tombstonePath, tw)
 		}
 
 		min, max := r.TimeRange()
@@ -963,7 +1141,7 @@ func (e *Engine) timeStampFilterTarFile(start, end time.Time) func(f os.FileInfo
 
 		// the TSM file is 100% inside the range, so we can just write it without scanning each block
 		if min >= start.UnixNano() && max <= end.UnixNano() {
-			if err := intar.StreamFile(fi, shardRelativePath, fullPath, tw); err != nil {
+			if err := e.streamFile(fi, shardRelativePath, fullPath, tw); err != nil {
 				return err
 			}
 		}
@@ -990,7 +1168,7 @@ func (e *Engine) filterFileToBackup(r *TSMReader, fi os.FileInfo, shardRelativeP
 	}
 	defer os.Remove(path)
 
-	w, err := NewTSMWriter(out)
+	w, err := NewTSMWriter(out, WithWriterCipher(e.FileStore.cipher))
 	if err != nil {
 		return err
 	}
@@ -1059,12 +1237,24 @@ func (e *Engine) Import(r io.Reader, basePath string) error {
 // from the archive matching basePath to the shard.
 // If asNew is true, each file will be installed as a new TSM file even if an
 // existing file with the same name in the backup exists.
-func (e *Engine) overlay(r io.Reader, basePath string, asNew bool) error {
+func (e *Engine) overlay(r io.Reader, basePath string, asNew bool) (err error) {
+	// The WAL segments of the archive are replayed once its files are installed.
+	var segments []string
+	defer func() {
+		for _, segment := range segments {
+			os.Remove(segment)
+		}
+	}()
+
 	// Copy files from archive while under lock to prevent reopening.
 	newFiles, err := func() ([]string, error) {
 		e.mu.Lock()
 		defer e.mu.Unlock()
 
+		if err := os.RemoveAll(filepath.Join(e.path, tsdb.RestoredIndexDirectory+"."+TmpTSMFileExtension)); err != nil {
+			return nil, err
+		}
+
 		var newFiles []string
 		tr := tar.NewReader(r)
 		for {
@@ -1072,6 +1262,13 @@ func (e *Engine) overlay(r io.Reader, basePath string, asNew bool) error {
 				break
 			} else if err != nil {
 				return nil, err
+			} else if strings.HasSuffix(fileName, "."+WALFileExtension+"."+TmpTSMFileExtension) {
+				segments = append(segments, fileName)
+			} else if strings.HasSuffix(fileName, "."+tombstoneFileExtension+"."+TmpTSMFileExtension) {
+				// Tombstones must be in place before their TSM files are opened.
+				if err := os.Rename(fileName, strings.TrimSuffix(fileName, "."+TmpTSMFileExtension)); err != nil {
+					return nil, err
+				}
 			} else if fileName != "" {
 				newFiles = append(newFiles, fileName)
 			}
@@ -1092,6 +1289,20 @@ func (e *Engine) overlay(r io.Reader, basePath string, asNew bool) error {
 		return err
 	}
 
+	// A TSI index restored from the archive replaces the index when the shard
+	// is reopened, so only the fields of the restored keys are added.
+	addKeys := e.addToIndexFromKey
+	if restored, err := e.installRestoredIndex(); err != nil {
+		return err
+	} else if restored {
+		addKeys = e.addFieldsFromKey
+		defer func() {
+			if err == nil {
+				err = e.fieldset.Save()
+			}
+		}()
+	}
+
 	// Load any new series keys to the index
 	tsmFiles := make([]TSMFile, 0, len(newFiles))
 	defer func() {
@@ -1115,7 +1326,7 @@ func (e *Engine) overlay(r io.Reader, basePath string, asNew bool) error {
 			return err
 		}
 
-		r, err := NewTSMReader(fd)
+		r, err := NewTSMReader(fd, WithReaderCipher(e.FileStore.cipher))
 		if err != nil {
 			return err
 		}
@@ -1140,7 +1351,7 @@ func (e *Engine) overlay(r io.Reader, basePath string, asNew bool) error {
 
 		if len(keys) == cap(keys) {
 			// Send batch of keys to the index.
-			if err := e.addToIndexFromKey(keys, fieldTypes); err != nil {
+			if err := addKeys(keys, fieldTypes); err != nil {
 				return err
 			}
 
@@ -1151,7 +1362,133 @@ func (e *Engine) overlay(r io.Reader, basePath string, asNew bool) error {
 
 	if len(keys) > 0 {
 		// Add remaining partial batch.
-		if err := e.addToIndexFromKey(keys, fieldTypes); err != nil {
+		if err := addKeys(keys, fieldTypes); err != nil {
+			return err
+		}
+	}
+
+	for _, segment := range segments {
+		if err := e.replayBackupSegment(segment, addKeys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// installRestoredIndex validates the TSI index restored from an archive and
+// moves it where the shard installs it when reopened. An index whose series
+// do not match the series file, as when the series file of the database was
+// not restored before its shards, is removed, and the index is rebuilt from
+// the restored keys instead.
+func (e *Engine) installRestoredIndex() (bool, error) {
+	tsiIndex, ok := e.index.(*tsi1.Index)
+	if !ok {
+		return false, nil
+	}
+
+	staged := filepath.Join(e.path, tsdb.RestoredIndexDirectory+"."+TmpTSMFileExtension)
+	if _, err := os.Stat(staged); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(staged)
+
+	if err := tsi1.ValidateSnapshot(staged, tsiIndex.Path(), e.sfile); err != nil {
+		e.logger.Info("Rebuilding index of restored shard", zap.Error(err))
+		return false, nil
+	}
+
+	restored := filepath.Join(e.path, tsdb.RestoredIndexDirectory)
+	if err := os.RemoveAll(restored); err != nil {
+		return false, err
+	}
+	if err := os.Rename(staged, restored); err != nil {
+		return false, err
+	}
+	return true, file.SyncDir(e.path)
+}
+
+// addFieldsFromKey adds the fields of composite keys to the measurement fields.
+func (e *Engine) addFieldsFromKey(keys [][]byte, fieldTypes []influxql.DataType) error {
+	for i, key := range keys {
+		seriesKey, field := SeriesAndFieldFromCompositeKey(key)
+		mf := e.fieldset.CreateFieldsIfNotExists(models.ParseName(seriesKey))
+		if err := mf.CreateFieldIfNotExists(field, fieldTypes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replayBackupSegment writes the entries of a WAL segment of a backup to the
+// cache and the WAL of the engine, adding the keys written with addKeys.
+func (e *Engine) replayBackupSegment(path string, addKeys func([][]byte, []influxql.DataType) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	r := NewWALSegmentReader(f)
+	r.cipher = e.FileStore.cipher
+	defer r.Close()
+
+	// The cache may be over its max size until it is snapshotted.
+	limit := e.Cache.MaxSize()
+	e.Cache.SetMaxSize(0)
+	defer e.Cache.SetMaxSize(limit)
+
+	for r.Next() {
+		entry, err := r.Read()
+		if err != nil {
+			return fmt.Errorf("read wal segment %s: %s", filepath.Base(path), err)
+		}
+
+		switch t := entry.(type) {
+		case *WriteWALEntry:
+			keys := make([][]byte, 0, len(t.Values))
+			fieldTypes := make([]influxql.DataType, 0, len(t.Values))
+			for k, values := range t.Values {
+				if len(values) == 0 {
+					continue
+				}
+				keys = append(keys, []byte(k))
+				fieldTypes = append(fieldTypes, BlockTypeToInfluxQLDataType(valueType(values[0])))
+			}
+			if err := addKeys(keys, fieldTypes); err != nil {
+				return err
+			}
+			if err := e.writeWALEntry(t); err != nil {
+				return err
+			}
+		case *DeleteRangeWALEntry:
+			e.Cache.DeleteRange(t.Keys, t.Min, t.Max)
+			if e.WALEnabled {
+				if _, err := e.WAL.DeleteRange(t.Keys, t.Min, t.Max); err != nil {
+					return err
+				}
+			}
+		case *DeleteWALEntry:
+			e.Cache.Delete(t.Keys)
+			if e.WALEnabled {
+				if _, err := e.WAL.Delete(t.Keys); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return r.Error()
+}
+
+// writeWALEntry writes the values of a WAL entry to the cache and the WAL.
+func (e *Engine) writeWALEntry(entry *WriteWALEntry) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if err := e.Cache.WriteMulti(entry.Values); err != nil {
+		return err
+	}
+	if e.WALEnabled {
+		if _, err := e.WAL.WriteMulti(entry.Values); err != nil {
 			return err
 		}
 	}
@@ -1169,12 +1506,18 @@ func (e *Engine) readFileFromBackup(tr *tar.Reader, shardRelativePath string, as
 		return "", err
 	}
 
-	if !strings.HasSuffix(hdr.Name, TSMFileExtension) {
+	nativeFileName := filepath.FromSlash(hdr.Name)
+	isSegment := strings.HasSuffix(hdr.Name, "."+WALFileExtension) &&
+		filepath.Base(filepath.Dir(nativeFileName)) == BackupWALDirectory
+	isIndex := e.isRestoredIndexFile(nativeFileName, shardRelativePath, asNew)
+	// Tombstones are restored with the TSM files they belong to, but dropped
+	// when the files are imported as new ones.
+	isTombstone := !asNew && strings.HasSuffix(hdr.Name, "."+tombstoneFileExtension)
+	if !strings.HasSuffix(hdr.Name, TSMFileExtension) && !isSegment && !isIndex && !isTombstone {
 		// This isn't a .tsm file.
 		return "", nil
 	}
 
-	nativeFileName := filepath.FromSlash(hdr.Name)
 	// Skip file if it does not have a matching prefix.
 	if !strings.HasPrefix(nativeFileName, shardRelativePath) {
 		return "", nil
@@ -1184,6 +1527,16 @@ func (e *Engine) readFileFromBackup(tr *tar.Reader, shardRelativePath string, as
 		return "", err
 	}
 
+	// WAL segments are copied next to the shard files until they are replayed.
+	if isSegment {
+		filename = filepath.Base(filename)
+	}
+
+	// TSI index files are staged until the restored index is validated.
+	if isIndex {
+		return "", e.stageRestoredIndexFile(tr, hdr, filename)
+	}
+
 	// If this is a directory entry (usually just `index` for tsi), create it an move on.
 	if hdr.Typeflag == tar.TypeDir {
 		if err := os.MkdirAll(filepath.Join(e.path, filename), os.FileMode(hdr.Mode).Perm()); err != nil {
@@ -1192,7 +1545,7 @@ func (e *Engine) readFileFromBackup(tr *tar.Reader, shardRelativePath string, as
 		return "", nil
 	}
 
-	if asNew {
+	if asNew && !isSegment {
 		filename = e.formatFileName(e.FileStore.NextGeneration(), 1) + "." + TSMFileExtension
 	}
 
@@ -1217,6 +1570,40 @@ func (e *Engine) readFileFromBackup(tr *tar.Reader, shardRelativePath string, as
 	return tmp, nil
 }
 
+// isRestoredIndexFile returns true if the archive file name is a file of the
+// TSI index of the shard at shardRelativePath, which is restored along with the
+// shard files when they are not imported as new files.
+func (e *Engine) isRestoredIndexFile(name, shardRelativePath string, asNew bool) bool {
+	if _, ok := e.index.(*tsi1.Index); !ok || asNew {
+		return false
+	}
+	rel, err := filepath.Rel(shardRelativePath, name)
+	return err == nil && strings.HasPrefix(rel, "index"+string(filepath.Separator))
+}
+
+// stageRestoredIndexFile copies the TSI index file of the archive named
+// filename, relative to the shard, to the staged restored index.
+func (e *Engine) stageRestoredIndexFile(tr *tar.Reader, hdr *tar.Header, filename string) error {
+	path := filepath.Join(e.path, tsdb.RestoredIndexDirectory+"."+TmpTSMFileExtension, strings.TrimPrefix(filename, "index"+string(filepath.Separator)))
+	if hdr.Typeflag == tar.TypeDir {
+		return os.MkdirAll(path, 0777)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.CopyN(f, tr, hdr.Size); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
 // addToIndexFromKey will pull the measurement names, series keys, and field
 // names from composite keys, and add them to the database index and measurement
 // fields.
@@ -1255,6 +1642,12 @@ func (e *Engine) addToIndexFromKey(keys [][]byte, fieldTypes []influxql.DataType
 // WritePoints writes metadata and point data into the engine.
 // It returns an error if new points are added to an existing key.
 func (e *Engine) WritePoints(points []models.Point) error {
+	return e.WritePointsWithContext(context.Background(), points)
+}
+
+// WritePointsWithContext writes points like WritePoints, recording the time
+// spent writing to the cache and the WAL in the write trace of ctx.
+func (e *Engine) WritePointsWithContext(ctx context.Context, points []models.Point) error {
 	values := make(map[string][]Value, len(points))
 	var (
 		keyBuf    []byte
@@ -1344,15 +1737,21 @@ func (e *Engine) WritePoints(points []models.Point) error {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
+	trace := tsdb.WriteTraceFromContext(ctx)
+
 	// first try to write to the cache
+	start := time.Now()
 	if err := e.Cache.WriteMulti(values); err != nil {
 		return err
 	}
+	trace.Record("cache", e.id, start)
 
 	if e.WALEnabled {
+		start = time.Now()
 		if _, err := e.WAL.WriteMulti(values); err != nil {
 			return err
 		}
+		trace.Record("wal", e.id, start)
 	}
 	return seriesErr
 }
@@ -1865,6 +2264,75 @@ func (e *Engine) CreateSnapshot() (string, error) {
 	return path, nil
 }
 
+// createBackupSnapshot creates a temp directory that holds temporary hardlinks
+// to the shard files, and to the closed WAL segments whose points are not in
+// those files in its wal directory. The cache is written to a TSM file first
+// if possible, but a hot shard whose cache is already being written is
+// snapshotted with its WAL segments instead. A TSI index is snapshotted in
+// the index directory, after the files so that it has all of their series.
+func (e *Engine) createBackupSnapshot() (string, error) {
+	var path string
+	var err error
+	if e.WALEnabled {
+		path, err = e.createWALSnapshot()
+	} else {
+		path, err = e.CreateSnapshot()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if tsiIndex, ok := e.index.(*tsi1.Index); ok {
+		if err := tsiIndex.SnapshotTo(filepath.Join(path, "index")); err != nil {
+			os.RemoveAll(path)
+			return "", err
+		}
+	}
+	return path, nil
+}
+
+// createWALSnapshot creates a temp directory that holds temporary hardlinks to
+// the shard files and to the closed WAL segments, for createBackupSnapshot.
+func (e *Engine) createWALSnapshot() (string, error) {
+	if err := e.WriteSnapshot(); err != nil && err != ErrSnapshotInProgress && err != errCompactionsDisabled {
+		return "", err
+	}
+
+	// Closing the current segment and linking the files under the write lock
+	// keeps a cache snapshot from being committed in between, so that each
+	// point is either in a linked TSM file or in a linked segment.
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.WAL.CloseSegment(); err != nil {
+		return "", err
+	}
+	segments, err := e.WAL.ClosedSegments()
+	if err != nil {
+		return "", err
+	}
+
+	path, err := e.FileStore.CreateSnapshot()
+	if err != nil {
+		return "", err
+	} else if len(segments) == 0 {
+		return path, nil
+	}
+
+	walPath := filepath.Join(path, BackupWALDirectory)
+	if err := os.Mkdir(walPath, 0777); err != nil {
+		os.RemoveAll(path)
+		return "", err
+	}
+	for _, segment := range segments {
+		if err := os.Link(segment, filepath.Join(walPath, filepath.Base(segment))); err != nil {
+			os.RemoveAll(path)
+			return "", fmt.Errorf("error creating wal hard link: %q", err)
+		}
+	}
+	return path, nil
+}
+
 // writeSnapshotAndCommit will write the passed cache to a new TSM file and remove the closed WAL segments.
 func (e *Engine) writeSnapshotAndCommit(log *zap.Logger, closedFiles []string, snapshot *Cache) (err error) {
 	defer func() {
@@ -1884,7 +2352,9 @@ func (e *Engine) writeSnapshotAndCommit(log *zap.Logger, closedFiles []string, s
 	defer e.mu.RUnlock()
 
 	// update the file store with these new files
+	e.readMu.Lock()
 	if err := e.FileStore.Replace(nil, newFiles); err != nil {
+		e.readMu.Unlock()
 		log.Info("Error adding new TSM files from snapshot. Removing temp files.", zap.Error(err))
 
 		// Remove the new snapshot files. We will try again.
@@ -1898,6 +2368,7 @@ func (e *Engine) writeSnapshotAndCommit(log *zap.Logger, closedFiles []string, s
 
 	// clear the snapshot from the in-memory cache, then the old WAL files
 	e.Cache.ClearSnapshot(true)
+	e.readMu.Unlock()
 
 	if e.WALEnabled {
 		if err := e.WAL.Remove(closedFiles); err != nil {
@@ -2248,11 +2719,20 @@ func (e *Engine) reloadCache() error {
 	e.Cache.SetMaxSize(0)
 
 	loader := NewCacheLoader(files)
+	loader.Cipher = e.FileStore.cipher
+	loader.Recover = e.walRecover
 	loader.WithLogger(e.logger)
 	if err := loader.Load(e.Cache); err != nil {
 		return err
 	}
 
+	// The open segment may have been replaced by salvage: write to a new one.
+	if loader.Salvaged() > 0 {
+		if err := e.WAL.CloseSegment(); err != nil {
+			return err
+		}
+	}
+
 	e.traceLogger.Info("Reloaded WAL cache",
 		zap.String("path", e.WAL.Path()), zap.Duration("duration", time.Since(now)))
 	return nil
@@ -2319,6 +2799,14 @@ func (e *Engine) CreateIterator(ctx context.Context, measurement string, opt que
 		defer group.GetTimer(planningTimer).UpdateSince(start)
 	}
 
+	// Build every cursor from the same snapshot, unless the query already
+	// reads from snapshots. Cursors keep the files they read referenced.
+	if tsdb.ReadSnapshotsFromContext(ctx) == nil {
+		var snapshots *tsdb.ReadSnapshots
+		ctx, snapshots = tsdb.NewReadSnapshotsContext(ctx)
+		defer snapshots.Release()
+	}
+
 	if call, ok := opt.Expr.(*influxql.Call); ok {
 		if opt.Interval.IsZero() {
 			if call.Name == "first" || call.Name == "last" {
@@ -2660,6 +3148,17 @@ func (e *Engine) createVarRefSeriesIterator(ctx context.Context, ref *influxql.V
 	if len(opt.Aux) > 0 {
 		aux = make([]cursorAt, len(opt.Aux))
 		for i, ref := range opt.Aux {
+			// The shard and the replica serving it are the same for every
+			// point in the series.
+			switch ref.Val {
+			case "_shard":
+				aux[i] = &literalValueCursor{value: int64(e.id)}
+				continue
+			case "_replica":
+				aux[i] = &literalValueCursor{value: int64(e.nodeID)}
+				continue
+			}
+
 			// Create cursor from field if a tag wasn't requested.
 			if ref.Type != influxql.Tag {
 				cur := e.buildCursor(ctx, name, seriesKey, tfs, &ref, opt)
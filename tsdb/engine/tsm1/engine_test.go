@@ -26,10 +26,16 @@ import (
 	"github.com/freetsdb/freetsdb/query"
 	"github.com/freetsdb/freetsdb/tsdb"
 	"github.com/freetsdb/freetsdb/tsdb/engine/tsm1"
+	"github.com/freetsdb/freetsdb/tsdb/enginetest"
 	"github.com/freetsdb/freetsdb/tsdb/index/inmem"
 	"github.com/freetsdb/freetsdb/services/influxql"
 )
 
+// Ensure the engine passes the engine conformance tests.
+func TestEngine_Conformance(t *testing.T) {
+	enginetest.Run(t, "tsm1")
+}
+
 // Ensure that deletes only sent to the WAL will clear out the data from the cache on restart
 func TestEngine_DeleteWALLoadMetadata(t *testing.T) {
 	for _, index := range tsdb.RegisteredIndexes() {
@@ -322,6 +328,63 @@ func TestEngine_Backup(t *testing.T) {
 	}
 }
 
+// Ensure a shard whose cache is being written is backed up with its WAL
+// segments, and that restoring the backup replays them.
+func TestEngine_Backup_HotShard(t *testing.T) {
+	e := MustOpenEngine(inmem.IndexName)
+	defer e.Close()
+
+	if err := e.WritePointsString("cpu,host=A value=1.1 1000000000"); err != nil {
+		t.Fatalf("failed to write points: %s", err.Error())
+	}
+	e.MustWriteSnapshot()
+
+	// A cache snapshot in progress keeps the backup from writing the cache.
+	if _, err := e.Cache.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WritePointsString("cpu,host=B value=1.2 2000000000"); err != nil {
+		t.Fatalf("failed to write points: %s", err.Error())
+	}
+
+	b := bytes.NewBuffer(nil)
+	if err := e.Backup(b, "", time.Unix(0, 0)); err != nil {
+		t.Fatalf("failed to backup: %s", err.Error())
+	}
+	e.Cache.ClearSnapshot(false)
+
+	var tsmFiles, segments int
+	tr := tar.NewReader(bytes.NewReader(b.Bytes()))
+	th, err := tr.Next()
+	for err == nil {
+		switch {
+		case strings.HasSuffix(th.Name, "."+tsm1.TSMFileExtension):
+			tsmFiles++
+		case strings.HasPrefix(th.Name, tsm1.BackupWALDirectory+"/") && strings.HasSuffix(th.Name, "."+tsm1.WALFileExtension):
+			segments++
+		}
+		th, err = tr.Next()
+	}
+	if err != io.EOF {
+		t.Fatalf("Problem reading tar header: %s", err)
+	} else if tsmFiles != 1 || segments == 0 {
+		t.Fatalf("unexpected backup: %d tsm files, %d wal segments", tsmFiles, segments)
+	}
+
+	e2 := MustOpenEngine(inmem.IndexName)
+	defer e2.Close()
+	if err := e2.Restore(bytes.NewReader(b.Bytes()), ""); err != nil {
+		t.Fatalf("failed to restore: %s", err.Error())
+	}
+
+	if got := e2.FileStore.Count(); got != 1 {
+		t.Fatalf("unexpected file count: %d", got)
+	}
+	if values := e2.Cache.Values([]byte("cpu,host=B#!~#value")); len(values) != 1 || values[0].Value() != 1.2 {
+		t.Fatalf("unexpected cache values: %v", values)
+	}
+}
+
 func TestEngine_Export(t *testing.T) {
 	// Generate temporary file.
 	f, _ := ioutil.TempFile("", "tsm")
@@ -643,6 +706,73 @@ func TestEngine_CreateIterator_Cache_Ascending(t *testing.T) {
 	}
 }
 
+// Ensure iterators created with read snapshots see the points of a cache
+// snapshot exactly once, even after it is written to a file.
+func TestEngine_CreateIterator_ReadSnapshot(t *testing.T) {
+	t.Parallel()
+
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			e := MustOpenEngine(index)
+			defer e.Close()
+
+			e.MeasurementFields([]byte("cpu")).CreateFieldIfNotExists([]byte("value"), influxql.Float)
+			e.CreateSeriesIfNotExists([]byte("cpu,host=A"), []byte("cpu"), models.NewTags(map[string]string{"host": "A"}))
+
+			if err := e.WritePointsString(`cpu,host=A value=1.1 1000000000`); err != nil {
+				t.Fatalf("failed to write points: %s", err.Error())
+			}
+
+			opt := query.IteratorOptions{
+				Expr:       influxql.MustParseExpr(`value`),
+				Dimensions: []string{"host"},
+				StartTime:  influxql.MinTime,
+				EndTime:    influxql.MaxTime,
+				Ascending:  true,
+			}
+			readAll := func(ctx context.Context) []int64 {
+				itr, err := e.CreateIterator(ctx, "cpu", opt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer itr.Close()
+
+				var times []int64
+				for {
+					p, err := itr.(query.FloatIterator).Next()
+					if err != nil {
+						t.Fatal(err)
+					} else if p == nil {
+						return times
+					}
+					times = append(times, p.Time)
+				}
+			}
+
+			ctx, snapshots := tsdb.NewReadSnapshotsContext(context.Background())
+			if got, exp := readAll(ctx), []int64{1000000000}; !reflect.DeepEqual(got, exp) {
+				t.Fatalf("unexpected points: %v", got)
+			}
+
+			// Move the point to a file, then write another one to the cache.
+			if err := e.WriteSnapshot(); err != nil {
+				t.Fatal(err)
+			} else if err := e.WritePointsString(`cpu,host=A value=1.2 2000000000`); err != nil {
+				t.Fatalf("failed to write points: %s", err.Error())
+			}
+
+			if got, exp := readAll(ctx), []int64{1000000000}; !reflect.DeepEqual(got, exp) {
+				t.Fatalf("unexpected points from snapshot: %v", got)
+			}
+			snapshots.Release()
+
+			if got, exp := readAll(context.Background()), []int64{1000000000, 2000000000}; !reflect.DeepEqual(got, exp) {
+				t.Fatalf("unexpected points: %v", got)
+			}
+		})
+	}
+}
+
 // Ensure engine can create an descending iterator for cached values.
 func TestEngine_CreateIterator_Cache_Descending(t *testing.T) {
 	t.Parallel()
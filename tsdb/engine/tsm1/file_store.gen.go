@@ -27,7 +27,7 @@ LOOP:
 	values = values.Exclude(first.readMin, first.readMax)
 
 	// Remove any tombstones
-	tombstones := first.r.TombstoneRange(c.key)
+	tombstones := first.tombstones
 	values = excludeTombstonesFloatValues(tombstones, values)
 	// If there are no values in this first block (all tombstoned or previously read) and
 	// we have more potential blocks too search.  Try again.
@@ -96,7 +96,7 @@ LOOP:
 				c.col.GetCounter(floatBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
 
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			v = excludeTombstonesFloatValues(tombstones, v)
 
@@ -158,7 +158,7 @@ LOOP:
 				c.col.GetCounter(floatBlocksDecodedCounter).Add(1)
 				c.col.GetCounter(floatBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			v = excludeTombstonesFloatValues(tombstones, v)
 
@@ -213,7 +213,7 @@ LOOP:
 	values = values.Exclude(first.readMin, first.readMax)
 
 	// Remove any tombstones
-	tombstones := first.r.TombstoneRange(c.key)
+	tombstones := first.tombstones
 	values = excludeTombstonesIntegerValues(tombstones, values)
 	// If there are no values in this first block (all tombstoned or previously read) and
 	// we have more potential blocks too search.  Try again.
@@ -282,7 +282,7 @@ LOOP:
 				c.col.GetCounter(integerBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
 
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			v = excludeTombstonesIntegerValues(tombstones, v)
 
@@ -344,7 +344,7 @@ LOOP:
 				c.col.GetCounter(integerBlocksDecodedCounter).Add(1)
 				c.col.GetCounter(integerBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			v = excludeTombstonesIntegerValues(tombstones, v)
 
@@ -399,7 +399,7 @@ LOOP:
 	values = values.Exclude(first.readMin, first.readMax)
 
 	// Remove any tombstones
-	tombstones := first.r.TombstoneRange(c.key)
+	tombstones := first.tombstones
 	values = excludeTombstonesUnsignedValues(tombstones, values)
 	// If there are no values in this first block (all tombstoned or previously read) and
 	// we have more potential blocks too search.  Try again.
@@ -468,7 +468,7 @@ LOOP:
 				c.col.GetCounter(unsignedBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
 
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			v = excludeTombstonesUnsignedValues(tombstones, v)
 
@@ -530,7 +530,7 @@ LOOP:
 				c.col.GetCounter(unsignedBlocksDecodedCounter).Add(1)
 				c.col.GetCounter(unsignedBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			v = excludeTombstonesUnsignedValues(tombstones, v)
 
@@ -585,7 +585,7 @@ LOOP:
 	values = values.Exclude(first.readMin, first.readMax)
 
 	// Remove any tombstones
-	tombstones := first.r.TombstoneRange(c.key)
+	tombstones := first.tombstones
 	values = excludeTombstonesStringValues(tombstones, values)
 	// If there are no values in this first block (all tombstoned or previously read) and
 	// we have more potential blocks too search.  Try again.
@@ -654,7 +654,7 @@ LOOP:
 				c.col.GetCounter(stringBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
 
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			v = excludeTombstonesStringValues(tombstones, v)
 
@@ -716,7 +716,7 @@ LOOP:
 				c.col.GetCounter(stringBlocksDecodedCounter).Add(1)
 				c.col.GetCounter(stringBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			v = excludeTombstonesStringValues(tombstones, v)
 
@@ -771,7 +771,7 @@ LOOP:
 	values = values.Exclude(first.readMin, first.readMax)
 
 	// Remove any tombstones
-	tombstones := first.r.TombstoneRange(c.key)
+	tombstones := first.tombstones
 	values = excludeTombstonesBooleanValues(tombstones, values)
 	// If there are no values in this first block (all tombstoned or previously read) and
 	// we have more potential blocks too search.  Try again.
@@ -840,7 +840,7 @@ LOOP:
 				c.col.GetCounter(booleanBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
 
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			v = excludeTombstonesBooleanValues(tombstones, v)
 
@@ -902,7 +902,7 @@ LOOP:
 				c.col.GetCounter(booleanBlocksDecodedCounter).Add(1)
 				c.col.GetCounter(booleanBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			v = excludeTombstonesBooleanValues(tombstones, v)
 
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/pkg/encryption"
 	"github.com/freetsdb/freetsdb/pkg/file"
 	"github.com/freetsdb/freetsdb/pkg/limiter"
 	"github.com/freetsdb/freetsdb/pkg/metrics"
@@ -80,6 +81,9 @@ type TSMFile interface {
 	// TombstoneRange returns ranges of time that are deleted for the given key.
 	TombstoneRange(key []byte) []TimeRange
 
+	// MeasurementStats returns the statistics of the measurements in the file.
+	MeasurementStats() map[string]tsdb.MeasurementStats
+
 	// KeyRange returns the min and max keys in the file.
 	KeyRange() ([]byte, []byte)
 
@@ -151,6 +155,10 @@ type TSMFile interface {
 const (
 	statFileStoreBytes = "diskBytes"
 	statFileStoreCount = "numFiles"
+
+	// The tombstones pending compaction.
+	statFileStoreTombstoneFiles = "numTombstoneFiles"
+	statFileStoreTombstoneBytes = "tombstoneDiskBytes"
 )
 
 var (
@@ -178,8 +186,11 @@ type FileStore struct {
 	dir               string
 
 	files           []TSMFile
-	tsmMMAPWillNeed bool          // If true then the kernel will be advised MMAP_WILLNEED for TSM files.
-	openLimiter     limiter.Fixed // limit the number of concurrent opening TSM files.
+	tsmMMAPWillNeed bool               // If true then the kernel will be advised MMAP_WILLNEED for TSM files.
+	openLimiter     limiter.Fixed      // limit the number of concurrent opening TSM files.
+	cipher          *encryption.Cipher // decrypts encrypted TSM files.
+	blockCache      *blockCache        // if set, TSM files are read with pread rather than mmap.
+	readahead       int64              // bytes following the blocks read to read ahead.
 
 	logger       *zap.Logger // Logger to be used for important messages
 	traceLogger  *zap.Logger // Logger to be used when trace-logging is on.
@@ -280,12 +291,24 @@ type FileStoreStatistics struct {
 
 // Statistics returns statistics for periodic monitoring.
 func (f *FileStore) Statistics(tags map[string]string) []models.Statistic {
+	var tombstoneFiles, tombstoneBytes int64
+	f.mu.RLock()
+	for _, fd := range f.files {
+		for _, ts := range fd.TombstoneFiles() {
+			tombstoneFiles++
+			tombstoneBytes += int64(ts.Size)
+		}
+	}
+	f.mu.RUnlock()
+
 	return []models.Statistic{{
 		Name: "tsm1_filestore",
 		Tags: tags,
 		Values: map[string]interface{}{
-			statFileStoreBytes: atomic.LoadInt64(&f.stats.DiskBytes),
-			statFileStoreCount: atomic.LoadInt64(&f.stats.FileCount),
+			statFileStoreBytes:          atomic.LoadInt64(&f.stats.DiskBytes),
+			statFileStoreCount:          atomic.LoadInt64(&f.stats.FileCount),
+			statFileStoreTombstoneFiles: tombstoneFiles,
+			statFileStoreTombstoneBytes: tombstoneBytes,
 		},
 	}}
 }
@@ -305,6 +328,20 @@ func (f *FileStore) Files() []TSMFile {
 	return f.files
 }
 
+// referencedFiles returns the TSM files currently loaded. Each file is
+// referenced, so it stays valid until the caller calls Unref.
+func (f *FileStore) referencedFiles() []TSMFile {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	files := make([]TSMFile, len(f.files))
+	copy(files, f.files)
+	for _, fd := range files {
+		fd.Ref()
+	}
+	return files
+}
+
 // Free releases any resources held by the FileStore.  The resources will be re-acquired
 // if necessary if they are needed after freeing them.
 func (f *FileStore) Free() error {
@@ -496,6 +533,21 @@ func (f *FileStore) Open() error {
 		}
 	}
 
+	// Remove the measurement statistics of TSM files that were never renamed
+	// into place or were removed before their statistics.
+	statsFiles, err := filepath.Glob(filepath.Join(f.dir, fmt.Sprintf("*.%s", MeasurementStatsFileExtension)))
+	if err != nil {
+		return err
+	}
+	for _, fn := range statsFiles {
+		tsmPath := strings.TrimSuffix(fn, MeasurementStatsFileExtension) + TSMFileExtension
+		if _, err := os.Stat(tsmPath); os.IsNotExist(err) {
+			if err := os.Remove(fn); err != nil {
+				return err
+			}
+		}
+	}
+
 	files, err := filepath.Glob(filepath.Join(f.dir, fmt.Sprintf("*.%s", TSMFileExtension)))
 	if err != nil {
 		return err
@@ -532,7 +584,7 @@ func (f *FileStore) Open() error {
 			defer f.openLimiter.Release()
 
 			start := time.Now()
-			df, err := NewTSMReader(file, WithMadviseWillNeed(f.tsmMMAPWillNeed))
+			df, err := NewTSMReader(file, WithMadviseWillNeed(f.tsmMMAPWillNeed), WithReaderCipher(f.cipher), WithBlockCache(f.blockCache), WithReadahead(f.readahead))
 			f.logger.Info("Opened file",
 				zap.String("path", file.Name()),
 				zap.Int("id", idx),
@@ -664,7 +716,7 @@ func (f *FileStore) TSMReader(path string) *TSMReader {
 func (f *FileStore) KeyCursor(ctx context.Context, key []byte, t int64, ascending bool) *KeyCursor {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
-	return newKeyCursor(ctx, f, key, t, ascending)
+	return newKeyCursor(ctx, f.files, key, t, ascending)
 }
 
 // Stats returns the stats of the underlying files, preferring the cached version if it is still valid.
@@ -760,7 +812,7 @@ func (f *FileStore) replace(oldFiles, newFiles []string, updatedFn func(r []TSMF
 			}
 		}
 
-		tsm, err := NewTSMReader(fd, WithMadviseWillNeed(f.tsmMMAPWillNeed))
+		tsm, err := NewTSMReader(fd, WithMadviseWillNeed(f.tsmMMAPWillNeed), WithReaderCipher(f.cipher), WithBlockCache(f.blockCache), WithReadahead(f.readahead))
 		if err != nil {
 			if newName != oldName {
 				if err1 := os.Rename(newName, oldName); err1 != nil {
@@ -893,6 +945,25 @@ func (f *FileStore) replace(oldFiles, newFiles []string, updatedFn func(r []TSMF
 	return nil
 }
 
+// MeasurementStats returns the statistics of the measurements in the TSM
+// files, merged across the files.
+func (f *FileStore) MeasurementStats() map[string]tsdb.MeasurementStats {
+	f.mu.RLock()
+	files := make([]TSMFile, len(f.files))
+	copy(files, f.files)
+	for _, file := range files {
+		file.Ref()
+	}
+	f.mu.RUnlock()
+
+	stats := make(map[string]tsdb.MeasurementStats)
+	for _, file := range files {
+		tsdb.MergeMeasurementStats(stats, file.MeasurementStats())
+		file.Unref()
+	}
+	return stats
+}
+
 // LastModified returns the last time the file store was updated with new
 // TSM files or a delete.
 func (f *FileStore) LastModified() time.Time {
@@ -972,11 +1043,11 @@ func (f *FileStore) cost(key []byte, min, max int64) query.IteratorCost {
 
 // locations returns the files and index blocks for a key and time.  ascending indicates
 // whether the key will be scan in ascending time order or descenging time order.
-// This function assumes the read-lock has been taken.
-func (f *FileStore) locations(key []byte, t int64, ascending bool) []*location {
+// The files must not be closed while this function runs.
+func locations(files []TSMFile, key []byte, t int64, ascending bool) []*location {
 	var cache []IndexEntry
-	locations := make([]*location, 0, len(f.files))
-	for _, fd := range f.files {
+	locations := make([]*location, 0, len(files))
+	for _, fd := range files {
 		minTime, maxTime := fd.TimeRange()
 
 		// If we ascending and the max time of the file is before where we want to start
@@ -993,15 +1064,14 @@ func (f *FileStore) locations(key []byte, t int64, ascending bool) []*location {
 		// This file could potential contain points we are looking for so find the blocks for
 		// the given key.
 		entries := fd.ReadEntries(key, &cache)
-	LOOP:
 		for i := 0; i < len(entries); i++ {
 			ie := entries[i]
 
-			// Skip any blocks only contain values that are tombstoned.
-			for _, t := range tombstones {
-				if t.Min <= ie.MinTime && t.Max >= ie.MaxTime {
-					continue LOOP
-				}
+			// Skip any blocks only contain values that are tombstoned. The
+			// ranges are merged, so a single range covers such blocks.
+			blockTombstones := overlappingTimeRanges(tombstones, ie.MinTime, ie.MaxTime)
+			if len(blockTombstones) == 1 && blockTombstones[0].Min <= ie.MinTime && blockTombstones[0].Max >= ie.MaxTime {
+				continue
 			}
 
 			// If we ascending and the max time of a block is before where we are looking, skip
@@ -1015,8 +1085,9 @@ func (f *FileStore) locations(key []byte, t int64, ascending bool) []*location {
 			}
 
 			location := &location{
-				r:     fd,
-				entry: ie,
+				r:          fd,
+				entry:      ie,
+				tombstones: blockTombstones,
 			}
 
 			if ascending {
@@ -1148,6 +1219,9 @@ type location struct {
 	r     TSMFile
 	entry IndexEntry
 
+	// tombstones are the deleted ranges overlapping the block.
+	tombstones []TimeRange
+
 	readMin, readMax int64
 }
 
@@ -1189,12 +1263,12 @@ func (a ascLocations) Less(i, j int) bool {
 	return a[i].entry.MinTime < a[j].entry.MinTime
 }
 
-// newKeyCursor returns a new instance of KeyCursor.
-// This function assumes the read-lock has been taken.
-func newKeyCursor(ctx context.Context, fs *FileStore, key []byte, t int64, ascending bool) *KeyCursor {
+// newKeyCursor returns a new instance of KeyCursor reading files.
+// This function assumes the read-lock has been taken, or that files are referenced.
+func newKeyCursor(ctx context.Context, files []TSMFile, key []byte, t int64, ascending bool) *KeyCursor {
 	c := &KeyCursor{
 		key:       key,
-		seeks:     fs.locations(key, t, ascending),
+		seeks:     locations(files, key, t, ascending),
 		ctx:       ctx,
 		col:       metrics.GroupFromContext(ctx),
 		ascending: ascending,
@@ -31,7 +31,7 @@ LOOP:
 	values.Exclude(first.readMin, first.readMax)
 
 	// Remove any tombstones
-	tombstones := first.r.TombstoneRange(c.key)
+	tombstones := first.tombstones
 	excludeTombstonesFloatArray(tombstones, values)
 	// If there are no values in this first block (all tombstoned or previously read) and
 	// we have more potential blocks too search.  Try again.
@@ -99,7 +99,7 @@ LOOP:
 				c.col.GetCounter(floatBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
 
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			excludeTombstonesFloatArray(tombstones, v)
 
@@ -160,7 +160,7 @@ LOOP:
 				c.col.GetCounter(floatBlocksDecodedCounter).Add(1)
 				c.col.GetCounter(floatBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			excludeTombstonesFloatArray(tombstones, v)
 
@@ -215,7 +215,7 @@ LOOP:
 	values.Exclude(first.readMin, first.readMax)
 
 	// Remove any tombstones
-	tombstones := first.r.TombstoneRange(c.key)
+	tombstones := first.tombstones
 	excludeTombstonesIntegerArray(tombstones, values)
 	// If there are no values in this first block (all tombstoned or previously read) and
 	// we have more potential blocks too search.  Try again.
@@ -283,7 +283,7 @@ LOOP:
 				c.col.GetCounter(integerBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
 
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			excludeTombstonesIntegerArray(tombstones, v)
 
@@ -344,7 +344,7 @@ LOOP:
 				c.col.GetCounter(integerBlocksDecodedCounter).Add(1)
 				c.col.GetCounter(integerBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			excludeTombstonesIntegerArray(tombstones, v)
 
@@ -399,7 +399,7 @@ LOOP:
 	values.Exclude(first.readMin, first.readMax)
 
 	// Remove any tombstones
-	tombstones := first.r.TombstoneRange(c.key)
+	tombstones := first.tombstones
 	excludeTombstonesUnsignedArray(tombstones, values)
 	// If there are no values in this first block (all tombstoned or previously read) and
 	// we have more potential blocks too search.  Try again.
@@ -467,7 +467,7 @@ LOOP:
 				c.col.GetCounter(unsignedBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
 
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			excludeTombstonesUnsignedArray(tombstones, v)
 
@@ -528,7 +528,7 @@ LOOP:
 				c.col.GetCounter(unsignedBlocksDecodedCounter).Add(1)
 				c.col.GetCounter(unsignedBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			excludeTombstonesUnsignedArray(tombstones, v)
 
@@ -583,7 +583,7 @@ LOOP:
 	values.Exclude(first.readMin, first.readMax)
 
 	// Remove any tombstones
-	tombstones := first.r.TombstoneRange(c.key)
+	tombstones := first.tombstones
 	excludeTombstonesStringArray(tombstones, values)
 	// If there are no values in this first block (all tombstoned or previously read) and
 	// we have more potential blocks too search.  Try again.
@@ -651,7 +651,7 @@ LOOP:
 				c.col.GetCounter(stringBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
 
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			excludeTombstonesStringArray(tombstones, v)
 
@@ -712,7 +712,7 @@ LOOP:
 				c.col.GetCounter(stringBlocksDecodedCounter).Add(1)
 				c.col.GetCounter(stringBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			excludeTombstonesStringArray(tombstones, v)
 
@@ -767,7 +767,7 @@ LOOP:
 	values.Exclude(first.readMin, first.readMax)
 
 	// Remove any tombstones
-	tombstones := first.r.TombstoneRange(c.key)
+	tombstones := first.tombstones
 	excludeTombstonesBooleanArray(tombstones, values)
 	// If there are no values in this first block (all tombstoned or previously read) and
 	// we have more potential blocks too search.  Try again.
@@ -835,7 +835,7 @@ LOOP:
 				c.col.GetCounter(booleanBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
 
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			excludeTombstonesBooleanArray(tombstones, v)
 
@@ -896,7 +896,7 @@ LOOP:
 				c.col.GetCounter(booleanBlocksDecodedCounter).Add(1)
 				c.col.GetCounter(booleanBlocksSizeCounter).Add(int64(cur.entry.Size))
 			}
-			tombstones := cur.r.TombstoneRange(c.key)
+			tombstones := cur.tombstones
 			// Remove any tombstoned values
 			excludeTombstonesBooleanArray(tombstones, v)
 
@@ -160,6 +160,7 @@ func (*mockTSMFile) OverlapsTimeRange(min, max int64) bool
 func (*mockTSMFile) OverlapsKeyRange(min, max []byte) bool                      { panic("implement me") }
 func (*mockTSMFile) TimeRange() (int64, int64)                                  { panic("implement me") }
 func (*mockTSMFile) TombstoneRange(key []byte) []TimeRange                      { panic("implement me") }
+func (*mockTSMFile) MeasurementStats() map[string]tsdb.MeasurementStats { panic("implement me") }
 func (*mockTSMFile) KeyRange() ([]byte, []byte)                                 { panic("implement me") }
 func (*mockTSMFile) Type(key []byte) (byte, error)                              { panic("implement me") }
 func (*mockTSMFile) BatchDelete() BatchDeleter                                  { panic("implement me") }
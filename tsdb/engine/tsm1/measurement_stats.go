@@ -0,0 +1,198 @@
+package tsm1
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/tsdb"
+)
+
+const (
+	// MeasurementStatsFileExtension is the extension of the files holding the
+	// measurement statistics of the TSM file with the same name.
+	MeasurementStatsFileExtension = "stats"
+
+	// measurementStatsMagic identifies the start of a measurement statistics
+	// file.
+	measurementStatsMagic uint32 = 0x4d535431
+)
+
+// errMeasurementStatsCorrupt is returned when reading a measurement statistics
+// file that was not written completely.
+var errMeasurementStatsCorrupt = errors.New("measurement statistics file corrupt")
+
+// measurementStatsPath returns the path of the measurement statistics file of
+// the TSM file at path. The temporary extension of a TSM file being written
+// is dropped, so the statistics are found once the file is renamed.
+func measurementStatsPath(path string) string {
+	path = strings.TrimSuffix(path, "."+TmpTSMFileExtension)
+	path = strings.TrimSuffix(path, "."+TSMFileExtension)
+	return path + "." + MeasurementStatsFileExtension
+}
+
+// measurementStatsBuilder accumulates the statistics of the blocks of a TSM
+// file by measurement.
+type measurementStatsBuilder struct {
+	stats map[string]*tsdb.MeasurementStats
+
+	// The series key and statistics of the last block, as blocks of a series
+	// are added in a row.
+	seriesKey []byte
+	last      *tsdb.MeasurementStats
+}
+
+func newMeasurementStatsBuilder() *measurementStatsBuilder {
+	return &measurementStatsBuilder{stats: make(map[string]*tsdb.MeasurementStats)}
+}
+
+// add adds a block of key holding n points.
+func (b *measurementStatsBuilder) add(key []byte, minTime, maxTime int64, n int, size int64) {
+	seriesKey, _ := SeriesAndFieldFromCompositeKey(key)
+	if b.last == nil || string(seriesKey) != string(b.seriesKey) {
+		name := models.ParseName(seriesKey)
+		b.last = b.stats[string(name)]
+		if b.last == nil {
+			b.last = &tsdb.MeasurementStats{}
+			b.stats[string(name)] = b.last
+		}
+		b.seriesKey = append(b.seriesKey[:0], seriesKey...)
+	}
+
+	b.last.Add(tsdb.MeasurementStats{
+		MinTime:  minTime,
+		MaxTime:  maxTime,
+		PointN:   int64(n),
+		DiskSize: size,
+	})
+}
+
+// addBlock adds a block about to be written to a TSM file.
+func (b *measurementStatsBuilder) addBlock(key []byte, minTime, maxTime int64, block []byte) {
+	// A block is written with its checksum and indexed by an entry.
+	b.add(key, minTime, maxTime, BlockCount(block), int64(len(block))+4+indexEntrySize)
+}
+
+// addEntries adds the blocks indexed by entries. Their points are not
+// counted, as that requires reading the blocks.
+func (b *measurementStatsBuilder) addEntries(key []byte, entries []IndexEntry) {
+	for _, e := range entries {
+		b.add(key, e.MinTime, e.MaxTime, 0, int64(e.Size)+indexEntrySize)
+	}
+}
+
+// build returns the statistics accumulated.
+func (b *measurementStatsBuilder) build() map[string]tsdb.MeasurementStats {
+	stats := make(map[string]tsdb.MeasurementStats, len(b.stats))
+	for name, s := range b.stats {
+		stats[name] = *s
+	}
+	return stats
+}
+
+// writeMeasurementStats writes stats to the file at path. The file is not
+// synced: statistics lost in a crash fail their checksum when read and are
+// rebuilt from the index of the TSM file.
+func writeMeasurementStats(path string, stats map[string]tsdb.MeasurementStats) error {
+	fd, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	h := crc32.NewIEEE()
+	w := bufio.NewWriter(fd)
+	var buf [4 * binary.MaxVarintLen64]byte
+
+	binary.BigEndian.PutUint32(buf[:4], measurementStatsMagic)
+	h.Write(buf[:4])
+	if _, err := w.Write(buf[:4]); err != nil {
+		return err
+	}
+
+	for name, s := range stats {
+		n := binary.PutUvarint(buf[:], uint64(len(name)))
+		h.Write(buf[:n])
+		h.Write([]byte(name))
+		if _, err := w.Write(buf[:n]); err != nil {
+			return err
+		} else if _, err := w.WriteString(name); err != nil {
+			return err
+		}
+
+		n = binary.PutVarint(buf[:], s.MinTime)
+		n += binary.PutVarint(buf[n:], s.MaxTime)
+		n += binary.PutUvarint(buf[n:], uint64(s.PointN))
+		n += binary.PutUvarint(buf[n:], uint64(s.DiskSize))
+		h.Write(buf[:n])
+		if _, err := w.Write(buf[:n]); err != nil {
+			return err
+		}
+	}
+
+	binary.BigEndian.PutUint32(buf[:4], h.Sum32())
+	if _, err := w.Write(buf[:4]); err != nil {
+		return err
+	} else if err := w.Flush(); err != nil {
+		return err
+	}
+	return fd.Close()
+}
+
+// readMeasurementStats reads the statistics written by writeMeasurementStats
+// to the file at path.
+func readMeasurementStats(path string) (map[string]tsdb.MeasurementStats, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) < 8 || binary.BigEndian.Uint32(b[:4]) != measurementStatsMagic {
+		return nil, errMeasurementStatsCorrupt
+	}
+	b, sum := b[:len(b)-4], binary.BigEndian.Uint32(b[len(b)-4:])
+	if crc32.ChecksumIEEE(b) != sum {
+		return nil, errMeasurementStatsCorrupt
+	}
+	b = b[4:]
+
+	uvarint := func(v *uint64) bool {
+		x, n := binary.Uvarint(b)
+		if n <= 0 {
+			return false
+		}
+		*v, b = x, b[n:]
+		return true
+	}
+	varint := func(v *int64) bool {
+		x, n := binary.Varint(b)
+		if n <= 0 {
+			return false
+		}
+		*v, b = x, b[n:]
+		return true
+	}
+
+	stats := make(map[string]tsdb.MeasurementStats)
+	for len(b) > 0 {
+		var n, pointN, size uint64
+		if !uvarint(&n) || uint64(len(b)) < n {
+			return nil, errMeasurementStatsCorrupt
+		}
+		name := string(b[:n])
+		b = b[n:]
+
+		var s tsdb.MeasurementStats
+		if !varint(&s.MinTime) || !varint(&s.MaxTime) || !uvarint(&pointN) || !uvarint(&size) {
+			return nil, errMeasurementStatsCorrupt
+		}
+		s.PointN, s.DiskSize = int64(pointN), int64(size)
+		stats[name] = s
+	}
+	return stats, nil
+}
@@ -0,0 +1,69 @@
+package tsm1
+
+import (
+	"context"
+	"io"
+
+	"github.com/freetsdb/freetsdb/tsdb"
+)
+
+// readSnapshot is a view of the cache and TSM files of an engine at a point
+// in time. Points written to the cache after the snapshot may be seen, but
+// points moving from the cache to a new file, or from compacted files to
+// their replacement, are seen exactly once.
+type readSnapshot struct {
+	// The cache stores are never reset while referenced, so the points
+	// written from them to files not in the snapshot are still read.
+	store, snapshot storer
+
+	// files are referenced until the snapshot is closed.
+	files []TSMFile
+}
+
+// newReadSnapshot returns a snapshot of the cache and files of the engine.
+func (e *Engine) newReadSnapshot() *readSnapshot {
+	e.readMu.RLock()
+	defer e.readMu.RUnlock()
+
+	s := &readSnapshot{files: e.FileStore.referencedFiles()}
+	s.store, s.snapshot = e.Cache.readStores()
+	return s
+}
+
+// values returns a copy of the cached values for key, deduped and sorted.
+func (s *readSnapshot) values(key []byte) Values {
+	var snapshotEntries *entry
+	if s.snapshot != nil {
+		snapshotEntries = s.snapshot.entry(key)
+	}
+	return entryValues(s.store.entry(key), snapshotEntries)
+}
+
+// keyCursor returns a cursor over the blocks of key in the files of the
+// snapshot. The cursor references the files it reads, so it may outlive the
+// snapshot.
+func (s *readSnapshot) keyCursor(ctx context.Context, key []byte, t int64, ascending bool) *KeyCursor {
+	return newKeyCursor(ctx, s.files, key, t, ascending)
+}
+
+// Close releases the files of the snapshot.
+func (s *readSnapshot) Close() error {
+	for _, f := range s.files {
+		f.Unref()
+	}
+	s.files = nil
+	return nil
+}
+
+// readValues returns the cached values of key and a cursor over its blocks.
+// If ctx holds read snapshots, they are read from the snapshot of the engine,
+// which is taken on first use.
+func (e *Engine) readValues(ctx context.Context, key []byte, t int64, ascending bool) (Values, *KeyCursor) {
+	if snapshots := tsdb.ReadSnapshotsFromContext(ctx); snapshots != nil {
+		s, ok := snapshots.Get(e, func() io.Closer { return e.newReadSnapshot() }).(*readSnapshot)
+		if ok {
+			return s.values(key), s.keyCursor(ctx, key, t, ascending)
+		}
+	}
+	return e.Cache.Values(key), e.KeyCursor(ctx, key, t, ascending)
+}
@@ -123,7 +123,11 @@ func (m *mmapAccessor) readFloatBlock(entry *IndexEntry, values *[]FloatValue) (
 		return nil, ErrTSMClosed
 	}
 
-	a, err := DecodeFloatBlock(m.b[entry.Offset+4:entry.Offset+int64(entry.Size)], values)
+	var a []FloatValue
+	b, err := m.blockData(entry)
+	if err == nil {
+		a, err = DecodeFloatBlock(b, values)
+	}
 	m.mu.RUnlock()
 
 	if err != nil {
@@ -142,7 +146,10 @@ func (m *mmapAccessor) readFloatArrayBlock(entry *IndexEntry, values *tsdb.Float
 		return ErrTSMClosed
 	}
 
-	err := DecodeFloatArrayBlock(m.b[entry.Offset+4:entry.Offset+int64(entry.Size)], values)
+	b, err := m.blockData(entry)
+	if err == nil {
+		err = DecodeFloatArrayBlock(b, values)
+	}
 	m.mu.RUnlock()
 
 	return err
@@ -157,7 +164,11 @@ func (m *mmapAccessor) readIntegerBlock(entry *IndexEntry, values *[]IntegerValu
 		return nil, ErrTSMClosed
 	}
 
-	a, err := DecodeIntegerBlock(m.b[entry.Offset+4:entry.Offset+int64(entry.Size)], values)
+	var a []IntegerValue
+	b, err := m.blockData(entry)
+	if err == nil {
+		a, err = DecodeIntegerBlock(b, values)
+	}
 	m.mu.RUnlock()
 
 	if err != nil {
@@ -176,7 +187,10 @@ func (m *mmapAccessor) readIntegerArrayBlock(entry *IndexEntry, values *tsdb.Int
 		return ErrTSMClosed
 	}
 
-	err := DecodeIntegerArrayBlock(m.b[entry.Offset+4:entry.Offset+int64(entry.Size)], values)
+	b, err := m.blockData(entry)
+	if err == nil {
+		err = DecodeIntegerArrayBlock(b, values)
+	}
 	m.mu.RUnlock()
 
 	return err
@@ -191,7 +205,11 @@ func (m *mmapAccessor) readUnsignedBlock(entry *IndexEntry, values *[]UnsignedVa
 		return nil, ErrTSMClosed
 	}
 
-	a, err := DecodeUnsignedBlock(m.b[entry.Offset+4:entry.Offset+int64(entry.Size)], values)
+	var a []UnsignedValue
+	b, err := m.blockData(entry)
+	if err == nil {
+		a, err = DecodeUnsignedBlock(b, values)
+	}
 	m.mu.RUnlock()
 
 	if err != nil {
@@ -210,7 +228,10 @@ func (m *mmapAccessor) readUnsignedArrayBlock(entry *IndexEntry, values *tsdb.Un
 		return ErrTSMClosed
 	}
 
-	err := DecodeUnsignedArrayBlock(m.b[entry.Offset+4:entry.Offset+int64(entry.Size)], values)
+	b, err := m.blockData(entry)
+	if err == nil {
+		err = DecodeUnsignedArrayBlock(b, values)
+	}
 	m.mu.RUnlock()
 
 	return err
@@ -225,7 +246,11 @@ func (m *mmapAccessor) readStringBlock(entry *IndexEntry, values *[]StringValue)
 		return nil, ErrTSMClosed
 	}
 
-	a, err := DecodeStringBlock(m.b[entry.Offset+4:entry.Offset+int64(entry.Size)], values)
+	var a []StringValue
+	b, err := m.blockData(entry)
+	if err == nil {
+		a, err = DecodeStringBlock(b, values)
+	}
 	m.mu.RUnlock()
 
 	if err != nil {
@@ -244,7 +269,10 @@ func (m *mmapAccessor) readStringArrayBlock(entry *IndexEntry, values *tsdb.Stri
 		return ErrTSMClosed
 	}
 
-	err := DecodeStringArrayBlock(m.b[entry.Offset+4:entry.Offset+int64(entry.Size)], values)
+	b, err := m.blockData(entry)
+	if err == nil {
+		err = DecodeStringArrayBlock(b, values)
+	}
 	m.mu.RUnlock()
 
 	return err
@@ -259,7 +287,11 @@ func (m *mmapAccessor) readBooleanBlock(entry *IndexEntry, values *[]BooleanValu
 		return nil, ErrTSMClosed
 	}
 
-	a, err := DecodeBooleanBlock(m.b[entry.Offset+4:entry.Offset+int64(entry.Size)], values)
+	var a []BooleanValue
+	b, err := m.blockData(entry)
+	if err == nil {
+		a, err = DecodeBooleanBlock(b, values)
+	}
 	m.mu.RUnlock()
 
 	if err != nil {
@@ -278,8 +310,91 @@ func (m *mmapAccessor) readBooleanArrayBlock(entry *IndexEntry, values *tsdb.Boo
 		return ErrTSMClosed
 	}
 
-	err := DecodeBooleanArrayBlock(m.b[entry.Offset+4:entry.Offset+int64(entry.Size)], values)
+	b, err := m.blockData(entry)
+	if err == nil {
+		err = DecodeBooleanArrayBlock(b, values)
+	}
 	m.mu.RUnlock()
 
 	return err
 }
+
+func (p *preadAccessor) readFloatBlock(entry *IndexEntry, values *[]FloatValue) ([]FloatValue, error) {
+	b, err := p.blockData(entry)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeFloatBlock(b, values)
+}
+
+func (p *preadAccessor) readFloatArrayBlock(entry *IndexEntry, values *tsdb.FloatArray) error {
+	b, err := p.blockData(entry)
+	if err != nil {
+		return err
+	}
+	return DecodeFloatArrayBlock(b, values)
+}
+
+func (p *preadAccessor) readIntegerBlock(entry *IndexEntry, values *[]IntegerValue) ([]IntegerValue, error) {
+	b, err := p.blockData(entry)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeIntegerBlock(b, values)
+}
+
+func (p *preadAccessor) readIntegerArrayBlock(entry *IndexEntry, values *tsdb.IntegerArray) error {
+	b, err := p.blockData(entry)
+	if err != nil {
+		return err
+	}
+	return DecodeIntegerArrayBlock(b, values)
+}
+
+func (p *preadAccessor) readUnsignedBlock(entry *IndexEntry, values *[]UnsignedValue) ([]UnsignedValue, error) {
+	b, err := p.blockData(entry)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeUnsignedBlock(b, values)
+}
+
+func (p *preadAccessor) readUnsignedArrayBlock(entry *IndexEntry, values *tsdb.UnsignedArray) error {
+	b, err := p.blockData(entry)
+	if err != nil {
+		return err
+	}
+	return DecodeUnsignedArrayBlock(b, values)
+}
+
+func (p *preadAccessor) readStringBlock(entry *IndexEntry, values *[]StringValue) ([]StringValue, error) {
+	b, err := p.blockData(entry)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeStringBlock(b, values)
+}
+
+func (p *preadAccessor) readStringArrayBlock(entry *IndexEntry, values *tsdb.StringArray) error {
+	b, err := p.blockData(entry)
+	if err != nil {
+		return err
+	}
+	return DecodeStringArrayBlock(b, values)
+}
+
+func (p *preadAccessor) readBooleanBlock(entry *IndexEntry, values *[]BooleanValue) ([]BooleanValue, error) {
+	b, err := p.blockData(entry)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeBooleanBlock(b, values)
+}
+
+func (p *preadAccessor) readBooleanArrayBlock(entry *IndexEntry, values *tsdb.BooleanArray) error {
+	b, err := p.blockData(entry)
+	if err != nil {
+		return err
+	}
+	return DecodeBooleanArrayBlock(b, values)
+}
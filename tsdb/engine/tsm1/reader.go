@@ -13,6 +13,7 @@ import (
 	"sync/atomic"
 
 	"github.com/freetsdb/freetsdb/pkg/bytesutil"
+	"github.com/freetsdb/freetsdb/pkg/encryption"
 	"github.com/freetsdb/freetsdb/pkg/file"
 	"github.com/freetsdb/freetsdb/tsdb"
 )
@@ -20,6 +21,9 @@ import (
 // ErrFileInUse is returned when attempting to remove or close a TSM file that is still being used.
 var ErrFileInUse = fmt.Errorf("file still in use")
 
+// ErrTSMEncrypted is returned when opening an encrypted TSM file without a cipher.
+var ErrTSMEncrypted = fmt.Errorf("tsm file is encrypted and no encryption key is configured")
+
 // nilOffset is the value written to the offsets to indicate that position is deleted.  The value is the max
 // uint32 which is an invalid position.  We don't use 0 as 0 is actually a valid position.
 var nilOffset = []byte{255, 255, 255, 255}
@@ -33,6 +37,15 @@ type TSMReader struct {
 	madviseWillNeed bool // Hint to the kernel with MADV_WILLNEED.
 	mu              sync.RWMutex
 
+	// cipher decrypts the blocks of encrypted files.
+	cipher *encryption.Cipher
+
+	// blockCache holds the blocks read if the file is not memory mapped.
+	blockCache *blockCache
+
+	// readahead is the number of bytes following a block read to read ahead.
+	readahead int64
+
 	// accessor provides access and decoding of blocks for the reader.
 	accessor blockAccessor
 
@@ -50,6 +63,11 @@ type TSMReader struct {
 
 	// deleteMu limits concurrent deletes
 	deleteMu sync.Mutex
+
+	// measurementStats are the statistics of the measurements in the file,
+	// loaded when first requested.
+	statsMu          sync.Mutex
+	measurementStats map[string]tsdb.MeasurementStats
 }
 
 // TSMIndex represent the index section of a TSM file.  The index records all
@@ -217,6 +235,31 @@ var WithMadviseWillNeed = func(willNeed bool) tsmReaderOption {
 	}
 }
 
+// WithReaderCipher is an option for specifying the cipher used to decrypt
+// blocks if the file is encrypted.
+var WithReaderCipher = func(c *encryption.Cipher) tsmReaderOption {
+	return func(r *TSMReader) {
+		r.cipher = c
+	}
+}
+
+// WithBlockCache is an option for reading blocks with positioned reads into
+// the given cache rather than memory mapping the file. A nil cache keeps the
+// file memory mapped.
+var WithBlockCache = func(c *blockCache) tsmReaderOption {
+	return func(r *TSMReader) {
+		r.blockCache = c
+	}
+}
+
+// WithReadahead is an option for asking the kernel to read ahead the given
+// number of bytes following the blocks read.
+var WithReadahead = func(n int64) tsmReaderOption {
+	return func(r *TSMReader) {
+		r.readahead = n
+	}
+}
+
 // NewTSMReader returns a new TSMReader from the given file.
 func NewTSMReader(f *os.File, options ...tsmReaderOption) (*TSMReader, error) {
 	t := &TSMReader{}
@@ -230,9 +273,20 @@ func NewTSMReader(f *os.File, options ...tsmReaderOption) (*TSMReader, error) {
 	}
 	t.size = stat.Size()
 	t.lastModified = stat.ModTime().UnixNano()
-	t.accessor = &mmapAccessor{
-		f:            f,
-		mmapWillNeed: t.madviseWillNeed,
+	if t.blockCache != nil {
+		t.accessor = &preadAccessor{
+			f:         f,
+			cache:     t.blockCache,
+			cipher:    t.cipher,
+			readahead: readahead{size: t.readahead},
+		}
+	} else {
+		t.accessor = &mmapAccessor{
+			f:            f,
+			mmapWillNeed: t.madviseWillNeed,
+			cipher:       t.cipher,
+			readahead:    readahead{size: t.readahead},
+		}
 	}
 
 	index, err := t.accessor.init()
@@ -427,6 +481,12 @@ func (t *TSMReader) remove() error {
 	if err := t.tombstoner.Delete(); err != nil {
 		return err
 	}
+
+	if path != "" {
+		if err := os.RemoveAll(measurementStatsPath(path)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -556,6 +616,78 @@ func (t *TSMReader) TombstoneRange(key []byte) []TimeRange {
 	return tr
 }
 
+// MeasurementStats returns the statistics of the measurements in the file.
+// They are read from the statistics file written with the TSM file, or
+// rebuilt from the index without counting points if it is missing. Points
+// deleted are left out: the statistics of a file with tombstones are rebuilt
+// from the blocks left in its index, and its points are estimated from the
+// bytes left.
+func (t *TSMReader) MeasurementStats() map[string]tsdb.MeasurementStats {
+	t.statsMu.Lock()
+	defer t.statsMu.Unlock()
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.measurementStats == nil {
+		stats, err := readMeasurementStats(measurementStatsPath(t.accessor.path()))
+		if err != nil {
+			stats = t.indexMeasurementStats()
+		}
+		t.measurementStats = stats
+	}
+	if !t.tombstoner.HasTombstones() {
+		return t.measurementStats
+	}
+
+	// The statistics left are not kept, as deletes keep changing them until
+	// the file is compacted.
+	stats := t.indexMeasurementStats()
+	for name, s := range stats {
+		if written := t.measurementStats[name]; written.DiskSize > 0 && s.DiskSize < written.DiskSize {
+			s.PointN = int64(float64(written.PointN) * float64(s.DiskSize) / float64(written.DiskSize))
+		} else {
+			s.PointN = written.PointN
+		}
+		stats[name] = s
+	}
+	return stats
+}
+
+// indexMeasurementStats builds the statistics of the measurements from the
+// blocks in the index, without counting points. Blocks whose points are all
+// deleted are left out.
+func (t *TSMReader) indexMeasurementStats() map[string]tsdb.MeasurementStats {
+	b := newMeasurementStatsBuilder()
+	var entries []IndexEntry
+	for i := 0; i < t.index.KeyCount(); i++ {
+		key, _ := t.index.KeyAt(i)
+		entries = t.index.ReadEntries(key, &entries)
+		if tombstones := t.index.TombstoneRange(key); len(tombstones) > 0 {
+			n := 0
+			for _, e := range entries {
+				if !timeRangesCover(tombstones, e.MinTime, e.MaxTime) {
+					entries[n] = e
+					n++
+				}
+			}
+			entries = entries[:n]
+		}
+		b.addEntries(key, entries)
+	}
+	return b.build()
+}
+
+// timeRangesCover returns true if one of ranges covers min to max.
+func timeRangesCover(ranges []TimeRange, min, max int64) bool {
+	for _, r := range ranges {
+		if r.Min <= min && r.Max >= max {
+			return true
+		}
+	}
+	return false
+}
+
 // Stats returns the FileStat for the TSMReader's underlying file.
 func (t *TSMReader) Stats() FileStat {
 	minTime, maxTime := t.index.TimeRange()
@@ -752,6 +884,49 @@ func (t TimeRange) Overlaps(min, max int64) bool {
 	return t.Min <= max && t.Max >= min
 }
 
+// adjacent returns true if r ends right before or overlaps min.
+func (t TimeRange) adjacent(min int64) bool {
+	return t.Max == math.MaxInt64 || t.Max+1 >= min
+}
+
+// addTimeRange adds r to ranges, which are sorted and neither overlap nor
+// touch, merging r with the ranges it overlaps or touches. The returned
+// ranges keep these properties.
+func addTimeRange(ranges []TimeRange, r TimeRange) []TimeRange {
+	// i is the first range ending at most one before r.
+	i := sort.Search(len(ranges), func(i int) bool { return ranges[i].adjacent(r.Min) })
+
+	j := i
+	for ; j < len(ranges) && r.adjacent(ranges[j].Min); j++ {
+		if ranges[j].Min < r.Min {
+			r.Min = ranges[j].Min
+		}
+		if ranges[j].Max > r.Max {
+			r.Max = ranges[j].Max
+		}
+	}
+
+	if i == j {
+		ranges = append(ranges, TimeRange{})
+		copy(ranges[i+1:], ranges[i:])
+		ranges[i] = r
+		return ranges
+	}
+	ranges[i] = r
+	return append(ranges[:i+1], ranges[j:]...)
+}
+
+// overlappingTimeRanges returns the ranges overlapping min and max. ranges
+// must be sorted and must not overlap, as returned by addTimeRange.
+func overlappingTimeRanges(ranges []TimeRange, min, max int64) []TimeRange {
+	i := sort.Search(len(ranges), func(i int) bool { return ranges[i].Max >= min })
+	j := i
+	for j < len(ranges) && ranges[j].Min <= max {
+		j++
+	}
+	return ranges[i:j:j]
+}
+
 // NewIndirectIndex returns a new indirect index.
 func NewIndirectIndex() *indirectIndex {
 	return &indirectIndex{
@@ -1049,56 +1224,21 @@ func (d *indirectIndex) DeleteRange(keys [][]byte, minTime, maxTime int64) {
 			continue
 		}
 
-		d.mu.RLock()
-		existing := d.tombstones[string(k)]
-		d.mu.RUnlock()
-
-		// Append the new tombonstes to the existing ones
-		newTs := append(existing, append(tombstones[string(k)], TimeRange{minTime, maxTime})...)
-		fn := func(i, j int) bool {
-			a, b := newTs[i], newTs[j]
-			if a.Min == b.Min {
-				return a.Max <= b.Max
-			}
-			return a.Min < b.Min
-		}
-
-		// Sort the updated tombstones if necessary
-		if len(newTs) > 1 && !sort.SliceIsSorted(newTs, fn) {
-			sort.Slice(newTs, fn)
+		existing, ok := tombstones[string(k)]
+		if !ok {
+			d.mu.RLock()
+			existing = d.tombstones[string(k)]
+			d.mu.RUnlock()
 		}
 
+		// Merge the range with the existing ones. The existing ranges may be
+		// in use by readers, so they are copied.
+		newTs := addTimeRange(append([]TimeRange(nil), existing...), TimeRange{minTime, maxTime})
 		tombstones[string(k)] = newTs
 
-		// We need to see if all the tombstones end up deleting the entire series.  This
-		// could happen if their is one tombstore with min,max time spanning all the block
-		// time ranges or from multiple smaller tombstones the delete segments.  To detect
-		// this cases, we use a window starting at the first tombstone and grow it be each
-		// tombstone that is immediately adjacent to the current window or if it overlaps.
-		// If there are any gaps, we abort.
-		minTs, maxTs := newTs[0].Min, newTs[0].Max
-		for j := 1; j < len(newTs); j++ {
-			prevTs := newTs[j-1]
-			ts := newTs[j]
-
-			// Make sure all the tombstone line up for a continuous range.  We don't
-			// want to have two small deletes on each edges end up causing us to
-			// remove the full key.
-			if prevTs.Max != ts.Min-1 && !prevTs.Overlaps(ts.Min, ts.Max) {
-				minTs, maxTs = int64(math.MaxInt64), int64(math.MinInt64)
-				break
-			}
-
-			if ts.Min < minTs {
-				minTs = ts.Min
-			}
-			if ts.Max > maxTs {
-				maxTs = ts.Max
-			}
-		}
-
-		// If we have a fully deleted series, delete it all of it.
-		if minTs <= min && maxTs >= max {
+		// The ranges are merged, so the series is fully deleted if a single
+		// range spans all of its blocks.
+		if len(newTs) == 1 && newTs[0].Min <= min && newTs[0].Max >= max {
 			fullKeys = append(fullKeys, keys[0])
 			keys = keys[1:]
 			continue
@@ -1309,6 +1449,12 @@ type mmapAccessor struct {
 	b  []byte
 	f  *os.File
 
+	// If encrypted is set, blocks are decrypted with cipher.
+	encrypted bool
+	cipher    *encryption.Cipher
+
+	readahead readahead
+
 	index *indirectIndex
 }
 
@@ -1316,11 +1462,14 @@ func (m *mmapAccessor) init() (*indirectIndex, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if err := verifyVersion(m.f); err != nil {
+	version, err := verifyVersion(m.f)
+	if err != nil {
 		return nil, err
 	}
-
-	var err error
+	m.encrypted = version == EncryptedVersion
+	if m.encrypted && m.cipher == nil {
+		return nil, ErrTSMEncrypted
+	}
 
 	if _, err := m.f.Seek(0, 0); err != nil {
 		return nil, err
@@ -1354,8 +1503,17 @@ func (m *mmapAccessor) init() (*indirectIndex, error) {
 		return nil, fmt.Errorf("mmapAccessor: invalid indexStart")
 	}
 
+	// The index of an encrypted file is decrypted into memory, which the
+	// index references for as long as the reader.
+	b := m.b[indexStart:indexOfsPos]
+	if m.encrypted {
+		if b, err = m.cipher.Open(nil, b); err != nil {
+			return nil, err
+		}
+	}
+
 	m.index = NewIndirectIndex()
-	if err := m.index.UnmarshalBinary(m.b[indexStart:indexOfsPos]); err != nil {
+	if err := m.index.UnmarshalBinary(b); err != nil {
 		return nil, err
 	}
 
@@ -1460,8 +1618,11 @@ func (m *mmapAccessor) readBlock(entry *IndexEntry, values []Value) ([]Value, er
 		return nil, ErrTSMClosed
 	}
 	//TODO: Validate checksum
-	var err error
-	values, err = DecodeBlock(m.b[entry.Offset+4:entry.Offset+int64(entry.Size)], values)
+	b, err := m.blockData(entry)
+	if err != nil {
+		return nil, err
+	}
+	values, err = DecodeBlock(b, values)
 	if err != nil {
 		return nil, err
 	}
@@ -1479,12 +1640,61 @@ func (m *mmapAccessor) readBytes(entry *IndexEntry, b []byte) (uint32, []byte, e
 	}
 
 	// return the bytes after the 4 byte checksum
-	crc, block := binary.BigEndian.Uint32(m.b[entry.Offset:entry.Offset+4]), m.b[entry.Offset+4:entry.Offset+int64(entry.Size)]
+	crc := binary.BigEndian.Uint32(m.b[entry.Offset : entry.Offset+4])
+	block, err := m.blockData(entry)
 	m.mu.RUnlock()
 
+	if err != nil {
+		return 0, nil, err
+	}
 	return crc, block, nil
 }
 
+// blockData returns the data of the block for entry, decrypting it if the
+// file is encrypted. m.mu must be held.
+func (m *mmapAccessor) blockData(entry *IndexEntry) ([]byte, error) {
+	if off, n, ok := m.readahead.next(entry, int64(len(m.b))); ok {
+		// madvise requires a page aligned address.
+		start := off &^ int64(os.Getpagesize()-1)
+		madviseWillNeed(m.b[start : off+n])
+	}
+
+	// The +4 is the 4 byte checksum length
+	b := m.b[entry.Offset+4 : entry.Offset+int64(entry.Size)]
+	if !m.encrypted {
+		return b, nil
+	}
+	return m.cipher.Open(nil, b)
+}
+
+// readahead tracks the range of a file the kernel was asked to read ahead.
+type readahead struct {
+	size int64 // number of bytes to read ahead, 0 if disabled
+	end  int64 // end of the last range read ahead, accessed atomically
+}
+
+// next returns the range following entry to read ahead in a file of fileSize
+// bytes, unless most of it was already read ahead. The hints are best effort,
+// so errors giving them are ignored.
+func (r *readahead) next(entry *IndexEntry, fileSize int64) (off, n int64, ok bool) {
+	if r.size <= 0 {
+		return 0, 0, false
+	}
+
+	off = entry.Offset + int64(entry.Size)
+	end := atomic.LoadInt64(&r.end)
+	if off >= fileSize || (off >= end-r.size && off+r.size/2 <= end) {
+		return 0, 0, false
+	}
+
+	n = r.size
+	if off+n > fileSize {
+		n = fileSize - off
+	}
+	atomic.StoreInt64(&r.end, off+n)
+	return off, n, true
+}
+
 // readAll returns all values for a key in all blocks.
 func (m *mmapAccessor) readAll(key []byte) ([]Value, error) {
 	m.incAccess()
@@ -1500,7 +1710,6 @@ func (m *mmapAccessor) readAll(key []byte) ([]Value, error) {
 	defer m.mu.RUnlock()
 
 	var temp []Value
-	var err error
 	var values []Value
 	for _, block := range blocks {
 		var skip bool
@@ -1517,14 +1726,17 @@ func (m *mmapAccessor) readAll(key []byte) ([]Value, error) {
 		}
 		//TODO: Validate checksum
 		temp = temp[:0]
-		// The +4 is the 4 byte checksum length
-		temp, err = DecodeBlock(m.b[block.Offset+4:block.Offset+int64(block.Size)], temp)
+		b, err := m.blockData(&block)
+		if err != nil {
+			return nil, err
+		}
+		temp, err = DecodeBlock(b, temp)
 		if err != nil {
 			return nil, err
 		}
 
 		// Filter out any values that were deleted
-		for _, t := range tombstones {
+		for _, t := range overlappingTimeRanges(tombstones, block.MinTime, block.MaxTime) {
 			temp = Values(temp).Exclude(t.Min, t.Max)
 		}
 
@@ -0,0 +1,281 @@
+package tsm1
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/freetsdb/freetsdb/pkg/encryption"
+	"github.com/freetsdb/freetsdb/pkg/file"
+)
+
+// preadAccessor is a block accessor reading blocks with positioned reads
+// rather than memory mapping the file. The blocks read are kept in a block
+// cache shared with the other readers, and the index is held in memory.
+type preadAccessor struct {
+	mu     sync.RWMutex
+	f      *os.File
+	size   int64
+	closed bool
+
+	// cache holds the blocks read, keyed by id.
+	cache *blockCache
+	id    uint64
+
+	// If encrypted is set, blocks are decrypted with cipher.
+	encrypted bool
+	cipher    *encryption.Cipher
+
+	readahead readahead
+
+	index *indirectIndex
+}
+
+func (p *preadAccessor) init() (*indirectIndex, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	version, err := verifyVersion(p.f)
+	if err != nil {
+		return nil, err
+	}
+	p.encrypted = version == EncryptedVersion
+	if p.encrypted && p.cipher == nil {
+		return nil, ErrTSMEncrypted
+	}
+
+	stat, err := p.f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	p.size = stat.Size()
+	if p.size < 8 {
+		return nil, fmt.Errorf("preadAccessor: file too small for indirectIndex")
+	}
+
+	var buf [8]byte
+	indexOfsPos := p.size - 8
+	if _, err := p.f.ReadAt(buf[:], indexOfsPos); err != nil {
+		return nil, err
+	}
+	indexStart := binary.BigEndian.Uint64(buf[:])
+	if indexStart >= uint64(indexOfsPos) {
+		return nil, fmt.Errorf("preadAccessor: invalid indexStart")
+	}
+
+	// The index references the bytes it is unmarshaled from, so they are kept
+	// for as long as the reader.
+	b := make([]byte, uint64(indexOfsPos)-indexStart)
+	if _, err := p.f.ReadAt(b, int64(indexStart)); err != nil {
+		return nil, err
+	}
+	if p.encrypted {
+		if b, err = p.cipher.Open(nil, b); err != nil {
+			return nil, err
+		}
+	}
+
+	p.index = NewIndirectIndex()
+	if err := p.index.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	p.id = p.cache.newFileID()
+
+	return p.index, nil
+}
+
+// free is a no-op: the memory used for blocks is bounded by the block cache.
+func (p *preadAccessor) free() error {
+	return nil
+}
+
+func (p *preadAccessor) rename(path string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.f.Close(); err != nil {
+		return err
+	}
+
+	if err := file.RenameFile(p.f.Name(), path); err != nil {
+		return err
+	}
+
+	// The blocks cached are unchanged, so the file keeps its id.
+	var err error
+	p.f, err = os.Open(path)
+	return err
+}
+
+func (p *preadAccessor) read(key []byte, timestamp int64) ([]Value, error) {
+	// The lock is released before reading the block, which takes it again.
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return nil, ErrTSMClosed
+	}
+	entry := p.index.Entry(key, timestamp)
+	p.mu.RUnlock()
+
+	if entry == nil {
+		return nil, nil
+	}
+
+	return p.readBlock(entry, nil)
+}
+
+func (p *preadAccessor) readBlock(entry *IndexEntry, values []Value) ([]Value, error) {
+	b, err := p.blockData(entry)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeBlock(b, values)
+}
+
+func (p *preadAccessor) readBytes(entry *IndexEntry, b []byte) (uint32, []byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	// Blocks read in full, usually by compactions, are not cached as they would
+	// evict the blocks of queries.
+	buf, err := p.readAt(entry, b)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// return the bytes after the 4 byte checksum
+	crc := binary.BigEndian.Uint32(buf[:4])
+	if !p.encrypted {
+		return crc, buf[4:], nil
+	}
+
+	block, err := p.cipher.Open(nil, buf[4:])
+	if err != nil {
+		return 0, nil, err
+	}
+	return crc, block, nil
+}
+
+// blockData returns the data of the block for entry, decrypting it if the
+// file is encrypted. The data is read from the block cache if possible, and
+// must not be modified.
+func (p *preadAccessor) blockData(entry *IndexEntry) ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return nil, ErrTSMClosed
+	}
+	if b, ok := p.cache.get(p.id, entry.Offset); ok {
+		return b, nil
+	}
+
+	buf, err := p.readAt(entry, nil)
+	if err != nil {
+		return nil, err
+	}
+	if off, n, ok := p.readahead.next(entry, p.size); ok {
+		file.AdviseWillNeed(p.f, off, n)
+	}
+
+	// The +4 is the 4 byte checksum length
+	b := buf[4:]
+	if p.encrypted {
+		if b, err = p.cipher.Open(nil, b); err != nil {
+			return nil, err
+		}
+	}
+
+	p.cache.put(p.id, entry.Offset, b)
+	return b, nil
+}
+
+// readAt reads the block for entry, checksum included, into buf if it is large
+// enough. p.mu must be held.
+func (p *preadAccessor) readAt(entry *IndexEntry, buf []byte) ([]byte, error) {
+	if p.closed || p.size < entry.Offset+int64(entry.Size) || entry.Size < 4 {
+		return nil, ErrTSMClosed
+	}
+
+	if cap(buf) < int(entry.Size) {
+		buf = make([]byte, entry.Size)
+	}
+	buf = buf[:entry.Size]
+	if _, err := p.f.ReadAt(buf, entry.Offset); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// readAll returns all values for a key in all blocks.
+func (p *preadAccessor) readAll(key []byte) ([]Value, error) {
+	// The lock is released before reading the blocks, which takes it again.
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return nil, ErrTSMClosed
+	}
+	blocks := p.index.Entries(key)
+	tombstones := p.index.TombstoneRange(key)
+	p.mu.RUnlock()
+
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+
+	var temp []Value
+	var values []Value
+	for _, block := range blocks {
+		var skip bool
+		for _, t := range tombstones {
+			// Should we skip this block because it contains points that have been deleted
+			if t.Min <= block.MinTime && t.Max >= block.MaxTime {
+				skip = true
+				break
+			}
+		}
+
+		if skip {
+			continue
+		}
+		//TODO: Validate checksum
+		temp = temp[:0]
+		b, err := p.blockData(&block)
+		if err != nil {
+			return nil, err
+		}
+		temp, err = DecodeBlock(b, temp)
+		if err != nil {
+			return nil, err
+		}
+
+		// Filter out any values that were deleted
+		for _, t := range overlappingTimeRanges(tombstones, block.MinTime, block.MaxTime) {
+			temp = Values(temp).Exclude(t.Min, t.Max)
+		}
+
+		values = append(values, temp...)
+	}
+
+	return values, nil
+}
+
+func (p *preadAccessor) path() string {
+	p.mu.RLock()
+	path := p.f.Name()
+	p.mu.RUnlock()
+	return path
+}
+
+func (p *preadAccessor) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil
+	}
+
+	p.closed = true
+	return p.f.Close()
+}
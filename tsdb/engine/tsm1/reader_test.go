@@ -130,6 +130,125 @@ func TestTSMReader_MMAP_ReadAll(t *testing.T) {
 	}
 }
 
+func TestTSMReader_Pread_ReadAll(t *testing.T) {
+	dir := mustTempDir()
+	defer os.RemoveAll(dir)
+	f := mustTempFile(dir)
+	defer f.Close()
+
+	w, err := NewTSMWriter(f)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+
+	var data = map[string][]Value{
+		"float":  []Value{NewValue(1, 1.0)},
+		"int":    []Value{NewValue(1, int64(1))},
+		"uint":   []Value{NewValue(1, ^uint64(0))},
+		"bool":   []Value{NewValue(1, true)},
+		"string": []Value{NewValue(1, "foo")},
+	}
+
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := w.Write([]byte(k), data[k]); err != nil {
+			t.Fatalf("unexpected error writing: %v", err)
+		}
+	}
+
+	if err := w.WriteIndex(); err != nil {
+		t.Fatalf("unexpected error writing index: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error closing: %v", err)
+	}
+
+	f, err = os.Open(f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error open file: %v", err)
+	}
+
+	cache := newBlockCache(1024)
+	r, err := NewTSMReader(f, WithBlockCache(cache))
+	if err != nil {
+		t.Fatalf("unexpected error created reader: %v", err)
+	}
+	if _, ok := r.accessor.(*preadAccessor); !ok {
+		t.Fatalf("unexpected accessor: %T", r.accessor)
+	}
+
+	// Read the values twice, the second time from the cache.
+	for i := 0; i < 2; i++ {
+		for k, vals := range data {
+			readValues, err := r.ReadAll([]byte(k))
+			if err != nil {
+				t.Fatalf("unexpected error readin: %v", err)
+			}
+
+			if exp := len(vals); exp != len(readValues) {
+				t.Fatalf("read values length mismatch: got %v, exp %v", len(readValues), exp)
+			}
+
+			for i, v := range vals {
+				if v.Value() != readValues[i].Value() {
+					t.Fatalf("read value mismatch(%d): got %v, exp %d", i, readValues[i].Value(), v.Value())
+				}
+			}
+		}
+	}
+
+	if cache.used() == 0 {
+		t.Fatal("expected blocks to be cached")
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("unexpected error closing: %v", err)
+	}
+	if _, err := r.ReadAll([]byte("float")); err != ErrTSMClosed {
+		t.Fatalf("unexpected error reading closed file: %v", err)
+	}
+}
+
+func TestBlockCache_Evict(t *testing.T) {
+	c := newBlockCache(10)
+	id := c.newFileID()
+
+	c.put(id, 0, make([]byte, 4))
+	c.put(id, 4, make([]byte, 4))
+	if _, ok := c.get(id, 0); !ok {
+		t.Fatal("expected block 0 to be cached")
+	}
+
+	// Block 4 is the least recently used, so it is evicted.
+	c.put(id, 8, make([]byte, 4))
+	if _, ok := c.get(id, 4); ok {
+		t.Fatal("expected block 4 to be evicted")
+	}
+	if _, ok := c.get(id, 0); !ok {
+		t.Fatal("expected block 0 to be cached")
+	}
+	if got, exp := c.used(), int64(8); got != exp {
+		t.Fatalf("size mismatch: got %v, exp %v", got, exp)
+	}
+
+	// Blocks larger than the cache are not cached.
+	c.put(id, 12, make([]byte, 11))
+	if _, ok := c.get(id, 12); ok {
+		t.Fatal("expected block 12 not to be cached")
+	}
+
+	// Blocks of other files are distinct.
+	if _, ok := c.get(c.newFileID(), 0); ok {
+		t.Fatal("expected no block for a new file")
+	}
+}
+
 func TestTSMReader_MMAP_Read(t *testing.T) {
 	dir := mustTempDir()
 	defer os.RemoveAll(dir)
@@ -909,6 +1028,42 @@ func TestTSMReader_MMAP_TombstoneMultipleRangesNoOverlap(t *testing.T) {
 	}
 }
 
+func TestAddTimeRange(t *testing.T) {
+	var ranges []TimeRange
+	for _, r := range []TimeRange{
+		{20, 30},
+		{0, 5},
+		{40, 50},
+		{6, 10},  // touches {0, 5}
+		{25, 42}, // overlaps {20, 30} and {40, 50}
+		{60, math.MaxInt64},
+		{55, 59},            // touches {60, MaxInt64}
+		{math.MinInt64, -1}, // touches {0, 10}
+	} {
+		ranges = addTimeRange(ranges, r)
+	}
+
+	exp := []TimeRange{{math.MinInt64, 10}, {20, 50}, {55, math.MaxInt64}}
+	if got := fmt.Sprint(ranges); got != fmt.Sprint(exp) {
+		t.Fatalf("ranges mismatch: got %v, exp %v", got, exp)
+	}
+
+	for _, tt := range []struct {
+		min, max int64
+		exp      []TimeRange
+	}{
+		{11, 19, nil},
+		{10, 20, []TimeRange{{math.MinInt64, 10}, {20, 50}}},
+		{51, 54, nil},
+		{45, 100, []TimeRange{{20, 50}, {55, math.MaxInt64}}},
+		{math.MinInt64, math.MaxInt64, exp},
+	} {
+		if got := overlappingTimeRanges(ranges, tt.min, tt.max); fmt.Sprint(got) != fmt.Sprint(tt.exp) {
+			t.Fatalf("overlapping(%d, %d) mismatch: got %v, exp %v", tt.min, tt.max, got, tt.exp)
+		}
+	}
+}
+
 func TestTSMReader_MMAP_TombstoneOutsideRange(t *testing.T) {
 	dir := mustTempDir()
 	defer os.RemoveAll(dir)
@@ -1936,3 +2091,31 @@ func BenchmarkBlockIterator_Next(b *testing.B) {
 		}
 	}
 }
+
+// Ensure the range following a block is read ahead unless most of it was
+// already, and never past the end of the file.
+func TestReadahead_Next(t *testing.T) {
+	var disabled readahead
+	if _, _, ok := disabled.next(&IndexEntry{Offset: 0, Size: 10}, 1000); ok {
+		t.Fatal("expected no readahead when disabled")
+	}
+
+	r := readahead{size: 100}
+	for i, tt := range []struct {
+		entry IndexEntry
+		off   int64
+		n     int64
+		ok    bool
+	}{
+		{entry: IndexEntry{Offset: 0, Size: 10}, off: 10, n: 100, ok: true},
+		{entry: IndexEntry{Offset: 10, Size: 20}},
+		{entry: IndexEntry{Offset: 50, Size: 20}, off: 70, n: 100, ok: true},
+		{entry: IndexEntry{Offset: 950, Size: 40}, off: 990, n: 10, ok: true},
+		{entry: IndexEntry{Offset: 990, Size: 10}},
+	} {
+		off, n, ok := r.next(&tt.entry, 1000)
+		if off != tt.off || n != tt.n || ok != tt.ok {
+			t.Fatalf("%d: got (%d, %d, %v), exp (%d, %d, %v)", i, off, n, ok, tt.off, tt.n, tt.ok)
+		}
+	}
+}
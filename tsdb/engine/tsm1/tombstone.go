@@ -25,6 +25,9 @@ const (
 	v4header   = 0x1504
 )
 
+// tombstoneFileExtension is the extension of the tombstone file of a TSM file.
+const tombstoneFileExtension = "tombstone"
+
 var errIncompatibleVersion = errors.New("incompatible v4 version")
 
 // Tombstoner records tombstones when entries are deleted.
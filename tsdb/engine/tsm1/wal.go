@@ -19,6 +19,7 @@ import (
 
 	"github.com/golang/snappy"
 	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/pkg/encryption"
 	"github.com/freetsdb/freetsdb/pkg/limiter"
 	"github.com/freetsdb/freetsdb/pkg/pool"
 	"go.uber.org/zap"
@@ -56,6 +57,10 @@ const (
 
 	// DeleteRangeWALEntryType indicates a delete range entry.
 	DeleteRangeWALEntryType WalEntryType = 0x03
+
+	// walEncryptedEntryFlag is set on the type of entries whose data is
+	// encrypted.
+	walEncryptedEntryFlag WalEntryType = 0x80
 )
 
 var (
@@ -65,6 +70,9 @@ var (
 	// ErrWALCorrupt is returned when reading a corrupt WAL entry.
 	ErrWALCorrupt = fmt.Errorf("corrupted WAL entry")
 
+	// ErrWALEncrypted is returned when reading an encrypted WAL entry without a cipher.
+	ErrWALEncrypted = fmt.Errorf("WAL entry is encrypted and no encryption key is configured")
+
 	defaultWaitingWALWrites = runtime.GOMAXPROCS(0) * 2
 
 	// bytePool is a shared bytes pool buffer re-cycle []byte slices to reduce allocations.
@@ -83,7 +91,12 @@ const (
 type WAL struct {
 	// goroutines waiting for the next fsync
 	syncCount   uint64
-	syncWaiters chan chan error
+	syncWaiters []chan error
+
+	// writers is the number of writes being encoded or appended to the
+	// current segment; appended is signaled each time one of them is done.
+	writers  int64
+	appended chan struct{}
 
 	mu            sync.RWMutex
 	lastWriteTime time.Time
@@ -103,6 +116,12 @@ type WAL struct {
 	// is opened if a non-default value is required.
 	syncDelay time.Duration
 
+	// groupCommitDelay is the longest an fsync waits for the writes in progress
+	// to be appended, so that they share it rather than each needing their own.
+	// A value of 0 (default) fsyncs as soon as possible.  This must be set
+	// before the WAL is opened if a non-default value is required.
+	groupCommitDelay time.Duration
+
 	// WALOutput is the writer used by the logger.
 	logger       *zap.Logger // Logger to be used for important messages
 	traceLogger  *zap.Logger // Logger to be used when trace-logging is on.
@@ -114,6 +133,9 @@ type WAL struct {
 	// statistics for the WAL
 	stats   *WALStatistics
 	limiter limiter.Fixed
+
+	// cipher encrypts new segment entries, if set.
+	cipher *encryption.Cipher
 }
 
 // NewWAL initializes a new WAL at the given directory.
@@ -125,7 +147,7 @@ func NewWAL(path string) *WAL {
 		// these options should be overriden by any options in the config
 		SegmentSize: DefaultSegmentSize,
 		closing:     make(chan struct{}),
-		syncWaiters: make(chan chan error, 1024),
+		appended:    make(chan struct{}, 1),
 		stats:       &WALStatistics{},
 		limiter:     limiter.NewFixed(defaultWaitingWALWrites),
 		logger:      logger,
@@ -221,6 +243,7 @@ func (l *WAL) Open() error {
 				return err
 			}
 			l.currentSegmentWriter = NewWALSegmentWriter(fd)
+			l.currentSegmentWriter.cipher = l.cipher
 
 			// Reset the current segment size stat
 			atomic.StoreInt64(&l.stats.CurrentBytes, stat.Size())
@@ -277,6 +300,8 @@ func (l *WAL) scheduleSync() {
 		for {
 			select {
 			case <-timerCh:
+				l.waitForWriters()
+
 				l.mu.Lock()
 				if len(l.syncWaiters) == 0 {
 					atomic.StoreUint64(&l.syncCount, 0)
@@ -298,9 +323,31 @@ func (l *WAL) scheduleSync() {
 // a write lock on the WAL is obtained before calling sync.
 func (l *WAL) sync() {
 	err := l.currentSegmentWriter.sync()
-	for len(l.syncWaiters) > 0 {
-		errC := <-l.syncWaiters
+	for i, errC := range l.syncWaiters {
 		errC <- err
+		l.syncWaiters[i] = nil
+	}
+	l.syncWaiters = l.syncWaiters[:0]
+}
+
+// waitForWriters waits until the writes in progress have been appended to the
+// current segment, so they are covered by the next fsync, or until the group
+// commit delay has passed.
+func (l *WAL) waitForWriters() {
+	if l.groupCommitDelay <= 0 || atomic.LoadInt64(&l.writers) == 0 {
+		return
+	}
+
+	t := time.NewTimer(l.groupCommitDelay)
+	defer t.Stop()
+	for atomic.LoadInt64(&l.writers) > 0 {
+		select {
+		case <-l.appended:
+		case <-t.C:
+			return
+		case <-l.closing:
+			return
+		}
 	}
 }
 
@@ -395,6 +442,9 @@ func (l *WAL) DiskSizeBytes() int64 {
 }
 
 func (l *WAL) writeToLog(entry WALEntry) (int, error) {
+	// Let a pending fsync wait for this write to be appended.
+	atomic.AddInt64(&l.writers, 1)
+
 	// limit how many concurrent encodings can be in flight.  Since we can only
 	// write one at a time to disk, a slow disk can cause the allocations below
 	// to increase quickly.  If we're backed up, wait until others have completed.
@@ -402,6 +452,7 @@ func (l *WAL) writeToLog(entry WALEntry) (int, error) {
 
 	b, err := entry.Encode(bytes)
 	if err != nil {
+		l.doneWriting()
 		bytesPool.Put(bytes)
 		return -1, err
 	}
@@ -416,6 +467,7 @@ func (l *WAL) writeToLog(entry WALEntry) (int, error) {
 	segID, err := func() (int, error) {
 		l.mu.Lock()
 		defer l.mu.Unlock()
+		defer l.doneWriting()
 
 		// Make sure the log has not been closed
 		select {
@@ -434,11 +486,7 @@ func (l *WAL) writeToLog(entry WALEntry) (int, error) {
 			return -1, fmt.Errorf("error writing WAL entry: %v", err)
 		}
 
-		select {
-		case l.syncWaiters <- syncErr:
-		default:
-			return -1, fmt.Errorf("error syncing wal")
-		}
+		l.syncWaiters = append(l.syncWaiters, syncErr)
 		l.scheduleSync()
 
 		// Update stats for current segment size
@@ -460,6 +508,15 @@ func (l *WAL) writeToLog(entry WALEntry) (int, error) {
 	return segID, <-syncErr
 }
 
+// doneWriting marks a write as no longer in progress.
+func (l *WAL) doneWriting() {
+	atomic.AddInt64(&l.writers, -1)
+	select {
+	case l.appended <- struct{}{}:
+	default:
+	}
+}
+
 // rollSegment checks if the current segment is due to roll over to a new segment;
 // and if so, opens a new segment file for future writes.
 func (l *WAL) rollSegment() error {
@@ -573,6 +630,7 @@ func (l *WAL) newSegmentFile() error {
 		return err
 	}
 	l.currentSegmentWriter = NewWALSegmentWriter(fd)
+	l.currentSegmentWriter.cipher = l.cipher
 
 	// Reset the current segment size stat
 	atomic.StoreInt64(&l.stats.CurrentBytes, 0)
@@ -1040,6 +1098,9 @@ type WALSegmentWriter struct {
 	bw   *bufio.Writer
 	w    io.WriteCloser
 	size int
+
+	cipher *encryption.Cipher
+	buf    []byte
 }
 
 // NewWALSegmentWriter returns a new WALSegmentWriter writing to w.
@@ -1059,6 +1120,15 @@ func (w *WALSegmentWriter) path() string {
 
 // Write writes entryType and the buffer containing compressed entry data.
 func (w *WALSegmentWriter) Write(entryType WalEntryType, compressed []byte) error {
+	if w.cipher != nil {
+		var err error
+		if w.buf, err = w.cipher.Seal(w.buf[:0], compressed); err != nil {
+			return err
+		}
+		compressed = w.buf
+		entryType |= walEncryptedEntryFlag
+	}
+
 	var buf [5]byte
 	buf[0] = byte(entryType)
 	binary.BigEndian.PutUint32(buf[1:5], uint32(len(compressed)))
@@ -1107,6 +1177,8 @@ type WALSegmentReader struct {
 	entry WALEntry
 	n     int64
 	err   error
+
+	cipher *encryption.Cipher
 }
 
 // NewWALSegmentReader returns a new WALSegmentReader reading from r.
@@ -1158,7 +1230,20 @@ func (r *WALSegmentReader) Next() bool {
 	}
 	nReadOK += n
 
-	decLen, err := snappy.DecodedLen(b[:length])
+	compressed := b[:length]
+	if WalEntryType(entryType)&walEncryptedEntryFlag != 0 {
+		if r.cipher == nil {
+			r.err = ErrWALEncrypted
+			return true
+		}
+		if compressed, err = r.cipher.Open(nil, compressed); err != nil {
+			r.err = err
+			return true
+		}
+		entryType &^= byte(walEncryptedEntryFlag)
+	}
+
+	decLen, err := snappy.DecodedLen(compressed)
 	if err != nil {
 		r.err = err
 		return true
@@ -1166,7 +1251,7 @@ func (r *WALSegmentReader) Next() bool {
 	decBuf := *(getBuf(decLen))
 	defer putBuf(&decBuf)
 
-	data, err := snappy.Decode(decBuf, b[:length])
+	data, err := snappy.Decode(decBuf, compressed)
 	if err != nil {
 		r.err = err
 		return true
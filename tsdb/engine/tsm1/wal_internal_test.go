@@ -0,0 +1,120 @@
+package tsm1
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/freetsdb/freetsdb/pkg/encryption"
+)
+
+// Ensure an fsync waits for the writes in progress to be appended, so they
+// share it, but no longer than the group commit delay.
+func TestWAL_WaitForWriters(t *testing.T) {
+	// The WAL is not opened, so nothing is written to its path.
+	l := NewWAL("wal")
+
+	// Without a group commit delay the fsync does not wait.
+	atomic.AddInt64(&l.writers, 1)
+	l.waitForWriters()
+	l.doneWriting()
+
+	l.groupCommitDelay = time.Minute
+	atomic.AddInt64(&l.writers, 2)
+	done := make(chan struct{})
+	go func() {
+		l.waitForWriters()
+		close(done)
+	}()
+
+	l.doneWriting()
+	select {
+	case <-done:
+		t.Fatal("expected the fsync to wait for the second write")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	l.doneWriting()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the fsync to stop waiting once the writes were appended")
+	}
+
+	// A write that takes too long does not hold the fsync back.
+	l.groupCommitDelay = 10 * time.Millisecond
+	atomic.AddInt64(&l.writers, 1)
+	start := time.Now()
+	l.waitForWriters()
+	if d := time.Since(start); d < l.groupCommitDelay {
+		t.Fatalf("fsync waited %s, expected at least %s", d, l.groupCommitDelay)
+	}
+	l.doneWriting()
+}
+
+// Ensure WAL entries written with a cipher are encrypted on disk and loaded
+// into the cache only with the cipher.
+func TestWAL_Encrypted(t *testing.T) {
+	dir := mustTempDir()
+	defer os.RemoveAll(dir)
+
+	c, err := encryption.NewCipher([]encryption.Key{{ID: 1, Secret: bytes.Repeat([]byte{1}, encryption.KeySize)}})
+	if err != nil {
+		t.Fatalf("unexpected error creating cipher: %v", err)
+	}
+
+	l := NewWAL(dir)
+	l.cipher = c
+	if err := l.Open(); err != nil {
+		t.Fatalf("unexpected error opening wal: %v", err)
+	}
+
+	values := map[string][]Value{
+		"cpu,host=server01#!~#value": {NewValue(1, 1.5), NewValue(2, 2.5)},
+	}
+	if _, err := l.WriteMulti(values); err != nil {
+		t.Fatalf("unexpected error writing: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("unexpected error closing wal: %v", err)
+	}
+
+	files, err := segmentFileNames(dir)
+	if err != nil {
+		t.Fatalf("unexpected error listing segments: %v", err)
+	} else if len(files) != 1 {
+		t.Fatalf("unexpected segments: %v", files)
+	}
+	if b, err := ioutil.ReadFile(files[0]); err != nil {
+		t.Fatalf("unexpected error reading segment: %v", err)
+	} else if bytes.Contains(b, []byte("server01")) {
+		t.Fatal("expected the entry to be encrypted")
+	}
+
+	// Loading without the cipher fails rather than dropping the entries.
+	if err := NewCacheLoader(files).Load(NewCache(0)); err == nil || !strings.Contains(err.Error(), ErrWALEncrypted.Error()) {
+		t.Fatalf("unexpected error: got %v, exp %v", err, ErrWALEncrypted)
+	}
+
+	cache := NewCache(0)
+	loader := NewCacheLoader(files)
+	loader.Cipher = c
+	if err := loader.Load(cache); err != nil {
+		t.Fatalf("unexpected error loading wal: %v", err)
+	}
+
+	got := cache.Values([]byte("cpu,host=server01#!~#value"))
+	exp := values["cpu,host=server01#!~#value"]
+	if len(got) != len(exp) {
+		t.Fatalf("values length mismatch: got %v, exp %v", len(got), len(exp))
+	}
+	for i := range exp {
+		if got[i].String() != exp[i].String() {
+			t.Fatalf("value mismatch(%d): got %v, exp %v", i, got[i], exp[i])
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package tsm1
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/freetsdb/freetsdb/pkg/encryption"
+	"github.com/golang/snappy"
+	"go.uber.org/zap"
+)
+
+// WALQuarantineDir is the directory of the WAL of a shard where damaged
+// segments are moved to by WAL salvage, along with a report of the damage.
+const WALQuarantineDir = "quarantine"
+
+// walSalvagedEntry is a valid entry found in a damaged WAL segment.
+type walSalvagedEntry struct {
+	entry  WALEntry
+	offset int
+	size   int
+}
+
+// walCorruptRange is a range of a damaged WAL segment holding no valid entry.
+type walCorruptRange struct {
+	offset int
+	size   int
+}
+
+// salvageWALSegment returns the valid entries of the WAL segment b from
+// offset on, and the ranges between them that could not be read. After a
+// corrupt entry, the next valid entry is searched for at every following
+// offset.
+func salvageWALSegment(b []byte, offset int, cipher *encryption.Cipher) ([]walSalvagedEntry, []walCorruptRange) {
+	var entries []walSalvagedEntry
+	var corrupt []walCorruptRange
+	start := -1
+	for i := offset; i < len(b); {
+		entry, n, err := decodeWALEntry(b[i:], cipher)
+		if err != nil {
+			if start == -1 {
+				start = i
+			}
+			i++
+			continue
+		}
+		if start != -1 {
+			corrupt = append(corrupt, walCorruptRange{offset: start, size: i - start})
+			start = -1
+		}
+		entries = append(entries, walSalvagedEntry{entry: entry, offset: i, size: n})
+		i += n
+	}
+	if start != -1 {
+		corrupt = append(corrupt, walCorruptRange{offset: start, size: len(b) - start})
+	}
+	return entries, corrupt
+}
+
+// decodeWALEntry decodes the WAL entry at the start of b and returns it with
+// its encoded size. Unlike WALSegmentReader, it never allocates more than the
+// entry could hold, as b may be garbage.
+func decodeWALEntry(b []byte, cipher *encryption.Cipher) (WALEntry, int, error) {
+	if len(b) < 5 {
+		return nil, 0, ErrWALCorrupt
+	}
+	entryType := b[0]
+	length := int(binary.BigEndian.Uint32(b[1:5]))
+	if length > len(b)-5 {
+		return nil, 0, ErrWALCorrupt
+	}
+
+	compressed := b[5 : 5+length]
+	if WalEntryType(entryType)&walEncryptedEntryFlag != 0 {
+		if cipher == nil {
+			return nil, 0, ErrWALEncrypted
+		}
+		var err error
+		if compressed, err = cipher.Open(nil, compressed); err != nil {
+			return nil, 0, err
+		}
+		entryType &^= byte(walEncryptedEntryFlag)
+	}
+
+	// Snappy expands data at most about 21 times.
+	if n, err := snappy.DecodedLen(compressed); err != nil {
+		return nil, 0, err
+	} else if n > 32*len(compressed)+32 {
+		return nil, 0, ErrWALCorrupt
+	}
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var entry WALEntry
+	switch WalEntryType(entryType) {
+	case WriteWALEntryType:
+		entry = &WriteWALEntry{Values: make(map[string][]Value)}
+	case DeleteWALEntryType:
+		entry = &DeleteWALEntry{}
+	case DeleteRangeWALEntryType:
+		entry = &DeleteRangeWALEntry{}
+	default:
+		return nil, 0, ErrWALCorrupt
+	}
+	if err := entry.UnmarshalBinary(data); err != nil {
+		return nil, 0, err
+	}
+	return entry, 5 + length, nil
+}
+
+// salvage loads the valid entries of the segment at path found after the
+// corrupt entry at offset n, the entries before it being loaded already.
+// The original segment is moved to the quarantine directory with a report
+// of its corrupt ranges, and replaced by a segment of its valid entries.
+func (cl *CacheLoader) salvage(path string, n int64, cache *Cache) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	entries, corrupt := salvageWALSegment(b, int(n), cl.Cipher)
+
+	valid := append([]byte(nil), b[:n]...)
+	for _, e := range entries {
+		if err := applyWALEntry(cache, e.entry); err != nil {
+			return err
+		}
+		valid = append(valid, b[e.offset:e.offset+e.size]...)
+	}
+
+	dir := filepath.Join(filepath.Dir(path), WALQuarantineDir)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	name := filepath.Base(path)
+
+	var report bytes.Buffer
+	fmt.Fprintf(&report, "segment: %s\n", path)
+	fmt.Fprintf(&report, "size: %d\n", len(b))
+	fmt.Fprintf(&report, "valid bytes before corruption: %d\n", n)
+	fmt.Fprintf(&report, "entries recovered after corruption: %d\n", len(entries))
+	fmt.Fprintf(&report, "bytes kept: %d\n", len(valid))
+	fmt.Fprintln(&report, "corrupt ranges (offset size):")
+	for _, r := range corrupt {
+		fmt.Fprintf(&report, "%d %d\n", r.offset, r.size)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".report"), report.Bytes(), 0666); err != nil {
+		return err
+	}
+
+	// Write the valid entries before quarantining the original so that a
+	// crash never loses both.
+	tmp := filepath.Join(dir, name+".salvaged")
+	if err := writeFileSync(tmp, valid); err != nil {
+		return err
+	} else if err := os.Rename(path, filepath.Join(dir, name)); err != nil {
+		return err
+	} else if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	cl.salvaged++
+	cl.Logger.Warn("Salvaged corrupt WAL segment",
+		zap.String("path", path),
+		zap.Int64("pos", n),
+		zap.Int("recovered_entries", len(entries)),
+		zap.Int("corrupt_ranges", len(corrupt)),
+		zap.Int("lost_bytes", len(b)-len(valid)),
+		zap.String("quarantine", filepath.Join(dir, name)))
+	return nil
+}
+
+// writeFileSync writes b to a new file at path and syncs it.
+func writeFileSync(path string, b []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	} else if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
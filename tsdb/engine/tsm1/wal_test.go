@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/golang/snappy"
@@ -548,6 +550,64 @@ func TestWAL_Delete(t *testing.T) {
 	}
 }
 
+func TestWAL_WriteMulti_Concurrent(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	w := tsm1.NewWAL(dir)
+	if err := w.Open(); err != nil {
+		t.Fatalf("error opening WAL: %v", err)
+	}
+	defer w.Close()
+
+	// More writers than used to be able to wait for a single fsync.
+	const n = 2000
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := w.WriteMulti(map[string][]tsm1.Value{
+				fmt.Sprintf("cpu,host=%d#!~#value", i): []tsm1.Value{tsm1.NewValue(1, 1.1)},
+			})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("error writing points: %v", err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "_*."+tsm1.WALFileExtension))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entries int
+	for _, fn := range files {
+		f, err := os.Open(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := tsm1.NewWALSegmentReader(f)
+		for r.Next() {
+			if _, err := r.Read(); err != nil {
+				t.Fatalf("error reading entry: %v", err)
+			}
+			entries++
+		}
+		r.Close()
+	}
+	if entries != n {
+		t.Fatalf("entry count mismatch: got %v, exp %v", entries, n)
+	}
+}
+
 func TestWALWriter_Corrupt(t *testing.T) {
 	dir := MustTempDir()
 	defer os.RemoveAll(dir)
@@ -31,6 +31,12 @@ is stored in the index.
 │ 4 bytes │ N bytes │ 4 bytes │ N bytes │ 4 bytes │ N bytes │
 └─────────┴─────────┴─────────┴─────────┴─────────┴─────────┘
 
+In encrypted files (version 2) the data of each block is sealed with
+AES-256-GCM and prefixed with the ID of the key used and the nonce.  The CRC
+is computed over the unencrypted data.  The index described below is sealed
+as a whole the same way, and the footer holds the position of the sealed
+index.
+
 Following the blocks is the index for the blocks in the file.  The index is
 composed of a sequence of index entries ordered lexicographically by key and
 then by time.  Each index entry starts with a key length and key followed by a
@@ -73,6 +79,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/freetsdb/freetsdb/pkg/encryption"
 )
 
 const (
@@ -83,6 +91,9 @@ const (
 	// Version indicates the version of the TSM file format.
 	Version byte = 1
 
+	// EncryptedVersion indicates a TSM file whose blocks are encrypted.
+	EncryptedVersion byte = 2
+
 	// Size in bytes of an index entry
 	indexEntrySize = 28
 
@@ -548,17 +559,35 @@ type tsmWriter struct {
 
 	// The bytes written count of when we last fsync'd
 	lastSync int64
+
+	// If set, block data is encrypted.
+	cipher *encryption.Cipher
+	buf    []byte
+}
+
+type tsmWriterOption func(*tsmWriter)
+
+// WithWriterCipher is an option for encrypting the blocks written. A nil
+// cipher writes an unencrypted file.
+var WithWriterCipher = func(c *encryption.Cipher) tsmWriterOption {
+	return func(t *tsmWriter) {
+		t.cipher = c
+	}
 }
 
 // NewTSMWriter returns a new TSMWriter writing to w.
-func NewTSMWriter(w io.Writer) (TSMWriter, error) {
+func NewTSMWriter(w io.Writer, options ...tsmWriterOption) (TSMWriter, error) {
 	index := NewIndexWriter()
-	return &tsmWriter{wrapped: w, w: bufio.NewWriterSize(w, 1024*1024), index: index}, nil
+	t := &tsmWriter{wrapped: w, w: bufio.NewWriterSize(w, 1024*1024), index: index}
+	for _, option := range options {
+		option(t)
+	}
+	return t, nil
 }
 
 // NewTSMWriterWithDiskBuffer returns a new TSMWriter writing to w and will use a disk
 // based buffer for the TSM index if possible.
-func NewTSMWriterWithDiskBuffer(w io.Writer) (TSMWriter, error) {
+func NewTSMWriterWithDiskBuffer(w io.Writer, options ...tsmWriterOption) (TSMWriter, error) {
 	var index IndexWriter
 	// Make sure is a File so we can write the temp index alongside it.
 	if fw, ok := w.(syncer); ok {
@@ -572,13 +601,20 @@ func NewTSMWriterWithDiskBuffer(w io.Writer) (TSMWriter, error) {
 		index = NewIndexWriter()
 	}
 
-	return &tsmWriter{wrapped: w, w: bufio.NewWriterSize(w, 1024*1024), index: index}, nil
+	t := &tsmWriter{wrapped: w, w: bufio.NewWriterSize(w, 1024*1024), index: index}
+	for _, option := range options {
+		option(t)
+	}
+	return t, nil
 }
 
 func (t *tsmWriter) writeHeader() error {
 	var buf [5]byte
 	binary.BigEndian.PutUint32(buf[0:4], MagicNumber)
 	buf[4] = Version
+	if t.cipher != nil {
+		buf[4] = EncryptedVersion
+	}
 
 	n, err := t.w.Write(buf[:])
 	if err != nil {
@@ -616,19 +652,10 @@ func (t *tsmWriter) Write(key []byte, values Values) error {
 		return err
 	}
 
-	var checksum [crc32.Size]byte
-	binary.BigEndian.PutUint32(checksum[:], crc32.ChecksumIEEE(block))
-
-	_, err = t.w.Write(checksum[:])
-	if err != nil {
-		return err
-	}
-
-	n, err := t.w.Write(block)
+	n, err := t.writeBlock(block)
 	if err != nil {
 		return err
 	}
-	n += len(checksum)
 
 	// Record this block in index
 	t.index.Add(key, blockType, values[0].UnixNano(), values[len(values)-1].UnixNano(), t.n, uint32(n))
@@ -668,19 +695,10 @@ func (t *tsmWriter) WriteBlock(key []byte, minTime, maxTime int64, block []byte)
 		}
 	}
 
-	var checksum [crc32.Size]byte
-	binary.BigEndian.PutUint32(checksum[:], crc32.ChecksumIEEE(block))
-
-	_, err = t.w.Write(checksum[:])
-	if err != nil {
-		return err
-	}
-
-	n, err := t.w.Write(block)
+	n, err := t.writeBlock(block)
 	if err != nil {
 		return err
 	}
-	n += len(checksum)
 
 	// Record this block in index
 	t.index.Add(key, blockType, minTime, maxTime, t.n, uint32(n))
@@ -703,6 +721,31 @@ func (t *tsmWriter) WriteBlock(key []byte, minTime, maxTime int64, block []byte)
 	return nil
 }
 
+// writeBlock writes the checksum and data of block and returns the number of
+// bytes written.
+func (t *tsmWriter) writeBlock(block []byte) (int, error) {
+	var checksum [crc32.Size]byte
+	binary.BigEndian.PutUint32(checksum[:], crc32.ChecksumIEEE(block))
+
+	if _, err := t.w.Write(checksum[:]); err != nil {
+		return 0, err
+	}
+
+	if t.cipher != nil {
+		var err error
+		if t.buf, err = t.cipher.Seal(t.buf[:0], block); err != nil {
+			return 0, err
+		}
+		block = t.buf
+	}
+
+	n, err := t.w.Write(block)
+	if err != nil {
+		return 0, err
+	}
+	return n + len(checksum), nil
+}
+
 // WriteIndex writes the index section of the file.  If there are no index entries to write,
 // this returns ErrNoValues.
 func (t *tsmWriter) WriteIndex() error {
@@ -719,7 +762,21 @@ func (t *tsmWriter) WriteIndex() error {
 	}
 
 	// Write the index
-	if _, err := t.index.WriteTo(t.w); err != nil {
+	if t.cipher != nil {
+		// The index of an encrypted file is sealed as a whole, so it is
+		// buffered in memory.
+		var index bytes.Buffer
+		if _, err := t.index.WriteTo(&index); err != nil {
+			return err
+		}
+		sealed, err := t.cipher.Seal(nil, index.Bytes())
+		if err != nil {
+			return err
+		}
+		if _, err := t.w.Write(sealed); err != nil {
+			return err
+		}
+	} else if _, err := t.index.WriteTo(t.w); err != nil {
 		return err
 	}
 
@@ -798,27 +855,27 @@ func (t *tsmWriter) Size() uint32 {
 }
 
 // verifyVersion verifies that the reader's bytes are a TSM byte
-// stream of the correct version (1)
-func verifyVersion(r io.ReadSeeker) error {
+// stream of a supported version (1 or 2) and returns the version.
+func verifyVersion(r io.ReadSeeker) (byte, error) {
 	_, err := r.Seek(0, 0)
 	if err != nil {
-		return fmt.Errorf("init: failed to seek: %v", err)
+		return 0, fmt.Errorf("init: failed to seek: %v", err)
 	}
 	var b [4]byte
 	_, err = io.ReadFull(r, b[:])
 	if err != nil {
-		return fmt.Errorf("init: error reading magic number of file: %v", err)
+		return 0, fmt.Errorf("init: error reading magic number of file: %v", err)
 	}
 	if binary.BigEndian.Uint32(b[:]) != MagicNumber {
-		return fmt.Errorf("can only read from tsm file")
+		return 0, fmt.Errorf("can only read from tsm file")
 	}
 	_, err = io.ReadFull(r, b[:1])
 	if err != nil {
-		return fmt.Errorf("init: error reading version: %v", err)
+		return 0, fmt.Errorf("init: error reading version: %v", err)
 	}
-	if b[0] != Version {
-		return fmt.Errorf("init: file is version %b. expected %b", b[0], Version)
+	if b[0] != Version && b[0] != EncryptedVersion {
+		return 0, fmt.Errorf("init: file is version %b. expected %b or %b", b[0], Version, EncryptedVersion)
 	}
 
-	return nil
+	return b[0], nil
 }
@@ -8,6 +8,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/freetsdb/freetsdb/pkg/encryption"
 	"github.com/freetsdb/freetsdb/tsdb/engine/tsm1"
 )
 
@@ -113,6 +114,73 @@ func TestTSMWriter_Write_Single(t *testing.T) {
 	}
 }
 
+func TestTSMWriter_Write_Encrypted(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+	f := MustTempFile(dir)
+
+	c, err := encryption.NewCipher([]encryption.Key{{ID: 1, Secret: bytes.Repeat([]byte{1}, encryption.KeySize)}})
+	if err != nil {
+		t.Fatalf("unexpected error creating cipher: %v", err)
+	}
+
+	w, err := tsm1.NewTSMWriter(f, tsm1.WithWriterCipher(c))
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+
+	values := []tsm1.Value{tsm1.NewValue(0, 1.0), tsm1.NewValue(1, 2.0)}
+	if err := w.Write([]byte("cpu"), values); err != nil {
+		t.Fatalf("unexpected error writing: %v", err)
+	}
+	if err := w.WriteIndex(); err != nil {
+		t.Fatalf("unexpected error writing index: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error closing: %v", err)
+	}
+
+	// Neither the blocks nor the index hold the key in plaintext.
+	if b, err := ioutil.ReadFile(f.Name()); err != nil {
+		t.Fatalf("unexpected error reading file: %v", err)
+	} else if bytes.Contains(b, []byte("cpu")) {
+		t.Fatal("expected the key to be encrypted")
+	}
+
+	// Opening without a cipher must fail rather than return ciphertext.
+	fd, err := os.Open(f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error open file: %v", err)
+	}
+	if _, err := tsm1.NewTSMReader(fd); err != tsm1.ErrTSMEncrypted {
+		t.Fatalf("unexpected error: got %v, exp %v", err, tsm1.ErrTSMEncrypted)
+	}
+	fd.Close()
+
+	fd, err = os.Open(f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error open file: %v", err)
+	}
+	r, err := tsm1.NewTSMReader(fd, tsm1.WithReaderCipher(c))
+	if err != nil {
+		t.Fatalf("unexpected error created reader: %v", err)
+	}
+	defer r.Close()
+
+	readValues, err := r.ReadAll([]byte("cpu"))
+	if err != nil {
+		t.Fatalf("unexpected error reading: %v", err)
+	}
+	if len(readValues) != len(values) {
+		t.Fatalf("read values length mismatch: got %v, exp %v", len(readValues), len(values))
+	}
+	for i, v := range values {
+		if v.Value() != readValues[i].Value() {
+			t.Fatalf("read value mismatch(%d): got %v, exp %v", i, readValues[i].Value(), v.Value())
+		}
+	}
+}
+
 func TestTSMWriter_Write_Multiple(t *testing.T) {
 	dir := MustTempDir()
 	defer os.RemoveAll(dir)
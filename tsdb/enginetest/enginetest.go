@@ -0,0 +1,267 @@
+// Package enginetest provides conformance tests for tsdb.Engine
+// implementations.
+//
+// An out-of-tree engine runs the tests from a test of its own package, after
+// registering itself with tsdb.RegisterEngine:
+//
+//	func TestEngine(t *testing.T) {
+//		enginetest.Run(t, "myengine")
+//	}
+package enginetest // import "github.com/freetsdb/freetsdb/tsdb/enginetest"
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/query"
+	"github.com/freetsdb/freetsdb/services/influxql"
+	"github.com/freetsdb/freetsdb/tsdb"
+	_ "github.com/freetsdb/freetsdb/tsdb/index"
+)
+
+// Run runs the conformance tests against the engine registered as name,
+// with each of the registered indexes.
+func Run(t *testing.T, name string) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			for _, tt := range []struct {
+				name string
+				fn   func(t *testing.T, sh *shard)
+			}{
+				{name: "WritePoints", fn: testWritePoints},
+				{name: "CreateIterator", fn: testCreateIterator},
+				{name: "DeleteMeasurement", fn: testDeleteMeasurement},
+				{name: "Reopen", fn: testReopen},
+			} {
+				t.Run(tt.name, func(t *testing.T) {
+					sh := mustOpenShard(t, name, index)
+					defer sh.Close()
+					tt.fn(t, sh)
+				})
+			}
+		})
+	}
+}
+
+const points = `
+cpu,host=serverA,region=uswest value=1,count=1i 10
+cpu,host=serverA,region=uswest value=2,count=2i 20
+cpu,host=serverB,region=useast value=3,count=3i 30
+mem,host=serverA free=100i,ok=true,state="up" 10
+`
+
+func testWritePoints(t *testing.T, sh *shard) {
+	sh.mustWritePoints(t, points)
+
+	if n := sh.SeriesN(); n != 3 {
+		t.Fatalf("unexpected series count: got=%d exp=3", n)
+	}
+	for _, name := range []string{"cpu", "mem"} {
+		if ok, err := sh.MeasurementExists([]byte(name)); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			t.Fatalf("measurement %s does not exist", name)
+		}
+	}
+
+	mf := sh.MeasurementFields([]byte("mem"))
+	for key, typ := range map[string]influxql.DataType{
+		"free":  influxql.Integer,
+		"ok":    influxql.Boolean,
+		"state": influxql.String,
+	} {
+		if f := mf.Field(key); f == nil || f.Type != typ {
+			t.Fatalf("unexpected field %s: %+v", key, f)
+		}
+	}
+}
+
+func testCreateIterator(t *testing.T, sh *shard) {
+	sh.mustWritePoints(t, points)
+
+	if got, exp := sh.readFloats(t, "cpu", "value"), []float64{1, 2, 3}; !equal(got, exp) {
+		t.Fatalf("unexpected values: got=%v exp=%v", got, exp)
+	}
+}
+
+func testDeleteMeasurement(t *testing.T, sh *shard) {
+	sh.mustWritePoints(t, points)
+
+	if err := sh.DeleteMeasurement([]byte("cpu")); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := sh.MeasurementExists([]byte("cpu")); err != nil {
+		t.Fatal(err)
+	} else if ok {
+		t.Fatal("measurement cpu exists after delete")
+	}
+	if got := sh.readFloats(t, "cpu", "value"); len(got) != 0 {
+		t.Fatalf("unexpected values after delete: %v", got)
+	}
+	if ok, err := sh.MeasurementExists([]byte("mem")); err != nil || !ok {
+		t.Fatalf("measurement mem does not exist after deleting cpu: %v", err)
+	}
+}
+
+func testReopen(t *testing.T, sh *shard) {
+	sh.mustWritePoints(t, points)
+
+	if err := sh.reopen(); err != nil {
+		t.Fatal(err)
+	}
+
+	if sh.engine != "tsm1" {
+		buf, err := ioutil.ReadFile(filepath.Join(sh.Path(), tsdb.EngineFile))
+		if err != nil {
+			t.Fatal(err)
+		} else if got := strings.TrimSpace(string(buf)); got != sh.engine {
+			t.Fatalf("unexpected engine recorded: got=%s exp=%s", got, sh.engine)
+		}
+	}
+
+	if n := sh.SeriesN(); n != 3 {
+		t.Fatalf("unexpected series count after reopen: got=%d exp=3", n)
+	}
+	if got, exp := sh.readFloats(t, "cpu", "value"), []float64{1, 2, 3}; !equal(got, exp) {
+		t.Fatalf("unexpected values after reopen: got=%v exp=%v", got, exp)
+	}
+}
+
+// shard is a shard of the engine under test in a temporary directory.
+type shard struct {
+	*tsdb.Shard
+	engine string
+	index  string
+	dir    string
+	sfile  *tsdb.SeriesFile
+}
+
+func mustOpenShard(t *testing.T, engine, index string) *shard {
+	dir, err := ioutil.TempDir("", "freetsdb-enginetest-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sfile := tsdb.NewSeriesFile(filepath.Join(dir, tsdb.SeriesFileDirectory))
+	if err := sfile.Open(); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	sh := &shard{engine: engine, index: index, dir: dir, sfile: sfile}
+	sh.Shard = sh.newShard()
+	if err := sh.Open(); err != nil {
+		sh.Close()
+		t.Fatal(err)
+	}
+	return sh
+}
+
+func (sh *shard) newShard() *tsdb.Shard {
+	opt := tsdb.NewEngineOptions()
+	opt.EngineVersion = sh.engine
+	opt.IndexVersion = sh.index
+	opt.Config.WALDir = filepath.Join(sh.dir, "wal")
+	if sh.index == tsdb.InmemIndexName {
+		idx, err := tsdb.NewInmemIndex("db0", sh.sfile)
+		if err != nil {
+			panic(err)
+		}
+		opt.InmemIndex = idx
+	}
+	opt.SeriesIDSets = seriesIDSets{}
+
+	s := tsdb.NewShard(1,
+		filepath.Join(sh.dir, "data", "db0", "rp0", "1"),
+		filepath.Join(sh.dir, "wal", "db0", "rp0", "1"),
+		sh.sfile,
+		opt,
+	)
+	s.EnableOnOpen = true
+	return s
+}
+
+// reopen closes the shard and opens it again from its files.
+func (sh *shard) reopen() error {
+	if err := sh.Shard.Close(); err != nil {
+		return err
+	}
+	sh.Shard = sh.newShard()
+	return sh.Open()
+}
+
+// Close closes the shard and removes its files.
+func (sh *shard) Close() error {
+	defer os.RemoveAll(sh.dir)
+	if sh.Shard != nil {
+		sh.Shard.Close()
+	}
+	return sh.sfile.Close()
+}
+
+func (sh *shard) mustWritePoints(t *testing.T, s string) {
+	pts, err := models.ParsePointsString(strings.TrimSpace(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sh.WritePoints(pts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readFloats returns the values of the float field of measurement, in time
+// order.
+func (sh *shard) readFloats(t *testing.T, measurement, field string) []float64 {
+	itr, err := sh.CreateIterator(context.Background(), &influxql.Measurement{Name: measurement}, query.IteratorOptions{
+		Expr:      influxql.MustParseExpr(field),
+		Ascending: true,
+		Ordered:   true,
+		StartTime: influxql.MinTime,
+		EndTime:   influxql.MaxTime,
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if itr == nil {
+		return nil
+	}
+	defer itr.Close()
+
+	fitr, ok := itr.(query.FloatIterator)
+	if !ok {
+		t.Fatalf("unexpected iterator type: %T", itr)
+	}
+
+	var values []float64
+	for {
+		p, err := fitr.Next()
+		if err != nil {
+			t.Fatal(err)
+		} else if p == nil {
+			return values
+		}
+		values = append(values, p.Value)
+	}
+}
+
+func equal(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// seriesIDSets provides the series ID sets of the shards of a database,
+// which the store provides outside of tests.
+type seriesIDSets struct{}
+
+func (seriesIDSets) ForEach(f func(ids *tsdb.SeriesIDSet)) error { return nil }
@@ -43,6 +43,7 @@ func (defaultFieldValidator) Validate(mf *MeasurementFields, point models.Point)
 					"%s: input field \"%s\" on measurement \"%s\" is type %s, already exists as type %s",
 					ErrFieldTypeConflict, iter.FieldKey(), point.Name(), dataType, f.Type),
 				Dropped: 1,
+				Err:     ErrFieldTypeConflict,
 			}
 		}
 	}
@@ -52,6 +52,10 @@ type Index interface {
 	SeriesSketches() (estimator.Sketch, estimator.Sketch, error)
 	SeriesIDSet() *SeriesIDSet
 
+	// Epoch returns a number incremented whenever series are added to or
+	// dropped from the index.
+	Epoch() uint64
+
 	HasTagKey(name, key []byte) (bool, error)
 	HasTagValue(name, key, value []byte) (bool, error)
 
@@ -771,6 +775,10 @@ type seriesPointIterator struct {
 	keys     [][]byte
 	opt      query.IteratorOptions
 
+	// n is the number of series keys read, bounded by opt.MaxSeriesN as the
+	// keys of a measurement are held in memory to be sorted.
+	n int
+
 	point query.FloatPoint // reusable point
 }
 
@@ -898,6 +906,10 @@ func (itr *seriesPointIterator) readSeriesKeys(name []byte) error {
 			continue
 		}
 		itr.keys = append(itr.keys, key)
+
+		if itr.n++; itr.opt.MaxSeriesN > 0 && itr.n > itr.opt.MaxSeriesN {
+			return fmt.Errorf("max-select-series limit exceeded: (%d/%d)", itr.n, itr.opt.MaxSeriesN)
+		}
 	}
 
 	// Sort keys.
@@ -2483,33 +2495,10 @@ func (is IndexSet) MeasurementTagKeyValuesByExpr(auth query.Authorizer, name []b
 					break
 				}
 
-				sitr, err := is.tagValueSeriesIDIterator(name, []byte(key), val)
-				if err != nil {
-					return nil, err
-				} else if sitr == nil {
-					continue
-				}
-				defer sitr.Close()
-				sitr = FilterUndeletedSeriesIDIterator(is.SeriesFile, sitr)
-
-				for {
-					se, err := sitr.Next()
-					if err != nil {
-						return nil, err
-					}
-
-					if se.SeriesID == 0 {
-						break
-					}
-
-					name, tags := is.SeriesFile.Series(se.SeriesID)
-					if auth.AuthorizeSeriesRead(is.Database(), name, tags) {
-						results[ki] = append(results[ki], string(val))
-						break
-					}
-				}
-				if err := sitr.Close(); err != nil {
+				if ok, err := is.tagValueHasAuthorizedSeries(auth, name, []byte(key), val); err != nil {
 					return nil, err
+				} else if ok {
+					results[ki] = append(results[ki], string(val))
 				}
 			}
 		}
@@ -2536,6 +2525,188 @@ func (is IndexSet) MeasurementTagKeyValuesByExpr(auth query.Authorizer, name []b
 	return results, nil
 }
 
+// tagValueHasAuthorizedSeries determines if there exists an authorized series
+// with the tag value for the measurement name and tag key.
+func (is IndexSet) tagValueHasAuthorizedSeries(auth query.Authorizer, name, key, value []byte) (bool, error) {
+	sitr, err := is.tagValueSeriesIDIterator(name, key, value)
+	if err != nil {
+		return false, err
+	} else if sitr == nil {
+		return false, nil
+	}
+	sitr = FilterUndeletedSeriesIDIterator(is.SeriesFile, sitr)
+	defer sitr.Close()
+
+	for {
+		se, err := sitr.Next()
+		if err != nil {
+			return false, err
+		} else if se.SeriesID == 0 {
+			return false, nil
+		}
+
+		name, tags := is.SeriesFile.Series(se.SeriesID)
+		if auth.AuthorizeSeriesRead(is.Database(), name, tags) {
+			return true, nil
+		}
+	}
+}
+
+// KeyValueIterator represents an iterator over tag key/value pairs.
+type KeyValueIterator interface {
+	Close() error
+	Next() (*KeyValue, error)
+}
+
+// MeasurementTagKeyValueIterator returns an iterator over the values of keys
+// for the measurement, ordered by key and then value. Without expr the values
+// are read from the index as the iterator advances, so only the pairs that are
+// consumed are ever held in memory. With expr the values of all keys are read
+// when the iterator is created, as they are found by scanning the series.
+func (is IndexSet) MeasurementTagKeyValueIterator(auth query.Authorizer, name []byte, keys []string, expr influxql.Expr) (KeyValueIterator, error) {
+	keys = append([]string(nil), keys...)
+	sort.Strings(keys)
+
+	itr := &keyValueIterator{
+		is:      is,
+		auth:    auth,
+		name:    name,
+		keys:    keys,
+		release: is.SeriesFile.Retain(),
+	}
+	if expr != nil {
+		values, err := is.MeasurementTagKeyValuesByExpr(auth, name, keys, expr, true)
+		if err != nil {
+			itr.Close()
+			return nil, err
+		}
+		itr.values = values
+	}
+	return itr, nil
+}
+
+// keyValueIterator iterates over the tag values of a measurement. The values
+// come from a tag value iterator per key, unless they were read upfront.
+type keyValueIterator struct {
+	is   IndexSet
+	auth query.Authorizer
+	name []byte
+
+	keys   []string
+	values [][]string // values of each key, if read upfront
+	vitr   TagValueIterator
+	kv     KeyValue
+
+	release func()
+}
+
+// Next returns the next key/value pair, or nil when there are none left.
+func (itr *keyValueIterator) Next() (*KeyValue, error) {
+	for len(itr.keys) > 0 {
+		key := itr.keys[0]
+
+		// Values read upfront.
+		if itr.values != nil {
+			if len(itr.values[0]) == 0 {
+				itr.keys, itr.values = itr.keys[1:], itr.values[1:]
+				continue
+			}
+			itr.kv = KeyValue{Key: key, Value: itr.values[0][0]}
+			itr.values[0] = itr.values[0][1:]
+			return &itr.kv, nil
+		}
+
+		if itr.vitr == nil {
+			vitr, err := itr.is.tagValueIterator(itr.name, []byte(key))
+			if err != nil {
+				return nil, err
+			} else if vitr == nil {
+				itr.keys = itr.keys[1:]
+				continue
+			}
+			itr.vitr = vitr
+		}
+
+		val, err := itr.vitr.Next()
+		if err != nil {
+			return nil, err
+		} else if val == nil {
+			if err := itr.vitr.Close(); err != nil {
+				return nil, err
+			}
+			itr.vitr, itr.keys = nil, itr.keys[1:]
+			continue
+		}
+
+		if !query.AuthorizerIsOpen(itr.auth) {
+			if ok, err := itr.is.tagValueHasAuthorizedSeries(itr.auth, itr.name, []byte(key), val); err != nil {
+				return nil, err
+			} else if !ok {
+				continue
+			}
+		}
+		itr.kv = KeyValue{Key: key, Value: string(val)}
+		return &itr.kv, nil
+	}
+	return nil, nil
+}
+
+// Close closes the iterator.
+func (itr *keyValueIterator) Close() (err error) {
+	if itr.vitr != nil {
+		err = itr.vitr.Close()
+		itr.vitr = nil
+	}
+	if itr.release != nil {
+		itr.release()
+		itr.release = nil
+	}
+	itr.keys, itr.values = nil, nil
+	return err
+}
+
+// MeasurementTagValuesByPrefix returns the values of a tag key of the
+// measurement that start with prefix, in order. At most limit values are
+// returned if limit is positive. As the index yields the values in order, the
+// scan stops at the first value past the prefix instead of reading them all.
+func (is IndexSet) MeasurementTagValuesByPrefix(auth query.Authorizer, name, key, prefix []byte, limit int) ([]string, error) {
+	release := is.SeriesFile.Retain()
+	defer release()
+
+	itr, err := is.tagValueIterator(name, key)
+	if err != nil {
+		return nil, err
+	} else if itr == nil {
+		return nil, nil
+	}
+	defer itr.Close()
+
+	var a []string
+	for limit <= 0 || len(a) < limit {
+		v, err := itr.Next()
+		if err != nil {
+			return nil, err
+		} else if v == nil {
+			break
+		} else if !bytes.HasPrefix(v, prefix) {
+			if bytes.Compare(v, prefix) > 0 {
+				break
+			}
+			continue
+		}
+
+		if !query.AuthorizerIsOpen(auth) {
+			if ok, err := is.tagValueHasAuthorizedSeries(auth, name, key, v); err != nil {
+				return nil, err
+			} else if !ok {
+				continue
+			}
+		}
+		a = append(a, string(v))
+	}
+	return a, nil
+}
+
 // TagSets returns an ordered list of tag sets for a measurement by dimension
 // and filtered by an optional conditional expression.
 func (is IndexSet) TagSets(sfile *SeriesFile, name []byte, opt query.IteratorOptions) ([]*query.TagSet, error) {
@@ -2613,6 +2784,12 @@ func (is IndexSet) TagSets(sfile *SeriesFile, name []byte, opt query.IteratorOpt
 			continue
 		}
 
+		keyBuf = models.AppendMakeKey(keyBuf, name, tagsBuf)
+		if opt.SampleSeries > 0 && !opt.SeriesSampled(string(keyBuf)) {
+			keyBuf = keyBuf[:0]
+			continue
+		}
+
 		var tagsAsKey []byte
 		if len(dims) > 0 {
 			tagsAsKey = MakeTagsKey(dims, tagsBuf)
@@ -2628,7 +2805,6 @@ func (is IndexSet) TagSets(sfile *SeriesFile, name []byte, opt query.IteratorOpt
 		}
 
 		// Associate the series and filter with the Tagset.
-		keyBuf = models.AppendMakeKey(keyBuf, name, tagsBuf)
 		tagSet.AddFilter(string(keyBuf), se.Expr)
 		keyBuf = keyBuf[:0]
 
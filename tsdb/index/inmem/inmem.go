@@ -17,6 +17,7 @@ import (
 	"regexp"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"unsafe"
 
 	"github.com/freetsdb/freetsdb/models"
@@ -45,6 +46,11 @@ func init() {
 // series, and their tags. Exported functions are goroutine safe while
 // un-exported functions assume the caller will use the appropriate locks.
 type Index struct {
+	// epoch is incremented whenever series are added to or dropped from the
+	// shards of the database. It is accessed atomically and kept first for
+	// 64-bit alignment.
+	epoch uint64
+
 	mu sync.RWMutex
 
 	database string
@@ -215,6 +221,7 @@ func (i *Index) CreateSeriesListIfNotExists(seriesIDSet *tsdb.SeriesIDSet, measu
 		if !seriesIDSet.ContainsNoLock(ss.ID) {
 			seriesIDSet.AddNoLock(ss.ID)
 			measurements[ss.Measurement.Name]++
+			atomic.AddUint64(&i.epoch, 1)
 		}
 		seriesIDSet.Unlock()
 	}
@@ -251,6 +258,7 @@ func (i *Index) CreateSeriesListIfNotExists(seriesIDSet *tsdb.SeriesIDSet, measu
 		if !seriesIDSet.ContainsNoLock(ss.ID) {
 			seriesIDSet.AddNoLock(ss.ID)
 			measurements[ss.Measurement.Name]++
+			atomic.AddUint64(&i.epoch, 1)
 		}
 		seriesIDSet.Unlock()
 	}
@@ -287,6 +295,7 @@ func (i *Index) CreateSeriesListIfNotExists(seriesIDSet *tsdb.SeriesIDSet, measu
 		measurements[mms[j].Name]++
 		seriesIDSet.Unlock()
 	}
+	atomic.AddUint64(&i.epoch, 1)
 
 	return nil
 }
@@ -752,6 +761,7 @@ func (i *Index) dropMeasurement(name string) error {
 	if m == nil {
 		return nil
 	}
+	atomic.AddUint64(&i.epoch, 1)
 
 	delete(i.measurements, name)
 	for _, s := range m.SeriesByIDMap() {
@@ -779,6 +789,12 @@ func (i *Index) DropMeasurementIfSeriesNotExist(name []byte) (bool, error) {
 	return true, i.dropMeasurement(string(name))
 }
 
+// Epoch returns a number incremented whenever series are added to or dropped
+// from the shards of the database.
+func (i *Index) Epoch() uint64 {
+	return atomic.LoadUint64(&i.epoch)
+}
+
 // DropSeriesGlobal removes the series key and its tags from the index.
 func (i *Index) DropSeriesGlobal(key []byte) error {
 	if key == nil {
@@ -796,6 +812,7 @@ func (i *Index) DropSeriesGlobal(key []byte) error {
 
 	// Update the tombstone sketch.
 	i.seriesTSSketch.Add([]byte(k))
+	atomic.AddUint64(&i.epoch, 1)
 
 	// Remove from the index.
 	delete(i.series, k)
@@ -1080,6 +1097,7 @@ func (i *Index) assignExistingSeries(shardID uint64, seriesIDSet *tsdb.SeriesIDS
 				if !seriesIDSet.ContainsNoLock(ss.ID) {
 					seriesIDSet.AddNoLock(ss.ID)
 					measurements[string(names[j])]++
+					atomic.AddUint64(&i.epoch, 1)
 				}
 				seriesIDSet.Unlock()
 			}
@@ -1117,6 +1135,7 @@ func (idx *ShardIndex) DropSeries(seriesID uint64, key []byte, _ bool) error {
 	idx.seriesIDSet.Lock()
 	if idx.seriesIDSet.ContainsNoLock(seriesID) {
 		idx.seriesIDSet.RemoveNoLock(seriesID)
+		atomic.AddUint64(&idx.epoch, 1)
 
 		name := models.ParseName(key)
 		if curr := idx.measurements[string(name)]; curr <= 1 {
@@ -1154,6 +1173,7 @@ func (idx *ShardIndex) CreateSeriesListIfNotExists(keys, names [][]byte, tagsSli
 
 	var (
 		reason      string
+		cause       error
 		droppedKeys [][]byte
 	)
 
@@ -1177,8 +1197,9 @@ func (idx *ShardIndex) CreateSeriesListIfNotExists(keys, names [][]byte, tagsSli
 				}
 
 				if reason == "" {
-					reason = fmt.Sprintf("max-values-per-tag limit exceeded (%d/%d): measurement=%q tag=%q value=%q",
-						n, maxValuesPerTag, name, string(tag.Key), string(tag.Value))
+					reason = fmt.Sprintf("%s (%d/%d): measurement=%q tag=%q value=%q",
+						tsdb.ErrMaxValuesPerTagExceeded, n, maxValuesPerTag, name, string(tag.Key), string(tag.Value))
+					cause = tsdb.ErrMaxValuesPerTagExceeded
 				}
 
 				droppedKeys = append(droppedKeys, keys[i])
@@ -1197,7 +1218,7 @@ func (idx *ShardIndex) CreateSeriesListIfNotExists(keys, names [][]byte, tagsSli
 	}
 
 	if err := idx.Index.CreateSeriesListIfNotExists(idx.seriesIDSet, idx.measurements, keys, names, tagsSlice, &idx.opt, idx.opt.Config.MaxSeriesPerDatabase == 0); err != nil {
-		reason = err.Error()
+		reason, cause = err.Error(), err
 		droppedKeys = append(droppedKeys, keys...)
 	}
 
@@ -1208,6 +1229,7 @@ func (idx *ShardIndex) CreateSeriesListIfNotExists(keys, names [][]byte, tagsSli
 		return tsdb.PartialWriteError{
 			Reason:      reason,
 			Dropped:     dropped,
+			Err:         cause,
 			DroppedKeys: droppedKeys,
 		}
 	}
@@ -1335,5 +1357,8 @@ type errMaxSeriesPerDatabaseExceeded struct {
 }
 
 func (e errMaxSeriesPerDatabaseExceeded) Error() string {
-	return fmt.Sprintf("max-series-per-database limit exceeded: (%d)", e.limit)
+	return fmt.Sprintf("%s: (%d)", tsdb.ErrMaxSeriesPerDatabaseExceeded, e.limit)
 }
+
+// Unwrap returns tsdb.ErrMaxSeriesPerDatabaseExceeded.
+func (e errMaxSeriesPerDatabaseExceeded) Unwrap() error { return tsdb.ErrMaxSeriesPerDatabaseExceeded }
@@ -410,6 +410,8 @@ func (m *measurement) TagSets(shardSeriesIDs *tsdb.SeriesIDSet, opt query.Iterat
 
 		if opt.Authorizer != nil && !opt.Authorizer.AuthorizeSeriesRead(m.Database, m.NameBytes, s.Tags) {
 			continue
+		} else if !opt.SeriesSampled(s.Key) {
+			continue
 		}
 
 		var tagsAsKey []byte
@@ -123,6 +123,10 @@ var WithSeriesIDCacheSize = func(sz int) IndexOption {
 
 // Index represents a collection of layered index files and WAL.
 type Index struct {
+	// epoch is incremented whenever series are added or dropped. It is
+	// accessed atomically and kept first for 64-bit alignment.
+	epoch uint64
+
 	mu         sync.RWMutex
 	partitions []*Partition
 	opened     bool
@@ -617,6 +621,7 @@ func (i *Index) DropMeasurement(name []byte) error {
 			return err
 		}
 	}
+	atomic.AddUint64(&i.epoch, 1)
 
 	// Update sketches under lock.
 	i.mu.Lock()
@@ -678,6 +683,7 @@ func (i *Index) CreateSeriesListIfNotExists(keys [][]byte, names [][]byte, tagsS
 					errC <- err
 					continue
 				}
+				atomic.AddUint64(&i.epoch, 1)
 
 				// Some cached bitset results may need to be updated.
 				i.tagValueCache.RLock()
@@ -751,6 +757,7 @@ func (i *Index) CreateSeriesIfNotExists(key, name []byte, tags models.Tags) erro
 	if ids[0] == 0 {
 		return nil // No new series, nothing further to update.
 	}
+	atomic.AddUint64(&i.epoch, 1)
 
 	// If there are cached sets for any of the tag pairs, they will need to be
 	// updated with the series id.
@@ -787,6 +794,7 @@ func (i *Index) DropSeries(seriesID uint64, key []byte, cascade bool) error {
 	if err := i.partition(key).DropSeries(seriesID); err != nil {
 		return err
 	}
+	atomic.AddUint64(&i.epoch, 1)
 
 	// Add sketch tombstone.
 	i.mu.Lock()
@@ -824,6 +832,12 @@ func (i *Index) DropSeries(seriesID uint64, key []byte, cascade bool) error {
 	return nil
 }
 
+// Epoch returns a number incremented whenever series are added to or dropped
+// from the index.
+func (i *Index) Epoch() uint64 {
+	return atomic.LoadUint64(&i.epoch)
+}
+
 // DropSeriesGlobal is a no-op on the tsi1 index.
 func (i *Index) DropSeriesGlobal(key []byte) error { return nil }
 
@@ -1,6 +1,7 @@
 package tsi1_test
 
 import (
+	"bytes"
 	"compress/gzip"
 	"fmt"
 	"io/ioutil"
@@ -207,6 +208,66 @@ func TestIndex_DropMeasurement(t *testing.T) {
 	})
 }
 
+// Ensure a snapshot of the index can be validated and opened as an index.
+func TestIndex_SnapshotTo(t *testing.T) {
+	idx := MustOpenDefaultIndex()
+	defer idx.Close()
+
+	if err := idx.CreateSeriesSliceIfNotExists([]Series{
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"region": "east"})},
+		{Name: []byte("mem"), Tags: models.NewTags(map[string]string{"region": "west"})},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	path := MustTempDir()
+	defer os.RemoveAll(path)
+	if err := idx.SnapshotTo(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tsi1.ValidateSnapshot(path, idx.Path(), idx.SeriesFile.SeriesFile); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := tsi1.NewIndex(idx.SeriesFile.SeriesFile, "db0", tsi1.WithPath(path))
+	snapshot.PartitionN = idx.PartitionN
+	if err := snapshot.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer snapshot.Close()
+	for _, name := range []string{"cpu", "mem"} {
+		if ok, err := snapshot.MeasurementExists([]byte(name)); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			t.Fatalf("measurement %s missing from snapshot", name)
+		}
+	}
+
+	// The series of another series file have other ids.
+	sfile := MustOpenSeriesFile()
+	defer sfile.Close()
+	if err := tsi1.ValidateSnapshot(path, idx.Path(), sfile.SeriesFile); err != tsi1.ErrSnapshotSeriesMismatch {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Unless the series file is restored along with the snapshot.
+	var buf bytes.Buffer
+	if err := idx.SeriesFile.Backup(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewSeriesFile()
+	if err := tsdb.RestoreSeriesFile(restored.Path(), &buf); err != nil {
+		t.Fatal(err)
+	} else if err := restored.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Close()
+	if err := tsi1.ValidateSnapshot(path, idx.Path(), restored.SeriesFile); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestIndex_Open(t *testing.T) {
 	// Opening a fresh index should set the MANIFEST version to current version.
 	idx := NewDefaultIndex()
@@ -0,0 +1,194 @@
+package tsi1
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/freetsdb/freetsdb/tsdb"
+)
+
+// SeriesManifestFileName is the name of the file of an index snapshot
+// partition listing the ids and keys of its series. The series file of the
+// index the snapshot is restored into must have the same ids for the index
+// files of the snapshot to be valid.
+const SeriesManifestFileName = "SERIES"
+
+// ErrSnapshotSeriesMismatch is returned when the series of an index snapshot
+// do not have the same ids in the series file it is restored with.
+var ErrSnapshotSeriesMismatch = errors.New("index snapshot series do not match the series file")
+
+// SnapshotTo writes a snapshot of the index to path, in one directory per
+// partition. Index files are hard linked, so that unchanged files keep their
+// modification time across snapshots, while log files are copied as of the
+// time of the snapshot.
+func (i *Index) SnapshotTo(path string) error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	for j, p := range i.partitions {
+		if err := p.snapshotTo(filepath.Join(path, fmt.Sprint(j))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotTo writes a snapshot of the partition to path.
+func (p *Partition) snapshotTo(path string) error {
+	p.mu.RLock()
+	m := p.Manifest()
+	fs := p.retainFileSet()
+	ids := p.seriesIDSet.Clone()
+	sizes := make(map[string]int64)
+	for _, f := range fs.files {
+		if f, ok := f.(*LogFile); ok {
+			sizes[f.Path()] = f.Size()
+		}
+	}
+	p.mu.RUnlock()
+	defer fs.Release()
+
+	if err := os.MkdirAll(path, 0777); err != nil {
+		return err
+	}
+
+	for _, f := range fs.files {
+		dst := filepath.Join(path, filepath.Base(f.Path()))
+		if size, ok := sizes[f.Path()]; ok {
+			if err := copyLogFile(f.Path(), dst, size); err != nil {
+				return err
+			}
+		} else if err := os.Link(f.Path(), dst); err != nil {
+			return fmt.Errorf("error creating tsi hard link: %q", err)
+		}
+	}
+
+	m.path = filepath.Join(path, ManifestFileName)
+	if _, err := m.Write(); err != nil {
+		return err
+	}
+	return writeSeriesManifest(filepath.Join(path, SeriesManifestFileName), p.sfile, ids)
+}
+
+// copyLogFile copies the first size bytes of the log file at src to dst. A
+// log file is only appended to, so its first bytes are complete entries.
+func copyLogFile(src, dst string, size int64) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(out, in, size); err != nil && err != io.EOF {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// writeSeriesManifest writes the ids of ids and their series keys to path.
+func writeSeriesManifest(path string, sfile *tsdb.SeriesFile, ids *tsdb.SeriesIDSet) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+
+	var buf [8]byte
+	ids.ForEach(func(id uint64) {
+		key := sfile.SeriesKey(id)
+		if key == nil || err != nil {
+			return
+		}
+		binary.BigEndian.PutUint64(buf[:], id)
+		if _, err = w.Write(buf[:]); err == nil {
+			_, err = w.Write(key)
+		}
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// ValidateSnapshot checks that the index snapshot at path can be opened as
+// an index with sfile. The files of incremental snapshots that are listed in
+// the manifest of a partition but were unchanged since the previous snapshot
+// are linked from the index at current, if it has them.
+func ValidateSnapshot(path, current string, sfile *tsdb.SeriesFile) error {
+	fis, err := ioutil.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	for _, fi := range fis {
+		if !fi.IsDir() {
+			continue
+		}
+		ppath := filepath.Join(path, fi.Name())
+
+		m, _, err := ReadManifestFile(filepath.Join(ppath, ManifestFileName))
+		if err != nil {
+			return err
+		} else if err := m.Validate(); err != nil {
+			return err
+		}
+
+		for _, name := range m.Files {
+			dst := filepath.Join(ppath, name)
+			if _, err := os.Stat(dst); err == nil {
+				continue
+			} else if !os.IsNotExist(err) {
+				return err
+			}
+			if err := os.Link(filepath.Join(current, fi.Name(), name), dst); err != nil {
+				return fmt.Errorf("index snapshot file %s missing: %s", name, err)
+			}
+		}
+
+		if err := validateSeriesManifest(filepath.Join(ppath, SeriesManifestFileName), sfile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSeriesManifest returns ErrSnapshotSeriesMismatch if a series of the
+// manifest at path does not have its id in sfile.
+func validateSeriesManifest(path string, sfile *tsdb.SeriesFile) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return fmt.Errorf("invalid series manifest: %s", path)
+		}
+		id := binary.BigEndian.Uint64(data)
+		sz, n := binary.Uvarint(data[8:])
+		if n <= 0 || uint64(len(data)-8-n) < sz {
+			return fmt.Errorf("invalid series manifest: %s", path)
+		}
+
+		var key []byte
+		key, data = tsdb.ReadSeriesKey(data[8:])
+		if p := sfile.SeriesKeyPartition(key); p == nil || p.FindIDBySeriesKey(key) != id {
+			return ErrSnapshotSeriesMismatch
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package tsdb
+
+import (
+	"fmt"
+
+	"github.com/freetsdb/freetsdb/models"
+)
+
+// maxReportedKeyLength is the length at which a series key reported by an
+// error is cut.
+const maxReportedKeyLength = 256
+
+// ValidateSeriesKeyLength returns an error if the series key of a point, its
+// measurement and tags in line protocol, is longer than maxKeyLength bytes, or
+// if one of its tag values is longer than maxTagValueLength bytes.
+//
+// A maxKeyLength of 0 limits keys to models.MaxKeyLength, the longest key the
+// series file and the storage engine support. A maxTagValueLength of 0
+// disables the limit of tag values.
+func ValidateSeriesKeyLength(key, name []byte, tags models.Tags, maxKeyLength, maxTagValueLength int) error {
+	if maxKeyLength <= 0 || maxKeyLength > models.MaxKeyLength {
+		maxKeyLength = models.MaxKeyLength
+	}
+	if len(key) > maxKeyLength {
+		return KeyLengthError{Key: ReportedSeriesKey(key), Length: len(key), Limit: maxKeyLength}
+	}
+
+	if maxTagValueLength > 0 {
+		for _, tag := range tags {
+			if len(tag.Value) > maxTagValueLength {
+				return KeyLengthError{
+					Key:         ReportedSeriesKey(key),
+					Measurement: string(name),
+					Tag:         string(tag.Key),
+					Length:      len(tag.Value),
+					Limit:       maxTagValueLength,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// KeyLengthError is returned by ValidateSeriesKeyLength for a point whose
+// series key, or the value of its tag Tag, is longer than Limit bytes.
+type KeyLengthError struct {
+	Key         string // the series key, as reported by ReportedSeriesKey
+	Measurement string
+	Tag         string // empty if the series key is too long
+	Length      int
+	Limit       int
+}
+
+func (e KeyLengthError) Error() string {
+	if e.Tag == "" {
+		return fmt.Sprintf("max-series-key-length limit exceeded (%d/%d): key=%q", e.Length, e.Limit, e.Key)
+	}
+	return fmt.Sprintf("max-tag-value-length limit exceeded (%d/%d): measurement=%q tag=%q key=%q",
+		e.Length, e.Limit, e.Measurement, e.Tag, e.Key)
+}
+
+// ReportedSeriesKey returns a series key cut to a length fit for errors and
+// reports, with the length of the key if it was cut.
+func ReportedSeriesKey(key []byte) string {
+	if len(key) <= maxReportedKeyLength {
+		return string(key)
+	}
+	return fmt.Sprintf("%s...(%d bytes)", key[:maxReportedKeyLength], len(key))
+}
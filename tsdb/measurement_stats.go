@@ -0,0 +1,53 @@
+package tsdb
+
+// MeasurementStats holds the statistics of the points of a measurement
+// stored on disk. They are maintained as the cache is snapshotted and files
+// are compacted, so points still in the cache are not counted. Points
+// deleted are left out, and the points left in the files holding them are
+// estimated until the files are compacted.
+type MeasurementStats struct {
+	// MinTime and MaxTime are the timestamps of the first and last points.
+	MinTime, MaxTime int64
+
+	// PointN is the approximate number of points.
+	PointN int64
+
+	// DiskSize is the number of bytes of the blocks and index entries of the
+	// measurement.
+	DiskSize int64
+}
+
+// Add merges the statistics of o into s. A zero MeasurementStats holds no
+// points.
+func (s *MeasurementStats) Add(o MeasurementStats) {
+	if o.DiskSize == 0 {
+		return
+	} else if s.DiskSize == 0 {
+		*s = o
+		return
+	}
+
+	if o.MinTime < s.MinTime {
+		s.MinTime = o.MinTime
+	}
+	if o.MaxTime > s.MaxTime {
+		s.MaxTime = o.MaxTime
+	}
+	s.PointN += o.PointN
+	s.DiskSize += o.DiskSize
+}
+
+// ShardMeasurementStats holds the statistics of the measurements of a shard.
+type ShardMeasurementStats struct {
+	ID           uint64
+	Measurements map[string]MeasurementStats
+}
+
+// MergeMeasurementStats merges the statistics of src into dst.
+func MergeMeasurementStats(dst, src map[string]MeasurementStats) {
+	for name, s := range src {
+		stats := dst[name]
+		stats.Add(s)
+		dst[name] = stats
+	}
+}
@@ -0,0 +1,298 @@
+package tsdb
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/freetsdb/freetsdb/pkg/objstore"
+	"go.uber.org/zap"
+)
+
+// ObjectStore keeps the TSM and tombstone files of the shards in a bucket of
+// object storage, with the data directory as their local cache. A file is
+// uploaded before it becomes part of a shard and removed from the bucket when
+// the shard stops using it, so that a node opening a shard restores the files
+// missing locally from the bucket, even if it never owned the shard before.
+//
+// The WAL is not uploaded: the writes not yet snapshotted to TSM files stay
+// on the node that received them.
+type ObjectStore struct {
+	Bucket objstore.Bucket
+
+	// Prefix is prepended to the keys of the objects.
+	Prefix string
+
+	// Node names the files uploaded by this node. The replicas of a shard
+	// name their files the same, so each node keeps its own.
+	Node string
+
+	// path is the data directory of the store.
+	path string
+
+	// shards serializes the restores of each shard.
+	mu     sync.Mutex
+	shards map[uint64]*sync.Mutex
+
+	Logger *zap.Logger
+}
+
+// NewObjectStore returns an object store keeping the files of the shards of
+// the data directory path in bucket, under prefix.
+func NewObjectStore(bucket objstore.Bucket, prefix, path string) *ObjectStore {
+	return &ObjectStore{
+		Bucket: bucket,
+		Prefix: prefix,
+		path:   path,
+		shards: make(map[uint64]*sync.Mutex),
+		Logger: zap.NewNop(),
+	}
+}
+
+// shardPrefix returns the prefix of the keys of the files of a shard.
+func (o *ObjectStore) shardPrefix(database, rp string, id uint64) string {
+	return objstore.Join(o.Prefix, database, rp, strconv.FormatUint(id, 10)) + "/"
+}
+
+// key returns the key of the object of the shard file at path. It returns
+// false for the files that are not kept in the bucket.
+func (o *ObjectStore) key(path string) (string, bool) {
+	rel, err := filepath.Rel(o.path, path)
+	if err != nil {
+		return "", false
+	}
+	elem := strings.Split(filepath.ToSlash(rel), "/")
+	if len(elem) != 4 {
+		return "", false
+	}
+
+	// New files are written with a temporary extension, dropped once they
+	// are complete.
+	name := strings.TrimSuffix(elem[3], ".tmp")
+	if !isObjectFile(name) {
+		return "", false
+	}
+	id, err := strconv.ParseUint(elem[2], 10, 64)
+	if err != nil {
+		return "", false
+	}
+	return o.shardPrefix(elem[0], elem[1], id) + objstore.Join(o.Node, name), true
+}
+
+// isObjectFile returns true if the shard file name is kept in the bucket.
+func isObjectFile(name string) bool {
+	return strings.HasSuffix(name, ".tsm") || strings.HasSuffix(name, ".tombstone")
+}
+
+// FileFinishing uploads the file at path before it becomes part of a shard.
+// The file does not become part of the shard if it cannot be uploaded.
+func (o *ObjectStore) FileFinishing(path string) error {
+	key, ok := o.key(path)
+	if !ok {
+		return nil
+	}
+	if err := o.upload(path, key); err != nil {
+		return fmt.Errorf("upload %s: %s", path, err)
+	}
+	return nil
+}
+
+// FileUnlinking removes the file at path from the bucket before it is removed
+// from the shard.
+func (o *ObjectStore) FileUnlinking(path string) error {
+	key, ok := o.key(path)
+	if !ok {
+		return nil
+	}
+	if err := o.Bucket.Delete(key); err != nil {
+		return fmt.Errorf("delete object %s: %s", key, err)
+	}
+	return nil
+}
+
+// upload writes the file at path as the object key.
+func (o *ObjectStore) upload(path, key string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return o.Bucket.Put(key, f, fi.Size())
+}
+
+// RestoreShard makes the files of a shard in the bucket and in its directory
+// dir the same before the shard is opened. The files missing locally are
+// downloaded from the ones uploaded by this node, or, if there are none and
+// the shard has no files yet, by the node that uploaded files of the shard
+// last. The local files missing in the bucket, such as the ones written
+// before the object store was used, are then uploaded.
+func (o *ObjectStore) RestoreShard(database, rp string, id uint64, dir string) error {
+	o.mu.Lock()
+	mu := o.shards[id]
+	if mu == nil {
+		mu = &sync.Mutex{}
+		o.shards[id] = mu
+	}
+	o.mu.Unlock()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	prefix := o.shardPrefix(database, rp, id)
+	objects, err := o.Bucket.List(prefix)
+	if err != nil {
+		return fmt.Errorf("list objects of shard %d: %s", id, err)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	local := make(map[string]int64, len(fis))
+	for _, fi := range fis {
+		if !fi.IsDir() && isObjectFile(fi.Name()) {
+			local[fi.Name()] = fi.Size()
+		}
+	}
+
+	// The files of each node are kept apart.
+	nodes := make(map[string][]objstore.Object)
+	modified := make(map[string]time.Time)
+	for _, obj := range objects {
+		elem := strings.Split(strings.TrimPrefix(obj.Key, prefix), "/")
+		if len(elem) != 2 {
+			continue
+		}
+		nodes[elem[0]] = append(nodes[elem[0]], obj)
+		if obj.Modified.After(modified[elem[0]]) {
+			modified[elem[0]] = obj.Modified
+		}
+	}
+
+	// The files of another node are restored only into an empty shard, so
+	// that they never mix with the files of this node.
+	var source string
+	if len(nodes[o.Node]) > 0 {
+		source = o.Node
+	} else if len(local) == 0 {
+		for node := range nodes {
+			if source == "" || modified[node].After(modified[source]) {
+				source = node
+			}
+		}
+	}
+
+	uploaded := make(map[string]int64, len(nodes[o.Node]))
+	for _, obj := range nodes[o.Node] {
+		uploaded[path.Base(obj.Key)] = obj.Size
+	}
+
+	var restored int
+	for _, obj := range nodes[source] {
+		name := path.Base(obj.Key)
+		if size, ok := local[name]; ok && size == obj.Size {
+			continue
+		}
+		if err := o.download(obj.Key, filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("restore %s of shard %d: %s", name, id, err)
+		}
+		local[name] = obj.Size
+		restored++
+	}
+
+	var synced int
+	for name := range local {
+		if size, ok := uploaded[name]; ok && size == local[name] {
+			continue
+		}
+		if err := o.upload(filepath.Join(dir, name), prefix+objstore.Join(o.Node, name)); err != nil {
+			return fmt.Errorf("upload %s of shard %d: %s", name, id, err)
+		}
+		synced++
+	}
+
+	if restored > 0 || synced > 0 {
+		o.Logger.Info("Restored shard from object storage",
+			zap.Uint64("shard", id),
+			zap.String("source_node", source),
+			zap.Int("downloaded", restored),
+			zap.Int("uploaded", synced))
+	}
+	return nil
+}
+
+// download writes the object key to path. The object is written to a
+// temporary file first, removed when the shard is opened if the download
+// does not complete.
+func (o *ObjectStore) download(key, path string) error {
+	rc, err := o.Bucket.Get(key)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, rc); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// DeleteShard removes the files of a shard, uploaded by any node, from the
+// bucket.
+func (o *ObjectStore) DeleteShard(database, rp string, id uint64) error {
+	return o.deletePrefix(o.shardPrefix(database, rp, id))
+}
+
+// DeleteRetentionPolicy removes the files of the shards of a retention
+// policy from the bucket.
+func (o *ObjectStore) DeleteRetentionPolicy(database, rp string) error {
+	return o.deletePrefix(objstore.Join(o.Prefix, database, rp) + "/")
+}
+
+// DeleteDatabase removes the files of the shards of a database from the
+// bucket.
+func (o *ObjectStore) DeleteDatabase(database string) error {
+	return o.deletePrefix(objstore.Join(o.Prefix, database) + "/")
+}
+
+// deletePrefix removes the objects whose keys start with prefix.
+func (o *ObjectStore) deletePrefix(prefix string) error {
+	objects, err := o.Bucket.List(prefix)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if err := o.Bucket.Delete(obj.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package tsdb_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/pkg/objstore"
+	"github.com/freetsdb/freetsdb/tsdb"
+)
+
+// Ensure the files of a shard are uploaded, restored on another node, and
+// removed from the bucket.
+func TestObjectStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsdb-object-store-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bucket := objstore.NewDirBucket(filepath.Join(dir, "bucket"))
+	o1 := tsdb.NewObjectStore(bucket, "cluster0", filepath.Join(dir, "node1"))
+	o1.Node = "1"
+	o2 := tsdb.NewObjectStore(bucket, "cluster0", filepath.Join(dir, "node2"))
+	o2.Node = "2"
+
+	// A new TSM file is uploaded under its final name.
+	shardDir := filepath.Join(dir, "node1", "db0", "rp0", "1")
+	if err := os.MkdirAll(shardDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	tmpPath := filepath.Join(shardDir, "000000001-000000001.tsm.tmp")
+	if err := ioutil.WriteFile(tmpPath, []byte("tsm"), 0600); err != nil {
+		t.Fatal(err)
+	} else if err := o1.FileFinishing(tmpPath); err != nil {
+		t.Fatal(err)
+	} else if err := os.Rename(tmpPath, filepath.Join(shardDir, "000000001-000000001.tsm")); err != nil {
+		t.Fatal(err)
+	}
+	if objects, err := bucket.List("cluster0/"); err != nil {
+		t.Fatal(err)
+	} else if len(objects) != 1 || objects[0].Key != "cluster0/db0/rp0/1/1/000000001-000000001.tsm" {
+		t.Fatalf("unexpected objects: %+v", objects)
+	}
+
+	// Another node restores the files of the shard, and keeps its own copy.
+	restoreDir := filepath.Join(dir, "node2", "db0", "rp0", "1")
+	if err := o2.RestoreShard("db0", "rp0", 1, restoreDir); err != nil {
+		t.Fatal(err)
+	} else if buf, err := ioutil.ReadFile(filepath.Join(restoreDir, "000000001-000000001.tsm")); err != nil {
+		t.Fatal(err)
+	} else if string(buf) != "tsm" {
+		t.Fatalf("unexpected content: %q", buf)
+	}
+	if objects, err := bucket.List("cluster0/db0/rp0/1/2/"); err != nil {
+		t.Fatal(err)
+	} else if len(objects) != 1 {
+		t.Fatalf("unexpected objects: %+v", objects)
+	}
+
+	// Files removed from the shard are removed from the bucket.
+	if err := o1.FileUnlinking(filepath.Join(shardDir, "000000001-000000001.tsm")); err != nil {
+		t.Fatal(err)
+	} else if objects, err := bucket.List("cluster0/db0/rp0/1/1/"); err != nil {
+		t.Fatal(err)
+	} else if len(objects) != 0 {
+		t.Fatalf("unexpected objects: %+v", objects)
+	}
+
+	if err := o1.DeleteShard("db0", "rp0", 1); err != nil {
+		t.Fatal(err)
+	} else if objects, err := bucket.List("cluster0/"); err != nil {
+		t.Fatal(err)
+	} else if len(objects) != 0 {
+		t.Fatalf("unexpected objects: %+v", objects)
+	}
+}
@@ -0,0 +1,63 @@
+package tsdb
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+type readSnapshotsKey struct{}
+
+// ReadSnapshots holds the read snapshots of the shards read by a query. A
+// shard takes a snapshot of its cache and files the first time the query
+// reads it and reads from it until the query ends, so points moving from the
+// cache to a file or between files being compacted are seen exactly once.
+type ReadSnapshots struct {
+	mu        sync.Mutex
+	snapshots map[interface{}]io.Closer
+	released  bool
+}
+
+// NewReadSnapshotsContext returns a new context holding the read snapshots of
+// a query. The snapshots must be released with Release once the query ends.
+func NewReadSnapshotsContext(ctx context.Context) (context.Context, *ReadSnapshots) {
+	s := &ReadSnapshots{snapshots: make(map[interface{}]io.Closer)}
+	return context.WithValue(ctx, readSnapshotsKey{}, s), s
+}
+
+// ReadSnapshotsFromContext returns the read snapshots of ctx, or nil if the
+// query does not read from snapshots.
+func ReadSnapshotsFromContext(ctx context.Context) *ReadSnapshots {
+	s, _ := ctx.Value(readSnapshotsKey{}).(*ReadSnapshots)
+	return s
+}
+
+// Get returns the snapshot of key, taking it with fn on the first call. It
+// returns nil once the snapshots have been released.
+func (s *ReadSnapshots) Get(key interface{}, fn func() io.Closer) io.Closer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.released {
+		return nil
+	} else if snapshot, ok := s.snapshots[key]; ok {
+		return snapshot
+	}
+
+	snapshot := fn()
+	s.snapshots[key] = snapshot
+	return snapshot
+}
+
+// Release releases every snapshot. Cursors created from a snapshot keep
+// reading from it until they are closed.
+func (s *ReadSnapshots) Release() {
+	s.mu.Lock()
+	snapshots := s.snapshots
+	s.snapshots, s.released = nil, true
+	s.mu.Unlock()
+
+	for _, snapshot := range snapshots {
+		snapshot.Close()
+	}
+}
@@ -0,0 +1,55 @@
+package tsdb_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/freetsdb/freetsdb/tsdb"
+)
+
+func TestReadSnapshots(t *testing.T) {
+	if tsdb.ReadSnapshotsFromContext(context.Background()) != nil {
+		t.Fatal("expected no read snapshots")
+	}
+
+	ctx, snapshots := tsdb.NewReadSnapshotsContext(context.Background())
+	if tsdb.ReadSnapshotsFromContext(ctx) != snapshots {
+		t.Fatal("expected the read snapshots of the context")
+	}
+
+	var taken, closed int
+	take := func() io.Closer {
+		taken++
+		return &readSnapshot{closed: &closed}
+	}
+
+	// A snapshot is taken once per key.
+	a := snapshots.Get("a", take)
+	if snapshots.Get("a", take) != a {
+		t.Fatal("expected the same snapshot")
+	}
+	snapshots.Get("b", take)
+	if taken != 2 {
+		t.Fatalf("unexpected snapshots taken: %d", taken)
+	}
+
+	snapshots.Release()
+	if closed != 2 {
+		t.Fatalf("unexpected snapshots closed: %d", closed)
+	} else if snapshots.Get("c", take) != nil {
+		t.Fatal("expected no snapshot once released")
+	} else if taken != 2 {
+		t.Fatalf("unexpected snapshots taken: %d", taken)
+	}
+}
+
+// readSnapshot counts the snapshots closed.
+type readSnapshot struct {
+	closed *int
+}
+
+func (s *readSnapshot) Close() error {
+	*s.closed++
+	return nil
+}
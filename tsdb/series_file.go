@@ -1,14 +1,20 @@
 package tsdb
 
 import (
+	"archive/tar"
 	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/cespare/xxhash"
 	"github.com/freetsdb/freetsdb/models"
@@ -111,6 +117,86 @@ func (f *SeriesFile) Retain() func() {
 	return nop
 }
 
+// Backup writes a tar archive of the segments of the series file to w, with
+// the series created so far. The series index is not included, as it is
+// recovered from the segments when the series file is opened.
+func (f *SeriesFile) Backup(w io.Writer) error {
+	release := f.Retain()
+	defer release()
+
+	tw := tar.NewWriter(w)
+	for _, p := range f.partitions {
+		segments, sizes := p.segmentSizes()
+		for i, segment := range segments {
+			if err := tw.WriteHeader(&tar.Header{
+				Name:    path.Join(fmt.Sprintf("%02x", p.ID()), fmt.Sprintf("%04x", segment.ID())),
+				Mode:    0666,
+				Size:    int64(sizes[i]),
+				ModTime: time.Now(),
+			}); err != nil {
+				return err
+			} else if _, err := tw.Write(segment.Data()[:sizes[i]]); err != nil {
+				return err
+			}
+		}
+	}
+	return tw.Close()
+}
+
+// RestoreSeriesFile writes the segments of the tar archive r, written by
+// SeriesFile.Backup, to a series file at dir.
+func RestoreSeriesFile(dir string, r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		// Only the segments of the partitions are restored.
+		partition, name := path.Split(hdr.Name)
+		partitionID, err := strconv.ParseUint(strings.TrimSuffix(partition, "/"), 16, 8)
+		if err != nil || len(partition) != 3 || partitionID >= SeriesFilePartitionN || !IsValidSeriesSegmentFilename(name) {
+			return fmt.Errorf("invalid series file entry: %q", hdr.Name)
+		}
+		segmentID, err := ParseSeriesSegmentFilename(name)
+		if err != nil {
+			return err
+		} else if hdr.Size > int64(SeriesSegmentSize(segmentID)) {
+			return fmt.Errorf("series segment too large: %q", hdr.Name)
+		}
+
+		if err := restoreSeriesSegment(filepath.Join(dir, fmt.Sprintf("%02x", partitionID), name), segmentID, tr, hdr.Size); err != nil {
+			return err
+		}
+	}
+}
+
+// restoreSeriesSegment writes the first n bytes of a segment read from r to
+// path, with the size of the segment.
+func restoreSeriesSegment(path string, id uint16, r io.Reader, n int64) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.CopyN(f, r, n); err != nil {
+		return err
+	} else if err := f.Truncate(int64(SeriesSegmentSize(id))); err != nil {
+		return err
+	} else if err := f.Sync(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
 // EnableCompactions allows compactions to run.
 func (f *SeriesFile) EnableCompactions() {
 	for _, p := range f.partitions {
@@ -1,6 +1,7 @@
 package tsdb_test
 
 import (
+	"archive/tar"
 	"bytes"
 	"fmt"
 	"io/ioutil"
@@ -167,6 +168,84 @@ type Series struct {
 	Deleted bool
 }
 
+// Ensure a backup of the series file restores its series with their ids.
+func TestSeriesFile_Backup(t *testing.T) {
+	sfile := MustOpenSeriesFile()
+	defer sfile.Close()
+
+	var names [][]byte
+	var tags []models.Tags
+	for i := 0; i < 100; i++ {
+		names = append(names, []byte(fmt.Sprintf("m%d", i%10)))
+		tags = append(tags, models.NewTags(map[string]string{"host": fmt.Sprintf("h%d", i)}))
+	}
+	ids, err := sfile.CreateSeriesListIfNotExists(names, tags)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := sfile.DeleteSeriesID(ids[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := sfile.Backup(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewSeriesFile()
+	if err := tsdb.RestoreSeriesFile(restored.Path(), &buf); err != nil {
+		t.Fatal(err)
+	} else if err := restored.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Close()
+
+	for i, id := range ids[1:] {
+		if got := restored.SeriesID(names[i+1], tags[i+1], nil); got != id {
+			t.Fatalf("unexpected id of series %d: got %d, exp %d", i+1, got, id)
+		}
+	}
+	if !restored.IsDeleted(ids[0]) {
+		t.Fatal("expected the deleted series to stay deleted")
+	}
+
+	// The series created after the restore have new ids.
+	newIDs, err := restored.CreateSeriesListIfNotExists([][]byte{[]byte("cpu")}, []models.Tags{nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		if newIDs[0] == id {
+			t.Fatalf("id %d reused", id)
+		}
+	}
+}
+
+// Ensure a series file is not restored from an archive of other files.
+func TestRestoreSeriesFile_Invalid(t *testing.T) {
+	for _, name := range []string{"../0000", "00/../../0000", "08/0000", "00/index", "0/0000"} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0666, Size: 1}); err != nil {
+				t.Fatal(err)
+			} else if _, err := tw.Write([]byte{0}); err != nil {
+				t.Fatal(err)
+			} else if err := tw.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			dir, err := ioutil.TempDir("", "tsdb-series-file-")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			if err := tsdb.RestoreSeriesFile(dir, &buf); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
 // SeriesFile is a test wrapper for tsdb.SeriesFile.
 type SeriesFile struct {
 	*tsdb.SeriesFile
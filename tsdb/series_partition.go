@@ -410,6 +410,20 @@ func (p *SeriesPartition) AppendSeriesIDs(a []uint64) []uint64 {
 	return a
 }
 
+// segmentSizes returns the segments of the partition and the size of their
+// data, which is not changed by the writes after it is returned.
+func (p *SeriesPartition) segmentSizes() ([]*SeriesSegment, []uint32) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	segments := make([]*SeriesSegment, len(p.segments))
+	sizes := make([]uint32, len(p.segments))
+	for i, segment := range p.segments {
+		segments[i], sizes[i] = segment, segment.dataSize()
+	}
+	return segments, sizes
+}
+
 // activeSegment returns the last segment.
 func (p *SeriesPartition) activeSegment() *SeriesSegment {
 	if len(p.segments) == 0 {
@@ -116,13 +116,7 @@ func (s *SeriesSegment) Open() error {
 // This is only used for the last segment in the series file.
 func (s *SeriesSegment) InitForWrite() (err error) {
 	// Only calculcate segment data size if writing.
-	for s.size = uint32(SeriesSegmentHeaderSize); s.size < uint32(len(s.data)); {
-		flag, _, _, sz := ReadSeriesEntry(s.data[s.size:])
-		if !IsValidSeriesEntryFlag(flag) {
-			break
-		}
-		s.size += uint32(sz)
-	}
+	s.size = s.dataSize()
 
 	// Open file handler for writing & seek to end of data.
 	if s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE, 0666); err != nil {
@@ -135,6 +129,19 @@ func (s *SeriesSegment) InitForWrite() (err error) {
 	return nil
 }
 
+// dataSize returns the size of the header and the entries of the segment.
+func (s *SeriesSegment) dataSize() uint32 {
+	size := uint32(SeriesSegmentHeaderSize)
+	for size < uint32(len(s.data)) {
+		flag, _, _, sz := ReadSeriesEntry(s.data[size:])
+		if !IsValidSeriesEntryFlag(flag) {
+			break
+		}
+		size += uint32(sz)
+	}
+	return size
+}
+
 // Close unmaps the segment.
 func (s *SeriesSegment) Close() (err error) {
 	if e := s.CloseForWrite(); e != nil && err == nil {
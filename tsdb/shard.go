@@ -51,6 +51,14 @@ var (
 	// ErrFieldTypeConflict is returned when a new field already exists with a different type.
 	ErrFieldTypeConflict = errors.New("field type conflict")
 
+	// ErrMaxSeriesPerDatabaseExceeded is the cause of the points dropped
+	// because their series would exceed the max-series-per-database limit.
+	ErrMaxSeriesPerDatabaseExceeded = errors.New("max-series-per-database limit exceeded")
+
+	// ErrMaxValuesPerTagExceeded is the cause of the points dropped because
+	// their tag values would exceed the max-values-per-tag limit.
+	ErrMaxValuesPerTagExceeded = errors.New("max-values-per-tag limit exceeded")
+
 	// ErrFieldNotFound is returned when a field cannot be found.
 	ErrFieldNotFound = errors.New("field not found")
 
@@ -112,14 +120,43 @@ type PartialWriteError struct {
 	Reason  string
 	Dropped int
 
+	// The error the points of Reason were dropped with, when it is known.
+	Err error
+
 	// A sorted slice of series keys that were dropped.
 	DroppedKeys [][]byte
+
+	// The dropped points, when they are known.
+	Points []DroppedPoint
 }
 
 func (e PartialWriteError) Error() string {
 	return fmt.Sprintf("partial write: %s dropped=%d", e.Reason, e.Dropped)
 }
 
+// Unwrap returns the error the points were dropped with, so the cause of a
+// partial write can be checked with errors.Is.
+func (e PartialWriteError) Unwrap() error { return e.Err }
+
+// DroppedPoint is a point dropped by a partial write and the reason it was
+// dropped, with the error it was dropped with when it is known.
+type DroppedPoint struct {
+	Point  models.Point
+	Reason string
+	Err    error
+}
+
+// MergePartialWriteErrors returns the partial write of a and b. The reason of
+// a is kept.
+func MergePartialWriteErrors(a, b PartialWriteError) PartialWriteError {
+	if a.Reason == "" {
+		a.Reason, a.Err = b.Reason, b.Err
+	}
+	a.Dropped += b.Dropped
+	a.Points = append(a.Points[:len(a.Points):len(a.Points)], b.Points...)
+	return a
+}
+
 // Shard represents a self-contained time series database. An inverted index of
 // the measurement and tag data is kept along with the raw time series data.
 // Data can be split across many shards. The query engine in TSDB is responsible
@@ -306,57 +343,70 @@ func (s *Shard) Open() error {
 		if s._engine != nil {
 			return nil
 		}
+		return s.open()
+	}(); err != nil {
+		s.close()
+		return NewShardError(s.id, err)
+	}
 
-		seriesIDSet := NewSeriesIDSet()
+	if s.EnableOnOpen {
+		// enable writes, queries and compactions
+		s.SetEnabled(true)
+	}
 
-		// Initialize underlying index.
-		ipath := filepath.Join(s.path, "index")
-		idx, err := NewIndex(s.id, s.database, ipath, seriesIDSet, s.sfile, s.options)
-		if err != nil {
-			return err
-		}
+	return nil
+}
 
-		idx.WithLogger(s.baseLogger)
+// open opens the index and engine of the shard. s.mu must be held.
+func (s *Shard) open() error {
+	// Record the engine of a new shard before the index creates the
+	// shard directory.
+	if err := createEngineFile(s.path, s.options.EngineVersion); err != nil {
+		return err
+	}
 
-		// Open index.
-		if err := idx.Open(); err != nil {
-			return err
-		}
-		s.index = idx
+	seriesIDSet := NewSeriesIDSet()
 
-		// Initialize underlying engine.
-		e, err := NewEngine(s.id, idx, s.path, s.walPath, s.sfile, s.options)
-		if err != nil {
-			return err
-		}
+	// Initialize underlying index.
+	ipath := filepath.Join(s.path, "index")
+	if err := installRestoredIndex(s.path, ipath); err != nil {
+		return err
+	}
+	idx, err := NewIndex(s.id, s.database, ipath, seriesIDSet, s.sfile, s.options)
+	if err != nil {
+		return err
+	}
 
-		// Set log output on the engine.
-		e.WithLogger(s.baseLogger)
+	idx.WithLogger(s.baseLogger)
 
-		// Disable compactions while loading the index
-		e.SetEnabled(false)
+	// Open index.
+	if err := idx.Open(); err != nil {
+		return err
+	}
+	s.index = idx
 
-		// Open engine.
-		if err := e.Open(); err != nil {
-			return err
-		}
+	// Initialize underlying engine.
+	e, err := NewEngine(s.id, idx, s.path, s.walPath, s.sfile, s.options)
+	if err != nil {
+		return err
+	}
 
-		// Load metadata index for the inmem index only.
-		if err := e.LoadMetadataIndex(s.id, s.index); err != nil {
-			return err
-		}
-		s._engine = e
+	// Set log output on the engine.
+	e.WithLogger(s.baseLogger)
 
-		return nil
-	}(); err != nil {
-		s.close()
-		return NewShardError(s.id, err)
+	// Disable compactions while loading the index
+	e.SetEnabled(false)
+
+	// Open engine.
+	if err := e.Open(); err != nil {
+		return err
 	}
 
-	if s.EnableOnOpen {
-		// enable writes, queries and compactions
-		s.SetEnabled(true)
+	// Load metadata index for the inmem index only.
+	if err := e.LoadMetadataIndex(s.id, s.index); err != nil {
+		return err
 	}
+	s._engine = e
 
 	return nil
 }
@@ -386,6 +436,100 @@ func (s *Shard) close() error {
 	return err
 }
 
+// Reindex rebuilds the TSI index of the shard from the series of its data,
+// for instance to recover from a corrupt index. The new index is built while
+// the shard keeps serving writes and queries, which are only blocked while
+// the series written in the meantime are added and the new index is swapped
+// in.
+func (s *Shard) Reindex() error {
+	engine, err := s.Engine()
+	if err != nil {
+		return err
+	} else if typ := s.IndexType(); typ != TSI1IndexName {
+		return fmt.Errorf("cannot reindex shard %d: %s index is not supported", s.id, typ)
+	}
+
+	// Remove a partial index left by a previous run.
+	ipath, tmpPath := filepath.Join(s.path, "index"), filepath.Join(s.path, ".index.reindex")
+	if err := os.RemoveAll(tmpPath); err != nil {
+		return err
+	}
+
+	opt := s.options
+	opt.IndexVersion = TSI1IndexName
+	idx, err := NewIndex(s.id, s.database, tmpPath, NewSeriesIDSet(), s.sfile, opt)
+	if err != nil {
+		return err
+	}
+	idx.WithLogger(s.baseLogger)
+	defer os.RemoveAll(tmpPath)
+	if err := idx.Open(); err != nil {
+		return err
+	}
+	defer func() {
+		if idx != nil {
+			idx.Close()
+		}
+	}()
+
+	s.logger.Info("Rebuilding index", zap.String("path", tmpPath))
+	seen := make(map[string]struct{})
+	if err := engine.IndexSeries(idx, seen); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s._engine != engine {
+		return fmt.Errorf("cannot reindex shard %d: shard was closed", s.id)
+	}
+
+	// Writes are blocked: add the series written while the index was built.
+	if err := engine.IndexSeries(idx, seen); err != nil {
+		return err
+	}
+	if c, ok := idx.(interface {
+		Compact()
+		Wait()
+	}); ok {
+		c.Compact()
+		c.Wait()
+	}
+	err = idx.Close()
+	idx = nil
+	if err != nil {
+		return err
+	}
+
+	// Swap in the new index and reopen the shard with it. The old index is
+	// restored if it cannot be replaced.
+	if err := s.close(); err != nil {
+		return err
+	}
+	oldPath := filepath.Join(s.path, ".index.old")
+	err = os.RemoveAll(oldPath)
+	if err == nil {
+		err = os.Rename(ipath, oldPath)
+	}
+	if err == nil {
+		if err = os.Rename(tmpPath, ipath); err != nil {
+			os.Rename(oldPath, ipath)
+		}
+	}
+	if e := s.open(); e != nil {
+		s.close()
+		return NewShardError(s.id, e)
+	}
+	if !s.CompactionDisabled {
+		s._engine.SetEnabled(s.enabled)
+	}
+	if err != nil {
+		return err
+	}
+	s.logger.Info("Rebuilt index", zap.String("path", ipath))
+	return os.RemoveAll(oldPath)
+}
+
 // IndexType returns the index version being used for this shard.
 //
 // IndexType returns the empty string if it is called before the shard is opened,
@@ -431,6 +575,16 @@ func (s *Shard) Index() (Index, error) {
 	return s.index, nil
 }
 
+// IndexEpoch returns a number that changes whenever series or fields are
+// added to or dropped from the shard.
+func (s *Shard) IndexEpoch() uint64 {
+	index, err := s.Index()
+	if err != nil {
+		return 0
+	}
+	return index.Epoch() + uint64(atomic.LoadInt64(&s.stats.FieldsCreated))
+}
+
 // SeriesFile returns a reference the underlying series file. If return an error
 // if the series file is nil.
 func (s *Shard) SeriesFile() (*SeriesFile, error) {
@@ -486,6 +640,58 @@ func (s *Shard) DiskSize() (int64, error) {
 	return size, nil
 }
 
+// DiskUsage returns the disk usage of the shard, with the estimated disk usage
+// of each of its measurements. Like DiskSize, it is reported for disabled
+// shards.
+func (s *Shard) DiskUsage() (ShardDiskUsage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s._engine == nil {
+		return ShardDiskUsage{}, ErrEngineClosed
+	}
+
+	stats, err := s._engine.MeasurementStats()
+	if err != nil {
+		return ShardDiskUsage{}, err
+	}
+	u := ShardDiskUsage{
+		ID:              s.id,
+		Database:        s.database,
+		RetentionPolicy: s.retentionPolicy,
+		DiskUsage:       s._engine.DiskUsage(),
+		Measurements:    make(map[string]int64, len(stats)),
+	}
+	for name, st := range stats {
+		u.Measurements[name] = st.DiskSize
+	}
+	return u, nil
+}
+
+// ShardFile describes a data file of a shard.
+type ShardFile struct {
+	Path string
+	Size int64
+
+	// MinTime and MaxTime are the timestamps of the first and last points
+	// in the file.
+	MinTime, MaxTime int64
+
+	// Generation and Sequence are parsed from the file name. The sequence
+	// grows each time the points of the file are compacted further.
+	Generation, Sequence int
+
+	HasTombstone bool
+}
+
+// Files returns the data files of the shard.
+func (s *Shard) Files() ([]ShardFile, error) {
+	engine, err := s.Engine()
+	if err != nil {
+		return nil, err
+	}
+	return engine.Files(), nil
+}
+
 // FieldCreate holds information for a field to create on a measurement.
 type FieldCreate struct {
 	Measurement []byte
@@ -494,6 +700,12 @@ type FieldCreate struct {
 
 // WritePoints will write the raw data points and any new metadata to the index in the shard.
 func (s *Shard) WritePoints(points []models.Point) error {
+	return s.WritePointsWithContext(context.Background(), points)
+}
+
+// WritePointsWithContext writes points like WritePoints, recording its stages
+// in the write trace of ctx.
+func (s *Shard) WritePointsWithContext(ctx context.Context, points []models.Point) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -505,13 +717,41 @@ func (s *Shard) WritePoints(points []models.Point) error {
 	var writeError error
 	atomic.AddInt64(&s.stats.WriteReq, 1)
 
+	// originals maps the copies of the points with an ingest time to the
+	// points that were written.
+	var originals map[models.Point]models.Point
+	if s.options.Config.RecordIngestTime {
+		var dropped []DroppedPoint
+		points, originals, dropped, err = addIngestTime(points, time.Now().UnixNano())
+		if err != nil {
+			return err
+		}
+		if len(dropped) > 0 {
+			atomic.AddInt64(&s.stats.WritePointsDropped, int64(len(dropped)))
+			writeError = PartialWriteError{Reason: dropped[0].Reason, Dropped: len(dropped), Points: dropped}
+		}
+	}
+
+	trace := WriteTraceFromContext(ctx)
+	start := time.Now()
 	points, fieldsToCreate, err := s.validateSeriesAndFields(points)
 	if err != nil {
-		if _, ok := err.(PartialWriteError); !ok {
+		perr, ok := err.(PartialWriteError)
+		if !ok {
 			return err
 		}
+		// Report the points given to the shard rather than their copies.
+		if originals != nil {
+			for i := range perr.Points {
+				perr.Points[i].Point = originals[perr.Points[i].Point]
+			}
+			err = perr
+		}
 		// There was a partial write (points dropped), hold onto the error to return
 		// to the caller, but continue on writing the remaining points.
+		if ierr, ok := writeError.(PartialWriteError); ok {
+			err = MergePartialWriteErrors(ierr, perr)
+		}
 		writeError = err
 	}
 	atomic.AddInt64(&s.stats.FieldsCreated, int64(len(fieldsToCreate)))
@@ -520,9 +760,10 @@ func (s *Shard) WritePoints(points []models.Point) error {
 	if err := s.createFieldsAndMeasurements(fieldsToCreate); err != nil {
 		return err
 	}
+	trace.Record("index", s.id, start)
 
 	// Write to the engine.
-	if err := engine.WritePoints(points); err != nil {
+	if err := engine.WritePointsWithContext(ctx, points); err != nil {
 		atomic.AddInt64(&s.stats.WritePointsErr, int64(len(points)))
 		atomic.AddInt64(&s.stats.WriteReqErr, 1)
 		return fmt.Errorf("engine: %s", err)
@@ -533,6 +774,36 @@ func (s *Shard) WritePoints(points []models.Point) error {
 	return writeError
 }
 
+// addIngestTime returns copies of points with an _ingest_time field set to
+// now, mapped to the points they were copied from. Points that set the field
+// themselves are dropped rather than having their value replaced.
+func addIngestTime(points []models.Point, now int64) ([]models.Point, map[models.Point]models.Point, []DroppedPoint, error) {
+	a := make([]models.Point, 0, len(points))
+	originals := make(map[models.Point]models.Point, len(points))
+	var dropped []DroppedPoint
+	for _, p := range points {
+		fields, err := p.Fields()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if _, ok := fields["_ingest_time"]; ok {
+			dropped = append(dropped, DroppedPoint{Point: p, Reason: fmt.Sprintf(
+				"invalid field name: input field \"%s\" on measurement \"%s\" is reserved for the ingest time",
+				"_ingest_time", string(p.Name()))})
+			continue
+		}
+		fields["_ingest_time"] = now
+
+		pt, err := models.NewPoint(string(p.Name()), p.Tags(), fields, p.Time())
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		originals[pt] = p
+		a = append(a, pt)
+	}
+	return a, originals, dropped, nil
+}
+
 // validateSeriesAndFields checks which series and fields are new and whose metadata should be saved and indexed.
 func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point, []*FieldCreate, error) {
 	var (
@@ -540,6 +811,8 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 		err            error
 		dropped        int
 		reason         string // only first error reason is set unless returned from CreateSeriesListIfNotExists
+		cause          error  // the error of reason, when it is known
+		droppedPoints  []DroppedPoint
 	)
 
 	// Create all series against the index in bulk.
@@ -549,6 +822,7 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 
 	// Check if keys should be unicode validated.
 	validateKeys := s.options.Config.ValidateKeys
+	maxKeyLength, maxTagValueLength := s.options.Config.MaxSeriesKeyLength, s.options.Config.MaxTagValueLength
 
 	var j int
 	for i, p := range points {
@@ -557,20 +831,35 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 		// Drop any series w/ a "time" tag, these are illegal
 		if v := tags.Get(timeBytes); v != nil {
 			dropped++
+			r := fmt.Sprintf(
+				"invalid tag key: input tag \"%s\" on measurement \"%s\" is invalid",
+				"time", string(p.Name()))
 			if reason == "" {
-				reason = fmt.Sprintf(
-					"invalid tag key: input tag \"%s\" on measurement \"%s\" is invalid",
-					"time", string(p.Name()))
+				reason = r
 			}
+			droppedPoints = append(droppedPoints, DroppedPoint{Point: p, Reason: r})
 			continue
 		}
 
 		// Drop any series with invalid unicode characters in the key.
 		if validateKeys && !models.ValidKeyTokens(string(p.Name()), tags) {
 			dropped++
+			r := fmt.Sprintf("key contains invalid unicode: \"%s\"", string(p.Key()))
+			if reason == "" {
+				reason = r
+			}
+			droppedPoints = append(droppedPoints, DroppedPoint{Point: p, Reason: r})
+			continue
+		}
+
+		// Drop any series whose key or tag values are too long to be stored.
+		if err := ValidateSeriesKeyLength(p.Key(), p.Name(), tags, maxKeyLength, maxTagValueLength); err != nil {
+			dropped++
+			r := err.Error()
 			if reason == "" {
-				reason = fmt.Sprintf("key contains invalid unicode: \"%s\"", string(p.Key()))
+				reason, cause = r, err
 			}
+			droppedPoints = append(droppedPoints, DroppedPoint{Point: p, Reason: r, Err: err})
 			continue
 		}
 
@@ -589,15 +878,18 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 
 	// Add new series. Check for partial writes.
 	var droppedKeys [][]byte
+	var droppedKeysReason string
+	var droppedKeysErr error
 	if err := engine.CreateSeriesListIfNotExists(keys, names, tagsSlice); err != nil {
 		switch err := err.(type) {
 		// TODO(jmw): why is this a *PartialWriteError when everything else is not a pointer?
 		// Maybe we can just change it to be consistent if we change it also in all
 		// the places that construct it.
 		case *PartialWriteError:
-			reason = err.Reason
+			reason, cause = err.Reason, err.Err
 			dropped += err.Dropped
 			droppedKeys = err.DroppedKeys
+			droppedKeysReason, droppedKeysErr = err.Reason, err.Err
 			atomic.AddInt64(&s.stats.WritePointsDropped, int64(err.Dropped))
 		default:
 			return nil, nil, err
@@ -617,17 +909,20 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 			break
 		}
 		if !validField {
+			r := fmt.Sprintf(
+				"invalid field name: input field \"%s\" on measurement \"%s\" is invalid",
+				"time", string(p.Name()))
 			if reason == "" {
-				reason = fmt.Sprintf(
-					"invalid field name: input field \"%s\" on measurement \"%s\" is invalid",
-					"time", string(p.Name()))
+				reason = r
 			}
 			dropped++
+			droppedPoints = append(droppedPoints, DroppedPoint{Point: p, Reason: r})
 			continue
 		}
 
 		// Skip any points whos keys have been dropped. Dropped has already been incremented for them.
 		if len(droppedKeys) > 0 && bytesutil.Contains(droppedKeys, keys[i]) {
+			droppedPoints = append(droppedPoints, DroppedPoint{Point: p, Reason: droppedKeysReason, Err: droppedKeysErr})
 			continue
 		}
 
@@ -639,9 +934,10 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 			switch err := err.(type) {
 			case PartialWriteError:
 				if reason == "" {
-					reason = err.Reason
+					reason, cause = err.Reason, err.Err
 				}
 				dropped += err.Dropped
+				droppedPoints = append(droppedPoints, DroppedPoint{Point: p, Reason: err.Reason, Err: err.Err})
 				atomic.AddInt64(&s.stats.WritePointsDropped, int64(err.Dropped))
 			default:
 				return nil, nil, err
@@ -682,7 +978,7 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 	}
 
 	if dropped > 0 {
-		err = PartialWriteError{Reason: reason, Dropped: dropped}
+		err = PartialWriteError{Reason: reason, Dropped: dropped, Err: cause, Points: droppedPoints}
 	}
 
 	return points[:j], fieldsToCreate, err
@@ -770,6 +1066,16 @@ func (s *Shard) MeasurementsSketches() (estimator.Sketch, estimator.Sketch, erro
 	return engine.MeasurementsSketches()
 }
 
+// MeasurementStats returns the statistics of the measurements stored on disk
+// in the shard.
+func (s *Shard) MeasurementStats() (map[string]MeasurementStats, error) {
+	engine, err := s.Engine()
+	if err != nil {
+		return nil, err
+	}
+	return engine.MeasurementStats()
+}
+
 // MeasurementNamesByRegex returns names of measurements matching the regular expression.
 func (s *Shard) MeasurementNamesByRegex(re *regexp.Regexp) ([][]byte, error) {
 	engine, err := s.Engine()
@@ -927,6 +1233,10 @@ func (s *Shard) FieldDimensions(measurements []string) (fields map[string]influx
 		mf := engine.MeasurementFields([]byte(name))
 		if mf != nil {
 			for k, typ := range mf.FieldSet() {
+				// The ingest time is only returned when it is selected.
+				if k == "_ingest_time" {
+					continue
+				}
 				if fields[k].LessThan(typ) {
 					fields[k] = typ
 				}
@@ -955,6 +1265,8 @@ func (s *Shard) mapType(measurement, field string) (influxql.DataType, error) {
 	switch field {
 	case "_name", "_tagKey", "_tagValue", "_seriesKey":
 		return influxql.String, nil
+	case "_shard", "_replica":
+		return influxql.Integer, nil
 	}
 
 	// Process system measurements.
@@ -3,6 +3,7 @@ package tsdb_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math"
@@ -265,6 +266,58 @@ func TestShard_MaxTagValuesLimit(t *testing.T) {
 	sh.Close()
 }
 
+func TestShard_MaxSeriesKeyLength(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := filepath.Join(tmpDir, "db", "rp", "1")
+	tmpWal := filepath.Join(tmpDir, "wal")
+
+	sfile := MustOpenSeriesFile()
+	defer sfile.Close()
+
+	opts := tsdb.NewEngineOptions()
+	opts.Config.WALDir = filepath.Join(tmpDir, "wal")
+	opts.Config.MaxSeriesKeyLength = 32
+	opts.Config.MaxTagValueLength = 8
+	opts.InmemIndex = inmem.NewIndex(filepath.Base(tmpDir), sfile.SeriesFile)
+
+	sh := tsdb.NewShard(1, tmpShard, tmpWal, sfile.SeriesFile, opts)
+	if err := sh.Open(); err != nil {
+		t.Fatalf("error opening shard: %s", err.Error())
+	}
+	defer sh.Close()
+
+	for _, tt := range []struct {
+		tags models.Tags
+		err  string
+	}{
+		{
+			tags: models.Tags{{Key: []byte("host"), Value: []byte("server0")}},
+		},
+		{
+			tags: models.Tags{{Key: []byte("host"), Value: []byte("server0.example.com")}},
+			err:  `partial write: max-tag-value-length limit exceeded (19/8): measurement="cpu" tag="host" key="cpu,host=server0.example.com" dropped=1`,
+		},
+		{
+			tags: models.Tags{
+				{Key: []byte("host"), Value: []byte("server0")},
+				{Key: []byte("region"), Value: []byte("us-west")},
+				{Key: []byte("zone"), Value: []byte("a")},
+			},
+			err: `partial write: max-series-key-length limit exceeded (38/32): key="cpu,host=server0,region=us-west,zone=a" dropped=1`,
+		},
+	} {
+		pt := models.MustNewPoint("cpu", tt.tags, map[string]interface{}{"value": 1.0}, time.Unix(1, 2))
+		if err := sh.WritePoints([]models.Point{pt}); tt.err == "" && err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		} else if tt.err != "" && (err == nil || err.Error() != tt.err) {
+			t.Fatalf("unexpected error message:\n\texp = %s\n\tgot = %v", tt.err, err)
+		} else if tt.err != "" && !errors.As(err, new(tsdb.KeyLengthError)) {
+			t.Fatalf("unexpected error type: %T", err)
+		}
+	}
+}
+
 func TestWriteTimeTag(t *testing.T) {
 	tmpDir, _ := ioutil.TempDir("", "shard_test")
 	defer os.RemoveAll(tmpDir)
@@ -400,6 +453,76 @@ func TestShardWriteAddNewField(t *testing.T) {
 	}
 }
 
+// Ensure the ingest time and the shard and node serving a point are
+// returned as system columns, and points setting the ingest time themselves
+// are dropped.
+func TestShard_WritePoints_IngestTime(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := filepath.Join(tmpDir, "db", "rp", "1")
+	tmpWal := filepath.Join(tmpDir, "wal")
+
+	sfile := MustOpenSeriesFile()
+	defer sfile.Close()
+
+	opts := tsdb.NewEngineOptions()
+	opts.Config.WALDir = filepath.Join(tmpDir, "wal")
+	opts.Config.RecordIngestTime = true
+	opts.NodeID = 7
+	opts.InmemIndex = inmem.NewIndex(filepath.Base(tmpDir), sfile.SeriesFile)
+
+	sh := tsdb.NewShard(1, tmpShard, tmpWal, sfile.SeriesFile, opts)
+	if err := sh.Open(); err != nil {
+		t.Fatalf("error opening shard: %s", err.Error())
+	}
+	defer sh.Close()
+
+	conflict := models.MustNewPoint("cpu", nil, map[string]interface{}{"value": 2.0, "_ingest_time": int64(1)}, time.Unix(20, 0))
+	before := time.Now().UnixNano()
+	err := sh.WritePoints([]models.Point{
+		models.MustNewPoint("cpu", nil, map[string]interface{}{"value": 1.0}, time.Unix(10, 0)),
+		conflict,
+	})
+	perr, ok := err.(tsdb.PartialWriteError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	} else if perr.Dropped != 1 || len(perr.Points) != 1 || perr.Points[0].Point != conflict {
+		t.Fatalf("unexpected partial write: %+v", perr)
+	} else if exp := `invalid field name: input field "_ingest_time" on measurement "cpu" is reserved for the ingest time`; perr.Reason != exp {
+		t.Fatalf("unexpected reason: %s", perr.Reason)
+	}
+
+	itr, err := sh.CreateIterator(context.Background(), &influxql.Measurement{Name: "cpu"}, query.IteratorOptions{
+		Expr: influxql.MustParseExpr(`value`),
+		Aux: []influxql.VarRef{
+			{Val: "_ingest_time", Type: influxql.Integer},
+			{Val: "_replica", Type: influxql.Integer},
+			{Val: "_shard", Type: influxql.Integer},
+		},
+		Ascending: true,
+		StartTime: influxql.MinTime,
+		EndTime:   influxql.MaxTime,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer itr.Close()
+
+	p, err := itr.(query.FloatIterator).Next()
+	if err != nil {
+		t.Fatal(err)
+	} else if p == nil || p.Time != int64(10*time.Second) || len(p.Aux) != 3 {
+		t.Fatalf("unexpected point: %s", spew.Sdump(p))
+	} else if ts, ok := p.Aux[0].(int64); !ok || ts < before {
+		t.Fatalf("unexpected ingest time: %v", p.Aux[0])
+	} else if p.Aux[1] != int64(7) || p.Aux[2] != int64(1) {
+		t.Fatalf("unexpected replica and shard: %v", p.Aux[1:])
+	}
+	if p, err := itr.(query.FloatIterator).Next(); err != nil || p != nil {
+		t.Fatalf("unexpected point: %s (%v)", spew.Sdump(p), err)
+	}
+}
+
 // Tests concurrently writing to the same shard with different field types which
 // can trigger a panic when the shard is snapshotted to TSM files.
 func TestShard_WritePoints_FieldConflictConcurrent(t *testing.T) {
@@ -1002,6 +1125,46 @@ cpu,secret=foo value=100 0
 	}
 }
 
+// Ensure the series of SHOW SERIES are bounded by max-select-series.
+func TestShard_CreateIterator_Series_MaxSeriesN(t *testing.T) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			sh := MustNewOpenShard(index)
+			defer sh.Close()
+			sh.MustWritePointsString(`
+cpu,host=serverA value=100 0
+cpu,host=serverB value=25  0
+mem,host=serverA value=25  0
+`)
+
+			itr, err := sh.CreateIterator(context.Background(), &influxql.Measurement{Name: "cpu", SystemIterator: "_series"}, query.IteratorOptions{
+				Aux:        []influxql.VarRef{{Val: "key", Type: influxql.String}},
+				Ascending:  true,
+				StartTime:  influxql.MinTime,
+				EndTime:    influxql.MaxTime,
+				MaxSeriesN: 2,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer itr.Close()
+
+			fitr := itr.(query.FloatIterator)
+			for {
+				p, err := fitr.Next()
+				if err != nil {
+					if exp := "max-select-series limit exceeded: (3/2)"; err.Error() != exp {
+						t.Fatalf("unexpected error: %s", err)
+					}
+					return
+				} else if p == nil {
+					t.Fatal("expected max-select-series limit to be exceeded")
+				}
+			}
+		})
+	}
+}
+
 func TestShard_Disabled_WriteQuery(t *testing.T) {
 	var sh *Shard
 
@@ -1,9 +1,13 @@
 package tsdb // import "github.com/freetsdb/freetsdb/tsdb"
 
 import (
+	"archive/tar"
 	"bytes"
+	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"io/ioutil"
 	"os"
@@ -17,9 +21,12 @@ import (
 
 	"github.com/freetsdb/freetsdb/logger"
 	"github.com/freetsdb/freetsdb/models"
+	"github.com/freetsdb/freetsdb/pkg/encryption"
 	"github.com/freetsdb/freetsdb/pkg/estimator"
 	"github.com/freetsdb/freetsdb/pkg/estimator/hll"
+	"github.com/freetsdb/freetsdb/pkg/file"
 	"github.com/freetsdb/freetsdb/pkg/limiter"
+	intar "github.com/freetsdb/freetsdb/pkg/tar"
 	"github.com/freetsdb/freetsdb/query"
 	"github.com/freetsdb/freetsdb/services/influxql"
 	"go.uber.org/zap"
@@ -36,6 +43,9 @@ var (
 	// ErrMultipleIndexTypes is returned when trying to do deletes on a database with
 	// multiple index types.
 	ErrMultipleIndexTypes = errors.New("cannot delete data. DB contains shards using both inmem and tsi1 indexes. Please convert all shards to use the same index type to delete data.")
+	// ErrSeriesFileExists is returned when restoring the series file of a
+	// database that already has one on this node.
+	ErrSeriesFileExists = errors.New("series file already exists")
 )
 
 // Statistics gathered by the store.
@@ -48,6 +58,10 @@ const (
 // a database.
 const SeriesFileDirectory = "_series"
 
+// restoringSeriesFilePrefix is the prefix of the directories of the series
+// files being restored, which are removed when the store is opened.
+const restoringSeriesFilePrefix = SeriesFileDirectory + ".restoring"
+
 // databaseState keeps track of the state of a database.
 type databaseState struct{ indexTypes map[string]int }
 
@@ -92,14 +106,35 @@ type Store struct {
 	// is stored by shard.
 	epochs map[uint64]*epochTracker
 
+	// indexEpochs are the last index epochs of the databases, and when they
+	// were first seen.
+	epochMu     sync.Mutex
+	indexEpochs map[string]indexEpoch
+
 	EngineOptions EngineOptions
 
+	// ObjectStore keeps the TSM files of the shards in object storage, if
+	// set. The shards are restored from it when they are opened.
+	ObjectStore *ObjectStore
+
+	// compactions lowers the limit of concurrent compactions set when opening
+	// the store to maxCompactions, if set.
+	compactions    *limiter.Reservation
+	maxCompactions int
+
 	baseLogger *zap.Logger
 	Logger     *zap.Logger
 
 	closing chan struct{}
 	wg      sync.WaitGroup
 	opened  bool
+
+	// openedAt is when the store was last opened.
+	openedAt time.Time
+
+	// usageHistory is the history of the disk usage of the store.
+	usageMu      sync.Mutex
+	usageHistory *diskUsageHistory
 }
 
 // NewStore returns a new store with the given path and a default configuration.
@@ -113,6 +148,7 @@ func NewStore(path string) *Store {
 		indexes:             make(map[string]interface{}),
 		pendingShardDeletes: make(map[uint64]struct{}),
 		epochs:              make(map[uint64]*epochTracker),
+		indexEpochs:         make(map[string]indexEpoch),
 		EngineOptions:       NewEngineOptions(),
 		Logger:              logger,
 		baseLogger:          logger,
@@ -123,6 +159,9 @@ func NewStore(path string) *Store {
 func (s *Store) WithLogger(log *zap.Logger) {
 	s.baseLogger = log
 	s.Logger = log.With(zap.String("service", "store"))
+	if s.ObjectStore != nil {
+		s.ObjectStore.Logger = log.With(zap.String("service", "object-store"))
+	}
 	for _, sh := range s.shards {
 		sh.WithLogger(s.baseLogger)
 	}
@@ -210,18 +249,38 @@ func (s *Store) Open() error {
 
 	s.closing = make(chan struct{})
 	s.shards = map[uint64]*Shard{}
+	s.openedAt = time.Now()
 
 	s.Logger.Info("Using data dir", zap.String("path", s.Path()))
+	if s.EngineOptions.KeyProvider != nil {
+		// The series file and the indexes are read in place, and so are
+		// not sealed like the blocks of TSM files and WAL entries.
+		s.Logger.Warn("Encryption at rest does not cover the series file and the indexes, which hold the series keys in plaintext")
+	}
 
 	// Create directory.
 	if err := os.MkdirAll(s.path, 0777); err != nil {
 		return err
 	}
 
+	// Files are uploaded to the object store before they become part of a
+	// shard.
+	if s.ObjectStore != nil {
+		s.EngineOptions.FileStoreObserver = s.ObjectStore
+	}
+
 	if err := s.loadShards(); err != nil {
 		return err
 	}
 
+	history, err := loadDiskUsageHistory(filepath.Join(s.path, DiskUsageHistoryFile))
+	if err != nil {
+		return err
+	}
+	s.usageMu.Lock()
+	s.usageHistory = history
+	s.usageMu.Unlock()
+
 	s.opened = true
 
 	if !s.EngineOptions.MonitorDisabled {
@@ -245,6 +304,12 @@ func (s *Store) loadShards() error {
 	// Limit the number of concurrent TSM files to be opened to the number of cores.
 	s.EngineOptions.OpenLimiter = limiter.NewFixed(runtime.GOMAXPROCS(0))
 
+	// Share a single block cache between the shards, so its size bounds the memory
+	// used to read TSM files without memory maps.
+	if c := s.EngineOptions.Config; c.TSMReadMode == TSMReadModePread && NewTSMBlockCache != nil {
+		s.EngineOptions.TSMBlockCache = NewTSMBlockCache(int64(c.TSMBlockCacheSize))
+	}
+
 	// Setup a shared limiter for compactions
 	lim := s.EngineOptions.Config.MaxConcurrentCompactions
 	if lim == 0 {
@@ -261,6 +326,7 @@ func (s *Store) loadShards() error {
 	}
 
 	s.EngineOptions.CompactionLimiter = limiter.NewFixed(lim)
+	s.applyCompactionLimit()
 
 	compactionSettings := []zapcore.Field{zap.Int("max_concurrent_compactions", lim)}
 	throughput := int(s.EngineOptions.Config.CompactThroughput)
@@ -339,6 +405,11 @@ func (s *Store) loadShards() error {
 			// The .series directory is not a retention policy.
 			if rp.Name() == SeriesFileDirectory {
 				continue
+			} else if strings.HasPrefix(rp.Name(), restoringSeriesFilePrefix) {
+				if err := os.RemoveAll(rpPath); err != nil {
+					return err
+				}
+				continue
 			}
 
 			if s.EngineOptions.RetentionPolicyFilter != nil && !s.EngineOptions.RetentionPolicyFilter(db.Name(), rp.Name()) {
@@ -393,6 +464,20 @@ func (s *Store) loadShards() error {
 						opt.IndexVersion = InmemIndexName
 					}
 
+					if opt.Cipher, err = s.shardCipher(db); err != nil {
+						log.Info("Failed to load encryption keys", logger.Shard(shardID), zap.Error(err))
+						resC <- &res{err: fmt.Errorf("Failed to open shard: %d: %s", shardID, err)}
+						return
+					}
+
+					if s.ObjectStore != nil {
+						if err := s.ObjectStore.RestoreShard(db, rp, shardID, path); err != nil {
+							log.Info("Failed to restore shard from object storage", logger.Shard(shardID), zap.Error(err))
+							resC <- &res{err: fmt.Errorf("Failed to open shard: %d: %s", shardID, err)}
+							return
+						}
+					}
+
 					// Open engine.
 					shard := NewShard(shardID, path, walPath, sfile, opt)
 
@@ -488,11 +573,58 @@ func (s *Store) Close() error {
 	s.indexes = make(map[string]interface{})
 	s.pendingShardDeletes = make(map[uint64]struct{})
 	s.shards = nil
+	if s.compactions != nil {
+		s.compactions.Close()
+		s.compactions = nil
+	}
 	s.opened = false // Store may now be opened again.
 	s.mu.Unlock()
 	return nil
 }
 
+// SetMaxConcurrentCompactions limits the number of concurrent compactions to
+// n, at most the limit the store was opened with. A value of 0 restores that
+// limit. Running compactions are not interrupted, so a lower limit is reached
+// as they finish.
+func (s *Store) SetMaxConcurrentCompactions(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxCompactions = n
+	if s.opened {
+		s.applyCompactionLimit()
+	}
+}
+
+// MaxConcurrentCompactions returns the number of concurrent compactions
+// allowed, or 0 if the store is not open.
+func (s *Store) MaxConcurrentCompactions() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.opened {
+		return 0
+	} else if s.compactions != nil {
+		return s.compactions.Limit()
+	}
+	return s.EngineOptions.CompactionLimiter.Capacity()
+}
+
+// applyCompactionLimit applies maxCompactions to the compaction limiter. It
+// must be called under a full lock.
+func (s *Store) applyCompactionLimit() {
+	if s.compactions == nil {
+		if s.maxCompactions <= 0 {
+			return
+		}
+		s.compactions = limiter.NewReservation(s.EngineOptions.CompactionLimiter)
+	}
+
+	n := s.maxCompactions
+	if n <= 0 {
+		n = s.EngineOptions.CompactionLimiter.Capacity()
+	}
+	s.compactions.SetLimit(n)
+}
+
 // openSeriesFile either returns or creates a series file for the provided
 // database. It must be called under a full lock.
 func (s *Store) openSeriesFile(database string) (*SeriesFile, error) {
@@ -585,8 +717,28 @@ func (s *Store) ShardDigest(id uint64) (io.ReadCloser, int64, error) {
 	return sh.Digest()
 }
 
+// ReindexShard rebuilds the TSI index of the shard with the specified ID
+// while the shard stays online.
+func (s *Store) ReindexShard(id uint64) error {
+	sh := s.Shard(id)
+	if sh == nil {
+		return ErrShardNotFound
+	}
+	return sh.Reindex()
+}
+
 // CreateShard creates a shard with the given id and retention policy on a database.
 func (s *Store) CreateShard(database, retentionPolicy string, shardID uint64, enabled bool) error {
+	path := filepath.Join(s.path, database, retentionPolicy, strconv.FormatUint(shardID, 10))
+
+	// The files of the shard are restored from the object store before the
+	// store is locked, so that other shards are not held up by the download.
+	if s.ObjectStore != nil && s.Shard(shardID) == nil {
+		if err := s.ObjectStore.RestoreShard(database, retentionPolicy, shardID, path); err != nil {
+			return err
+		}
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -634,8 +786,13 @@ func (s *Store) CreateShard(database, retentionPolicy string, shardID uint64, en
 	opt := s.EngineOptions
 	opt.InmemIndex = idx
 	opt.SeriesIDSets = shardSet{store: s, db: database}
+	if e := opt.Config.EngineFor(database, retentionPolicy); e != opt.Config.Engine {
+		opt.EngineVersion = e
+	}
+	if opt.Cipher, err = s.shardCipher(database); err != nil {
+		return err
+	}
 
-	path := filepath.Join(s.path, database, retentionPolicy, strconv.FormatUint(shardID, 10))
 	shard := NewShard(shardID, path, walPath, sfile, opt)
 	shard.WithLogger(s.baseLogger)
 	shard.EnableOnOpen = enabled
@@ -661,6 +818,20 @@ func (s *Store) CreateShard(database, retentionPolicy string, shardID uint64, en
 	return nil
 }
 
+// shardCipher returns the cipher for shards of database, or nil if
+// encryption at rest is disabled.
+func (s *Store) shardCipher(database string) (*encryption.Cipher, error) {
+	if s.EngineOptions.KeyProvider == nil {
+		return nil, nil
+	}
+
+	keys, err := s.EngineOptions.KeyProvider.Keys(database)
+	if err != nil {
+		return nil, err
+	}
+	return encryption.NewCipher(keys)
+}
+
 // CreateShardSnapShot will create a hard link to the underlying shard and return a path.
 // The caller is responsible for cleaning up (removing) the file path returned.
 func (s *Store) CreateShardSnapshot(id uint64) (string, error) {
@@ -672,6 +843,148 @@ func (s *Store) CreateShardSnapshot(id uint64) (string, error) {
 	return sh.CreateSnapshot()
 }
 
+// ShardDiskSize returns the size on disk of the shard with id.
+func (s *Store) ShardDiskSize(id uint64) (int64, error) {
+	sh := s.Shard(id)
+	if sh == nil {
+		return 0, ErrShardNotFound
+	}
+	return sh.DiskSize()
+}
+
+// DiskUsage returns the disk usage of the shards of database, ordered by ID.
+// The shards of every database are returned if database is empty.
+func (s *Store) DiskUsage(database string) ([]ShardDiskUsage, error) {
+	s.mu.RLock()
+	var shards []*Shard
+	if database == "" {
+		shards = s.filterShards(nil)
+	} else {
+		shards = s.filterShards(byDatabase(database))
+	}
+	s.mu.RUnlock()
+
+	usages := make([]ShardDiskUsage, 0, len(shards))
+	for _, sh := range shards {
+		u, err := sh.DiskUsage()
+		if err == ErrEngineClosed {
+			// Shards being opened or restored have nothing to report yet.
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		usages = append(usages, u)
+	}
+	sort.Slice(usages, func(i, j int) bool { return usages[i].ID < usages[j].ID })
+	return usages, nil
+}
+
+// SampleDiskUsage adds the disk usage of the retention policies to the
+// history of the store. It is called every DiskUsageSampleInterval while the
+// store is open.
+func (s *Store) SampleDiskUsage() error {
+	shards, err := s.DiskUsage("")
+	if err != nil {
+		return err
+	}
+
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	if s.usageHistory == nil {
+		return nil
+	}
+	return s.usageHistory.add(newDiskUsageSample(time.Now(), shards))
+}
+
+// DiskUsageAt returns the disk usage of the retention policies of database
+// in the last sample of the history taken at or before t, or nil if the
+// history does not reach t. The retention policies of every database are
+// returned if database is empty.
+func (s *Store) DiskUsageAt(database string, t time.Time) *DiskUsageSample {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	if s.usageHistory == nil {
+		return nil
+	}
+	if sample := s.usageHistory.at(t); sample != nil {
+		return sample.filter(database)
+	}
+	return nil
+}
+
+// ShardFiles returns the data files of the shard with id.
+func (s *Store) ShardFiles(id uint64) ([]ShardFile, error) {
+	sh := s.Shard(id)
+	if sh == nil {
+		return nil, ErrShardNotFound
+	}
+	return sh.Files()
+}
+
+// ShardManifest returns the sizes of the TSM and tombstone files of the shard
+// with id by name, once its cache is written to a TSM file.
+func (s *Store) ShardManifest(id uint64) (map[string]int64, error) {
+	sh := s.Shard(id)
+	if sh == nil {
+		return nil, ErrShardNotFound
+	}
+
+	dir, err := sh.CreateSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]int64, len(fis))
+	for _, fi := range fis {
+		if fi.Mode().IsRegular() {
+			m[fi.Name()] = fi.Size()
+		}
+	}
+	return m, nil
+}
+
+// BackupShardFiles writes a tar archive of the TSM and tombstone files of the
+// shard with id named in names to w, in the format of BackupShard. An error is
+// returned if a file is not part of the shard anymore, such as a TSM file
+// that was compacted since its manifest was read.
+func (s *Store) BackupShardFiles(id uint64, names []string, w io.Writer) error {
+	sh := s.Shard(id)
+	if sh == nil {
+		return fmt.Errorf("shard %d doesn't exist on this server", id)
+	}
+
+	path, err := relativePath(s.path, sh.path)
+	if err != nil {
+		return err
+	}
+
+	dir, err := sh.CreateSnapshot()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	want := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		if fi, err := os.Stat(filepath.Join(dir, filepath.Base(name))); err != nil || !fi.Mode().IsRegular() {
+			return fmt.Errorf("file %s not found in shard %d", name, id)
+		}
+		want[filepath.Base(name)] = struct{}{}
+	}
+
+	return intar.Stream(w, dir, path, func(fi os.FileInfo, shardRelativePath, fullPath string, tw *tar.Writer) error {
+		if _, ok := want[fi.Name()]; !ok || fi.IsDir() {
+			return nil
+		}
+		return intar.StreamFile(fi, shardRelativePath, fullPath, tw)
+	})
+}
+
 // SetShardEnabled enables or disables a shard for read and writes.
 func (s *Store) SetShardEnabled(shardID uint64, enabled bool) error {
 	sh := s.Shard(shardID)
@@ -791,6 +1104,14 @@ func (s *Store) DeleteShard(shardID uint64) error {
 
 // DeleteDatabase will close all shards associated with a database and remove the directory and files from disk.
 func (s *Store) DeleteDatabase(name string) error {
+	// The files of the database are removed from the object store even if
+	// none of its shards are stored on this node.
+	if s.ObjectStore != nil {
+		if err := s.ObjectStore.DeleteDatabase(name); err != nil {
+			return err
+		}
+	}
+
 	s.mu.RLock()
 	if _, ok := s.databases[name]; !ok {
 		s.mu.RUnlock()
@@ -858,6 +1179,12 @@ func (s *Store) DeleteDatabase(name string) error {
 // provided retention policy, remove the retention policy directories on
 // both the DB and WAL, and remove all shard files from disk.
 func (s *Store) DeleteRetentionPolicy(database, name string) error {
+	if s.ObjectStore != nil {
+		if err := s.ObjectStore.DeleteRetentionPolicy(database, name); err != nil {
+			return err
+		}
+	}
+
 	s.mu.RLock()
 	if _, ok := s.databases[database]; !ok {
 		s.mu.RUnlock()
@@ -937,6 +1264,52 @@ func (s *Store) DeleteMeasurement(database, name string) error {
 	})
 }
 
+// indexEpoch is the index epoch of a database and the time it was first seen.
+type indexEpoch struct {
+	epoch    uint64
+	modified time.Time
+}
+
+// IndexEpoch returns a number that changes whenever series, measurements,
+// tags or fields are added to or dropped from the shards of the database
+// stored on this node, and the time the change was first seen. The results of
+// metadata queries of the database can be cached until it changes.
+func (s *Store) IndexEpoch(database string) (uint64, time.Time) {
+	s.mu.RLock()
+	shards := s.filterShards(byDatabase(database))
+	openedAt := s.openedAt
+	s.mu.RUnlock()
+	sort.Slice(shards, func(i, j int) bool { return shards[i].id < shards[j].id })
+
+	// The epoch of a shard is reset when the store is reopened or the shard
+	// reindexed, so the time the store was opened and the references of the
+	// indexes are hashed too.
+	h := fnv.New64a()
+	var buf [8]byte
+	write := func(v uint64) {
+		binary.BigEndian.PutUint64(buf[:], v)
+		h.Write(buf[:])
+	}
+	write(uint64(openedAt.UnixNano()))
+	for _, sh := range shards {
+		write(sh.id)
+		write(sh.IndexEpoch())
+		if index, err := sh.Index(); err == nil {
+			write(uint64(index.UniqueReferenceID()))
+		}
+	}
+	epoch := h.Sum64()
+
+	s.epochMu.Lock()
+	defer s.epochMu.Unlock()
+	e, ok := s.indexEpochs[database]
+	if !ok || e.epoch != epoch {
+		e = indexEpoch{epoch: epoch, modified: time.Now().UTC()}
+		s.indexEpochs[database] = e
+	}
+	return e.epoch, e.modified
+}
+
 // filterShards returns a slice of shards where fn returns true
 // for the shard. If the provided predicate is nil then all shards are returned.
 // filterShards should be called under a lock.
@@ -1159,6 +1532,28 @@ func (s *Store) MeasurementsCardinality(database string) (int64, error) {
 	return int64(ss.Count() - ts.Count()), nil
 }
 
+// MeasurementStats returns the statistics of the measurements stored on disk
+// by the shards of the provided database, ordered by ID.
+func (s *Store) MeasurementStats(database string) ([]ShardMeasurementStats, error) {
+	s.mu.RLock()
+	shards := s.filterShards(byDatabase(database))
+	s.mu.RUnlock()
+
+	stats := make([]ShardMeasurementStats, 0, len(shards))
+	for _, sh := range shards {
+		ss, err := sh.MeasurementStats()
+		if err == ErrEngineClosed || err == ErrShardDisabled {
+			// Shards being opened or restored have nothing to report yet.
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		stats = append(stats, ShardMeasurementStats{ID: sh.ID(), Measurements: ss})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ID < stats[j].ID })
+	return stats, nil
+}
+
 // MeasurementsSketches returns the sketches associated with the measurement
 // data in all the shards in the provided database.
 //
@@ -1189,6 +1584,73 @@ func (s *Store) BackupShard(id uint64, since time.Time, w io.Writer) error {
 	return shard.Backup(w, path, since)
 }
 
+// BackupSeriesFile writes a tar archive of the series file of database to w.
+// The series file must be backed up after the shards of the database, so
+// that it has the series of their index snapshots.
+func (s *Store) BackupSeriesFile(database string, w io.Writer) error {
+	sfile := s.seriesFile(database)
+	if sfile == nil {
+		return fmt.Errorf("database %s doesn't exist on this server", database)
+	}
+	return sfile.Backup(w)
+}
+
+// RestoreSeriesFile installs the series file of database from a tar archive
+// written by BackupSeriesFile. The database must not be stored on this node
+// yet, so that the ids of the series restored are not in use. The TSI index
+// snapshots of the shards restored afterwards are then used as they are,
+// since their series have the same ids as when they were backed up.
+func (s *Store) RestoreSeriesFile(database string, r io.Reader) error {
+	if database == "" || database != filepath.Base(database) || database == ".." {
+		return fmt.Errorf("invalid database name: %q", database)
+	}
+
+	dbPath := filepath.Join(s.path, database)
+	s.mu.RLock()
+	err := s.checkNoSeriesFile(database)
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	} else if err := os.MkdirAll(dbPath, 0777); err != nil {
+		return err
+	}
+
+	// The series file is restored next to its final location, which it is
+	// moved to at once.
+	tmp, err := ioutil.TempDir(dbPath, restoringSeriesFilePrefix)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := RestoreSeriesFile(tmp, r); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkNoSeriesFile(database); err != nil {
+		return err
+	} else if err := os.Rename(tmp, filepath.Join(dbPath, SeriesFileDirectory)); err != nil {
+		return err
+	}
+	return file.SyncDir(dbPath)
+}
+
+// checkNoSeriesFile returns ErrSeriesFileExists if database has a series
+// file on this node. It must be called under a lock.
+func (s *Store) checkNoSeriesFile(database string) error {
+	if s.sfiles[database] != nil {
+		return ErrSeriesFileExists
+	}
+	if _, err := os.Stat(filepath.Join(s.path, database, SeriesFileDirectory)); err == nil {
+		return ErrSeriesFileExists
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
 func (s *Store) ExportShard(id uint64, start time.Time, end time.Time, w io.Writer) error {
 	shard := s.Shard(id)
 	if shard == nil {
@@ -1237,6 +1699,32 @@ func (s *Store) ImportShard(id uint64, r io.Reader) error {
 	return shard.Import(r, path)
 }
 
+// CopyShard copies all the data of shard srcID into shard dstID. The data is
+// imported as new files, so copying several shards into one merges them.
+func (s *Store) CopyShard(srcID, dstID uint64) error {
+	src, dst := s.Shard(srcID), s.Shard(dstID)
+	if src == nil {
+		return fmt.Errorf("shard %d doesn't exist on this server", srcID)
+	} else if dst == nil {
+		return fmt.Errorf("shard %d doesn't exist on this server", dstID)
+	}
+
+	path, err := relativePath(s.path, dst.path)
+	if err != nil {
+		return err
+	}
+
+	// The source is exported under the path of the destination, as the
+	// import skips the files of other shards.
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(src.Export(pw, path, time.Unix(0, models.MinNanoTime), time.Unix(0, models.MaxNanoTime)))
+	}()
+	err = dst.Import(pr, path)
+	pr.CloseWithError(io.ErrClosedPipe)
+	return err
+}
+
 // ShardRelativePath will return the relative path to the shard, i.e.,
 // <database>/<retention>/<id>.
 func (s *Store) ShardRelativePath(id uint64) (string, error) {
@@ -1359,6 +1847,12 @@ func (s *Store) ExpandSources(sources influxql.Sources) (influxql.Sources, error
 
 // WriteToShard writes a list of points to a shard identified by its ID.
 func (s *Store) WriteToShard(shardID uint64, points []models.Point) error {
+	return s.WriteToShardWithContext(context.Background(), shardID, points)
+}
+
+// WriteToShardWithContext writes points like WriteToShard, recording its
+// stages in the write trace of ctx.
+func (s *Store) WriteToShardWithContext(ctx context.Context, shardID uint64, points []models.Point) error {
 	s.mu.RLock()
 
 	select {
@@ -1392,7 +1886,7 @@ func (s *Store) WriteToShard(shardID uint64, points []models.Point) error {
 		sh.SetCompactionsEnabled(true)
 	}
 
-	return sh.WritePoints(points)
+	return sh.WritePointsWithContext(ctx, points)
 }
 
 // IsRetryable returns true if this error is temporary and could be retried
@@ -1433,6 +1927,32 @@ func (s *Store) MeasurementNames(auth query.Authorizer, database string, cond in
 	return is.MeasurementNamesByExpr(auth, cond)
 }
 
+// TagValuesByPrefix returns the values of a tag key of a measurement in the
+// database that start with prefix, in order. At most limit values are
+// returned if limit is positive.
+func (s *Store) TagValuesByPrefix(auth query.Authorizer, database string, name, key, prefix []byte, limit int) ([]string, error) {
+	s.mu.RLock()
+	shards := s.filterShards(byDatabase(database))
+	s.mu.RUnlock()
+
+	sfile := s.seriesFile(database)
+	if sfile == nil {
+		return nil, nil
+	}
+
+	// Build indexset.
+	is := IndexSet{Indexes: make([]Index, 0, len(shards)), SeriesFile: sfile}
+	for _, sh := range shards {
+		index, err := sh.Index()
+		if err != nil {
+			return nil, err
+		}
+		is.Indexes = append(is.Indexes, index)
+	}
+	is = is.DedupeInmemIndexes()
+	return is.MeasurementTagValuesByPrefix(auth, name, key, prefix, limit)
+}
+
 // MeasurementSeriesCounts returns the number of measurements and series in all
 // the shards' indices.
 func (s *Store) MeasurementSeriesCounts(database string) (measuments int, series int) {
@@ -1623,64 +2143,13 @@ func (s *Store) TagValues(auth query.Authorizer, shardIDs []uint64, cond influxq
 		return nil, errors.New("a condition is required")
 	}
 
-	measurementExpr := influxql.CloneExpr(cond)
-	measurementExpr = influxql.Reduce(influxql.RewriteExpr(measurementExpr, func(e influxql.Expr) influxql.Expr {
-		switch e := e.(type) {
-		case *influxql.BinaryExpr:
-			switch e.Op {
-			case influxql.EQ, influxql.NEQ, influxql.EQREGEX, influxql.NEQREGEX:
-				tag, ok := e.LHS.(*influxql.VarRef)
-				if !ok || tag.Val != "_name" {
-					return nil
-				}
-			}
-		}
-		return e
-	}), nil)
-
-	filterExpr := influxql.CloneExpr(cond)
-	filterExpr = influxql.Reduce(influxql.RewriteExpr(filterExpr, func(e influxql.Expr) influxql.Expr {
-		switch e := e.(type) {
-		case *influxql.BinaryExpr:
-			switch e.Op {
-			case influxql.EQ, influxql.NEQ, influxql.EQREGEX, influxql.NEQREGEX:
-				tag, ok := e.LHS.(*influxql.VarRef)
-				if !ok || influxql.IsSystemName(tag.Val) {
-					return nil
-				}
-			}
-		}
-		return e
-	}), nil)
+	measurementExpr, filterExpr := tagValuesExprs(cond)
 
 	// Build index set to work on.
-	is := IndexSet{Indexes: make([]Index, 0, len(shardIDs))}
-	s.mu.RLock()
-	for _, sid := range shardIDs {
-		shard, ok := s.shards[sid]
-		if !ok {
-			continue
-		}
-
-		if is.SeriesFile == nil {
-			sfile, err := shard.SeriesFile()
-			if err != nil {
-				s.mu.RUnlock()
-				return nil, err
-			}
-			is.SeriesFile = sfile
-		}
-
-		index, err := shard.Index()
-		if err != nil {
-			s.mu.RUnlock()
-			return nil, err
-		}
-
-		is.Indexes = append(is.Indexes, index)
+	is, err := s.tagValuesIndexSet(shardIDs)
+	if err != nil {
+		return nil, err
 	}
-	s.mu.RUnlock()
-	is = is.DedupeInmemIndexes()
 
 	// Stores each list of TagValues for each measurement.
 	var allResults []tagValues
@@ -1790,6 +2259,186 @@ func (s *Store) TagValues(auth query.Authorizer, shardIDs []uint64, cond influxq
 	return result, nil
 }
 
+// tagValuesExprs splits the condition of a SHOW TAG VALUES into the
+// expression selecting measurements and the one filtering series.
+func tagValuesExprs(cond influxql.Expr) (measurementExpr, filterExpr influxql.Expr) {
+	measurementExpr = influxql.CloneExpr(cond)
+	measurementExpr = influxql.Reduce(influxql.RewriteExpr(measurementExpr, func(e influxql.Expr) influxql.Expr {
+		switch e := e.(type) {
+		case *influxql.BinaryExpr:
+			switch e.Op {
+			case influxql.EQ, influxql.NEQ, influxql.EQREGEX, influxql.NEQREGEX:
+				tag, ok := e.LHS.(*influxql.VarRef)
+				if !ok || tag.Val != "_name" {
+					return nil
+				}
+			}
+		}
+		return e
+	}), nil)
+
+	filterExpr = influxql.CloneExpr(cond)
+	filterExpr = influxql.Reduce(influxql.RewriteExpr(filterExpr, func(e influxql.Expr) influxql.Expr {
+		switch e := e.(type) {
+		case *influxql.BinaryExpr:
+			switch e.Op {
+			case influxql.EQ, influxql.NEQ, influxql.EQREGEX, influxql.NEQREGEX:
+				tag, ok := e.LHS.(*influxql.VarRef)
+				if !ok || influxql.IsSystemName(tag.Val) {
+					return nil
+				}
+			}
+		}
+		return e
+	}), nil)
+	return measurementExpr, filterExpr
+}
+
+// tagValuesIndexSet returns the index set of the shards.
+func (s *Store) tagValuesIndexSet(shardIDs []uint64) (IndexSet, error) {
+	is := IndexSet{Indexes: make([]Index, 0, len(shardIDs))}
+	s.mu.RLock()
+	for _, sid := range shardIDs {
+		shard, ok := s.shards[sid]
+		if !ok {
+			continue
+		}
+
+		if is.SeriesFile == nil {
+			sfile, err := shard.SeriesFile()
+			if err != nil {
+				s.mu.RUnlock()
+				return IndexSet{}, err
+			}
+			is.SeriesFile = sfile
+		}
+
+		index, err := shard.Index()
+		if err != nil {
+			s.mu.RUnlock()
+			return IndexSet{}, err
+		}
+
+		is.Indexes = append(is.Indexes, index)
+	}
+	s.mu.RUnlock()
+	return is.DedupeInmemIndexes(), nil
+}
+
+// TagValuesIterator iterates over the measurements of the tag values of
+// a set of shards.
+type TagValuesIterator interface {
+	// Next returns the next measurement, in name order, with an iterator
+	// over its tag key/value pairs that the caller must close. It returns
+	// a nil name when there are no measurements left.
+	Next() ([]byte, KeyValueIterator, error)
+	Close() error
+}
+
+// TagValuesIterator returns an iterator over the same tag values as
+// TagValues. The values are read from the index as the iterator advances,
+// so a caller that streams them never holds all of them in memory.
+func (s *Store) TagValuesIterator(auth query.Authorizer, shardIDs []uint64, cond influxql.Expr) (TagValuesIterator, error) {
+	if cond == nil {
+		return nil, errors.New("a condition is required")
+	}
+
+	measurementExpr, filterExpr := tagValuesExprs(cond)
+	is, err := s.tagValuesIndexSet(shardIDs)
+	if err != nil {
+		return nil, err
+	} else if len(is.Indexes) == 0 {
+		return &tagValuesIterator{}, nil
+	}
+
+	names, err := is.MeasurementNamesByExpr(nil, measurementExpr)
+	if err != nil {
+		return nil, err
+	}
+	return &tagValuesIterator{
+		is:         is,
+		auth:       auth,
+		cond:       cond,
+		filterExpr: filterExpr,
+		names:      names,
+	}, nil
+}
+
+type tagValuesIterator struct {
+	is         IndexSet
+	auth       query.Authorizer
+	cond       influxql.Expr
+	filterExpr influxql.Expr
+	names      [][]byte
+}
+
+func (itr *tagValuesIterator) Next() ([]byte, KeyValueIterator, error) {
+	for len(itr.names) > 0 {
+		name := itr.names[0]
+		itr.names = itr.names[1:]
+
+		keySet, err := itr.is.MeasurementTagKeysByExpr(name, itr.cond)
+		if err != nil {
+			return nil, nil, err
+		} else if len(keySet) == 0 {
+			// No matching tag keys for this measurement
+			continue
+		}
+
+		keys := make([]string, 0, len(keySet))
+		for k := range keySet {
+			keys = append(keys, k)
+		}
+
+		kitr, err := itr.is.MeasurementTagKeyValueIterator(itr.auth, name, keys, itr.filterExpr)
+		if err != nil {
+			return nil, nil, err
+		}
+		return name, kitr, nil
+	}
+	return nil, nil, nil
+}
+
+func (itr *tagValuesIterator) Close() error {
+	itr.names = nil
+	return nil
+}
+
+// NewTagValuesSliceIterator returns a TagValuesIterator over a slice.
+func NewTagValuesSliceIterator(a []TagValues) TagValuesIterator {
+	return &tagValuesSliceIterator{a: a}
+}
+
+type tagValuesSliceIterator struct {
+	a []TagValues
+}
+
+func (itr *tagValuesSliceIterator) Next() ([]byte, KeyValueIterator, error) {
+	if len(itr.a) == 0 {
+		return nil, nil, nil
+	}
+	tv := itr.a[0]
+	itr.a = itr.a[1:]
+	return []byte(tv.Measurement), &keyValueSliceIterator{a: tv.Values}, nil
+}
+
+func (itr *tagValuesSliceIterator) Close() error { return nil }
+
+type keyValueSliceIterator struct {
+	a  []KeyValue
+	kv KeyValue
+}
+
+func (itr *keyValueSliceIterator) Next() (*KeyValue, error) {
+	if len(itr.a) == 0 {
+		return nil, nil
+	}
+	itr.kv, itr.a = itr.a[0], itr.a[1:]
+	return &itr.kv, nil
+}
+
+func (itr *keyValueSliceIterator) Close() error { return nil }
+
 // mergeTagValues merges multiple sorted sets of temporary tagValues using a
 // direct k-way merge whilst also removing duplicated entries. The result is a
 // single TagValue type.
@@ -1903,10 +2552,20 @@ func (s *Store) monitorShards() {
 	defer t.Stop()
 	t2 := time.NewTicker(time.Minute)
 	defer t2.Stop()
+	t3 := time.NewTicker(DiskUsageSampleInterval)
+	defer t3.Stop()
+
+	if err := s.SampleDiskUsage(); err != nil {
+		s.Logger.Warn("Cannot sample disk usage", zap.Error(err))
+	}
 	for {
 		select {
 		case <-s.closing:
 			return
+		case <-t3.C:
+			if err := s.SampleDiskUsage(); err != nil {
+				s.Logger.Warn("Cannot sample disk usage", zap.Error(err))
+			}
 		case <-t.C:
 			s.mu.RLock()
 			for _, sh := range s.shards {
@@ -647,6 +647,49 @@ func TestStore_MeasurementNames_Deduplicate(t *testing.T) {
 	}
 }
 
+// Ensure the index epoch of a database changes only when its index does.
+func TestStore_IndexEpoch(t *testing.T) {
+	t.Parallel()
+
+	test := func(index string) {
+		s := MustOpenStore(index)
+		defer s.Close()
+
+		s.MustCreateShardWithData("db0", "rp0", 1, `cpu,host=serverA value=1 0`)
+		epoch, modified := s.IndexEpoch("db0")
+
+		// Writing to existing series leaves the index unchanged.
+		s.MustWriteToShardString(1, `cpu,host=serverA value=2 10`)
+		if got, t0 := s.IndexEpoch("db0"); got != epoch || !t0.Equal(modified) {
+			t.Fatalf("unexpected epoch change: %d != %d", got, epoch)
+		}
+
+		// New series, fields and measurements change it.
+		for _, data := range []string{
+			`cpu,host=serverB value=1 20`,
+			`cpu,host=serverB value=1,idle=2 30`,
+			`mem value=1 40`,
+		} {
+			s.MustWriteToShardString(1, data)
+			got, _ := s.IndexEpoch("db0")
+			if got == epoch {
+				t.Fatalf("expected epoch change after writing %s", data)
+			}
+			epoch = got
+		}
+
+		if err := s.DeleteMeasurement("db0", "mem"); err != nil {
+			t.Fatal(err)
+		} else if got, _ := s.IndexEpoch("db0"); got == epoch {
+			t.Fatal("expected epoch change after dropping a measurement")
+		}
+	}
+
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) { test(index) })
+	}
+}
+
 func testStoreCardinalityTombstoning(t *testing.T, store *Store) {
 	// Generate point data to write to the shards.
 	series := genTestSeries(10, 2, 4) // 160 series
@@ -1207,11 +1250,247 @@ func TestStore_TagValues(t *testing.T) {
 					t.Fatalf("got:\n%#v\n\nexp:\n%#v", got, exp)
 				}
 			})
+			t.Run(example.Name+"_Iterator_"+index, func(t *testing.T) {
+				itr, err := s.TagValuesIterator(nil, shardIDs, example.Expr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer itr.Close()
+
+				got, err := readTagValuesIterator(itr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				exp := example.Exp
+
+				if !reflect.DeepEqual(got, exp) {
+					t.Fatalf("got:\n%#v\n\nexp:\n%#v", got, exp)
+				}
+			})
 			s.Close()
 		}
 	}
 }
 
+func TestStore_TagValuesByPrefix(t *testing.T) {
+	t.Parallel()
+
+	test := func(index string) error {
+		s := MustOpenStore(index)
+		defer s.Close()
+
+		s.MustCreateShardWithData("db0", "rp0", 0,
+			`cpu,host=apple value=1 0`,
+			`cpu,host=server01 value=1 0`,
+			`cpu,host=server02 value=1 0`,
+			`cpu,host=server03 value=1 0`,
+			`cpu,host=zebra value=1 0`,
+			`mem,host=server04 value=1 0`,
+		)
+
+		values, err := s.TagValuesByPrefix(nil, "db0", []byte("cpu"), []byte("host"), []byte("serv"), 0)
+		if err != nil {
+			return err
+		} else if exp := []string{"server01", "server02", "server03"}; !reflect.DeepEqual(values, exp) {
+			return fmt.Errorf("got values %v, expected %v", values, exp)
+		}
+
+		values, err = s.TagValuesByPrefix(nil, "db0", []byte("cpu"), []byte("host"), []byte("serv"), 2)
+		if err != nil {
+			return err
+		} else if exp := []string{"server01", "server02"}; !reflect.DeepEqual(values, exp) {
+			return fmt.Errorf("got values %v, expected %v", values, exp)
+		}
+		return nil
+	}
+
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			if err := test(index); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestStore_DiskUsage(t *testing.T) {
+	t.Parallel()
+
+	test := func(index string) error {
+		s := MustOpenStore(index)
+		defer s.Close()
+
+		s.MustCreateShardWithData("db0", "rp0", 1, `cpu,host=a value=1 0`, `mem,host=a value=1 0`)
+		s.MustCreateShardWithData("db0", "rp1", 0, `cpu,host=a value=1 0`)
+		s.MustCreateShardWithData("db1", "rp0", 2, `cpu,host=a value=1 0`)
+
+		// Measurements are estimated from the TSM files.
+		engine, err := s.Shard(1).Engine()
+		if err != nil {
+			return err
+		} else if err := engine.(interface{ WriteSnapshot() error }).WriteSnapshot(); err != nil {
+			return err
+		}
+
+		usages, err := s.DiskUsage("db0")
+		if err != nil {
+			return err
+		} else if len(usages) != 2 {
+			return fmt.Errorf("got %d shards, expected 2", len(usages))
+		}
+		if u := usages[0]; u.ID != 0 || u.Database != "db0" || u.RetentionPolicy != "rp1" || u.WAL == 0 {
+			return fmt.Errorf("unexpected usage of shard 0: %+v", u)
+		}
+		if u := usages[1]; u.ID != 1 || u.RetentionPolicy != "rp0" || u.TSM == 0 || u.Measurements["cpu"] == 0 || u.Measurements["mem"] == 0 {
+			return fmt.Errorf("unexpected usage of shard 1: %+v", u)
+		}
+		if index == tsdb.TSI1IndexName && usages[1].Index == 0 {
+			return fmt.Errorf("expected index bytes: %+v", usages[1])
+		}
+
+		if usages, err := s.DiskUsage(""); err != nil {
+			return err
+		} else if len(usages) != 3 {
+			return fmt.Errorf("got %d shards, expected 3", len(usages))
+		}
+		return nil
+	}
+
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			if err := test(index); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// Ensure the disk usage of the retention policies is kept in a history that
+// survives the store being reopened.
+func TestStore_DiskUsageHistory(t *testing.T) {
+	t.Parallel()
+
+	s := MustOpenStore(tsdb.InmemIndexName)
+	defer s.Close()
+
+	if sample := s.DiskUsageAt("db0", time.Now().Add(-time.Hour)); sample != nil {
+		t.Fatalf("unexpected sample: %+v", sample)
+	}
+
+	s.MustCreateShardWithData("db0", "rp0", 1, `cpu,host=a value=1 0`)
+	s.MustCreateShardWithData("db1", "rp0", 2, `cpu,host=a value=1 0`)
+	if err := s.SampleDiskUsage(); err != nil {
+		t.Fatal(err)
+	}
+
+	sample := s.DiskUsageAt("db0", time.Now())
+	if sample == nil || len(sample.Policies) != 1 {
+		t.Fatalf("unexpected sample: %+v", sample)
+	} else if u := sample.Policies[0]; u.Database != "db0" || u.RetentionPolicy != "rp0" || u.Total() == 0 {
+		t.Fatalf("unexpected usage: %+v", u)
+	}
+
+	if err := s.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	if sample := s.DiskUsageAt("", time.Now()); sample == nil || len(sample.Policies) != 2 {
+		t.Fatalf("unexpected sample: %+v", sample)
+	}
+}
+
+func TestStore_ReindexShard(t *testing.T) {
+	t.Parallel()
+
+	test := func(index string) error {
+		s := MustOpenStore(index)
+		defer s.Close()
+
+		// Series are indexed from both the TSM files and the cache.
+		s.MustCreateShardWithData("db0", "rp0", 1, `cpu,host=a value=1 0`, `cpu,host=b value=1 0`)
+		engine, err := s.Shard(1).Engine()
+		if err != nil {
+			return err
+		} else if err := engine.(interface{ WriteSnapshot() error }).WriteSnapshot(); err != nil {
+			return err
+		}
+		s.MustWriteToShardString(1, `mem,host=a value=1 0`)
+
+		err = s.ReindexShard(1)
+		if index != tsdb.TSI1IndexName {
+			if err == nil {
+				return fmt.Errorf("expected error reindexing %s index", index)
+			}
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err := s.ReindexShard(2); err != tsdb.ErrShardNotFound {
+			return fmt.Errorf("got error %v, expected %v", err, tsdb.ErrShardNotFound)
+		}
+
+		names, err := s.MeasurementNames(query.OpenAuthorizer, "db0", nil)
+		if err != nil {
+			return err
+		} else if got, exp := names, [][]byte{[]byte("cpu"), []byte("mem")}; !reflect.DeepEqual(got, exp) {
+			return fmt.Errorf("got measurements %q, expected %q", got, exp)
+		}
+		if n, err := s.SeriesCardinality("db0"); err != nil {
+			return err
+		} else if n != 3 {
+			return fmt.Errorf("got %d series, expected 3", n)
+		}
+
+		// The shard accepts writes with its new index.
+		s.MustWriteToShardString(1, `disk,host=a value=1 0`)
+		if n, err := s.MeasurementsCardinality("db0"); err != nil {
+			return err
+		} else if n != 3 {
+			return fmt.Errorf("got %d measurements, expected 3", n)
+		}
+		return nil
+	}
+
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			if err := test(index); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// readTagValuesIterator reads the values of all measurements of itr. The
+// measurements without values are left out, as they are by Store.TagValues.
+func readTagValuesIterator(itr tsdb.TagValuesIterator) ([]tsdb.TagValues, error) {
+	var a []tsdb.TagValues
+	for {
+		name, kitr, err := itr.Next()
+		if err != nil {
+			return nil, err
+		} else if name == nil {
+			return a, nil
+		}
+
+		tv := tsdb.TagValues{Measurement: string(name)}
+		for {
+			kv, err := kitr.Next()
+			if err != nil {
+				kitr.Close()
+				return nil, err
+			} else if kv == nil {
+				break
+			}
+			tv.Values = append(tv.Values, *kv)
+		}
+		kitr.Close()
+
+		if len(tv.Values) > 0 {
+			a = append(a, tv)
+		}
+	}
+}
+
 func TestStore_Measurements_Auth(t *testing.T) {
 	t.Parallel()
 
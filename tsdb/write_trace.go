@@ -0,0 +1,63 @@
+package tsdb
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type writeTraceKey struct{}
+
+// WriteTrace records the time spent in each stage of writing a sampled batch
+// of points. It is carried through the write path by the context.
+type WriteTrace struct {
+	ID string
+
+	mu     sync.Mutex
+	stages []WriteTraceStage
+}
+
+// WriteTraceStage is the time spent in a stage of a traced write. Shard is
+// zero for the stages that are not specific to a shard.
+type WriteTraceStage struct {
+	Name     string
+	Shard    uint64
+	Duration time.Duration
+}
+
+// NewWriteTrace returns a new trace identified by id.
+func NewWriteTrace(id string) *WriteTrace {
+	return &WriteTrace{ID: id}
+}
+
+// Record adds a stage of shardID that started at start and ends now. It is a
+// no-op on a nil trace, so untraced writes do not need to check for one.
+func (t *WriteTrace) Record(name string, shardID uint64, start time.Time) {
+	if t == nil {
+		return
+	}
+	d := time.Since(start)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stages = append(t.stages, WriteTraceStage{Name: name, Shard: shardID, Duration: d})
+}
+
+// Stages returns the recorded stages in the order they ended.
+func (t *WriteTrace) Stages() []WriteTraceStage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]WriteTraceStage(nil), t.stages...)
+}
+
+// NewContextWithWriteTrace returns a new context with t.
+func NewContextWithWriteTrace(ctx context.Context, t *WriteTrace) context.Context {
+	return context.WithValue(ctx, writeTraceKey{}, t)
+}
+
+// WriteTraceFromContext returns the trace of ctx, or nil if the write is not
+// traced.
+func WriteTraceFromContext(ctx context.Context) *WriteTrace {
+	t, _ := ctx.Value(writeTraceKey{}).(*WriteTrace)
+	return t
+}